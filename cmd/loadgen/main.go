@@ -0,0 +1,139 @@
+// Command loadgen measures journal entry posting throughput and latency of a
+// running ledger server. It creates the accounts it posts to, and a tenant
+// through the admin server unless one is given, then posts balanced entries
+// from concurrent workers and prints p50/p90/p99 latency and throughput.
+//
+//	go run ./cmd/loadgen -tenant <id> -concurrency 32 -duration 1m -scenario hot
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/loadgen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9090", "address of the ledger server")
+	adminAddr := flag.String("admin-addr", "localhost:9091", "address of the admin server, used to create a tenant when -tenant is not set")
+	adminToken := flag.String("admin-token", os.Getenv("ADMIN_AUTH_TOKEN"), "admin bearer token")
+	apiKey := flag.String("api-key", os.Getenv("LEDGER_API_KEY"), "bearer token of the ledger server, with the admin:tenant and write:journal scopes")
+	useTLS := flag.Bool("tls", false, "connect over TLS")
+	tenantID := flag.String("tenant", "", "tenant to post for; a new tenant is created when empty")
+	accounts := flag.Int("accounts", 20, "number of accounts to spread postings across")
+	currency := flag.String("currency", "USD", "currency of the accounts")
+	concurrency := flag.Int("concurrency", 16, "number of concurrent workers")
+	duration := flag.Duration("duration", 30*time.Second, "how long to run; 0 runs until -requests have been sent")
+	requests := flag.Int("requests", 0, "number of postings to send; 0 runs for -duration")
+	scenario := flag.String("scenario", "spread", "posting pattern: "+scenarioNames())
+	hotRatio := flag.Float64("hot-ratio", -1, "share of postings debiting the hot account; overrides -scenario")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of each posting")
+	flag.Parse()
+
+	ratio, ok := loadgen.Scenarios[*scenario]
+	if !ok {
+		log.Fatalf("Unknown scenario %q, expected one of %s", *scenario, scenarioNames())
+	}
+	if *hotRatio >= 0 {
+		ratio = *hotRatio
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	conn, err := dial(*addr, *useTLS)
+	if err != nil {
+		log.Fatalf("Failed to connect to %s: %v", *addr, err)
+	}
+	defer conn.Close()
+	client := pb.NewLedgerServiceClient(conn)
+
+	if *tenantID == "" {
+		*tenantID, err = createTenant(ctx, *adminAddr, *adminToken, *useTLS)
+		if err != nil {
+			log.Fatalf("Failed to create tenant: %v", err)
+		}
+		log.Printf("Created tenant %s", *tenantID)
+	}
+	if *apiKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+*apiKey)
+	}
+
+	accountIDs, err := loadgen.SetupAccounts(ctx, client, *tenantID, *currency, *accounts)
+	if err != nil {
+		log.Fatalf("Failed to set up accounts: %v", err)
+	}
+
+	log.Printf("Posting with %d workers, hot ratio %.2f", *concurrency, ratio)
+	report, err := loadgen.Run(ctx, client, loadgen.Config{
+		TenantID:    *tenantID,
+		Accounts:    accountIDs,
+		Concurrency: *concurrency,
+		Duration:    *duration,
+		Requests:    *requests,
+		HotRatio:    ratio,
+		Timeout:     *timeout,
+	})
+	if err != nil {
+		log.Fatalf("Load run failed: %v", err)
+	}
+
+	if err := report.Write(os.Stdout); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+}
+
+func dial(addr string, useTLS bool) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if useTLS {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	return grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+}
+
+// createTenant creates a tenant for the run through the admin server
+func createTenant(ctx context.Context, addr, token string, useTLS bool) (string, error) {
+	if token == "" {
+		return "", fmt.Errorf("-admin-token or ADMIN_AUTH_TOKEN is required to create a tenant")
+	}
+
+	conn, err := dial(addr, useTLS)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	resp, err := pb.NewAdminServiceClient(conn).CreateTenant(ctx, &pb.CreateTenantRequest{
+		Name: "loadgen-" + uuid.NewString()[:8],
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.TenantId, nil
+}
+
+func scenarioNames() string {
+	names := make([]string, 0, len(loadgen.Scenarios))
+	for name := range loadgen.Scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,66 @@
+// Command seed creates a demo tenant with a chart of accounts and several
+// months of journal entries in the configured database. It uses the same
+// configuration as the server and posts through the ledger service, so the
+// demo data passes the same validation as client postings.
+//
+//	go run ./cmd/seed -tenant "Demo Company" -months 6
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/config"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/internal/seed"
+	"github.com/hesabFun/ledger/internal/service"
+)
+
+func main() {
+	configFile := flag.String("config", os.Getenv(config.ConfigFileEnv), "path to a YAML configuration file")
+	tenantName := flag.String("tenant", "Demo Company", "name of the demo tenant to create")
+	currency := flag.String("currency", "USD", "currency of the demo accounts")
+	months := flag.Int("months", 6, "months of history to generate, ending with the current month")
+	randomSeed := flag.Uint64("seed", 1, "seed of the generated amounts and dates")
+	flag.Parse()
+
+	cfg, err := config.LoadFile(*configFile)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	ctx := context.Background()
+	database, err := db.Connect(ctx, &cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	tenantRepo := repository.NewTenantRepository(database)
+	referenceRepo := repository.NewReferenceRepository(database)
+	adminService := service.NewAdminService(tenantRepo, referenceRepo, repository.NewSchemaRepository(database))
+	ledgerService := service.NewLedgerService(
+		tenantRepo,
+		repository.NewAccountRepository(database),
+		repository.NewJournalRepository(database),
+		referenceRepo,
+	)
+
+	result, err := seed.Run(ctx, adminService, ledgerService, seed.Options{
+		TenantName:   *tenantName,
+		CurrencyCode: *currency,
+		Months:       *months,
+		Seed:         *randomSeed,
+		Now:          time.Now(),
+	})
+	if err != nil {
+		log.Fatalf("Failed to seed demo data: %v", err)
+	}
+
+	log.Printf("Created tenant %q (%s) with %d accounts and %d journal entries",
+		*tenantName, result.TenantID, result.Accounts, result.Entries)
+}
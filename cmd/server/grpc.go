@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/compression"
+	"github.com/hesabFun/ledger/internal/config"
+	"github.com/hesabFun/ledger/internal/recovery"
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/requestid"
+	"github.com/hesabFun/ledger/internal/telemetry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// serverOptions returns the transport options and outermost interceptors
+// shared by the gRPC servers
+func serverOptions(c *config.Config) ([]grpc.ServerOption, error) {
+	cfg := c.Server
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(cfg.MaxSendMsgSize),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:                  cfg.Keepalive.Time,
+			Timeout:               cfg.Keepalive.Timeout,
+			MaxConnectionIdle:     cfg.Keepalive.MaxConnectionIdle,
+			MaxConnectionAge:      cfg.Keepalive.MaxConnectionAge,
+			MaxConnectionAgeGrace: cfg.Keepalive.MaxConnectionAgeGrace,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.Keepalive.MinTime,
+			PermitWithoutStream: cfg.Keepalive.PermitWithoutStream,
+		}),
+	}
+
+	if c.TLS.Enabled() {
+		creds, err := serverCredentials(c.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	if c.Telemetry.Enabled() {
+		opts = append(opts, telemetry.ServerOption())
+	}
+
+	if cfg.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(uint32(cfg.MaxConcurrentStreams)))
+	}
+
+	// Installed before the other interceptors so every call is tagged and
+	// logged, internal errors anywhere in the chain are redacted and panics
+	// are recovered
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(requestid.UnaryServerInterceptor(), redact.UnaryServerInterceptor(), recovery.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(requestid.StreamServerInterceptor(), redact.StreamServerInterceptor(), recovery.StreamServerInterceptor()),
+	)
+
+	if err := compression.Configure(cfg.Compression.Level); err != nil {
+		return nil, err
+	}
+	if cfg.Compression.Default != "" {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(compression.UnaryServerInterceptor(cfg.Compression.Default)),
+			grpc.ChainStreamInterceptor(compression.StreamServerInterceptor(cfg.Compression.Default)),
+		)
+	}
+
+	// The deadline covers the interceptors installed after it
+	if cfg.RequestTimeout > 0 {
+		opts = append(opts, grpc.ChainUnaryInterceptor(timeoutInterceptor(cfg.RequestTimeout)))
+	}
+
+	return opts, nil
+}
+
+// graphqlInterceptors returns the interceptors GraphQL fields call the
+// tenant API through: those serverOptions installs, apart from compression
+// as no response is sent over gRPC, followed by the tenant API's own
+func graphqlInterceptors(cfg config.ServerConfig, unary []grpc.UnaryServerInterceptor) []grpc.UnaryServerInterceptor {
+	interceptors := []grpc.UnaryServerInterceptor{requestid.UnaryServerInterceptor(), redact.UnaryServerInterceptor(), recovery.UnaryServerInterceptor()}
+	if cfg.RequestTimeout > 0 {
+		interceptors = append(interceptors, timeoutInterceptor(cfg.RequestTimeout))
+	}
+	return append(interceptors, unary...)
+}
+
+// serverCredentials loads the server certificate and, for mutual TLS, the
+// CAs client certificates must be signed by
+func serverCredentials(cfg config.TLSConfig) (credentials.TransportCredentials, error) {
+	tlsConfig, err := serverTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// serverTLSConfig builds the TLS configuration shared by the gRPC servers
+// and the GraphQL endpoint
+func serverTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA file %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// timeoutInterceptor bounds the handling of unary calls. Client deadlines
+// shorter than the timeout still apply; streams are left alone since exports
+// may legitimately run for long.
+func timeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/config"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// registerDatabaseMetrics exposes the query durations, the state of the
+// database circuit breaker and the number of calls it rejected
+func registerDatabaseMetrics(reg prometheus.Registerer, database *db.DB) {
+	breaker := database.Breaker()
+	reg.MustRegister(
+		database.QueryMetrics(),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "ledger_db_circuit_breaker_state",
+			Help: "State of the database circuit breaker: 0 closed, 1 open, 2 half-open.",
+		}, func() float64 { return float64(breaker.State()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "ledger_db_circuit_breaker_rejected_total",
+			Help: "Database calls failed fast while the circuit breaker was open.",
+		}, func() float64 { return float64(breaker.Rejected()) }),
+	)
+}
+
+// checkRowLevelSecurity verifies that row-level security isolates the
+// tenant tables, logging every problem and exposing their number. In fail
+// mode problems are returned as an error, so the server does not start
+// with tenants that can see each other's rows.
+func checkRowLevelSecurity(ctx context.Context, reg prometheus.Registerer, repo repository.SchemaRepositoryInterface, mode string) error {
+	if mode == config.RLSCheckOff {
+		return nil
+	}
+
+	verification, err := repo.VerifyRowLevelSecurity(ctx)
+	if err != nil {
+		if mode == config.RLSCheckFail {
+			return fmt.Errorf("failed to verify row level security: %w", err)
+		}
+		log.Printf("Failed to verify row level security: %v", err)
+		return nil
+	}
+
+	problems := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ledger_rls_problems",
+		Help: "Row-level security problems found at startup.",
+	})
+	problems.Set(float64(len(verification.Problems)))
+	reg.MustRegister(problems)
+
+	if verification.OK() {
+		log.Printf("Row level security verified on %d tables for role %s", len(verification.Tables), verification.Role)
+		return nil
+	}
+	for _, problem := range verification.Problems {
+		log.Printf("Row level security problem: %s", problem)
+	}
+	if mode == config.RLSCheckFail {
+		return fmt.Errorf("row level security does not isolate tenants: %d problems", len(verification.Problems))
+	}
+	return nil
+}
+
+// checkChangeDataCapture verifies that logical replication consumers can
+// capture ledger_events through the publication, logging every problem and
+// exposing their number. Problems do not stop the server, as the
+// publication may be created after it starts.
+func checkChangeDataCapture(ctx context.Context, reg prometheus.Registerer, repo repository.SchemaRepositoryInterface, publication string) {
+	verification, err := repo.VerifyChangeDataCapture(ctx, publication)
+	if err != nil {
+		log.Printf("Failed to verify change data capture: %v", err)
+		return
+	}
+
+	problems := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ledger_cdc_problems",
+		Help: "Change data capture problems found at startup.",
+	})
+	problems.Set(float64(len(verification.Problems)))
+	reg.MustRegister(problems)
+
+	if verification.OK() {
+		log.Printf("Change data capture of ledger_events verified through publication %s", publication)
+		return
+	}
+	for _, problem := range verification.Problems {
+		log.Printf("Change data capture problem: %s", problem)
+	}
+}
+
+// watchDatabase pings the database every interval until ctx is done,
+// reporting the server as not serving while the circuit breaker is not
+// closed. The pings also probe an open breaker when no requests arrive, for
+// example while a load balancer keeps traffic away from the server.
+func watchDatabase(ctx context.Context, database *db.DB, server *health.Server, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := db.BreakerClosed
+	for {
+		pingCtx, cancel := context.WithTimeout(ctx, interval)
+		_ = database.Ping(pingCtx)
+		cancel()
+
+		state := database.Breaker().State()
+		if state != last {
+			log.Printf("Database circuit breaker is %s", state)
+			last = state
+		}
+
+		status := healthpb.HealthCheckResponse_SERVING
+		if state != db.BreakerClosed {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		server.SetServingStatus("", status)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
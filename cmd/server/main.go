@@ -2,34 +2,77 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/auth"
+	"github.com/hesabFun/ledger/internal/cache"
+	"github.com/hesabFun/ledger/internal/cdc"
 	"github.com/hesabFun/ledger/internal/config"
+	"github.com/hesabFun/ledger/internal/consistency"
 	"github.com/hesabFun/ledger/internal/db"
+	"github.com/hesabFun/ledger/internal/delivery"
+	"github.com/hesabFun/ledger/internal/depreciation"
+	"github.com/hesabFun/ledger/internal/digest"
+	"github.com/hesabFun/ledger/internal/eventsig"
+	"github.com/hesabFun/ledger/internal/export"
+	"github.com/hesabFun/ledger/internal/graphqlapi"
+	"github.com/hesabFun/ledger/internal/interest"
+	"github.com/hesabFun/ledger/internal/partitions"
+	"github.com/hesabFun/ledger/internal/periodtotals"
+	"github.com/hesabFun/ledger/internal/redact"
 	"github.com/hesabFun/ledger/internal/repository"
 	"github.com/hesabFun/ledger/internal/service"
+	"github.com/hesabFun/ledger/internal/statementrun"
+	"github.com/hesabFun/ledger/internal/telemetry"
+	"github.com/hesabFun/ledger/internal/testtenants"
+	"github.com/hesabFun/ledger/internal/validation"
+	"github.com/hesabFun/ledger/internal/watch"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
 	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+	pbv2 "github.com/hesabFun/ledger/gen/go/ledger/v2"
 )
 
 func main() {
-	// Load configuration
-	cfg, err := config.Load()
+	configFile := flag.String("config", os.Getenv(config.ConfigFileEnv), "path to a YAML configuration file")
+	inMemory := flag.Bool("memory", false, memoryUsage)
+	flag.Parse()
+
+	// Load configuration; environment variables override the file
+	cfg, err := config.LoadFile(*configFile)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	verbatimLevel, err := redact.ParseLevel(cfg.Logging.VerbatimLevel)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	redact.SetVerbatimLevel(verbatimLevel)
 
-	// Initialize database connection
+	// Keep all data in the process when no database is wanted
+	if *inMemory || cfg.Database.Driver == config.DatabaseDriverMemory {
+		serveInMemory(cfg)
+		return
+	}
+
+	// Initialize database connection, waiting for the database to become ready
 	ctx := context.Background()
-	database, err := db.New(ctx, &cfg.Database)
+	database, err := db.Connect(ctx, &cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -37,28 +80,157 @@ func main() {
 
 	log.Println("Successfully connected to database")
 
+	// Export traces when a collector is configured
+	stopTracing := func(context.Context) error { return nil }
+	if cfg.Telemetry.Enabled() {
+		stopTracing, err = telemetry.Setup(ctx, cfg.Telemetry)
+		if err != nil {
+			log.Fatalf("Failed to configure tracing: %v", err)
+		}
+		log.Printf("Exporting traces to %s", cfg.Telemetry.TracingEndpoint)
+	}
+
 	// Initialize repositories
 	tenantRepo := repository.NewTenantRepository(database)
 	accountRepo := repository.NewAccountRepository(database)
 	journalRepo := repository.NewJournalRepository(database)
-	referenceRepo := repository.NewReferenceRepository(database)
+	var referenceRepo repository.ReferenceRepositoryInterface = repository.NewReferenceRepository(database)
+	if cfg.Cache.Enabled() {
+		referenceRepo = cache.NewReferenceRepository(referenceRepo, cfg.Cache.ReferenceDataTTL, cfg.Cache.MaxEntries)
+	}
+	schemaRepo := repository.NewSchemaRepository(database)
+	quotaRepo := repository.NewQuotaRepository(database)
+	settingsRepo := repository.NewTenantSettingsRepository(database)
+	budgetRepo := repository.NewBudgetRepository(database)
+	closeRepo := repository.NewCloseRepository(database)
+	statementRepo := repository.NewStatementRepository(database)
+	reconciliationRepo := repository.NewReconciliationRepository(database)
+	intercompanyRepo := repository.NewIntercompanyRepository(database)
+	reportRepo := repository.NewReportRepository(database)
+	consolidationRepo := repository.NewConsolidationRepository(database)
+	subledgerRepo := repository.NewSubledgerRepository(database)
+	policyRepo := repository.NewPostingPolicyRepository(database)
+	eventRepo := repository.NewEventRepository(database)
+	balanceRepo := repository.NewBalanceRepository(database)
+	consistencyRepo := repository.NewConsistencyRepository(database)
+	taxRepo := repository.NewTaxCodeRepository(database)
+	assetRepo := repository.NewFixedAssetRepository(database)
+	interestRepo := repository.NewInterestRepository(database)
+	partyRepo := repository.NewPartyRepository(database)
+	dimensionRepo := repository.NewDimensionRepository(database)
+	bookRepo := repository.NewBookRepository(database)
+	holdRepo := repository.NewHoldRepository(database)
+	preparedRepo := repository.NewPreparedEntryRepository(database)
+	batchRepo := repository.NewJournalBatchRepository(database)
+	sequenceRepo := repository.NewReferenceSequenceRepository(database)
+	digestRepo := repository.NewDigestRepository(database)
+	tenantDataRepo := repository.NewTenantDataRepository(database)
 
-	// Initialize service
+	// Refuse to serve, or warn, when tenants are not isolated
+	if err := checkRowLevelSecurity(ctx, prometheus.DefaultRegisterer, schemaRepo, cfg.Database.RLSCheck); err != nil {
+		log.Fatalf("Row level security check failed: %v", err)
+	}
+
+	// Balance changes committed by any server are streamed to watchers
+	broker := watch.NewBroker()
+
+	// Optional dependencies shared by the services
+	serviceOpts := []service.Option{
+		service.WithQuotaRepository(quotaRepo),
+		service.WithTenantSettingsRepository(settingsRepo),
+		service.WithBudgetRepository(budgetRepo),
+		service.WithCloseRepository(closeRepo),
+		service.WithPostingPolicyRepository(policyRepo),
+		service.WithBalanceRepository(balanceRepo),
+		service.WithConsistencyRepository(consistencyRepo),
+		service.WithTaxCodeRepository(taxRepo),
+		service.WithPartyRepository(partyRepo),
+		service.WithDimensionRepository(dimensionRepo),
+		service.WithBookRepository(bookRepo),
+		service.WithBalanceBroker(broker),
+		service.WithHoldRepository(holdRepo),
+		service.WithPreparedEntryRepository(preparedRepo),
+		service.WithJournalBatchRepository(batchRepo),
+		service.WithReportRepository(reportRepo),
+		service.WithReferenceSequenceRepository(sequenceRepo),
+		service.WithDigestRepository(digestRepo),
+		service.WithTenantDataRepository(tenantDataRepo),
+		service.WithEntryLimits(service.EntryLimits{
+			MaxLines:             cfg.Limits.MaxLinesPerEntry,
+			MaxStreamedLines:     cfg.Limits.MaxStreamedLinesPerEntry,
+			MaxMetadataBytes:     cfg.Limits.MaxMetadataBytes,
+			MaxDescriptionLength: cfg.Limits.MaxDescriptionLength,
+		}),
+	}
+	var eventSigner *eventsig.Signer
+	if cfg.Events.Enabled {
+		serviceOpts = append(serviceOpts, service.WithEventRepository(eventRepo))
+		if secrets := cfg.Events.SigningSecrets; len(secrets) > 0 {
+			keys := make([][]byte, len(secrets))
+			for i, secret := range secrets {
+				keys[i] = []byte(secret)
+			}
+			eventSigner = eventsig.NewSigner(keys...)
+			serviceOpts = append(serviceOpts, service.WithEventSigner(eventSigner))
+		}
+	} else {
+		log.Println("EVENTS_ENABLED is false, the event store is disabled")
+	}
+	if cfg.Export.Enabled() {
+		exportJobRepo := repository.NewExportJobRepository(database)
+		store := export.NewDirStore(cfg.Export.Dir)
+		exporter := export.NewExporter(accountRepo, journalRepo, exportJobRepo, tenantDataRepo, store)
+		// Statement runs deliver their PDFs through the export store
+		statementRunner := statementrun.NewRunner(accountRepo, partyRepo, reportRepo, repository.NewStatementRunRepository(database), store)
+		serviceOpts = append(serviceOpts, service.WithExporter(exporter), service.WithStatementRunner(statementRunner))
+	} else {
+		log.Println("EXPORT_DIR is not set, data exports and statement runs are disabled")
+	}
+
+	// Initialize services
 	ledgerService := service.NewLedgerService(
 		tenantRepo,
 		accountRepo,
 		journalRepo,
 		referenceRepo,
+		serviceOpts...,
 	)
-
-	// Create gRPC server
-	grpcServer := grpc.NewServer(
-		grpc.MaxRecvMsgSize(10*1024*1024), // 10MB
-		grpc.MaxSendMsgSize(10*1024*1024), // 10MB
+	adminService := service.NewAdminService(
+		tenantRepo,
+		referenceRepo,
+		schemaRepo,
+		serviceOpts...,
 	)
+	reconciliationService := service.NewReconciliationService(accountRepo, statementRepo, reconciliationRepo)
+	consolidationService := service.NewConsolidationService(journalRepo, intercompanyRepo, reportRepo, consolidationRepo)
+	subledgerService := service.NewSubledgerService(accountRepo, subledgerRepo)
+	assetService := service.NewAssetService(accountRepo, assetRepo)
+	interestService := service.NewInterestService(accountRepo, interestRepo)
 
-	// Register service
+	// Create gRPC server; the tenant of a call may be sent as x-tenant-id metadata,
+	// and suspended and archived tenants are held to what their status allows
+	tenantResolver := auth.NewTenantResolver(tenantRepo)
+	opts, err := serverOptions(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure gRPC server: %v", err)
+	}
+	unary, stream := tenantInterceptors(cfg, tenantResolver)
+	grpcServer := grpc.NewServer(append(opts,
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)...)
+
+	// Register services
 	pb.RegisterLedgerServiceServer(grpcServer, ledgerService)
+	pbv2.RegisterLedgerServiceServer(grpcServer, service.NewLedgerServiceV2(ledgerService))
+	pb.RegisterReconciliationServiceServer(grpcServer, reconciliationService)
+	pb.RegisterSubledgerServiceServer(grpcServer, subledgerService)
+	pb.RegisterAssetServiceServer(grpcServer, assetService)
+	pb.RegisterInterestServiceServer(grpcServer, interestService)
+
+	// Report the server as not serving while the database circuit breaker is open
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
 
 	// Enable reflection for grpcurl and other tools
 	reflection.Register(grpcServer)
@@ -78,6 +250,205 @@ func main() {
 		}
 	}()
 
+	// Create the admin server on its own listener so it is never exposed with the tenant API
+	var adminServer *grpc.Server
+	if cfg.Admin.Enabled() {
+		authenticator := auth.NewTokenAuthenticator(cfg.Admin.AuthToken)
+		adminOpts, err := serverOptions(cfg)
+		if err != nil {
+			log.Fatalf("Failed to configure admin server: %v", err)
+		}
+		adminServer = grpc.NewServer(append(adminOpts,
+			grpc.ChainUnaryInterceptor(authenticator.UnaryServerInterceptor(), validation.UnaryServerInterceptor()),
+			grpc.ChainStreamInterceptor(authenticator.StreamServerInterceptor(), validation.StreamServerInterceptor()),
+		)...)
+		pb.RegisterAdminServiceServer(adminServer, adminService)
+		pb.RegisterConsolidationServiceServer(adminServer, consolidationService)
+		reflection.Register(adminServer)
+
+		adminAddress := fmt.Sprintf("%s:%d", cfg.Admin.Host, cfg.Admin.Port)
+		adminListener, err := net.Listen("tcp", adminAddress)
+		if err != nil {
+			log.Fatalf("Failed to listen on %s: %v", adminAddress, err)
+		}
+
+		go func() {
+			log.Printf("Starting admin gRPC server on %s", adminAddress)
+			if err := adminServer.Serve(adminListener); err != nil {
+				log.Fatalf("Failed to serve admin: %v", err)
+			}
+		}()
+	} else {
+		log.Println("ADMIN_AUTH_TOKEN is not set, admin server is disabled")
+	}
+
+	// Check ledger invariants in the background
+	checkCtx, stopChecks := context.WithCancel(ctx)
+	defer stopChecks()
+	if cfg.Consistency.Enabled() {
+		checker := consistency.NewChecker(tenantRepo, consistencyRepo, cfg.Consistency.Interval, prometheus.DefaultRegisterer)
+		go checker.Run(checkCtx)
+		log.Printf("Checking ledger consistency every %s", cfg.Consistency.Interval)
+	} else {
+		log.Println("CONSISTENCY_CHECK_INTERVAL is 0, background consistency checks are disabled")
+	}
+	if cfg.Events.CDC.Enabled() {
+		checkChangeDataCapture(ctx, prometheus.DefaultRegisterer, schemaRepo, cfg.Events.CDC.Publication)
+		monitor := cdc.NewMonitor(schemaRepo, cfg.Events.CDC.LagInterval, prometheus.DefaultRegisterer)
+		go monitor.Run(checkCtx)
+		log.Printf("Sampling replication slot lag every %s", cfg.Events.CDC.LagInterval)
+	}
+
+	// Probe the database so the health status follows the circuit breaker
+	registerDatabaseMetrics(prometheus.DefaultRegisterer, database)
+	go watchDatabase(checkCtx, database, healthServer, 5*time.Second)
+
+	// Forward balance change notifications from the database to watchers
+	go watch.Follow(checkCtx, repository.NewBalanceListener(database), broker, 5*time.Second)
+
+	// Post depreciation of fixed assets as it falls due
+	if cfg.Depreciation.Enabled() {
+		runner := depreciation.NewRunner(tenantRepo, assetRepo, cfg.Depreciation.Interval, prometheus.DefaultRegisterer)
+		go runner.Run(checkCtx)
+		log.Printf("Posting due depreciation every %s", cfg.Depreciation.Interval)
+	} else {
+		log.Println("DEPRECIATION_INTERVAL is 0, background depreciation posting is disabled")
+	}
+
+	// Accrue interest on accounts with an interest scheme through the last complete day
+	if cfg.Interest.Enabled() {
+		runner := interest.NewRunner(tenantRepo, interestRepo, cfg.Interest.Interval, prometheus.DefaultRegisterer)
+		go runner.Run(checkCtx)
+		log.Printf("Accruing interest every %s", cfg.Interest.Interval)
+	} else {
+		log.Println("INTEREST_ACCRUAL_INTERVAL is 0, background interest accrual is disabled")
+	}
+
+	// Compute the digests of completed days
+	if cfg.Digest.Enabled() {
+		runner := digest.NewRunner(tenantRepo, digestRepo, cfg.Digest.Interval, cfg.Digest.Publish, prometheus.DefaultRegisterer)
+		go runner.Run(checkCtx)
+		log.Printf("Computing daily digests every %s", cfg.Digest.Interval)
+	} else {
+		log.Println("DIGEST_INTERVAL is 0, daily digests are not computed")
+	}
+
+	// Deliver events to the webhook, dead-lettering those it keeps refusing
+	deliveryRepo := repository.NewEventDeliveryRepository(database)
+	if cfg.Events.Webhook.Enabled() {
+		sink := delivery.NewWebhook(cfg.Events.Webhook.URL, cfg.Events.Webhook.Timeout, eventSigner)
+		runner := delivery.NewRunner(tenantRepo, deliveryRepo, sink, cfg.Events.Webhook.Interval, cfg.Events.Webhook.MaxAttempts, prometheus.DefaultRegisterer)
+		go runner.Run(checkCtx)
+		log.Printf("Delivering events to the webhook every %s", cfg.Events.Webhook.Interval)
+	} else {
+		log.Println("EVENTS_WEBHOOK_URL is not set, events are not delivered")
+	}
+
+	// Purge test tenants once their retention has passed
+	if cfg.TestTenants.Enabled() {
+		runner := testtenants.NewRunner(tenantRepo, tenantDataRepo, cfg.TestTenants.Retention, cfg.TestTenants.Interval, prometheus.DefaultRegisterer)
+		go runner.Run(checkCtx)
+		log.Printf("Purging test tenants %s after they were marked as test, every %s", cfg.TestTenants.Retention, cfg.TestTenants.Interval)
+	} else {
+		log.Println("TEST_TENANT_RETENTION is 0, test tenants are not purged")
+	}
+
+	// Create the coming monthly journal partitions and archive the old ones
+	if cfg.Partitions.Enabled() {
+		partitionRepo := repository.NewJournalPartitionRepository(database)
+		runner := partitions.NewRunner(partitionRepo, cfg.Partitions.AheadMonths, cfg.Partitions.ArchiveTablespace, cfg.Partitions.ArchiveAfterMonths, cfg.Partitions.Interval, prometheus.DefaultRegisterer)
+		go runner.Run(checkCtx)
+		log.Printf("Maintaining journal partitions every %s", cfg.Partitions.Interval)
+	} else {
+		log.Println("JOURNAL_PARTITION_INTERVAL is 0, journal partitions are not maintained")
+	}
+
+	// Add queued entries to the period totals behind reports
+	if cfg.Database.PeriodTotals.Scheduled() {
+		runner := periodtotals.NewRunner(tenantRepo, reportRepo, cfg.Database.PeriodTotals.Interval, prometheus.DefaultRegisterer)
+		go runner.Run(checkCtx)
+		log.Printf("Refreshing period totals every %s", cfg.Database.PeriodTotals.Interval)
+	}
+
+	// Serve Prometheus metrics
+	var metricsServer *http.Server
+	if cfg.Metrics.Enabled() {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		metricsServer = &http.Server{Addr: cfg.Metrics.Addr, Handler: mux}
+
+		go func() {
+			log.Printf("Serving metrics on %s", cfg.Metrics.Addr)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to serve metrics: %v", err)
+			}
+		}()
+	} else {
+		log.Println("METRICS_ADDR is not set, metrics endpoint is disabled")
+	}
+
+	// Serve the admin HTTP endpoints for failed event deliveries with the
+	// admin token, on their own listener like the admin gRPC server
+	var adminHTTPServer *http.Server
+	if cfg.Admin.Enabled() && cfg.Admin.HTTPAddr != "" {
+		handler := delivery.NewHandler(deliveryRepo, auth.NewTokenAuthenticator(cfg.Admin.AuthToken), eventSigner)
+		adminHTTPServer = &http.Server{Addr: cfg.Admin.HTTPAddr, Handler: handler}
+		if cfg.TLS.Enabled() {
+			tlsConfig, err := serverTLSConfig(cfg.TLS)
+			if err != nil {
+				log.Fatalf("Failed to configure admin HTTP TLS: %v", err)
+			}
+			adminHTTPServer.TLSConfig = tlsConfig
+		}
+
+		go func() {
+			log.Printf("Serving admin HTTP endpoints on %s", cfg.Admin.HTTPAddr)
+			var err error
+			if adminHTTPServer.TLSConfig != nil {
+				err = adminHTTPServer.ListenAndServeTLS("", "")
+			} else {
+				err = adminHTTPServer.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to serve admin HTTP endpoints: %v", err)
+			}
+		}()
+	} else {
+		log.Println("ADMIN_HTTP_ADDR or ADMIN_AUTH_TOKEN is not set, the admin HTTP endpoints are disabled")
+	}
+
+	// Serve the read-only GraphQL API over the tenant API
+	var graphqlServer *http.Server
+	if cfg.GraphQL.Enabled() {
+		handler, err := graphqlapi.NewHandler(ledgerService, graphqlInterceptors(cfg.Server, unary), cfg.GraphQL.MaxDepth, cfg.GraphQL.MaxCalls)
+		if err != nil {
+			log.Fatalf("Failed to build GraphQL schema: %v", err)
+		}
+		graphqlServer = &http.Server{Addr: cfg.GraphQL.Addr, Handler: handler}
+		if cfg.TLS.Enabled() {
+			tlsConfig, err := serverTLSConfig(cfg.TLS)
+			if err != nil {
+				log.Fatalf("Failed to configure GraphQL TLS: %v", err)
+			}
+			graphqlServer.TLSConfig = tlsConfig
+		}
+
+		go func() {
+			log.Printf("Serving GraphQL on %s", cfg.GraphQL.Addr)
+			var err error
+			if graphqlServer.TLSConfig != nil {
+				err = graphqlServer.ListenAndServeTLS("", "")
+			} else {
+				err = graphqlServer.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to serve GraphQL: %v", err)
+			}
+		}()
+	} else {
+		log.Println("GRAPHQL_ADDR is not set, the GraphQL endpoint is disabled")
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -85,19 +456,89 @@ func main() {
 
 	log.Println("Shutting down server...")
 
-	// Gracefully stop the server
+	stopChecks()
+	healthServer.Shutdown()
+	broker.Close()
+	if metricsServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Metrics server shutdown: %v", err)
+		}
+		cancel()
+	}
+	if adminHTTPServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		if err := adminHTTPServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Admin HTTP server shutdown: %v", err)
+		}
+		cancel()
+	}
+	if graphqlServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		if err := graphqlServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("GraphQL server shutdown: %v", err)
+		}
+		cancel()
+	}
+
+	if adminServer != nil {
+		stopServer(adminServer, "Admin server")
+	}
+	stopServer(grpcServer, "Server")
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	if err := stopTracing(shutdownCtx); err != nil {
+		log.Printf("Trace export shutdown: %v", err)
+	}
+	cancel()
+}
+
+// tenantInterceptors returns the interceptors of the tenant API: the API
+// credentials, when configured, are checked before the tenant is resolved,
+// and otherwise every call is granted every scope. Requests are validated
+// last, once the tenant resolver has filled in their tenant_id.
+func tenantInterceptors(cfg *config.Config, tenantResolver *auth.TenantResolver) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
+	unary := []grpc.UnaryServerInterceptor{tenantResolver.UnaryServerInterceptor(), validation.UnaryServerInterceptor()}
+	stream := []grpc.StreamServerInterceptor{tenantResolver.StreamServerInterceptor(), validation.StreamServerInterceptor()}
+	if cfg.Auth.Enabled() {
+		scopeAuth, err := auth.NewScopeAuthenticator(apiKeys(cfg.Auth), cfg.Auth.JWTSecret)
+		if err != nil {
+			log.Fatalf("Failed to configure API credentials: %v", err)
+		}
+		unary = append([]grpc.UnaryServerInterceptor{scopeAuth.UnaryServerInterceptor()}, unary...)
+		stream = append([]grpc.StreamServerInterceptor{scopeAuth.StreamServerInterceptor()}, stream...)
+	} else {
+		log.Println("AUTH_API_KEYS and AUTH_JWT_SECRET are not set, the tenant API is not authenticated")
+		unary = append([]grpc.UnaryServerInterceptor{auth.GrantAllScopesUnaryServerInterceptor()}, unary...)
+		stream = append([]grpc.StreamServerInterceptor{auth.GrantAllScopesStreamServerInterceptor()}, stream...)
+	}
+	return unary, stream
+}
+
+// apiKeys returns the configured API keys with the tenants they are bound to
+// and the scopes they grant
+func apiKeys(cfg config.AuthConfig) map[string]auth.APIKey {
+	keys := make(map[string]auth.APIKey, len(cfg.APIKeys))
+	for _, k := range cfg.APIKeys {
+		keys[k.Key] = auth.APIKey{Tenant: uuid.MustParse(k.Tenant), Scopes: k.Scopes}
+	}
+	return keys
+}
+
+// stopServer gracefully stops a gRPC server, forcing a stop after a timeout
+func stopServer(server *grpc.Server, name string) {
 	stopped := make(chan struct{})
 	go func() {
-		grpcServer.GracefulStop()
+		server.GracefulStop()
 		close(stopped)
 	}()
 
 	// Wait for graceful shutdown or timeout
 	select {
 	case <-stopped:
-		log.Println("Server stopped gracefully")
+		log.Printf("%s stopped gracefully", name)
 	case <-time.After(10 * time.Second):
-		log.Println("Server shutdown timeout, forcing stop")
-		grpcServer.Stop()
+		log.Printf("%s shutdown timeout, forcing stop", name)
+		server.Stop()
 	}
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/hesabFun/ledger/internal/auth"
+	"github.com/hesabFun/ledger/internal/config"
+	"github.com/hesabFun/ledger/internal/service"
+	"github.com/hesabFun/ledger/repository/memory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+	pbv2 "github.com/hesabFun/ledger/gen/go/ledger/v2"
+)
+
+// memoryUsage is the help of the -memory flag, which states what the
+// in-memory server leaves out
+const memoryUsage = "keep all data in the process instead of Postgres, like DB_DRIVER=memory, " +
+	"for trying the API. Only ledger.v1 and ledger.v2 LedgerService run, on in-memory tenants, " +
+	"books, accounts, journal entries and reference data; their RPCs that need other " +
+	"repositories (parties, tax codes, dimensions, budgets, holds, batches, events, periods) " +
+	"return UNIMPLEMENTED. The reconciliation, subledger, asset and interest services, the " +
+	"admin server with the admin and consolidation services, and background jobs are not " +
+	"served, and all data is lost when the server stops"
+
+// serveInMemory serves the ledger service on the in-memory repositories
+// instead of Postgres, for trying the API without a database. A demo tenant
+// is created at startup. The limits are those listed in memoryUsage.
+func serveInMemory(cfg *config.Config) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	tenantRepo := memory.NewTenantRepository(store)
+
+	tenant, err := tenantRepo.Create(ctx, "Demo", nil)
+	if err != nil {
+		log.Fatalf("Failed to create demo tenant: %v", err)
+	}
+	log.Printf("Running in memory, data is not persisted and only the ledger service is served; demo tenant %s", tenant.ID)
+
+	ledgerService := service.NewLedgerService(
+		tenantRepo,
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+		service.WithBookRepository(memory.NewBookRepository(store)),
+		service.WithEntryLimits(service.EntryLimits{
+			MaxLines:             cfg.Limits.MaxLinesPerEntry,
+			MaxStreamedLines:     cfg.Limits.MaxStreamedLinesPerEntry,
+			MaxMetadataBytes:     cfg.Limits.MaxMetadataBytes,
+			MaxDescriptionLength: cfg.Limits.MaxDescriptionLength,
+		}),
+	)
+
+	opts, err := serverOptions(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure gRPC server: %v", err)
+	}
+	unary, stream := tenantInterceptors(cfg, auth.NewTenantResolver(tenantRepo))
+	grpcServer := grpc.NewServer(append(opts,
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)...)
+
+	pb.RegisterLedgerServiceServer(grpcServer, ledgerService)
+	pbv2.RegisterLedgerServiceServer(grpcServer, service.NewLedgerServiceV2(ledgerService))
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	reflection.Register(grpcServer)
+
+	address := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", address, err)
+	}
+
+	go func() {
+		log.Printf("Starting in-memory gRPC server on %s", address)
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Fatalf("Failed to serve: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down server...")
+	stopServer(grpcServer, "Server")
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// jwtHeader is the part of a JWT header that is checked
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+// jwtClaims are the claims of a JWT the authenticator reads
+type jwtClaims struct {
+	Scope     string `json:"scope"`
+	TenantID  string `json:"tenant_id"`
+	ExpiresAt *int64 `json:"exp"`
+	NotBefore *int64 `json:"nbf"`
+}
+
+// verifyJWT checks the HS256 signature and validity period of a JWT and
+// returns the tenant of its "tenant_id" claim with the scopes of its
+// space-separated "scope" claim
+func verifyJWT(token string, secret []byte, now time.Time) (credential, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return credential{}, fmt.Errorf("malformed JWT")
+	}
+
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return credential{}, err
+	}
+	if header.Alg != "HS256" {
+		return credential{}, fmt.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return credential{}, fmt.Errorf("malformed JWT signature")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return credential{}, fmt.Errorf("invalid JWT signature")
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return credential{}, err
+	}
+	if claims.ExpiresAt != nil && !now.Before(time.Unix(*claims.ExpiresAt, 0)) {
+		return credential{}, fmt.Errorf("JWT has expired")
+	}
+	if claims.NotBefore != nil && now.Before(time.Unix(*claims.NotBefore, 0)) {
+		return credential{}, fmt.Errorf("JWT is not valid yet")
+	}
+
+	tenantID, err := uuid.Parse(claims.TenantID)
+	if err != nil {
+		return credential{}, fmt.Errorf("JWT must name a tenant in its tenant_id claim")
+	}
+
+	return credential{tenant: tenantID, scopes: strings.Fields(claims.Scope)}, nil
+}
+
+// decodeJWTPart decodes a base64url-encoded JSON part of a JWT
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("malformed JWT")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("malformed JWT")
+	}
+	return nil
+}
@@ -0,0 +1,427 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+	pbv2 "github.com/hesabFun/ledger/gen/go/ledger/v2"
+)
+
+// Scopes granted to tenant API credentials. Scopes do not imply each other,
+// so a credential lists every scope it needs.
+const (
+	// ScopeReadAccounts allows reading accounts, entries, balances and reports
+	ScopeReadAccounts = "read:accounts"
+	// ScopeWriteJournal allows posting entries and the operations that post
+	// or settle them, such as holds, payments and reconciliation
+	ScopeWriteJournal = "write:journal"
+	// ScopeAdminTenant allows changing the tenant's chart of accounts, master
+	// data, settings and policies
+	ScopeAdminTenant = "admin:tenant"
+	// ScopeOverrideLock allows posting entries dated on or before the
+	// posting policy's lock date. No method requires it; the posting paths
+	// check it when an entry asks to override the lock.
+	ScopeOverrideLock = "override:lock"
+	// ScopeApproveJournal allows approving and rejecting journal batches, so
+	// the credentials that prepare a batch need not be able to approve it
+	ScopeApproveJournal = "approve:journal"
+)
+
+// scopes lists the valid scopes
+var scopes = map[string]bool{
+	ScopeReadAccounts:   true,
+	ScopeWriteJournal:   true,
+	ScopeAdminTenant:    true,
+	ScopeOverrideLock:   true,
+	ScopeApproveJournal: true,
+}
+
+// methodScopes maps every tenant API method to the scope its callers need.
+// Methods outside this table are denied, unless they belong to one of the
+// publicServices.
+var methodScopes = map[string]string{
+	pb.LedgerService_CreateAccount_FullMethodName:            ScopeAdminTenant,
+	pb.LedgerService_GetAccount_FullMethodName:               ScopeReadAccounts,
+	pb.LedgerService_ListAccounts_FullMethodName:             ScopeReadAccounts,
+	pb.LedgerService_GetAccountBalance_FullMethodName:        ScopeReadAccounts,
+	pb.LedgerService_WatchAccountBalances_FullMethodName:     ScopeReadAccounts,
+	pb.LedgerService_GetProjectedBalance_FullMethodName:      ScopeReadAccounts,
+	pb.LedgerService_DeleteAccount_FullMethodName:            ScopeAdminTenant,
+	pb.LedgerService_RestoreAccount_FullMethodName:           ScopeAdminTenant,
+	pb.LedgerService_SetAccountOverdraftLimit_FullMethodName: ScopeAdminTenant,
+	pb.LedgerService_SetAccountLabels_FullMethodName:         ScopeAdminTenant,
+	pb.LedgerService_SetAccountExternalId_FullMethodName:     ScopeAdminTenant,
+	pb.LedgerService_GetAccountByExternalId_FullMethodName:   ScopeReadAccounts,
+	pb.LedgerService_MoveAccount_FullMethodName:              ScopeAdminTenant,
+	pb.LedgerService_MergeAccounts_FullMethodName:            ScopeAdminTenant,
+	pb.LedgerService_CreateBook_FullMethodName:               ScopeAdminTenant,
+	pb.LedgerService_GetBook_FullMethodName:                  ScopeReadAccounts,
+	pb.LedgerService_ListBooks_FullMethodName:                ScopeReadAccounts,
+	pb.LedgerService_CreateJournalEntry_FullMethodName:       ScopeWriteJournal,
+	pb.LedgerService_CreateLargeJournalEntry_FullMethodName:  ScopeWriteJournal,
+	pb.LedgerService_Transfer_FullMethodName:                 ScopeWriteJournal,
+	pb.LedgerService_CloneJournalEntry_FullMethodName:        ScopeWriteJournal,
+	pb.LedgerService_GetTransactionGroup_FullMethodName:      ScopeReadAccounts,
+	pb.LedgerService_GetJournalEntry_FullMethodName:          ScopeReadAccounts,
+	pb.LedgerService_ListJournalEntries_FullMethodName:       ScopeReadAccounts,
+	pb.LedgerService_SearchJournalEntries_FullMethodName:     ScopeReadAccounts,
+	pb.LedgerService_ExportJournalEntries_FullMethodName:     ScopeReadAccounts,
+	pb.LedgerService_AggregateJournalLines_FullMethodName:    ScopeReadAccounts,
+	pb.LedgerService_VerifyLedgerIntegrity_FullMethodName:    ScopeReadAccounts,
+	pb.LedgerService_VerifyTenantBalances_FullMethodName:     ScopeReadAccounts,
+	pb.LedgerService_GetDailyDigest_FullMethodName:           ScopeReadAccounts,
+	pb.LedgerService_ListLedgerEvents_FullMethodName:         ScopeReadAccounts,
+	pb.LedgerService_GetEventSchema_FullMethodName:           ScopeReadAccounts,
+	pb.LedgerService_WatchAuditEvents_FullMethodName:         ScopeAdminTenant,
+	pb.LedgerService_GetEntityHistory_FullMethodName:         ScopeReadAccounts,
+	pb.LedgerService_ListAccountTypes_FullMethodName:         ScopeReadAccounts,
+	pb.LedgerService_ListCurrencies_FullMethodName:           ScopeReadAccounts,
+	pb.LedgerService_GetQuotaUsage_FullMethodName:            ScopeReadAccounts,
+	pb.LedgerService_GetTenantSettings_FullMethodName:        ScopeReadAccounts,
+	pb.LedgerService_UpdateTenantSettings_FullMethodName:     ScopeAdminTenant,
+	pb.LedgerService_GetPostingPolicy_FullMethodName:         ScopeReadAccounts,
+	pb.LedgerService_UpdatePostingPolicy_FullMethodName:      ScopeAdminTenant,
+	pb.LedgerService_GetReferenceSequence_FullMethodName:     ScopeReadAccounts,
+	pb.LedgerService_UpdateReferenceSequence_FullMethodName:  ScopeAdminTenant,
+	pb.LedgerService_CreateBudget_FullMethodName:             ScopeAdminTenant,
+	pb.LedgerService_GetBudget_FullMethodName:                ScopeReadAccounts,
+	pb.LedgerService_ListBudgets_FullMethodName:              ScopeReadAccounts,
+	pb.LedgerService_UpdateBudget_FullMethodName:             ScopeAdminTenant,
+	pb.LedgerService_DeleteBudget_FullMethodName:             ScopeAdminTenant,
+	pb.LedgerService_GetBudgetVsActual_FullMethodName:        ScopeReadAccounts,
+	pb.LedgerService_GetCloseTemplate_FullMethodName:         ScopeReadAccounts,
+	pb.LedgerService_UpdateCloseTemplate_FullMethodName:      ScopeAdminTenant,
+	pb.LedgerService_StartPeriodClose_FullMethodName:         ScopeWriteJournal,
+	pb.LedgerService_GetCloseChecklist_FullMethodName:        ScopeReadAccounts,
+	pb.LedgerService_ListCloseChecklists_FullMethodName:      ScopeReadAccounts,
+	pb.LedgerService_UpdateCloseTask_FullMethodName:          ScopeWriteJournal,
+	pb.LedgerService_CreateTaxCode_FullMethodName:            ScopeAdminTenant,
+	pb.LedgerService_GetTaxCode_FullMethodName:               ScopeReadAccounts,
+	pb.LedgerService_ListTaxCodes_FullMethodName:             ScopeReadAccounts,
+	pb.LedgerService_UpdateTaxCode_FullMethodName:            ScopeAdminTenant,
+	pb.LedgerService_GetTaxReport_FullMethodName:             ScopeReadAccounts,
+	pb.LedgerService_CreateParty_FullMethodName:              ScopeAdminTenant,
+	pb.LedgerService_GetParty_FullMethodName:                 ScopeReadAccounts,
+	pb.LedgerService_ListParties_FullMethodName:              ScopeReadAccounts,
+	pb.LedgerService_UpdateParty_FullMethodName:              ScopeAdminTenant,
+	pb.LedgerService_DeleteParty_FullMethodName:              ScopeAdminTenant,
+	pb.LedgerService_GetPartyBalance_FullMethodName:          ScopeReadAccounts,
+	pb.LedgerService_GetPartyStatement_FullMethodName:        ScopeReadAccounts,
+	pb.LedgerService_CreateDimension_FullMethodName:          ScopeAdminTenant,
+	pb.LedgerService_GetDimension_FullMethodName:             ScopeReadAccounts,
+	pb.LedgerService_ListDimensions_FullMethodName:           ScopeReadAccounts,
+	pb.LedgerService_UpdateDimension_FullMethodName:          ScopeAdminTenant,
+	pb.LedgerService_GetDimensionBalances_FullMethodName:     ScopeReadAccounts,
+	pb.LedgerService_CreateHold_FullMethodName:               ScopeWriteJournal,
+	pb.LedgerService_GetHold_FullMethodName:                  ScopeReadAccounts,
+	pb.LedgerService_ListHolds_FullMethodName:                ScopeReadAccounts,
+	pb.LedgerService_CaptureHold_FullMethodName:              ScopeWriteJournal,
+	pb.LedgerService_ReleaseHold_FullMethodName:              ScopeWriteJournal,
+	pb.LedgerService_PrepareJournalEntry_FullMethodName:      ScopeWriteJournal,
+	pb.LedgerService_GetPreparedJournalEntry_FullMethodName:  ScopeReadAccounts,
+	pb.LedgerService_ConfirmJournalEntry_FullMethodName:      ScopeWriteJournal,
+	pb.LedgerService_CancelJournalEntry_FullMethodName:       ScopeWriteJournal,
+	pb.LedgerService_CreateBatch_FullMethodName:              ScopeWriteJournal,
+	pb.LedgerService_GetBatch_FullMethodName:                 ScopeReadAccounts,
+	pb.LedgerService_ListBatches_FullMethodName:              ScopeReadAccounts,
+	pb.LedgerService_AddToBatch_FullMethodName:               ScopeWriteJournal,
+	pb.LedgerService_ValidateBatch_FullMethodName:            ScopeWriteJournal,
+	pb.LedgerService_ApproveBatch_FullMethodName:             ScopeApproveJournal,
+	pb.LedgerService_RejectBatch_FullMethodName:              ScopeApproveJournal,
+	pb.LedgerService_PostBatch_FullMethodName:                ScopeWriteJournal,
+	pb.LedgerService_ExportLedgerData_FullMethodName:         ScopeReadAccounts,
+	pb.LedgerService_GetExportJob_FullMethodName:             ScopeReadAccounts,
+	pb.LedgerService_DownloadExportFile_FullMethodName:       ScopeReadAccounts,
+	pb.LedgerService_GenerateStatements_FullMethodName:       ScopeReadAccounts,
+	pb.LedgerService_GetStatementRun_FullMethodName:          ScopeReadAccounts,
+	pb.LedgerService_DownloadStatement_FullMethodName:        ScopeReadAccounts,
+
+	pb.ReconciliationService_ImportBankStatement_FullMethodName:     ScopeWriteJournal,
+	pb.ReconciliationService_ListStatementLines_FullMethodName:      ScopeReadAccounts,
+	pb.ReconciliationService_AutoMatch_FullMethodName:               ScopeWriteJournal,
+	pb.ReconciliationService_MatchStatementLine_FullMethodName:      ScopeWriteJournal,
+	pb.ReconciliationService_UnmatchStatementLine_FullMethodName:    ScopeWriteJournal,
+	pb.ReconciliationService_GetReconciliationStatus_FullMethodName: ScopeReadAccounts,
+
+	pb.SubledgerService_CreateDocument_FullMethodName: ScopeWriteJournal,
+	pb.SubledgerService_GetDocument_FullMethodName:    ScopeReadAccounts,
+	pb.SubledgerService_ListDocuments_FullMethodName:  ScopeReadAccounts,
+	pb.SubledgerService_ApplyPayment_FullMethodName:   ScopeWriteJournal,
+	pb.SubledgerService_UnapplyPayment_FullMethodName: ScopeWriteJournal,
+
+	pb.AssetService_CreateFixedAsset_FullMethodName:            ScopeAdminTenant,
+	pb.AssetService_GetFixedAsset_FullMethodName:               ScopeReadAccounts,
+	pb.AssetService_ListFixedAssets_FullMethodName:             ScopeReadAccounts,
+	pb.AssetService_PreviewDepreciationSchedule_FullMethodName: ScopeReadAccounts,
+	pb.AssetService_PostDepreciation_FullMethodName:            ScopeWriteJournal,
+
+	pb.InterestService_CreateInterestScheme_FullMethodName: ScopeAdminTenant,
+	pb.InterestService_UpdateInterestScheme_FullMethodName: ScopeAdminTenant,
+	pb.InterestService_GetInterestScheme_FullMethodName:    ScopeReadAccounts,
+	pb.InterestService_ListInterestSchemes_FullMethodName:  ScopeReadAccounts,
+	pb.InterestService_AttachInterestScheme_FullMethodName: ScopeAdminTenant,
+	pb.InterestService_DetachInterestScheme_FullMethodName: ScopeAdminTenant,
+	pb.InterestService_AccrueInterest_FullMethodName:       ScopeWriteJournal,
+	pb.InterestService_ListInterestAccruals_FullMethodName: ScopeReadAccounts,
+
+	pbv2.LedgerService_GetAccountBalance_FullMethodName:  ScopeReadAccounts,
+	pbv2.LedgerService_CreateJournalEntry_FullMethodName: ScopeWriteJournal,
+	pbv2.LedgerService_GetJournalEntry_FullMethodName:    ScopeReadAccounts,
+	pbv2.LedgerService_Transfer_FullMethodName:           ScopeWriteJournal,
+}
+
+// publicServices lists the services served next to the tenant API that do
+// not require credentials
+var publicServices = map[string]bool{
+	healthpb.Health_ServiceDesc.ServiceName:                    true,
+	reflectionv1.ServerReflection_ServiceDesc.ServiceName:      true,
+	reflectionv1alpha.ServerReflection_ServiceDesc.ServiceName: true,
+}
+
+// isPublic reports whether a method belongs to one of the publicServices
+func isPublic(method string) bool {
+	service, _, ok := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	return ok && publicServices[service]
+}
+
+// RequiredScope returns the scope needed to call a method, if any
+func RequiredScope(method string) (string, bool) {
+	scope, ok := methodScopes[method]
+	return scope, ok
+}
+
+type scopesContextKey struct{}
+
+// NewScopesContext returns a context carrying the scopes granted to the
+// credentials of a call
+func NewScopesContext(ctx context.Context, granted []string) context.Context {
+	return context.WithValue(ctx, scopesContextKey{}, granted)
+}
+
+// HasScope reports whether the credentials of a call grant a scope. Calls
+// that carry no granted scopes are denied every scope, so a call that
+// bypassed the authenticator cannot act with more than it was granted.
+func HasScope(ctx context.Context, scope string) bool {
+	granted, _ := ctx.Value(scopesContextKey{}).([]string)
+	for _, s := range granted {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllScopes returns every valid scope
+func AllScopes() []string {
+	all := make([]string, 0, len(scopes))
+	for scope := range scopes {
+		all = append(all, scope)
+	}
+	return all
+}
+
+type credentialTenantContextKey struct{}
+
+// NewCredentialTenantContext returns a context carrying the tenant the
+// credentials of a call are bound to
+func NewCredentialTenantContext(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, credentialTenantContextKey{}, tenantID)
+}
+
+// CredentialTenantFromContext returns the tenant the credentials of a call
+// are bound to, if the call was authenticated
+func CredentialTenantFromContext(ctx context.Context) (uuid.UUID, bool) {
+	tenantID, ok := ctx.Value(credentialTenantContextKey{}).(uuid.UUID)
+	return tenantID, ok
+}
+
+// ScopeAuthenticator authenticates calls to the tenant API with API keys or
+// HS256-signed JWTs sent as bearer tokens, and rejects calls whose
+// credentials lack the scope of the method. Methods without a scope are
+// denied, except those of the health and reflection services. Every credential is bound to one
+// tenant: a JWT names it in a "tenant_id" claim and carries its scopes as a
+// space-separated "scope" claim.
+type ScopeAuthenticator struct {
+	apiKeys   []apiKey
+	jwtSecret []byte
+	now       func() time.Time
+}
+
+// APIKey is the tenant an API key is bound to and the scopes it grants
+type APIKey struct {
+	Tenant uuid.UUID
+	Scopes []string
+}
+
+// credential is the tenant and scopes of an authenticated bearer token
+type credential struct {
+	tenant uuid.UUID
+	scopes []string
+}
+
+// apiKey is a static credential
+type apiKey struct {
+	key []byte
+	credential
+}
+
+// NewScopeAuthenticator creates a new scope authenticator for API keys
+// mapped to their tenants and scopes and, when jwtSecret is not empty, JWTs
+// signed with it. Unknown scopes and keys without a tenant are rejected.
+func NewScopeAuthenticator(apiKeys map[string]APIKey, jwtSecret string) (*ScopeAuthenticator, error) {
+	a := &ScopeAuthenticator{now: time.Now}
+	if jwtSecret != "" {
+		a.jwtSecret = []byte(jwtSecret)
+	}
+
+	for key, k := range apiKeys {
+		if key == "" {
+			return nil, fmt.Errorf("API key must not be empty")
+		}
+		if k.Tenant == uuid.Nil {
+			return nil, fmt.Errorf("API key must be bound to a tenant")
+		}
+		for _, scope := range k.Scopes {
+			if !scopes[scope] {
+				return nil, fmt.Errorf("unknown scope %q", scope)
+			}
+		}
+		a.apiKeys = append(a.apiKeys, apiKey{key: []byte(key), credential: credential{tenant: k.Tenant, scopes: k.Scopes}})
+	}
+
+	return a, nil
+}
+
+// Authorize checks that the bearer token carried in the incoming context
+// grants the scope of a method
+func (a *ScopeAuthenticator) Authorize(ctx context.Context, method string) error {
+	_, err := a.authorize(ctx, method)
+	return err
+}
+
+// authorize checks the scope of a method like Authorize and returns the
+// context of the call carrying the tenant and scopes of its credentials
+func (a *ScopeAuthenticator) authorize(ctx context.Context, method string) (context.Context, error) {
+	required, ok := RequiredScope(method)
+	if !ok {
+		if isPublic(method) {
+			return ctx, nil
+		}
+		return nil, status.Errorf(codes.PermissionDenied, "method %s is not open to API credentials", method)
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing metadata")
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization header")
+	}
+
+	token, found := strings.CutPrefix(values[0], "Bearer ")
+	if !found {
+		return nil, status.Error(codes.Unauthenticated, "authorization header must use the Bearer scheme")
+	}
+
+	cred, err := a.credential(token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+
+	for _, scope := range cred.scopes {
+		if scope == required {
+			return NewCredentialTenantContext(NewScopesContext(ctx, cred.scopes), cred.tenant), nil
+		}
+	}
+
+	return nil, status.Errorf(codes.PermissionDenied, "credentials lack the %s scope", required)
+}
+
+// credential returns the tenant and scopes of a bearer token
+func (a *ScopeAuthenticator) credential(token string) (credential, error) {
+	// Compare against every key so the time taken does not reveal which matched
+	var matched *credential
+	for i, k := range a.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(token), k.key) == 1 {
+			matched = &a.apiKeys[i].credential
+		}
+	}
+	if matched != nil {
+		return *matched, nil
+	}
+
+	if a.jwtSecret != nil && strings.Count(token, ".") == 2 {
+		return verifyJWT(token, a.jwtSecret, a.now())
+	}
+
+	return credential{}, fmt.Errorf("unknown credentials")
+}
+
+// UnaryServerInterceptor returns a unary interceptor that rejects calls
+// without the scope of their method and passes the tenant and scopes of their
+// credentials on to the handler
+func (a *ScopeAuthenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a stream interceptor that rejects calls
+// without the scope of their method and passes the tenant and scopes of their
+// credentials on to the handler
+func (a *ScopeAuthenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &scopedServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// scopedServerStream carries the tenant and scopes of the credentials
+// through a stream
+type scopedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *scopedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// GrantAllScopesUnaryServerInterceptor returns a unary interceptor granting
+// every scope to calls, for a tenant API that has no credentials configured
+func GrantAllScopesUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(NewScopesContext(ctx, AllScopes()), req)
+	}
+}
+
+// GrantAllScopesStreamServerInterceptor returns a stream interceptor
+// granting every scope to calls, for a tenant API that has no credentials
+// configured
+func GrantAllScopesStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &scopedServerStream{ServerStream: ss, ctx: NewScopesContext(ss.Context(), AllScopes())})
+	}
+}
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+	// Registers the ledger.v2 services walked by TestRequiredScope
+	_ "github.com/hesabFun/ledger/gen/go/ledger/v2"
+)
+
+// signJWT builds an HS256 JWT with the given header and claims
+func signJWT(secret, header, claims string) string {
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func bearer(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestRequiredScope(t *testing.T) {
+	t.Run("covers every method of the registered tenant services", func(t *testing.T) {
+		// The admin and consolidation services are served by the admin server
+		adminServices := map[protoreflect.FullName]bool{
+			protoreflect.FullName(pb.AdminService_ServiceDesc.ServiceName):         true,
+			protoreflect.FullName(pb.ConsolidationService_ServiceDesc.ServiceName): true,
+		}
+
+		var checked int
+		protoregistry.GlobalFiles.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+			if pkg := file.Package(); pkg != "ledger.v1" && pkg != "ledger.v2" {
+				return true
+			}
+			for i := 0; i < file.Services().Len(); i++ {
+				service := file.Services().Get(i)
+				if adminServices[service.FullName()] {
+					continue
+				}
+				for j := 0; j < service.Methods().Len(); j++ {
+					method := "/" + string(service.FullName()) + "/" + string(service.Methods().Get(j).Name())
+					_, ok := RequiredScope(method)
+					assert.True(t, ok, "%s has no scope", method)
+					checked++
+				}
+			}
+			return true
+		})
+		assert.NotZero(t, checked)
+	})
+
+	t.Run("keeps reads and postings apart", func(t *testing.T) {
+		scope, _ := RequiredScope(pb.LedgerService_ListJournalEntries_FullMethodName)
+		assert.Equal(t, ScopeReadAccounts, scope)
+		scope, _ = RequiredScope(pb.LedgerService_CreateJournalEntry_FullMethodName)
+		assert.Equal(t, ScopeWriteJournal, scope)
+		scope, _ = RequiredScope(pb.LedgerService_UpdatePostingPolicy_FullMethodName)
+		assert.Equal(t, ScopeAdminTenant, scope)
+	})
+}
+
+// testTenant is the tenant the credentials of the tests are bound to
+var testTenant = uuid.MustParse("9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c04")
+
+func TestScopeAuthenticator_Authorize(t *testing.T) {
+	authenticator, err := NewScopeAuthenticator(map[string]APIKey{
+		"reporting": {Tenant: testTenant, Scopes: []string{ScopeReadAccounts}},
+		"poster":    {Tenant: testTenant, Scopes: []string{ScopeReadAccounts, ScopeWriteJournal}},
+	}, "jwt-secret")
+	require.NoError(t, err)
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	authenticator.now = func() time.Time { return now }
+
+	read := pb.LedgerService_GetAccount_FullMethodName
+	post := pb.LedgerService_CreateJournalEntry_FullMethodName
+
+	t.Run("allows methods within the scopes of an API key", func(t *testing.T) {
+		assert.NoError(t, authenticator.Authorize(bearer("reporting"), read))
+		assert.NoError(t, authenticator.Authorize(bearer("poster"), post))
+	})
+
+	t.Run("denies methods outside the scopes of an API key", func(t *testing.T) {
+		err := authenticator.Authorize(bearer("reporting"), post)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("rejects unknown and missing credentials", func(t *testing.T) {
+		assert.Equal(t, codes.Unauthenticated, status.Code(authenticator.Authorize(bearer("wrong"), read)))
+		assert.Equal(t, codes.Unauthenticated, status.Code(authenticator.Authorize(context.Background(), read)))
+	})
+
+	t.Run("allows health checks and reflection without credentials", func(t *testing.T) {
+		assert.NoError(t, authenticator.Authorize(context.Background(), "/grpc.health.v1.Health/Check"))
+		assert.NoError(t, authenticator.Authorize(context.Background(), "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"))
+	})
+
+	t.Run("denies methods without a scope", func(t *testing.T) {
+		for _, method := range []string{"/ledger.v1.LedgerService/NewMethod", "/grpc.health.v1.Health", "/other.Service/Check"} {
+			err := authenticator.Authorize(bearer("poster"), method)
+			assert.Equal(t, codes.PermissionDenied, status.Code(err), method)
+		}
+	})
+
+	t.Run("reads the scopes of a signed JWT", func(t *testing.T) {
+		token := signJWT("jwt-secret", `{"alg":"HS256","typ":"JWT"}`, `{"scope":"read:accounts write:journal","tenant_id":"`+testTenant.String()+`","exp":1773662400}`)
+
+		assert.NoError(t, authenticator.Authorize(bearer(token), post))
+		err := authenticator.Authorize(bearer(token), pb.LedgerService_UpdateTenantSettings_FullMethodName)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("rejects invalid JWTs", func(t *testing.T) {
+		for name, token := range map[string]string{
+			"wrong secret":    signJWT("other-secret", `{"alg":"HS256"}`, `{"scope":"read:accounts","tenant_id":"`+testTenant.String()+`"}`),
+			"expired":         signJWT("jwt-secret", `{"alg":"HS256"}`, `{"scope":"read:accounts","tenant_id":"`+testTenant.String()+`","exp":1773576000}`),
+			"not yet valid":   signJWT("jwt-secret", `{"alg":"HS256"}`, `{"scope":"read:accounts","tenant_id":"`+testTenant.String()+`","nbf":1773662400}`),
+			"other algorithm": signJWT("jwt-secret", `{"alg":"none"}`, `{"scope":"read:accounts","tenant_id":"`+testTenant.String()+`"}`),
+			"no tenant":       signJWT("jwt-secret", `{"alg":"HS256"}`, `{"scope":"read:accounts"}`),
+		} {
+			err := authenticator.Authorize(bearer(token), read)
+			assert.Equal(t, codes.Unauthenticated, status.Code(err), name)
+		}
+	})
+
+	t.Run("rejects unknown scopes", func(t *testing.T) {
+		_, err := NewScopeAuthenticator(map[string]APIKey{"key": {Tenant: testTenant, Scopes: []string{"write:everything"}}}, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects API keys without a tenant", func(t *testing.T) {
+		_, err := NewScopeAuthenticator(map[string]APIKey{"key": {Scopes: []string{ScopeReadAccounts}}}, "")
+		assert.Error(t, err)
+	})
+}
+
+func TestScopeAuthenticator_UnaryServerInterceptor(t *testing.T) {
+	otherTenant := uuid.New()
+	authenticator, err := NewScopeAuthenticator(map[string]APIKey{
+		"poster":     {Tenant: testTenant, Scopes: []string{ScopeWriteJournal}},
+		"controller": {Tenant: otherTenant, Scopes: []string{ScopeWriteJournal, ScopeOverrideLock}},
+	}, "")
+	require.NoError(t, err)
+	interceptor := authenticator.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: pb.LedgerService_CreateJournalEntry_FullMethodName}
+
+	canOverride := func(token string) bool {
+		var granted bool
+		_, err := interceptor(bearer(token), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			granted = HasScope(ctx, ScopeOverrideLock)
+			return nil, nil
+		})
+		require.NoError(t, err)
+		return granted
+	}
+
+	t.Run("passes the granted scopes to the handler", func(t *testing.T) {
+		assert.False(t, canOverride("poster"))
+		assert.True(t, canOverride("controller"))
+	})
+
+	t.Run("passes the tenant of the credentials to the handler", func(t *testing.T) {
+		var bound uuid.UUID
+		_, err := interceptor(bearer("controller"), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			bound, _ = CredentialTenantFromContext(ctx)
+			return nil, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, otherTenant, bound)
+	})
+
+	t.Run("denies every scope to calls that were not authenticated", func(t *testing.T) {
+		assert.False(t, HasScope(context.Background(), ScopeOverrideLock))
+	})
+}
+
+func TestGrantAllScopesUnaryServerInterceptor(t *testing.T) {
+	var granted bool
+	_, err := GrantAllScopesUnaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		granted = HasScope(ctx, ScopeOverrideLock) && HasScope(ctx, ScopeApproveJournal)
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.True(t, granted)
+}
@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// TenantHeader is the metadata header carrying the tenant of a call
+const TenantHeader = "x-tenant-id"
+
+type tenantContextKey struct{}
+
+// NewTenantContext returns a context carrying the tenant of a call
+func NewTenantContext(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant resolved for a call, if any
+func TenantFromContext(ctx context.Context) (uuid.UUID, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(uuid.UUID)
+	return tenantID, ok
+}
+
+// Tenant statuses reported by a TenantStatusLookup
+const (
+	TenantActive    = "ACTIVE"
+	TenantSuspended = "SUSPENDED"
+	TenantArchived  = "ARCHIVED"
+	TenantDeleted   = "DELETED"
+)
+
+// TenantStatusLookup returns the lifecycle status of a tenant, or an empty
+// status for an unknown tenant
+type TenantStatusLookup interface {
+	Status(ctx context.Context, tenantID uuid.UUID) (string, error)
+}
+
+// TenantResolver resolves the tenant of a call from the "x-tenant-id"
+// metadata header, or from the tenant its credentials are bound to. Requests
+// with a tenant_id field, directly or in their header message, get it filled
+// in when left empty and are rejected when it names another tenant, so clients can send the tenant once per
+// connection instead of in every message. Calls with neither keep using the
+// tenant_id of the request.
+//
+// With a status lookup, calls to tenant API methods are also checked against
+// the status of their tenant: suspended and deleted tenants are denied every
+// method, and archived tenants every method that needs more than the
+// read:accounts scope.
+type TenantResolver struct {
+	statuses TenantStatusLookup
+}
+
+// NewTenantResolver creates a new tenant resolver that checks tenant
+// statuses with statuses, unless it is nil
+func NewTenantResolver(statuses TenantStatusLookup) *TenantResolver {
+	return &TenantResolver{statuses: statuses}
+}
+
+// Resolve returns a context carrying the tenant named in the incoming
+// metadata, or else the tenant the credentials of the call are bound to, or
+// ctx unchanged when there is neither. A header naming another tenant than
+// the credentials is rejected.
+func (r *TenantResolver) Resolve(ctx context.Context) (context.Context, error) {
+	bound, authenticated := CredentialTenantFromContext(ctx)
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(TenantHeader)
+	if len(values) == 0 {
+		if authenticated {
+			return NewTenantContext(ctx, bound), nil
+		}
+		return ctx, nil
+	}
+
+	tenantID, err := uuid.Parse(values[0])
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s header", TenantHeader)
+	}
+	for _, value := range values[1:] {
+		if other, err := uuid.Parse(value); err != nil || other != tenantID {
+			return nil, status.Errorf(codes.InvalidArgument, "conflicting %s headers", TenantHeader)
+		}
+	}
+	if authenticated && tenantID != bound {
+		return nil, status.Errorf(codes.PermissionDenied, "credentials are not valid for the tenant of the %s header", TenantHeader)
+	}
+
+	return NewTenantContext(ctx, tenantID), nil
+}
+
+// UnaryServerInterceptor returns a unary interceptor that resolves the tenant
+// of a call and applies it to the request
+func (r *TenantResolver) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := r.Resolve(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyTenant(ctx, req); err != nil {
+			return nil, err
+		}
+		if tenantID, ok := requestTenant(ctx, req); ok {
+			if err := r.checkStatus(ctx, info.FullMethod, tenantID); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a stream interceptor that resolves the
+// tenant of a call and applies it to every received message, checking the
+// status of each tenant the messages name once
+func (r *TenantResolver) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := r.Resolve(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantServerStream{
+			ServerStream: ss,
+			ctx:          ctx,
+			resolver:     r,
+			method:       info.FullMethod,
+			checked:      make(map[uuid.UUID]bool),
+		})
+	}
+}
+
+// tenantServerStream carries the resolved tenant through a stream
+type tenantServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	resolver *TenantResolver
+	method   string
+	// checked holds the tenants whose status was already checked
+	checked map[uuid.UUID]bool
+}
+
+func (s *tenantServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *tenantServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if err := applyTenant(s.ctx, m); err != nil {
+		return err
+	}
+
+	tenantID, ok := requestTenant(s.ctx, m)
+	if !ok || s.checked[tenantID] {
+		return nil
+	}
+	if err := s.resolver.checkStatus(s.ctx, s.method, tenantID); err != nil {
+		return err
+	}
+	s.checked[tenantID] = true
+	return nil
+}
+
+// checkStatus rejects a call to a tenant API method that the status of its
+// tenant does not allow
+func (r *TenantResolver) checkStatus(ctx context.Context, method string, tenantID uuid.UUID) error {
+	if r.statuses == nil {
+		return nil
+	}
+	scope, ok := RequiredScope(method)
+	if !ok {
+		return nil
+	}
+
+	tenantStatus, err := r.statuses.Status(ctx, tenantID)
+	if err != nil {
+		return status.Error(codes.Internal, "failed to check tenant status")
+	}
+
+	switch tenantStatus {
+	case TenantSuspended:
+		return status.Error(codes.PermissionDenied, "tenant is suspended")
+	case TenantDeleted:
+		return status.Error(codes.PermissionDenied, "tenant is deleted")
+	case TenantArchived:
+		if scope != ScopeReadAccounts {
+			return status.Error(codes.PermissionDenied, "tenant is archived and read-only")
+		}
+	}
+
+	return nil
+}
+
+// applyTenant fills in the tenant_id field of a request with the tenant of
+// the call, or checks that it names the same tenant when already set
+func applyTenant(ctx context.Context, req interface{}) error {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	m, field := tenantField(req)
+	if field == nil {
+		return nil
+	}
+
+	value := m.Get(field).String()
+	if value == "" {
+		m.Set(field, protoreflect.ValueOfString(tenantID.String()))
+		return nil
+	}
+
+	requested, err := uuid.Parse(value)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid tenant ID")
+	}
+	if requested != tenantID {
+		return status.Error(codes.PermissionDenied, "tenant ID does not match the tenant of the call")
+	}
+
+	return nil
+}
+
+// requestTenant returns the tenant a request is for: the tenant of the call,
+// or else the tenant_id field of the request when it holds a valid ID
+func requestTenant(ctx context.Context, req interface{}) (uuid.UUID, bool) {
+	if tenantID, ok := TenantFromContext(ctx); ok {
+		return tenantID, true
+	}
+
+	m, field := tenantField(req)
+	if field == nil {
+		return uuid.Nil, false
+	}
+
+	tenantID, err := uuid.Parse(m.Get(field).String())
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return tenantID, true
+}
+
+// tenantField returns the string tenant_id field of a request, or else of
+// the header message it carries, as the first message of the streaming
+// uploads does. The field is nil when there is neither.
+func tenantField(req interface{}) (protoreflect.Message, protoreflect.FieldDescriptor) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil, nil
+	}
+
+	m := msg.ProtoReflect()
+	if field := stringTenantField(m); field != nil {
+		return m, field
+	}
+
+	header := m.Descriptor().Fields().ByName("header")
+	if header == nil || header.Kind() != protoreflect.MessageKind || header.IsList() || !m.Has(header) {
+		return nil, nil
+	}
+
+	h := m.Mutable(header).Message()
+	if field := stringTenantField(h); field != nil {
+		return h, field
+	}
+
+	return nil, nil
+}
+
+// stringTenantField returns the string tenant_id field of a message, if any
+func stringTenantField(m protoreflect.Message) protoreflect.FieldDescriptor {
+	field := m.Descriptor().Fields().ByName("tenant_id")
+	if field == nil || field.Kind() != protoreflect.StringKind || field.IsList() {
+		return nil
+	}
+	return field
+}
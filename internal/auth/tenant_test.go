@@ -0,0 +1,300 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+func TestTenantResolver_UnaryServerInterceptor(t *testing.T) {
+	interceptor := NewTenantResolver(nil).UnaryServerInterceptor()
+	tenantID := uuid.New()
+
+	var handledCtx context.Context
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handledCtx = ctx
+		return "ok", nil
+	}
+
+	withTenant := func(values ...string) context.Context {
+		md := metadata.MD{}
+		md.Append(TenantHeader, values...)
+		return metadata.NewIncomingContext(context.Background(), md)
+	}
+
+	t.Run("fills in a missing tenant ID from metadata", func(t *testing.T) {
+		req := &pb.GetAccountRequest{AccountId: uuid.New().String()}
+
+		resp, err := interceptor(withTenant(tenantID.String()), req, &grpc.UnaryServerInfo{}, handler)
+
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp)
+		assert.Equal(t, tenantID.String(), req.TenantId)
+		resolved, ok := TenantFromContext(handledCtx)
+		assert.True(t, ok)
+		assert.Equal(t, tenantID, resolved)
+	})
+
+	t.Run("accepts a matching tenant ID", func(t *testing.T) {
+		req := &pb.GetAccountRequest{TenantId: tenantID.String()}
+
+		_, err := interceptor(withTenant(tenantID.String()), req, &grpc.UnaryServerInfo{}, handler)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects a tenant ID naming another tenant", func(t *testing.T) {
+		req := &pb.GetAccountRequest{TenantId: uuid.New().String()}
+
+		resp, err := interceptor(withTenant(tenantID.String()), req, &grpc.UnaryServerInfo{}, handler)
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("rejects an invalid header", func(t *testing.T) {
+		resp, err := interceptor(withTenant("not-a-uuid"), &pb.GetAccountRequest{}, &grpc.UnaryServerInfo{}, handler)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("rejects conflicting headers", func(t *testing.T) {
+		resp, err := interceptor(withTenant(tenantID.String(), uuid.New().String()), &pb.GetAccountRequest{}, &grpc.UnaryServerInfo{}, handler)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("leaves requests alone without the header", func(t *testing.T) {
+		req := &pb.GetAccountRequest{TenantId: "body-tenant"}
+
+		_, err := interceptor(context.Background(), req, &grpc.UnaryServerInfo{}, handler)
+
+		require.NoError(t, err)
+		assert.Equal(t, "body-tenant", req.TenantId)
+		_, ok := TenantFromContext(handledCtx)
+		assert.False(t, ok)
+	})
+
+	t.Run("ignores requests without a tenant ID field", func(t *testing.T) {
+		req := &pb.ListCurrenciesRequest{}
+
+		_, err := interceptor(withTenant(tenantID.String()), req, &grpc.UnaryServerInfo{}, handler)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("fills in the tenant of the credentials without the header", func(t *testing.T) {
+		req := &pb.GetAccountRequest{}
+
+		_, err := interceptor(NewCredentialTenantContext(context.Background(), tenantID), req, &grpc.UnaryServerInfo{}, handler)
+
+		require.NoError(t, err)
+		assert.Equal(t, tenantID.String(), req.TenantId)
+	})
+
+	t.Run("rejects a header naming another tenant than the credentials", func(t *testing.T) {
+		ctx := NewCredentialTenantContext(withTenant(uuid.New().String()), tenantID)
+
+		resp, err := interceptor(ctx, &pb.GetAccountRequest{}, &grpc.UnaryServerInfo{}, handler)
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("rejects a tenant ID naming another tenant than the credentials", func(t *testing.T) {
+		req := &pb.GetAccountRequest{TenantId: uuid.New().String()}
+
+		resp, err := interceptor(NewCredentialTenantContext(context.Background(), tenantID), req, &grpc.UnaryServerInfo{}, handler)
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	req proto.Message
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeServerStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.req)
+	return nil
+}
+
+func TestTenantResolver_StreamServerInterceptor(t *testing.T) {
+	interceptor := NewTenantResolver(nil).StreamServerInterceptor()
+	tenantID := uuid.New()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(TenantHeader, tenantID.String()))
+
+	t.Run("fills in the tenant ID of received messages", func(t *testing.T) {
+		stream := &fakeServerStream{ctx: ctx, req: &pb.ExportJournalEntriesRequest{}}
+
+		err := interceptor(nil, stream, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+			req := &pb.ExportJournalEntriesRequest{}
+			require.NoError(t, ss.RecvMsg(req))
+			assert.Equal(t, tenantID.String(), req.TenantId)
+
+			resolved, ok := TenantFromContext(ss.Context())
+			assert.True(t, ok)
+			assert.Equal(t, tenantID, resolved)
+			return nil
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects received messages naming another tenant", func(t *testing.T) {
+		stream := &fakeServerStream{ctx: ctx, req: &pb.ExportJournalEntriesRequest{TenantId: uuid.New().String()}}
+
+		err := interceptor(nil, stream, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+			return ss.RecvMsg(&pb.ExportJournalEntriesRequest{})
+		})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("fills in the tenant ID of a streamed header", func(t *testing.T) {
+		stream := &fakeServerStream{ctx: ctx, req: &pb.ImportBankStatementRequest{
+			Payload: &pb.ImportBankStatementRequest_Header{Header: &pb.StatementHeader{}},
+		}}
+
+		err := interceptor(nil, stream, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+			req := &pb.ImportBankStatementRequest{}
+			require.NoError(t, ss.RecvMsg(req))
+			assert.Equal(t, tenantID.String(), req.GetHeader().GetTenantId())
+			return nil
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("accepts messages without a header", func(t *testing.T) {
+		stream := &fakeServerStream{ctx: ctx, req: &pb.ImportBankStatementRequest{
+			Payload: &pb.ImportBankStatementRequest_Chunk{Chunk: []byte("date,amount")},
+		}}
+
+		err := interceptor(nil, stream, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+			return ss.RecvMsg(&pb.ImportBankStatementRequest{})
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects a streamed header naming another tenant than the credentials", func(t *testing.T) {
+		bound := NewCredentialTenantContext(context.Background(), tenantID)
+		other := uuid.New().String()
+
+		for name, req := range map[string]proto.Message{
+			"large journal entry": &pb.CreateLargeJournalEntryRequest{
+				Payload: &pb.CreateLargeJournalEntryRequest_Header{Header: &pb.LargeJournalEntryHeader{TenantId: other}},
+			},
+			"bank statement": &pb.ImportBankStatementRequest{
+				Payload: &pb.ImportBankStatementRequest_Header{Header: &pb.StatementHeader{TenantId: other}},
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				stream := &fakeServerStream{ctx: bound, req: req}
+
+				err := interceptor(nil, stream, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+					return ss.RecvMsg(req.ProtoReflect().New().Interface())
+				})
+
+				assert.Equal(t, codes.PermissionDenied, status.Code(err))
+			})
+		}
+	})
+}
+
+// fakeTenantStatuses reports fixed tenant statuses
+type fakeTenantStatuses map[uuid.UUID]string
+
+func (f fakeTenantStatuses) Status(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	return f[tenantID], nil
+}
+
+func TestTenantResolver_TenantStatus(t *testing.T) {
+	active, suspended, archived, deleted := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	resolver := NewTenantResolver(fakeTenantStatuses{
+		active:    TenantActive,
+		suspended: TenantSuspended,
+		archived:  TenantArchived,
+		deleted:   TenantDeleted,
+	})
+	interceptor := resolver.UnaryServerInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	read := &grpc.UnaryServerInfo{FullMethod: pb.LedgerService_GetAccount_FullMethodName}
+	write := &grpc.UnaryServerInfo{FullMethod: pb.LedgerService_CreateJournalEntry_FullMethodName}
+
+	tests := []struct {
+		name     string
+		tenantID uuid.UUID
+		info     *grpc.UnaryServerInfo
+		want     codes.Code
+	}{
+		{"active tenants may write", active, write, codes.OK},
+		{"suspended tenants may not read", suspended, read, codes.PermissionDenied},
+		{"deleted tenants may not read", deleted, read, codes.PermissionDenied},
+		{"archived tenants may read", archived, read, codes.OK},
+		{"archived tenants may not write", archived, write, codes.PermissionDenied},
+		{"unknown tenants are left to the handler", uuid.New(), write, codes.OK},
+		{"methods outside the tenant API are not checked", suspended, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interceptor(context.Background(), &pb.GetAccountRequest{TenantId: tt.tenantID.String()}, tt.info, handler)
+
+			assert.Equal(t, tt.want, status.Code(err))
+		})
+	}
+
+	t.Run("checks the tenant of the header", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(TenantHeader, suspended.String()))
+
+		_, err := interceptor(ctx, &pb.ListCurrenciesRequest{}, read, handler)
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("checks the tenant of received stream messages", func(t *testing.T) {
+		stream := &fakeServerStream{ctx: context.Background(), req: &pb.ExportJournalEntriesRequest{TenantId: suspended.String()}}
+		info := &grpc.StreamServerInfo{FullMethod: pb.LedgerService_ExportJournalEntries_FullMethodName}
+
+		err := resolver.StreamServerInterceptor()(nil, stream, info, func(srv interface{}, ss grpc.ServerStream) error {
+			return ss.RecvMsg(&pb.ExportJournalEntriesRequest{})
+		})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("checks the tenant of a streamed header", func(t *testing.T) {
+		stream := &fakeServerStream{ctx: context.Background(), req: &pb.CreateLargeJournalEntryRequest{
+			Payload: &pb.CreateLargeJournalEntryRequest_Header{Header: &pb.LargeJournalEntryHeader{TenantId: suspended.String()}},
+		}}
+		info := &grpc.StreamServerInfo{FullMethod: pb.LedgerService_CreateLargeJournalEntry_FullMethodName}
+
+		err := resolver.StreamServerInterceptor()(nil, stream, info, func(srv interface{}, ss grpc.ServerStream) error {
+			return ss.RecvMsg(&pb.CreateLargeJournalEntryRequest{})
+		})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TokenAuthenticator validates a static bearer token sent in the
+// "authorization" metadata header
+type TokenAuthenticator struct {
+	token []byte
+}
+
+// NewTokenAuthenticator creates a new token authenticator
+func NewTokenAuthenticator(token string) *TokenAuthenticator {
+	return &TokenAuthenticator{token: []byte(token)}
+}
+
+// Authenticate checks the bearer token carried in the incoming context
+func (a *TokenAuthenticator) Authenticate(ctx context.Context) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing metadata")
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "missing authorization header")
+	}
+
+	token, found := strings.CutPrefix(values[0], "Bearer ")
+	if !found {
+		return status.Error(codes.Unauthenticated, "authorization header must use the Bearer scheme")
+	}
+
+	if len(a.token) == 0 || subtle.ConstantTimeCompare([]byte(token), a.token) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	return nil
+}
+
+// UnaryServerInterceptor returns a unary interceptor that rejects unauthenticated calls
+func (a *TokenAuthenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := a.Authenticate(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a stream interceptor that rejects unauthenticated calls
+func (a *TokenAuthenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.Authenticate(ss.Context()); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTokenAuthenticator_Authenticate(t *testing.T) {
+	authenticator := NewTokenAuthenticator("secret")
+
+	t.Run("accepts valid bearer token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer secret"))
+		assert.NoError(t, authenticator.Authenticate(ctx))
+	})
+
+	t.Run("rejects missing metadata", func(t *testing.T) {
+		err := authenticator.Authenticate(context.Background())
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("rejects missing authorization header", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-other", "value"))
+		err := authenticator.Authenticate(ctx)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("rejects non-bearer scheme", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic secret"))
+		err := authenticator.Authenticate(ctx)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("rejects wrong token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer wrong"))
+		err := authenticator.Authenticate(ctx)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("rejects everything when no token is configured", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "))
+		err := NewTokenAuthenticator("").Authenticate(ctx)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestTokenAuthenticator_UnaryServerInterceptor(t *testing.T) {
+	interceptor := NewTokenAuthenticator("secret").UnaryServerInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	t.Run("calls handler when authenticated", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer secret"))
+		resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", resp)
+	})
+
+	t.Run("does not call handler when unauthenticated", func(t *testing.T) {
+		resp, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
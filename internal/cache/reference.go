@@ -0,0 +1,185 @@
+// Package cache caches reads of rarely changing reference data: currencies,
+// account types and their translations. Cached reads expire after a fixed
+// time. Writes through the cache drop every cached read, while writes made
+// through other instances become visible once the cached reads expire.
+package cache
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/repository"
+)
+
+// ReferenceRepository caches the reads of a reference repository. Every read
+// returns copies, so callers may modify what they get.
+type ReferenceRepository struct {
+	repository.ReferenceRepositoryInterface
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]entry
+	// generation counts invalidations, so a read that started before one
+	// is not cached after it
+	generation uint64
+}
+
+var _ repository.ReferenceRepositoryInterface = (*ReferenceRepository)(nil)
+
+type entry struct {
+	value   any
+	expires time.Time
+}
+
+// NewReferenceRepository caches the reads of repo for ttl, holding at most
+// maxEntries reads
+func NewReferenceRepository(repo repository.ReferenceRepositoryInterface, ttl time.Duration, maxEntries int) *ReferenceRepository {
+	return &ReferenceRepository{
+		ReferenceRepositoryInterface: repo,
+		ttl:                          ttl,
+		maxEntries:                   maxEntries,
+		now:                          time.Now,
+		entries:                      make(map[string]entry),
+	}
+}
+
+// ListAccountTypes lists the account types, from the cache while it is fresh
+func (r *ReferenceRepository) ListAccountTypes(ctx context.Context) ([]*repository.AccountType, error) {
+	accountTypes, err := load(r, "account_types", func() ([]*repository.AccountType, error) {
+		return r.ReferenceRepositoryInterface.ListAccountTypes(ctx)
+	})
+	return cloneAll(accountTypes), err
+}
+
+// ListCurrencies lists the currencies, from the cache while it is fresh
+func (r *ReferenceRepository) ListCurrencies(ctx context.Context) ([]*repository.Currency, error) {
+	currencies, err := load(r, "currencies", func() ([]*repository.Currency, error) {
+		return r.ReferenceRepositoryInterface.ListCurrencies(ctx)
+	})
+	return cloneAll(currencies), err
+}
+
+// ListAccountTypeTranslations lists the account type names in the first of
+// locales that has one, from the cache while it is fresh
+func (r *ReferenceRepository) ListAccountTypeTranslations(ctx context.Context, locales []string) (map[string]string, error) {
+	names, err := load(r, "account_type_translations:"+strings.Join(locales, ","), func() (map[string]string, error) {
+		return r.ReferenceRepositoryInterface.ListAccountTypeTranslations(ctx, locales)
+	})
+	return maps.Clone(names), err
+}
+
+// ListCurrencyTranslations lists the currency names in the first of locales
+// that has one, from the cache while it is fresh
+func (r *ReferenceRepository) ListCurrencyTranslations(ctx context.Context, locales []string) (map[string]string, error) {
+	names, err := load(r, "currency_translations:"+strings.Join(locales, ","), func() (map[string]string, error) {
+		return r.ReferenceRepositoryInterface.ListCurrencyTranslations(ctx, locales)
+	})
+	return maps.Clone(names), err
+}
+
+// CreateAccountType creates an account type and drops the cached reads
+func (r *ReferenceRepository) CreateAccountType(ctx context.Context, params repository.CreateAccountTypeParams) (*repository.AccountType, error) {
+	defer r.invalidate()
+	return r.ReferenceRepositoryInterface.CreateAccountType(ctx, params)
+}
+
+// CreateCurrency creates a currency and drops the cached reads
+func (r *ReferenceRepository) CreateCurrency(ctx context.Context, params repository.CreateCurrencyParams) (*repository.Currency, error) {
+	defer r.invalidate()
+	return r.ReferenceRepositoryInterface.CreateCurrency(ctx, params)
+}
+
+// UpdateCurrency updates a currency and drops the cached reads
+func (r *ReferenceRepository) UpdateCurrency(ctx context.Context, code string, params repository.UpdateCurrencyParams) (*repository.Currency, error) {
+	defer r.invalidate()
+	return r.ReferenceRepositoryInterface.UpdateCurrency(ctx, code, params)
+}
+
+// SetAccountTypeTranslation names an account type in a locale and drops the
+// cached reads
+func (r *ReferenceRepository) SetAccountTypeTranslation(ctx context.Context, code, locale, name string) error {
+	defer r.invalidate()
+	return r.ReferenceRepositoryInterface.SetAccountTypeTranslation(ctx, code, locale, name)
+}
+
+// SetCurrencyTranslation names a currency in a locale and drops the cached
+// reads
+func (r *ReferenceRepository) SetCurrencyTranslation(ctx context.Context, code, locale, name string) error {
+	defer r.invalidate()
+	return r.ReferenceRepositoryInterface.SetCurrencyTranslation(ctx, code, locale, name)
+}
+
+// load returns the cached read under key while it is fresh, and otherwise
+// reads and caches it. Failed reads, and reads overtaken by a write, are not
+// cached.
+func load[T any](r *ReferenceRepository, key string, read func() (T, error)) (T, error) {
+	now := r.now()
+
+	r.mu.Lock()
+	if e, ok := r.entries[key]; ok && now.Before(e.expires) {
+		r.mu.Unlock()
+		return e.value.(T), nil
+	}
+	generation := r.generation
+	r.mu.Unlock()
+
+	value, err := read()
+	if err != nil {
+		return value, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if generation != r.generation {
+		return value, nil
+	}
+	if _, ok := r.entries[key]; !ok && len(r.entries) >= r.maxEntries {
+		r.evict(now)
+	}
+	r.entries[key] = entry{value: value, expires: now.Add(r.ttl)}
+	return value, nil
+}
+
+// evict makes room for one entry: it drops the expired entries, or the one
+// expiring first when none has. The caller must hold r.mu.
+func (r *ReferenceRepository) evict(now time.Time) {
+	var first string
+	for key, e := range r.entries {
+		if !now.Before(e.expires) {
+			delete(r.entries, key)
+			continue
+		}
+		if first == "" || e.expires.Before(r.entries[first].expires) {
+			first = key
+		}
+	}
+	if len(r.entries) >= r.maxEntries {
+		delete(r.entries, first)
+	}
+}
+
+// invalidate drops every cached read
+func (r *ReferenceRepository) invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.entries)
+	r.generation++
+}
+
+// cloneAll copies the structs a slice points to
+func cloneAll[T any](values []*T) []*T {
+	if values == nil {
+		return nil
+	}
+	clones := make([]*T, len(values))
+	for i, v := range values {
+		c := *v
+		clones[i] = &c
+	}
+	return clones
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepository serves fixed reference data and counts the reads that
+// reach it
+type countingRepository struct {
+	repository.ReferenceRepositoryInterface
+	reads int
+	err   error
+}
+
+func (c *countingRepository) ListCurrencies(ctx context.Context) ([]*repository.Currency, error) {
+	c.reads++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return []*repository.Currency{{Code: "USD", Name: "US Dollar", Precision: 2}}, nil
+}
+
+func (c *countingRepository) ListCurrencyTranslations(ctx context.Context, locales []string) (map[string]string, error) {
+	c.reads++
+	return map[string]string{"USD": "دلار آمریکا"}, nil
+}
+
+func (c *countingRepository) UpdateCurrency(ctx context.Context, code string, params repository.UpdateCurrencyParams) (*repository.Currency, error) {
+	return &repository.Currency{Code: code}, nil
+}
+
+func TestReferenceRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	newCache := func(repo *countingRepository, maxEntries int) *ReferenceRepository {
+		r := NewReferenceRepository(repo, time.Minute, maxEntries)
+		r.now = func() time.Time { return now }
+		return r
+	}
+
+	t.Run("serves reads from the cache until they expire", func(t *testing.T) {
+		repo := &countingRepository{}
+		r := newCache(repo, 10)
+
+		for i := 0; i < 3; i++ {
+			currencies, err := r.ListCurrencies(ctx)
+			require.NoError(t, err)
+			require.Len(t, currencies, 1)
+		}
+		assert.Equal(t, 1, repo.reads)
+
+		r.now = func() time.Time { return now.Add(time.Minute) }
+		_, err := r.ListCurrencies(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, repo.reads)
+	})
+
+	t.Run("returns copies callers may modify", func(t *testing.T) {
+		r := newCache(&countingRepository{}, 10)
+
+		currencies, err := r.ListCurrencies(ctx)
+		require.NoError(t, err)
+		currencies[0].Name = "Changed"
+		names, err := r.ListCurrencyTranslations(ctx, []string{"fa"})
+		require.NoError(t, err)
+		names["USD"] = "Changed"
+
+		currencies, err = r.ListCurrencies(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "US Dollar", currencies[0].Name)
+		names, err = r.ListCurrencyTranslations(ctx, []string{"fa"})
+		require.NoError(t, err)
+		assert.Equal(t, "دلار آمریکا", names["USD"])
+	})
+
+	t.Run("caches translations per locale list", func(t *testing.T) {
+		repo := &countingRepository{}
+		r := newCache(repo, 10)
+
+		_, err := r.ListCurrencyTranslations(ctx, []string{"fa-IR", "fa"})
+		require.NoError(t, err)
+		_, err = r.ListCurrencyTranslations(ctx, []string{"fa"})
+		require.NoError(t, err)
+		_, err = r.ListCurrencyTranslations(ctx, []string{"fa"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, repo.reads)
+	})
+
+	t.Run("drops cached reads on writes", func(t *testing.T) {
+		repo := &countingRepository{}
+		r := newCache(repo, 10)
+
+		_, err := r.ListCurrencies(ctx)
+		require.NoError(t, err)
+		_, err = r.UpdateCurrency(ctx, "USD", repository.UpdateCurrencyParams{})
+		require.NoError(t, err)
+		_, err = r.ListCurrencies(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, repo.reads)
+	})
+
+	t.Run("does not cache failed reads", func(t *testing.T) {
+		repo := &countingRepository{err: errors.New("connection refused")}
+		r := newCache(repo, 10)
+
+		_, err := r.ListCurrencies(ctx)
+		assert.Error(t, err)
+		_, err = r.ListCurrencies(ctx)
+		assert.Error(t, err)
+		assert.Equal(t, 2, repo.reads)
+	})
+
+	t.Run("holds at most max entries", func(t *testing.T) {
+		repo := &countingRepository{}
+		r := newCache(repo, 2)
+
+		for _, locale := range []string{"fa", "de", "fr"} {
+			_, err := r.ListCurrencyTranslations(ctx, []string{locale})
+			require.NoError(t, err)
+		}
+		assert.Len(t, r.entries, 2)
+	})
+}
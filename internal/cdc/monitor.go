@@ -0,0 +1,87 @@
+// Package cdc reports how far change data capture consumers reading
+// ledger_events through logical replication lag behind the database.
+package cdc
+
+import (
+	"context"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Monitor periodically samples the logical replication slots of the ledger
+// database and reports their lag as metrics
+type Monitor struct {
+	schemaRepo repository.SchemaRepositoryInterface
+	interval   time.Duration
+
+	lag    *prometheus.GaugeVec
+	active *prometheus.GaugeVec
+	errors prometheus.Counter
+}
+
+// NewMonitor creates a new monitor and registers its metrics with reg
+func NewMonitor(schemaRepo repository.SchemaRepositoryInterface, interval time.Duration, reg prometheus.Registerer) *Monitor {
+	m := &Monitor{
+		schemaRepo: schemaRepo,
+		interval:   interval,
+		lag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ledger_cdc_replication_slot_lag_bytes",
+			Help: "WAL written since the consumer of a logical replication slot last confirmed a flush.",
+		}, []string{"slot"}),
+		active: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ledger_cdc_replication_slot_active",
+			Help: "Whether a consumer is connected to a logical replication slot.",
+		}, []string{"slot"}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_cdc_sample_errors_total",
+			Help: "Replication slot samples that failed.",
+		}),
+	}
+
+	reg.MustRegister(m.lag, m.active, m.errors)
+
+	return m
+}
+
+// Run samples the replication slots once per interval until ctx is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.Sample(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sample reads the replication slots and updates the metrics. Slots that
+// were dropped since the last sample are removed from them.
+func (m *Monitor) Sample(ctx context.Context) {
+	slots, err := m.schemaRepo.ListReplicationSlots(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			redact.Printf(redact.LevelError, "replication slot sample: %v", err)
+			m.errors.Inc()
+		}
+		return
+	}
+
+	m.lag.Reset()
+	m.active.Reset()
+	for _, slot := range slots {
+		m.lag.WithLabelValues(slot.Name).Set(float64(slot.LagBytes))
+		active := 0.0
+		if slot.Active {
+			active = 1
+		}
+		m.active.WithLabelValues(slot.Name).Set(active)
+	}
+}
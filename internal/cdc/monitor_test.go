@@ -0,0 +1,58 @@
+package cdc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSchemaRepository struct {
+	repository.SchemaRepositoryInterface
+	slots []*repository.ReplicationSlot
+	err   error
+}
+
+func (f *fakeSchemaRepository) ListReplicationSlots(ctx context.Context) ([]*repository.ReplicationSlot, error) {
+	return f.slots, f.err
+}
+
+func TestMonitor_Sample(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reports the lag and activity of every slot", func(t *testing.T) {
+		repo := &fakeSchemaRepository{slots: []*repository.ReplicationSlot{
+			{Name: "debezium", Plugin: "pgoutput", Active: true, LagBytes: 4096},
+			{Name: "stale", Plugin: "pgoutput", LagBytes: 1 << 30},
+		}}
+		monitor := NewMonitor(repo, 0, prometheus.NewRegistry())
+
+		monitor.Sample(ctx)
+
+		assert.Equal(t, 4096.0, testutil.ToFloat64(monitor.lag.WithLabelValues("debezium")))
+		assert.Equal(t, 1.0, testutil.ToFloat64(monitor.active.WithLabelValues("debezium")))
+		assert.Equal(t, float64(1<<30), testutil.ToFloat64(monitor.lag.WithLabelValues("stale")))
+		assert.Equal(t, 0.0, testutil.ToFloat64(monitor.active.WithLabelValues("stale")))
+
+		// Dropped slots disappear from the metrics
+		repo.slots = repo.slots[:1]
+		monitor.Sample(ctx)
+		assert.Equal(t, 1, testutil.CollectAndCount(monitor.lag))
+	})
+
+	t.Run("counts an error and keeps the last sample when slots cannot be read", func(t *testing.T) {
+		repo := &fakeSchemaRepository{slots: []*repository.ReplicationSlot{{Name: "debezium", LagBytes: 10}}}
+		monitor := NewMonitor(repo, 0, prometheus.NewRegistry())
+		monitor.Sample(ctx)
+
+		repo.err = errors.New("connection refused")
+		monitor.Sample(ctx)
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(monitor.errors))
+		assert.Equal(t, 10.0, testutil.ToFloat64(monitor.lag.WithLabelValues("debezium")))
+	})
+}
@@ -0,0 +1,77 @@
+// Package compression registers the gzip and zstd compressors with gRPC and
+// lets the servers compress responses by default, so large pages such as
+// ListJournalEntries with metadata cost less bandwidth between regions.
+package compression
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// Names of the registered compressors
+const (
+	Gzip = gzip.Name
+	Zstd = "zstd"
+)
+
+// MaxLevel is the highest compression level, favouring size over speed
+const MaxLevel = 9
+
+func init() {
+	encoding.RegisterCompressor(newZstdCompressor(0))
+}
+
+// Configure sets the level of the registered compressors, from 1 (fastest)
+// to MaxLevel (smallest); 0 keeps their defaults. It is not safe for
+// concurrent use and must be called before the servers start.
+func Configure(level int) error {
+	if level == 0 {
+		return nil
+	}
+	if level < 1 || level > MaxLevel {
+		return fmt.Errorf("compression level must be between 1 and %d", MaxLevel)
+	}
+	if err := gzip.SetLevel(level); err != nil {
+		return err
+	}
+	encoding.RegisterCompressor(newZstdCompressor(level))
+	return nil
+}
+
+// UnaryServerInterceptor returns a unary interceptor that compresses
+// responses with the named compressor when the client accepts it
+func UnaryServerInterceptor(name string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		setSendCompressor(ctx, name)
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a stream interceptor that compresses
+// responses with the named compressor when the client accepts it
+func StreamServerInterceptor(name string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		setSendCompressor(ss.Context(), name)
+		return handler(srv, ss)
+	}
+}
+
+// setSendCompressor switches the responses of a call to the named
+// compressor. Clients that do not advertise it in grpc-accept-encoding keep
+// getting responses compressed like their requests, or uncompressed.
+func setSendCompressor(ctx context.Context, name string) {
+	supported, err := grpc.ClientSupportedCompressors(ctx)
+	if err != nil {
+		return
+	}
+	for _, s := range supported {
+		if s == name {
+			_ = grpc.SetSendCompressor(ctx, name)
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package compression
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// compressionRecorder records the compression of the responses a client
+// receives
+type compressionRecorder struct {
+	mu          sync.Mutex
+	compression string
+}
+
+func (r *compressionRecorder) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (r *compressionRecorder) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if header, ok := s.(*stats.InHeader); ok {
+		r.mu.Lock()
+		r.compression = header.Compression
+		r.mu.Unlock()
+	}
+}
+
+func (r *compressionRecorder) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (r *compressionRecorder) HandleConn(context.Context, stats.ConnStats) {}
+
+// checkHealth calls a health server compressing responses with the named
+// compressor and returns the compression of the response
+func checkHealth(t *testing.T, name string) string {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(UnaryServerInterceptor(name)))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	recorder := &compressionRecorder{}
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(recorder),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.compression
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Run("compresses responses with the default compressor", func(t *testing.T) {
+		assert.Equal(t, Gzip, checkHealth(t, Gzip))
+		assert.Equal(t, Zstd, checkHealth(t, Zstd))
+	})
+
+	t.Run("leaves responses alone for compressors the client does not accept", func(t *testing.T) {
+		assert.Empty(t, checkHealth(t, "br"))
+	})
+}
+
+func TestZstdCompressor(t *testing.T) {
+	compressor := encoding.GetCompressor(Zstd)
+	require.NotNil(t, compressor)
+	message := bytes.Repeat([]byte(`{"account":"1100","amount":"125.00"}`), 100)
+
+	// Round trips twice so pooled encoders and decoders are reused
+	for i := 0; i < 2; i++ {
+		var compressed bytes.Buffer
+		w, err := compressor.Compress(&compressed)
+		require.NoError(t, err)
+		_, err = w.Write(message)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		assert.Less(t, compressed.Len(), len(message))
+
+		r, err := compressor.Decompress(&compressed)
+		require.NoError(t, err)
+		decompressed, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, message, decompressed)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	assert.NoError(t, Configure(0))
+	assert.Error(t, Configure(-1))
+	assert.Error(t, Configure(MaxLevel+1))
+}
@@ -0,0 +1,88 @@
+package compression
+
+import (
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdCompressor implements the gRPC compressor interface with pooled zstd
+// encoders and decoders
+type zstdCompressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+// newZstdCompressor returns a zstd compressor for a level from 1 to
+// MaxLevel, or the default level for 0
+func newZstdCompressor(level int) *zstdCompressor {
+	opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+	if level > 0 {
+		opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	}
+
+	c := &zstdCompressor{}
+	c.encoders.New = func() any {
+		// The options are fixed, so creating an encoder cannot fail
+		encoder, err := zstd.NewWriter(nil, opts...)
+		if err != nil {
+			panic(err)
+		}
+		return &zstdWriter{Encoder: encoder, pool: &c.encoders}
+	}
+	return c
+}
+
+func (c *zstdCompressor) Name() string {
+	return Zstd
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	z := c.encoders.Get().(*zstdWriter)
+	z.Encoder.Reset(w)
+	return z, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	z, ok := c.decoders.Get().(*zstdReader)
+	if !ok {
+		// A single-threaded decoder decodes synchronously and starts no
+		// goroutines, so pooled decoders need no closing
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return &zstdReader{Decoder: decoder, pool: &c.decoders}, nil
+	}
+	if err := z.Decoder.Reset(r); err != nil {
+		c.decoders.Put(z)
+		return nil, err
+	}
+	return z, nil
+}
+
+// zstdWriter returns its encoder to the pool once a message is compressed
+type zstdWriter struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+func (z *zstdWriter) Close() error {
+	defer z.pool.Put(z)
+	return z.Encoder.Close()
+}
+
+// zstdReader returns its decoder to the pool once a message is read
+type zstdReader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+func (z *zstdReader) Read(p []byte) (int, error) {
+	n, err := z.Decoder.Read(p)
+	if err == io.EOF {
+		z.pool.Put(z)
+	}
+	return n, err
+}
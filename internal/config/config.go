@@ -1,55 +1,1079 @@
 package config
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds all configuration for the ledger service
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
+	Server       ServerConfig       `yaml:"server"`
+	Admin        AdminConfig        `yaml:"admin"`
+	Auth         AuthConfig         `yaml:"auth"`
+	Database     DatabaseConfig     `yaml:"database"`
+	Export       ExportConfig       `yaml:"export"`
+	Metrics      MetricsConfig      `yaml:"metrics"`
+	GraphQL      GraphQLConfig      `yaml:"graphql"`
+	Consistency  ConsistencyConfig  `yaml:"consistency"`
+	Depreciation DepreciationConfig `yaml:"depreciation"`
+	Interest     InterestConfig     `yaml:"interest"`
+	Digest       DigestConfig       `yaml:"digest"`
+	TestTenants  TestTenantsConfig  `yaml:"test_tenants"`
+	Partitions   PartitionsConfig   `yaml:"partitions"`
+	TLS          TLSConfig          `yaml:"tls"`
+	Telemetry    TelemetryConfig    `yaml:"telemetry"`
+	Events       EventsConfig       `yaml:"events"`
+	Cache        CacheConfig        `yaml:"cache"`
+	Limits       LimitsConfig       `yaml:"limits"`
+	Logging      LoggingConfig      `yaml:"logging"`
 }
 
 // ServerConfig holds gRPC server configuration
 type ServerConfig struct {
-	Port int
-	Host string
+	Port int    `yaml:"port"`
+	Host string `yaml:"host"`
+	// MaxRecvMsgSize and MaxSendMsgSize limit message sizes in bytes
+	MaxRecvMsgSize int `yaml:"max_recv_msg_size"`
+	MaxSendMsgSize int `yaml:"max_send_msg_size"`
+	// MaxConcurrentStreams limits the concurrent calls of a connection, 0
+	// leaves it unlimited
+	MaxConcurrentStreams int `yaml:"max_concurrent_streams"`
+	// RequestTimeout bounds the handling of unary calls, 0 disables it
+	RequestTimeout time.Duration     `yaml:"request_timeout"`
+	Keepalive      KeepaliveConfig   `yaml:"keepalive"`
+	Compression    CompressionConfig `yaml:"compression"`
+}
+
+// CompressionConfig holds the response compression of the gRPC servers.
+// Requests compressed with gzip or zstd are accepted either way.
+type CompressionConfig struct {
+	// Default is the compressor ("gzip" or "zstd") used for responses to
+	// clients that accept it; empty compresses responses only like requests
+	Default string `yaml:"default"`
+	// Level is the compression level from 1 (fastest) to 9 (smallest); 0
+	// keeps the compressors' defaults
+	Level int `yaml:"level"`
+}
+
+// KeepaliveConfig holds the keepalive settings of the gRPC servers. Zero
+// durations for the connection limits leave connections open indefinitely.
+type KeepaliveConfig struct {
+	// Time is the idle time after which the server pings a client
+	Time time.Duration `yaml:"time"`
+	// Timeout is how long the server waits for a ping ack before closing
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxConnectionIdle closes connections without calls for this long
+	MaxConnectionIdle time.Duration `yaml:"max_connection_idle"`
+	// MaxConnectionAge closes connections older than this, with
+	// MaxConnectionAgeGrace for calls in flight to finish
+	MaxConnectionAge      time.Duration `yaml:"max_connection_age"`
+	MaxConnectionAgeGrace time.Duration `yaml:"max_connection_age_grace"`
+	// MinTime is the minimum interval clients may ping at; faster clients
+	// are disconnected
+	MinTime time.Duration `yaml:"min_time"`
+	// PermitWithoutStream allows client pings on connections without calls
+	PermitWithoutStream bool `yaml:"permit_without_stream"`
+}
+
+// AdminConfig holds configuration for the privileged admin gRPC server
+type AdminConfig struct {
+	Port      int    `yaml:"port"`
+	Host      string `yaml:"host"`
+	AuthToken string `yaml:"auth_token"`
+	// HTTPAddr is the address the admin HTTP endpoints, authenticated with
+	// the same token, are served on; they are off when empty
+	HTTPAddr string `yaml:"http_addr"`
+}
+
+// Enabled reports whether the admin server should be started
+func (a *AdminConfig) Enabled() bool {
+	return a.AuthToken != ""
+}
+
+// AuthConfig holds the credentials accepted by the tenant API. Calls are
+// not authenticated when neither API keys nor a JWT secret are set.
+type AuthConfig struct {
+	// APIKeys are bearer tokens, the tenant each is bound to and the scopes
+	// each grants
+	APIKeys []APIKeyConfig `yaml:"api_keys"`
+	// JWTSecret verifies HS256-signed bearer tokens whose "tenant_id" claim
+	// names their tenant and whose "scope" claim lists the scopes they grant
+	JWTSecret string `yaml:"jwt_secret"`
+}
+
+// APIKeyConfig is an API key, the tenant it is bound to and the scopes it
+// grants
+type APIKeyConfig struct {
+	Key    string   `yaml:"key"`
+	Tenant string   `yaml:"tenant"`
+	Scopes []string `yaml:"scopes"`
+}
+
+// Enabled reports whether calls to the tenant API must be authenticated
+func (a *AuthConfig) Enabled() bool {
+	return len(a.APIKeys) > 0 || a.JWTSecret != ""
+}
+
+// ExportConfig holds configuration for ledger data export jobs
+type ExportConfig struct {
+	// Dir is the directory export files are written to
+	Dir string `yaml:"dir"`
+}
+
+// Enabled reports whether data exports are available
+func (e *ExportConfig) Enabled() bool {
+	return e.Dir != ""
+}
+
+// MetricsConfig holds configuration for the Prometheus metrics endpoint
+type MetricsConfig struct {
+	// Addr is the address /metrics is served on
+	Addr string `yaml:"addr"`
+}
+
+// Enabled reports whether the metrics endpoint should be started
+func (m *MetricsConfig) Enabled() bool {
+	return m.Addr != ""
+}
+
+// GraphQLConfig holds configuration for the read-only GraphQL endpoint
+type GraphQLConfig struct {
+	// Addr is the address /graphql is served on
+	Addr string `yaml:"addr"`
+	// MaxDepth bounds how deeply the fields of a query may be nested
+	MaxDepth int `yaml:"max_depth"`
+	// MaxCalls bounds the calls to the tenant API one query may make
+	MaxCalls int `yaml:"max_calls"`
+}
+
+// Enabled reports whether the GraphQL endpoint should be started
+func (g *GraphQLConfig) Enabled() bool {
+	return g.Addr != ""
+}
+
+// ConsistencyConfig holds configuration for the background ledger consistency checker
+type ConsistencyConfig struct {
+	// Interval is the time between checks of every tenant
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Enabled reports whether the background consistency checker should run
+func (c *ConsistencyConfig) Enabled() bool {
+	return c.Interval > 0
+}
+
+// DepreciationConfig holds configuration for the background depreciation runner
+type DepreciationConfig struct {
+	// Interval is the time between runs posting the depreciation that fell due
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Enabled reports whether the background depreciation runner should run
+func (d *DepreciationConfig) Enabled() bool {
+	return d.Interval > 0
+}
+
+// InterestConfig holds configuration for the background interest accrual runner
+type InterestConfig struct {
+	// Interval is the time between runs accruing interest through the last
+	// complete day
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Enabled reports whether the background interest accrual runner should run
+func (i *InterestConfig) Enabled() bool {
+	return i.Interval > 0
+}
+
+// DigestConfig holds configuration for the background daily digest runner
+type DigestConfig struct {
+	// Interval is the time between runs computing the digests of completed days
+	Interval time.Duration `yaml:"interval"`
+	// Publish appends each computed digest to the event store
+	Publish bool `yaml:"publish"`
+}
+
+// Enabled reports whether the background digest runner should run
+func (d *DigestConfig) Enabled() bool {
+	return d.Interval > 0
+}
+
+// TestTenantsConfig holds configuration for the background purge of test tenants
+type TestTenantsConfig struct {
+	// Retention is how long a tenant is kept after it was marked as test,
+	// 0 keeps test tenants until they are purged by hand
+	Retention time.Duration `yaml:"retention"`
+	// Interval is the time between runs purging the expired test tenants
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Enabled reports whether the background test tenant purge should run
+func (t *TestTenantsConfig) Enabled() bool {
+	return t.Retention > 0 && t.Interval > 0
+}
+
+// PartitionsConfig holds configuration for the background maintenance of the
+// monthly journal partitions
+type PartitionsConfig struct {
+	// Interval is the time between runs, 0 leaves the partitions to be
+	// maintained by hand
+	Interval time.Duration `yaml:"interval"`
+	// AheadMonths is how many months past the current one are partitioned
+	AheadMonths int `yaml:"ahead_months"`
+	// ArchiveTablespace receives the archived partitions, empty archives none
+	ArchiveTablespace string `yaml:"archive_tablespace"`
+	// ArchiveAfterMonths is how many months after it ends a partition is archived
+	ArchiveAfterMonths int `yaml:"archive_after_months"`
+}
+
+// Enabled reports whether the background partition maintenance should run
+func (p *PartitionsConfig) Enabled() bool {
+	return p.Interval > 0
+}
+
+// TLSConfig holds the certificates the gRPC servers are served with
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile requires and verifies client certificates signed by
+	// these CAs when set
+	ClientCAFile string `yaml:"client_ca_file"`
+}
+
+// Enabled reports whether the servers should be served over TLS
+func (t *TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// TelemetryConfig holds configuration for exporting traces
+type TelemetryConfig struct {
+	// ServiceName identifies the service in exported telemetry
+	ServiceName string `yaml:"service_name"`
+	// TracingEndpoint is the collector traces are exported to
+	TracingEndpoint string `yaml:"tracing_endpoint"`
+	// TracingSampleRatio is the fraction of calls traced
+	TracingSampleRatio float64 `yaml:"tracing_sample_ratio"`
+}
+
+// Enabled reports whether traces should be exported
+func (t *TelemetryConfig) Enabled() bool {
+	return t.TracingEndpoint != ""
+}
+
+// EventsConfig holds configuration for the ledger event store
+type EventsConfig struct {
+	// Enabled exposes the event store through ListLedgerEvents,
+	// WatchAuditEvents and point-in-time balances
+	Enabled bool `yaml:"enabled"`
+	// SigningSecrets sign the published events with HMAC-SHA256, one
+	// signature per secret so secrets can be rotated; events are not signed
+	// without secrets
+	SigningSecrets []string `yaml:"signing_secrets"`
+	// CDC configures change data capture of ledger_events
+	CDC CDCConfig `yaml:"cdc"`
+	// Webhook configures the delivery of events to a webhook
+	Webhook WebhookConfig `yaml:"webhook"`
+}
+
+// CDCConfig holds configuration for change data capture, where Debezium or
+// other logical replication consumers read ledger_events directly
+type CDCConfig struct {
+	// Publication is the logical replication publication that must include
+	// ledger_events; change data capture is off when empty
+	Publication string `yaml:"publication"`
+	// LagInterval is the time between samples of the replication slot lag
+	LagInterval time.Duration `yaml:"lag_interval"`
+}
+
+// Enabled reports whether change data capture is configured
+func (c *CDCConfig) Enabled() bool {
+	return c.Publication != ""
+}
+
+// WebhookConfig holds configuration for delivering ledger events to a webhook
+type WebhookConfig struct {
+	// URL receives every event as a POSTed JSON document; delivery is off
+	// when empty
+	URL string `yaml:"url"`
+	// Interval is the time between delivery runs
+	Interval time.Duration `yaml:"interval"`
+	// Timeout bounds each delivery request
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxAttempts is how many delivery runs try an event before it is
+	// dead-lettered
+	MaxAttempts int `yaml:"max_attempts"`
+}
+
+// Enabled reports whether events are delivered to a webhook
+func (c *WebhookConfig) Enabled() bool {
+	return c.URL != ""
+}
+
+// CacheConfig holds configuration for caching rarely changing reads
+type CacheConfig struct {
+	// ReferenceDataTTL is how long currencies and account types are cached,
+	// 0 disables caching
+	ReferenceDataTTL time.Duration `yaml:"reference_data_ttl"`
+	// MaxEntries bounds the number of cached entries
+	MaxEntries int `yaml:"max_entries"`
+}
+
+// Enabled reports whether reads should be cached
+func (c *CacheConfig) Enabled() bool {
+	return c.ReferenceDataTTL > 0
+}
+
+// LimitsConfig bounds the size of the journal entries the API accepts, so
+// oversized entries fail with a clear error instead of timing out or
+// exceeding the message size limit. A zero limit disables it.
+type LimitsConfig struct {
+	// MaxLinesPerEntry bounds the lines of a journal entry for every tenant,
+	// on top of any per-tenant quota
+	MaxLinesPerEntry int `yaml:"max_lines_per_entry"`
+	// MaxStreamedLinesPerEntry bounds the lines of an entry streamed by
+	// CreateLargeJournalEntry instead
+	MaxStreamedLinesPerEntry int `yaml:"max_streamed_lines_per_entry"`
+	// MaxMetadataBytes bounds the JSON metadata of a journal entry
+	MaxMetadataBytes int `yaml:"max_metadata_bytes"`
+	// MaxDescriptionLength bounds entry and line descriptions, in characters
+	MaxDescriptionLength int `yaml:"max_description_length"`
+}
+
+// LoggingConfig holds what personal data logs may contain
+type LoggingConfig struct {
+	// VerbatimLevel is the lowest level whose log lines carry errors
+	// verbatim; lines below it have descriptions, metadata, party names and
+	// database errors redacted. "none" redacts every line.
+	VerbatimLevel string `yaml:"verbatim_level"`
+}
+
+// Log levels, from the most to the least verbose
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+	LogLevelNone  = "none"
+)
+
+// validate rejects unknown levels
+func (l *LoggingConfig) validate() error {
+	switch l.VerbatimLevel {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError, LogLevelNone:
+		return nil
+	}
+	return fmt.Errorf("unknown verbatim log level %q, expected %s, %s, %s, %s or %s",
+		l.VerbatimLevel, LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError, LogLevelNone)
 }
 
 // DatabaseConfig holds database connection configuration
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
-	MaxConns int
-	MinConns int
+	// Driver is "postgres", or "memory" to keep all data in the process
+	// for trying the API without a database
+	Driver   string `yaml:"driver"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	DBName   string `yaml:"dbname"`
+	SSLMode  string `yaml:"sslmode"`
+	MaxConns int    `yaml:"max_conns"`
+	MinConns int    `yaml:"min_conns"`
+	// ConnectTimeout bounds the retries of the initial connection at startup,
+	// 0 tries once
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	// ConnectBackoff is the wait after the first failed attempt, doubled
+	// after each further failure up to ConnectMaxBackoff
+	ConnectBackoff    time.Duration `yaml:"connect_backoff"`
+	ConnectMaxBackoff time.Duration `yaml:"connect_max_backoff"`
+	// StatementTimeout makes the server cancel statements running longer,
+	// 0 disables it
+	StatementTimeout time.Duration `yaml:"statement_timeout"`
+	// BreakerThreshold is the number of consecutive connection failures
+	// after which calls fail fast for BreakerCooldown before the database
+	// is tried again, 0 disables the circuit breaker
+	BreakerThreshold int           `yaml:"breaker_threshold"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown"`
+	// SlowQueryThreshold logs statements running at least this long with
+	// their tenant and gRPC method, 0 disables the log
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+	// SQLFunctions creates accounts and posts journal entries through the
+	// create_account and create_journal_entry database functions instead of
+	// in Go, for databases that still rely on them
+	SQLFunctions bool `yaml:"sql_functions"`
+	// StatementCache selects how statements are prepared and cached on each
+	// connection
+	StatementCache StatementCacheConfig `yaml:"statement_cache"`
+	// PeriodTotals selects when the monthly account totals behind reports
+	// are brought up to date with the journal
+	PeriodTotals PeriodTotalsConfig `yaml:"period_totals"`
+	// RLSCheck verifies at startup that row-level security isolates the
+	// tenant tables: "off" skips it, "warn" logs each problem and "fail"
+	// also refuses to start
+	RLSCheck string `yaml:"rls_check"`
+	// MetadataEncryption holds the keys that encrypt the journal entry
+	// metadata of tenants that opt in
+	MetadataEncryption MetadataEncryptionConfig `yaml:"metadata_encryption"`
+	// Credentials replaces User and Password with credentials fetched from
+	// a secret store
+	Credentials CredentialsConfig `yaml:"credentials"`
+}
+
+// Statement cache modes, named after the pgx query exec modes
+const (
+	StatementCacheStatement      = "cache_statement"
+	StatementCacheDescribe       = "cache_describe"
+	StatementCacheDescribeExec   = "describe_exec"
+	StatementCacheExec           = "exec"
+	StatementCacheSimpleProtocol = "simple_protocol"
+)
+
+// StatementCacheConfig holds the prepared statement settings of the pool
+type StatementCacheConfig struct {
+	// Mode is "cache_statement" to prepare each statement once per
+	// connection, "cache_describe" to cache only result descriptions,
+	// "describe_exec" or "exec" to cache nothing, or "simple_protocol" for
+	// poolers such as PgBouncer in transaction mode that cannot keep
+	// prepared statements
+	Mode string `yaml:"mode"`
+	// Capacity bounds the statements, or descriptions, cached per connection
+	Capacity int `yaml:"capacity"`
+	// PlanCacheMode sets the server's plan_cache_mode: "auto",
+	// "force_custom_plan" or "force_generic_plan"; empty keeps the server
+	// setting
+	PlanCacheMode string `yaml:"plan_cache_mode"`
+}
+
+// validate rejects unknown modes and caching modes without room to cache
+func (s *StatementCacheConfig) validate() error {
+	switch s.Mode {
+	case StatementCacheStatement, StatementCacheDescribe:
+		if s.Capacity <= 0 {
+			return fmt.Errorf("statement cache mode %s requires a positive capacity", s.Mode)
+		}
+	case StatementCacheDescribeExec, StatementCacheExec, StatementCacheSimpleProtocol:
+	default:
+		return fmt.Errorf("unknown statement cache mode %q", s.Mode)
+	}
+
+	switch s.PlanCacheMode {
+	case "", "auto", "force_custom_plan", "force_generic_plan":
+	default:
+		return fmt.Errorf("unknown plan cache mode %q, expected auto, force_custom_plan or force_generic_plan", s.PlanCacheMode)
+	}
+	return nil
+}
+
+// Metadata keyrings
+const (
+	KeyringNone   = "none"
+	KeyringLocal  = "local"
+	KeyringAWSKMS = "aws-kms"
+)
+
+// MetadataEncryptionConfig holds the root keys the per-tenant metadata keys
+// are derived from
+type MetadataEncryptionConfig struct {
+	// Keyring is "none", "local" for root keys given in Keys, or "aws-kms"
+	// for AWS KMS HMAC keys named in Keys
+	Keyring string `yaml:"keyring"`
+	// Keys maps key IDs to a base64-encoded 32-byte root key for the local
+	// keyring, or to a KMS key ID, ARN or alias
+	Keys map[string]string `yaml:"keys"`
+	// ActiveKey is the ID of the key new metadata is encrypted with; the
+	// other keys only decrypt, so root keys can be rotated
+	ActiveKey string `yaml:"active_key"`
+	// AWSRegion is the region of the KMS keys
+	AWSRegion string `yaml:"aws_region"`
+}
+
+// Enabled reports whether a keyring is configured
+func (m *MetadataEncryptionConfig) Enabled() bool {
+	return m.Keyring != KeyringNone
+}
+
+// validate rejects unknown keyrings, an active key that is not configured
+// and local root keys that are not 32 bytes
+func (m *MetadataEncryptionConfig) validate() error {
+	switch m.Keyring {
+	case KeyringNone:
+		return nil
+	case KeyringLocal:
+		for id, key := range m.Keys {
+			decoded, err := base64.StdEncoding.DecodeString(key)
+			if err != nil || len(decoded) != 32 {
+				return fmt.Errorf("metadata key %q must be 32 bytes encoded in base64", id)
+			}
+		}
+	case KeyringAWSKMS:
+		if m.AWSRegion == "" {
+			return fmt.Errorf("aws kms metadata keyring requires a region")
+		}
+	default:
+		return fmt.Errorf("unknown metadata keyring %q, expected %s, %s or %s", m.Keyring, KeyringNone, KeyringLocal, KeyringAWSKMS)
+	}
+	if _, ok := m.Keys[m.ActiveKey]; !ok {
+		return fmt.Errorf("active metadata key %q is not one of the configured keys", m.ActiveKey)
+	}
+	return nil
+}
+
+// Database drivers
+const (
+	DatabaseDriverPostgres = "postgres"
+	DatabaseDriverMemory   = "memory"
+)
+
+// Row-level security startup check modes
+const (
+	RLSCheckOff  = "off"
+	RLSCheckWarn = "warn"
+	RLSCheckFail = "fail"
+)
+
+// Period totals refresh strategies
+const (
+	PeriodTotalsOnPost    = "on_post"
+	PeriodTotalsScheduled = "scheduled"
+)
+
+// PeriodTotalsConfig holds the refresh strategy of the period totals
+type PeriodTotalsConfig struct {
+	// Refresh is "on_post" to add every entry to the totals in its posting
+	// transaction, or "scheduled" to queue posted entries and add them in
+	// the background every Interval, keeping postings to busy accounts from
+	// contending on the same totals rows
+	Refresh  string        `yaml:"refresh"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Scheduled reports whether the totals are refreshed in the background
+func (p *PeriodTotalsConfig) Scheduled() bool {
+	return p.Refresh == PeriodTotalsScheduled
+}
+
+// validate rejects unknown strategies and schedules without an interval
+func (p *PeriodTotalsConfig) validate() error {
+	switch p.Refresh {
+	case PeriodTotalsOnPost:
+	case PeriodTotalsScheduled:
+		if p.Interval <= 0 {
+			return fmt.Errorf("scheduled period totals refresh requires a positive interval")
+		}
+	default:
+		return fmt.Errorf("unknown period totals refresh %q, expected %s or %s", p.Refresh, PeriodTotalsOnPost, PeriodTotalsScheduled)
+	}
+	return nil
+}
+
+// Database credential sources
+const (
+	CredentialsStatic            = "static"
+	CredentialsVault             = "vault"
+	CredentialsAWSSecretsManager = "aws-secrets-manager"
+	CredentialsAWSRDSIAM         = "aws-rds-iam"
+	CredentialsGCPCloudSQLIAM    = "gcp-cloudsql-iam"
+)
+
+// CredentialsConfig selects where database credentials come from
+type CredentialsConfig struct {
+	// Source is "static" for User and Password, "vault" or
+	// "aws-secrets-manager" for a stored secret, or "aws-rds-iam" or
+	// "gcp-cloudsql-iam" for IAM authentication tokens issued to User
+	Source string `yaml:"source"`
+	// RefreshInterval is how often credentials are fetched again to pick up
+	// rotations, 0 fetches them once at startup. IAM tokens are also
+	// renewed before they expire whenever a connection is opened.
+	RefreshInterval time.Duration   `yaml:"refresh_interval"`
+	Vault           VaultConfig     `yaml:"vault"`
+	AWS             AWSSecretConfig `yaml:"aws"`
+}
+
+// VaultConfig locates database credentials in HashiCorp Vault
+type VaultConfig struct {
+	Addr  string `yaml:"addr"`
+	Token string `yaml:"token"`
+	// Path is read with the HTTP API, e.g. "secret/data/ledger/db" for a
+	// KV v2 secret or "database/creds/ledger" for dynamic credentials
+	Path string `yaml:"path"`
+}
+
+// AWSSecretConfig locates database credentials in AWS Secrets Manager, or
+// the region of an RDS database for IAM authentication
+type AWSSecretConfig struct {
+	Region   string `yaml:"region"`
+	SecretID string `yaml:"secret_id"`
+}
+
+// validate rejects credential settings missing what their source needs
+func (c *CredentialsConfig) validate() error {
+	switch c.Source {
+	case CredentialsStatic:
+	case CredentialsVault:
+		if c.Vault.Addr == "" || c.Vault.Path == "" {
+			return fmt.Errorf("vault credentials require an address and a path")
+		}
+	case CredentialsAWSSecretsManager:
+		if c.AWS.Region == "" || c.AWS.SecretID == "" {
+			return fmt.Errorf("aws secrets manager credentials require a region and a secret ID")
+		}
+	case CredentialsAWSRDSIAM:
+		if c.AWS.Region == "" {
+			return fmt.Errorf("rds iam authentication requires a region")
+		}
+	case CredentialsGCPCloudSQLIAM:
+	default:
+		return fmt.Errorf("unknown database credentials source %q", c.Source)
+	}
+	if c.RefreshInterval < 0 {
+		return fmt.Errorf("credentials refresh interval must not be negative")
+	}
+	return nil
 }
 
-// Load loads configuration from environment variables with defaults
+// ConfigFileEnv names the environment variable holding the path of the
+// configuration file
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Load loads configuration from the file named by CONFIG_FILE, if any,
+// overridden by environment variables
 func Load() (*Config, error) {
-	cfg := &Config{
+	return LoadFile(os.Getenv(ConfigFileEnv))
+}
+
+// LoadFile loads configuration in layers: defaults, then the YAML file at
+// path when path is not empty, then any environment variables that are set
+func LoadFile(path string) (*Config, error) {
+	cfg := defaults()
+
+	if path != "" {
+		if err := cfg.loadYAML(path); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.applyEnv()
+
+	if err := cfg.Server.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Database.Credentials.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Database.StatementCache.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Database.PeriodTotals.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Database.MetadataEncryption.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Logging.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Database.ConnectTimeout < 0 {
+		return nil, fmt.Errorf("database connect timeout must not be negative")
+	}
+	if cfg.Database.ConnectBackoff <= 0 || cfg.Database.ConnectMaxBackoff < cfg.Database.ConnectBackoff {
+		return nil, fmt.Errorf("database connect backoff must be positive and not exceed the max backoff")
+	}
+	if cfg.Database.StatementTimeout < 0 {
+		return nil, fmt.Errorf("database statement timeout must not be negative")
+	}
+	if cfg.Events.CDC.Enabled() && cfg.Events.CDC.LagInterval <= 0 {
+		return nil, fmt.Errorf("change data capture requires a positive lag interval")
+	}
+	if cfg.Events.Webhook.Enabled() {
+		if !cfg.Events.Enabled {
+			return nil, fmt.Errorf("webhook delivery requires the event store")
+		}
+		if cfg.Events.Webhook.Interval <= 0 || cfg.Events.Webhook.Timeout <= 0 || cfg.Events.Webhook.MaxAttempts < 1 {
+			return nil, fmt.Errorf("webhook delivery requires a positive interval, timeout and max attempts")
+		}
+	}
+	if cfg.GraphQL.Enabled() && (cfg.GraphQL.MaxDepth <= 0 || cfg.GraphQL.MaxCalls <= 0) {
+		return nil, fmt.Errorf("the GraphQL endpoint requires a positive max depth and max calls")
+	}
+	if cfg.TestTenants.Retention < 0 {
+		return nil, fmt.Errorf("test tenant retention must not be negative")
+	}
+	if cfg.Partitions.AheadMonths < 0 || cfg.Partitions.ArchiveAfterMonths < 1 {
+		return nil, fmt.Errorf("journal partition ahead months must not be negative and archive after months must be positive")
+	}
+	if cfg.Database.SlowQueryThreshold < 0 {
+		return nil, fmt.Errorf("database slow query threshold must not be negative")
+	}
+	if cfg.Database.BreakerThreshold < 0 || (cfg.Database.BreakerThreshold > 0 && cfg.Database.BreakerCooldown <= 0) {
+		return nil, fmt.Errorf("database breaker threshold must not be negative and its cooldown must be positive")
+	}
+	switch cfg.Database.Driver {
+	case DatabaseDriverPostgres, DatabaseDriverMemory:
+	default:
+		return nil, fmt.Errorf("unknown database driver %q, expected %s or %s", cfg.Database.Driver, DatabaseDriverPostgres, DatabaseDriverMemory)
+	}
+	switch cfg.Database.RLSCheck {
+	case RLSCheckOff, RLSCheckWarn, RLSCheckFail:
+	default:
+		return nil, fmt.Errorf("unknown row level security check %q, expected %s, %s or %s", cfg.Database.RLSCheck, RLSCheckOff, RLSCheckWarn, RLSCheckFail)
+	}
+	if cfg.Database.usesIAM() && cfg.Database.SSLMode == "disable" {
+		return nil, fmt.Errorf("IAM database authentication requires TLS, set an SSL mode other than disable")
+	}
+	for _, k := range cfg.Auth.APIKeys {
+		if k.Key == "" || len(k.Scopes) == 0 {
+			return nil, fmt.Errorf("API keys require a key and at least one scope")
+		}
+		if _, err := uuid.Parse(k.Tenant); err != nil {
+			return nil, fmt.Errorf("API keys must be bound to a tenant ID")
+		}
+	}
+	if cfg.TLS.Enabled() && (cfg.TLS.CertFile == "" || cfg.TLS.KeyFile == "") {
+		return nil, fmt.Errorf("TLS requires both a certificate and a key file")
+	}
+	if r := cfg.Telemetry.TracingSampleRatio; r < 0 || r > 1 {
+		return nil, fmt.Errorf("tracing sample ratio must be between 0 and 1")
+	}
+	if cfg.Cache.ReferenceDataTTL < 0 || (cfg.Cache.Enabled() && cfg.Cache.MaxEntries < 1) {
+		return nil, fmt.Errorf("cache requires a non-negative TTL and, when enabled, a positive max entries")
+	}
+	if cfg.Telemetry.Enabled() {
+		u, err := url.Parse(cfg.Telemetry.TracingEndpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("tracing endpoint must be an http or https URL of an OTLP/gRPC collector")
+		}
+	}
+	if l := cfg.Limits; l.MaxLinesPerEntry < 0 || l.MaxStreamedLinesPerEntry < 0 || l.MaxMetadataBytes < 0 || l.MaxDescriptionLength < 0 {
+		return nil, fmt.Errorf("journal entry limits must not be negative")
+	}
+
+	return cfg, nil
+}
+
+// defaults returns the configuration used when neither the file nor the
+// environment set a value
+func defaults() *Config {
+	return &Config{
 		Server: ServerConfig{
-			Port: getEnvAsInt("SERVER_PORT", 9090),
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
+			Port:           9090,
+			Host:           "0.0.0.0",
+			MaxRecvMsgSize: 10 * 1024 * 1024,
+			MaxSendMsgSize: 10 * 1024 * 1024,
+			Keepalive: KeepaliveConfig{
+				Time:    2 * time.Hour,
+				Timeout: 20 * time.Second,
+				MinTime: 5 * time.Minute,
+			},
+		},
+		Admin: AdminConfig{
+			Port: 9091,
+			Host: "127.0.0.1",
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnvAsInt("DB_PORT", 5432),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			DBName:   getEnv("DB_NAME", "ledger"),
-			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
-			MaxConns: getEnvAsInt("DB_MAX_CONNS", 25),
-			MinConns: getEnvAsInt("DB_MIN_CONNS", 5),
+			Driver:             DatabaseDriverPostgres,
+			Host:               "localhost",
+			Port:               5432,
+			User:               "postgres",
+			Password:           "postgres",
+			DBName:             "ledger",
+			SSLMode:            "disable",
+			MaxConns:           25,
+			MinConns:           5,
+			ConnectTimeout:     time.Minute,
+			ConnectBackoff:     500 * time.Millisecond,
+			ConnectMaxBackoff:  10 * time.Second,
+			StatementTimeout:   30 * time.Second,
+			BreakerThreshold:   5,
+			BreakerCooldown:    10 * time.Second,
+			SlowQueryThreshold: 500 * time.Millisecond,
+			StatementCache: StatementCacheConfig{
+				Mode:     StatementCacheStatement,
+				Capacity: 512,
+			},
+			PeriodTotals: PeriodTotalsConfig{
+				Refresh:  PeriodTotalsOnPost,
+				Interval: time.Minute,
+			},
+			RLSCheck: RLSCheckWarn,
+			MetadataEncryption: MetadataEncryptionConfig{
+				Keyring: KeyringNone,
+			},
+			Credentials: CredentialsConfig{
+				Source:          CredentialsStatic,
+				RefreshInterval: 5 * time.Minute,
+			},
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth: 10,
+			MaxCalls: 200,
+		},
+		Consistency: ConsistencyConfig{
+			Interval: time.Hour,
+		},
+		Depreciation: DepreciationConfig{
+			Interval: 24 * time.Hour,
+		},
+		Interest: InterestConfig{
+			Interval: 24 * time.Hour,
+		},
+		Digest: DigestConfig{
+			Interval: time.Hour,
+		},
+		TestTenants: TestTenantsConfig{
+			Interval: time.Hour,
+		},
+		Partitions: PartitionsConfig{
+			Interval:           24 * time.Hour,
+			AheadMonths:        3,
+			ArchiveAfterMonths: 24,
+		},
+		Telemetry: TelemetryConfig{
+			ServiceName:        "ledger",
+			TracingSampleRatio: 1,
+		},
+		Events: EventsConfig{
+			Enabled: true,
+			CDC: CDCConfig{
+				LagInterval: 30 * time.Second,
+			},
+			Webhook: WebhookConfig{
+				Interval:    10 * time.Second,
+				Timeout:     10 * time.Second,
+				MaxAttempts: 5,
+			},
+		},
+		Cache: CacheConfig{
+			MaxEntries: 1000,
+		},
+		Limits: LimitsConfig{
+			MaxLinesPerEntry:         10000,
+			MaxStreamedLinesPerEntry: 200000,
+			MaxMetadataBytes:         64 * 1024,
+			MaxDescriptionLength:     1000,
+		},
+		Logging: LoggingConfig{
+			VerbatimLevel: LogLevelNone,
 		},
 	}
+}
 
-	return cfg, nil
+// loadYAML overlays the settings of a YAML file. Unknown keys are rejected
+// so typos do not silently fall back to defaults.
+func (c *Config) loadYAML(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// applyEnv overrides settings with the environment variables that are set
+func (c *Config) applyEnv() {
+	s := &c.Server
+	s.Port = getEnvAsInt("SERVER_PORT", s.Port)
+	s.Host = getEnv("SERVER_HOST", s.Host)
+	s.MaxRecvMsgSize = getEnvAsInt("SERVER_MAX_RECV_MSG_SIZE", s.MaxRecvMsgSize)
+	s.MaxSendMsgSize = getEnvAsInt("SERVER_MAX_SEND_MSG_SIZE", s.MaxSendMsgSize)
+	s.MaxConcurrentStreams = getEnvAsInt("SERVER_MAX_CONCURRENT_STREAMS", s.MaxConcurrentStreams)
+	s.RequestTimeout = getEnvAsDuration("SERVER_REQUEST_TIMEOUT", s.RequestTimeout)
+	s.Compression.Default = getEnv("SERVER_COMPRESSION", s.Compression.Default)
+	s.Compression.Level = getEnvAsInt("SERVER_COMPRESSION_LEVEL", s.Compression.Level)
+	s.Keepalive.Time = getEnvAsDuration("SERVER_KEEPALIVE_TIME", s.Keepalive.Time)
+	s.Keepalive.Timeout = getEnvAsDuration("SERVER_KEEPALIVE_TIMEOUT", s.Keepalive.Timeout)
+	s.Keepalive.MaxConnectionIdle = getEnvAsDuration("SERVER_MAX_CONNECTION_IDLE", s.Keepalive.MaxConnectionIdle)
+	s.Keepalive.MaxConnectionAge = getEnvAsDuration("SERVER_MAX_CONNECTION_AGE", s.Keepalive.MaxConnectionAge)
+	s.Keepalive.MaxConnectionAgeGrace = getEnvAsDuration("SERVER_MAX_CONNECTION_AGE_GRACE", s.Keepalive.MaxConnectionAgeGrace)
+	s.Keepalive.MinTime = getEnvAsDuration("SERVER_KEEPALIVE_MIN_TIME", s.Keepalive.MinTime)
+	s.Keepalive.PermitWithoutStream = getEnvAsBool("SERVER_KEEPALIVE_PERMIT_WITHOUT_STREAM", s.Keepalive.PermitWithoutStream)
+
+	c.Admin.Port = getEnvAsInt("ADMIN_SERVER_PORT", c.Admin.Port)
+	c.Admin.Host = getEnv("ADMIN_SERVER_HOST", c.Admin.Host)
+	c.Admin.AuthToken = getEnv("ADMIN_AUTH_TOKEN", c.Admin.AuthToken)
+	c.Admin.HTTPAddr = getEnv("ADMIN_HTTP_ADDR", c.Admin.HTTPAddr)
+
+	c.Auth.JWTSecret = getEnv("AUTH_JWT_SECRET", c.Auth.JWTSecret)
+	if value := os.Getenv("AUTH_API_KEYS"); value != "" {
+		c.Auth.APIKeys = parseAPIKeys(value)
+	}
+
+	d := &c.Database
+	d.Driver = getEnv("DB_DRIVER", d.Driver)
+	d.Host = getEnv("DB_HOST", d.Host)
+	d.Port = getEnvAsInt("DB_PORT", d.Port)
+	d.User = getEnv("DB_USER", d.User)
+	d.Password = getEnv("DB_PASSWORD", d.Password)
+	d.DBName = getEnv("DB_NAME", d.DBName)
+	d.SSLMode = getEnv("DB_SSL_MODE", d.SSLMode)
+	d.MaxConns = getEnvAsInt("DB_MAX_CONNS", d.MaxConns)
+	d.MinConns = getEnvAsInt("DB_MIN_CONNS", d.MinConns)
+	d.ConnectTimeout = getEnvAsDuration("DB_CONNECT_TIMEOUT", d.ConnectTimeout)
+	d.ConnectBackoff = getEnvAsDuration("DB_CONNECT_BACKOFF", d.ConnectBackoff)
+	d.ConnectMaxBackoff = getEnvAsDuration("DB_CONNECT_MAX_BACKOFF", d.ConnectMaxBackoff)
+	d.StatementTimeout = getEnvAsDuration("DB_STATEMENT_TIMEOUT", d.StatementTimeout)
+	d.BreakerThreshold = getEnvAsInt("DB_BREAKER_THRESHOLD", d.BreakerThreshold)
+	d.BreakerCooldown = getEnvAsDuration("DB_BREAKER_COOLDOWN", d.BreakerCooldown)
+	d.SQLFunctions = getEnvAsBool("DB_SQL_FUNCTIONS", d.SQLFunctions)
+	d.SlowQueryThreshold = getEnvAsDuration("DB_SLOW_QUERY_THRESHOLD", d.SlowQueryThreshold)
+	d.StatementCache.Mode = getEnv("DB_STATEMENT_CACHE_MODE", d.StatementCache.Mode)
+	d.StatementCache.Capacity = getEnvAsInt("DB_STATEMENT_CACHE_CAPACITY", d.StatementCache.Capacity)
+	d.StatementCache.PlanCacheMode = getEnv("DB_PLAN_CACHE_MODE", d.StatementCache.PlanCacheMode)
+	d.PeriodTotals.Refresh = getEnv("DB_PERIOD_TOTALS_REFRESH", d.PeriodTotals.Refresh)
+	d.PeriodTotals.Interval = getEnvAsDuration("DB_PERIOD_TOTALS_INTERVAL", d.PeriodTotals.Interval)
+	d.RLSCheck = getEnv("DB_RLS_CHECK", d.RLSCheck)
+	d.MetadataEncryption.Keyring = getEnv("DB_METADATA_KEYRING", d.MetadataEncryption.Keyring)
+	d.MetadataEncryption.ActiveKey = getEnv("DB_METADATA_ACTIVE_KEY", d.MetadataEncryption.ActiveKey)
+	d.MetadataEncryption.AWSRegion = getEnv("AWS_REGION", d.MetadataEncryption.AWSRegion)
+	if value := os.Getenv("DB_METADATA_KEYS"); value != "" {
+		d.MetadataEncryption.Keys = parseKeyMap(value)
+	}
+	d.Credentials.Source = getEnv("DB_CREDENTIALS_SOURCE", d.Credentials.Source)
+	d.Credentials.RefreshInterval = getEnvAsDuration("DB_CREDENTIALS_REFRESH_INTERVAL", d.Credentials.RefreshInterval)
+	d.Credentials.Vault.Addr = getEnv("VAULT_ADDR", d.Credentials.Vault.Addr)
+	d.Credentials.Vault.Token = getEnv("VAULT_TOKEN", d.Credentials.Vault.Token)
+	d.Credentials.Vault.Path = getEnv("DB_VAULT_PATH", d.Credentials.Vault.Path)
+	d.Credentials.AWS.Region = getEnv("AWS_REGION", d.Credentials.AWS.Region)
+	d.Credentials.AWS.SecretID = getEnv("DB_AWS_SECRET_ID", d.Credentials.AWS.SecretID)
+
+	c.Export.Dir = getEnv("EXPORT_DIR", c.Export.Dir)
+	c.Metrics.Addr = getEnv("METRICS_ADDR", c.Metrics.Addr)
+	c.GraphQL.Addr = getEnv("GRAPHQL_ADDR", c.GraphQL.Addr)
+	c.GraphQL.MaxDepth = getEnvAsInt("GRAPHQL_MAX_DEPTH", c.GraphQL.MaxDepth)
+	c.GraphQL.MaxCalls = getEnvAsInt("GRAPHQL_MAX_CALLS", c.GraphQL.MaxCalls)
+	c.Consistency.Interval = getEnvAsDuration("CONSISTENCY_CHECK_INTERVAL", c.Consistency.Interval)
+	c.Depreciation.Interval = getEnvAsDuration("DEPRECIATION_INTERVAL", c.Depreciation.Interval)
+	c.Interest.Interval = getEnvAsDuration("INTEREST_ACCRUAL_INTERVAL", c.Interest.Interval)
+	c.Digest.Interval = getEnvAsDuration("DIGEST_INTERVAL", c.Digest.Interval)
+	c.Digest.Publish = getEnvAsBool("DIGEST_PUBLISH", c.Digest.Publish)
+	c.TestTenants.Retention = getEnvAsDuration("TEST_TENANT_RETENTION", c.TestTenants.Retention)
+	c.TestTenants.Interval = getEnvAsDuration("TEST_TENANT_PURGE_INTERVAL", c.TestTenants.Interval)
+	c.Partitions.Interval = getEnvAsDuration("JOURNAL_PARTITION_INTERVAL", c.Partitions.Interval)
+	c.Partitions.AheadMonths = getEnvAsInt("JOURNAL_PARTITION_AHEAD_MONTHS", c.Partitions.AheadMonths)
+	c.Partitions.ArchiveTablespace = getEnv("JOURNAL_ARCHIVE_TABLESPACE", c.Partitions.ArchiveTablespace)
+	c.Partitions.ArchiveAfterMonths = getEnvAsInt("JOURNAL_ARCHIVE_AFTER_MONTHS", c.Partitions.ArchiveAfterMonths)
+
+	c.TLS.CertFile = getEnv("TLS_CERT_FILE", c.TLS.CertFile)
+	c.TLS.KeyFile = getEnv("TLS_KEY_FILE", c.TLS.KeyFile)
+	c.TLS.ClientCAFile = getEnv("TLS_CLIENT_CA_FILE", c.TLS.ClientCAFile)
+
+	c.Telemetry.ServiceName = getEnv("TELEMETRY_SERVICE_NAME", c.Telemetry.ServiceName)
+	c.Telemetry.TracingEndpoint = getEnv("TELEMETRY_TRACING_ENDPOINT", c.Telemetry.TracingEndpoint)
+	c.Telemetry.TracingSampleRatio = getEnvAsFloat("TELEMETRY_TRACING_SAMPLE_RATIO", c.Telemetry.TracingSampleRatio)
+
+	c.Events.Enabled = getEnvAsBool("EVENTS_ENABLED", c.Events.Enabled)
+	if value := os.Getenv("EVENTS_SIGNING_SECRETS"); value != "" {
+		c.Events.SigningSecrets = parseList(value)
+	}
+	c.Events.CDC.Publication = getEnv("EVENTS_CDC_PUBLICATION", c.Events.CDC.Publication)
+	c.Events.CDC.LagInterval = getEnvAsDuration("EVENTS_CDC_LAG_INTERVAL", c.Events.CDC.LagInterval)
+	c.Events.Webhook.URL = getEnv("EVENTS_WEBHOOK_URL", c.Events.Webhook.URL)
+	c.Events.Webhook.Interval = getEnvAsDuration("EVENTS_WEBHOOK_INTERVAL", c.Events.Webhook.Interval)
+	c.Events.Webhook.Timeout = getEnvAsDuration("EVENTS_WEBHOOK_TIMEOUT", c.Events.Webhook.Timeout)
+	c.Events.Webhook.MaxAttempts = getEnvAsInt("EVENTS_WEBHOOK_MAX_ATTEMPTS", c.Events.Webhook.MaxAttempts)
+
+	c.Cache.ReferenceDataTTL = getEnvAsDuration("CACHE_REFERENCE_DATA_TTL", c.Cache.ReferenceDataTTL)
+	c.Cache.MaxEntries = getEnvAsInt("CACHE_MAX_ENTRIES", c.Cache.MaxEntries)
+
+	l := &c.Limits
+	l.MaxLinesPerEntry = getEnvAsInt("LIMITS_MAX_LINES_PER_ENTRY", l.MaxLinesPerEntry)
+	l.MaxStreamedLinesPerEntry = getEnvAsInt("LIMITS_MAX_STREAMED_LINES_PER_ENTRY", l.MaxStreamedLinesPerEntry)
+	l.MaxMetadataBytes = getEnvAsInt("LIMITS_MAX_METADATA_BYTES", l.MaxMetadataBytes)
+	l.MaxDescriptionLength = getEnvAsInt("LIMITS_MAX_DESCRIPTION_LENGTH", l.MaxDescriptionLength)
+
+	c.Logging.VerbatimLevel = getEnv("LOG_VERBATIM_LEVEL", c.Logging.VerbatimLevel)
+}
+
+// validate rejects server settings gRPC cannot use
+func (s *ServerConfig) validate() error {
+	if s.MaxRecvMsgSize <= 0 || s.MaxSendMsgSize <= 0 {
+		return fmt.Errorf("message size limits must be positive")
+	}
+	if s.MaxConcurrentStreams < 0 || int64(s.MaxConcurrentStreams) > math.MaxUint32 {
+		return fmt.Errorf("max concurrent streams must be between 0 and %d", uint32(math.MaxUint32))
+	}
+	if s.RequestTimeout < 0 {
+		return fmt.Errorf("request timeout must not be negative")
+	}
+	switch s.Compression.Default {
+	case "", "gzip", "zstd":
+	default:
+		return fmt.Errorf("unknown compressor %q, expected gzip or zstd", s.Compression.Default)
+	}
+	if s.Compression.Level < 0 || s.Compression.Level > 9 {
+		return fmt.Errorf("compression level must be between 0 and 9")
+	}
+	return nil
+}
+
+// parseAPIKeys parses API keys bound to tenants given as
+// "key@tenant=scope,scope;key@tenant=scope"
+func parseAPIKeys(value string) []APIKeyConfig {
+	var keys []APIKeyConfig
+	for _, entry := range strings.Split(value, ";") {
+		key, scopes, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		if key == "" {
+			continue
+		}
+		k := APIKeyConfig{Key: key}
+		if i := strings.LastIndex(key, "@"); i >= 0 {
+			k.Key, k.Tenant = key[:i], key[i+1:]
+		}
+		for _, scope := range strings.Split(scopes, ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				k.Scopes = append(k.Scopes, scope)
+			}
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// parseKeyMap parses keys given as "id=key,id=key"; keys may contain "=" as
+// base64 padding
+func parseKeyMap(value string) map[string]string {
+	keys := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		id, key, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		if id == "" {
+			continue
+		}
+		keys[id] = key
+	}
+	return keys
+}
+
+// parseList parses values given as "value,value", dropping empty ones
+func parseList(value string) []string {
+	var values []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			values = append(values, entry)
+		}
+	}
+	return values
+}
+
+// usesIAM reports whether the database password is an IAM token
+func (d *DatabaseConfig) usesIAM() bool {
+	return d.Credentials.Source == CredentialsAWSRDSIAM || d.Credentials.Source == CredentialsGCPCloudSQLIAM
 }
 
 // ConnectionString returns the PostgreSQL connection string
@@ -82,3 +1106,48 @@ func getEnvAsInt(key string, defaultValue int) int {
 
 	return value
 }
+
+// getEnvAsDuration retrieves an environment variable as a duration or returns a default value
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvAsBool retrieves an environment variable as a boolean or returns a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvAsFloat retrieves an environment variable as a float or returns a default value
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,11 +18,60 @@ func TestLoad(t *testing.T) {
 
 		assert.Equal(t, 9090, cfg.Server.Port)
 		assert.Equal(t, "0.0.0.0", cfg.Server.Host)
+		assert.Equal(t, 9091, cfg.Admin.Port)
+		assert.Equal(t, "127.0.0.1", cfg.Admin.Host)
+		assert.False(t, cfg.Admin.Enabled())
+		assert.False(t, cfg.Auth.Enabled())
 		assert.Equal(t, "localhost", cfg.Database.Host)
 		assert.Equal(t, 5432, cfg.Database.Port)
 		assert.Equal(t, "postgres", cfg.Database.User)
 		assert.Equal(t, "ledger", cfg.Database.DBName)
 		assert.Equal(t, "disable", cfg.Database.SSLMode)
+		assert.Equal(t, CredentialsStatic, cfg.Database.Credentials.Source)
+		assert.False(t, cfg.Export.Enabled())
+		assert.False(t, cfg.Metrics.Enabled())
+		assert.False(t, cfg.GraphQL.Enabled())
+		assert.Equal(t, time.Hour, cfg.Consistency.Interval)
+		assert.True(t, cfg.Consistency.Enabled())
+		assert.Equal(t, 24*time.Hour, cfg.Depreciation.Interval)
+		assert.True(t, cfg.Depreciation.Enabled())
+		assert.Equal(t, 24*time.Hour, cfg.Interest.Interval)
+		assert.True(t, cfg.Interest.Enabled())
+		assert.Equal(t, time.Hour, cfg.Digest.Interval)
+		assert.False(t, cfg.Digest.Publish)
+		assert.Equal(t, time.Hour, cfg.TestTenants.Interval)
+		assert.False(t, cfg.TestTenants.Enabled())
+		assert.True(t, cfg.Partitions.Enabled())
+		assert.Equal(t, 3, cfg.Partitions.AheadMonths)
+		assert.Empty(t, cfg.Partitions.ArchiveTablespace)
+		assert.Equal(t, 24, cfg.Partitions.ArchiveAfterMonths)
+		assert.Equal(t, 10*1024*1024, cfg.Server.MaxRecvMsgSize)
+		assert.Equal(t, 10*1024*1024, cfg.Server.MaxSendMsgSize)
+		assert.Zero(t, cfg.Server.MaxConcurrentStreams)
+		assert.Zero(t, cfg.Server.RequestTimeout)
+		assert.Empty(t, cfg.Server.Compression.Default)
+		assert.Equal(t, 30*time.Second, cfg.Database.StatementTimeout)
+		assert.Equal(t, 5, cfg.Database.BreakerThreshold)
+		assert.Equal(t, 10*time.Second, cfg.Database.BreakerCooldown)
+		assert.False(t, cfg.Database.SQLFunctions)
+		assert.Equal(t, StatementCacheStatement, cfg.Database.StatementCache.Mode)
+		assert.Equal(t, 512, cfg.Database.StatementCache.Capacity)
+		assert.Empty(t, cfg.Database.StatementCache.PlanCacheMode)
+		assert.Equal(t, PeriodTotalsOnPost, cfg.Database.PeriodTotals.Refresh)
+		assert.False(t, cfg.Database.PeriodTotals.Scheduled())
+		assert.Equal(t, RLSCheckWarn, cfg.Database.RLSCheck)
+		assert.Equal(t, DatabaseDriverPostgres, cfg.Database.Driver)
+		assert.False(t, cfg.Database.MetadataEncryption.Enabled())
+		assert.Equal(t, 500*time.Millisecond, cfg.Database.SlowQueryThreshold)
+		assert.Equal(t, 2*time.Hour, cfg.Server.Keepalive.Time)
+		assert.Equal(t, 20*time.Second, cfg.Server.Keepalive.Timeout)
+		assert.Equal(t, 5*time.Minute, cfg.Server.Keepalive.MinTime)
+		assert.False(t, cfg.Server.Keepalive.PermitWithoutStream)
+		assert.Equal(t, 10000, cfg.Limits.MaxLinesPerEntry)
+		assert.Equal(t, 200000, cfg.Limits.MaxStreamedLinesPerEntry)
+		assert.Equal(t, 64*1024, cfg.Limits.MaxMetadataBytes)
+		assert.Equal(t, 1000, cfg.Limits.MaxDescriptionLength)
+		assert.Equal(t, LogLevelNone, cfg.Logging.VerbatimLevel)
 	})
 
 	t.Run("loads configuration from environment variables", func(t *testing.T) {
@@ -28,7 +79,27 @@ func TestLoad(t *testing.T) {
 		os.Setenv("DB_HOST", "testhost")
 		os.Setenv("DB_PORT", "5433")
 		os.Setenv("DB_NAME", "testdb")
+		os.Setenv("ADMIN_SERVER_PORT", "9191")
+		os.Setenv("ADMIN_AUTH_TOKEN", "secret")
+		os.Setenv("METRICS_ADDR", ":9100")
+		os.Setenv("CONSISTENCY_CHECK_INTERVAL", "0")
+		os.Setenv("DEPRECIATION_INTERVAL", "6h")
+		os.Setenv("INTEREST_ACCRUAL_INTERVAL", "0")
+		os.Setenv("DIGEST_PUBLISH", "true")
+		os.Setenv("TEST_TENANT_RETENTION", "720h")
+		os.Setenv("JOURNAL_ARCHIVE_TABLESPACE", "archive")
+		os.Setenv("JOURNAL_ARCHIVE_AFTER_MONTHS", "36")
 		defer func() {
+			os.Unsetenv("JOURNAL_ARCHIVE_AFTER_MONTHS")
+			os.Unsetenv("JOURNAL_ARCHIVE_TABLESPACE")
+			os.Unsetenv("TEST_TENANT_RETENTION")
+			os.Unsetenv("DIGEST_PUBLISH")
+			os.Unsetenv("INTEREST_ACCRUAL_INTERVAL")
+			os.Unsetenv("DEPRECIATION_INTERVAL")
+			os.Unsetenv("METRICS_ADDR")
+			os.Unsetenv("CONSISTENCY_CHECK_INTERVAL")
+			os.Unsetenv("ADMIN_SERVER_PORT")
+			os.Unsetenv("ADMIN_AUTH_TOKEN")
 			os.Unsetenv("SERVER_PORT")
 			os.Unsetenv("DB_HOST")
 			os.Unsetenv("DB_PORT")
@@ -42,9 +113,455 @@ func TestLoad(t *testing.T) {
 		assert.Equal(t, "testhost", cfg.Database.Host)
 		assert.Equal(t, 5433, cfg.Database.Port)
 		assert.Equal(t, "testdb", cfg.Database.DBName)
+		assert.Equal(t, 9191, cfg.Admin.Port)
+		assert.Equal(t, "secret", cfg.Admin.AuthToken)
+		assert.True(t, cfg.Admin.Enabled())
+		assert.Equal(t, ":9100", cfg.Metrics.Addr)
+		assert.True(t, cfg.Metrics.Enabled())
+		assert.False(t, cfg.Consistency.Enabled())
+		assert.Equal(t, 6*time.Hour, cfg.Depreciation.Interval)
+		assert.False(t, cfg.Interest.Enabled())
+		assert.True(t, cfg.Digest.Publish)
+		assert.Equal(t, 720*time.Hour, cfg.TestTenants.Retention)
+		assert.True(t, cfg.TestTenants.Enabled())
+		assert.Equal(t, "archive", cfg.Partitions.ArchiveTablespace)
+		assert.Equal(t, 36, cfg.Partitions.ArchiveAfterMonths)
+	})
+
+	t.Run("loads gRPC server options from environment variables", func(t *testing.T) {
+		os.Setenv("SERVER_MAX_RECV_MSG_SIZE", "1048576")
+		os.Setenv("SERVER_MAX_CONCURRENT_STREAMS", "100")
+		os.Setenv("SERVER_REQUEST_TIMEOUT", "30s")
+		os.Setenv("SERVER_KEEPALIVE_MIN_TIME", "10s")
+		os.Setenv("SERVER_KEEPALIVE_PERMIT_WITHOUT_STREAM", "true")
+		os.Setenv("SERVER_MAX_CONNECTION_AGE", "30m")
+		os.Setenv("SERVER_COMPRESSION", "zstd")
+		os.Setenv("SERVER_COMPRESSION_LEVEL", "3")
+		defer func() {
+			os.Unsetenv("SERVER_MAX_RECV_MSG_SIZE")
+			os.Unsetenv("SERVER_MAX_CONCURRENT_STREAMS")
+			os.Unsetenv("SERVER_REQUEST_TIMEOUT")
+			os.Unsetenv("SERVER_KEEPALIVE_MIN_TIME")
+			os.Unsetenv("SERVER_KEEPALIVE_PERMIT_WITHOUT_STREAM")
+			os.Unsetenv("SERVER_MAX_CONNECTION_AGE")
+			os.Unsetenv("SERVER_COMPRESSION")
+			os.Unsetenv("SERVER_COMPRESSION_LEVEL")
+		}()
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.Equal(t, 1048576, cfg.Server.MaxRecvMsgSize)
+		assert.Equal(t, 100, cfg.Server.MaxConcurrentStreams)
+		assert.Equal(t, 30*time.Second, cfg.Server.RequestTimeout)
+		assert.Equal(t, 10*time.Second, cfg.Server.Keepalive.MinTime)
+		assert.True(t, cfg.Server.Keepalive.PermitWithoutStream)
+		assert.Equal(t, 30*time.Minute, cfg.Server.Keepalive.MaxConnectionAge)
+		assert.Equal(t, "zstd", cfg.Server.Compression.Default)
+		assert.Equal(t, 3, cfg.Server.Compression.Level)
+	})
+
+	t.Run("rejects invalid gRPC server options", func(t *testing.T) {
+		os.Setenv("SERVER_MAX_SEND_MSG_SIZE", "0")
+		defer os.Unsetenv("SERVER_MAX_SEND_MSG_SIZE")
+
+		_, err := Load()
+		assert.Error(t, err)
+	})
+
+	t.Run("loads journal entry limits from environment variables", func(t *testing.T) {
+		os.Setenv("LIMITS_MAX_LINES_PER_ENTRY", "50000")
+		os.Setenv("LIMITS_MAX_DESCRIPTION_LENGTH", "0")
+		defer func() {
+			os.Unsetenv("LIMITS_MAX_LINES_PER_ENTRY")
+			os.Unsetenv("LIMITS_MAX_DESCRIPTION_LENGTH")
+		}()
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.Equal(t, 50000, cfg.Limits.MaxLinesPerEntry)
+		assert.Zero(t, cfg.Limits.MaxDescriptionLength)
+	})
+
+	t.Run("rejects negative journal entry limits", func(t *testing.T) {
+		os.Setenv("LIMITS_MAX_METADATA_BYTES", "-1")
+		defer os.Unsetenv("LIMITS_MAX_METADATA_BYTES")
+
+		_, err := Load()
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects unknown compressors", func(t *testing.T) {
+		os.Setenv("SERVER_COMPRESSION", "br")
+		defer os.Unsetenv("SERVER_COMPRESSION")
+
+		_, err := Load()
+		assert.Error(t, err)
+	})
+
+	t.Run("loads the verbatim log level from the environment", func(t *testing.T) {
+		os.Setenv("LOG_VERBATIM_LEVEL", "debug")
+		defer os.Unsetenv("LOG_VERBATIM_LEVEL")
+
+		cfg, err := Load()
+		require.NoError(t, err)
+		assert.Equal(t, LogLevelDebug, cfg.Logging.VerbatimLevel)
 	})
 }
 
+func TestLoadFile(t *testing.T) {
+	writeConfig := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("overlays the file on the defaults", func(t *testing.T) {
+		path := writeConfig(t, `
+server:
+  port: 7070
+  request_timeout: 15s
+  keepalive:
+    min_time: 30s
+database:
+  host: filehost
+  statement_timeout: 2m
+  sql_functions: true
+  statement_cache:
+    mode: cache_describe
+    capacity: 64
+    plan_cache_mode: force_custom_plan
+  period_totals:
+    refresh: scheduled
+    interval: 30s
+  rls_check: fail
+tls:
+  cert_file: /etc/ledger/tls.crt
+  key_file: /etc/ledger/tls.key
+events:
+  enabled: false
+cache:
+  reference_data_ttl: 5m
+`)
+
+		cfg, err := LoadFile(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, 7070, cfg.Server.Port)
+		assert.Equal(t, "0.0.0.0", cfg.Server.Host)
+		assert.Equal(t, 15*time.Second, cfg.Server.RequestTimeout)
+		assert.Equal(t, 30*time.Second, cfg.Server.Keepalive.MinTime)
+		assert.Equal(t, 2*time.Hour, cfg.Server.Keepalive.Time)
+		assert.Equal(t, "filehost", cfg.Database.Host)
+		assert.Equal(t, 5432, cfg.Database.Port)
+		assert.Equal(t, 2*time.Minute, cfg.Database.StatementTimeout)
+		assert.True(t, cfg.Database.SQLFunctions)
+		assert.Equal(t, StatementCacheConfig{Mode: StatementCacheDescribe, Capacity: 64, PlanCacheMode: "force_custom_plan"}, cfg.Database.StatementCache)
+		assert.True(t, cfg.Database.PeriodTotals.Scheduled())
+		assert.Equal(t, 30*time.Second, cfg.Database.PeriodTotals.Interval)
+		assert.Equal(t, RLSCheckFail, cfg.Database.RLSCheck)
+		assert.True(t, cfg.TLS.Enabled())
+		assert.False(t, cfg.Events.Enabled)
+		assert.True(t, cfg.Cache.Enabled())
+		assert.Equal(t, 1000, cfg.Cache.MaxEntries)
+	})
+
+	t.Run("lets environment variables override the file", func(t *testing.T) {
+		path := writeConfig(t, "server:\n  port: 7070\ndatabase:\n  host: filehost\n")
+		os.Setenv("DB_HOST", "envhost")
+		defer os.Unsetenv("DB_HOST")
+
+		cfg, err := LoadFile(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, 7070, cfg.Server.Port)
+		assert.Equal(t, "envhost", cfg.Database.Host)
+	})
+
+	t.Run("reads the path from CONFIG_FILE", func(t *testing.T) {
+		os.Setenv(ConfigFileEnv, writeConfig(t, "admin:\n  auth_token: from-file\n"))
+		defer os.Unsetenv(ConfigFileEnv)
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.Equal(t, "from-file", cfg.Admin.AuthToken)
+	})
+
+	t.Run("accepts an empty file", func(t *testing.T) {
+		cfg, err := LoadFile(writeConfig(t, ""))
+		require.NoError(t, err)
+
+		assert.Equal(t, 9090, cfg.Server.Port)
+	})
+
+	t.Run("rejects unknown keys", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "server:\n  prot: 7070\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects a missing file", func(t *testing.T) {
+		_, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects IAM authentication without TLS", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "database:\n  credentials:\n    source: gcp-cloudsql-iam\n"))
+		assert.Error(t, err)
+
+		cfg, err := LoadFile(writeConfig(t, "database:\n  sslmode: require\n  credentials:\n    source: gcp-cloudsql-iam\n"))
+		require.NoError(t, err)
+		assert.Equal(t, CredentialsGCPCloudSQLIAM, cfg.Database.Credentials.Source)
+	})
+
+	t.Run("rejects a negative statement timeout", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "database:\n  statement_timeout: -1s\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects a negative test tenant retention", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "test_tenants:\n  retention: -1h\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects archiving partitions before they end", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "partitions:\n  archive_after_months: 0\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects a tracing endpoint that is not a URL", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "telemetry:\n  tracing_endpoint: otel-collector:4317\n"))
+		assert.Error(t, err)
+
+		cfg, err := LoadFile(writeConfig(t, "telemetry:\n  tracing_endpoint: http://otel-collector:4317\n"))
+		require.NoError(t, err)
+		assert.True(t, cfg.Telemetry.Enabled())
+	})
+
+	t.Run("rejects a negative cache size", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "cache:\n  reference_data_ttl: 5m\n  max_entries: -1\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects a negative slow query threshold", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "database:\n  slow_query_threshold: -1s\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects a circuit breaker without a cooldown", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "database:\n  breaker_cooldown: 0s\n"))
+		assert.Error(t, err)
+
+		cfg, err := LoadFile(writeConfig(t, "database:\n  breaker_threshold: 0\n  breaker_cooldown: 0s\n"))
+		require.NoError(t, err)
+		assert.Zero(t, cfg.Database.BreakerThreshold)
+	})
+
+	t.Run("rejects unknown statement cache modes and caches without capacity", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "database:\n  statement_cache:\n    mode: prepare_all\n"))
+		assert.Error(t, err)
+
+		_, err = LoadFile(writeConfig(t, "database:\n  statement_cache:\n    capacity: 0\n"))
+		assert.Error(t, err)
+
+		_, err = LoadFile(writeConfig(t, "database:\n  statement_cache:\n    plan_cache_mode: always\n"))
+		assert.Error(t, err)
+
+		cfg, err := LoadFile(writeConfig(t, "database:\n  statement_cache:\n    mode: simple_protocol\n    capacity: 0\n"))
+		require.NoError(t, err)
+		assert.Equal(t, StatementCacheSimpleProtocol, cfg.Database.StatementCache.Mode)
+	})
+
+	t.Run("rejects unknown or unscheduled period totals refreshes", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "database:\n  period_totals:\n    refresh: nightly\n"))
+		assert.Error(t, err)
+
+		_, err = LoadFile(writeConfig(t, "database:\n  period_totals:\n    refresh: scheduled\n    interval: 0s\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects unknown database drivers", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "database:\n  driver: sqlite\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects unknown row level security checks", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "database:\n  rls_check: strict\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects unknown verbatim log levels", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "logging:\n  verbatim_level: trace\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects a certificate without a key", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "tls:\n  cert_file: /etc/ledger/tls.crt\n"))
+		assert.Error(t, err)
+	})
+
+	tenantA := "6f1c1d52-4a6e-4f55-9d43-1b7f0f6b8c01"
+	tenantB := "0b5d6a1e-2f0c-4c7e-8a3b-9e4d5c6b7a02"
+
+	t.Run("reads API keys from the file and the environment", func(t *testing.T) {
+		path := writeConfig(t, "auth:\n  api_keys:\n    - key: reporting\n      tenant: "+tenantA+"\n      scopes: [read:accounts]\n")
+		cfg, err := LoadFile(path)
+		require.NoError(t, err)
+		assert.True(t, cfg.Auth.Enabled())
+		assert.Equal(t, []APIKeyConfig{{Key: "reporting", Tenant: tenantA, Scopes: []string{"read:accounts"}}}, cfg.Auth.APIKeys)
+
+		os.Setenv("AUTH_API_KEYS", "poster@"+tenantA+"=read:accounts,write:journal; admin@"+tenantB+"=admin:tenant")
+		defer os.Unsetenv("AUTH_API_KEYS")
+
+		cfg, err = LoadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, []APIKeyConfig{
+			{Key: "poster", Tenant: tenantA, Scopes: []string{"read:accounts", "write:journal"}},
+			{Key: "admin", Tenant: tenantB, Scopes: []string{"admin:tenant"}},
+		}, cfg.Auth.APIKeys)
+	})
+
+	t.Run("rejects an API key without scopes", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "auth:\n  api_keys:\n    - key: reporting\n      tenant: "+tenantA+"\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects an API key without a tenant", func(t *testing.T) {
+		_, err := LoadFile(writeConfig(t, "auth:\n  api_keys:\n    - key: reporting\n      scopes: [read:accounts]\n"))
+		assert.Error(t, err)
+
+		os.Setenv("AUTH_API_KEYS", "reporting=read:accounts")
+		defer os.Unsetenv("AUTH_API_KEYS")
+		_, err = LoadFile(writeConfig(t, ""))
+		assert.Error(t, err)
+	})
+
+	t.Run("reads event signing secrets", func(t *testing.T) {
+		path := writeConfig(t, "events:\n  signing_secrets: [current]\n")
+		cfg, err := LoadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"current"}, cfg.Events.SigningSecrets)
+
+		os.Setenv("EVENTS_SIGNING_SECRETS", "new, old,")
+		defer os.Unsetenv("EVENTS_SIGNING_SECRETS")
+
+		cfg, err = LoadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"new", "old"}, cfg.Events.SigningSecrets)
+	})
+
+	t.Run("reads the change data capture publication", func(t *testing.T) {
+		cfg, err := LoadFile(writeConfig(t, "events:\n  cdc:\n    publication: ledger_events\n"))
+		require.NoError(t, err)
+		assert.True(t, cfg.Events.CDC.Enabled())
+		assert.Equal(t, 30*time.Second, cfg.Events.CDC.LagInterval)
+
+		_, err = LoadFile(writeConfig(t, "events:\n  cdc:\n    publication: ledger_events\n    lag_interval: 0s\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("reads the event webhook", func(t *testing.T) {
+		cfg, err := LoadFile(writeConfig(t, "events:\n  webhook:\n    url: https://example.com/events\n"))
+		require.NoError(t, err)
+		assert.True(t, cfg.Events.Webhook.Enabled())
+		assert.Equal(t, 10*time.Second, cfg.Events.Webhook.Interval)
+		assert.Equal(t, 5, cfg.Events.Webhook.MaxAttempts)
+
+		_, err = LoadFile(writeConfig(t, "events:\n  webhook:\n    url: https://example.com/events\n    max_attempts: 0\n"))
+		assert.Error(t, err)
+		_, err = LoadFile(writeConfig(t, "events:\n  enabled: false\n  webhook:\n    url: https://example.com/events\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("reads the GraphQL endpoint", func(t *testing.T) {
+		cfg, err := LoadFile(writeConfig(t, "graphql:\n  addr: \":8080\"\n  max_depth: 6\n"))
+		require.NoError(t, err)
+		assert.True(t, cfg.GraphQL.Enabled())
+		assert.Equal(t, 6, cfg.GraphQL.MaxDepth)
+		assert.Equal(t, 200, cfg.GraphQL.MaxCalls)
+
+		_, err = LoadFile(writeConfig(t, "graphql:\n  addr: \":8080\"\n  max_calls: 0\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestCredentialsConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     CredentialsConfig
+		wantErr bool
+	}{
+		{name: "accepts static credentials", cfg: CredentialsConfig{Source: CredentialsStatic}},
+		{
+			name: "accepts a complete vault source",
+			cfg:  CredentialsConfig{Source: CredentialsVault, Vault: VaultConfig{Addr: "https://vault:8200", Path: "database/creds/ledger"}},
+		},
+		{name: "rejects a vault source without a path", cfg: CredentialsConfig{Source: CredentialsVault, Vault: VaultConfig{Addr: "https://vault:8200"}}, wantErr: true},
+		{name: "rejects an aws source without a secret", cfg: CredentialsConfig{Source: CredentialsAWSSecretsManager, AWS: AWSSecretConfig{Region: "eu-west-1"}}, wantErr: true},
+		{name: "accepts rds iam authentication", cfg: CredentialsConfig{Source: CredentialsAWSRDSIAM, AWS: AWSSecretConfig{Region: "eu-west-1"}}},
+		{name: "rejects rds iam authentication without a region", cfg: CredentialsConfig{Source: CredentialsAWSRDSIAM}, wantErr: true},
+		{name: "accepts cloud sql iam authentication", cfg: CredentialsConfig{Source: CredentialsGCPCloudSQLIAM}},
+		{name: "rejects unknown sources", cfg: CredentialsConfig{Source: "keychain"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMetadataEncryptionConfig_Validate(t *testing.T) {
+	rootKey := "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+	tests := []struct {
+		name    string
+		cfg     MetadataEncryptionConfig
+		wantErr bool
+	}{
+		{name: "accepts no keyring", cfg: MetadataEncryptionConfig{Keyring: KeyringNone}},
+		{
+			name: "accepts local root keys",
+			cfg:  MetadataEncryptionConfig{Keyring: KeyringLocal, Keys: map[string]string{"2026": rootKey, "2025": rootKey}, ActiveKey: "2026"},
+		},
+		{name: "rejects a short local root key", cfg: MetadataEncryptionConfig{Keyring: KeyringLocal, Keys: map[string]string{"2026": "c2hvcnQ="}, ActiveKey: "2026"}, wantErr: true},
+		{name: "rejects an unknown active key", cfg: MetadataEncryptionConfig{Keyring: KeyringLocal, Keys: map[string]string{"2026": rootKey}, ActiveKey: "2027"}, wantErr: true},
+		{
+			name: "accepts kms keys",
+			cfg:  MetadataEncryptionConfig{Keyring: KeyringAWSKMS, Keys: map[string]string{"primary": "alias/ledger-metadata"}, ActiveKey: "primary", AWSRegion: "eu-west-1"},
+		},
+		{name: "rejects kms keys without a region", cfg: MetadataEncryptionConfig{Keyring: KeyringAWSKMS, Keys: map[string]string{"primary": "alias/ledger-metadata"}, ActiveKey: "primary"}, wantErr: true},
+		{name: "rejects unknown keyrings", cfg: MetadataEncryptionConfig{Keyring: "pkcs11"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseKeyMap(t *testing.T) {
+	keys := parseKeyMap("2026=MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=, primary=alias/ledger,=ignored")
+
+	assert.Equal(t, map[string]string{
+		"2026":    "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+		"primary": "alias/ledger",
+	}, keys)
+}
+
 func TestDatabaseConfig_ConnectionString(t *testing.T) {
 	cfg := &DatabaseConfig{
 		Host:     "localhost",
@@ -96,3 +613,26 @@ func TestGetEnvAsInt(t *testing.T) {
 		assert.Equal(t, 10, value)
 	})
 }
+
+func TestGetEnvAsDuration(t *testing.T) {
+	t.Run("returns duration from environment variable", func(t *testing.T) {
+		os.Setenv("TEST_DURATION", "15m")
+		defer os.Unsetenv("TEST_DURATION")
+
+		value := getEnvAsDuration("TEST_DURATION", time.Hour)
+		assert.Equal(t, 15*time.Minute, value)
+	})
+
+	t.Run("returns default value when environment variable is not set", func(t *testing.T) {
+		value := getEnvAsDuration("NON_EXISTENT_DURATION", time.Hour)
+		assert.Equal(t, time.Hour, value)
+	})
+
+	t.Run("returns default value when environment variable is not a valid duration", func(t *testing.T) {
+		os.Setenv("TEST_INVALID_DURATION", "soon")
+		defer os.Unsetenv("TEST_INVALID_DURATION")
+
+		value := getEnvAsDuration("TEST_INVALID_DURATION", time.Hour)
+		assert.Equal(t, time.Hour, value)
+	})
+}
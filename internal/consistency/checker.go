@@ -0,0 +1,140 @@
+package consistency
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Checker periodically checks the ledger invariants of every tenant and
+// reports the outcome as metrics and log lines
+type Checker struct {
+	tenantRepo      repository.TenantRepositoryInterface
+	consistencyRepo repository.ConsistencyRepositoryInterface
+	interval        time.Duration
+
+	violations          *prometheus.GaugeVec
+	inconsistentTenants prometheus.Gauge
+	tenantsChecked      prometheus.Gauge
+	lastRun             prometheus.Gauge
+	errors              prometheus.Counter
+}
+
+// NewChecker creates a new checker and registers its metrics with reg
+func NewChecker(
+	tenantRepo repository.TenantRepositoryInterface,
+	consistencyRepo repository.ConsistencyRepositoryInterface,
+	interval time.Duration,
+	reg prometheus.Registerer,
+) *Checker {
+	c := &Checker{
+		tenantRepo:      tenantRepo,
+		consistencyRepo: consistencyRepo,
+		interval:        interval,
+		violations: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ledger_consistency_violations",
+			Help: "Violations found by the last consistency run, by check.",
+		}, []string{"check"}),
+		inconsistentTenants: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ledger_consistency_inconsistent_tenants",
+			Help: "Tenants with at least one violation in the last consistency run.",
+		}),
+		tenantsChecked: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ledger_consistency_tenants_checked",
+			Help: "Tenants other than test tenants checked by the last consistency run.",
+		}),
+		lastRun: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ledger_consistency_last_run_timestamp_seconds",
+			Help: "Unix time the last consistency run completed.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_consistency_check_errors_total",
+			Help: "Consistency checks that failed to run.",
+		}),
+	}
+
+	reg.MustRegister(c.violations, c.inconsistentTenants, c.tenantsChecked, c.lastRun, c.errors)
+
+	return c
+}
+
+// Run checks every tenant once per interval until ctx is cancelled
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.CheckAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll checks every active tenant and updates the metrics. Violations of
+// test tenants are logged but left out of the metrics. A tenant whose check
+// fails is logged and counted, and the run carries on with the others.
+func (c *Checker) CheckAll(ctx context.Context) {
+	tenantIDs, err := c.tenantRepo.ListIDs(ctx)
+	if err != nil {
+		redact.Printf(redact.LevelError, "consistency check: %v", err)
+		c.errors.Inc()
+		return
+	}
+	testIDs, err := c.tenantRepo.ListTestIDs(ctx)
+	if err != nil {
+		redact.Printf(redact.LevelError, "consistency check: %v", err)
+		c.errors.Inc()
+		return
+	}
+
+	counts := make(map[string]int, len(repository.ConsistencyChecks))
+	for _, check := range repository.ConsistencyChecks {
+		counts[check] = 0
+	}
+	inconsistent, checked := 0, 0
+
+	for _, tenantID := range tenantIDs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		report, err := c.consistencyRepo.Check(ctx, tenantID)
+		if err != nil {
+			redact.Printf(redact.LevelError, "consistency check of tenant %s: %v", tenantID, err)
+			c.errors.Inc()
+			continue
+		}
+		test := slices.Contains(testIDs, tenantID)
+		for _, v := range report.Violations {
+			log.Printf("consistency check of tenant %s: %s: %s", tenantID, v.Check, v.Detail)
+		}
+		if test {
+			continue
+		}
+
+		checked++
+		if report.Consistent() {
+			continue
+		}
+		inconsistent++
+		for _, v := range report.Violations {
+			counts[v.Check]++
+		}
+	}
+
+	for check, n := range counts {
+		c.violations.WithLabelValues(check).Set(float64(n))
+	}
+	c.inconsistentTenants.Set(float64(inconsistent))
+	c.tenantsChecked.Set(float64(checked))
+	c.lastRun.SetToCurrentTime()
+}
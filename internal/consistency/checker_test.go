@@ -0,0 +1,95 @@
+package consistency
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTenantRepository struct {
+	repository.TenantRepositoryInterface
+	ids     []uuid.UUID
+	testIDs []uuid.UUID
+	err     error
+}
+
+func (f *fakeTenantRepository) ListIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return f.ids, f.err
+}
+
+func (f *fakeTenantRepository) ListTestIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return f.testIDs, f.err
+}
+
+type fakeConsistencyRepository struct {
+	reports map[uuid.UUID]*repository.ConsistencyReport
+}
+
+func (f *fakeConsistencyRepository) Check(ctx context.Context, tenantID uuid.UUID) (*repository.ConsistencyReport, error) {
+	report, ok := f.reports[tenantID]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	return report, nil
+}
+
+func (f *fakeConsistencyRepository) VerifyBalances(ctx context.Context, tenantID uuid.UUID) (*repository.BalanceVerification, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestChecker_CheckAll(t *testing.T) {
+	ctx := context.Background()
+	clean, broken, failing := uuid.New(), uuid.New(), uuid.New()
+	accountID := uuid.New()
+
+	consistencyRepo := &fakeConsistencyRepository{reports: map[uuid.UUID]*repository.ConsistencyReport{
+		clean: {TenantID: clean},
+		broken: {TenantID: broken, Violations: []*repository.ConsistencyViolation{
+			{Check: repository.CheckAccountBalances, AccountID: &accountID, Detail: "drifted"},
+			{Check: repository.CheckOrphanLines, Detail: "line references a missing account"},
+		}},
+	}}
+
+	t.Run("records violations and inconsistent tenants", func(t *testing.T) {
+		tenantRepo := &fakeTenantRepository{ids: []uuid.UUID{clean, broken, failing}}
+		checker := NewChecker(tenantRepo, consistencyRepo, 0, prometheus.NewRegistry())
+
+		checker.CheckAll(ctx)
+
+		assert.Equal(t, 0.0, testutil.ToFloat64(checker.violations.WithLabelValues(repository.CheckDebitsEqualCredits)))
+		assert.Equal(t, 1.0, testutil.ToFloat64(checker.violations.WithLabelValues(repository.CheckAccountBalances)))
+		assert.Equal(t, 1.0, testutil.ToFloat64(checker.violations.WithLabelValues(repository.CheckOrphanLines)))
+		assert.Equal(t, 1.0, testutil.ToFloat64(checker.inconsistentTenants))
+		assert.Equal(t, 2.0, testutil.ToFloat64(checker.tenantsChecked))
+		assert.Equal(t, 1.0, testutil.ToFloat64(checker.errors))
+		assert.NotZero(t, testutil.ToFloat64(checker.lastRun))
+	})
+
+	t.Run("leaves test tenants out of the metrics", func(t *testing.T) {
+		tenantRepo := &fakeTenantRepository{ids: []uuid.UUID{clean, broken}, testIDs: []uuid.UUID{broken}}
+		checker := NewChecker(tenantRepo, consistencyRepo, 0, prometheus.NewRegistry())
+
+		checker.CheckAll(ctx)
+
+		assert.Equal(t, 0.0, testutil.ToFloat64(checker.violations.WithLabelValues(repository.CheckAccountBalances)))
+		assert.Equal(t, 0.0, testutil.ToFloat64(checker.inconsistentTenants))
+		assert.Equal(t, 1.0, testutil.ToFloat64(checker.tenantsChecked))
+		assert.Equal(t, 0.0, testutil.ToFloat64(checker.errors))
+	})
+
+	t.Run("counts an error when tenants cannot be listed", func(t *testing.T) {
+		tenantRepo := &fakeTenantRepository{err: errors.New("connection refused")}
+		checker := NewChecker(tenantRepo, consistencyRepo, 0, prometheus.NewRegistry())
+
+		checker.CheckAll(ctx)
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(checker.errors))
+		assert.Zero(t, testutil.ToFloat64(checker.lastRun))
+	})
+}
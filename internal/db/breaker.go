@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of waiting for a connection while the
+// breaker is open
+var ErrCircuitOpen = errors.New("database is unavailable, circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int
+
+// Breaker states; a closed breaker lets calls through, an open one rejects
+// them and a half-open one lets a single probe through to test the database
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+// String returns the lowercase name of the state
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker is a circuit breaker for database connections. After threshold
+// consecutive failures it opens and rejects calls with ErrCircuitOpen for
+// the cooldown, then lets one probe through: its success closes the breaker
+// and its failure opens it for another cooldown.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	rejected uint64
+}
+
+// NewBreaker creates a closed circuit breaker, a threshold of 0 disables it
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow reports whether a call may go to the database, returning
+// ErrCircuitOpen if not. Every allowed call must be followed by Record.
+func (b *Breaker) Allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) >= b.cooldown {
+			b.state = BreakerHalfOpen
+			return nil
+		}
+	case BreakerHalfOpen:
+		// A probe is already in flight
+	default:
+		return nil
+	}
+
+	b.rejected++
+	return ErrCircuitOpen
+}
+
+// Record reports the outcome of an allowed call. Canceled calls say nothing
+// about the database and are ignored, but a deadline passing while waiting
+// for a connection counts as a failure as the pool is saturated.
+func (b *Breaker) Record(err error) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if errors.Is(err, context.Canceled) {
+		// A canceled probe leaves the next call to probe again
+		if b.state == BreakerHalfOpen {
+			b.state = BreakerOpen
+		}
+		return
+	}
+
+	if err == nil {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// State returns the current state of the breaker
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Rejected returns the number of calls rejected while the breaker was open
+func (b *Breaker) Rejected() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rejected
+}
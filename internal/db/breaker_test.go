@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	refused := errors.New("connection refused")
+
+	// newBreaker returns a breaker with a clock the test moves by hand
+	newBreaker := func(threshold int) (*Breaker, *time.Time) {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		b := NewBreaker(threshold, 10*time.Second)
+		b.now = func() time.Time { return now }
+		return b, &now
+	}
+
+	// fail records failed calls until the breaker has seen n of them
+	fail := func(b *Breaker, n int) {
+		for range n {
+			if b.Allow() == nil {
+				b.Record(refused)
+			}
+		}
+	}
+
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		b, _ := newBreaker(3)
+
+		fail(b, 2)
+		assert.Equal(t, BreakerClosed, b.State())
+		assert.NoError(t, b.Allow())
+		b.Record(nil)
+
+		fail(b, 3)
+		assert.Equal(t, BreakerOpen, b.State())
+		assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+		assert.Equal(t, uint64(1), b.Rejected())
+	})
+
+	t.Run("lets one probe through after the cooldown", func(t *testing.T) {
+		b, now := newBreaker(1)
+		fail(b, 1)
+
+		*now = now.Add(10 * time.Second)
+		assert.NoError(t, b.Allow())
+		assert.Equal(t, BreakerHalfOpen, b.State())
+		assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+
+		b.Record(nil)
+		assert.Equal(t, BreakerClosed, b.State())
+		assert.NoError(t, b.Allow())
+	})
+
+	t.Run("reopens when the probe fails", func(t *testing.T) {
+		b, now := newBreaker(3)
+		fail(b, 3)
+
+		*now = now.Add(10 * time.Second)
+		assert.NoError(t, b.Allow())
+		b.Record(refused)
+
+		assert.Equal(t, BreakerOpen, b.State())
+		assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+	})
+
+	t.Run("ignores canceled calls", func(t *testing.T) {
+		b, now := newBreaker(1)
+		b.Record(context.Canceled)
+		assert.Equal(t, BreakerClosed, b.State())
+
+		fail(b, 1)
+		*now = now.Add(10 * time.Second)
+		assert.NoError(t, b.Allow())
+		b.Record(context.Canceled)
+
+		assert.Equal(t, BreakerOpen, b.State())
+		assert.NoError(t, b.Allow(), "the next call probes again")
+	})
+
+	t.Run("counts a deadline passing as a failure", func(t *testing.T) {
+		b, _ := newBreaker(1)
+		assert.NoError(t, b.Allow())
+		b.Record(context.DeadlineExceeded)
+
+		assert.Equal(t, BreakerOpen, b.State())
+	})
+
+	t.Run("never opens without a threshold", func(t *testing.T) {
+		b, _ := newBreaker(0)
+		fail(b, 10)
+
+		assert.Equal(t, BreakerClosed, b.State())
+		assert.NoError(t, b.Allow())
+	})
+}
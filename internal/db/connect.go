@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/config"
+)
+
+// Connect creates the connection pool like New, retrying with exponential
+// backoff for up to cfg.ConnectTimeout. At startup the database is often not
+// ready yet, e.g. while its pod is still being scheduled.
+func Connect(ctx context.Context, cfg *config.DatabaseConfig) (*DB, error) {
+	var database *DB
+	err := retry(ctx, cfg.ConnectTimeout, cfg.ConnectBackoff, cfg.ConnectMaxBackoff, func(ctx context.Context) error {
+		var err error
+		database, err = New(ctx, cfg)
+		return err
+	})
+	return database, err
+}
+
+// retry calls attempt until it succeeds or timeout has passed, waiting
+// backoff after the first failure and doubling the wait up to maxBackoff
+func retry(ctx context.Context, timeout, backoff, maxBackoff time.Duration, attempt func(context.Context) error) error {
+	deadline := time.Now().Add(timeout)
+
+	for n := 1; ; n++ {
+		err := attempt(ctx)
+		if err == nil {
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("giving up after %d attempts: %w", n, err)
+		}
+
+		wait := min(backoff, remaining)
+		log.Printf("Database connection attempt %d failed, retrying in %s: %v", n, wait, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("giving up after %d attempts: %w", n, ctx.Err())
+		case <-timer.C:
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
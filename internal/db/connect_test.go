@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	refused := errors.New("connection refused")
+
+	t.Run("retries until an attempt succeeds", func(t *testing.T) {
+		attempts := 0
+		err := retry(context.Background(), time.Second, time.Millisecond, 4*time.Millisecond, func(ctx context.Context) error {
+			attempts++
+			if attempts < 4 {
+				return refused
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, attempts)
+	})
+
+	t.Run("tries once without a timeout", func(t *testing.T) {
+		attempts := 0
+		err := retry(context.Background(), 0, time.Millisecond, time.Millisecond, func(ctx context.Context) error {
+			attempts++
+			return refused
+		})
+
+		assert.ErrorIs(t, err, refused)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("gives up once the timeout has passed", func(t *testing.T) {
+		start := time.Now()
+		err := retry(context.Background(), 20*time.Millisecond, time.Millisecond, 5*time.Millisecond, func(ctx context.Context) error {
+			return refused
+		})
+
+		assert.ErrorIs(t, err, refused)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("stops when the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		err := retry(ctx, time.Minute, time.Minute, time.Minute, func(ctx context.Context) error {
+			cancel()
+			return refused
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/secrets"
+	"github.com/jackc/pgx/v5"
+)
+
+// tokenRenewMargin is how long before expiry an IAM token is renewed, so a
+// connection is never attempted with a token about to lapse
+const tokenRenewMargin = 2 * time.Minute
+
+// credentialCache holds the database credentials new connections use. They
+// are fetched from a secret store at startup and again on every refresh;
+// IAM tokens are also renewed when a connection needs one near expiry.
+type credentialCache struct {
+	provider secrets.Provider
+
+	mu      sync.RWMutex
+	current secrets.Credentials
+}
+
+func newCredentialCache(provider secrets.Provider) *credentialCache {
+	return &credentialCache{provider: provider}
+}
+
+// refresh fetches the credentials and reports whether they changed
+func (c *credentialCache) refresh(ctx context.Context) (bool, error) {
+	creds, err := c.provider.Credentials(ctx)
+	if err != nil {
+		return false, fmt.Errorf("unable to fetch database credentials: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := creds.Username != c.current.Username || creds.Password != c.current.Password
+	c.current = creds
+	return changed, nil
+}
+
+// beforeConnect sets the current credentials on a new connection, renewing
+// a token that is about to expire
+func (c *credentialCache) beforeConnect(ctx context.Context, cfg *pgx.ConnConfig) error {
+	c.mu.RLock()
+	creds := c.current
+	c.mu.RUnlock()
+
+	if creds.IsToken() && time.Until(creds.ExpiresAt) < tokenRenewMargin {
+		if _, err := c.refresh(ctx); err != nil {
+			return err
+		}
+		c.mu.RLock()
+		creds = c.current
+		c.mu.RUnlock()
+	}
+
+	cfg.User = creds.Username
+	cfg.Password = creds.Password
+	return nil
+}
+
+// isToken reports whether the cached password is an IAM token
+func (c *credentialCache) isToken() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.current.IsToken()
+}
+
+// rotate refreshes the credentials every interval until ctx is done. When
+// a stored secret changes the pool is reset so no connection keeps using
+// credentials that may be revoked; connections in use are closed once
+// released. New IAM tokens are only picked up by new connections, as
+// established ones stay authenticated.
+func (d *DB) rotate(ctx context.Context, creds *credentialCache, interval time.Duration) {
+	defer close(d.rotateDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := creds.refresh(ctx)
+			if err != nil {
+				log.Printf("Failed to refresh database credentials: %v", err)
+				continue
+			}
+			if changed && !creds.isToken() {
+				log.Println("Database credentials rotated, reconnecting the pool")
+				d.pool.Reset()
+			}
+		}
+	}
+}
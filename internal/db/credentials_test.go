@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/secrets"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	creds secrets.Credentials
+	err   error
+}
+
+func (p *fakeProvider) Credentials(ctx context.Context) (secrets.Credentials, error) {
+	return p.creds, p.err
+}
+
+func TestCredentialCache(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{creds: secrets.Credentials{Username: "ledger", Password: "first"}}
+	cache := newCredentialCache(provider)
+
+	changed, err := cache.refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	connConfig := &pgx.ConnConfig{}
+	require.NoError(t, cache.beforeConnect(ctx, connConfig))
+	assert.Equal(t, "ledger", connConfig.User)
+	assert.Equal(t, "first", connConfig.Password)
+
+	t.Run("reports unchanged credentials", func(t *testing.T) {
+		changed, err := cache.refresh(ctx)
+		require.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("keeps the last credentials when a refresh fails", func(t *testing.T) {
+		provider.err = errors.New("vault sealed")
+		defer func() { provider.err = nil }()
+
+		_, err := cache.refresh(ctx)
+		assert.Error(t, err)
+
+		connConfig := &pgx.ConnConfig{}
+		require.NoError(t, cache.beforeConnect(ctx, connConfig))
+		assert.Equal(t, "first", connConfig.Password)
+	})
+
+	t.Run("picks up rotated credentials", func(t *testing.T) {
+		provider.creds.Password = "second"
+
+		changed, err := cache.refresh(ctx)
+		require.NoError(t, err)
+		assert.True(t, changed)
+
+		connConfig := &pgx.ConnConfig{}
+		require.NoError(t, cache.beforeConnect(ctx, connConfig))
+		assert.Equal(t, "second", connConfig.Password)
+	})
+}
+
+type sequenceProvider struct {
+	issued int
+}
+
+func (p *sequenceProvider) Credentials(ctx context.Context) (secrets.Credentials, error) {
+	p.issued++
+	return secrets.Credentials{
+		Username: "ledger",
+		Password: fmt.Sprintf("token-%d", p.issued),
+		// The first token is about to expire, later ones are fresh
+		ExpiresAt: time.Now().Add(time.Duration(p.issued-1) * 15 * time.Minute),
+	}, nil
+}
+
+func TestCredentialCache_RenewsExpiringTokens(t *testing.T) {
+	ctx := context.Background()
+	provider := &sequenceProvider{}
+	cache := newCredentialCache(provider)
+	_, err := cache.refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, cache.isToken())
+
+	connConfig := &pgx.ConnConfig{}
+	require.NoError(t, cache.beforeConnect(ctx, connConfig))
+	assert.Equal(t, "token-2", connConfig.Password)
+
+	require.NoError(t, cache.beforeConnect(ctx, connConfig))
+	assert.Equal(t, "token-2", connConfig.Password)
+	assert.Equal(t, 2, provider.issued)
+}
@@ -3,25 +3,71 @@ package db
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/hesabFun/ledger/internal/config"
+	"github.com/hesabFun/ledger/internal/keyring"
+	"github.com/hesabFun/ledger/internal/secrets"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgconn/ctxwatch"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+// cancelGracePeriod is how long a statement whose context is done may take
+// to stop after the cancel request is sent before its connection is closed
+const cancelGracePeriod = 5 * time.Second
+
 // DB wraps the pgxpool connection pool
 type DB struct {
-	pool *pgxpool.Pool
+	pool    *pgxpool.Pool
+	breaker *Breaker
+	tracer  *queryTracer
+	// sqlFunctions posts through the legacy create_account and
+	// create_journal_entry database functions
+	sqlFunctions bool
+	// deferPeriodTotals queues posted entries for the period totals runner
+	// instead of adding them to the totals as they post
+	deferPeriodTotals bool
+	// metadataKeyring encrypts the journal entry metadata of tenants that
+	// opt in, nil when no keyring is configured
+	metadataKeyring *keyring.Keyring
+
+	// stopRotation ends the credential rotation, if running
+	stopRotation context.CancelFunc
+	rotateDone   chan struct{}
 }
 
-// New creates a new database connection pool
+// New creates a new database connection pool. With a secret store as the
+// credentials source, the credentials are fetched before connecting and,
+// given a refresh interval, fetched again periodically.
 func New(ctx context.Context, cfg *config.DatabaseConfig) (*DB, error) {
 	poolConfig, err := pgxpool.ParseConfig(cfg.ConnectionString())
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse database config: %w", err)
 	}
 
+	provider, err := secrets.NewProvider(ctx, *cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to configure database credentials: %w", err)
+	}
+
+	metadataKeyring, err := keyring.NewFromConfig(ctx, cfg.MetadataEncryption)
+	if err != nil {
+		return nil, fmt.Errorf("unable to configure metadata keyring: %w", err)
+	}
+
+	var creds *credentialCache
+	if provider != nil {
+		creds = newCredentialCache(provider)
+		if _, err := creds.refresh(ctx); err != nil {
+			return nil, err
+		}
+		poolConfig.BeforeConnect = creds.beforeConnect
+	}
+
 	// Configure connection pool
 	poolConfig.MaxConns = int32(cfg.MaxConns)
 	poolConfig.MinConns = int32(cfg.MinConns)
@@ -29,6 +75,28 @@ func New(ctx context.Context, cfg *config.DatabaseConfig) (*DB, error) {
 	poolConfig.MaxConnIdleTime = 30 * time.Minute
 	poolConfig.HealthCheckPeriod = time.Minute
 
+	// Statements running past the timeout are canceled by the server
+	if cfg.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+
+	applyStatementCache(poolConfig.ConnConfig, cfg.StatementCache)
+
+	// A done context cancels the running statement on the server as well,
+	// rather than only abandoning the connection while the query runs on
+	poolConfig.ConnConfig.BuildContextWatcherHandler = func(conn *pgconn.PgConn) ctxwatch.Handler {
+		return &pgconn.CancelRequestContextWatcherHandler{Conn: conn, DeadlineDelay: cancelGracePeriod}
+	}
+
+	// Time every statement and log the slow ones with their tenant
+	tracer := newQueryTracer(cfg.SlowQueryThreshold)
+	poolConfig.ConnConfig.Tracer = tracer
+	poolConfig.AfterRelease = func(conn *pgx.Conn) bool {
+		tracer.forget(conn)
+		return true
+	}
+	poolConfig.BeforeClose = tracer.forget
+
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create connection pool: %w", err)
@@ -40,7 +108,22 @@ func New(ctx context.Context, cfg *config.DatabaseConfig) (*DB, error) {
 		return nil, fmt.Errorf("unable to ping database: %w", err)
 	}
 
-	return &DB{pool: pool}, nil
+	d := &DB{
+		pool:              pool,
+		breaker:           NewBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		tracer:            tracer,
+		sqlFunctions:      cfg.SQLFunctions,
+		deferPeriodTotals: cfg.PeriodTotals.Scheduled(),
+		metadataKeyring:   metadataKeyring,
+	}
+	if creds != nil && cfg.Credentials.RefreshInterval > 0 {
+		rotateCtx, stop := context.WithCancel(context.Background())
+		d.stopRotation = stop
+		d.rotateDone = make(chan struct{})
+		go d.rotate(rotateCtx, creds, cfg.Credentials.RefreshInterval)
+	}
+
+	return d, nil
 }
 
 // Pool returns the underlying connection pool
@@ -48,20 +131,73 @@ func (d *DB) Pool() *pgxpool.Pool {
 	return d.pool
 }
 
-// Close closes the database connection pool
+// SQLFunctions reports whether accounts and journal entries are created by
+// the legacy database functions rather than by the repositories
+func (d *DB) SQLFunctions() bool {
+	return d.sqlFunctions
+}
+
+// DeferPeriodTotals reports whether period totals are refreshed on a
+// schedule rather than as entries post
+func (d *DB) DeferPeriodTotals() bool {
+	return d.deferPeriodTotals
+}
+
+// MetadataKeyring returns the keyring that encrypts the journal entry
+// metadata of tenants that opt in, or nil when none is configured
+func (d *DB) MetadataKeyring() *keyring.Keyring {
+	return d.metadataKeyring
+}
+
+// QueryMetrics returns the collector of the query duration histogram
+func (d *DB) QueryMetrics() prometheus.Collector {
+	return d.tracer.duration
+}
+
+// Breaker returns the circuit breaker guarding tenant connections
+func (d *DB) Breaker() *Breaker {
+	return d.breaker
+}
+
+// Ping checks that the database accepts connections. It goes through the
+// circuit breaker, so pinging an open breaker after its cooldown probes the
+// database without waiting for a request to do so.
+func (d *DB) Ping(ctx context.Context) error {
+	if err := d.breaker.Allow(); err != nil {
+		return err
+	}
+	err := d.pool.Ping(ctx)
+	d.breaker.Record(err)
+	return err
+}
+
+// Close stops credential rotation and closes the database connection pool
 func (d *DB) Close() {
+	if d.stopRotation != nil {
+		d.stopRotation()
+		<-d.rotateDone
+	}
 	d.pool.Close()
 }
 
-// WithTenant returns a connection with the tenant_id set for RLS
+// WithTenant returns a connection with the tenant_id set for RLS. It fails
+// with ErrCircuitOpen without waiting for a connection while the database is
+// known to be unavailable.
 func (d *DB) WithTenant(ctx context.Context, tenantID string) (context.Context, *pgxpool.Conn, error) {
+	if err := d.breaker.Allow(); err != nil {
+		return nil, nil, err
+	}
+
 	conn, err := d.pool.Acquire(ctx)
 	if err != nil {
+		d.breaker.Record(err)
 		return nil, nil, fmt.Errorf("unable to acquire connection: %w", err)
 	}
+	d.tracer.setTenant(conn.Conn(), tenantID)
 
 	// Set the tenant_id for Row-Level Security
 	_, err = conn.Exec(ctx, "SET LOCAL app.current_tenant_id = $1", tenantID)
+	d.breaker.Record(err)
 	if err != nil {
 		conn.Release()
 		return nil, nil, fmt.Errorf("unable to set tenant_id: %w", err)
@@ -70,21 +206,57 @@ func (d *DB) WithTenant(ctx context.Context, tenantID string) (context.Context,
 	return ctx, conn, nil
 }
 
-// BeginTx starts a transaction with tenant context
+// WithoutStatementTimeout lifts the statement timeout of a connection for
+// streaming reads that run as long as the client keeps reading, bounded by
+// their context instead. The returned function restores the timeout and must
+// be called before the connection is released.
+func WithoutStatementTimeout(ctx context.Context, conn *pgxpool.Conn) (func(), error) {
+	if _, err := conn.Exec(ctx, "SET statement_timeout = 0"); err != nil {
+		return nil, fmt.Errorf("unable to lift statement timeout: %w", err)
+	}
+
+	return func() {
+		// A connection whose timeout cannot be restored is not reused
+		if _, err := conn.Exec(context.Background(), "RESET statement_timeout"); err != nil {
+			conn.Conn().Close(context.Background())
+		}
+	}, nil
+}
+
+// BeginTx starts a transaction with tenant context. Like WithTenant it fails
+// with ErrCircuitOpen while the circuit breaker is open.
 func (d *DB) BeginTx(ctx context.Context, tenantID string) (*TenantTx, error) {
+	return d.beginTx(ctx, tenantID, pgx.TxOptions{})
+}
+
+// BeginSnapshotTx starts a read-only repeatable read transaction with
+// tenant context, whose reads all see the data as of its first statement
+func (d *DB) BeginSnapshotTx(ctx context.Context, tenantID string) (*TenantTx, error) {
+	return d.beginTx(ctx, tenantID, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+}
+
+func (d *DB) beginTx(ctx context.Context, tenantID string, opts pgx.TxOptions) (*TenantTx, error) {
+	if err := d.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
 	conn, err := d.pool.Acquire(ctx)
 	if err != nil {
+		d.breaker.Record(err)
 		return nil, fmt.Errorf("unable to acquire connection: %w", err)
 	}
+	d.tracer.setTenant(conn.Conn(), tenantID)
 
-	tx, err := conn.Begin(ctx)
+	tx, err := conn.BeginTx(ctx, opts)
 	if err != nil {
+		d.breaker.Record(err)
 		conn.Release()
 		return nil, fmt.Errorf("unable to begin transaction: %w", err)
 	}
 
 	// Set the tenant_id for Row-Level Security within the transaction
 	_, err = tx.Exec(ctx, "SET LOCAL app.current_tenant_id = $1", tenantID)
+	d.breaker.Record(err)
 	if err != nil {
 		_ = tx.Rollback(ctx)
 		conn.Release()
@@ -92,17 +264,40 @@ func (d *DB) BeginTx(ctx context.Context, tenantID string) (*TenantTx, error) {
 	}
 
 	return &TenantTx{
-		tx:       tx,
-		conn:     conn,
-		tenantID: tenantID,
+		tx:                tx,
+		conn:              conn,
+		tenantID:          tenantID,
+		sqlFunctions:      d.sqlFunctions,
+		deferPeriodTotals: d.deferPeriodTotals,
+		metadataKeyring:   d.metadataKeyring,
 	}, nil
 }
 
 // TenantTx wraps a transaction with tenant context
 type TenantTx struct {
-	tx       pgx.Tx
-	conn     *pgxpool.Conn
-	tenantID string
+	tx                pgx.Tx
+	conn              *pgxpool.Conn
+	tenantID          string
+	sqlFunctions      bool
+	deferPeriodTotals bool
+	metadataKeyring   *keyring.Keyring
+}
+
+// SQLFunctions reports whether the legacy database functions are enabled,
+// see DB.SQLFunctions
+func (t *TenantTx) SQLFunctions() bool {
+	return t.sqlFunctions
+}
+
+// DeferPeriodTotals reports whether posted entries are queued for the
+// period totals runner, see DB.DeferPeriodTotals
+func (t *TenantTx) DeferPeriodTotals() bool {
+	return t.deferPeriodTotals
+}
+
+// MetadataKeyring returns the keyring of the pool, see DB.MetadataKeyring
+func (t *TenantTx) MetadataKeyring() *keyring.Keyring {
+	return t.metadataKeyring
 }
 
 // Exec executes a query within the tenant transaction
@@ -121,6 +316,11 @@ func (t *TenantTx) QueryRow(ctx context.Context, sql string, args ...interface{}
 	return t.tx.QueryRow(ctx, sql, args...)
 }
 
+// CopyFrom bulk loads rows into a table with COPY within the tenant transaction
+func (t *TenantTx) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error) {
+	return t.tx.CopyFrom(ctx, table, columns, rows)
+}
+
 // Commit commits the transaction and releases the connection
 func (t *TenantTx) Commit(ctx context.Context) error {
 	err := t.tx.Commit(ctx)
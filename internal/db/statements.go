@@ -0,0 +1,32 @@
+package db
+
+import (
+	"github.com/hesabFun/ledger/internal/config"
+	"github.com/jackc/pgx/v5"
+)
+
+// queryExecModes maps the statement cache modes of the configuration to
+// their pgx query exec modes
+var queryExecModes = map[string]pgx.QueryExecMode{
+	config.StatementCacheStatement:      pgx.QueryExecModeCacheStatement,
+	config.StatementCacheDescribe:       pgx.QueryExecModeCacheDescribe,
+	config.StatementCacheDescribeExec:   pgx.QueryExecModeDescribeExec,
+	config.StatementCacheExec:           pgx.QueryExecModeExec,
+	config.StatementCacheSimpleProtocol: pgx.QueryExecModeSimpleProtocol,
+}
+
+// applyStatementCache configures how connections prepare and cache
+// statements. The repositories keep each query to a fixed text whatever the
+// filters, so a handful of statements per connection serve every call.
+func applyStatementCache(connConfig *pgx.ConnConfig, cfg config.StatementCacheConfig) {
+	if mode, ok := queryExecModes[cfg.Mode]; ok {
+		connConfig.DefaultQueryExecMode = mode
+	}
+	if cfg.Capacity > 0 {
+		connConfig.StatementCacheCapacity = cfg.Capacity
+		connConfig.DescriptionCacheCapacity = cfg.Capacity
+	}
+	if cfg.PlanCacheMode != "" {
+		connConfig.RuntimeParams["plan_cache_mode"] = cfg.PlanCacheMode
+	}
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/hesabFun/ledger/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyStatementCache(t *testing.T) {
+	parse := func(t *testing.T) *pgx.ConnConfig {
+		t.Helper()
+		connConfig, err := pgx.ParseConfig("postgres://ledger@localhost/ledger")
+		require.NoError(t, err)
+		return connConfig
+	}
+
+	t.Run("caches prepared statements", func(t *testing.T) {
+		connConfig := parse(t)
+		applyStatementCache(connConfig, config.StatementCacheConfig{Mode: config.StatementCacheStatement, Capacity: 128})
+
+		assert.Equal(t, pgx.QueryExecModeCacheStatement, connConfig.DefaultQueryExecMode)
+		assert.Equal(t, 128, connConfig.StatementCacheCapacity)
+		assert.Equal(t, 128, connConfig.DescriptionCacheCapacity)
+		assert.NotContains(t, connConfig.RuntimeParams, "plan_cache_mode")
+	})
+
+	t.Run("uses the simple protocol behind a pooler and sets the plan cache mode", func(t *testing.T) {
+		connConfig := parse(t)
+		applyStatementCache(connConfig, config.StatementCacheConfig{
+			Mode:          config.StatementCacheSimpleProtocol,
+			PlanCacheMode: "force_custom_plan",
+		})
+
+		assert.Equal(t, pgx.QueryExecModeSimpleProtocol, connConfig.DefaultQueryExecMode)
+		assert.Equal(t, "force_custom_plan", connConfig.RuntimeParams["plan_cache_mode"])
+	})
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/requestid"
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+)
+
+// noMethod labels queries that do not run on behalf of a gRPC call, such as
+// those of the background jobs
+const noMethod = "none"
+
+// queryTracer times every query and COPY, exporting the durations per gRPC
+// method and logging statements slower than a threshold with the tenant and
+// method they ran for
+type queryTracer struct {
+	slowThreshold time.Duration
+	duration      *prometheus.HistogramVec
+
+	// tenants holds the tenant set on each connection by WithTenant and
+	// BeginTx, until the connection goes back to the pool
+	tenants sync.Map
+}
+
+// traceStart is carried in the context from the start to the end of a query
+type traceStart struct {
+	sql   string
+	start time.Time
+}
+
+type traceStartKey struct{}
+
+// newQueryTracer creates a tracer logging statements that take at least
+// slowThreshold, 0 disables the log
+func newQueryTracer(slowThreshold time.Duration) *queryTracer {
+	return &queryTracer{
+		slowThreshold: slowThreshold,
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ledger_db_query_duration_seconds",
+			Help:    "Duration of database queries by gRPC method.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"method"}),
+	}
+}
+
+// setTenant records the tenant a connection was acquired for
+func (t *queryTracer) setTenant(conn *pgx.Conn, tenantID string) {
+	t.tenants.Store(conn, tenantID)
+}
+
+// forget drops the tenant of a connection released to or removed from the pool
+func (t *queryTracer) forget(conn *pgx.Conn) {
+	t.tenants.Delete(conn)
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, traceStartKey{}, traceStart{sql: data.SQL, start: time.Now()})
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	t.end(ctx, conn)
+}
+
+func (t *queryTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	sql := "COPY " + data.TableName.Sanitize() + " (" + strings.Join(data.ColumnNames, ", ") + ")"
+	return context.WithValue(ctx, traceStartKey{}, traceStart{sql: sql, start: time.Now()})
+}
+
+func (t *queryTracer) TraceCopyFromEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	t.end(ctx, conn)
+}
+
+// end observes the duration of the statement started in ctx and logs it if
+// it was slow
+func (t *queryTracer) end(ctx context.Context, conn *pgx.Conn) {
+	started, ok := ctx.Value(traceStartKey{}).(traceStart)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(started.start)
+
+	method, ok := grpc.Method(ctx)
+	if !ok {
+		method = noMethod
+	}
+	t.duration.WithLabelValues(method).Observe(elapsed.Seconds())
+
+	if t.slowThreshold <= 0 || elapsed < t.slowThreshold {
+		return
+	}
+
+	tenant := "-"
+	if tenantID, ok := t.tenants.Load(conn); ok {
+		tenant = tenantID.(string)
+	}
+	requestID := requestid.FromContext(ctx)
+	if requestID == "" {
+		requestID = "-"
+	}
+	log.Printf("Slow query took %s method=%s tenant=%s request_id=%s: %s",
+		elapsed.Round(time.Millisecond), method, tenant, requestID, strings.Join(strings.Fields(started.sql), " "))
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// fakeTransportStream makes grpc.Method report a method for a context
+type fakeTransportStream struct{}
+
+func (fakeTransportStream) Method() string                  { return "/ledger.v1.LedgerService/ListJournalEntries" }
+func (fakeTransportStream) SetHeader(md metadata.MD) error  { return nil }
+func (fakeTransportStream) SendHeader(md metadata.MD) error { return nil }
+func (fakeTransportStream) SetTrailer(md metadata.MD) error { return nil }
+
+func TestQueryTracer(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	conn := &pgx.Conn{}
+	callCtx := grpc.NewContextWithServerTransportStream(context.Background(), fakeTransportStream{})
+
+	// run traces a statement that appears to have started elapsed ago
+	run := func(tracer *queryTracer, ctx context.Context, elapsed time.Duration) {
+		ctx = tracer.TraceQueryStart(ctx, conn, pgx.TraceQueryStartData{SQL: "SELECT *\n\t\tFROM journal_entries"})
+		started := ctx.Value(traceStartKey{}).(traceStart)
+		started.start = started.start.Add(-elapsed)
+		tracer.TraceQueryEnd(context.WithValue(ctx, traceStartKey{}, started), conn, pgx.TraceQueryEndData{})
+	}
+
+	t.Run("observes durations per method", func(t *testing.T) {
+		tracer := newQueryTracer(0)
+		run(tracer, callCtx, time.Millisecond)
+		run(tracer, callCtx, time.Millisecond)
+		run(tracer, context.Background(), time.Millisecond)
+
+		assert.Equal(t, 2, testutil.CollectAndCount(tracer.duration))
+		assert.Empty(t, logs.String())
+	})
+
+	t.Run("logs slow queries with their tenant and method", func(t *testing.T) {
+		logs.Reset()
+		tracer := newQueryTracer(100 * time.Millisecond)
+		tracer.setTenant(conn, "tenant-1")
+
+		run(tracer, callCtx, 10*time.Millisecond)
+		assert.Empty(t, logs.String())
+
+		run(tracer, callCtx, 200*time.Millisecond)
+		assert.Contains(t, logs.String(), "method=/ledger.v1.LedgerService/ListJournalEntries tenant=tenant-1")
+		assert.Contains(t, logs.String(), ": SELECT * FROM journal_entries")
+	})
+
+	t.Run("forgets the tenant of a released connection", func(t *testing.T) {
+		logs.Reset()
+		tracer := newQueryTracer(100 * time.Millisecond)
+		tracer.setTenant(conn, "tenant-1")
+		tracer.forget(conn)
+
+		run(tracer, context.Background(), 200*time.Millisecond)
+		assert.Contains(t, logs.String(), "method=none tenant=- request_id=-")
+	})
+}
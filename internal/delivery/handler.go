@@ -0,0 +1,219 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/auth"
+	"github.com/hesabFun/ledger/internal/eventsig"
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/metadata"
+)
+
+// Delivery is the delivery state of an event as returned by the handler,
+// with the message the destination receives
+type Delivery struct {
+	*Message
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	LastError   *string    `json:"last_error,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+}
+
+// FailedDeliveries is a page of dead-lettered events, with the sequence to
+// pass as after_sequence for the next page
+type FailedDeliveries struct {
+	Deliveries   []*Delivery `json:"deliveries"`
+	LastSequence int64       `json:"last_sequence"`
+}
+
+// Replayed counts the dead-lettered events returned to pending by a replay
+type Replayed struct {
+	Replayed int64 `json:"replayed"`
+}
+
+// Handler serves the admin endpoints that list, inspect and replay failed
+// event deliveries of a tenant:
+//
+//	GET  /v1/tenants/{tenant_id}/event-deliveries/failed?after_sequence=&page_size=
+//	GET  /v1/tenants/{tenant_id}/event-deliveries/{sequence}
+//	POST /v1/tenants/{tenant_id}/event-deliveries/failed/replay
+//	POST /v1/tenants/{tenant_id}/event-deliveries/{sequence}/replay
+//
+// Every request must carry the admin bearer token. A replayed event is
+// delivered again by the next delivery run, in sequence order.
+type Handler struct {
+	deliveryRepo  repository.EventDeliveryRepositoryInterface
+	authenticator *auth.TokenAuthenticator
+	signer        *eventsig.Signer
+	mux           *http.ServeMux
+}
+
+// NewHandler creates a handler authenticating requests with authenticator;
+// signer, when set, signs the messages it returns as the sink does
+func NewHandler(deliveryRepo repository.EventDeliveryRepositoryInterface, authenticator *auth.TokenAuthenticator, signer *eventsig.Signer) *Handler {
+	h := &Handler{
+		deliveryRepo:  deliveryRepo,
+		authenticator: authenticator,
+		signer:        signer,
+		mux:           http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /v1/tenants/{tenant_id}/event-deliveries/failed", h.listFailed)
+	h.mux.HandleFunc("GET /v1/tenants/{tenant_id}/event-deliveries/{sequence}", h.get)
+	h.mux.HandleFunc("POST /v1/tenants/{tenant_id}/event-deliveries/failed/replay", h.replay)
+	h.mux.HandleFunc("POST /v1/tenants/{tenant_id}/event-deliveries/{sequence}/replay", h.replay)
+
+	return h
+}
+
+// ServeHTTP authenticates a request and routes it to its endpoint
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	md := metadata.Pairs("authorization", r.Header.Get("Authorization"))
+	if err := h.authenticator.Authenticate(metadata.NewIncomingContext(r.Context(), md)); err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthenticated")
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) listFailed(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantOf(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	afterSequence, err := intParam(query.Get("after_sequence"), 0)
+	if err != nil || afterSequence < 0 {
+		writeError(w, http.StatusBadRequest, "after_sequence must be a non-negative integer")
+		return
+	}
+	pageSize, err := intParam(query.Get("page_size"), 50)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "page_size must be an integer")
+		return
+	}
+	pageSize = min(max(pageSize, 1), 100)
+
+	deliveries, err := h.deliveryRepo.ListFailed(r.Context(), tenantID, afterSequence, int(pageSize))
+	if err != nil {
+		writeRepositoryError(w, "list failed event deliveries", err)
+		return
+	}
+
+	page := &FailedDeliveries{
+		Deliveries:   make([]*Delivery, len(deliveries)),
+		LastSequence: afterSequence,
+	}
+	for i, delivery := range deliveries {
+		page.Deliveries[i] = h.delivery(delivery)
+		page.LastSequence = delivery.Event.Sequence
+	}
+	writeJSON(w, page)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantOf(w, r)
+	if !ok {
+		return
+	}
+	sequence, ok := sequenceOf(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.deliveryRepo.Get(r.Context(), tenantID, *sequence)
+	if err != nil {
+		writeRepositoryError(w, "get event delivery", err)
+		return
+	}
+	writeJSON(w, h.delivery(delivery))
+}
+
+// replay returns one dead-lettered event to pending, or all of them when the
+// path names no sequence
+func (h *Handler) replay(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantOf(w, r)
+	if !ok {
+		return
+	}
+	var sequence *int64
+	if r.PathValue("sequence") != "" {
+		if sequence, ok = sequenceOf(w, r); !ok {
+			return
+		}
+	}
+
+	replayed, err := h.deliveryRepo.Replay(r.Context(), tenantID, sequence)
+	if err != nil {
+		writeRepositoryError(w, "replay event deliveries", err)
+		return
+	}
+	writeJSON(w, &Replayed{Replayed: replayed})
+}
+
+func (h *Handler) delivery(delivery *repository.EventDelivery) *Delivery {
+	return &Delivery{
+		Message:     NewMessage(delivery.Event, h.signer),
+		Status:      delivery.Status,
+		Attempts:    delivery.Attempts,
+		LastError:   delivery.LastError,
+		UpdatedAt:   delivery.UpdatedAt,
+		DeliveredAt: delivery.DeliveredAt,
+	}
+}
+
+func tenantOf(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	tenantID, err := uuid.Parse(r.PathValue("tenant_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid tenant_id")
+		return uuid.Nil, false
+	}
+	return tenantID, true
+}
+
+func sequenceOf(w http.ResponseWriter, r *http.Request) (*int64, bool) {
+	sequence, err := strconv.ParseInt(r.PathValue("sequence"), 10, 64)
+	if err != nil || sequence < 1 {
+		writeError(w, http.StatusBadRequest, "invalid sequence")
+		return nil, false
+	}
+	return &sequence, true
+}
+
+// intParam parses an optional integer query parameter
+func intParam(value string, fallback int64) (int64, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// writeRepositoryError responds with not found for a missing event, and
+// otherwise logs the error and hides it behind an internal error
+func writeRepositoryError(w http.ResponseWriter, operation string, err error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	redact.Printf(redact.LevelError, "failed to %s: %v", operation, err)
+	writeError(w, http.StatusInternalServerError, "failed to "+operation)
+}
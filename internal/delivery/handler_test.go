@@ -0,0 +1,124 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/auth"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (f *fakeDeliveryRepository) ListFailed(ctx context.Context, tenantID uuid.UUID, afterSequence int64, limit int) ([]*repository.EventDelivery, error) {
+	var failed []*repository.EventDelivery
+	for _, delivery := range f.deliveries[tenantID] {
+		if delivery.Status == repository.EventDeliveryFailed && delivery.Event.Sequence > afterSequence && len(failed) < limit {
+			failed = append(failed, delivery)
+		}
+	}
+	return failed, nil
+}
+
+func (f *fakeDeliveryRepository) Get(ctx context.Context, tenantID uuid.UUID, sequence int64) (*repository.EventDelivery, error) {
+	if delivery := f.find(tenantID, sequence); delivery != nil {
+		return delivery, nil
+	}
+	return nil, fmt.Errorf("ledger event %w", repository.ErrNotFound)
+}
+
+func (f *fakeDeliveryRepository) Replay(ctx context.Context, tenantID uuid.UUID, sequence *int64) (int64, error) {
+	var replayed int64
+	for _, delivery := range f.deliveries[tenantID] {
+		if delivery.Status == repository.EventDeliveryFailed && (sequence == nil || delivery.Event.Sequence == *sequence) {
+			delivery.Status = repository.EventDeliveryPending
+			delivery.Attempts = 0
+			replayed++
+		}
+	}
+	if sequence != nil && replayed == 0 {
+		return 0, fmt.Errorf("dead-lettered event %w", repository.ErrNotFound)
+	}
+	return replayed, nil
+}
+
+func TestHandler(t *testing.T) {
+	tenantID := uuid.New()
+	repo := &fakeDeliveryRepository{deliveries: map[uuid.UUID][]*repository.EventDelivery{}}
+	for sequence := int64(1); sequence <= 3; sequence++ {
+		repo.add(tenantID, sequence)
+	}
+	reason := "webhook responded 503 Service Unavailable"
+	for _, sequence := range []int64{1, 3} {
+		delivery := repo.find(tenantID, sequence)
+		delivery.Status, delivery.Attempts, delivery.LastError = repository.EventDeliveryFailed, 5, &reason
+	}
+	handler := NewHandler(repo, auth.NewTokenAuthenticator("admin-token"), nil)
+
+	serve := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	base := "/v1/tenants/" + tenantID.String() + "/event-deliveries"
+
+	t.Run("requires the admin token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, base+"/failed", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, base+"/failed", "wrong").Code)
+	})
+
+	t.Run("lists failed deliveries page by page", func(t *testing.T) {
+		rec := serve(http.MethodGet, base+"/failed?page_size=1", "admin-token")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var page FailedDeliveries
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		require.Len(t, page.Deliveries, 1)
+		assert.Equal(t, int64(1), page.Deliveries[0].Sequence)
+		assert.Equal(t, 5, page.Deliveries[0].Attempts)
+		assert.Equal(t, reason, *page.Deliveries[0].LastError)
+		assert.Equal(t, int64(1), page.LastSequence)
+
+		rec = serve(http.MethodGet, fmt.Sprintf("%s/failed?after_sequence=%d", base, page.LastSequence), "admin-token")
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		require.Len(t, page.Deliveries, 1)
+		assert.Equal(t, int64(3), page.Deliveries[0].Sequence)
+
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, base+"/failed?after_sequence=-1", "admin-token").Code)
+	})
+
+	t.Run("inspects the delivery of an event", func(t *testing.T) {
+		rec := serve(http.MethodGet, base+"/3", "admin-token")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var delivery Delivery
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &delivery))
+		assert.Equal(t, repository.EventDeliveryFailed, delivery.Status)
+		assert.Equal(t, repository.EventAccountCreated, delivery.EventType)
+		assert.Equal(t, tenantID.String(), delivery.TenantID)
+
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, base+"/9", "admin-token").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, base+"/abc", "admin-token").Code)
+	})
+
+	t.Run("replays one failed delivery or all of them", func(t *testing.T) {
+		rec := serve(http.MethodPost, base+"/1/replay", "admin-token")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"replayed": 1}`, rec.Body.String())
+		assert.Equal(t, repository.EventDeliveryPending, repo.find(tenantID, 1).Status)
+
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, base+"/1/replay", "admin-token").Code)
+
+		rec = serve(http.MethodPost, base+"/failed/replay", "admin-token")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"replayed": 1}`, rec.Body.String())
+		assert.Equal(t, repository.EventDeliveryPending, repo.find(tenantID, 3).Status)
+	})
+}
@@ -0,0 +1,173 @@
+package delivery
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// batchSize is the number of pending events read at a time
+const batchSize = 100
+
+// Runner periodically delivers the pending events of every tenant to a sink
+// and reports the outcome and the backlog left as metrics and log lines
+type Runner struct {
+	tenantRepo   repository.TenantRepositoryInterface
+	deliveryRepo repository.EventDeliveryRepositoryInterface
+	sink         Sink
+	interval     time.Duration
+	maxAttempts  int
+
+	delivered    prometheus.Counter
+	failures     prometheus.Counter
+	deadLettered prometheus.Counter
+	errors       prometheus.Counter
+	pending      prometheus.Gauge
+	failed       prometheus.Gauge
+}
+
+// NewRunner creates a new runner dead-lettering an event once maxAttempts
+// runs failed to deliver it, and registers its metrics with reg
+func NewRunner(
+	tenantRepo repository.TenantRepositoryInterface,
+	deliveryRepo repository.EventDeliveryRepositoryInterface,
+	sink Sink,
+	interval time.Duration,
+	maxAttempts int,
+	reg prometheus.Registerer,
+) *Runner {
+	r := &Runner{
+		tenantRepo:   tenantRepo,
+		deliveryRepo: deliveryRepo,
+		sink:         sink,
+		interval:     interval,
+		maxAttempts:  maxAttempts,
+		delivered: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_events_delivered_total",
+			Help: "Ledger events accepted by the delivery destination.",
+		}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_event_delivery_failures_total",
+			Help: "Attempts to deliver a ledger event that the destination did not accept.",
+		}),
+		deadLettered: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_events_dead_lettered_total",
+			Help: "Ledger events dead-lettered after their last delivery attempt failed.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_event_delivery_errors_total",
+			Help: "Event delivery runs that failed for a tenant.",
+		}),
+		pending: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ledger_event_delivery_backlog",
+			Help: "Ledger events waiting to be delivered after the last delivery run.",
+		}),
+		failed: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ledger_event_dead_letters",
+			Help: "Dead-lettered ledger events waiting to be replayed after the last delivery run.",
+		}),
+	}
+
+	reg.MustRegister(r.delivered, r.failures, r.deadLettered, r.errors, r.pending, r.failed)
+
+	return r
+}
+
+// Run delivers events once per interval until ctx is cancelled
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.DeliverAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverAll delivers the pending events of every active tenant, oldest
+// first, and then counts the events left. A tenant whose event fails without
+// being dead-lettered is left until the next run, so its events are not
+// delivered out of order. A tenant whose run fails is logged and counted,
+// and the run carries on with the others.
+func (r *Runner) DeliverAll(ctx context.Context) {
+	tenantIDs, err := r.tenantRepo.ListIDs(ctx)
+	if err != nil {
+		redact.Printf(redact.LevelError, "event delivery run: %v", err)
+		r.errors.Inc()
+		return
+	}
+
+	var pending, failed int64
+	for _, tenantID := range tenantIDs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := r.deliver(ctx, tenantID); err != nil {
+			redact.Printf(redact.LevelError, "event delivery run of tenant %s: %v", tenantID, err)
+			r.errors.Inc()
+		}
+
+		backlog, err := r.deliveryRepo.Backlog(ctx, tenantID)
+		if err != nil {
+			redact.Printf(redact.LevelError, "event delivery backlog of tenant %s: %v", tenantID, err)
+			r.errors.Inc()
+			continue
+		}
+		pending += backlog.Pending
+		failed += backlog.Failed
+	}
+
+	r.pending.Set(float64(pending))
+	r.failed.Set(float64(failed))
+}
+
+// deliver delivers the pending events of a tenant until none is left or one
+// fails without being dead-lettered
+func (r *Runner) deliver(ctx context.Context, tenantID uuid.UUID) error {
+	for {
+		deliveries, err := r.deliveryRepo.Pending(ctx, tenantID, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			sequence := delivery.Event.Sequence
+			if err := r.sink.Deliver(ctx, delivery.Event); err != nil {
+				r.failures.Inc()
+				deadLetter := delivery.Attempts+1 >= r.maxAttempts
+				if err := r.deliveryRepo.MarkFailed(ctx, tenantID, sequence, err.Error(), deadLetter); err != nil {
+					return err
+				}
+				if !deadLetter {
+					return nil
+				}
+				redact.Printf(redact.LevelError, "dead-lettered event %d of tenant %s after %d attempts: %v", sequence, tenantID, delivery.Attempts+1, err)
+				r.deadLettered.Inc()
+				continue
+			}
+
+			if err := r.deliveryRepo.MarkDelivered(ctx, tenantID, sequence); err != nil {
+				return err
+			}
+			r.delivered.Inc()
+		}
+
+		if len(deliveries) < batchSize {
+			return nil
+		}
+	}
+}
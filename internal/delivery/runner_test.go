@@ -0,0 +1,169 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTenantRepository struct {
+	repository.TenantRepositoryInterface
+	ids []uuid.UUID
+}
+
+func (f *fakeTenantRepository) ListIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return f.ids, nil
+}
+
+// fakeDeliveryRepository holds the deliveries of each tenant by sequence
+type fakeDeliveryRepository struct {
+	repository.EventDeliveryRepositoryInterface
+	deliveries map[uuid.UUID][]*repository.EventDelivery
+	pendingErr error
+}
+
+func (f *fakeDeliveryRepository) add(tenantID uuid.UUID, sequence int64) {
+	f.deliveries[tenantID] = append(f.deliveries[tenantID], &repository.EventDelivery{
+		Event:  &repository.LedgerEvent{TenantID: tenantID, Sequence: sequence, EventType: repository.EventAccountCreated},
+		Status: repository.EventDeliveryPending,
+	})
+}
+
+func (f *fakeDeliveryRepository) find(tenantID uuid.UUID, sequence int64) *repository.EventDelivery {
+	for _, delivery := range f.deliveries[tenantID] {
+		if delivery.Event.Sequence == sequence {
+			return delivery
+		}
+	}
+	return nil
+}
+
+func (f *fakeDeliveryRepository) Pending(ctx context.Context, tenantID uuid.UUID, limit int) ([]*repository.EventDelivery, error) {
+	if f.pendingErr != nil {
+		return nil, f.pendingErr
+	}
+	var pending []*repository.EventDelivery
+	for _, delivery := range f.deliveries[tenantID] {
+		if delivery.Status == repository.EventDeliveryPending && len(pending) < limit {
+			copied := *delivery
+			pending = append(pending, &copied)
+		}
+	}
+	return pending, nil
+}
+
+func (f *fakeDeliveryRepository) MarkDelivered(ctx context.Context, tenantID uuid.UUID, sequence int64) error {
+	delivery := f.find(tenantID, sequence)
+	delivery.Status = repository.EventDeliveryDelivered
+	delivery.Attempts++
+	return nil
+}
+
+func (f *fakeDeliveryRepository) MarkFailed(ctx context.Context, tenantID uuid.UUID, sequence int64, reason string, deadLetter bool) error {
+	delivery := f.find(tenantID, sequence)
+	if deadLetter {
+		delivery.Status = repository.EventDeliveryFailed
+	}
+	delivery.Attempts++
+	delivery.LastError = &reason
+	return nil
+}
+
+func (f *fakeDeliveryRepository) Backlog(ctx context.Context, tenantID uuid.UUID) (*repository.EventDeliveryBacklog, error) {
+	backlog := &repository.EventDeliveryBacklog{}
+	for _, delivery := range f.deliveries[tenantID] {
+		switch delivery.Status {
+		case repository.EventDeliveryPending:
+			backlog.Pending++
+		case repository.EventDeliveryFailed:
+			backlog.Failed++
+		}
+	}
+	return backlog, nil
+}
+
+// fakeSink records the events it accepts and refuses the sequences in refuse
+type fakeSink struct {
+	delivered []int64
+	refuse    map[int64]bool
+}
+
+func (f *fakeSink) Deliver(ctx context.Context, event *repository.LedgerEvent) error {
+	if f.refuse[event.Sequence] {
+		return errors.New("webhook responded 503 Service Unavailable")
+	}
+	f.delivered = append(f.delivered, event.Sequence)
+	return nil
+}
+
+func TestRunner_DeliverAll(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("delivers pending events in sequence order", func(t *testing.T) {
+		tenantID := uuid.New()
+		repo := &fakeDeliveryRepository{deliveries: map[uuid.UUID][]*repository.EventDelivery{}}
+		for sequence := int64(1); sequence <= batchSize+5; sequence++ {
+			repo.add(tenantID, sequence)
+		}
+		sink := &fakeSink{}
+		runner := NewRunner(&fakeTenantRepository{ids: []uuid.UUID{tenantID}}, repo, sink, 0, 3, prometheus.NewRegistry())
+
+		runner.DeliverAll(ctx)
+
+		assert.Len(t, sink.delivered, batchSize+5)
+		assert.Equal(t, int64(1), sink.delivered[0])
+		assert.Equal(t, int64(batchSize+5), sink.delivered[batchSize+4])
+		assert.Equal(t, float64(batchSize+5), testutil.ToFloat64(runner.delivered))
+		assert.Zero(t, testutil.ToFloat64(runner.pending))
+	})
+
+	t.Run("holds back a tenant's events behind a failed one until it is dead-lettered", func(t *testing.T) {
+		failing, healthy := uuid.New(), uuid.New()
+		repo := &fakeDeliveryRepository{deliveries: map[uuid.UUID][]*repository.EventDelivery{}}
+		repo.add(failing, 1)
+		repo.add(failing, 2)
+		repo.add(healthy, 3)
+		sink := &fakeSink{refuse: map[int64]bool{1: true}}
+		runner := NewRunner(&fakeTenantRepository{ids: []uuid.UUID{failing, healthy}}, repo, sink, 0, 2, prometheus.NewRegistry())
+
+		runner.DeliverAll(ctx)
+
+		assert.Equal(t, []int64{3}, sink.delivered)
+		assert.Equal(t, repository.EventDeliveryPending, repo.find(failing, 1).Status)
+		assert.Equal(t, 1, repo.find(failing, 1).Attempts)
+		assert.Equal(t, 2.0, testutil.ToFloat64(runner.pending))
+		assert.Zero(t, testutil.ToFloat64(runner.failed))
+
+		// The last attempt dead-letters the event and the next one goes out
+		runner.DeliverAll(ctx)
+
+		assert.Equal(t, []int64{3, 2}, sink.delivered)
+		delivery := repo.find(failing, 1)
+		assert.Equal(t, repository.EventDeliveryFailed, delivery.Status)
+		assert.Equal(t, 2, delivery.Attempts)
+		assert.Equal(t, "webhook responded 503 Service Unavailable", *delivery.LastError)
+		assert.Equal(t, 2.0, testutil.ToFloat64(runner.failures))
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.deadLettered))
+		assert.Zero(t, testutil.ToFloat64(runner.pending))
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.failed))
+	})
+
+	t.Run("counts a tenant whose events cannot be read", func(t *testing.T) {
+		tenantID := uuid.New()
+		repo := &fakeDeliveryRepository{
+			deliveries: map[uuid.UUID][]*repository.EventDelivery{},
+			pendingErr: errors.New("connection refused"),
+		}
+		runner := NewRunner(&fakeTenantRepository{ids: []uuid.UUID{tenantID}}, repo, &fakeSink{}, 0, 3, prometheus.NewRegistry())
+
+		runner.DeliverAll(ctx)
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.errors))
+	})
+}
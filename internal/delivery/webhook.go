@@ -0,0 +1,118 @@
+// Package delivery pushes ledger events to an external destination, such as
+// a webhook, in sequence order for each tenant. An event the destination
+// keeps refusing is dead-lettered after a number of delivery runs, so the
+// events after it are not held up, and stays dead-lettered until an operator
+// replays it through the admin HTTP endpoints.
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/eventsig"
+	"github.com/hesabFun/ledger/internal/repository"
+)
+
+// Sink is a destination events are delivered to. Deliver returns an error
+// unless the destination accepted the event.
+type Sink interface {
+	Deliver(ctx context.Context, event *repository.LedgerEvent) error
+}
+
+// Message is the JSON document a webhook receives for an event. Its fields
+// are those of a LedgerEvent returned by ListLedgerEvents.
+type Message struct {
+	TenantID      string    `json:"tenant_id"`
+	Sequence      int64     `json:"sequence"`
+	AggregateType string    `json:"aggregate_type"`
+	AggregateID   string    `json:"aggregate_id"`
+	EventType     string    `json:"event_type"`
+	SchemaVersion int32     `json:"schema_version"`
+	Payload       string    `json:"payload"`
+	RecordedAt    time.Time `json:"recorded_at"`
+	Signature     string    `json:"signature,omitempty"`
+}
+
+// NewMessage builds the message of an event, signed when signer is set
+func NewMessage(event *repository.LedgerEvent, signer *eventsig.Signer) *Message {
+	message := &Message{
+		TenantID:      event.TenantID.String(),
+		Sequence:      event.Sequence,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID.String(),
+		EventType:     event.EventType,
+		SchemaVersion: event.SchemaVersion,
+		Payload:       string(event.Payload),
+		RecordedAt:    event.RecordedAt,
+	}
+
+	if signer != nil {
+		message.Signature = signer.Sign(eventsig.Event{
+			TenantID:      event.TenantID,
+			Sequence:      event.Sequence,
+			EventType:     event.EventType,
+			SchemaVersion: event.SchemaVersion,
+			Payload:       event.Payload,
+		})
+	}
+
+	return message
+}
+
+// Webhook delivers events by POSTing their messages to a URL. Any 2xx
+// response accepts an event; consumers should ignore sequences they have
+// already seen, as an event is sent again when its acceptance is lost.
+type Webhook struct {
+	url    string
+	client *http.Client
+	signer *eventsig.Signer
+}
+
+// NewWebhook creates a webhook sink whose requests time out after timeout
+func NewWebhook(url string, timeout time.Duration, signer *eventsig.Signer) *Webhook {
+	return &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+		signer: signer,
+	}
+}
+
+// Deliver POSTs the message of an event to the webhook
+func (w *Webhook) Deliver(ctx context.Context, event *repository.LedgerEvent) error {
+	body, err := json.Marshal(NewMessage(event, w.signer))
+	if err != nil {
+		return fmt.Errorf("failed to encode event %d: %w", event.Sequence, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		// Leave out the URL, which may carry credentials, as failures are
+		// stored and shown to operators
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/eventsig"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook_Deliver(t *testing.T) {
+	ctx := context.Background()
+	event := &repository.LedgerEvent{
+		Sequence:      42,
+		TenantID:      uuid.New(),
+		AggregateType: repository.AggregateAccount,
+		AggregateID:   uuid.New(),
+		EventType:     repository.EventAccountCreated,
+		SchemaVersion: 1,
+		Payload:       json.RawMessage(`{"name": "Cash"}`),
+		RecordedAt:    time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("posts the signed message of an event", func(t *testing.T) {
+		var received Message
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		secret := []byte("secret")
+		webhook := NewWebhook(server.URL, time.Second, eventsig.NewSigner(secret))
+		require.NoError(t, webhook.Deliver(ctx, event))
+
+		assert.Equal(t, int64(42), received.Sequence)
+		assert.Equal(t, event.TenantID.String(), received.TenantID)
+		assert.Equal(t, repository.EventAccountCreated, received.EventType)
+		assert.Equal(t, `{"name": "Cash"}`, received.Payload)
+		assert.NoError(t, eventsig.Verify(received.Signature, eventsig.Event{
+			TenantID:      event.TenantID,
+			Sequence:      event.Sequence,
+			EventType:     event.EventType,
+			SchemaVersion: event.SchemaVersion,
+			Payload:       []byte(received.Payload),
+		}, secret))
+	})
+
+	t.Run("fails unless the webhook responds with a success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		err := NewWebhook(server.URL, time.Second, nil).Deliver(ctx, event)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "503")
+	})
+
+	t.Run("leaves the URL out of a failed request", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		url := server.URL + "/events?token=hidden"
+		server.Close()
+
+		err := NewWebhook(url, time.Second, nil).Deliver(ctx, event)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "hidden")
+	})
+}
@@ -0,0 +1,86 @@
+package depreciation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// Poster posts the journal entries of depreciation that has fallen due
+type Poster struct {
+	assetRepo repository.FixedAssetRepositoryInterface
+}
+
+// NewPoster creates a new poster
+func NewPoster(assetRepo repository.FixedAssetRepositoryInterface) *Poster {
+	return &Poster{assetRepo: assetRepo}
+}
+
+// PostDue posts every unposted schedule line dated on or before through, in
+// period order per asset, and returns the lines it posted. An asset ID limits
+// posting to that asset. Lines posted concurrently by another run are
+// skipped; any other error stops posting and is returned with the lines
+// posted so far.
+func (p *Poster) PostDue(ctx context.Context, tenantID uuid.UUID, assetID *uuid.UUID, through time.Time) ([]*repository.DepreciationLine, error) {
+	due, err := p.assetRepo.ListDueDepreciation(ctx, tenantID, assetID, through)
+	if err != nil {
+		return nil, err
+	}
+
+	posted := make([]*repository.DepreciationLine, 0, len(due))
+	var asset *repository.FixedAsset
+	for _, line := range due {
+		if asset == nil || asset.ID != line.AssetID {
+			asset, err = p.assetRepo.GetByID(ctx, tenantID, line.AssetID)
+			if err != nil {
+				return posted, err
+			}
+		}
+
+		result, err := p.assetRepo.PostDepreciation(ctx, tenantID, line.ID, Entry(asset, line))
+		if errors.Is(err, repository.ErrDepreciationPosted) {
+			continue
+		}
+		if err != nil {
+			return posted, fmt.Errorf("failed to post depreciation of asset %s period %d: %w", asset.Code, line.Period, err)
+		}
+		posted = append(posted, result)
+	}
+
+	return posted, nil
+}
+
+// Entry builds the journal entry for a schedule line: the depreciation
+// expense is debited and accumulated depreciation credited on the period date
+func Entry(asset *repository.FixedAsset, line *repository.DepreciationLine) repository.CreateJournalEntryParams {
+	description := fmt.Sprintf("Depreciation of %s, period %d", asset.Name, line.Period)
+
+	return repository.CreateJournalEntryParams{
+		ReferenceNumber: fmt.Sprintf("%s-DEP-%03d", asset.Code, line.Period),
+		Description:     description,
+		EntryDate:       line.PeriodDate,
+		Metadata: map[string]interface{}{
+			"fixed_asset_id":      asset.ID.String(),
+			"depreciation_period": line.Period,
+		},
+		Lines: []*repository.CreateJournalEntryLineParams{
+			{
+				AccountID:   asset.DepreciationExpenseAccountID,
+				Debit:       line.Amount,
+				Credit:      decimal.Zero,
+				Description: description,
+			},
+			{
+				AccountID:   asset.AccumulatedDepreciationAccountID,
+				Debit:       decimal.Zero,
+				Credit:      line.Amount,
+				Description: description,
+			},
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package depreciation
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Runner periodically posts the depreciation that has fallen due for every
+// tenant and reports the outcome as metrics and log lines
+type Runner struct {
+	tenantRepo repository.TenantRepositoryInterface
+	poster     *Poster
+	interval   time.Duration
+	now        func() time.Time
+
+	posted prometheus.Counter
+	errors prometheus.Counter
+}
+
+// NewRunner creates a new runner and registers its metrics with reg
+func NewRunner(
+	tenantRepo repository.TenantRepositoryInterface,
+	assetRepo repository.FixedAssetRepositoryInterface,
+	interval time.Duration,
+	reg prometheus.Registerer,
+) *Runner {
+	r := &Runner{
+		tenantRepo: tenantRepo,
+		poster:     NewPoster(assetRepo),
+		interval:   interval,
+		now:        time.Now,
+		posted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_depreciation_entries_posted_total",
+			Help: "Depreciation journal entries posted by the background runner.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_depreciation_errors_total",
+			Help: "Depreciation runs that failed for a tenant.",
+		}),
+	}
+
+	reg.MustRegister(r.posted, r.errors)
+
+	return r
+}
+
+// Run posts due depreciation once per interval until ctx is cancelled
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.PostAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PostAll posts the depreciation due up to now for every active tenant. A
+// tenant whose run fails is logged and counted, and the run carries on with
+// the others.
+func (r *Runner) PostAll(ctx context.Context) {
+	tenantIDs, err := r.tenantRepo.ListIDs(ctx)
+	if err != nil {
+		redact.Printf(redact.LevelError, "depreciation run: %v", err)
+		r.errors.Inc()
+		return
+	}
+
+	through := r.now()
+	for _, tenantID := range tenantIDs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		posted, err := r.poster.PostDue(ctx, tenantID, nil, through)
+		r.posted.Add(float64(len(posted)))
+		if err != nil {
+			redact.Printf(redact.LevelError, "depreciation run of tenant %s: %v", tenantID, err)
+			r.errors.Inc()
+			continue
+		}
+		if len(posted) > 0 {
+			log.Printf("depreciation run of tenant %s: posted %d entries", tenantID, len(posted))
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package depreciation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTenantRepository struct {
+	repository.TenantRepositoryInterface
+	ids []uuid.UUID
+	err error
+}
+
+func (f *fakeTenantRepository) ListIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return f.ids, f.err
+}
+
+type fakeAssetRepository struct {
+	repository.FixedAssetRepositoryInterface
+	assets  map[uuid.UUID]*repository.FixedAsset
+	due     map[uuid.UUID][]*repository.DepreciationLine
+	through time.Time
+	// postErr is returned when posting the line with this ID
+	postErr map[uuid.UUID]error
+	entries []repository.CreateJournalEntryParams
+}
+
+func (f *fakeAssetRepository) GetByID(ctx context.Context, tenantID uuid.UUID, assetID uuid.UUID) (*repository.FixedAsset, error) {
+	asset, ok := f.assets[assetID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return asset, nil
+}
+
+func (f *fakeAssetRepository) ListDueDepreciation(ctx context.Context, tenantID uuid.UUID, assetID *uuid.UUID, through time.Time) ([]*repository.DepreciationLine, error) {
+	f.through = through
+	lines, ok := f.due[tenantID]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	return lines, nil
+}
+
+func (f *fakeAssetRepository) PostDepreciation(ctx context.Context, tenantID uuid.UUID, lineID uuid.UUID, entry repository.CreateJournalEntryParams) (*repository.DepreciationLine, error) {
+	if err := f.postErr[lineID]; err != nil {
+		return nil, err
+	}
+	f.entries = append(f.entries, entry)
+	entryID := uuid.New()
+	return &repository.DepreciationLine{ID: lineID, JournalEntryID: &entryID}, nil
+}
+
+func TestPoster_PostDue(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	expenseID, accumulatedID := uuid.New(), uuid.New()
+	asset := &repository.FixedAsset{
+		ID:                               uuid.New(),
+		Code:                             "FA-001",
+		Name:                             "Delivery van",
+		DepreciationExpenseAccountID:     expenseID,
+		AccumulatedDepreciationAccountID: accumulatedID,
+	}
+	january := &repository.DepreciationLine{
+		ID:         uuid.New(),
+		AssetID:    asset.ID,
+		Period:     1,
+		PeriodDate: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		Amount:     decimal.NewFromInt(75),
+	}
+	february := &repository.DepreciationLine{
+		ID:         uuid.New(),
+		AssetID:    asset.ID,
+		Period:     2,
+		PeriodDate: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		Amount:     decimal.NewFromInt(75),
+	}
+
+	t.Run("posts an expense entry per due line", func(t *testing.T) {
+		assetRepo := &fakeAssetRepository{
+			assets: map[uuid.UUID]*repository.FixedAsset{asset.ID: asset},
+			due:    map[uuid.UUID][]*repository.DepreciationLine{tenantID: {january, february}},
+		}
+
+		posted, err := NewPoster(assetRepo).PostDue(ctx, tenantID, nil, time.Now())
+		require.NoError(t, err)
+
+		assert.Len(t, posted, 2)
+		require.Len(t, assetRepo.entries, 2)
+		entry := assetRepo.entries[0]
+		assert.Equal(t, "FA-001-DEP-001", entry.ReferenceNumber)
+		assert.Equal(t, january.PeriodDate, entry.EntryDate)
+		assert.Equal(t, expenseID, entry.Lines[0].AccountID)
+		assert.True(t, entry.Lines[0].Debit.Equal(decimal.NewFromInt(75)))
+		assert.Equal(t, accumulatedID, entry.Lines[1].AccountID)
+		assert.True(t, entry.Lines[1].Credit.Equal(decimal.NewFromInt(75)))
+	})
+
+	t.Run("skips lines posted by another run", func(t *testing.T) {
+		assetRepo := &fakeAssetRepository{
+			assets:  map[uuid.UUID]*repository.FixedAsset{asset.ID: asset},
+			due:     map[uuid.UUID][]*repository.DepreciationLine{tenantID: {january, february}},
+			postErr: map[uuid.UUID]error{january.ID: repository.ErrDepreciationPosted},
+		}
+
+		posted, err := NewPoster(assetRepo).PostDue(ctx, tenantID, nil, time.Now())
+		require.NoError(t, err)
+
+		require.Len(t, posted, 1)
+		assert.Equal(t, february.ID, posted[0].ID)
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		assetRepo := &fakeAssetRepository{
+			assets:  map[uuid.UUID]*repository.FixedAsset{asset.ID: asset},
+			due:     map[uuid.UUID][]*repository.DepreciationLine{tenantID: {january, february}},
+			postErr: map[uuid.UUID]error{january.ID: repository.ErrDeletedAccount},
+		}
+
+		posted, err := NewPoster(assetRepo).PostDue(ctx, tenantID, nil, time.Now())
+		assert.ErrorIs(t, err, repository.ErrDeletedAccount)
+		assert.Empty(t, posted)
+	})
+}
+
+func TestRunner_PostAll(t *testing.T) {
+	ctx := context.Background()
+	healthy, failing := uuid.New(), uuid.New()
+	asset := &repository.FixedAsset{ID: uuid.New(), Code: "FA-001"}
+	now := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+
+	t.Run("posts due depreciation and counts failing tenants", func(t *testing.T) {
+		assetRepo := &fakeAssetRepository{
+			assets: map[uuid.UUID]*repository.FixedAsset{asset.ID: asset},
+			due: map[uuid.UUID][]*repository.DepreciationLine{healthy: {
+				{ID: uuid.New(), AssetID: asset.ID, Period: 1, Amount: decimal.NewFromInt(75)},
+			}},
+		}
+		tenantRepo := &fakeTenantRepository{ids: []uuid.UUID{healthy, failing}}
+		runner := NewRunner(tenantRepo, assetRepo, 0, prometheus.NewRegistry())
+		runner.now = func() time.Time { return now }
+
+		runner.PostAll(ctx)
+
+		assert.Equal(t, now, assetRepo.through)
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.posted))
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.errors))
+	})
+
+	t.Run("counts an error when tenants cannot be listed", func(t *testing.T) {
+		tenantRepo := &fakeTenantRepository{err: errors.New("connection refused")}
+		runner := NewRunner(tenantRepo, &fakeAssetRepository{}, 0, prometheus.NewRegistry())
+
+		runner.PostAll(ctx)
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.errors))
+		assert.Zero(t, testutil.ToFloat64(runner.posted))
+	})
+}
@@ -0,0 +1,145 @@
+// Package depreciation builds depreciation schedules for fixed assets and
+// posts the depreciation entries that fall due.
+package depreciation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Method is how the depreciable amount of an asset is spread over its life
+type Method string
+
+const (
+	// MethodStraightLine charges the same amount every month
+	MethodStraightLine Method = "STRAIGHT_LINE"
+	// MethodDecliningBalance charges a fixed rate of the remaining book value
+	// every month, switching to straight line once that charges more
+	MethodDecliningBalance Method = "DECLINING_BALANCE"
+)
+
+// DefaultDecliningFactor is the declining balance factor used when none is
+// configured (double declining balance)
+var DefaultDecliningFactor = decimal.NewFromInt(2)
+
+// Params describes an asset for schedule generation
+type Params struct {
+	Method Method
+	Cost   decimal.Decimal
+	// SalvageValue is the book value left at the end of the useful life
+	SalvageValue     decimal.Decimal
+	UsefulLifeMonths int
+	// InServiceDate is the date the asset is put in use; it is depreciated
+	// for the whole month it starts in
+	InServiceDate time.Time
+	// DecliningFactor is the multiple of the straight line rate charged by the
+	// declining balance method
+	DecliningFactor decimal.Decimal
+	// Precision is the number of decimal places amounts are rounded to
+	Precision int32
+}
+
+// Period is one month of a depreciation schedule
+type Period struct {
+	// Number counts the periods from 1
+	Number int
+	// Date is the last day of the month the charge is posted on
+	Date   time.Time
+	Amount decimal.Decimal
+	// Accumulated is the depreciation charged up to and including this period
+	Accumulated decimal.Decimal
+	BookValue   decimal.Decimal
+}
+
+// Schedule returns one period per month of the asset's useful life. The
+// amounts are rounded to the configured precision and always add up to the
+// cost less the salvage value.
+func Schedule(p Params) ([]Period, error) {
+	if !p.Cost.IsPositive() {
+		return nil, errors.New("cost must be positive")
+	}
+	if p.SalvageValue.IsNegative() || p.SalvageValue.GreaterThanOrEqual(p.Cost) {
+		return nil, errors.New("salvage value must be at least zero and less than the cost")
+	}
+	if p.UsefulLifeMonths < 1 {
+		return nil, errors.New("useful life must be at least one month")
+	}
+
+	var amounts []decimal.Decimal
+	switch p.Method {
+	case MethodStraightLine:
+		amounts = straightLine(p)
+	case MethodDecliningBalance:
+		if !p.DecliningFactor.IsPositive() {
+			return nil, errors.New("declining factor must be positive")
+		}
+		amounts = decliningBalance(p)
+	default:
+		return nil, errors.New("method must be STRAIGHT_LINE or DECLINING_BALANCE")
+	}
+
+	start := time.Date(p.InServiceDate.Year(), p.InServiceDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	periods := make([]Period, len(amounts))
+	accumulated := decimal.Zero
+	for i, amount := range amounts {
+		accumulated = accumulated.Add(amount)
+		periods[i] = Period{
+			Number:      i + 1,
+			Date:        start.AddDate(0, i+1, -1),
+			Amount:      amount,
+			Accumulated: accumulated,
+			BookValue:   p.Cost.Sub(accumulated),
+		}
+	}
+
+	return periods, nil
+}
+
+// straightLine spreads the depreciable amount evenly. Each month charges the
+// rounded cumulative amount less what was charged before, so rounding
+// differences never build up.
+func straightLine(p Params) []decimal.Decimal {
+	base := p.Cost.Sub(p.SalvageValue)
+	life := decimal.NewFromInt(int64(p.UsefulLifeMonths))
+
+	amounts := make([]decimal.Decimal, p.UsefulLifeMonths)
+	charged := decimal.Zero
+	for i := range amounts {
+		cumulative := base.Mul(decimal.NewFromInt(int64(i + 1))).Div(life).Round(p.Precision)
+		amounts[i] = cumulative.Sub(charged)
+		charged = cumulative
+	}
+
+	return amounts
+}
+
+// decliningBalance charges the monthly rate on the remaining book value, or
+// the straight line amount over the remaining life when that is higher, and
+// never depreciates below the salvage value. The last month charges whatever
+// is left.
+func decliningBalance(p Params) []decimal.Decimal {
+	rate := p.DecliningFactor.Div(decimal.NewFromInt(int64(p.UsefulLifeMonths)))
+
+	amounts := make([]decimal.Decimal, p.UsefulLifeMonths)
+	bookValue := p.Cost
+	for i := range amounts {
+		remaining := bookValue.Sub(p.SalvageValue)
+		amount := remaining
+		if i < len(amounts)-1 {
+			amount = bookValue.Mul(rate).Round(p.Precision)
+			left := decimal.NewFromInt(int64(len(amounts) - i))
+			if straight := remaining.Div(left).Round(p.Precision); straight.GreaterThan(amount) {
+				amount = straight
+			}
+			if amount.GreaterThan(remaining) {
+				amount = remaining
+			}
+		}
+		amounts[i] = amount
+		bookValue = bookValue.Sub(amount)
+	}
+
+	return amounts
+}
@@ -0,0 +1,113 @@
+package depreciation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func amounts(periods []Period) []string {
+	out := make([]string, len(periods))
+	for i, p := range periods {
+		out[i] = p.Amount.StringFixed(2)
+	}
+	return out
+}
+
+func TestSchedule(t *testing.T) {
+	inService := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	t.Run("straight line spreads rounding across periods", func(t *testing.T) {
+		periods, err := Schedule(Params{
+			Method:           MethodStraightLine,
+			Cost:             decimal.NewFromInt(1000),
+			UsefulLifeMonths: 3,
+			InServiceDate:    inService,
+			Precision:        2,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"333.33", "333.34", "333.33"}, amounts(periods))
+		assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), periods[0].Date)
+		assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), periods[1].Date)
+		assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), periods[2].Date)
+		assert.True(t, periods[2].Accumulated.Equal(decimal.NewFromInt(1000)))
+		assert.True(t, periods[2].BookValue.IsZero())
+	})
+
+	t.Run("straight line stops at the salvage value", func(t *testing.T) {
+		periods, err := Schedule(Params{
+			Method:           MethodStraightLine,
+			Cost:             decimal.NewFromInt(1000),
+			SalvageValue:     decimal.NewFromInt(100),
+			UsefulLifeMonths: 12,
+			InServiceDate:    inService,
+			Precision:        2,
+		})
+		require.NoError(t, err)
+
+		require.Len(t, periods, 12)
+		assert.Equal(t, "75.00", periods[0].Amount.StringFixed(2))
+		assert.Equal(t, 12, periods[11].Number)
+		assert.True(t, periods[11].BookValue.Equal(decimal.NewFromInt(100)))
+	})
+
+	t.Run("declining balance charges the rate on book value", func(t *testing.T) {
+		periods, err := Schedule(Params{
+			Method:           MethodDecliningBalance,
+			Cost:             decimal.NewFromInt(1000),
+			SalvageValue:     decimal.NewFromInt(100),
+			UsefulLifeMonths: 4,
+			InServiceDate:    inService,
+			DecliningFactor:  DefaultDecliningFactor,
+			Precision:        2,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"500.00", "250.00", "125.00", "25.00"}, amounts(periods))
+		assert.True(t, periods[3].BookValue.Equal(decimal.NewFromInt(100)))
+	})
+
+	t.Run("declining balance switches to straight line", func(t *testing.T) {
+		periods, err := Schedule(Params{
+			Method:           MethodDecliningBalance,
+			Cost:             decimal.NewFromInt(1000),
+			UsefulLifeMonths: 4,
+			InServiceDate:    inService,
+			DecliningFactor:  decimal.NewFromInt(1),
+			Precision:        2,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"250.00", "250.00", "250.00", "250.00"}, amounts(periods))
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		valid := Params{
+			Method:           MethodStraightLine,
+			Cost:             decimal.NewFromInt(1000),
+			UsefulLifeMonths: 12,
+			InServiceDate:    inService,
+			Precision:        2,
+		}
+
+		for name, modify := range map[string]func(p *Params){
+			"zero cost":             func(p *Params) { p.Cost = decimal.Zero },
+			"salvage above cost":    func(p *Params) { p.SalvageValue = decimal.NewFromInt(1000) },
+			"negative salvage":      func(p *Params) { p.SalvageValue = decimal.NewFromInt(-1) },
+			"zero useful life":      func(p *Params) { p.UsefulLifeMonths = 0 },
+			"unknown method":        func(p *Params) { p.Method = "SUM_OF_YEARS" },
+			"zero declining factor": func(p *Params) { p.Method = MethodDecliningBalance },
+		} {
+			t.Run(name, func(t *testing.T) {
+				p := valid
+				modify(&p)
+				_, err := Schedule(p)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
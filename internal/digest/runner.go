@@ -0,0 +1,114 @@
+// Package digest computes the daily digests that let a tenant's journal be
+// checked against roots recorded outside the ledger
+package digest
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Runner periodically computes the digests of the UTC days that have ended
+// for every tenant and reports the outcome as metrics and log lines
+type Runner struct {
+	tenantRepo repository.TenantRepositoryInterface
+	digestRepo repository.DigestRepositoryInterface
+	interval   time.Duration
+	publish    bool
+	now        func() time.Time
+
+	computed prometheus.Counter
+	errors   prometheus.Counter
+}
+
+// NewRunner creates a new runner and registers its metrics with reg. When
+// publish is set, every computed digest is appended to the event store.
+func NewRunner(
+	tenantRepo repository.TenantRepositoryInterface,
+	digestRepo repository.DigestRepositoryInterface,
+	interval time.Duration,
+	publish bool,
+	reg prometheus.Registerer,
+) *Runner {
+	r := &Runner{
+		tenantRepo: tenantRepo,
+		digestRepo: digestRepo,
+		interval:   interval,
+		publish:    publish,
+		now:        time.Now,
+		computed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_daily_digests_computed_total",
+			Help: "Daily digests computed by the background runner.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_daily_digest_errors_total",
+			Help: "Daily digest runs that failed for a tenant.",
+		}),
+	}
+
+	reg.MustRegister(r.computed, r.errors)
+
+	return r
+}
+
+// Run computes digests once per interval until ctx is cancelled
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.ComputeAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ComputeAll computes the missing digests of every active tenant, from the
+// day after its latest digest through yesterday. A tenant without digests
+// starts with yesterday. A tenant whose run fails is logged and counted, and
+// the run carries on with the others.
+func (r *Runner) ComputeAll(ctx context.Context) {
+	tenantIDs, err := r.tenantRepo.ListIDs(ctx)
+	if err != nil {
+		redact.Printf(redact.LevelError, "daily digest run: %v", err)
+		r.errors.Inc()
+		return
+	}
+
+	now := r.now().UTC()
+	yesterday := time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.UTC)
+	for _, tenantID := range tenantIDs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		day := yesterday
+		latest, err := r.digestRepo.Latest(ctx, tenantID)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+		case err != nil:
+			redact.Printf(redact.LevelError, "daily digest run of tenant %s: %v", tenantID, err)
+			r.errors.Inc()
+			continue
+		default:
+			day = latest.DigestDate.AddDate(0, 0, 1)
+		}
+
+		for ; !day.After(yesterday); day = day.AddDate(0, 0, 1) {
+			if _, err := r.digestRepo.Compute(ctx, tenantID, day, r.publish); err != nil {
+				redact.Printf(redact.LevelError, "daily digest run of tenant %s for %s: %v", tenantID, day.Format("2006-01-02"), err)
+				r.errors.Inc()
+				break
+			}
+			r.computed.Inc()
+		}
+	}
+}
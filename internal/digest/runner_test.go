@@ -0,0 +1,92 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTenantRepository struct {
+	repository.TenantRepositoryInterface
+	ids []uuid.UUID
+	err error
+}
+
+func (f *fakeTenantRepository) ListIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return f.ids, f.err
+}
+
+type fakeDigestRepository struct {
+	repository.DigestRepositoryInterface
+	latest map[uuid.UUID]*repository.DailyDigest
+	// latestErr is returned when getting the latest digest of this tenant
+	latestErr map[uuid.UUID]error
+	computed  map[uuid.UUID][]time.Time
+	published bool
+}
+
+func (f *fakeDigestRepository) Latest(ctx context.Context, tenantID uuid.UUID) (*repository.DailyDigest, error) {
+	if err := f.latestErr[tenantID]; err != nil {
+		return nil, err
+	}
+	digest, ok := f.latest[tenantID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return digest, nil
+}
+
+func (f *fakeDigestRepository) Compute(ctx context.Context, tenantID uuid.UUID, date time.Time, publish bool) (*repository.DailyDigest, error) {
+	f.computed[tenantID] = append(f.computed[tenantID], date)
+	f.published = publish
+	return &repository.DailyDigest{TenantID: tenantID, DigestDate: date}, nil
+}
+
+func TestRunner_ComputeAll(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 2, 0, 0, 0, time.UTC)
+
+	t.Run("computes the days since the latest digest", func(t *testing.T) {
+		fresh, behind, current, failing := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+		digestRepo := &fakeDigestRepository{
+			latest: map[uuid.UUID]*repository.DailyDigest{
+				behind:  {DigestDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+				current: {DigestDate: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+			},
+			latestErr: map[uuid.UUID]error{failing: errors.New("connection refused")},
+			computed:  map[uuid.UUID][]time.Time{},
+		}
+		tenantRepo := &fakeTenantRepository{ids: []uuid.UUID{fresh, behind, current, failing}}
+		runner := NewRunner(tenantRepo, digestRepo, 0, true, prometheus.NewRegistry())
+		runner.now = func() time.Time { return now }
+
+		runner.ComputeAll(ctx)
+
+		assert.Equal(t, []time.Time{time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)}, digestRepo.computed[fresh])
+		assert.Equal(t, []time.Time{
+			time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC),
+		}, digestRepo.computed[behind])
+		assert.Empty(t, digestRepo.computed[current])
+		assert.True(t, digestRepo.published)
+		assert.Equal(t, 3.0, testutil.ToFloat64(runner.computed))
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.errors))
+	})
+
+	t.Run("counts an error when tenants cannot be listed", func(t *testing.T) {
+		tenantRepo := &fakeTenantRepository{err: errors.New("connection refused")}
+		runner := NewRunner(tenantRepo, &fakeDigestRepository{}, 0, false, prometheus.NewRegistry())
+
+		runner.ComputeAll(ctx)
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.errors))
+		assert.Zero(t, testutil.ToFloat64(runner.computed))
+	})
+}
@@ -0,0 +1,101 @@
+// Package eventsig signs published ledger events with HMAC-SHA256, so
+// consumers receiving them over webhooks, queues or the event RPCs can check
+// that an event comes from the ledger and was not altered.
+//
+// A signature covers the tenant, sequence, event type, schema version and
+// payload of an event, joined as
+//
+//	<tenant_id>.<sequence>.<event_type>.<schema_version>.<payload>
+//
+// and is sent as "v1=<hex HMAC-SHA256>". While secrets are rotated an event
+// carries one comma-separated signature per secret, and a consumer accepts
+// it when any of them verifies with a secret it holds.
+package eventsig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Scheme prefixes signatures made with HMAC-SHA256 over the fields above
+const Scheme = "v1"
+
+// ErrInvalidSignature is returned when no signature of an event verifies
+var ErrInvalidSignature = errors.New("eventsig: invalid signature")
+
+// Event holds the signed fields of an event
+type Event struct {
+	TenantID      uuid.UUID
+	Sequence      int64
+	EventType     string
+	SchemaVersion int32
+	Payload       []byte
+}
+
+// message returns the bytes a signature is computed over
+func (e Event) message() []byte {
+	var b strings.Builder
+	b.WriteString(e.TenantID.String())
+	b.WriteByte('.')
+	b.WriteString(strconv.FormatInt(e.Sequence, 10))
+	b.WriteByte('.')
+	b.WriteString(e.EventType)
+	b.WriteByte('.')
+	b.WriteString(strconv.FormatInt(int64(e.SchemaVersion), 10))
+	b.WriteByte('.')
+	b.Write(e.Payload)
+	return []byte(b.String())
+}
+
+func mac(secret []byte, e Event) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(e.message())
+	return h.Sum(nil)
+}
+
+// Signer signs events with one or more secrets
+type Signer struct {
+	secrets [][]byte
+}
+
+// NewSigner creates a signer signing with every secret, the current one
+// first, so consumers can move to a new secret before the old one is
+// dropped
+func NewSigner(secrets ...[]byte) *Signer {
+	return &Signer{secrets: secrets}
+}
+
+// Sign returns the signature of an event
+func (s *Signer) Sign(e Event) string {
+	signatures := make([]string, len(s.secrets))
+	for i, secret := range s.secrets {
+		signatures[i] = Scheme + "=" + hex.EncodeToString(mac(secret, e))
+	}
+	return strings.Join(signatures, ",")
+}
+
+// Verify checks a signature sent with an event against a secret. Signatures
+// of other schemes are ignored.
+func Verify(signature string, e Event, secret []byte) error {
+	expected := mac(secret, e)
+	for _, part := range strings.Split(signature, ",") {
+		scheme, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || scheme != Scheme {
+			continue
+		}
+		sum, err := hex.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		if hmac.Equal(sum, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
@@ -0,0 +1,60 @@
+package eventsig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	event := Event{
+		TenantID:      uuid.MustParse("7b0c6f1e-2a43-4a8e-9d4f-2a9c1d3e5f60"),
+		Sequence:      42,
+		EventType:     "AccountCreated",
+		SchemaVersion: 1,
+		Payload:       []byte(`{"name": "Cash"}`),
+	}
+
+	t.Run("signs the documented message", func(t *testing.T) {
+		h := hmac.New(sha256.New, []byte("secret"))
+		h.Write([]byte(`7b0c6f1e-2a43-4a8e-9d4f-2a9c1d3e5f60.42.AccountCreated.1.{"name": "Cash"}`))
+
+		signature := NewSigner([]byte("secret")).Sign(event)
+
+		assert.Equal(t, "v1="+hex.EncodeToString(h.Sum(nil)), signature)
+		assert.NoError(t, Verify(signature, event, []byte("secret")))
+	})
+
+	t.Run("accepts any secret while rotating", func(t *testing.T) {
+		signature := NewSigner([]byte("new"), []byte("old")).Sign(event)
+
+		assert.Len(t, strings.Split(signature, ","), 2)
+		assert.NoError(t, Verify(signature, event, []byte("old")))
+		assert.NoError(t, Verify(signature, event, []byte("new")))
+		assert.ErrorIs(t, Verify(signature, event, []byte("other")), ErrInvalidSignature)
+	})
+
+	t.Run("rejects altered events", func(t *testing.T) {
+		signature := NewSigner([]byte("secret")).Sign(event)
+
+		altered := event
+		altered.Payload = []byte(`{"name": "Bank"}`)
+		assert.ErrorIs(t, Verify(signature, altered, []byte("secret")), ErrInvalidSignature)
+
+		altered = event
+		altered.TenantID = uuid.New()
+		assert.ErrorIs(t, Verify(signature, altered, []byte("secret")), ErrInvalidSignature)
+	})
+
+	t.Run("ignores unknown schemes and malformed signatures", func(t *testing.T) {
+		valid := NewSigner([]byte("secret")).Sign(event)
+
+		assert.NoError(t, Verify("v0=abc,v1=zz,"+valid, event, []byte("secret")))
+		assert.ErrorIs(t, Verify("", event, []byte("secret")), ErrInvalidSignature)
+	})
+}
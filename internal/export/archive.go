@@ -0,0 +1,200 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+)
+
+// ArchiveManifest is the manifest.json entry of a tenant data archive
+type ArchiveManifest struct {
+	TenantID   uuid.UUID              `json:"tenant_id"`
+	JobID      uuid.UUID              `json:"job_id"`
+	Format     string                 `json:"format"`
+	SnapshotAt time.Time              `json:"snapshot_at"`
+	Datasets   []ArchiveManifestEntry `json:"datasets"`
+}
+
+// ArchiveManifestEntry describes one dataset file of an archive
+type ArchiveManifestEntry struct {
+	Dataset string `json:"dataset"`
+	File    string `json:"file"`
+	Rows    int64  `json:"rows"`
+}
+
+// archiveFile is the name of the archive written by a tenant data export
+const archiveFile = "tenant-data.zip"
+
+// writeArchive writes every dataset of the job from a single snapshot of the
+// tenant into one zip file with a manifest, and returns its key
+func (e *Exporter) writeArchive(ctx context.Context, job *repository.ExportJob) ([]string, error) {
+	if e.tenantDataRepo == nil {
+		return nil, errors.New("tenant data export is not configured")
+	}
+
+	format := Format(job.Format)
+	key := fmt.Sprintf("%s/%s/%s", job.TenantID, job.ID, archiveFile)
+	file, err := e.store.Create(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	manifest := ArchiveManifest{TenantID: job.TenantID, JobID: job.ID, Format: job.Format}
+	archive := zip.NewWriter(file)
+
+	err = e.tenantDataRepo.Snapshot(ctx, job.TenantID, func(snapshot repository.TenantSnapshot) error {
+		manifest.SnapshotAt = snapshot.TakenAt().UTC()
+
+		for _, name := range job.Datasets {
+			dataset := Dataset(name)
+			entry := ArchiveManifestEntry{
+				Dataset: name,
+				File:    strings.ToLower(name) + "." + format.Extension(),
+			}
+
+			rows, err := writeArchiveDataset(ctx, archive, format, entry.File, dataset, snapshot)
+			if err != nil {
+				return err
+			}
+			entry.Rows = rows
+			manifest.Datasets = append(manifest.Datasets, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export tenant data: %w", err)
+	}
+
+	w, err := archive.Create("manifest.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return nil, fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	return []string{key}, nil
+}
+
+// writeArchiveDataset writes one dataset of a snapshot as the archive entry
+// name and returns the number of rows written
+func writeArchiveDataset(ctx context.Context, archive *zip.Writer, format Format, name string, dataset Dataset, snapshot repository.TenantSnapshot) (int64, error) {
+	cols, ok := columns[dataset]
+	if !ok {
+		return 0, fmt.Errorf("unknown dataset %q", dataset)
+	}
+
+	w, err := archive.Create(name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive entry %s: %w", name, err)
+	}
+	table, err := NewTableWriter(format, w, cols)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows int64
+	write := func(values []*string) error {
+		rows++
+		return table.WriteRow(values)
+	}
+
+	switch dataset {
+	case DatasetAccounts:
+		err = snapshot.Accounts(ctx, func(a *repository.Account) error {
+			return write(accountRow(a))
+		})
+	case DatasetJournalEntries:
+		err = snapshot.JournalEntries(ctx, func(entry *repository.JournalEntry) error {
+			return write(entryRow(entry))
+		})
+	case DatasetJournalLines:
+		err = snapshot.JournalEntries(ctx, func(entry *repository.JournalEntry) error {
+			for _, line := range entry.Lines {
+				if err := write(lineRow(line)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case DatasetBalances:
+		err = snapshot.Balances(ctx, func(b *repository.AccountBalance) error {
+			return write(balanceRow(b))
+		})
+	case DatasetSettings:
+		var settings *repository.TenantSettings
+		if settings, err = snapshot.Settings(ctx); err == nil {
+			err = write(settingsRow(settings))
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to export %s: %w", strings.ToLower(string(dataset)), err)
+	}
+
+	if err := table.Close(); err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
+
+func balanceRow(b *repository.AccountBalance) []*string {
+	return []*string{
+		str(b.AccountID.String()),
+		str(b.DebitBalance.String()),
+		str(b.CreditBalance.String()),
+		timeStr(&b.UpdatedAt),
+	}
+}
+
+func settingsRow(s *repository.TenantSettings) []*string {
+	var conversion *string
+	if len(s.FxConversionAccounts) > 0 {
+		if b, err := json.Marshal(s.FxConversionAccounts); err == nil {
+			conversion = str(string(b))
+		}
+	}
+
+	var overdraftLimits *string
+	if len(s.OverdraftLimits) > 0 {
+		if b, err := json.Marshal(s.OverdraftLimits); err == nil {
+			overdraftLimits = str(string(b))
+		}
+	}
+
+	var updatedAt *string
+	if !s.UpdatedAt.IsZero() {
+		updatedAt = timeStr(&s.UpdatedAt)
+	}
+
+	return []*string{
+		str(s.TenantID.String()),
+		str(s.BaseCurrency),
+		str(s.Timezone),
+		str(s.Locale),
+		str(s.Calendar),
+		uuidStr(s.FxGainAccountID),
+		uuidStr(s.FxLossAccountID),
+		conversion,
+		overdraftLimits,
+		str(strconv.FormatBool(s.EncryptMetadata)),
+		updatedAt,
+	}
+}
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTenantDataRepository struct {
+	repository.TenantDataRepositoryInterface
+	snapshot *fakeSnapshot
+}
+
+func (f *fakeTenantDataRepository) Snapshot(ctx context.Context, tenantID uuid.UUID, fn func(repository.TenantSnapshot) error) error {
+	return fn(f.snapshot)
+}
+
+type fakeSnapshot struct {
+	takenAt  time.Time
+	accounts []*repository.Account
+	entries  []*repository.JournalEntry
+	balances []*repository.AccountBalance
+	settings *repository.TenantSettings
+}
+
+func (f *fakeSnapshot) TakenAt() time.Time { return f.takenAt }
+
+func (f *fakeSnapshot) Accounts(ctx context.Context, fn func(*repository.Account) error) error {
+	for _, a := range f.accounts {
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeSnapshot) JournalEntries(ctx context.Context, fn func(*repository.JournalEntry) error) error {
+	for _, e := range f.entries {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeSnapshot) Balances(ctx context.Context, fn func(*repository.AccountBalance) error) error {
+	for _, b := range f.balances {
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeSnapshot) Settings(ctx context.Context) (*repository.TenantSettings, error) {
+	return f.settings, nil
+}
+
+func TestExporter_RunArchive(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	accountID := uuid.New()
+	entryID := uuid.New()
+
+	snapshot := &fakeSnapshot{
+		takenAt:  time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC),
+		accounts: []*repository.Account{{ID: accountID, AccountNumber: "1000", Name: "Cash", CurrencyCode: "USD"}},
+		entries: []*repository.JournalEntry{{
+			ID:              entryID,
+			ReferenceNumber: "JE-1",
+			EntryDate:       time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+			Lines: []*repository.JournalEntryLine{
+				{ID: uuid.New(), JournalEntryID: entryID, AccountID: accountID, Debit: decimal.NewFromInt(10), Credit: decimal.Zero},
+				{ID: uuid.New(), JournalEntryID: entryID, AccountID: accountID, Debit: decimal.Zero, Credit: decimal.NewFromInt(10)},
+			},
+		}},
+		balances: []*repository.AccountBalance{{AccountID: accountID, DebitBalance: decimal.NewFromInt(10), CreditBalance: decimal.NewFromInt(10)}},
+		settings: &repository.TenantSettings{TenantID: tenantID, BaseCurrency: "USD", Timezone: "UTC", Locale: "en", Calendar: "GREGORIAN"},
+	}
+
+	jobRepo := &fakeJobRepository{}
+	store := &memoryStore{files: map[string]*bytes.Buffer{}}
+	exporter := NewExporter(nil, nil, jobRepo, &fakeTenantDataRepository{snapshot: snapshot}, store)
+
+	names := make([]string, len(ArchiveDatasets))
+	for i, d := range ArchiveDatasets {
+		names[i] = string(d)
+	}
+	job := &repository.ExportJob{
+		ID:       uuid.New(),
+		TenantID: tenantID,
+		Format:   string(FormatJSONL),
+		Datasets: names,
+		Archive:  true,
+	}
+	exporter.Run(ctx, job)
+
+	require.Equal(t, repository.ExportJobCompleted, jobRepo.status)
+	require.Equal(t, []string{tenantID.String() + "/" + job.ID.String() + "/tenant-data.zip"}, jobRepo.files)
+
+	data := store.files[jobRepo.files[0]].Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	read := func(name string) []byte {
+		f, err := archive.Open(name)
+		require.NoError(t, err)
+		defer f.Close()
+		b, err := io.ReadAll(f)
+		require.NoError(t, err)
+		return b
+	}
+
+	var manifest ArchiveManifest
+	require.NoError(t, json.Unmarshal(read("manifest.json"), &manifest))
+	assert.Equal(t, job.ID, manifest.JobID)
+	assert.Equal(t, snapshot.takenAt, manifest.SnapshotAt)
+	require.Len(t, manifest.Datasets, len(ArchiveDatasets))
+	assert.Equal(t, ArchiveManifestEntry{Dataset: "JOURNAL_LINES", File: "journal_lines.jsonl", Rows: 2}, manifest.Datasets[2])
+
+	assert.Equal(t, 2, bytes.Count(read("journal_lines.jsonl"), []byte("\n")))
+	assert.Contains(t, string(read("settings.jsonl")), `"base_currency":"USD"`)
+}
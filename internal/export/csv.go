@@ -0,0 +1,42 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// csvWriter writes rows as RFC 4180 CSV with a header line. Nulls are written
+// as empty fields.
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return nil, fmt.Errorf("failed to write csv header: %w", err)
+	}
+	return &csvWriter{w: cw, record: make([]string, len(columns))}, nil
+}
+
+func (c *csvWriter) WriteRow(values []*string) error {
+	if len(values) != len(c.record) {
+		return fmt.Errorf("expected %d values, got %d", len(c.record), len(values))
+	}
+
+	for i, v := range values {
+		c.record[i] = ""
+		if v != nil {
+			c.record[i] = *v
+		}
+	}
+
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
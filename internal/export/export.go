@@ -0,0 +1,95 @@
+// Package export writes tenant ledger data to flat files for analytics tools
+// and to archives of all data of a tenant.
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+// Format identifies an export file format
+type Format string
+
+// Supported export formats
+const (
+	FormatCSV     Format = "CSV"
+	FormatParquet Format = "PARQUET"
+	FormatJSONL   Format = "JSONL"
+)
+
+// Extension returns the file extension used for the format
+func (f Format) Extension() string {
+	switch f {
+	case FormatParquet:
+		return "parquet"
+	case FormatJSONL:
+		return "jsonl"
+	default:
+		return "csv"
+	}
+}
+
+// Dataset identifies a table of ledger data that can be exported
+type Dataset string
+
+// Exportable datasets
+const (
+	DatasetAccounts       Dataset = "ACCOUNTS"
+	DatasetJournalEntries Dataset = "JOURNAL_ENTRIES"
+	DatasetJournalLines   Dataset = "JOURNAL_LINES"
+	DatasetBalances       Dataset = "BALANCES"
+	DatasetSettings       Dataset = "SETTINGS"
+)
+
+// AllDatasets lists every dataset of a ledger export in export order
+var AllDatasets = []Dataset{DatasetAccounts, DatasetJournalEntries, DatasetJournalLines}
+
+// ArchiveDatasets lists the datasets of a tenant data archive in the order
+// they are written
+var ArchiveDatasets = []Dataset{
+	DatasetAccounts, DatasetJournalEntries, DatasetJournalLines, DatasetBalances, DatasetSettings,
+}
+
+// columns lists the columns written for each dataset
+var columns = map[Dataset][]string{
+	DatasetAccounts: {
+		"account_id", "account_number", "name", "description", "account_type_id", "currency_code",
+		"parent_account_id", "is_active", "created_at", "updated_at", "deleted_at", "book_id",
+	},
+	DatasetJournalEntries: {
+		"journal_entry_id", "reference_number", "description", "entry_date", "posted_at", "metadata",
+		"created_at", "updated_at",
+	},
+	DatasetJournalLines: {
+		"line_id", "journal_entry_id", "account_id", "debit", "credit", "description",
+		"counterparty_tenant_id", "created_at",
+	},
+	DatasetBalances: {
+		"account_id", "debit_balance", "credit_balance", "updated_at",
+	},
+	DatasetSettings: {
+		"tenant_id", "base_currency", "timezone", "locale", "calendar", "fx_gain_account_id",
+		"fx_loss_account_id", "fx_conversion_accounts", "overdraft_limits", "encrypt_metadata", "updated_at",
+	},
+}
+
+// TableWriter writes rows of nullable string values; a nil value is written as null
+type TableWriter interface {
+	WriteRow(values []*string) error
+	Close() error
+}
+
+// NewTableWriter creates a writer for the given format over w. Close flushes
+// buffered rows but does not close w.
+func NewTableWriter(format Format, w io.Writer, columns []string) (TableWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatParquet:
+		return newParquetWriter(w, columns), nil
+	case FormatJSONL:
+		return newJSONLWriter(w, columns), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
@@ -0,0 +1,308 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+)
+
+// accountPageSize is the number of accounts read per query while exporting
+const accountPageSize = 100
+
+// Exporter runs export jobs, writing one file per dataset to a store, or a
+// single archive for tenant data exports
+type Exporter struct {
+	accountRepo    repository.AccountRepositoryInterface
+	journalRepo    repository.JournalRepositoryInterface
+	jobRepo        repository.ExportJobRepositoryInterface
+	tenantDataRepo repository.TenantDataRepositoryInterface
+	store          Store
+}
+
+// NewExporter creates a new exporter
+func NewExporter(
+	accountRepo repository.AccountRepositoryInterface,
+	journalRepo repository.JournalRepositoryInterface,
+	jobRepo repository.ExportJobRepositoryInterface,
+	tenantDataRepo repository.TenantDataRepositoryInterface,
+	store Store,
+) *Exporter {
+	return &Exporter{
+		accountRepo:    accountRepo,
+		journalRepo:    journalRepo,
+		jobRepo:        jobRepo,
+		tenantDataRepo: tenantDataRepo,
+		store:          store,
+	}
+}
+
+// Start records a pending job and runs it in the background
+func (e *Exporter) Start(ctx context.Context, tenantID uuid.UUID, format Format, datasets []Dataset) (*repository.ExportJob, error) {
+	names := make([]string, len(datasets))
+	for i, d := range datasets {
+		names[i] = string(d)
+	}
+
+	job, err := e.jobRepo.Create(ctx, tenantID, string(format), names, false)
+	if err != nil {
+		return nil, err
+	}
+
+	go e.Run(context.Background(), job)
+
+	return job, nil
+}
+
+// StartArchive records a pending tenant data export and runs it in the
+// background
+func (e *Exporter) StartArchive(ctx context.Context, tenantID uuid.UUID, format Format) (*repository.ExportJob, error) {
+	names := make([]string, len(ArchiveDatasets))
+	for i, d := range ArchiveDatasets {
+		names[i] = string(d)
+	}
+
+	job, err := e.jobRepo.Create(ctx, tenantID, string(format), names, true)
+	if err != nil {
+		return nil, err
+	}
+
+	go e.Run(context.Background(), job)
+
+	return job, nil
+}
+
+// Get retrieves an export job
+func (e *Exporter) Get(ctx context.Context, tenantID uuid.UUID, jobID uuid.UUID) (*repository.ExportJob, error) {
+	return e.jobRepo.GetByID(ctx, tenantID, jobID)
+}
+
+// Open opens a file produced by a job
+func (e *Exporter) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return e.store.Open(ctx, key)
+}
+
+// Run writes the datasets of a job and records its outcome
+func (e *Exporter) Run(ctx context.Context, job *repository.ExportJob) {
+	if err := e.jobRepo.UpdateStatus(ctx, job.TenantID, job.ID, repository.ExportJobRunning, nil, nil); err != nil {
+		redact.Printf(redact.LevelError, "export job %s: %v", job.ID, err)
+		return
+	}
+
+	status := repository.ExportJobCompleted
+	write := e.write
+	if job.Archive {
+		write = e.writeArchive
+	}
+	files, err := write(ctx, job)
+	var errMsg *string
+	if err != nil {
+		status = repository.ExportJobFailed
+		msg := redact.Error(err)
+		errMsg = &msg
+	}
+
+	if err := e.jobRepo.UpdateStatus(ctx, job.TenantID, job.ID, status, files, errMsg); err != nil {
+		redact.Printf(redact.LevelError, "export job %s: %v", job.ID, err)
+	}
+}
+
+// write exports every dataset of the job and returns the keys of the files written
+func (e *Exporter) write(ctx context.Context, job *repository.ExportJob) ([]string, error) {
+	format := Format(job.Format)
+	files := make([]string, 0, len(job.Datasets))
+	open := make(map[Dataset]*datasetFile)
+
+	// Close any files left open by an error
+	defer func() {
+		for _, f := range open {
+			f.abort()
+		}
+	}()
+
+	for _, name := range job.Datasets {
+		dataset := Dataset(name)
+		cols, ok := columns[dataset]
+		if !ok {
+			return nil, fmt.Errorf("unknown dataset %q", name)
+		}
+
+		key := fmt.Sprintf("%s/%s/%s.%s", job.TenantID, job.ID, strings.ToLower(name), format.Extension())
+		f, err := e.createFile(ctx, format, key, cols)
+		if err != nil {
+			return nil, err
+		}
+		open[dataset] = f
+		files = append(files, key)
+	}
+
+	if f, ok := open[DatasetAccounts]; ok {
+		if err := e.writeAccounts(ctx, job.TenantID, f.table); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, lines := open[DatasetJournalEntries], open[DatasetJournalLines]
+	if entries != nil || lines != nil {
+		// Entries and lines are written from a single pass over the journal
+		err := e.journalRepo.Stream(ctx, job.TenantID, nil, nil, func(entry *repository.JournalEntry) error {
+			if entries != nil {
+				if err := entries.table.WriteRow(entryRow(entry)); err != nil {
+					return err
+				}
+			}
+			if lines != nil {
+				for _, line := range entry.Lines {
+					if err := lines.table.WriteRow(lineRow(line)); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to export journal: %w", err)
+		}
+	}
+
+	for dataset, f := range open {
+		delete(open, dataset)
+		if err := f.close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+func (e *Exporter) writeAccounts(ctx context.Context, tenantID uuid.UUID, table TableWriter) error {
+	filter := repository.AccountFilter{
+		IncludeDeleted: true,
+		SortBy:         repository.AccountSortNumber,
+	}
+
+	for offset := 0; ; offset += accountPageSize {
+		accounts, _, err := e.accountRepo.List(ctx, tenantID, filter, accountPageSize, offset)
+		if err != nil {
+			return fmt.Errorf("failed to export accounts: %w", err)
+		}
+
+		for _, account := range accounts {
+			if err := table.WriteRow(accountRow(account)); err != nil {
+				return err
+			}
+		}
+
+		if len(accounts) < accountPageSize {
+			return nil
+		}
+	}
+}
+
+// datasetFile is an export file being written
+type datasetFile struct {
+	file  io.WriteCloser
+	table TableWriter
+}
+
+func (e *Exporter) createFile(ctx context.Context, format Format, key string, cols []string) (*datasetFile, error) {
+	file, err := e.store.Create(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := NewTableWriter(format, file, cols)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &datasetFile{file: file, table: table}, nil
+}
+
+func (f *datasetFile) close() error {
+	if err := f.table.Close(); err != nil {
+		f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
+
+func (f *datasetFile) abort() {
+	f.file.Close()
+}
+
+func accountRow(a *repository.Account) []*string {
+	return []*string{
+		str(a.ID.String()),
+		str(a.AccountNumber),
+		str(a.Name),
+		a.Description,
+		str(strconv.Itoa(int(a.AccountTypeID))),
+		str(a.CurrencyCode),
+		uuidStr(a.ParentAccountID),
+		str(strconv.FormatBool(a.IsActive)),
+		timeStr(&a.CreatedAt),
+		timeStr(&a.UpdatedAt),
+		timeStr(a.DeletedAt),
+		str(a.BookID.String()),
+	}
+}
+
+func entryRow(e *repository.JournalEntry) []*string {
+	var metadata *string
+	if e.Metadata != nil {
+		if b, err := json.Marshal(e.Metadata); err == nil {
+			metadata = str(string(b))
+		}
+	}
+
+	return []*string{
+		str(e.ID.String()),
+		str(e.ReferenceNumber),
+		str(e.Description),
+		str(e.EntryDate.UTC().Format("2006-01-02")),
+		timeStr(&e.PostedAt),
+		metadata,
+		timeStr(&e.CreatedAt),
+		timeStr(&e.UpdatedAt),
+	}
+}
+
+func lineRow(l *repository.JournalEntryLine) []*string {
+	return []*string{
+		str(l.ID.String()),
+		str(l.JournalEntryID.String()),
+		str(l.AccountID.String()),
+		str(l.Debit.String()),
+		str(l.Credit.String()),
+		str(l.Description),
+		uuidStr(l.CounterpartyTenantID),
+		timeStr(&l.CreatedAt),
+	}
+}
+
+func str(s string) *string {
+	return &s
+}
+
+func uuidStr(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	return str(id.String())
+}
+
+func timeStr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	return str(t.UTC().Format(time.RFC3339Nano))
+}
@@ -0,0 +1,153 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAccountRepository struct {
+	repository.AccountRepositoryInterface
+	accounts []*repository.Account
+}
+
+func (f *fakeAccountRepository) List(ctx context.Context, tenantID uuid.UUID, filter repository.AccountFilter, limit, offset int) ([]*repository.Account, int, error) {
+	if offset >= len(f.accounts) {
+		return nil, len(f.accounts), nil
+	}
+	end := min(offset+limit, len(f.accounts))
+	return f.accounts[offset:end], len(f.accounts), nil
+}
+
+type fakeJournalRepository struct {
+	repository.JournalRepositoryInterface
+	entries []*repository.JournalEntry
+}
+
+func (f *fakeJournalRepository) Stream(ctx context.Context, tenantID uuid.UUID, fromDate, toDate *time.Time, fn func(*repository.JournalEntry) error) error {
+	for _, entry := range f.entries {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fakeJobRepository struct {
+	repository.ExportJobRepositoryInterface
+	status string
+	files  []string
+	errMsg *string
+}
+
+func (f *fakeJobRepository) UpdateStatus(ctx context.Context, tenantID uuid.UUID, jobID uuid.UUID, status string, files []string, errMsg *string) error {
+	f.status, f.files, f.errMsg = status, files, errMsg
+	return nil
+}
+
+type memoryStore struct {
+	files map[string]*bytes.Buffer
+}
+
+type memoryFile struct {
+	*bytes.Buffer
+}
+
+func (memoryFile) Close() error { return nil }
+
+func (m *memoryStore) Create(ctx context.Context, key string) (io.WriteCloser, error) {
+	buf := &bytes.Buffer{}
+	m.files[key] = buf
+	return memoryFile{buf}, nil
+}
+
+func (m *memoryStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(m.files[key].Bytes())), nil
+}
+
+func TestExporter_Run(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	entryID := uuid.New()
+
+	accounts := make([]*repository.Account, 150)
+	for i := range accounts {
+		accounts[i] = &repository.Account{ID: uuid.New(), AccountNumber: "1000", Name: "Cash", CurrencyCode: "USD"}
+	}
+
+	journalRepo := &fakeJournalRepository{entries: []*repository.JournalEntry{{
+		ID:              entryID,
+		ReferenceNumber: "JE-1",
+		EntryDate:       time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		Lines: []*repository.JournalEntryLine{
+			{ID: uuid.New(), JournalEntryID: entryID, Debit: decimal.NewFromInt(10), Credit: decimal.Zero},
+			{ID: uuid.New(), JournalEntryID: entryID, Debit: decimal.Zero, Credit: decimal.NewFromInt(10)},
+		},
+	}}}
+
+	t.Run("writes one file per dataset and completes the job", func(t *testing.T) {
+		jobRepo := &fakeJobRepository{}
+		store := &memoryStore{files: map[string]*bytes.Buffer{}}
+		exporter := NewExporter(&fakeAccountRepository{accounts: accounts}, journalRepo, jobRepo, nil, store)
+
+		job := &repository.ExportJob{
+			ID:       uuid.New(),
+			TenantID: tenantID,
+			Format:   string(FormatCSV),
+			Datasets: []string{string(DatasetAccounts), string(DatasetJournalLines)},
+		}
+		exporter.Run(ctx, job)
+
+		require.Equal(t, repository.ExportJobCompleted, jobRepo.status)
+		require.Len(t, jobRepo.files, 2)
+		assert.Equal(t, tenantID.String()+"/"+job.ID.String()+"/accounts.csv", jobRepo.files[0])
+
+		// Header plus every account across pages
+		assert.Equal(t, 151, bytes.Count(store.files[jobRepo.files[0]].Bytes(), []byte("\n")))
+		assert.Equal(t, 3, bytes.Count(store.files[jobRepo.files[1]].Bytes(), []byte("\n")))
+	})
+
+	t.Run("fails the job for an unknown dataset", func(t *testing.T) {
+		jobRepo := &fakeJobRepository{}
+		exporter := NewExporter(nil, nil, jobRepo, nil, &memoryStore{files: map[string]*bytes.Buffer{}})
+
+		exporter.Run(ctx, &repository.ExportJob{
+			ID:       uuid.New(),
+			TenantID: tenantID,
+			Format:   string(FormatParquet),
+			Datasets: []string{"BUDGETS"},
+		})
+
+		assert.Equal(t, repository.ExportJobFailed, jobRepo.status)
+		require.NotNil(t, jobRepo.errMsg)
+		assert.Contains(t, *jobRepo.errMsg, "unknown dataset")
+	})
+}
+
+func TestDirStore(t *testing.T) {
+	store := NewDirStore(t.TempDir())
+
+	_, err := store.Create(context.Background(), "../outside.csv")
+	assert.Error(t, err)
+
+	w, err := store.Create(context.Background(), "tenant/job/accounts.csv")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("id\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := store.Open(context.Background(), "tenant/job/accounts.csv")
+	require.NoError(t, err)
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "id\n", string(data))
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// jsonlWriter writes rows as JSON Lines, one object per row with the columns
+// in order. Nulls are written as JSON null.
+type jsonlWriter struct {
+	w       *bufio.Writer
+	columns [][]byte
+}
+
+func newJSONLWriter(w io.Writer, columns []string) *jsonlWriter {
+	keys := make([][]byte, len(columns))
+	for i, c := range columns {
+		// Column names are plain identifiers, marshaling them cannot fail
+		keys[i], _ = json.Marshal(c)
+	}
+	return &jsonlWriter{w: bufio.NewWriter(w), columns: keys}
+}
+
+func (j *jsonlWriter) WriteRow(values []*string) error {
+	if len(values) != len(j.columns) {
+		return fmt.Errorf("expected %d values, got %d", len(j.columns), len(values))
+	}
+
+	j.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		j.w.Write(j.columns[i])
+		j.w.WriteByte(':')
+		if v == nil {
+			j.w.WriteString("null")
+			continue
+		}
+		b, err := json.Marshal(*v)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", j.columns[i], err)
+		}
+		j.w.Write(b)
+	}
+	j.w.WriteByte('}')
+	return j.w.WriteByte('\n')
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.w.Flush()
+}
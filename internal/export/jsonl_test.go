@@ -0,0 +1,30 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLWriter(t *testing.T) {
+	t.Run("writes one object per row with nulls", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := NewTableWriter(FormatJSONL, &buf, []string{"id", "name"})
+		require.NoError(t, err)
+
+		require.NoError(t, w.WriteRow([]*string{str("1"), str(`Cash "petty"`)}))
+		require.NoError(t, w.WriteRow([]*string{str("2"), nil}))
+		require.NoError(t, w.Close())
+
+		assert.Equal(t, "{\"id\":\"1\",\"name\":\"Cash \\\"petty\\\"\"}\n{\"id\":\"2\",\"name\":null}\n", buf.String())
+	})
+
+	t.Run("rejects rows with the wrong number of values", func(t *testing.T) {
+		w, err := NewTableWriter(FormatJSONL, &bytes.Buffer{}, []string{"id", "name"})
+		require.NoError(t, err)
+
+		assert.Error(t, w.WriteRow([]*string{str("1")}))
+	})
+}
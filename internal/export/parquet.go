@@ -0,0 +1,79 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// rowGroupSize is the number of rows buffered before a row group is written
+const rowGroupSize = 64 * 1024
+
+// parquetWriter writes a Parquet file with one optional UTF-8 string column
+// per exported column, in export order. Pages are Snappy compressed and rows
+// are buffered into row groups of rowGroupSize rows.
+type parquetWriter struct {
+	w       *parquet.Writer
+	columns int
+	row     parquet.Row
+}
+
+func newParquetWriter(w io.Writer, columns []string) *parquetWriter {
+	group := make(parquet.Group, len(columns))
+	for _, column := range columns {
+		group[column] = parquet.Optional(parquet.String())
+	}
+	schema := parquet.NewSchema("export", orderedGroup{Group: group, order: columns})
+
+	return &parquetWriter{
+		w: parquet.NewWriter(w, schema,
+			parquet.Compression(&parquet.Snappy),
+			parquet.MaxRowsPerRowGroup(rowGroupSize),
+		),
+		columns: len(columns),
+		row:     make(parquet.Row, len(columns)),
+	}
+}
+
+func (p *parquetWriter) WriteRow(values []*string) error {
+	if len(values) != p.columns {
+		return fmt.Errorf("expected %d values, got %d", p.columns, len(values))
+	}
+
+	for i, v := range values {
+		if v == nil {
+			p.row[i] = parquet.NullValue().Level(0, 0, i)
+			continue
+		}
+		p.row[i] = parquet.ByteArrayValue([]byte(*v)).Level(0, 1, i)
+	}
+
+	if _, err := p.w.WriteRows([]parquet.Row{p.row}); err != nil {
+		return fmt.Errorf("failed to write parquet row: %w", err)
+	}
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	if err := p.w.Close(); err != nil {
+		return fmt.Errorf("failed to write parquet footer: %w", err)
+	}
+	return nil
+}
+
+// orderedGroup is a group whose fields keep the order of the exported
+// columns; parquet.Group orders its fields by name
+type orderedGroup struct {
+	parquet.Group
+	order []string
+}
+
+func (g orderedGroup) Fields() []parquet.Field {
+	fields := g.Group.Fields()
+	slices.SortStableFunc(fields, func(a, b parquet.Field) int {
+		return slices.Index(g.order, a.Name()) - slices.Index(g.order, b.Name())
+	})
+	return fields
+}
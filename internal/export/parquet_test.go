@@ -0,0 +1,103 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readParquet reads a file back with the parquet-go reader and returns its
+// column names and rows, with nulls as nil
+func readParquet(t *testing.T, data []byte) ([]string, [][]*string) {
+	t.Helper()
+
+	file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	var names []string
+	for _, field := range file.Schema().Fields() {
+		names = append(names, field.Name())
+	}
+
+	reader := parquet.NewReader(file)
+	defer reader.Close()
+
+	var rows [][]*string
+	buf := make([]parquet.Row, 1)
+	for {
+		n, err := reader.ReadRows(buf)
+		if n == 1 {
+			values := make([]*string, len(names))
+			for _, value := range buf[0] {
+				if !value.IsNull() {
+					s := value.String()
+					values[value.Column()] = &s
+				}
+			}
+			rows = append(rows, values)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return names, rows
+}
+
+func TestParquetWriter(t *testing.T) {
+	t.Run("round-trips rows with nulls in column order", func(t *testing.T) {
+		columns := []string{"id", "name", "description"}
+		written := [][]*string{
+			{str("1"), str("Cash"), nil},
+			{str("2"), nil, str("Accounts receivable, net")},
+			{str("3"), str("Sales ünicode"), str("")},
+		}
+
+		var buf bytes.Buffer
+		w, err := NewTableWriter(FormatParquet, &buf, columns)
+		require.NoError(t, err)
+		for _, row := range written {
+			require.NoError(t, w.WriteRow(row))
+		}
+		require.NoError(t, w.Close())
+
+		names, rows := readParquet(t, buf.Bytes())
+		assert.Equal(t, columns, names)
+		assert.Equal(t, written, rows)
+	})
+
+	t.Run("splits rows into row groups", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := NewTableWriter(FormatParquet, &buf, []string{"id"})
+		require.NoError(t, err)
+		for i := 0; i < rowGroupSize+10; i++ {
+			require.NoError(t, w.WriteRow([]*string{str(fmt.Sprint(i))}))
+		}
+		require.NoError(t, w.Close())
+
+		file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		assert.Len(t, file.RowGroups(), 2)
+		assert.Equal(t, int64(rowGroupSize+10), file.NumRows())
+	})
+
+	t.Run("writes an empty file without row groups", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := NewTableWriter(FormatParquet, &buf, []string{"id"})
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		names, rows := readParquet(t, buf.Bytes())
+		assert.Equal(t, []string{"id"}, names)
+		assert.Empty(t, rows)
+	})
+
+	t.Run("rejects rows of the wrong width", func(t *testing.T) {
+		w, err := NewTableWriter(FormatParquet, &bytes.Buffer{}, []string{"id", "name"})
+		require.NoError(t, err)
+		assert.Error(t, w.WriteRow([]*string{str("1")}))
+	})
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store persists export files under slash-separated keys
+type Store interface {
+	Create(ctx context.Context, key string) (io.WriteCloser, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// DirStore stores export files in a local directory. Point it at a mounted
+// bucket (gcsfuse, s3fs, mountpoint-s3) to write exports to object storage.
+type DirStore struct {
+	root string
+}
+
+// NewDirStore creates a store rooted at dir
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{root: dir}
+}
+
+// Create creates or truncates the file for key, creating parent directories
+func (s *DirStore) Create(ctx context.Context, key string) (io.WriteCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export file: %w", err)
+	}
+	return f, nil
+}
+
+// Open opens the file for key
+func (s *DirStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export file: %w", err)
+	}
+	return f, nil
+}
+
+// path resolves key inside the root, rejecting keys that escape it
+func (s *DirStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid export key %q", key)
+	}
+	return filepath.Join(s.root, clean), nil
+}
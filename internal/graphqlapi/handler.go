@@ -0,0 +1,347 @@
+// Package graphqlapi serves a read-only GraphQL API over the accounts,
+// journal entries, balances and reports of the tenant API, so clients such
+// as analytics frontends can fetch nested data, like the lines of an account
+// with their entries, in one request.
+//
+// Every field is resolved by calling the tenant API in process through the
+// same interceptors as gRPC calls, so a request is authenticated with the
+// same bearer credentials and needs the same scopes, and is scoped to the
+// tenant named by its X-Tenant-ID header. Calls and records of any other
+// tenant are refused.
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+	"github.com/hesabFun/ledger/internal/auth"
+	"github.com/hesabFun/ledger/internal/requestid"
+)
+
+// Paths the handler serves queries and the schema on
+const (
+	Path       = "/graphql"
+	SchemaPath = "/graphql/schema"
+)
+
+// maxBodySize bounds the size of a request body
+const maxBodySize = 1 << 20
+
+// Handler serves GraphQL queries over HTTP
+type Handler struct {
+	schema *graphql.Schema
+}
+
+// request is a GraphQL request as sent over HTTP
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewHandler creates a handler resolving fields with the ledger service.
+// Interceptors are applied to every call in order, as ChainUnaryInterceptor
+// would. maxDepth bounds how deeply fields may be nested and maxCalls the
+// calls to the ledger service one query may make.
+func NewHandler(ledger pb.LedgerServiceServer, interceptors []grpc.UnaryServerInterceptor, maxDepth, maxCalls int) (*Handler, error) {
+	c := &caller{ledger: ledger, interceptors: interceptors, maxCalls: maxCalls}
+	schema, err := graphql.ParseSchema(schemaDefinition, &queryResolver{c: c},
+		graphql.UseStringDescriptions(),
+		graphql.MaxDepth(maxDepth),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{schema: schema}, nil
+}
+
+// ServeHTTP serves queries sent to Path as POSTed JSON or as GET parameters,
+// and the schema definition at SchemaPath
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case Path:
+		h.serveQuery(w, r)
+	case SchemaPath:
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = fmt.Fprint(w, schemaDefinition)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request) {
+	req := &request{}
+	switch r.Method {
+	case http.MethodPost:
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, "requests must be sent as application/json")
+			return
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid variables: "+err.Error())
+				return
+			}
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	tenant := r.Header.Get(auth.TenantHeader)
+	if tenant == "" {
+		writeError(w, http.StatusBadRequest, "the "+auth.TenantHeader+" header is required")
+		return
+	}
+	tenantID, err := uuid.Parse(tenant)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid "+auth.TenantHeader+" header")
+		return
+	}
+
+	// Every call of the query is logged under one request ID
+	id := r.Header.Get(requestid.Header)
+	if id == "" {
+		id = uuid.New().String()
+	}
+	w.Header().Set(requestid.Header, id)
+
+	md := metadata.Pairs(auth.TenantHeader, tenant, requestid.Header, id)
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		md.Set("authorization", authorization)
+	}
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+	made := newCalls(tenantID)
+	ctx = context.WithValue(ctx, callsContextKey{}, made)
+
+	result := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+	body = append(body, '\n')
+
+	// Complete results of GET queries can be revalidated from when the
+	// records in them were last updated, so clients polling the same query
+	// download it again only when one of them changed
+	if r.Method == http.MethodGet && len(result.Errors) == 0 {
+		if tag, lastModified, ok := made.validators(req); ok {
+			w.Header().Set("ETag", tag)
+			if !lastModified.IsZero() {
+				w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+			}
+			w.Header().Set("Cache-Control", "private, no-cache")
+			w.Header().Set("Vary", "Authorization, "+auth.TenantHeader)
+			if notModified(r, tag, lastModified) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// writeError responds with a request error in the shape of a GraphQL response
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(&graphql.Response{Errors: []*gqlerrors.QueryError{{Message: message}}})
+}
+
+// caller calls the ledger service through the interceptors of the tenant API
+type caller struct {
+	ledger       pb.LedgerServiceServer
+	interceptors []grpc.UnaryServerInterceptor
+	maxCalls     int
+}
+
+type callsContextKey struct{}
+
+// calls holds the calls made for one query, which resolves fields
+// concurrently. Calls are cached, so fields asking for the same account or
+// list of entries call the service once.
+type calls struct {
+	// tenant is the tenant the query was sent for
+	tenant uuid.UUID
+
+	mu       sync.Mutex
+	count    int
+	cache    map[string]*callResult
+	versions versions
+}
+
+func newCalls(tenant uuid.UUID) *calls {
+	return &calls{tenant: tenant, cache: make(map[string]*callResult)}
+}
+
+// callsFrom returns the calls of the query ctx was made for, if any
+func callsFrom(ctx context.Context) *calls {
+	made, _ := ctx.Value(callsContextKey{}).(*calls)
+	return made
+}
+
+// callResult is the result of a call, set once done is closed
+type callResult struct {
+	done chan struct{}
+	resp interface{}
+	err  error
+}
+
+// call invokes a method of the ledger service with req. The call must be
+// made for the tenant of the query, and fails otherwise.
+func call[Req proto.Message, Resp any](ctx context.Context, c *caller, method string, req Req, handler func(context.Context, Req) (Resp, error)) (Resp, error) {
+	var zero Resp
+
+	made := callsFrom(ctx)
+	if made == nil {
+		return zero, &fieldError{message: "no tenant to call the ledger for", code: codes.Internal}
+	}
+
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return zero, err
+	}
+	key := method + "\x00" + string(encoded)
+
+	made.mu.Lock()
+	result, ok := made.cache[key]
+	if !ok {
+		if c.maxCalls > 0 && made.count >= c.maxCalls {
+			made.mu.Unlock()
+			return zero, &fieldError{
+				message: fmt.Sprintf("query needs more than %d calls to the ledger; select fewer or smaller pages", c.maxCalls),
+				code:    codes.ResourceExhausted,
+			}
+		}
+		made.count++
+		result = &callResult{done: make(chan struct{})}
+		made.cache[key] = result
+	}
+	made.mu.Unlock()
+
+	if ok {
+		<-result.done
+	} else {
+		result.resp, result.err = invoke(ctx, c, made.tenant, method, req, handler)
+		close(result.done)
+	}
+	if result.err != nil {
+		return zero, result.err
+	}
+	return result.resp.(Resp), nil
+}
+
+// invoke calls handler through the interceptors. Once they authenticated
+// the call and resolved its tenant, it is refused unless both the tenant and
+// the tenant the credentials are bound to are the tenant of the query.
+func invoke[Req proto.Message, Resp any](ctx context.Context, c *caller, tenant uuid.UUID, method string, req Req, handler func(context.Context, Req) (Resp, error)) (interface{}, error) {
+	info := &grpc.UnaryServerInfo{Server: c.ledger, FullMethod: method}
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		if resolved, ok := auth.TenantFromContext(ctx); !ok || resolved != tenant {
+			return nil, status.Error(codes.PermissionDenied, "call is not scoped to the tenant of the query")
+		}
+		if bound, ok := auth.CredentialTenantFromContext(ctx); ok && bound != tenant {
+			return nil, status.Error(codes.PermissionDenied, "credentials are not valid for the tenant of the query")
+		}
+		return handler(ctx, req.(Req))
+	}
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := c.interceptors[i], next
+		next = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor(ctx, req, info, inner)
+		}
+	}
+
+	resp, err := next(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return resp, nil
+}
+
+// checkTenant fails unless a record returned by the ledger belongs to the
+// tenant of the query
+func checkTenant(ctx context.Context, tenantID string) error {
+	made := callsFrom(ctx)
+	if made == nil || tenantID != made.tenant.String() {
+		return &fieldError{message: "record does not belong to the tenant of the query", code: codes.PermissionDenied}
+	}
+	return nil
+}
+
+// fieldError is the error of a field, reported with its status code as the
+// code extension, such as NOT_FOUND
+type fieldError struct {
+	message string
+	code    codes.Code
+}
+
+func (e *fieldError) Error() string {
+	return e.message
+}
+
+// Extensions returns the extensions the error is reported with
+func (e *fieldError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": upperSnake(e.code.String())}
+}
+
+// statusError reports a gRPC status as a field error
+func statusError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return &fieldError{message: st.Message(), code: st.Code()}
+}
+
+// upperSnake turns a code name such as NotFound into NOT_FOUND
+func upperSnake(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,441 @@
+package graphqlapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+	"github.com/hesabFun/ledger/internal/auth"
+)
+
+const testTenant = "7b0c6f1e-2a43-4a8e-9d4f-2a9c1d3e5f60"
+
+// fakeLedger serves one cash account with one entry against sales, and
+// records the requests it received
+type fakeLedger struct {
+	pb.UnimplementedLedgerServiceServer
+
+	mu           sync.Mutex
+	accountCalls int
+	tenants      []string
+	// cashUpdatedAt, when set, replaces when the cash account was updated
+	cashUpdatedAt *timestamppb.Timestamp
+	// assetUpdatedAt, when set, replaces when the asset account type was
+	// updated
+	assetUpdatedAt *timestamppb.Timestamp
+}
+
+var (
+	cash = &pb.Account{
+		AccountId: "cash", TenantId: testTenant, AccountNumber: "1000", Name: "Cash", AccountTypeId: 1, CurrencyCode: "USD", IsActive: true,
+		UpdatedAt:   timestamppb.New(mustTime("2025-03-01T09:30:00Z")),
+		Labels:      map[string]string{"team": "treasury", "region": "emea"},
+		ExternalIds: map[string]string{"sap": "100000"},
+	}
+	sales = &pb.Account{AccountId: "sales", TenantId: testTenant, AccountNumber: "4000", Name: "Sales", AccountTypeId: 4, CurrencyCode: "USD", IsActive: true, UpdatedAt: timestamppb.New(mustTime("2025-01-15T08:00:00Z"))}
+	// foreign belongs to another tenant than the one the fake serves
+	foreign = &pb.Account{AccountId: "foreign", TenantId: "0d6f3e2a-8c1b-4f5e-9a7d-3b2c1e0f9a84", AccountNumber: "9000", Name: "Foreign", AccountTypeId: 1, CurrencyCode: "USD", IsActive: true}
+	sale    = &pb.JournalEntry{
+		JournalEntryId:  "entry-1",
+		TenantId:        testTenant,
+		ReferenceNumber: "INV-1",
+		Description:     "Cash sale",
+		EntryDate:       timestamppb.New(mustTime("2025-03-01T00:00:00Z")),
+		UpdatedAt:       timestamppb.New(mustTime("2025-03-01T09:30:00Z")),
+		Lines: []*pb.JournalEntryLine{
+			{AccountId: "cash", Debit: "100.00", Credit: "0", Dimensions: map[string]string{"region": "north", "channel": "shop"}},
+			{AccountId: "sales", Debit: "0", Credit: "100.00"},
+		},
+	}
+)
+
+func (f *fakeLedger) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.accountCalls++
+	f.tenants = append(f.tenants, req.TenantId)
+	for _, account := range []*pb.Account{cash, sales, foreign} {
+		if account.AccountId == req.AccountId {
+			if account == cash && f.cashUpdatedAt != nil {
+				account = proto.Clone(cash).(*pb.Account)
+				account.UpdatedAt = f.cashUpdatedAt
+			}
+			return &pb.GetAccountResponse{Account: account}, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "account not found")
+}
+
+func (f *fakeLedger) ListAccountTypes(ctx context.Context, req *pb.ListAccountTypesRequest) (*pb.ListAccountTypesResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	asset := &pb.AccountType{Id: 1, Code: "ASSET", Name: "Asset", NormalBalance: "DEBIT", UpdatedAt: timestamppb.New(mustTime("2025-01-01T00:00:00Z"))}
+	if f.assetUpdatedAt != nil {
+		asset.UpdatedAt = f.assetUpdatedAt
+	}
+	return &pb.ListAccountTypesResponse{AccountTypes: []*pb.AccountType{
+		asset,
+		{Id: 4, Code: "REVENUE", Name: "Revenue", NormalBalance: "CREDIT", UpdatedAt: timestamppb.New(mustTime("2025-01-01T00:00:00Z"))},
+	}}, nil
+}
+
+func (f *fakeLedger) GetAccountByExternalId(ctx context.Context, req *pb.GetAccountByExternalIdRequest) (*pb.GetAccountByExternalIdResponse, error) {
+	if req.Source == "sap" && req.ExternalId == cash.ExternalIds["sap"] {
+		return &pb.GetAccountByExternalIdResponse{Account: cash}, nil
+	}
+	return nil, status.Error(codes.NotFound, "account not found")
+}
+
+func (f *fakeLedger) GetAccountBalance(ctx context.Context, req *pb.GetAccountBalanceRequest) (*pb.GetAccountBalanceResponse, error) {
+	if req.AsOf != nil {
+		return &pb.GetAccountBalanceResponse{AccountId: req.AccountId, DebitBalance: "40.00", CreditBalance: "0", NetBalance: "40.00"}, nil
+	}
+	return &pb.GetAccountBalanceResponse{AccountId: req.AccountId, DebitBalance: "100.00", CreditBalance: "0", NetBalance: "100.00"}, nil
+}
+
+func (f *fakeLedger) ListJournalEntries(ctx context.Context, req *pb.ListJournalEntriesRequest) (*pb.ListJournalEntriesResponse, error) {
+	return &pb.ListJournalEntriesResponse{
+		JournalEntries: []*pb.JournalEntry{sale},
+		TotalCount:     1,
+		Totals:         &pb.JournalEntryTotals{EntryCount: 1, TotalDebit: "100.00", TotalCredit: "0"},
+	}, nil
+}
+
+func (f *fakeLedger) AggregateJournalLines(ctx context.Context, req *pb.AggregateJournalLinesRequest) (*pb.AggregateJournalLinesResponse, error) {
+	if len(req.GroupBy) != 2 || req.GroupBy[0] != pb.AggregateGroupBy_AGGREGATE_GROUP_BY_ACCOUNT || req.GroupBy[1] != pb.AggregateGroupBy_AGGREGATE_GROUP_BY_MONTH {
+		return nil, status.Error(codes.InvalidArgument, "unexpected grouping")
+	}
+	accountID := "cash"
+	return &pb.AggregateJournalLinesResponse{Aggregates: []*pb.JournalLineAggregate{{
+		AccountId:   &accountID,
+		PeriodStart: timestamppb.New(mustTime("2025-03-01T00:00:00Z")),
+		TotalDebit:  "100.00",
+		TotalCredit: "0",
+		NetAmount:   "100.00",
+		LineCount:   1,
+	}}}, nil
+}
+
+func mustTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func newTestHandler(t *testing.T, ledger *fakeLedger, maxCalls int) *Handler {
+	tenant := uuid.MustParse(testTenant)
+	scopeAuth, err := auth.NewScopeAuthenticator(map[string]auth.APIKey{
+		"reader":   {Tenant: tenant, Scopes: []string{auth.ScopeReadAccounts}},
+		"poster":   {Tenant: tenant, Scopes: []string{auth.ScopeWriteJournal}},
+		"outsider": {Tenant: uuid.MustParse(foreign.TenantId), Scopes: []string{auth.ScopeReadAccounts}},
+	}, "")
+	require.NoError(t, err)
+
+	h, err := NewHandler(ledger, []grpc.UnaryServerInterceptor{
+		scopeAuth.UnaryServerInterceptor(),
+		auth.NewTenantResolver(nil).UnaryServerInterceptor(),
+	}, 8, maxCalls)
+	require.NoError(t, err)
+	return h
+}
+
+func post(h http.Handler, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Tenant-ID", testTenant)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("resolves an account with its lines and their entries in one request", func(t *testing.T) {
+		ledger := &fakeLedger{}
+		w := post(newTestHandler(t, ledger, 0), "reader", `{
+			"query": "query($id: ID!, $asOf: DateTime) { account(id: $id) { name balance { netBalance } earlier: balance(asOf: $asOf) { netBalance } lines(fromDate: \"2025-01-01\") { entryCount totalDebit lines { debit dimensions { code value } entry { referenceNumber entryDate lines { account { accountNumber } credit } } } } } }",
+			"variables": {"id": "cash", "asOf": "2025-02-01T00:00:00Z"}
+		}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.NotEmpty(t, w.Header().Get("x-request-id"))
+		assert.JSONEq(t, `{"data": {"account": {
+			"name": "Cash",
+			"balance": {"netBalance": "100.00"},
+			"earlier": {"netBalance": "40.00"},
+			"lines": {
+				"entryCount": 1,
+				"totalDebit": "100.00",
+				"lines": [{
+					"debit": "100.00",
+					"dimensions": [{"code": "channel", "value": "shop"}, {"code": "region", "value": "north"}],
+					"entry": {
+						"referenceNumber": "INV-1",
+						"entryDate": "2025-03-01T00:00:00Z",
+						"lines": [
+							{"account": {"accountNumber": "1000"}, "credit": "0"},
+							{"account": {"accountNumber": "4000"}, "credit": "100.00"}
+						]
+					}
+				}]
+			}
+		}}}`, w.Body.String())
+
+		// The cash account was looked up once for the query and once more
+		// for its line, which the cache served, and every call was scoped
+		// to the tenant of the request
+		assert.Equal(t, 2, ledger.accountCalls)
+		assert.Equal(t, []string{testTenant, testTenant}, ledger.tenants)
+	})
+
+	t.Run("serves reports", func(t *testing.T) {
+		w := post(newTestHandler(t, &fakeLedger{}, 0), "reader", `{"query": "{ aggregates(groupBy: [ACCOUNT, MONTH]) { aggregates { account { name } periodStart netAmount lineCount } totalsAsOf } }"}`)
+
+		assert.JSONEq(t, `{"data": {"aggregates": {
+			"aggregates": [{"account": {"name": "Cash"}, "periodStart": "2025-03-01T00:00:00Z", "netAmount": "100.00", "lineCount": 1}],
+			"totalsAsOf": null
+		}}}`, w.Body.String())
+	})
+
+	t.Run("reports service errors on their fields with the status code", func(t *testing.T) {
+		w := post(newTestHandler(t, &fakeLedger{}, 0), "reader", `{"query": "{ cash: account(id: \"cash\") { name } missing: account(id: \"nope\") { name } }"}`)
+
+		assert.JSONEq(t, `{
+			"data": {"cash": {"name": "Cash"}, "missing": null},
+			"errors": [{"message": "account not found", "path": ["missing"], "extensions": {"code": "NOT_FOUND"}}]
+		}`, w.Body.String())
+	})
+
+	t.Run("looks accounts up by external ID", func(t *testing.T) {
+		w := post(newTestHandler(t, &fakeLedger{}, 0), "reader", `{"query": "{ accountByExternalId(source: \"sap\", externalId: \"100000\") { name labels { key value } externalIds { source externalId } } }"}`)
+
+		assert.JSONEq(t, `{"data": {"accountByExternalId": {
+			"name": "Cash",
+			"labels": [{"key": "region", "value": "emea"}, {"key": "team", "value": "treasury"}],
+			"externalIds": [{"source": "sap", "externalId": "100000"}]
+		}}}`, w.Body.String())
+	})
+
+	t.Run("requires credentials with the read scope", func(t *testing.T) {
+		h := newTestHandler(t, &fakeLedger{}, 0)
+
+		w := post(h, "", `{"query": "{ account(id: \"cash\") { name } }"}`)
+		assert.Contains(t, w.Body.String(), `"code":"UNAUTHENTICATED"`)
+
+		w = post(h, "poster", `{"query": "{ account(id: \"cash\") { name } }"}`)
+		assert.Contains(t, w.Body.String(), `"message":"credentials lack the read:accounts scope"`)
+		assert.Contains(t, w.Body.String(), `"code":"PERMISSION_DENIED"`)
+	})
+
+	t.Run("refuses records of other tenants", func(t *testing.T) {
+		w := post(newTestHandler(t, &fakeLedger{}, 0), "outsider", `{"query": "{ account(id: \"cash\") { name } }"}`)
+		assert.Contains(t, w.Body.String(), `"code":"PERMISSION_DENIED"`)
+		assert.Contains(t, w.Body.String(), `"data":{"account":null}`)
+
+		w = post(newTestHandler(t, &fakeLedger{}, 0), "reader", `{"query": "{ account(id: \"foreign\") { name } }"}`)
+
+		assert.JSONEq(t, `{
+			"data": {"account": null},
+			"errors": [{"message": "record does not belong to the tenant of the query", "path": ["account"], "extensions": {"code": "PERMISSION_DENIED"}}]
+		}`, w.Body.String())
+	})
+
+	t.Run("bounds the calls a query makes", func(t *testing.T) {
+		w := post(newTestHandler(t, &fakeLedger{}, 1), "reader", `{"query": "{ account(id: \"cash\") { name balance { netBalance } } }"}`)
+
+		assert.Contains(t, w.Body.String(), `"message":"query needs more than 1 calls to the ledger; select fewer or smaller pages"`)
+		assert.Contains(t, w.Body.String(), `"data":{"account":null}`)
+	})
+
+	t.Run("accepts queries sent with GET", func(t *testing.T) {
+		params := url.Values{
+			"query":     {"query($id: ID!) { account(id: $id) { name } }"},
+			"variables": {`{"id": "sales"}`},
+		}
+		r := httptest.NewRequest(http.MethodGet, Path+"?"+params.Encode(), nil)
+		r.Header.Set("X-Tenant-ID", testTenant)
+		r.Header.Set("Authorization", "Bearer reader")
+		w := httptest.NewRecorder()
+		newTestHandler(t, &fakeLedger{}, 0).ServeHTTP(w, r)
+
+		assert.JSONEq(t, `{"data": {"account": {"name": "Sales"}}}`, w.Body.String())
+	})
+
+	t.Run("revalidates GET queries from when their records were updated", func(t *testing.T) {
+		ledger := &fakeLedger{}
+		h := newTestHandler(t, ledger, 0)
+		get := func(query string, header http.Header) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodGet, Path+"?"+url.Values{"query": {query}}.Encode(), nil)
+			for name, values := range header {
+				r.Header[name] = values
+			}
+			r.Header.Set("X-Tenant-ID", testTenant)
+			r.Header.Set("Authorization", "Bearer reader")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			return w
+		}
+		const query = `{ account(id: "cash") { name parent { name } } }`
+
+		w := get(query, nil)
+		tag := w.Header().Get("ETag")
+		require.NotEmpty(t, tag)
+		assert.True(t, strings.HasPrefix(tag, `W/"`))
+		assert.Equal(t, "Sat, 01 Mar 2025 09:30:00 GMT", w.Header().Get("Last-Modified"))
+		assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+
+		w = get(query, http.Header{"If-None-Match": {`"other", ` + tag}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+
+		w = get(query, http.Header{"If-Modified-Since": {"Sat, 01 Mar 2025 09:30:00 GMT"}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		w = get(query, http.Header{"If-Modified-Since": {"Sat, 01 Mar 2025 09:29:59 GMT"}})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// The same record selected differently is another result
+		w = get(`{ account(id: "cash") { name } }`, http.Header{"If-None-Match": {tag}})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, tag, w.Header().Get("ETag"))
+
+		// Updating the account changes its validators
+		ledger.cashUpdatedAt = timestamppb.New(mustTime("2025-03-02T10:00:00Z"))
+		w = get(query, http.Header{"If-None-Match": {tag}})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, tag, w.Header().Get("ETag"))
+		w = get(query, http.Header{"If-Modified-Since": {"Sat, 01 Mar 2025 09:30:00 GMT"}})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// Lists are tagged without a modification time, and results holding
+		// records without an updated_at, partial results and POSTed queries
+		// are not tagged
+		w = get(`{ account(id: "cash") { lines { entryCount } } }`, nil)
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+		assert.Empty(t, w.Header().Get("Last-Modified"))
+		w = get(`{ account(id: "cash") { balance { netBalance } } }`, nil)
+		assert.Empty(t, w.Header().Get("ETag"))
+		w = get(`{ account(id: "nope") { name } }`, nil)
+		assert.Empty(t, w.Header().Get("ETag"))
+		w = post(h, "reader", `{"query": "{ account(id: \"cash\") { name } }"}`)
+		assert.Empty(t, w.Header().Get("ETag"))
+	})
+
+	t.Run("revalidates reference data from when it was updated", func(t *testing.T) {
+		ledger := &fakeLedger{}
+		h := newTestHandler(t, ledger, 0)
+		get := func(query string, header http.Header) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodGet, Path+"?"+url.Values{"query": {query}}.Encode(), nil)
+			for name, values := range header {
+				r.Header[name] = values
+			}
+			r.Header.Set("X-Tenant-ID", testTenant)
+			r.Header.Set("Authorization", "Bearer reader")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			return w
+		}
+		const query = `{ account(id: "cash") { name accountType { code } } }`
+
+		w := get(query, nil)
+		assert.JSONEq(t, `{"data": {"account": {"name": "Cash", "accountType": {"code": "ASSET"}}}}`, w.Body.String())
+		tag := w.Header().Get("ETag")
+		require.NotEmpty(t, tag)
+		assert.Equal(t, "Sat, 01 Mar 2025 09:30:00 GMT", w.Header().Get("Last-Modified"))
+
+		w = get(query, http.Header{"If-None-Match": {tag}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+
+		// Renaming the account type, or translating it, moves its updated_at
+		ledger.mu.Lock()
+		ledger.assetUpdatedAt = timestamppb.New(mustTime("2025-04-01T12:00:00Z"))
+		ledger.mu.Unlock()
+		w = get(query, http.Header{"If-None-Match": {tag}})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, tag, w.Header().Get("ETag"))
+		assert.Equal(t, "Tue, 01 Apr 2025 12:00:00 GMT", w.Header().Get("Last-Modified"))
+
+		// The list of account types is tagged without a modification time
+		w = get(`{ accountTypes { code name } }`, nil)
+		tag = w.Header().Get("ETag")
+		assert.NotEmpty(t, tag)
+		assert.Empty(t, w.Header().Get("Last-Modified"))
+		w = get(`{ accountTypes { code name } }`, http.Header{"If-None-Match": {tag}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("rejects malformed requests", func(t *testing.T) {
+		h := newTestHandler(t, &fakeLedger{}, 0)
+
+		tests := []struct {
+			name    string
+			request func() *http.Request
+			code    int
+			message string
+		}{
+			{"missing tenant", func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(`{"query": "{ accountTypes { code } }"}`))
+				r.Header.Set("Content-Type", "application/json")
+				return r
+			}, http.StatusBadRequest, "the x-tenant-id header is required"},
+			{"invalid tenant", func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(`{"query": "{ accountTypes { code } }"}`))
+				r.Header.Set("Content-Type", "application/json")
+				r.Header.Set("X-Tenant-ID", "acme")
+				return r
+			}, http.StatusBadRequest, "invalid x-tenant-id header"},
+			{"not json", func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(`query=x`))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return r
+			}, http.StatusUnsupportedMediaType, "requests must be sent as application/json"},
+			{"no query", func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(`{}`))
+				r.Header.Set("Content-Type", "application/json")
+				return r
+			}, http.StatusBadRequest, "query is required"},
+			{"wrong method", func() *http.Request {
+				return httptest.NewRequest(http.MethodPut, Path, nil)
+			}, http.StatusMethodNotAllowed, "method not allowed"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, tt.request())
+
+				assert.Equal(t, tt.code, w.Code)
+				assert.JSONEq(t, `{"errors": [{"message": "`+tt.message+`"}]}`, w.Body.String())
+			})
+		}
+	})
+
+	t.Run("serves the schema", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newTestHandler(t, &fakeLedger{}, 0).ServeHTTP(w, httptest.NewRequest(http.MethodGet, SchemaPath, nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasPrefix(w.Body.String(), "type Query {\n"))
+		assert.Contains(t, w.Body.String(), "  lines(fromDate: DateTime, toDate: DateTime, page: Int, pageSize: Int): JournalLineList!\n")
+		assert.Contains(t, w.Body.String(), "scalar Decimal\n")
+	})
+}
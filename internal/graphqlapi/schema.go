@@ -0,0 +1,776 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// schemaDefinition is the schema of the API, resolved by the types below
+const schemaDefinition = `type Query {
+  account(id: ID!): Account
+  "Looks up the account mapped to an identifier of another system."
+  accountByExternalId(source: String!, externalId: String!): Account
+  accounts(bookId: ID, accountTypeId: Int, currencyCode: String, parentId: ID, "Lists the descendants of the account at any depth." ancestorId: ID, namePrefix: String, numberPrefix: String, isActive: Boolean, includeDeleted: Boolean, "Page to return, from 1." page: Int, "Items per page; 50 when not given, at most 100." pageSize: Int): AccountList!
+  accountTypes: [AccountType!]!
+  journalEntry(id: ID!): JournalEntry
+  journalEntries(accountId: ID, bookId: ID, fromDate: DateTime, toDate: DateTime, referencePrefix: String, descriptionContains: String, "Page to return, from 1." page: Int, "Items per page; 50 when not given, at most 100." pageSize: Int): JournalEntryList!
+  "Sums journal lines into groups, like AggregateJournalLines."
+  aggregates(groupBy: [AggregateGroupBy!], "Dimension codes grouped by with DIMENSION." dimensions: [String!], fromDate: DateTime, toDate: DateTime, accountId: ID, "The default book when not given." bookId: ID): AggregateReport!
+}
+
+type Account {
+  id: ID!
+  accountNumber: String!
+  name: String!
+  description: String!
+  accountTypeId: Int!
+  accountType: AccountType
+  currencyCode: String!
+  isActive: Boolean!
+  "Ancestors of the account; 0 for a root account."
+  depth: Int!
+  "Account numbers from the root down to the account, separated by \"/\"."
+  path: String!
+  bookId: ID!
+  overdraftLimit: Decimal
+  labels: [Label!]!
+  externalIds: [ExternalId!]!
+  createdAt: DateTime
+  updatedAt: DateTime
+  deletedAt: DateTime
+  parent: Account
+  "Direct children of the account."
+  children("Page to return, from 1." page: Int, "Items per page; 50 when not given, at most 100." pageSize: Int): AccountList!
+  "Current balance, or the balance as posted at asOf or effective on effectiveAsOf."
+  balance(asOf: DateTime, effectiveAsOf: DateTime): Balance!
+  "Lines posted to the account, with the entries they belong to."
+  lines(fromDate: DateTime, toDate: DateTime, page: Int, pageSize: Int): JournalLineList!
+}
+
+type AccountType {
+  id: Int!
+  code: String!
+  name: String!
+  "DEBIT or CREDIT."
+  normalBalance: String!
+}
+
+type Label {
+  key: String!
+  value: String!
+}
+
+"An identifier of an account in another system."
+type ExternalId {
+  "System the identifier comes from, such as sap."
+  source: String!
+  externalId: String!
+}
+
+type AccountList {
+  accounts: [Account!]!
+  "Accounts matching the filters on every page."
+  totalCount: Int!
+}
+
+type Balance {
+  debitBalance: Decimal!
+  creditBalance: Decimal!
+  netBalance: Decimal!
+  "Pending holds and reservations; null for point-in-time balances."
+  heldAmount: Decimal
+  "Null for point-in-time balances."
+  availableBalance: Decimal
+  updatedAt: DateTime
+}
+
+"Lines of an account, paged by the entries they belong to."
+type JournalLineList {
+  lines: [JournalLine!]!
+  "Entries with lines on the account on every page."
+  entryCount: Int!
+  "Sum of the debits of the lines on every page."
+  totalDebit: Decimal
+  totalCredit: Decimal
+}
+
+type JournalLine {
+  id: ID
+  accountId: ID!
+  account: Account
+  debit: Decimal!
+  credit: Decimal!
+  description: String!
+  "Rate, in account currency units per entry currency unit, the amounts of a line in another currency than its entry were converted at."
+  fxRate: Decimal
+  taxCodeId: ID
+  isTax: Boolean!
+  partyId: ID
+  counterpartyTenantId: ID
+  dimensions: [DimensionValue!]!
+  entry: JournalEntry!
+}
+
+type DimensionValue {
+  code: String!
+  value: String!
+}
+
+type JournalEntry {
+  id: ID!
+  referenceNumber: String!
+  description: String!
+  "Date the entry is effective for."
+  entryDate: DateTime
+  "When the entry was recorded in the ledger."
+  postedAt: DateTime
+  createdAt: DateTime
+  currencyCode: String
+  "JSON metadata of the entry."
+  metadata: String
+  lines: [JournalLine!]!
+}
+
+type JournalEntryList {
+  entries: [JournalEntry!]!
+  "Entries matching the filters on every page."
+  totalCount: Int!
+  "Sum of the debits of the matching entries; of the lines on the account when filtered by one."
+  totalDebit: Decimal
+  totalCredit: Decimal
+}
+
+type AggregateReport {
+  aggregates: [LineAggregate!]!
+  reportingCurrency: String
+  "When read from the period totals, the time every entry posted before is in them."
+  totalsAsOf: DateTime
+  "When read from the period totals, posted entries not yet in them."
+  pendingEntries: Int
+}
+
+"Totals of a group of journal lines; keys not grouped by are null."
+type LineAggregate {
+  accountId: ID
+  account: Account
+  accountNumber: String
+  accountTypeCode: String
+  currencyCode: String
+  dimensions: [DimensionValue!]!
+  "Start of the day or month bucket."
+  periodStart: DateTime
+  totalDebit: Decimal!
+  totalCredit: Decimal!
+  netAmount: Decimal!
+  lineCount: Int!
+}
+
+"Key to group journal lines by."
+enum AggregateGroupBy {
+  ACCOUNT
+  ACCOUNT_TYPE
+  CURRENCY
+  "Groups by the values of the dimensions asked for."
+  DIMENSION
+  DAY
+  MONTH
+}
+
+"An RFC 3339 timestamp. Arguments also accept a date, YYYY-MM-DD, meaning midnight UTC."
+scalar DateTime
+
+"An exact decimal amount, as a string so no precision is lost."
+scalar Decimal
+`
+
+// dateTime is the DateTime scalar
+type dateTime struct {
+	time.Time
+}
+
+func (dateTime) ImplementsGraphQLType(name string) bool {
+	return name == "DateTime"
+}
+
+func (t *dateTime) UnmarshalGraphQL(input interface{}) error {
+	s, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("DateTime cannot represent a non-string value: %v", input)
+	}
+	if parsed, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		t.Time = parsed
+		return nil
+	}
+	if parsed, err := time.Parse(time.DateOnly, s); err == nil {
+		t.Time = parsed
+		return nil
+	}
+	return fmt.Errorf("DateTime cannot represent %q, expected an RFC 3339 timestamp or YYYY-MM-DD date", s)
+}
+
+func (t dateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.UTC().Format(time.RFC3339Nano))
+}
+
+// amount is the Decimal scalar, kept as the string the ledger sends so no
+// precision is lost
+type amount string
+
+func (amount) ImplementsGraphQLType(name string) bool {
+	return name == "Decimal"
+}
+
+func (a *amount) UnmarshalGraphQL(input interface{}) error {
+	s, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("Decimal cannot represent a non-string value: %v", input)
+	}
+	if _, err := decimal.NewFromString(s); err != nil {
+		return fmt.Errorf("Decimal cannot represent %q", s)
+	}
+	*a = amount(s)
+	return nil
+}
+
+func (a amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(a))
+}
+
+// timestamp returns the time of ts, or nil when it is unset
+func timestamp(ts *timestamppb.Timestamp) *dateTime {
+	if ts == nil {
+		return nil
+	}
+	return &dateTime{ts.AsTime()}
+}
+
+// timeArg returns t as a timestamp, or nil when the argument was not given
+func timeArg(t *dateTime) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(t.Time)
+}
+
+func optionalAmount(s *string) *amount {
+	if s == nil {
+		return nil
+	}
+	a := amount(*s)
+	return &a
+}
+
+// totalDebit returns the debits summed over every page of entries, or nil
+// when the ledger sent no totals
+func totalDebit(resp *pb.ListJournalEntriesResponse) *amount {
+	if resp.Totals == nil {
+		return nil
+	}
+	return optionalAmount(&resp.Totals.TotalDebit)
+}
+
+// totalCredit returns the credits summed over every page of entries, or nil
+// when the ledger sent no totals
+func totalCredit(resp *pb.ListJournalEntriesResponse) *amount {
+	if resp.Totals == nil {
+		return nil
+	}
+	return optionalAmount(&resp.Totals.TotalCredit)
+}
+
+func optionalID(s *string) *graphql.ID {
+	if s == nil {
+		return nil
+	}
+	id := graphql.ID(*s)
+	return &id
+}
+
+func optionalString(id *graphql.ID) *string {
+	if id == nil {
+		return nil
+	}
+	s := string(*id)
+	return &s
+}
+
+// pageArgs are the arguments of paged fields
+type pageArgs struct {
+	Page     *int32
+	PageSize *int32
+}
+
+func (a pageArgs) page() (int32, int32) {
+	var p, size int32
+	if a.Page != nil {
+		p = *a.Page
+	}
+	if a.PageSize != nil {
+		size = *a.PageSize
+	}
+	return p, size
+}
+
+// mapEntry is an entry of a map field, such as a dimension value or a label
+type mapEntry struct {
+	key   string
+	value string
+}
+
+func (e *mapEntry) Code() string       { return e.key }
+func (e *mapEntry) Key() string        { return e.key }
+func (e *mapEntry) Source() string     { return e.key }
+func (e *mapEntry) Value() string      { return e.value }
+func (e *mapEntry) ExternalID() string { return e.value }
+
+// entries returns the entries of a map ordered by key
+func entries(values map[string]string) []*mapEntry {
+	result := make([]*mapEntry, 0, len(values))
+	for key, value := range values {
+		result = append(result, &mapEntry{key: key, value: value})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].key < result[j].key })
+	return result
+}
+
+// queryResolver resolves the fields of Query with c
+type queryResolver struct {
+	c *caller
+}
+
+func (q *queryResolver) Account(ctx context.Context, args struct{ ID graphql.ID }) (*accountResolver, error) {
+	return q.c.account(ctx, string(args.ID))
+}
+
+func (q *queryResolver) AccountByExternalID(ctx context.Context, args struct {
+	Source     string
+	ExternalID string
+}) (*accountResolver, error) {
+	resp, err := call(ctx, q.c, pb.LedgerService_GetAccountByExternalId_FullMethodName, &pb.GetAccountByExternalIdRequest{
+		Source:     args.Source,
+		ExternalId: args.ExternalID,
+	}, q.c.ledger.GetAccountByExternalId)
+	if err != nil {
+		return nil, err
+	}
+	return q.c.newAccount(ctx, resp.Account)
+}
+
+func (q *queryResolver) Accounts(ctx context.Context, args struct {
+	BookID         *graphql.ID
+	AccountTypeID  *int32
+	CurrencyCode   *string
+	ParentID       *graphql.ID
+	AncestorID     *graphql.ID
+	NamePrefix     *string
+	NumberPrefix   *string
+	IsActive       *bool
+	IncludeDeleted *bool
+	pageArgs
+}) (*accountListResolver, error) {
+	p, size := args.page()
+	return q.c.accounts(ctx, &pb.ListAccountsRequest{
+		BookId:            optionalString(args.BookID),
+		AccountTypeId:     args.AccountTypeID,
+		CurrencyCode:      args.CurrencyCode,
+		ParentAccountId:   optionalString(args.ParentID),
+		AncestorAccountId: optionalString(args.AncestorID),
+		NamePrefix:        args.NamePrefix,
+		NumberPrefix:      args.NumberPrefix,
+		IsActive:          args.IsActive,
+		IncludeDeleted:    args.IncludeDeleted != nil && *args.IncludeDeleted,
+		Page:              p,
+		PageSize:          size,
+	})
+}
+
+func (q *queryResolver) AccountTypes(ctx context.Context) ([]*accountTypeResolver, error) {
+	resp, err := call(ctx, q.c, pb.LedgerService_ListAccountTypes_FullMethodName, &pb.ListAccountTypesRequest{}, q.c.ledger.ListAccountTypes)
+	if err != nil {
+		return nil, err
+	}
+	calls := callsFrom(ctx)
+	calls.recordList("accountTypes", int32(len(resp.AccountTypes)))
+	types := make([]*accountTypeResolver, len(resp.AccountTypes))
+	for i, t := range resp.AccountTypes {
+		calls.record("accountType", t.Code, t.UpdatedAt)
+		types[i] = &accountTypeResolver{t}
+	}
+	return types, nil
+}
+
+func (q *queryResolver) JournalEntry(ctx context.Context, args struct{ ID graphql.ID }) (*entryResolver, error) {
+	resp, err := call(ctx, q.c, pb.LedgerService_GetJournalEntry_FullMethodName, &pb.GetJournalEntryRequest{JournalEntryId: string(args.ID)}, q.c.ledger.GetJournalEntry)
+	if err != nil {
+		return nil, err
+	}
+	return q.c.newEntry(ctx, resp.JournalEntry)
+}
+
+func (q *queryResolver) JournalEntries(ctx context.Context, args struct {
+	AccountID           *graphql.ID
+	BookID              *graphql.ID
+	FromDate            *dateTime
+	ToDate              *dateTime
+	ReferencePrefix     *string
+	DescriptionContains *string
+	pageArgs
+}) (*entryListResolver, error) {
+	p, size := args.page()
+	resp, err := call(ctx, q.c, pb.LedgerService_ListJournalEntries_FullMethodName, &pb.ListJournalEntriesRequest{
+		AccountId:           optionalString(args.AccountID),
+		BookId:              optionalString(args.BookID),
+		FromDate:            timeArg(args.FromDate),
+		ToDate:              timeArg(args.ToDate),
+		ReferencePrefix:     args.ReferencePrefix,
+		DescriptionContains: args.DescriptionContains,
+		Page:                p,
+		PageSize:            size,
+	}, q.c.ledger.ListJournalEntries)
+	if err != nil {
+		return nil, err
+	}
+	callsFrom(ctx).recordList("entries", resp.TotalCount)
+	list := &entryListResolver{resp: resp}
+	for _, e := range resp.JournalEntries {
+		entry, err := q.c.newEntry(ctx, e)
+		if err != nil {
+			return nil, err
+		}
+		list.entries = append(list.entries, entry)
+	}
+	return list, nil
+}
+
+func (q *queryResolver) Aggregates(ctx context.Context, args struct {
+	GroupBy    *[]string
+	Dimensions *[]string
+	FromDate   *dateTime
+	ToDate     *dateTime
+	AccountID  *graphql.ID
+	BookID     *graphql.ID
+}) (*aggregateReportResolver, error) {
+	req := &pb.AggregateJournalLinesRequest{
+		FromDate:  timeArg(args.FromDate),
+		ToDate:    timeArg(args.ToDate),
+		AccountId: optionalString(args.AccountID),
+		BookId:    optionalString(args.BookID),
+	}
+	if args.GroupBy != nil {
+		for _, g := range *args.GroupBy {
+			req.GroupBy = append(req.GroupBy, pb.AggregateGroupBy(pb.AggregateGroupBy_value["AGGREGATE_GROUP_BY_"+g]))
+		}
+	}
+	if args.Dimensions != nil {
+		req.Dimensions = *args.Dimensions
+	}
+	resp, err := call(ctx, q.c, pb.LedgerService_AggregateJournalLines_FullMethodName, req, q.c.ledger.AggregateJournalLines)
+	if err != nil {
+		return nil, err
+	}
+	callsFrom(ctx).recordUnversioned()
+	return &aggregateReportResolver{c: q.c, r: resp}, nil
+}
+
+// account gets an account by ID
+func (c *caller) account(ctx context.Context, id string) (*accountResolver, error) {
+	resp, err := call(ctx, c, pb.LedgerService_GetAccount_FullMethodName, &pb.GetAccountRequest{AccountId: id}, c.ledger.GetAccount)
+	if err != nil {
+		return nil, err
+	}
+	return c.newAccount(ctx, resp.Account)
+}
+
+// accounts lists the accounts matching req
+func (c *caller) accounts(ctx context.Context, req *pb.ListAccountsRequest) (*accountListResolver, error) {
+	resp, err := call(ctx, c, pb.LedgerService_ListAccounts_FullMethodName, req, c.ledger.ListAccounts)
+	if err != nil {
+		return nil, err
+	}
+	callsFrom(ctx).recordList("accounts", resp.TotalCount)
+	list := &accountListResolver{totalCount: resp.TotalCount}
+	for _, a := range resp.Accounts {
+		account, err := c.newAccount(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		list.accounts = append(list.accounts, account)
+	}
+	return list, nil
+}
+
+// newAccount resolves an account returned by the ledger, once it is known
+// to belong to the tenant of the query, and records its version
+func (c *caller) newAccount(ctx context.Context, a *pb.Account) (*accountResolver, error) {
+	if err := checkTenant(ctx, a.TenantId); err != nil {
+		return nil, err
+	}
+	callsFrom(ctx).record("account", a.AccountId, a.UpdatedAt)
+	return &accountResolver{c: c, a: a}, nil
+}
+
+// newEntry resolves a journal entry returned by the ledger, once it is
+// known to belong to the tenant of the query, and records its version
+func (c *caller) newEntry(ctx context.Context, e *pb.JournalEntry) (*entryResolver, error) {
+	if err := checkTenant(ctx, e.TenantId); err != nil {
+		return nil, err
+	}
+	callsFrom(ctx).record("entry", e.JournalEntryId, e.UpdatedAt)
+	return &entryResolver{c: c, e: e}, nil
+}
+
+type accountTypeResolver struct {
+	t *pb.AccountType
+}
+
+func (r *accountTypeResolver) ID() int32             { return r.t.Id }
+func (r *accountTypeResolver) Code() string          { return r.t.Code }
+func (r *accountTypeResolver) Name() string          { return r.t.Name }
+func (r *accountTypeResolver) NormalBalance() string { return r.t.NormalBalance }
+
+type accountResolver struct {
+	c *caller
+	a *pb.Account
+}
+
+func (r *accountResolver) ID() graphql.ID           { return graphql.ID(r.a.AccountId) }
+func (r *accountResolver) AccountNumber() string    { return r.a.AccountNumber }
+func (r *accountResolver) Name() string             { return r.a.Name }
+func (r *accountResolver) Description() string      { return r.a.Description }
+func (r *accountResolver) AccountTypeID() int32     { return r.a.AccountTypeId }
+func (r *accountResolver) CurrencyCode() string     { return r.a.CurrencyCode }
+func (r *accountResolver) IsActive() bool           { return r.a.IsActive }
+func (r *accountResolver) Depth() int32             { return r.a.Depth }
+func (r *accountResolver) Path() string             { return r.a.Path }
+func (r *accountResolver) BookID() graphql.ID       { return graphql.ID(r.a.BookId) }
+func (r *accountResolver) OverdraftLimit() *amount  { return optionalAmount(r.a.OverdraftLimit) }
+func (r *accountResolver) Labels() []*mapEntry      { return entries(r.a.Labels) }
+func (r *accountResolver) ExternalIDs() []*mapEntry { return entries(r.a.ExternalIds) }
+func (r *accountResolver) CreatedAt() *dateTime     { return timestamp(r.a.CreatedAt) }
+func (r *accountResolver) UpdatedAt() *dateTime     { return timestamp(r.a.UpdatedAt) }
+func (r *accountResolver) DeletedAt() *dateTime     { return timestamp(r.a.DeletedAt) }
+
+func (r *accountResolver) AccountType(ctx context.Context) (*accountTypeResolver, error) {
+	resp, err := call(ctx, r.c, pb.LedgerService_ListAccountTypes_FullMethodName, &pb.ListAccountTypesRequest{}, r.c.ledger.ListAccountTypes)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range resp.AccountTypes {
+		if t.Id == r.a.AccountTypeId {
+			callsFrom(ctx).record("accountType", t.Code, t.UpdatedAt)
+			return &accountTypeResolver{t}, nil
+		}
+	}
+	callsFrom(ctx).recordUnversioned()
+	return nil, nil
+}
+
+func (r *accountResolver) Parent(ctx context.Context) (*accountResolver, error) {
+	if r.a.ParentAccountId == nil {
+		return nil, nil
+	}
+	return r.c.account(ctx, *r.a.ParentAccountId)
+}
+
+func (r *accountResolver) Children(ctx context.Context, args pageArgs) (*accountListResolver, error) {
+	p, size := args.page()
+	return r.c.accounts(ctx, &pb.ListAccountsRequest{
+		ParentAccountId: &r.a.AccountId,
+		Page:            p,
+		PageSize:        size,
+	})
+}
+
+func (r *accountResolver) Balance(ctx context.Context, args struct {
+	AsOf          *dateTime
+	EffectiveAsOf *dateTime
+}) (*balanceResolver, error) {
+	resp, err := call(ctx, r.c, pb.LedgerService_GetAccountBalance_FullMethodName, &pb.GetAccountBalanceRequest{
+		AccountId:     r.a.AccountId,
+		AsOf:          timeArg(args.AsOf),
+		EffectiveAsOf: timeArg(args.EffectiveAsOf),
+	}, r.c.ledger.GetAccountBalance)
+	if err != nil {
+		return nil, err
+	}
+	callsFrom(ctx).record("balance", r.a.AccountId, resp.UpdatedAt)
+	return &balanceResolver{resp}, nil
+}
+
+func (r *accountResolver) Lines(ctx context.Context, args struct {
+	FromDate *dateTime
+	ToDate   *dateTime
+	pageArgs
+}) (*lineListResolver, error) {
+	p, size := args.page()
+	resp, err := call(ctx, r.c, pb.LedgerService_ListJournalEntries_FullMethodName, &pb.ListJournalEntriesRequest{
+		AccountId: &r.a.AccountId,
+		FromDate:  timeArg(args.FromDate),
+		ToDate:    timeArg(args.ToDate),
+		Page:      p,
+		PageSize:  size,
+	}, r.c.ledger.ListJournalEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	callsFrom(ctx).recordList("lines:"+r.a.AccountId, resp.TotalCount)
+	list := &lineListResolver{resp: resp}
+	for _, e := range resp.JournalEntries {
+		entry, err := r.c.newEntry(ctx, e)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range e.Lines {
+			if l.AccountId == r.a.AccountId {
+				list.lines = append(list.lines, &lineResolver{l: l, entry: entry})
+			}
+		}
+	}
+	return list, nil
+}
+
+type accountListResolver struct {
+	accounts   []*accountResolver
+	totalCount int32
+}
+
+func (r *accountListResolver) Accounts() []*accountResolver { return r.accounts }
+func (r *accountListResolver) TotalCount() int32            { return r.totalCount }
+
+type balanceResolver struct {
+	b *pb.GetAccountBalanceResponse
+}
+
+func (r *balanceResolver) DebitBalance() amount      { return amount(r.b.DebitBalance) }
+func (r *balanceResolver) CreditBalance() amount     { return amount(r.b.CreditBalance) }
+func (r *balanceResolver) NetBalance() amount        { return amount(r.b.NetBalance) }
+func (r *balanceResolver) HeldAmount() *amount       { return optionalAmount(r.b.HeldAmount) }
+func (r *balanceResolver) AvailableBalance() *amount { return optionalAmount(r.b.AvailableBalance) }
+func (r *balanceResolver) UpdatedAt() *dateTime      { return timestamp(r.b.UpdatedAt) }
+
+// lineListResolver is a page of the lines of an account
+type lineListResolver struct {
+	lines []*lineResolver
+	resp  *pb.ListJournalEntriesResponse
+}
+
+func (r *lineListResolver) Lines() []*lineResolver { return r.lines }
+func (r *lineListResolver) EntryCount() int32      { return r.resp.TotalCount }
+func (r *lineListResolver) TotalDebit() *amount    { return totalDebit(r.resp) }
+func (r *lineListResolver) TotalCredit() *amount   { return totalCredit(r.resp) }
+
+// lineResolver is a journal line with the entry it belongs to
+type lineResolver struct {
+	l     *pb.JournalEntryLine
+	entry *entryResolver
+}
+
+func (r *lineResolver) ID() *graphql.ID        { return optionalID(r.l.LineId) }
+func (r *lineResolver) AccountID() graphql.ID  { return graphql.ID(r.l.AccountId) }
+func (r *lineResolver) Debit() amount          { return amount(r.l.Debit) }
+func (r *lineResolver) Credit() amount         { return amount(r.l.Credit) }
+func (r *lineResolver) Description() string    { return r.l.Description }
+func (r *lineResolver) FxRate() *amount        { return optionalAmount(r.l.FxRate) }
+func (r *lineResolver) TaxCodeID() *graphql.ID { return optionalID(r.l.TaxCodeId) }
+func (r *lineResolver) IsTax() bool            { return r.l.IsTax }
+func (r *lineResolver) PartyID() *graphql.ID   { return optionalID(r.l.PartyId) }
+func (r *lineResolver) CounterpartyTenantID() *graphql.ID {
+	return optionalID(r.l.CounterpartyTenantId)
+}
+func (r *lineResolver) Dimensions() []*mapEntry { return entries(r.l.Dimensions) }
+func (r *lineResolver) Entry() *entryResolver   { return r.entry }
+
+func (r *lineResolver) Account(ctx context.Context) (*accountResolver, error) {
+	return r.entry.c.account(ctx, r.l.AccountId)
+}
+
+type entryResolver struct {
+	c *caller
+	e *pb.JournalEntry
+}
+
+func (r *entryResolver) ID() graphql.ID          { return graphql.ID(r.e.JournalEntryId) }
+func (r *entryResolver) ReferenceNumber() string { return r.e.ReferenceNumber }
+func (r *entryResolver) Description() string     { return r.e.Description }
+func (r *entryResolver) EntryDate() *dateTime    { return timestamp(r.e.EntryDate) }
+func (r *entryResolver) PostedAt() *dateTime     { return timestamp(r.e.PostedAt) }
+func (r *entryResolver) CreatedAt() *dateTime    { return timestamp(r.e.CreatedAt) }
+func (r *entryResolver) CurrencyCode() *string   { return r.e.CurrencyCode }
+func (r *entryResolver) Metadata() *string       { return r.e.Metadata }
+
+func (r *entryResolver) Lines() []*lineResolver {
+	lines := make([]*lineResolver, len(r.e.Lines))
+	for i, l := range r.e.Lines {
+		lines[i] = &lineResolver{l: l, entry: r}
+	}
+	return lines
+}
+
+type entryListResolver struct {
+	entries []*entryResolver
+	resp    *pb.ListJournalEntriesResponse
+}
+
+func (r *entryListResolver) Entries() []*entryResolver { return r.entries }
+func (r *entryListResolver) TotalCount() int32         { return r.resp.TotalCount }
+func (r *entryListResolver) TotalDebit() *amount       { return totalDebit(r.resp) }
+func (r *entryListResolver) TotalCredit() *amount      { return totalCredit(r.resp) }
+
+type aggregateReportResolver struct {
+	c *caller
+	r *pb.AggregateJournalLinesResponse
+}
+
+func (r *aggregateReportResolver) Aggregates() []*aggregateResolver {
+	aggregates := make([]*aggregateResolver, len(r.r.Aggregates))
+	for i, a := range r.r.Aggregates {
+		aggregates[i] = &aggregateResolver{c: r.c, a: a}
+	}
+	return aggregates
+}
+
+func (r *aggregateReportResolver) ReportingCurrency() *string { return r.r.ReportingCurrency }
+func (r *aggregateReportResolver) TotalsAsOf() *dateTime {
+	return timestamp(r.r.GetFreshness().GetTotalsAsOf())
+}
+
+func (r *aggregateReportResolver) PendingEntries() *int32 {
+	if r.r.Freshness == nil {
+		return nil
+	}
+	pending := int32(r.r.Freshness.PendingEntries)
+	return &pending
+}
+
+type aggregateResolver struct {
+	c *caller
+	a *pb.JournalLineAggregate
+}
+
+func (r *aggregateResolver) AccountID() *graphql.ID   { return optionalID(r.a.AccountId) }
+func (r *aggregateResolver) AccountNumber() *string   { return r.a.AccountNumber }
+func (r *aggregateResolver) AccountTypeCode() *string { return r.a.AccountTypeCode }
+func (r *aggregateResolver) CurrencyCode() *string    { return r.a.CurrencyCode }
+func (r *aggregateResolver) Dimensions() []*mapEntry  { return entries(r.a.Dimensions) }
+func (r *aggregateResolver) PeriodStart() *dateTime   { return timestamp(r.a.PeriodStart) }
+func (r *aggregateResolver) TotalDebit() amount       { return amount(r.a.TotalDebit) }
+func (r *aggregateResolver) TotalCredit() amount      { return amount(r.a.TotalCredit) }
+func (r *aggregateResolver) NetAmount() amount        { return amount(r.a.NetAmount) }
+func (r *aggregateResolver) LineCount() int32         { return int32(r.a.LineCount) }
+
+func (r *aggregateResolver) Account(ctx context.Context) (*accountResolver, error) {
+	if r.a.AccountId == nil {
+		return nil, nil
+	}
+	return r.c.account(ctx, *r.a.AccountId)
+}
@@ -0,0 +1,122 @@
+package graphqlapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// versions records the version of every record a query resolved, so a
+// result can be revalidated from when its records were last updated instead
+// of by running it again and comparing bodies
+type versions struct {
+	records      []string
+	lastModified time.Time
+	// unversioned is set once a field resolved something without an
+	// updated_at, such as an aggregate, which cannot be revalidated
+	unversioned bool
+	// listed is set once a field resolved a list, whose membership can
+	// change without any updated_at in the result moving
+	listed bool
+}
+
+// record adds the version of a record of the given kind
+func (c *calls) record(kind, id string, updatedAt *timestamppb.Timestamp) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if updatedAt == nil {
+		c.versions.unversioned = true
+		return
+	}
+	t := updatedAt.AsTime()
+	c.versions.records = append(c.versions.records, kind+":"+id+"@"+t.Format(time.RFC3339Nano))
+	if t.After(c.versions.lastModified) {
+		c.versions.lastModified = t
+	}
+}
+
+// recordList adds a list of the given kind with total items on every page;
+// its items are recorded on their own
+func (c *calls) recordList(kind string, total int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.versions.records = append(c.versions.records, fmt.Sprintf("%s#%d", kind, total))
+	c.versions.listed = true
+}
+
+// recordUnversioned marks the result as holding something without an
+// updated_at
+func (c *calls) recordUnversioned() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.versions.unversioned = true
+}
+
+// validators returns the entity tag of the result of req, and its last
+// modification time unless it holds lists. ok is false when the result
+// holds something that cannot be revalidated.
+func (c *calls) validators(req *request) (tag string, lastModified time.Time, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.versions.unversioned {
+		return "", time.Time{}, false
+	}
+
+	// The same records selected differently give another result, so the
+	// tag covers the request as well
+	variables, err := json.Marshal(req.Variables)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	records := append([]string(nil), c.versions.records...)
+	sort.Strings(records)
+
+	h := sha256.New()
+	for _, part := range append([]string{req.Query, req.OperationName, string(variables)}, records...) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	tag = `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+
+	if !c.versions.listed {
+		lastModified = c.versions.lastModified
+	}
+	return tag, lastModified, true
+}
+
+// notModified reports whether the preconditions of r hold for a result
+// with the given validators, as RFC 9110 evaluates them for GET:
+// If-None-Match when sent, and otherwise If-Modified-Since
+func notModified(r *http.Request, tag string, lastModified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		return etagMatches(header, tag)
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagMatches reports whether an If-None-Match header lists tag, comparing
+// weakly as RFC 9110 requires for GET
+func etagMatches(header, tag string) bool {
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Package interest computes interest on account balances under a day-count
+// convention and posts the accrual entries that fall due.
+package interest
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DayCount is the convention that turns a number of days into a fraction of
+// a year
+type DayCount string
+
+const (
+	// DayCountActual360 counts actual days over a 360-day year
+	DayCountActual360 DayCount = "ACT_360"
+	// DayCountActual365 counts actual days over a 365-day year
+	DayCountActual365 DayCount = "ACT_365"
+	// DayCountThirty360 counts 30-day months over a 360-day year (US bond
+	// basis)
+	DayCountThirty360 DayCount = "30_360"
+)
+
+// Valid reports whether the convention is one of the supported ones
+func (d DayCount) Valid() bool {
+	switch d {
+	case DayCountActual360, DayCountActual365, DayCountThirty360:
+		return true
+	}
+	return false
+}
+
+// Days returns the days counted from one date up to, but excluding, another
+func (d DayCount) Days(from, to time.Time) int64 {
+	from, to = Date(from), Date(to)
+
+	if d != DayCountThirty360 {
+		return int64(to.Sub(from).Hours() / 24)
+	}
+
+	d1, d2 := from.Day(), to.Day()
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+	return int64(360*(to.Year()-from.Year()) + 30*(int(to.Month())-int(from.Month())) + d2 - d1)
+}
+
+// Basis returns the days in a year
+func (d DayCount) Basis() int64 {
+	if d == DayCountActual365 {
+		return 365
+	}
+	return 360
+}
+
+// Movement is the net change of an account balance on one day, debits less
+// credits
+type Movement struct {
+	Date   time.Time
+	Amount decimal.Decimal
+}
+
+// Period is the days after Start up to and including End. Each day accrues
+// interest on its closing balance: Opening, the closing balance of Start,
+// plus the movements dated up to that day.
+type Period struct {
+	Start   time.Time
+	End     time.Time
+	Opening decimal.Decimal
+	// Movements are dated after Start up to End, in date order
+	Movements []Movement
+}
+
+// Accrue returns the simple interest of a period at an annual rate, rounded
+// to precision. A debit balance accrues positive interest and a credit
+// balance negative interest. The balances are weighted by the days they are
+// held and the rate applied to their sum, so rounding happens once per
+// period.
+func Accrue(p Period, rate decimal.Decimal, dayCount DayCount, precision int32) decimal.Decimal {
+	if !p.End.After(p.Start) {
+		return decimal.Zero
+	}
+
+	balance := p.Opening
+	from := Date(p.Start).AddDate(0, 0, 1)
+	weighted := decimal.Zero
+	for _, m := range p.Movements {
+		weighted = weighted.Add(balance.Mul(decimal.NewFromInt(dayCount.Days(from, m.Date))))
+		balance = balance.Add(m.Amount)
+		from = Date(m.Date)
+	}
+	weighted = weighted.Add(balance.Mul(decimal.NewFromInt(dayCount.Days(from, Date(p.End).AddDate(0, 0, 1)))))
+
+	return weighted.Mul(rate).Div(decimal.NewFromInt(dayCount.Basis())).Round(precision)
+}
+
+// Date returns the UTC date of a time
+func Date(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,77 @@
+package interest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDayCount_Days(t *testing.T) {
+	tests := []struct {
+		name     string
+		dayCount DayCount
+		from, to time.Time
+		days     int64
+	}{
+		{"actual days over a leap February", DayCountActual360, date(2024, 2, 1), date(2024, 3, 1), 29},
+		{"actual days over a year", DayCountActual365, date(2024, 1, 1), date(2025, 1, 1), 366},
+		{"30/360 counts every month as 30 days", DayCountThirty360, date(2024, 2, 1), date(2024, 3, 1), 30},
+		{"30/360 treats the 31st as the 30th", DayCountThirty360, date(2024, 1, 30), date(2024, 3, 31), 60},
+		{"30/360 keeps the 31st at the end of a period starting mid-month", DayCountThirty360, date(2024, 1, 15), date(2024, 1, 31), 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.days, tt.dayCount.Days(tt.from, tt.to))
+		})
+	}
+}
+
+func TestAccrue(t *testing.T) {
+	rate := decimal.RequireFromString("0.05")
+	january := Period{Start: date(2023, 12, 31), End: date(2024, 1, 31)}
+
+	t.Run("accrues a month under each convention", func(t *testing.T) {
+		january.Opening = decimal.NewFromInt(36000)
+		assert.Equal(t, "155", Accrue(january, rate, DayCountActual360, 2).String())
+		assert.Equal(t, "150", Accrue(january, rate, DayCountThirty360, 2).String())
+
+		january.Opening = decimal.NewFromInt(36500)
+		assert.Equal(t, "155", Accrue(january, rate, DayCountActual365, 2).String())
+	})
+
+	t.Run("charges each day on its closing balance", func(t *testing.T) {
+		period := Period{
+			Start:   date(2023, 12, 31),
+			End:     date(2024, 1, 31),
+			Opening: decimal.NewFromInt(10000),
+			Movements: []Movement{
+				{Date: date(2024, 1, 11), Amount: decimal.NewFromInt(8000)},
+			},
+		}
+
+		// 10 days at 10,000 and 21 days at 18,000
+		assert.Equal(t, "47.8", Accrue(period, decimal.RequireFromString("0.036"), DayCountActual360, 2).String())
+	})
+
+	t.Run("accrues negative interest on a credit balance", func(t *testing.T) {
+		january.Opening = decimal.NewFromInt(-36000)
+		assert.Equal(t, "-155", Accrue(january, rate, DayCountActual360, 2).String())
+	})
+
+	t.Run("rounds the interest of the period once", func(t *testing.T) {
+		day := Period{Start: date(2024, 1, 1), End: date(2024, 1, 2), Opening: decimal.NewFromInt(1000)}
+		assert.Equal(t, "0.14", Accrue(day, rate, DayCountActual365, 2).String())
+	})
+
+	t.Run("accrues nothing over an empty period", func(t *testing.T) {
+		empty := Period{Start: date(2024, 1, 31), End: date(2024, 1, 31), Opening: decimal.NewFromInt(1000)}
+		assert.True(t, Accrue(empty, rate, DayCountActual360, 2).IsZero())
+	})
+}
@@ -0,0 +1,126 @@
+package interest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// Poster posts the interest accruals that have fallen due
+type Poster struct {
+	interestRepo repository.InterestRepositoryInterface
+}
+
+// NewPoster creates a new poster
+func NewPoster(interestRepo repository.InterestRepositoryInterface) *Poster {
+	return &Poster{interestRepo: interestRepo}
+}
+
+// AccrueDue accrues the interest of every account with a scheme attached
+// from the day after its last accrual through the given day, and returns the
+// accruals it posted. An account ID limits accrual to that account. A period
+// whose interest rounds to zero is left to accrue with the next one. Periods
+// accrued concurrently by another run are skipped; any other error stops
+// accruing and is returned with the accruals posted so far.
+func (p *Poster) AccrueDue(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, through time.Time) ([]*repository.InterestAccrual, error) {
+	through = Date(through)
+
+	due, err := p.interestRepo.ListDueAccruals(ctx, tenantID, accountID, through)
+	if err != nil {
+		return nil, err
+	}
+
+	posted := make([]*repository.InterestAccrual, 0, len(due))
+	schemes := make(map[uuid.UUID]*repository.InterestScheme)
+	for _, ai := range due {
+		scheme, ok := schemes[ai.SchemeID]
+		if !ok {
+			scheme, err = p.interestRepo.GetScheme(ctx, tenantID, ai.SchemeID)
+			if err != nil {
+				return posted, err
+			}
+			schemes[ai.SchemeID] = scheme
+		}
+
+		period := Period{Start: Date(ai.AccruedThrough), End: through}
+		opening, movements, err := p.interestRepo.GetDailyMovements(ctx, tenantID, ai.AccountID, period.Start, period.End)
+		if err != nil {
+			return posted, err
+		}
+		period.Opening = opening
+		for _, m := range movements {
+			period.Movements = append(period.Movements, Movement{Date: m.Date, Amount: m.Amount})
+		}
+
+		amount := Accrue(period, scheme.AnnualRate, DayCount(scheme.DayCount), ai.Precision)
+		if amount.IsZero() {
+			continue
+		}
+
+		accrual := repository.InterestAccrual{
+			AccountID:   ai.AccountID,
+			SchemeID:    scheme.ID,
+			PeriodStart: period.Start.AddDate(0, 0, 1),
+			PeriodEnd:   period.End,
+			Amount:      amount,
+		}
+
+		result, err := p.interestRepo.PostAccrual(ctx, tenantID, accrual, Entry(scheme, ai, accrual))
+		if errors.Is(err, repository.ErrInterestAccrued) {
+			continue
+		}
+		if err != nil {
+			return posted, fmt.Errorf("failed to accrue interest of account %s through %s: %w", ai.AccountNumber, period.End.Format(time.DateOnly), err)
+		}
+		posted = append(posted, result)
+	}
+
+	return posted, nil
+}
+
+// Entry builds the journal entry of an accrual, dated on the last day of its
+// period. Interest on a debit balance, such as a loan, debits the accrual
+// account and credits the interest account; interest on a credit balance,
+// such as a deposit, debits the interest account and credits the accrual
+// account.
+func Entry(scheme *repository.InterestScheme, ai *repository.AccountInterest, accrual repository.InterestAccrual) repository.CreateJournalEntryParams {
+	description := fmt.Sprintf("%s interest on %s, %s to %s", scheme.Name, ai.AccountNumber,
+		accrual.PeriodStart.Format(time.DateOnly), accrual.PeriodEnd.Format(time.DateOnly))
+
+	debitAccountID, creditAccountID := scheme.AccrualAccountID, scheme.InterestAccountID
+	if accrual.Amount.IsNegative() {
+		debitAccountID, creditAccountID = creditAccountID, debitAccountID
+	}
+	amount := accrual.Amount.Abs()
+
+	return repository.CreateJournalEntryParams{
+		ReferenceNumber: fmt.Sprintf("INT-%s-%s", ai.AccountNumber, accrual.PeriodEnd.Format("20060102")),
+		Description:     description,
+		EntryDate:       accrual.PeriodEnd,
+		Metadata: map[string]interface{}{
+			"interest_account_id": ai.AccountID.String(),
+			"interest_scheme_id":  scheme.ID.String(),
+			"period_start":        accrual.PeriodStart.Format(time.DateOnly),
+			"period_end":          accrual.PeriodEnd.Format(time.DateOnly),
+		},
+		Lines: []*repository.CreateJournalEntryLineParams{
+			{
+				AccountID:   debitAccountID,
+				Debit:       amount,
+				Credit:      decimal.Zero,
+				Description: description,
+			},
+			{
+				AccountID:   creditAccountID,
+				Debit:       decimal.Zero,
+				Credit:      amount,
+				Description: description,
+			},
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package interest
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Runner periodically accrues the interest of every tenant through the last
+// complete day and reports the outcome as metrics and log lines
+type Runner struct {
+	tenantRepo repository.TenantRepositoryInterface
+	poster     *Poster
+	interval   time.Duration
+	now        func() time.Time
+
+	posted prometheus.Counter
+	errors prometheus.Counter
+}
+
+// NewRunner creates a new runner and registers its metrics with reg
+func NewRunner(
+	tenantRepo repository.TenantRepositoryInterface,
+	interestRepo repository.InterestRepositoryInterface,
+	interval time.Duration,
+	reg prometheus.Registerer,
+) *Runner {
+	r := &Runner{
+		tenantRepo: tenantRepo,
+		poster:     NewPoster(interestRepo),
+		interval:   interval,
+		now:        time.Now,
+		posted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_interest_accruals_posted_total",
+			Help: "Interest accrual entries posted by the background runner.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_interest_accrual_errors_total",
+			Help: "Interest accrual runs that failed for a tenant.",
+		}),
+	}
+
+	reg.MustRegister(r.posted, r.errors)
+
+	return r
+}
+
+// Run accrues interest once per interval until ctx is cancelled
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.AccrueAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// AccrueAll accrues interest through yesterday for every active tenant. A
+// tenant whose run fails is logged and counted, and the run carries on with
+// the others.
+func (r *Runner) AccrueAll(ctx context.Context) {
+	tenantIDs, err := r.tenantRepo.ListIDs(ctx)
+	if err != nil {
+		redact.Printf(redact.LevelError, "interest accrual run: %v", err)
+		r.errors.Inc()
+		return
+	}
+
+	through := Date(r.now()).AddDate(0, 0, -1)
+	for _, tenantID := range tenantIDs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		posted, err := r.poster.AccrueDue(ctx, tenantID, nil, through)
+		r.posted.Add(float64(len(posted)))
+		if err != nil {
+			redact.Printf(redact.LevelError, "interest accrual run of tenant %s: %v", tenantID, err)
+			r.errors.Inc()
+			continue
+		}
+		if len(posted) > 0 {
+			log.Printf("interest accrual run of tenant %s: posted %d entries", tenantID, len(posted))
+		}
+	}
+}
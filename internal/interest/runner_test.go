@@ -0,0 +1,199 @@
+package interest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTenantRepository struct {
+	repository.TenantRepositoryInterface
+	ids []uuid.UUID
+	err error
+}
+
+func (f *fakeTenantRepository) ListIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return f.ids, f.err
+}
+
+type fakeInterestRepository struct {
+	repository.InterestRepositoryInterface
+	schemes map[uuid.UUID]*repository.InterestScheme
+	due     map[uuid.UUID][]*repository.AccountInterest
+	opening map[uuid.UUID]decimal.Decimal
+	through time.Time
+	// postErr is returned when posting an accrual of the account with this ID
+	postErr  map[uuid.UUID]error
+	accruals []repository.InterestAccrual
+	entries  []repository.CreateJournalEntryParams
+}
+
+func (f *fakeInterestRepository) GetScheme(ctx context.Context, tenantID uuid.UUID, schemeID uuid.UUID) (*repository.InterestScheme, error) {
+	scheme, ok := f.schemes[schemeID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return scheme, nil
+}
+
+func (f *fakeInterestRepository) ListDueAccruals(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, through time.Time) ([]*repository.AccountInterest, error) {
+	f.through = through
+	due, ok := f.due[tenantID]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	return due, nil
+}
+
+func (f *fakeInterestRepository) GetDailyMovements(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, after, through time.Time) (decimal.Decimal, []*repository.DailyMovement, error) {
+	return f.opening[accountID], nil, nil
+}
+
+func (f *fakeInterestRepository) PostAccrual(ctx context.Context, tenantID uuid.UUID, accrual repository.InterestAccrual, entry repository.CreateJournalEntryParams) (*repository.InterestAccrual, error) {
+	if err := f.postErr[accrual.AccountID]; err != nil {
+		return nil, err
+	}
+	f.accruals = append(f.accruals, accrual)
+	f.entries = append(f.entries, entry)
+	accrual.ID = uuid.New()
+	return &accrual, nil
+}
+
+func TestPoster_AccrueDue(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	receivableID, incomeID := uuid.New(), uuid.New()
+	scheme := &repository.InterestScheme{
+		ID:                uuid.New(),
+		Name:              "Term loan",
+		AnnualRate:        decimal.RequireFromString("0.05"),
+		DayCount:          string(DayCountActual360),
+		AccrualAccountID:  receivableID,
+		InterestAccountID: incomeID,
+	}
+	loan := &repository.AccountInterest{
+		AccountID:      uuid.New(),
+		SchemeID:       scheme.ID,
+		AccountNumber:  "1500",
+		Precision:      2,
+		AccruedThrough: date(2023, 12, 31),
+	}
+	deposit := &repository.AccountInterest{
+		AccountID:      uuid.New(),
+		SchemeID:       scheme.ID,
+		AccountNumber:  "2500",
+		Precision:      2,
+		AccruedThrough: date(2023, 12, 31),
+	}
+	newRepo := func() *fakeInterestRepository {
+		return &fakeInterestRepository{
+			schemes: map[uuid.UUID]*repository.InterestScheme{scheme.ID: scheme},
+			due:     map[uuid.UUID][]*repository.AccountInterest{tenantID: {loan, deposit}},
+			opening: map[uuid.UUID]decimal.Decimal{
+				loan.AccountID:    decimal.NewFromInt(36000),
+				deposit.AccountID: decimal.NewFromInt(-7200),
+			},
+		}
+	}
+
+	t.Run("posts an accrual entry per account", func(t *testing.T) {
+		interestRepo := newRepo()
+
+		posted, err := NewPoster(interestRepo).AccrueDue(ctx, tenantID, nil, date(2024, 1, 31))
+		require.NoError(t, err)
+
+		require.Len(t, posted, 2)
+		assert.Equal(t, date(2024, 1, 1), posted[0].PeriodStart)
+		assert.Equal(t, date(2024, 1, 31), posted[0].PeriodEnd)
+		assert.Equal(t, "155", posted[0].Amount.String())
+		assert.Equal(t, "-31", posted[1].Amount.String())
+
+		loanEntry := interestRepo.entries[0]
+		assert.Equal(t, "INT-1500-20240131", loanEntry.ReferenceNumber)
+		assert.Equal(t, date(2024, 1, 31), loanEntry.EntryDate)
+		assert.Equal(t, receivableID, loanEntry.Lines[0].AccountID)
+		assert.True(t, loanEntry.Lines[0].Debit.Equal(decimal.NewFromInt(155)))
+		assert.Equal(t, incomeID, loanEntry.Lines[1].AccountID)
+
+		depositEntry := interestRepo.entries[1]
+		assert.Equal(t, incomeID, depositEntry.Lines[0].AccountID)
+		assert.True(t, depositEntry.Lines[0].Debit.Equal(decimal.NewFromInt(31)))
+		assert.Equal(t, receivableID, depositEntry.Lines[1].AccountID)
+		assert.True(t, depositEntry.Lines[1].Credit.Equal(decimal.NewFromInt(31)))
+	})
+
+	t.Run("leaves interest that rounds to zero to the next period", func(t *testing.T) {
+		interestRepo := newRepo()
+		interestRepo.opening[loan.AccountID] = decimal.NewFromInt(10)
+
+		posted, err := NewPoster(interestRepo).AccrueDue(ctx, tenantID, nil, date(2024, 1, 1))
+		require.NoError(t, err)
+
+		require.Len(t, posted, 1)
+		assert.Equal(t, deposit.AccountID, posted[0].AccountID)
+	})
+
+	t.Run("skips periods accrued by another run", func(t *testing.T) {
+		interestRepo := newRepo()
+		interestRepo.postErr = map[uuid.UUID]error{loan.AccountID: repository.ErrInterestAccrued}
+
+		posted, err := NewPoster(interestRepo).AccrueDue(ctx, tenantID, nil, date(2024, 1, 31))
+		require.NoError(t, err)
+
+		require.Len(t, posted, 1)
+		assert.Equal(t, deposit.AccountID, posted[0].AccountID)
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		interestRepo := newRepo()
+		interestRepo.postErr = map[uuid.UUID]error{loan.AccountID: repository.ErrDeletedAccount}
+
+		posted, err := NewPoster(interestRepo).AccrueDue(ctx, tenantID, nil, date(2024, 1, 31))
+		assert.ErrorIs(t, err, repository.ErrDeletedAccount)
+		assert.Empty(t, posted)
+	})
+}
+
+func TestRunner_AccrueAll(t *testing.T) {
+	ctx := context.Background()
+	healthy, failing := uuid.New(), uuid.New()
+	scheme := &repository.InterestScheme{ID: uuid.New(), AnnualRate: decimal.RequireFromString("0.05"), DayCount: string(DayCountActual360)}
+	account := &repository.AccountInterest{AccountID: uuid.New(), SchemeID: scheme.ID, Precision: 2, AccruedThrough: date(2024, 2, 28)}
+	now := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+
+	t.Run("accrues through yesterday and counts failing tenants", func(t *testing.T) {
+		interestRepo := &fakeInterestRepository{
+			schemes: map[uuid.UUID]*repository.InterestScheme{scheme.ID: scheme},
+			due:     map[uuid.UUID][]*repository.AccountInterest{healthy: {account}},
+			opening: map[uuid.UUID]decimal.Decimal{account.AccountID: decimal.NewFromInt(36000)},
+		}
+		tenantRepo := &fakeTenantRepository{ids: []uuid.UUID{healthy, failing}}
+		runner := NewRunner(tenantRepo, interestRepo, 0, prometheus.NewRegistry())
+		runner.now = func() time.Time { return now }
+
+		runner.AccrueAll(ctx)
+
+		assert.Equal(t, date(2024, 2, 29), interestRepo.through)
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.posted))
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.errors))
+	})
+
+	t.Run("counts an error when tenants cannot be listed", func(t *testing.T) {
+		tenantRepo := &fakeTenantRepository{err: errors.New("connection refused")}
+		runner := NewRunner(tenantRepo, &fakeInterestRepository{}, 0, prometheus.NewRegistry())
+
+		runner.AccrueAll(ctx)
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.errors))
+		assert.Zero(t, testutil.ToFloat64(runner.posted))
+	})
+}
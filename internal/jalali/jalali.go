@@ -0,0 +1,147 @@
+// Package jalali converts between Gregorian dates and the Jalali (Solar
+// Hijri) calendar used for civil and fiscal dates in Iran. Conversions use
+// the arithmetic 33-year leap cycle, which matches the official calendar for
+// the Gregorian years 1800 to 2100.
+package jalali
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date is a day in the Jalali calendar
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// FromTime returns the Jalali date of the UTC date of t
+func FromTime(t time.Time) Date {
+	t = t.UTC()
+	gy, gm, gd := t.Year(), int(t.Month()), t.Day()
+
+	jy := 979
+	gy -= 1600
+	gy2 := gy
+	if gm > 2 {
+		gy2++
+	}
+
+	days := 365*gy + (gy2+3)/4 - (gy2+99)/100 + (gy2+399)/400 - 80 + gd + gregorianDaysBefore[gm-1]
+	jy += 33 * (days / 12053)
+	days %= 12053
+	jy += 4 * (days / 1461)
+	days %= 1461
+	if days > 365 {
+		jy += (days - 1) / 365
+		days = (days - 1) % 365
+	}
+
+	if days < 186 {
+		return Date{Year: jy, Month: 1 + days/31, Day: 1 + days%31}
+	}
+	return Date{Year: jy, Month: 7 + (days-186)/30, Day: 1 + (days-186)%30}
+}
+
+// Time returns the Gregorian date of d at midnight UTC. The year starts at
+// Nowruz, between 19 and 22 March, so the date is estimated from 20 March and
+// then corrected by the day or two it may be off.
+func (d Date) Time() time.Time {
+	t := time.Date(d.Year+621, time.March, 20+d.dayOfYear(), 0, 0, 0, 0, time.UTC)
+	for range 4 {
+		switch got := FromTime(t); {
+		case got.before(d):
+			t = t.AddDate(0, 0, 1)
+		case d.before(got):
+			t = t.AddDate(0, 0, -1)
+		default:
+			return t
+		}
+	}
+	return t
+}
+
+// Valid reports whether d is a day of the calendar
+func (d Date) Valid() bool {
+	return d.Year >= 1 && d.Month >= 1 && d.Month <= 12 && d.Day >= 1 && d.Day <= MonthLength(d.Year, d.Month)
+}
+
+// String formats d as year/month/day, such as 1402/11/11
+func (d Date) String() string {
+	return fmt.Sprintf("%04d/%02d/%02d", d.Year, d.Month, d.Day)
+}
+
+// MonthStart returns the first day of the month of d
+func (d Date) MonthStart() Date {
+	return Date{Year: d.Year, Month: d.Month, Day: 1}
+}
+
+// dayOfYear returns the days of the year before d
+func (d Date) dayOfYear() int {
+	if d.Month <= 6 {
+		return (d.Month-1)*31 + d.Day - 1
+	}
+	return 186 + (d.Month-7)*30 + d.Day - 1
+}
+
+// before reports whether d is earlier than other
+func (d Date) before(other Date) bool {
+	if d.Year != other.Year {
+		return d.Year < other.Year
+	}
+	if d.Month != other.Month {
+		return d.Month < other.Month
+	}
+	return d.Day < other.Day
+}
+
+// IsLeap reports whether Esfand, the last month of a year, has 30 days
+func IsLeap(year int) bool {
+	return FromTime(Date{Year: year + 1, Month: 1, Day: 1}.Time().AddDate(0, 0, -1)).Day == 30
+}
+
+// MonthLength returns the number of days in a month: 31 in the first six
+// months, 30 in the next five and 29, or 30 in a leap year, in Esfand
+func MonthLength(year, month int) int {
+	switch {
+	case month <= 6:
+		return 31
+	case month <= 11:
+		return 30
+	case IsLeap(year):
+		return 30
+	default:
+		return 29
+	}
+}
+
+// Parse parses a date written year/month/day or year-month-day, such as
+// 1402/11/11
+func Parse(s string) (Date, error) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '/' || r == '-' })
+	if len(parts) != 3 {
+		return Date{}, fmt.Errorf("invalid Jalali date %q", s)
+	}
+
+	var fields [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Date{}, fmt.Errorf("invalid Jalali date %q", s)
+		}
+		fields[i] = n
+	}
+
+	d := Date{Year: fields[0], Month: fields[1], Day: fields[2]}
+	if !d.Valid() {
+		return Date{}, fmt.Errorf("invalid Jalali date %q", s)
+	}
+
+	return d, nil
+}
+
+// gregorianDaysBefore are the days of a common year before each month
+var gregorianDaysBefore = [12]int{0, 31, 59, 90, 120, 151, 181, 212, 243, 273, 304, 334}
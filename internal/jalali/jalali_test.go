@@ -0,0 +1,68 @@
+package jalali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromTime(t *testing.T) {
+	tests := []struct {
+		gregorian time.Time
+		jalali    Date
+	}{
+		{time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), Date{1402, 11, 11}},
+		{time.Date(2024, 3, 19, 0, 0, 0, 0, time.UTC), Date{1402, 12, 29}},
+		{time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC), Date{1403, 1, 1}},
+		{time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC), Date{1403, 12, 30}},
+		{time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC), Date{1404, 1, 1}},
+		{time.Date(2024, 9, 22, 0, 0, 0, 0, time.UTC), Date{1403, 7, 1}},
+		{time.Date(1979, 2, 11, 0, 0, 0, 0, time.UTC), Date{1357, 11, 22}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.jalali.String(), func(t *testing.T) {
+			assert.Equal(t, tt.jalali, FromTime(tt.gregorian))
+			assert.Equal(t, tt.gregorian, tt.jalali.Time())
+		})
+	}
+}
+
+func TestDate_TimeRoundTrips(t *testing.T) {
+	day := time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+	previous := FromTime(day.AddDate(0, 0, -1))
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		d := FromTime(day)
+		require.True(t, d.Valid(), "%s is %s", day, d)
+		require.Equal(t, day, d.Time(), "%s", d)
+		require.True(t, previous.before(d), "%s follows %s", d, previous)
+		previous = d
+	}
+}
+
+func TestMonthLength(t *testing.T) {
+	assert.Equal(t, 31, MonthLength(1402, 6))
+	assert.Equal(t, 30, MonthLength(1402, 7))
+	assert.Equal(t, 29, MonthLength(1402, 12))
+	assert.Equal(t, 30, MonthLength(1403, 12))
+	assert.True(t, IsLeap(1403))
+	assert.False(t, IsLeap(1404))
+}
+
+func TestParse(t *testing.T) {
+	d, err := Parse("1402/11/11")
+	require.NoError(t, err)
+	assert.Equal(t, Date{1402, 11, 11}, d)
+
+	d, err = Parse("1403-12-30")
+	require.NoError(t, err)
+	assert.Equal(t, "1403/12/30", d.String())
+
+	for _, invalid := range []string{"1402/12/30", "1402/13/01", "1402/07/31", "1402/11", "۱۴۰۲/۱۱/۱۱"} {
+		_, err := Parse(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
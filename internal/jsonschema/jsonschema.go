@@ -0,0 +1,176 @@
+// Package jsonschema describes the JSON encoding of Go types as JSON Schema
+// (draft 2020-12) documents, so consumers of the JSON the ledger publishes
+// can validate it.
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Draft is the JSON Schema dialect of the generated documents
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document or subschema
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+// decimalPattern matches decimals as shopspring/decimal encodes them
+const decimalPattern = `^-?[0-9]+(\.[0-9]+)?$`
+
+var (
+	uuidType        = reflect.TypeFor[uuid.UUID]()
+	decimalType     = reflect.TypeFor[decimal.Decimal]()
+	timeType        = reflect.TypeFor[time.Time]()
+	rawMessageType  = reflect.TypeFor[json.RawMessage]()
+	textMarshalType = reflect.TypeFor[encoding.TextMarshaler]()
+	jsonMarshalType = reflect.TypeFor[json.Marshaler]()
+)
+
+// For returns the schema of the JSON encoding/json produces for t. Struct
+// fields are required unless tagged omitempty, and pointers and maps that
+// are not omitted may be null. Objects allow properties the schema does not
+// list, so documents stay valid as fields are added.
+func For(t reflect.Type) (*Schema, error) {
+	schema, err := schemaOf(t)
+	if err != nil {
+		return nil, err
+	}
+	schema.Schema = Draft
+	return schema, nil
+}
+
+func schemaOf(t reflect.Type) (*Schema, error) {
+	switch t {
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}, nil
+	case decimalType:
+		return &Schema{Type: "string", Pattern: decimalPattern}, nil
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	case rawMessageType:
+		return &Schema{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem())
+	case reflect.Interface:
+		return &Schema{}, nil
+	}
+
+	// Types encoding themselves are only known to be strings when they
+	// marshal to text
+	if t.Implements(jsonMarshalType) || reflect.PointerTo(t).Implements(jsonMarshalType) {
+		return nil, fmt.Errorf("jsonschema: %s has a custom JSON encoding", t)
+	}
+	if t.Implements(textMarshalType) || reflect.PointerTo(t).Implements(textMarshalType) {
+		return &Schema{Type: "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentEncoding: "base64"}, nil
+		}
+		fallthrough
+	case reflect.Array:
+		items, err := schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("jsonschema: map keys of %s are not strings", t)
+		}
+		values, err := schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return structSchema(t)
+	}
+
+	return nil, fmt.Errorf("jsonschema: unsupported type %s", t)
+}
+
+func structSchema(t reflect.Type) (*Schema, error) {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get("json") == "-" {
+			continue
+		}
+		if field.Anonymous {
+			return nil, fmt.Errorf("jsonschema: embedded field %s of %s is not supported", field.Name, t)
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		property, err := schemaOf(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%w in field %s", err, field.Name)
+		}
+
+		omitEmpty := strings.Contains(","+opts+",", ",omitempty,")
+		switch field.Type.Kind() {
+		case reflect.Pointer, reflect.Map, reflect.Interface:
+			if !omitEmpty {
+				property = nullable(property)
+			}
+		case reflect.Slice:
+			if !omitEmpty && field.Type != rawMessageType {
+				property = nullable(property)
+			}
+		}
+
+		schema.Properties[name] = property
+		if !omitEmpty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema, nil
+}
+
+// nullable also allows null where s is expected; the empty schema already
+// allows anything
+func nullable(s *Schema) *Schema {
+	if typ, ok := s.Type.(string); ok {
+		s.Type = []string{typ, "null"}
+	}
+	return s
+}
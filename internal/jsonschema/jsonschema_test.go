@@ -0,0 +1,68 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type payload struct {
+	ID       uuid.UUID         `json:"id"`
+	Amount   decimal.Decimal   `json:"amount"`
+	Parent   *uuid.UUID        `json:"parent"`
+	Note     *string           `json:"note,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Lines    []line            `json:"lines"`
+	Digest   []byte            `json:"digest"`
+	Metadata map[string]any    `json:"metadata,omitempty"`
+	internal int
+	Skipped  string `json:"-"`
+}
+
+type line struct {
+	Count int64 `json:"count"`
+	Done  bool  `json:"done,omitempty"`
+}
+
+func TestFor(t *testing.T) {
+	schema, err := For(reflect.TypeFor[payload]())
+	require.NoError(t, err)
+
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"amount": {"type": "string", "pattern": "^-?[0-9]+(\\.[0-9]+)?$"},
+			"parent": {"type": ["string", "null"], "format": "uuid"},
+			"note": {"type": "string"},
+			"tags": {"type": "object", "additionalProperties": {"type": "string"}},
+			"lines": {"type": ["array", "null"], "items": {
+				"type": "object",
+				"properties": {"count": {"type": "integer"}, "done": {"type": "boolean"}},
+				"required": ["count"]
+			}},
+			"digest": {"type": ["string", "null"], "contentEncoding": "base64"},
+			"metadata": {"type": "object", "additionalProperties": {}}
+		},
+		"required": ["id", "amount", "parent", "lines", "digest"]
+	}`, string(data))
+}
+
+func TestFor_Unsupported(t *testing.T) {
+	_, err := For(reflect.TypeFor[struct {
+		Values map[int]string `json:"values"`
+	}]())
+	assert.Error(t, err)
+
+	_, err = For(reflect.TypeFor[struct{ C chan int }]())
+	assert.Error(t, err)
+}
@@ -0,0 +1,174 @@
+// Package keyring encrypts journal entry metadata with a key per tenant.
+// Tenant keys are derived from root keys held in the configuration or in
+// AWS KMS, so no key has to be stored next to the data it protects.
+package keyring
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/config"
+	"github.com/hesabFun/ledger/internal/secrets"
+)
+
+// RootKey derives the data key of a tenant. Derivation is deterministic, so
+// a root key can be rotated by keeping the old one for decryption.
+type RootKey interface {
+	DeriveKey(ctx context.Context, tenantID uuid.UUID) ([]byte, error)
+}
+
+// Sealed is a plaintext encrypted with AES-256-GCM under the data key that
+// the root key KeyID derived for a tenant. The tenant ID is authenticated
+// with the ciphertext, so a sealed value copied to another tenant does not
+// open.
+type Sealed struct {
+	KeyID      string `json:"key_id"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Keyring seals with the data keys of its active root key and opens with
+// those of any of its root keys. Data keys are cached once derived.
+type Keyring struct {
+	active string
+	roots  map[string]RootKey
+
+	mu   sync.Mutex
+	keys map[dataKeyID]cipher.AEAD
+}
+
+type dataKeyID struct {
+	rootID   string
+	tenantID uuid.UUID
+}
+
+// New creates a keyring sealing with the root key active
+func New(active string, roots map[string]RootKey) (*Keyring, error) {
+	if _, ok := roots[active]; !ok {
+		return nil, fmt.Errorf("active root key %q is not in the keyring", active)
+	}
+	return &Keyring{
+		active: active,
+		roots:  roots,
+		keys:   make(map[dataKeyID]cipher.AEAD),
+	}, nil
+}
+
+// NewFromConfig creates the configured keyring, or returns nil when none is
+// configured
+func NewFromConfig(ctx context.Context, cfg config.MetadataEncryptionConfig) (*Keyring, error) {
+	roots := make(map[string]RootKey, len(cfg.Keys))
+
+	switch cfg.Keyring {
+	case config.KeyringNone:
+		return nil, nil
+	case config.KeyringLocal:
+		for id, value := range cfg.Keys {
+			key, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid metadata key %q: %w", id, err)
+			}
+			root, err := NewLocalRootKey(key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid metadata key %q: %w", id, err)
+			}
+			roots[id] = root
+		}
+	case config.KeyringAWSKMS:
+		awsCfg, err := secrets.LoadAWSConfig(ctx, cfg.AWSRegion)
+		if err != nil {
+			return nil, err
+		}
+		client := secrets.NewKMSClient(awsCfg)
+		for id, keyID := range cfg.Keys {
+			roots[id] = NewKMSRootKey(client, keyID)
+		}
+	default:
+		return nil, fmt.Errorf("unknown metadata keyring %q", cfg.Keyring)
+	}
+
+	return New(cfg.ActiveKey, roots)
+}
+
+// Seal encrypts plaintext for a tenant with the active root key
+func (k *Keyring) Seal(ctx context.Context, tenantID uuid.UUID, plaintext []byte) (*Sealed, error) {
+	aead, err := k.dataKey(ctx, k.active, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return &Sealed{
+		KeyID:      k.active,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, tenantID[:]),
+	}, nil
+}
+
+// Open decrypts a value sealed for a tenant
+func (k *Keyring) Open(ctx context.Context, tenantID uuid.UUID, sealed *Sealed) ([]byte, error) {
+	aead, err := k.dataKey(ctx, sealed.KeyID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("sealed value has an invalid nonce")
+	}
+
+	plaintext, err := aead.Open(nil, sealed.Nonce, sealed.Ciphertext, tenantID[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sealed value: %w", err)
+	}
+	return plaintext, nil
+}
+
+// dataKey returns the cipher of the data key rootID derives for a tenant
+func (k *Keyring) dataKey(ctx context.Context, rootID string, tenantID uuid.UUID) (cipher.AEAD, error) {
+	id := dataKeyID{rootID: rootID, tenantID: tenantID}
+
+	k.mu.Lock()
+	aead, ok := k.keys[id]
+	k.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+
+	root, ok := k.roots[rootID]
+	if !ok {
+		return nil, fmt.Errorf("root key %q is not in the keyring", rootID)
+	}
+
+	key, err := root.DeriveKey(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive data key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data key cipher: %w", err)
+	}
+	aead, err = cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data key cipher: %w", err)
+	}
+
+	k.mu.Lock()
+	k.keys[id] = aead
+	k.mu.Unlock()
+	return aead, nil
+}
+
+// derivationMessage is what a root key authenticates to derive the data key
+// of a tenant
+func derivationMessage(tenantID uuid.UUID) []byte {
+	return []byte("ledger-metadata:" + tenantID.String())
+}
@@ -0,0 +1,136 @@
+package keyring
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRoot counts derivations and fails once failing is set
+type countingRoot struct {
+	RootKey
+	derived int
+	failing bool
+}
+
+func (c *countingRoot) DeriveKey(ctx context.Context, tenantID uuid.UUID) ([]byte, error) {
+	if c.failing {
+		return nil, errors.New("kms unavailable")
+	}
+	c.derived++
+	return c.RootKey.DeriveKey(ctx, tenantID)
+}
+
+func localRoot(t *testing.T, b byte) *LocalRootKey {
+	root, err := NewLocalRootKey(bytes.Repeat([]byte{b}, 32))
+	require.NoError(t, err)
+	return root
+}
+
+func TestKeyring_SealOpen(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	t.Run("opens what it sealed", func(t *testing.T) {
+		kr, err := New("2026", map[string]RootKey{"2026": localRoot(t, 1)})
+		require.NoError(t, err)
+
+		sealed, err := kr.Seal(ctx, tenantID, []byte(`{"customer":"Jane Doe"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "2026", sealed.KeyID)
+		assert.NotContains(t, string(sealed.Ciphertext), "Jane")
+
+		plaintext, err := kr.Open(ctx, tenantID, sealed)
+		require.NoError(t, err)
+		assert.Equal(t, `{"customer":"Jane Doe"}`, string(plaintext))
+	})
+
+	t.Run("does not open a value sealed for another tenant", func(t *testing.T) {
+		kr, err := New("2026", map[string]RootKey{"2026": localRoot(t, 1)})
+		require.NoError(t, err)
+
+		sealed, err := kr.Seal(ctx, tenantID, []byte("pii"))
+		require.NoError(t, err)
+
+		_, err = kr.Open(ctx, uuid.New(), sealed)
+		assert.Error(t, err)
+	})
+
+	t.Run("opens values sealed before a rotation", func(t *testing.T) {
+		old, err := New("2025", map[string]RootKey{"2025": localRoot(t, 1)})
+		require.NoError(t, err)
+		sealed, err := old.Seal(ctx, tenantID, []byte("pii"))
+		require.NoError(t, err)
+
+		rotated, err := New("2026", map[string]RootKey{"2025": localRoot(t, 1), "2026": localRoot(t, 2)})
+		require.NoError(t, err)
+
+		plaintext, err := rotated.Open(ctx, tenantID, sealed)
+		require.NoError(t, err)
+		assert.Equal(t, "pii", string(plaintext))
+
+		resealed, err := rotated.Seal(ctx, tenantID, []byte("pii"))
+		require.NoError(t, err)
+		assert.Equal(t, "2026", resealed.KeyID)
+	})
+
+	t.Run("rejects values sealed with an unknown root key", func(t *testing.T) {
+		kr, err := New("2026", map[string]RootKey{"2026": localRoot(t, 1)})
+		require.NoError(t, err)
+
+		_, err = kr.Open(ctx, tenantID, &Sealed{KeyID: "2024", Nonce: make([]byte, 12)})
+		assert.Error(t, err)
+	})
+
+	t.Run("derives each tenant key once", func(t *testing.T) {
+		root := &countingRoot{RootKey: localRoot(t, 1)}
+		kr, err := New("2026", map[string]RootKey{"2026": root})
+		require.NoError(t, err)
+
+		sealed, err := kr.Seal(ctx, tenantID, []byte("pii"))
+		require.NoError(t, err)
+		root.failing = true
+
+		_, err = kr.Open(ctx, tenantID, sealed)
+		require.NoError(t, err)
+		_, err = kr.Seal(ctx, uuid.New(), []byte("pii"))
+		assert.Error(t, err)
+		assert.Equal(t, 1, root.derived)
+	})
+}
+
+func TestNewFromConfig(t *testing.T) {
+	t.Run("returns no keyring when none is configured", func(t *testing.T) {
+		kr, err := NewFromConfig(context.Background(), config.MetadataEncryptionConfig{Keyring: config.KeyringNone})
+		require.NoError(t, err)
+		assert.Nil(t, kr)
+	})
+
+	t.Run("creates a local keyring", func(t *testing.T) {
+		kr, err := NewFromConfig(context.Background(), config.MetadataEncryptionConfig{
+			Keyring:   config.KeyringLocal,
+			Keys:      map[string]string{"2026": "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="},
+			ActiveKey: "2026",
+		})
+		require.NoError(t, err)
+
+		sealed, err := kr.Seal(context.Background(), uuid.New(), []byte("pii"))
+		require.NoError(t, err)
+		assert.Equal(t, "2026", sealed.KeyID)
+	})
+
+	t.Run("rejects an active key that is not configured", func(t *testing.T) {
+		_, err := NewFromConfig(context.Background(), config.MetadataEncryptionConfig{
+			Keyring:   config.KeyringLocal,
+			Keys:      map[string]string{"2026": "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="},
+			ActiveKey: "2027",
+		})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,50 @@
+package keyring
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/secrets"
+)
+
+// LocalRootKey derives data keys as the HMAC-SHA256 of the tenant under a
+// root key held in the configuration
+type LocalRootKey struct {
+	key []byte
+}
+
+// NewLocalRootKey creates a root key from 32 bytes of key material
+func NewLocalRootKey(key []byte) (*LocalRootKey, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("root key must be 32 bytes, got %d", len(key))
+	}
+	return &LocalRootKey{key: key}, nil
+}
+
+// DeriveKey returns the data key of a tenant
+func (l *LocalRootKey) DeriveKey(_ context.Context, tenantID uuid.UUID) ([]byte, error) {
+	mac := hmac.New(sha256.New, l.key)
+	mac.Write(derivationMessage(tenantID))
+	return mac.Sum(nil), nil
+}
+
+// KMSRootKey derives data keys as the HMAC-SHA256 of the tenant under an AWS
+// KMS HMAC key, so the root key never leaves KMS
+type KMSRootKey struct {
+	client *secrets.KMSClient
+	keyID  string
+}
+
+// NewKMSRootKey creates a root key for the KMS key keyID, an ID, ARN or
+// alias
+func NewKMSRootKey(client *secrets.KMSClient, keyID string) *KMSRootKey {
+	return &KMSRootKey{client: client, keyID: keyID}
+}
+
+// DeriveKey returns the data key of a tenant
+func (k *KMSRootKey) DeriveKey(ctx context.Context, tenantID uuid.UUID) ([]byte, error) {
+	return k.client.GenerateMac(ctx, k.keyID, derivationMessage(tenantID))
+}
@@ -0,0 +1,274 @@
+// Package loadgen drives concurrent journal entry postings against a ledger
+// server and reports their latency and throughput.
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// Scenarios maps scenario names to the share of postings that debit the
+// hot account. Postings to one account contend for its balance row, so the
+// hot scenarios measure the cost of that contention.
+var Scenarios = map[string]float64{
+	"spread": 0,
+	"mixed":  0.2,
+	"hot":    1,
+}
+
+// Config controls a load run
+type Config struct {
+	// TenantID is the tenant the postings are made for
+	TenantID string
+	// Accounts lists the accounts postings are spread across; the first is
+	// the hot account
+	Accounts []string
+	// Concurrency is the number of workers posting in parallel
+	Concurrency int
+	// Duration bounds the run; zero runs until Requests have been sent
+	Duration time.Duration
+	// Requests bounds the number of postings; zero runs for Duration
+	Requests int
+	// HotRatio is the share of postings that debit the hot account
+	HotRatio float64
+	// Timeout bounds each posting; zero leaves it to the context
+	Timeout time.Duration
+}
+
+func (c *Config) validate() error {
+	switch {
+	case c.TenantID == "":
+		return errors.New("tenant ID is required")
+	case len(c.Accounts) < 2:
+		return errors.New("at least two accounts are required")
+	case c.Concurrency < 1:
+		return errors.New("concurrency must be at least 1")
+	case c.Duration <= 0 && c.Requests <= 0:
+		return errors.New("a duration or a number of requests is required")
+	case c.HotRatio < 0 || c.HotRatio > 1:
+		return errors.New("hot ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+// postContext returns the context of a single posting
+func (c *Config) postContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.Timeout > 0 {
+		return context.WithTimeout(ctx, c.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// SetupAccounts creates count asset accounts for a tenant to post to and
+// returns their IDs. Account numbers get a random prefix so repeated runs
+// against the same tenant do not collide.
+func SetupAccounts(ctx context.Context, client pb.LedgerServiceClient, tenantID, currencyCode string, count int) ([]string, error) {
+	prefix := uuid.NewString()[:8]
+	accounts := make([]string, count)
+	for i := range accounts {
+		resp, err := client.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: fmt.Sprintf("LOAD-%s-%04d", prefix, i),
+			Name:          fmt.Sprintf("Load test account %d", i),
+			AccountTypeId: 1,
+			CurrencyCode:  currencyCode,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create account: %w", err)
+		}
+		accounts[i] = resp.AccountId
+	}
+	return accounts, nil
+}
+
+// Run posts journal entries until the configured duration or number of
+// requests is reached, or ctx is done, and reports the outcome. Failed
+// postings are counted by status code and do not stop the run.
+func Run(ctx context.Context, client pb.LedgerServiceClient, cfg Config) (*Report, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	var remaining chan struct{}
+	if cfg.Requests > 0 {
+		remaining = make(chan struct{}, cfg.Requests)
+		for i := 0; i < cfg.Requests; i++ {
+			remaining <- struct{}{}
+		}
+		close(remaining)
+	}
+
+	results := make([]*workerResult, cfg.Concurrency)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := range results {
+		results[i] = &workerResult{errors: make(map[codes.Code]int)}
+		wg.Add(1)
+		go func(result *workerResult) {
+			defer wg.Done()
+			worker(ctx, client, cfg, remaining, result)
+		}(results[i])
+	}
+	wg.Wait()
+
+	report := &Report{Elapsed: time.Since(start), Errors: make(map[codes.Code]int)}
+	for _, result := range results {
+		report.Latencies = append(report.Latencies, result.latencies...)
+		for code, n := range result.errors {
+			report.Errors[code] += n
+		}
+	}
+	sort.Slice(report.Latencies, func(i, j int) bool {
+		return report.Latencies[i] < report.Latencies[j]
+	})
+
+	return report, nil
+}
+
+// workerResult collects the outcome of one worker, so workers never share state
+type workerResult struct {
+	latencies []time.Duration
+	errors    map[codes.Code]int
+}
+
+func worker(ctx context.Context, client pb.LedgerServiceClient, cfg Config, remaining <-chan struct{}, result *workerResult) {
+	for ctx.Err() == nil {
+		if remaining != nil {
+			if _, ok := <-remaining; !ok {
+				return
+			}
+		}
+
+		req := NewEntry(cfg.TenantID, cfg.Accounts, cfg.HotRatio)
+
+		postCtx, cancel := cfg.postContext(ctx)
+		started := time.Now()
+		_, err := client.CreateJournalEntry(postCtx, req)
+		latency := time.Since(started)
+		cancel()
+
+		if err != nil {
+			// Postings cut short by the end of the run are not failures
+			if ctx.Err() != nil {
+				return
+			}
+			result.errors[status.Code(err)]++
+			continue
+		}
+		result.latencies = append(result.latencies, latency)
+	}
+}
+
+// NewEntry builds a balanced two-line posting between random accounts. With
+// probability hotRatio the debit goes to the first, hot, account.
+func NewEntry(tenantID string, accounts []string, hotRatio float64) *pb.CreateJournalEntryRequest {
+	debit := 1 + rand.IntN(len(accounts)-1)
+	if rand.Float64() < hotRatio {
+		debit = 0
+	}
+	credit := rand.IntN(len(accounts) - 1)
+	if credit >= debit {
+		credit++
+	}
+
+	amount := fmt.Sprintf("%d.%02d", 1+rand.IntN(1000), rand.IntN(100))
+	return &pb.CreateJournalEntryRequest{
+		TenantId:        tenantID,
+		ReferenceNumber: "LOAD-" + uuid.NewString(),
+		Description:     "Load test posting",
+		EntryDate:       timestamppb.Now(),
+		Lines: []*pb.JournalEntryLine{
+			{AccountId: accounts[debit], Debit: amount, Credit: "0"},
+			{AccountId: accounts[credit], Debit: "0", Credit: amount},
+		},
+	}
+}
+
+// Report is the outcome of a load run
+type Report struct {
+	Elapsed time.Duration
+	// Latencies of the successful postings, fastest first
+	Latencies []time.Duration
+	// Errors counts the failed postings by status code
+	Errors map[codes.Code]int
+}
+
+// Succeeded returns the number of successful postings
+func (r *Report) Succeeded() int {
+	return len(r.Latencies)
+}
+
+// Failed returns the number of failed postings
+func (r *Report) Failed() int {
+	failed := 0
+	for _, n := range r.Errors {
+		failed += n
+	}
+	return failed
+}
+
+// Throughput returns the successful postings per second
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Succeeded()) / r.Elapsed.Seconds()
+}
+
+// Percentile returns the latency below which p percent of the successful
+// postings completed, using the nearest-rank method
+func (r *Report) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(float64(len(r.Latencies))*p/100)) - 1
+	rank = max(0, min(rank, len(r.Latencies)-1))
+	return r.Latencies[rank]
+}
+
+// Write prints a summary of the report
+func (r *Report) Write(w io.Writer) error {
+	_, err := fmt.Fprintf(w,
+		"requests:   %d ok, %d failed in %s\nthroughput: %.1f postings/s\nlatency:    p50 %s  p90 %s  p99 %s  max %s\n",
+		r.Succeeded(), r.Failed(), r.Elapsed.Round(time.Millisecond),
+		r.Throughput(),
+		r.Percentile(50), r.Percentile(90), r.Percentile(99), r.Percentile(100),
+	)
+	if err != nil {
+		return err
+	}
+
+	failed := make([]codes.Code, 0, len(r.Errors))
+	for code := range r.Errors {
+		failed = append(failed, code)
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i] < failed[j] })
+	for _, code := range failed {
+		if _, err := fmt.Fprintf(w, "errors:     %s %d\n", code, r.Errors[code]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/service"
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// Benchmarks run against the server at LEDGER_BENCH_ADDR, posting for the
+// tenant LEDGER_BENCH_TENANT, or against an in-process server backed by the
+// in-memory repositories when no address is set
+const (
+	benchAddrEnv   = "LEDGER_BENCH_ADDR"
+	benchTenantEnv = "LEDGER_BENCH_TENANT"
+)
+
+// startServer serves the ledger service over an in-memory connection and
+// returns a client and a tenant to post for
+func startServer(tb testing.TB) (pb.LedgerServiceClient, string) {
+	tb.Helper()
+
+	store := memory.NewStore()
+	tenant, err := memory.NewTenantRepository(store).Create(context.Background(), "loadgen", nil)
+	require.NoError(tb, err)
+
+	server := grpc.NewServer()
+	pb.RegisterLedgerServiceServer(server, service.NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+	))
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	tb.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(tb, err)
+	tb.Cleanup(func() { conn.Close() })
+
+	return pb.NewLedgerServiceClient(conn), tenant.ID.String()
+}
+
+// benchTarget returns the client and tenant benchmarks post to
+func benchTarget(b *testing.B) (pb.LedgerServiceClient, string) {
+	addr := os.Getenv(benchAddrEnv)
+	if addr == "" {
+		return startServer(b)
+	}
+
+	tenantID := os.Getenv(benchTenantEnv)
+	if tenantID == "" {
+		b.Fatalf("%s is required with %s", benchTenantEnv, benchAddrEnv)
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(b, err)
+	b.Cleanup(func() { conn.Close() })
+
+	return pb.NewLedgerServiceClient(conn), tenantID
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	client, tenantID := startServer(t)
+
+	accounts, err := SetupAccounts(ctx, client, tenantID, "USD", 5)
+	require.NoError(t, err)
+
+	t.Run("sends the requested number of postings", func(t *testing.T) {
+		report, err := Run(ctx, client, Config{
+			TenantID:    tenantID,
+			Accounts:    accounts,
+			Concurrency: 4,
+			Requests:    100,
+			HotRatio:    Scenarios["hot"],
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 100, report.Succeeded())
+		assert.Zero(t, report.Failed())
+		assert.True(t, sort.SliceIsSorted(report.Latencies, func(i, j int) bool {
+			return report.Latencies[i] < report.Latencies[j]
+		}))
+
+		// Every posting debited the hot account
+		resp, err := client.ListJournalEntries(ctx, &pb.ListJournalEntriesRequest{TenantId: tenantID, AccountId: &accounts[0], PageSize: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int32(100), resp.TotalCount)
+	})
+
+	t.Run("runs for a duration", func(t *testing.T) {
+		report, err := Run(ctx, client, Config{
+			TenantID:    tenantID,
+			Accounts:    accounts,
+			Concurrency: 2,
+			Duration:    50 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		assert.Positive(t, report.Succeeded())
+		assert.Positive(t, report.Throughput())
+	})
+
+	t.Run("counts failed postings", func(t *testing.T) {
+		report, err := Run(ctx, client, Config{
+			TenantID:    tenantID,
+			Accounts:    []string{accounts[0], "not-an-account"},
+			Concurrency: 1,
+			Requests:    3,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Failed())
+	})
+
+	t.Run("rejects invalid configurations", func(t *testing.T) {
+		_, err := Run(ctx, client, Config{TenantID: tenantID, Accounts: accounts, Concurrency: 1})
+		assert.Error(t, err)
+	})
+}
+
+func TestReport(t *testing.T) {
+	report := &Report{Elapsed: 2 * time.Second, Errors: map[codes.Code]int{}}
+	for i := 1; i <= 100; i++ {
+		report.Latencies = append(report.Latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	assert.Equal(t, 50*time.Millisecond, report.Percentile(50))
+	assert.Equal(t, 99*time.Millisecond, report.Percentile(99))
+	assert.Equal(t, 100*time.Millisecond, report.Percentile(100))
+	assert.Equal(t, 50.0, report.Throughput())
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	assert.Contains(t, out.String(), "p99 99ms")
+}
+
+// BenchmarkCreateJournalEntry posts balanced entries in parallel for each
+// scenario and reports posting latency percentiles alongside ns/op. Use
+// -cpu to vary the number of concurrent clients.
+func BenchmarkCreateJournalEntry(b *testing.B) {
+	client, tenantID := benchTarget(b)
+	accounts, err := SetupAccounts(context.Background(), client, tenantID, "USD", 20)
+	require.NoError(b, err)
+
+	for _, scenario := range []string{"spread", "mixed", "hot"} {
+		b.Run(scenario, func(b *testing.B) {
+			report := &Report{}
+			var mu sync.Mutex
+
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				var local []time.Duration
+				for p.Next() {
+					started := time.Now()
+					_, err := client.CreateJournalEntry(context.Background(), NewEntry(tenantID, accounts, Scenarios[scenario]))
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					local = append(local, time.Since(started))
+				}
+				mu.Lock()
+				report.Latencies = append(report.Latencies, local...)
+				mu.Unlock()
+			})
+			b.StopTimer()
+
+			sort.Slice(report.Latencies, func(i, j int) bool {
+				return report.Latencies[i] < report.Latencies[j]
+			})
+			b.ReportMetric(float64(report.Percentile(50).Microseconds()), "p50-µs")
+			b.ReportMetric(float64(report.Percentile(99).Microseconds()), "p99-µs")
+		})
+	}
+}
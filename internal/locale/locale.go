@@ -0,0 +1,212 @@
+// Package locale formats amounts and dates for display in a user's locale,
+// with the digits, separators and calendar the locale uses.
+package locale
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hesabFun/ledger/internal/jalali"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Formatter formats numbers and dates for one locale. A nil Formatter
+// formats them in their plain machine-readable form.
+type Formatter struct {
+	tag       language.Tag
+	digits    [10]string
+	group     string
+	decimal   string
+	minus     string
+	primary   int
+	secondary int
+	persian   bool
+}
+
+// Parse returns the canonical form of a BCP 47 locale, such as fa-IR
+func Parse(locale string) (language.Tag, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return language.Und, fmt.Errorf("invalid locale %q: %w", locale, err)
+	}
+	return tag, nil
+}
+
+// Fallbacks returns the locales translations are looked up in for a locale,
+// most specific first: the locale itself and then its language alone, so fa-IR
+// falls back to fa
+func Fallbacks(tag language.Tag) []string {
+	locales := []string{tag.String()}
+	base, _ := tag.Base()
+	if base.String() != tag.String() {
+		locales = append(locales, base.String())
+	}
+	return locales
+}
+
+// NewFormatter creates a formatter for a BCP 47 locale. Number symbols come
+// from the CLDR data in golang.org/x/text; a regional locale it has no data
+// for falls back to its language, so fa-IR formats like fa.
+func NewFormatter(locale string) (*Formatter, error) {
+	tag, err := Parse(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	root := sample(language.Und)
+	symbolsTag := tag
+	for symbolsTag != language.Und && sample(symbolsTag) == root {
+		symbolsTag = symbolsTag.Parent()
+	}
+
+	f := &Formatter{tag: tag}
+	p := message.NewPrinter(symbolsTag)
+	for i := range f.digits {
+		f.digits[i] = p.Sprint(number.Decimal(i))
+	}
+
+	// The sample has four runs of digits, 1 234 567 5 in most locales and
+	// 12 34 567 5 in those grouping by two above the thousands
+	runs, separators := f.split(sample(symbolsTag))
+	if len(runs) != 4 || len(separators) != 3 {
+		return nil, fmt.Errorf("unsupported number format for locale %q", locale)
+	}
+	f.group = separators[0]
+	f.decimal = separators[2]
+	f.secondary = len(runs[1])
+	f.primary = len(runs[2])
+
+	negative := p.Sprint(number.Decimal(-1))
+	f.minus = strings.TrimSuffix(negative, f.digits[1])
+
+	calendar := tag.TypeForKey("ca")
+	base, _ := tag.Base()
+	f.persian = calendar == "persian" || (calendar == "" && base.String() == "fa")
+
+	return f, nil
+}
+
+// Locale returns the canonical form of the formatter's locale
+func (f *Formatter) Locale() string {
+	if f == nil {
+		return ""
+	}
+	return f.tag.String()
+}
+
+// Decimal formats an amount with the locale's digits, grouping and decimal
+// separator, keeping all of its fractional digits
+func (f *Formatter) Decimal(d decimal.Decimal) string {
+	if f == nil {
+		return d.String()
+	}
+
+	plain := d.Abs().String()
+	integer, fraction, _ := strings.Cut(plain, ".")
+
+	var b strings.Builder
+	if d.IsNegative() {
+		b.WriteString(f.minus)
+	}
+	for i := range len(integer) {
+		if i > 0 && f.groupBefore(len(integer)-i) {
+			b.WriteString(f.group)
+		}
+		b.WriteString(f.digits[integer[i]-'0'])
+	}
+	if fraction != "" {
+		b.WriteString(f.decimal)
+		for i := range len(fraction) {
+			b.WriteString(f.digits[fraction[i]-'0'])
+		}
+	}
+
+	return b.String()
+}
+
+// Date formats the UTC date of t as year-month-day in the locale's digits and
+// calendar; Persian locales use the Solar Hijri calendar
+func (f *Formatter) Date(t time.Time) string {
+	t = t.UTC()
+	if f == nil {
+		return t.Format(time.DateOnly)
+	}
+
+	year, month, day := t.Year(), int(t.Month()), t.Day()
+	if f.persian {
+		d := jalali.FromTime(t)
+		year, month, day = d.Year, d.Month, d.Day
+	}
+
+	return f.localize(fmt.Sprintf("%04d-%02d-%02d", year, month, day))
+}
+
+// groupBefore reports whether a group separator precedes the digit with
+// remaining digits left to its right, itself included
+func (f *Formatter) groupBefore(remaining int) bool {
+	if remaining == f.primary {
+		return true
+	}
+	return remaining > f.primary && (remaining-f.primary)%f.secondary == 0
+}
+
+// localize replaces the ASCII digits of s with the locale's digits
+func (f *Formatter) localize(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteString(f.digits[r-'0'])
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// split splits a formatted number into its runs of digits and the symbols
+// between them
+func (f *Formatter) split(s string) ([]string, []string) {
+	runs := make([]string, 0)
+	separators := make([]string, 0)
+	var run, separator strings.Builder
+	for s != "" {
+		digit := -1
+		for i, d := range f.digits {
+			if strings.HasPrefix(s, d) {
+				digit = i
+				break
+			}
+		}
+		if digit < 0 {
+			if run.Len() > 0 {
+				runs = append(runs, run.String())
+				run.Reset()
+			}
+			r, size := utf8.DecodeRuneInString(s)
+			separator.WriteRune(r)
+			s = s[size:]
+			continue
+		}
+		if separator.Len() > 0 {
+			separators = append(separators, separator.String())
+			separator.Reset()
+		}
+		run.WriteByte(byte('0' + digit))
+		s = s[len(f.digits[digit]):]
+	}
+	if run.Len() > 0 {
+		runs = append(runs, run.String())
+	}
+	return runs, separators
+}
+
+// sample formats a number showing a locale's digits, grouping and decimal
+// separator
+func sample(tag language.Tag) string {
+	return message.NewPrinter(tag).Sprint(number.Decimal(1234567.5, number.MinFractionDigits(1)))
+}
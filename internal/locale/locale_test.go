@@ -0,0 +1,85 @@
+package locale
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatter_Decimal(t *testing.T) {
+	tests := []struct {
+		locale string
+		amount string
+		want   string
+	}{
+		{"en-US", "1234567.50", "1,234,567.5"},
+		{"en-US", "-1000", "-1,000"},
+		{"en-US", "999.99", "999.99"},
+		{"de", "1234567.25", "1.234.567,25"},
+		{"en-IN", "123456789", "12,34,56,789"},
+		{"fa", "1234567.5", "۱٬۲۳۴٬۵۶۷٫۵"},
+		{"fa-IR", "-25000", "‎−۲۵٬۰۰۰"},
+		{"ar-EG", "1500.75", "١٬٥٠٠٫٧٥"},
+		{"ar-MA", "1500.75", "1.500,75"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+" "+tt.amount, func(t *testing.T) {
+			f, err := NewFormatter(tt.locale)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, f.Decimal(decimal.RequireFromString(tt.amount)))
+		})
+	}
+
+	t.Run("a nil formatter keeps the plain form", func(t *testing.T) {
+		var f *Formatter
+		assert.Equal(t, "-1234567.5", f.Decimal(decimal.RequireFromString("-1234567.5")))
+	})
+}
+
+func TestFormatter_Date(t *testing.T) {
+	date := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		locale string
+		date   time.Time
+		want   string
+	}{
+		{"en-US", date, "2024-01-31"},
+		{"ar", date, "٢٠٢٤-٠١-٣١"},
+		{"fa-IR", date, "۱۴۰۲-۱۱-۱۱"},
+		{"fa", time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC), "۱۴۰۳-۰۱-۰۱"},
+		{"fa", time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC), "۱۴۰۳-۱۲-۳۰"},
+		{"fa-u-ca-gregory", date, "۲۰۲۴-۰۱-۳۱"},
+		{"en-u-ca-persian", date, "1402-11-11"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			f, err := NewFormatter(tt.locale)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, f.Date(tt.date))
+		})
+	}
+
+	t.Run("a nil formatter uses ISO dates", func(t *testing.T) {
+		var f *Formatter
+		assert.Equal(t, "2024-01-31", f.Date(date))
+	})
+}
+
+func TestFallbacks(t *testing.T) {
+	tag, err := Parse("fa-ir")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fa-IR", "fa"}, Fallbacks(tag))
+
+	tag, err = Parse("ar")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ar"}, Fallbacks(tag))
+
+	_, err = Parse("not a locale")
+	assert.Error(t, err)
+}
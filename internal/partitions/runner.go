@@ -0,0 +1,128 @@
+// Package partitions keeps the monthly partitions of the journal tables ahead
+// of the entries posted to them and archives the old ones
+package partitions
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Runner periodically creates the journal partitions of the coming months
+// and moves the partitions of months long past to the archive tablespace,
+// reporting the outcome as metrics and log lines
+type Runner struct {
+	partitionRepo repository.JournalPartitionRepositoryInterface
+	aheadMonths   int
+	tablespace    string
+	afterMonths   int
+	interval      time.Duration
+
+	created  prometheus.Counter
+	archived prometheus.Counter
+	errors   prometheus.Counter
+}
+
+// NewRunner creates a new runner and registers its metrics with reg. With an
+// empty tablespace partitions are created but never archived.
+func NewRunner(
+	partitionRepo repository.JournalPartitionRepositoryInterface,
+	aheadMonths int,
+	tablespace string,
+	afterMonths int,
+	interval time.Duration,
+	reg prometheus.Registerer,
+) *Runner {
+	r := &Runner{
+		partitionRepo: partitionRepo,
+		aheadMonths:   aheadMonths,
+		tablespace:    tablespace,
+		afterMonths:   afterMonths,
+		interval:      interval,
+		created: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_journal_partitions_created_total",
+			Help: "Monthly journal partitions created by the background runner.",
+		}),
+		archived: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_journal_partitions_archived_total",
+			Help: "Journal partitions moved to the archive tablespace by the background runner.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_journal_partition_errors_total",
+			Help: "Journal partition creations and archivals that failed.",
+		}),
+	}
+
+	reg.MustRegister(r.created, r.archived, r.errors)
+
+	return r
+}
+
+// Run maintains the journal partitions once per interval until ctx is
+// cancelled
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.Maintain(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Maintain creates the partitions from the month of now through the ahead
+// months, then archives every bounded partition ending before the start of
+// the month the after months before now. A partition that fails to archive,
+// as when queries hold it past the lock timeout, is logged and counted and
+// retried on the next run.
+func (r *Runner) Maintain(ctx context.Context, now time.Time) {
+	month := time.Date(now.UTC().Year(), now.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	created, err := r.partitionRepo.CreatePartitions(ctx, month, month.AddDate(0, r.aheadMonths, 0))
+	for _, partition := range created {
+		r.created.Inc()
+		log.Printf("journal partitions: created %s", partition.Name)
+	}
+	if err != nil {
+		redact.Printf(redact.LevelError, "journal partitions: %v", err)
+		r.errors.Inc()
+	}
+
+	if r.tablespace == "" {
+		return
+	}
+
+	partitions, err := r.partitionRepo.ListPartitions(ctx)
+	if err != nil {
+		redact.Printf(redact.LevelError, "journal partitions: %v", err)
+		r.errors.Inc()
+		return
+	}
+
+	cutoff := month.AddDate(0, -r.afterMonths, 0)
+	for _, partition := range partitions {
+		if ctx.Err() != nil {
+			return
+		}
+		if partition.Tablespace == r.tablespace || partition.To.IsZero() || partition.To.After(cutoff) {
+			continue
+		}
+
+		if err := r.partitionRepo.ArchivePartition(ctx, partition, r.tablespace); err != nil {
+			redact.Printf(redact.LevelError, "journal partitions: %v", err)
+			r.errors.Inc()
+			continue
+		}
+		r.archived.Inc()
+		log.Printf("journal partitions: archived %s to tablespace %s", partition.Name, r.tablespace)
+	}
+}
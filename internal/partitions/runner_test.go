@@ -0,0 +1,98 @@
+package partitions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePartitionRepository struct {
+	partitions    []*repository.JournalPartition
+	listErr       error
+	createErr     error
+	archiveErrs   map[string]error
+	from, through time.Time
+	archived      []string
+}
+
+func (f *fakePartitionRepository) ListPartitions(ctx context.Context) ([]*repository.JournalPartition, error) {
+	return f.partitions, f.listErr
+}
+
+func (f *fakePartitionRepository) CreatePartitions(ctx context.Context, from, through time.Time) ([]*repository.JournalPartition, error) {
+	f.from, f.through = from, through
+	return []*repository.JournalPartition{{Table: "journal_entries", Name: "journal_entries_2026_12"}}, f.createErr
+}
+
+func (f *fakePartitionRepository) ArchivePartition(ctx context.Context, partition *repository.JournalPartition, tablespace string) error {
+	if err := f.archiveErrs[partition.Name]; err != nil {
+		return err
+	}
+	f.archived = append(f.archived, partition.Name)
+	return nil
+}
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRunner_Maintain(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	t.Run("creates the coming partitions and archives the old ones", func(t *testing.T) {
+		partitionRepo := &fakePartitionRepository{
+			partitions: []*repository.JournalPartition{
+				{Name: "journal_entries_old", To: month(2024, 1)},
+				{Name: "journal_entries_archived", From: month(2024, 1), To: month(2024, 2), Tablespace: "archive"},
+				{Name: "journal_entries_2024_09", From: month(2024, 9), To: month(2024, 10)},
+				{Name: "journal_entries_2024_10", From: month(2024, 10), To: month(2024, 11)},
+				{Name: "journal_entry_lines_2024_02", From: month(2024, 2), To: month(2024, 3)},
+				{Name: "journal_entries_future", From: month(2030, 1)},
+			},
+			archiveErrs: map[string]error{"journal_entry_lines_2024_02": errors.New("canceling statement due to lock timeout")},
+		}
+		runner := NewRunner(partitionRepo, 3, "archive", 24, 0, prometheus.NewRegistry())
+
+		runner.Maintain(ctx, now)
+
+		assert.Equal(t, month(2026, 10), partitionRepo.from)
+		assert.Equal(t, month(2027, 1), partitionRepo.through)
+		assert.Equal(t, []string{"journal_entries_old", "journal_entries_2024_09"}, partitionRepo.archived)
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.created))
+		assert.Equal(t, 2.0, testutil.ToFloat64(runner.archived))
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.errors))
+	})
+
+	t.Run("does not archive without an archive tablespace", func(t *testing.T) {
+		partitionRepo := &fakePartitionRepository{
+			partitions: []*repository.JournalPartition{{Name: "journal_entries_old", To: month(2020, 1)}},
+		}
+		runner := NewRunner(partitionRepo, 3, "", 24, 0, prometheus.NewRegistry())
+
+		runner.Maintain(ctx, now)
+
+		assert.Empty(t, partitionRepo.archived)
+		assert.Zero(t, testutil.ToFloat64(runner.errors))
+	})
+
+	t.Run("counts an error when partitions cannot be created or listed", func(t *testing.T) {
+		partitionRepo := &fakePartitionRepository{
+			createErr: errors.New("connection refused"),
+			listErr:   errors.New("connection refused"),
+		}
+		runner := NewRunner(partitionRepo, 3, "archive", 24, 0, prometheus.NewRegistry())
+
+		runner.Maintain(ctx, now)
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.created))
+		assert.Equal(t, 2.0, testutil.ToFloat64(runner.errors))
+		assert.Zero(t, testutil.ToFloat64(runner.archived))
+	})
+}
@@ -0,0 +1,236 @@
+// Package pdf writes simple text documents, such as account statements, as
+// PDF files. Text is set in the standard Helvetica fonts, which every PDF
+// reader provides, so no font is embedded; characters outside the Windows
+// Latin-1 encoding those fonts use are written as question marks.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Font selects one of the standard fonts
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+// resource names of the fonts in the page resources
+var fontNames = map[Font]string{Regular: "F1", Bold: "F2"}
+
+// Document is a PDF document built page by page. Positions are in points
+// from the top left corner of the page, with y pointing down.
+type Document struct {
+	title string
+	pages []*bytes.Buffer
+}
+
+// New creates an empty document with the given title
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// AddPage starts a new page that following drawing goes to
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// PageCount returns the number of pages added
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text draws text with its baseline starting at x, y
+func (d *Document) Text(x, y, size float64, font Font, text string) {
+	fmt.Fprintf(d.page(), "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		fontNames[font], size, x, PageHeight-y, escape(text))
+}
+
+// TextRight draws text with its baseline ending at x, y
+func (d *Document) TextRight(x, y, size float64, font Font, text string) {
+	d.Text(x-TextWidth(text, size), y, size, font, text)
+}
+
+// Line draws a straight line
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page(), "%.2f w %.2f %.2f m %.2f %.2f l S\n",
+		width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// WriteTo writes the document as a PDF file
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	pw := &writer{w: bufio.NewWriter(w)}
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 5 are the catalog, the page tree, the two fonts and the
+	// document information; each page then takes a page and a content object
+	const firstPage = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	pw.object("<< /Type /Catalog /Pages 2 0 R >>")
+	pw.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	pw.object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	pw.object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	pw.object(fmt.Sprintf("<< /Title (%s) /Producer (hesabFun ledger) >>", escape(d.title)))
+
+	for i, content := range d.pages {
+		pw.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, firstPage+2*i+1))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(content.Bytes()); err != nil {
+			return pw.n, err
+		}
+		if err := zw.Close(); err != nil {
+			return pw.n, err
+		}
+		pw.stream(compressed.Bytes())
+	}
+
+	pw.trailer()
+	if pw.err == nil {
+		pw.err = pw.w.Flush()
+	}
+	return pw.n, pw.err
+}
+
+// writer writes numbered objects, recording their offsets for the
+// cross-reference table
+type writer struct {
+	w       *bufio.Writer
+	n       int64
+	offsets []int64
+	err     error
+}
+
+func (pw *writer) printf(format string, args ...any) {
+	if pw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(pw.w, format, args...)
+	pw.n += int64(n)
+	pw.err = err
+}
+
+func (pw *writer) write(data []byte) {
+	if pw.err != nil {
+		return
+	}
+	n, err := pw.w.Write(data)
+	pw.n += int64(n)
+	pw.err = err
+}
+
+func (pw *writer) object(dict string) {
+	pw.offsets = append(pw.offsets, pw.n)
+	pw.printf("%d 0 obj\n%s\nendobj\n", len(pw.offsets), dict)
+}
+
+func (pw *writer) stream(data []byte) {
+	pw.offsets = append(pw.offsets, pw.n)
+	pw.printf("%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", len(pw.offsets), len(data))
+	pw.write(data)
+	pw.printf("\nendstream\nendobj\n")
+}
+
+func (pw *writer) trailer() {
+	xref := pw.n
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for _, offset := range pw.offsets {
+		pw.printf("%010d 00000 n \n", offset)
+	}
+	pw.printf("trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.offsets)+1, xref)
+}
+
+// escape encodes text as the body of a PDF literal string in WinAnsi
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			// WinAnsi matches Latin-1 here
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// helveticaWidths holds the advance widths of the printable ASCII characters
+// in Helvetica, in thousandths of the font size
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0 to 9
+	278, 278, 584, 584, 584, 556, 1015, // : to @
+	667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // A to M
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N to Z
+	278, 278, 278, 469, 556, 333, // [ to `
+	556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // a to m
+	556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // n to z
+	334, 260, 334, 584, // { to ~
+}
+
+// TextWidth returns the width of text set in Helvetica at the given size.
+// Characters outside printable ASCII count as wide as a digit.
+func TextWidth(text string, size float64) float64 {
+	width := 0
+	for _, r := range text {
+		if r >= 0x20 && r < 0x7f {
+			width += helveticaWidths[r-0x20]
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// Truncate shortens text with an ellipsis so it fits within width at the
+// given size
+func Truncate(text string, size, width float64) string {
+	if TextWidth(text, size) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if candidate := string(runes) + "..."; TextWidth(candidate, size) <= width {
+			return candidate
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_WriteTo(t *testing.T) {
+	doc := New("Statement")
+	doc.AddPage()
+	doc.Text(40, 60, 14, Bold, "Account Statement")
+	doc.TextRight(555, 80, 10, Regular, "1,250.00")
+	doc.Line(40, 90, 555, 90, 0.5)
+	doc.AddPage()
+	doc.Text(40, 60, 10, Regular, "Café (ICE) \\ 東京")
+
+	var buf bytes.Buffer
+	n, err := doc.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	data := buf.Bytes()
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(data, []byte("%%EOF\n")))
+	assert.Contains(t, string(data), "/Count 2")
+
+	// Every cross-reference offset points at the start of its object
+	xref := regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`).FindAllSubmatch(data, -1)
+	require.Len(t, xref, 9)
+	for i, match := range xref {
+		offset, err := strconv.Atoi(string(match[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(data[offset:], fmt.Appendf(nil, "%d 0 obj", i+1)), "object %d", i+1)
+	}
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	require.NotNil(t, startxref)
+	offset, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data[offset:], []byte("xref\n")))
+
+	// The second page's content stream escapes and re-encodes the text
+	streams := regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(data, -1)
+	require.Len(t, streams, 2)
+	zr, err := zlib.NewReader(bytes.NewReader(streams[1][1]))
+	require.NoError(t, err)
+	content, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `(Caf\351 \(ICE\) \\ ??) Tj`)
+}
+
+func TestTextWidth(t *testing.T) {
+	assert.InDelta(t, 5.56*3, TextWidth("100", 10), 0.001)
+	assert.InDelta(t, 0, TextWidth("", 10), 0.001)
+
+	truncated := Truncate("Monthly subscription renewal", 10, 60)
+	assert.LessOrEqual(t, TextWidth(truncated, 10), 60.0)
+	assert.Regexp(t, `^Monthly .*\.\.\.$`, truncated)
+	assert.Equal(t, "Rent", Truncate("Rent", 10, 60))
+}
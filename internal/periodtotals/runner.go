@@ -0,0 +1,92 @@
+// Package periodtotals refreshes the monthly account totals behind reports
+// when they are kept up to date on a schedule rather than as entries post
+package periodtotals
+
+import (
+	"context"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Runner periodically adds the entries every tenant posted since the last
+// refresh to its period totals and reports the outcome as metrics and log
+// lines
+type Runner struct {
+	tenantRepo repository.TenantRepositoryInterface
+	reportRepo repository.ReportRepositoryInterface
+	interval   time.Duration
+
+	refreshed prometheus.Counter
+	errors    prometheus.Counter
+}
+
+// NewRunner creates a new runner and registers its metrics with reg
+func NewRunner(
+	tenantRepo repository.TenantRepositoryInterface,
+	reportRepo repository.ReportRepositoryInterface,
+	interval time.Duration,
+	reg prometheus.Registerer,
+) *Runner {
+	r := &Runner{
+		tenantRepo: tenantRepo,
+		reportRepo: reportRepo,
+		interval:   interval,
+		refreshed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_period_totals_refreshed_entries_total",
+			Help: "Journal entries added to the period totals by the background runner.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_period_totals_refresh_errors_total",
+			Help: "Period totals refreshes that failed for a tenant.",
+		}),
+	}
+
+	reg.MustRegister(r.refreshed, r.errors)
+
+	return r
+}
+
+// Run refreshes the period totals once per interval until ctx is cancelled
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.RefreshAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshAll refreshes the period totals of every active tenant. A tenant
+// whose refresh fails is logged and counted, and the run carries on with the
+// others; its queued entries are picked up by the next run.
+func (r *Runner) RefreshAll(ctx context.Context) {
+	tenantIDs, err := r.tenantRepo.ListIDs(ctx)
+	if err != nil {
+		redact.Printf(redact.LevelError, "period totals refresh: %v", err)
+		r.errors.Inc()
+		return
+	}
+
+	for _, tenantID := range tenantIDs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		refreshed, err := r.reportRepo.RefreshPeriodTotals(ctx, tenantID)
+		if err != nil {
+			redact.Printf(redact.LevelError, "period totals refresh of tenant %s: %v", tenantID, err)
+			r.errors.Inc()
+			continue
+		}
+		r.refreshed.Add(float64(refreshed))
+	}
+}
@@ -0,0 +1,68 @@
+package periodtotals
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTenantRepository struct {
+	repository.TenantRepositoryInterface
+	ids []uuid.UUID
+	err error
+}
+
+func (f *fakeTenantRepository) ListIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return f.ids, f.err
+}
+
+type fakeReportRepository struct {
+	repository.ReportRepositoryInterface
+	queued    map[uuid.UUID]int
+	errs      map[uuid.UUID]error
+	refreshed []uuid.UUID
+}
+
+func (f *fakeReportRepository) RefreshPeriodTotals(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	if err := f.errs[tenantID]; err != nil {
+		return 0, err
+	}
+	f.refreshed = append(f.refreshed, tenantID)
+	return f.queued[tenantID], nil
+}
+
+func TestRunner_RefreshAll(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("refreshes every tenant and counts the entries added", func(t *testing.T) {
+		busy, idle, failing := uuid.New(), uuid.New(), uuid.New()
+		reportRepo := &fakeReportRepository{
+			queued: map[uuid.UUID]int{busy: 5},
+			errs:   map[uuid.UUID]error{failing: errors.New("connection refused")},
+		}
+		tenantRepo := &fakeTenantRepository{ids: []uuid.UUID{busy, failing, idle}}
+		runner := NewRunner(tenantRepo, reportRepo, 0, prometheus.NewRegistry())
+
+		runner.RefreshAll(ctx)
+
+		assert.Equal(t, []uuid.UUID{busy, idle}, reportRepo.refreshed)
+		assert.Equal(t, 5.0, testutil.ToFloat64(runner.refreshed))
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.errors))
+	})
+
+	t.Run("counts an error when tenants cannot be listed", func(t *testing.T) {
+		tenantRepo := &fakeTenantRepository{err: errors.New("connection refused")}
+		runner := NewRunner(tenantRepo, &fakeReportRepository{}, 0, prometheus.NewRegistry())
+
+		runner.RefreshAll(ctx)
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.errors))
+		assert.Zero(t, testutil.ToFloat64(runner.refreshed))
+	})
+}
@@ -0,0 +1,110 @@
+package projection
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+)
+
+// Accounts projects the state of accounts from their events: creation sets
+// every field, later events change the overdraft limit, parent, labels,
+// external IDs or deletion, and a merge closes the merged account. The
+// accounts table is a cache of this projection, which
+// repository.BalanceRepository.RebuildFromEvents restores.
+type Accounts struct {
+	accounts map[uuid.UUID]*repository.Account
+	// order is the creation order, in which parents come before children
+	order []uuid.UUID
+}
+
+// NewAccounts creates an empty account projection
+func NewAccounts() *Accounts {
+	return &Accounts{accounts: make(map[uuid.UUID]*repository.Account)}
+}
+
+// Apply folds an event into the projection; events of other aggregates are
+// ignored
+func (a *Accounts) Apply(event *repository.LedgerEvent) error {
+	if event.AggregateType != repository.AggregateAccount {
+		return nil
+	}
+
+	if event.EventType == repository.EventAccountCreated {
+		var payload repository.AccountCreatedPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid %s event %d: %w", event.EventType, event.Sequence, err)
+		}
+		a.accounts[event.AggregateID] = &repository.Account{
+			ID:              event.AggregateID,
+			TenantID:        event.TenantID,
+			BookID:          payload.BookID,
+			AccountNumber:   payload.AccountNumber,
+			Name:            payload.Name,
+			Description:     payload.Description,
+			AccountTypeID:   payload.AccountTypeID,
+			CurrencyCode:    payload.CurrencyCode,
+			ParentAccountID: payload.ParentAccountID,
+			IsActive:        !payload.Inactive,
+			CreatedAt:       event.RecordedAt,
+			OverdraftLimit:  payload.OverdraftLimit,
+			Labels:          map[string]string{},
+			ExternalIDs:     map[string]string{},
+		}
+		a.order = append(a.order, event.AggregateID)
+		return nil
+	}
+
+	account, ok := a.accounts[event.AggregateID]
+	if !ok {
+		return fmt.Errorf("%s event %d for account %s without an %s event", event.EventType, event.Sequence,
+			event.AggregateID, repository.EventAccountCreated)
+	}
+
+	var err error
+	switch event.EventType {
+	case repository.EventAccountDeleted, repository.EventAccountsMerged:
+		deletedAt := event.RecordedAt
+		account.DeletedAt = &deletedAt
+	case repository.EventAccountRestored:
+		account.DeletedAt = nil
+	case repository.EventAccountOverdraftLimitSet:
+		var payload repository.AccountOverdraftLimitSetPayload
+		err = json.Unmarshal(event.Payload, &payload)
+		account.OverdraftLimit = payload.OverdraftLimit
+	case repository.EventAccountMoved:
+		var payload repository.AccountMovedPayload
+		err = json.Unmarshal(event.Payload, &payload)
+		account.ParentAccountID = payload.ParentAccountID
+	case repository.EventAccountLabelsSet:
+		var payload repository.AccountLabelsSetPayload
+		err = json.Unmarshal(event.Payload, &payload)
+		account.Labels = payload.Labels
+		if account.Labels == nil {
+			account.Labels = map[string]string{}
+		}
+	case repository.EventAccountExternalIDSet:
+		var payload repository.AccountExternalIDSetPayload
+		err = json.Unmarshal(event.Payload, &payload)
+		if payload.ExternalID == nil {
+			delete(account.ExternalIDs, payload.Source)
+		} else {
+			account.ExternalIDs[payload.Source] = *payload.ExternalID
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s event %d: %w", event.EventType, event.Sequence, err)
+	}
+
+	return nil
+}
+
+// Accounts returns the projected accounts in the order they were created
+func (a *Accounts) Accounts() []*repository.Account {
+	accounts := make([]*repository.Account, len(a.order))
+	for i, id := range a.order {
+		accounts[i] = a.accounts[id]
+	}
+	return accounts
+}
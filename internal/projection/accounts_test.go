@@ -0,0 +1,87 @@
+package projection
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func accountEvent(t *testing.T, sequence int64, accountID uuid.UUID, eventType string, payload interface{}) *repository.LedgerEvent {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	return &repository.LedgerEvent{
+		Sequence:      sequence,
+		AggregateType: repository.AggregateAccount,
+		AggregateID:   accountID,
+		EventType:     eventType,
+		Payload:       data,
+		RecordedAt:    time.Date(2026, 3, 1, 0, 0, int(sequence), 0, time.UTC),
+	}
+}
+
+func TestAccounts_Apply(t *testing.T) {
+	book, assets, cash, petty := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	limit := decimal.NewFromInt(50)
+	externalID := "ERP-1000"
+
+	events := []*repository.LedgerEvent{
+		accountEvent(t, 1, cash, repository.EventAccountCreated, repository.AccountCreatedPayload{
+			BookID: book, AccountNumber: "1000", Name: "Cash", AccountTypeID: 1, CurrencyCode: "USD",
+		}),
+		accountEvent(t, 2, petty, repository.EventAccountCreated, repository.AccountCreatedPayload{
+			BookID: book, AccountNumber: "1010", Name: "Petty Cash", AccountTypeID: 1, CurrencyCode: "USD", Inactive: true,
+		}),
+		// A parent created after its child was moved under it
+		accountEvent(t, 3, assets, repository.EventAccountCreated, repository.AccountCreatedPayload{
+			BookID: book, AccountNumber: "1", Name: "Assets", AccountTypeID: 1, CurrencyCode: "USD",
+		}),
+		accountEvent(t, 4, cash, repository.EventAccountMoved, repository.AccountMovedPayload{ParentAccountID: &assets}),
+		accountEvent(t, 5, cash, repository.EventAccountOverdraftLimitSet, repository.AccountOverdraftLimitSetPayload{OverdraftLimit: &limit}),
+		accountEvent(t, 6, cash, repository.EventAccountLabelsSet, repository.AccountLabelsSetPayload{Labels: map[string]string{"team": "ops"}}),
+		accountEvent(t, 7, cash, repository.EventAccountExternalIDSet, repository.AccountExternalIDSetPayload{Source: "erp", ExternalID: &externalID}),
+		accountEvent(t, 8, petty, repository.EventAccountsMerged, repository.AccountsMergedPayload{TargetAccountID: cash}),
+		// Events of other aggregates are ignored
+		postedEvent(t, 9, repository.PostedLine{AccountID: cash, Debit: decimal.NewFromInt(10)}),
+	}
+
+	accounts := NewAccounts()
+	for _, event := range events {
+		require.NoError(t, accounts.Apply(event))
+	}
+
+	projected := accounts.Accounts()
+	require.Len(t, projected, 3)
+	assert.Equal(t, []uuid.UUID{cash, petty, assets}, []uuid.UUID{projected[0].ID, projected[1].ID, projected[2].ID})
+
+	assert.Equal(t, "Cash", projected[0].Name)
+	assert.Equal(t, &assets, projected[0].ParentAccountID)
+	assert.Equal(t, "50", projected[0].OverdraftLimit.String())
+	assert.Equal(t, map[string]string{"team": "ops"}, projected[0].Labels)
+	assert.Equal(t, map[string]string{"erp": "ERP-1000"}, projected[0].ExternalIDs)
+	assert.True(t, projected[0].IsActive)
+	assert.Nil(t, projected[0].DeletedAt)
+
+	assert.False(t, projected[1].IsActive)
+	require.NotNil(t, projected[1].DeletedAt)
+	assert.Equal(t, events[7].RecordedAt, *projected[1].DeletedAt)
+
+	// Restoring clears the deletion and removing an external ID drops it
+	require.NoError(t, accounts.Apply(accountEvent(t, 10, petty, repository.EventAccountRestored, struct{}{})))
+	require.NoError(t, accounts.Apply(accountEvent(t, 11, cash, repository.EventAccountExternalIDSet, repository.AccountExternalIDSetPayload{Source: "erp"})))
+	assert.Nil(t, accounts.Accounts()[1].DeletedAt)
+	assert.Empty(t, accounts.Accounts()[0].ExternalIDs)
+}
+
+func TestAccounts_ApplyWithoutCreation(t *testing.T) {
+	err := NewAccounts().Apply(accountEvent(t, 4, uuid.New(), repository.EventAccountDeleted, struct{}{}))
+
+	assert.ErrorContains(t, err, "event 4")
+}
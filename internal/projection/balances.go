@@ -0,0 +1,93 @@
+// Package projection builds ledger state by replaying events from the event
+// store, so state can be rebuilt from scratch or as of any point in time.
+package projection
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// Balance is the projected balance of an account
+type Balance struct {
+	Debit  decimal.Decimal
+	Credit decimal.Decimal
+}
+
+// Net returns debits minus credits
+func (b Balance) Net() decimal.Decimal {
+	return b.Debit.Sub(b.Credit)
+}
+
+// Balances projects account balances from JournalEntryPosted events. Line
+// amounts are in the account's currency, so they are added as posted. A merge
+// moves balances with a posted reclassification entry, so AccountsMerged
+// events are ignored like other events.
+type Balances struct {
+	accounts map[uuid.UUID]*Balance
+	// effectiveThrough, when set, is the last entry date counted
+	effectiveThrough string
+}
+
+// NewBalances creates an empty balance projection
+func NewBalances() *Balances {
+	return &Balances{accounts: make(map[uuid.UUID]*Balance)}
+}
+
+// NewBalancesEffectiveAsOf creates an empty balance projection that only
+// counts entries effective on or before date, however late they were posted
+func NewBalancesEffectiveAsOf(date time.Time) *Balances {
+	b := NewBalances()
+	b.effectiveThrough = date.UTC().Format("2006-01-02")
+	return b
+}
+
+// Apply folds an event into the projection; events that do not affect
+// balances are ignored
+func (b *Balances) Apply(event *repository.LedgerEvent) error {
+	if event.EventType != repository.EventJournalEntryPosted {
+		return nil
+	}
+
+	var payload repository.JournalEntryPostedPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid %s event %d: %w", event.EventType, event.Sequence, err)
+	}
+
+	// Entry dates are ISO 8601 dates, so they compare as strings
+	if b.effectiveThrough != "" && payload.EntryDate > b.effectiveThrough {
+		return nil
+	}
+
+	for _, line := range payload.Lines {
+		balance, ok := b.accounts[line.AccountID]
+		if !ok {
+			balance = &Balance{}
+			b.accounts[line.AccountID] = balance
+		}
+		balance.Debit = balance.Debit.Add(line.Debit)
+		balance.Credit = balance.Credit.Add(line.Credit)
+	}
+
+	return nil
+}
+
+// Get returns the projected balance of an account, zero if nothing was posted to it
+func (b *Balances) Get(accountID uuid.UUID) Balance {
+	if balance, ok := b.accounts[accountID]; ok {
+		return *balance
+	}
+	return Balance{}
+}
+
+// Totals returns the projected debits and credits of an account, so the
+// projection can rebuild stored balances through
+// repository.BalanceRepository.RebuildFromEvents
+func (b *Balances) Totals(accountID uuid.UUID) (debit, credit decimal.Decimal) {
+	balance := b.Get(accountID)
+	return balance.Debit, balance.Credit
+}
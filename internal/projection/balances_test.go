@@ -0,0 +1,128 @@
+package projection
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postedEvent(t *testing.T, sequence int64, lines ...repository.PostedLine) *repository.LedgerEvent {
+	t.Helper()
+	payload, err := json.Marshal(repository.JournalEntryPostedPayload{
+		ReferenceNumber: "REF",
+		EntryDate:       "2026-01-01",
+		Lines:           lines,
+	})
+	require.NoError(t, err)
+
+	return &repository.LedgerEvent{
+		Sequence:      sequence,
+		AggregateType: repository.AggregateJournalEntry,
+		AggregateID:   uuid.New(),
+		EventType:     repository.EventJournalEntryPosted,
+		Payload:       payload,
+	}
+}
+
+func TestBalances_Apply(t *testing.T) {
+	cash, revenue, eurCash := uuid.New(), uuid.New(), uuid.New()
+	rate := decimal.RequireFromString("0.9")
+
+	events := []*repository.LedgerEvent{
+		{Sequence: 1, EventType: repository.EventAccountCreated, Payload: json.RawMessage(`{}`)},
+		postedEvent(t, 2,
+			repository.PostedLine{AccountID: cash, Debit: decimal.NewFromInt(100)},
+			repository.PostedLine{AccountID: revenue, Credit: decimal.NewFromInt(100)},
+		),
+		// Lines in another currency are already in the account's currency
+		postedEvent(t, 3,
+			repository.PostedLine{AccountID: cash, Credit: decimal.NewFromInt(30)},
+			repository.PostedLine{AccountID: eurCash, Debit: decimal.NewFromInt(27), FxRate: &rate},
+		),
+	}
+
+	balances := NewBalances()
+	for _, event := range events {
+		require.NoError(t, balances.Apply(event))
+	}
+
+	assert.Equal(t, "70", balances.Get(cash).Net().String())
+	assert.Equal(t, "-100", balances.Get(revenue).Net().String())
+	assert.Equal(t, "27", balances.Get(eurCash).Debit.String())
+	assert.True(t, balances.Get(uuid.New()).Net().IsZero())
+}
+
+func TestBalances_ApplyMerge(t *testing.T) {
+	cash, petty, revenue := uuid.New(), uuid.New(), uuid.New()
+	reclassification := uuid.New()
+	payload, err := json.Marshal(repository.AccountsMergedPayload{TargetAccountID: cash, ReclassificationEntryID: &reclassification})
+	require.NoError(t, err)
+
+	events := []*repository.LedgerEvent{
+		postedEvent(t, 1,
+			repository.PostedLine{AccountID: cash, Debit: decimal.NewFromInt(100)},
+			repository.PostedLine{AccountID: revenue, Credit: decimal.NewFromInt(100)},
+		),
+		postedEvent(t, 2,
+			repository.PostedLine{AccountID: petty, Debit: decimal.NewFromInt(20)},
+			repository.PostedLine{AccountID: revenue, Credit: decimal.NewFromInt(20)},
+		),
+		postedEvent(t, 3,
+			repository.PostedLine{AccountID: petty, Credit: decimal.NewFromInt(20)},
+			repository.PostedLine{AccountID: cash, Debit: decimal.NewFromInt(20)},
+		),
+		{Sequence: 4, AggregateType: repository.AggregateAccount, AggregateID: petty, EventType: repository.EventAccountsMerged, SchemaVersion: 1, Payload: payload},
+	}
+
+	balances := NewBalances()
+	for _, event := range events {
+		require.NoError(t, balances.Apply(event))
+	}
+
+	// The reclassification entry moved the balance; the merge adds nothing
+	assert.Equal(t, "120", balances.Get(cash).Net().String())
+	assert.True(t, balances.Get(petty).Net().IsZero())
+}
+
+func TestBalances_EffectiveAsOf(t *testing.T) {
+	cash := uuid.New()
+
+	// A late adjustment posted after the first entry but effective in February
+	adjustment, err := json.Marshal(repository.JournalEntryPostedPayload{
+		ReferenceNumber: "ADJ",
+		EntryDate:       "2026-02-15",
+		Lines:           []repository.PostedLine{{AccountID: cash, Debit: decimal.NewFromInt(40)}},
+	})
+	require.NoError(t, err)
+
+	events := []*repository.LedgerEvent{
+		postedEvent(t, 1, repository.PostedLine{AccountID: cash, Debit: decimal.NewFromInt(100)}),
+		{Sequence: 2, EventType: repository.EventJournalEntryPosted, Payload: adjustment},
+	}
+
+	january := NewBalancesEffectiveAsOf(time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC))
+	february := NewBalancesEffectiveAsOf(time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC))
+	for _, event := range events {
+		require.NoError(t, january.Apply(event))
+		require.NoError(t, february.Apply(event))
+	}
+
+	assert.Equal(t, "100", january.Get(cash).Debit.String())
+	assert.Equal(t, "140", february.Get(cash).Debit.String())
+}
+
+func TestBalances_ApplyInvalidPayload(t *testing.T) {
+	err := NewBalances().Apply(&repository.LedgerEvent{
+		Sequence:  7,
+		EventType: repository.EventJournalEntryPosted,
+		Payload:   json.RawMessage(`{"lines": "oops"}`),
+	})
+
+	assert.ErrorContains(t, err, "event 7")
+}
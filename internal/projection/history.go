@@ -0,0 +1,144 @@
+package projection
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/repository"
+)
+
+// FieldChange is a change of one field of an entity, with its values as
+// compact JSON. OldValue is nil when the field had no value before and
+// NewValue when the change cleared it.
+type FieldChange struct {
+	Field    string
+	OldValue json.RawMessage
+	NewValue json.RawMessage
+}
+
+// EntityChange is an event with the fields it changed on its entity
+type EntityChange struct {
+	Sequence   int64
+	EventType  string
+	RecordedAt time.Time
+	Fields     []FieldChange
+}
+
+// History projects the field-level change history of one entity from its
+// events. Creation and update events set the fields of their payload, and
+// deletion and restoration set a deleted field; events that leave every
+// field as it was are not part of the history.
+type History struct {
+	fields  map[string]json.RawMessage
+	changes []*EntityChange
+}
+
+// NewHistory creates an empty history projection
+func NewHistory() *History {
+	return &History{fields: make(map[string]json.RawMessage)}
+}
+
+// Apply folds an event of the entity into the history
+func (h *History) Apply(event *repository.LedgerEvent) error {
+	fields, err := eventFields(event)
+	if err != nil {
+		return fmt.Errorf("invalid %s event %d: %w", event.EventType, event.Sequence, err)
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	change := &EntityChange{
+		Sequence:   event.Sequence,
+		EventType:  event.EventType,
+		RecordedAt: event.RecordedAt,
+	}
+	for _, name := range names {
+		old, value := h.fields[name], fields[name]
+		if bytes.Equal(old, value) {
+			continue
+		}
+
+		change.Fields = append(change.Fields, FieldChange{Field: name, OldValue: old, NewValue: value})
+		if value == nil {
+			delete(h.fields, name)
+		} else {
+			h.fields[name] = value
+		}
+	}
+
+	if len(change.Fields) > 0 {
+		h.changes = append(h.changes, change)
+	}
+
+	return nil
+}
+
+// Changes returns the changes applied so far, oldest first
+func (h *History) Changes() []*EntityChange {
+	return h.changes
+}
+
+// eventFields returns the fields an event sets on its entity, with nil for
+// fields it clears
+func eventFields(event *repository.LedgerEvent) (map[string]json.RawMessage, error) {
+	switch event.EventType {
+	case repository.EventAccountDeleted, repository.EventTenantDeleted:
+		return map[string]json.RawMessage{"deleted": json.RawMessage("true")}, nil
+	case repository.EventAccountRestored, repository.EventTenantRestored:
+		return map[string]json.RawMessage{"deleted": json.RawMessage("false")}, nil
+	case repository.EventTenantPurged:
+		return map[string]json.RawMessage{"purged": json.RawMessage("true")}, nil
+	case repository.EventAccountsMerged:
+		var payload repository.AccountsMergedPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, err
+		}
+		target, err := json.Marshal(payload.TargetAccountID)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]json.RawMessage{"merged_into_account_id": target}, nil
+	case repository.EventAccountExternalIDSet:
+		var payload repository.AccountExternalIDSetPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, err
+		}
+		var externalID json.RawMessage
+		if payload.ExternalID != nil {
+			value, err := json.Marshal(*payload.ExternalID)
+			if err != nil {
+				return nil, err
+			}
+			externalID = value
+		}
+		return map[string]json.RawMessage{"external_ids." + payload.Source: externalID}, nil
+	}
+
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	// Payloads are read back from JSONB, which adds whitespace
+	fields := make(map[string]json.RawMessage, len(payload))
+	for name, value := range payload {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, value); err != nil {
+			return nil, err
+		}
+		if compact.String() == "null" {
+			fields[name] = nil
+			continue
+		}
+		fields[name] = compact.Bytes()
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,83 @@
+package projection
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory_Apply(t *testing.T) {
+	accountID, parentID, targetID := uuid.New(), uuid.New(), uuid.New()
+
+	events := []*repository.LedgerEvent{
+		{Sequence: 1, EventType: repository.EventAccountCreated, Payload: json.RawMessage(`{"name": "Cash", "account_number": "1000"}`)},
+		{Sequence: 2, EventType: repository.EventAccountMoved, Payload: json.RawMessage(`{"parent_account_id": "` + parentID.String() + `"}`)},
+		// Moving the account to its parent again changes nothing
+		{Sequence: 3, EventType: repository.EventAccountMoved, Payload: json.RawMessage(`{"parent_account_id":"` + parentID.String() + `"}`)},
+		{Sequence: 4, EventType: repository.EventAccountMoved, Payload: json.RawMessage(`{"parent_account_id": null}`)},
+		{Sequence: 5, EventType: repository.EventAccountDeleted, Payload: json.RawMessage(`{}`)},
+		{Sequence: 6, EventType: repository.EventAccountsMerged, Payload: json.RawMessage(`{"target_account_id": "` + targetID.String() + `", "lines_moved": 3}`)},
+	}
+
+	history := NewHistory()
+	for _, event := range events {
+		event.AggregateID = accountID
+		require.NoError(t, history.Apply(event))
+	}
+
+	changes := history.Changes()
+	require.Len(t, changes, 5)
+
+	assert.Equal(t, int64(1), changes[0].Sequence)
+	assert.Equal(t, []FieldChange{
+		{Field: "account_number", NewValue: json.RawMessage(`"1000"`)},
+		{Field: "name", NewValue: json.RawMessage(`"Cash"`)},
+	}, changes[0].Fields)
+
+	parent := json.RawMessage(`"` + parentID.String() + `"`)
+	assert.Equal(t, []FieldChange{{Field: "parent_account_id", NewValue: parent}}, changes[1].Fields)
+
+	assert.Equal(t, int64(4), changes[2].Sequence)
+	assert.Equal(t, []FieldChange{{Field: "parent_account_id", OldValue: parent}}, changes[2].Fields)
+
+	assert.Equal(t, repository.EventAccountDeleted, changes[3].EventType)
+	assert.Equal(t, []FieldChange{{Field: "deleted", NewValue: json.RawMessage("true")}}, changes[3].Fields)
+
+	assert.Equal(t, []FieldChange{
+		{Field: "merged_into_account_id", NewValue: json.RawMessage(`"` + targetID.String() + `"`)},
+	}, changes[4].Fields)
+}
+
+func TestHistory_ApplyAccountMappings(t *testing.T) {
+	events := []*repository.LedgerEvent{
+		{Sequence: 1, EventType: repository.EventAccountLabelsSet, Payload: json.RawMessage(`{"labels": {"region": "emea"}}`)},
+		{Sequence: 2, EventType: repository.EventAccountExternalIDSet, Payload: json.RawMessage(`{"source": "sap", "external_id": "1000-01"}`)},
+		{Sequence: 3, EventType: repository.EventAccountExternalIDSet, Payload: json.RawMessage(`{"source": "crm", "external_id": "C-7"}`)},
+		{Sequence: 4, EventType: repository.EventAccountExternalIDSet, Payload: json.RawMessage(`{"source": "sap", "external_id": null}`)},
+	}
+
+	history := NewHistory()
+	for _, event := range events {
+		require.NoError(t, history.Apply(event))
+	}
+
+	changes := history.Changes()
+	require.Len(t, changes, 4)
+	assert.Equal(t, []FieldChange{{Field: "labels", NewValue: json.RawMessage(`{"region":"emea"}`)}}, changes[0].Fields)
+	assert.Equal(t, []FieldChange{{Field: "external_ids.sap", NewValue: json.RawMessage(`"1000-01"`)}}, changes[1].Fields)
+	assert.Equal(t, []FieldChange{{Field: "external_ids.crm", NewValue: json.RawMessage(`"C-7"`)}}, changes[2].Fields)
+	assert.Equal(t, []FieldChange{{Field: "external_ids.sap", OldValue: json.RawMessage(`"1000-01"`)}}, changes[3].Fields)
+}
+
+func TestHistory_ApplyInvalidPayload(t *testing.T) {
+	err := NewHistory().Apply(&repository.LedgerEvent{
+		Sequence:  1,
+		EventType: repository.EventTenantSettingsUpdated,
+		Payload:   json.RawMessage(`[]`),
+	})
+	assert.Error(t, err)
+}
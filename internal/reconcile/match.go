@@ -0,0 +1,137 @@
+// Package reconcile matches bank statement lines against ledger lines.
+package reconcile
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Method records how a statement line was matched
+type Method string
+
+const (
+	MethodExact  Method = "EXACT"
+	MethodFuzzy  Method = "FUZZY"
+	MethodManual Method = "MANUAL"
+)
+
+// DefaultDateToleranceDays is the date window used by fuzzy matching when none is configured
+const DefaultDateToleranceDays = 3
+
+// StatementLine is an unmatched line from a bank statement
+type StatementLine struct {
+	ID          uuid.UUID
+	PostedAt    time.Time
+	Amount      decimal.Decimal
+	Description string
+	Reference   string
+}
+
+// LedgerLine is an unmatched journal line on the reconciled account. Amount is
+// debit minus credit, so deposits are positive like on the statement.
+type LedgerLine struct {
+	ID          uuid.UUID
+	EntryDate   time.Time
+	Amount      decimal.Decimal
+	Description string
+	Reference   string
+}
+
+// Match pairs a statement line with a ledger line
+type Match struct {
+	StatementLineID uuid.UUID
+	LedgerLineID    uuid.UUID
+	Method          Method
+}
+
+// Rules configures automatic matching
+type Rules struct {
+	// DateToleranceDays is how many days apart a fuzzy match may be
+	DateToleranceDays int
+}
+
+// AutoMatch pairs statement lines with ledger lines. Exact matches (same
+// amount and date) are made first; the remaining lines are then matched
+// fuzzily on amount within the date tolerance, or on amount and reference
+// regardless of date. Each ledger line is used at most once.
+func AutoMatch(statement []StatementLine, ledger []LedgerLine, rules Rules) []Match {
+	used := make([]bool, len(ledger))
+	matched := make([]bool, len(statement))
+	var matches []Match
+
+	for i, s := range statement {
+		if j := bestCandidate(s, ledger, used, 0, false); j >= 0 {
+			used[j] = true
+			matched[i] = true
+			matches = append(matches, Match{StatementLineID: s.ID, LedgerLineID: ledger[j].ID, Method: MethodExact})
+		}
+	}
+
+	for i, s := range statement {
+		if matched[i] {
+			continue
+		}
+		if j := bestCandidate(s, ledger, used, rules.DateToleranceDays, true); j >= 0 {
+			used[j] = true
+			matches = append(matches, Match{StatementLineID: s.ID, LedgerLineID: ledger[j].ID, Method: MethodFuzzy})
+		}
+	}
+
+	return matches
+}
+
+// bestCandidate returns the index of the unused ledger line that best matches
+// s, or -1. Candidates must have the same amount and be within toleranceDays;
+// when byReference is set, a matching reference also qualifies regardless of
+// date. Matching references win, then the closest date.
+func bestCandidate(s StatementLine, ledger []LedgerLine, used []bool, toleranceDays int, byReference bool) int {
+	best := -1
+	bestRef := false
+	bestDistance := 0
+
+	for j, l := range ledger {
+		if used[j] || !s.Amount.Equal(l.Amount) {
+			continue
+		}
+
+		distance := daysBetween(s.PostedAt, l.EntryDate)
+		ref := referencesMatch(s, l)
+		if distance > toleranceDays && !(byReference && ref) {
+			continue
+		}
+
+		if best < 0 || (ref && !bestRef) || (ref == bestRef && distance < bestDistance) {
+			best, bestRef, bestDistance = j, ref, distance
+		}
+	}
+
+	return best
+}
+
+// daysBetween returns the absolute number of calendar days between a and b
+func daysBetween(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(da.Sub(db).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
+
+// referencesMatch reports whether the statement line refers to the ledger line,
+// either by equal references or by the ledger reference appearing in the
+// statement description
+func referencesMatch(s StatementLine, l LedgerLine) bool {
+	if l.Reference == "" {
+		return false
+	}
+	ledgerRef := strings.ToLower(l.Reference)
+	if s.Reference != "" && strings.ToLower(s.Reference) == ledgerRef {
+		return true
+	}
+	return strings.Contains(strings.ToLower(s.Description), ledgerRef)
+}
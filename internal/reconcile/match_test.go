@@ -0,0 +1,81 @@
+package reconcile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(day int) time.Time {
+	return time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestAutoMatch(t *testing.T) {
+	rules := Rules{DateToleranceDays: 3}
+
+	t.Run("prefers exact matches over fuzzy ones", func(t *testing.T) {
+		statement := []StatementLine{
+			{ID: uuid.New(), PostedAt: date(10), Amount: decimal.NewFromInt(-50)},
+		}
+		ledger := []LedgerLine{
+			{ID: uuid.New(), EntryDate: date(9), Amount: decimal.NewFromInt(-50)},
+			{ID: uuid.New(), EntryDate: date(10), Amount: decimal.NewFromInt(-50)},
+		}
+
+		matches := AutoMatch(statement, ledger, rules)
+
+		require.Len(t, matches, 1)
+		assert.Equal(t, ledger[1].ID, matches[0].LedgerLineID)
+		assert.Equal(t, MethodExact, matches[0].Method)
+	})
+
+	t.Run("matches within the date tolerance", func(t *testing.T) {
+		statement := []StatementLine{
+			{ID: uuid.New(), PostedAt: date(12), Amount: decimal.NewFromInt(200)},
+			{ID: uuid.New(), PostedAt: date(20), Amount: decimal.NewFromInt(75)},
+		}
+		ledger := []LedgerLine{
+			{ID: uuid.New(), EntryDate: date(10), Amount: decimal.NewFromInt(200)},
+			{ID: uuid.New(), EntryDate: date(10), Amount: decimal.NewFromInt(75)},
+		}
+
+		matches := AutoMatch(statement, ledger, rules)
+
+		require.Len(t, matches, 1)
+		assert.Equal(t, statement[0].ID, matches[0].StatementLineID)
+		assert.Equal(t, MethodFuzzy, matches[0].Method)
+	})
+
+	t.Run("matches on reference outside the date tolerance", func(t *testing.T) {
+		statement := []StatementLine{
+			{ID: uuid.New(), PostedAt: date(25), Amount: decimal.NewFromInt(-120), Description: "Payment INV-1042"},
+		}
+		ledger := []LedgerLine{
+			{ID: uuid.New(), EntryDate: date(22), Amount: decimal.NewFromInt(-120), Reference: "INV-1001"},
+			{ID: uuid.New(), EntryDate: date(1), Amount: decimal.NewFromInt(-120), Reference: "INV-1042"},
+		}
+
+		matches := AutoMatch(statement, ledger, rules)
+
+		require.Len(t, matches, 1)
+		assert.Equal(t, ledger[1].ID, matches[0].LedgerLineID)
+	})
+
+	t.Run("uses each ledger line once", func(t *testing.T) {
+		statement := []StatementLine{
+			{ID: uuid.New(), PostedAt: date(5), Amount: decimal.NewFromInt(10)},
+			{ID: uuid.New(), PostedAt: date(5), Amount: decimal.NewFromInt(10)},
+		}
+		ledger := []LedgerLine{
+			{ID: uuid.New(), EntryDate: date(5), Amount: decimal.NewFromInt(10)},
+		}
+
+		matches := AutoMatch(statement, ledger, rules)
+
+		assert.Len(t, matches, 1)
+	})
+}
@@ -0,0 +1,49 @@
+// Package recovery keeps a panicking gRPC handler from taking the process
+// down by turning the panic into an Internal error.
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns a unary interceptor that recovers from
+// panics in later interceptors and the handler
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a stream interceptor that recovers from
+// panics in later interceptors and the handler
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ss.Context(), info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// recovered logs a panic with its stack and returns the error sent to the
+// client, which leaves out the panic value so no internals leak. The value
+// is logged as an error so it is redacted like any other.
+func recovered(ctx context.Context, method string, r interface{}) error {
+	redact.Printf(redact.LevelError, "panic in %s request_id=%s: %v\n%s", method, requestid.FromContext(ctx), fmt.Errorf("%v", r), debug.Stack())
+	return status.Error(codes.Internal, "internal error")
+}
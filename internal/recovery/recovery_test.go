@@ -0,0 +1,52 @@
+package recovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/ledger.v1.LedgerService/GetAccount"}
+
+	t.Run("converts panics into internal errors", func(t *testing.T) {
+		resp, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			panic("boom")
+		})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.NotContains(t, status.Convert(err).Message(), "boom")
+	})
+
+	t.Run("passes through normal results", func(t *testing.T) {
+		resp, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "ok", status.Error(codes.NotFound, "missing")
+		})
+
+		assert.Equal(t, "ok", resp)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *fakeServerStream) Context() context.Context { return context.Background() }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	interceptor := StreamServerInterceptor()
+
+	err := interceptor(nil, &fakeServerStream{}, &grpc.StreamServerInfo{FullMethod: "/ledger.v1.LedgerService/ExportJournalEntries"},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			panic("boom")
+		})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
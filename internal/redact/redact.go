@@ -0,0 +1,165 @@
+// Package redact keeps personal data out of the errors sent to clients and
+// out of the logs. Descriptions, metadata and party names reach error
+// strings through database errors and quoted values; Internal errors only
+// ever carry a redacted message, and log lines below the verbatim level
+// carry redacted errors.
+package redact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/hesabFun/ledger/internal/config"
+	"github.com/hesabFun/ledger/internal/requestid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Level is the level of a log line
+type Level int32
+
+// Log levels, from the most to the least verbose. LevelNone is above every
+// line, as a verbatim level it redacts them all.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelNone
+)
+
+var levelNames = map[string]Level{
+	config.LogLevelDebug: LevelDebug,
+	config.LogLevelInfo:  LevelInfo,
+	config.LogLevelWarn:  LevelWarn,
+	config.LogLevelError: LevelError,
+	config.LogLevelNone:  LevelNone,
+}
+
+// ParseLevel returns the level with a configured name
+func ParseLevel(name string) (Level, error) {
+	level, ok := levelNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+// verbatim is the lowest level logged without redaction
+var verbatim atomic.Int32
+
+func init() {
+	verbatim.Store(int32(LevelNone))
+}
+
+// SetVerbatimLevel sets the lowest level whose lines carry errors verbatim
+func SetVerbatimLevel(level Level) {
+	verbatim.Store(int32(level))
+}
+
+// Placeholder replaces redacted values
+const Placeholder = "[redacted]"
+
+// quoted matches double-quoted values, as formatted by %q and in database
+// error messages
+var quoted = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// Error returns the message of err without the values it quotes and with
+// database errors reduced to their SQLSTATE and constraint
+func Error(err error) string {
+	msg := err.Error()
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		summary := "database error (SQLSTATE " + pgErr.Code + ")"
+		if pgErr.ConstraintName != "" {
+			summary = "database error on constraint " + pgErr.ConstraintName + " (SQLSTATE " + pgErr.Code + ")"
+		}
+		msg = strings.Replace(msg, pgErr.Error(), summary, 1)
+	}
+
+	return quoted.ReplaceAllString(msg, `"`+Placeholder+`"`)
+}
+
+// Printf logs a line at level, redacting the errors among args when level
+// is below the verbatim level
+func Printf(level Level, format string, args ...interface{}) {
+	if int32(level) < verbatim.Load() {
+		for i, arg := range args {
+			if err, ok := arg.(error); ok && err != nil {
+				args[i] = Error(err)
+			}
+		}
+	}
+	log.Printf(format, args...)
+}
+
+// internalError is an Internal error whose status carries the redacted cause
+// while the error itself keeps the cause for logging
+type internalError struct {
+	msg   string
+	cause error
+}
+
+// Internal returns an Internal error with the message msg followed by the
+// redacted cause
+func Internal(msg string, cause error) error {
+	return &internalError{msg: msg, cause: cause}
+}
+
+func (e *internalError) Error() string {
+	return e.msg + ": " + e.cause.Error()
+}
+
+func (e *internalError) Unwrap() error {
+	return e.cause
+}
+
+func (e *internalError) GRPCStatus() *status.Status {
+	return status.New(codes.Internal, e.msg+": "+Error(e.cause))
+}
+
+// UnaryServerInterceptor returns a unary interceptor that logs Internal and
+// Unknown errors and sends them to the client redacted
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, clientError(ctx, info.FullMethod, err)
+	}
+}
+
+// StreamServerInterceptor returns a stream interceptor that logs Internal
+// and Unknown errors and sends them to the client redacted
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return clientError(ss.Context(), info.FullMethod, handler(srv, ss))
+	}
+}
+
+// clientError returns the error sent to the client for the error a call
+// returned. Statuses built by the service are sent as they are; wrapped
+// statuses and plain errors, whose messages gRPC would send verbatim, are
+// redacted.
+func clientError(ctx context.Context, method string, err error) error {
+	code := status.Code(err)
+	if err == nil || (code != codes.Internal && code != codes.Unknown) {
+		return err
+	}
+	Printf(LevelError, "%s request_id=%s: %v", method, requestid.FromContext(ctx), err)
+
+	var internal *internalError
+	if errors.As(err, &internal) {
+		return internal.GRPCStatus().Err()
+	}
+	if _, ok := err.(interface{ GRPCStatus() *status.Status }); ok {
+		return err
+	}
+	return status.Error(code, Error(err))
+}
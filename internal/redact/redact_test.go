@@ -0,0 +1,123 @@
+package redact
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// captureLog returns what f logs
+func captureLog(t *testing.T, f func()) string {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	f()
+	return buf.String()
+}
+
+func TestError(t *testing.T) {
+	t.Run("reduces database errors to their code and constraint", func(t *testing.T) {
+		err := fmt.Errorf("failed to create party: %w", &pgconn.PgError{
+			Severity:       "ERROR",
+			Code:           "23514",
+			Message:        `new row for relation "parties" violates check constraint "parties_name_check"`,
+			Detail:         "Failing row contains (Jane Doe).",
+			ConstraintName: "parties_name_check",
+		})
+
+		assert.Equal(t, "failed to create party: database error on constraint parties_name_check (SQLSTATE 23514)", Error(err))
+	})
+
+	t.Run("removes quoted values", func(t *testing.T) {
+		err := fmt.Errorf("malformed balance change %q", `{"description":"Rent for Jane Doe"}`)
+
+		assert.NotContains(t, Error(err), "Jane")
+		assert.Equal(t, `malformed balance change "[redacted]"`, Error(err))
+	})
+
+	t.Run("keeps messages without data", func(t *testing.T) {
+		assert.Equal(t, "connection reset", Error(errors.New("connection reset")))
+	})
+}
+
+func TestPrintf(t *testing.T) {
+	err := fmt.Errorf("failed to post %q", "Jane Doe")
+
+	t.Run("redacts errors below the verbatim level", func(t *testing.T) {
+		out := captureLog(t, func() { Printf(LevelError, "run: %v", err) })
+
+		assert.NotContains(t, out, "Jane")
+		assert.Contains(t, out, Placeholder)
+	})
+
+	t.Run("logs errors at the verbatim level as they are", func(t *testing.T) {
+		SetVerbatimLevel(LevelError)
+		defer SetVerbatimLevel(LevelNone)
+
+		assert.Contains(t, captureLog(t, func() { Printf(LevelError, "run: %v", err) }), "Jane Doe")
+		assert.NotContains(t, captureLog(t, func() { Printf(LevelWarn, "run: %v", err) }), "Jane Doe")
+	})
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("warn")
+	require.NoError(t, err)
+	assert.Equal(t, LevelWarn, level)
+
+	_, err = ParseLevel("trace")
+	assert.Error(t, err)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/ledger.v1.LedgerService/CreateJournalEntry"}
+
+	call := func(err error) (error, string) {
+		var got error
+		out := captureLog(t, func() {
+			_, got = interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, err
+			})
+		})
+		return got, out
+	}
+
+	t.Run("sends internal errors redacted and logs them", func(t *testing.T) {
+		err, out := call(Internal("failed to create journal entry", fmt.Errorf("bad metadata %q", "Jane Doe")))
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Equal(t, `failed to create journal entry: bad metadata "[redacted]"`, status.Convert(err).Message())
+		assert.Contains(t, out, "CreateJournalEntry")
+	})
+
+	t.Run("redacts wrapped internal errors", func(t *testing.T) {
+		err, _ := call(fmt.Errorf("stream: %w", Internal("failed to list entries", fmt.Errorf("bad %q", "Jane Doe"))))
+
+		assert.NotContains(t, status.Convert(err).Message(), "Jane")
+	})
+
+	t.Run("redacts plain errors", func(t *testing.T) {
+		err, _ := call(fmt.Errorf("failed to scan %q", "Jane Doe"))
+
+		assert.Equal(t, codes.Unknown, status.Code(err))
+		assert.NotContains(t, status.Convert(err).Message(), "Jane")
+	})
+
+	t.Run("passes through other errors", func(t *testing.T) {
+		err, out := call(status.Error(codes.NotFound, `account "1000" not found`))
+
+		assert.Equal(t, `account "1000" not found`, status.Convert(err).Message())
+		assert.Empty(t, out)
+	})
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/hesabFun/ledger/internal/db"
@@ -12,37 +12,104 @@ import (
 	"github.com/shopspring/decimal"
 )
 
-// Account represents an account entity
-type Account struct {
-	ID              uuid.UUID
-	TenantID        uuid.UUID
-	AccountNumber   string
-	Name            string
-	Description     *string
-	AccountTypeID   int32
-	CurrencyCode    string
-	ParentAccountID *uuid.UUID
-	IsActive        bool
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
-}
-
-// AccountBalance represents account balance entity
-type AccountBalance struct {
-	AccountID     uuid.UUID
-	DebitBalance  decimal.Decimal
-	CreditBalance decimal.Decimal
-	UpdatedAt     time.Time
-}
-
-// CreateAccountParams holds parameters for creating an account
-type CreateAccountParams struct {
-	AccountNumber   string
-	Name            string
-	Description     *string
-	AccountTypeID   int32
-	CurrencyCode    string
-	ParentAccountID *uuid.UUID
+// accountColumns lists the account columns in the order expected by scanAccount
+const accountColumns = `id, tenant_id, book_id, account_number, name, description, account_type_id,
+		       currency_code, parent_account_id, is_active, created_at, updated_at, deleted_at,
+		       overdraft_limit, labels,
+		       (SELECT COALESCE(jsonb_object_agg(x.source, x.external_id), '{}')
+		        FROM account_external_ids x WHERE x.account_id = accounts.id)`
+
+// scanAccount scans a row selected with accountColumns into an account
+func scanAccount(row pgx.Row, account *Account) error {
+	return row.Scan(
+		&account.ID,
+		&account.TenantID,
+		&account.BookID,
+		&account.AccountNumber,
+		&account.Name,
+		&account.Description,
+		&account.AccountTypeID,
+		&account.CurrencyCode,
+		&account.ParentAccountID,
+		&account.IsActive,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+		&account.DeletedAt,
+		&account.OverdraftLimit,
+		&account.Labels,
+		&account.ExternalIDs,
+	)
+}
+
+// rowQuerier is implemented by pooled connections and tenant transactions
+type rowQuerier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// setAccountPaths fills in the depth and path of accounts by walking up
+// their parents. Visited accounts are tracked so that a cycle left by
+// earlier versions cannot loop forever.
+func setAccountPaths(ctx context.Context, q rowQuerier, accounts ...*Account) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*Account, len(accounts))
+	ids := make([]uuid.UUID, 0, len(accounts))
+	for _, account := range accounts {
+		byID[account.ID] = account
+		ids = append(ids, account.ID)
+	}
+
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id AS account_id, parent_account_id, account_number::text AS path,
+			       0 AS depth, ARRAY[id] AS visited
+			FROM accounts
+			WHERE id = ANY($1)
+			UNION ALL
+			SELECT a.account_id, p.parent_account_id, p.account_number || '/' || a.path,
+			       a.depth + 1, a.visited || p.id
+			FROM ancestors a
+			JOIN accounts p ON p.id = a.parent_account_id
+			WHERE p.id <> ALL(a.visited)
+		)
+		SELECT DISTINCT ON (account_id) account_id, path, depth
+		FROM ancestors
+		ORDER BY account_id, depth DESC
+	`
+
+	rows, err := q.Query(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get account paths: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var accountID uuid.UUID
+		var path string
+		var depth int32
+		if err := rows.Scan(&accountID, &path, &depth); err != nil {
+			return fmt.Errorf("failed to scan account path: %w", err)
+		}
+		if account, ok := byID[accountID]; ok {
+			account.Path = path
+			account.Depth = depth
+		}
+	}
+
+	return rows.Err()
+}
+
+// lockAccountTree serializes changes to the parents of the tenant's
+// accounts, so that two concurrent moves cannot together form a cycle that
+// neither sees on its own
+func lockAccountTree(ctx context.Context, tx *db.TenantTx) error {
+	err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended('account_tree:' || current_setting('app.current_tenant_id'), 0))")
+	if err != nil {
+		return fmt.Errorf("failed to lock account tree: %w", err)
+	}
+	return nil
 }
 
 // AccountRepository handles account database operations
@@ -55,7 +122,7 @@ func NewAccountRepository(database *db.DB) *AccountRepository {
 	return &AccountRepository{db: database}
 }
 
-// Create creates a new account using the database function
+// Create creates a new account with a zero balance
 func (r *AccountRepository) Create(ctx context.Context, tenantID uuid.UUID, params CreateAccountParams) (*Account, error) {
 	// Start a transaction with tenant context
 	tx, err := r.db.BeginTx(ctx, tenantID.String())
@@ -64,20 +131,50 @@ func (r *AccountRepository) Create(ctx context.Context, tenantID uuid.UUID, para
 	}
 	defer tx.Rollback(ctx)
 
+	if params.MaxAccounts != nil {
+		if err := checkAccountQuota(ctx, tx, *params.MaxAccounts); err != nil {
+			return nil, err
+		}
+	}
+
+	bookID, err := accountBook(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+
 	var accountID uuid.UUID
-	query := "SELECT create_account($1, $2, $3, $4, $5, $6)"
+	if tx.SQLFunctions() {
+		accountID, err = callCreateAccount(ctx, tx, params, bookID)
+	} else {
+		accountID, err = insertAccount(ctx, tx, params, bookID)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	err = tx.QueryRow(ctx, query,
-		params.AccountNumber,
-		params.Name,
-		params.AccountTypeID,
-		params.CurrencyCode,
-		params.Description,
-		params.ParentAccountID,
-	).Scan(&accountID)
+	if params.OverdraftLimit != nil {
+		err = tx.Exec(ctx, "UPDATE accounts SET overdraft_limit = $2 WHERE id = $1", accountID, params.OverdraftLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set overdraft limit: %w", err)
+		}
+	}
 
+	err = appendEvent(ctx, tx, AggregateAccount, accountID, EventAccountCreated, AccountCreatedPayload{
+		BookID:          bookID,
+		AccountNumber:   params.AccountNumber,
+		Name:            params.Name,
+		AccountTypeID:   params.AccountTypeID,
+		CurrencyCode:    params.CurrencyCode,
+		Description:     params.Description,
+		ParentAccountID: params.ParentAccountID,
+		OverdraftLimit:  params.OverdraftLimit,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create account: %w", err)
+		return nil, err
+	}
+
+	if err := setAccountMappings(ctx, tx, accountID, params.Labels, params.ExternalIDs); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -98,84 +195,73 @@ func (r *AccountRepository) GetByID(ctx context.Context, tenantID uuid.UUID, acc
 
 	account := &Account{}
 	query := `
-		SELECT id, tenant_id, account_number, name, description, account_type_id,
-		       currency_code, parent_account_id, is_active, created_at, updated_at
+		SELECT ` + accountColumns + `
 		FROM accounts
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	err = conn.QueryRow(ctx, query, accountID).Scan(
-		&account.ID,
-		&account.TenantID,
-		&account.AccountNumber,
-		&account.Name,
-		&account.Description,
-		&account.AccountTypeID,
-		&account.CurrencyCode,
-		&account.ParentAccountID,
-		&account.IsActive,
-		&account.CreatedAt,
-		&account.UpdatedAt,
-	)
+	err = scanAccount(conn.QueryRow(ctx, query, accountID), account)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("account not found")
+			return nil, fmt.Errorf("account %w", ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get account: %w", err)
 	}
 
+	if err := setAccountPaths(ctx, conn, account); err != nil {
+		return nil, err
+	}
+
 	return account, nil
 }
 
-// List retrieves accounts with optional filters
-func (r *AccountRepository) List(ctx context.Context, tenantID uuid.UUID, accountTypeID *int32, currencyCode *string, limit, offset int) ([]*Account, int, error) {
+// List retrieves accounts matching a filter; deleted accounts are only included when requested
+func (r *AccountRepository) List(ctx context.Context, tenantID uuid.UUID, filter AccountFilter, limit, offset int) ([]*Account, int, error) {
 	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to set tenant context: %w", err)
 	}
 	defer conn.Release()
 
-	// Build query with filters
-	query := `
-		SELECT id, tenant_id, account_number, name, description, account_type_id,
-		       currency_code, parent_account_id, is_active, created_at, updated_at
-		FROM accounts
-		WHERE 1=1
-	`
-	countQuery := "SELECT COUNT(*) FROM accounts WHERE 1=1"
-	var args []interface{}
-	argCount := 0
-
-	if accountTypeID != nil {
-		argCount++
-		query += fmt.Sprintf(" AND account_type_id = $%d", argCount)
-		countQuery += fmt.Sprintf(" AND account_type_id = $%d", argCount)
-		args = append(args, *accountTypeID)
+	var namePattern, numberPattern *string
+	var labels interface{}
+	if filter.NamePrefix != nil {
+		pattern := likePrefix(*filter.NamePrefix)
+		namePattern = &pattern
+	}
+	if filter.NumberPrefix != nil {
+		pattern := likePrefix(*filter.NumberPrefix)
+		numberPattern = &pattern
+	}
+	if len(filter.Labels) > 0 {
+		labels = filter.Labels
 	}
 
-	if currencyCode != nil {
-		argCount++
-		query += fmt.Sprintf(" AND currency_code = $%d", argCount)
-		countQuery += fmt.Sprintf(" AND currency_code = $%d", argCount)
-		args = append(args, *currencyCode)
+	args := []interface{}{
+		filter.IncludeDeleted,
+		filter.BookID,
+		filter.AccountTypeID,
+		filter.CurrencyCode,
+		namePattern,
+		numberPattern,
+		filter.IsActive,
+		filter.ParentAccountID,
+		filter.AncestorAccountID,
+		labels,
 	}
 
 	// Get total count
 	var totalCount int
-	err = conn.QueryRow(ctx, countQuery, args...).Scan(&totalCount)
+	err = conn.QueryRow(ctx, "SELECT COUNT(*) FROM accounts"+accountListWhere, args...).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count accounts: %w", err)
 	}
 
-	// Add pagination
-	argCount++
-	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", argCount)
-	args = append(args, limit)
-
-	argCount++
-	query += fmt.Sprintf(" OFFSET $%d", argCount)
-	args = append(args, offset)
+	// Add ordering and pagination
+	query := `SELECT ` + accountColumns + ` FROM accounts` + accountListWhere +
+		" ORDER BY " + accountOrderBy(filter) + " LIMIT $11 OFFSET $12"
+	args = append(args, limit, offset)
 
 	rows, err := conn.Query(ctx, query, args...)
 	if err != nil {
@@ -186,28 +272,73 @@ func (r *AccountRepository) List(ctx context.Context, tenantID uuid.UUID, accoun
 	accounts := make([]*Account, 0)
 	for rows.Next() {
 		account := &Account{}
-		err := rows.Scan(
-			&account.ID,
-			&account.TenantID,
-			&account.AccountNumber,
-			&account.Name,
-			&account.Description,
-			&account.AccountTypeID,
-			&account.CurrencyCode,
-			&account.ParentAccountID,
-			&account.IsActive,
-			&account.CreatedAt,
-			&account.UpdatedAt,
-		)
+		err := scanAccount(rows, account)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan account: %w", err)
 		}
 		accounts = append(accounts, account)
 	}
+	rows.Close()
+
+	if err := setAccountPaths(ctx, conn, accounts...); err != nil {
+		return nil, 0, err
+	}
 
 	return accounts, totalCount, nil
 }
 
+// accountListWhere filters accounts by the filters of List. Filters that
+// are not set are passed as NULL rather than left out, so the query text is
+// the same for every combination and its prepared statement is reused.
+const accountListWhere = `
+		WHERE ($1::boolean OR deleted_at IS NULL)
+		  AND ($2::uuid IS NULL OR book_id = $2)
+		  AND ($3::integer IS NULL OR account_type_id = $3)
+		  AND ($4::text IS NULL OR currency_code = $4)
+		  AND ($5::text IS NULL OR name ILIKE $5)
+		  AND ($6::text IS NULL OR account_number LIKE $6)
+		  AND ($7::boolean IS NULL OR is_active = $7)
+		  AND ($8::uuid IS NULL OR parent_account_id = $8)
+		  AND ($9::uuid IS NULL OR id IN (
+			WITH RECURSIVE descendants AS (
+				SELECT id FROM accounts WHERE parent_account_id = $9
+				UNION
+				SELECT c.id FROM accounts c JOIN descendants d ON c.parent_account_id = d.id
+			)
+			SELECT id FROM descendants))
+		  AND ($10::jsonb IS NULL OR labels @> $10)`
+
+// accountOrderBy builds the ORDER BY clause for a filter. Only known columns
+// are used, so the result is safe to concatenate into the query.
+func accountOrderBy(filter AccountFilter) string {
+	var column string
+	switch filter.SortBy {
+	case AccountSortNumber, AccountSortName, AccountSortCreatedAt:
+		column = filter.SortBy
+	default:
+		return "created_at DESC, id"
+	}
+
+	direction := "ASC"
+	if filter.SortDescending {
+		direction = "DESC"
+	}
+
+	return column + " " + direction + ", id"
+}
+
+// likePrefix escapes LIKE wildcards in s and returns a pattern matching values that start with it
+func likePrefix(s string) string {
+	return likeEscaper.Replace(s) + "%"
+}
+
+// likeContains escapes LIKE wildcards in s and returns a pattern matching values that contain it
+func likeContains(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // GetBalance retrieves the balance for an account
 func (r *AccountRepository) GetBalance(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*AccountBalance, error) {
 	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
@@ -231,10 +362,465 @@ func (r *AccountRepository) GetBalance(ctx context.Context, tenantID uuid.UUID,
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("balance not found for account")
+			return nil, fmt.Errorf("balance %w", ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get account balance: %w", err)
 	}
 
 	return balance, nil
 }
+
+// bookedBalanceExpr is the balance of account a on its normal side, with
+// its type joined as t and its balance as b
+const bookedBalanceExpr = `CASE WHEN t.normal_balance = 'CREDIT'
+		THEN COALESCE(b.credit_balance, 0) - COALESCE(b.debit_balance, 0)
+		ELSE COALESCE(b.debit_balance, 0) - COALESCE(b.credit_balance, 0) END`
+
+// heldAmountExpr sums the pending, unexpired holds of account a and the
+// reservations of pending, unexpired prepared entries on it
+const heldAmountExpr = `((SELECT COALESCE(SUM(h.amount), 0) FROM holds h
+		WHERE h.account_id = a.id AND h.status = 'PENDING' AND h.expires_at > NOW()) +
+		(SELECT COALESCE(SUM(r.amount), 0) FROM prepared_entry_reservations r
+		JOIN prepared_journal_entries p ON p.id = r.prepared_entry_id
+		WHERE r.account_id = a.id AND p.status = 'PENDING' AND p.expires_at > NOW()))`
+
+// overdraftLimitExpr is the overdraft limit of account a with its type
+// joined as t: the account's own limit, or else the limit the tenant settings
+// give its account type, or NULL when neither is set
+const overdraftLimitExpr = `COALESCE(a.overdraft_limit, (SELECT (s.overdraft_limits ->> t.code)::numeric
+		FROM tenant_settings s WHERE s.tenant_id = a.tenant_id))`
+
+// GetAvailableBalance retrieves the booked, held and available balance of an account
+func (r *AccountRepository) GetAvailableBalance(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*AvailableBalance, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	balance := &AvailableBalance{AccountID: accountID}
+	query := `
+		SELECT ` + bookedBalanceExpr + `, ` + heldAmountExpr + `, ` + overdraftLimitExpr + `
+		FROM accounts a
+		JOIN account_types t ON t.id = a.account_type_id
+		LEFT JOIN account_balances b ON b.account_id = a.id
+		WHERE a.id = $1 AND a.deleted_at IS NULL
+	`
+
+	err = conn.QueryRow(ctx, query, accountID).Scan(&balance.Booked, &balance.Held, &balance.OverdraftLimit)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("account %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get available balance: %w", err)
+	}
+
+	balance.Available = balance.Booked.Sub(balance.Held)
+	if balance.OverdraftLimit != nil {
+		balance.Available = balance.Available.Add(*balance.OverdraftLimit)
+	}
+
+	return balance, nil
+}
+
+// draftAmountExpr sums the lines on account a of the draft entries of open
+// and approved journal batches, debits less credits, with its type joined as t
+const draftAmountExpr = `(CASE WHEN t.normal_balance = 'CREDIT' THEN -1 ELSE 1 END *
+		(SELECT COALESCE(SUM((l->>'Debit')::numeric - (l->>'Credit')::numeric), 0)
+		FROM journal_batch_entries e
+		JOIN journal_batches jb ON jb.id = e.batch_id
+		CROSS JOIN jsonb_array_elements(e.entry->'Lines') AS l
+		WHERE jb.status IN ('OPEN', 'APPROVED') AND l->>'AccountID' = a.id::text))`
+
+// GetProjectedBalances retrieves the booked, held, draft and projected
+// balance of each account, in the order given
+func (r *AccountRepository) GetProjectedBalances(ctx context.Context, tenantID uuid.UUID, accountIDs []uuid.UUID) ([]*ProjectedBalance, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT a.id, ` + bookedBalanceExpr + `, ` + heldAmountExpr + `, ` + overdraftLimitExpr + `, ` + draftAmountExpr + `
+		FROM unnest($1::uuid[]) WITH ORDINALITY AS req(account_id, ord)
+		JOIN accounts a ON a.id = req.account_id
+		JOIN account_types t ON t.id = a.account_type_id
+		LEFT JOIN account_balances b ON b.account_id = a.id
+		WHERE a.deleted_at IS NULL
+		ORDER BY req.ord
+	`
+
+	rows, err := conn.Query(ctx, query, accountIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get projected balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make([]*ProjectedBalance, 0, len(accountIDs))
+	for rows.Next() {
+		balance := &ProjectedBalance{}
+		err := rows.Scan(&balance.AccountID, &balance.Booked, &balance.Held, &balance.OverdraftLimit, &balance.Drafts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan projected balance: %w", err)
+		}
+
+		balance.Available = balance.Booked.Sub(balance.Held)
+		if balance.OverdraftLimit != nil {
+			balance.Available = balance.Available.Add(*balance.OverdraftLimit)
+		}
+		balance.Pending = balance.Drafts.Sub(balance.Held)
+		balance.Projected = balance.Booked.Add(balance.Pending)
+
+		balances = append(balances, balance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get projected balances: %w", err)
+	}
+
+	if len(balances) < len(accountIDs) {
+		return nil, fmt.Errorf("account %w", ErrNotFound)
+	}
+
+	return balances, nil
+}
+
+// checkAvailableBalances rejects a posting that leaves an account with an
+// overdraft limit, its own or its account type's, below its available
+// balance. Only accounts the lines reduce are checked, so an overdrawn
+// account can still be topped up. The caller must hold the tenant journal
+// lock and have posted the lines.
+func checkAvailableBalances(ctx context.Context, tx *db.TenantTx, lines []*CreateJournalEntryLineParams) error {
+	accountIDs, amounts := lineNets(lines)
+
+	query := `
+		SELECT a.account_number
+		FROM unnest($1::uuid[], $2::numeric[]) AS l(account_id, net)
+		JOIN accounts a ON a.id = l.account_id
+		JOIN account_types t ON t.id = a.account_type_id
+		LEFT JOIN account_balances b ON b.account_id = a.id
+		WHERE CASE WHEN t.normal_balance = 'CREDIT' THEN l.net > 0 ELSE l.net < 0 END
+		  AND ` + bookedBalanceExpr + ` - ` + heldAmountExpr + ` + ` + overdraftLimitExpr + ` < 0
+		ORDER BY a.account_number
+		LIMIT 1
+	`
+
+	return overdrawnAccount(tx.QueryRow(ctx, query, accountIDs, amounts))
+}
+
+// lineNets returns each account of the lines with its debits less credits
+func lineNets(lines []*CreateJournalEntryLineParams) ([]uuid.UUID, []decimal.Decimal) {
+	nets := make(map[uuid.UUID]decimal.Decimal, len(lines))
+	for _, line := range lines {
+		nets[line.AccountID] = nets[line.AccountID].Add(line.Debit).Sub(line.Credit)
+	}
+
+	accountIDs := make([]uuid.UUID, 0, len(nets))
+	amounts := make([]decimal.Decimal, 0, len(nets))
+	for accountID, net := range nets {
+		accountIDs = append(accountIDs, accountID)
+		amounts = append(amounts, net)
+	}
+
+	return accountIDs, amounts
+}
+
+// checkAvailableBalance rejects a hold that leaves an account with an
+// overdraft limit, its own or its account type's, below its available
+// balance. The caller must hold the tenant journal lock and have inserted
+// the hold.
+func checkAvailableBalance(ctx context.Context, tx *db.TenantTx, accountID uuid.UUID) error {
+	query := `
+		SELECT a.account_number
+		FROM accounts a
+		JOIN account_types t ON t.id = a.account_type_id
+		LEFT JOIN account_balances b ON b.account_id = a.id
+		WHERE a.id = $1
+		  AND ` + bookedBalanceExpr + ` - ` + heldAmountExpr + ` + ` + overdraftLimitExpr + ` < 0
+	`
+
+	return overdrawnAccount(tx.QueryRow(ctx, query, accountID))
+}
+
+// overdrawnAccount reports ErrInsufficientFunds for the account number
+// selected by row, if any
+func overdrawnAccount(row pgx.Row) error {
+	var accountNumber string
+	if err := row.Scan(&accountNumber); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to check available balance: %w", err)
+	}
+	return fmt.Errorf("account %s: %w", accountNumber, ErrInsufficientFunds)
+}
+
+// AccountCurrencies returns the currency of each account with its precision.
+// Unknown accounts are left out of the map.
+func (r *AccountRepository) AccountCurrencies(ctx context.Context, tenantID uuid.UUID, accountIDs []uuid.UUID) (map[uuid.UUID]AccountCurrency, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT a.id, c.code, c.precision
+		FROM accounts a
+		JOIN currencies c ON c.code = a.currency_code
+		WHERE a.id = ANY($1)
+	`
+
+	rows, err := conn.Query(ctx, query, accountIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account currencies: %w", err)
+	}
+	defer rows.Close()
+
+	currencies := make(map[uuid.UUID]AccountCurrency, len(accountIDs))
+	for rows.Next() {
+		var accountID uuid.UUID
+		var currency AccountCurrency
+		if err := rows.Scan(&accountID, &currency.CurrencyCode, &currency.Precision); err != nil {
+			return nil, fmt.Errorf("failed to scan account currency: %w", err)
+		}
+		currencies[accountID] = currency
+	}
+
+	return currencies, nil
+}
+
+// Delete soft-deletes an account; accounts with a balance or active children cannot be deleted
+func (r *AccountRepository) Delete(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*Account, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var debitBalance, creditBalance decimal.Decimal
+	var activeChildren int
+	checkQuery := `
+		SELECT COALESCE(b.debit_balance, 0), COALESCE(b.credit_balance, 0),
+		       (SELECT COUNT(*) FROM accounts c WHERE c.parent_account_id = a.id AND c.deleted_at IS NULL)
+		FROM accounts a
+		LEFT JOIN account_balances b ON b.account_id = a.id
+		WHERE a.id = $1 AND a.deleted_at IS NULL
+		FOR UPDATE OF a
+	`
+
+	err = tx.QueryRow(ctx, checkQuery, accountID).Scan(&debitBalance, &creditBalance, &activeChildren)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("account %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to check account: %w", err)
+	}
+
+	if !debitBalance.Equal(creditBalance) {
+		return nil, ErrNonZeroBalance
+	}
+
+	if activeChildren > 0 {
+		return nil, ErrAccountHasChildren
+	}
+
+	account := &Account{}
+	query := `
+		UPDATE accounts
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + accountColumns
+
+	if err := scanAccount(tx.QueryRow(ctx, query, accountID), account); err != nil {
+		return nil, fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	if err := appendEvent(ctx, tx, AggregateAccount, accountID, EventAccountDeleted, struct{}{}); err != nil {
+		return nil, err
+	}
+
+	if err := setAccountPaths(ctx, tx, account); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return account, nil
+}
+
+// Restore reverses the soft deletion of an account
+func (r *AccountRepository) Restore(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, maxAccounts *int32) (*Account, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if maxAccounts != nil {
+		if err := checkAccountQuota(ctx, tx, *maxAccounts); err != nil {
+			return nil, err
+		}
+	}
+
+	account := &Account{}
+	query := `
+		UPDATE accounts
+		SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING ` + accountColumns
+
+	if err := scanAccount(tx.QueryRow(ctx, query, accountID), account); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("deleted account %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to restore account: %w", err)
+	}
+
+	if err := appendEvent(ctx, tx, AggregateAccount, accountID, EventAccountRestored, struct{}{}); err != nil {
+		return nil, err
+	}
+
+	if err := setAccountPaths(ctx, tx, account); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return account, nil
+}
+
+// SetOverdraftLimit sets how far the available balance of an account may go
+// below zero, or stops checking the account when limit is nil
+func (r *AccountRepository) SetOverdraftLimit(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, limit *decimal.Decimal) (*Account, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	account := &Account{}
+	query := `
+		UPDATE accounts
+		SET overdraft_limit = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + accountColumns
+
+	if err := scanAccount(tx.QueryRow(ctx, query, accountID, limit), account); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("account %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to set overdraft limit: %w", err)
+	}
+
+	err = appendEvent(ctx, tx, AggregateAccount, accountID, EventAccountOverdraftLimitSet, AccountOverdraftLimitSetPayload{
+		OverdraftLimit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setAccountPaths(ctx, tx, account); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return account, nil
+}
+
+// Move moves an account with its descendants under a new parent, or makes
+// it a root account when parentID is nil. The parent must be a live account
+// of the same book and type that is neither the account itself nor one of
+// its descendants.
+func (r *AccountRepository) Move(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, parentID *uuid.UUID) (*Account, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockAccountTree(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	var bookID uuid.UUID
+	var accountTypeID int32
+	err = tx.QueryRow(ctx, "SELECT book_id, account_type_id FROM accounts WHERE id = $1 AND deleted_at IS NULL", accountID).Scan(&bookID, &accountTypeID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("account %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if parentID != nil {
+		// The account may not become its own ancestor
+		var parentBookID *uuid.UUID
+		var parentTypeID *int32
+		var cycle bool
+		err := tx.QueryRow(ctx, `
+			WITH RECURSIVE ancestors AS (
+				SELECT id, parent_account_id FROM accounts WHERE id = $1 AND deleted_at IS NULL
+				UNION
+				SELECT p.id, p.parent_account_id FROM accounts p JOIN ancestors a ON p.id = a.parent_account_id
+			)
+			SELECT (SELECT book_id FROM accounts WHERE id = $1 AND deleted_at IS NULL),
+			       (SELECT account_type_id FROM accounts WHERE id = $1 AND deleted_at IS NULL),
+			       COALESCE(bool_or(id = $2), false)
+			FROM ancestors
+		`, *parentID, accountID).Scan(&parentBookID, &parentTypeID, &cycle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check parent account: %w", err)
+		}
+		if parentTypeID == nil {
+			return nil, fmt.Errorf("parent account %w", ErrNotFound)
+		}
+		if cycle {
+			return nil, ErrAccountCycle
+		}
+		if *parentBookID != bookID {
+			return nil, ErrBookMismatch
+		}
+		if *parentTypeID != accountTypeID {
+			return nil, ErrAccountTypeMismatch
+		}
+	}
+
+	account := &Account{}
+	query := `
+		UPDATE accounts
+		SET parent_account_id = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + accountColumns
+
+	if err := scanAccount(tx.QueryRow(ctx, query, accountID, parentID), account); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("account %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to move account: %w", err)
+	}
+
+	err = appendEvent(ctx, tx, AggregateAccount, accountID, EventAccountMoved, AccountMovedPayload{
+		ParentAccountID: parentID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setAccountPaths(ctx, tx, account); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return account, nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// SetLabels replaces the labels of an account
+func (r *AccountRepository) SetLabels(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, labels map[string]string) (*Account, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := setAccountLabels(ctx, tx, accountID, labels); err != nil {
+		return nil, err
+	}
+
+	return commitAccount(ctx, tx, accountID)
+}
+
+// SetExternalID maps an account to its identifier in a source system,
+// replacing any earlier identifier for that source, or removes the mapping
+// when externalID is empty. An identifier belongs to at most one account of
+// the tenant per source.
+func (r *AccountRepository) SetExternalID(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, source, externalID string) (*Account, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := setAccountExternalID(ctx, tx, accountID, source, externalID); err != nil {
+		return nil, err
+	}
+
+	return commitAccount(ctx, tx, accountID)
+}
+
+// GetByExternalID retrieves the live account mapped to an identifier of a
+// source system
+func (r *AccountRepository) GetByExternalID(ctx context.Context, tenantID uuid.UUID, source, externalID string) (*Account, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	account := &Account{}
+	query := `
+		SELECT ` + accountColumns + `
+		FROM accounts
+		WHERE id = (SELECT account_id FROM account_external_ids WHERE source = $1 AND external_id = $2)
+		  AND deleted_at IS NULL
+	`
+
+	if err := scanAccount(conn.QueryRow(ctx, query, source, externalID), account); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("account %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if err := setAccountPaths(ctx, conn, account); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// setAccountMappings sets the labels and external identifiers a new
+// account was created with, recording an event for each
+func setAccountMappings(ctx context.Context, tx *db.TenantTx, accountID uuid.UUID, labels, externalIDs map[string]string) error {
+	if len(labels) > 0 {
+		if err := setAccountLabels(ctx, tx, accountID, labels); err != nil {
+			return err
+		}
+	}
+
+	sources := make([]string, 0, len(externalIDs))
+	for source := range externalIDs {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		if err := setAccountExternalID(ctx, tx, accountID, source, externalIDs[source]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setAccountLabels replaces the labels of a live account and records an
+// AccountLabelsSet event
+func setAccountLabels(ctx context.Context, tx *db.TenantTx, accountID uuid.UUID, labels map[string]string) error {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, `
+		UPDATE accounts
+		SET labels = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id
+	`, accountID, labels).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("account %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to set account labels: %w", err)
+	}
+
+	return appendEvent(ctx, tx, AggregateAccount, accountID, EventAccountLabelsSet, AccountLabelsSetPayload{
+		Labels: labels,
+	})
+}
+
+// setAccountExternalID maps a live account to an identifier of a source
+// system, or removes its mapping when externalID is empty, and records an
+// AccountExternalIdSet event. An identifier mapped to another account is a
+// unique violation.
+func setAccountExternalID(ctx context.Context, tx *db.TenantTx, accountID uuid.UUID, source, externalID string) error {
+	// Locks the account, so a concurrent deletion waits for the mapping
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, `
+		UPDATE accounts
+		SET updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id
+	`, accountID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("account %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to lock account: %w", err)
+	}
+
+	payload := AccountExternalIDSetPayload{Source: source}
+	if externalID == "" {
+		err = tx.Exec(ctx, "DELETE FROM account_external_ids WHERE account_id = $1 AND source = $2", accountID, source)
+	} else {
+		payload.ExternalID = &externalID
+		err = tx.Exec(ctx, `
+			INSERT INTO account_external_ids (tenant_id, account_id, source, external_id)
+			VALUES (current_setting('app.current_tenant_id')::uuid, $1, $2, $3)
+			ON CONFLICT (account_id, source) DO UPDATE SET external_id = EXCLUDED.external_id
+		`, accountID, source, externalID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set external ID: %w", err)
+	}
+
+	return appendEvent(ctx, tx, AggregateAccount, accountID, EventAccountExternalIDSet, payload)
+}
+
+// commitAccount reads an account changed in tx and commits it
+func commitAccount(ctx context.Context, tx *db.TenantTx, accountID uuid.UUID) (*Account, error) {
+	account := &Account{}
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE id = $1`
+	if err := scanAccount(tx.QueryRow(ctx, query, accountID), account); err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if err := setAccountPaths(ctx, tx, account); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return account, nil
+}
@@ -0,0 +1,420 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// Fixed asset statuses
+const (
+	AssetStatusActive           = "ACTIVE"
+	AssetStatusFullyDepreciated = "FULLY_DEPRECIATED"
+)
+
+// FixedAsset is an entry in the fixed asset register. Its depreciation is
+// charged to the expense account and credited to the accumulated
+// depreciation account according to its schedule.
+type FixedAsset struct {
+	ID                               uuid.UUID
+	TenantID                         uuid.UUID
+	Code                             string
+	Name                             string
+	Description                      string
+	AssetAccountID                   uuid.UUID
+	AccumulatedDepreciationAccountID uuid.UUID
+	DepreciationExpenseAccountID     uuid.UUID
+	Method                           string
+	Cost                             decimal.Decimal
+	SalvageValue                     decimal.Decimal
+	UsefulLifeMonths                 int32
+	DecliningFactor                  *decimal.Decimal
+	InServiceDate                    time.Time
+	Status                           string
+	AccumulatedDepreciation          decimal.Decimal
+	Schedule                         []*DepreciationLine
+	CreatedAt                        time.Time
+	UpdatedAt                        time.Time
+}
+
+// BookValue returns the cost less the depreciation posted so far
+func (a *FixedAsset) BookValue() decimal.Decimal {
+	return a.Cost.Sub(a.AccumulatedDepreciation)
+}
+
+// DepreciationLine is one period of an asset's depreciation schedule.
+// JournalEntryID is set once its entry has been posted.
+type DepreciationLine struct {
+	ID             uuid.UUID
+	AssetID        uuid.UUID
+	Period         int32
+	PeriodDate     time.Time
+	Amount         decimal.Decimal
+	Accumulated    decimal.Decimal
+	BookValue      decimal.Decimal
+	JournalEntryID *uuid.UUID
+	PostedAt       *time.Time
+}
+
+// CreateFixedAssetParams holds parameters for registering a fixed asset
+// together with its generated schedule
+type CreateFixedAssetParams struct {
+	Code                             string
+	Name                             string
+	Description                      string
+	AssetAccountID                   uuid.UUID
+	AccumulatedDepreciationAccountID uuid.UUID
+	DepreciationExpenseAccountID     uuid.UUID
+	Method                           string
+	Cost                             decimal.Decimal
+	SalvageValue                     decimal.Decimal
+	UsefulLifeMonths                 int32
+	DecliningFactor                  *decimal.Decimal
+	InServiceDate                    time.Time
+	Schedule                         []*DepreciationLine
+}
+
+// FixedAssetFilter holds filters for listing fixed assets
+type FixedAssetFilter struct {
+	Status *string
+}
+
+const fixedAssetColumns = `id, tenant_id, code, name, description, asset_account_id,
+		       accumulated_depreciation_account_id, depreciation_expense_account_id, method,
+		       cost, salvage_value, useful_life_months, declining_factor, in_service_date,
+		       status, accumulated_depreciation, created_at, updated_at`
+
+const depreciationLineColumns = `id, asset_id, period, period_date, amount, accumulated, book_value,
+		       journal_entry_id, posted_at`
+
+func scanFixedAsset(row pgx.Row, asset *FixedAsset) error {
+	return row.Scan(
+		&asset.ID,
+		&asset.TenantID,
+		&asset.Code,
+		&asset.Name,
+		&asset.Description,
+		&asset.AssetAccountID,
+		&asset.AccumulatedDepreciationAccountID,
+		&asset.DepreciationExpenseAccountID,
+		&asset.Method,
+		&asset.Cost,
+		&asset.SalvageValue,
+		&asset.UsefulLifeMonths,
+		&asset.DecliningFactor,
+		&asset.InServiceDate,
+		&asset.Status,
+		&asset.AccumulatedDepreciation,
+		&asset.CreatedAt,
+		&asset.UpdatedAt,
+	)
+}
+
+func scanDepreciationLine(row pgx.Row, line *DepreciationLine) error {
+	return row.Scan(
+		&line.ID,
+		&line.AssetID,
+		&line.Period,
+		&line.PeriodDate,
+		&line.Amount,
+		&line.Accumulated,
+		&line.BookValue,
+		&line.JournalEntryID,
+		&line.PostedAt,
+	)
+}
+
+// FixedAssetRepository handles fixed asset register operations
+type FixedAssetRepository struct {
+	db *db.DB
+}
+
+// NewFixedAssetRepository creates a new fixed asset repository
+func NewFixedAssetRepository(database *db.DB) *FixedAssetRepository {
+	return &FixedAssetRepository{db: database}
+}
+
+// Create registers a fixed asset and stores its schedule in a single transaction
+func (r *FixedAssetRepository) Create(ctx context.Context, tenantID uuid.UUID, params CreateFixedAssetParams) (*FixedAsset, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var assetID uuid.UUID
+	query := `
+		INSERT INTO fixed_assets (
+			tenant_id, code, name, description, asset_account_id, accumulated_depreciation_account_id,
+			depreciation_expense_account_id, method, cost, salvage_value, useful_life_months,
+			declining_factor, in_service_date, status
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id
+	`
+
+	err = tx.QueryRow(ctx, query,
+		tenantID,
+		params.Code,
+		params.Name,
+		params.Description,
+		params.AssetAccountID,
+		params.AccumulatedDepreciationAccountID,
+		params.DepreciationExpenseAccountID,
+		params.Method,
+		params.Cost,
+		params.SalvageValue,
+		params.UsefulLifeMonths,
+		params.DecliningFactor,
+		params.InServiceDate,
+		AssetStatusActive,
+	).Scan(&assetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fixed asset: %w", err)
+	}
+
+	lineQuery := `
+		INSERT INTO depreciation_schedule (tenant_id, asset_id, period, period_date, amount, accumulated, book_value)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	for _, line := range params.Schedule {
+		err := tx.Exec(ctx, lineQuery,
+			tenantID,
+			assetID,
+			line.Period,
+			line.PeriodDate,
+			line.Amount,
+			line.Accumulated,
+			line.BookValue,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create depreciation line: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetByID(ctx, tenantID, assetID)
+}
+
+// GetByID retrieves a fixed asset with its depreciation schedule
+func (r *FixedAssetRepository) GetByID(ctx context.Context, tenantID uuid.UUID, assetID uuid.UUID) (*FixedAsset, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	asset := &FixedAsset{}
+	query := `SELECT ` + fixedAssetColumns + ` FROM fixed_assets WHERE id = $1`
+
+	if err := scanFixedAsset(conn.QueryRow(ctx, query, assetID), asset); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("fixed asset %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get fixed asset: %w", err)
+	}
+
+	schedule, err := r.getSchedule(ctx, conn, assetID)
+	if err != nil {
+		return nil, err
+	}
+	asset.Schedule = schedule
+
+	return asset, nil
+}
+
+// List retrieves fixed assets without their schedules, ordered by code
+func (r *FixedAssetRepository) List(ctx context.Context, tenantID uuid.UUID, filter FixedAssetFilter, limit, offset int) ([]*FixedAsset, int, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	argCount := 0
+
+	if filter.Status != nil {
+		argCount++
+		where += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, *filter.Status)
+	}
+
+	var totalCount int
+	if err := conn.QueryRow(ctx, "SELECT COUNT(*) FROM fixed_assets"+where, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count fixed assets: %w", err)
+	}
+
+	query := `SELECT ` + fixedAssetColumns + ` FROM fixed_assets` + where +
+		fmt.Sprintf(" ORDER BY code LIMIT $%d OFFSET $%d", argCount+1, argCount+2)
+	args = append(args, limit, offset)
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list fixed assets: %w", err)
+	}
+	defer rows.Close()
+
+	assets := make([]*FixedAsset, 0)
+	for rows.Next() {
+		asset := &FixedAsset{}
+		if err := scanFixedAsset(rows, asset); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan fixed asset: %w", err)
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, totalCount, nil
+}
+
+// ListDueDepreciation retrieves the unposted schedule lines of active assets
+// dated on or before through, ordered by asset and period. An asset ID limits
+// the lines to that asset.
+func (r *FixedAssetRepository) ListDueDepreciation(ctx context.Context, tenantID uuid.UUID, assetID *uuid.UUID, through time.Time) ([]*DepreciationLine, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT ` + depreciationLineColumns + `
+		FROM depreciation_schedule
+		WHERE journal_entry_id IS NULL
+		  AND period_date <= $1
+		  AND asset_id IN (SELECT id FROM fixed_assets WHERE status = $2)
+		  AND ($3::uuid IS NULL OR asset_id = $3)
+		ORDER BY asset_id, period
+	`
+
+	rows, err := conn.Query(ctx, query, through, AssetStatusActive, assetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due depreciation: %w", err)
+	}
+	defer rows.Close()
+
+	lines := make([]*DepreciationLine, 0)
+	for rows.Next() {
+		line := &DepreciationLine{}
+		if err := scanDepreciationLine(rows, line); err != nil {
+			return nil, fmt.Errorf("failed to scan depreciation line: %w", err)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// PostDepreciation posts the journal entry of a schedule line and records it
+// against the line and its asset in a single transaction. The asset becomes
+// fully depreciated once its last line is posted, and the depreciation tasks
+// of close checklists complete once their period is fully posted.
+func (r *FixedAssetRepository) PostDepreciation(ctx context.Context, tenantID uuid.UUID, lineID uuid.UUID, entry CreateJournalEntryParams) (*DepreciationLine, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Take the tenant journal lock before the schedule row, in the order merges
+	// take them, so the two cannot deadlock
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	line := &DepreciationLine{}
+	query := `SELECT ` + depreciationLineColumns + ` FROM depreciation_schedule WHERE id = $1 FOR UPDATE`
+
+	if err := scanDepreciationLine(tx.QueryRow(ctx, query, lineID), line); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("depreciation line %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to lock depreciation line: %w", err)
+	}
+
+	if line.JournalEntryID != nil {
+		return nil, ErrDepreciationPosted
+	}
+
+	journalEntryID, err := insertJournalEntry(ctx, tx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	updateLineQuery := `
+		UPDATE depreciation_schedule
+		SET journal_entry_id = $2, posted_at = NOW()
+		WHERE id = $1
+		RETURNING ` + depreciationLineColumns
+
+	if err := scanDepreciationLine(tx.QueryRow(ctx, updateLineQuery, lineID, journalEntryID), line); err != nil {
+		return nil, fmt.Errorf("failed to update depreciation line: %w", err)
+	}
+
+	updateAssetQuery := `
+		UPDATE fixed_assets
+		SET accumulated_depreciation = accumulated_depreciation + $2,
+		    status = CASE
+		        WHEN EXISTS (
+		            SELECT 1 FROM depreciation_schedule
+		            WHERE asset_id = $1 AND journal_entry_id IS NULL
+		        ) THEN status
+		        ELSE 'FULLY_DEPRECIATED'
+		    END,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if err := tx.Exec(ctx, updateAssetQuery, line.AssetID, line.Amount); err != nil {
+		return nil, fmt.Errorf("failed to update fixed asset: %w", err)
+	}
+
+	if err := completeCloseTasks(ctx, tx, CloseActionPostDepreciation); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return line, nil
+}
+
+// getSchedule retrieves the depreciation schedule of an asset in period order
+func (r *FixedAssetRepository) getSchedule(ctx context.Context, conn *pgxpool.Conn, assetID uuid.UUID) ([]*DepreciationLine, error) {
+	query := `
+		SELECT ` + depreciationLineColumns + `
+		FROM depreciation_schedule
+		WHERE asset_id = $1
+		ORDER BY period
+	`
+
+	rows, err := conn.Query(ctx, query, assetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get depreciation schedule: %w", err)
+	}
+	defer rows.Close()
+
+	schedule := make([]*DepreciationLine, 0)
+	for rows.Next() {
+		line := &DepreciationLine{}
+		if err := scanDepreciationLine(rows, line); err != nil {
+			return nil, fmt.Errorf("failed to scan depreciation line: %w", err)
+		}
+		schedule = append(schedule, line)
+	}
+
+	return schedule, nil
+}
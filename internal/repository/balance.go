@@ -0,0 +1,419 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// BalanceDiscrepancy is an account whose stored balance differed from the sum
+// of its journal lines
+type BalanceDiscrepancy struct {
+	AccountID      uuid.UUID
+	StoredDebit    decimal.Decimal
+	StoredCredit   decimal.Decimal
+	ComputedDebit  decimal.Decimal
+	ComputedCredit decimal.Decimal
+	// Missing is set when the account had no balance row at all
+	Missing bool
+}
+
+// BalanceRebuild is the outcome of rebuilding account balances
+type BalanceRebuild struct {
+	AccountsChecked int
+	Discrepancies   []*BalanceDiscrepancy
+	// PeriodTotalsCorrected counts the account periods whose reporting
+	// totals were missing or differed from their journal lines
+	PeriodTotalsCorrected int
+	// AccountsRestored counts the accounts whose cached rows were missing
+	// or differed from their events
+	AccountsRestored int
+}
+
+// BalanceProjection folds ledger events into account balances, as the
+// projections of internal/projection do
+type BalanceProjection interface {
+	Apply(event *LedgerEvent) error
+	Totals(accountID uuid.UUID) (debit, credit decimal.Decimal)
+}
+
+// AccountProjection folds ledger events into the state of accounts, as the
+// projections of internal/projection do
+type AccountProjection interface {
+	Apply(event *LedgerEvent) error
+	// Accounts returns the projected accounts in the order they were
+	// created
+	Accounts() []*Account
+}
+
+// BalanceRepository maintains the denormalized account_balances and
+// account_period_totals tables
+type BalanceRepository struct {
+	db *db.DB
+}
+
+// NewBalanceRepository creates a new balance repository
+func NewBalanceRepository(database *db.DB) *BalanceRepository {
+	return &BalanceRepository{db: database}
+}
+
+// Rebuild recomputes the balances and period totals of a tenant's accounts
+// from their journal lines, or of a single account when accountID is set,
+// and corrects any that drifted. Postings are locked out for the duration of
+// the transaction so the recomputed sums stay current.
+func (r *BalanceRepository) Rebuild(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID) (*BalanceRebuild, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT a.id,
+		       COALESCE(l.debit, 0), COALESCE(l.credit, 0),
+		       b.account_id IS NOT NULL,
+		       COALESCE(b.debit_balance, 0), COALESCE(b.credit_balance, 0)
+		FROM accounts a
+		LEFT JOIN (
+			SELECT account_id, SUM(debit) AS debit, SUM(credit) AS credit
+			FROM journal_entry_lines
+			GROUP BY account_id
+		) l ON l.account_id = a.id
+		LEFT JOIN account_balances b ON b.account_id = a.id
+		WHERE $1::uuid IS NULL OR a.id = $1
+		ORDER BY a.account_number
+	`
+
+	rows, err := tx.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute account balances: %w", err)
+	}
+
+	result := &BalanceRebuild{Discrepancies: make([]*BalanceDiscrepancy, 0)}
+	for rows.Next() {
+		d := &BalanceDiscrepancy{}
+		var stored bool
+		err := rows.Scan(
+			&d.AccountID,
+			&d.ComputedDebit,
+			&d.ComputedCredit,
+			&stored,
+			&d.StoredDebit,
+			&d.StoredCredit,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan account balance: %w", err)
+		}
+
+		result.AccountsChecked++
+		d.Missing = !stored
+		if d.Missing || !d.StoredDebit.Equal(d.ComputedDebit) || !d.StoredCredit.Equal(d.ComputedCredit) {
+			result.Discrepancies = append(result.Discrepancies, d)
+		}
+	}
+	rows.Close()
+
+	if accountID != nil && result.AccountsChecked == 0 {
+		return nil, fmt.Errorf("account %w", ErrNotFound)
+	}
+
+	corrected := make([]uuid.UUID, len(result.Discrepancies))
+	for i, d := range result.Discrepancies {
+		corrected[i] = d.AccountID
+		if err := upsertAccountBalance(ctx, tx, d.AccountID, d.ComputedDebit, d.ComputedCredit); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := notifyBalanceChanges(ctx, tx, corrected); err != nil {
+		return nil, err
+	}
+
+	result.PeriodTotalsCorrected, err = rebuildPeriodTotals(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// RebuildFromEvents replays every event of a tenant into the account and
+// balance projections and restores the cached accounts rows and stored
+// balances of its accounts, or of a single account when accountID is set,
+// that are missing or differ from the projected ones. They are rebuilt from
+// ledger_events alone; journal lines and period totals are not read or
+// changed. Accounts and entries recorded before the tenant recorded events
+// have no events to rebuild them from, so a tenant holding any is refused
+// with ErrEventHistoryIncomplete rather than losing them.
+func (r *BalanceRepository) RebuildFromEvents(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, balances BalanceProjection, accounts AccountProjection) (*BalanceRebuild, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := lockAccountTree(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	var incomplete bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM journal_entries e
+			WHERE NOT EXISTS (
+				SELECT 1 FROM ledger_events v
+				WHERE v.aggregate_type = $1 AND v.aggregate_id = e.id AND v.event_type = $2
+			)
+		) OR EXISTS (
+			SELECT 1 FROM accounts a
+			WHERE NOT EXISTS (
+				SELECT 1 FROM ledger_events v
+				WHERE v.aggregate_type = $3 AND v.aggregate_id = a.id AND v.event_type = $4
+			)
+		)
+	`, AggregateJournalEntry, EventJournalEntryPosted, AggregateAccount, EventAccountCreated).Scan(&incomplete)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check event history: %w", err)
+	}
+	if incomplete {
+		return nil, ErrEventHistoryIncomplete
+	}
+
+	rows, err := tx.Query(ctx, `SELECT `+ledgerEventColumns+` FROM ledger_events ORDER BY sequence`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay ledger events: %w", err)
+	}
+	for rows.Next() {
+		event := &LedgerEvent{}
+		if err := scanLedgerEvent(rows, event); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan ledger event: %w", err)
+		}
+		if err := balances.Apply(event); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if err := accounts.Apply(event); err != nil {
+			rows.Close()
+			return nil, err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to replay ledger events: %w", err)
+	}
+
+	restored, err := restoreAccounts(ctx, tx, accounts.Accounts(), accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT a.id, b.account_id IS NOT NULL,
+		       COALESCE(b.debit_balance, 0), COALESCE(b.credit_balance, 0)
+		FROM accounts a
+		LEFT JOIN account_balances b ON b.account_id = a.id
+		WHERE $1::uuid IS NULL OR a.id = $1
+		ORDER BY a.account_number
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read account balances: %w", err)
+	}
+
+	result := &BalanceRebuild{Discrepancies: make([]*BalanceDiscrepancy, 0), AccountsRestored: restored}
+	for rows.Next() {
+		d := &BalanceDiscrepancy{}
+		var stored bool
+		if err := rows.Scan(&d.AccountID, &stored, &d.StoredDebit, &d.StoredCredit); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan account balance: %w", err)
+		}
+
+		result.AccountsChecked++
+		d.Missing = !stored
+		d.ComputedDebit, d.ComputedCredit = balances.Totals(d.AccountID)
+		if d.Missing || !d.StoredDebit.Equal(d.ComputedDebit) || !d.StoredCredit.Equal(d.ComputedCredit) {
+			result.Discrepancies = append(result.Discrepancies, d)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read account balances: %w", err)
+	}
+
+	if accountID != nil && result.AccountsChecked == 0 {
+		return nil, fmt.Errorf("account %w", ErrNotFound)
+	}
+
+	corrected := make([]uuid.UUID, len(result.Discrepancies))
+	for i, d := range result.Discrepancies {
+		corrected[i] = d.AccountID
+		if err := upsertAccountBalance(ctx, tx, d.AccountID, d.ComputedDebit, d.ComputedCredit); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := notifyBalanceChanges(ctx, tx, corrected); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// restoreAccounts writes projected accounts, or only the one with accountID
+// when set, to the accounts rows they are cached in, inserting missing rows
+// and correcting rows and external IDs that differ. Deletion times are kept
+// while the row agrees the account is deleted. It returns the number of
+// accounts written.
+func restoreAccounts(ctx context.Context, tx *db.TenantTx, accounts []*Account, accountID *uuid.UUID) (int, error) {
+	if accountID != nil {
+		accounts = slices.DeleteFunc(slices.Clone(accounts), func(account *Account) bool {
+			return account.ID != *accountID
+		})
+	}
+
+	restored := make(map[uuid.UUID]bool)
+	for _, account := range accounts {
+		var id uuid.UUID
+		err := tx.QueryRow(ctx, `
+			INSERT INTO accounts (id, tenant_id, book_id, account_number, name, description, account_type_id,
+			                      currency_code, is_active, created_at, deleted_at, overdraft_limit, labels)
+			VALUES ($1, current_setting('app.current_tenant_id')::uuid, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			ON CONFLICT (id) DO UPDATE
+			SET book_id = EXCLUDED.book_id,
+			    account_number = EXCLUDED.account_number,
+			    name = EXCLUDED.name,
+			    description = EXCLUDED.description,
+			    account_type_id = EXCLUDED.account_type_id,
+			    currency_code = EXCLUDED.currency_code,
+			    is_active = EXCLUDED.is_active,
+			    deleted_at = CASE WHEN (accounts.deleted_at IS NULL) = (EXCLUDED.deleted_at IS NULL)
+			                      THEN accounts.deleted_at ELSE EXCLUDED.deleted_at END,
+			    overdraft_limit = EXCLUDED.overdraft_limit,
+			    labels = EXCLUDED.labels,
+			    updated_at = NOW()
+			WHERE (accounts.book_id, accounts.account_number, accounts.name, accounts.description,
+			       accounts.account_type_id, accounts.currency_code, accounts.is_active,
+			       accounts.deleted_at IS NULL, accounts.overdraft_limit, COALESCE(accounts.labels, '{}'))
+			      IS DISTINCT FROM
+			      (EXCLUDED.book_id, EXCLUDED.account_number, EXCLUDED.name, EXCLUDED.description,
+			       EXCLUDED.account_type_id, EXCLUDED.currency_code, EXCLUDED.is_active,
+			       EXCLUDED.deleted_at IS NULL, EXCLUDED.overdraft_limit, EXCLUDED.labels)
+			RETURNING id
+		`, account.ID, account.BookID, account.AccountNumber, account.Name, account.Description, account.AccountTypeID,
+			account.CurrencyCode, account.IsActive, account.CreatedAt, account.DeletedAt, account.OverdraftLimit, account.Labels,
+		).Scan(&id)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+		case err != nil:
+			return 0, fmt.Errorf("failed to restore account: %w", err)
+		default:
+			restored[account.ID] = true
+		}
+	}
+
+	// Parents are set once every account exists, as an account may have
+	// been moved under one created after it
+	for _, account := range accounts {
+		var id uuid.UUID
+		err := tx.QueryRow(ctx, `
+			UPDATE accounts
+			SET parent_account_id = $2, updated_at = NOW()
+			WHERE id = $1 AND parent_account_id IS DISTINCT FROM $2
+			RETURNING id
+		`, account.ID, account.ParentAccountID).Scan(&id)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+		case err != nil:
+			return 0, fmt.Errorf("failed to restore account parent: %w", err)
+		default:
+			restored[account.ID] = true
+		}
+	}
+
+	ids := make([]uuid.UUID, len(accounts))
+	for i, account := range accounts {
+		ids[i] = account.ID
+	}
+	rows, err := tx.Query(ctx, "SELECT account_id, source, external_id FROM account_external_ids WHERE account_id = ANY($1)", ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read external IDs: %w", err)
+	}
+	stored := make(map[uuid.UUID]map[string]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var source, externalID string
+		if err := rows.Scan(&id, &source, &externalID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan external ID: %w", err)
+		}
+		if stored[id] == nil {
+			stored[id] = make(map[string]string)
+		}
+		stored[id][source] = externalID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read external IDs: %w", err)
+	}
+
+	for _, account := range accounts {
+		if maps.Equal(stored[account.ID], account.ExternalIDs) {
+			continue
+		}
+		if err := tx.Exec(ctx, "DELETE FROM account_external_ids WHERE account_id = $1", account.ID); err != nil {
+			return 0, fmt.Errorf("failed to restore external IDs: %w", err)
+		}
+		for source, externalID := range account.ExternalIDs {
+			err := tx.Exec(ctx, `
+				INSERT INTO account_external_ids (tenant_id, account_id, source, external_id)
+				VALUES (current_setting('app.current_tenant_id')::uuid, $1, $2, $3)
+			`, account.ID, source, externalID)
+			if err != nil {
+				return 0, fmt.Errorf("failed to restore external IDs: %w", err)
+			}
+		}
+		restored[account.ID] = true
+	}
+
+	return len(restored), nil
+}
+
+// upsertAccountBalance sets the stored balance of an account
+func upsertAccountBalance(ctx context.Context, tx *db.TenantTx, accountID uuid.UUID, debit, credit decimal.Decimal) error {
+	err := tx.Exec(ctx, `
+		INSERT INTO account_balances (account_id, debit_balance, credit_balance, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (account_id) DO UPDATE
+		SET debit_balance = EXCLUDED.debit_balance,
+		    credit_balance = EXCLUDED.credit_balance,
+		    updated_at = NOW()
+	`, accountID, debit, credit)
+	if err != nil {
+		return fmt.Errorf("failed to update account balance: %w", err)
+	}
+	return nil
+}
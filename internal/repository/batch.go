@@ -0,0 +1,531 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// Journal batch statuses. A batch collects entries while OPEN, is APPROVED
+// once every entry validates, and is then either POSTED or REJECTED.
+const (
+	JournalBatchStatusOpen     = "OPEN"
+	JournalBatchStatusApproved = "APPROVED"
+	JournalBatchStatusPosted   = "POSTED"
+	JournalBatchStatusRejected = "REJECTED"
+)
+
+// JournalBatch is a named group of draft journal entries, such as a payroll
+// or billing run, that is reviewed and posted or rejected as a unit
+type JournalBatch struct {
+	ID              uuid.UUID
+	TenantID        uuid.UUID
+	Name            string
+	Description     string
+	Status          string
+	EntryCount      int
+	TotalDebit      decimal.Decimal
+	Entries         []*JournalBatchEntry
+	RejectionReason *string
+	ApprovedAt      *time.Time
+	PostedAt        *time.Time
+	RejectedAt      *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// JournalBatchEntry is a draft journal entry of a batch, posted when the
+// batch is posted
+type JournalBatchEntry struct {
+	ID             uuid.UUID
+	BatchID        uuid.UUID
+	Position       int
+	Entry          CreateJournalEntryParams
+	JournalEntryID *uuid.UUID
+	CreatedAt      time.Time
+}
+
+// JournalBatchProblem is an entry of a batch that fails to post, with the
+// error posting it returned
+type JournalBatchProblem struct {
+	Entry *JournalBatchEntry
+	Err   error
+}
+
+const journalBatchColumns = `id, tenant_id, name, description, status,
+		       (SELECT COUNT(*) FROM journal_batch_entries e WHERE e.batch_id = journal_batches.id),
+		       (SELECT COALESCE(SUM(e.total_debit), 0) FROM journal_batch_entries e WHERE e.batch_id = journal_batches.id),
+		       rejection_reason, approved_at, posted_at, rejected_at, created_at, updated_at`
+
+const journalBatchEntryColumns = `id, batch_id, position, entry, journal_entry_id, created_at`
+
+func scanJournalBatch(row pgx.Row, batch *JournalBatch) error {
+	return row.Scan(
+		&batch.ID,
+		&batch.TenantID,
+		&batch.Name,
+		&batch.Description,
+		&batch.Status,
+		&batch.EntryCount,
+		&batch.TotalDebit,
+		&batch.RejectionReason,
+		&batch.ApprovedAt,
+		&batch.PostedAt,
+		&batch.RejectedAt,
+		&batch.CreatedAt,
+		&batch.UpdatedAt,
+	)
+}
+
+func scanJournalBatchEntry(row pgx.Row, batchEntry *JournalBatchEntry) error {
+	var entry []byte
+	err := row.Scan(
+		&batchEntry.ID,
+		&batchEntry.BatchID,
+		&batchEntry.Position,
+		&entry,
+		&batchEntry.JournalEntryID,
+		&batchEntry.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(entry, &batchEntry.Entry)
+}
+
+// JournalBatchRepository handles journal batch database operations
+type JournalBatchRepository struct {
+	db *db.DB
+}
+
+// NewJournalBatchRepository creates a new journal batch repository
+func NewJournalBatchRepository(database *db.DB) *JournalBatchRepository {
+	return &JournalBatchRepository{db: database}
+}
+
+// Create creates an open batch without entries
+func (r *JournalBatchRepository) Create(ctx context.Context, tenantID uuid.UUID, name, description string) (*JournalBatch, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &JournalBatch{Entries: make([]*JournalBatchEntry, 0)}
+	query := `
+		INSERT INTO journal_batches (tenant_id, name, description, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + journalBatchColumns
+
+	row := tx.QueryRow(ctx, query, tenantID, name, description, JournalBatchStatusOpen)
+	if err := scanJournalBatch(row, batch); err != nil {
+		return nil, fmt.Errorf("failed to create journal batch: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return batch, nil
+}
+
+// GetByID retrieves a batch with its entries
+func (r *JournalBatchRepository) GetByID(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID) (*JournalBatch, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	return getJournalBatch(ctx, tx, batchID, false)
+}
+
+// List retrieves batches without their entries, newest first
+func (r *JournalBatchRepository) List(ctx context.Context, tenantID uuid.UUID, batchStatus *string, limit, offset int) ([]*JournalBatch, int, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	argCount := 0
+
+	if batchStatus != nil {
+		argCount++
+		where += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, *batchStatus)
+	}
+
+	var totalCount int
+	if err := conn.QueryRow(ctx, "SELECT COUNT(*) FROM journal_batches"+where, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count journal batches: %w", err)
+	}
+
+	query := `SELECT ` + journalBatchColumns + ` FROM journal_batches` + where +
+		fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", argCount+1, argCount+2)
+	args = append(args, limit, offset)
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list journal batches: %w", err)
+	}
+	defer rows.Close()
+
+	batches := make([]*JournalBatch, 0)
+	for rows.Next() {
+		batch := &JournalBatch{}
+		if err := scanJournalBatch(rows, batch); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan journal batch: %w", err)
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, totalCount, nil
+}
+
+// AddEntry appends a draft entry to an open batch and returns the batch
+// along with the added entry. The entry is not posted, so account balances
+// are only checked when the batch is validated or approved.
+func (r *JournalBatchRepository) AddEntry(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID, entry CreateJournalEntryParams) (*JournalBatch, *JournalBatchEntry, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch, err := getJournalBatch(ctx, tx, batchID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if batch.Status != JournalBatchStatusOpen {
+		return nil, nil, ErrBatchNotOpen
+	}
+
+	// The stored entry keeps the metadata as the posted entry will
+	entry.Metadata, err = sealMetadata(ctx, tx, entry.Metadata)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+
+	totalDebit := decimal.Zero
+	for _, line := range entry.Lines {
+		totalDebit = totalDebit.Add(line.Debit)
+	}
+
+	batchEntry := &JournalBatchEntry{}
+	query := `
+		INSERT INTO journal_batch_entries (tenant_id, batch_id, position, total_debit, entry)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + journalBatchEntryColumns
+
+	row := tx.QueryRow(ctx, query, tenantID, batchID, batch.EntryCount+1, totalDebit, entryBytes)
+	if err := scanJournalBatchEntry(row, batchEntry); err != nil {
+		return nil, nil, fmt.Errorf("failed to add journal batch entry: %w", err)
+	}
+
+	batch, err = updateJournalBatch(ctx, tx, batchID, `updated_at = NOW()`)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return batch, batchEntry, nil
+}
+
+// Validate posts every entry of a batch in order and rolls the postings
+// back, returning the entries that fail. Each entry is posted after the
+// ones before it, so entries that together overdraw an account are caught.
+func (r *JournalBatchRepository) Validate(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID) ([]*JournalBatchProblem, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Take the tenant journal lock before the batch row, since the entries are
+	// trial posted, in the order merges take them
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	batch, err := getJournalBatch(ctx, tx, batchID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return trialPostJournalBatch(ctx, tx, batch)
+}
+
+// Approve approves an open batch for posting once every entry validates,
+// failing with the error of the first entry that does not. Approving a batch
+// that was already approved returns it unchanged.
+func (r *JournalBatchRepository) Approve(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID) (*JournalBatch, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Take the tenant journal lock before the batch row, since the entries are
+	// trial posted, in the order merges take them
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	batch, err := getJournalBatch(ctx, tx, batchID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch batch.Status {
+	case JournalBatchStatusApproved:
+		return batch, nil
+	case JournalBatchStatusPosted, JournalBatchStatusRejected:
+		return nil, ErrBatchNotOpen
+	}
+
+	if len(batch.Entries) == 0 {
+		return nil, ErrBatchEmpty
+	}
+
+	problems, err := trialPostJournalBatch(ctx, tx, batch)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("batch entry %d: %w", problems[0].Entry.Position, problems[0].Err)
+	}
+
+	batch, err = updateJournalBatch(ctx, tx, batchID,
+		`status = $2, approved_at = NOW(), updated_at = NOW()`, JournalBatchStatusApproved)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return batch, nil
+}
+
+// Reject rejects an open or approved batch so none of its entries post.
+// Rejecting a batch that was already rejected returns it unchanged.
+func (r *JournalBatchRepository) Reject(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID, reason string) (*JournalBatch, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch, err := getJournalBatch(ctx, tx, batchID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch batch.Status {
+	case JournalBatchStatusRejected:
+		return batch, nil
+	case JournalBatchStatusPosted:
+		return nil, ErrBatchPosted
+	}
+
+	batch, err = updateJournalBatch(ctx, tx, batchID,
+		`status = $2, rejection_reason = $3, rejected_at = NOW(), updated_at = NOW()`,
+		JournalBatchStatusRejected, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return batch, nil
+}
+
+// Post posts every entry of an approved batch in order in a single
+// transaction, so either all of them post or none do. Posting a batch that
+// was already posted returns it unchanged, so a retried posting does not
+// fail.
+func (r *JournalBatchRepository) Post(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID) (*JournalBatch, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Take the tenant journal lock before the batch row, in the order merges
+	// take them, so the two cannot deadlock
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	batch, err := getJournalBatch(ctx, tx, batchID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch batch.Status {
+	case JournalBatchStatusPosted:
+		return batch, nil
+	case JournalBatchStatusOpen, JournalBatchStatusRejected:
+		return nil, ErrBatchNotApproved
+	}
+
+	for _, batchEntry := range batch.Entries {
+		journalEntryID, err := insertJournalEntry(ctx, tx, batchEntry.Entry)
+		if err != nil {
+			return nil, fmt.Errorf("batch entry %d: %w", batchEntry.Position, err)
+		}
+
+		err = tx.Exec(ctx, `
+			UPDATE journal_batch_entries
+			SET journal_entry_id = $2
+			WHERE id = $1
+		`, batchEntry.ID, journalEntryID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to post journal batch entry: %w", err)
+		}
+	}
+
+	batch, err = updateJournalBatch(ctx, tx, batchID,
+		`status = $2, posted_at = NOW(), updated_at = NOW()`, JournalBatchStatusPosted)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return batch, nil
+}
+
+// trialPostJournalBatch posts the entries of a batch in order inside a
+// savepoint and rolls them all back. An entry that fails is rolled back on
+// its own and reported, and the entries after it are still tried.
+func trialPostJournalBatch(ctx context.Context, tx *db.TenantTx, batch *JournalBatch) ([]*JournalBatchProblem, error) {
+	if err := tx.Exec(ctx, "SAVEPOINT validate_batch"); err != nil {
+		return nil, fmt.Errorf("failed to validate journal batch: %w", err)
+	}
+
+	problems := make([]*JournalBatchProblem, 0)
+	for _, batchEntry := range batch.Entries {
+		if err := tx.Exec(ctx, "SAVEPOINT validate_batch_entry"); err != nil {
+			return nil, fmt.Errorf("failed to validate journal batch: %w", err)
+		}
+
+		if _, err := insertJournalEntry(ctx, tx, batchEntry.Entry); err != nil {
+			problems = append(problems, &JournalBatchProblem{Entry: batchEntry, Err: err})
+			if err := tx.Exec(ctx, "ROLLBACK TO SAVEPOINT validate_batch_entry"); err != nil {
+				return nil, fmt.Errorf("failed to validate journal batch: %w", err)
+			}
+			continue
+		}
+
+		if err := tx.Exec(ctx, "RELEASE SAVEPOINT validate_batch_entry"); err != nil {
+			return nil, fmt.Errorf("failed to validate journal batch: %w", err)
+		}
+	}
+
+	if err := tx.Exec(ctx, "ROLLBACK TO SAVEPOINT validate_batch"); err != nil {
+		return nil, fmt.Errorf("failed to validate journal batch: %w", err)
+	}
+
+	return problems, nil
+}
+
+// getJournalBatch retrieves a batch with its entries, locking it for update
+// when lock is set
+func getJournalBatch(ctx context.Context, tx *db.TenantTx, batchID uuid.UUID, lock bool) (*JournalBatch, error) {
+	query := `SELECT ` + journalBatchColumns + ` FROM journal_batches WHERE id = $1`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+
+	batch := &JournalBatch{}
+	if err := scanJournalBatch(tx.QueryRow(ctx, query, batchID), batch); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("journal batch %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get journal batch: %w", err)
+	}
+
+	entries, err := listJournalBatchEntries(ctx, tx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	batch.Entries = entries
+
+	return batch, nil
+}
+
+// updateJournalBatch applies set to a batch and returns it with its
+// entries. The batch ID is $1 in set and args follow from $2.
+func updateJournalBatch(ctx context.Context, tx *db.TenantTx, batchID uuid.UUID, set string, args ...interface{}) (*JournalBatch, error) {
+	query := `
+		UPDATE journal_batches
+		SET ` + set + `
+		WHERE id = $1
+		RETURNING ` + journalBatchColumns
+
+	batch := &JournalBatch{}
+	row := tx.QueryRow(ctx, query, append([]interface{}{batchID}, args...)...)
+	if err := scanJournalBatch(row, batch); err != nil {
+		return nil, fmt.Errorf("failed to update journal batch: %w", err)
+	}
+
+	entries, err := listJournalBatchEntries(ctx, tx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	batch.Entries = entries
+
+	return batch, nil
+}
+
+// listJournalBatchEntries retrieves the entries of a batch in order
+func listJournalBatchEntries(ctx context.Context, tx *db.TenantTx, batchID uuid.UUID) ([]*JournalBatchEntry, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT `+journalBatchEntryColumns+`
+		FROM journal_batch_entries
+		WHERE batch_id = $1
+		ORDER BY position
+	`, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journal batch entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*JournalBatchEntry, 0)
+	for rows.Next() {
+		batchEntry := &JournalBatchEntry{}
+		if err := scanJournalBatchEntry(rows, batchEntry); err != nil {
+			return nil, fmt.Errorf("failed to scan journal batch entry: %w", err)
+		}
+		entries = append(entries, batchEntry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list journal batch entries: %w", err)
+	}
+
+	return entries, nil
+}
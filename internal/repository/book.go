@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// bookColumns lists the book columns in the order expected by scanBook
+const bookColumns = `id, tenant_id, code, name, description, is_default, created_at, updated_at`
+
+// scanBook scans a row selected with bookColumns into a book
+func scanBook(row pgx.Row, book *Book) error {
+	return row.Scan(
+		&book.ID,
+		&book.TenantID,
+		&book.Code,
+		&book.Name,
+		&book.Description,
+		&book.IsDefault,
+		&book.CreatedAt,
+		&book.UpdatedAt,
+	)
+}
+
+// BookRepository handles book database operations
+type BookRepository struct {
+	db *db.DB
+}
+
+// NewBookRepository creates a new book repository
+func NewBookRepository(database *db.DB) *BookRepository {
+	return &BookRepository{db: database}
+}
+
+// Create creates a book that is not the default. Codes are unique per tenant.
+func (r *BookRepository) Create(ctx context.Context, tenantID uuid.UUID, params CreateBookParams) (*Book, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	book := &Book{}
+	query := `
+		INSERT INTO books (tenant_id, code, name, description, is_default)
+		VALUES ($1, $2, $3, $4, false)
+		RETURNING ` + bookColumns
+
+	row := tx.QueryRow(ctx, query, tenantID, params.Code, params.Name, params.Description)
+	if err := scanBook(row, book); err != nil {
+		return nil, fmt.Errorf("failed to create book: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return book, nil
+}
+
+// GetByID retrieves a book
+func (r *BookRepository) GetByID(ctx context.Context, tenantID uuid.UUID, bookID uuid.UUID) (*Book, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	book := &Book{}
+	query := `SELECT ` + bookColumns + ` FROM books WHERE id = $1`
+
+	if err := scanBook(conn.QueryRow(ctx, query, bookID), book); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("book %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get book: %w", err)
+	}
+
+	return book, nil
+}
+
+// List retrieves the books of a tenant, the default book first and the
+// others ordered by code
+func (r *BookRepository) List(ctx context.Context, tenantID uuid.UUID) ([]*Book, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `SELECT ` + bookColumns + ` FROM books ORDER BY is_default DESC, code`
+
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list books: %w", err)
+	}
+	defer rows.Close()
+
+	books := make([]*Book, 0)
+	for rows.Next() {
+		book := &Book{}
+		if err := scanBook(rows, book); err != nil {
+			return nil, fmt.Errorf("failed to scan book: %w", err)
+		}
+		books = append(books, book)
+	}
+
+	return books, nil
+}
+
+// defaultBookSQL selects the default book of the current tenant; it is
+// used where an unset book means the default one
+const defaultBookSQL = `(SELECT id FROM books WHERE is_default)`
@@ -0,0 +1,382 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// Budget represents a named set of budgeted amounts
+type Budget struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	Name        string
+	Description string
+	Lines       []*BudgetLine
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// BudgetLine represents the budgeted amount of an account over a period,
+// optionally restricted to the lines with a value of one of its dimensions
+type BudgetLine struct {
+	ID             uuid.UUID
+	BudgetID       uuid.UUID
+	AccountID      uuid.UUID
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	Amount         decimal.Decimal
+	DimensionKey   *string
+	DimensionValue *string
+}
+
+// BudgetParams holds parameters for creating or updating a budget
+type BudgetParams struct {
+	Name        string
+	Description string
+	Lines       []*BudgetLineParams
+}
+
+// BudgetLineParams holds parameters for a budget line
+type BudgetLineParams struct {
+	AccountID      uuid.UUID
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	Amount         decimal.Decimal
+	DimensionKey   *string
+	DimensionValue *string
+}
+
+// BudgetActual represents a budget line together with the amounts posted in its period
+type BudgetActual struct {
+	Line          *BudgetLine
+	AccountNumber string
+	AccountName   string
+	NormalBalance string
+	Debit         decimal.Decimal
+	Credit        decimal.Decimal
+}
+
+// Actual returns the posted amount on the account's normal balance side
+func (a *BudgetActual) Actual() decimal.Decimal {
+	if a.NormalBalance == "CREDIT" {
+		return a.Credit.Sub(a.Debit)
+	}
+	return a.Debit.Sub(a.Credit)
+}
+
+// BudgetRepository handles budget database operations
+type BudgetRepository struct {
+	db *db.DB
+}
+
+// NewBudgetRepository creates a new budget repository
+func NewBudgetRepository(database *db.DB) *BudgetRepository {
+	return &BudgetRepository{db: database}
+}
+
+// Create creates a budget with its lines
+func (r *BudgetRepository) Create(ctx context.Context, tenantID uuid.UUID, params BudgetParams) (*Budget, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var budgetID uuid.UUID
+	query := `
+		INSERT INTO budgets (tenant_id, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	if err := tx.QueryRow(ctx, query, tenantID, params.Name, params.Description).Scan(&budgetID); err != nil {
+		return nil, fmt.Errorf("failed to create budget: %w", err)
+	}
+
+	if err := insertBudgetLines(ctx, tx, tenantID, budgetID, params.Lines); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetByID(ctx, tenantID, budgetID)
+}
+
+// Update replaces the name, description and lines of a budget
+func (r *BudgetRepository) Update(ctx context.Context, tenantID uuid.UUID, budgetID uuid.UUID, params BudgetParams) (*Budget, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id uuid.UUID
+	query := `
+		UPDATE budgets
+		SET name = $2, description = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING id
+	`
+
+	if err := tx.QueryRow(ctx, query, budgetID, params.Name, params.Description).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("budget %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update budget: %w", err)
+	}
+
+	if err := tx.Exec(ctx, "DELETE FROM budget_lines WHERE budget_id = $1", budgetID); err != nil {
+		return nil, fmt.Errorf("failed to clear budget lines: %w", err)
+	}
+
+	if err := insertBudgetLines(ctx, tx, tenantID, budgetID, params.Lines); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetByID(ctx, tenantID, budgetID)
+}
+
+// Delete removes a budget and its lines
+func (r *BudgetRepository) Delete(ctx context.Context, tenantID uuid.UUID, budgetID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id uuid.UUID
+	if err := tx.QueryRow(ctx, "DELETE FROM budgets WHERE id = $1 RETURNING id", budgetID).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("budget %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a budget with its lines
+func (r *BudgetRepository) GetByID(ctx context.Context, tenantID uuid.UUID, budgetID uuid.UUID) (*Budget, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	budget := &Budget{}
+	query := `
+		SELECT id, tenant_id, name, description, created_at, updated_at
+		FROM budgets
+		WHERE id = $1
+	`
+
+	err = conn.QueryRow(ctx, query, budgetID).Scan(
+		&budget.ID,
+		&budget.TenantID,
+		&budget.Name,
+		&budget.Description,
+		&budget.CreatedAt,
+		&budget.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("budget %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get budget: %w", err)
+	}
+
+	lines, err := r.getLinesByBudgetID(ctx, conn, budgetID)
+	if err != nil {
+		return nil, err
+	}
+	budget.Lines = lines
+
+	return budget, nil
+}
+
+// List retrieves budgets without their lines
+func (r *BudgetRepository) List(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*Budget, int, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	var totalCount int
+	if err := conn.QueryRow(ctx, "SELECT COUNT(*) FROM budgets").Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count budgets: %w", err)
+	}
+
+	query := `
+		SELECT id, tenant_id, name, description, created_at, updated_at
+		FROM budgets
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := conn.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list budgets: %w", err)
+	}
+	defer rows.Close()
+
+	budgets := make([]*Budget, 0)
+	for rows.Next() {
+		budget := &Budget{}
+		err := rows.Scan(
+			&budget.ID,
+			&budget.TenantID,
+			&budget.Name,
+			&budget.Description,
+			&budget.CreatedAt,
+			&budget.UpdatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, totalCount, nil
+}
+
+// GetActuals returns every line of a budget together with the amounts posted
+// to its account within the line's period and, for a line with a dimension,
+// by journal lines carrying that dimension value
+func (r *BudgetRepository) GetActuals(ctx context.Context, tenantID uuid.UUID, budgetID uuid.UUID) ([]*BudgetActual, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT bl.id, bl.budget_id, bl.account_id, bl.period_start, bl.period_end, bl.amount,
+		       bl.dimension_key, bl.dimension_value,
+		       a.account_number, a.name, at.normal_balance,
+		       COALESCE(actual.debit, 0), COALESCE(actual.credit, 0)
+		FROM budget_lines bl
+		INNER JOIN accounts a ON a.id = bl.account_id
+		INNER JOIN account_types at ON at.id = a.account_type_id
+		LEFT JOIN LATERAL (
+			SELECT SUM(jel.debit) AS debit, SUM(jel.credit) AS credit
+			FROM journal_entry_lines jel
+			INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+			WHERE jel.account_id = bl.account_id
+			  AND je.entry_date BETWEEN bl.period_start AND bl.period_end
+			  AND (bl.dimension_key IS NULL OR jel.dimensions->>bl.dimension_key = bl.dimension_value)
+		) actual ON TRUE
+		WHERE bl.budget_id = $1
+		ORDER BY a.account_number, bl.period_start
+	`
+
+	rows, err := conn.Query(ctx, query, budgetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget actuals: %w", err)
+	}
+	defer rows.Close()
+
+	actuals := make([]*BudgetActual, 0)
+	for rows.Next() {
+		actual := &BudgetActual{Line: &BudgetLine{}}
+		err := rows.Scan(
+			&actual.Line.ID,
+			&actual.Line.BudgetID,
+			&actual.Line.AccountID,
+			&actual.Line.PeriodStart,
+			&actual.Line.PeriodEnd,
+			&actual.Line.Amount,
+			&actual.Line.DimensionKey,
+			&actual.Line.DimensionValue,
+			&actual.AccountNumber,
+			&actual.AccountName,
+			&actual.NormalBalance,
+			&actual.Debit,
+			&actual.Credit,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget actual: %w", err)
+		}
+		actuals = append(actuals, actual)
+	}
+
+	return actuals, nil
+}
+
+// getLinesByBudgetID retrieves all lines of a budget
+func (r *BudgetRepository) getLinesByBudgetID(ctx context.Context, conn *pgxpool.Conn, budgetID uuid.UUID) ([]*BudgetLine, error) {
+	query := `
+		SELECT id, budget_id, account_id, period_start, period_end, amount, dimension_key, dimension_value
+		FROM budget_lines
+		WHERE budget_id = $1
+		ORDER BY period_start, account_id
+	`
+
+	rows, err := conn.Query(ctx, query, budgetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query budget lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines := make([]*BudgetLine, 0)
+	for rows.Next() {
+		line := &BudgetLine{}
+		err := rows.Scan(
+			&line.ID,
+			&line.BudgetID,
+			&line.AccountID,
+			&line.PeriodStart,
+			&line.PeriodEnd,
+			&line.Amount,
+			&line.DimensionKey,
+			&line.DimensionValue,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget line: %w", err)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+func insertBudgetLines(ctx context.Context, tx *db.TenantTx, tenantID uuid.UUID, budgetID uuid.UUID, lines []*BudgetLineParams) error {
+	query := `
+		INSERT INTO budget_lines (tenant_id, budget_id, account_id, period_start, period_end, amount, dimension_key, dimension_value)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	for i, line := range lines {
+		err := tx.Exec(ctx, query,
+			tenantID,
+			budgetID,
+			line.AccountID,
+			line.PeriodStart,
+			line.PeriodEnd,
+			line.Amount,
+			line.DimensionKey,
+			line.DimensionValue,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create budget line %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// CDCVerification is the outcome of checking that logical replication
+// consumers can capture ledger_events
+type CDCVerification struct {
+	// WALLevel is the server's wal_level, which must be logical
+	WALLevel string
+	// PublicationExists and PublishesEvents report whether the publication
+	// exists and includes ledger_events
+	PublicationExists bool
+	PublishesEvents   bool
+	// Problems describes every reason consumers cannot capture the events;
+	// empty when they can
+	Problems []string
+}
+
+// OK reports whether no problems were found
+func (v *CDCVerification) OK() bool {
+	return len(v.Problems) == 0
+}
+
+// ReplicationSlot is a logical replication slot of the ledger database
+type ReplicationSlot struct {
+	Name   string
+	Plugin string
+	Active bool
+	// LagBytes is the WAL written since the consumer last confirmed a flush
+	LagBytes int64
+}
+
+// VerifyChangeDataCapture checks that the server writes logical WAL and that
+// the publication exists and includes ledger_events. The publication is
+// created with the schema, as creating it needs ownership of the table.
+func (r *SchemaRepository) VerifyChangeDataCapture(ctx context.Context, publication string) (*CDCVerification, error) {
+	result := &CDCVerification{}
+
+	if err := r.db.Pool().QueryRow(ctx, "SHOW wal_level").Scan(&result.WALLevel); err != nil {
+		return nil, fmt.Errorf("failed to get wal level: %w", err)
+	}
+	if result.WALLevel != "logical" {
+		result.Problems = append(result.Problems, fmt.Sprintf("wal_level is %s, logical replication needs logical", result.WALLevel))
+	}
+
+	err := r.db.Pool().QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM pg_publication WHERE pubname = $1),
+		       EXISTS (
+		           SELECT 1 FROM pg_publication_tables
+		           WHERE pubname = $1 AND schemaname = current_schema() AND tablename = 'ledger_events'
+		       )
+	`, publication).Scan(&result.PublicationExists, &result.PublishesEvents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get publication: %w", err)
+	}
+
+	switch {
+	case !result.PublicationExists:
+		result.Problems = append(result.Problems, fmt.Sprintf("publication %s does not exist", publication))
+	case !result.PublishesEvents:
+		result.Problems = append(result.Problems, fmt.Sprintf("publication %s does not include ledger_events", publication))
+	}
+
+	return result, nil
+}
+
+// ListReplicationSlots retrieves the logical replication slots of the ledger
+// database with the WAL each has yet to confirm
+func (r *SchemaRepository) ListReplicationSlots(ctx context.Context) ([]*ReplicationSlot, error) {
+	query := `
+		SELECT slot_name, plugin, active,
+		       COALESCE(pg_wal_lsn_diff(pg_current_wal_lsn(), confirmed_flush_lsn), 0)::bigint
+		FROM pg_replication_slots
+		WHERE slot_type = 'logical' AND database = current_database()
+		ORDER BY slot_name
+	`
+
+	rows, err := r.db.Pool().Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replication slots: %w", err)
+	}
+	defer rows.Close()
+
+	slots := make([]*ReplicationSlot, 0)
+	for rows.Next() {
+		slot := &ReplicationSlot{}
+		if err := rows.Scan(&slot.Name, &slot.Plugin, &slot.Active, &slot.LagBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan replication slot: %w", err)
+		}
+		slots = append(slots, slot)
+	}
+
+	return slots, rows.Err()
+}
@@ -0,0 +1,415 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// Clone modes, from the least to the most data copied
+const (
+	// CloneChart copies the books, the live accounts and the settings
+	CloneChart = "CHART"
+	// CloneBalances also posts one opening entry per book bringing every
+	// account to its balance in the source
+	CloneBalances = "BALANCES"
+	// CloneHistory also reposts every journal entry of the source, oldest
+	// first, and copies deleted accounts
+	CloneHistory = "HISTORY"
+)
+
+// CloneTenantParams holds parameters for cloning a tenant
+type CloneTenantParams struct {
+	SourceTenantID uuid.UUID
+	Name           string
+	Mode           string
+}
+
+// TenantClone is the outcome of cloning a tenant
+type TenantClone struct {
+	Tenant         *Tenant
+	Accounts       int
+	JournalEntries int
+}
+
+// clonedAccount is a source account with the ID of its copy
+type clonedAccount struct {
+	source *Account
+	id     uuid.UUID
+}
+
+// Clone copies a live tenant into a new test tenant, as much of it as the
+// mode asks for. The source is read from a single snapshot and the clone is
+// written in one transaction, so it either matches the source at one point in
+// time or does not exist. Parties, tax codes, policies and other tenant data
+// are not copied; lines keep their dimensions but lose their party, tax code
+// and counterparty tenant.
+func (r *TenantDataRepository) Clone(ctx context.Context, params CloneTenantParams) (*TenantClone, error) {
+	source, err := r.db.BeginSnapshotTx(ctx, params.SourceTenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer source.Rollback(ctx)
+
+	if err := source.Exec(ctx, "SET LOCAL statement_timeout = 0"); err != nil {
+		return nil, fmt.Errorf("failed to lift statement timeout: %w", err)
+	}
+
+	var exists bool
+	err = source.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM tenants WHERE id = $1 AND deleted_at IS NULL)", params.SourceTenantID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("tenant %w", ErrNotFound)
+	}
+
+	snapshot := &tenantSnapshot{tx: source, tenantID: params.SourceTenantID}
+
+	cloneID := uuid.New()
+	target, err := r.db.BeginTx(ctx, cloneID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer target.Rollback(ctx)
+
+	if err := target.Exec(ctx, "SET LOCAL statement_timeout = 0"); err != nil {
+		return nil, fmt.Errorf("failed to lift statement timeout: %w", err)
+	}
+
+	if err := target.QueryRow(ctx, "SELECT create_tenant($1, $2)", params.Name, cloneID).Scan(&cloneID); err != nil {
+		return nil, fmt.Errorf("failed to create tenant: %w", err)
+	}
+	err = target.Exec(ctx, "UPDATE tenants SET is_test = true, test_since = NOW(), cloned_from_tenant_id = $2 WHERE id = $1", cloneID, params.SourceTenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark test tenant: %w", err)
+	}
+	err = appendEvent(ctx, target, AggregateTenant, cloneID, EventTenantCreated, TenantCreatedPayload{
+		Name:       params.Name,
+		Status:     TenantStatusActive,
+		IsTest:     true,
+		ClonedFrom: &params.SourceTenantID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	books, err := cloneBooks(ctx, source, target)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := cloneAccounts(ctx, snapshot, target, books, params.Mode == CloneHistory)
+	if err != nil {
+		return nil, err
+	}
+	accountIDs := make(map[uuid.UUID]uuid.UUID, len(accounts))
+	for _, a := range accounts {
+		accountIDs[a.source.ID] = a.id
+	}
+
+	// Settings come before the entries so metadata is sealed as the source
+	// tenant's would be
+	if err := cloneSettings(ctx, snapshot, target, cloneID, accountIDs); err != nil {
+		return nil, err
+	}
+
+	clone := &TenantClone{Accounts: len(accounts)}
+	switch params.Mode {
+	case CloneBalances:
+		clone.JournalEntries, err = postOpeningBalances(ctx, snapshot, target, accounts, accountIDs)
+	case CloneHistory:
+		clone.JournalEntries, err = repostJournal(ctx, snapshot, target, accountIDs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Overdraft limits and deletions would reject the postings above, so
+	// they are copied last
+	for _, a := range accounts {
+		if a.source.OverdraftLimit == nil && a.source.DeletedAt == nil {
+			continue
+		}
+		err := target.Exec(ctx, "UPDATE accounts SET overdraft_limit = $2, deleted_at = $3 WHERE id = $1",
+			a.id, a.source.OverdraftLimit, a.source.DeletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update cloned account: %w", err)
+		}
+		if a.source.DeletedAt != nil {
+			if err := appendEvent(ctx, target, AggregateAccount, a.id, EventAccountDeleted, struct{}{}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	clone.Tenant = &Tenant{}
+	if err := scanTenant(target.QueryRow(ctx, "SELECT "+tenantColumns+" FROM tenants WHERE id = $1", cloneID), clone.Tenant); err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	if err := target.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return clone, nil
+}
+
+// cloneBooks copies the books of the source and maps each to its copy; the
+// default book maps to the one the clone was created with
+func cloneBooks(ctx context.Context, source, target *db.TenantTx) (map[uuid.UUID]uuid.UUID, error) {
+	rows, err := source.Query(ctx, "SELECT "+bookColumns+" FROM books ORDER BY is_default DESC, code")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list books: %w", err)
+	}
+	books, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*Book, error) {
+		book := &Book{}
+		return book, scanBook(row, book)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan book: %w", err)
+	}
+
+	ids := make(map[uuid.UUID]uuid.UUID, len(books))
+	for _, book := range books {
+		var id uuid.UUID
+		if book.IsDefault {
+			err = target.QueryRow(ctx, "SELECT id FROM books WHERE is_default").Scan(&id)
+		} else {
+			err = target.QueryRow(ctx, `
+				INSERT INTO books (tenant_id, code, name, description, is_default)
+				VALUES (current_setting('app.current_tenant_id')::uuid, $1, $2, $3, false)
+				RETURNING id
+			`, book.Code, book.Name, book.Description).Scan(&id)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to clone book %s: %w", book.Code, err)
+		}
+		ids[book.ID] = id
+	}
+
+	return ids, nil
+}
+
+// cloneAccounts copies the accounts of the source, parents before their
+// children, with zero balances. Deleted accounts are copied live, so entries
+// can still be reposted to them, when withDeleted is set and skipped
+// otherwise.
+func cloneAccounts(ctx context.Context, snapshot *tenantSnapshot, target *db.TenantTx, books map[uuid.UUID]uuid.UUID, withDeleted bool) ([]*clonedAccount, error) {
+	var pending []*Account
+	err := snapshot.Accounts(ctx, func(a *Account) error {
+		if a.DeletedAt == nil || withDeleted {
+			pending = append(pending, a)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[uuid.UUID]uuid.UUID, len(pending))
+	cloned := make([]*clonedAccount, 0, len(pending))
+	for len(pending) > 0 {
+		var waiting []*Account
+		for _, a := range pending {
+			var parentID *uuid.UUID
+			if a.ParentAccountID != nil {
+				id, ok := ids[*a.ParentAccountID]
+				if !ok {
+					waiting = append(waiting, a)
+					continue
+				}
+				parentID = &id
+			}
+
+			params := CreateAccountParams{
+				AccountNumber:   a.AccountNumber,
+				Name:            a.Name,
+				AccountTypeID:   a.AccountTypeID,
+				CurrencyCode:    a.CurrencyCode,
+				Description:     a.Description,
+				ParentAccountID: parentID,
+			}
+			bookID := books[a.BookID]
+
+			var id uuid.UUID
+			if target.SQLFunctions() {
+				id, err = callCreateAccount(ctx, target, params, bookID)
+			} else {
+				id, err = insertAccount(ctx, target, params, bookID)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !a.IsActive {
+				if err := target.Exec(ctx, "UPDATE accounts SET is_active = false WHERE id = $1", id); err != nil {
+					return nil, fmt.Errorf("failed to clone account: %w", err)
+				}
+			}
+
+			err = appendEvent(ctx, target, AggregateAccount, id, EventAccountCreated, AccountCreatedPayload{
+				BookID:          bookID,
+				AccountNumber:   params.AccountNumber,
+				Name:            params.Name,
+				AccountTypeID:   params.AccountTypeID,
+				CurrencyCode:    params.CurrencyCode,
+				Description:     params.Description,
+				ParentAccountID: params.ParentAccountID,
+				OverdraftLimit:  a.OverdraftLimit,
+				Inactive:        !a.IsActive,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := setAccountMappings(ctx, target, id, a.Labels, a.ExternalIDs); err != nil {
+				return nil, err
+			}
+
+			ids[a.ID] = id
+			cloned = append(cloned, &clonedAccount{source: a, id: id})
+		}
+
+		// A parent that was not copied leaves its children waiting forever
+		if len(waiting) == len(pending) {
+			return nil, fmt.Errorf("parent account %w", ErrNotFound)
+		}
+		pending = waiting
+	}
+
+	return cloned, nil
+}
+
+// cloneSettings copies the settings of the source, if it has any, with their
+// accounts mapped to the copies
+func cloneSettings(ctx context.Context, snapshot *tenantSnapshot, target *db.TenantTx, cloneID uuid.UUID, accountIDs map[uuid.UUID]uuid.UUID) error {
+	settings, err := snapshot.Settings(ctx)
+	if err != nil {
+		return err
+	}
+	if settings.UpdatedAt.IsZero() {
+		return nil
+	}
+
+	mapID := func(id *uuid.UUID) *uuid.UUID {
+		if id == nil {
+			return nil
+		}
+		mapped, ok := accountIDs[*id]
+		if !ok {
+			return nil
+		}
+		return &mapped
+	}
+
+	settings.TenantID = cloneID
+	settings.FxGainAccountID = mapID(settings.FxGainAccountID)
+	settings.FxLossAccountID = mapID(settings.FxLossAccountID)
+	conversion := make(map[string]uuid.UUID, len(settings.FxConversionAccounts))
+	for currency, id := range settings.FxConversionAccounts {
+		if mapped, ok := accountIDs[id]; ok {
+			conversion[currency] = mapped
+		}
+	}
+	settings.FxConversionAccounts = conversion
+
+	_, err = upsertTenantSettings(ctx, target, settings)
+	return err
+}
+
+// postOpeningBalances posts one entry per book that brings every copied
+// account to the net balance of its source account, and returns the number
+// of entries posted
+func postOpeningBalances(ctx context.Context, snapshot *tenantSnapshot, target *db.TenantTx, accounts []*clonedAccount, accountIDs map[uuid.UUID]uuid.UUID) (int, error) {
+	books := make(map[uuid.UUID]uuid.UUID, len(accounts))
+	for _, a := range accounts {
+		books[a.id] = a.source.BookID
+	}
+
+	lines := make(map[uuid.UUID][]*CreateJournalEntryLineParams)
+	var order []uuid.UUID
+	err := snapshot.Balances(ctx, func(b *AccountBalance) error {
+		id, ok := accountIDs[b.AccountID]
+		net := b.DebitBalance.Sub(b.CreditBalance)
+		if !ok || net.IsZero() {
+			return nil
+		}
+
+		line := &CreateJournalEntryLineParams{AccountID: id, Debit: decimal.Zero, Credit: decimal.Zero, Description: "Opening balance"}
+		if net.IsPositive() {
+			line.Debit = net
+		} else {
+			line.Credit = net.Neg()
+		}
+
+		book := books[id]
+		if _, ok := lines[book]; !ok {
+			order = append(order, book)
+		}
+		lines[book] = append(lines[book], line)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	entryDate := time.Now().UTC()
+	for i, book := range order {
+		_, err := insertJournalEntry(ctx, target, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("OPENING-%d", i+1),
+			Description:     "Opening balances cloned from tenant " + snapshot.tenantID.String(),
+			EntryDate:       entryDate,
+			Lines:           lines[book],
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to post opening balances: %w", err)
+		}
+	}
+
+	return len(order), nil
+}
+
+// repostJournal posts a copy of every journal entry of the source, oldest
+// first, and returns the number of entries posted
+func repostJournal(ctx context.Context, snapshot *tenantSnapshot, target *db.TenantTx, accountIDs map[uuid.UUID]uuid.UUID) (int, error) {
+	var posted int
+	err := snapshot.JournalEntries(ctx, func(entry *JournalEntry) error {
+		params := CreateJournalEntryParams{
+			ReferenceNumber:    entry.ReferenceNumber,
+			Description:        entry.Description,
+			EntryDate:          entry.EntryDate,
+			Metadata:           entry.Metadata,
+			LockOverrideReason: entry.LockOverrideReason,
+			CurrencyCode:       entry.CurrencyCode,
+			Lines:              make([]*CreateJournalEntryLineParams, len(entry.Lines)),
+		}
+		for i, line := range entry.Lines {
+			id, ok := accountIDs[line.AccountID]
+			if !ok {
+				return fmt.Errorf("account %w", ErrNotFound)
+			}
+			params.Lines[i] = &CreateJournalEntryLineParams{
+				AccountID:   id,
+				Debit:       line.Debit,
+				Credit:      line.Credit,
+				Description: line.Description,
+				Dimensions:  line.Dimensions,
+			}
+		}
+
+		if _, err := insertJournalEntry(ctx, target, params); err != nil {
+			return err
+		}
+		posted++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return posted, nil
+}
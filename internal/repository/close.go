@@ -0,0 +1,446 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Actions that complete close tasks
+const (
+	CloseActionManual           = "MANUAL"
+	CloseActionReconcileBank    = "RECONCILE_BANK"
+	CloseActionPostDepreciation = "POST_DEPRECIATION"
+	CloseActionLockPeriod       = "LOCK_PERIOD"
+)
+
+// Statuses of a close task
+const (
+	CloseTaskPending    = "PENDING"
+	CloseTaskInProgress = "IN_PROGRESS"
+	CloseTaskDone       = "DONE"
+	CloseTaskSkipped    = "SKIPPED"
+)
+
+// DefaultCloseTemplate is the close template of tenants that have not set
+// their own
+var DefaultCloseTemplate = []*CloseTaskTemplate{
+	{Code: "RECONCILE_BANK", Name: "Reconcile bank accounts", Action: CloseActionReconcileBank},
+	{Code: "POST_DEPRECIATION", Name: "Post depreciation", Action: CloseActionPostDepreciation},
+	{Code: "REVALUE_FX", Name: "Revalue foreign currency balances", Action: CloseActionManual},
+	{Code: "LOCK_PERIOD", Name: "Lock the period", Action: CloseActionLockPeriod},
+}
+
+// closeActionConditions are the conditions, on a checklist aliased c, under
+// which an action has run for the whole period of the checklist. They
+// require evidence of the action in the period, so a tenant without bank
+// statements or fixed assets completes those tasks by hand.
+var closeActionConditions = map[string]string{
+	CloseActionReconcileBank: `
+		EXISTS (SELECT 1 FROM bank_statement_lines
+		        WHERE posted_at BETWEEN c.period_start AND c.period_end)
+		AND NOT EXISTS (SELECT 1 FROM bank_statement_lines
+		                WHERE status = 'UNMATCHED' AND posted_at <= c.period_end)`,
+	CloseActionPostDepreciation: `
+		EXISTS (SELECT 1 FROM depreciation_schedule
+		        WHERE journal_entry_id IS NOT NULL AND period_date BETWEEN c.period_start AND c.period_end)
+		AND NOT EXISTS (SELECT 1 FROM depreciation_schedule
+		                WHERE journal_entry_id IS NULL AND period_date <= c.period_end)`,
+	CloseActionLockPeriod: `
+		EXISTS (SELECT 1 FROM posting_policies WHERE lock_date >= c.period_end)`,
+}
+
+// CloseTaskTemplate is a task every period close of a tenant starts with
+type CloseTaskTemplate struct {
+	Code   string
+	Name   string
+	Action string
+}
+
+// CloseChecklist tracks the tasks of closing a period
+type CloseChecklist struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Tasks       []*CloseTask
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+// CloseTask is a task of a close checklist
+type CloseTask struct {
+	ID                     uuid.UUID
+	ChecklistID            uuid.UUID
+	Code                   string
+	Name                   string
+	Action                 string
+	Status                 string
+	Note                   string
+	CompletedAutomatically bool
+	CompletedAt            *time.Time
+	UpdatedAt              time.Time
+}
+
+// CloseRepository handles period close database operations
+type CloseRepository struct {
+	db *db.DB
+}
+
+// NewCloseRepository creates a new close repository
+func NewCloseRepository(database *db.DB) *CloseRepository {
+	return &CloseRepository{db: database}
+}
+
+// GetTemplate retrieves the close template of a tenant, or the default
+// template when the tenant has not set one
+func (r *CloseRepository) GetTemplate(ctx context.Context, tenantID uuid.UUID) ([]*CloseTaskTemplate, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT code, name, action
+		FROM close_task_templates
+		ORDER BY position
+	`
+
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get close template: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := make([]*CloseTaskTemplate, 0)
+	for rows.Next() {
+		task := &CloseTaskTemplate{}
+		if err := rows.Scan(&task.Code, &task.Name, &task.Action); err != nil {
+			return nil, fmt.Errorf("failed to scan close task template: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get close template: %w", err)
+	}
+
+	if len(tasks) == 0 {
+		return DefaultCloseTemplate, nil
+	}
+
+	return tasks, nil
+}
+
+// SetTemplate replaces the close template of a tenant
+func (r *CloseRepository) SetTemplate(ctx context.Context, tenantID uuid.UUID, tasks []*CloseTaskTemplate) ([]*CloseTaskTemplate, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.Exec(ctx, "DELETE FROM close_task_templates WHERE tenant_id = $1", tenantID); err != nil {
+		return nil, fmt.Errorf("failed to clear close template: %w", err)
+	}
+
+	query := `
+		INSERT INTO close_task_templates (tenant_id, position, code, name, action)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	for i, task := range tasks {
+		if err := tx.Exec(ctx, query, tenantID, i, task.Code, task.Name, task.Action); err != nil {
+			return nil, fmt.Errorf("failed to insert close task template: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetTemplate(ctx, tenantID)
+}
+
+// Start creates the checklist of a period from the tenant's close template.
+// Tasks whose action has already run for the period start out done.
+func (r *CloseRepository) Start(ctx context.Context, tenantID uuid.UUID, periodStart, periodEnd time.Time) (*CloseChecklist, error) {
+	template, err := r.GetTemplate(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var checklistID uuid.UUID
+	query := `
+		INSERT INTO close_checklists (tenant_id, period_start, period_end)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	if err := tx.QueryRow(ctx, query, tenantID, periodStart, periodEnd).Scan(&checklistID); err != nil {
+		return nil, fmt.Errorf("failed to create close checklist: %w", err)
+	}
+
+	taskQuery := `
+		INSERT INTO close_tasks (tenant_id, checklist_id, position, code, name, action, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	for i, task := range template {
+		if err := tx.Exec(ctx, taskQuery, tenantID, checklistID, i, task.Code, task.Name, task.Action, CloseTaskPending); err != nil {
+			return nil, fmt.Errorf("failed to create close task: %w", err)
+		}
+	}
+
+	for action := range closeActionConditions {
+		if err := completeCloseTasks(ctx, tx, action); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetByID(ctx, tenantID, checklistID)
+}
+
+// GetByID retrieves a close checklist with its tasks
+func (r *CloseRepository) GetByID(ctx context.Context, tenantID uuid.UUID, checklistID uuid.UUID) (*CloseChecklist, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	checklist := &CloseChecklist{}
+	query := `
+		SELECT id, tenant_id, period_start, period_end, created_at, completed_at
+		FROM close_checklists
+		WHERE id = $1
+	`
+
+	err = conn.QueryRow(ctx, query, checklistID).Scan(
+		&checklist.ID,
+		&checklist.TenantID,
+		&checklist.PeriodStart,
+		&checklist.PeriodEnd,
+		&checklist.CreatedAt,
+		&checklist.CompletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("close checklist %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get close checklist: %w", err)
+	}
+
+	tasks, err := r.getTasks(ctx, conn, checklistID)
+	if err != nil {
+		return nil, err
+	}
+	checklist.Tasks = tasks
+
+	return checklist, nil
+}
+
+// List retrieves close checklists without their tasks, latest period first
+func (r *CloseRepository) List(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*CloseChecklist, int, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	var totalCount int
+	if err := conn.QueryRow(ctx, "SELECT COUNT(*) FROM close_checklists").Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count close checklists: %w", err)
+	}
+
+	query := `
+		SELECT id, tenant_id, period_start, period_end, created_at, completed_at
+		FROM close_checklists
+		ORDER BY period_end DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := conn.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list close checklists: %w", err)
+	}
+	defer rows.Close()
+
+	checklists := make([]*CloseChecklist, 0)
+	for rows.Next() {
+		checklist := &CloseChecklist{}
+		err := rows.Scan(
+			&checklist.ID,
+			&checklist.TenantID,
+			&checklist.PeriodStart,
+			&checklist.PeriodEnd,
+			&checklist.CreatedAt,
+			&checklist.CompletedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan close checklist: %w", err)
+		}
+		checklists = append(checklists, checklist)
+	}
+
+	return checklists, totalCount, nil
+}
+
+// UpdateTask sets the status of a close task, and its note when one is
+// given, and returns the task's checklist
+func (r *CloseRepository) UpdateTask(ctx context.Context, tenantID uuid.UUID, taskID uuid.UUID, status string, note *string) (*CloseChecklist, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var checklistID uuid.UUID
+	query := `
+		UPDATE close_tasks
+		SET status = $2,
+		    note = COALESCE($3, note),
+		    completed_automatically = FALSE,
+		    completed_at = CASE WHEN $2 IN ('DONE', 'SKIPPED') THEN NOW() END,
+		    updated_at = NOW()
+		WHERE id = $1
+		RETURNING checklist_id
+	`
+
+	if err := tx.QueryRow(ctx, query, taskID, status, note).Scan(&checklistID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("close task %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update close task: %w", err)
+	}
+
+	if err := refreshCloseChecklists(ctx, tx, []uuid.UUID{checklistID}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetByID(ctx, tenantID, checklistID)
+}
+
+// getTasks retrieves the tasks of a checklist in template order
+func (r *CloseRepository) getTasks(ctx context.Context, conn *pgxpool.Conn, checklistID uuid.UUID) ([]*CloseTask, error) {
+	query := `
+		SELECT id, checklist_id, code, name, action, status, note, completed_automatically,
+		       completed_at, updated_at
+		FROM close_tasks
+		WHERE checklist_id = $1
+		ORDER BY position
+	`
+
+	rows, err := conn.Query(ctx, query, checklistID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get close tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := make([]*CloseTask, 0)
+	for rows.Next() {
+		task := &CloseTask{}
+		err := rows.Scan(
+			&task.ID,
+			&task.ChecklistID,
+			&task.Code,
+			&task.Name,
+			&task.Action,
+			&task.Status,
+			&task.Note,
+			&task.CompletedAutomatically,
+			&task.CompletedAt,
+			&task.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan close task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// completeCloseTasks marks done the open tasks of an action in every
+// checklist of the tenant whose period the action has now run for. It runs
+// in the transaction of the action, so the checklist changes with the data
+// it describes. Tasks are not reopened when the action is undone.
+func completeCloseTasks(ctx context.Context, tx *db.TenantTx, action string) error {
+	query := `
+		UPDATE close_tasks t
+		SET status = 'DONE', completed_automatically = TRUE, completed_at = NOW(), updated_at = NOW()
+		FROM close_checklists c
+		WHERE c.id = t.checklist_id
+		  AND t.action = $1
+		  AND t.status IN ('PENDING', 'IN_PROGRESS')
+		  AND ` + closeActionConditions[action] + `
+		RETURNING t.checklist_id
+	`
+
+	rows, err := tx.Query(ctx, query, action)
+	if err != nil {
+		return fmt.Errorf("failed to complete close tasks: %w", err)
+	}
+	defer rows.Close()
+
+	checklistIDs := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var checklistID uuid.UUID
+		if err := rows.Scan(&checklistID); err != nil {
+			return fmt.Errorf("failed to scan completed close task: %w", err)
+		}
+		checklistIDs = append(checklistIDs, checklistID)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to complete close tasks: %w", err)
+	}
+	rows.Close()
+
+	if len(checklistIDs) == 0 {
+		return nil
+	}
+
+	return refreshCloseChecklists(ctx, tx, checklistIDs)
+}
+
+// refreshCloseChecklists sets the completion time of checklists whose tasks
+// are all done or skipped, and clears it from those with open tasks
+func refreshCloseChecklists(ctx context.Context, tx *db.TenantTx, checklistIDs []uuid.UUID) error {
+	query := `
+		UPDATE close_checklists c
+		SET completed_at = CASE
+		        WHEN EXISTS (
+		            SELECT 1 FROM close_tasks t
+		            WHERE t.checklist_id = c.id AND t.status IN ('PENDING', 'IN_PROGRESS')
+		        ) THEN NULL
+		        ELSE COALESCE(c.completed_at, NOW())
+		    END
+		WHERE c.id = ANY($1)
+	`
+
+	if err := tx.Exec(ctx, query, checklistIDs); err != nil {
+		return fmt.Errorf("failed to update close checklists: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/shopspring/decimal"
+)
+
+// Ledger consistency checks
+const (
+	// CheckDebitsEqualCredits asserts that debits equal credits, both in total
+	// and within every journal entry
+	CheckDebitsEqualCredits = "DEBITS_EQUAL_CREDITS"
+	// CheckAccountBalances asserts that stored balances match the sums of the
+	// account's journal lines
+	CheckAccountBalances = "ACCOUNT_BALANCES"
+	// CheckOrphanLines asserts that every journal line belongs to an existing
+	// journal entry and account
+	CheckOrphanLines = "ORPHAN_LINES"
+	// CheckEventProjection asserts that stored balances match the balances
+	// projected by replaying the event store. It is run by the service, which
+	// owns the projections, when the event store is enabled.
+	CheckEventProjection = "EVENT_PROJECTION"
+)
+
+// ConsistencyChecks lists every check run by a consistency check
+var ConsistencyChecks = []string{CheckDebitsEqualCredits, CheckAccountBalances, CheckOrphanLines}
+
+// MaxViolationsPerCheck caps the violations reported for a single check
+const MaxViolationsPerCheck = 100
+
+// ConsistencyViolation is a broken ledger invariant
+type ConsistencyViolation struct {
+	Check          string
+	JournalEntryID *uuid.UUID
+	JournalLineID  *uuid.UUID
+	AccountID      *uuid.UUID
+	Detail         string
+}
+
+// ConsistencyReport is the outcome of checking a tenant's ledger invariants
+type ConsistencyReport struct {
+	TenantID    uuid.UUID
+	CheckedAt   time.Time
+	TotalDebit  decimal.Decimal
+	TotalCredit decimal.Decimal
+	Violations  []*ConsistencyViolation
+}
+
+// Consistent reports whether every invariant holds
+func (r *ConsistencyReport) Consistent() bool {
+	return len(r.Violations) == 0
+}
+
+// BalanceVerification is the outcome of verifying a tenant's balances
+// against each other and against its journal lines
+type BalanceVerification struct {
+	TenantID         uuid.UUID
+	VerifiedAt       time.Time
+	AccountsVerified int
+	// BalanceDebit and BalanceCredit sum the stored balances of all accounts
+	BalanceDebit  decimal.Decimal
+	BalanceCredit decimal.Decimal
+	// LineDebit and LineCredit sum all journal lines
+	LineDebit  decimal.Decimal
+	LineCredit decimal.Decimal
+	// Discrepancies lists accounts whose stored balance differs from the
+	// sums of their lines, at most MaxViolationsPerCheck
+	Discrepancies []*BalanceDiscrepancy
+}
+
+// BalancesEqual reports whether debit balances equal credit balances
+func (v *BalanceVerification) BalancesEqual() bool {
+	return v.BalanceDebit.Equal(v.BalanceCredit)
+}
+
+// BalancesMatchLines reports whether the balance totals equal the line totals
+func (v *BalanceVerification) BalancesMatchLines() bool {
+	return v.BalanceDebit.Equal(v.LineDebit) && v.BalanceCredit.Equal(v.LineCredit)
+}
+
+// Verified reports whether every balance check passed
+func (v *BalanceVerification) Verified() bool {
+	return v.BalancesEqual() && v.BalancesMatchLines() && len(v.Discrepancies) == 0
+}
+
+// ConsistencyRepository checks ledger invariants
+type ConsistencyRepository struct {
+	db *db.DB
+}
+
+// NewConsistencyRepository creates a new consistency repository
+func NewConsistencyRepository(database *db.DB) *ConsistencyRepository {
+	return &ConsistencyRepository{db: database}
+}
+
+// Check runs every consistency check for a tenant. Each check is a single
+// statement, so it sees a consistent snapshot even while entries are posted.
+// At most MaxViolationsPerCheck violations are reported per check.
+func (r *ConsistencyRepository) Check(ctx context.Context, tenantID uuid.UUID) (*ConsistencyReport, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	report := &ConsistencyReport{
+		TenantID:   tenantID,
+		CheckedAt:  time.Now(),
+		Violations: make([]*ConsistencyViolation, 0),
+	}
+
+	err = conn.QueryRow(ctx, `
+		SELECT COALESCE(SUM(debit), 0), COALESCE(SUM(credit), 0)
+		FROM journal_entry_lines
+	`).Scan(&report.TotalDebit, &report.TotalCredit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum journal lines: %w", err)
+	}
+
+	if !report.TotalDebit.Equal(report.TotalCredit) {
+		report.Violations = append(report.Violations, &ConsistencyViolation{
+			Check:  CheckDebitsEqualCredits,
+			Detail: fmt.Sprintf("total debits %s do not equal total credits %s", report.TotalDebit, report.TotalCredit),
+		})
+	}
+
+	// Unbalanced entries
+	rows, err := conn.Query(ctx, `
+		SELECT journal_entry_id, SUM(debit), SUM(credit)
+		FROM journal_entry_lines
+		GROUP BY journal_entry_id
+		HAVING SUM(debit) <> SUM(credit)
+		ORDER BY journal_entry_id
+		LIMIT $1
+	`, MaxViolationsPerCheck)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check journal entries: %w", err)
+	}
+	for rows.Next() {
+		var entryID uuid.UUID
+		var debit, credit decimal.Decimal
+		if err := rows.Scan(&entryID, &debit, &credit); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan journal entry totals: %w", err)
+		}
+		report.Violations = append(report.Violations, &ConsistencyViolation{
+			Check:          CheckDebitsEqualCredits,
+			JournalEntryID: &entryID,
+			Detail:         fmt.Sprintf("entry debits %s do not equal credits %s", debit, credit),
+		})
+	}
+	rows.Close()
+
+	// Balances that drifted from their lines
+	rows, err = conn.Query(ctx, `
+		SELECT a.id,
+		       COALESCE(l.debit, 0), COALESCE(l.credit, 0),
+		       COALESCE(b.debit_balance, 0), COALESCE(b.credit_balance, 0)
+		FROM accounts a
+		LEFT JOIN (
+			SELECT account_id, SUM(debit) AS debit, SUM(credit) AS credit
+			FROM journal_entry_lines
+			GROUP BY account_id
+		) l ON l.account_id = a.id
+		LEFT JOIN account_balances b ON b.account_id = a.id
+		WHERE COALESCE(l.debit, 0) <> COALESCE(b.debit_balance, 0)
+		   OR COALESCE(l.credit, 0) <> COALESCE(b.credit_balance, 0)
+		ORDER BY a.account_number
+		LIMIT $1
+	`, MaxViolationsPerCheck)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check account balances: %w", err)
+	}
+	for rows.Next() {
+		var accountID uuid.UUID
+		var lineDebit, lineCredit, storedDebit, storedCredit decimal.Decimal
+		if err := rows.Scan(&accountID, &lineDebit, &lineCredit, &storedDebit, &storedCredit); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan account balance: %w", err)
+		}
+		report.Violations = append(report.Violations, &ConsistencyViolation{
+			Check:     CheckAccountBalances,
+			AccountID: &accountID,
+			Detail: fmt.Sprintf("stored balance %s/%s does not match line sums %s/%s",
+				storedDebit, storedCredit, lineDebit, lineCredit),
+		})
+	}
+	rows.Close()
+
+	// Lines without an entry or account
+	rows, err = conn.Query(ctx, `
+		SELECT jel.id, jel.journal_entry_id, jel.account_id, je.id IS NULL, a.id IS NULL
+		FROM journal_entry_lines jel
+		LEFT JOIN journal_entries je ON je.id = jel.journal_entry_id
+		LEFT JOIN accounts a ON a.id = jel.account_id
+		WHERE je.id IS NULL OR a.id IS NULL
+		ORDER BY jel.id
+		LIMIT $1
+	`, MaxViolationsPerCheck)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check journal lines: %w", err)
+	}
+	for rows.Next() {
+		var lineID, entryID, accountID uuid.UUID
+		var missingEntry, missingAccount bool
+		if err := rows.Scan(&lineID, &entryID, &accountID, &missingEntry, &missingAccount); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan journal line: %w", err)
+		}
+
+		violation := &ConsistencyViolation{
+			Check:          CheckOrphanLines,
+			JournalLineID:  &lineID,
+			JournalEntryID: &entryID,
+			AccountID:      &accountID,
+		}
+		switch {
+		case missingEntry && missingAccount:
+			violation.Detail = "line references a missing journal entry and account"
+		case missingEntry:
+			violation.Detail = "line references a missing journal entry"
+		default:
+			violation.Detail = "line references a missing account"
+		}
+		report.Violations = append(report.Violations, violation)
+	}
+	rows.Close()
+
+	return report, nil
+}
+
+// VerifyBalances verifies a tenant's stored balances: debit balances must
+// equal credit balances and the line totals, and every account's balance the
+// sums of its lines. Totals and discrepancies come from a single statement,
+// so they describe one snapshot even while entries are posted.
+func (r *ConsistencyRepository) VerifyBalances(ctx context.Context, tenantID uuid.UUID) (*BalanceVerification, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		WITH lines AS (
+			SELECT account_id, SUM(debit) AS debit, SUM(credit) AS credit
+			FROM journal_entry_lines
+			GROUP BY account_id
+		), per_account AS (
+			SELECT a.id, a.account_number,
+			       COALESCE(l.debit, 0) AS line_debit, COALESCE(l.credit, 0) AS line_credit,
+			       COALESCE(b.debit_balance, 0) AS stored_debit, COALESCE(b.credit_balance, 0) AS stored_credit,
+			       b.account_id IS NULL AS missing
+			FROM accounts a
+			LEFT JOIN lines l ON l.account_id = a.id
+			LEFT JOIN account_balances b ON b.account_id = a.id
+		)
+		SELECT t.accounts, t.stored_debit, t.stored_credit, lt.debit, lt.credit,
+		       d.id, d.stored_debit, d.stored_credit, d.line_debit, d.line_credit, d.missing
+		FROM (
+			SELECT COUNT(*) AS accounts,
+			       COALESCE(SUM(stored_debit), 0) AS stored_debit, COALESCE(SUM(stored_credit), 0) AS stored_credit
+			FROM per_account
+		) t
+		CROSS JOIN (
+			SELECT COALESCE(SUM(debit), 0) AS debit, COALESCE(SUM(credit), 0) AS credit
+			FROM lines
+		) lt
+		LEFT JOIN LATERAL (
+			SELECT *
+			FROM per_account
+			WHERE missing OR line_debit <> stored_debit OR line_credit <> stored_credit
+			ORDER BY account_number
+			LIMIT $1
+		) d ON true
+	`
+
+	rows, err := conn.Query(ctx, query, MaxViolationsPerCheck)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify account balances: %w", err)
+	}
+	defer rows.Close()
+
+	verification := &BalanceVerification{
+		TenantID:      tenantID,
+		VerifiedAt:    time.Now(),
+		Discrepancies: make([]*BalanceDiscrepancy, 0),
+	}
+	for rows.Next() {
+		var accountID *uuid.UUID
+		var storedDebit, storedCredit, lineDebit, lineCredit decimal.NullDecimal
+		var missing *bool
+		err := rows.Scan(
+			&verification.AccountsVerified,
+			&verification.BalanceDebit,
+			&verification.BalanceCredit,
+			&verification.LineDebit,
+			&verification.LineCredit,
+			&accountID,
+			&storedDebit,
+			&storedCredit,
+			&lineDebit,
+			&lineCredit,
+			&missing,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan balance verification: %w", err)
+		}
+
+		// Without discrepancies the single row carries only the totals
+		if accountID == nil {
+			continue
+		}
+		verification.Discrepancies = append(verification.Discrepancies, &BalanceDiscrepancy{
+			AccountID:      *accountID,
+			StoredDebit:    storedDebit.Decimal,
+			StoredCredit:   storedCredit.Decimal,
+			ComputedDebit:  lineDebit.Decimal,
+			ComputedCredit: lineCredit.Decimal,
+			Missing:        *missing,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to verify account balances: %w", err)
+	}
+
+	return verification, nil
+}
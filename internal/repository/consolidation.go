@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// ConsolidationGroup represents a set of tenants reported on together
+type ConsolidationGroup struct {
+	ID                  uuid.UUID
+	Name                string
+	ReportingCurrency   string
+	EliminationTenantID *uuid.UUID
+	MemberTenantIDs     []uuid.UUID
+	// TestTenantIDs lists the members and elimination tenant in test mode,
+	// which consolidated reports leave out
+	TestTenantIDs []uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// ConsolidationGroupParams holds parameters for creating or updating a consolidation group
+type ConsolidationGroupParams struct {
+	Name                string
+	ReportingCurrency   string
+	EliminationTenantID *uuid.UUID
+	MemberTenantIDs     []uuid.UUID
+}
+
+// ConsolidationRepository handles consolidation group database operations.
+// Groups span tenants, so they are stored outside of row-level security.
+type ConsolidationRepository struct {
+	db *db.DB
+}
+
+// NewConsolidationRepository creates a new consolidation repository
+func NewConsolidationRepository(database *db.DB) *ConsolidationRepository {
+	return &ConsolidationRepository{db: database}
+}
+
+// CreateGroup creates a consolidation group with its members
+func (r *ConsolidationRepository) CreateGroup(ctx context.Context, params ConsolidationGroupParams) (*ConsolidationGroup, error) {
+	tx, err := r.db.Pool().Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var groupID uuid.UUID
+	query := `
+		INSERT INTO consolidation_groups (name, reporting_currency, elimination_tenant_id)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	err = tx.QueryRow(ctx, query, params.Name, params.ReportingCurrency, params.EliminationTenantID).Scan(&groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consolidation group: %w", err)
+	}
+
+	if err := insertGroupMembers(ctx, tx, groupID, params.MemberTenantIDs); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetGroup(ctx, groupID)
+}
+
+// UpdateGroup replaces the settings and members of a consolidation group
+func (r *ConsolidationRepository) UpdateGroup(ctx context.Context, groupID uuid.UUID, params ConsolidationGroupParams) (*ConsolidationGroup, error) {
+	tx, err := r.db.Pool().Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE consolidation_groups
+		SET name = $2, reporting_currency = $3, elimination_tenant_id = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	tag, err := tx.Exec(ctx, query, groupID, params.Name, params.ReportingCurrency, params.EliminationTenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update consolidation group: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, fmt.Errorf("consolidation group %w", ErrNotFound)
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM consolidation_group_members WHERE group_id = $1", groupID); err != nil {
+		return nil, fmt.Errorf("failed to clear group members: %w", err)
+	}
+
+	if err := insertGroupMembers(ctx, tx, groupID, params.MemberTenantIDs); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetGroup(ctx, groupID)
+}
+
+// GetGroup retrieves a consolidation group with its members and which of
+// its tenants are in test mode
+func (r *ConsolidationRepository) GetGroup(ctx context.Context, groupID uuid.UUID) (*ConsolidationGroup, error) {
+	group := &ConsolidationGroup{}
+	query := `
+		SELECT g.id, g.name, g.reporting_currency, g.elimination_tenant_id, g.created_at, g.updated_at,
+			COALESCE(t.is_test, false)
+		FROM consolidation_groups g
+		LEFT JOIN tenants t ON t.id = g.elimination_tenant_id
+		WHERE g.id = $1
+	`
+
+	var eliminationIsTest bool
+	err := r.db.Pool().QueryRow(ctx, query, groupID).Scan(
+		&group.ID,
+		&group.Name,
+		&group.ReportingCurrency,
+		&group.EliminationTenantID,
+		&group.CreatedAt,
+		&group.UpdatedAt,
+		&eliminationIsTest,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("consolidation group %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get consolidation group: %w", err)
+	}
+
+	rows, err := r.db.Pool().Query(ctx, `
+		SELECT m.tenant_id, t.is_test
+		FROM consolidation_group_members m
+		JOIN tenants t ON t.id = m.tenant_id
+		WHERE m.group_id = $1
+		ORDER BY m.tenant_id
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group members: %w", err)
+	}
+	defer rows.Close()
+
+	group.MemberTenantIDs = make([]uuid.UUID, 0)
+	group.TestTenantIDs = make([]uuid.UUID, 0)
+	for rows.Next() {
+		var tenantID uuid.UUID
+		var isTest bool
+		if err := rows.Scan(&tenantID, &isTest); err != nil {
+			return nil, fmt.Errorf("failed to scan group member: %w", err)
+		}
+		group.MemberTenantIDs = append(group.MemberTenantIDs, tenantID)
+		if isTest {
+			group.TestTenantIDs = append(group.TestTenantIDs, tenantID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list group members: %w", err)
+	}
+	if eliminationIsTest {
+		group.TestTenantIDs = append(group.TestTenantIDs, *group.EliminationTenantID)
+	}
+
+	return group, nil
+}
+
+func insertGroupMembers(ctx context.Context, tx pgx.Tx, groupID uuid.UUID, tenantIDs []uuid.UUID) error {
+	for _, tenantID := range tenantIDs {
+		_, err := tx.Exec(ctx,
+			"INSERT INTO consolidation_group_members (group_id, tenant_id) VALUES ($1, $2)",
+			groupID, tenantID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add group member: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// AggregateDailyDigest is the aggregate type of daily digest events
+const AggregateDailyDigest = "DAILY_DIGEST"
+
+// EventDailyDigestComputed is recorded when a published daily digest is computed
+const EventDailyDigestComputed = "DailyDigestComputed"
+
+// DailyDigest is the Merkle root over the hashes of the journal entries a
+// tenant chained during a UTC day. Digests cover consecutive ranges of the
+// hash chain: each starts after the last entry of the digest before it and
+// ends with the last entry created before the end of its day, so an entry
+// committed just after midnight by a posting started earlier falls into the
+// next digest rather than none. A tenant's first digest covers every entry
+// chained until the end of its day.
+type DailyDigest struct {
+	ID         uuid.UUID
+	TenantID   uuid.UUID
+	DigestDate time.Time
+	MerkleRoot []byte
+	EntryCount int
+	// Chain sequence of the last entry covered, carried over from the
+	// previous digest on a day without entries
+	LastSequence int64
+	Published    bool
+	CreatedAt    time.Time
+}
+
+// DailyDigestComputedPayload is the payload of a DailyDigestComputed event
+type DailyDigestComputedPayload struct {
+	DigestDate   string `json:"digest_date"`
+	MerkleRoot   []byte `json:"merkle_root"`
+	EntryCount   int    `json:"entry_count"`
+	LastSequence int64  `json:"last_sequence"`
+}
+
+// MerkleRoot returns the Merkle tree hash of RFC 6962 over leaves: a leaf is
+// SHA-256(0x00 || leaf), an interior node SHA-256(0x01 || left || right),
+// and a tree of n leaves splits after the largest power of two below n. The
+// root of no leaves is SHA-256 of the empty string.
+func MerkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	}
+	if len(leaves) == 1 {
+		sum := sha256.Sum256(append([]byte{0x00}, leaves[0]...))
+		return sum[:]
+	}
+
+	split := 1
+	for split*2 < len(leaves) {
+		split *= 2
+	}
+
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(MerkleRoot(leaves[:split]))
+	h.Write(MerkleRoot(leaves[split:]))
+	return h.Sum(nil)
+}
+
+const dailyDigestColumns = `id, tenant_id, digest_date, merkle_root, entry_count, last_sequence, published, created_at`
+
+func scanDailyDigest(row pgx.Row, digest *DailyDigest) error {
+	return row.Scan(
+		&digest.ID,
+		&digest.TenantID,
+		&digest.DigestDate,
+		&digest.MerkleRoot,
+		&digest.EntryCount,
+		&digest.LastSequence,
+		&digest.Published,
+		&digest.CreatedAt,
+	)
+}
+
+// DigestRepository handles daily digest database operations
+type DigestRepository struct {
+	db *db.DB
+}
+
+// NewDigestRepository creates a new digest repository
+func NewDigestRepository(database *db.DB) *DigestRepository {
+	return &DigestRepository{db: database}
+}
+
+// Get retrieves the digest of a tenant for a UTC day
+func (r *DigestRepository) Get(ctx context.Context, tenantID uuid.UUID, date time.Time) (*DailyDigest, error) {
+	return r.get(ctx, tenantID, "WHERE digest_date = $1", utcDay(date))
+}
+
+// Latest retrieves the most recent digest of a tenant
+func (r *DigestRepository) Latest(ctx context.Context, tenantID uuid.UUID) (*DailyDigest, error) {
+	return r.get(ctx, tenantID, "ORDER BY digest_date DESC LIMIT 1")
+}
+
+func (r *DigestRepository) get(ctx context.Context, tenantID uuid.UUID, clause string, args ...interface{}) (*DailyDigest, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	digest := &DailyDigest{}
+	err = scanDailyDigest(conn.QueryRow(ctx, `SELECT `+dailyDigestColumns+` FROM digests `+clause, args...), digest)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("daily digest %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get daily digest: %w", err)
+	}
+
+	return digest, nil
+}
+
+// Compute computes and stores the digest of a tenant for a completed UTC
+// day, appending a DailyDigestComputed event when publish is set. A day that
+// already has a digest returns it unchanged; days must be computed in order,
+// so a day before the latest digest that has none cannot be computed.
+func (r *DigestRepository) Compute(ctx context.Context, tenantID uuid.UUID, date time.Time, publish bool) (*DailyDigest, error) {
+	day := utcDay(date)
+
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Keep postings out while the chain is read
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	latest := &DailyDigest{}
+	err = scanDailyDigest(tx.QueryRow(ctx, `SELECT `+dailyDigestColumns+` FROM digests ORDER BY digest_date DESC LIMIT 1`), latest)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		latest = nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get latest daily digest: %w", err)
+	case latest.DigestDate.Equal(day):
+		return latest, nil
+	case latest.DigestDate.After(day):
+		return nil, fmt.Errorf("daily digest of %s precedes the latest digest of %s",
+			day.Format("2006-01-02"), latest.DigestDate.Format("2006-01-02"))
+	}
+
+	digest := &DailyDigest{TenantID: tenantID, DigestDate: day, Published: publish}
+	if latest != nil {
+		digest.LastSequence = latest.LastSequence
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT chain_sequence, entry_hash
+		FROM journal_entries
+		WHERE chain_sequence > $1 AND created_at < $2
+		ORDER BY chain_sequence
+	`, digest.LastSequence, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal chain: %w", err)
+	}
+
+	var leaves [][]byte
+	for rows.Next() {
+		var hash []byte
+		if err := rows.Scan(&digest.LastSequence, &hash); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan journal chain: %w", err)
+		}
+		leaves = append(leaves, hash)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal chain: %w", err)
+	}
+
+	digest.MerkleRoot = MerkleRoot(leaves)
+	digest.EntryCount = len(leaves)
+
+	query := `
+		INSERT INTO digests (tenant_id, digest_date, merkle_root, entry_count, last_sequence, published)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRow(ctx, query,
+		tenantID,
+		day,
+		digest.MerkleRoot,
+		digest.EntryCount,
+		digest.LastSequence,
+		digest.Published,
+	).Scan(&digest.ID, &digest.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store daily digest: %w", err)
+	}
+
+	if publish {
+		err = appendEvent(ctx, tx, AggregateDailyDigest, digest.ID, EventDailyDigestComputed, DailyDigestComputedPayload{
+			DigestDate:   day.Format("2006-01-02"),
+			MerkleRoot:   digest.MerkleRoot,
+			EntryCount:   digest.EntryCount,
+			LastSequence: digest.LastSequence,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return digest, nil
+}
+
+// utcDay truncates a time to the start of its UTC day
+func utcDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
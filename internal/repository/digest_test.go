@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerkleRoot(t *testing.T) {
+	leaf := func(b byte) []byte { return []byte{b} }
+	hashLeaf := func(b byte) []byte {
+		sum := sha256.Sum256([]byte{0x00, b})
+		return sum[:]
+	}
+	node := func(left, right []byte) []byte {
+		sum := sha256.Sum256(append(append([]byte{0x01}, left...), right...))
+		return sum[:]
+	}
+
+	t.Run("hashes the empty string without leaves", func(t *testing.T) {
+		assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", hex.EncodeToString(MerkleRoot(nil)))
+	})
+
+	t.Run("hashes a single leaf with the leaf prefix", func(t *testing.T) {
+		assert.Equal(t, hashLeaf(1), MerkleRoot([][]byte{leaf(1)}))
+	})
+
+	t.Run("splits after the largest power of two", func(t *testing.T) {
+		leaves := [][]byte{leaf(1), leaf(2), leaf(3)}
+		want := node(node(hashLeaf(1), hashLeaf(2)), hashLeaf(3))
+
+		assert.Equal(t, want, MerkleRoot(leaves))
+	})
+
+	t.Run("changes when a leaf changes", func(t *testing.T) {
+		leaves := [][]byte{leaf(1), leaf(2), leaf(3), leaf(4), leaf(5)}
+		root := MerkleRoot(leaves)
+
+		leaves[4] = leaf(6)
+		assert.NotEqual(t, root, MerkleRoot(leaves))
+	})
+}
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// Dimension is a tenant-defined analysis dimension, such as a cost center or
+// project, that journal lines can be tagged with. Lines refer to it by code.
+type Dimension struct {
+	ID       uuid.UUID
+	TenantID uuid.UUID
+	Code     string
+	Name     string
+	// AllowedValues restricts the values lines may use; empty accepts any
+	AllowedValues []string
+	IsActive      bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Allows reports whether lines may be tagged with value
+func (d *Dimension) Allows(value string) bool {
+	if len(d.AllowedValues) == 0 {
+		return true
+	}
+	for _, allowed := range d.AllowedValues {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+// DimensionParams holds parameters for creating or updating a dimension
+type DimensionParams struct {
+	Code          string
+	Name          string
+	AllowedValues []string
+	IsActive      bool
+}
+
+// DimensionBalanceFilter selects the lines summed by GetBalances
+type DimensionBalanceFilter struct {
+	// GroupBy lists the dimension codes balances are broken down by
+	GroupBy []string
+	// BookID selects the book; nil selects the default book of the tenant
+	BookID    *uuid.UUID
+	AccountID *uuid.UUID
+	FromDate  *time.Time
+	ToDate    *time.Time
+}
+
+// DimensionBalance sums the lines of an account sharing the same values for
+// the grouped dimensions. Values holds only the dimensions the lines were
+// tagged with; a missing code groups the untagged lines.
+type DimensionBalance struct {
+	AccountID       uuid.UUID
+	AccountNumber   string
+	AccountName     string
+	AccountTypeCode string
+	CurrencyCode    string
+	Values          map[string]string
+	Debit           decimal.Decimal
+	Credit          decimal.Decimal
+}
+
+// Balance returns debits less credits
+func (b *DimensionBalance) Balance() decimal.Decimal {
+	return b.Debit.Sub(b.Credit)
+}
+
+const dimensionColumns = `id, tenant_id, code, name, allowed_values, is_active, created_at, updated_at`
+
+func scanDimension(row pgx.Row, dimension *Dimension) error {
+	return row.Scan(
+		&dimension.ID,
+		&dimension.TenantID,
+		&dimension.Code,
+		&dimension.Name,
+		&dimension.AllowedValues,
+		&dimension.IsActive,
+		&dimension.CreatedAt,
+		&dimension.UpdatedAt,
+	)
+}
+
+// DimensionRepository handles dimension database operations
+type DimensionRepository struct {
+	db *db.DB
+}
+
+// NewDimensionRepository creates a new dimension repository
+func NewDimensionRepository(database *db.DB) *DimensionRepository {
+	return &DimensionRepository{db: database}
+}
+
+// Create creates an active dimension
+func (r *DimensionRepository) Create(ctx context.Context, tenantID uuid.UUID, params DimensionParams) (*Dimension, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	dimension := &Dimension{}
+	query := `
+		INSERT INTO dimensions (tenant_id, code, name, allowed_values, is_active)
+		VALUES ($1, $2, $3, $4, true)
+		RETURNING ` + dimensionColumns
+
+	row := tx.QueryRow(ctx, query, tenantID, params.Code, params.Name, params.AllowedValues)
+	if err := scanDimension(row, dimension); err != nil {
+		return nil, fmt.Errorf("failed to create dimension: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return dimension, nil
+}
+
+// Update replaces the settings of a dimension. Lines already posted keep
+// their values, even ones no longer allowed.
+func (r *DimensionRepository) Update(ctx context.Context, tenantID uuid.UUID, dimensionID uuid.UUID, params DimensionParams) (*Dimension, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	dimension := &Dimension{}
+	query := `
+		UPDATE dimensions
+		SET code = $2, name = $3, allowed_values = $4, is_active = $5, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + dimensionColumns
+
+	row := tx.QueryRow(ctx, query, dimensionID, params.Code, params.Name, params.AllowedValues, params.IsActive)
+	if err := scanDimension(row, dimension); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("dimension %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update dimension: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return dimension, nil
+}
+
+// GetByID retrieves a dimension
+func (r *DimensionRepository) GetByID(ctx context.Context, tenantID uuid.UUID, dimensionID uuid.UUID) (*Dimension, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	dimension := &Dimension{}
+	query := `SELECT ` + dimensionColumns + ` FROM dimensions WHERE id = $1`
+
+	if err := scanDimension(conn.QueryRow(ctx, query, dimensionID), dimension); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("dimension %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get dimension: %w", err)
+	}
+
+	return dimension, nil
+}
+
+// GetByCodes retrieves dimensions by code, including inactive ones. Unknown
+// codes are left out of the map.
+func (r *DimensionRepository) GetByCodes(ctx context.Context, tenantID uuid.UUID, codes []string) (map[string]*Dimension, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `SELECT ` + dimensionColumns + ` FROM dimensions WHERE code = ANY($1)`
+
+	rows, err := conn.Query(ctx, query, codes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dimensions: %w", err)
+	}
+	defer rows.Close()
+
+	dimensions := make(map[string]*Dimension, len(codes))
+	for rows.Next() {
+		dimension := &Dimension{}
+		if err := scanDimension(rows, dimension); err != nil {
+			return nil, fmt.Errorf("failed to scan dimension: %w", err)
+		}
+		dimensions[dimension.Code] = dimension
+	}
+
+	return dimensions, nil
+}
+
+// List retrieves the dimensions of a tenant ordered by code
+func (r *DimensionRepository) List(ctx context.Context, tenantID uuid.UUID, includeInactive bool) ([]*Dimension, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT ` + dimensionColumns + `
+		FROM dimensions
+		WHERE $1 OR is_active
+		ORDER BY code
+	`
+
+	rows, err := conn.Query(ctx, query, includeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dimensions: %w", err)
+	}
+	defer rows.Close()
+
+	dimensions := make([]*Dimension, 0)
+	for rows.Next() {
+		dimension := &Dimension{}
+		if err := scanDimension(rows, dimension); err != nil {
+			return nil, fmt.Errorf("failed to scan dimension: %w", err)
+		}
+		dimensions = append(dimensions, dimension)
+	}
+
+	return dimensions, nil
+}
+
+// GetBalances sums journal lines per account and per combination of values
+// of the grouped dimensions, for entries dated within the optional period.
+// Without GroupBy it returns one row per account.
+func (r *DimensionRepository) GetBalances(ctx context.Context, tenantID uuid.UUID, filter DimensionBalanceFilter) ([]*DimensionBalance, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	groupBy := filter.GroupBy
+	if groupBy == nil {
+		groupBy = []string{}
+	}
+
+	query := `
+		SELECT a.id, a.account_number, a.name, at.code, a.currency_code, g.vals,
+		       COALESCE(SUM(jel.debit), 0), COALESCE(SUM(jel.credit), 0)
+		FROM journal_entry_lines jel
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		INNER JOIN accounts a ON a.id = jel.account_id
+		INNER JOIN account_types at ON at.id = a.account_type_id
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(jsonb_object_agg(k, jel.dimensions->>k) FILTER (WHERE jel.dimensions ? k), '{}') AS vals
+			FROM unnest($1::text[]) k
+		) g
+		WHERE ($2::uuid IS NULL OR jel.account_id = $2)
+		  AND ($3::date IS NULL OR je.entry_date >= $3)
+		  AND ($4::date IS NULL OR je.entry_date <= $4)
+		  AND a.book_id = COALESCE($5::uuid, ` + defaultBookSQL + `)
+		GROUP BY a.id, a.account_number, a.name, at.code, a.currency_code, g.vals
+		ORDER BY a.account_number, g.vals::text
+	`
+
+	rows, err := conn.Query(ctx, query, groupBy, filter.AccountID, filter.FromDate, filter.ToDate, filter.BookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dimension balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make([]*DimensionBalance, 0)
+	for rows.Next() {
+		balance := &DimensionBalance{}
+		err := rows.Scan(
+			&balance.AccountID,
+			&balance.AccountNumber,
+			&balance.AccountName,
+			&balance.AccountTypeCode,
+			&balance.CurrencyCode,
+			&balance.Values,
+			&balance.Debit,
+			&balance.Credit,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dimension balance: %w", err)
+		}
+		balances = append(balances, balance)
+	}
+
+	return balances, nil
+}
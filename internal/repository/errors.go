@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"errors"
+
+	public "github.com/hesabFun/ledger/repository"
+)
+
+// Errors of the ledger models, defined with them in the public repository
+// package
+var (
+	ErrNotFound            = public.ErrNotFound
+	ErrNonZeroBalance      = public.ErrNonZeroBalance
+	ErrAccountHasChildren  = public.ErrAccountHasChildren
+	ErrAccountCycle        = public.ErrAccountCycle
+	ErrAccountTypeMismatch = public.ErrAccountTypeMismatch
+	ErrCurrencyMismatch    = public.ErrCurrencyMismatch
+	ErrBookMismatch        = public.ErrBookMismatch
+	ErrDeletedAccount      = public.ErrDeletedAccount
+	ErrInvalidEntryLines   = public.ErrInvalidEntryLines
+	ErrUnbalancedEntry     = public.ErrUnbalancedEntry
+	ErrInsufficientFunds   = public.ErrInsufficientFunds
+	ErrQuotaExceeded       = public.ErrQuotaExceeded
+	ErrPeriodLocked        = public.ErrPeriodLocked
+	ErrFutureDate          = public.ErrFutureDate
+	ErrBackdateLimit       = public.ErrBackdateLimit
+)
+
+var (
+	// ErrAlreadyReconciled is returned when matching a statement or journal line that is already matched
+	ErrAlreadyReconciled = errors.New("line is already reconciled")
+
+	// ErrAccountMismatch is returned when matching lines that belong to different accounts
+	ErrAccountMismatch = errors.New("lines belong to different accounts")
+
+	// ErrApplicationMismatch is returned when applying a payment to a document it cannot settle
+	ErrApplicationMismatch = errors.New("payment cannot be applied to this document")
+
+	// ErrOverApplication is returned when an application exceeds the open amount of the payment or document
+	ErrOverApplication = errors.New("application exceeds the open amount")
+
+	// ErrDepreciationPosted is returned when posting a depreciation line that has already been posted
+	ErrDepreciationPosted = errors.New("depreciation is already posted")
+
+	// ErrInterestAccrued is returned when posting an interest accrual for a period that has already been accrued
+	ErrInterestAccrued = errors.New("interest is already accrued for this period")
+
+	// ErrHoldNotPending is returned when capturing or releasing a hold that was already captured or released
+	ErrHoldNotPending = errors.New("hold is no longer pending")
+
+	// ErrHoldExpired is returned when capturing or releasing a hold past its expiry
+	ErrHoldExpired = errors.New("hold has expired")
+
+	// ErrCaptureExceedsHold is returned when capturing more than the held amount
+	ErrCaptureExceedsHold = errors.New("capture exceeds the held amount")
+
+	// ErrPreparedEntryNotPending is returned when confirming a prepared entry that was cancelled or cancelling
+	// one that was confirmed
+	ErrPreparedEntryNotPending = errors.New("prepared journal entry is no longer pending")
+
+	// ErrPreparedEntryExpired is returned when confirming a prepared entry past its expiry
+	ErrPreparedEntryExpired = errors.New("prepared journal entry has expired")
+
+	// ErrBatchNotOpen is returned when adding to or approving a journal batch that was approved, posted or
+	// rejected
+	ErrBatchNotOpen = errors.New("journal batch is not open")
+
+	// ErrBatchNotApproved is returned when posting a journal batch that was not approved
+	ErrBatchNotApproved = errors.New("journal batch is not approved")
+
+	// ErrBatchPosted is returned when rejecting a journal batch that was posted
+	ErrBatchPosted = errors.New("journal batch has been posted")
+
+	// ErrBatchEmpty is returned when approving a journal batch without entries
+	ErrBatchEmpty = errors.New("journal batch has no entries")
+
+	// ErrFxAccountNotConfigured is returned when settling documents booked at different exchange rates before the
+	// tenant configured the account the exchange gain or loss is posted to
+	ErrFxAccountNotConfigured = errors.New("exchange gain or loss account is not configured")
+
+	// ErrTenantNotDeleted is returned when purging the data of a tenant that has not been deleted
+	ErrTenantNotDeleted = errors.New("tenant must be deleted before its data is purged")
+
+	// ErrTenantPurged is returned when purging the data of a tenant whose data is already purged
+	ErrTenantPurged = errors.New("tenant data is already purged")
+
+	// ErrPurgeTokenInvalid is returned when confirming a purge with a token that was not issued for the tenant
+	// or has expired
+	ErrPurgeTokenInvalid = errors.New("purge confirmation token is invalid or expired")
+
+	// ErrEliminationTenant is returned when purging the data of a tenant that holds the eliminations of a
+	// consolidation group
+	ErrEliminationTenant = errors.New("tenant is the elimination tenant of a consolidation group")
+
+	// ErrTestTenantNotExpired is returned when purging a tenant as an expired test tenant that is not test
+	// mode or was marked as test after the retention cutoff
+	ErrTestTenantNotExpired = errors.New("tenant is not an expired test tenant")
+
+	// ErrEventHistoryIncomplete is returned when rebuilding balances from the event store of a tenant with
+	// journal entries posted before it recorded events
+	ErrEventHistoryIncomplete = errors.New("journal entries were posted without events")
+
+	// ErrSequenceMovedBack is returned when storing a reference sequence whose next value is lower than the
+	// stored one, which would hand out numbers that were already used
+	ErrSequenceMovedBack = errors.New("reference sequence next value must not move back")
+)
+
+// Postgres error checks, defined with the errors in the public repository
+// package
+var (
+	IsPermissionDenied    = public.IsPermissionDenied
+	IsUniqueViolation     = public.IsUniqueViolation
+	IsForeignKeyViolation = public.IsForeignKeyViolation
+	IsQueryCanceled       = public.IsQueryCanceled
+)
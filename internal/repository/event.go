@@ -0,0 +1,450 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// Ledger event aggregate types
+const (
+	AggregateAccount      = "ACCOUNT"
+	AggregateJournalEntry = "JOURNAL_ENTRY"
+	AggregateTenant       = "TENANT"
+)
+
+// Ledger event types
+const (
+	EventAccountCreated           = "AccountCreated"
+	EventAccountDeleted           = "AccountDeleted"
+	EventAccountRestored          = "AccountRestored"
+	EventAccountOverdraftLimitSet = "AccountOverdraftLimitSet"
+	EventAccountMoved             = "AccountMoved"
+	EventAccountLabelsSet         = "AccountLabelsSet"
+	EventAccountExternalIDSet     = "AccountExternalIdSet"
+	EventAccountsMerged           = "AccountsMerged"
+	EventJournalEntryPosted       = "JournalEntryPosted"
+	EventTenantCreated            = "TenantCreated"
+	EventTenantDeleted            = "TenantDeleted"
+	EventTenantRestored           = "TenantRestored"
+	EventTenantSettingsUpdated    = "TenantSettingsUpdated"
+	EventTenantStatusChanged      = "TenantStatusChanged"
+	EventTenantTestModeChanged    = "TenantTestModeChanged"
+	EventTenantPurged             = "TenantPurged"
+)
+
+// LedgerEvent is an immutable record of a change to the ledger. Events are
+// appended in the same transaction as the change they describe, so the event
+// store is the complete history from which current state and balances can be
+// rebuilt.
+type LedgerEvent struct {
+	Sequence      int64
+	TenantID      uuid.UUID
+	AggregateType string
+	AggregateID   uuid.UUID
+	EventType     string
+	// SchemaVersion is the version of the event type's payload schema the
+	// event was recorded with
+	SchemaVersion int32
+	Payload       json.RawMessage
+	RecordedAt    time.Time
+}
+
+// AccountCreatedPayload is the payload of an AccountCreated event
+type AccountCreatedPayload struct {
+	BookID          uuid.UUID  `json:"book_id"`
+	AccountNumber   string     `json:"account_number"`
+	Name            string     `json:"name"`
+	AccountTypeID   int32      `json:"account_type_id"`
+	CurrencyCode    string     `json:"currency_code"`
+	Description     *string    `json:"description,omitempty"`
+	ParentAccountID *uuid.UUID `json:"parent_account_id,omitempty"`
+	// OverdraftLimit is set when the account was created with one
+	OverdraftLimit *decimal.Decimal `json:"overdraft_limit,omitempty"`
+	// Inactive is set when the account was created inactive, as clones of
+	// inactive accounts are
+	Inactive bool `json:"inactive,omitempty"`
+}
+
+// AccountOverdraftLimitSetPayload is the payload of an AccountOverdraftLimitSet
+// event; a nil limit stops checking the account
+type AccountOverdraftLimitSetPayload struct {
+	OverdraftLimit *decimal.Decimal `json:"overdraft_limit"`
+}
+
+// AccountMovedPayload is the payload of an AccountMoved event; a nil
+// parent makes the account a root account
+type AccountMovedPayload struct {
+	ParentAccountID *uuid.UUID `json:"parent_account_id"`
+}
+
+// AccountLabelsSetPayload is the payload of an AccountLabelsSet event,
+// holding every label of the account after the change
+type AccountLabelsSetPayload struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// AccountExternalIDSetPayload is the payload of an AccountExternalIdSet
+// event; a nil external ID removes the account's mapping for the source
+type AccountExternalIDSetPayload struct {
+	Source     string  `json:"source"`
+	ExternalID *string `json:"external_id"`
+}
+
+// AccountsMergedPayload is the payload of an AccountsMerged event, recorded
+// on the merged account. Its balance moved to the target with the
+// reclassification entry, posted just before, when it had one.
+type AccountsMergedPayload struct {
+	MergeID                 uuid.UUID  `json:"merge_id"`
+	TargetAccountID         uuid.UUID  `json:"target_account_id"`
+	ReclassificationEntryID *uuid.UUID `json:"reclassification_entry_id"`
+}
+
+// TenantCreatedPayload is the payload of a TenantCreated event
+type TenantCreatedPayload struct {
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	IsTest     bool       `json:"is_test,omitempty"`
+	ClonedFrom *uuid.UUID `json:"cloned_from,omitempty"`
+}
+
+// TenantStatusChangedPayload is the payload of a TenantStatusChanged event
+type TenantStatusChangedPayload struct {
+	Status string `json:"status"`
+}
+
+// TenantTestModeChangedPayload is the payload of a TenantTestModeChanged event
+type TenantTestModeChangedPayload struct {
+	Test bool `json:"test"`
+}
+
+// TenantSettingsUpdatedPayload is the payload of a TenantSettingsUpdated
+// event. It holds all settings as stored, so unset fields are null rather
+// than omitted.
+type TenantSettingsUpdatedPayload struct {
+	BaseCurrency         string                     `json:"base_currency"`
+	Timezone             string                     `json:"timezone"`
+	Locale               string                     `json:"locale"`
+	Calendar             string                     `json:"calendar"`
+	FxGainAccountID      *uuid.UUID                 `json:"fx_gain_account_id"`
+	FxLossAccountID      *uuid.UUID                 `json:"fx_loss_account_id"`
+	FxConversionAccounts map[string]uuid.UUID       `json:"fx_conversion_accounts"`
+	OverdraftLimits      map[string]decimal.Decimal `json:"overdraft_limits"`
+	EncryptMetadata      bool                       `json:"encrypt_metadata"`
+}
+
+// TenantSettingsUpdatedPayloadV1 is the payload of TenantSettingsUpdated
+// events recorded at schema version 1, before the overdraft limits of
+// account types were added
+type TenantSettingsUpdatedPayloadV1 struct {
+	BaseCurrency         string               `json:"base_currency"`
+	Timezone             string               `json:"timezone"`
+	Locale               string               `json:"locale"`
+	Calendar             string               `json:"calendar"`
+	FxGainAccountID      *uuid.UUID           `json:"fx_gain_account_id"`
+	FxLossAccountID      *uuid.UUID           `json:"fx_loss_account_id"`
+	FxConversionAccounts map[string]uuid.UUID `json:"fx_conversion_accounts"`
+	EncryptMetadata      bool                 `json:"encrypt_metadata"`
+}
+
+// JournalEntryPostedPayload is the payload of a JournalEntryPosted event
+type JournalEntryPostedPayload struct {
+	ReferenceNumber string                 `json:"reference_number"`
+	Description     string                 `json:"description"`
+	EntryDate       string                 `json:"entry_date"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	Lines           []PostedLine           `json:"lines"`
+	// LockOverrideReason is set when the entry was posted into a locked
+	// period, so the justification is part of the audit trail
+	LockOverrideReason *string `json:"lock_override_reason,omitempty"`
+	// CurrencyCode is set when the entry was posted with an explicit currency
+	CurrencyCode string `json:"currency_code,omitempty"`
+}
+
+// PostedLine is a journal line in a JournalEntryPosted event
+type PostedLine struct {
+	AccountID            uuid.UUID         `json:"account_id"`
+	Debit                decimal.Decimal   `json:"debit"`
+	Credit               decimal.Decimal   `json:"credit"`
+	Description          string            `json:"description"`
+	CounterpartyTenantID *uuid.UUID        `json:"counterparty_tenant_id,omitempty"`
+	FxRate               *decimal.Decimal  `json:"fx_rate,omitempty"`
+	TaxCodeID            *uuid.UUID        `json:"tax_code_id,omitempty"`
+	IsTax                bool              `json:"is_tax,omitempty"`
+	PartyID              *uuid.UUID        `json:"party_id,omitempty"`
+	Dimensions           map[string]string `json:"dimensions,omitempty"`
+}
+
+const ledgerEventColumns = `sequence, tenant_id, aggregate_type, aggregate_id, event_type, schema_version, payload, recorded_at`
+
+func scanLedgerEvent(row pgx.Row, event *LedgerEvent) error {
+	return row.Scan(
+		&event.Sequence,
+		&event.TenantID,
+		&event.AggregateType,
+		&event.AggregateID,
+		&event.EventType,
+		&event.SchemaVersion,
+		&event.Payload,
+		&event.RecordedAt,
+	)
+}
+
+// appendEvent records an event for the tenant of an open transaction, with
+// the current schema version of its type. The payload must be of the type
+// registered for that version.
+func appendEvent(ctx context.Context, tx *db.TenantTx, aggregateType string, aggregateID uuid.UUID, eventType string, payload interface{}) error {
+	schema, ok := currentEventSchema(eventType)
+	if !ok {
+		return fmt.Errorf("no schema registered for %s events", eventType)
+	}
+	if reflect.TypeOf(payload) != schema.Payload {
+		return fmt.Errorf("%s event payload is a %T, expected %s", eventType, payload, schema.Payload)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	query := `
+		INSERT INTO ledger_events (tenant_id, aggregate_type, aggregate_id, event_type, schema_version, payload)
+		VALUES (current_setting('app.current_tenant_id')::uuid, $1, $2, $3, $4, $5)
+	`
+
+	if err := tx.Exec(ctx, query, aggregateType, aggregateID, eventType, schema.Version, data); err != nil {
+		return fmt.Errorf("failed to append %s event: %w", eventType, err)
+	}
+
+	return nil
+}
+
+// journalEntryPostedPayload builds the event payload of a new journal entry
+func journalEntryPostedPayload(params CreateJournalEntryParams) JournalEntryPostedPayload {
+	payload := JournalEntryPostedPayload{
+		ReferenceNumber:    params.ReferenceNumber,
+		Description:        params.Description,
+		EntryDate:          params.EntryDate.Format("2006-01-02"),
+		Metadata:           params.Metadata,
+		Lines:              make([]PostedLine, len(params.Lines)),
+		LockOverrideReason: params.LockOverrideReason,
+		CurrencyCode:       params.CurrencyCode,
+	}
+
+	for i, line := range params.Lines {
+		payload.Lines[i] = PostedLine{
+			AccountID:            line.AccountID,
+			Debit:                line.Debit,
+			Credit:               line.Credit,
+			Description:          line.Description,
+			CounterpartyTenantID: line.CounterpartyTenantID,
+			FxRate:               line.FxRate,
+			TaxCodeID:            line.TaxCodeID,
+			IsTax:                line.IsTax,
+			PartyID:              line.PartyID,
+			Dimensions:           line.Dimensions,
+		}
+	}
+
+	return payload
+}
+
+// EventRepository reads the ledger event store
+type EventRepository struct {
+	db *db.DB
+}
+
+// NewEventRepository creates a new event repository
+func NewEventRepository(database *db.DB) *EventRepository {
+	return &EventRepository{db: database}
+}
+
+// List retrieves up to limit events recorded after a sequence number, oldest
+// first, for consumers that maintain their own read models
+func (r *EventRepository) List(ctx context.Context, tenantID uuid.UUID, afterSequence int64, limit int) ([]*LedgerEvent, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT ` + ledgerEventColumns + `
+		FROM ledger_events
+		WHERE sequence > $1
+		ORDER BY sequence
+		LIMIT $2
+	`
+
+	rows, err := conn.Query(ctx, query, afterSequence, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*LedgerEvent, 0)
+	for rows.Next() {
+		event := &LedgerEvent{}
+		if err := scanLedgerEvent(rows, event); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// ReplayAggregate calls fn for every event of one aggregate, oldest first.
+// Returning an error from fn stops the replay.
+func (r *EventRepository) ReplayAggregate(ctx context.Context, tenantID uuid.UUID, aggregateType string, aggregateID uuid.UUID, fn func(*LedgerEvent) error) error {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT ` + ledgerEventColumns + `
+		FROM ledger_events
+		WHERE aggregate_type = $1 AND aggregate_id = $2
+		ORDER BY sequence
+	`
+
+	rows, err := conn.Query(ctx, query, aggregateType, aggregateID)
+	if err != nil {
+		return fmt.Errorf("failed to replay ledger events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event := &LedgerEvent{}
+		if err := scanLedgerEvent(rows, event); err != nil {
+			return fmt.Errorf("failed to scan ledger event: %w", err)
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LastSequence returns the sequence number of the latest event of a tenant,
+// or 0 when it has none
+func (r *EventRepository) LastSequence(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	var sequence int64
+	if err := conn.QueryRow(ctx, "SELECT COALESCE(MAX(sequence), 0) FROM ledger_events").Scan(&sequence); err != nil {
+		return 0, fmt.Errorf("failed to get last ledger event: %w", err)
+	}
+
+	return sequence, nil
+}
+
+// Replay calls fn for every event recorded up to and including a point in
+// time, oldest first; a nil until replays the whole history. Returning an
+// error from fn stops the replay.
+func (r *EventRepository) Replay(ctx context.Context, tenantID uuid.UUID, until *time.Time, fn func(*LedgerEvent) error) error {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `SELECT ` + ledgerEventColumns + ` FROM ledger_events`
+	args := []interface{}{}
+	if until != nil {
+		query += " WHERE recorded_at <= $1"
+		args = append(args, *until)
+	}
+	query += " ORDER BY sequence"
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to replay ledger events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event := &LedgerEvent{}
+		if err := scanLedgerEvent(rows, event); err != nil {
+			return fmt.Errorf("failed to scan ledger event: %w", err)
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReplayWithBalances calls fn for every event of the tenant, oldest first,
+// and returns the stored balance of every account. Both are read from one
+// snapshot, so a projection built by fn can be compared with the stored
+// balances while entries are posted. Returning an error from fn stops the
+// replay.
+func (r *EventRepository) ReplayWithBalances(ctx context.Context, tenantID uuid.UUID, fn func(*LedgerEvent) error) ([]*AccountBalance, error) {
+	tx, err := r.db.BeginSnapshotTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `SELECT `+ledgerEventColumns+` FROM ledger_events ORDER BY sequence`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay ledger events: %w", err)
+	}
+	for rows.Next() {
+		event := &LedgerEvent{}
+		if err := scanLedgerEvent(rows, event); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan ledger event: %w", err)
+		}
+		if err := fn(event); err != nil {
+			rows.Close()
+			return nil, err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to replay ledger events: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT a.id, COALESCE(b.debit_balance, 0), COALESCE(b.credit_balance, 0),
+		       COALESCE(b.updated_at, a.created_at)
+		FROM accounts a
+		LEFT JOIN account_balances b ON b.account_id = a.id
+		ORDER BY a.account_number
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read account balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make([]*AccountBalance, 0)
+	for rows.Next() {
+		balance := &AccountBalance{}
+		if err := rows.Scan(&balance.AccountID, &balance.DebitBalance, &balance.CreditBalance, &balance.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan account balance: %w", err)
+		}
+		balances = append(balances, balance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read account balances: %w", err)
+	}
+
+	return balances, nil
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// Event delivery statuses
+const (
+	// EventDeliveryPending is the status of an event still to be delivered,
+	// including one whose earlier attempts failed
+	EventDeliveryPending = "PENDING"
+	// EventDeliveryDelivered is the status of an event the destination accepted
+	EventDeliveryDelivered = "DELIVERED"
+	// EventDeliveryFailed is the status of a dead-lettered event, which is no
+	// longer tried until it is replayed
+	EventDeliveryFailed = "FAILED"
+)
+
+// EventDelivery is the delivery state of a ledger event pushed to an
+// external destination. Events without a row in event_deliveries are
+// pending and have not been tried.
+type EventDelivery struct {
+	Event       *LedgerEvent
+	Status      string
+	Attempts    int
+	LastError   *string
+	UpdatedAt   *time.Time
+	DeliveredAt *time.Time
+}
+
+// EventDeliveryBacklog counts the events of a tenant that are not delivered
+type EventDeliveryBacklog struct {
+	Pending int64
+	Failed  int64
+}
+
+const eventDeliveryColumns = `COALESCE(d.status, 'PENDING'), COALESCE(d.attempts, 0), d.last_error, d.updated_at, d.delivered_at`
+
+func scanEventDelivery(row pgx.Row, delivery *EventDelivery) error {
+	event := &LedgerEvent{}
+	delivery.Event = event
+	return row.Scan(
+		&event.Sequence,
+		&event.TenantID,
+		&event.AggregateType,
+		&event.AggregateID,
+		&event.EventType,
+		&event.SchemaVersion,
+		&event.Payload,
+		&event.RecordedAt,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.LastError,
+		&delivery.UpdatedAt,
+		&delivery.DeliveredAt,
+	)
+}
+
+// EventDeliveryRepository tracks the delivery of ledger events to external
+// destinations
+type EventDeliveryRepository struct {
+	db *db.DB
+}
+
+// NewEventDeliveryRepository creates a new event delivery repository
+func NewEventDeliveryRepository(database *db.DB) *EventDeliveryRepository {
+	return &EventDeliveryRepository{db: database}
+}
+
+// Pending retrieves up to limit events of a tenant that are still to be
+// delivered, oldest first. An event committed after a later one is picked up
+// by the next call, so none is skipped.
+func (r *EventDeliveryRepository) Pending(ctx context.Context, tenantID uuid.UUID, limit int) ([]*EventDelivery, error) {
+	return r.list(ctx, tenantID, "d.status IS NULL OR d.status = 'PENDING'", 0, limit)
+}
+
+// ListFailed retrieves up to limit dead-lettered events of a tenant recorded
+// after a sequence number, oldest first
+func (r *EventDeliveryRepository) ListFailed(ctx context.Context, tenantID uuid.UUID, afterSequence int64, limit int) ([]*EventDelivery, error) {
+	return r.list(ctx, tenantID, "d.status = 'FAILED'", afterSequence, limit)
+}
+
+func (r *EventDeliveryRepository) list(ctx context.Context, tenantID uuid.UUID, condition string, afterSequence int64, limit int) ([]*EventDelivery, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT e.sequence, e.tenant_id, e.aggregate_type, e.aggregate_id, e.event_type,
+		       e.schema_version, e.payload, e.recorded_at, ` + eventDeliveryColumns + `
+		FROM ledger_events e
+		LEFT JOIN event_deliveries d ON d.sequence = e.sequence
+		WHERE (` + condition + `) AND e.sequence > $1
+		ORDER BY e.sequence
+		LIMIT $2
+	`
+
+	rows, err := conn.Query(ctx, query, afterSequence, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list event deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*EventDelivery, 0)
+	for rows.Next() {
+		delivery := &EventDelivery{}
+		if err := scanEventDelivery(rows, delivery); err != nil {
+			return nil, fmt.Errorf("failed to scan event delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list event deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Get retrieves the delivery state of an event of a tenant, with the event
+func (r *EventDeliveryRepository) Get(ctx context.Context, tenantID uuid.UUID, sequence int64) (*EventDelivery, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT e.sequence, e.tenant_id, e.aggregate_type, e.aggregate_id, e.event_type,
+		       e.schema_version, e.payload, e.recorded_at, ` + eventDeliveryColumns + `
+		FROM ledger_events e
+		LEFT JOIN event_deliveries d ON d.sequence = e.sequence
+		WHERE e.sequence = $1
+	`
+
+	delivery := &EventDelivery{}
+	if err := scanEventDelivery(conn.QueryRow(ctx, query, sequence), delivery); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("ledger event %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get event delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// MarkDelivered records that the destination accepted an event
+func (r *EventDeliveryRepository) MarkDelivered(ctx context.Context, tenantID uuid.UUID, sequence int64) error {
+	return r.record(ctx, tenantID, sequence, EventDeliveryDelivered, nil)
+}
+
+// MarkFailed records a failed attempt to deliver an event. A dead-lettered
+// event is no longer tried until it is replayed; any other stays pending.
+func (r *EventDeliveryRepository) MarkFailed(ctx context.Context, tenantID uuid.UUID, sequence int64, reason string, deadLetter bool) error {
+	status := EventDeliveryPending
+	if deadLetter {
+		status = EventDeliveryFailed
+	}
+	return r.record(ctx, tenantID, sequence, status, &reason)
+}
+
+func (r *EventDeliveryRepository) record(ctx context.Context, tenantID uuid.UUID, sequence int64, status string, reason *string) error {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		INSERT INTO event_deliveries (tenant_id, sequence, status, attempts, last_error, delivered_at)
+		VALUES ($1, $2, $3, 1, $4, CASE WHEN $3 = 'DELIVERED' THEN NOW() END)
+		ON CONFLICT (sequence) DO UPDATE
+		SET status = EXCLUDED.status,
+		    attempts = event_deliveries.attempts + 1,
+		    last_error = COALESCE(EXCLUDED.last_error, event_deliveries.last_error),
+		    delivered_at = EXCLUDED.delivered_at,
+		    updated_at = NOW()
+	`
+
+	if _, err := conn.Exec(ctx, query, tenantID, sequence, status, reason); err != nil {
+		return fmt.Errorf("failed to record event delivery: %w", err)
+	}
+
+	return nil
+}
+
+// Replay returns dead-lettered events of a tenant to pending with their
+// attempts reset, so they are delivered again in sequence order. A nil
+// sequence replays every dead-lettered event of the tenant; the number of
+// events replayed is returned, and ErrNotFound when a given event is not
+// dead-lettered.
+func (r *EventDeliveryRepository) Replay(ctx context.Context, tenantID uuid.UUID, sequence *int64) (int64, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		UPDATE event_deliveries
+		SET status = 'PENDING', attempts = 0, updated_at = NOW()
+		WHERE status = 'FAILED' AND ($1::bigint IS NULL OR sequence = $1)
+	`
+
+	tag, err := conn.Exec(ctx, query, sequence)
+	if err != nil {
+		return 0, fmt.Errorf("failed to replay event deliveries: %w", err)
+	}
+	if sequence != nil && tag.RowsAffected() == 0 {
+		return 0, fmt.Errorf("dead-lettered event %w", ErrNotFound)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// Backlog counts the pending and dead-lettered events of a tenant
+func (r *EventDeliveryRepository) Backlog(ctx context.Context, tenantID uuid.UUID) (*EventDeliveryBacklog, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT COUNT(*) FILTER (WHERE d.status IS NULL OR d.status = 'PENDING'),
+		       COUNT(*) FILTER (WHERE d.status = 'FAILED')
+		FROM ledger_events e
+		LEFT JOIN event_deliveries d ON d.sequence = e.sequence
+	`
+
+	backlog := &EventDeliveryBacklog{}
+	if err := conn.QueryRow(ctx, query).Scan(&backlog.Pending, &backlog.Failed); err != nil {
+		return nil, fmt.Errorf("failed to count event deliveries: %w", err)
+	}
+
+	return backlog, nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"reflect"
+)
+
+// EventSchema registers the payload of an event type at one schema version.
+// A payload changes shape only under a new version, registered next to the
+// old one, so consumers can validate each event against the version it was
+// recorded with.
+type EventSchema struct {
+	EventType     string
+	AggregateType string
+	Version       int32
+	// Payload is the Go type the payload is encoded from
+	Payload reflect.Type
+}
+
+// eventSchemas lists every schema version of every event type, oldest
+// first. Events without data have an empty payload.
+var eventSchemas = []EventSchema{
+	{EventAccountCreated, AggregateAccount, 1, reflect.TypeFor[AccountCreatedPayload]()},
+	{EventAccountDeleted, AggregateAccount, 1, reflect.TypeFor[struct{}]()},
+	{EventAccountRestored, AggregateAccount, 1, reflect.TypeFor[struct{}]()},
+	{EventAccountOverdraftLimitSet, AggregateAccount, 1, reflect.TypeFor[AccountOverdraftLimitSetPayload]()},
+	{EventAccountMoved, AggregateAccount, 1, reflect.TypeFor[AccountMovedPayload]()},
+	{EventAccountLabelsSet, AggregateAccount, 1, reflect.TypeFor[AccountLabelsSetPayload]()},
+	{EventAccountExternalIDSet, AggregateAccount, 1, reflect.TypeFor[AccountExternalIDSetPayload]()},
+	{EventAccountsMerged, AggregateAccount, 1, reflect.TypeFor[AccountsMergedPayload]()},
+	{EventJournalEntryPosted, AggregateJournalEntry, 1, reflect.TypeFor[JournalEntryPostedPayload]()},
+	{EventTenantCreated, AggregateTenant, 1, reflect.TypeFor[TenantCreatedPayload]()},
+	{EventTenantDeleted, AggregateTenant, 1, reflect.TypeFor[struct{}]()},
+	{EventTenantRestored, AggregateTenant, 1, reflect.TypeFor[struct{}]()},
+	{EventTenantSettingsUpdated, AggregateTenant, 1, reflect.TypeFor[TenantSettingsUpdatedPayloadV1]()},
+	{EventTenantSettingsUpdated, AggregateTenant, 2, reflect.TypeFor[TenantSettingsUpdatedPayload]()},
+	{EventTenantStatusChanged, AggregateTenant, 1, reflect.TypeFor[TenantStatusChangedPayload]()},
+	{EventTenantTestModeChanged, AggregateTenant, 1, reflect.TypeFor[TenantTestModeChangedPayload]()},
+	{EventTenantPurged, AggregateTenant, 1, reflect.TypeFor[TenantPurgedPayload]()},
+	{EventDailyDigestComputed, AggregateDailyDigest, 1, reflect.TypeFor[DailyDigestComputedPayload]()},
+}
+
+// EventSchemas returns the registered schema versions of an event type,
+// oldest first, or of every event type when eventType is empty
+func EventSchemas(eventType string) []EventSchema {
+	var schemas []EventSchema
+	for _, schema := range eventSchemas {
+		if eventType == "" || schema.EventType == eventType {
+			schemas = append(schemas, schema)
+		}
+	}
+	return schemas
+}
+
+// currentEventSchema returns the latest schema version of an event type,
+// which new events are recorded with
+func currentEventSchema(eventType string) (EventSchema, bool) {
+	var current EventSchema
+	for _, schema := range eventSchemas {
+		if schema.EventType == eventType && schema.Version > current.Version {
+			current = schema
+		}
+	}
+	return current, current.Version > 0
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/hesabFun/ledger/internal/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSchemas(t *testing.T) {
+	seen := map[string]int32{}
+	for _, schema := range EventSchemas("") {
+		// Versions of a type are registered oldest first, without gaps
+		assert.Equal(t, seen[schema.EventType]+1, schema.Version, schema.EventType)
+		seen[schema.EventType] = schema.Version
+
+		_, err := jsonschema.For(schema.Payload)
+		assert.NoError(t, err, schema.EventType)
+	}
+
+	schemas := EventSchemas(EventJournalEntryPosted)
+	require.Len(t, schemas, 1)
+	assert.Equal(t, AggregateJournalEntry, schemas[0].AggregateType)
+	assert.Empty(t, EventSchemas("Unknown"))
+}
+
+func TestCurrentEventSchema(t *testing.T) {
+	schema, ok := currentEventSchema(EventAccountDeleted)
+	require.True(t, ok)
+	assert.Equal(t, int32(1), schema.Version)
+
+	_, ok = currentEventSchema("Unknown")
+	assert.False(t, ok)
+}
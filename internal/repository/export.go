@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// Export job statuses
+const (
+	ExportJobPending   = "PENDING"
+	ExportJobRunning   = "RUNNING"
+	ExportJobCompleted = "COMPLETED"
+	ExportJobFailed    = "FAILED"
+)
+
+// ExportJob represents a background export of a tenant's ledger data to
+// files. Archive jobs write every dataset of a tenant snapshot into a single
+// zip file.
+type ExportJob struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	Format      string
+	Datasets    []string
+	Status      string
+	Files       []string
+	Archive     bool
+	Error       *string
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+const exportJobColumns = `id, tenant_id, format, datasets, status, files, archive, error, created_at, completed_at`
+
+func scanExportJob(row pgx.Row, job *ExportJob) error {
+	return row.Scan(
+		&job.ID,
+		&job.TenantID,
+		&job.Format,
+		&job.Datasets,
+		&job.Status,
+		&job.Files,
+		&job.Archive,
+		&job.Error,
+		&job.CreatedAt,
+		&job.CompletedAt,
+	)
+}
+
+// ExportJobRepository handles export job database operations
+type ExportJobRepository struct {
+	db *db.DB
+}
+
+// NewExportJobRepository creates a new export job repository
+func NewExportJobRepository(database *db.DB) *ExportJobRepository {
+	return &ExportJobRepository{db: database}
+}
+
+// Create creates a pending export job
+func (r *ExportJobRepository) Create(ctx context.Context, tenantID uuid.UUID, format string, datasets []string, archive bool) (*ExportJob, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	job := &ExportJob{}
+	query := `
+		INSERT INTO export_jobs (tenant_id, format, datasets, status, files, archive)
+		VALUES ($1, $2, $3, $4, '{}', $5)
+		RETURNING ` + exportJobColumns
+
+	if err := scanExportJob(tx.QueryRow(ctx, query, tenantID, format, datasets, ExportJobPending, archive), job); err != nil {
+		return nil, fmt.Errorf("failed to create export job: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return job, nil
+}
+
+// GetByID retrieves an export job
+func (r *ExportJobRepository) GetByID(ctx context.Context, tenantID uuid.UUID, jobID uuid.UUID) (*ExportJob, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	job := &ExportJob{}
+	query := `SELECT ` + exportJobColumns + ` FROM export_jobs WHERE id = $1`
+
+	if err := scanExportJob(conn.QueryRow(ctx, query, jobID), job); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("export job %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+
+	return job, nil
+}
+
+// UpdateStatus records the progress of an export job. Completed and failed
+// jobs also get their completion time set.
+func (r *ExportJobRepository) UpdateStatus(ctx context.Context, tenantID uuid.UUID, jobID uuid.UUID, status string, files []string, errMsg *string) error {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if files == nil {
+		files = []string{}
+	}
+
+	query := `
+		UPDATE export_jobs
+		SET status = $2, files = $3, error = $4,
+		    completed_at = CASE WHEN $2 IN ('COMPLETED', 'FAILED') THEN NOW() END
+		WHERE id = $1
+	`
+
+	if err := tx.Exec(ctx, query, jobID, status, files, errMsg); err != nil {
+		return fmt.Errorf("failed to update export job: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// Hold statuses. Holds are stored as PENDING until captured or released;
+// a pending hold past its expiry is reported as EXPIRED.
+const (
+	HoldStatusPending  = "PENDING"
+	HoldStatusCaptured = "CAPTURED"
+	HoldStatusReleased = "RELEASED"
+	HoldStatusExpired  = "EXPIRED"
+)
+
+// Hold reserves an amount of an account until it is captured into a
+// journal entry, released or expires. Pending holds reduce the available
+// balance of the account but not its booked balance.
+type Hold struct {
+	ID                   uuid.UUID
+	TenantID             uuid.UUID
+	AccountID            uuid.UUID
+	DestinationAccountID uuid.UUID
+	Amount               decimal.Decimal
+	CapturedAmount       decimal.Decimal
+	Status               string
+	ReferenceNumber      string
+	Description          string
+	ExpiresAt            time.Time
+	JournalEntryID       *uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// CreateHoldParams holds parameters for creating a hold
+type CreateHoldParams struct {
+	AccountID            uuid.UUID
+	DestinationAccountID uuid.UUID
+	Amount               decimal.Decimal
+	ReferenceNumber      string
+	Description          string
+	ExpiresAt            time.Time
+}
+
+// HoldFilter holds filters for listing holds
+type HoldFilter struct {
+	AccountID *uuid.UUID
+	Status    *string
+}
+
+// holdStatusExpr reports pending holds past their expiry as expired
+const holdStatusExpr = `CASE WHEN status = 'PENDING' AND expires_at <= NOW() THEN 'EXPIRED' ELSE status END`
+
+const holdColumns = `id, tenant_id, account_id, destination_account_id, amount, captured_amount,
+		       ` + holdStatusExpr + `, reference_number, description, expires_at, journal_entry_id,
+		       created_at, updated_at`
+
+func scanHold(row pgx.Row, hold *Hold) error {
+	return row.Scan(
+		&hold.ID,
+		&hold.TenantID,
+		&hold.AccountID,
+		&hold.DestinationAccountID,
+		&hold.Amount,
+		&hold.CapturedAmount,
+		&hold.Status,
+		&hold.ReferenceNumber,
+		&hold.Description,
+		&hold.ExpiresAt,
+		&hold.JournalEntryID,
+		&hold.CreatedAt,
+		&hold.UpdatedAt,
+	)
+}
+
+// HoldRepository handles authorization hold database operations
+type HoldRepository struct {
+	db *db.DB
+}
+
+// NewHoldRepository creates a new hold repository
+func NewHoldRepository(database *db.DB) *HoldRepository {
+	return &HoldRepository{db: database}
+}
+
+// Create places a pending hold
+func (r *HoldRepository) Create(ctx context.Context, tenantID uuid.UUID, params CreateHoldParams) (*Hold, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Holds reduce the available balance that postings are checked against
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	hold := &Hold{}
+	query := `
+		INSERT INTO holds (
+			tenant_id, account_id, destination_account_id, amount, captured_amount,
+			status, reference_number, description, expires_at
+		)
+		VALUES ($1, $2, $3, $4, 0, $5, $6, $7, $8)
+		RETURNING ` + holdColumns
+
+	row := tx.QueryRow(ctx, query,
+		tenantID,
+		params.AccountID,
+		params.DestinationAccountID,
+		params.Amount,
+		HoldStatusPending,
+		params.ReferenceNumber,
+		params.Description,
+		params.ExpiresAt,
+	)
+	if err := scanHold(row, hold); err != nil {
+		return nil, fmt.Errorf("failed to create hold: %w", err)
+	}
+
+	if err := checkAvailableBalance(ctx, tx, params.AccountID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return hold, nil
+}
+
+// GetByID retrieves a hold
+func (r *HoldRepository) GetByID(ctx context.Context, tenantID uuid.UUID, holdID uuid.UUID) (*Hold, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	hold := &Hold{}
+	query := `SELECT ` + holdColumns + ` FROM holds WHERE id = $1`
+
+	if err := scanHold(conn.QueryRow(ctx, query, holdID), hold); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("hold %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+
+	return hold, nil
+}
+
+// List retrieves holds, newest first
+func (r *HoldRepository) List(ctx context.Context, tenantID uuid.UUID, filter HoldFilter, limit, offset int) ([]*Hold, int, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	argCount := 0
+
+	if filter.AccountID != nil {
+		argCount++
+		where += fmt.Sprintf(" AND account_id = $%d", argCount)
+		args = append(args, *filter.AccountID)
+	}
+
+	if filter.Status != nil {
+		argCount++
+		where += fmt.Sprintf(" AND "+holdStatusExpr+" = $%d", argCount)
+		args = append(args, *filter.Status)
+	}
+
+	var totalCount int
+	if err := conn.QueryRow(ctx, "SELECT COUNT(*) FROM holds"+where, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count holds: %w", err)
+	}
+
+	query := `SELECT ` + holdColumns + ` FROM holds` + where +
+		fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", argCount+1, argCount+2)
+	args = append(args, limit, offset)
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list holds: %w", err)
+	}
+	defer rows.Close()
+
+	holds := make([]*Hold, 0)
+	for rows.Next() {
+		hold := &Hold{}
+		if err := scanHold(rows, hold); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan hold: %w", err)
+		}
+		holds = append(holds, hold)
+	}
+
+	return holds, totalCount, nil
+}
+
+// Capture posts the journal entry of a pending hold and marks the hold
+// captured for amount in a single transaction. Capturing less than the held
+// amount releases the rest.
+func (r *HoldRepository) Capture(ctx context.Context, tenantID uuid.UUID, holdID uuid.UUID, amount decimal.Decimal, entry CreateJournalEntryParams) (*Hold, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Take the tenant journal lock before the hold row, in the order merges
+	// take them, so the two cannot deadlock
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	hold, err := lockPendingHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount.GreaterThan(hold.Amount) {
+		return nil, ErrCaptureExceedsHold
+	}
+
+	// The hold stops counting against the available balance before its
+	// entry is checked against it
+	err = tx.Exec(ctx, `
+		UPDATE holds
+		SET status = $2, captured_amount = $3, updated_at = NOW()
+		WHERE id = $1
+	`, holdID, HoldStatusCaptured, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture hold: %w", err)
+	}
+
+	journalEntryID, err := insertJournalEntry(ctx, tx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE holds
+		SET journal_entry_id = $2
+		WHERE id = $1
+		RETURNING ` + holdColumns
+
+	if err := scanHold(tx.QueryRow(ctx, query, holdID, journalEntryID), hold); err != nil {
+		return nil, fmt.Errorf("failed to capture hold: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return hold, nil
+}
+
+// Release frees the amount of a pending hold without posting
+func (r *HoldRepository) Release(ctx context.Context, tenantID uuid.UUID, holdID uuid.UUID) (*Hold, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Take the tenant journal lock before the hold row, in the order merges
+	// take them, so the two cannot deadlock
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	hold, err := lockPendingHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE holds
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + holdColumns
+
+	if err := scanHold(tx.QueryRow(ctx, query, holdID, HoldStatusReleased), hold); err != nil {
+		return nil, fmt.Errorf("failed to release hold: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return hold, nil
+}
+
+// lockPendingHold locks a hold for update, rejecting holds that are no
+// longer pending. The caller must already hold the tenant journal lock.
+func lockPendingHold(ctx context.Context, tx *db.TenantTx, holdID uuid.UUID) (*Hold, error) {
+	hold := &Hold{}
+	query := `SELECT ` + holdColumns + ` FROM holds WHERE id = $1 FOR UPDATE`
+
+	if err := scanHold(tx.QueryRow(ctx, query, holdID), hold); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("hold %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to lock hold: %w", err)
+	}
+
+	switch hold.Status {
+	case HoldStatusPending:
+		return hold, nil
+	case HoldStatusExpired:
+		return nil, ErrHoldExpired
+	default:
+		return nil, ErrHoldNotPending
+	}
+}
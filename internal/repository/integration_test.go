@@ -5,6 +5,8 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -21,12 +23,35 @@ import (
 // IntegrationTestSuite is the test suite for integration tests
 type IntegrationTestSuite struct {
 	suite.Suite
-	db            *db.DB
-	tenantRepo    *TenantRepository
-	accountRepo   *AccountRepository
-	journalRepo   *JournalRepository
-	referenceRepo *ReferenceRepository
-	testTenantID  uuid.UUID
+	db              *db.DB
+	tenantRepo      *TenantRepository
+	accountRepo     *AccountRepository
+	journalRepo     *JournalRepository
+	referenceRepo   *ReferenceRepository
+	quotaRepo       *QuotaRepository
+	balanceRepo     *BalanceRepository
+	consistencyRepo *ConsistencyRepository
+	taxRepo         *TaxCodeRepository
+	assetRepo       *FixedAssetRepository
+	partyRepo       *PartyRepository
+	dimensionRepo   *DimensionRepository
+	budgetRepo      *BudgetRepository
+	holdRepo        *HoldRepository
+	preparedRepo    *PreparedEntryRepository
+	batchRepo       *JournalBatchRepository
+	reportRepo      *ReportRepository
+	digestRepo      *DigestRepository
+	bookRepo        *BookRepository
+	interestRepo    *InterestRepository
+	policyRepo      *PostingPolicyRepository
+	closeRepo       *CloseRepository
+	eventRepo       *EventRepository
+	deliveryRepo    *EventDeliveryRepository
+	settingsRepo    *TenantSettingsRepository
+	tenantDataRepo  *TenantDataRepository
+	runRepo         *StatementRunRepository
+	dbConfig        *config.DatabaseConfig
+	testTenantID    uuid.UUID
 }
 
 // SetupSuite runs once before all tests
@@ -49,12 +74,35 @@ func (s *IntegrationTestSuite) SetupSuite() {
 	require.NoError(s.T(), err, "Failed to connect to database")
 
 	s.db = database
+	s.dbConfig = cfg
 
 	// Initialize repositories
 	s.tenantRepo = NewTenantRepository(database)
 	s.accountRepo = NewAccountRepository(database)
 	s.journalRepo = NewJournalRepository(database)
 	s.referenceRepo = NewReferenceRepository(database)
+	s.quotaRepo = NewQuotaRepository(database)
+	s.balanceRepo = NewBalanceRepository(database)
+	s.consistencyRepo = NewConsistencyRepository(database)
+	s.taxRepo = NewTaxCodeRepository(database)
+	s.assetRepo = NewFixedAssetRepository(database)
+	s.partyRepo = NewPartyRepository(database)
+	s.dimensionRepo = NewDimensionRepository(database)
+	s.budgetRepo = NewBudgetRepository(database)
+	s.holdRepo = NewHoldRepository(database)
+	s.preparedRepo = NewPreparedEntryRepository(database)
+	s.batchRepo = NewJournalBatchRepository(database)
+	s.reportRepo = NewReportRepository(database)
+	s.digestRepo = NewDigestRepository(database)
+	s.bookRepo = NewBookRepository(database)
+	s.interestRepo = NewInterestRepository(database)
+	s.policyRepo = NewPostingPolicyRepository(database)
+	s.closeRepo = NewCloseRepository(database)
+	s.eventRepo = NewEventRepository(database)
+	s.deliveryRepo = NewEventDeliveryRepository(database)
+	s.settingsRepo = NewTenantSettingsRepository(database)
+	s.tenantDataRepo = NewTenantDataRepository(database)
+	s.runRepo = NewStatementRunRepository(database)
 }
 
 // TearDownSuite runs once after all tests
@@ -111,6 +159,81 @@ func (s *IntegrationTestSuite) TestTenantRepository_GetByID() {
 	assert.NotEmpty(s.T(), tenant.Name)
 }
 
+// TestTenantRepository_List tests listing tenants by name and status
+func (s *IntegrationTestSuite) TestTenantRepository_List() {
+	ctx := context.Background()
+
+	tenant, err := s.tenantRepo.Create(ctx, "integration-list_tenant", nil)
+	require.NoError(s.T(), err)
+	_, err = s.tenantRepo.SetStatus(ctx, tenant.ID, TenantStatusArchived)
+	require.NoError(s.T(), err)
+
+	// The underscore is matched literally, not as a wildcard
+	name := "LIST_TENANT"
+	tenants, total, err := s.tenantRepo.List(ctx, TenantFilter{NameContains: &name}, 10, 0)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, total)
+	require.Len(s.T(), tenants, 1)
+	assert.Equal(s.T(), tenant.ID, tenants[0].ID)
+
+	archived := TenantStatusArchived
+	createdFrom := tenant.CreatedAt
+	tenants, _, err = s.tenantRepo.List(ctx, TenantFilter{Status: &archived, CreatedFrom: &createdFrom}, 10, 0)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), tenants, 1)
+	assert.Equal(s.T(), tenant.ID, tenants[0].ID)
+
+	active := TenantStatusActive
+	tenants, _, err = s.tenantRepo.List(ctx, TenantFilter{NameContains: &name, Status: &active}, 10, 0)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), tenants)
+
+	// Clean up
+	_, err = s.db.Pool().Exec(ctx, "DELETE FROM tenants WHERE id = $1", tenant.ID)
+	require.NoError(s.T(), err)
+}
+
+// TestTenantRepository_SetStatus tests suspending, archiving and
+// reactivating a tenant
+func (s *IntegrationTestSuite) TestTenantRepository_SetStatus() {
+	ctx := context.Background()
+
+	tenant, err := s.tenantRepo.Create(ctx, "integration-status-tenant", nil)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), TenantStatusActive, tenant.Status)
+
+	suspended, err := s.tenantRepo.SetStatus(ctx, tenant.ID, TenantStatusSuspended)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), TenantStatusSuspended, suspended.Status)
+
+	ids, err := s.tenantRepo.ListIDs(ctx)
+	require.NoError(s.T(), err)
+	assert.NotContains(s.T(), ids, tenant.ID)
+
+	_, err = s.tenantRepo.SetStatus(ctx, tenant.ID, TenantStatusArchived)
+	require.NoError(s.T(), err)
+	status, err := s.tenantRepo.Status(ctx, tenant.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), TenantStatusArchived, status)
+
+	_, err = s.tenantRepo.Delete(ctx, tenant.ID)
+	require.NoError(s.T(), err)
+	status, err = s.tenantRepo.Status(ctx, tenant.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), TenantStatusDeleted, status)
+
+	_, err = s.tenantRepo.SetStatus(ctx, tenant.ID, TenantStatusActive)
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+
+	status, err = s.tenantRepo.Status(ctx, uuid.New())
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), status)
+
+	// Clean up
+	_, err = s.db.Pool().Exec(ctx, "DELETE FROM tenants WHERE id = $1", tenant.ID)
+	require.NoError(s.T(), err)
+}
+
 // TestAccountRepository_Create tests creating an account
 func (s *IntegrationTestSuite) TestAccountRepository_Create() {
 	ctx := context.Background()
@@ -174,13 +297,361 @@ func (s *IntegrationTestSuite) TestAccountRepository_List() {
 	}
 
 	// List accounts
-	accounts, totalCount, err := s.accountRepo.List(ctx, s.testTenantID, nil, nil, 10, 0)
+	accounts, totalCount, err := s.accountRepo.List(ctx, s.testTenantID, AccountFilter{}, 10, 0)
 	require.NoError(s.T(), err)
 
 	assert.GreaterOrEqual(s.T(), len(accounts), 3)
 	assert.GreaterOrEqual(s.T(), totalCount, 3)
 }
 
+// TestAccountRepository_ListFilters tests prefix filtering and sorting of accounts
+func (s *IntegrationTestSuite) TestAccountRepository_ListFilters() {
+	ctx := context.Background()
+
+	prefix := uuid.New().String()[:6]
+	for _, name := range []string{"Bank B", "Bank A", "Cash"} {
+		_, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: prefix + "-" + name,
+			Name:          name,
+			AccountTypeID: 1,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(s.T(), err)
+	}
+
+	numberPrefix := prefix + "-B"
+	accounts, totalCount, err := s.accountRepo.List(ctx, s.testTenantID, AccountFilter{
+		NumberPrefix: &numberPrefix,
+		SortBy:       AccountSortName,
+	}, 10, 0)
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), 2, totalCount)
+	require.Len(s.T(), accounts, 2)
+	assert.Equal(s.T(), "Bank A", accounts[0].Name)
+	assert.Equal(s.T(), "Bank B", accounts[1].Name)
+}
+
+// TestAccountRepository_Move tests moving accounts in the hierarchy,
+// their depth and path and listing descendants
+func (s *IntegrationTestSuite) TestAccountRepository_Move() {
+	ctx := context.Background()
+
+	create := func(number string, parentID *uuid.UUID) *Account {
+		account, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber:   number,
+			Name:            "Tree " + number,
+			AccountTypeID:   4,
+			CurrencyCode:    "USD",
+			ParentAccountID: parentID,
+		})
+		require.NoError(s.T(), err)
+		return account
+	}
+	root := create("9960", nil)
+	child := create("9961", &root.ID)
+	grandchild := create("9962", &child.ID)
+	other := create("9970", nil)
+
+	fetched, err := s.accountRepo.GetByID(ctx, s.testTenantID, grandchild.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), int32(2), fetched.Depth)
+	assert.Equal(s.T(), "9960/9961/9962", fetched.Path)
+
+	moved, err := s.accountRepo.Move(ctx, s.testTenantID, other.ID, &child.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "9960/9961/9970", moved.Path)
+
+	descendants, totalCount, err := s.accountRepo.List(ctx, s.testTenantID, AccountFilter{
+		AncestorAccountID: &root.ID,
+		SortBy:            AccountSortNumber,
+	}, 10, 0)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 3, totalCount)
+	require.Len(s.T(), descendants, 3)
+	assert.Equal(s.T(), "9961", descendants[0].AccountNumber)
+	assert.Equal(s.T(), int32(1), descendants[0].Depth)
+
+	_, err = s.accountRepo.Move(ctx, s.testTenantID, root.ID, &grandchild.ID)
+	assert.ErrorIs(s.T(), err, ErrAccountCycle)
+	_, err = s.accountRepo.Move(ctx, s.testTenantID, root.ID, &root.ID)
+	assert.ErrorIs(s.T(), err, ErrAccountCycle)
+
+	detached, err := s.accountRepo.Move(ctx, s.testTenantID, child.ID, nil)
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), detached.ParentAccountID)
+	assert.Equal(s.T(), "9961", detached.Path)
+}
+
+// TestEventRepository_ReplayAggregate tests replaying the events of one
+// account and of the tenant itself
+func (s *IntegrationTestSuite) TestEventRepository_ReplayAggregate() {
+	ctx := context.Background()
+
+	parent, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9980",
+		Name:          "History Parent",
+		AccountTypeID: 4,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+	account, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9981",
+		Name:          "History Child",
+		AccountTypeID: 4,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+	_, err = s.accountRepo.Move(ctx, s.testTenantID, account.ID, &parent.ID)
+	require.NoError(s.T(), err)
+
+	var eventTypes []string
+	err = s.eventRepo.ReplayAggregate(ctx, s.testTenantID, AggregateAccount, account.ID, func(event *LedgerEvent) error {
+		eventTypes = append(eventTypes, event.EventType)
+		assert.Equal(s.T(), int32(1), event.SchemaVersion)
+		return nil
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{EventAccountCreated, EventAccountMoved}, eventTypes)
+
+	_, err = s.settingsRepo.Upsert(ctx, &TenantSettings{
+		TenantID:     s.testTenantID,
+		BaseCurrency: "USD",
+		Timezone:     "Asia/Tehran",
+		Locale:       DefaultLocale,
+		Calendar:     DefaultCalendar,
+	})
+	require.NoError(s.T(), err)
+
+	eventTypes = nil
+	err = s.eventRepo.ReplayAggregate(ctx, s.testTenantID, AggregateTenant, s.testTenantID, func(event *LedgerEvent) error {
+		eventTypes = append(eventTypes, event.EventType)
+		return nil
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{EventTenantCreated, EventTenantSettingsUpdated}, eventTypes)
+}
+
+// TestEventRepository_ReplayWithBalances tests replaying a tenant's events
+// along with the stored balances they project
+func (s *IntegrationTestSuite) TestEventRepository_ReplayWithBalances() {
+	ctx := context.Background()
+
+	cash, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9990",
+		Name:          "Replay Cash",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+	revenue, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9991",
+		Name:          "Replay Revenue",
+		AccountTypeID: 4,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "REPLAY-001",
+		Description:     "Replayed entry",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: cash.ID, Debit: decimal.NewFromInt(75), Credit: decimal.Zero},
+			{AccountID: revenue.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(75)},
+		},
+	})
+	require.NoError(s.T(), err)
+
+	var eventTypes []string
+	balances, err := s.eventRepo.ReplayWithBalances(ctx, s.testTenantID, func(event *LedgerEvent) error {
+		eventTypes = append(eventTypes, event.EventType)
+		return nil
+	})
+	require.NoError(s.T(), err)
+	assert.Contains(s.T(), eventTypes, EventJournalEntryPosted)
+
+	stored := make(map[uuid.UUID]*AccountBalance)
+	for _, balance := range balances {
+		stored[balance.AccountID] = balance
+	}
+	require.Contains(s.T(), stored, cash.ID)
+	assert.True(s.T(), stored[cash.ID].DebitBalance.Equal(decimal.NewFromInt(75)))
+	require.Contains(s.T(), stored, revenue.ID)
+	assert.True(s.T(), stored[revenue.ID].CreditBalance.Equal(decimal.NewFromInt(75)))
+}
+
+// TestAccountRepository_LabelsAndExternalIDs tests labelling accounts and
+// looking them up by the identifiers of other systems
+func (s *IntegrationTestSuite) TestAccountRepository_LabelsAndExternalIDs() {
+	ctx := context.Background()
+
+	account, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9300",
+		Name:          "ERP Mapped",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+		Labels:        map[string]string{"region": "emea"},
+		ExternalIDs:   map[string]string{"sap": "930000"},
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), map[string]string{"region": "emea"}, account.Labels)
+	assert.Equal(s.T(), map[string]string{"sap": "930000"}, account.ExternalIDs)
+
+	found, err := s.accountRepo.GetByExternalID(ctx, s.testTenantID, "sap", "930000")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), account.ID, found.ID)
+
+	accounts, total, err := s.accountRepo.List(ctx, s.testTenantID, AccountFilter{Labels: map[string]string{"region": "emea"}}, 10, 0)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, total)
+	require.Len(s.T(), accounts, 1)
+	assert.Equal(s.T(), account.ID, accounts[0].ID)
+
+	other, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9310",
+		Name:          "ERP Unmapped",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), other.Labels)
+	assert.Empty(s.T(), other.ExternalIDs)
+
+	_, err = s.accountRepo.SetExternalID(ctx, s.testTenantID, other.ID, "sap", "930000")
+	assert.True(s.T(), IsUniqueViolation(err), err)
+
+	account, err = s.accountRepo.SetExternalID(ctx, s.testTenantID, account.ID, "sap", "")
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), account.ExternalIDs)
+	_, err = s.accountRepo.GetByExternalID(ctx, s.testTenantID, "sap", "930000")
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+
+	account, err = s.accountRepo.SetLabels(ctx, s.testTenantID, account.ID, map[string]string{"region": "apac"})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), map[string]string{"region": "apac"}, account.Labels)
+
+	var eventTypes []string
+	err = s.eventRepo.ReplayAggregate(ctx, s.testTenantID, AggregateAccount, account.ID, func(event *LedgerEvent) error {
+		eventTypes = append(eventTypes, event.EventType)
+		return nil
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{
+		EventAccountCreated, EventAccountLabelsSet, EventAccountExternalIDSet, EventAccountExternalIDSet, EventAccountLabelsSet,
+	}, eventTypes)
+}
+
+// TestAccountRepository_Merge tests moving the balance, holds, reservations
+// and overdraft limit of an account to another without touching its posted
+// lines or breaking the hash chain
+func (s *IntegrationTestSuite) TestAccountRepository_Merge() {
+	ctx := context.Background()
+
+	create := func(number string, accountTypeID int32) *Account {
+		account, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Merge " + number,
+			AccountTypeID: accountTypeID,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(s.T(), err)
+		return account
+	}
+	target := create("9980", 1)
+	source := create("9981", 1)
+	revenue := create("9982", 4)
+
+	posted, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "MERGE-001",
+		Description:     "Merged entry",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: source.ID, Debit: decimal.NewFromInt(40), Credit: decimal.Zero},
+			{AccountID: revenue.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(40)},
+		},
+	})
+	require.NoError(s.T(), err)
+
+	limit := decimal.NewFromInt(5)
+	_, err = s.accountRepo.SetOverdraftLimit(ctx, s.testTenantID, source.ID, &limit)
+	require.NoError(s.T(), err)
+
+	hold, err := s.holdRepo.Create(ctx, s.testTenantID, CreateHoldParams{
+		AccountID:            source.ID,
+		DestinationAccountID: revenue.ID,
+		Amount:               decimal.NewFromInt(10),
+		ExpiresAt:            time.Now().Add(time.Hour),
+	})
+	require.NoError(s.T(), err)
+
+	prepared, err := s.preparedRepo.Prepare(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "MERGE-002",
+		Description:     "Prepared entry",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: revenue.ID, Debit: decimal.NewFromInt(15), Credit: decimal.Zero},
+			{AccountID: source.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(15)},
+		},
+	}, time.Now().Add(time.Hour))
+	require.NoError(s.T(), err)
+
+	mergeDate := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	_, err = s.accountRepo.Merge(ctx, s.testTenantID, source.ID, revenue.ID, mergeDate)
+	assert.ErrorIs(s.T(), err, ErrAccountTypeMismatch)
+
+	merge, err := s.accountRepo.Merge(ctx, s.testTenantID, source.ID, target.ID, mergeDate)
+	require.NoError(s.T(), err)
+	assert.NotNil(s.T(), merge.Source.DeletedAt)
+	require.NotNil(s.T(), merge.Target.OverdraftLimit)
+	assert.True(s.T(), merge.Target.OverdraftLimit.Equal(limit))
+
+	// The balance moved with a reclassification entry
+	require.NotNil(s.T(), merge.ReclassificationEntryID)
+	reclassification, err := s.journalRepo.GetByID(ctx, s.testTenantID, *merge.ReclassificationEntryID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), reclassification.EntryDate.Equal(mergeDate))
+	assert.Equal(s.T(), MergeReference(source.AccountNumber, merge.ID), reclassification.ReferenceNumber)
+	require.Len(s.T(), reclassification.Lines, 2)
+	assert.Equal(s.T(), source.ID, reclassification.Lines[0].AccountID)
+	assert.True(s.T(), reclassification.Lines[0].Credit.Equal(decimal.NewFromInt(40)))
+	assert.Equal(s.T(), target.ID, reclassification.Lines[1].AccountID)
+	assert.True(s.T(), reclassification.Lines[1].Debit.Equal(decimal.NewFromInt(40)))
+
+	// The posted line stays on the account it was posted to
+	entry, err := s.journalRepo.GetByID(ctx, s.testTenantID, posted.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), source.ID, entry.Lines[0].AccountID)
+
+	balance, err := s.accountRepo.GetBalance(ctx, s.testTenantID, target.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), balance.DebitBalance.Equal(decimal.NewFromInt(40)))
+	balance, err = s.accountRepo.GetBalance(ctx, s.testTenantID, source.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), balance.DebitBalance.Equal(balance.CreditBalance))
+
+	// The hold and the reservation now take from the target
+	moved, err := s.holdRepo.GetByID(ctx, s.testTenantID, hold.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), target.ID, moved.AccountID)
+
+	reserved, err := s.preparedRepo.GetByID(ctx, s.testTenantID, prepared.ID)
+	require.NoError(s.T(), err)
+	reservedAccounts := make([]uuid.UUID, len(reserved.Reservations))
+	for i, reservation := range reserved.Reservations {
+		reservedAccounts[i] = reservation.AccountID
+	}
+	assert.ElementsMatch(s.T(), []uuid.UUID{revenue.ID, target.ID}, reservedAccounts)
+
+	available, err := s.accountRepo.GetAvailableBalance(ctx, s.testTenantID, target.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), available.Held.Equal(decimal.NewFromInt(25)))
+
+	integrity, err := s.journalRepo.VerifyIntegrity(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), integrity.Valid, integrity.Reason)
+}
+
 // TestAccountRepository_GetBalance tests retrieving account balance
 func (s *IntegrationTestSuite) TestAccountRepository_GetBalance() {
 	ctx := context.Background()
@@ -205,6 +676,183 @@ func (s *IntegrationTestSuite) TestAccountRepository_GetBalance() {
 	assert.Equal(s.T(), decimal.Zero, balance.CreditBalance)
 }
 
+// TestBookRepository_ParallelBooks tests keeping accounts and entries in
+// separate books of one tenant
+func (s *IntegrationTestSuite) TestBookRepository_ParallelBooks() {
+	ctx := context.Background()
+
+	books, err := s.bookRepo.List(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), books, 1)
+	assert.Equal(s.T(), DefaultBookCode, books[0].Code)
+	assert.True(s.T(), books[0].IsDefault)
+
+	ifrs, err := s.bookRepo.Create(ctx, s.testTenantID, CreateBookParams{Code: "IFRS", Name: "IFRS reporting"})
+	require.NoError(s.T(), err)
+
+	// The same account number may be used in each book
+	mainCash, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9990",
+		Name:          "Cash",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), books[0].ID, mainCash.BookID)
+
+	ifrsCash, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9990",
+		Name:          "Cash",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+		BookID:        &ifrs.ID,
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), ifrs.ID, ifrsCash.BookID)
+
+	ifrsEquity, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9991",
+		Name:          "Equity",
+		AccountTypeID: 3,
+		CurrencyCode:  "USD",
+		BookID:        &ifrs.ID,
+	})
+	require.NoError(s.T(), err)
+
+	lines := func(debit, credit uuid.UUID) []*CreateJournalEntryLineParams {
+		return []*CreateJournalEntryLineParams{
+			{AccountID: debit, Debit: decimal.NewFromInt(100), Credit: decimal.Zero},
+			{AccountID: credit, Debit: decimal.Zero, Credit: decimal.NewFromInt(100)},
+		}
+	}
+
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "BOOK-001",
+		EntryDate:       time.Now(),
+		BookID:          &ifrs.ID,
+		Lines:           lines(ifrsCash.ID, ifrsEquity.ID),
+	})
+	require.NoError(s.T(), err)
+
+	// An entry may not post to accounts of different books
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "BOOK-002",
+		EntryDate:       time.Now(),
+		Lines:           lines(mainCash.ID, ifrsEquity.ID),
+	})
+	assert.ErrorIs(s.T(), err, ErrBookMismatch)
+
+	// Reports cover the requested book, or else the default book
+	rows, err := s.reportRepo.GetTrialBalance(ctx, s.testTenantID, "IFRS", nil, time.Now().Add(time.Hour), nil)
+	require.NoError(s.T(), err)
+	assert.Len(s.T(), rows, 2)
+
+	rows, err = s.reportRepo.GetTrialBalance(ctx, s.testTenantID, "", nil, time.Now().Add(time.Hour), nil)
+	require.NoError(s.T(), err)
+	for _, row := range rows {
+		assert.NotEqual(s.T(), ifrsCash.ID, row.AccountID)
+	}
+}
+
+// TestInterestRepository_PostAccrual tests accruing interest on an account
+// with a scheme attached
+func (s *IntegrationTestSuite) TestInterestRepository_PostAccrual() {
+	ctx := context.Background()
+
+	account := func(number string, accountTypeID int32) *Account {
+		a, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeID: accountTypeID,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(s.T(), err)
+		return a
+	}
+	loan := account("9992", 1)
+	cash := account("9993", 1)
+	receivable := account("9994", 1)
+	income := account("9995", 4)
+
+	scheme, err := s.interestRepo.CreateScheme(ctx, s.testTenantID, InterestSchemeParams{
+		Code:              "LOAN",
+		Name:              "Term loan",
+		AnnualRate:        decimal.RequireFromString("0.05"),
+		DayCount:          "ACT_360",
+		AccrualAccountID:  receivable.ID,
+		InterestAccountID: income.ID,
+	})
+	require.NoError(s.T(), err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ai, err := s.interestRepo.AttachScheme(ctx, s.testTenantID, loan.ID, scheme.ID, start.AddDate(0, 0, -1))
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "9992", ai.AccountNumber)
+	assert.Equal(s.T(), int32(2), ai.Precision)
+
+	_, err = s.interestRepo.AttachScheme(ctx, s.testTenantID, loan.ID, scheme.ID, start)
+	assert.True(s.T(), IsUniqueViolation(err))
+
+	for _, day := range []int{1, 11} {
+		_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("DRAW-%d", day),
+			EntryDate:       time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: loan.ID, Debit: decimal.NewFromInt(1000), Credit: decimal.Zero},
+				{AccountID: cash.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(1000)},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+
+	end := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	due, err := s.interestRepo.ListDueAccruals(ctx, s.testTenantID, nil, end)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), due, 1)
+
+	opening, movements, err := s.interestRepo.GetDailyMovements(ctx, s.testTenantID, loan.ID, ai.AccruedThrough, end)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), opening.IsZero())
+	require.Len(s.T(), movements, 2)
+	assert.Equal(s.T(), time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC), movements[1].Date)
+
+	accrual := InterestAccrual{
+		AccountID:   loan.ID,
+		SchemeID:    scheme.ID,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Amount:      decimal.RequireFromString("7.08"),
+	}
+	entry := CreateJournalEntryParams{
+		ReferenceNumber: "INT-9992-20240131",
+		EntryDate:       end,
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: receivable.ID, Debit: accrual.Amount, Credit: decimal.Zero},
+			{AccountID: income.ID, Debit: decimal.Zero, Credit: accrual.Amount},
+		},
+	}
+
+	posted, err := s.interestRepo.PostAccrual(ctx, s.testTenantID, accrual, entry)
+	require.NoError(s.T(), err)
+	assert.NotEqual(s.T(), uuid.Nil, posted.JournalEntryID)
+
+	// The same period cannot be accrued twice
+	_, err = s.interestRepo.PostAccrual(ctx, s.testTenantID, accrual, entry)
+	assert.ErrorIs(s.T(), err, ErrInterestAccrued)
+
+	due, err = s.interestRepo.ListDueAccruals(ctx, s.testTenantID, nil, end)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), due)
+
+	accruals, total, err := s.interestRepo.ListAccruals(ctx, s.testTenantID, &loan.ID, 10, 0)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, total)
+	assert.Equal(s.T(), "7.08", accruals[0].Amount.String())
+
+	require.NoError(s.T(), s.interestRepo.DetachScheme(ctx, s.testTenantID, loan.ID))
+	assert.ErrorIs(s.T(), s.interestRepo.DetachScheme(ctx, s.testTenantID, loan.ID), ErrNotFound)
+}
+
 // TestJournalRepository_Create tests creating a journal entry
 func (s *IntegrationTestSuite) TestJournalRepository_Create() {
 	ctx := context.Background()
@@ -254,6 +902,7 @@ func (s *IntegrationTestSuite) TestJournalRepository_Create() {
 	assert.NotEqual(s.T(), uuid.Nil, entry.ID)
 	assert.Equal(s.T(), "TEST-001", entry.ReferenceNumber)
 	assert.Len(s.T(), entry.Lines, 2)
+	assert.False(s.T(), entry.PostedAt.IsZero())
 
 	// Verify balances were updated
 	balance1, err := s.accountRepo.GetBalance(ctx, s.testTenantID, account1.ID)
@@ -304,17 +953,2261 @@ func (s *IntegrationTestSuite) TestJournalRepository_GetByID() {
 	assert.Len(s.T(), entry.Lines, 2)
 }
 
-// TestReferenceRepository_ListAccountTypes tests listing account types
-func (s *IntegrationTestSuite) TestReferenceRepository_ListAccountTypes() {
+// TestJournalRepository_LockOverride tests that the reason an entry was
+// posted into a locked period is stored with it
+func (s *IntegrationTestSuite) TestJournalRepository_LockOverride() {
 	ctx := context.Background()
 
-	accountTypes, err := s.referenceRepo.ListAccountTypes(ctx)
+	account1, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8400",
+		Name:          "Override Account 1",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
 	require.NoError(s.T(), err)
 
-	// Assuming there are some account types in the database
-	assert.NotEmpty(s.T(), accountTypes)
-}
-
+	account2, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8500",
+		Name:          "Override Account 2",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	reason := "Late supplier invoice"
+	created, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "LOCK-001",
+		Description:     "Posted into a locked period",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: account1.ID, Debit: decimal.NewFromInt(20), Credit: decimal.Zero, Description: "Line 1"},
+			{AccountID: account2.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(20), Description: "Line 2"},
+		},
+		LockOverrideReason: &reason,
+	})
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), created.LockOverrideReason)
+	assert.Equal(s.T(), reason, *created.LockOverrideReason)
+
+	entries, _, err := s.journalRepo.List(ctx, s.testTenantID, JournalEntryFilter{}, 10, 0)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), entries, 1)
+	require.NotNil(s.T(), entries[0].LockOverrideReason)
+	assert.Equal(s.T(), reason, *entries[0].LockOverrideReason)
+}
+
+// TestJournalRepository_CurrencyCode tests that entries report the currency
+// of their amounts, whether recorded or taken from their lines
+func (s *IntegrationTestSuite) TestJournalRepository_CurrencyCode() {
+	ctx := context.Background()
+
+	usd, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8600",
+		Name:          "Currency USD",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	eur, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8700",
+		Name:          "Currency EUR",
+		AccountTypeID: 2,
+		CurrencyCode:  "EUR",
+	})
+	require.NoError(s.T(), err)
+
+	derived, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "CUR-001",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: usd.ID, Debit: decimal.NewFromInt(10), Credit: decimal.Zero},
+			{AccountID: usd.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(10)},
+		},
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "USD", derived.CurrencyCode)
+
+	// Every line is converted, so only the recorded currency names it
+	rate := decimal.RequireFromString("0.9")
+	recorded, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "CUR-002",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: eur.ID, Debit: decimal.NewFromInt(10), Credit: decimal.Zero, FxRate: &rate},
+			{AccountID: eur.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(10), FxRate: &rate},
+		},
+		CurrencyCode: "GBP",
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "GBP", recorded.CurrencyCode)
+}
+
+// TestJournalRepository_IdempotencyKey tests posting with an idempotency key
+func (s *IntegrationTestSuite) TestJournalRepository_IdempotencyKey() {
+	ctx := context.Background()
+
+	account1, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8600",
+		Name:          "Idempotency Account 1",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	account2, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8700",
+		Name:          "Idempotency Account 2",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	params := CreateJournalEntryParams{
+		ReferenceNumber: "IDEM-001",
+		Description:     "Idempotent entry",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: account1.ID, Debit: decimal.NewFromInt(10), Credit: decimal.Zero, Description: "Line 1"},
+			{AccountID: account2.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(10), Description: "Line 2"},
+		},
+		IdempotencyKey: "idem-" + uuid.New().String(),
+	}
+
+	entry, err := s.journalRepo.Create(ctx, s.testTenantID, params)
+	require.NoError(s.T(), err)
+
+	found, err := s.journalRepo.GetByIdempotencyKey(ctx, s.testTenantID, params.IdempotencyKey)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), entry.ID, found.ID)
+
+	params.ReferenceNumber = "IDEM-002"
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, params)
+	assert.True(s.T(), IsUniqueViolation(err))
+
+	_, err = s.journalRepo.GetByIdempotencyKey(ctx, s.testTenantID, "unknown")
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+}
+
+// TestHoldRepository_Capture tests that holds count towards the held amount
+// until they are captured, released or expire
+func (s *IntegrationTestSuite) TestHoldRepository_Capture() {
+	ctx := context.Background()
+
+	wallet, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8800",
+		Name:          "Hold Wallet",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	merchant, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8900",
+		Name:          "Hold Merchant",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	createHold := func(amount int64, expiresAt time.Time) *Hold {
+		hold, err := s.holdRepo.Create(ctx, s.testTenantID, CreateHoldParams{
+			AccountID:            wallet.ID,
+			DestinationAccountID: merchant.ID,
+			Amount:               decimal.NewFromInt(amount),
+			ReferenceNumber:      "AUTH",
+			ExpiresAt:            expiresAt,
+		})
+		require.NoError(s.T(), err)
+		return hold
+	}
+
+	captured := createHold(100, time.Now().Add(time.Hour))
+	released := createHold(30, time.Now().Add(time.Hour))
+	createHold(20, time.Now().Add(time.Hour))
+	expired := createHold(50, time.Now().Add(-time.Second))
+	assert.Equal(s.T(), HoldStatusExpired, expired.Status)
+
+	balance, err := s.accountRepo.GetAvailableBalance(ctx, s.testTenantID, wallet.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), balance.Held.Equal(decimal.NewFromInt(150)))
+
+	entry := CreateJournalEntryParams{
+		ReferenceNumber: "AUTH",
+		Description:     "Capture",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: wallet.ID, Debit: decimal.NewFromInt(60), Credit: decimal.Zero},
+			{AccountID: merchant.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(60)},
+		},
+	}
+
+	_, err = s.holdRepo.Capture(ctx, s.testTenantID, captured.ID, decimal.NewFromInt(120), entry)
+	assert.ErrorIs(s.T(), err, ErrCaptureExceedsHold)
+
+	hold, err := s.holdRepo.Capture(ctx, s.testTenantID, captured.ID, decimal.NewFromInt(60), entry)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), HoldStatusCaptured, hold.Status)
+	assert.True(s.T(), hold.CapturedAmount.Equal(decimal.NewFromInt(60)))
+	require.NotNil(s.T(), hold.JournalEntryID)
+
+	_, err = s.journalRepo.GetByID(ctx, s.testTenantID, *hold.JournalEntryID)
+	require.NoError(s.T(), err)
+
+	_, err = s.holdRepo.Capture(ctx, s.testTenantID, captured.ID, decimal.NewFromInt(10), entry)
+	assert.ErrorIs(s.T(), err, ErrHoldNotPending)
+
+	_, err = s.holdRepo.Release(ctx, s.testTenantID, released.ID)
+	require.NoError(s.T(), err)
+
+	_, err = s.holdRepo.Release(ctx, s.testTenantID, expired.ID)
+	assert.ErrorIs(s.T(), err, ErrHoldExpired)
+
+	balance, err = s.accountRepo.GetAvailableBalance(ctx, s.testTenantID, wallet.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), balance.Held.Equal(decimal.NewFromInt(20)))
+
+	status := HoldStatusExpired
+	holds, total, err := s.holdRepo.List(ctx, s.testTenantID, HoldFilter{AccountID: &wallet.ID, Status: &status}, 10, 0)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, total)
+	require.Len(s.T(), holds, 1)
+	assert.Equal(s.T(), expired.ID, holds[0].ID)
+}
+
+// TestAccountRepository_OverdraftLimit tests that postings and holds may not
+// take a limited account below its available balance
+func (s *IntegrationTestSuite) TestAccountRepository_OverdraftLimit() {
+	ctx := context.Background()
+
+	wallet, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9100",
+		Name:          "Limited Wallet",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	funding, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9200",
+		Name:          "Funding",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	limit := decimal.NewFromInt(10)
+	account, err := s.accountRepo.SetOverdraftLimit(ctx, s.testTenantID, wallet.ID, &limit)
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), account.OverdraftLimit)
+	assert.True(s.T(), account.OverdraftLimit.Equal(limit))
+
+	post := func(debitID, creditID uuid.UUID, amount int64) error {
+		_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: "OVD",
+			Description:     "Overdraft",
+			EntryDate:       time.Now(),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: debitID, Debit: decimal.NewFromInt(amount), Credit: decimal.Zero},
+				{AccountID: creditID, Debit: decimal.Zero, Credit: decimal.NewFromInt(amount)},
+			},
+		})
+		return err
+	}
+
+	// Deposit 50 into the wallet, then hold 30 of it
+	require.NoError(s.T(), post(funding.ID, wallet.ID, 50))
+	_, err = s.holdRepo.Create(ctx, s.testTenantID, CreateHoldParams{
+		AccountID:            wallet.ID,
+		DestinationAccountID: funding.ID,
+		Amount:               decimal.NewFromInt(30),
+		ExpiresAt:            time.Now().Add(time.Hour),
+	})
+	require.NoError(s.T(), err)
+
+	available, err := s.accountRepo.GetAvailableBalance(ctx, s.testTenantID, wallet.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), available.Booked.Equal(decimal.NewFromInt(50)))
+	assert.True(s.T(), available.Held.Equal(decimal.NewFromInt(30)))
+	assert.True(s.T(), available.Available.Equal(decimal.NewFromInt(30)))
+
+	assert.ErrorIs(s.T(), post(wallet.ID, funding.ID, 31), ErrInsufficientFunds)
+	require.NoError(s.T(), post(wallet.ID, funding.ID, 30))
+
+	_, err = s.holdRepo.Create(ctx, s.testTenantID, CreateHoldParams{
+		AccountID:            wallet.ID,
+		DestinationAccountID: funding.ID,
+		Amount:               decimal.NewFromInt(1),
+		ExpiresAt:            time.Now().Add(time.Hour),
+	})
+	assert.ErrorIs(s.T(), err, ErrInsufficientFunds)
+
+	// Deposits are allowed while the wallet has nothing available
+	require.NoError(s.T(), post(funding.ID, wallet.ID, 1))
+
+	// A wallet opened with a zero limit cannot be overdrawn by its first posting
+	zero := decimal.Zero
+	opened, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber:  "9101",
+		Name:           "Wallet Opened Limited",
+		AccountTypeID:  2,
+		CurrencyCode:   "USD",
+		OverdraftLimit: &zero,
+	})
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), opened.OverdraftLimit)
+	assert.ErrorIs(s.T(), post(opened.ID, funding.ID, 1), ErrInsufficientFunds)
+}
+
+// TestAccountRepository_OverdraftLimitByAccountType tests that the overdraft
+// limit the tenant settings give an account type applies to the accounts of
+// that type without a limit of their own
+func (s *IntegrationTestSuite) TestAccountRepository_OverdraftLimitByAccountType() {
+	ctx := context.Background()
+
+	wallet, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9110",
+		Name:          "Customer Wallet",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	limit := decimal.NewFromInt(5)
+	exempt, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber:  "9111",
+		Name:           "Wallet With Own Limit",
+		AccountTypeID:  2,
+		CurrencyCode:   "USD",
+		OverdraftLimit: &limit,
+	})
+	require.NoError(s.T(), err)
+
+	funding, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9210",
+		Name:          "Funding",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	post := func(debitID, creditID uuid.UUID, amount int64) error {
+		_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: "OVD",
+			Description:     "Overdraft",
+			EntryDate:       time.Now(),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: debitID, Debit: decimal.NewFromInt(amount), Credit: decimal.Zero},
+				{AccountID: creditID, Debit: decimal.Zero, Credit: decimal.NewFromInt(amount)},
+			},
+		})
+		return err
+	}
+
+	// Without a limit for the type, the wallet may go negative
+	require.NoError(s.T(), post(wallet.ID, funding.ID, 1))
+	require.NoError(s.T(), post(funding.ID, wallet.ID, 1))
+
+	_, err = s.settingsRepo.Upsert(ctx, &TenantSettings{
+		TenantID:        s.testTenantID,
+		Timezone:        DefaultTimezone,
+		Locale:          DefaultLocale,
+		Calendar:        DefaultCalendar,
+		OverdraftLimits: map[string]decimal.Decimal{AccountTypeLiability: decimal.Zero},
+	})
+	require.NoError(s.T(), err)
+
+	available, err := s.accountRepo.GetAvailableBalance(ctx, s.testTenantID, wallet.ID)
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), available.OverdraftLimit)
+	assert.True(s.T(), available.OverdraftLimit.IsZero())
+
+	assert.ErrorIs(s.T(), post(wallet.ID, funding.ID, 1), ErrInsufficientFunds)
+	_, err = s.holdRepo.Create(ctx, s.testTenantID, CreateHoldParams{
+		AccountID:            wallet.ID,
+		DestinationAccountID: funding.ID,
+		Amount:               decimal.NewFromInt(1),
+		ExpiresAt:            time.Now().Add(time.Hour),
+	})
+	assert.ErrorIs(s.T(), err, ErrInsufficientFunds)
+
+	// The account's own limit takes precedence over its type's
+	require.NoError(s.T(), post(exempt.ID, funding.ID, 5))
+	assert.ErrorIs(s.T(), post(exempt.ID, funding.ID, 1), ErrInsufficientFunds)
+}
+
+// TestPreparedEntryRepository_Confirm tests that prepared entries reserve
+// funds until they are confirmed, cancelled or expire
+func (s *IntegrationTestSuite) TestPreparedEntryRepository_Confirm() {
+	ctx := context.Background()
+
+	zero := decimal.Zero
+	wallet, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber:  "9300",
+		Name:           "Saga Wallet",
+		AccountTypeID:  2,
+		CurrencyCode:   "USD",
+		OverdraftLimit: &zero,
+	})
+	require.NoError(s.T(), err)
+
+	funding, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9400",
+		Name:          "Saga Funding",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	entry := func(debitID, creditID uuid.UUID, amount int64) CreateJournalEntryParams {
+		return CreateJournalEntryParams{
+			ReferenceNumber: "SAGA",
+			Description:     "Order payment",
+			EntryDate:       time.Now(),
+			TransactionID:   "order-1",
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: debitID, Debit: decimal.NewFromInt(amount), Credit: decimal.Zero},
+				{AccountID: creditID, Debit: decimal.Zero, Credit: decimal.NewFromInt(amount)},
+			},
+		}
+	}
+
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, entry(funding.ID, wallet.ID, 50))
+	require.NoError(s.T(), err)
+
+	// Preparing reserves 40 of the wallet without posting
+	prepared, err := s.preparedRepo.Prepare(ctx, s.testTenantID, entry(wallet.ID, funding.ID, 40), time.Now().Add(time.Hour))
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), PreparedEntryStatusPending, prepared.Status)
+	require.Len(s.T(), prepared.Reservations, 1)
+	assert.Equal(s.T(), wallet.ID, prepared.Reservations[0].AccountID)
+	assert.True(s.T(), prepared.Reservations[0].Amount.Equal(decimal.NewFromInt(40)))
+
+	available, err := s.accountRepo.GetAvailableBalance(ctx, s.testTenantID, wallet.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), available.Booked.Equal(decimal.NewFromInt(50)))
+	assert.True(s.T(), available.Held.Equal(decimal.NewFromInt(40)))
+
+	// The reservation counts against other postings and preparations
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, entry(wallet.ID, funding.ID, 11))
+	assert.ErrorIs(s.T(), err, ErrInsufficientFunds)
+	_, err = s.preparedRepo.Prepare(ctx, s.testTenantID, entry(wallet.ID, funding.ID, 11), time.Now().Add(time.Hour))
+	assert.ErrorIs(s.T(), err, ErrInsufficientFunds)
+
+	// Cancelling frees the reservation and cannot be undone by a confirmation
+	cancelled, err := s.preparedRepo.Prepare(ctx, s.testTenantID, entry(wallet.ID, funding.ID, 10), time.Now().Add(time.Hour))
+	require.NoError(s.T(), err)
+	cancelled, err = s.preparedRepo.Cancel(ctx, s.testTenantID, cancelled.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), PreparedEntryStatusCancelled, cancelled.Status)
+	_, err = s.preparedRepo.Confirm(ctx, s.testTenantID, cancelled.ID)
+	assert.ErrorIs(s.T(), err, ErrPreparedEntryNotPending)
+
+	// Confirming posts the entry once, however often it is retried
+	confirmed, err := s.preparedRepo.Confirm(ctx, s.testTenantID, prepared.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), PreparedEntryStatusConfirmed, confirmed.Status)
+	require.NotNil(s.T(), confirmed.JournalEntryID)
+
+	retried, err := s.preparedRepo.Confirm(ctx, s.testTenantID, prepared.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), *confirmed.JournalEntryID, *retried.JournalEntryID)
+
+	_, err = s.preparedRepo.Cancel(ctx, s.testTenantID, prepared.ID)
+	assert.ErrorIs(s.T(), err, ErrPreparedEntryNotPending)
+
+	available, err = s.accountRepo.GetAvailableBalance(ctx, s.testTenantID, wallet.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), available.Booked.Equal(decimal.NewFromInt(10)))
+	assert.True(s.T(), available.Held.IsZero())
+
+	// Expired entries no longer reserve funds and cannot be confirmed
+	expired, err := s.preparedRepo.Prepare(ctx, s.testTenantID, entry(wallet.ID, funding.ID, 10), time.Now().Add(time.Second))
+	require.NoError(s.T(), err)
+	time.Sleep(time.Second)
+	_, err = s.preparedRepo.Confirm(ctx, s.testTenantID, expired.ID)
+	assert.ErrorIs(s.T(), err, ErrPreparedEntryExpired)
+
+	expired, err = s.preparedRepo.GetByID(ctx, s.testTenantID, expired.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), PreparedEntryStatusExpired, expired.Status)
+}
+
+// TestJournalBatchRepository_Post tests that a batch is validated entry by
+// entry and posts all of its entries or none
+func (s *IntegrationTestSuite) TestJournalBatchRepository_Post() {
+	ctx := context.Background()
+
+	zero := decimal.Zero
+	payroll, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber:  "9310",
+		Name:           "Payroll Clearing",
+		AccountTypeID:  2,
+		CurrencyCode:   "USD",
+		OverdraftLimit: &zero,
+	})
+	require.NoError(s.T(), err)
+
+	salaries, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9410",
+		Name:          "Salaries",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	entry := func(reference string, debitID, creditID uuid.UUID, amount int64) CreateJournalEntryParams {
+		return CreateJournalEntryParams{
+			ReferenceNumber: reference,
+			Description:     "September payroll",
+			EntryDate:       time.Now(),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: debitID, Debit: decimal.NewFromInt(amount), Credit: decimal.Zero},
+				{AccountID: creditID, Debit: decimal.Zero, Credit: decimal.NewFromInt(amount)},
+			},
+		}
+	}
+
+	batch, err := s.batchRepo.Create(ctx, s.testTenantID, "Payroll 2026-09", "")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), JournalBatchStatusOpen, batch.Status)
+
+	_, err = s.batchRepo.Approve(ctx, s.testTenantID, batch.ID)
+	assert.ErrorIs(s.T(), err, ErrBatchEmpty)
+
+	// Funding 100 into the clearing account lets only the first payout post
+	// after it
+	for _, params := range []CreateJournalEntryParams{
+		entry("PAY-FUND", salaries.ID, payroll.ID, 100),
+		entry("PAY-1", payroll.ID, salaries.ID, 60),
+		entry("PAY-2", payroll.ID, salaries.ID, 60),
+	} {
+		batch, _, err = s.batchRepo.AddEntry(ctx, s.testTenantID, batch.ID, params)
+		require.NoError(s.T(), err)
+	}
+	assert.Equal(s.T(), 3, batch.EntryCount)
+	assert.True(s.T(), batch.TotalDebit.Equal(decimal.NewFromInt(220)))
+	require.Len(s.T(), batch.Entries, 3)
+	assert.Equal(s.T(), "PAY-2", batch.Entries[2].Entry.ReferenceNumber)
+
+	problems, err := s.batchRepo.Validate(ctx, s.testTenantID, batch.ID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), problems, 1)
+	assert.Equal(s.T(), 3, problems[0].Entry.Position)
+	assert.ErrorIs(s.T(), problems[0].Err, ErrInsufficientFunds)
+
+	_, err = s.batchRepo.Approve(ctx, s.testTenantID, batch.ID)
+	assert.ErrorIs(s.T(), err, ErrInsufficientFunds)
+	_, err = s.batchRepo.Post(ctx, s.testTenantID, batch.ID)
+	assert.ErrorIs(s.T(), err, ErrBatchNotApproved)
+
+	// Validation posted nothing
+	available, err := s.accountRepo.GetAvailableBalance(ctx, s.testTenantID, payroll.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), available.Booked.IsZero())
+
+	// A rejected batch takes no more entries and cannot be posted
+	rejected, err := s.batchRepo.Reject(ctx, s.testTenantID, batch.ID, "second payout exceeds funding")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), JournalBatchStatusRejected, rejected.Status)
+	require.NotNil(s.T(), rejected.RejectionReason)
+	_, _, err = s.batchRepo.AddEntry(ctx, s.testTenantID, batch.ID, entry("PAY-3", payroll.ID, salaries.ID, 1))
+	assert.ErrorIs(s.T(), err, ErrBatchNotOpen)
+	_, err = s.batchRepo.Approve(ctx, s.testTenantID, batch.ID)
+	assert.ErrorIs(s.T(), err, ErrBatchNotOpen)
+
+	// A valid batch posts every entry once, however often it is retried
+	batch, err = s.batchRepo.Create(ctx, s.testTenantID, "Payroll 2026-10", "")
+	require.NoError(s.T(), err)
+	for _, params := range []CreateJournalEntryParams{
+		entry("PAY-FUND-2", salaries.ID, payroll.ID, 100),
+		entry("PAY-4", payroll.ID, salaries.ID, 60),
+		entry("PAY-5", payroll.ID, salaries.ID, 40),
+	} {
+		_, _, err = s.batchRepo.AddEntry(ctx, s.testTenantID, batch.ID, params)
+		require.NoError(s.T(), err)
+	}
+
+	approved, err := s.batchRepo.Approve(ctx, s.testTenantID, batch.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), JournalBatchStatusApproved, approved.Status)
+	require.NotNil(s.T(), approved.ApprovedAt)
+
+	posted, err := s.batchRepo.Post(ctx, s.testTenantID, batch.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), JournalBatchStatusPosted, posted.Status)
+	for _, batchEntry := range posted.Entries {
+		require.NotNil(s.T(), batchEntry.JournalEntryID)
+		journalEntry, err := s.journalRepo.GetByID(ctx, s.testTenantID, *batchEntry.JournalEntryID)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), batchEntry.Entry.ReferenceNumber, journalEntry.ReferenceNumber)
+	}
+
+	retried, err := s.batchRepo.Post(ctx, s.testTenantID, batch.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), *posted.Entries[0].JournalEntryID, *retried.Entries[0].JournalEntryID)
+	_, err = s.batchRepo.Reject(ctx, s.testTenantID, batch.ID, "too late")
+	assert.ErrorIs(s.T(), err, ErrBatchPosted)
+
+	available, err = s.accountRepo.GetAvailableBalance(ctx, s.testTenantID, payroll.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), available.Booked.IsZero())
+
+	postedStatus := JournalBatchStatusPosted
+	batches, total, err := s.batchRepo.List(ctx, s.testTenantID, &postedStatus, 10, 0)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, total)
+	require.Len(s.T(), batches, 1)
+	assert.Equal(s.T(), batch.ID, batches[0].ID)
+}
+
+// TestAccountRepository_GetProjectedBalances tests that projected balances
+// count draft batch entries and holds next to the booked balance
+func (s *IntegrationTestSuite) TestAccountRepository_GetProjectedBalances() {
+	ctx := context.Background()
+
+	treasury, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9320",
+		Name:          "Treasury",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	payable, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9420",
+		Name:          "Salaries Payable",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	entry := func(debitID, creditID uuid.UUID, amount int64) CreateJournalEntryParams {
+		return CreateJournalEntryParams{
+			ReferenceNumber: "PROJ",
+			Description:     "Projection",
+			EntryDate:       time.Now(),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: debitID, Debit: decimal.NewFromInt(amount), Credit: decimal.Zero},
+				{AccountID: creditID, Debit: decimal.Zero, Credit: decimal.NewFromInt(amount)},
+			},
+		}
+	}
+
+	// Book 500 to treasury, hold 50 of it and draft a payout of 120 from it
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, entry(treasury.ID, payable.ID, 500))
+	require.NoError(s.T(), err)
+	_, err = s.holdRepo.Create(ctx, s.testTenantID, CreateHoldParams{
+		AccountID:            treasury.ID,
+		DestinationAccountID: payable.ID,
+		Amount:               decimal.NewFromInt(50),
+		ExpiresAt:            time.Now().Add(time.Hour),
+	})
+	require.NoError(s.T(), err)
+
+	batch, err := s.batchRepo.Create(ctx, s.testTenantID, "Payouts", "")
+	require.NoError(s.T(), err)
+	_, _, err = s.batchRepo.AddEntry(ctx, s.testTenantID, batch.ID, entry(payable.ID, treasury.ID, 120))
+	require.NoError(s.T(), err)
+
+	// Drafts of rejected batches are not counted
+	rejected, err := s.batchRepo.Create(ctx, s.testTenantID, "Rejected payouts", "")
+	require.NoError(s.T(), err)
+	_, _, err = s.batchRepo.AddEntry(ctx, s.testTenantID, rejected.ID, entry(payable.ID, treasury.ID, 70))
+	require.NoError(s.T(), err)
+	_, err = s.batchRepo.Reject(ctx, s.testTenantID, rejected.ID, "duplicate")
+	require.NoError(s.T(), err)
+
+	balances, err := s.accountRepo.GetProjectedBalances(ctx, s.testTenantID, []uuid.UUID{payable.ID, treasury.ID})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), balances, 2)
+
+	assert.Equal(s.T(), payable.ID, balances[0].AccountID)
+	assert.True(s.T(), balances[0].Booked.Equal(decimal.NewFromInt(500)))
+	assert.True(s.T(), balances[0].Drafts.Equal(decimal.NewFromInt(-120)))
+	assert.True(s.T(), balances[0].Projected.Equal(decimal.NewFromInt(380)))
+
+	assert.Equal(s.T(), treasury.ID, balances[1].AccountID)
+	assert.True(s.T(), balances[1].Booked.Equal(decimal.NewFromInt(500)))
+	assert.True(s.T(), balances[1].Held.Equal(decimal.NewFromInt(50)))
+	assert.True(s.T(), balances[1].Drafts.Equal(decimal.NewFromInt(-120)))
+	assert.True(s.T(), balances[1].Pending.Equal(decimal.NewFromInt(-170)))
+	assert.True(s.T(), balances[1].Projected.Equal(decimal.NewFromInt(330)))
+	assert.True(s.T(), balances[1].Available.Equal(decimal.NewFromInt(450)))
+
+	// Posting the batch moves its drafts into the booked balance
+	_, err = s.batchRepo.Approve(ctx, s.testTenantID, batch.ID)
+	require.NoError(s.T(), err)
+	_, err = s.batchRepo.Post(ctx, s.testTenantID, batch.ID)
+	require.NoError(s.T(), err)
+
+	balances, err = s.accountRepo.GetProjectedBalances(ctx, s.testTenantID, []uuid.UUID{treasury.ID})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), balances, 1)
+	assert.True(s.T(), balances[0].Booked.Equal(decimal.NewFromInt(380)))
+	assert.True(s.T(), balances[0].Drafts.IsZero())
+	assert.True(s.T(), balances[0].Projected.Equal(decimal.NewFromInt(330)))
+
+	_, err = s.accountRepo.GetProjectedBalances(ctx, s.testTenantID, []uuid.UUID{treasury.ID, uuid.New()})
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+}
+
+// TestJournalRepository_ListByTransactionID tests grouping entries under a transaction ID
+func (s *IntegrationTestSuite) TestJournalRepository_ListByTransactionID() {
+	ctx := context.Background()
+
+	account1, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9300",
+		Name:          "Transaction Account 1",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	account2, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9400",
+		Name:          "Transaction Account 2",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	transactionID := "order-" + uuid.New().String()
+	for _, reference := range []string{"TXN-001", "TXN-002"} {
+		_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: reference,
+			Description:     "Grouped entry",
+			EntryDate:       time.Now(),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: account1.ID, Debit: decimal.NewFromInt(10), Credit: decimal.Zero, Description: "Line 1"},
+				{AccountID: account2.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(10), Description: "Line 2"},
+			},
+			TransactionID: transactionID,
+		})
+		require.NoError(s.T(), err)
+	}
+
+	entries, err := s.journalRepo.ListByTransactionID(ctx, s.testTenantID, transactionID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), entries, 2)
+	assert.Equal(s.T(), "TXN-001", entries[0].ReferenceNumber)
+	assert.Equal(s.T(), "TXN-002", entries[1].ReferenceNumber)
+	assert.Len(s.T(), entries[0].Lines, 2)
+	assert.Equal(s.T(), transactionID, entries[0].TransactionID)
+	assert.Equal(s.T(), account1.BookID, entries[0].BookID)
+
+	entries, err = s.journalRepo.ListByTransactionID(ctx, s.testTenantID, "unknown")
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), entries)
+}
+
+// TestJournalRepository_ListTotals tests the totals returned with a page of entries
+func (s *IntegrationTestSuite) TestJournalRepository_ListTotals() {
+	ctx := context.Background()
+
+	account1, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9700",
+		Name:          "Totals Account 1",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	account2, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9800",
+		Name:          "Totals Account 2",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	for i, amount := range []int64{10, 20, 30} {
+		_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("TOT-%03d", i+1),
+			Description:     "Totalled entry",
+			EntryDate:       time.Now(),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: account1.ID, Debit: decimal.NewFromInt(amount), Credit: decimal.Zero, Description: "Line 1"},
+				{AccountID: account2.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(amount), Description: "Line 2"},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+
+	entries, totals, err := s.journalRepo.List(ctx, s.testTenantID, JournalEntryFilter{AccountID: &account1.ID}, 2, 0)
+	require.NoError(s.T(), err)
+	assert.Len(s.T(), entries, 2)
+	assert.Equal(s.T(), 3, totals.EntryCount)
+	assert.Equal(s.T(), "60", totals.Debit.String())
+	assert.Equal(s.T(), "0", totals.Credit.String())
+
+	// Each entry of the page gets its own lines
+	for _, entry := range entries {
+		require.Len(s.T(), entry.Lines, 2)
+		for _, line := range entry.Lines {
+			assert.Equal(s.T(), entry.ID, line.JournalEntryID)
+		}
+		assert.True(s.T(), entry.Lines[0].Debit.Equal(entry.Lines[1].Credit))
+	}
+}
+
+// TestJournalRepository_CreateManyLines tests posting an entry with many lines, loaded with COPY
+func (s *IntegrationTestSuite) TestJournalRepository_CreateManyLines() {
+	ctx := context.Background()
+
+	account1, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9900",
+		Name:          "Bulk Account 1",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	account2, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9910",
+		Name:          "Bulk Account 2",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	const pairs = 100
+	lines := make([]*CreateJournalEntryLineParams, 0, 2*pairs)
+	for i := 1; i <= pairs; i++ {
+		lines = append(lines,
+			&CreateJournalEntryLineParams{AccountID: account1.ID, Debit: decimal.NewFromInt(int64(i)), Credit: decimal.Zero, Description: fmt.Sprintf("Debit %d", i)},
+			&CreateJournalEntryLineParams{AccountID: account2.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(int64(i)), Description: fmt.Sprintf("Credit %d", i),
+				Dimensions: map[string]string{"BATCH": "B1"}},
+		)
+	}
+
+	entry, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "BULK-001",
+		Description:     "Bulk entry",
+		EntryDate:       time.Now(),
+		Lines:           lines,
+	})
+	require.NoError(s.T(), err)
+	assert.Len(s.T(), entry.Lines, 2*pairs)
+
+	// The sum of 1..n on each side
+	total := int64(pairs * (pairs + 1) / 2)
+	balance, err := s.accountRepo.GetBalance(ctx, s.testTenantID, account1.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), balance.DebitBalance.Equal(decimal.NewFromInt(total)))
+
+	balance, err = s.accountRepo.GetBalance(ctx, s.testTenantID, account2.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), balance.CreditBalance.Equal(decimal.NewFromInt(total)))
+
+	lines[0] = &CreateJournalEntryLineParams{AccountID: account1.ID, Debit: decimal.NewFromInt(1000), Credit: decimal.Zero}
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "BULK-002",
+		Description:     "Unbalanced bulk entry",
+		EntryDate:       time.Now(),
+		Lines:           lines,
+	})
+	assert.ErrorIs(s.T(), err, ErrUnbalancedEntry)
+}
+
+// TestJournalRepository_VerifyIntegrity tests that posted entries form a valid hash chain
+func (s *IntegrationTestSuite) TestJournalRepository_VerifyIntegrity() {
+	ctx := context.Background()
+
+	account1, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8000",
+		Name:          "Chain Account 1",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	account2, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8100",
+		Name:          "Chain Account 2",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	for i := 1; i <= 3; i++ {
+		_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("CHAIN-%03d", i),
+			Description:     "Chained entry",
+			EntryDate:       time.Now(),
+			Metadata:        map[string]interface{}{"batch": i},
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: account1.ID, Debit: decimal.NewFromInt(int64(i)), Credit: decimal.Zero, Description: "Line 1"},
+				{AccountID: account2.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(int64(i)), Description: "Line 2"},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+
+	result, err := s.journalRepo.VerifyIntegrity(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+
+	assert.True(s.T(), result.Valid, result.Reason)
+	assert.Equal(s.T(), 3, result.EntriesVerified)
+	assert.Equal(s.T(), int64(3), result.HeadSequence)
+	assert.Len(s.T(), result.HeadHash, 32)
+	assert.Nil(s.T(), result.FirstInvalidEntryID)
+}
+
+// TestJournalRepository_AppendOnly tests that the db-schema triggers reject
+// changes to posted entries and lines, including the columns the hash covers
+// beyond the amounts
+func (s *IntegrationTestSuite) TestJournalRepository_AppendOnly() {
+	ctx := context.Background()
+
+	account1, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8200",
+		Name:          "Append Only Account 1",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	account2, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8300",
+		Name:          "Append Only Account 2",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	entry, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "APPEND-001",
+		Description:     "Append only entry",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: account1.ID, Debit: decimal.NewFromInt(10), Credit: decimal.Zero, Dimensions: map[string]string{"region": "EU"}},
+			{AccountID: account2.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(10)},
+		},
+	})
+	require.NoError(s.T(), err)
+
+	updates := []string{
+		"UPDATE journal_entries SET description = 'Changed' WHERE id = $1",
+		"UPDATE journal_entry_lines SET debit = debit + 1 WHERE journal_entry_id = $1",
+		"UPDATE journal_entry_lines SET dimensions = '{\"region\": \"US\"}' WHERE journal_entry_id = $1",
+		"UPDATE journal_entry_lines SET account_id = account_id WHERE journal_entry_id = $1",
+		"DELETE FROM journal_entry_lines WHERE journal_entry_id = $1",
+	}
+	_, conn, err := s.db.WithTenant(ctx, s.testTenantID.String())
+	require.NoError(s.T(), err)
+	defer conn.Release()
+	for _, update := range updates {
+		_, err := conn.Exec(ctx, update, entry.ID)
+		assert.Error(s.T(), err, update)
+	}
+
+	result, err := s.journalRepo.VerifyIntegrity(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), result.Valid, result.Reason)
+}
+
+// TestJournalRepository_PostingParity posts the same entries through the Go
+// posting path and the legacy create_journal_entry function, and checks
+// both store the same lines and balances and reject the same entries
+func (s *IntegrationTestSuite) TestJournalRepository_PostingParity() {
+	ctx := context.Background()
+
+	legacyCfg := *s.dbConfig
+	legacyCfg.SQLFunctions = true
+	legacyDB, err := db.New(ctx, &legacyCfg)
+	require.NoError(s.T(), err)
+	defer legacyDB.Close()
+	legacyRepo := NewJournalRepository(legacyDB)
+
+	createAccount := func(number, currency string, typeID int32) *Account {
+		account, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Parity " + number,
+			AccountTypeID: typeID,
+			CurrencyCode:  currency,
+		})
+		require.NoError(s.T(), err)
+		return account
+	}
+	cash := createAccount("8500", "USD", 1)
+	sales := createAccount("8510", "USD", 2)
+	euroCash := createAccount("8520", "EUR", 1)
+	euroSales := createAccount("8530", "EUR", 2)
+
+	rate := decimal.RequireFromString("0.92")
+	params := func(reference string) CreateJournalEntryParams {
+		return CreateJournalEntryParams{
+			ReferenceNumber: reference,
+			Description:     "Parity entry",
+			EntryDate:       time.Now(),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(100), Credit: decimal.Zero, Dimensions: map[string]string{"region": "EU"}},
+				{AccountID: sales.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(100)},
+				{AccountID: euroCash.ID, Debit: decimal.NewFromInt(92), Credit: decimal.Zero, FxRate: &rate},
+				{AccountID: euroSales.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(92), FxRate: &rate},
+			},
+		}
+	}
+
+	posted, err := s.journalRepo.Create(ctx, s.testTenantID, params("PARITY-GO"))
+	require.NoError(s.T(), err)
+	legacyPosted, err := legacyRepo.Create(ctx, s.testTenantID, params("PARITY-SQL"))
+	require.NoError(s.T(), err)
+
+	entry, err := s.journalRepo.GetByID(ctx, s.testTenantID, posted.ID)
+	require.NoError(s.T(), err)
+	legacyEntry, err := s.journalRepo.GetByID(ctx, s.testTenantID, legacyPosted.ID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), legacyEntry.Lines, len(entry.Lines))
+	for i, line := range entry.Lines {
+		legacyLine := legacyEntry.Lines[i]
+		assert.Equal(s.T(), line.AccountID, legacyLine.AccountID)
+		assert.True(s.T(), line.Debit.Equal(legacyLine.Debit))
+		assert.True(s.T(), line.Credit.Equal(legacyLine.Credit))
+		assert.Equal(s.T(), line.FxRate != nil, legacyLine.FxRate != nil)
+		if line.FxRate != nil && legacyLine.FxRate != nil {
+			assert.True(s.T(), line.FxRate.Equal(*legacyLine.FxRate))
+		}
+		assert.Equal(s.T(), len(line.Dimensions), len(legacyLine.Dimensions))
+	}
+
+	// Each path added its entry to the balances once
+	balance, err := s.accountRepo.GetBalance(ctx, s.testTenantID, cash.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), balance.DebitBalance.Equal(decimal.NewFromInt(200)))
+	balance, err = s.accountRepo.GetBalance(ctx, s.testTenantID, euroSales.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), balance.CreditBalance.Equal(decimal.NewFromInt(184)))
+
+	// Debits and credits in different currencies do not balance each other
+	crossCurrency := CreateJournalEntryParams{
+		ReferenceNumber: "PARITY-FX",
+		Description:     "Cross currency entry",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: cash.ID, Debit: decimal.NewFromInt(100), Credit: decimal.Zero},
+			{AccountID: euroSales.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(100), FxRate: &rate},
+		},
+	}
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, crossCurrency)
+	assert.ErrorIs(s.T(), err, ErrUnbalancedEntry)
+	_, err = legacyRepo.Create(ctx, s.testTenantID, crossCurrency)
+	assert.ErrorIs(s.T(), err, ErrUnbalancedEntry)
+}
+
+// TestDigestRepository_Compute tests computing daily digests over the hash chain
+func (s *IntegrationTestSuite) TestDigestRepository_Compute() {
+	ctx := context.Background()
+
+	account1, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9940",
+		Name:          "Digest Account 1",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	account2, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9950",
+		Name:          "Digest Account 2",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	for i := 1; i <= 3; i++ {
+		_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("DIGEST-%03d", i),
+			Description:     "Digested entry",
+			EntryDate:       time.Now(),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: account1.ID, Debit: decimal.NewFromInt(int64(i)), Credit: decimal.Zero, Description: "Line 1"},
+				{AccountID: account2.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(int64(i)), Description: "Line 2"},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+
+	today := time.Now()
+	digest, err := s.digestRepo.Compute(ctx, s.testTenantID, today, true)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 3, digest.EntryCount)
+	assert.Equal(s.T(), int64(3), digest.LastSequence)
+	assert.Len(s.T(), digest.MerkleRoot, 32)
+
+	// Computing the same day again returns the stored digest
+	again, err := s.digestRepo.Compute(ctx, s.testTenantID, today, true)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), digest.ID, again.ID)
+
+	// The next day starts after the last entry of today's digest
+	next, err := s.digestRepo.Compute(ctx, s.testTenantID, today.AddDate(0, 0, 1), false)
+	require.NoError(s.T(), err)
+	assert.Zero(s.T(), next.EntryCount)
+	assert.Equal(s.T(), int64(3), next.LastSequence)
+
+	stored, err := s.digestRepo.Get(ctx, s.testTenantID, today)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), digest.MerkleRoot, stored.MerkleRoot)
+	assert.True(s.T(), stored.Published)
+
+	_, err = s.digestRepo.Compute(ctx, s.testTenantID, today.AddDate(0, 0, -1), false)
+	assert.Error(s.T(), err)
+}
+
+// TestEventDeliveryRepository tests dead-lettering and replaying an event delivery
+func (s *IntegrationTestSuite) TestEventDeliveryRepository() {
+	ctx := context.Background()
+
+	_, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9960",
+		Name:          "Delivered Account",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+	sequence, err := s.eventRepo.LastSequence(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+
+	pending, err := s.deliveryRepo.Pending(ctx, s.testTenantID, 1000)
+	require.NoError(s.T(), err)
+	require.NotEmpty(s.T(), pending)
+	assert.Equal(s.T(), sequence, pending[len(pending)-1].Event.Sequence)
+	assert.Equal(s.T(), EventDeliveryPending, pending[len(pending)-1].Status)
+
+	// A failed attempt leaves the event pending until it is dead-lettered
+	require.NoError(s.T(), s.deliveryRepo.MarkFailed(ctx, s.testTenantID, sequence, "webhook responded 503", false))
+	delivery, err := s.deliveryRepo.Get(ctx, s.testTenantID, sequence)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), EventDeliveryPending, delivery.Status)
+	assert.Equal(s.T(), 1, delivery.Attempts)
+
+	require.NoError(s.T(), s.deliveryRepo.MarkFailed(ctx, s.testTenantID, sequence, "webhook responded 500", true))
+	failed, err := s.deliveryRepo.ListFailed(ctx, s.testTenantID, sequence-1, 10)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), failed, 1)
+	assert.Equal(s.T(), 2, failed[0].Attempts)
+	assert.Equal(s.T(), "webhook responded 500", *failed[0].LastError)
+	assert.Equal(s.T(), EventAccountCreated, failed[0].Event.EventType)
+
+	backlog, err := s.deliveryRepo.Backlog(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1), backlog.Failed)
+
+	// A replayed event is pending again with its attempts reset
+	replayed, err := s.deliveryRepo.Replay(ctx, s.testTenantID, &sequence)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1), replayed)
+	delivery, err = s.deliveryRepo.Get(ctx, s.testTenantID, sequence)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), EventDeliveryPending, delivery.Status)
+	assert.Zero(s.T(), delivery.Attempts)
+
+	require.NoError(s.T(), s.deliveryRepo.MarkDelivered(ctx, s.testTenantID, sequence))
+	delivery, err = s.deliveryRepo.Get(ctx, s.testTenantID, sequence)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), EventDeliveryDelivered, delivery.Status)
+	assert.NotNil(s.T(), delivery.DeliveredAt)
+
+	_, err = s.deliveryRepo.Replay(ctx, s.testTenantID, &sequence)
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+}
+
+// TestBalanceRepository_Rebuild tests that balances kept by postings match their journal lines
+func (s *IntegrationTestSuite) TestBalanceRepository_Rebuild() {
+	ctx := context.Background()
+
+	account1, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8200",
+		Name:          "Rebuild Account 1",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	account2, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8300",
+		Name:          "Rebuild Account 2",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "REBUILD-001",
+		Description:     "Rebuild entry",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: account1.ID, Debit: decimal.NewFromInt(75), Credit: decimal.Zero, Description: "Line 1"},
+			{AccountID: account2.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(75), Description: "Line 2"},
+		},
+	})
+	require.NoError(s.T(), err)
+
+	result, err := s.balanceRepo.Rebuild(ctx, s.testTenantID, nil)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, result.AccountsChecked)
+	assert.Empty(s.T(), result.Discrepancies)
+	assert.Zero(s.T(), result.AccountsRestored)
+	assert.Zero(s.T(), result.PeriodTotalsCorrected)
+
+	// Lost period totals are restored from the journal lines
+	_, err = s.db.Pool().Exec(ctx, "DELETE FROM account_period_totals WHERE account_id = $1", account1.ID)
+	require.NoError(s.T(), err)
+	result, err = s.balanceRepo.Rebuild(ctx, s.testTenantID, nil)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, result.PeriodTotalsCorrected)
+
+	unknown := uuid.New()
+	_, err = s.balanceRepo.Rebuild(ctx, s.testTenantID, &unknown)
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+}
+
+// eventTotals projects balances from JournalEntryPosted events for
+// RebuildFromEvents, as internal/projection does
+type eventTotals map[uuid.UUID][2]decimal.Decimal
+
+func (t eventTotals) Apply(event *LedgerEvent) error {
+	if event.EventType != EventJournalEntryPosted {
+		return nil
+	}
+	var payload JournalEntryPostedPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+	for _, line := range payload.Lines {
+		totals := t[line.AccountID]
+		t[line.AccountID] = [2]decimal.Decimal{totals[0].Add(line.Debit), totals[1].Add(line.Credit)}
+	}
+	return nil
+}
+
+func (t eventTotals) Totals(accountID uuid.UUID) (decimal.Decimal, decimal.Decimal) {
+	return t[accountID][0], t[accountID][1]
+}
+
+// eventAccounts projects accounts from their creation events for
+// RebuildFromEvents, as internal/projection does more fully
+type eventAccounts struct {
+	accounts []*Account
+}
+
+func (a *eventAccounts) Apply(event *LedgerEvent) error {
+	if event.EventType != EventAccountCreated {
+		return nil
+	}
+	var payload AccountCreatedPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+	a.accounts = append(a.accounts, &Account{
+		ID:              event.AggregateID,
+		BookID:          payload.BookID,
+		AccountNumber:   payload.AccountNumber,
+		Name:            payload.Name,
+		Description:     payload.Description,
+		AccountTypeID:   payload.AccountTypeID,
+		CurrencyCode:    payload.CurrencyCode,
+		ParentAccountID: payload.ParentAccountID,
+		IsActive:        !payload.Inactive,
+		CreatedAt:       event.RecordedAt,
+		OverdraftLimit:  payload.OverdraftLimit,
+		Labels:          map[string]string{},
+		ExternalIDs:     map[string]string{},
+	})
+	return nil
+}
+
+func (a *eventAccounts) Accounts() []*Account {
+	return a.accounts
+}
+
+// TestBalanceRepository_RebuildFromEvents tests restoring lost balances
+// from the event store
+func (s *IntegrationTestSuite) TestBalanceRepository_RebuildFromEvents() {
+	ctx := context.Background()
+
+	cash, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8210",
+		Name:          "Replayed Cash",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	sales, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8310",
+		Name:          "Replayed Sales",
+		AccountTypeID: 4,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	for i, amount := range []int64{75, 25} {
+		_, err = s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("REPLAY-%03d", i+1),
+			Description:     "Replayed entry",
+			EntryDate:       time.Now(),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(amount), Credit: decimal.Zero},
+				{AccountID: sales.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(amount)},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+
+	// Matching balances are left alone
+	result, err := s.balanceRepo.RebuildFromEvents(ctx, s.testTenantID, nil, eventTotals{}, &eventAccounts{})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, result.AccountsChecked)
+	assert.Empty(s.T(), result.Discrepancies)
+
+	// One balance is wrong and the other lost; both come back from the events
+	_, err = s.db.Pool().Exec(ctx, "UPDATE account_balances SET debit_balance = 0 WHERE account_id = $1", cash.ID)
+	require.NoError(s.T(), err)
+	_, err = s.db.Pool().Exec(ctx, "DELETE FROM account_balances WHERE account_id = $1", sales.ID)
+	require.NoError(s.T(), err)
+
+	result, err = s.balanceRepo.RebuildFromEvents(ctx, s.testTenantID, nil, eventTotals{}, &eventAccounts{})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), result.Discrepancies, 2)
+	assert.Zero(s.T(), result.PeriodTotalsCorrected)
+
+	cashBalance, err := s.accountRepo.GetBalance(ctx, s.testTenantID, cash.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "100", cashBalance.DebitBalance.String())
+	salesBalance, err := s.accountRepo.GetBalance(ctx, s.testTenantID, sales.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "100", salesBalance.CreditBalance.String())
+
+	// The replayed balances agree with the journal lines
+	lines, err := s.balanceRepo.Rebuild(ctx, s.testTenantID, nil)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), lines.Discrepancies)
+
+	// The accounts table is a cache of the account events too
+	_, err = s.db.Pool().Exec(ctx, "UPDATE accounts SET name = 'Drifted' WHERE id = $1", cash.ID)
+	require.NoError(s.T(), err)
+	result, err = s.balanceRepo.RebuildFromEvents(ctx, s.testTenantID, nil, eventTotals{}, &eventAccounts{})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, result.AccountsRestored)
+	assert.Empty(s.T(), result.Discrepancies)
+	restored, err := s.accountRepo.GetByID(ctx, s.testTenantID, cash.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "Replayed Cash", restored.Name)
+
+	unknown := uuid.New()
+	_, err = s.balanceRepo.RebuildFromEvents(ctx, s.testTenantID, &unknown, eventTotals{}, &eventAccounts{})
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+}
+
+// TestBalanceListener_Listen tests receiving balance changes of postings
+func (s *IntegrationTestSuite) TestBalanceListener_Listen() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	account1, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8400",
+		Name:          "Notify Account 1",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	account2, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8500",
+		Name:          "Notify Account 2",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	listening := make(chan struct{})
+	changes := make(chan BalanceChange, 10)
+	go NewBalanceListener(s.db).Listen(ctx, func() { close(listening) }, func(change BalanceChange) {
+		changes <- change
+	})
+	<-listening
+
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "NOTIFY-001",
+		Description:     "Notify entry",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: account1.ID, Debit: decimal.NewFromInt(30), Credit: decimal.Zero, Description: "Line 1"},
+			{AccountID: account2.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(30), Description: "Line 2"},
+		},
+	})
+	require.NoError(s.T(), err)
+
+	received := make(map[uuid.UUID]bool)
+	for len(received) < 2 {
+		select {
+		case change := <-changes:
+			assert.Equal(s.T(), s.testTenantID, change.TenantID)
+			received[change.AccountID] = true
+		case <-ctx.Done():
+			s.T().Fatal("balance changes were not received")
+		}
+	}
+	assert.True(s.T(), received[account1.ID])
+	assert.True(s.T(), received[account2.ID])
+}
+
+// TestConsistencyRepository_Check tests checking ledger invariants
+func (s *IntegrationTestSuite) TestConsistencyRepository_Check() {
+	ctx := context.Background()
+
+	account1, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8400",
+		Name:          "Consistency Account 1",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	account2, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8500",
+		Name:          "Consistency Account 2",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "CONSISTENCY-001",
+		Description:     "Consistency entry",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: account1.ID, Debit: decimal.NewFromInt(40), Credit: decimal.Zero, Description: "Line 1"},
+			{AccountID: account2.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(40), Description: "Line 2"},
+		},
+	})
+	require.NoError(s.T(), err)
+
+	report, err := s.consistencyRepo.Check(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), report.Consistent(), "violations: %v", report.Violations)
+	assert.True(s.T(), report.TotalDebit.Equal(report.TotalCredit))
+}
+
+// TestConsistencyRepository_VerifyBalances tests verifying a tenant's balances
+func (s *IntegrationTestSuite) TestConsistencyRepository_VerifyBalances() {
+	ctx := context.Background()
+
+	account1, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9920",
+		Name:          "Verification Account 1",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	account2, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9930",
+		Name:          "Verification Account 2",
+		AccountTypeID: 2,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "VERIFY-001",
+		Description:     "Verification entry",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: account1.ID, Debit: decimal.NewFromInt(65), Credit: decimal.Zero, Description: "Line 1"},
+			{AccountID: account2.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(65), Description: "Line 2"},
+		},
+	})
+	require.NoError(s.T(), err)
+
+	verification, err := s.consistencyRepo.VerifyBalances(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), verification.Verified(), "discrepancies: %v", verification.Discrepancies)
+	assert.GreaterOrEqual(s.T(), verification.AccountsVerified, 2)
+	assert.True(s.T(), verification.BalanceDebit.Equal(verification.LineDebit))
+}
+
+// TestTaxCodeRepository_GetReport tests reporting tax posted with a tax code
+func (s *IntegrationTestSuite) TestTaxCodeRepository_GetReport() {
+	ctx := context.Background()
+
+	accounts := make([]*Account, 3)
+	for i, number := range []string{"8600", "8700", "8800"} {
+		account, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Tax Account " + number,
+			AccountTypeID: 1,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(s.T(), err)
+		accounts[i] = account
+	}
+	receivable, revenue, vat := accounts[0], accounts[1], accounts[2]
+
+	code, err := s.taxRepo.Create(ctx, s.testTenantID, TaxCodeParams{
+		Code:         "VAT20",
+		Name:         "VAT 20%",
+		Type:         TaxTypeSales,
+		Rate:         decimal.RequireFromString("0.2"),
+		TaxAccountID: vat.ID,
+	})
+	require.NoError(s.T(), err)
+	assert.True(s.T(), code.IsActive)
+
+	entryDate := time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC)
+	_, err = s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+		ReferenceNumber: "TAX-001",
+		Description:     "Taxed sale",
+		EntryDate:       entryDate,
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: receivable.ID, Debit: decimal.NewFromInt(120), Credit: decimal.Zero},
+			{AccountID: revenue.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(100), TaxCodeID: &code.ID},
+			{AccountID: vat.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(20), TaxCodeID: &code.ID, IsTax: true},
+		},
+	})
+	require.NoError(s.T(), err)
+
+	report, err := s.taxRepo.GetReport(ctx, s.testTenantID, entryDate.AddDate(0, 0, -1), entryDate)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), report, 1)
+	assert.Equal(s.T(), "100", report[0].TaxableAmount().String())
+	assert.Equal(s.T(), "20", report[0].TaxAmount().String())
+}
+
+// TestFixedAssetRepository_PostDepreciation tests posting a schedule line of a fixed asset
+func (s *IntegrationTestSuite) TestFixedAssetRepository_PostDepreciation() {
+	ctx := context.Background()
+
+	accounts := make([]*Account, 3)
+	for i, number := range []string{"1500", "1590", "6500"} {
+		account, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Asset Account " + number,
+			AccountTypeID: 1,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(s.T(), err)
+		accounts[i] = account
+	}
+	equipment, accumulated, expense := accounts[0], accounts[1], accounts[2]
+
+	asset, err := s.assetRepo.Create(ctx, s.testTenantID, CreateFixedAssetParams{
+		Code:                             "FA-001",
+		Name:                             "Laptop",
+		AssetAccountID:                   equipment.ID,
+		AccumulatedDepreciationAccountID: accumulated.ID,
+		DepreciationExpenseAccountID:     expense.ID,
+		Method:                           "STRAIGHT_LINE",
+		Cost:                             decimal.NewFromInt(1200),
+		SalvageValue:                     decimal.Zero,
+		UsefulLifeMonths:                 2,
+		InServiceDate:                    time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		Schedule: []*DepreciationLine{
+			{Period: 1, PeriodDate: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), Amount: decimal.NewFromInt(600), Accumulated: decimal.NewFromInt(600), BookValue: decimal.NewFromInt(600)},
+			{Period: 2, PeriodDate: time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC), Amount: decimal.NewFromInt(600), Accumulated: decimal.NewFromInt(1200), BookValue: decimal.Zero},
+		},
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), asset.Schedule, 2)
+
+	due, err := s.assetRepo.ListDueDepreciation(ctx, s.testTenantID, &asset.ID, time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(s.T(), err)
+	require.Len(s.T(), due, 1)
+
+	entry := CreateJournalEntryParams{
+		ReferenceNumber: "FA-001-DEP-001",
+		Description:     "Depreciation of Laptop, period 1",
+		EntryDate:       due[0].PeriodDate,
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: expense.ID, Debit: due[0].Amount, Credit: decimal.Zero},
+			{AccountID: accumulated.ID, Debit: decimal.Zero, Credit: due[0].Amount},
+		},
+	}
+	line, err := s.assetRepo.PostDepreciation(ctx, s.testTenantID, due[0].ID, entry)
+	require.NoError(s.T(), err)
+	assert.NotNil(s.T(), line.JournalEntryID)
+
+	_, err = s.assetRepo.PostDepreciation(ctx, s.testTenantID, due[0].ID, entry)
+	assert.ErrorIs(s.T(), err, ErrDepreciationPosted)
+
+	asset, err = s.assetRepo.GetByID(ctx, s.testTenantID, asset.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), AssetStatusActive, asset.Status)
+	assert.Equal(s.T(), "600", asset.BookValue().String())
+}
+
+// TestPartyRepository_GetStatement tests balances and statements of a party
+func (s *IntegrationTestSuite) TestPartyRepository_GetStatement() {
+	ctx := context.Background()
+
+	accounts := make([]*Account, 2)
+	for i, number := range []string{"1200", "4200"} {
+		account, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Party Account " + number,
+			AccountTypeID: 1,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(s.T(), err)
+		accounts[i] = account
+	}
+	receivable, revenue := accounts[0], accounts[1]
+
+	party, err := s.partyRepo.Create(ctx, s.testTenantID, PartyParams{
+		Code: "CUST-001",
+		Name: "Acme",
+		Type: PartyTypeCustomer,
+	})
+	require.NoError(s.T(), err)
+
+	for i, day := range []int{5, 15} {
+		_, err = s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("INV-%03d", i+1),
+			Description:     "Invoice",
+			EntryDate:       time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: receivable.ID, Debit: decimal.NewFromInt(100), Credit: decimal.Zero, PartyID: &party.ID},
+				{AccountID: revenue.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(100)},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+
+	balances, err := s.partyRepo.GetBalances(ctx, s.testTenantID, party.ID, nil)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), balances, 1)
+	assert.Equal(s.T(), receivable.ID, balances[0].AccountID)
+	assert.Equal(s.T(), AccountTypeAsset, balances[0].AccountTypeCode)
+	assert.Equal(s.T(), "200", balances[0].Balance().String())
+
+	statement, err := s.partyRepo.GetStatement(ctx, s.testTenantID, party.ID, receivable.ID,
+		time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "100", statement.OpeningBalance.String())
+	require.Len(s.T(), statement.Lines, 1)
+	assert.Equal(s.T(), "200", statement.Lines[0].Balance.String())
+	assert.Equal(s.T(), "200", statement.ClosingBalance.String())
+
+	deleted, err := s.partyRepo.Delete(ctx, s.testTenantID, party.ID)
+	require.NoError(s.T(), err)
+	assert.NotNil(s.T(), deleted.DeletedAt)
+}
+
+// TestReportRepository_GetAccountStatement tests statements of an account
+// and recording the statement runs that deliver them
+func (s *IntegrationTestSuite) TestReportRepository_GetAccountStatement() {
+	ctx := context.Background()
+
+	accounts := make([]*Account, 2)
+	for i, number := range []string{"1300", "4300"} {
+		account, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Statement Account " + number,
+			AccountTypeID: 1,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(s.T(), err)
+		accounts[i] = account
+	}
+	cash, revenue := accounts[0], accounts[1]
+
+	for i, day := range []int{5, 15, 20} {
+		_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("SALE-%03d", i+1),
+			Description:     "Sale",
+			EntryDate:       time.Date(2026, 4, day, 0, 0, 0, 0, time.UTC),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(50), Credit: decimal.Zero},
+				{AccountID: revenue.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(50)},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+
+	from, to := time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC)
+	statement, err := s.reportRepo.GetAccountStatement(ctx, s.testTenantID, cash.ID, from, to)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "50", statement.OpeningBalance.String())
+	require.Len(s.T(), statement.Lines, 2)
+	assert.Equal(s.T(), "SALE-002", statement.Lines[0].ReferenceNumber)
+	assert.Equal(s.T(), "100", statement.Lines[0].Balance.String())
+	assert.Equal(s.T(), "150", statement.ClosingBalance.String())
+
+	run, err := s.runRepo.Create(ctx, s.testTenantID, StatementRunParams{
+		AccountIDs: []uuid.UUID{cash.ID},
+		FromDate:   from,
+		ToDate:     to,
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), StatementRunPending, run.Status)
+	assert.Empty(s.T(), run.PartyIDs)
+
+	statements := []DeliveredStatement{{AccountID: cash.ID, File: "cash.pdf"}}
+	require.NoError(s.T(), s.runRepo.UpdateStatus(ctx, s.testTenantID, run.ID, StatementRunCompleted, statements, nil))
+
+	run, err = s.runRepo.GetByID(ctx, s.testTenantID, run.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), StatementRunCompleted, run.Status)
+	assert.Equal(s.T(), statements, run.Statements)
+	assert.NotNil(s.T(), run.CompletedAt)
+
+	_, err = s.runRepo.GetByID(ctx, s.testTenantID, uuid.New())
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+}
+
+// TestDimensionRepository_GetBalances tests grouping balances by dimension values
+func (s *IntegrationTestSuite) TestDimensionRepository_GetBalances() {
+	ctx := context.Background()
+
+	accounts := make([]*Account, 2)
+	for i, number := range []string{"6100", "1010"} {
+		account, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Dimension Account " + number,
+			AccountTypeID: 1,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(s.T(), err)
+		accounts[i] = account
+	}
+	expense, cash := accounts[0], accounts[1]
+
+	dimension, err := s.dimensionRepo.Create(ctx, s.testTenantID, DimensionParams{
+		Code:          "COST_CENTER",
+		Name:          "Cost center",
+		AllowedValues: []string{"OPS", "SALES"},
+	})
+	require.NoError(s.T(), err)
+	assert.True(s.T(), dimension.IsActive)
+
+	dimensions, err := s.dimensionRepo.GetByCodes(ctx, s.testTenantID, []string{"COST_CENTER", "REGION"})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), dimensions, 1)
+	assert.Equal(s.T(), []string{"OPS", "SALES"}, dimensions["COST_CENTER"].AllowedValues)
+
+	entryDate := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	for i, values := range []map[string]string{{"COST_CENTER": "OPS"}, {"COST_CENTER": "OPS"}, nil} {
+		_, err = s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("DIM-%03d", i+1),
+			Description:     "Expense",
+			EntryDate:       entryDate,
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: expense.ID, Debit: decimal.NewFromInt(40), Credit: decimal.Zero, Dimensions: values},
+				{AccountID: cash.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(40)},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+
+	balances, err := s.dimensionRepo.GetBalances(ctx, s.testTenantID, DimensionBalanceFilter{
+		GroupBy:   []string{"COST_CENTER"},
+		AccountID: &expense.ID,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), balances, 2)
+	assert.Equal(s.T(), "OPS", balances[0].Values["COST_CENTER"])
+	assert.Equal(s.T(), AccountTypeAsset, balances[0].AccountTypeCode)
+	assert.Equal(s.T(), "80", balances[0].Balance().String())
+	assert.Empty(s.T(), balances[1].Values)
+	assert.Equal(s.T(), "40", balances[1].Balance().String())
+}
+
+// TestReportRepository_PeriodTotals tests that reports summed from the period
+// totals match the journal lines, including partial months
+func (s *IntegrationTestSuite) TestReportRepository_PeriodTotals() {
+	ctx := context.Background()
+
+	cash, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8600",
+		Name:          "Projected Cash",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	revenue, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8700",
+		Name:          "Projected Revenue",
+		AccountTypeID: 4,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2023, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	for i, entryDate := range []time.Time{date(1, 10), date(1, 20), date(2, 5), date(3, 1), date(3, 25)} {
+		_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("PROJ-%d", i),
+			Description:     "Projected sale",
+			EntryDate:       entryDate,
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(10), Credit: decimal.Zero},
+				{AccountID: revenue.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(10)},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+
+	var months int
+	err = s.db.Pool().QueryRow(ctx, "SELECT COUNT(*) FROM account_period_totals WHERE account_id = $1", cash.ID).Scan(&months)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 3, months)
+
+	// January 15 to March 10 reads February whole and the edges from lines
+	from := date(1, 15)
+	rows, err := s.reportRepo.GetTrialBalance(ctx, s.testTenantID, "", &from, date(3, 10), nil)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), rows, 2)
+	assert.Equal(s.T(), cash.ID, rows[0].AccountID)
+	assert.True(s.T(), rows[0].Debit.Equal(decimal.NewFromInt(30)))
+	assert.True(s.T(), rows[1].Credit.Equal(decimal.NewFromInt(30)))
+
+	// Reading as posted uses the journal lines and agrees
+	postedAsOf := time.Now().Add(time.Hour)
+	asPosted, err := s.reportRepo.GetTrialBalance(ctx, s.testTenantID, "", &from, date(3, 10), &postedAsOf)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), asPosted, 2)
+	assert.True(s.T(), asPosted[0].Debit.Equal(rows[0].Debit))
+
+	to := date(3, 24)
+	aggregates, err := s.reportRepo.AggregateJournalLines(ctx, s.testTenantID, LineAggregateFilter{
+		AccountID: &cash.ID,
+		Period:    AggregatePeriodMonth,
+		ToDate:    &to,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), aggregates, 3)
+	assert.Equal(s.T(), int64(2), aggregates[0].LineCount)
+	assert.True(s.T(), aggregates[0].Debit.Equal(decimal.NewFromInt(20)))
+	assert.Equal(s.T(), int64(1), aggregates[2].LineCount)
+}
+
+// TestReportRepository_ScheduledPeriodTotals tests that with scheduled
+// refreshes posted entries are queued, reported as pending, and added to
+// the period totals by a refresh
+func (s *IntegrationTestSuite) TestReportRepository_ScheduledPeriodTotals() {
+	ctx := context.Background()
+
+	cfg := *s.dbConfig
+	cfg.PeriodTotals = config.PeriodTotalsConfig{Refresh: config.PeriodTotalsScheduled, Interval: time.Minute}
+	scheduled, err := db.New(ctx, &cfg)
+	require.NoError(s.T(), err)
+	defer scheduled.Close()
+	journalRepo := NewJournalRepository(scheduled)
+	reportRepo := NewReportRepository(scheduled)
+
+	cash, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8800",
+		Name:          "Scheduled Cash",
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	revenue, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "8900",
+		Name:          "Scheduled Revenue",
+		AccountTypeID: 4,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(s.T(), err)
+
+	before := time.Now().Add(-time.Minute)
+	for i := 0; i < 2; i++ {
+		_, err := journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("SCHED-%d", i),
+			Description:     "Scheduled sale",
+			EntryDate:       time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(10), Credit: decimal.Zero},
+				{AccountID: revenue.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(10)},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+
+	var months int
+	err = s.db.Pool().QueryRow(ctx, "SELECT COUNT(*) FROM account_period_totals WHERE account_id = $1", cash.ID).Scan(&months)
+	require.NoError(s.T(), err)
+	assert.Zero(s.T(), months)
+
+	freshness, err := reportRepo.GetFreshness(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), freshness.Scheduled)
+	assert.Equal(s.T(), int64(2), freshness.PendingEntries)
+	assert.True(s.T(), freshness.AsOf.After(before))
+
+	refreshed, err := reportRepo.RefreshPeriodTotals(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, refreshed)
+
+	freshness, err = reportRepo.GetFreshness(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	assert.Zero(s.T(), freshness.PendingEntries)
+
+	aggregates, err := reportRepo.AggregateJournalLines(ctx, s.testTenantID, LineAggregateFilter{AccountID: &cash.ID})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), aggregates, 1)
+	assert.Equal(s.T(), int64(2), aggregates[0].LineCount)
+	assert.True(s.T(), aggregates[0].Debit.Equal(decimal.NewFromInt(20)))
+}
+
+// TestJournalRepository_EncryptedMetadata tests that the metadata of a
+// tenant that encrypts it is stored sealed and read back in plain
+func (s *IntegrationTestSuite) TestJournalRepository_EncryptedMetadata() {
+	ctx := context.Background()
+
+	cfg := *s.dbConfig
+	cfg.MetadataEncryption = config.MetadataEncryptionConfig{
+		Keyring:   config.KeyringLocal,
+		Keys:      map[string]string{"2026": "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="},
+		ActiveKey: "2026",
+	}
+	encrypted, err := db.New(ctx, &cfg)
+	require.NoError(s.T(), err)
+	defer encrypted.Close()
+	journalRepo := NewJournalRepository(encrypted)
+
+	tenant, err := s.tenantRepo.Create(ctx, "Encrypted Metadata Tenant", nil)
+	require.NoError(s.T(), err)
+	_, err = s.settingsRepo.Upsert(ctx, &TenantSettings{
+		TenantID:        tenant.ID,
+		Timezone:        DefaultTimezone,
+		Locale:          DefaultLocale,
+		Calendar:        DefaultCalendar,
+		EncryptMetadata: true,
+	})
+	require.NoError(s.T(), err)
+
+	cash, err := s.accountRepo.Create(ctx, tenant.ID, CreateAccountParams{
+		AccountNumber: "1000", Name: "Cash", AccountTypeID: 1, CurrencyCode: "USD",
+	})
+	require.NoError(s.T(), err)
+	revenue, err := s.accountRepo.Create(ctx, tenant.ID, CreateAccountParams{
+		AccountNumber: "4000", Name: "Revenue", AccountTypeID: 4, CurrencyCode: "USD",
+	})
+	require.NoError(s.T(), err)
+
+	entry, err := journalRepo.Create(ctx, tenant.ID, CreateJournalEntryParams{
+		ReferenceNumber: "PII-1",
+		EntryDate:       time.Now(),
+		Metadata:        map[string]interface{}{"customer": "Jane Doe"},
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: cash.ID, Debit: decimal.NewFromInt(10), Credit: decimal.Zero},
+			{AccountID: revenue.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(10)},
+		},
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "Jane Doe", entry.Metadata["customer"])
+
+	var stored string
+	_, conn, err := s.db.WithTenant(ctx, tenant.ID.String())
+	require.NoError(s.T(), err)
+	err = conn.QueryRow(ctx, "SELECT metadata::text FROM journal_entries WHERE id = $1", entry.ID).Scan(&stored)
+	conn.Release()
+	require.NoError(s.T(), err)
+	assert.Contains(s.T(), stored, SealedMetadataKey)
+	assert.NotContains(s.T(), stored, "Jane Doe")
+
+	// Without the keyring the entry cannot be read
+	_, err = s.journalRepo.GetByID(ctx, tenant.ID, entry.ID)
+	assert.ErrorIs(s.T(), err, errNoMetadataKeyring)
+
+	result, err := journalRepo.VerifyIntegrity(ctx, tenant.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), result.Valid)
+}
+
+// TestTenantDataRepository_SnapshotAndPurge tests exporting a tenant's data
+// and purging it with a confirmation token
+func (s *IntegrationTestSuite) TestTenantDataRepository_SnapshotAndPurge() {
+	ctx := context.Background()
+	tenantID := s.testTenantID
+
+	cash, err := s.accountRepo.Create(ctx, tenantID, CreateAccountParams{
+		AccountNumber: "1000", Name: "Cash", AccountTypeID: 1, CurrencyCode: "USD",
+	})
+	require.NoError(s.T(), err)
+	revenue, err := s.accountRepo.Create(ctx, tenantID, CreateAccountParams{
+		AccountNumber: "4000", Name: "Revenue", AccountTypeID: 4, CurrencyCode: "USD",
+	})
+	require.NoError(s.T(), err)
+	_, err = s.journalRepo.Create(ctx, tenantID, CreateJournalEntryParams{
+		ReferenceNumber: "GDPR-1",
+		EntryDate:       time.Now(),
+		Lines: []*CreateJournalEntryLineParams{
+			{AccountID: cash.ID, Debit: decimal.NewFromInt(10), Credit: decimal.Zero},
+			{AccountID: revenue.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(10)},
+		},
+	})
+	require.NoError(s.T(), err)
+
+	var accounts, lines, balances int
+	err = s.tenantDataRepo.Snapshot(ctx, tenantID, func(snapshot TenantSnapshot) error {
+		if err := snapshot.Accounts(ctx, func(*Account) error { accounts++; return nil }); err != nil {
+			return err
+		}
+		if err := snapshot.JournalEntries(ctx, func(e *JournalEntry) error { lines += len(e.Lines); return nil }); err != nil {
+			return err
+		}
+		return snapshot.Balances(ctx, func(*AccountBalance) error { balances++; return nil })
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, accounts)
+	assert.Equal(s.T(), 2, lines)
+	assert.Equal(s.T(), 2, balances)
+
+	// Only deleted tenants can be purged
+	_, err = s.tenantDataRepo.RequestPurge(ctx, tenantID)
+	assert.ErrorIs(s.T(), err, ErrTenantNotDeleted)
+
+	_, err = s.tenantRepo.Delete(ctx, tenantID)
+	require.NoError(s.T(), err)
+
+	request, err := s.tenantDataRepo.RequestPurge(ctx, tenantID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(2), request.Rows["journal_entry_lines"])
+
+	_, err = s.tenantDataRepo.Purge(ctx, tenantID, "not-the-token")
+	assert.ErrorIs(s.T(), err, ErrPurgeTokenInvalid)
+
+	purge, err := s.tenantDataRepo.Purge(ctx, tenantID, request.Token)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(2), purge.Rows["accounts"])
+	assert.Equal(s.T(), int64(1), purge.Rows["journal_entries"])
+
+	_, err = s.tenantDataRepo.Purge(ctx, tenantID, request.Token)
+	assert.ErrorIs(s.T(), err, ErrTenantPurged)
+
+	_, err = s.tenantRepo.Restore(ctx, tenantID)
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+}
+
+// TestTenantDataRepository_PurgeExpiredTest tests marking a tenant as test
+// and purging it once its retention has passed
+func (s *IntegrationTestSuite) TestTenantDataRepository_PurgeExpiredTest() {
+	ctx := context.Background()
+	tenantID := s.testTenantID
+
+	_, err := s.accountRepo.Create(ctx, tenantID, CreateAccountParams{
+		AccountNumber: "1000", Name: "Cash", AccountTypeID: 1, CurrencyCode: "USD",
+	})
+	require.NoError(s.T(), err)
+
+	// Only test tenants expire
+	_, err = s.tenantDataRepo.PurgeExpiredTest(ctx, tenantID, time.Now().Add(time.Hour))
+	assert.ErrorIs(s.T(), err, ErrTestTenantNotExpired)
+
+	tenant, err := s.tenantRepo.SetTestMode(ctx, tenantID, true)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), tenant.IsTest)
+	require.NotNil(s.T(), tenant.TestSince)
+
+	testIDs, err := s.tenantRepo.ListTestIDs(ctx)
+	require.NoError(s.T(), err)
+	assert.Contains(s.T(), testIDs, tenantID)
+
+	expiredIDs, err := s.tenantRepo.ListExpiredTestIDs(ctx, tenant.TestSince.Add(-time.Minute))
+	require.NoError(s.T(), err)
+	assert.NotContains(s.T(), expiredIDs, tenantID)
+	_, err = s.tenantDataRepo.PurgeExpiredTest(ctx, tenantID, tenant.TestSince.Add(-time.Minute))
+	assert.ErrorIs(s.T(), err, ErrTestTenantNotExpired)
+
+	before := tenant.TestSince.Add(time.Minute)
+	expiredIDs, err = s.tenantRepo.ListExpiredTestIDs(ctx, before)
+	require.NoError(s.T(), err)
+	assert.Contains(s.T(), expiredIDs, tenantID)
+
+	purge, err := s.tenantDataRepo.PurgeExpiredTest(ctx, tenantID, before)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1), purge.Rows["accounts"])
+
+	_, err = s.tenantRepo.GetByID(ctx, tenantID)
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+	expiredIDs, err = s.tenantRepo.ListExpiredTestIDs(ctx, before)
+	require.NoError(s.T(), err)
+	assert.NotContains(s.T(), expiredIDs, tenantID)
+}
+
+// TestTenantDataRepository_Clone tests cloning a tenant into a test tenant
+// with its balances or its history
+func (s *IntegrationTestSuite) TestTenantDataRepository_Clone() {
+	ctx := context.Background()
+	tenantID := s.testTenantID
+
+	assets, err := s.accountRepo.Create(ctx, tenantID, CreateAccountParams{
+		AccountNumber: "1000", Name: "Assets", AccountTypeID: 1, CurrencyCode: "USD",
+	})
+	require.NoError(s.T(), err)
+	cash, err := s.accountRepo.Create(ctx, tenantID, CreateAccountParams{
+		AccountNumber: "1100", Name: "Cash", AccountTypeID: 1, CurrencyCode: "USD", ParentAccountID: &assets.ID,
+	})
+	require.NoError(s.T(), err)
+	revenue, err := s.accountRepo.Create(ctx, tenantID, CreateAccountParams{
+		AccountNumber: "4000", Name: "Revenue", AccountTypeID: 4, CurrencyCode: "USD",
+	})
+	require.NoError(s.T(), err)
+	for i := 0; i < 2; i++ {
+		_, err = s.journalRepo.Create(ctx, tenantID, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("SALE-%d", i),
+			EntryDate:       time.Now(),
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(25), Credit: decimal.Zero},
+				{AccountID: revenue.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(25)},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+
+	cashBalance := func(clone *TenantClone) decimal.Decimal {
+		prefix := "1100"
+		accounts, _, err := s.accountRepo.List(ctx, clone.Tenant.ID, AccountFilter{NumberPrefix: &prefix}, 10, 0)
+		require.NoError(s.T(), err)
+		require.Len(s.T(), accounts, 1)
+		require.NotNil(s.T(), accounts[0].ParentAccountID)
+		balance, err := s.accountRepo.GetBalance(ctx, clone.Tenant.ID, accounts[0].ID)
+		require.NoError(s.T(), err)
+		return balance.DebitBalance.Sub(balance.CreditBalance)
+	}
+	cleanup := func(clone *TenantClone) {
+		_, err := s.db.Pool().Exec(ctx, "DELETE FROM tenants WHERE id = $1", clone.Tenant.ID)
+		require.NoError(s.T(), err)
+	}
+
+	balances, err := s.tenantDataRepo.Clone(ctx, CloneTenantParams{SourceTenantID: tenantID, Name: "Sandbox Balances", Mode: CloneBalances})
+	require.NoError(s.T(), err)
+	defer cleanup(balances)
+	assert.True(s.T(), balances.Tenant.IsTest)
+	assert.Equal(s.T(), &tenantID, balances.Tenant.ClonedFromID)
+	assert.Equal(s.T(), 3, balances.Accounts)
+	assert.Equal(s.T(), 1, balances.JournalEntries)
+	assert.True(s.T(), cashBalance(balances).Equal(decimal.NewFromInt(50)))
+
+	history, err := s.tenantDataRepo.Clone(ctx, CloneTenantParams{SourceTenantID: tenantID, Name: "Sandbox History", Mode: CloneHistory})
+	require.NoError(s.T(), err)
+	defer cleanup(history)
+	assert.Equal(s.T(), 2, history.JournalEntries)
+	assert.True(s.T(), cashBalance(history).Equal(decimal.NewFromInt(50)))
+
+	_, err = s.tenantDataRepo.Clone(ctx, CloneTenantParams{SourceTenantID: uuid.New(), Name: "Missing", Mode: CloneChart})
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+}
+
+// TestReportRepository_AggregateJournalLines tests summing lines per group key
+func (s *IntegrationTestSuite) TestReportRepository_AggregateJournalLines() {
+	ctx := context.Background()
+
+	accounts := make([]*Account, 2)
+	for i, number := range []string{"9500", "9600"} {
+		account, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Aggregate Account " + number,
+			AccountTypeID: 1,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(s.T(), err)
+		accounts[i] = account
+	}
+	receivable, cash := accounts[0], accounts[1]
+
+	for i, entryDate := range []time.Time{
+		time.Date(2026, 5, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 6, 7, 0, 0, 0, 0, time.UTC),
+	} {
+		_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: fmt.Sprintf("AGG-%03d", i+1),
+			Description:     "Collection",
+			EntryDate:       entryDate,
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(25), Credit: decimal.Zero},
+				{AccountID: receivable.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(25)},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+
+	aggregates, err := s.reportRepo.AggregateJournalLines(ctx, s.testTenantID, LineAggregateFilter{
+		ByAccount:  true,
+		ByCurrency: true,
+		Period:     AggregatePeriodMonth,
+		AccountID:  &cash.ID,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), aggregates, 2)
+	assert.Equal(s.T(), cash.ID, *aggregates[0].AccountID)
+	assert.Equal(s.T(), "USD", *aggregates[0].CurrencyCode)
+	assert.Nil(s.T(), aggregates[0].AccountTypeCode)
+	assert.Equal(s.T(), time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), aggregates[0].PeriodStart.UTC())
+	assert.Equal(s.T(), "50", aggregates[0].Debit.String())
+	assert.Equal(s.T(), int64(2), aggregates[0].LineCount)
+	assert.Equal(s.T(), "25", aggregates[1].Debit.String())
+
+	fromDate := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+	aggregates, err = s.reportRepo.AggregateJournalLines(ctx, s.testTenantID, LineAggregateFilter{
+		AccountID: &receivable.ID,
+		FromDate:  &fromDate,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), aggregates, 1)
+	assert.Equal(s.T(), "-50", aggregates[0].Net().String())
+}
+
+// TestSchemaRepository_VerifyRowLevelSecurity tests that the journal tables
+// are checked and that the probe tenant sees none of the test tenant's rows
+func (s *IntegrationTestSuite) TestSchemaRepository_VerifyRowLevelSecurity() {
+	ctx := context.Background()
+
+	verification, err := NewSchemaRepository(s.db).VerifyRowLevelSecurity(ctx)
+	require.NoError(s.T(), err)
+
+	assert.NotEmpty(s.T(), verification.Role)
+	assert.True(s.T(), verification.TenantSettingApplied)
+	names := make([]string, len(verification.Tables))
+	for i, table := range verification.Tables {
+		names[i] = table.Name
+		if !verification.BypassesRLS {
+			assert.False(s.T(), table.Leaked, table.Name)
+		}
+	}
+	assert.Contains(s.T(), names, "journal_entries")
+	assert.Contains(s.T(), names, "journal_entry_lines")
+	assert.NotContains(s.T(), names, "consolidation_group_members")
+	assert.Equal(s.T(), len(verification.Problems) == 0, verification.OK())
+}
+
+// TestSchemaRepository_VerifyChangeDataCapture tests that a missing
+// publication is reported and that replication slots can be listed
+func (s *IntegrationTestSuite) TestSchemaRepository_VerifyChangeDataCapture() {
+	ctx := context.Background()
+	repo := NewSchemaRepository(s.db)
+
+	verification, err := repo.VerifyChangeDataCapture(ctx, "ledger_missing_publication")
+	require.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), verification.WALLevel)
+	assert.False(s.T(), verification.PublicationExists)
+	assert.False(s.T(), verification.OK())
+
+	slots, err := repo.ListReplicationSlots(ctx)
+	require.NoError(s.T(), err)
+	assert.NotNil(s.T(), slots)
+}
+
+// TestReferenceRepository_ListAccountTypes tests listing account types
+func (s *IntegrationTestSuite) TestReferenceRepository_ListAccountTypes() {
+	ctx := context.Background()
+
+	accountTypes, err := s.referenceRepo.ListAccountTypes(ctx)
+	require.NoError(s.T(), err)
+
+	// Assuming there are some account types in the database
+	assert.NotEmpty(s.T(), accountTypes)
+}
+
 // TestReferenceRepository_ListCurrencies tests listing currencies
 func (s *IntegrationTestSuite) TestReferenceRepository_ListCurrencies() {
 	ctx := context.Background()
@@ -326,6 +3219,316 @@ func (s *IntegrationTestSuite) TestReferenceRepository_ListCurrencies() {
 	assert.NotEmpty(s.T(), currencies)
 }
 
+// TestReferenceRepository_Translations tests naming currencies in a locale
+func (s *IntegrationTestSuite) TestReferenceRepository_Translations() {
+	ctx := context.Background()
+	defer func() {
+		_ = s.referenceRepo.SetCurrencyTranslation(ctx, "USD", "fa", "")
+		_ = s.referenceRepo.SetCurrencyTranslation(ctx, "USD", "fa-AF", "")
+	}()
+
+	require.NoError(s.T(), s.referenceRepo.SetCurrencyTranslation(ctx, "USD", "fa", "دلار آمریکا"))
+	require.NoError(s.T(), s.referenceRepo.SetCurrencyTranslation(ctx, "USD", "fa-AF", "دالر امریکایی"))
+
+	names, err := s.referenceRepo.ListCurrencyTranslations(ctx, []string{"fa-AF", "fa"})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "دالر امریکایی", names["USD"])
+
+	require.NoError(s.T(), s.referenceRepo.SetCurrencyTranslation(ctx, "USD", "fa-AF", ""))
+	names, err = s.referenceRepo.ListCurrencyTranslations(ctx, []string{"fa-AF", "fa"})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "دلار آمریکا", names["USD"])
+
+	err = s.referenceRepo.SetCurrencyTranslation(ctx, "XXX", "fa", "نامعلوم")
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+}
+
+// TestBudgetRepository_ActualsByLineDimension tests that dimension budget
+// lines count the journal lines tagged with their dimension value
+func (s *IntegrationTestSuite) TestBudgetRepository_ActualsByLineDimension() {
+	ctx := context.Background()
+
+	create := func(number string, accountTypeID int32) *Account {
+		account, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Budget " + number,
+			AccountTypeID: accountTypeID,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(s.T(), err)
+		return account
+	}
+	cash := create("1000", 1)
+	travel := create("6000", 5)
+
+	post := func(amount int64, costCenter string) {
+		_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber: "TRAVEL-" + costCenter,
+			Description:     "Travel",
+			EntryDate:       time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+			// Entry metadata no longer decides the dimension
+			Metadata: map[string]interface{}{"cost_center": "SALES"},
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: travel.ID, Debit: decimal.NewFromInt(amount), Credit: decimal.Zero, Dimensions: map[string]string{"cost_center": costCenter}},
+				{AccountID: cash.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(amount)},
+			},
+		})
+		require.NoError(s.T(), err)
+	}
+	post(300, "OPS")
+	post(200, "RND")
+
+	key, value := "cost_center", "OPS"
+	budget, err := s.budgetRepo.Create(ctx, s.testTenantID, BudgetParams{
+		Name: "Travel 2024",
+		Lines: []*BudgetLineParams{{
+			AccountID:      travel.ID,
+			PeriodStart:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			PeriodEnd:      time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+			Amount:         decimal.NewFromInt(1000),
+			DimensionKey:   &key,
+			DimensionValue: &value,
+		}},
+	})
+	require.NoError(s.T(), err)
+
+	actuals, err := s.budgetRepo.GetActuals(ctx, s.testTenantID, budget.ID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), actuals, 1)
+	assert.True(s.T(), actuals[0].Debit.Equal(decimal.NewFromInt(300)), actuals[0].Debit.String())
+}
+
+// TestReferenceSequenceRepository_Upsert tests that a stored sequence never
+// moves back
+func (s *IntegrationTestSuite) TestReferenceSequenceRepository_Upsert() {
+	ctx := context.Background()
+	repo := NewReferenceSequenceRepository(s.db)
+
+	sequence, err := repo.Get(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	sequence.NextValue = 10
+	_, err = repo.Upsert(ctx, sequence)
+	require.NoError(s.T(), err)
+
+	reference, err := repo.Next(ctx, s.testTenantID, time.Now())
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "JE-000010", reference)
+
+	// The sequence read before the posting claimed 10 would reissue it
+	sequence.Prefix = "INV-"
+	_, err = repo.Upsert(ctx, sequence)
+	assert.ErrorIs(s.T(), err, ErrSequenceMovedBack)
+
+	stored, err := repo.Get(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(11), stored.NextValue)
+	assert.Equal(s.T(), DefaultReferencePrefix, stored.Prefix)
+
+	stored.Prefix = "INV-"
+	updated, err := repo.Upsert(ctx, stored)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "INV-", updated.Prefix)
+	assert.Equal(s.T(), int64(11), updated.NextValue)
+}
+
+// TestQuotaRepository_SetAndGet tests updating and reading tenant quotas
+func (s *IntegrationTestSuite) TestQuotaRepository_SetAndGet() {
+	ctx := context.Background()
+
+	quota, err := s.quotaRepo.Get(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), quota.MaxAccounts)
+
+	maxAccounts := int32(5)
+	quota.MaxAccounts = &maxAccounts
+	_, err = s.quotaRepo.Set(ctx, quota)
+	require.NoError(s.T(), err)
+
+	quota, err = s.quotaRepo.Get(ctx, s.testTenantID)
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), quota.MaxAccounts)
+	assert.Equal(s.T(), int32(5), *quota.MaxAccounts)
+
+	usage, err := s.quotaRepo.GetUsage(ctx, s.testTenantID, time.Now().Add(-time.Hour))
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 0, usage.AccountCount)
+}
+
+// TestQuotaRepository_IgnoresDeletedAccounts tests that deleted accounts
+// count against the account quota only once restored
+func (s *IntegrationTestSuite) TestQuotaRepository_IgnoresDeletedAccounts() {
+	ctx := context.Background()
+
+	create := func(number string, maxAccounts *int32) (*Account, error) {
+		return s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Quota " + number,
+			AccountTypeID: 1,
+			CurrencyCode:  "USD",
+			MaxAccounts:   maxAccounts,
+		})
+	}
+	closed, err := create("9950", nil)
+	require.NoError(s.T(), err)
+	_, err = create("9951", nil)
+	require.NoError(s.T(), err)
+
+	_, err = s.accountRepo.Delete(ctx, s.testTenantID, closed.ID)
+	require.NoError(s.T(), err)
+
+	usage, err := s.quotaRepo.GetUsage(ctx, s.testTenantID, time.Now())
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, usage.AccountCount)
+
+	maxAccounts := int32(2)
+	_, err = create("9952", &maxAccounts)
+	require.NoError(s.T(), err)
+
+	_, err = s.accountRepo.Restore(ctx, s.testTenantID, closed.ID, &maxAccounts)
+	assert.ErrorIs(s.T(), err, ErrQuotaExceeded)
+
+	maxAccounts = 3
+	_, err = s.accountRepo.Restore(ctx, s.testTenantID, closed.ID, &maxAccounts)
+	assert.NoError(s.T(), err)
+}
+
+// TestQuotaRepository_EnforcedInTransaction tests that concurrent postings
+// and account creations cannot together exceed a quota
+func (s *IntegrationTestSuite) TestQuotaRepository_EnforcedInTransaction() {
+	ctx := context.Background()
+
+	create := func(number string, maxAccounts *int32) (*Account, error) {
+		return s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Quota " + number,
+			AccountTypeID: 1,
+			CurrencyCode:  "USD",
+			MaxAccounts:   maxAccounts,
+		})
+	}
+	cash, err := create("9960", nil)
+	require.NoError(s.T(), err)
+	bank, err := create("9961", nil)
+	require.NoError(s.T(), err)
+
+	usage, err := s.quotaRepo.GetUsage(ctx, s.testTenantID, time.Now())
+	require.NoError(s.T(), err)
+	maxAccounts := int32(usage.AccountCount + 1)
+
+	maxEntries := int32(2)
+	_, err = s.quotaRepo.Set(ctx, &TenantQuota{TenantID: s.testTenantID, MaxEntriesPerDay: &maxEntries})
+	require.NoError(s.T(), err)
+
+	results := make(chan error, 10)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := create(fmt.Sprintf("997%d", i), &maxAccounts)
+			results <- err
+		}()
+		go func() {
+			_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+				ReferenceNumber: fmt.Sprintf("QUOTA-%d", i),
+				Description:     "Quota entry",
+				EntryDate:       time.Now(),
+				Lines: []*CreateJournalEntryLineParams{
+					{AccountID: cash.ID, Debit: decimal.NewFromInt(1), Credit: decimal.Zero},
+					{AccountID: bank.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(1)},
+				},
+			})
+			results <- err
+		}()
+	}
+
+	succeeded, exceeded := 0, 0
+	for i := 0; i < 10; i++ {
+		if err := <-results; err != nil {
+			assert.ErrorIs(s.T(), err, ErrQuotaExceeded)
+			exceeded++
+		} else {
+			succeeded++
+		}
+	}
+	// One account and two entries fit the quotas
+	assert.Equal(s.T(), 3, succeeded)
+	assert.Equal(s.T(), 7, exceeded)
+}
+
+// TestJournalRepository_PostingPolicy tests that every posting is held to
+// the tenant's posting policy, whichever service posts it
+func (s *IntegrationTestSuite) TestJournalRepository_PostingPolicy() {
+	ctx := context.Background()
+
+	cash, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9980", Name: "Policy Cash", AccountTypeID: 1, CurrencyCode: "USD",
+	})
+	require.NoError(s.T(), err)
+	bank, err := s.accountRepo.Create(ctx, s.testTenantID, CreateAccountParams{
+		AccountNumber: "9981", Name: "Policy Bank", AccountTypeID: 1, CurrencyCode: "USD",
+	})
+	require.NoError(s.T(), err)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	lockDate := today.AddDate(0, 0, -10)
+	maxBackdateDays := int32(30)
+	_, err = s.policyRepo.Upsert(ctx, &PostingPolicy{TenantID: s.testTenantID, LockDate: &lockDate, MaxBackdateDays: &maxBackdateDays})
+	require.NoError(s.T(), err)
+
+	post := func(entryDate time.Time, overrideReason *string) error {
+		_, err := s.journalRepo.Create(ctx, s.testTenantID, CreateJournalEntryParams{
+			ReferenceNumber:    "POLICY-" + uuid.New().String(),
+			EntryDate:          entryDate,
+			LockOverrideReason: overrideReason,
+			Lines: []*CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(1), Credit: decimal.Zero},
+				{AccountID: bank.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(1)},
+			},
+		})
+		return err
+	}
+
+	assert.ErrorIs(s.T(), post(lockDate, nil), ErrPeriodLocked)
+	reason := "audit adjustment"
+	assert.NoError(s.T(), post(lockDate, &reason))
+	assert.ErrorIs(s.T(), post(today.AddDate(0, 0, 2), nil), ErrFutureDate)
+	assert.ErrorIs(s.T(), post(today.AddDate(0, 0, -40), &reason), ErrBackdateLimit)
+	assert.NoError(s.T(), post(today, nil))
+}
+
+// TestCloseRepository_LockCompletesChecklist tests that locking a period
+// completes the lock task of its checklist and, with the other tasks closed
+// by hand, the checklist itself
+func (s *IntegrationTestSuite) TestCloseRepository_LockCompletesChecklist() {
+	ctx := context.Background()
+	january := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endOfJanuary := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	_, err := s.closeRepo.SetTemplate(ctx, s.testTenantID, []*CloseTaskTemplate{
+		{Code: "ACCRUALS", Name: "Post accruals", Action: CloseActionManual},
+		{Code: "LOCK", Name: "Lock the period", Action: CloseActionLockPeriod},
+	})
+	require.NoError(s.T(), err)
+
+	checklist, err := s.closeRepo.Start(ctx, s.testTenantID, january, endOfJanuary)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), checklist.Tasks, 2)
+	assert.Equal(s.T(), CloseTaskPending, checklist.Tasks[1].Status)
+
+	_, err = s.closeRepo.Start(ctx, s.testTenantID, january, endOfJanuary)
+	assert.True(s.T(), IsUniqueViolation(err))
+
+	_, err = s.closeRepo.UpdateTask(ctx, s.testTenantID, checklist.Tasks[0].ID, CloseTaskDone, nil)
+	require.NoError(s.T(), err)
+
+	_, err = s.policyRepo.Upsert(ctx, &PostingPolicy{TenantID: s.testTenantID, LockDate: &endOfJanuary})
+	require.NoError(s.T(), err)
+
+	checklist, err = s.closeRepo.GetByID(ctx, s.testTenantID, checklist.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), CloseTaskDone, checklist.Tasks[1].Status)
+	assert.True(s.T(), checklist.Tasks[1].CompletedAutomatically)
+	assert.NotNil(s.T(), checklist.CompletedAt)
+}
+
 // TestIntegrationSuite runs the integration test suite
 func TestIntegrationSuite(t *testing.T) {
 	if testing.Short() {
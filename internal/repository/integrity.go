@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// chainedEntry is a journal entry with its position in the hash chain
+type chainedEntry struct {
+	sequence     *int64
+	previousHash []byte
+	hash         []byte
+	entry        *JournalEntry
+}
+
+const chainedEntryQuery = `
+	SELECT je.chain_sequence, je.previous_hash, je.entry_hash,
+	       je.id, je.tenant_id, je.reference_number, je.description,
+	       je.entry_date, je.metadata, je.created_at,
+	       jel.id, jel.account_id, jel.debit, jel.credit, jel.description,
+	       jel.counterparty_tenant_id, jel.fx_rate, jel.tax_code_id, jel.is_tax,
+	       jel.party_id, jel.dimensions
+	FROM journal_entries je
+	INNER JOIN journal_entry_lines jel ON jel.journal_entry_id = je.id
+`
+
+// errStopVerification ends verification at the first invalid entry
+var errStopVerification = errors.New("stop verification")
+
+// lockTenantJournal takes a transaction-scoped advisory lock that serializes
+// postings and other journal-wide operations of the transaction's tenant
+func lockTenantJournal(ctx context.Context, tx *db.TenantTx) error {
+	err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended('journal_chain:' || current_setting('app.current_tenant_id'), 0))")
+	if err != nil {
+		return fmt.Errorf("failed to lock tenant journal: %w", err)
+	}
+	return nil
+}
+
+// chainJournalEntry appends a newly inserted entry to the tenant's hash
+// chain. The caller must hold the tenant journal lock so that concurrent
+// postings cannot link to the same previous entry.
+func chainJournalEntry(ctx context.Context, tx *db.TenantTx, journalEntryID uuid.UUID) error {
+	var sequence int64
+	var previousHash []byte
+	err := tx.QueryRow(ctx, `
+		SELECT chain_sequence, entry_hash
+		FROM journal_entries
+		WHERE chain_sequence IS NOT NULL
+		ORDER BY chain_sequence DESC
+		LIMIT 1
+	`).Scan(&sequence, &previousHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to get journal chain head: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, chainedEntryQuery+" WHERE je.id = $1 ORDER BY jel.id", journalEntryID)
+	if err != nil {
+		return fmt.Errorf("failed to read journal entry: %w", err)
+	}
+
+	var entry *JournalEntry
+	err = scanChainedEntries(rows, func(c *chainedEntry) error {
+		entry = c.entry
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("journal entry %w", ErrNotFound)
+	}
+
+	hash, err := EntryHash(previousHash, entry)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Exec(ctx, `
+		UPDATE journal_entries
+		SET chain_sequence = $2, previous_hash = $3, entry_hash = $4
+		WHERE id = $1 AND entry_hash IS NULL
+	`, journalEntryID, sequence+1, previousHash, hash)
+	if err != nil {
+		return fmt.Errorf("failed to chain journal entry: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyIntegrity recomputes the hash chain of a tenant's journal entries and
+// reports the first entry that does not match. Entries posted before hash
+// chaining was introduced are counted as unchained and not verified.
+func (r *JournalRepository) VerifyIntegrity(ctx context.Context, tenantID uuid.UUID) (*LedgerIntegrity, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	result := &LedgerIntegrity{Valid: true}
+
+	err = conn.QueryRow(ctx, "SELECT COUNT(*) FROM journal_entries WHERE chain_sequence IS NULL").Scan(&result.UnchainedEntries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unchained journal entries: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, chainedEntryQuery+" WHERE je.chain_sequence IS NOT NULL ORDER BY je.chain_sequence, jel.id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal chain: %w", err)
+	}
+
+	var previousHash []byte
+	err = scanChainedEntries(rows, func(c *chainedEntry) error {
+		expected := result.HeadSequence + 1
+		switch {
+		case *c.sequence != expected:
+			result.Reason = fmt.Sprintf("expected chain sequence %d, found %d", expected, *c.sequence)
+		case !bytes.Equal(c.previousHash, previousHash):
+			result.Reason = "previous hash does not match the preceding entry"
+		default:
+			hash, err := EntryHash(previousHash, c.entry)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(hash, c.hash) {
+				result.Reason = "entry content does not match its hash"
+			}
+		}
+
+		if result.Reason != "" {
+			result.Valid = false
+			result.FirstInvalidEntryID = &c.entry.ID
+			return errStopVerification
+		}
+
+		result.EntriesVerified++
+		result.HeadSequence = *c.sequence
+		result.HeadHash = c.hash
+		previousHash = c.hash
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopVerification) {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// scanChainedEntries groups rows of chainedEntryQuery into entries and calls
+// fn for each; rows must be ordered by entry
+func scanChainedEntries(rows pgx.Rows, fn func(*chainedEntry) error) error {
+	defer rows.Close()
+
+	var current *chainedEntry
+	for rows.Next() {
+		c := &chainedEntry{entry: &JournalEntry{}}
+		line := &JournalEntryLine{}
+		var metadataBytes []byte
+
+		err := rows.Scan(
+			&c.sequence,
+			&c.previousHash,
+			&c.hash,
+			&c.entry.ID,
+			&c.entry.TenantID,
+			&c.entry.ReferenceNumber,
+			&c.entry.Description,
+			&c.entry.EntryDate,
+			&metadataBytes,
+			&c.entry.CreatedAt,
+			&line.ID,
+			&line.AccountID,
+			&line.Debit,
+			&line.Credit,
+			&line.Description,
+			&line.CounterpartyTenantID,
+			&line.FxRate,
+			&line.TaxCodeID,
+			&line.IsTax,
+			&line.PartyID,
+			&line.Dimensions,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan journal entry: %w", err)
+		}
+		line.JournalEntryID = c.entry.ID
+
+		if current == nil || current.entry.ID != c.entry.ID {
+			if current != nil {
+				if err := fn(current); err != nil {
+					return err
+				}
+			}
+
+			if len(metadataBytes) > 0 {
+				if err := json.Unmarshal(metadataBytes, &c.entry.Metadata); err != nil {
+					return fmt.Errorf("failed to unmarshal metadata: %w", err)
+				}
+			}
+			current = c
+		}
+		current.entry.Lines = append(current.entry.Lines, line)
+	}
+
+	if current != nil {
+		return fn(current)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntryHash(t *testing.T) {
+	newEntry := func() *JournalEntry {
+		rate := decimal.RequireFromString("0.92")
+		taxCodeID := uuid.MustParse("6f1c1d52-4a6e-4f55-9d43-1b7f0f6b8c01")
+		partyID := uuid.MustParse("0b5d6a1e-2f0c-4c7e-8a3b-9e4d5c6b7a02")
+		return &JournalEntry{
+			ID:              uuid.MustParse("3e0f7c8a-5b1d-4e2f-9a6c-7d8e9f0a1b03"),
+			TenantID:        uuid.MustParse("9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c04"),
+			ReferenceNumber: "REF-001",
+			Description:     "Sale",
+			EntryDate:       time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			CreatedAt:       time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+			Lines: []*JournalEntryLine{
+				{
+					ID:         uuid.MustParse("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c05"),
+					AccountID:  uuid.MustParse("2b3c4d5e-6f7a-4b8c-9d0e-1f2a3b4c5d06"),
+					Debit:      decimal.NewFromInt(92),
+					Credit:     decimal.Zero,
+					FxRate:     &rate,
+					TaxCodeID:  &taxCodeID,
+					PartyID:    &partyID,
+					Dimensions: map[string]string{"region": "EU"},
+				},
+			},
+		}
+	}
+
+	base, err := EntryHash(nil, newEntry())
+	require.NoError(t, err)
+
+	t.Run("is stable", func(t *testing.T) {
+		hash, err := EntryHash(nil, newEntry())
+		require.NoError(t, err)
+		assert.Equal(t, base, hash)
+	})
+
+	changes := map[string]func(line *JournalEntryLine){
+		"fx rate": func(line *JournalEntryLine) {
+			rate := decimal.RequireFromString("0.93")
+			line.FxRate = &rate
+		},
+		"tax code":  func(line *JournalEntryLine) { line.TaxCodeID = nil },
+		"tax flag":  func(line *JournalEntryLine) { line.IsTax = true },
+		"party":     func(line *JournalEntryLine) { line.PartyID = nil },
+		"dimension": func(line *JournalEntryLine) { line.Dimensions["region"] = "US" },
+		"amount":    func(line *JournalEntryLine) { line.Debit = decimal.NewFromInt(93) },
+	}
+	for name, change := range changes {
+		t.Run("covers the "+name, func(t *testing.T) {
+			entry := newEntry()
+			change(entry.Lines[0])
+
+			hash, err := EntryHash(nil, entry)
+			require.NoError(t, err)
+			assert.NotEqual(t, base, hash)
+		})
+	}
+
+	t.Run("hashes empty and missing dimensions alike", func(t *testing.T) {
+		withNil := newEntry()
+		withNil.Lines[0].Dimensions = nil
+		withEmpty := newEntry()
+		withEmpty.Lines[0].Dimensions = map[string]string{}
+
+		nilHash, err := EntryHash(nil, withNil)
+		require.NoError(t, err)
+		emptyHash, err := EntryHash(nil, withEmpty)
+		require.NoError(t, err)
+		assert.Equal(t, nilHash, emptyHash)
+	})
+}
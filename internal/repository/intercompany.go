@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/shopspring/decimal"
+)
+
+// IntercompanyBalance represents a tenant's balance on an account against a counterparty tenant
+type IntercompanyBalance struct {
+	TenantID             uuid.UUID
+	CounterpartyTenantID uuid.UUID
+	AccountID            uuid.UUID
+	AccountNumber        string
+	AccountName          string
+	CurrencyCode         string
+	Debit                decimal.Decimal
+	Credit               decimal.Decimal
+}
+
+// Balance returns the net debit balance
+func (b *IntercompanyBalance) Balance() decimal.Decimal {
+	return b.Debit.Sub(b.Credit)
+}
+
+// IntercompanyRepository handles intercompany balance queries
+type IntercompanyRepository struct {
+	db *db.DB
+}
+
+// NewIntercompanyRepository creates a new intercompany repository
+func NewIntercompanyRepository(database *db.DB) *IntercompanyRepository {
+	return &IntercompanyRepository{db: database}
+}
+
+// ListBalances sums a tenant's intercompany lines per account and counterparty up to a date,
+// in the default book of the tenant
+func (r *IntercompanyRepository) ListBalances(ctx context.Context, tenantID uuid.UUID, asOf time.Time) ([]*IntercompanyBalance, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT jel.counterparty_tenant_id, a.id, a.account_number, a.name, a.currency_code,
+		       SUM(jel.debit), SUM(jel.credit)
+		FROM journal_entry_lines jel
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		INNER JOIN accounts a ON a.id = jel.account_id
+		WHERE jel.counterparty_tenant_id IS NOT NULL
+		  AND je.entry_date <= $1
+		  AND a.book_id = ` + defaultBookSQL + `
+		GROUP BY jel.counterparty_tenant_id, a.id, a.account_number, a.name, a.currency_code
+		ORDER BY a.account_number, jel.counterparty_tenant_id
+	`
+
+	rows, err := conn.Query(ctx, query, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list intercompany balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make([]*IntercompanyBalance, 0)
+	for rows.Next() {
+		balance := &IntercompanyBalance{TenantID: tenantID}
+		err := rows.Scan(
+			&balance.CounterpartyTenantID,
+			&balance.AccountID,
+			&balance.AccountNumber,
+			&balance.AccountName,
+			&balance.CurrencyCode,
+			&balance.Debit,
+			&balance.Credit,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan intercompany balance: %w", err)
+		}
+		balances = append(balances, balance)
+	}
+
+	return balances, nil
+}
+
+// FindAccountIDsByNumber maps account numbers to the IDs of the active accounts in the
+// tenant's default book
+func (r *IntercompanyRepository) FindAccountIDsByNumber(ctx context.Context, tenantID uuid.UUID, accountNumbers []string) (map[string]uuid.UUID, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT account_number, id
+		FROM accounts
+		WHERE account_number = ANY($1) AND deleted_at IS NULL
+		  AND book_id = ` + defaultBookSQL + `
+	`
+
+	rows, err := conn.Query(ctx, query, accountNumbers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := make(map[string]uuid.UUID, len(accountNumbers))
+	for rows.Next() {
+		var number string
+		var id uuid.UUID
+		if err := rows.Scan(&number, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts[number] = id
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,493 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// InterestScheme is a tenant-managed interest rate under a day-count
+// convention. Interest accrued on an account with the scheme attached is
+// kept in the accrual account and recognized in the interest account.
+type InterestScheme struct {
+	ID                uuid.UUID
+	TenantID          uuid.UUID
+	Code              string
+	Name              string
+	AnnualRate        decimal.Decimal
+	DayCount          string
+	AccrualAccountID  uuid.UUID
+	InterestAccountID uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// InterestSchemeParams holds parameters for creating or updating an interest
+// scheme
+type InterestSchemeParams struct {
+	Code              string
+	Name              string
+	AnnualRate        decimal.Decimal
+	DayCount          string
+	AccrualAccountID  uuid.UUID
+	InterestAccountID uuid.UUID
+}
+
+// AccountInterest is an account with an interest scheme attached.
+// AccruedThrough is the last day interest has been accrued for.
+type AccountInterest struct {
+	AccountID      uuid.UUID
+	SchemeID       uuid.UUID
+	AccountNumber  string
+	Precision      int32
+	AccruedThrough time.Time
+	CreatedAt      time.Time
+}
+
+// InterestAccrual is the interest of an account over a period, posted as a
+// journal entry. Amount is positive on a debit balance and negative on a
+// credit balance.
+type InterestAccrual struct {
+	ID             uuid.UUID
+	AccountID      uuid.UUID
+	SchemeID       uuid.UUID
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	Amount         decimal.Decimal
+	JournalEntryID uuid.UUID
+	PostedAt       time.Time
+}
+
+// DailyMovement is the net change of an account balance on one day, debits
+// less credits
+type DailyMovement struct {
+	Date   time.Time
+	Amount decimal.Decimal
+}
+
+const interestSchemeColumns = `id, tenant_id, code, name, annual_rate, day_count, accrual_account_id,
+		       interest_account_id, created_at, updated_at`
+
+const accountInterestColumns = `ai.account_id, ai.scheme_id, a.account_number, c.precision,
+		       ai.accrued_through, ai.created_at`
+
+const accountInterestFrom = `
+		FROM account_interest ai
+		JOIN accounts a ON a.id = ai.account_id
+		JOIN currencies c ON c.code = a.currency_code`
+
+const interestAccrualColumns = `id, account_id, scheme_id, period_start, period_end, amount,
+		       journal_entry_id, posted_at`
+
+func scanInterestScheme(row pgx.Row, scheme *InterestScheme) error {
+	return row.Scan(
+		&scheme.ID,
+		&scheme.TenantID,
+		&scheme.Code,
+		&scheme.Name,
+		&scheme.AnnualRate,
+		&scheme.DayCount,
+		&scheme.AccrualAccountID,
+		&scheme.InterestAccountID,
+		&scheme.CreatedAt,
+		&scheme.UpdatedAt,
+	)
+}
+
+func scanAccountInterest(row pgx.Row, ai *AccountInterest) error {
+	return row.Scan(
+		&ai.AccountID,
+		&ai.SchemeID,
+		&ai.AccountNumber,
+		&ai.Precision,
+		&ai.AccruedThrough,
+		&ai.CreatedAt,
+	)
+}
+
+func scanInterestAccrual(row pgx.Row, accrual *InterestAccrual) error {
+	return row.Scan(
+		&accrual.ID,
+		&accrual.AccountID,
+		&accrual.SchemeID,
+		&accrual.PeriodStart,
+		&accrual.PeriodEnd,
+		&accrual.Amount,
+		&accrual.JournalEntryID,
+		&accrual.PostedAt,
+	)
+}
+
+// InterestRepository handles interest scheme and accrual operations
+type InterestRepository struct {
+	db *db.DB
+}
+
+// NewInterestRepository creates a new interest repository
+func NewInterestRepository(database *db.DB) *InterestRepository {
+	return &InterestRepository{db: database}
+}
+
+// CreateScheme creates an interest scheme. Codes are unique per tenant.
+func (r *InterestRepository) CreateScheme(ctx context.Context, tenantID uuid.UUID, params InterestSchemeParams) (*InterestScheme, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	scheme := &InterestScheme{}
+	query := `
+		INSERT INTO interest_schemes (tenant_id, code, name, annual_rate, day_count, accrual_account_id, interest_account_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + interestSchemeColumns
+
+	row := tx.QueryRow(ctx, query, tenantID, params.Code, params.Name, params.AnnualRate, params.DayCount, params.AccrualAccountID, params.InterestAccountID)
+	if err := scanInterestScheme(row, scheme); err != nil {
+		return nil, fmt.Errorf("failed to create interest scheme: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return scheme, nil
+}
+
+// UpdateScheme replaces the settings of an interest scheme
+func (r *InterestRepository) UpdateScheme(ctx context.Context, tenantID uuid.UUID, schemeID uuid.UUID, params InterestSchemeParams) (*InterestScheme, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	scheme := &InterestScheme{}
+	query := `
+		UPDATE interest_schemes
+		SET code = $2, name = $3, annual_rate = $4, day_count = $5, accrual_account_id = $6,
+		    interest_account_id = $7, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + interestSchemeColumns
+
+	row := tx.QueryRow(ctx, query, schemeID, params.Code, params.Name, params.AnnualRate, params.DayCount, params.AccrualAccountID, params.InterestAccountID)
+	if err := scanInterestScheme(row, scheme); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("interest scheme %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update interest scheme: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return scheme, nil
+}
+
+// GetScheme retrieves an interest scheme
+func (r *InterestRepository) GetScheme(ctx context.Context, tenantID uuid.UUID, schemeID uuid.UUID) (*InterestScheme, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	scheme := &InterestScheme{}
+	query := `SELECT ` + interestSchemeColumns + ` FROM interest_schemes WHERE id = $1`
+
+	if err := scanInterestScheme(conn.QueryRow(ctx, query, schemeID), scheme); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("interest scheme %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get interest scheme: %w", err)
+	}
+
+	return scheme, nil
+}
+
+// ListSchemes retrieves the interest schemes of a tenant ordered by code
+func (r *InterestRepository) ListSchemes(ctx context.Context, tenantID uuid.UUID) ([]*InterestScheme, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `SELECT `+interestSchemeColumns+` FROM interest_schemes ORDER BY code`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interest schemes: %w", err)
+	}
+	defer rows.Close()
+
+	schemes := make([]*InterestScheme, 0)
+	for rows.Next() {
+		scheme := &InterestScheme{}
+		if err := scanInterestScheme(rows, scheme); err != nil {
+			return nil, fmt.Errorf("failed to scan interest scheme: %w", err)
+		}
+		schemes = append(schemes, scheme)
+	}
+
+	return schemes, nil
+}
+
+// AttachScheme attaches a scheme to an account that has none, with interest
+// accrued through the given day, so accrual starts the day after. Attaching
+// a second scheme fails with a unique violation.
+func (r *InterestRepository) AttachScheme(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, schemeID uuid.UUID, accruedThrough time.Time) (*AccountInterest, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO account_interest (tenant_id, account_id, scheme_id, accrued_through)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	if err := tx.Exec(ctx, query, tenantID, accountID, schemeID, accruedThrough); err != nil {
+		return nil, fmt.Errorf("failed to attach interest scheme: %w", err)
+	}
+
+	ai := &AccountInterest{}
+	selectQuery := `SELECT ` + accountInterestColumns + accountInterestFrom + ` WHERE ai.account_id = $1`
+
+	if err := scanAccountInterest(tx.QueryRow(ctx, selectQuery, accountID), ai); err != nil {
+		return nil, fmt.Errorf("failed to get account interest: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return ai, nil
+}
+
+// DetachScheme stops interest accruing on an account. Accruals already
+// posted are kept.
+func (r *InterestRepository) DetachScheme(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var detached bool
+	query := `
+		WITH deleted AS (
+			DELETE FROM account_interest WHERE account_id = $1 RETURNING 1
+		)
+		SELECT EXISTS (SELECT 1 FROM deleted)
+	`
+
+	if err := tx.QueryRow(ctx, query, accountID).Scan(&detached); err != nil {
+		return fmt.Errorf("failed to detach interest scheme: %w", err)
+	}
+	if !detached {
+		return fmt.Errorf("account interest %w", ErrNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListDueAccruals retrieves the live accounts with a scheme attached whose
+// interest has not been accrued through the given day, ordered by account
+// number. An account ID limits the list to that account.
+func (r *InterestRepository) ListDueAccruals(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, through time.Time) ([]*AccountInterest, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `SELECT ` + accountInterestColumns + accountInterestFrom + `
+		WHERE ai.accrued_through < $1
+		  AND a.deleted_at IS NULL
+		  AND ($2::uuid IS NULL OR ai.account_id = $2)
+		ORDER BY a.account_number, ai.account_id
+	`
+
+	rows, err := conn.Query(ctx, query, through, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due interest accruals: %w", err)
+	}
+	defer rows.Close()
+
+	due := make([]*AccountInterest, 0)
+	for rows.Next() {
+		ai := &AccountInterest{}
+		if err := scanAccountInterest(rows, ai); err != nil {
+			return nil, fmt.Errorf("failed to scan account interest: %w", err)
+		}
+		due = append(due, ai)
+	}
+
+	return due, nil
+}
+
+// GetDailyMovements returns the balance of an account at the close of a day,
+// debits less credits by entry date, and its net movement on every later day
+// up to and including through that has entries
+func (r *InterestRepository) GetDailyMovements(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, after, through time.Time) (decimal.Decimal, []*DailyMovement, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return decimal.Zero, nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	var opening decimal.Decimal
+	openingQuery := `
+		SELECT COALESCE(SUM(jel.debit - jel.credit), 0)
+		FROM journal_entry_lines jel
+		JOIN journal_entries je ON je.id = jel.journal_entry_id
+		WHERE jel.account_id = $1 AND je.entry_date <= $2
+	`
+
+	if err := conn.QueryRow(ctx, openingQuery, accountID, after).Scan(&opening); err != nil {
+		return decimal.Zero, nil, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+
+	query := `
+		SELECT je.entry_date, SUM(jel.debit - jel.credit)
+		FROM journal_entry_lines jel
+		JOIN journal_entries je ON je.id = jel.journal_entry_id
+		WHERE jel.account_id = $1 AND je.entry_date > $2 AND je.entry_date <= $3
+		GROUP BY je.entry_date
+		ORDER BY je.entry_date
+	`
+
+	rows, err := conn.Query(ctx, query, accountID, after, through)
+	if err != nil {
+		return decimal.Zero, nil, fmt.Errorf("failed to get daily movements: %w", err)
+	}
+	defer rows.Close()
+
+	movements := make([]*DailyMovement, 0)
+	for rows.Next() {
+		movement := &DailyMovement{}
+		if err := rows.Scan(&movement.Date, &movement.Amount); err != nil {
+			return decimal.Zero, nil, fmt.Errorf("failed to scan daily movement: %w", err)
+		}
+		movements = append(movements, movement)
+	}
+
+	return opening, movements, nil
+}
+
+// PostAccrual posts the journal entry of an accrual, records it and moves the
+// account's accrued-through day to the end of its period in a single
+// transaction. It fails with ErrInterestAccrued unless the period starts the
+// day after the account's accrued-through day, so a period is never accrued
+// twice.
+func (r *InterestRepository) PostAccrual(ctx context.Context, tenantID uuid.UUID, accrual InterestAccrual, entry CreateJournalEntryParams) (*InterestAccrual, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Take the tenant journal lock before the interest row, in the order merges
+	// take them, so the two cannot deadlock
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	var accruedThrough time.Time
+	lockQuery := `SELECT accrued_through FROM account_interest WHERE account_id = $1 FOR UPDATE`
+
+	if err := tx.QueryRow(ctx, lockQuery, accrual.AccountID).Scan(&accruedThrough); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("account interest %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to lock account interest: %w", err)
+	}
+
+	if !accruedThrough.AddDate(0, 0, 1).Equal(accrual.PeriodStart) {
+		return nil, ErrInterestAccrued
+	}
+
+	journalEntryID, err := insertJournalEntry(ctx, tx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &InterestAccrual{}
+	insertQuery := `
+		INSERT INTO interest_accruals (tenant_id, account_id, scheme_id, period_start, period_end, amount, journal_entry_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + interestAccrualColumns
+
+	row := tx.QueryRow(ctx, insertQuery,
+		tenantID,
+		accrual.AccountID,
+		accrual.SchemeID,
+		accrual.PeriodStart,
+		accrual.PeriodEnd,
+		accrual.Amount,
+		journalEntryID,
+	)
+	if err := scanInterestAccrual(row, result); err != nil {
+		return nil, fmt.Errorf("failed to record interest accrual: %w", err)
+	}
+
+	updateQuery := `UPDATE account_interest SET accrued_through = $2 WHERE account_id = $1`
+
+	if err := tx.Exec(ctx, updateQuery, accrual.AccountID, accrual.PeriodEnd); err != nil {
+		return nil, fmt.Errorf("failed to update account interest: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// ListAccruals retrieves posted accruals, latest period first. An account ID
+// limits them to that account.
+func (r *InterestRepository) ListAccruals(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, limit, offset int) ([]*InterestAccrual, int, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	where := ` WHERE ($1::uuid IS NULL OR account_id = $1)`
+
+	var totalCount int
+	if err := conn.QueryRow(ctx, `SELECT COUNT(*) FROM interest_accruals`+where, accountID).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count interest accruals: %w", err)
+	}
+
+	query := `SELECT ` + interestAccrualColumns + ` FROM interest_accruals` + where +
+		` ORDER BY period_end DESC, account_id LIMIT $2 OFFSET $3`
+
+	rows, err := conn.Query(ctx, query, accountID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list interest accruals: %w", err)
+	}
+	defer rows.Close()
+
+	accruals := make([]*InterestAccrual, 0)
+	for rows.Next() {
+		accrual := &InterestAccrual{}
+		if err := scanInterestAccrual(rows, accrual); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan interest accrual: %w", err)
+		}
+		accruals = append(accruals, accrual)
+	}
+
+	return accruals, totalCount, nil
+}
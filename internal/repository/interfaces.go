@@ -5,32 +5,275 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
-// TenantRepositoryInterface defines methods for tenant operations
-type TenantRepositoryInterface interface {
-	Create(ctx context.Context, name string, tenantUUID *uuid.UUID) (*Tenant, error)
-	GetByID(ctx context.Context, tenantID uuid.UUID) (*Tenant, error)
-	GetByName(ctx context.Context, name string) (*Tenant, error)
+// SchemaRepositoryInterface defines methods for schema metadata operations
+type SchemaRepositoryInterface interface {
+	ListMigrations(ctx context.Context) ([]*SchemaMigration, error)
+	VerifyRowLevelSecurity(ctx context.Context) (*RLSVerification, error)
+	VerifyChangeDataCapture(ctx context.Context, publication string) (*CDCVerification, error)
+	ListReplicationSlots(ctx context.Context) ([]*ReplicationSlot, error)
 }
 
-// AccountRepositoryInterface defines methods for account operations
-type AccountRepositoryInterface interface {
-	Create(ctx context.Context, tenantID uuid.UUID, params CreateAccountParams) (*Account, error)
-	GetByID(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*Account, error)
-	List(ctx context.Context, tenantID uuid.UUID, accountTypeID *int32, currencyCode *string, limit, offset int) ([]*Account, int, error)
-	GetBalance(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*AccountBalance, error)
+// JournalPartitionRepositoryInterface defines methods for maintaining the partitions of the journal tables
+type JournalPartitionRepositoryInterface interface {
+	ListPartitions(ctx context.Context) ([]*JournalPartition, error)
+	CreatePartitions(ctx context.Context, from, through time.Time) ([]*JournalPartition, error)
+	ArchivePartition(ctx context.Context, partition *JournalPartition, tablespace string) error
 }
 
-// JournalRepositoryInterface defines methods for journal entry operations
-type JournalRepositoryInterface interface {
-	Create(ctx context.Context, tenantID uuid.UUID, params CreateJournalEntryParams) (*JournalEntry, error)
-	GetByID(ctx context.Context, tenantID uuid.UUID, journalEntryID uuid.UUID) (*JournalEntry, error)
-	List(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, fromDate, toDate *time.Time, limit, offset int) ([]*JournalEntry, int, error)
+// QuotaRepositoryInterface defines methods for tenant quota operations
+type QuotaRepositoryInterface interface {
+	Get(ctx context.Context, tenantID uuid.UUID) (*TenantQuota, error)
+	Set(ctx context.Context, quota *TenantQuota) (*TenantQuota, error)
+	GetUsage(ctx context.Context, tenantID uuid.UUID, since time.Time) (*QuotaUsage, error)
 }
 
-// ReferenceRepositoryInterface defines methods for reference data operations
-type ReferenceRepositoryInterface interface {
-	ListAccountTypes(ctx context.Context) ([]*AccountType, error)
-	ListCurrencies(ctx context.Context) ([]*Currency, error)
+// TenantSettingsRepositoryInterface defines methods for tenant settings operations
+type TenantSettingsRepositoryInterface interface {
+	Get(ctx context.Context, tenantID uuid.UUID) (*TenantSettings, error)
+	Upsert(ctx context.Context, settings *TenantSettings) (*TenantSettings, error)
+}
+
+// EventRepositoryInterface defines methods for reading the ledger event store
+type EventRepositoryInterface interface {
+	List(ctx context.Context, tenantID uuid.UUID, afterSequence int64, limit int) ([]*LedgerEvent, error)
+	Replay(ctx context.Context, tenantID uuid.UUID, until *time.Time, fn func(*LedgerEvent) error) error
+	ReplayAggregate(ctx context.Context, tenantID uuid.UUID, aggregateType string, aggregateID uuid.UUID, fn func(*LedgerEvent) error) error
+	LastSequence(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	ReplayWithBalances(ctx context.Context, tenantID uuid.UUID, fn func(*LedgerEvent) error) ([]*AccountBalance, error)
+}
+
+// EventDeliveryRepositoryInterface defines methods for tracking the delivery
+// of ledger events to external destinations
+type EventDeliveryRepositoryInterface interface {
+	Pending(ctx context.Context, tenantID uuid.UUID, limit int) ([]*EventDelivery, error)
+	ListFailed(ctx context.Context, tenantID uuid.UUID, afterSequence int64, limit int) ([]*EventDelivery, error)
+	Get(ctx context.Context, tenantID uuid.UUID, sequence int64) (*EventDelivery, error)
+	MarkDelivered(ctx context.Context, tenantID uuid.UUID, sequence int64) error
+	MarkFailed(ctx context.Context, tenantID uuid.UUID, sequence int64, reason string, deadLetter bool) error
+	Replay(ctx context.Context, tenantID uuid.UUID, sequence *int64) (int64, error)
+	Backlog(ctx context.Context, tenantID uuid.UUID) (*EventDeliveryBacklog, error)
+}
+
+// BalanceRepositoryInterface defines methods for maintaining account balances
+type BalanceRepositoryInterface interface {
+	Rebuild(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID) (*BalanceRebuild, error)
+	RebuildFromEvents(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, balances BalanceProjection, accounts AccountProjection) (*BalanceRebuild, error)
+}
+
+// TaxCodeRepositoryInterface defines methods for tax code operations
+type TaxCodeRepositoryInterface interface {
+	Create(ctx context.Context, tenantID uuid.UUID, params TaxCodeParams) (*TaxCode, error)
+	Update(ctx context.Context, tenantID uuid.UUID, taxCodeID uuid.UUID, params TaxCodeParams) (*TaxCode, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, taxCodeID uuid.UUID) (*TaxCode, error)
+	GetByIDs(ctx context.Context, tenantID uuid.UUID, taxCodeIDs []uuid.UUID) (map[uuid.UUID]*TaxCode, error)
+	List(ctx context.Context, tenantID uuid.UUID, includeInactive bool) ([]*TaxCode, error)
+	GetReport(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time) ([]*TaxReportRow, error)
+}
+
+// PartyRepositoryInterface defines methods for party operations
+type PartyRepositoryInterface interface {
+	Create(ctx context.Context, tenantID uuid.UUID, params PartyParams) (*Party, error)
+	Update(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID, params PartyParams) (*Party, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID) (*Party, error)
+	GetByIDs(ctx context.Context, tenantID uuid.UUID, partyIDs []uuid.UUID) (map[uuid.UUID]*Party, error)
+	List(ctx context.Context, tenantID uuid.UUID, filter PartyFilter, limit, offset int) ([]*Party, int, error)
+	Delete(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID) (*Party, error)
+	GetBalances(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID, asOf *time.Time) ([]*PartyAccountBalance, error)
+	GetStatement(ctx context.Context, tenantID uuid.UUID, partyID, accountID uuid.UUID, fromDate, toDate time.Time) (*PartyStatement, error)
+}
+
+// DimensionRepositoryInterface defines methods for custom dimension operations
+type DimensionRepositoryInterface interface {
+	Create(ctx context.Context, tenantID uuid.UUID, params DimensionParams) (*Dimension, error)
+	Update(ctx context.Context, tenantID uuid.UUID, dimensionID uuid.UUID, params DimensionParams) (*Dimension, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, dimensionID uuid.UUID) (*Dimension, error)
+	GetByCodes(ctx context.Context, tenantID uuid.UUID, codes []string) (map[string]*Dimension, error)
+	List(ctx context.Context, tenantID uuid.UUID, includeInactive bool) ([]*Dimension, error)
+	GetBalances(ctx context.Context, tenantID uuid.UUID, filter DimensionBalanceFilter) ([]*DimensionBalance, error)
+}
+
+// ConsistencyRepositoryInterface defines methods for checking ledger invariants
+type ConsistencyRepositoryInterface interface {
+	Check(ctx context.Context, tenantID uuid.UUID) (*ConsistencyReport, error)
+	VerifyBalances(ctx context.Context, tenantID uuid.UUID) (*BalanceVerification, error)
+}
+
+// ReferenceSequenceRepositoryInterface defines methods for reference number generation
+type ReferenceSequenceRepositoryInterface interface {
+	Get(ctx context.Context, tenantID uuid.UUID) (*ReferenceSequence, error)
+	Upsert(ctx context.Context, sequence *ReferenceSequence) (*ReferenceSequence, error)
+	Next(ctx context.Context, tenantID uuid.UUID, entryDate time.Time) (string, error)
+}
+
+// DigestRepositoryInterface defines methods for daily digest operations
+type DigestRepositoryInterface interface {
+	Get(ctx context.Context, tenantID uuid.UUID, date time.Time) (*DailyDigest, error)
+	Latest(ctx context.Context, tenantID uuid.UUID) (*DailyDigest, error)
+	Compute(ctx context.Context, tenantID uuid.UUID, date time.Time, publish bool) (*DailyDigest, error)
+}
+
+// PostingPolicyRepositoryInterface defines methods for posting policy operations
+type PostingPolicyRepositoryInterface interface {
+	Get(ctx context.Context, tenantID uuid.UUID) (*PostingPolicy, error)
+	Upsert(ctx context.Context, policy *PostingPolicy) (*PostingPolicy, error)
+}
+
+// StatementRepositoryInterface defines methods for bank statement operations
+type StatementRepositoryInterface interface {
+	Import(ctx context.Context, tenantID uuid.UUID, params ImportStatementParams) (*BankStatement, error)
+}
+
+// ReconciliationRepositoryInterface defines methods for bank reconciliation operations
+type ReconciliationRepositoryInterface interface {
+	ListStatementLines(ctx context.Context, tenantID uuid.UUID, filter StatementLineFilter) ([]*StatementLine, error)
+	ListUnreconciledJournalLines(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, fromDate, toDate *time.Time) ([]*UnreconciledJournalLine, error)
+	Match(ctx context.Context, tenantID uuid.UUID, matches []*MatchParams) ([]*StatementLine, error)
+	Unmatch(ctx context.Context, tenantID uuid.UUID, statementLineID uuid.UUID) (*StatementLine, error)
+	GetSummary(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, fromDate, toDate time.Time) (*ReconciliationSummary, error)
+}
+
+// IntercompanyRepositoryInterface defines methods for intercompany balance operations
+type IntercompanyRepositoryInterface interface {
+	ListBalances(ctx context.Context, tenantID uuid.UUID, asOf time.Time) ([]*IntercompanyBalance, error)
+	FindAccountIDsByNumber(ctx context.Context, tenantID uuid.UUID, accountNumbers []string) (map[string]uuid.UUID, error)
+}
+
+// ReportRepositoryInterface defines methods for reporting queries
+type ReportRepositoryInterface interface {
+	GetTrialBalance(ctx context.Context, tenantID uuid.UUID, bookCode string, fromDate *time.Time, toDate time.Time, postedAsOf *time.Time) ([]*TrialBalanceRow, error)
+	AggregateJournalLines(ctx context.Context, tenantID uuid.UUID, filter LineAggregateFilter) ([]*LineAggregate, error)
+	GetFreshness(ctx context.Context, tenantID uuid.UUID) (*ReportFreshness, error)
+	RefreshPeriodTotals(ctx context.Context, tenantID uuid.UUID) (int, error)
+	GetAccountStatement(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, fromDate, toDate time.Time) (*AccountStatement, error)
+}
+
+// ConsolidationRepositoryInterface defines methods for consolidation group operations
+type ConsolidationRepositoryInterface interface {
+	CreateGroup(ctx context.Context, params ConsolidationGroupParams) (*ConsolidationGroup, error)
+	UpdateGroup(ctx context.Context, groupID uuid.UUID, params ConsolidationGroupParams) (*ConsolidationGroup, error)
+	GetGroup(ctx context.Context, groupID uuid.UUID) (*ConsolidationGroup, error)
+}
+
+// BudgetRepositoryInterface defines methods for budget operations
+type BudgetRepositoryInterface interface {
+	Create(ctx context.Context, tenantID uuid.UUID, params BudgetParams) (*Budget, error)
+	Update(ctx context.Context, tenantID uuid.UUID, budgetID uuid.UUID, params BudgetParams) (*Budget, error)
+	Delete(ctx context.Context, tenantID uuid.UUID, budgetID uuid.UUID) error
+	GetByID(ctx context.Context, tenantID uuid.UUID, budgetID uuid.UUID) (*Budget, error)
+	List(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*Budget, int, error)
+	GetActuals(ctx context.Context, tenantID uuid.UUID, budgetID uuid.UUID) ([]*BudgetActual, error)
+}
+
+// SubledgerRepositoryInterface defines methods for receivable and payable document operations
+type SubledgerRepositoryInterface interface {
+	CreateDocument(ctx context.Context, tenantID uuid.UUID, params CreateDocumentParams) (*SubledgerDocument, error)
+	GetDocument(ctx context.Context, tenantID uuid.UUID, documentID uuid.UUID) (*SubledgerDocument, error)
+	ListDocuments(ctx context.Context, tenantID uuid.UUID, filter DocumentFilter, limit, offset int) ([]*SubledgerDocument, int, error)
+	Apply(ctx context.Context, tenantID uuid.UUID, paymentID, documentID uuid.UUID, amount decimal.Decimal) (*DocumentApplication, error)
+	Unapply(ctx context.Context, tenantID uuid.UUID, applicationID uuid.UUID) (*DocumentApplication, error)
+}
+
+// FixedAssetRepositoryInterface defines methods for fixed asset register operations
+type FixedAssetRepositoryInterface interface {
+	Create(ctx context.Context, tenantID uuid.UUID, params CreateFixedAssetParams) (*FixedAsset, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, assetID uuid.UUID) (*FixedAsset, error)
+	List(ctx context.Context, tenantID uuid.UUID, filter FixedAssetFilter, limit, offset int) ([]*FixedAsset, int, error)
+	ListDueDepreciation(ctx context.Context, tenantID uuid.UUID, assetID *uuid.UUID, through time.Time) ([]*DepreciationLine, error)
+	PostDepreciation(ctx context.Context, tenantID uuid.UUID, lineID uuid.UUID, entry CreateJournalEntryParams) (*DepreciationLine, error)
+}
+
+// InterestRepositoryInterface defines methods for interest scheme and accrual operations
+type InterestRepositoryInterface interface {
+	CreateScheme(ctx context.Context, tenantID uuid.UUID, params InterestSchemeParams) (*InterestScheme, error)
+	UpdateScheme(ctx context.Context, tenantID uuid.UUID, schemeID uuid.UUID, params InterestSchemeParams) (*InterestScheme, error)
+	GetScheme(ctx context.Context, tenantID uuid.UUID, schemeID uuid.UUID) (*InterestScheme, error)
+	ListSchemes(ctx context.Context, tenantID uuid.UUID) ([]*InterestScheme, error)
+	AttachScheme(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, schemeID uuid.UUID, accruedThrough time.Time) (*AccountInterest, error)
+	DetachScheme(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) error
+	ListDueAccruals(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, through time.Time) ([]*AccountInterest, error)
+	GetDailyMovements(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, after, through time.Time) (decimal.Decimal, []*DailyMovement, error)
+	PostAccrual(ctx context.Context, tenantID uuid.UUID, accrual InterestAccrual, entry CreateJournalEntryParams) (*InterestAccrual, error)
+	ListAccruals(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, limit, offset int) ([]*InterestAccrual, int, error)
+}
+
+// HoldRepositoryInterface defines methods for authorization hold operations
+type HoldRepositoryInterface interface {
+	Create(ctx context.Context, tenantID uuid.UUID, params CreateHoldParams) (*Hold, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, holdID uuid.UUID) (*Hold, error)
+	List(ctx context.Context, tenantID uuid.UUID, filter HoldFilter, limit, offset int) ([]*Hold, int, error)
+	Capture(ctx context.Context, tenantID uuid.UUID, holdID uuid.UUID, amount decimal.Decimal, entry CreateJournalEntryParams) (*Hold, error)
+	Release(ctx context.Context, tenantID uuid.UUID, holdID uuid.UUID) (*Hold, error)
+}
+
+// PreparedEntryRepositoryInterface defines methods for two-phase journal
+// entry operations
+type PreparedEntryRepositoryInterface interface {
+	Prepare(ctx context.Context, tenantID uuid.UUID, entry CreateJournalEntryParams, expiresAt time.Time) (*PreparedJournalEntry, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, preparedID uuid.UUID) (*PreparedJournalEntry, error)
+	Confirm(ctx context.Context, tenantID uuid.UUID, preparedID uuid.UUID) (*PreparedJournalEntry, error)
+	Cancel(ctx context.Context, tenantID uuid.UUID, preparedID uuid.UUID) (*PreparedJournalEntry, error)
+}
+
+// JournalBatchRepositoryInterface defines methods for journal batch
+// operations
+type JournalBatchRepositoryInterface interface {
+	Create(ctx context.Context, tenantID uuid.UUID, name, description string) (*JournalBatch, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID) (*JournalBatch, error)
+	List(ctx context.Context, tenantID uuid.UUID, batchStatus *string, limit, offset int) ([]*JournalBatch, int, error)
+	AddEntry(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID, entry CreateJournalEntryParams) (*JournalBatch, *JournalBatchEntry, error)
+	Validate(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID) ([]*JournalBatchProblem, error)
+	Approve(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID) (*JournalBatch, error)
+	Reject(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID, reason string) (*JournalBatch, error)
+	Post(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID) (*JournalBatch, error)
+}
+
+// ExportJobRepositoryInterface defines methods for export job operations
+type ExportJobRepositoryInterface interface {
+	Create(ctx context.Context, tenantID uuid.UUID, format string, datasets []string, archive bool) (*ExportJob, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, jobID uuid.UUID) (*ExportJob, error)
+	UpdateStatus(ctx context.Context, tenantID uuid.UUID, jobID uuid.UUID, status string, files []string, errMsg *string) error
+}
+
+// StatementRunRepositoryInterface defines methods for statement run operations
+type StatementRunRepositoryInterface interface {
+	Create(ctx context.Context, tenantID uuid.UUID, params StatementRunParams) (*StatementRun, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, runID uuid.UUID) (*StatementRun, error)
+	UpdateStatus(ctx context.Context, tenantID uuid.UUID, runID uuid.UUID, status string, statements []DeliveredStatement, errMsg *string) error
+}
+
+// TenantDataRepositoryInterface defines methods for exporting, purging and cloning all data of a tenant
+type TenantDataRepositoryInterface interface {
+	Snapshot(ctx context.Context, tenantID uuid.UUID, fn func(TenantSnapshot) error) error
+	RequestPurge(ctx context.Context, tenantID uuid.UUID) (*TenantPurgeRequest, error)
+	Purge(ctx context.Context, tenantID uuid.UUID, token string) (*TenantPurge, error)
+	PurgeExpiredTest(ctx context.Context, tenantID uuid.UUID, before time.Time) (*TenantPurge, error)
+	Clone(ctx context.Context, params CloneTenantParams) (*TenantClone, error)
+}
+
+// TenantSnapshot reads the data of a tenant as of a single point in time, so
+// the datasets read from it are consistent with each other
+type TenantSnapshot interface {
+	TakenAt() time.Time
+	Accounts(ctx context.Context, fn func(*Account) error) error
+	JournalEntries(ctx context.Context, fn func(*JournalEntry) error) error
+	Balances(ctx context.Context, fn func(*AccountBalance) error) error
+	Settings(ctx context.Context) (*TenantSettings, error)
+}
+
+// BalanceListenerInterface defines methods for receiving committed balance changes
+type BalanceListenerInterface interface {
+	Listen(ctx context.Context, listening func(), fn func(BalanceChange)) error
+}
+
+// CloseRepositoryInterface defines methods for period close checklists
+type CloseRepositoryInterface interface {
+	GetTemplate(ctx context.Context, tenantID uuid.UUID) ([]*CloseTaskTemplate, error)
+	SetTemplate(ctx context.Context, tenantID uuid.UUID, tasks []*CloseTaskTemplate) ([]*CloseTaskTemplate, error)
+	Start(ctx context.Context, tenantID uuid.UUID, periodStart, periodEnd time.Time) (*CloseChecklist, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, checklistID uuid.UUID) (*CloseChecklist, error)
+	List(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*CloseChecklist, int, error)
+	UpdateTask(ctx context.Context, tenantID uuid.UUID, taskID uuid.UUID, status string, note *string) (*CloseChecklist, error)
 }
@@ -9,52 +9,11 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/hesabFun/ledger/internal/db"
+	"github.com/hesabFun/ledger/internal/keyring"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/shopspring/decimal"
 )
 
-// JournalEntry represents a journal entry entity
-type JournalEntry struct {
-	ID              uuid.UUID
-	TenantID        uuid.UUID
-	ReferenceNumber string
-	Description     string
-	EntryDate       time.Time
-	Metadata        map[string]interface{}
-	Lines           []*JournalEntryLine
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
-}
-
-// JournalEntryLine represents a single line in a journal entry
-type JournalEntryLine struct {
-	ID             uuid.UUID
-	JournalEntryID uuid.UUID
-	AccountID      uuid.UUID
-	Debit          decimal.Decimal
-	Credit         decimal.Decimal
-	Description    string
-	CreatedAt      time.Time
-}
-
-// CreateJournalEntryParams holds parameters for creating a journal entry
-type CreateJournalEntryParams struct {
-	ReferenceNumber string
-	Description     string
-	EntryDate       time.Time
-	Metadata        map[string]interface{}
-	Lines           []*CreateJournalEntryLineParams
-}
-
-// CreateJournalEntryLineParams holds parameters for creating a journal entry line
-type CreateJournalEntryLineParams struct {
-	AccountID   uuid.UUID
-	Debit       decimal.Decimal
-	Credit      decimal.Decimal
-	Description string
-}
-
 // JournalRepository handles journal entry database operations
 type JournalRepository struct {
 	db *db.DB
@@ -74,53 +33,215 @@ func (r *JournalRepository) Create(ctx context.Context, tenantID uuid.UUID, para
 	}
 	defer tx.Rollback(ctx)
 
-	// Convert lines to JSONB format expected by the database function
-	linesJSON := make([]map[string]interface{}, len(params.Lines))
+	journalEntryID, err := insertJournalEntry(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Fetch the created journal entry details
+	return r.GetByID(ctx, tenantID, journalEntryID)
+}
+
+// insertJournalEntry creates a journal entry inside an open transaction and
+// returns its ID. The entry is posted by postJournalEntry, or by the legacy
+// create_journal_entry database function when it is enabled.
+func insertJournalEntry(ctx context.Context, tx *db.TenantTx, params CreateJournalEntryParams) (uuid.UUID, error) {
+	// Serialize postings per tenant for the hash chain and balance rebuilds
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := checkPostingRules(ctx, tx, params); err != nil {
+		return uuid.Nil, err
+	}
+
+	// Reject postings to soft-deleted accounts and across books, and
+	// entries that do not balance in the currency of each account
+	accountIDs := make([]uuid.UUID, len(params.Lines))
 	for i, line := range params.Lines {
-		linesJSON[i] = map[string]interface{}{
-			"account_id":  line.AccountID.String(),
-			"debit":       line.Debit.String(),
-			"credit":      line.Credit.String(),
-			"description": line.Description,
+		accountIDs[i] = line.AccountID
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, currency_code, deleted_at IS NOT NULL, book_id
+		FROM accounts
+		WHERE id = ANY($1)
+	`, accountIDs)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to check accounts: %w", err)
+	}
+	currencies := make(map[uuid.UUID]string, len(accountIDs))
+	var deletedAccount, otherBook bool
+	bookID := params.BookID
+	for rows.Next() {
+		var accountID, accountBookID uuid.UUID
+		var currency string
+		var deleted bool
+		if err := rows.Scan(&accountID, &currency, &deleted, &accountBookID); err != nil {
+			rows.Close()
+			return uuid.Nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		currencies[accountID] = currency
+		deletedAccount = deletedAccount || deleted
+		if bookID != nil && *bookID != accountBookID {
+			otherBook = true
 		}
+		bookID = &accountBookID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to check accounts: %w", err)
+	}
+	if deletedAccount {
+		return uuid.Nil, ErrDeletedAccount
+	}
+	if otherBook {
+		return uuid.Nil, ErrBookMismatch
+	}
+	if err := validateJournalLines(params.Lines, currencies); err != nil {
+		return uuid.Nil, err
 	}
 
-	linesBytes, err := json.Marshal(linesJSON)
+	// Sealed metadata is also what the event, and the entry hash, record
+	params.Metadata, err = sealMetadata(ctx, tx, params.Metadata)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal lines: %w", err)
+		return uuid.Nil, err
 	}
 
 	var metadataBytes []byte
 	if params.Metadata != nil {
 		metadataBytes, err = json.Marshal(params.Metadata)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+			return uuid.Nil, fmt.Errorf("failed to marshal metadata: %w", err)
 		}
 	}
 
 	var journalEntryID uuid.UUID
-	query := "SELECT create_journal_entry($1, $2, $3, $4, $5)"
+	if tx.SQLFunctions() {
+		journalEntryID, err = callCreateJournalEntry(ctx, tx, params, metadataBytes)
+	} else {
+		journalEntryID, err = postJournalEntry(ctx, tx, params, metadataBytes)
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := projectJournalEntry(ctx, tx, journalEntryID); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := checkAvailableBalances(ctx, tx, params.Lines); err != nil {
+		return uuid.Nil, err
+	}
+
+	// Keys live in their own table so journal entries stay append-only
+	if params.IdempotencyKey != "" {
+		err := tx.Exec(ctx, `
+			INSERT INTO journal_entry_idempotency_keys (tenant_id, idempotency_key, journal_entry_id)
+			VALUES (current_setting('app.current_tenant_id')::uuid, $1, $2)
+		`, params.IdempotencyKey, journalEntryID)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to record idempotency key: %w", err)
+		}
+	}
+
+	if params.TransactionID != "" {
+		err := tx.Exec(ctx, `
+			INSERT INTO journal_entry_transactions (tenant_id, transaction_id, journal_entry_id)
+			VALUES (current_setting('app.current_tenant_id')::uuid, $1, $2)
+		`, params.TransactionID, journalEntryID)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to record transaction ID: %w", err)
+		}
+	}
+
+	if params.LockOverrideReason != nil {
+		err := tx.Exec(ctx, `
+			INSERT INTO journal_entry_lock_overrides (tenant_id, journal_entry_id, reason)
+			VALUES (current_setting('app.current_tenant_id')::uuid, $1, $2)
+		`, journalEntryID, *params.LockOverrideReason)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to record lock override: %w", err)
+		}
+	}
+
+	if params.CurrencyCode != "" {
+		err := tx.Exec(ctx, `
+			INSERT INTO journal_entry_currencies (tenant_id, journal_entry_id, currency_code)
+			VALUES (current_setting('app.current_tenant_id')::uuid, $1, $2)
+		`, journalEntryID, params.CurrencyCode)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to record entry currency: %w", err)
+		}
+	}
 
-	err = tx.QueryRow(ctx, query,
-		params.ReferenceNumber,
-		params.Description,
-		params.EntryDate,
-		string(linesBytes),
-		string(metadataBytes),
-	).Scan(&journalEntryID)
+	if err := chainJournalEntry(ctx, tx, journalEntryID); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := appendEvent(ctx, tx, AggregateJournalEntry, journalEntryID, EventJournalEntryPosted, journalEntryPostedPayload(params)); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := notifyBalanceChanges(ctx, tx, accountIDs); err != nil {
+		return uuid.Nil, err
+	}
+
+	return journalEntryID, nil
+}
 
+// GetByIdempotencyKey retrieves the journal entry posted with an idempotency key
+func (r *JournalRepository) GetByIdempotencyKey(ctx context.Context, tenantID uuid.UUID, key string) (*JournalEntry, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create journal entry: %w", err)
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	var journalEntryID uuid.UUID
+	err = conn.QueryRow(ctx, "SELECT journal_entry_id FROM journal_entry_idempotency_keys WHERE idempotency_key = $1", key).Scan(&journalEntryID)
+	conn.Release()
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("journal entry %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get journal entry: %w", err)
 	}
 
-	// Fetch the created journal entry details
 	return r.GetByID(ctx, tenantID, journalEntryID)
 }
 
+// ListByTransactionID retrieves the journal entries of a business
+// transaction, oldest first
+func (r *JournalRepository) ListByTransactionID(ctx context.Context, tenantID uuid.UUID, transactionID string) ([]*JournalEntry, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT je.id, je.tenant_id, je.reference_number, je.description,
+		       je.entry_date, je.posted_at, je.metadata, je.created_at, je.updated_at,
+		       ` + lockOverrideColumn + `, ` + currencyColumn + `,
+		       ` + bookColumn + `, ` + transactionColumn + `
+		FROM journal_entries je
+		JOIN journal_entry_transactions jet ON jet.journal_entry_id = je.id
+		WHERE jet.transaction_id = $1
+		ORDER BY je.posted_at, je.id
+	`
+
+	rows, err := conn.Query(ctx, query, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journal entries: %w", err)
+	}
+
+	return r.collectEntries(ctx, conn, rows)
+}
+
 // GetByID retrieves a journal entry by ID with tenant context
 func (r *JournalRepository) GetByID(ctx context.Context, tenantID uuid.UUID, journalEntryID uuid.UUID) (*JournalEntry, error) {
 	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
@@ -133,10 +254,11 @@ func (r *JournalRepository) GetByID(ctx context.Context, tenantID uuid.UUID, jou
 	var metadataBytes []byte
 
 	query := `
-		SELECT id, tenant_id, reference_number, description, entry_date,
-		       metadata, created_at, updated_at
-		FROM journal_entries
-		WHERE id = $1
+		SELECT je.id, je.tenant_id, je.reference_number, je.description, je.entry_date,
+		       je.posted_at, je.metadata, je.created_at, je.updated_at, ` + lockOverrideColumn + `, ` + currencyColumn + `,
+		       ` + bookColumn + `, ` + transactionColumn + `
+		FROM journal_entries je
+		WHERE je.id = $1
 	`
 
 	err = conn.QueryRow(ctx, query, journalEntryID).Scan(
@@ -145,23 +267,26 @@ func (r *JournalRepository) GetByID(ctx context.Context, tenantID uuid.UUID, jou
 		&entry.ReferenceNumber,
 		&entry.Description,
 		&entry.EntryDate,
+		&entry.PostedAt,
 		&metadataBytes,
 		&entry.CreatedAt,
 		&entry.UpdatedAt,
+		&entry.LockOverrideReason,
+		&entry.CurrencyCode,
+		&entry.BookID,
+		&entry.TransactionID,
 	)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("journal entry not found")
+			return nil, fmt.Errorf("journal entry %w", ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get journal entry: %w", err)
 	}
 
-	// Parse metadata if present
-	if len(metadataBytes) > 0 {
-		if err := json.Unmarshal(metadataBytes, &entry.Metadata); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-		}
+	entry.Metadata, err = decodeMetadata(ctx, r.db.MetadataKeyring(), tenantID, metadataBytes)
+	if err != nil {
+		return nil, err
 	}
 
 	// Fetch journal entry lines
@@ -174,112 +299,315 @@ func (r *JournalRepository) GetByID(ctx context.Context, tenantID uuid.UUID, jou
 	return entry, nil
 }
 
+// lockOverrideColumn selects the lock override reason of a journal entry
+// aliased je
+const lockOverrideColumn = `(SELECT reason FROM journal_entry_lock_overrides WHERE journal_entry_id = je.id)`
+
+// currencyColumn selects the currency of a journal entry aliased je. Entries
+// that did not record it are in the currency of the accounts of their lines
+// without an fx rate.
+const currencyColumn = `COALESCE(
+		       (SELECT currency_code FROM journal_entry_currencies WHERE journal_entry_id = je.id),
+		       (SELECT a.currency_code FROM journal_entry_lines l JOIN accounts a ON a.id = l.account_id
+		        WHERE l.journal_entry_id = je.id AND l.fx_rate IS NULL LIMIT 1),
+		       '')`
+
+// bookColumn selects the book of a journal entry aliased je, which is the
+// book of the accounts of all of its lines
+const bookColumn = `(SELECT a.book_id FROM journal_entry_lines l JOIN accounts a ON a.id = l.account_id
+		        WHERE l.journal_entry_id = je.id LIMIT 1)`
+
+// transactionColumn selects the business transaction of a journal entry
+// aliased je, or an empty string
+const transactionColumn = `COALESCE((SELECT transaction_id FROM journal_entry_transactions WHERE journal_entry_id = je.id), '')`
+
+// lineColumns are the journal_entry_lines columns read by scanJournalLine
+const lineColumns = `id, journal_entry_id, account_id, debit, credit, description,
+		       counterparty_tenant_id, fx_rate, tax_code_id, is_tax, party_id, dimensions, created_at`
+
+// scanJournalLine scans a row selected with lineColumns into a line
+func scanJournalLine(row pgx.Row, line *JournalEntryLine) error {
+	return row.Scan(
+		&line.ID,
+		&line.JournalEntryID,
+		&line.AccountID,
+		&line.Debit,
+		&line.Credit,
+		&line.Description,
+		&line.CounterpartyTenantID,
+		&line.FxRate,
+		&line.TaxCodeID,
+		&line.IsTax,
+		&line.PartyID,
+		&line.Dimensions,
+		&line.CreatedAt,
+	)
+}
+
 // getLinesByJournalEntryID retrieves all lines for a journal entry
 func (r *JournalRepository) getLinesByJournalEntryID(ctx context.Context, conn *pgxpool.Conn, journalEntryID uuid.UUID) ([]*JournalEntryLine, error) {
+	lines, err := r.getLinesByJournalEntryIDs(ctx, conn, []uuid.UUID{journalEntryID})
+	if err != nil {
+		return nil, err
+	}
+	return lines[journalEntryID], nil
+}
+
+// getLinesByJournalEntryIDs retrieves the lines of several journal entries
+// with a single query, keyed by entry. Every requested entry has a non-nil
+// slice, empty if it has no lines.
+func (r *JournalRepository) getLinesByJournalEntryIDs(ctx context.Context, conn *pgxpool.Conn, journalEntryIDs []uuid.UUID) (map[uuid.UUID][]*JournalEntryLine, error) {
+	lines := make(map[uuid.UUID][]*JournalEntryLine, len(journalEntryIDs))
+	for _, id := range journalEntryIDs {
+		lines[id] = make([]*JournalEntryLine, 0)
+	}
+	if len(journalEntryIDs) == 0 {
+		return lines, nil
+	}
+
 	query := `
-		SELECT id, journal_entry_id, account_id, debit, credit, description, created_at
+		SELECT ` + lineColumns + `
 		FROM journal_entry_lines
-		WHERE journal_entry_id = $1
+		WHERE journal_entry_id = ANY($1)
 		ORDER BY created_at
 	`
 
-	rows, err := conn.Query(ctx, query, journalEntryID)
+	rows, err := conn.Query(ctx, query, journalEntryIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query journal entry lines: %w", err)
 	}
 	defer rows.Close()
 
-	lines := make([]*JournalEntryLine, 0)
 	for rows.Next() {
 		line := &JournalEntryLine{}
-		err := rows.Scan(
-			&line.ID,
-			&line.JournalEntryID,
-			&line.AccountID,
-			&line.Debit,
-			&line.Credit,
-			&line.Description,
-			&line.CreatedAt,
-		)
-		if err != nil {
+		if err := scanJournalLine(rows, line); err != nil {
 			return nil, fmt.Errorf("failed to scan journal entry line: %w", err)
 		}
-		lines = append(lines, line)
+		lines[line.JournalEntryID] = append(lines[line.JournalEntryID], line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal entry lines: %w", err)
 	}
 
 	return lines, nil
 }
 
-// List retrieves journal entries with optional filters
-func (r *JournalRepository) List(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, fromDate, toDate *time.Time, limit, offset int) ([]*JournalEntry, int, error) {
+// journalEntryListWhere filters journal entries by the filters of List.
+// Filters that are not set are passed as NULL rather than left out, so the
+// query text is the same for every combination and its prepared statement
+// is reused.
+const journalEntryListWhere = `
+		WHERE ($1::uuid IS NULL OR EXISTS (
+			SELECT 1 FROM journal_entry_lines jel WHERE jel.journal_entry_id = je.id AND jel.account_id = $1))
+		  AND ($2::uuid IS NULL OR EXISTS (
+			SELECT 1 FROM journal_entry_lines jel JOIN accounts a ON a.id = jel.account_id
+			WHERE jel.journal_entry_id = je.id AND a.book_id = $2))
+		  AND ($3::date IS NULL OR je.entry_date >= $3)
+		  AND ($4::date IS NULL OR je.entry_date <= $4)
+		  AND ($5::timestamptz IS NULL OR je.posted_at >= $5)
+		  AND ($6::timestamptz IS NULL OR je.posted_at <= $6)
+		  AND ($7::text IS NULL OR je.reference_number = $7)
+		  AND ($8::text IS NULL OR je.reference_number LIKE $8)
+		  AND ($9::text IS NULL OR je.description ILIKE $9)
+		  AND ($10::numeric IS NULL OR (
+			SELECT COALESCE(SUM(jel.debit), 0) FROM journal_entry_lines jel WHERE jel.journal_entry_id = je.id) >= $10)
+		  AND ($11::numeric IS NULL OR (
+			SELECT COALESCE(SUM(jel.debit), 0) FROM journal_entry_lines jel WHERE jel.journal_entry_id = je.id) <= $11)`
+
+// List retrieves a page of journal entries matching a filter together with
+// the totals of all matching entries
+func (r *JournalRepository) List(ctx context.Context, tenantID uuid.UUID, filter JournalEntryFilter, limit, offset int) ([]*JournalEntry, *JournalEntryTotals, error) {
 	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to set tenant context: %w", err)
+		return nil, nil, fmt.Errorf("failed to set tenant context: %w", err)
 	}
 	defer conn.Release()
 
-	// Build query with filters
+	var referencePattern, descriptionPattern *string
+	if filter.ReferencePrefix != nil {
+		pattern := likePrefix(*filter.ReferencePrefix)
+		referencePattern = &pattern
+	}
+	if filter.DescriptionContains != nil {
+		pattern := likeContains(*filter.DescriptionContains)
+		descriptionPattern = &pattern
+	}
+
+	args := []interface{}{
+		filter.AccountID,
+		filter.BookID,
+		filter.FromDate,
+		filter.ToDate,
+		filter.PostedFrom,
+		filter.PostedTo,
+		filter.ReferenceNumber,
+		referencePattern,
+		descriptionPattern,
+		filter.MinAmount,
+		filter.MaxAmount,
+	}
+
+	// Count and sum all matching entries; with an account filter, only the
+	// lines on that account are summed
+	totals := &JournalEntryTotals{}
+	err = conn.QueryRow(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(t.debit), 0), COALESCE(SUM(t.credit), 0)
+		FROM journal_entries je
+		CROSS JOIN LATERAL (
+			SELECT SUM(l.debit) AS debit, SUM(l.credit) AS credit
+			FROM journal_entry_lines l
+			WHERE l.journal_entry_id = je.id AND ($1::uuid IS NULL OR l.account_id = $1)
+		) t
+	`+journalEntryListWhere, args...).Scan(&totals.EntryCount, &totals.Debit, &totals.Credit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count journal entries: %w", err)
+	}
+
+	// Add pagination
 	query := `
-		SELECT DISTINCT je.id, je.tenant_id, je.reference_number, je.description,
-		       je.entry_date, je.metadata, je.created_at, je.updated_at
+		SELECT je.id, je.tenant_id, je.reference_number, je.description,
+		       je.entry_date, je.posted_at, je.metadata, je.created_at, je.updated_at,
+		       ` + lockOverrideColumn + `, ` + currencyColumn + `,
+		       ` + bookColumn + `, ` + transactionColumn + `
 		FROM journal_entries je
-	`
-	countQuery := "SELECT COUNT(DISTINCT je.id) FROM journal_entries je"
-	args := []interface{}{}
-	argCount := 0
+	` + journalEntryListWhere + `
+		ORDER BY je.entry_date DESC, je.created_at DESC
+		LIMIT $12 OFFSET $13`
+	args = append(args, limit, offset)
 
-	// Add join if filtering by account
-	if accountID != nil {
-		query += " INNER JOIN journal_entry_lines jel ON je.id = jel.journal_entry_id"
-		countQuery += " INNER JOIN journal_entry_lines jel ON je.id = jel.journal_entry_id"
-		argCount++
-		query += fmt.Sprintf(" WHERE jel.account_id = $%d", argCount)
-		countQuery += fmt.Sprintf(" WHERE jel.account_id = $%d", argCount)
-		args = append(args, *accountID)
-	} else {
-		query += " WHERE 1=1"
-		countQuery += " WHERE 1=1"
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list journal entries: %w", err)
+	}
+
+	entries, err := r.collectEntries(ctx, conn, rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entries, totals, nil
+}
+
+// Search retrieves journal entries matching a full-text query over their
+// description, reference number, line descriptions and metadata values,
+// best matches first. The query uses web search syntax ("quoted phrases",
+// OR, -excluded words).
+func (r *JournalRepository) Search(ctx context.Context, tenantID uuid.UUID, text string, fromDate, toDate *time.Time, limit, offset int) ([]*JournalEntry, int, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to set tenant context: %w", err)
 	}
+	defer conn.Release()
+
+	// search_vector is maintained by a trigger and backed by a GIN index
+	where := " WHERE je.search_vector @@ websearch_to_tsquery('simple', $1)"
+	args := []interface{}{text}
+	argCount := 1
 
 	if fromDate != nil {
 		argCount++
-		query += fmt.Sprintf(" AND je.entry_date >= $%d", argCount)
-		countQuery += fmt.Sprintf(" AND je.entry_date >= $%d", argCount)
+		where += fmt.Sprintf(" AND je.entry_date >= $%d", argCount)
 		args = append(args, *fromDate)
 	}
 
 	if toDate != nil {
 		argCount++
-		query += fmt.Sprintf(" AND je.entry_date <= $%d", argCount)
-		countQuery += fmt.Sprintf(" AND je.entry_date <= $%d", argCount)
+		where += fmt.Sprintf(" AND je.entry_date <= $%d", argCount)
 		args = append(args, *toDate)
 	}
 
-	// Get total count
 	var totalCount int
-	err = conn.QueryRow(ctx, countQuery, args...).Scan(&totalCount)
-	if err != nil {
+	if err := conn.QueryRow(ctx, "SELECT COUNT(*) FROM journal_entries je"+where, args...).Scan(&totalCount); err != nil {
 		return nil, 0, fmt.Errorf("failed to count journal entries: %w", err)
 	}
 
-	// Add pagination
-	argCount++
-	query += fmt.Sprintf(" ORDER BY je.entry_date DESC, je.created_at DESC LIMIT $%d", argCount)
-	args = append(args, limit)
-
-	argCount++
-	query += fmt.Sprintf(" OFFSET $%d", argCount)
-	args = append(args, offset)
+	query := `
+		SELECT je.id, je.tenant_id, je.reference_number, je.description,
+		       je.entry_date, je.posted_at, je.metadata, je.created_at, je.updated_at,
+		       ` + lockOverrideColumn + `, ` + currencyColumn + `,
+		       ` + bookColumn + `, ` + transactionColumn + `
+		FROM journal_entries je
+	` + where + fmt.Sprintf(`
+		ORDER BY ts_rank(je.search_vector, websearch_to_tsquery('simple', $1)) DESC,
+		         je.entry_date DESC, je.created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, argCount+1, argCount+2)
+	args = append(args, limit, offset)
 
 	rows, err := conn.Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list journal entries: %w", err)
+		return nil, 0, fmt.Errorf("failed to search journal entries: %w", err)
+	}
+
+	entries, err := r.collectEntries(ctx, conn, rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, totalCount, nil
+}
+
+// Stream calls fn for every journal entry in a date range, with its lines,
+// oldest first. Entries and lines are read in a single query so memory use
+// stays flat regardless of the number of entries; returning an error from fn
+// stops the stream.
+func (r *JournalRepository) Stream(ctx context.Context, tenantID uuid.UUID, fromDate, toDate *time.Time, fn func(*JournalEntry) error) error {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	restoreTimeout, err := db.WithoutStatementTimeout(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer restoreTimeout()
+
+	return streamJournalEntries(ctx, conn, r.db.MetadataKeyring(), tenantID, fromDate, toDate, fn)
+}
+
+// streamJournalEntries runs the query of Stream on q, a connection or a
+// transaction of the tenant
+func streamJournalEntries(ctx context.Context, q rowQuerier, kr *keyring.Keyring, tenantID uuid.UUID, fromDate, toDate *time.Time, fn func(*JournalEntry) error) error {
+	query := `
+		SELECT je.id, je.tenant_id, je.reference_number, je.description,
+		       je.entry_date, je.posted_at, je.metadata, je.created_at, je.updated_at,
+		       ` + lockOverrideColumn + `, ` + currencyColumn + `,
+		       ` + bookColumn + `, ` + transactionColumn + `,
+		       jel.id, jel.account_id, jel.debit, jel.credit, jel.description,
+		       jel.counterparty_tenant_id, jel.fx_rate, jel.tax_code_id, jel.is_tax, jel.party_id, jel.dimensions, jel.created_at
+		FROM journal_entries je
+		INNER JOIN journal_entry_lines jel ON jel.journal_entry_id = je.id
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argCount := 0
+
+	if fromDate != nil {
+		argCount++
+		query += fmt.Sprintf(" AND je.entry_date >= $%d", argCount)
+		args = append(args, *fromDate)
+	}
+
+	if toDate != nil {
+		argCount++
+		query += fmt.Sprintf(" AND je.entry_date <= $%d", argCount)
+		args = append(args, *toDate)
+	}
+
+	query += " ORDER BY je.entry_date, je.created_at, je.id, jel.created_at"
+
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to stream journal entries: %w", err)
 	}
 	defer rows.Close()
 
-	entries := make([]*JournalEntry, 0)
+	var current *JournalEntry
 	for rows.Next() {
 		entry := &JournalEntry{}
+		line := &JournalEntryLine{}
 		var metadataBytes []byte
 
 		err := rows.Scan(
@@ -288,30 +616,106 @@ func (r *JournalRepository) List(ctx context.Context, tenantID uuid.UUID, accoun
 			&entry.ReferenceNumber,
 			&entry.Description,
 			&entry.EntryDate,
+			&entry.PostedAt,
 			&metadataBytes,
 			&entry.CreatedAt,
 			&entry.UpdatedAt,
+			&entry.LockOverrideReason,
+			&entry.CurrencyCode,
+			&entry.BookID,
+			&entry.TransactionID,
+			&line.ID,
+			&line.AccountID,
+			&line.Debit,
+			&line.Credit,
+			&line.Description,
+			&line.CounterpartyTenantID,
+			&line.FxRate,
+			&line.TaxCodeID,
+			&line.IsTax,
+			&line.PartyID,
+			&line.Dimensions,
+			&line.CreatedAt,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan journal entry: %w", err)
+			return fmt.Errorf("failed to scan journal entry: %w", err)
 		}
+		line.JournalEntryID = entry.ID
+
+		if current == nil || current.ID != entry.ID {
+			if current != nil {
+				if err := fn(current); err != nil {
+					return err
+				}
+			}
 
-		// Parse metadata if present
-		if len(metadataBytes) > 0 {
-			if err := json.Unmarshal(metadataBytes, &entry.Metadata); err != nil {
-				return nil, 0, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			entry.Metadata, err = decodeMetadata(ctx, kr, tenantID, metadataBytes)
+			if err != nil {
+				return err
 			}
+			current = entry
 		}
+		current.Lines = append(current.Lines, line)
+	}
+
+	if current != nil {
+		return fn(current)
+	}
 
-		// Fetch lines for this entry
-		lines, err := r.getLinesByJournalEntryID(ctx, conn, entry.ID)
+	return nil
+}
+
+// collectEntries scans journal entry rows and then loads the lines of all of
+// them with one query. Lines are loaded once the result set is closed, since
+// the connection cannot run a second query while rows are still being read.
+func (r *JournalRepository) collectEntries(ctx context.Context, conn *pgxpool.Conn, rows pgx.Rows) ([]*JournalEntry, error) {
+	entries := make([]*JournalEntry, 0)
+	for rows.Next() {
+		entry := &JournalEntry{}
+		var metadataBytes []byte
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.TenantID,
+			&entry.ReferenceNumber,
+			&entry.Description,
+			&entry.EntryDate,
+			&entry.PostedAt,
+			&metadataBytes,
+			&entry.CreatedAt,
+			&entry.UpdatedAt,
+			&entry.LockOverrideReason,
+			&entry.CurrencyCode,
+			&entry.BookID,
+			&entry.TransactionID,
+		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to get journal entry lines: %w", err)
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan journal entry: %w", err)
+		}
+
+		entry.Metadata, err = decodeMetadata(ctx, r.db.MetadataKeyring(), entry.TenantID, metadataBytes)
+		if err != nil {
+			rows.Close()
+			return nil, err
 		}
-		entry.Lines = lines
 
 		entries = append(entries, entry)
 	}
+	rows.Close()
 
-	return entries, totalCount, nil
+	ids := make([]uuid.UUID, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+
+	lines, err := r.getLinesByJournalEntryIDs(ctx, conn, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal entry lines: %w", err)
+	}
+	for _, entry := range entries {
+		entry.Lines = lines[entry.ID]
+	}
+
+	return entries, nil
 }
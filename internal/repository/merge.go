@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// Merge moves the balance, the pending holds and reservations and the
+// overdraft limit of the source account to the target account and closes
+// the source, in one transaction. Both accounts must be live and share their
+// book, account type and currency, and the source may not have active
+// children. Posted lines are never rewritten: the balance moves with a
+// reclassification entry dated entryDate, crediting the source and debiting
+// the target, or the other way round.
+func (r *AccountRepository) Merge(ctx context.Context, tenantID uuid.UUID, sourceID, targetID uuid.UUID, entryDate time.Time) (*AccountMerge, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Postings to either account wait for the merge, and the source cannot
+	// gain children while it is checked
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := lockAccountTree(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT a.id, a.account_number, a.book_id, a.account_type_id, a.currency_code,
+		       (SELECT COUNT(*) FROM accounts c WHERE c.parent_account_id = a.id AND c.deleted_at IS NULL)
+		FROM accounts a
+		WHERE a.id = ANY($1) AND a.deleted_at IS NULL
+		FOR UPDATE OF a
+	`, []uuid.UUID{sourceID, targetID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check accounts: %w", err)
+	}
+	type mergedAccount struct {
+		accountNumber  string
+		bookID         uuid.UUID
+		accountTypeID  int32
+		currencyCode   string
+		activeChildren int
+	}
+	accounts := make(map[uuid.UUID]mergedAccount, 2)
+	for rows.Next() {
+		var id uuid.UUID
+		var account mergedAccount
+		if err := rows.Scan(&id, &account.accountNumber, &account.bookID, &account.accountTypeID, &account.currencyCode, &account.activeChildren); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts[id] = account
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to check accounts: %w", err)
+	}
+
+	source, ok := accounts[sourceID]
+	if !ok {
+		return nil, fmt.Errorf("source account %w", ErrNotFound)
+	}
+	target, ok := accounts[targetID]
+	if !ok {
+		return nil, fmt.Errorf("target account %w", ErrNotFound)
+	}
+	switch {
+	case source.bookID != target.bookID:
+		return nil, ErrBookMismatch
+	case source.accountTypeID != target.accountTypeID:
+		return nil, ErrAccountTypeMismatch
+	case source.currencyCode != target.currencyCode:
+		return nil, ErrCurrencyMismatch
+	case source.activeChildren > 0:
+		return nil, ErrAccountHasChildren
+	}
+
+	// The target covers what the source was allowed to go below zero: both
+	// limits when both are set, or else the source's
+	var overdraftLimit decimal.Decimal
+	err = tx.QueryRow(ctx, `
+		UPDATE accounts t
+		SET overdraft_limit = COALESCE(t.overdraft_limit + s.overdraft_limit, s.overdraft_limit),
+		    updated_at = NOW()
+		FROM accounts s
+		WHERE t.id = $2 AND s.id = $1 AND s.overdraft_limit IS NOT NULL
+		RETURNING t.overdraft_limit
+	`, sourceID, targetID).Scan(&overdraftLimit)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return nil, fmt.Errorf("failed to move overdraft limit: %w", err)
+	default:
+		err = appendEvent(ctx, tx, AggregateAccount, targetID, EventAccountOverdraftLimitSet, AccountOverdraftLimitSetPayload{
+			OverdraftLimit: &overdraftLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Pending holds and reservations take part of the balance that moves,
+	// so they move first and the reclassification leaves the source with
+	// nothing held
+	err = tx.Exec(ctx, "UPDATE holds SET account_id = $2 WHERE account_id = $1 AND status = 'PENDING'", sourceID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move holds: %w", err)
+	}
+
+	// A prepared entry that already reserves an amount of the target keeps
+	// one reservation of it, for both amounts
+	err = tx.Exec(ctx, `
+		WITH moved AS (
+			DELETE FROM prepared_entry_reservations r
+			USING prepared_journal_entries p
+			WHERE p.id = r.prepared_entry_id AND r.account_id = $1 AND p.status = 'PENDING'
+			RETURNING r.tenant_id, r.prepared_entry_id, r.amount
+		), added AS (
+			UPDATE prepared_entry_reservations t
+			SET amount = t.amount + m.amount
+			FROM moved m
+			WHERE t.prepared_entry_id = m.prepared_entry_id AND t.account_id = $2
+			RETURNING t.prepared_entry_id
+		)
+		INSERT INTO prepared_entry_reservations (tenant_id, prepared_entry_id, account_id, amount)
+		SELECT m.tenant_id, m.prepared_entry_id, $2, m.amount
+		FROM moved m
+		WHERE m.prepared_entry_id NOT IN (SELECT prepared_entry_id FROM added)
+	`, sourceID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move reservations: %w", err)
+	}
+
+	var net decimal.Decimal
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE((SELECT debit_balance - credit_balance FROM account_balances WHERE account_id = $1), 0)
+	`, sourceID).Scan(&net)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account balance: %w", err)
+	}
+
+	result := &AccountMerge{ID: uuid.New()}
+	if !net.IsZero() {
+		description := fmt.Sprintf("Merge of account %s into %s", source.accountNumber, target.accountNumber)
+		sourceLine := &CreateJournalEntryLineParams{AccountID: sourceID, Debit: decimal.Zero, Credit: decimal.Zero, Description: description}
+		targetLine := &CreateJournalEntryLineParams{AccountID: targetID, Debit: decimal.Zero, Credit: decimal.Zero, Description: description}
+		if net.IsPositive() {
+			sourceLine.Credit, targetLine.Debit = net, net
+		} else {
+			sourceLine.Debit, targetLine.Credit = net.Neg(), net.Neg()
+		}
+
+		entryID, err := insertJournalEntry(ctx, tx, CreateJournalEntryParams{
+			ReferenceNumber: MergeReference(source.accountNumber, result.ID),
+			Description:     description,
+			EntryDate:       entryDate,
+			Lines:           []*CreateJournalEntryLineParams{sourceLine, targetLine},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to post reclassification entry: %w", err)
+		}
+		result.ReclassificationEntryID = &entryID
+	}
+
+	result.Source = &Account{}
+	query := `
+		UPDATE accounts
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + accountColumns
+	if err := scanAccount(tx.QueryRow(ctx, query, sourceID), result.Source); err != nil {
+		return nil, fmt.Errorf("failed to close account: %w", err)
+	}
+
+	result.Target = &Account{}
+	query = `SELECT ` + accountColumns + ` FROM accounts WHERE id = $1`
+	if err := scanAccount(tx.QueryRow(ctx, query, targetID), result.Target); err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	err = appendEvent(ctx, tx, AggregateAccount, sourceID, EventAccountsMerged, AccountsMergedPayload{
+		MergeID:                 result.ID,
+		TargetAccountID:         targetID,
+		ReclassificationEntryID: result.ReclassificationEntryID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := notifyBalanceChanges(ctx, tx, []uuid.UUID{sourceID, targetID}); err != nil {
+		return nil, err
+	}
+
+	if err := setAccountPaths(ctx, tx, result.Source, result.Target); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/hesabFun/ledger/internal/keyring"
+)
+
+// SealedMetadataKey is the only key of the stored metadata of an entry whose
+// tenant encrypts metadata; its value is the keyring.Sealed JSON of the
+// metadata. Entries cannot be posted with metadata using the key.
+const SealedMetadataKey = "$sealed"
+
+// errNoMetadataKeyring is returned when metadata must be sealed or opened
+// but the server has no keyring
+var errNoMetadataKeyring = errors.New("metadata encryption requires a keyring, none is configured")
+
+// sealMetadata returns the metadata to store for a new entry of the tenant
+// of tx: the metadata sealed with the tenant's key when the tenant encrypts
+// metadata, or else as given. Metadata that is already sealed, as that of a
+// confirmed prepared entry, is returned as is.
+func sealMetadata(ctx context.Context, tx *db.TenantTx, metadata map[string]interface{}) (map[string]interface{}, error) {
+	if metadata == nil || isSealedMetadata(metadata) {
+		return metadata, nil
+	}
+
+	var tenantID uuid.UUID
+	var encrypt bool
+	err := tx.QueryRow(ctx, `
+		SELECT current_setting('app.current_tenant_id')::uuid,
+		       COALESCE((SELECT encrypt_metadata FROM tenant_settings), false)
+	`).Scan(&tenantID, &encrypt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata encryption setting: %w", err)
+	}
+	if !encrypt {
+		return metadata, nil
+	}
+
+	kr := tx.MetadataKeyring()
+	if kr == nil {
+		return nil, errNoMetadataKeyring
+	}
+
+	plaintext, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	sealed, err := kr.Seal(ctx, tenantID, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal metadata: %w", err)
+	}
+
+	return map[string]interface{}{SealedMetadataKey: sealed}, nil
+}
+
+// decodeMetadata parses stored metadata, opening it with the tenant's key
+// when it is sealed
+func decodeMetadata(ctx context.Context, kr *keyring.Keyring, tenantID uuid.UUID, data []byte) (map[string]interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+	if !isSealedMetadata(metadata) {
+		return metadata, nil
+	}
+
+	var stored struct {
+		Sealed *keyring.Sealed `json:"$sealed"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sealed metadata: %w", err)
+	}
+
+	if kr == nil {
+		return nil, errNoMetadataKeyring
+	}
+	plaintext, err := kr.Open(ctx, tenantID, stored.Sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata: %w", err)
+	}
+
+	metadata = nil
+	if err := json.Unmarshal(plaintext, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// isSealedMetadata reports whether metadata is in its sealed form
+func isSealedMetadata(metadata map[string]interface{}) bool {
+	if len(metadata) != 1 {
+		return false
+	}
+	switch metadata[SealedMetadataKey].(type) {
+	case *keyring.Sealed, map[string]interface{}:
+		return true
+	}
+	return false
+}
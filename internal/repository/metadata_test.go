@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/keyring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeMetadata(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	root, err := keyring.NewLocalRootKey(bytes.Repeat([]byte{7}, 32))
+	require.NoError(t, err)
+	kr, err := keyring.New("2026", map[string]keyring.RootKey{"2026": root})
+	require.NoError(t, err)
+
+	sealed, err := kr.Seal(ctx, tenantID, []byte(`{"customer":"Jane Doe"}`))
+	require.NoError(t, err)
+	stored, err := json.Marshal(map[string]interface{}{SealedMetadataKey: sealed})
+	require.NoError(t, err)
+
+	t.Run("opens sealed metadata", func(t *testing.T) {
+		metadata, err := decodeMetadata(ctx, kr, tenantID, stored)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"customer": "Jane Doe"}, metadata)
+	})
+
+	t.Run("returns plain metadata as stored", func(t *testing.T) {
+		metadata, err := decodeMetadata(ctx, nil, tenantID, []byte(`{"$sealed":"not an object","order":"A-1"}`))
+
+		require.NoError(t, err)
+		assert.Equal(t, "A-1", metadata["order"])
+	})
+
+	t.Run("returns no metadata when none is stored", func(t *testing.T) {
+		metadata, err := decodeMetadata(ctx, kr, tenantID, nil)
+
+		require.NoError(t, err)
+		assert.Nil(t, metadata)
+	})
+
+	t.Run("returns error without a keyring", func(t *testing.T) {
+		_, err := decodeMetadata(ctx, nil, tenantID, stored)
+
+		assert.ErrorIs(t, err, errNoMetadataKeyring)
+	})
+
+	t.Run("returns error for another tenant", func(t *testing.T) {
+		_, err := decodeMetadata(ctx, kr, uuid.New(), stored)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// BalanceChannel is the PostgreSQL notification channel announcing account
+// balance changes. Each notification carries "<tenant id>:<account id>" and
+// is delivered when the transaction that changed the balance commits.
+const BalanceChannel = "ledger_balance_changes"
+
+// notifyBalanceChanges announces balance changes of accounts of the
+// transaction's tenant on BalanceChannel. PostgreSQL delivers identical
+// notifications of a transaction once.
+func notifyBalanceChanges(ctx context.Context, tx *db.TenantTx, accountIDs []uuid.UUID) error {
+	err := tx.Exec(ctx, `
+		SELECT pg_notify($1, current_setting('app.current_tenant_id') || ':' || id::text)
+		FROM unnest($2::uuid[]) AS id
+	`, BalanceChannel, accountIDs)
+	if err != nil {
+		return fmt.Errorf("failed to notify balance changes: %w", err)
+	}
+	return nil
+}
+
+// ParseBalanceChange decodes the payload of a BalanceChannel notification
+func ParseBalanceChange(payload string) (BalanceChange, error) {
+	tenant, account, ok := strings.Cut(payload, ":")
+	if !ok {
+		return BalanceChange{}, fmt.Errorf("malformed balance change %q", payload)
+	}
+
+	tenantID, err := uuid.Parse(tenant)
+	if err != nil {
+		return BalanceChange{}, fmt.Errorf("malformed balance change tenant: %w", err)
+	}
+	accountID, err := uuid.Parse(account)
+	if err != nil {
+		return BalanceChange{}, fmt.Errorf("malformed balance change account: %w", err)
+	}
+
+	return BalanceChange{TenantID: tenantID, AccountID: accountID}, nil
+}
+
+// BalanceListener receives the balance changes committed by any server
+// sharing the database
+type BalanceListener struct {
+	db *db.DB
+}
+
+// NewBalanceListener creates a new balance listener
+func NewBalanceListener(database *db.DB) *BalanceListener {
+	return &BalanceListener{db: database}
+}
+
+// Listen subscribes a dedicated connection to BalanceChannel and calls fn
+// for each balance change until ctx is done or the connection fails.
+// listening is called once the subscription is active; changes committed
+// before then are not received.
+func (l *BalanceListener) Listen(ctx context.Context, listening func(), fn func(BalanceChange)) error {
+	pooled, err := l.db.Pool().Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to acquire connection: %w", err)
+	}
+	// The connection keeps its subscription, so it never returns to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{BalanceChannel}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to listen for balance changes: %w", err)
+	}
+	listening()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to wait for balance changes: %w", err)
+		}
+
+		// Skip payloads not sent by notifyBalanceChanges
+		change, err := ParseBalanceChange(notification.Payload)
+		if err != nil {
+			continue
+		}
+		fn(change)
+	}
+}
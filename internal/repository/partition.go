@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// partitionedJournalTables are the journal tables partitioned by month of
+// entry_date. Lines carry the entry_date of their entry so that they are
+// partitioned alongside it.
+var partitionedJournalTables = []string{"journal_entries", "journal_entry_lines"}
+
+// archiveLockTimeout bounds how long archiving a partition waits for the
+// queries reading it, so it gives up until the next run instead of queueing
+// every later query behind it
+const archiveLockTimeout = "5s"
+
+// JournalPartition is one partition of a partitioned journal table, holding
+// the rows whose entry_date is in [From, To)
+type JournalPartition struct {
+	Table string
+	Name  string
+	// From is zero for a partition without a lower bound
+	From time.Time
+	// To is zero for a partition without an upper bound
+	To time.Time
+	// Tablespace is empty for the default tablespace
+	Tablespace string
+}
+
+// JournalPartitionRepository maintains the monthly partitions of the journal
+// tables and moves old partitions to an archive tablespace. Archived
+// partitions stay attached to their tables, so every read of the journal
+// still finds their rows and entry date filters prune the partitions they
+// do not need.
+type JournalPartitionRepository struct {
+	db *db.DB
+}
+
+// NewJournalPartitionRepository creates a new journal partition repository
+func NewJournalPartitionRepository(database *db.DB) *JournalPartitionRepository {
+	return &JournalPartitionRepository{db: database}
+}
+
+// ListPartitions returns the partitions of the journal tables that are
+// partitioned, ordered by table and range. Default partitions are left out.
+func (r *JournalPartitionRepository) ListPartitions(ctx context.Context) ([]*JournalPartition, error) {
+	query := `
+		SELECT parent.relname, child.relname, pg_get_expr(child.relpartbound, child.oid),
+		       COALESCE(ts.spcname, '')
+		FROM pg_inherits i
+		JOIN pg_class parent ON parent.oid = i.inhparent
+		JOIN pg_class child ON child.oid = i.inhrelid
+		LEFT JOIN pg_tablespace ts ON ts.oid = child.reltablespace
+		WHERE parent.relname = ANY($1)
+		  AND parent.relkind = 'p'
+		  AND parent.relnamespace = current_schema()::regnamespace
+	`
+
+	rows, err := r.db.Pool().Query(ctx, query, partitionedJournalTables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journal partitions: %w", err)
+	}
+	defer rows.Close()
+
+	partitions := make([]*JournalPartition, 0)
+	for rows.Next() {
+		partition := &JournalPartition{}
+		var bound string
+		if err := rows.Scan(&partition.Table, &partition.Name, &bound, &partition.Tablespace); err != nil {
+			return nil, fmt.Errorf("failed to scan journal partition: %w", err)
+		}
+
+		var ok bool
+		partition.From, partition.To, ok = parsePartitionBound(bound)
+		if !ok {
+			continue
+		}
+		partitions = append(partitions, partition)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list journal partitions: %w", err)
+	}
+
+	slices.SortStableFunc(partitions, func(a, b *JournalPartition) int {
+		return cmp.Or(cmp.Compare(a.Table, b.Table), a.From.Compare(b.From))
+	})
+	return partitions, nil
+}
+
+// CreatePartitions creates the monthly partitions of every partitioned
+// journal table from the month of from through the month of through,
+// skipping months an existing partition already covers, and returns the
+// partitions it created
+func (r *JournalPartitionRepository) CreatePartitions(ctx context.Context, from, through time.Time) ([]*JournalPartition, error) {
+	existing, err := r.ListPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := r.partitionedTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]*JournalPartition, 0)
+	for _, table := range tables {
+		for month := startOfMonth(from); !month.After(through); month = month.AddDate(0, 1, 0) {
+			partition := &JournalPartition{
+				Table: table,
+				Name:  fmt.Sprintf("%s_%04d_%02d", table, month.Year(), month.Month()),
+				From:  month,
+				To:    month.AddDate(0, 1, 0),
+			}
+			if partitionCovered(existing, partition) {
+				continue
+			}
+
+			query := fmt.Sprintf("CREATE TABLE %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+				pgx.Identifier{partition.Name}.Sanitize(), pgx.Identifier{table}.Sanitize(),
+				partition.From.Format(time.DateOnly), partition.To.Format(time.DateOnly))
+			if _, err := r.db.Pool().Exec(ctx, query); err != nil {
+				return created, fmt.Errorf("failed to create journal partition %s: %w", partition.Name, err)
+			}
+			created = append(created, partition)
+		}
+	}
+
+	return created, nil
+}
+
+// ArchivePartition moves a partition and its indexes to the archive
+// tablespace in one transaction. The partition stays attached, so its rows
+// remain readable and, should an entry be backdated into it, writable.
+func (r *JournalPartitionRepository) ArchivePartition(ctx context.Context, partition *JournalPartition, tablespace string) error {
+	tx, err := r.db.Pool().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SET LOCAL lock_timeout = '"+archiveLockTimeout+"'"); err != nil {
+		return fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+
+	name := pgx.Identifier{partition.Name}.Sanitize()
+	space := pgx.Identifier{tablespace}.Sanitize()
+	if _, err := tx.Exec(ctx, "ALTER TABLE "+name+" SET TABLESPACE "+space); err != nil {
+		return fmt.Errorf("failed to archive journal partition %s: %w", partition.Name, err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT idx.relname
+		FROM pg_index i
+		JOIN pg_class idx ON idx.oid = i.indexrelid
+		WHERE i.indrelid = $1::regclass
+	`, name)
+	if err != nil {
+		return fmt.Errorf("failed to list indexes of journal partition %s: %w", partition.Name, err)
+	}
+	indexes, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to list indexes of journal partition %s: %w", partition.Name, err)
+	}
+
+	for _, index := range indexes {
+		if _, err := tx.Exec(ctx, "ALTER INDEX "+pgx.Identifier{index}.Sanitize()+" SET TABLESPACE "+space); err != nil {
+			return fmt.Errorf("failed to archive index %s: %w", index, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// partitionedTables returns the journal tables that are partitioned, so the
+// partitions are only maintained once the schema has partitioned them
+func (r *JournalPartitionRepository) partitionedTables(ctx context.Context) ([]string, error) {
+	rows, err := r.db.Pool().Query(ctx, `
+		SELECT relname
+		FROM pg_class
+		WHERE relname = ANY($1)
+		  AND relkind = 'p'
+		  AND relnamespace = current_schema()::regnamespace
+		ORDER BY relname
+	`, partitionedJournalTables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitioned journal tables: %w", err)
+	}
+
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitioned journal tables: %w", err)
+	}
+	return tables, nil
+}
+
+// partitionBoundPattern matches the range bound of a partition as printed
+// by pg_get_expr, such as FOR VALUES FROM ('2026-01-01') TO ('2026-02-01')
+var partitionBoundPattern = regexp.MustCompile(`^FOR VALUES FROM \((.+)\) TO \((.+)\)$`)
+
+// parsePartitionBound parses the range bound of a partition; MINVALUE and
+// MAXVALUE yield a zero time. A default partition has no range and is
+// reported as not ok.
+func parsePartitionBound(bound string) (time.Time, time.Time, bool) {
+	m := partitionBoundPattern.FindStringSubmatch(bound)
+	if m == nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	from, ok := parseBoundValue(m[1])
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	to, ok := parseBoundValue(m[2])
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+func parseBoundValue(value string) (time.Time, bool) {
+	if value == "MINVALUE" || value == "MAXVALUE" {
+		return time.Time{}, true
+	}
+	if len(value) < 2 || value[0] != '\'' || value[len(value)-1] != '\'' {
+		return time.Time{}, false
+	}
+
+	value = value[1 : len(value)-1]
+	for _, layout := range []string{time.DateOnly, "2006-01-02 15:04:05-07", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// partitionCovered reports whether an existing partition of the same table
+// overlaps the range of a partition
+func partitionCovered(existing []*JournalPartition, partition *JournalPartition) bool {
+	for _, p := range existing {
+		if p.Table != partition.Table {
+			continue
+		}
+		startsBefore := p.From.IsZero() || p.From.Before(partition.To)
+		endsAfter := p.To.IsZero() || p.To.After(partition.From)
+		if startsBefore && endsAfter {
+			return true
+		}
+	}
+	return false
+}
+
+// startOfMonth returns the first day of the month of t in UTC
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePartitionBound(t *testing.T) {
+	month := func(year int, m time.Month) time.Time { return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC) }
+
+	for _, tc := range []struct {
+		bound    string
+		from, to time.Time
+		ok       bool
+	}{
+		{"FOR VALUES FROM ('2026-01-01') TO ('2026-02-01')", month(2026, 1), month(2026, 2), true},
+		{"FOR VALUES FROM ('2025-12-01 00:00:00+00') TO ('2026-01-01 00:00:00+00')", month(2025, 12), month(2026, 1), true},
+		{"FOR VALUES FROM (MINVALUE) TO ('2024-01-01')", time.Time{}, month(2024, 1), true},
+		{"FOR VALUES FROM ('2030-01-01') TO (MAXVALUE)", month(2030, 1), time.Time{}, true},
+		{"DEFAULT", time.Time{}, time.Time{}, false},
+		{"FOR VALUES IN ('a')", time.Time{}, time.Time{}, false},
+	} {
+		from, to, ok := parsePartitionBound(tc.bound)
+
+		assert.Equal(t, tc.ok, ok, tc.bound)
+		assert.True(t, tc.from.Equal(from), tc.bound)
+		assert.True(t, tc.to.Equal(to), tc.bound)
+	}
+}
+
+func TestPartitionCovered(t *testing.T) {
+	month := func(year int, m time.Month) time.Time { return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC) }
+	existing := []*JournalPartition{
+		{Table: "journal_entries", From: time.Time{}, To: month(2024, 1)},
+		{Table: "journal_entries", From: month(2026, 1), To: month(2026, 4)},
+		{Table: "journal_entry_lines", From: month(2026, 5), To: month(2026, 6)},
+	}
+	partition := func(table string, m time.Month, year int) *JournalPartition {
+		return &JournalPartition{Table: table, From: month(year, m), To: month(year, m).AddDate(0, 1, 0)}
+	}
+
+	assert.True(t, partitionCovered(existing, partition("journal_entries", 12, 2023)), "below an unbounded lower partition")
+	assert.True(t, partitionCovered(existing, partition("journal_entries", 2, 2026)), "inside a quarterly partition")
+	assert.False(t, partitionCovered(existing, partition("journal_entries", 4, 2026)), "starting where a partition ends")
+	assert.False(t, partitionCovered(existing, partition("journal_entries", 5, 2026)), "covered for another table only")
+	assert.True(t, partitionCovered(existing, partition("journal_entry_lines", 5, 2026)), "covered for its own table")
+}
@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// Party types
+const (
+	PartyTypeCustomer = "CUSTOMER"
+	PartyTypeVendor   = "VENDOR"
+	PartyTypeEmployee = "EMPLOYEE"
+)
+
+// Party is a customer, vendor or employee journal lines can be attributed to
+type Party struct {
+	ID        uuid.UUID
+	TenantID  uuid.UUID
+	Code      string
+	Name      string
+	Type      string
+	Email     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
+
+// PartyParams holds parameters for creating or updating a party
+type PartyParams struct {
+	Code  string
+	Name  string
+	Type  string
+	Email string
+}
+
+// PartyFilter holds filters for listing parties
+type PartyFilter struct {
+	Type           *string
+	IncludeDeleted bool
+}
+
+// PartyAccountBalance sums the lines of a party on one account
+type PartyAccountBalance struct {
+	AccountID       uuid.UUID
+	AccountNumber   string
+	AccountName     string
+	AccountTypeCode string
+	CurrencyCode    string
+	Debit           decimal.Decimal
+	Credit          decimal.Decimal
+}
+
+// Balance returns debits less credits
+func (b *PartyAccountBalance) Balance() decimal.Decimal {
+	return b.Debit.Sub(b.Credit)
+}
+
+// PartyStatementLine is a journal line of a party on a statement, with the
+// running balance after it
+type PartyStatementLine struct {
+	JournalEntryID  uuid.UUID
+	LineID          uuid.UUID
+	EntryDate       time.Time
+	ReferenceNumber string
+	Description     string
+	Debit           decimal.Decimal
+	Credit          decimal.Decimal
+	Balance         decimal.Decimal
+}
+
+// PartyStatement lists the lines of a party on one account over a period.
+// Balances are debits less credits.
+type PartyStatement struct {
+	OpeningBalance decimal.Decimal
+	Lines          []*PartyStatementLine
+	ClosingBalance decimal.Decimal
+}
+
+const partyColumns = `id, tenant_id, code, name, type, email, created_at, updated_at, deleted_at`
+
+func scanParty(row pgx.Row, party *Party) error {
+	return row.Scan(
+		&party.ID,
+		&party.TenantID,
+		&party.Code,
+		&party.Name,
+		&party.Type,
+		&party.Email,
+		&party.CreatedAt,
+		&party.UpdatedAt,
+		&party.DeletedAt,
+	)
+}
+
+// PartyRepository handles party database operations
+type PartyRepository struct {
+	db *db.DB
+}
+
+// NewPartyRepository creates a new party repository
+func NewPartyRepository(database *db.DB) *PartyRepository {
+	return &PartyRepository{db: database}
+}
+
+// Create creates a party
+func (r *PartyRepository) Create(ctx context.Context, tenantID uuid.UUID, params PartyParams) (*Party, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	party := &Party{}
+	query := `
+		INSERT INTO parties (tenant_id, code, name, type, email)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + partyColumns
+
+	row := tx.QueryRow(ctx, query, tenantID, params.Code, params.Name, params.Type, params.Email)
+	if err := scanParty(row, party); err != nil {
+		return nil, fmt.Errorf("failed to create party: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return party, nil
+}
+
+// Update replaces the details of a party that has not been deleted
+func (r *PartyRepository) Update(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID, params PartyParams) (*Party, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	party := &Party{}
+	query := `
+		UPDATE parties
+		SET code = $2, name = $3, type = $4, email = $5, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + partyColumns
+
+	row := tx.QueryRow(ctx, query, partyID, params.Code, params.Name, params.Type, params.Email)
+	if err := scanParty(row, party); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("party %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update party: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return party, nil
+}
+
+// GetByID retrieves a party, including a deleted one
+func (r *PartyRepository) GetByID(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID) (*Party, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	party := &Party{}
+	query := `SELECT ` + partyColumns + ` FROM parties WHERE id = $1`
+
+	if err := scanParty(conn.QueryRow(ctx, query, partyID), party); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("party %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get party: %w", err)
+	}
+
+	return party, nil
+}
+
+// GetByIDs retrieves parties by ID, including deleted ones. Unknown parties
+// are left out of the map.
+func (r *PartyRepository) GetByIDs(ctx context.Context, tenantID uuid.UUID, partyIDs []uuid.UUID) (map[uuid.UUID]*Party, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `SELECT ` + partyColumns + ` FROM parties WHERE id = ANY($1)`
+
+	rows, err := conn.Query(ctx, query, partyIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parties: %w", err)
+	}
+	defer rows.Close()
+
+	parties := make(map[uuid.UUID]*Party, len(partyIDs))
+	for rows.Next() {
+		party := &Party{}
+		if err := scanParty(rows, party); err != nil {
+			return nil, fmt.Errorf("failed to scan party: %w", err)
+		}
+		parties[party.ID] = party
+	}
+
+	return parties, nil
+}
+
+// List retrieves parties ordered by code
+func (r *PartyRepository) List(ctx context.Context, tenantID uuid.UUID, filter PartyFilter, limit, offset int) ([]*Party, int, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	argCount := 0
+
+	if !filter.IncludeDeleted {
+		where += " AND deleted_at IS NULL"
+	}
+
+	if filter.Type != nil {
+		argCount++
+		where += fmt.Sprintf(" AND type = $%d", argCount)
+		args = append(args, *filter.Type)
+	}
+
+	var totalCount int
+	if err := conn.QueryRow(ctx, "SELECT COUNT(*) FROM parties"+where, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count parties: %w", err)
+	}
+
+	query := `SELECT ` + partyColumns + ` FROM parties` + where +
+		fmt.Sprintf(" ORDER BY code LIMIT $%d OFFSET $%d", argCount+1, argCount+2)
+	args = append(args, limit, offset)
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list parties: %w", err)
+	}
+	defer rows.Close()
+
+	parties := make([]*Party, 0)
+	for rows.Next() {
+		party := &Party{}
+		if err := scanParty(rows, party); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan party: %w", err)
+		}
+		parties = append(parties, party)
+	}
+
+	return parties, totalCount, nil
+}
+
+// Delete soft-deletes a party. Lines already posted keep referring to it, but
+// no new lines can.
+func (r *PartyRepository) Delete(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID) (*Party, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	party := &Party{}
+	query := `
+		UPDATE parties
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + partyColumns
+
+	if err := scanParty(tx.QueryRow(ctx, query, partyID), party); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("party %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to delete party: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return party, nil
+}
+
+// GetBalances sums the lines of a party per account, for entries dated on or
+// before asOf when it is set. Accounts without lines are left out.
+func (r *PartyRepository) GetBalances(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID, asOf *time.Time) ([]*PartyAccountBalance, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT a.id, a.account_number, a.name, at.code, a.currency_code,
+		       COALESCE(SUM(jel.debit), 0), COALESCE(SUM(jel.credit), 0)
+		FROM journal_entry_lines jel
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		INNER JOIN accounts a ON a.id = jel.account_id
+		INNER JOIN account_types at ON at.id = a.account_type_id
+		WHERE jel.party_id = $1
+		  AND ($2::date IS NULL OR je.entry_date <= $2)
+		GROUP BY a.id, a.account_number, a.name, at.code, a.currency_code
+		ORDER BY a.account_number
+	`
+
+	rows, err := conn.Query(ctx, query, partyID, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get party balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make([]*PartyAccountBalance, 0)
+	for rows.Next() {
+		balance := &PartyAccountBalance{}
+		err := rows.Scan(
+			&balance.AccountID,
+			&balance.AccountNumber,
+			&balance.AccountName,
+			&balance.AccountTypeCode,
+			&balance.CurrencyCode,
+			&balance.Debit,
+			&balance.Credit,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan party balance: %w", err)
+		}
+		balances = append(balances, balance)
+	}
+
+	return balances, nil
+}
+
+// GetStatement lists the lines of a party on an account for entries dated
+// between fromDate and toDate, inclusive, oldest first. The opening balance
+// covers every earlier line.
+func (r *PartyRepository) GetStatement(ctx context.Context, tenantID uuid.UUID, partyID, accountID uuid.UUID, fromDate, toDate time.Time) (*PartyStatement, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	statement := &PartyStatement{Lines: make([]*PartyStatementLine, 0)}
+
+	openingQuery := `
+		SELECT COALESCE(SUM(jel.debit - jel.credit), 0)
+		FROM journal_entry_lines jel
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		WHERE jel.party_id = $1 AND jel.account_id = $2 AND je.entry_date < $3
+	`
+
+	if err := conn.QueryRow(ctx, openingQuery, partyID, accountID, fromDate).Scan(&statement.OpeningBalance); err != nil {
+		return nil, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+
+	query := `
+		SELECT je.id, jel.id, je.entry_date, je.reference_number,
+		       COALESCE(NULLIF(jel.description, ''), je.description), jel.debit, jel.credit
+		FROM journal_entry_lines jel
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		WHERE jel.party_id = $1 AND jel.account_id = $2
+		  AND je.entry_date >= $3 AND je.entry_date <= $4
+		ORDER BY je.entry_date, je.created_at, jel.created_at
+	`
+
+	rows, err := conn.Query(ctx, query, partyID, accountID, fromDate, toDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get party statement: %w", err)
+	}
+	defer rows.Close()
+
+	balance := statement.OpeningBalance
+	for rows.Next() {
+		line := &PartyStatementLine{}
+		err := rows.Scan(
+			&line.JournalEntryID,
+			&line.LineID,
+			&line.EntryDate,
+			&line.ReferenceNumber,
+			&line.Description,
+			&line.Debit,
+			&line.Credit,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan party statement line: %w", err)
+		}
+		balance = balance.Add(line.Debit).Sub(line.Credit)
+		line.Balance = balance
+		statement.Lines = append(statement.Lines, line)
+	}
+	statement.ClosingBalance = balance
+
+	return statement, nil
+}
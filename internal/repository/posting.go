@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// journalLineColumns are the columns of journal_entry_lines loaded by COPY
+var journalLineColumns = []string{
+	"journal_entry_id", "account_id", "debit", "credit", "description", "counterparty_tenant_id",
+	"fx_rate", "tax_code_id", "is_tax", "party_id", "dimensions",
+}
+
+// validateJournalLines checks the rules create_journal_entry enforced: at
+// least two lines, each either a debit or a credit, with debits equal to
+// credits. Amounts are in the currency of their account, so with currencies
+// mapping accounts to their currency the debits must equal the credits in
+// each currency; lines of accounts missing from it are totalled together.
+func validateJournalLines(lines []*CreateJournalEntryLineParams, currencies map[uuid.UUID]string) error {
+	if len(lines) < 2 {
+		return fmt.Errorf("%w: at least two lines are required", ErrInvalidEntryLines)
+	}
+
+	type total struct{ debit, credit decimal.Decimal }
+	totals := make(map[string]total)
+	for i, line := range lines {
+		if line.Debit.IsNegative() || line.Credit.IsNegative() || line.Debit.IsPositive() == line.Credit.IsPositive() {
+			return fmt.Errorf("%w: line %d must have either a positive debit or a positive credit", ErrInvalidEntryLines, i)
+		}
+		currency := currencies[line.AccountID]
+		t := totals[currency]
+		totals[currency] = total{debit: t.debit.Add(line.Debit), credit: t.credit.Add(line.Credit)}
+	}
+
+	for _, currency := range slices.Sorted(maps.Keys(totals)) {
+		t := totals[currency]
+		if t.debit.Equal(t.credit) {
+			continue
+		}
+		if len(totals) == 1 {
+			return fmt.Errorf("%w: debits %s, credits %s", ErrUnbalancedEntry, t.debit, t.credit)
+		}
+		return fmt.Errorf("%w in %s: debits %s, credits %s", ErrUnbalancedEntry, currency, t.debit, t.credit)
+	}
+
+	return nil
+}
+
+// postJournalEntry posts an entry, whose lines insertJournalEntry has
+// validated, inside an open transaction: it checks the accounts are visible
+// to the tenant, inserts the entry, loads the lines with COPY in one round
+// trip and adds them to the account balances with a single upsert
+func postJournalEntry(ctx context.Context, tx *db.TenantTx, params CreateJournalEntryParams, metadata []byte) (uuid.UUID, error) {
+	seen := make(map[uuid.UUID]struct{}, len(params.Lines))
+	accountIDs := make([]uuid.UUID, 0, len(params.Lines))
+	for _, line := range params.Lines {
+		if _, ok := seen[line.AccountID]; !ok {
+			seen[line.AccountID] = struct{}{}
+			accountIDs = append(accountIDs, line.AccountID)
+		}
+	}
+
+	// Accounts of other tenants are invisible under RLS but would still
+	// satisfy the foreign key
+	var visible int
+	err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM accounts WHERE id = ANY($1)", accountIDs).Scan(&visible)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to check accounts: %w", err)
+	}
+	if visible != len(accountIDs) {
+		return uuid.Nil, fmt.Errorf("account %w", ErrNotFound)
+	}
+
+	var journalEntryID uuid.UUID
+	err = tx.QueryRow(ctx, `
+		INSERT INTO journal_entries (tenant_id, reference_number, description, entry_date, metadata)
+		VALUES (current_setting('app.current_tenant_id')::uuid, $1, $2, $3, $4)
+		RETURNING id
+	`, params.ReferenceNumber, params.Description, params.EntryDate, metadata).Scan(&journalEntryID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	rows := make([][]interface{}, len(params.Lines))
+	for i, line := range params.Lines {
+		dimensions := line.Dimensions
+		if dimensions == nil {
+			dimensions = map[string]string{}
+		}
+		rows[i] = []interface{}{
+			journalEntryID, line.AccountID, line.Debit, line.Credit, line.Description, line.CounterpartyTenantID,
+			line.FxRate, line.TaxCodeID, line.IsTax, line.PartyID, dimensions,
+		}
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"journal_entry_lines"}, journalLineColumns, pgx.CopyFromRows(rows)); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create journal entry lines: %w", err)
+	}
+
+	err = tx.Exec(ctx, `
+		INSERT INTO account_balances (account_id, debit_balance, credit_balance, updated_at)
+		SELECT account_id, SUM(debit), SUM(credit), NOW()
+		FROM journal_entry_lines
+		WHERE journal_entry_id = $1
+		GROUP BY account_id
+		ON CONFLICT (account_id) DO UPDATE
+		SET debit_balance = account_balances.debit_balance + EXCLUDED.debit_balance,
+		    credit_balance = account_balances.credit_balance + EXCLUDED.credit_balance,
+		    updated_at = NOW()
+	`, journalEntryID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to update account balances: %w", err)
+	}
+
+	return journalEntryID, nil
+}
+
+// callCreateJournalEntry posts an entry through the legacy
+// create_journal_entry database function, which validates it, inserts its
+// lines from a JSONB array and updates the account balances
+func callCreateJournalEntry(ctx context.Context, tx *db.TenantTx, params CreateJournalEntryParams, metadata []byte) (uuid.UUID, error) {
+	// Convert lines to JSONB format expected by the database function
+	linesJSON := make([]map[string]interface{}, len(params.Lines))
+	for i, line := range params.Lines {
+		linesJSON[i] = map[string]interface{}{
+			"account_id":  line.AccountID.String(),
+			"debit":       line.Debit.String(),
+			"credit":      line.Credit.String(),
+			"description": line.Description,
+		}
+		if line.CounterpartyTenantID != nil {
+			linesJSON[i]["counterparty_tenant_id"] = line.CounterpartyTenantID.String()
+		}
+		if line.FxRate != nil {
+			linesJSON[i]["fx_rate"] = line.FxRate.String()
+		}
+		if line.TaxCodeID != nil {
+			linesJSON[i]["tax_code_id"] = line.TaxCodeID.String()
+			linesJSON[i]["is_tax"] = line.IsTax
+		}
+		if line.PartyID != nil {
+			linesJSON[i]["party_id"] = line.PartyID.String()
+		}
+		if len(line.Dimensions) > 0 {
+			linesJSON[i]["dimensions"] = line.Dimensions
+		}
+	}
+
+	linesBytes, err := json.Marshal(linesJSON)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to marshal lines: %w", err)
+	}
+
+	var journalEntryID uuid.UUID
+	query := "SELECT create_journal_entry($1, $2, $3, $4, $5)"
+
+	err = tx.QueryRow(ctx, query,
+		params.ReferenceNumber,
+		params.Description,
+		params.EntryDate,
+		string(linesBytes),
+		string(metadata),
+	).Scan(&journalEntryID)
+
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	return journalEntryID, nil
+}
+
+// accountBook resolves the book of a new account inside an open
+// transaction: the book of its parent, which must be a live account of the
+// tenant, else the requested book, else the default book of the tenant
+func accountBook(ctx context.Context, tx *db.TenantTx, params CreateAccountParams) (uuid.UUID, error) {
+	var bookID uuid.UUID
+
+	if params.ParentAccountID != nil {
+		err := tx.QueryRow(ctx,
+			"SELECT book_id FROM accounts WHERE id = $1 AND deleted_at IS NULL",
+			*params.ParentAccountID,
+		).Scan(&bookID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return uuid.Nil, fmt.Errorf("parent account %w", ErrNotFound)
+			}
+			return uuid.Nil, fmt.Errorf("failed to check parent account: %w", err)
+		}
+		if params.BookID != nil && *params.BookID != bookID {
+			return uuid.Nil, ErrBookMismatch
+		}
+		return bookID, nil
+	}
+
+	query := "SELECT id FROM books WHERE is_default"
+	var args []interface{}
+	if params.BookID != nil {
+		query = "SELECT id FROM books WHERE id = $1"
+		args = append(args, *params.BookID)
+	}
+
+	if err := tx.QueryRow(ctx, query, args...).Scan(&bookID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, fmt.Errorf("book %w", ErrNotFound)
+		}
+		return uuid.Nil, fmt.Errorf("failed to check book: %w", err)
+	}
+
+	return bookID, nil
+}
+
+// insertAccount creates an account inside an open transaction with a zero
+// balance, in the book resolved by accountBook
+func insertAccount(ctx context.Context, tx *db.TenantTx, params CreateAccountParams, bookID uuid.UUID) (uuid.UUID, error) {
+	var accountID uuid.UUID
+	err := tx.QueryRow(ctx, `
+		INSERT INTO accounts (tenant_id, book_id, account_number, name, account_type_id, currency_code, description, parent_account_id)
+		VALUES (current_setting('app.current_tenant_id')::uuid, $1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`,
+		bookID,
+		params.AccountNumber,
+		params.Name,
+		params.AccountTypeID,
+		params.CurrencyCode,
+		params.Description,
+		params.ParentAccountID,
+	).Scan(&accountID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create account: %w", err)
+	}
+
+	err = tx.Exec(ctx, `
+		INSERT INTO account_balances (account_id, debit_balance, credit_balance, updated_at)
+		VALUES ($1, 0, 0, NOW())
+	`, accountID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to initialize account balance: %w", err)
+	}
+
+	return accountID, nil
+}
+
+// callCreateAccount creates an account through the legacy create_account
+// database function, which files it in the default book, and then moves it
+// to the book resolved by accountBook
+func callCreateAccount(ctx context.Context, tx *db.TenantTx, params CreateAccountParams, bookID uuid.UUID) (uuid.UUID, error) {
+	var accountID uuid.UUID
+	query := "SELECT create_account($1, $2, $3, $4, $5, $6)"
+
+	err := tx.QueryRow(ctx, query,
+		params.AccountNumber,
+		params.Name,
+		params.AccountTypeID,
+		params.CurrencyCode,
+		params.Description,
+		params.ParentAccountID,
+	).Scan(&accountID)
+
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create account: %w", err)
+	}
+
+	err = tx.Exec(ctx, "UPDATE accounts SET book_id = $2 WHERE id = $1 AND book_id <> $2", accountID, bookID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to set account book: %w", err)
+	}
+
+	return accountID, nil
+}
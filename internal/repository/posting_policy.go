@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// PostingPolicy restricts the entry dates a tenant may post journal entries with
+type PostingPolicy struct {
+	TenantID         uuid.UUID
+	AllowFutureDates bool
+	MaxBackdateDays  *int32
+	LockDate         *time.Time
+	UpdatedAt        time.Time
+}
+
+// PostingPolicyRepository handles posting policy database operations
+type PostingPolicyRepository struct {
+	db *db.DB
+}
+
+// NewPostingPolicyRepository creates a new posting policy repository
+func NewPostingPolicyRepository(database *db.DB) *PostingPolicyRepository {
+	return &PostingPolicyRepository{db: database}
+}
+
+// Get retrieves the posting policy of a tenant. Tenants without a stored
+// policy may post with any date.
+func (r *PostingPolicyRepository) Get(ctx context.Context, tenantID uuid.UUID) (*PostingPolicy, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	policy := &PostingPolicy{TenantID: tenantID}
+	query := `
+		SELECT allow_future_dates, max_backdate_days, lock_date, updated_at
+		FROM posting_policies
+		WHERE tenant_id = $1
+	`
+
+	err = conn.QueryRow(ctx, query, tenantID).Scan(
+		&policy.AllowFutureDates,
+		&policy.MaxBackdateDays,
+		&policy.LockDate,
+		&policy.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			policy.AllowFutureDates = true
+			return policy, nil
+		}
+		return nil, fmt.Errorf("failed to get posting policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// Upsert stores the posting policy of a tenant and completes the lock tasks
+// of the close checklists whose period its lock date covers
+func (r *PostingPolicyRepository) Upsert(ctx context.Context, policy *PostingPolicy) (*PostingPolicy, error) {
+	tx, err := r.db.BeginTx(ctx, policy.TenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	stored := &PostingPolicy{TenantID: policy.TenantID}
+	query := `
+		INSERT INTO posting_policies (tenant_id, allow_future_dates, max_backdate_days, lock_date)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id) DO UPDATE
+		SET allow_future_dates = EXCLUDED.allow_future_dates,
+		    max_backdate_days = EXCLUDED.max_backdate_days,
+		    lock_date = EXCLUDED.lock_date,
+		    updated_at = NOW()
+		RETURNING allow_future_dates, max_backdate_days, lock_date, updated_at
+	`
+
+	err = tx.QueryRow(ctx, query,
+		policy.TenantID,
+		policy.AllowFutureDates,
+		policy.MaxBackdateDays,
+		policy.LockDate,
+	).Scan(
+		&stored.AllowFutureDates,
+		&stored.MaxBackdateDays,
+		&stored.LockDate,
+		&stored.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert posting policy: %w", err)
+	}
+
+	if err := completeCloseTasks(ctx, tx, CloseActionLockPeriod); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return stored, nil
+}
+
+// checkPostingRules holds an entry to the tenant's posting policy and entry
+// quotas. It runs under the tenant's journal lock in every posting, so
+// entries posted by the system, the subledgers and the runners are held to
+// the same rules as those posted through the API, and concurrent postings
+// count each other. The services check the policy first to report the
+// violation in detail and to decide whether a lock override is allowed.
+func checkPostingRules(ctx context.Context, tx *db.TenantTx, params CreateJournalEntryParams) error {
+	var timezone string
+	var policy PostingPolicy
+	var maxEntries, maxLines *int32
+	err := tx.QueryRow(ctx, `
+		SELECT COALESCE(s.timezone, ''), COALESCE(p.allow_future_dates, true), p.max_backdate_days, p.lock_date,
+		       t.max_entries_per_day, t.max_lines_per_entry
+		FROM tenants t
+		LEFT JOIN tenant_settings s ON s.tenant_id = t.id
+		LEFT JOIN posting_policies p ON p.tenant_id = t.id
+		WHERE t.id = current_setting('app.current_tenant_id')::uuid
+	`).Scan(&timezone, &policy.AllowFutureDates, &policy.MaxBackdateDays, &policy.LockDate, &maxEntries, &maxLines)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to get posting rules: %w", err)
+	}
+
+	if timezone == "" {
+		timezone = DefaultTimezone
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("invalid tenant timezone %q: %w", timezone, err)
+	}
+	now := time.Now().In(location)
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	entryDay := params.EntryDate.UTC().Truncate(24 * time.Hour)
+
+	if policy.LockDate != nil && !entryDay.After(policy.LockDate.UTC().Truncate(24*time.Hour)) && params.LockOverrideReason == nil {
+		return fmt.Errorf("entry date %s: %w", entryDay.Format("2006-01-02"), ErrPeriodLocked)
+	}
+	if !policy.AllowFutureDates && entryDay.After(today) {
+		return fmt.Errorf("entry date %s: %w", entryDay.Format("2006-01-02"), ErrFutureDate)
+	}
+	if policy.MaxBackdateDays != nil && entryDay.Before(today.AddDate(0, 0, -int(*policy.MaxBackdateDays))) {
+		return fmt.Errorf("entry date %s: %w", entryDay.Format("2006-01-02"), ErrBackdateLimit)
+	}
+
+	if maxLines != nil && len(params.Lines) > int(*maxLines) {
+		return fmt.Errorf("line %w: limit is %d lines per entry", ErrQuotaExceeded, *maxLines)
+	}
+	if maxEntries != nil {
+		return checkEntryQuota(ctx, tx, *maxEntries, time.Date(year, month, day, 0, 0, 0, 0, location))
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateJournalLines(t *testing.T) {
+	line := func(debit, credit int64) *CreateJournalEntryLineParams {
+		return &CreateJournalEntryLineParams{AccountID: uuid.New(), Debit: decimal.NewFromInt(debit), Credit: decimal.NewFromInt(credit)}
+	}
+
+	tests := []struct {
+		name  string
+		lines []*CreateJournalEntryLineParams
+		err   error
+	}{
+		{"balanced", []*CreateJournalEntryLineParams{line(100, 0), line(0, 60), line(0, 40)}, nil},
+		{"single line", []*CreateJournalEntryLineParams{line(100, 0)}, ErrInvalidEntryLines},
+		{"debit and credit on one line", []*CreateJournalEntryLineParams{line(100, 100), line(0, 0)}, ErrInvalidEntryLines},
+		{"zero line", []*CreateJournalEntryLineParams{line(100, 0), line(0, 100), line(0, 0)}, ErrInvalidEntryLines},
+		{"negative amount", []*CreateJournalEntryLineParams{line(-100, 0), line(0, -100)}, ErrInvalidEntryLines},
+		{"unbalanced", []*CreateJournalEntryLineParams{line(100, 0), line(0, 90)}, ErrUnbalancedEntry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateJournalLines(tt.lines, nil)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+
+	t.Run("balances each currency", func(t *testing.T) {
+		usdCash, usdSales, eurCash, eurSales := line(100, 0), line(0, 100), line(92, 0), line(0, 92)
+		currencies := map[uuid.UUID]string{
+			usdCash.AccountID: "USD", usdSales.AccountID: "USD",
+			eurCash.AccountID: "EUR", eurSales.AccountID: "EUR",
+		}
+
+		assert.NoError(t, validateJournalLines([]*CreateJournalEntryLineParams{usdCash, usdSales, eurCash, eurSales}, currencies))
+	})
+
+	t.Run("rejects an entry balanced only across currencies", func(t *testing.T) {
+		usdCash, eurSales := line(100, 0), line(0, 100)
+		currencies := map[uuid.UUID]string{usdCash.AccountID: "USD", eurSales.AccountID: "EUR"}
+
+		err := validateJournalLines([]*CreateJournalEntryLineParams{usdCash, eurSales}, currencies)
+		assert.ErrorIs(t, err, ErrUnbalancedEntry)
+		assert.Contains(t, err.Error(), "in EUR")
+	})
+}
@@ -0,0 +1,354 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// Prepared entry statuses. Prepared entries are stored as PENDING until
+// confirmed or cancelled; a pending entry past its expiry is reported as
+// EXPIRED.
+const (
+	PreparedEntryStatusPending   = "PENDING"
+	PreparedEntryStatusConfirmed = "CONFIRMED"
+	PreparedEntryStatusCancelled = "CANCELLED"
+	PreparedEntryStatusExpired   = "EXPIRED"
+)
+
+// PreparedJournalEntry is a validated journal entry waiting to be confirmed
+// into the journal or cancelled. While pending, it reserves the amounts its
+// lines take from each account, so confirming it cannot fail for lack of
+// funds.
+type PreparedJournalEntry struct {
+	ID              uuid.UUID
+	TenantID        uuid.UUID
+	ReferenceNumber string
+	Description     string
+	TransactionID   string
+	Status          string
+	ExpiresAt       time.Time
+	JournalEntryID  *uuid.UUID
+	Entry           CreateJournalEntryParams
+	Reservations    []*Reservation
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Reservation is the amount a prepared entry takes from an account on its
+// normal side, counted as held while the entry is pending
+type Reservation struct {
+	AccountID uuid.UUID
+	Amount    decimal.Decimal
+}
+
+// preparedEntryStatusExpr reports pending prepared entries past their expiry
+// as expired
+const preparedEntryStatusExpr = `CASE WHEN status = 'PENDING' AND expires_at <= NOW() THEN 'EXPIRED' ELSE status END`
+
+const preparedEntryColumns = `id, tenant_id, reference_number, description, transaction_id,
+		       ` + preparedEntryStatusExpr + `, expires_at, journal_entry_id, entry,
+		       created_at, updated_at`
+
+func scanPreparedEntry(row pgx.Row, prepared *PreparedJournalEntry) error {
+	var entry []byte
+	err := row.Scan(
+		&prepared.ID,
+		&prepared.TenantID,
+		&prepared.ReferenceNumber,
+		&prepared.Description,
+		&prepared.TransactionID,
+		&prepared.Status,
+		&prepared.ExpiresAt,
+		&prepared.JournalEntryID,
+		&entry,
+		&prepared.CreatedAt,
+		&prepared.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(entry, &prepared.Entry)
+}
+
+// PreparedEntryRepository handles prepared journal entry database operations
+type PreparedEntryRepository struct {
+	db *db.DB
+}
+
+// NewPreparedEntryRepository creates a new prepared entry repository
+func NewPreparedEntryRepository(database *db.DB) *PreparedEntryRepository {
+	return &PreparedEntryRepository{db: database}
+}
+
+// Prepare validates a journal entry by posting it and rolling the posting
+// back, then stores it as pending until expiresAt along with a reservation
+// of the amount it takes from each account
+func (r *PreparedEntryRepository) Prepare(ctx context.Context, tenantID uuid.UUID, entry CreateJournalEntryParams, expiresAt time.Time) (*PreparedJournalEntry, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Reservations reduce the available balance that postings are checked
+	// against
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	// The trial posting runs every check a confirmation will, including the
+	// available balance of the accounts it reduces
+	if err := tx.Exec(ctx, "SAVEPOINT prepare_entry"); err != nil {
+		return nil, fmt.Errorf("failed to prepare journal entry: %w", err)
+	}
+	if _, err := insertJournalEntry(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+	if err := tx.Exec(ctx, "ROLLBACK TO SAVEPOINT prepare_entry"); err != nil {
+		return nil, fmt.Errorf("failed to prepare journal entry: %w", err)
+	}
+
+	// The stored entry keeps the metadata as the posted entry will
+	entry.Metadata, err = sealMetadata(ctx, tx, entry.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+
+	prepared := &PreparedJournalEntry{}
+	query := `
+		INSERT INTO prepared_journal_entries (
+			tenant_id, reference_number, description, transaction_id, status, expires_at, entry
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + preparedEntryColumns
+
+	row := tx.QueryRow(ctx, query,
+		tenantID,
+		entry.ReferenceNumber,
+		entry.Description,
+		entry.TransactionID,
+		PreparedEntryStatusPending,
+		expiresAt,
+		entryBytes,
+	)
+	if err := scanPreparedEntry(row, prepared); err != nil {
+		return nil, fmt.Errorf("failed to prepare journal entry: %w", err)
+	}
+
+	accountIDs, nets := lineNets(entry.Lines)
+	err = tx.Exec(ctx, `
+		INSERT INTO prepared_entry_reservations (tenant_id, prepared_entry_id, account_id, amount)
+		SELECT $1, $2, l.account_id, CASE WHEN t.normal_balance = 'CREDIT' THEN l.net ELSE -l.net END
+		FROM unnest($3::uuid[], $4::numeric[]) AS l(account_id, net)
+		JOIN accounts a ON a.id = l.account_id
+		JOIN account_types t ON t.id = a.account_type_id
+		WHERE CASE WHEN t.normal_balance = 'CREDIT' THEN l.net > 0 ELSE l.net < 0 END
+	`, tenantID, prepared.ID, accountIDs, nets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve amounts: %w", err)
+	}
+
+	if prepared.Reservations, err = listReservations(ctx, tx, prepared.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return prepared, nil
+}
+
+// GetByID retrieves a prepared entry with its reservations
+func (r *PreparedEntryRepository) GetByID(ctx context.Context, tenantID uuid.UUID, preparedID uuid.UUID) (*PreparedJournalEntry, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	prepared := &PreparedJournalEntry{}
+	query := `SELECT ` + preparedEntryColumns + ` FROM prepared_journal_entries WHERE id = $1`
+
+	if err := scanPreparedEntry(tx.QueryRow(ctx, query, preparedID), prepared); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("prepared journal entry %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get prepared journal entry: %w", err)
+	}
+
+	if prepared.Reservations, err = listReservations(ctx, tx, preparedID); err != nil {
+		return nil, err
+	}
+
+	return prepared, nil
+}
+
+// Confirm posts the journal entry of a pending prepared entry and marks it
+// confirmed in a single transaction. Confirming an entry that was already
+// confirmed returns it unchanged, so a retried confirmation does not fail.
+func (r *PreparedEntryRepository) Confirm(ctx context.Context, tenantID uuid.UUID, preparedID uuid.UUID) (*PreparedJournalEntry, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Take the tenant journal lock before the prepared entry row, in the
+	// order merges take them, so the two cannot deadlock
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	prepared, err := lockPreparedEntry(ctx, tx, preparedID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch prepared.Status {
+	case PreparedEntryStatusConfirmed:
+		return prepared, nil
+	case PreparedEntryStatusExpired:
+		return nil, ErrPreparedEntryExpired
+	case PreparedEntryStatusCancelled:
+		return nil, ErrPreparedEntryNotPending
+	}
+
+	// The reservations stop counting against the available balance before
+	// the entry is checked against it
+	err = tx.Exec(ctx, `
+		UPDATE prepared_journal_entries
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1
+	`, preparedID, PreparedEntryStatusConfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm prepared journal entry: %w", err)
+	}
+
+	journalEntryID, err := insertJournalEntry(ctx, tx, prepared.Entry)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE prepared_journal_entries
+		SET journal_entry_id = $2
+		WHERE id = $1
+		RETURNING ` + preparedEntryColumns
+
+	if err := scanPreparedEntry(tx.QueryRow(ctx, query, preparedID, journalEntryID), prepared); err != nil {
+		return nil, fmt.Errorf("failed to confirm prepared journal entry: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return prepared, nil
+}
+
+// Cancel frees the reservations of a prepared entry without posting.
+// Cancelling an entry that was already cancelled or has expired marks it
+// cancelled as well, so a compensating saga step always succeeds unless the
+// entry was confirmed.
+func (r *PreparedEntryRepository) Cancel(ctx context.Context, tenantID uuid.UUID, preparedID uuid.UUID) (*PreparedJournalEntry, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Take the tenant journal lock before the prepared entry row, in the
+	// order merges take them, so the two cannot deadlock
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	prepared, err := lockPreparedEntry(ctx, tx, preparedID)
+	if err != nil {
+		return nil, err
+	}
+
+	if prepared.Status == PreparedEntryStatusConfirmed {
+		return nil, ErrPreparedEntryNotPending
+	}
+
+	query := `
+		UPDATE prepared_journal_entries
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + preparedEntryColumns
+
+	if err := scanPreparedEntry(tx.QueryRow(ctx, query, preparedID, PreparedEntryStatusCancelled), prepared); err != nil {
+		return nil, fmt.Errorf("failed to cancel prepared journal entry: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return prepared, nil
+}
+
+// lockPreparedEntry locks a prepared entry for update and loads its
+// reservations. The caller must already hold the tenant journal lock.
+func lockPreparedEntry(ctx context.Context, tx *db.TenantTx, preparedID uuid.UUID) (*PreparedJournalEntry, error) {
+	prepared := &PreparedJournalEntry{}
+	query := `SELECT ` + preparedEntryColumns + ` FROM prepared_journal_entries WHERE id = $1 FOR UPDATE`
+
+	if err := scanPreparedEntry(tx.QueryRow(ctx, query, preparedID), prepared); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("prepared journal entry %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to lock prepared journal entry: %w", err)
+	}
+
+	reservations, err := listReservations(ctx, tx, preparedID)
+	if err != nil {
+		return nil, err
+	}
+	prepared.Reservations = reservations
+
+	return prepared, nil
+}
+
+// listReservations retrieves the reservations of a prepared entry
+func listReservations(ctx context.Context, tx *db.TenantTx, preparedID uuid.UUID) ([]*Reservation, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT account_id, amount
+		FROM prepared_entry_reservations
+		WHERE prepared_entry_id = $1
+		ORDER BY account_id
+	`, preparedID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reservations: %w", err)
+	}
+	defer rows.Close()
+
+	reservations := make([]*Reservation, 0)
+	for rows.Next() {
+		reservation := &Reservation{}
+		if err := rows.Scan(&reservation.AccountID, &reservation.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		reservations = append(reservations, reservation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list reservations: %w", err)
+	}
+
+	return reservations, nil
+}
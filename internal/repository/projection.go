@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+)
+
+// account_period_totals is a read model of the journal: the debits, credits
+// and line counts of each account per calendar month of entry date. By
+// default it is kept in the posting transaction, so reports can sum a few
+// rows per account and month instead of every journal line. With scheduled
+// refreshes, posting only queues the entry in period_totals_pending and
+// RefreshPeriodTotals adds the queued entries later; reports then lag by the
+// entries still queued, which ReportFreshness makes visible.
+
+// addPeriodTotalsSQL adds the lines of the entries whose IDs are selected
+// by entryIDs to the period totals of their accounts
+func addPeriodTotalsSQL(entryIDs string) string {
+	return `
+		INSERT INTO account_period_totals (tenant_id, account_id, period_start, debit, credit, line_count, updated_at)
+		SELECT je.tenant_id, jel.account_id, date_trunc('month', je.entry_date)::date,
+		       SUM(jel.debit), SUM(jel.credit), COUNT(*), NOW()
+		FROM journal_entry_lines jel
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		WHERE je.id IN (` + entryIDs + `)
+		GROUP BY je.tenant_id, jel.account_id, date_trunc('month', je.entry_date)
+		ON CONFLICT (account_id, period_start) DO UPDATE
+		SET debit = account_period_totals.debit + EXCLUDED.debit,
+		    credit = account_period_totals.credit + EXCLUDED.credit,
+		    line_count = account_period_totals.line_count + EXCLUDED.line_count,
+		    updated_at = NOW()
+	`
+}
+
+// projectJournalEntry adds the lines of a posted entry to the period totals
+// of their accounts, or queues the entry when the totals are refreshed on a
+// schedule
+func projectJournalEntry(ctx context.Context, tx *db.TenantTx, journalEntryID uuid.UUID) error {
+	if tx.DeferPeriodTotals() {
+		err := tx.Exec(ctx, `
+			INSERT INTO period_totals_pending (tenant_id, journal_entry_id, queued_at)
+			SELECT tenant_id, id, NOW() FROM journal_entries WHERE id = $1
+		`, journalEntryID)
+		if err != nil {
+			return fmt.Errorf("failed to queue period totals: %w", err)
+		}
+		return nil
+	}
+
+	if err := tx.Exec(ctx, addPeriodTotalsSQL("$1"), journalEntryID); err != nil {
+		return fmt.Errorf("failed to update period totals: %w", err)
+	}
+	return nil
+}
+
+// refreshPeriodTotals adds the queued entries of the tenant to the period
+// totals and returns how many there were. The caller must hold the tenant
+// journal lock.
+func refreshPeriodTotals(ctx context.Context, tx *db.TenantTx) (int, error) {
+	var refreshed int
+	err := tx.QueryRow(ctx, `
+		WITH queued AS (
+			DELETE FROM period_totals_pending
+			RETURNING journal_entry_id
+		), added AS (`+addPeriodTotalsSQL("SELECT journal_entry_id FROM queued")+`
+			RETURNING 1
+		)
+		SELECT COUNT(*) FROM queued
+	`).Scan(&refreshed)
+	if err != nil {
+		return 0, fmt.Errorf("failed to refresh period totals: %w", err)
+	}
+	return refreshed, nil
+}
+
+// computedPeriodTotalsSQL sums the journal lines of every account, or of
+// account $1 when set, per month of entry date
+const computedPeriodTotalsSQL = `
+	SELECT je.tenant_id, jel.account_id, date_trunc('month', je.entry_date)::date AS period_start,
+	       SUM(jel.debit) AS debit, SUM(jel.credit) AS credit, COUNT(*) AS line_count
+	FROM journal_entry_lines jel
+	INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+	WHERE $1::uuid IS NULL OR jel.account_id = $1
+	GROUP BY je.tenant_id, jel.account_id, date_trunc('month', je.entry_date)
+`
+
+// rebuildPeriodTotals recomputes the period totals of every account, or of
+// one account, from their journal lines and returns how many periods were
+// missing or differed. Queued entries are added first, as the recomputed
+// totals include them. The caller must hold the tenant journal lock.
+func rebuildPeriodTotals(ctx context.Context, tx *db.TenantTx, accountID *uuid.UUID) (int, error) {
+	if _, err := refreshPeriodTotals(ctx, tx); err != nil {
+		return 0, err
+	}
+
+	var corrected int
+	err := tx.QueryRow(ctx, `
+		WITH computed AS (`+computedPeriodTotalsSQL+`)
+		SELECT COUNT(*)
+		FROM computed c
+		FULL JOIN (
+			SELECT * FROM account_period_totals WHERE $1::uuid IS NULL OR account_id = $1
+		) p ON p.account_id = c.account_id AND p.period_start = c.period_start
+		WHERE c.account_id IS NULL OR p.account_id IS NULL
+		   OR c.debit <> p.debit OR c.credit <> p.credit OR c.line_count <> p.line_count
+	`, accountID).Scan(&corrected)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compare period totals: %w", err)
+	}
+	if corrected == 0 {
+		return 0, nil
+	}
+
+	if err := tx.Exec(ctx, "DELETE FROM account_period_totals WHERE $1::uuid IS NULL OR account_id = $1", accountID); err != nil {
+		return 0, fmt.Errorf("failed to clear period totals: %w", err)
+	}
+
+	err = tx.Exec(ctx, `
+		INSERT INTO account_period_totals (tenant_id, account_id, period_start, debit, credit, line_count, updated_at)
+		SELECT tenant_id, account_id, period_start, debit, credit, line_count, NOW()
+		FROM (`+computedPeriodTotalsSQL+`) c
+	`, accountID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rebuild period totals: %w", err)
+	}
+
+	return corrected, nil
+}
+
+// periodLinesSQL selects account_id, period_start, debit, credit and
+// line_count of the journal lines with entries between fromDate and toDate,
+// both inclusive and optional, appending its parameters to args. Months
+// wholly inside the range are read from account_period_totals and only the
+// days of partial months at either end from the journal lines, so a report
+// over a year reads at most two months of lines.
+func periodLinesSQL(fromDate, toDate *time.Time, args *[]interface{}) string {
+	param := func(value interface{}) string {
+		*args = append(*args, value)
+		return fmt.Sprintf("$%d", len(*args))
+	}
+
+	// Whole months run from monthsFrom up to, not including, monthsTo;
+	// either end is open when the range is
+	var monthsFrom, monthsTo *time.Time
+	if fromDate != nil {
+		start := monthStart(*fromDate)
+		if !start.Equal(dateOf(*fromDate)) {
+			start = start.AddDate(0, 1, 0)
+		}
+		monthsFrom = &start
+	}
+	if toDate != nil {
+		end := monthStart(dateOf(*toDate).AddDate(0, 0, 1))
+		monthsTo = &end
+	}
+
+	const lines = `
+		SELECT jel.account_id, date_trunc('month', je.entry_date)::date AS period_start,
+		       jel.debit, jel.credit, 1::bigint AS line_count
+		FROM journal_entry_lines jel
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+	`
+
+	if monthsFrom != nil && monthsTo != nil && !monthsFrom.Before(*monthsTo) {
+		return lines + `
+		WHERE je.entry_date >= ` + param(*fromDate) + `::date AND je.entry_date <= ` + param(*toDate) + `::date`
+	}
+
+	from, to := param(monthsFrom), param(monthsTo)
+	query := `
+		SELECT account_id, period_start, debit, credit, line_count
+		FROM account_period_totals
+		WHERE (` + from + `::date IS NULL OR period_start >= ` + from + `)
+		  AND (` + to + `::date IS NULL OR period_start < ` + to + `)`
+
+	var edges []string
+	if fromDate != nil {
+		edges = append(edges, "(je.entry_date >= "+param(*fromDate)+"::date AND je.entry_date < "+from+")")
+	}
+	if toDate != nil {
+		edges = append(edges, "(je.entry_date >= "+to+" AND je.entry_date <= "+param(*toDate)+"::date)")
+	}
+	for i, edge := range edges {
+		if i == 0 {
+			query += `
+		UNION ALL` + lines + `
+		WHERE ` + edge
+		} else {
+			query += ` OR ` + edge
+		}
+	}
+
+	return query
+}
+
+// monthStart returns the first day of the month of t
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// dateOf returns the calendar date of t at midnight UTC
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodLinesSQL(t *testing.T) {
+	date := func(year int, month time.Month, day int) *time.Time {
+		d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+
+	tests := []struct {
+		name      string
+		from, to  *time.Time
+		totals    bool
+		lines     bool
+		monthArgs []interface{}
+	}{
+		{"whole months", date(2024, 1, 1), date(2024, 3, 31), true, true, []interface{}{date(2024, 1, 1), date(2024, 4, 1)}},
+		{"partial months at both ends", date(2024, 1, 15), date(2024, 3, 10), true, true, []interface{}{date(2024, 2, 1), date(2024, 3, 1)}},
+		{"open start", nil, date(2024, 6, 30), true, true, []interface{}{(*time.Time)(nil), date(2024, 7, 1)}},
+		{"open range", nil, nil, true, false, []interface{}{(*time.Time)(nil), (*time.Time)(nil)}},
+		{"within one month", date(2024, 5, 2), date(2024, 5, 20), false, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []interface{}
+			query := periodLinesSQL(tt.from, tt.to, &args)
+
+			assert.Equal(t, tt.totals, strings.Contains(query, "account_period_totals"))
+			assert.Equal(t, tt.lines, strings.Contains(query, "journal_entry_lines"))
+			if tt.monthArgs != nil {
+				assert.Equal(t, tt.monthArgs, args[:2])
+			}
+		})
+	}
+}
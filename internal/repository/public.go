@@ -0,0 +1,82 @@
+package repository
+
+import (
+	public "github.com/hesabFun/ledger/repository"
+)
+
+// The ledger models, parameters and repository interfaces are defined in
+// the public repository package, so implementations outside this module,
+// such as the in-memory repositories, can be built against them. They are
+// aliased here under the same names.
+
+// Tenant statuses
+const (
+	TenantStatusActive    = public.TenantStatusActive
+	TenantStatusSuspended = public.TenantStatusSuspended
+	TenantStatusArchived  = public.TenantStatusArchived
+	TenantStatusDeleted   = public.TenantStatusDeleted
+)
+
+// Account type codes
+const (
+	AccountTypeAsset     = public.AccountTypeAsset
+	AccountTypeLiability = public.AccountTypeLiability
+	AccountTypeEquity    = public.AccountTypeEquity
+	AccountTypeRevenue   = public.AccountTypeRevenue
+	AccountTypeExpense   = public.AccountTypeExpense
+)
+
+// Sort fields accepted by AccountFilter.SortBy
+const (
+	AccountSortNumber    = public.AccountSortNumber
+	AccountSortName      = public.AccountSortName
+	AccountSortCreatedAt = public.AccountSortCreatedAt
+)
+
+// DefaultBookCode is the code of the book every tenant is created with
+const DefaultBookCode = public.DefaultBookCode
+
+type (
+	Tenant       = public.Tenant
+	TenantFilter = public.TenantFilter
+
+	Account             = public.Account
+	CreateAccountParams = public.CreateAccountParams
+	AccountFilter       = public.AccountFilter
+	AccountBalance      = public.AccountBalance
+	AvailableBalance    = public.AvailableBalance
+	ProjectedBalance    = public.ProjectedBalance
+	AccountCurrency     = public.AccountCurrency
+	AccountMerge        = public.AccountMerge
+	BalanceChange       = public.BalanceChange
+
+	Book             = public.Book
+	CreateBookParams = public.CreateBookParams
+
+	JournalEntry                 = public.JournalEntry
+	JournalEntryLine             = public.JournalEntryLine
+	CreateJournalEntryParams     = public.CreateJournalEntryParams
+	CreateJournalEntryLineParams = public.CreateJournalEntryLineParams
+	JournalEntryFilter           = public.JournalEntryFilter
+	JournalEntryTotals           = public.JournalEntryTotals
+	LedgerIntegrity              = public.LedgerIntegrity
+
+	AccountType             = public.AccountType
+	Currency                = public.Currency
+	CreateAccountTypeParams = public.CreateAccountTypeParams
+	CreateCurrencyParams    = public.CreateCurrencyParams
+	UpdateCurrencyParams    = public.UpdateCurrencyParams
+
+	TenantRepositoryInterface    = public.TenantRepositoryInterface
+	AccountRepositoryInterface   = public.AccountRepositoryInterface
+	BookRepositoryInterface      = public.BookRepositoryInterface
+	JournalRepositoryInterface   = public.JournalRepositoryInterface
+	ReferenceRepositoryInterface = public.ReferenceRepositoryInterface
+)
+
+// EntryHash returns the hash that links an entry into its tenant's chain
+var EntryHash = public.EntryHash
+
+// MergeReference returns the reference number of a merge's reclassification
+// entry
+var MergeReference = public.MergeReference
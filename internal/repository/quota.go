@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// TenantQuota represents the resource limits stored with a tenant; nil means
+// unlimited. The limits are columns of the tenant row, so UpdatedAt is the
+// tenant's and changes with its other fields too.
+type TenantQuota struct {
+	TenantID         uuid.UUID
+	MaxAccounts      *int32
+	MaxEntriesPerDay *int32
+	MaxLinesPerEntry *int32
+	UpdatedAt        time.Time
+}
+
+// QuotaUsage represents a tenant's current consumption of quota-limited resources
+type QuotaUsage struct {
+	AccountCount int
+	EntriesSince int
+}
+
+// checkAccountQuota rejects a new or restored account once the tenant has
+// maxAccounts; deleted accounts do not count. It takes the account tree
+// lock, so concurrent creations count each other.
+func checkAccountQuota(ctx context.Context, tx *db.TenantTx, maxAccounts int32) error {
+	if err := lockAccountTree(ctx, tx); err != nil {
+		return err
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM accounts WHERE deleted_at IS NULL").Scan(&count); err != nil {
+		return fmt.Errorf("failed to count accounts: %w", err)
+	}
+	if count >= int(maxAccounts) {
+		return fmt.Errorf("account %w: limit is %d accounts", ErrQuotaExceeded, maxAccounts)
+	}
+
+	return nil
+}
+
+// checkEntryQuota rejects a posting once the tenant posted maxEntries
+// entries since dayStart, the start of its day. It must run under the
+// tenant's journal lock, so concurrent postings count each other.
+func checkEntryQuota(ctx context.Context, tx *db.TenantTx, maxEntries int32, dayStart time.Time) error {
+	var count int
+	err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM journal_entries WHERE created_at >= $1", dayStart).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to count journal entries: %w", err)
+	}
+	if count >= int(maxEntries) {
+		return fmt.Errorf("journal entry %w: limit is %d entries per day", ErrQuotaExceeded, maxEntries)
+	}
+
+	return nil
+}
+
+// QuotaRepository handles tenant quota database operations
+type QuotaRepository struct {
+	db *db.DB
+}
+
+// NewQuotaRepository creates a new quota repository
+func NewQuotaRepository(database *db.DB) *QuotaRepository {
+	return &QuotaRepository{db: database}
+}
+
+// Get retrieves the quota limits of a tenant
+func (r *QuotaRepository) Get(ctx context.Context, tenantID uuid.UUID) (*TenantQuota, error) {
+	quota := &TenantQuota{TenantID: tenantID}
+	query := `
+		SELECT max_accounts, max_entries_per_day, max_lines_per_entry, updated_at
+		FROM tenants
+		WHERE id = $1
+	`
+
+	err := r.db.Pool().QueryRow(ctx, query, tenantID).Scan(
+		&quota.MaxAccounts,
+		&quota.MaxEntriesPerDay,
+		&quota.MaxLinesPerEntry,
+		&quota.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("tenant quota %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get tenant quota: %w", err)
+	}
+
+	return quota, nil
+}
+
+// Set replaces all quota limits of a tenant. Changing them changes the
+// tenant's configuration, so it bumps tenants.updated_at like a status change.
+func (r *QuotaRepository) Set(ctx context.Context, quota *TenantQuota) (*TenantQuota, error) {
+	updated := &TenantQuota{TenantID: quota.TenantID}
+	query := `
+		UPDATE tenants
+		SET max_accounts = $2,
+		    max_entries_per_day = $3,
+		    max_lines_per_entry = $4,
+		    updated_at = NOW()
+		WHERE id = $1
+		RETURNING max_accounts, max_entries_per_day, max_lines_per_entry, updated_at
+	`
+
+	err := r.db.Pool().QueryRow(ctx, query,
+		quota.TenantID,
+		quota.MaxAccounts,
+		quota.MaxEntriesPerDay,
+		quota.MaxLinesPerEntry,
+	).Scan(
+		&updated.MaxAccounts,
+		&updated.MaxEntriesPerDay,
+		&updated.MaxLinesPerEntry,
+		&updated.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("tenant quota %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update tenant quota: %w", err)
+	}
+
+	return updated, nil
+}
+
+// GetUsage counts the tenant's accounts that are not deleted and the journal
+// entries created since the given time
+func (r *QuotaRepository) GetUsage(ctx context.Context, tenantID uuid.UUID, since time.Time) (*QuotaUsage, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	usage := &QuotaUsage{}
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM accounts WHERE deleted_at IS NULL),
+			(SELECT COUNT(*) FROM journal_entries WHERE created_at >= $1)
+	`
+
+	err = conn.QueryRow(ctx, query, since).Scan(&usage.AccountCount, &usage.EntriesSince)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota usage: %w", err)
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,355 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// Reconciliation statuses of a statement line
+const (
+	StatementLineUnmatched = "UNMATCHED"
+	StatementLineMatched   = "MATCHED"
+)
+
+// StatementLine represents a single transaction on an imported bank statement
+type StatementLine struct {
+	ID                 uuid.UUID
+	StatementID        uuid.UUID
+	AccountID          uuid.UUID
+	PostedAt           time.Time
+	Amount             decimal.Decimal
+	Description        string
+	Reference          string
+	Status             string
+	JournalEntryLineID *uuid.UUID
+	MatchMethod        *string
+	MatchedAt          *time.Time
+}
+
+// StatementLineFilter holds filters for listing statement lines
+type StatementLineFilter struct {
+	AccountID uuid.UUID
+	Status    *string
+	FromDate  *time.Time
+	ToDate    *time.Time
+}
+
+// UnreconciledJournalLine is a journal line on an account that is not matched to a statement line
+type UnreconciledJournalLine struct {
+	ID              uuid.UUID
+	JournalEntryID  uuid.UUID
+	EntryDate       time.Time
+	Debit           decimal.Decimal
+	Credit          decimal.Decimal
+	ReferenceNumber string
+	Description     string
+}
+
+// MatchParams holds parameters for matching a statement line to a journal line
+type MatchParams struct {
+	StatementLineID    uuid.UUID
+	JournalEntryLineID uuid.UUID
+	Method             string
+}
+
+// ReconciliationSummary represents the reconciliation state of an account over a period
+type ReconciliationSummary struct {
+	StatementLines        int
+	MatchedLines          int
+	UnmatchedLines        int
+	StatementTotal        decimal.Decimal
+	UnmatchedTotal        decimal.Decimal
+	UnmatchedJournalLines int
+}
+
+const statementLineColumns = `id, statement_id, account_id, posted_at, amount, description, reference,
+		       status, journal_entry_line_id, match_method, matched_at`
+
+func scanStatementLine(row pgx.Row, line *StatementLine) error {
+	return row.Scan(
+		&line.ID,
+		&line.StatementID,
+		&line.AccountID,
+		&line.PostedAt,
+		&line.Amount,
+		&line.Description,
+		&line.Reference,
+		&line.Status,
+		&line.JournalEntryLineID,
+		&line.MatchMethod,
+		&line.MatchedAt,
+	)
+}
+
+// ReconciliationRepository handles matching of statement lines against journal lines
+type ReconciliationRepository struct {
+	db *db.DB
+}
+
+// NewReconciliationRepository creates a new reconciliation repository
+func NewReconciliationRepository(database *db.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{db: database}
+}
+
+// ListStatementLines retrieves statement lines of an account ordered by posting date
+func (r *ReconciliationRepository) ListStatementLines(ctx context.Context, tenantID uuid.UUID, filter StatementLineFilter) ([]*StatementLine, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT ` + statementLineColumns + `
+		FROM bank_statement_lines
+		WHERE account_id = $1
+	`
+	args := []interface{}{filter.AccountID}
+	argCount := 1
+
+	if filter.Status != nil {
+		argCount++
+		query += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, *filter.Status)
+	}
+
+	if filter.FromDate != nil {
+		argCount++
+		query += fmt.Sprintf(" AND posted_at >= $%d", argCount)
+		args = append(args, *filter.FromDate)
+	}
+
+	if filter.ToDate != nil {
+		argCount++
+		query += fmt.Sprintf(" AND posted_at <= $%d", argCount)
+		args = append(args, *filter.ToDate)
+	}
+
+	query += " ORDER BY posted_at, id"
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statement lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines := make([]*StatementLine, 0)
+	for rows.Next() {
+		line := &StatementLine{}
+		if err := scanStatementLine(rows, line); err != nil {
+			return nil, fmt.Errorf("failed to scan statement line: %w", err)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// ListUnreconciledJournalLines retrieves journal lines of an account that are not matched to any statement line
+func (r *ReconciliationRepository) ListUnreconciledJournalLines(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, fromDate, toDate *time.Time) ([]*UnreconciledJournalLine, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT jel.id, jel.journal_entry_id, je.entry_date, jel.debit, jel.credit,
+		       je.reference_number, COALESCE(NULLIF(jel.description, ''), je.description)
+		FROM journal_entry_lines jel
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		WHERE jel.account_id = $1
+		  AND NOT EXISTS (SELECT 1 FROM bank_statement_lines bsl WHERE bsl.journal_entry_line_id = jel.id)
+	`
+	args := []interface{}{accountID}
+	argCount := 1
+
+	if fromDate != nil {
+		argCount++
+		query += fmt.Sprintf(" AND je.entry_date >= $%d", argCount)
+		args = append(args, *fromDate)
+	}
+
+	if toDate != nil {
+		argCount++
+		query += fmt.Sprintf(" AND je.entry_date <= $%d", argCount)
+		args = append(args, *toDate)
+	}
+
+	query += " ORDER BY je.entry_date, jel.created_at"
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unreconciled journal lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines := make([]*UnreconciledJournalLine, 0)
+	for rows.Next() {
+		line := &UnreconciledJournalLine{}
+		err := rows.Scan(
+			&line.ID,
+			&line.JournalEntryID,
+			&line.EntryDate,
+			&line.Debit,
+			&line.Credit,
+			&line.ReferenceNumber,
+			&line.Description,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan journal line: %w", err)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// Match marks statement lines as matched to journal lines in a single
+// transaction, completing the bank reconciliation tasks of the close
+// checklists it leaves without unmatched lines
+func (r *ReconciliationRepository) Match(ctx context.Context, tenantID uuid.UUID, matches []*MatchParams) ([]*StatementLine, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	checkQuery := `
+		SELECT bsl.status, bsl.account_id,
+		       (SELECT jel.account_id FROM journal_entry_lines jel WHERE jel.id = $2),
+		       EXISTS (SELECT 1 FROM bank_statement_lines o WHERE o.journal_entry_line_id = $2)
+		FROM bank_statement_lines bsl
+		WHERE bsl.id = $1
+		FOR UPDATE OF bsl
+	`
+	updateQuery := `
+		UPDATE bank_statement_lines
+		SET status = $2, journal_entry_line_id = $3, match_method = $4, matched_at = NOW()
+		WHERE id = $1
+		RETURNING ` + statementLineColumns
+
+	lines := make([]*StatementLine, 0, len(matches))
+	for _, m := range matches {
+		var lineStatus string
+		var statementAccountID uuid.UUID
+		var journalAccountID *uuid.UUID
+		var journalLineMatched bool
+
+		err := tx.QueryRow(ctx, checkQuery, m.StatementLineID, m.JournalEntryLineID).Scan(
+			&lineStatus,
+			&statementAccountID,
+			&journalAccountID,
+			&journalLineMatched,
+		)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, fmt.Errorf("statement line %w", ErrNotFound)
+			}
+			return nil, fmt.Errorf("failed to check statement line: %w", err)
+		}
+
+		if journalAccountID == nil {
+			return nil, fmt.Errorf("journal entry line %w", ErrNotFound)
+		}
+		if lineStatus == StatementLineMatched || journalLineMatched {
+			return nil, ErrAlreadyReconciled
+		}
+		if *journalAccountID != statementAccountID {
+			return nil, ErrAccountMismatch
+		}
+
+		line := &StatementLine{}
+		row := tx.QueryRow(ctx, updateQuery, m.StatementLineID, StatementLineMatched, m.JournalEntryLineID, m.Method)
+		if err := scanStatementLine(row, line); err != nil {
+			return nil, fmt.Errorf("failed to match statement line: %w", err)
+		}
+		lines = append(lines, line)
+	}
+
+	if err := completeCloseTasks(ctx, tx, CloseActionReconcileBank); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return lines, nil
+}
+
+// Unmatch clears the match of a statement line
+func (r *ReconciliationRepository) Unmatch(ctx context.Context, tenantID uuid.UUID, statementLineID uuid.UUID) (*StatementLine, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	line := &StatementLine{}
+	query := `
+		UPDATE bank_statement_lines
+		SET status = $2, journal_entry_line_id = NULL, match_method = NULL, matched_at = NULL
+		WHERE id = $1
+		RETURNING ` + statementLineColumns
+
+	if err := scanStatementLine(tx.QueryRow(ctx, query, statementLineID, StatementLineUnmatched), line); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("statement line %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to unmatch statement line: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return line, nil
+}
+
+// GetSummary returns the reconciliation state of an account between two dates
+func (r *ReconciliationRepository) GetSummary(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, fromDate, toDate time.Time) (*ReconciliationSummary, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	summary := &ReconciliationSummary{}
+	query := `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE status = 'MATCHED'),
+		       COUNT(*) FILTER (WHERE status = 'UNMATCHED'),
+		       COALESCE(SUM(amount), 0),
+		       COALESCE(SUM(amount) FILTER (WHERE status = 'UNMATCHED'), 0),
+		       (SELECT COUNT(*)
+		        FROM journal_entry_lines jel
+		        INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		        WHERE jel.account_id = $1
+		          AND je.entry_date BETWEEN $2 AND $3
+		          AND NOT EXISTS (SELECT 1 FROM bank_statement_lines o WHERE o.journal_entry_line_id = jel.id))
+		FROM bank_statement_lines
+		WHERE account_id = $1 AND posted_at BETWEEN $2 AND $3
+	`
+
+	err = conn.QueryRow(ctx, query, accountID, fromDate, toDate).Scan(
+		&summary.StatementLines,
+		&summary.MatchedLines,
+		&summary.UnmatchedLines,
+		&summary.StatementTotal,
+		&summary.UnmatchedTotal,
+		&summary.UnmatchedJournalLines,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reconciliation summary: %w", err)
+	}
+
+	return summary, nil
+}
@@ -2,33 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
 
 	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
 )
 
-// AccountType represents an account type entity
-type AccountType struct {
-	ID            int32
-	Code          string
-	Name          string
-	NormalBalance string
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-}
-
-// Currency represents a currency entity
-type Currency struct {
-	ID        int32
-	Code      string
-	Name      string
-	Symbol    string
-	Precision int32
-	CreatedAt time.Time
-	UpdatedAt time.Time
-}
-
 // ReferenceRepository handles reference data database operations
 type ReferenceRepository struct {
 	db *db.DB
@@ -107,3 +87,200 @@ func (r *ReferenceRepository) ListCurrencies(ctx context.Context) ([]*Currency,
 
 	return currencies, nil
 }
+
+// CreateAccountType inserts a new account type
+func (r *ReferenceRepository) CreateAccountType(ctx context.Context, params CreateAccountTypeParams) (*AccountType, error) {
+	accountType := &AccountType{}
+	query := `
+		INSERT INTO account_types (code, name, normal_balance)
+		VALUES ($1, $2, $3)
+		RETURNING id, code, name, normal_balance, created_at, updated_at
+	`
+
+	err := r.db.Pool().QueryRow(ctx, query, params.Code, params.Name, params.NormalBalance).Scan(
+		&accountType.ID,
+		&accountType.Code,
+		&accountType.Name,
+		&accountType.NormalBalance,
+		&accountType.CreatedAt,
+		&accountType.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create account type: %w", err)
+	}
+
+	return accountType, nil
+}
+
+// CreateCurrency inserts a new currency
+func (r *ReferenceRepository) CreateCurrency(ctx context.Context, params CreateCurrencyParams) (*Currency, error) {
+	currency := &Currency{}
+	query := `
+		INSERT INTO currencies (code, name, symbol, precision)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, code, name, symbol, precision, created_at, updated_at
+	`
+
+	err := r.db.Pool().QueryRow(ctx, query, params.Code, params.Name, params.Symbol, params.Precision).Scan(
+		&currency.ID,
+		&currency.Code,
+		&currency.Name,
+		&currency.Symbol,
+		&currency.Precision,
+		&currency.CreatedAt,
+		&currency.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create currency: %w", err)
+	}
+
+	return currency, nil
+}
+
+// UpdateCurrency updates an existing currency identified by its code
+func (r *ReferenceRepository) UpdateCurrency(ctx context.Context, code string, params UpdateCurrencyParams) (*Currency, error) {
+	currency := &Currency{}
+	query := `
+		UPDATE currencies
+		SET name = COALESCE($2, name),
+		    symbol = COALESCE($3, symbol),
+		    precision = COALESCE($4, precision),
+		    updated_at = NOW()
+		WHERE code = $1
+		RETURNING id, code, name, symbol, precision, created_at, updated_at
+	`
+
+	err := r.db.Pool().QueryRow(ctx, query, code, params.Name, params.Symbol, params.Precision).Scan(
+		&currency.ID,
+		&currency.Code,
+		&currency.Name,
+		&currency.Symbol,
+		&currency.Precision,
+		&currency.CreatedAt,
+		&currency.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("currency %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update currency: %w", err)
+	}
+
+	return currency, nil
+}
+
+// SetAccountTypeTranslation sets the name of an account type in a locale; an
+// empty name removes the translation. The account type's updated_at moves
+// along, so it versions its names in every locale.
+func (r *ReferenceRepository) SetAccountTypeTranslation(ctx context.Context, code, locale, name string) error {
+	query := `
+		WITH account_type AS (
+			UPDATE account_types SET updated_at = NOW() WHERE code = $1
+			RETURNING id
+		), removed AS (
+			DELETE FROM account_type_translations
+			WHERE $3 = '' AND locale = $2
+			  AND account_type_id = (SELECT id FROM account_type)
+		), upserted AS (
+			INSERT INTO account_type_translations (account_type_id, locale, name)
+			SELECT id, $2, $3 FROM account_type WHERE $3 <> ''
+			ON CONFLICT (account_type_id, locale) DO UPDATE
+			SET name = EXCLUDED.name, updated_at = NOW()
+		)
+		SELECT EXISTS (SELECT 1 FROM account_type)
+	`
+
+	var exists bool
+	if err := r.db.Pool().QueryRow(ctx, query, code, locale, name).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to set account type translation: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("account type %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// SetCurrencyTranslation sets the name of a currency in a locale; an empty
+// name removes the translation. The currency's updated_at moves along, so it
+// versions its names in every locale.
+func (r *ReferenceRepository) SetCurrencyTranslation(ctx context.Context, code, locale, name string) error {
+	query := `
+		WITH currency AS (
+			UPDATE currencies SET updated_at = NOW() WHERE code = $1
+			RETURNING id
+		), removed AS (
+			DELETE FROM currency_translations
+			WHERE $3 = '' AND locale = $2
+			  AND currency_id = (SELECT id FROM currency)
+		), upserted AS (
+			INSERT INTO currency_translations (currency_id, locale, name)
+			SELECT id, $2, $3 FROM currency WHERE $3 <> ''
+			ON CONFLICT (currency_id, locale) DO UPDATE
+			SET name = EXCLUDED.name, updated_at = NOW()
+		)
+		SELECT EXISTS (SELECT 1 FROM currency)
+	`
+
+	var exists bool
+	if err := r.db.Pool().QueryRow(ctx, query, code, locale, name).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to set currency translation: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("currency %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// ListAccountTypeTranslations retrieves the translated names of the account
+// types by code, in the first of the locales each has a translation in
+func (r *ReferenceRepository) ListAccountTypeTranslations(ctx context.Context, locales []string) (map[string]string, error) {
+	query := `
+		SELECT at.code, t.name
+		FROM account_type_translations t
+		JOIN account_types at ON at.id = t.account_type_id
+		WHERE t.locale = ANY($1)
+		ORDER BY array_position($1, t.locale) DESC
+	`
+
+	return r.listTranslations(ctx, "account type", query, locales)
+}
+
+// ListCurrencyTranslations retrieves the translated names of the currencies
+// by code, in the first of the locales each has a translation in
+func (r *ReferenceRepository) ListCurrencyTranslations(ctx context.Context, locales []string) (map[string]string, error) {
+	query := `
+		SELECT c.code, t.name
+		FROM currency_translations t
+		JOIN currencies c ON c.id = t.currency_id
+		WHERE t.locale = ANY($1)
+		ORDER BY array_position($1, t.locale) DESC
+	`
+
+	return r.listTranslations(ctx, "currency", query, locales)
+}
+
+// listTranslations runs a translation query returning codes and names ordered
+// from the least to the most preferred locale, so preferred names win
+func (r *ReferenceRepository) listTranslations(ctx context.Context, kind, query string, locales []string) (map[string]string, error) {
+	rows, err := r.db.Pool().Query(ctx, query, locales)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s translations: %w", kind, err)
+	}
+	defer rows.Close()
+
+	names := make(map[string]string)
+	for rows.Next() {
+		var code, name string
+		if err := rows.Scan(&code, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan %s translation: %w", kind, err)
+		}
+		names[code] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list %s translations: %w", kind, err)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// Date components a generated reference number may include
+const (
+	ReferenceDateNone      = "NONE"
+	ReferenceDateYear      = "YEAR"
+	ReferenceDateYearMonth = "YEAR_MONTH"
+	ReferenceDateDay       = "DATE"
+)
+
+// referenceDateLayouts maps each date component to its time layout
+var referenceDateLayouts = map[string]string{
+	ReferenceDateYear:      "2006",
+	ReferenceDateYearMonth: "200601",
+	ReferenceDateDay:       "20060102",
+}
+
+// Default reference sequence used until a tenant stores its own
+const (
+	DefaultReferencePrefix  = "JE-"
+	DefaultReferencePadding = 6
+)
+
+// ReferenceSequence generates the reference numbers of journal entries
+// created without one
+type ReferenceSequence struct {
+	TenantID      uuid.UUID
+	Prefix        string
+	Padding       int32
+	DateComponent string
+	NextValue     int64
+	UpdatedAt     time.Time
+}
+
+// Format builds the reference number for a value of the sequence and an
+// entry date: the prefix, the date followed by a dash when the sequence
+// includes one, and the value zero-padded to the padding width
+func (s *ReferenceSequence) Format(value int64, date time.Time) string {
+	var b strings.Builder
+	b.WriteString(s.Prefix)
+	if layout, ok := referenceDateLayouts[s.DateComponent]; ok {
+		b.WriteString(date.UTC().Format(layout))
+		b.WriteByte('-')
+	}
+
+	digits := strconv.FormatInt(value, 10)
+	if pad := int(s.Padding) - len(digits); pad > 0 {
+		b.WriteString(strings.Repeat("0", pad))
+	}
+	b.WriteString(digits)
+
+	return b.String()
+}
+
+// defaultReferenceSequence returns the sequence of a tenant that stored none
+func defaultReferenceSequence(tenantID uuid.UUID) *ReferenceSequence {
+	return &ReferenceSequence{
+		TenantID:      tenantID,
+		Prefix:        DefaultReferencePrefix,
+		Padding:       DefaultReferencePadding,
+		DateComponent: ReferenceDateNone,
+		NextValue:     1,
+	}
+}
+
+// ReferenceSequenceRepository handles reference sequence database operations
+type ReferenceSequenceRepository struct {
+	db *db.DB
+}
+
+// NewReferenceSequenceRepository creates a new reference sequence repository
+func NewReferenceSequenceRepository(database *db.DB) *ReferenceSequenceRepository {
+	return &ReferenceSequenceRepository{db: database}
+}
+
+// Get retrieves the reference sequence of a tenant, falling back to the
+// default when none is stored
+func (r *ReferenceSequenceRepository) Get(ctx context.Context, tenantID uuid.UUID) (*ReferenceSequence, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	sequence := &ReferenceSequence{TenantID: tenantID}
+	query := `
+		SELECT prefix, padding, date_component, next_value, updated_at
+		FROM reference_sequences
+		WHERE tenant_id = $1
+	`
+
+	err = conn.QueryRow(ctx, query, tenantID).Scan(
+		&sequence.Prefix,
+		&sequence.Padding,
+		&sequence.DateComponent,
+		&sequence.NextValue,
+		&sequence.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return defaultReferenceSequence(tenantID), nil
+		}
+		return nil, fmt.Errorf("failed to get reference sequence: %w", err)
+	}
+
+	return sequence, nil
+}
+
+// Upsert stores the reference sequence of a tenant. It returns
+// ErrSequenceMovedBack instead of lowering the stored next value, also when
+// postings claimed values after the sequence was read, so a write based on a
+// stale read is refused rather than reissuing numbers.
+func (r *ReferenceSequenceRepository) Upsert(ctx context.Context, sequence *ReferenceSequence) (*ReferenceSequence, error) {
+	tx, err := r.db.BeginTx(ctx, sequence.TenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	stored := &ReferenceSequence{TenantID: sequence.TenantID}
+	query := `
+		INSERT INTO reference_sequences (tenant_id, prefix, padding, date_component, next_value)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id) DO UPDATE
+		SET prefix = EXCLUDED.prefix,
+		    padding = EXCLUDED.padding,
+		    date_component = EXCLUDED.date_component,
+		    next_value = EXCLUDED.next_value,
+		    updated_at = NOW()
+		WHERE reference_sequences.next_value <= EXCLUDED.next_value
+		RETURNING prefix, padding, date_component, next_value, updated_at
+	`
+
+	err = tx.QueryRow(ctx, query,
+		sequence.TenantID,
+		sequence.Prefix,
+		sequence.Padding,
+		sequence.DateComponent,
+		sequence.NextValue,
+	).Scan(
+		&stored.Prefix,
+		&stored.Padding,
+		&stored.DateComponent,
+		&stored.NextValue,
+		&stored.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSequenceMovedBack
+		}
+		return nil, fmt.Errorf("failed to upsert reference sequence: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return stored, nil
+}
+
+// Next claims the next value of a tenant's sequence and returns the
+// reference number it forms for an entry date. The value is claimed in its
+// own statement, so concurrent postings never share a number, but a posting
+// that fails afterwards leaves a gap.
+func (r *ReferenceSequenceRepository) Next(ctx context.Context, tenantID uuid.UUID, entryDate time.Time) (string, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	sequence := defaultReferenceSequence(tenantID)
+	query := `
+		INSERT INTO reference_sequences (tenant_id, prefix, padding, date_component, next_value)
+		VALUES ($1, $2, $3, $4, $5 + 1)
+		ON CONFLICT (tenant_id) DO UPDATE
+		SET next_value = reference_sequences.next_value + 1,
+		    updated_at = NOW()
+		RETURNING prefix, padding, date_component, next_value - 1
+	`
+
+	var value int64
+	err = tx.QueryRow(ctx, query,
+		tenantID,
+		sequence.Prefix,
+		sequence.Padding,
+		sequence.DateComponent,
+		sequence.NextValue,
+	).Scan(
+		&sequence.Prefix,
+		&sequence.Padding,
+		&sequence.DateComponent,
+		&value,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to claim reference number: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return sequence.Format(value, entryDate), nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferenceSequence_Format(t *testing.T) {
+	date := time.Date(2026, 3, 15, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		sequence ReferenceSequence
+		value    int64
+		want     string
+	}{
+		{"default", ReferenceSequence{Prefix: DefaultReferencePrefix, Padding: DefaultReferencePadding, DateComponent: ReferenceDateNone}, 42, "JE-000042"},
+		{"year", ReferenceSequence{Prefix: "INV", Padding: 4, DateComponent: ReferenceDateYear}, 7, "INV2026-0007"},
+		{"year and month", ReferenceSequence{Prefix: "JE-", Padding: 3, DateComponent: ReferenceDateYearMonth}, 7, "JE-202603-007"},
+		{"date", ReferenceSequence{Padding: 2, DateComponent: ReferenceDateDay}, 7, "20260315-07"},
+		{"value wider than padding", ReferenceSequence{Prefix: "JE-", Padding: 2}, 12345, "JE-12345"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.sequence.Format(tt.value, date))
+		})
+	}
+}
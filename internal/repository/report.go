@@ -0,0 +1,461 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// TrialBalanceRow represents the debit and credit totals of one account over a period
+type TrialBalanceRow struct {
+	AccountID       uuid.UUID
+	AccountNumber   string
+	AccountName     string
+	AccountTypeCode string
+	NormalBalance   string
+	CurrencyCode    string
+	Debit           decimal.Decimal
+	Credit          decimal.Decimal
+}
+
+// Balance returns the net debit balance
+func (r *TrialBalanceRow) Balance() decimal.Decimal {
+	return r.Debit.Sub(r.Credit)
+}
+
+// ReportRepository handles read-only reporting queries
+type ReportRepository struct {
+	db *db.DB
+}
+
+// NewReportRepository creates a new report repository
+func NewReportRepository(database *db.DB) *ReportRepository {
+	return &ReportRepository{db: database}
+}
+
+// GetTrialBalance sums the journal lines of every account of a book with
+// entries between fromDate (inclusive, optional) and toDate (inclusive). The
+// book is given by code, as the books of different tenants are matched by
+// code; an empty code selects the default book. Whole months are summed from
+// the period totals. When postedAsOf is set, only entries posted by then are
+// included, reproducing the trial balance as it could have been reported at
+// that time; as the period totals do not record when lines were posted,
+// those are summed from the journal lines.
+func (r *ReportRepository) GetTrialBalance(ctx context.Context, tenantID uuid.UUID, bookCode string, fromDate *time.Time, toDate time.Time, postedAsOf *time.Time) ([]*TrialBalanceRow, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	var bookID uuid.UUID
+	err = conn.QueryRow(ctx,
+		"SELECT id FROM books WHERE CASE WHEN $1::text = '' THEN is_default ELSE code = $1 END",
+		bookCode,
+	).Scan(&bookID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("book %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get book: %w", err)
+	}
+
+	var query string
+	args := []interface{}{bookID}
+
+	if postedAsOf == nil {
+		query = `
+		SELECT a.id, a.account_number, a.name, at.code, at.normal_balance, a.currency_code,
+		       SUM(t.debit), SUM(t.credit)
+		FROM (` + periodLinesSQL(fromDate, &toDate, &args) + `
+		) t
+		INNER JOIN accounts a ON a.id = t.account_id
+		INNER JOIN account_types at ON at.id = a.account_type_id
+		WHERE a.book_id = $1
+	`
+	} else {
+		query = `
+		SELECT a.id, a.account_number, a.name, at.code, at.normal_balance, a.currency_code,
+		       SUM(jel.debit), SUM(jel.credit)
+		FROM journal_entry_lines jel
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		INNER JOIN accounts a ON a.id = jel.account_id
+		INNER JOIN account_types at ON at.id = a.account_type_id
+		WHERE a.book_id = $1
+	`
+		args = append(args, toDate)
+		query += fmt.Sprintf(" AND je.entry_date <= $%d", len(args))
+
+		if fromDate != nil {
+			args = append(args, *fromDate)
+			query += fmt.Sprintf(" AND je.entry_date >= $%d", len(args))
+		}
+
+		args = append(args, *postedAsOf)
+		query += fmt.Sprintf(" AND je.posted_at <= $%d", len(args))
+	}
+
+	query += `
+		GROUP BY a.id, a.account_number, a.name, at.code, at.normal_balance, a.currency_code
+		ORDER BY a.account_number
+	`
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trial balance: %w", err)
+	}
+	defer rows.Close()
+
+	trialBalance := make([]*TrialBalanceRow, 0)
+	for rows.Next() {
+		row := &TrialBalanceRow{}
+		err := rows.Scan(
+			&row.AccountID,
+			&row.AccountNumber,
+			&row.AccountName,
+			&row.AccountTypeCode,
+			&row.NormalBalance,
+			&row.CurrencyCode,
+			&row.Debit,
+			&row.Credit,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trial balance row: %w", err)
+		}
+		trialBalance = append(trialBalance, row)
+	}
+
+	return trialBalance, nil
+}
+
+// ReportFreshness tells how current the period totals behind a report are
+type ReportFreshness struct {
+	// Scheduled is set when the totals are refreshed in the background
+	// rather than as entries post
+	Scheduled bool
+	// AsOf is the time through which every posted entry is in the totals
+	AsOf time.Time
+	// PendingEntries counts the posted entries not yet in the totals
+	PendingEntries int64
+}
+
+// GetFreshness reports how far the period totals of a tenant lag its
+// journal. They are current unless entries are queued, in which case they
+// hold every entry posted before the oldest queued one.
+func (r *ReportRepository) GetFreshness(ctx context.Context, tenantID uuid.UUID) (*ReportFreshness, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	freshness := &ReportFreshness{Scheduled: r.db.DeferPeriodTotals()}
+	err = conn.QueryRow(ctx,
+		"SELECT COUNT(*), COALESCE(MIN(queued_at), NOW()) FROM period_totals_pending",
+	).Scan(&freshness.PendingEntries, &freshness.AsOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get period totals freshness: %w", err)
+	}
+
+	return freshness, nil
+}
+
+// RefreshPeriodTotals adds the entries a tenant has queued since the last
+// refresh to its period totals and returns how many there were. Postings
+// are locked out while the queue is drained, which is brief as each entry
+// only touches the totals of its accounts.
+func (r *ReportRepository) RefreshPeriodTotals(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return 0, err
+	}
+
+	refreshed, err := refreshPeriodTotals(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return refreshed, nil
+}
+
+// Periods journal lines can be bucketed by
+const (
+	AggregatePeriodDay   = "day"
+	AggregatePeriodMonth = "month"
+)
+
+// LineAggregateFilter selects the journal lines summed by
+// AggregateJournalLines and the keys they are grouped by
+type LineAggregateFilter struct {
+	ByAccount     bool
+	ByAccountType bool
+	ByCurrency    bool
+	// Dimensions are the dimension codes to group by
+	Dimensions []string
+	// Period buckets lines by entry date, AggregatePeriodDay or
+	// AggregatePeriodMonth; empty does not bucket
+	Period string
+	// BookID selects the book; nil selects the default book of the tenant
+	BookID    *uuid.UUID
+	AccountID *uuid.UUID
+	FromDate  *time.Time
+	ToDate    *time.Time
+}
+
+// LineAggregate sums the journal lines sharing the same group keys. Keys that
+// are not grouped by are nil.
+type LineAggregate struct {
+	AccountID       *uuid.UUID
+	AccountNumber   *string
+	AccountTypeCode *string
+	CurrencyCode    *string
+	Dimensions      map[string]string
+	PeriodStart     *time.Time
+	Debit           decimal.Decimal
+	Credit          decimal.Decimal
+	LineCount       int64
+}
+
+// UsesPeriodTotals reports whether the aggregates are summed from the period
+// totals, which carry neither dimensions nor days
+func (f LineAggregateFilter) UsesPeriodTotals() bool {
+	return len(f.Dimensions) == 0 && f.Period != AggregatePeriodDay
+}
+
+// Net returns debits less credits
+func (a *LineAggregate) Net() decimal.Decimal {
+	return a.Debit.Sub(a.Credit)
+}
+
+// AggregateJournalLines sums journal lines with entries between fromDate and
+// toDate (both inclusive and optional) per combination of the requested group
+// keys in a single query. Aggregates that neither group by dimensions nor
+// bucket by day are summed from the period totals for whole months.
+func (r *ReportRepository) AggregateJournalLines(ctx context.Context, tenantID uuid.UUID, filter LineAggregateFilter) ([]*LineAggregate, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	// Every key is selected so rows scan alike; keys that are not grouped by
+	// are selected as NULL
+	groupBy := make([]string, 0)
+	key := func(enabled bool, expr, null string) string {
+		if !enabled {
+			return null
+		}
+		groupBy = append(groupBy, expr)
+		return expr
+	}
+
+	switch filter.Period {
+	case "", AggregatePeriodDay, AggregatePeriodMonth:
+	default:
+		return nil, fmt.Errorf("unknown aggregate period %q", filter.Period)
+	}
+
+	projected := filter.UsesPeriodTotals()
+
+	var period, totals, from string
+	var args []interface{}
+	if projected {
+		period = "t.period_start"
+		totals = "COALESCE(SUM(t.debit), 0), COALESCE(SUM(t.credit), 0), COALESCE(SUM(t.line_count), 0)"
+		from = `(` + periodLinesSQL(filter.FromDate, filter.ToDate, &args) + `
+		) t
+		INNER JOIN accounts a ON a.id = t.account_id
+		INNER JOIN account_types at ON at.id = a.account_type_id`
+		args = append(args, filter.AccountID, filter.BookID)
+		from += fmt.Sprintf(`
+		WHERE ($%d::uuid IS NULL OR t.account_id = $%d)
+		  AND a.book_id = COALESCE($%d::uuid, `+defaultBookSQL+`)`, len(args)-1, len(args)-1, len(args))
+	} else {
+		period = fmt.Sprintf("date_trunc('%s', je.entry_date)::date", filter.Period)
+		totals = "COALESCE(SUM(jel.debit), 0), COALESCE(SUM(jel.credit), 0), COUNT(*)"
+		from = `journal_entry_lines jel
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		INNER JOIN accounts a ON a.id = jel.account_id
+		INNER JOIN account_types at ON at.id = a.account_type_id
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(jsonb_object_agg(k, jel.dimensions->>k) FILTER (WHERE jel.dimensions ? k), '{}') AS vals
+			FROM unnest($1::text[]) k
+		) g
+		WHERE ($2::uuid IS NULL OR jel.account_id = $2)
+		  AND ($3::date IS NULL OR je.entry_date >= $3)
+		  AND ($4::date IS NULL OR je.entry_date <= $4)
+		  AND a.book_id = COALESCE($5::uuid, ` + defaultBookSQL + `)`
+
+		dimensions := filter.Dimensions
+		if dimensions == nil {
+			dimensions = []string{}
+		}
+		args = []interface{}{dimensions, filter.AccountID, filter.FromDate, filter.ToDate, filter.BookID}
+	}
+
+	columns := []string{
+		key(filter.ByAccount, "a.id", "NULL::uuid"),
+		key(filter.ByAccount, "a.account_number", "NULL::text"),
+		key(filter.ByAccountType, "at.code", "NULL::text"),
+		key(filter.ByCurrency, "a.currency_code", "NULL::text"),
+		key(len(filter.Dimensions) > 0, "g.vals", "'{}'::jsonb"),
+		key(filter.Period != "", period, "NULL::date"),
+	}
+
+	query := `
+		SELECT ` + strings.Join(columns, ", ") + `,
+		       ` + totals + `
+		FROM ` + from + `
+	`
+	if len(groupBy) > 0 {
+		query += `
+		GROUP BY ` + strings.Join(groupBy, ", ") + `
+		`
+	}
+	query += `
+		HAVING COUNT(*) > 0
+	`
+	if len(groupBy) > 0 {
+		// Account numbers order accounts; jsonb has no meaningful order
+		ordering := make([]string, 0, len(groupBy))
+		for _, expr := range groupBy {
+			switch expr {
+			case "a.id":
+			case "g.vals":
+				ordering = append(ordering, "g.vals::text")
+			default:
+				ordering = append(ordering, expr)
+			}
+		}
+		query += `
+		ORDER BY ` + strings.Join(ordering, ", ")
+	}
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate journal lines: %w", err)
+	}
+	defer rows.Close()
+
+	aggregates := make([]*LineAggregate, 0)
+	for rows.Next() {
+		aggregate := &LineAggregate{}
+		err := rows.Scan(
+			&aggregate.AccountID,
+			&aggregate.AccountNumber,
+			&aggregate.AccountTypeCode,
+			&aggregate.CurrencyCode,
+			&aggregate.Dimensions,
+			&aggregate.PeriodStart,
+			&aggregate.Debit,
+			&aggregate.Credit,
+			&aggregate.LineCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan journal line aggregate: %w", err)
+		}
+		aggregates = append(aggregates, aggregate)
+	}
+
+	return aggregates, nil
+}
+
+// AccountStatementLine is a journal line on an account statement, with the
+// running balance after it
+type AccountStatementLine struct {
+	JournalEntryID  uuid.UUID
+	LineID          uuid.UUID
+	EntryDate       time.Time
+	ReferenceNumber string
+	Description     string
+	Debit           decimal.Decimal
+	Credit          decimal.Decimal
+	Balance         decimal.Decimal
+}
+
+// AccountStatement lists the lines of an account over a period. Balances are
+// debits less credits.
+type AccountStatement struct {
+	OpeningBalance decimal.Decimal
+	Lines          []*AccountStatementLine
+	ClosingBalance decimal.Decimal
+}
+
+// GetAccountStatement lists the lines of an account for entries dated
+// between fromDate and toDate, inclusive, oldest first. The opening balance
+// covers every earlier line.
+func (r *ReportRepository) GetAccountStatement(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, fromDate, toDate time.Time) (*AccountStatement, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	statement := &AccountStatement{Lines: make([]*AccountStatementLine, 0)}
+
+	openingQuery := `
+		SELECT COALESCE(SUM(jel.debit - jel.credit), 0)
+		FROM journal_entry_lines jel
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		WHERE jel.account_id = $1 AND je.entry_date < $2
+	`
+
+	if err := conn.QueryRow(ctx, openingQuery, accountID, fromDate).Scan(&statement.OpeningBalance); err != nil {
+		return nil, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+
+	query := `
+		SELECT je.id, jel.id, je.entry_date, je.reference_number,
+		       COALESCE(NULLIF(jel.description, ''), je.description), jel.debit, jel.credit
+		FROM journal_entry_lines jel
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		WHERE jel.account_id = $1
+		  AND je.entry_date >= $2 AND je.entry_date <= $3
+		ORDER BY je.entry_date, je.created_at, jel.created_at
+	`
+
+	rows, err := conn.Query(ctx, query, accountID, fromDate, toDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account statement: %w", err)
+	}
+	defer rows.Close()
+
+	balance := statement.OpeningBalance
+	for rows.Next() {
+		line := &AccountStatementLine{}
+		err := rows.Scan(
+			&line.JournalEntryID,
+			&line.LineID,
+			&line.EntryDate,
+			&line.ReferenceNumber,
+			&line.Description,
+			&line.Debit,
+			&line.Credit,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account statement line: %w", err)
+		}
+		balance = balance.Add(line.Debit).Sub(line.Credit)
+		line.Balance = balance
+		statement.Lines = append(statement.Lines, line)
+	}
+	statement.ClosingBalance = balance
+
+	return statement, nil
+}
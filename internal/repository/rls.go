@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// inheritedRLSTables are tenant tables without a tenant_id column, whose
+// policies admit the rows of parents visible to the tenant
+var inheritedRLSTables = []string{"journal_entry_lines", "account_balances"}
+
+// crossTenantTables have a tenant_id column but are read across tenants by
+// the admin API, so they are not isolated
+var crossTenantTables = []string{"consolidation_group_members"}
+
+// RLSTable is the row-level security state of one tenant table
+type RLSTable struct {
+	Name string
+	// Enabled and Forced report ENABLE and FORCE ROW LEVEL SECURITY; without
+	// FORCE the owner of the table bypasses its policies
+	Enabled bool
+	Forced  bool
+	// OwnedByRole is set when the connected role owns the table
+	OwnedByRole bool
+	// HasTenantID is set for tables isolated by their own tenant_id column
+	HasTenantID bool
+	Policies    int
+	// TenantPolicy is set when a policy checks app.current_tenant_id
+	TenantPolicy bool
+	// Leaked is set when a transaction for a tenant that does not exist saw
+	// rows of the table
+	Leaked bool
+}
+
+// RLSVerification is the outcome of checking that row-level security
+// isolates tenants
+type RLSVerification struct {
+	// Role is the role the ledger connects as
+	Role string
+	// BypassesRLS is set when the role is a superuser or has BYPASSRLS, so
+	// no policy applies to it
+	BypassesRLS bool
+	// TenantSettingApplied is set when app.current_tenant_id holds the
+	// tenant inside a tenant transaction
+	TenantSettingApplied bool
+	Tables               []*RLSTable
+	// Problems describes every way tenants are not isolated; empty when
+	// they are
+	Problems []string
+}
+
+// OK reports whether no problems were found
+func (v *RLSVerification) OK() bool {
+	return len(v.Problems) == 0
+}
+
+// VerifyRowLevelSecurity checks that row-level security isolates every
+// tenant table: the role does not bypass it, each table with a tenant_id
+// column or inheriting its isolation has it enabled with policies, tables
+// the role owns force it, and a transaction for a tenant that does not exist
+// sees app.current_tenant_id set and no rows of the tables with a tenant_id
+// column. Tables that inherit their isolation are not probed, as their
+// policies look up a parent for every row.
+func (r *SchemaRepository) VerifyRowLevelSecurity(ctx context.Context) (*RLSVerification, error) {
+	result := &RLSVerification{Tables: make([]*RLSTable, 0)}
+
+	err := r.db.Pool().QueryRow(ctx, `
+		SELECT current_user, rolsuper OR rolbypassrls
+		FROM pg_roles
+		WHERE rolname = current_user
+	`).Scan(&result.Role, &result.BypassesRLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database role: %w", err)
+	}
+	if result.BypassesRLS {
+		result.Problems = append(result.Problems, fmt.Sprintf("role %s bypasses row level security", result.Role))
+	}
+
+	rows, err := r.db.Pool().Query(ctx, `
+		SELECT c.relname, c.relrowsecurity, c.relforcerowsecurity,
+		       pg_get_userbyid(c.relowner) = current_user,
+		       t.has_tenant_id,
+		       (SELECT COUNT(*) FROM pg_policies p WHERE p.schemaname = n.nspname AND p.tablename = c.relname),
+		       EXISTS (
+		           SELECT 1 FROM pg_policies p
+		           WHERE p.schemaname = n.nspname AND p.tablename = c.relname
+		             AND (p.qual LIKE '%app.current_tenant_id%' OR p.with_check LIKE '%app.current_tenant_id%')
+		       )
+		FROM pg_class c
+		INNER JOIN pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN LATERAL (
+			SELECT EXISTS (
+				SELECT 1 FROM pg_attribute a
+				WHERE a.attrelid = c.oid AND a.attname = 'tenant_id' AND NOT a.attisdropped
+			) AS has_tenant_id
+		) t
+		WHERE n.nspname = current_schema()
+		  AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+		  AND (t.has_tenant_id OR c.relrowsecurity OR c.relname = ANY($1))
+		  AND NOT c.relname = ANY($2)
+		ORDER BY c.relname
+	`, inheritedRLSTables, crossTenantTables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant tables: %w", err)
+	}
+
+	found := make(map[string]bool)
+	for rows.Next() {
+		table := &RLSTable{}
+		err := rows.Scan(
+			&table.Name,
+			&table.Enabled,
+			&table.Forced,
+			&table.OwnedByRole,
+			&table.HasTenantID,
+			&table.Policies,
+			&table.TenantPolicy,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan tenant table: %w", err)
+		}
+		found[table.Name] = true
+		result.Tables = append(result.Tables, table)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tenant tables: %w", err)
+	}
+
+	for _, name := range inheritedRLSTables {
+		if !found[name] {
+			result.Problems = append(result.Problems, fmt.Sprintf("tenant table %s does not exist", name))
+		}
+	}
+
+	if err := r.probeTenantIsolation(ctx, result); err != nil {
+		return nil, err
+	}
+
+	for _, table := range result.Tables {
+		switch {
+		case !table.Enabled:
+			result.Problems = append(result.Problems, fmt.Sprintf("row level security is disabled on %s", table.Name))
+		case table.Policies == 0:
+			result.Problems = append(result.Problems, fmt.Sprintf("%s has no row level security policies", table.Name))
+		case table.HasTenantID && !table.TenantPolicy:
+			result.Problems = append(result.Problems, fmt.Sprintf("no policy on %s checks app.current_tenant_id", table.Name))
+		case table.OwnedByRole && !table.Forced:
+			result.Problems = append(result.Problems, fmt.Sprintf("%s is owned by role %s and does not force row level security", table.Name, result.Role))
+		}
+		if table.Leaked {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s shows rows of other tenants", table.Name))
+		}
+	}
+
+	return result, nil
+}
+
+// probeTenantIsolation opens a transaction for a tenant that does not exist
+// and checks that app.current_tenant_id holds it and that the tables with a
+// tenant_id column show no rows. The transaction is rolled back.
+func (r *SchemaRepository) probeTenantIsolation(ctx context.Context, result *RLSVerification) error {
+	probeID := uuid.New()
+	tx, err := r.db.BeginTx(ctx, probeID.String())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var setting string
+	err = tx.QueryRow(ctx, "SELECT COALESCE(current_setting('app.current_tenant_id', true), '')").Scan(&setting)
+	if err != nil {
+		return fmt.Errorf("failed to read tenant setting: %w", err)
+	}
+	result.TenantSettingApplied = setting == probeID.String()
+	if !result.TenantSettingApplied {
+		result.Problems = append(result.Problems, "app.current_tenant_id is not set inside tenant transactions")
+	}
+
+	for _, table := range result.Tables {
+		if !table.HasTenantID {
+			continue
+		}
+		query := "SELECT EXISTS (SELECT 1 FROM " + pgx.Identifier{table.Name}.Sanitize() + ")"
+		if err := tx.QueryRow(ctx, query).Scan(&table.Leaked); err != nil {
+			return fmt.Errorf("failed to probe %s: %w", table.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/db"
+)
+
+// SchemaMigration represents an applied schema migration
+type SchemaMigration struct {
+	Version     string
+	Description string
+	ExecutedAt  time.Time
+}
+
+// SchemaRepository reads schema migration metadata
+type SchemaRepository struct {
+	db *db.DB
+}
+
+// NewSchemaRepository creates a new schema repository
+func NewSchemaRepository(database *db.DB) *SchemaRepository {
+	return &SchemaRepository{db: database}
+}
+
+// ListMigrations retrieves the migrations applied by Atlas, oldest first
+func (r *SchemaRepository) ListMigrations(ctx context.Context) ([]*SchemaMigration, error) {
+	query := `
+		SELECT version, description, executed_at
+		FROM atlas_schema_revisions.atlas_schema_revisions
+		ORDER BY version
+	`
+
+	rows, err := r.db.Pool().Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schema migrations: %w", err)
+	}
+	defer rows.Close()
+
+	migrations := make([]*SchemaMigration, 0)
+	for rows.Next() {
+		migration := &SchemaMigration{}
+		err := rows.Scan(
+			&migration.Version,
+			&migration.Description,
+			&migration.ExecutedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schema migration: %w", err)
+		}
+		migrations = append(migrations, migration)
+	}
+
+	return migrations, nil
+}
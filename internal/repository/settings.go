@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// Default tenant settings used until a tenant stores its own
+const (
+	DefaultTimezone = "UTC"
+	DefaultLocale   = "en-US"
+	DefaultCalendar = CalendarGregorian
+)
+
+// Calendars a tenant can reckon months and periods in
+const (
+	CalendarGregorian = "GREGORIAN"
+	CalendarJalali    = "JALALI"
+)
+
+// TenantSettings represents tenant-wide conventions such as base currency and timezone
+type TenantSettings struct {
+	TenantID     uuid.UUID
+	BaseCurrency string
+	Timezone     string
+	Locale       string
+	// Calendar is CalendarGregorian or CalendarJalali
+	Calendar string
+	// FxGainAccountID and FxLossAccountID receive the realized exchange
+	// differences posted when foreign-currency documents are settled
+	FxGainAccountID *uuid.UUID
+	FxLossAccountID *uuid.UUID
+	// FxConversionAccounts holds the account of each currency, by code, that
+	// cross-currency transfers convert through
+	FxConversionAccounts map[string]uuid.UUID
+	// OverdraftLimits holds the overdraft limit of each account type, by
+	// code, for the accounts of that type without a limit of their own, so
+	// a class of accounts such as customer wallets can be kept from going
+	// negative without setting a limit on each
+	OverdraftLimits map[string]decimal.Decimal
+	// EncryptMetadata seals the metadata of new journal entries with the
+	// tenant's key, for tenants storing personal data in it
+	EncryptMetadata bool
+	UpdatedAt       time.Time
+}
+
+// TenantSettingsRepository handles tenant settings database operations
+type TenantSettingsRepository struct {
+	db *db.DB
+}
+
+// NewTenantSettingsRepository creates a new tenant settings repository
+func NewTenantSettingsRepository(database *db.DB) *TenantSettingsRepository {
+	return &TenantSettingsRepository{db: database}
+}
+
+// Get retrieves the settings of a tenant, falling back to defaults when none are stored
+func (r *TenantSettingsRepository) Get(ctx context.Context, tenantID uuid.UUID) (*TenantSettings, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	return getTenantSettings(ctx, conn, tenantID)
+}
+
+// singleRowQuerier is implemented by pooled connections and tenant
+// transactions
+type singleRowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// getTenantSettings reads the settings of a tenant on q, with the defaults
+// when the tenant has none stored
+func getTenantSettings(ctx context.Context, q singleRowQuerier, tenantID uuid.UUID) (*TenantSettings, error) {
+	settings := &TenantSettings{TenantID: tenantID}
+	query := `
+		SELECT base_currency, timezone, locale, calendar, fx_gain_account_id, fx_loss_account_id,
+		       fx_conversion_accounts, overdraft_limits, encrypt_metadata, updated_at
+		FROM tenant_settings
+		WHERE tenant_id = $1
+	`
+
+	err := q.QueryRow(ctx, query, tenantID).Scan(
+		&settings.BaseCurrency,
+		&settings.Timezone,
+		&settings.Locale,
+		&settings.Calendar,
+		&settings.FxGainAccountID,
+		&settings.FxLossAccountID,
+		&settings.FxConversionAccounts,
+		&settings.OverdraftLimits,
+		&settings.EncryptMetadata,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			settings.Timezone = DefaultTimezone
+			settings.Locale = DefaultLocale
+			settings.Calendar = DefaultCalendar
+			return settings, nil
+		}
+		return nil, fmt.Errorf("failed to get tenant settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// tenantToday returns the current day in the timezone of a tenant as
+// midnight UTC, the form entry dates are stored in, to date the entries
+// posted on the tenant's behalf
+func tenantToday(ctx context.Context, q singleRowQuerier, tenantID uuid.UUID) (time.Time, error) {
+	settings, err := getTenantSettings(ctx, q, tenantID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid tenant timezone %q: %w", settings.Timezone, err)
+	}
+
+	year, month, day := time.Now().In(location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), nil
+}
+
+// Upsert stores the settings of a tenant
+func (r *TenantSettingsRepository) Upsert(ctx context.Context, settings *TenantSettings) (*TenantSettings, error) {
+	tx, err := r.db.BeginTx(ctx, settings.TenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	stored, err := upsertTenantSettings(ctx, tx, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return stored, nil
+}
+
+// upsertTenantSettings stores the settings of a tenant inside an open
+// transaction and records a TenantSettingsUpdated event
+func upsertTenantSettings(ctx context.Context, tx *db.TenantTx, settings *TenantSettings) (*TenantSettings, error) {
+	// The columns are JSONB objects, never null
+	conversionAccounts := settings.FxConversionAccounts
+	if conversionAccounts == nil {
+		conversionAccounts = map[string]uuid.UUID{}
+	}
+	overdraftLimits := settings.OverdraftLimits
+	if overdraftLimits == nil {
+		overdraftLimits = map[string]decimal.Decimal{}
+	}
+
+	stored := &TenantSettings{TenantID: settings.TenantID}
+	query := `
+		INSERT INTO tenant_settings (
+			tenant_id, base_currency, timezone, locale, calendar, fx_gain_account_id, fx_loss_account_id,
+			fx_conversion_accounts, overdraft_limits, encrypt_metadata
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (tenant_id) DO UPDATE
+		SET base_currency = EXCLUDED.base_currency,
+		    timezone = EXCLUDED.timezone,
+		    locale = EXCLUDED.locale,
+		    calendar = EXCLUDED.calendar,
+		    fx_gain_account_id = EXCLUDED.fx_gain_account_id,
+		    fx_loss_account_id = EXCLUDED.fx_loss_account_id,
+		    fx_conversion_accounts = EXCLUDED.fx_conversion_accounts,
+		    overdraft_limits = EXCLUDED.overdraft_limits,
+		    encrypt_metadata = EXCLUDED.encrypt_metadata,
+		    updated_at = NOW()
+		RETURNING base_currency, timezone, locale, calendar, fx_gain_account_id, fx_loss_account_id,
+		          fx_conversion_accounts, overdraft_limits, encrypt_metadata, updated_at
+	`
+
+	err := tx.QueryRow(ctx, query,
+		settings.TenantID,
+		settings.BaseCurrency,
+		settings.Timezone,
+		settings.Locale,
+		settings.Calendar,
+		settings.FxGainAccountID,
+		settings.FxLossAccountID,
+		conversionAccounts,
+		overdraftLimits,
+		settings.EncryptMetadata,
+	).Scan(
+		&stored.BaseCurrency,
+		&stored.Timezone,
+		&stored.Locale,
+		&stored.Calendar,
+		&stored.FxGainAccountID,
+		&stored.FxLossAccountID,
+		&stored.FxConversionAccounts,
+		&stored.OverdraftLimits,
+		&stored.EncryptMetadata,
+		&stored.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert tenant settings: %w", err)
+	}
+
+	err = appendEvent(ctx, tx, AggregateTenant, settings.TenantID, EventTenantSettingsUpdated, TenantSettingsUpdatedPayload{
+		BaseCurrency:         stored.BaseCurrency,
+		Timezone:             stored.Timezone,
+		Locale:               stored.Locale,
+		Calendar:             stored.Calendar,
+		FxGainAccountID:      stored.FxGainAccountID,
+		FxLossAccountID:      stored.FxLossAccountID,
+		FxConversionAccounts: stored.FxConversionAccounts,
+		OverdraftLimits:      stored.OverdraftLimits,
+		EncryptMetadata:      stored.EncryptMetadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stored, nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// BankStatement represents an imported bank statement for a ledger account
+type BankStatement struct {
+	ID         uuid.UUID
+	TenantID   uuid.UUID
+	AccountID  uuid.UUID
+	Format     string
+	Reference  string
+	LineCount  int
+	ImportedAt time.Time
+}
+
+// ImportStatementParams holds parameters for importing a bank statement
+type ImportStatementParams struct {
+	AccountID uuid.UUID
+	Format    string
+	Reference string
+	Lines     []*CreateStatementLineParams
+}
+
+// CreateStatementLineParams holds parameters for creating a statement line
+type CreateStatementLineParams struct {
+	PostedAt    time.Time
+	Amount      decimal.Decimal
+	Description string
+	Reference   string
+}
+
+// StatementRepository handles bank statement database operations
+type StatementRepository struct {
+	db *db.DB
+}
+
+// NewStatementRepository creates a new statement repository
+func NewStatementRepository(database *db.DB) *StatementRepository {
+	return &StatementRepository{db: database}
+}
+
+// Import stores a bank statement together with all of its lines
+func (r *StatementRepository) Import(ctx context.Context, tenantID uuid.UUID, params ImportStatementParams) (*BankStatement, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	statement := &BankStatement{
+		TenantID:  tenantID,
+		AccountID: params.AccountID,
+		Format:    params.Format,
+		Reference: params.Reference,
+		LineCount: len(params.Lines),
+	}
+	query := `
+		INSERT INTO bank_statements (tenant_id, account_id, format, reference, line_count)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, imported_at
+	`
+
+	err = tx.QueryRow(ctx, query,
+		tenantID,
+		params.AccountID,
+		params.Format,
+		params.Reference,
+		len(params.Lines),
+	).Scan(&statement.ID, &statement.ImportedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bank statement: %w", err)
+	}
+
+	// Lines are loaded with COPY, a statement can have thousands of them
+	rows := make([][]interface{}, len(params.Lines))
+	for i, line := range params.Lines {
+		rows[i] = []interface{}{
+			tenantID,
+			statement.ID,
+			params.AccountID,
+			line.PostedAt,
+			line.Amount,
+			line.Description,
+			line.Reference,
+		}
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"bank_statement_lines"},
+		[]string{"tenant_id", "statement_id", "account_id", "posted_at", "amount", "description", "reference"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create statement lines: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return statement, nil
+}
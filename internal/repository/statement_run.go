@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// Statement run statuses
+const (
+	StatementRunPending   = "PENDING"
+	StatementRunRunning   = "RUNNING"
+	StatementRunCompleted = "COMPLETED"
+	StatementRunFailed    = "FAILED"
+)
+
+// StatementRunParams selects the statements of a run: one per account, and
+// one per party drawn up for PartyAccountID
+type StatementRunParams struct {
+	AccountIDs     []uuid.UUID
+	PartyIDs       []uuid.UUID
+	PartyAccountID *uuid.UUID
+	FromDate       time.Time
+	ToDate         time.Time
+}
+
+// DeliveredStatement is a statement written by a run, stored under File
+type DeliveredStatement struct {
+	AccountID uuid.UUID  `json:"account_id"`
+	PartyID   *uuid.UUID `json:"party_id,omitempty"`
+	File      string     `json:"file"`
+}
+
+// StatementRun represents a background run generating PDF statements for a
+// period
+type StatementRun struct {
+	ID             uuid.UUID
+	TenantID       uuid.UUID
+	AccountIDs     []uuid.UUID
+	PartyIDs       []uuid.UUID
+	PartyAccountID *uuid.UUID
+	FromDate       time.Time
+	ToDate         time.Time
+	Status         string
+	Statements     []DeliveredStatement
+	Error          *string
+	CreatedAt      time.Time
+	CompletedAt    *time.Time
+}
+
+const statementRunColumns = `id, tenant_id, account_ids, party_ids, party_account_id, from_date, to_date,
+	status, statements, error, created_at, completed_at`
+
+func scanStatementRun(row pgx.Row, run *StatementRun) error {
+	return row.Scan(
+		&run.ID,
+		&run.TenantID,
+		&run.AccountIDs,
+		&run.PartyIDs,
+		&run.PartyAccountID,
+		&run.FromDate,
+		&run.ToDate,
+		&run.Status,
+		&run.Statements,
+		&run.Error,
+		&run.CreatedAt,
+		&run.CompletedAt,
+	)
+}
+
+// StatementRunRepository handles statement run database operations
+type StatementRunRepository struct {
+	db *db.DB
+}
+
+// NewStatementRunRepository creates a new statement run repository
+func NewStatementRunRepository(database *db.DB) *StatementRunRepository {
+	return &StatementRunRepository{db: database}
+}
+
+// Create creates a pending statement run
+func (r *StatementRunRepository) Create(ctx context.Context, tenantID uuid.UUID, params StatementRunParams) (*StatementRun, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	accountIDs, partyIDs := params.AccountIDs, params.PartyIDs
+	if accountIDs == nil {
+		accountIDs = []uuid.UUID{}
+	}
+	if partyIDs == nil {
+		partyIDs = []uuid.UUID{}
+	}
+
+	run := &StatementRun{}
+	query := `
+		INSERT INTO statement_runs (tenant_id, account_ids, party_ids, party_account_id, from_date, to_date, status, statements)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, '[]')
+		RETURNING ` + statementRunColumns
+
+	err = scanStatementRun(tx.QueryRow(ctx, query, tenantID, accountIDs, partyIDs, params.PartyAccountID,
+		params.FromDate, params.ToDate, StatementRunPending), run)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create statement run: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return run, nil
+}
+
+// GetByID retrieves a statement run
+func (r *StatementRunRepository) GetByID(ctx context.Context, tenantID uuid.UUID, runID uuid.UUID) (*StatementRun, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	run := &StatementRun{}
+	query := `SELECT ` + statementRunColumns + ` FROM statement_runs WHERE id = $1`
+
+	if err := scanStatementRun(conn.QueryRow(ctx, query, runID), run); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("statement run %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get statement run: %w", err)
+	}
+
+	return run, nil
+}
+
+// UpdateStatus records the progress of a statement run. Completed and failed
+// runs also get their completion time set.
+func (r *StatementRunRepository) UpdateStatus(ctx context.Context, tenantID uuid.UUID, runID uuid.UUID, status string, statements []DeliveredStatement, errMsg *string) error {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if statements == nil {
+		statements = []DeliveredStatement{}
+	}
+
+	query := `
+		UPDATE statement_runs
+		SET status = $2, statements = $3, error = $4,
+		    completed_at = CASE WHEN $2 IN ('COMPLETED', 'FAILED') THEN NOW() END
+		WHERE id = $1
+	`
+
+	if err := tx.Exec(ctx, query, runID, status, statements, errMsg); err != nil {
+		return fmt.Errorf("failed to update statement run: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,618 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// Sub-ledgers a document belongs to
+const (
+	SubledgerReceivable = "RECEIVABLE"
+	SubledgerPayable    = "PAYABLE"
+)
+
+// Sub-ledger document types
+const (
+	DocumentTypeInvoice         = "INVOICE"
+	DocumentTypeBill            = "BILL"
+	DocumentTypeCustomerPayment = "CUSTOMER_PAYMENT"
+	DocumentTypeVendorPayment   = "VENDOR_PAYMENT"
+)
+
+// Open-item statuses of a document
+const (
+	DocumentStatusOpen    = "OPEN"
+	DocumentStatusPartial = "PARTIAL"
+	DocumentStatusSettled = "SETTLED"
+)
+
+// paymentSettles maps each payment type to the document type it can be applied to
+var paymentSettles = map[string]string{
+	DocumentTypeCustomerPayment: DocumentTypeInvoice,
+	DocumentTypeVendorPayment:   DocumentTypeBill,
+}
+
+// SubledgerDocument represents an invoice, bill or payment tracked as an open
+// item. Its amounts are in CurrencyCode; FxRate converts them into the control
+// account currency when the two differ.
+type SubledgerDocument struct {
+	ID               uuid.UUID
+	TenantID         uuid.UUID
+	Type             string
+	Ledger           string
+	Number           string
+	Party            string
+	ControlAccountID uuid.UUID
+	CounterAccountID uuid.UUID
+	Amount           decimal.Decimal
+	OpenAmount       decimal.Decimal
+	CurrencyCode     string
+	FxRate           *decimal.Decimal
+	Status           string
+	DocumentDate     time.Time
+	DueDate          *time.Time
+	Description      string
+	JournalEntryID   uuid.UUID
+	Applications     []*DocumentApplication
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// DocumentApplication represents part of a payment applied to an invoice or bill
+type DocumentApplication struct {
+	ID         uuid.UUID
+	PaymentID  uuid.UUID
+	DocumentID uuid.UUID
+	Amount     decimal.Decimal
+	AppliedAt  time.Time
+	// FxGainLoss is the realized exchange gain (positive) or loss
+	// (negative) of an application between documents booked at different
+	// rates, posted by the entry FxJournalEntryID
+	FxGainLoss       decimal.Decimal
+	FxJournalEntryID *uuid.UUID
+}
+
+// CreateDocumentParams holds parameters for creating a sub-ledger document
+// together with the journal entry it posts
+type CreateDocumentParams struct {
+	Type             string
+	Ledger           string
+	Number           string
+	Party            string
+	ControlAccountID uuid.UUID
+	CounterAccountID uuid.UUID
+	Amount           decimal.Decimal
+	CurrencyCode     string
+	FxRate           *decimal.Decimal
+	DocumentDate     time.Time
+	DueDate          *time.Time
+	Description      string
+	Entry            CreateJournalEntryParams
+}
+
+// DocumentFilter holds filters for listing sub-ledger documents
+type DocumentFilter struct {
+	Ledger   *string
+	Party    *string
+	OpenOnly bool
+}
+
+const documentColumns = `id, tenant_id, document_type, ledger, number, party, control_account_id,
+		       counter_account_id, amount, open_amount, currency_code, fx_rate, status, document_date,
+		       due_date, description, journal_entry_id, created_at, updated_at`
+
+const applicationColumns = `id, payment_id, document_id, amount, applied_at, fx_gain_loss, fx_journal_entry_id`
+
+func scanDocument(row pgx.Row, doc *SubledgerDocument) error {
+	return row.Scan(
+		&doc.ID,
+		&doc.TenantID,
+		&doc.Type,
+		&doc.Ledger,
+		&doc.Number,
+		&doc.Party,
+		&doc.ControlAccountID,
+		&doc.CounterAccountID,
+		&doc.Amount,
+		&doc.OpenAmount,
+		&doc.CurrencyCode,
+		&doc.FxRate,
+		&doc.Status,
+		&doc.DocumentDate,
+		&doc.DueDate,
+		&doc.Description,
+		&doc.JournalEntryID,
+		&doc.CreatedAt,
+		&doc.UpdatedAt,
+	)
+}
+
+func scanApplication(row pgx.Row, app *DocumentApplication) error {
+	return row.Scan(
+		&app.ID,
+		&app.PaymentID,
+		&app.DocumentID,
+		&app.Amount,
+		&app.AppliedAt,
+		&app.FxGainLoss,
+		&app.FxJournalEntryID,
+	)
+}
+
+// adjustOpenAmountQuery reduces the open amount of a document by $2 and
+// recomputes its status; a negative amount reopens the document
+const adjustOpenAmountQuery = `
+	UPDATE subledger_documents
+	SET open_amount = open_amount - $2,
+	    status = CASE
+	        WHEN open_amount - $2 = 0 THEN 'SETTLED'
+	        WHEN open_amount - $2 = amount THEN 'OPEN'
+	        ELSE 'PARTIAL'
+	    END,
+	    updated_at = NOW()
+	WHERE id = $1
+`
+
+// SubledgerRepository handles receivable and payable document operations
+type SubledgerRepository struct {
+	db *db.DB
+}
+
+// NewSubledgerRepository creates a new sub-ledger repository
+func NewSubledgerRepository(database *db.DB) *SubledgerRepository {
+	return &SubledgerRepository{db: database}
+}
+
+// CreateDocument posts the document's journal entry and stores the document as
+// an open item in a single transaction
+func (r *SubledgerRepository) CreateDocument(ctx context.Context, tenantID uuid.UUID, params CreateDocumentParams) (*SubledgerDocument, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	journalEntryID, err := insertJournalEntry(ctx, tx, params.Entry)
+	if err != nil {
+		return nil, err
+	}
+
+	var documentID uuid.UUID
+	query := `
+		INSERT INTO subledger_documents (
+			tenant_id, document_type, ledger, number, party, control_account_id, counter_account_id,
+			amount, open_amount, currency_code, fx_rate, status, document_date, due_date, description,
+			journal_entry_id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id
+	`
+
+	err = tx.QueryRow(ctx, query,
+		tenantID,
+		params.Type,
+		params.Ledger,
+		params.Number,
+		params.Party,
+		params.ControlAccountID,
+		params.CounterAccountID,
+		params.Amount,
+		params.CurrencyCode,
+		params.FxRate,
+		DocumentStatusOpen,
+		params.DocumentDate,
+		params.DueDate,
+		params.Description,
+		journalEntryID,
+	).Scan(&documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create document: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetDocument(ctx, tenantID, documentID)
+}
+
+// GetDocument retrieves a document with the applications made against it
+func (r *SubledgerRepository) GetDocument(ctx context.Context, tenantID uuid.UUID, documentID uuid.UUID) (*SubledgerDocument, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	doc := &SubledgerDocument{}
+	query := `SELECT ` + documentColumns + ` FROM subledger_documents WHERE id = $1`
+
+	if err := scanDocument(conn.QueryRow(ctx, query, documentID), doc); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("document %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	applications, err := r.getApplicationsByDocumentID(ctx, conn, documentID)
+	if err != nil {
+		return nil, err
+	}
+	doc.Applications = applications
+
+	return doc, nil
+}
+
+// ListDocuments retrieves documents without their applications, oldest first
+func (r *SubledgerRepository) ListDocuments(ctx context.Context, tenantID uuid.UUID, filter DocumentFilter, limit, offset int) ([]*SubledgerDocument, int, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	argCount := 0
+
+	if filter.Ledger != nil {
+		argCount++
+		where += fmt.Sprintf(" AND ledger = $%d", argCount)
+		args = append(args, *filter.Ledger)
+	}
+
+	if filter.Party != nil {
+		argCount++
+		where += fmt.Sprintf(" AND party = $%d", argCount)
+		args = append(args, *filter.Party)
+	}
+
+	if filter.OpenOnly {
+		argCount++
+		where += fmt.Sprintf(" AND status <> $%d", argCount)
+		args = append(args, DocumentStatusSettled)
+	}
+
+	var totalCount int
+	if err := conn.QueryRow(ctx, "SELECT COUNT(*) FROM subledger_documents"+where, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	query := `SELECT ` + documentColumns + ` FROM subledger_documents` + where +
+		fmt.Sprintf(" ORDER BY document_date, number LIMIT $%d OFFSET $%d", argCount+1, argCount+2)
+	args = append(args, limit, offset)
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list documents: %w", err)
+	}
+	defer rows.Close()
+
+	docs := make([]*SubledgerDocument, 0)
+	for rows.Next() {
+		doc := &SubledgerDocument{}
+		if err := scanDocument(rows, doc); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, totalCount, nil
+}
+
+// Apply applies part of a payment to an invoice or bill, reducing the open
+// amount of both documents. When the two were booked at different exchange
+// rates, the realized gain or loss is posted to the tenant's exchange
+// difference accounts in the same transaction.
+func (r *SubledgerRepository) Apply(ctx context.Context, tenantID uuid.UUID, paymentID, documentID uuid.UUID, amount decimal.Decimal) (*DocumentApplication, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Take the tenant journal lock before the documents, in the order merges
+	// take them, so the two cannot deadlock
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	// Lock both documents in a stable order so concurrent applications cannot deadlock
+	query := `
+		SELECT ` + documentColumns + `
+		FROM subledger_documents
+		WHERE id = ANY($1)
+		ORDER BY id
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(ctx, query, []uuid.UUID{paymentID, documentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock documents: %w", err)
+	}
+
+	locked := make(map[uuid.UUID]*SubledgerDocument, 2)
+	for rows.Next() {
+		doc := &SubledgerDocument{}
+		if err := scanDocument(rows, doc); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		locked[doc.ID] = doc
+	}
+	rows.Close()
+
+	payment, ok := locked[paymentID]
+	if !ok {
+		return nil, fmt.Errorf("payment %w", ErrNotFound)
+	}
+	document, ok := locked[documentID]
+	if !ok {
+		return nil, fmt.Errorf("document %w", ErrNotFound)
+	}
+
+	if paymentSettles[payment.Type] != document.Type ||
+		payment.Party != document.Party ||
+		payment.ControlAccountID != document.ControlAccountID ||
+		payment.CurrencyCode != document.CurrencyCode {
+		return nil, ErrApplicationMismatch
+	}
+
+	if amount.GreaterThan(payment.OpenAmount) || amount.GreaterThan(document.OpenAmount) {
+		return nil, ErrOverApplication
+	}
+
+	// The difference is posted to the control account, in its currency
+	var precision int32
+	err = tx.QueryRow(ctx, `
+		SELECT c.precision
+		FROM accounts a
+		JOIN currencies c ON c.code = a.currency_code
+		WHERE a.id = $1
+	`, document.ControlAccountID).Scan(&precision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get control account currency: %w", err)
+	}
+
+	gainLoss := realizedFxGainLoss(document.Ledger, amount, payment.FxRate, document.FxRate, precision)
+	var fxJournalEntryID *uuid.UUID
+	if !gainLoss.IsZero() {
+		entryID, err := postFxGainLoss(ctx, tx, payment, document, gainLoss)
+		if err != nil {
+			return nil, err
+		}
+		fxJournalEntryID = &entryID
+	}
+
+	app := &DocumentApplication{}
+	insertQuery := `
+		INSERT INTO subledger_applications (tenant_id, payment_id, document_id, amount, fx_gain_loss, fx_journal_entry_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + applicationColumns
+
+	row := tx.QueryRow(ctx, insertQuery, tenantID, paymentID, documentID, amount, gainLoss, fxJournalEntryID)
+	if err := scanApplication(row, app); err != nil {
+		return nil, fmt.Errorf("failed to create application: %w", err)
+	}
+
+	for _, id := range []uuid.UUID{paymentID, documentID} {
+		if err := tx.Exec(ctx, adjustOpenAmountQuery, id, amount); err != nil {
+			return nil, fmt.Errorf("failed to update open amount: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return app, nil
+}
+
+// Unapply removes an application and restores the open amounts of its
+// payment and document, reversing the exchange difference it posted
+func (r *SubledgerRepository) Unapply(ctx context.Context, tenantID uuid.UUID, applicationID uuid.UUID) (*DocumentApplication, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Take the tenant journal lock before the application row, whose exchange
+	// difference may be reversed, in the order merges take them
+	if err := lockTenantJournal(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	app := &DocumentApplication{}
+	query := `DELETE FROM subledger_applications WHERE id = $1 RETURNING ` + applicationColumns
+
+	if err := scanApplication(tx.QueryRow(ctx, query, applicationID), app); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("application %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to delete application: %w", err)
+	}
+
+	if app.FxJournalEntryID != nil {
+		if err := reverseFxGainLoss(ctx, tx, tenantID, *app.FxJournalEntryID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range []uuid.UUID{app.PaymentID, app.DocumentID} {
+		if err := tx.Exec(ctx, adjustOpenAmountQuery, id, app.Amount.Neg()); err != nil {
+			return nil, fmt.Errorf("failed to update open amount: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return app, nil
+}
+
+// getApplicationsByDocumentID retrieves the applications a document takes part in,
+// either as the payment or as the settled document
+func (r *SubledgerRepository) getApplicationsByDocumentID(ctx context.Context, conn *pgxpool.Conn, documentID uuid.UUID) ([]*DocumentApplication, error) {
+	query := `
+		SELECT ` + applicationColumns + `
+		FROM subledger_applications
+		WHERE payment_id = $1 OR document_id = $1
+		ORDER BY applied_at
+	`
+
+	rows, err := conn.Query(ctx, query, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applications: %w", err)
+	}
+	defer rows.Close()
+
+	applications := make([]*DocumentApplication, 0)
+	for rows.Next() {
+		app := &DocumentApplication{}
+		if err := scanApplication(rows, app); err != nil {
+			return nil, fmt.Errorf("failed to scan application: %w", err)
+		}
+		applications = append(applications, app)
+	}
+
+	return applications, nil
+}
+
+// realizedFxGainLoss returns the exchange gain (positive) or loss (negative)
+// realized when amount of a document booked at documentRate is settled by a
+// payment booked at paymentRate; a nil rate is 1. Both converted amounts are
+// rounded to precision, the decimal places of the control account currency,
+// as they were when posted. A receivable gains when the payment is worth
+// more than the invoice was, a payable when it is worth less than the bill.
+func realizedFxGainLoss(ledger string, amount decimal.Decimal, paymentRate, documentRate *decimal.Decimal, precision int32) decimal.Decimal {
+	convert := func(r *decimal.Decimal) decimal.Decimal {
+		if r == nil {
+			return amount.Round(precision)
+		}
+		return amount.Mul(*r).Round(precision)
+	}
+
+	difference := convert(paymentRate).Sub(convert(documentRate))
+	if ledger == SubledgerPayable {
+		return difference.Neg()
+	}
+	return difference
+}
+
+// fxGainLossLines builds the entry clearing an exchange difference from the
+// control account: a gain debits the control account and credits the gain
+// account, a loss debits the loss account and credits the control account
+func fxGainLossLines(controlAccountID uuid.UUID, gainAccountID, lossAccountID *uuid.UUID, gainLoss decimal.Decimal, description string) ([]*CreateJournalEntryLineParams, error) {
+	var debitAccountID, creditAccountID uuid.UUID
+	switch {
+	case gainLoss.IsPositive() && gainAccountID != nil:
+		debitAccountID, creditAccountID = controlAccountID, *gainAccountID
+	case gainLoss.IsNegative() && lossAccountID != nil:
+		debitAccountID, creditAccountID = *lossAccountID, controlAccountID
+	default:
+		return nil, ErrFxAccountNotConfigured
+	}
+
+	amount := gainLoss.Abs()
+	return []*CreateJournalEntryLineParams{
+		{AccountID: debitAccountID, Debit: amount, Credit: decimal.Zero, Description: description},
+		{AccountID: creditAccountID, Debit: decimal.Zero, Credit: amount, Description: description},
+	}, nil
+}
+
+// postFxGainLoss posts the exchange difference of an application to the
+// tenant's gain or loss account inside the application's transaction
+func postFxGainLoss(ctx context.Context, tx *db.TenantTx, payment, document *SubledgerDocument, gainLoss decimal.Decimal) (uuid.UUID, error) {
+	var gainAccountID, lossAccountID *uuid.UUID
+	err := tx.QueryRow(ctx, `
+		SELECT fx_gain_account_id, fx_loss_account_id
+		FROM tenant_settings
+		WHERE tenant_id = $1
+	`, document.TenantID).Scan(&gainAccountID, &lossAccountID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("failed to get exchange difference accounts: %w", err)
+	}
+
+	description := "Realized exchange gain on " + document.Number
+	if gainLoss.IsNegative() {
+		description = "Realized exchange loss on " + document.Number
+	}
+
+	lines, err := fxGainLossLines(document.ControlAccountID, gainAccountID, lossAccountID, gainLoss, description)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// The difference is realized when the later of the two documents exists
+	entryDate := document.DocumentDate
+	if payment.DocumentDate.After(entryDate) {
+		entryDate = payment.DocumentDate
+	}
+
+	return insertJournalEntry(ctx, tx, CreateJournalEntryParams{
+		ReferenceNumber: document.Number,
+		Description:     description,
+		EntryDate:       entryDate,
+		Metadata: map[string]interface{}{
+			"payment_id":  payment.ID.String(),
+			"document_id": document.ID.String(),
+			"party":       document.Party,
+		},
+		Lines: lines,
+	})
+}
+
+// reverseFxGainLoss posts the reverse of an exchange difference entry, since
+// posted entries are never changed, dated today in the tenant's timezone
+func reverseFxGainLoss(ctx context.Context, tx *db.TenantTx, tenantID, journalEntryID uuid.UUID) error {
+	var referenceNumber, description string
+	err := tx.QueryRow(ctx, "SELECT reference_number, description FROM journal_entries WHERE id = $1", journalEntryID).
+		Scan(&referenceNumber, &description)
+	if err != nil {
+		return fmt.Errorf("failed to get exchange difference entry: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, "SELECT account_id, debit, credit FROM journal_entry_lines WHERE journal_entry_id = $1", journalEntryID)
+	if err != nil {
+		return fmt.Errorf("failed to get exchange difference lines: %w", err)
+	}
+
+	description = "Reversal of " + description
+	lines := make([]*CreateJournalEntryLineParams, 0, 2)
+	for rows.Next() {
+		line := &CreateJournalEntryLineParams{Description: description}
+		if err := rows.Scan(&line.AccountID, &line.Credit, &line.Debit); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan exchange difference line: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get exchange difference lines: %w", err)
+	}
+
+	entryDate, err := tenantToday(ctx, tx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	_, err = insertJournalEntry(ctx, tx, CreateJournalEntryParams{
+		ReferenceNumber: referenceNumber,
+		Description:     description,
+		EntryDate:       entryDate,
+		Metadata:        map[string]interface{}{"reverses": journalEntryID.String()},
+		Lines:           lines,
+	})
+	return err
+}
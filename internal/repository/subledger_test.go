@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealizedFxGainLoss(t *testing.T) {
+	rate := func(s string) *decimal.Decimal {
+		r := decimal.RequireFromString(s)
+		return &r
+	}
+
+	tests := []struct {
+		name         string
+		ledger       string
+		paymentRate  *decimal.Decimal
+		documentRate *decimal.Decimal
+		want         string
+	}{
+		{"receivable paid at a higher rate", SubledgerReceivable, rate("1.15"), rate("1.1"), "5"},
+		{"receivable paid at a lower rate", SubledgerReceivable, rate("1.05"), rate("1.1"), "-5"},
+		{"payable paid at a higher rate", SubledgerPayable, rate("1.15"), rate("1.1"), "-5"},
+		{"payable paid at a lower rate", SubledgerPayable, rate("1.05"), rate("1.1"), "5"},
+		{"same rate", SubledgerReceivable, rate("1.1"), rate("1.1"), "0"},
+		{"ledger currency", SubledgerPayable, nil, nil, "0"},
+		{"rounds each converted amount", SubledgerReceivable, rate("1.23456"), rate("1.1"), "13.46"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := realizedFxGainLoss(tt.ledger, decimal.NewFromInt(100), tt.paymentRate, tt.documentRate, 2)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestFxGainLossLines(t *testing.T) {
+	controlID, gainID, lossID := uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("credits a gain to the gain account", func(t *testing.T) {
+		lines, err := fxGainLossLines(controlID, &gainID, &lossID, decimal.NewFromInt(5), "")
+		require.NoError(t, err)
+		assert.Equal(t, controlID, lines[0].AccountID)
+		assert.Equal(t, gainID, lines[1].AccountID)
+		assert.True(t, lines[1].Credit.Equal(decimal.NewFromInt(5)))
+		assert.NoError(t, validateJournalLines(lines, nil))
+	})
+
+	t.Run("debits a loss to the loss account", func(t *testing.T) {
+		lines, err := fxGainLossLines(controlID, &gainID, &lossID, decimal.NewFromInt(-5), "")
+		require.NoError(t, err)
+		assert.Equal(t, lossID, lines[0].AccountID)
+		assert.True(t, lines[0].Debit.Equal(decimal.NewFromInt(5)))
+		assert.Equal(t, controlID, lines[1].AccountID)
+		assert.NoError(t, validateJournalLines(lines, nil))
+	})
+
+	t.Run("requires the account for the difference", func(t *testing.T) {
+		_, err := fxGainLossLines(controlID, &gainID, nil, decimal.NewFromInt(-5), "")
+		assert.ErrorIs(t, err, ErrFxAccountNotConfigured)
+	})
+}
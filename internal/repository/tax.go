@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// Tax code types
+const (
+	// TaxTypeSales is output tax charged to customers
+	TaxTypeSales = "SALES"
+	// TaxTypePurchase is input tax paid to vendors
+	TaxTypePurchase = "PURCHASE"
+)
+
+// TaxCode is a tenant-managed tax rate posted to a tax account
+type TaxCode struct {
+	ID           uuid.UUID
+	TenantID     uuid.UUID
+	Code         string
+	Name         string
+	Type         string
+	Rate         decimal.Decimal
+	TaxAccountID uuid.UUID
+	IsActive     bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// TaxCodeParams holds parameters for creating or updating a tax code
+type TaxCodeParams struct {
+	Code         string
+	Name         string
+	Type         string
+	Rate         decimal.Decimal
+	TaxAccountID uuid.UUID
+	IsActive     bool
+}
+
+// TaxReportRow sums the lines posted with a tax code over a period. Taxable
+// amounts come from the lines the code was applied to, tax amounts from the
+// tax lines generated for them.
+type TaxReportRow struct {
+	TaxCode       *TaxCode
+	TaxableDebit  decimal.Decimal
+	TaxableCredit decimal.Decimal
+	TaxDebit      decimal.Decimal
+	TaxCredit     decimal.Decimal
+}
+
+// TaxableAmount returns the net taxable amount on the code's side: credits
+// less debits for sales, debits less credits for purchases
+func (r *TaxReportRow) TaxableAmount() decimal.Decimal {
+	if r.TaxCode.Type == TaxTypeSales {
+		return r.TaxableCredit.Sub(r.TaxableDebit)
+	}
+	return r.TaxableDebit.Sub(r.TaxableCredit)
+}
+
+// TaxAmount returns the net tax on the code's side
+func (r *TaxReportRow) TaxAmount() decimal.Decimal {
+	if r.TaxCode.Type == TaxTypeSales {
+		return r.TaxCredit.Sub(r.TaxDebit)
+	}
+	return r.TaxDebit.Sub(r.TaxCredit)
+}
+
+const taxCodeColumns = `id, tenant_id, code, name, type, rate, tax_account_id, is_active, created_at, updated_at`
+
+func scanTaxCode(row pgx.Row, code *TaxCode) error {
+	return row.Scan(
+		&code.ID,
+		&code.TenantID,
+		&code.Code,
+		&code.Name,
+		&code.Type,
+		&code.Rate,
+		&code.TaxAccountID,
+		&code.IsActive,
+		&code.CreatedAt,
+		&code.UpdatedAt,
+	)
+}
+
+// TaxCodeRepository handles tax code database operations
+type TaxCodeRepository struct {
+	db *db.DB
+}
+
+// NewTaxCodeRepository creates a new tax code repository
+func NewTaxCodeRepository(database *db.DB) *TaxCodeRepository {
+	return &TaxCodeRepository{db: database}
+}
+
+// Create creates an active tax code
+func (r *TaxCodeRepository) Create(ctx context.Context, tenantID uuid.UUID, params TaxCodeParams) (*TaxCode, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	code := &TaxCode{}
+	query := `
+		INSERT INTO tax_codes (tenant_id, code, name, type, rate, tax_account_id, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, true)
+		RETURNING ` + taxCodeColumns
+
+	row := tx.QueryRow(ctx, query, tenantID, params.Code, params.Name, params.Type, params.Rate, params.TaxAccountID)
+	if err := scanTaxCode(row, code); err != nil {
+		return nil, fmt.Errorf("failed to create tax code: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return code, nil
+}
+
+// Update replaces the settings of a tax code. Lines already posted keep the
+// amounts computed with the previous rate.
+func (r *TaxCodeRepository) Update(ctx context.Context, tenantID uuid.UUID, taxCodeID uuid.UUID, params TaxCodeParams) (*TaxCode, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	code := &TaxCode{}
+	query := `
+		UPDATE tax_codes
+		SET code = $2, name = $3, type = $4, rate = $5, tax_account_id = $6, is_active = $7, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + taxCodeColumns
+
+	row := tx.QueryRow(ctx, query, taxCodeID, params.Code, params.Name, params.Type, params.Rate, params.TaxAccountID, params.IsActive)
+	if err := scanTaxCode(row, code); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("tax code %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update tax code: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return code, nil
+}
+
+// GetByID retrieves a tax code
+func (r *TaxCodeRepository) GetByID(ctx context.Context, tenantID uuid.UUID, taxCodeID uuid.UUID) (*TaxCode, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	code := &TaxCode{}
+	query := `SELECT ` + taxCodeColumns + ` FROM tax_codes WHERE id = $1`
+
+	if err := scanTaxCode(conn.QueryRow(ctx, query, taxCodeID), code); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("tax code %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get tax code: %w", err)
+	}
+
+	return code, nil
+}
+
+// GetByIDs retrieves tax codes by ID. Unknown codes are left out of the map.
+func (r *TaxCodeRepository) GetByIDs(ctx context.Context, tenantID uuid.UUID, taxCodeIDs []uuid.UUID) (map[uuid.UUID]*TaxCode, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `SELECT ` + taxCodeColumns + ` FROM tax_codes WHERE id = ANY($1)`
+
+	rows, err := conn.Query(ctx, query, taxCodeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax codes: %w", err)
+	}
+	defer rows.Close()
+
+	codes := make(map[uuid.UUID]*TaxCode, len(taxCodeIDs))
+	for rows.Next() {
+		code := &TaxCode{}
+		if err := scanTaxCode(rows, code); err != nil {
+			return nil, fmt.Errorf("failed to scan tax code: %w", err)
+		}
+		codes[code.ID] = code
+	}
+
+	return codes, nil
+}
+
+// List retrieves the tax codes of a tenant ordered by code
+func (r *TaxCodeRepository) List(ctx context.Context, tenantID uuid.UUID, includeInactive bool) ([]*TaxCode, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `SELECT ` + taxCodeColumns + ` FROM tax_codes`
+	if !includeInactive {
+		query += ` WHERE is_active`
+	}
+	query += ` ORDER BY code`
+
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tax codes: %w", err)
+	}
+	defer rows.Close()
+
+	codes := make([]*TaxCode, 0)
+	for rows.Next() {
+		code := &TaxCode{}
+		if err := scanTaxCode(rows, code); err != nil {
+			return nil, fmt.Errorf("failed to scan tax code: %w", err)
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// GetReport sums the taxable and tax amounts posted with each tax code for
+// entries dated between fromDate and toDate, inclusive
+func (r *TaxCodeRepository) GetReport(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time) ([]*TaxReportRow, error) {
+	_, conn, err := r.db.WithTenant(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT tc.id, tc.tenant_id, tc.code, tc.name, tc.type, tc.rate, tc.tax_account_id,
+		       tc.is_active, tc.created_at, tc.updated_at,
+		       COALESCE(SUM(jel.debit) FILTER (WHERE NOT jel.is_tax), 0),
+		       COALESCE(SUM(jel.credit) FILTER (WHERE NOT jel.is_tax), 0),
+		       COALESCE(SUM(jel.debit) FILTER (WHERE jel.is_tax), 0),
+		       COALESCE(SUM(jel.credit) FILTER (WHERE jel.is_tax), 0)
+		FROM tax_codes tc
+		INNER JOIN journal_entry_lines jel ON jel.tax_code_id = tc.id
+		INNER JOIN journal_entries je ON je.id = jel.journal_entry_id
+		WHERE je.entry_date >= $1 AND je.entry_date <= $2
+		GROUP BY tc.id
+		ORDER BY tc.code
+	`
+
+	rows, err := conn.Query(ctx, query, fromDate, toDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax report: %w", err)
+	}
+	defer rows.Close()
+
+	report := make([]*TaxReportRow, 0)
+	for rows.Next() {
+		row := &TaxReportRow{TaxCode: &TaxCode{}}
+		err := rows.Scan(
+			&row.TaxCode.ID,
+			&row.TaxCode.TenantID,
+			&row.TaxCode.Code,
+			&row.TaxCode.Name,
+			&row.TaxCode.Type,
+			&row.TaxCode.Rate,
+			&row.TaxCode.TaxAccountID,
+			&row.TaxCode.IsActive,
+			&row.TaxCode.CreatedAt,
+			&row.TaxCode.UpdatedAt,
+			&row.TaxableDebit,
+			&row.TaxableCredit,
+			&row.TaxDebit,
+			&row.TaxCredit,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tax report row: %w", err)
+		}
+		report = append(report, row)
+	}
+
+	return report, nil
+}
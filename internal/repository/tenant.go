@@ -2,19 +2,31 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
 )
 
-// Tenant represents a tenant entity
-type Tenant struct {
-	ID        uuid.UUID
-	Name      string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+// tenantColumns lists the tenant columns in the order expected by scanTenant
+const tenantColumns = `id, name, status, created_at, updated_at, deleted_at, is_test, cloned_from_tenant_id, test_since`
+
+// scanTenant scans a row selected with tenantColumns into a tenant
+func scanTenant(row pgx.Row, tenant *Tenant) error {
+	return row.Scan(
+		&tenant.ID,
+		&tenant.Name,
+		&tenant.Status,
+		&tenant.CreatedAt,
+		&tenant.UpdatedAt,
+		&tenant.DeletedAt,
+		&tenant.IsTest,
+		&tenant.ClonedFromID,
+		&tenant.TestSince,
+	)
 }
 
 // TenantRepository handles tenant database operations
@@ -27,16 +39,34 @@ func NewTenantRepository(database *db.DB) *TenantRepository {
 	return &TenantRepository{db: database}
 }
 
-// Create creates a new tenant using the database function
+// Create creates a new tenant using the database function. The ID is chosen
+// up front, when not given, so the TenantCreated event can be recorded in the
+// tenant's context in the same transaction.
 func (r *TenantRepository) Create(ctx context.Context, name string, tenantUUID *uuid.UUID) (*Tenant, error) {
-	var tenantID uuid.UUID
+	tenantID := uuid.New()
+	if tenantUUID != nil {
+		tenantID = *tenantUUID
+	}
 
-	query := "SELECT create_tenant($1, $2)"
-	err := r.db.Pool().QueryRow(ctx, query, name, tenantUUID).Scan(&tenantID)
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
 	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := "SELECT create_tenant($1, $2)"
+	if err := tx.QueryRow(ctx, query, name, tenantID).Scan(&tenantID); err != nil {
 		return nil, fmt.Errorf("failed to create tenant: %w", err)
 	}
 
+	if err := appendEvent(ctx, tx, AggregateTenant, tenantID, EventTenantCreated, TenantCreatedPayload{Name: name, Status: TenantStatusActive}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	// Fetch the created tenant details
 	return r.GetByID(ctx, tenantID)
 }
@@ -46,43 +76,322 @@ func (r *TenantRepository) GetByID(ctx context.Context, tenantID uuid.UUID) (*Te
 	tenant := &Tenant{}
 
 	query := `
-		SELECT id, name, created_at, updated_at
+		SELECT ` + tenantColumns + `
 		FROM tenants
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	err := r.db.Pool().QueryRow(ctx, query, tenantID).Scan(
-		&tenant.ID,
-		&tenant.Name,
-		&tenant.CreatedAt,
-		&tenant.UpdatedAt,
-	)
+	err := scanTenant(r.db.Pool().QueryRow(ctx, query, tenantID), tenant)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("tenant %w", ErrNotFound)
+		}
 		return nil, fmt.Errorf("failed to get tenant: %w", err)
 	}
 
 	return tenant, nil
 }
 
+// ListIDs retrieves the IDs of all active tenants, leaving out suspended and
+// archived tenants so background jobs do not post to them
+func (r *TenantRepository) ListIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return r.listIDs(ctx, "SELECT id FROM tenants WHERE deleted_at IS NULL AND status = $1 ORDER BY created_at", TenantStatusActive)
+}
+
+// ListTestIDs retrieves the IDs of the test tenants that are not deleted, so
+// aggregate metrics can leave them out
+func (r *TenantRepository) ListTestIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return r.listIDs(ctx, "SELECT id FROM tenants WHERE deleted_at IS NULL AND is_test ORDER BY created_at")
+}
+
+// ListExpiredTestIDs retrieves the IDs of the test tenants marked as test
+// before a time whose data has not been purged, deleted or not
+func (r *TenantRepository) ListExpiredTestIDs(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	return r.listIDs(ctx, "SELECT id FROM tenants WHERE is_test AND test_since < $1 AND purged_at IS NULL ORDER BY test_since", before)
+}
+
+func (r *TenantRepository) listIDs(ctx context.Context, query string, args ...interface{}) ([]uuid.UUID, error) {
+	rows, err := r.db.Pool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	return ids, nil
+}
+
+// List retrieves a page of tenants matching a filter, newest first, and the
+// number of tenants matching it
+func (r *TenantRepository) List(ctx context.Context, filter TenantFilter, limit, offset int) ([]*Tenant, int, error) {
+	where := " WHERE 1=1"
+	var args []interface{}
+	argCount := 0
+
+	if !filter.IncludeDeleted {
+		where += " AND deleted_at IS NULL"
+	}
+
+	if filter.NameContains != nil {
+		argCount++
+		where += fmt.Sprintf(" AND name ILIKE $%d", argCount)
+		args = append(args, likeContains(*filter.NameContains))
+	}
+
+	if filter.CreatedFrom != nil {
+		argCount++
+		where += fmt.Sprintf(" AND created_at >= $%d", argCount)
+		args = append(args, *filter.CreatedFrom)
+	}
+
+	if filter.CreatedTo != nil {
+		argCount++
+		where += fmt.Sprintf(" AND created_at <= $%d", argCount)
+		args = append(args, *filter.CreatedTo)
+	}
+
+	if filter.Status != nil {
+		argCount++
+		where += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, *filter.Status)
+	}
+
+	if filter.IsTest != nil {
+		argCount++
+		where += fmt.Sprintf(" AND is_test = $%d", argCount)
+		args = append(args, *filter.IsTest)
+	}
+
+	var totalCount int
+	err := r.db.Pool().QueryRow(ctx, "SELECT COUNT(*) FROM tenants"+where, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count tenants: %w", err)
+	}
+
+	query := `SELECT ` + tenantColumns + ` FROM tenants` + where + " ORDER BY created_at DESC, id"
+
+	argCount++
+	query += fmt.Sprintf(" LIMIT $%d", argCount)
+	args = append(args, limit)
+
+	argCount++
+	query += fmt.Sprintf(" OFFSET $%d", argCount)
+	args = append(args, offset)
+
+	rows, err := r.db.Pool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := make([]*Tenant, 0)
+	for rows.Next() {
+		tenant := &Tenant{}
+		if err := scanTenant(rows, tenant); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, tenant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	return tenants, totalCount, nil
+}
+
+// Status returns the status of a tenant, TenantStatusDeleted for a
+// soft-deleted tenant and an empty status for an unknown one, so callers
+// checking access can leave unknown tenants to the handler of the call
+func (r *TenantRepository) Status(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	query := `
+		SELECT CASE WHEN deleted_at IS NOT NULL THEN $2 ELSE status END
+		FROM tenants
+		WHERE id = $1
+	`
+
+	var status string
+	err := r.db.Pool().QueryRow(ctx, query, tenantID, TenantStatusDeleted).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get tenant status: %w", err)
+	}
+
+	return status, nil
+}
+
 // GetByName retrieves a tenant by name
 func (r *TenantRepository) GetByName(ctx context.Context, name string) (*Tenant, error) {
 	tenant := &Tenant{}
 
 	query := `
-		SELECT id, name, created_at, updated_at
+		SELECT ` + tenantColumns + `
 		FROM tenants
-		WHERE name = $1
+		WHERE name = $1 AND deleted_at IS NULL
 	`
 
-	err := r.db.Pool().QueryRow(ctx, query, name).Scan(
-		&tenant.ID,
-		&tenant.Name,
-		&tenant.CreatedAt,
-		&tenant.UpdatedAt,
-	)
+	err := scanTenant(r.db.Pool().QueryRow(ctx, query, name), tenant)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tenant by name: %w", err)
 	}
 
 	return tenant, nil
 }
+
+// Delete soft-deletes a tenant, keeping its journal history intact
+func (r *TenantRepository) Delete(ctx context.Context, tenantID uuid.UUID) (*Tenant, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tenant := &Tenant{}
+
+	query := `
+		UPDATE tenants
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + tenantColumns
+
+	err = scanTenant(tx.QueryRow(ctx, query, tenantID), tenant)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("tenant %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to delete tenant: %w", err)
+	}
+
+	if err := appendEvent(ctx, tx, AggregateTenant, tenantID, EventTenantDeleted, struct{}{}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return tenant, nil
+}
+
+// Restore reverses the soft deletion of a tenant whose data has not been
+// purged
+func (r *TenantRepository) Restore(ctx context.Context, tenantID uuid.UUID) (*Tenant, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tenant := &Tenant{}
+
+	query := `
+		UPDATE tenants
+		SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL
+		RETURNING ` + tenantColumns
+
+	err = scanTenant(tx.QueryRow(ctx, query, tenantID), tenant)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("deleted tenant %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to restore tenant: %w", err)
+	}
+
+	if err := appendEvent(ctx, tx, AggregateTenant, tenantID, EventTenantRestored, struct{}{}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return tenant, nil
+}
+
+// SetStatus suspends, archives or reactivates a tenant. Deleted tenants
+// must be restored first.
+func (r *TenantRepository) SetStatus(ctx context.Context, tenantID uuid.UUID, status string) (*Tenant, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tenant := &Tenant{}
+
+	query := `
+		UPDATE tenants
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + tenantColumns
+
+	err = scanTenant(tx.QueryRow(ctx, query, tenantID, status), tenant)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("tenant %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to set tenant status: %w", err)
+	}
+
+	if err := appendEvent(ctx, tx, AggregateTenant, tenantID, EventTenantStatusChanged, TenantStatusChangedPayload{Status: status}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return tenant, nil
+}
+
+// SetTestMode marks a tenant as test or clears the mark. Marking a tenant
+// that is already test keeps the time it was first marked, so its retention
+// is not extended.
+func (r *TenantRepository) SetTestMode(ctx context.Context, tenantID uuid.UUID, test bool) (*Tenant, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tenant := &Tenant{}
+
+	query := `
+		UPDATE tenants
+		SET is_test = $2,
+			test_since = CASE WHEN $2 THEN COALESCE(test_since, NOW()) END,
+			updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + tenantColumns
+
+	err = scanTenant(tx.QueryRow(ctx, query, tenantID, test), tenant)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("tenant %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to set tenant test mode: %w", err)
+	}
+
+	if err := appendEvent(ctx, tx, AggregateTenant, tenantID, EventTenantTestModeChanged, TenantTestModeChangedPayload{Test: test}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return tenant, nil
+}
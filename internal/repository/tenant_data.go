@@ -0,0 +1,418 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// PurgeTokenTTL is how long a purge confirmation token can be used
+const PurgeTokenTTL = 15 * time.Minute
+
+// tenantDataTables lists every table holding data of a tenant with the rows
+// of the tenant, children before the rows they reference so they can be
+// deleted in order. Each condition takes the tenant as $1.
+var tenantDataTables = []struct {
+	table string
+	where string
+}{
+	{"subledger_applications", "tenant_id = $1"},
+	{"subledger_documents", "tenant_id = $1"},
+	{"bank_statement_lines", "tenant_id = $1"},
+	{"bank_statements", "tenant_id = $1"},
+	{"close_tasks", "tenant_id = $1"},
+	{"close_checklists", "tenant_id = $1"},
+	{"close_task_templates", "tenant_id = $1"},
+	{"interest_accruals", "tenant_id = $1"},
+	{"account_interest", "tenant_id = $1"},
+	{"interest_schemes", "tenant_id = $1"},
+	{"depreciation_schedule", "tenant_id = $1"},
+	{"fixed_assets", "tenant_id = $1"},
+	{"budget_lines", "tenant_id = $1"},
+	{"budgets", "tenant_id = $1"},
+	{"holds", "tenant_id = $1"},
+	{"prepared_entry_reservations", "tenant_id = $1"},
+	{"prepared_journal_entries", "tenant_id = $1"},
+	{"journal_batch_entries", "tenant_id = $1"},
+	{"journal_batches", "tenant_id = $1"},
+	{"journal_entry_idempotency_keys", "tenant_id = $1"},
+	{"journal_entry_transactions", "tenant_id = $1"},
+	{"journal_entry_lock_overrides", "tenant_id = $1"},
+	{"journal_entry_currencies", "tenant_id = $1"},
+	{"period_totals_pending", "tenant_id = $1"},
+	{"account_period_totals", "tenant_id = $1"},
+	{"digests", "tenant_id = $1"},
+	{"journal_entry_lines", "journal_entry_id IN (SELECT id FROM journal_entries WHERE tenant_id = $1)"},
+	{"journal_entries", "tenant_id = $1"},
+	{"event_deliveries", "tenant_id = $1"},
+	{"ledger_events", "tenant_id = $1"},
+	{"account_balances", "account_id IN (SELECT id FROM accounts WHERE tenant_id = $1)"},
+	{"account_external_ids", "tenant_id = $1"},
+	{"reference_sequences", "tenant_id = $1"},
+	{"posting_policies", "tenant_id = $1"},
+	{"tax_codes", "tenant_id = $1"},
+	{"dimensions", "tenant_id = $1"},
+	{"parties", "tenant_id = $1"},
+	{"tenant_settings", "tenant_id = $1"},
+	{"export_jobs", "tenant_id = $1"},
+	{"statement_runs", "tenant_id = $1"},
+	{"consolidation_group_members", "tenant_id = $1"},
+	{"accounts", "tenant_id = $1"},
+	{"books", "tenant_id = $1"},
+}
+
+// TenantPurgeRequest is a pending purge of a tenant's data, confirmed by
+// presenting Token before ExpiresAt
+type TenantPurgeRequest struct {
+	TenantID  uuid.UUID
+	Token     string
+	ExpiresAt time.Time
+	// Rows counts the rows of each table the purge would delete
+	Rows map[string]int64
+}
+
+// TenantPurge is the outcome of purging a tenant's data
+type TenantPurge struct {
+	TenantID uuid.UUID
+	PurgedAt time.Time
+	// Rows counts the rows deleted from each table
+	Rows map[string]int64
+}
+
+// TenantPurgedPayload is the payload of a TenantPurged event, the only
+// event of the tenant left after a purge
+type TenantPurgedPayload struct {
+	Rows int64 `json:"rows"`
+}
+
+// TenantDataRepository reads, purges and clones all data of a tenant at once
+type TenantDataRepository struct {
+	db *db.DB
+}
+
+// NewTenantDataRepository creates a new tenant data repository
+func NewTenantDataRepository(database *db.DB) *TenantDataRepository {
+	return &TenantDataRepository{db: database}
+}
+
+// tenantSnapshot reads a tenant's data in a repeatable read transaction
+type tenantSnapshot struct {
+	tx       *db.TenantTx
+	tenantID uuid.UUID
+	takenAt  time.Time
+}
+
+// Snapshot calls fn with a snapshot of the tenant's data. Reads from the
+// snapshot are not bounded by the statement timeout, only by ctx.
+func (r *TenantDataRepository) Snapshot(ctx context.Context, tenantID uuid.UUID, fn func(TenantSnapshot) error) error {
+	tx, err := r.db.BeginSnapshotTx(ctx, tenantID.String())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.Exec(ctx, "SET LOCAL statement_timeout = 0"); err != nil {
+		return fmt.Errorf("failed to lift statement timeout: %w", err)
+	}
+
+	snapshot := &tenantSnapshot{tx: tx, tenantID: tenantID}
+	if err := tx.QueryRow(ctx, "SELECT NOW()").Scan(&snapshot.takenAt); err != nil {
+		return fmt.Errorf("failed to take snapshot: %w", err)
+	}
+
+	return fn(snapshot)
+}
+
+// TakenAt returns the database time of the snapshot
+func (s *tenantSnapshot) TakenAt() time.Time {
+	return s.takenAt
+}
+
+// Accounts calls fn for every account, deleted ones included, by number
+func (s *tenantSnapshot) Accounts(ctx context.Context, fn func(*Account) error) error {
+	rows, err := s.tx.Query(ctx, `SELECT `+accountColumns+` FROM accounts ORDER BY account_number, id`)
+	if err != nil {
+		return fmt.Errorf("failed to list accounts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		account := &Account{}
+		if err := scanAccount(rows, account); err != nil {
+			return fmt.Errorf("failed to scan account: %w", err)
+		}
+		if err := fn(account); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// JournalEntries calls fn for every journal entry with its lines, oldest
+// first, with metadata opened like JournalRepository.Stream
+func (s *tenantSnapshot) JournalEntries(ctx context.Context, fn func(*JournalEntry) error) error {
+	return streamJournalEntries(ctx, s.tx, s.tx.MetadataKeyring(), s.tenantID, nil, nil, fn)
+}
+
+// Balances calls fn for the stored balance of every account
+func (s *tenantSnapshot) Balances(ctx context.Context, fn func(*AccountBalance) error) error {
+	rows, err := s.tx.Query(ctx, `
+		SELECT ab.account_id, ab.debit_balance, ab.credit_balance, ab.updated_at
+		FROM account_balances ab
+		INNER JOIN accounts a ON a.id = ab.account_id
+		ORDER BY a.account_number, a.id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list account balances: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		balance := &AccountBalance{}
+		if err := rows.Scan(&balance.AccountID, &balance.DebitBalance, &balance.CreditBalance, &balance.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to scan account balance: %w", err)
+		}
+		if err := fn(balance); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Settings returns the tenant's settings
+func (s *tenantSnapshot) Settings(ctx context.Context) (*TenantSettings, error) {
+	return getTenantSettings(ctx, s.tx, s.tenantID)
+}
+
+// RequestPurge issues the token that confirms a purge of the data of a
+// deleted tenant, replacing any token issued before, and counts the rows the
+// purge would delete
+func (r *TenantDataRepository) RequestPurge(ctx context.Context, tenantID uuid.UUID) (*TenantPurgeRequest, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkPurgeable(ctx, tx, tenantID); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate purge token: %w", err)
+	}
+	request := &TenantPurgeRequest{
+		TenantID: tenantID,
+		Token:    base64.RawURLEncoding.EncodeToString(secret),
+		Rows:     make(map[string]int64, len(tenantDataTables)),
+	}
+	hash := sha256.Sum256([]byte(request.Token))
+
+	err = tx.QueryRow(ctx, `
+		UPDATE tenants
+		SET purge_token_hash = $2, purge_token_expires_at = NOW() + $3 * INTERVAL '1 second'
+		WHERE id = $1
+		RETURNING purge_token_expires_at
+	`, tenantID, hash[:], PurgeTokenTTL.Seconds()).Scan(&request.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue purge token: %w", err)
+	}
+
+	for _, t := range tenantDataTables {
+		var n int64
+		if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM `+t.table+` WHERE `+t.where, tenantID).Scan(&n); err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", t.table, err)
+		}
+		request.Rows[t.table] = n
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return request, nil
+}
+
+// Purge deletes every row of a deleted tenant, given the token issued by
+// RequestPurge. The tenant row stays, marked as purged, so references from
+// other tenants and consolidation groups remain valid; it can no longer be
+// restored.
+func (r *TenantDataRepository) Purge(ctx context.Context, tenantID uuid.UUID, token string) (*TenantPurge, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkPurgeable(ctx, tx, tenantID); err != nil {
+		return nil, err
+	}
+
+	var storedHash []byte
+	var valid bool
+	err = tx.QueryRow(ctx, `
+		SELECT purge_token_hash, COALESCE(purge_token_expires_at > NOW(), false)
+		FROM tenants
+		WHERE id = $1
+	`, tenantID).Scan(&storedHash, &valid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purge token: %w", err)
+	}
+	hash := sha256.Sum256([]byte(token))
+	if !valid || subtle.ConstantTimeCompare(storedHash, hash[:]) != 1 {
+		return nil, ErrPurgeTokenInvalid
+	}
+
+	purge, err := purgeTenant(ctx, tx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return purge, nil
+}
+
+// PurgeExpiredTest deletes the tenant and every row of a test tenant marked
+// as test before a time, without the confirmation a purge otherwise needs.
+// Test tenants are disposable by definition, so the cleanup job purges them
+// once their retention has passed.
+func (r *TenantDataRepository) PurgeExpiredTest(ctx context.Context, tenantID uuid.UUID, before time.Time) (*TenantPurge, error) {
+	tx, err := r.db.BeginTx(ctx, tenantID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var expired, deleted, purged bool
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(is_test AND test_since < $2, false), deleted_at IS NOT NULL, purged_at IS NOT NULL
+		FROM tenants
+		WHERE id = $1
+		FOR UPDATE
+	`, tenantID, before).Scan(&expired, &deleted, &purged)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("tenant %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	switch {
+	case purged:
+		return nil, ErrTenantPurged
+	case !expired:
+		return nil, ErrTestTenantNotExpired
+	}
+
+	if !deleted {
+		if err := tx.Exec(ctx, "UPDATE tenants SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1", tenantID); err != nil {
+			return nil, fmt.Errorf("failed to delete tenant: %w", err)
+		}
+		if err := appendEvent(ctx, tx, AggregateTenant, tenantID, EventTenantDeleted, struct{}{}); err != nil {
+			return nil, err
+		}
+	}
+
+	purge, err := purgeTenant(ctx, tx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return purge, nil
+}
+
+// purgeTenant deletes every row of a deleted tenant locked by the caller and
+// marks the tenant purged
+func purgeTenant(ctx context.Context, tx *db.TenantTx, tenantID uuid.UUID) (*TenantPurge, error) {
+	var eliminates bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM consolidation_groups WHERE elimination_tenant_id = $1)`, tenantID).Scan(&eliminates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check consolidation groups: %w", err)
+	}
+	if eliminates {
+		return nil, ErrEliminationTenant
+	}
+
+	// The append-only triggers of the journal and the event store let the
+	// tenant named here delete its rows; deleting a large tenant may take
+	// longer than the statement timeout
+	if err := tx.Exec(ctx, "SELECT set_config('app.purge_tenant_id', $1, true)", tenantID.String()); err != nil {
+		return nil, fmt.Errorf("failed to allow purge: %w", err)
+	}
+	if err := tx.Exec(ctx, "SET LOCAL statement_timeout = 0"); err != nil {
+		return nil, fmt.Errorf("failed to lift statement timeout: %w", err)
+	}
+
+	purge := &TenantPurge{TenantID: tenantID, Rows: make(map[string]int64, len(tenantDataTables))}
+	var total int64
+	for _, t := range tenantDataTables {
+		var n int64
+		query := `WITH deleted AS (DELETE FROM ` + t.table + ` WHERE ` + t.where + ` RETURNING 1) SELECT COUNT(*) FROM deleted`
+		if err := tx.QueryRow(ctx, query, tenantID).Scan(&n); err != nil {
+			return nil, fmt.Errorf("failed to purge %s: %w", t.table, err)
+		}
+		purge.Rows[t.table] = n
+		total += n
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE tenants
+		SET purged_at = NOW(), purge_token_hash = NULL, purge_token_expires_at = NULL, updated_at = NOW()
+		WHERE id = $1
+		RETURNING purged_at
+	`, tenantID).Scan(&purge.PurgedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark tenant purged: %w", err)
+	}
+
+	if err := appendEvent(ctx, tx, AggregateTenant, tenantID, EventTenantPurged, TenantPurgedPayload{Rows: total}); err != nil {
+		return nil, err
+	}
+
+	return purge, nil
+}
+
+// checkPurgeable locks the tenant row and checks the tenant is deleted and
+// not purged yet
+func checkPurgeable(ctx context.Context, tx *db.TenantTx, tenantID uuid.UUID) error {
+	var deleted, purged bool
+	err := tx.QueryRow(ctx, `
+		SELECT deleted_at IS NOT NULL, purged_at IS NOT NULL
+		FROM tenants
+		WHERE id = $1
+		FOR UPDATE
+	`, tenantID).Scan(&deleted, &purged)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("tenant %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	switch {
+	case purged:
+		return ErrTenantPurged
+	case !deleted:
+		return ErrTenantNotDeleted
+	}
+	return nil
+}
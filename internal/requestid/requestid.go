@@ -0,0 +1,103 @@
+// Package requestid tags every gRPC call with a request ID, taken from the
+// "x-request-id" metadata header or generated, so log lines of one call can
+// be correlated with each other and with the client.
+package requestid
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Header is the metadata header carrying the request ID of a call
+const Header = "x-request-id"
+
+// maxLength bounds client-supplied request IDs so they cannot bloat logs
+const maxLength = 128
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the request ID of a call
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID of a call, or "" outside of a call
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// fromMetadata returns the request ID sent by the client, or a new one when
+// none or an unusable one was sent
+func fromMetadata(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(Header); len(values) > 0 && valid(values[0]) {
+			return values[0]
+		}
+	}
+	return uuid.New().String()
+}
+
+// valid accepts non-empty printable ASCII IDs of a bounded length
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// UnaryServerInterceptor returns a unary interceptor that assigns the request
+// ID, echoes it in the response headers and logs the outcome of the call
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		id := fromMetadata(ctx)
+		ctx = NewContext(ctx, id)
+		// Fails only outside of a real transport, e.g. in tests
+		_ = grpc.SetHeader(ctx, metadata.Pairs(Header, id))
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(info.FullMethod, id, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a stream interceptor that assigns the
+// request ID, echoes it in the response headers and logs the outcome of the
+// call
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id := fromMetadata(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(Header, id))
+
+		start := time.Now()
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: NewContext(ss.Context(), id)})
+		logCall(info.FullMethod, id, start, err)
+		return err
+	}
+}
+
+// serverStream carries the request ID through a stream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func logCall(method, id string, start time.Time, err error) {
+	log.Printf("%s request_id=%s code=%s duration=%s", method, id, status.Code(err), time.Since(start))
+}
@@ -0,0 +1,99 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// fakeTransportStream records the headers set by a unary handler
+type fakeTransportStream struct {
+	header metadata.MD
+}
+
+func (s *fakeTransportStream) Method() string { return "/ledger.v1.LedgerService/GetAccount" }
+
+func (s *fakeTransportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *fakeTransportStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *fakeTransportStream) SetTrailer(md metadata.MD) error { return nil }
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/ledger.v1.LedgerService/GetAccount"}
+
+	call := func(md metadata.MD) (string, *fakeTransportStream) {
+		stream := &fakeTransportStream{}
+		ctx := grpc.NewContextWithServerTransportStream(metadata.NewIncomingContext(context.Background(), md), stream)
+
+		var handled string
+		_, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			handled = FromContext(ctx)
+			return "ok", nil
+		})
+		require.NoError(t, err)
+		return handled, stream
+	}
+
+	t.Run("accepts the request ID of the client", func(t *testing.T) {
+		id, stream := call(metadata.Pairs(Header, "req-123"))
+
+		assert.Equal(t, "req-123", id)
+		assert.Equal(t, []string{"req-123"}, stream.header.Get(Header))
+	})
+
+	t.Run("generates a request ID when none was sent", func(t *testing.T) {
+		id, stream := call(metadata.MD{})
+
+		_, err := uuid.Parse(id)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{id}, stream.header.Get(Header))
+	})
+
+	t.Run("replaces unusable request IDs", func(t *testing.T) {
+		for _, sent := range []string{"has space", strings.Repeat("x", maxLength+1)} {
+			id, _ := call(metadata.Pairs(Header, sent))
+
+			assert.NotEqual(t, sent, id)
+			_, err := uuid.Parse(id)
+			assert.NoError(t, err)
+		}
+	})
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+
+func (s *fakeServerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	interceptor := StreamServerInterceptor()
+	stream := &fakeServerStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(Header, "req-456"))}
+
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/ledger.v1.LedgerService/ExportJournalEntries"},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			assert.Equal(t, "req-456", FromContext(ss.Context()))
+			return nil
+		})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"req-456"}, stream.header.Get(Header))
+}
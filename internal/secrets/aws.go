@@ -0,0 +1,51 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// AWSSecretsManagerProvider reads database credentials from an AWS Secrets
+// Manager secret holding "username" and "password" keys, the layout of
+// RDS-managed and rotated secrets
+type AWSSecretsManagerProvider struct {
+	client   *secretsmanager.Client
+	secretID string
+}
+
+// NewAWSSecretsManagerProvider creates a provider reading secretID in the
+// region of cfg
+func NewAWSSecretsManagerProvider(cfg aws.Config, secretID string) *AWSSecretsManagerProvider {
+	return &AWSSecretsManagerProvider{
+		client:   secretsmanager.NewFromConfig(cfg),
+		secretID: secretID,
+	}
+}
+
+// Credentials reads the current version of the secret
+func (p *AWSSecretsManagerProvider) Credentials(ctx context.Context) (Credentials, error) {
+	out, err := p.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(p.secretID),
+	})
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to read secret %s: %w", p.secretID, err)
+	}
+	if out.SecretString == nil {
+		return Credentials{}, fmt.Errorf("secret %s has no string value", p.secretID)
+	}
+
+	var secret map[string]interface{}
+	if err := json.Unmarshal([]byte(*out.SecretString), &secret); err != nil {
+		return Credentials{}, fmt.Errorf("secret %s is not a JSON object: %w", p.secretID, err)
+	}
+
+	creds, err := credentialsFromMap(secret)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("secret %s: %w", p.secretID, err)
+	}
+	return creds, nil
+}
@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/rds/auth"
+)
+
+// rdsTokenLifetime is how long RDS accepts an IAM authentication token
+const rdsTokenLifetime = 15 * time.Minute
+
+// RDSIAMProvider generates short-lived IAM authentication tokens for an
+// Amazon RDS or Aurora database user. A token is a presigned URL, so
+// generating one needs no call to RDS.
+type RDSIAMProvider struct {
+	endpoint string
+	user     string
+	cfg      aws.Config
+	now      func() time.Time
+}
+
+// NewRDSIAMProvider creates a provider for user on the database at
+// host:port, signing tokens with the credentials and region of cfg
+func NewRDSIAMProvider(host string, port int, user string, cfg aws.Config) *RDSIAMProvider {
+	return &RDSIAMProvider{
+		endpoint: net.JoinHostPort(host, strconv.Itoa(port)),
+		user:     user,
+		cfg:      cfg,
+		now:      time.Now,
+	}
+}
+
+// Credentials returns the user with a new authentication token as password
+func (p *RDSIAMProvider) Credentials(ctx context.Context) (Credentials, error) {
+	now := p.now()
+	token, err := auth.BuildAuthToken(ctx, p.endpoint, p.cfg.Region, p.user, p.cfg.Credentials)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to build RDS authentication token: %w", err)
+	}
+
+	return Credentials{
+		Username:  p.user,
+		Password:  token,
+		ExpiresAt: now.Add(rdsTokenLifetime),
+	}, nil
+}
+
+// gcpMetadataTokenURL serves access tokens of the service account attached
+// to a Compute Engine VM, Cloud Run service or GKE workload
+const gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// CloudSQLIAMProvider authenticates a Cloud SQL IAM database user with the
+// OAuth2 access token of the attached service account
+type CloudSQLIAMProvider struct {
+	client   *http.Client
+	tokenURL string
+	user     string
+	now      func() time.Time
+}
+
+// NewCloudSQLIAMProvider creates a provider for user, the service account
+// email without the ".gserviceaccount.com" suffix
+func NewCloudSQLIAMProvider(client *http.Client, user string) *CloudSQLIAMProvider {
+	return &CloudSQLIAMProvider{
+		client:   client,
+		tokenURL: gcpMetadataTokenURL,
+		user:     user,
+		now:      time.Now,
+	}
+}
+
+// Credentials returns the user with the current access token as password
+func (p *CloudSQLIAMProvider) Credentials(ctx context.Context) (Credentials, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.tokenURL, nil)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to build metadata request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to fetch access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Credentials{}, fmt.Errorf("failed to fetch access token: status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return Credentials{}, fmt.Errorf("failed to decode access token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return Credentials{}, fmt.Errorf("metadata server returned an empty access token")
+	}
+
+	return Credentials{
+		Username:  p.user,
+		Password:  token.AccessToken,
+		ExpiresAt: p.now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}, nil
+}
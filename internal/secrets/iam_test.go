@@ -0,0 +1,66 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRDSIAMProvider_Credentials(t *testing.T) {
+	now := time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)
+	cfg := aws.Config{
+		Region:      "eu-west-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "secret", "session"),
+	}
+	provider := NewRDSIAMProvider("ledger.abc123.eu-west-1.rds.amazonaws.com", 5432, "ledger_app", cfg)
+	provider.now = func() time.Time { return now }
+
+	creds, err := provider.Credentials(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "ledger_app", creds.Username)
+	assert.Equal(t, now.Add(15*time.Minute), creds.ExpiresAt)
+	assert.True(t, creds.IsToken())
+
+	require.True(t, strings.HasPrefix(creds.Password, "ledger.abc123.eu-west-1.rds.amazonaws.com:5432?"))
+	token, err := url.Parse("https://" + creds.Password)
+	require.NoError(t, err)
+	query := token.Query()
+	assert.Equal(t, "connect", query.Get("Action"))
+	assert.Equal(t, "ledger_app", query.Get("DBUser"))
+	assert.True(t, strings.HasPrefix(query.Get("X-Amz-Credential"), "AKID/"))
+	assert.True(t, strings.HasSuffix(query.Get("X-Amz-Credential"), "/eu-west-1/rds-db/aws4_request"))
+	assert.Equal(t, "900", query.Get("X-Amz-Expires"))
+	assert.Equal(t, "session", query.Get("X-Amz-Security-Token"))
+	assert.Len(t, query.Get("X-Amz-Signature"), 64)
+}
+
+func TestCloudSQLIAMProvider_Credentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"ya29.token","expires_in":3599,"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)
+	provider := NewCloudSQLIAMProvider(server.Client(), "ledger@project.iam")
+	provider.tokenURL = server.URL
+	provider.now = func() time.Time { return now }
+
+	creds, err := provider.Credentials(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{Username: "ledger@project.iam", Password: "ya29.token", ExpiresAt: now.Add(3599 * time.Second)}, creds)
+}
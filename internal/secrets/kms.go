@@ -0,0 +1,37 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// KMSClient calls AWS Key Management Service operations in one region
+type KMSClient struct {
+	client *kms.Client
+}
+
+// NewKMSClient creates a KMS client for the region of cfg
+func NewKMSClient(cfg aws.Config) *KMSClient {
+	return &KMSClient{client: kms.NewFromConfig(cfg)}
+}
+
+// GenerateMac returns the HMAC-SHA256 of message under the HMAC key keyID,
+// which never leaves KMS
+func (c *KMSClient) GenerateMac(ctx context.Context, keyID string, message []byte) ([]byte, error) {
+	out, err := c.client.GenerateMac(ctx, &kms.GenerateMacInput{
+		KeyId:        aws.String(keyID),
+		MacAlgorithm: types.MacAlgorithmSpecHmacSha256,
+		Message:      message,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mac with key %s: %w", keyID, err)
+	}
+	if len(out.Mac) == 0 {
+		return nil, fmt.Errorf("kms response for key %s has no mac", keyID)
+	}
+	return out.Mac, nil
+}
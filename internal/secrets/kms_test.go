@@ -0,0 +1,56 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKMSClient_GenerateMac(t *testing.T) {
+	serve := func(t *testing.T, status int, body string) *KMSClient {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "TrentService.GenerateMac", r.Header.Get("X-Amz-Target"))
+			assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+			assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/kms/aws4_request")
+
+			var req struct {
+				KeyId        string
+				MacAlgorithm string
+				Message      []byte
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "alias/ledger-metadata", req.KeyId)
+			assert.Equal(t, "HMAC_SHA_256", req.MacAlgorithm)
+			assert.Equal(t, "tenant", string(req.Message))
+
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+
+		return NewKMSClient(testAWSConfig(server))
+	}
+
+	t.Run("returns the mac", func(t *testing.T) {
+		client := serve(t, http.StatusOK, `{"KeyId":"arn:aws:kms:eu-west-1:1:key/abc","Mac":"c2VjcmV0LW1hYw==","MacAlgorithm":"HMAC_SHA_256"}`)
+
+		mac, err := client.GenerateMac(context.Background(), "alias/ledger-metadata", []byte("tenant"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "secret-mac", string(mac))
+	})
+
+	t.Run("returns error when access is denied", func(t *testing.T) {
+		client := serve(t, http.StatusBadRequest, `{"__type":"AccessDeniedException"}`)
+
+		_, err := client.GenerateMac(context.Background(), "alias/ledger-metadata", []byte("tenant"))
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,95 @@
+// Package secrets fetches database credentials from external secret stores,
+// or generates IAM authentication tokens, so static passwords need not be
+// kept in the environment. It also derives key material with AWS KMS for
+// the metadata keyring.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/hesabFun/ledger/internal/config"
+)
+
+// Credentials are a database user name and password
+type Credentials struct {
+	Username string
+	Password string
+	// ExpiresAt is when a token password stops being accepted for new
+	// connections, zero for passwords without expiry
+	ExpiresAt time.Time
+}
+
+// IsToken reports whether the password is a short-lived token. Tokens only
+// authenticate new connections, so established ones outlive them.
+func (c Credentials) IsToken() bool {
+	return !c.ExpiresAt.IsZero()
+}
+
+// Provider fetches the current database credentials
+type Provider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// httpTimeout bounds a single request to a secret store
+const httpTimeout = 10 * time.Second
+
+// NewProvider returns the provider for the configured credentials source, or
+// nil for static credentials
+func NewProvider(ctx context.Context, db config.DatabaseConfig) (Provider, error) {
+	cfg := db.Credentials
+
+	switch cfg.Source {
+	case config.CredentialsStatic:
+		return nil, nil
+	case config.CredentialsVault:
+		return NewVaultProvider(cfg.Vault.Addr, cfg.Vault.Token, cfg.Vault.Path)
+	case config.CredentialsAWSSecretsManager:
+		awsCfg, err := LoadAWSConfig(ctx, cfg.AWS.Region)
+		if err != nil {
+			return nil, err
+		}
+		return NewAWSSecretsManagerProvider(awsCfg, cfg.AWS.SecretID), nil
+	case config.CredentialsAWSRDSIAM:
+		awsCfg, err := LoadAWSConfig(ctx, cfg.AWS.Region)
+		if err != nil {
+			return nil, err
+		}
+		return NewRDSIAMProvider(db.Host, db.Port, db.User, awsCfg), nil
+	case config.CredentialsGCPCloudSQLIAM:
+		return NewCloudSQLIAMProvider(&http.Client{Timeout: httpTimeout}, db.User), nil
+	default:
+		return nil, fmt.Errorf("unknown database credentials source %q", cfg.Source)
+	}
+}
+
+// LoadAWSConfig loads the AWS configuration for region with the default
+// credential chain: environment variables, shared config files, web identity
+// tokens (IRSA), and the ECS task or EC2 instance role. Temporary
+// credentials are refreshed before they expire.
+func LoadAWSConfig(ctx context.Context, region string) (aws.Config, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx,
+		awsconfig.WithRegion(region),
+		awsconfig.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(httpTimeout)),
+	)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// credentialsFromMap extracts credentials from a secret holding "username"
+// and "password" keys, the layout used by Vault and by RDS-managed secrets
+func credentialsFromMap(secret map[string]interface{}) (Credentials, error) {
+	username, _ := secret["username"].(string)
+	password, _ := secret["password"].(string)
+	if username == "" || password == "" {
+		return Credentials{}, fmt.Errorf("secret does not contain a username and password")
+	}
+	return Credentials{Username: username, Password: password}, nil
+}
@@ -0,0 +1,142 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAWSConfig returns an AWS configuration sending requests to server,
+// signed with static credentials
+func testAWSConfig(server *httptest.Server) aws.Config {
+	return aws.Config{
+		Region:           "eu-west-1",
+		Credentials:      credentials.NewStaticCredentialsProvider("AKID", "secret", "session"),
+		BaseEndpoint:     aws.String(server.URL),
+		HTTPClient:       server.Client(),
+		RetryMaxAttempts: 1,
+	}
+}
+
+func TestVaultProvider_Credentials(t *testing.T) {
+	serve := func(t *testing.T, status int, body string) *VaultProvider {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "vault-token", r.Header.Get("X-Vault-Token"))
+			if r.URL.Path == "/v1/auth/token/lookup-self" {
+				_, _ = w.Write([]byte(`{"data":{"ttl":0,"renewable":false}}`))
+				return
+			}
+			assert.Equal(t, "/v1/secret/data/ledger/db", r.URL.Path)
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+
+		provider, err := NewVaultProvider(server.URL+"/", "vault-token", "/secret/data/ledger/db")
+		require.NoError(t, err)
+		return provider
+	}
+
+	t.Run("reads a KV v2 secret", func(t *testing.T) {
+		provider := serve(t, http.StatusOK, `{"data":{"data":{"username":"ledger","password":"s3cret"},"metadata":{"version":3}}}`)
+
+		creds, err := provider.Credentials(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, Credentials{Username: "ledger", Password: "s3cret"}, creds)
+	})
+
+	t.Run("reads dynamic database credentials", func(t *testing.T) {
+		provider := serve(t, http.StatusOK, `{"lease_duration":3600,"data":{"username":"v-ledger-abc","password":"generated"}}`)
+
+		creds, err := provider.Credentials(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, "v-ledger-abc", creds.Username)
+	})
+
+	t.Run("returns error when access is denied", func(t *testing.T) {
+		provider := serve(t, http.StatusForbidden, `{"errors":["permission denied"]}`)
+
+		_, err := provider.Credentials(context.Background())
+
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error when the secret has no password", func(t *testing.T) {
+		provider := serve(t, http.StatusOK, `{"data":{"data":{"username":"ledger"}}}`)
+
+		_, err := provider.Credentials(context.Background())
+
+		assert.Error(t, err)
+	})
+}
+
+func TestVaultProvider_RenewsToken(t *testing.T) {
+	var lookups, renewals int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			lookups++
+			_, _ = w.Write([]byte(`{"data":{"ttl":3600,"renewable":true}}`))
+		case "/v1/auth/token/renew-self":
+			renewals++
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"vault-token","lease_duration":3600,"renewable":true}}`))
+		default:
+			_, _ = w.Write([]byte(`{"data":{"username":"ledger","password":"s3cret"}}`))
+		}
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)
+	provider, err := NewVaultProvider(server.URL, "vault-token", "database/creds/ledger")
+	require.NoError(t, err)
+	provider.now = func() time.Time { return now }
+
+	_, err = provider.Credentials(context.Background())
+	require.NoError(t, err)
+	_, err = provider.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, lookups)
+	assert.Equal(t, 0, renewals)
+
+	now = now.Add(30 * time.Minute)
+	_, err = provider.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, lookups)
+	assert.Equal(t, 1, renewals)
+}
+
+func TestAWSSecretsManagerProvider_Credentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request")
+
+		var req map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "prod/ledger/db", req["SecretId"])
+
+		secret, _ := json.Marshal(map[string]interface{}{"username": "ledger", "password": "rotated", "port": 5432})
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": string(secret)})
+	}))
+	defer server.Close()
+
+	provider := NewAWSSecretsManagerProvider(testAWSConfig(server), "prod/ledger/db")
+
+	creds, err := provider.Credentials(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{Username: "ledger", Password: "rotated"}, creds)
+}
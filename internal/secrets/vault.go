@@ -0,0 +1,122 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// VaultProvider reads database credentials from HashiCorp Vault, either a
+// KV v2 secret or dynamic credentials of the database secrets engine. A
+// renewable token is renewed once half of its TTL has passed, checked each
+// time credentials are read.
+type VaultProvider struct {
+	client *vault.Client
+	path   string
+	now    func() time.Time
+
+	mu sync.Mutex
+	// tokenChecked is set once the token has been looked up
+	tokenChecked bool
+	// renewAt is when the token is next renewed, zero when it is not
+	// renewable
+	renewAt time.Time
+}
+
+// NewVaultProvider creates a provider reading the secret at path. TLS and
+// the other client settings come from the standard VAULT_* environment
+// variables; addr and token, when set, take precedence.
+func NewVaultProvider(addr, token, path string) (*VaultProvider, error) {
+	cfg := vault.DefaultConfig()
+	if cfg.Error != nil {
+		return nil, fmt.Errorf("failed to configure vault client: %w", cfg.Error)
+	}
+	if addr != "" {
+		cfg.Address = addr
+	}
+	cfg.Timeout = httpTimeout
+
+	client, err := vault.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault client: %w", err)
+	}
+	if token != "" {
+		client.SetToken(token)
+	}
+
+	return &VaultProvider{
+		client: client,
+		path:   strings.Trim(path, "/"),
+		now:    time.Now,
+	}, nil
+}
+
+// Credentials reads the secret and returns its username and password
+func (p *VaultProvider) Credentials(ctx context.Context) (Credentials, error) {
+	if err := p.renewToken(ctx); err != nil {
+		return Credentials{}, err
+	}
+
+	secret, err := p.client.Logical().ReadWithContext(ctx, p.path)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to read vault secret %s: %w", p.path, err)
+	}
+	if secret == nil {
+		return Credentials{}, fmt.Errorf("vault secret %s does not exist", p.path)
+	}
+
+	// KV v2 nests the secret under data.data
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+
+	creds, err := credentialsFromMap(data)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("vault secret %s: %w", p.path, err)
+	}
+	return creds, nil
+}
+
+// renewToken looks the token up on first use and renews it when it is
+// renewable and half of its TTL has passed
+func (p *VaultProvider) renewToken(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if p.tokenChecked && (p.renewAt.IsZero() || now.Before(p.renewAt)) {
+		return nil
+	}
+
+	var secret *vault.Secret
+	var err error
+	if p.tokenChecked {
+		secret, err = p.client.Auth().Token().RenewSelfWithContext(ctx, 0)
+	} else {
+		secret, err = p.client.Auth().Token().LookupSelfWithContext(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to renew vault token: %w", err)
+	}
+
+	ttl, err := secret.TokenTTL()
+	if err != nil {
+		return fmt.Errorf("failed to read vault token ttl: %w", err)
+	}
+	renewable, err := secret.TokenIsRenewable()
+	if err != nil {
+		return fmt.Errorf("failed to read vault token: %w", err)
+	}
+
+	p.tokenChecked = true
+	p.renewAt = time.Time{}
+	if renewable && ttl > 0 {
+		p.renewAt = now.Add(ttl / 2)
+	}
+	return nil
+}
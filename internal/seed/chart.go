@@ -0,0 +1,76 @@
+package seed
+
+import "github.com/hesabFun/ledger/internal/repository"
+
+// chartAccount is an account of the demo chart of accounts
+type chartAccount struct {
+	number      string
+	name        string
+	accountType string
+	parent      string
+	description string
+}
+
+// Account numbers the demo postings refer to
+const (
+	accountCash                    = "1010"
+	accountBank                    = "1020"
+	accountReceivable              = "1100"
+	accountInventory               = "1200"
+	accountEquipment               = "1500"
+	accountAccumulatedDepreciation = "1510"
+	accountPayable                 = "2010"
+	accountSalesTax                = "2100"
+	accountLoan                    = "2500"
+	accountCapital                 = "3010"
+	accountProductSales            = "4010"
+	accountServiceRevenue          = "4020"
+	accountCostOfGoodsSold         = "5010"
+	accountRent                    = "6010"
+	accountSalaries                = "6020"
+	accountUtilities               = "6030"
+	accountMarketing               = "6040"
+	accountSoftware                = "6050"
+	accountDepreciation            = "6060"
+	accountBankFees                = "6070"
+	accountInterest                = "6080"
+)
+
+// chart is the demo chart of accounts of a small trading and services
+// company. Parents are listed before their children.
+var chart = []chartAccount{
+	{number: "1000", name: "Assets", accountType: repository.AccountTypeAsset},
+	{number: accountCash, name: "Cash on Hand", accountType: repository.AccountTypeAsset, parent: "1000"},
+	{number: accountBank, name: "Bank - Operating", accountType: repository.AccountTypeAsset, parent: "1000"},
+	{number: accountReceivable, name: "Accounts Receivable", accountType: repository.AccountTypeAsset, parent: "1000"},
+	{number: accountInventory, name: "Inventory", accountType: repository.AccountTypeAsset, parent: "1000"},
+	{number: accountEquipment, name: "Equipment", accountType: repository.AccountTypeAsset, parent: "1000"},
+	{number: accountAccumulatedDepreciation, name: "Accumulated Depreciation", accountType: repository.AccountTypeAsset, parent: "1000",
+		description: "Contra asset reducing the carrying amount of equipment"},
+
+	{number: "2000", name: "Liabilities", accountType: repository.AccountTypeLiability},
+	{number: accountPayable, name: "Accounts Payable", accountType: repository.AccountTypeLiability, parent: "2000"},
+	{number: accountSalesTax, name: "Sales Tax Payable", accountType: repository.AccountTypeLiability, parent: "2000"},
+	{number: accountLoan, name: "Bank Loan", accountType: repository.AccountTypeLiability, parent: "2000"},
+
+	{number: "3000", name: "Equity", accountType: repository.AccountTypeEquity},
+	{number: accountCapital, name: "Owner's Capital", accountType: repository.AccountTypeEquity, parent: "3000"},
+	{number: "3100", name: "Retained Earnings", accountType: repository.AccountTypeEquity, parent: "3000"},
+
+	{number: "4000", name: "Revenue", accountType: repository.AccountTypeRevenue},
+	{number: accountProductSales, name: "Product Sales", accountType: repository.AccountTypeRevenue, parent: "4000"},
+	{number: accountServiceRevenue, name: "Service Revenue", accountType: repository.AccountTypeRevenue, parent: "4000"},
+
+	{number: "5000", name: "Cost of Sales", accountType: repository.AccountTypeExpense},
+	{number: accountCostOfGoodsSold, name: "Cost of Goods Sold", accountType: repository.AccountTypeExpense, parent: "5000"},
+
+	{number: "6000", name: "Operating Expenses", accountType: repository.AccountTypeExpense},
+	{number: accountRent, name: "Rent", accountType: repository.AccountTypeExpense, parent: "6000"},
+	{number: accountSalaries, name: "Salaries and Wages", accountType: repository.AccountTypeExpense, parent: "6000"},
+	{number: accountUtilities, name: "Utilities", accountType: repository.AccountTypeExpense, parent: "6000"},
+	{number: accountMarketing, name: "Marketing", accountType: repository.AccountTypeExpense, parent: "6000"},
+	{number: accountSoftware, name: "Software Subscriptions", accountType: repository.AccountTypeExpense, parent: "6000"},
+	{number: accountDepreciation, name: "Depreciation", accountType: repository.AccountTypeExpense, parent: "6000"},
+	{number: accountBankFees, name: "Bank Fees", accountType: repository.AccountTypeExpense, parent: "6000"},
+	{number: accountInterest, name: "Interest Expense", accountType: repository.AccountTypeExpense, parent: "6000"},
+}
@@ -0,0 +1,382 @@
+// Package seed creates a demo tenant with a chart of accounts and several
+// months of realistic journal entries, so there is data to explore right
+// after installing the ledger.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// Admin creates tenants; it is implemented by service.AdminService
+type Admin interface {
+	CreateTenant(ctx context.Context, req *pb.CreateTenantRequest) (*pb.CreateTenantResponse, error)
+}
+
+// Ledger creates accounts and postings; it is implemented by
+// service.LedgerService
+type Ledger interface {
+	ListAccountTypes(ctx context.Context, req *pb.ListAccountTypesRequest) (*pb.ListAccountTypesResponse, error)
+	CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error)
+	CreateJournalEntry(ctx context.Context, req *pb.CreateJournalEntryRequest) (*pb.CreateJournalEntryResponse, error)
+}
+
+// Options controls the generated demo data
+type Options struct {
+	TenantName   string
+	CurrencyCode string
+	// Months of history to generate, ending with the month of Now
+	Months int
+	// Seed makes the generated amounts and dates reproducible
+	Seed uint64
+	// Now is the date of the latest postings; no entry is dated after it
+	Now time.Time
+}
+
+// Result summarizes the created demo data
+type Result struct {
+	TenantID string
+	Accounts int
+	Entries  int
+}
+
+// maxMonths bounds the generated history
+const maxMonths = 36
+
+// Run creates the demo tenant, its chart of accounts and its postings. The
+// postings go through the ledger service, so they are validated like any
+// other client's.
+func Run(ctx context.Context, admin Admin, ledger Ledger, opts Options) (*Result, error) {
+	if opts.TenantName == "" {
+		return nil, errors.New("tenant name is required")
+	}
+	if opts.Months < 1 || opts.Months > maxMonths {
+		return nil, fmt.Errorf("months must be between 1 and %d", maxMonths)
+	}
+
+	tenant, err := admin.CreateTenant(ctx, &pb.CreateTenantRequest{Name: opts.TenantName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tenant: %w", err)
+	}
+	result := &Result{TenantID: tenant.TenantId}
+
+	accounts, err := createAccounts(ctx, ledger, tenant.TenantId, opts.CurrencyCode)
+	if err != nil {
+		return nil, err
+	}
+	result.Accounts = len(accounts)
+
+	for _, e := range generate(opts) {
+		req := &pb.CreateJournalEntryRequest{
+			TenantId:        tenant.TenantId,
+			ReferenceNumber: e.reference,
+			Description:     e.description,
+			EntryDate:       timestamppb.New(e.date),
+			Lines:           make([]*pb.JournalEntryLine, len(e.lines)),
+		}
+		for i, l := range e.lines {
+			req.Lines[i] = &pb.JournalEntryLine{
+				AccountId: accounts[l.account],
+				Debit:     money(l.debit),
+				Credit:    money(l.credit),
+			}
+		}
+
+		if _, err := ledger.CreateJournalEntry(ctx, req); err != nil {
+			return nil, fmt.Errorf("failed to post %s: %w", e.reference, err)
+		}
+		result.Entries++
+	}
+
+	return result, nil
+}
+
+// createAccounts creates the chart of accounts and returns the account IDs
+// by account number
+func createAccounts(ctx context.Context, ledger Ledger, tenantID, currencyCode string) (map[string]string, error) {
+	types, err := ledger.ListAccountTypes(ctx, &pb.ListAccountTypesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account types: %w", err)
+	}
+	typeIDs := make(map[string]int32, len(types.AccountTypes))
+	for _, t := range types.AccountTypes {
+		typeIDs[t.Code] = t.Id
+	}
+
+	accounts := make(map[string]string, len(chart))
+	for _, a := range chart {
+		typeID, ok := typeIDs[a.accountType]
+		if !ok {
+			return nil, fmt.Errorf("account type %s is not installed", a.accountType)
+		}
+
+		req := &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: a.number,
+			Name:          a.name,
+			Description:   a.description,
+			AccountTypeId: typeID,
+			CurrencyCode:  currencyCode,
+		}
+		if a.parent != "" {
+			parentID := accounts[a.parent]
+			req.ParentAccountId = &parentID
+		}
+
+		resp, err := ledger.CreateAccount(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create account %s: %w", a.number, err)
+		}
+		accounts[a.number] = resp.AccountId
+	}
+
+	return accounts, nil
+}
+
+// entry is a generated posting; amounts are in cents
+type entry struct {
+	date        time.Time
+	prefix      string
+	reference   string
+	description string
+	lines       []line
+}
+
+type line struct {
+	account string
+	debit   int64
+	credit  int64
+}
+
+func debit(account string, cents int64) line  { return line{account: account, debit: cents} }
+func credit(account string, cents int64) line { return line{account: account, credit: cents} }
+
+// Business assumptions of the demo company
+const (
+	salesTaxPercent     = 8
+	costOfGoodsPercent  = 45
+	equipmentCost       = 36_000_00
+	equipmentLifeMonths = 60
+	loanAmount          = 60_000_00
+	loanRepayment       = 1_000_00
+	loanInterestPercent = 6
+	customerTermsDays   = 30
+	supplierTermsDays   = 30
+	monthlyRent         = 4_500_00
+	monthlyBankFee      = 25_00
+	salaryRunsPerMonth  = 2
+	minProductInvoices  = 8
+	maxProductInvoices  = 14
+)
+
+// generator builds the postings of the demo company
+type generator struct {
+	rng     *rand.Rand
+	now     time.Time
+	entries []entry
+}
+
+// generate returns the demo postings dated up to opts.Now, oldest first
+func generate(opts Options) []entry {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	g := &generator{
+		rng: rand.New(rand.NewPCG(opts.Seed, opts.Seed)),
+		now: now,
+	}
+
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -(opts.Months - 1), 0)
+	g.opening(start)
+
+	loanBalance := int64(loanAmount)
+	var previousTax int64
+	for month := 0; month < opts.Months; month++ {
+		first := start.AddDate(0, month, 0)
+		tax := g.month(first, previousTax, &loanBalance)
+		previousTax = tax
+	}
+
+	sort.SliceStable(g.entries, func(i, j int) bool {
+		return g.entries[i].date.Before(g.entries[j].date)
+	})
+
+	// Number the documents of each kind in date order
+	counters := make(map[string]int)
+	for i := range g.entries {
+		e := &g.entries[i]
+		counters[e.prefix]++
+		e.reference = fmt.Sprintf("%s-%04d", e.prefix, counters[e.prefix])
+	}
+
+	return g.entries
+}
+
+// add records a posting unless it is dated after now
+func (g *generator) add(date time.Time, prefix, description string, lines ...line) {
+	if date.After(g.now) {
+		return
+	}
+	g.entries = append(g.entries, entry{
+		date:        date,
+		prefix:      prefix,
+		description: description,
+		lines:       lines,
+	})
+}
+
+// amount returns a random amount in cents between min and max whole units
+func (g *generator) amount(min, max int64) int64 {
+	return (min+g.rng.Int64N(max-min+1))*100 + g.rng.Int64N(100)
+}
+
+// day returns a random day of the month starting at first
+func (g *generator) day(first time.Time) time.Time {
+	days := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, g.rng.IntN(days))
+}
+
+// opening funds the company and buys its equipment and first inventory
+func (g *generator) opening(first time.Time) {
+	g.add(first, "JE", "Owner capital contribution",
+		debit(accountBank, 150_000_00), credit(accountCapital, 150_000_00))
+	g.add(first.AddDate(0, 0, 1), "JE", "Bank loan drawdown",
+		debit(accountBank, loanAmount), credit(accountLoan, loanAmount))
+	g.add(first.AddDate(0, 0, 2), "JE", "Purchase of warehouse equipment",
+		debit(accountEquipment, equipmentCost), credit(accountBank, equipmentCost))
+	g.add(first.AddDate(0, 0, 3), "BILL", "Opening inventory purchase",
+		debit(accountInventory, 20_000_00), credit(accountPayable, 20_000_00))
+	g.add(first.AddDate(0, 0, 3+supplierTermsDays), "PAY", "Payment for opening inventory",
+		debit(accountPayable, 20_000_00), credit(accountBank, 20_000_00))
+}
+
+// month generates the postings of the month starting at first and returns
+// the sales tax it collected, which is remitted the following month
+func (g *generator) month(first time.Time, previousTax int64, loanBalance *int64) int64 {
+	last := first.AddDate(0, 1, -1)
+	var tax, cashTakings int64
+
+	g.add(first, "JE", "Office and warehouse rent",
+		debit(accountRent, monthlyRent), credit(accountBank, monthlyRent))
+
+	software := g.amount(300, 900)
+	g.add(first.AddDate(0, 0, 4), "JE", "Software subscriptions",
+		debit(accountSoftware, software), credit(accountBank, software))
+
+	// Product sales on credit, with the cost of the goods shipped
+	invoices := minProductInvoices + g.rng.IntN(maxProductInvoices-minProductInvoices+1)
+	for i := 0; i < invoices; i++ {
+		date := g.day(first)
+		net := g.amount(800, 6_000)
+		invoiceTax := net * salesTaxPercent / 100
+		cost := net * costOfGoodsPercent / 100
+		tax += invoiceTax
+
+		g.add(date, "INV", "Product sale on account",
+			debit(accountReceivable, net+invoiceTax),
+			credit(accountProductSales, net),
+			credit(accountSalesTax, invoiceTax),
+			debit(accountCostOfGoodsSold, cost),
+			credit(accountInventory, cost))
+		g.add(date.AddDate(0, 0, customerTermsDays-5+g.rng.IntN(15)), "RCPT", "Customer payment received",
+			debit(accountBank, net+invoiceTax), credit(accountReceivable, net+invoiceTax))
+	}
+
+	// Consulting and installation services, not subject to sales tax
+	for i := 0; i < 3+g.rng.IntN(3); i++ {
+		date := g.day(first)
+		fee := g.amount(1_500, 7_500)
+		g.add(date, "INV", "Installation and consulting services",
+			debit(accountReceivable, fee), credit(accountServiceRevenue, fee))
+		g.add(date.AddDate(0, 0, customerTermsDays+g.rng.IntN(10)), "RCPT", "Customer payment received",
+			debit(accountBank, fee), credit(accountReceivable, fee))
+	}
+
+	// Counter sales paid in cash
+	for i := 0; i < 2+g.rng.IntN(3); i++ {
+		net := g.amount(100, 900)
+		saleTax := net * salesTaxPercent / 100
+		cost := net * costOfGoodsPercent / 100
+		tax += saleTax
+		cashTakings += net + saleTax
+
+		g.add(g.day(first), "CS", "Counter sale",
+			debit(accountCash, net+saleTax),
+			credit(accountProductSales, net),
+			credit(accountSalesTax, saleTax),
+			debit(accountCostOfGoodsSold, cost),
+			credit(accountInventory, cost))
+	}
+
+	// Restocking on supplier credit
+	for i := 0; i < 2; i++ {
+		date := first.AddDate(0, 0, 2+i*14)
+		stock := g.amount(6_000, 14_000)
+		g.add(date, "BILL", "Inventory purchase",
+			debit(accountInventory, stock), credit(accountPayable, stock))
+		g.add(date.AddDate(0, 0, supplierTermsDays), "PAY", "Supplier payment",
+			debit(accountPayable, stock), credit(accountBank, stock))
+	}
+
+	for i := 0; i < salaryRunsPerMonth; i++ {
+		payday := first.AddDate(0, 0, 14)
+		if i == salaryRunsPerMonth-1 {
+			payday = last
+		}
+		payroll := g.amount(9_000, 11_000)
+		g.add(payday, "PAYROLL", "Salaries and wages",
+			debit(accountSalaries, payroll), credit(accountBank, payroll))
+	}
+
+	utilities := g.amount(250, 600)
+	g.add(first.AddDate(0, 0, 19), "JE", "Electricity, water and internet",
+		debit(accountUtilities, utilities), credit(accountBank, utilities))
+
+	marketing := g.amount(500, 2_500)
+	g.add(g.day(first), "JE", "Online advertising campaign",
+		debit(accountMarketing, marketing), credit(accountBank, marketing))
+
+	if previousTax > 0 {
+		g.add(first.AddDate(0, 0, 19), "TAX", "Sales tax remittance for the previous month",
+			debit(accountSalesTax, previousTax), credit(accountBank, previousTax))
+	}
+
+	if cashTakings > 0 {
+		g.add(last, "JE", "Cash takings deposited at the bank",
+			debit(accountBank, cashTakings), credit(accountCash, cashTakings))
+	}
+
+	depreciation := int64(equipmentCost / equipmentLifeMonths)
+	g.add(last, "JE", "Monthly depreciation of equipment",
+		debit(accountDepreciation, depreciation), credit(accountAccumulatedDepreciation, depreciation))
+
+	interest := *loanBalance * loanInterestPercent / 100 / 12
+	repayment := min(int64(loanRepayment), *loanBalance)
+	if !last.After(g.now) {
+		*loanBalance -= repayment
+	}
+	g.add(last, "JE", "Loan instalment",
+		debit(accountLoan, repayment), debit(accountInterest, interest), credit(accountBank, repayment+interest))
+
+	g.add(last, "JE", "Bank account fees",
+		debit(accountBankFees, monthlyBankFee), credit(accountBank, monthlyBankFee))
+
+	return tax
+}
+
+// money formats cents as a decimal amount
+func money(cents int64) string {
+	return decimal.New(cents, -2).StringFixed(2)
+}
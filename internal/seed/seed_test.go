@@ -0,0 +1,125 @@
+package seed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/internal/service"
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var testNow = time.Date(2024, 6, 18, 0, 0, 0, 0, time.UTC)
+
+func newServices() (*memory.Store, *service.AdminService, *service.LedgerService) {
+	store := memory.NewStore()
+	tenants := memory.NewTenantRepository(store)
+	reference := memory.NewReferenceRepository(store)
+	admin := service.NewAdminService(tenants, reference, nil)
+	ledger := service.NewLedgerService(tenants, memory.NewAccountRepository(store), memory.NewJournalRepository(store), reference)
+	return store, admin, ledger
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	store, admin, ledger := newServices()
+
+	result, err := Run(ctx, admin, ledger, Options{
+		TenantName:   "Demo Company",
+		CurrencyCode: "USD",
+		Months:       6,
+		Seed:         1,
+		Now:          testNow,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, len(chart), result.Accounts)
+	assert.Greater(t, result.Entries, 6*20)
+
+	tenantID := uuidOf(t, result.TenantID)
+	accounts, total, err := memory.NewAccountRepository(store).List(ctx, tenantID, repository.AccountFilter{}, 100, 0)
+	require.NoError(t, err)
+	assert.Equal(t, len(chart), total)
+
+	// The trial balance of the tenant balances
+	debits, credits := decimal.Zero, decimal.Zero
+	for _, account := range accounts {
+		balance, err := memory.NewAccountRepository(store).GetBalance(ctx, tenantID, account.ID)
+		require.NoError(t, err)
+		debits = debits.Add(balance.DebitBalance)
+		credits = credits.Add(balance.CreditBalance)
+	}
+	assert.True(t, debits.Equal(credits))
+	assert.True(t, debits.IsPositive())
+
+	// History starts at the first of the month five months back and stops today
+	var first, last time.Time
+	err = memory.NewJournalRepository(store).Stream(ctx, tenantID, nil, nil, func(entry *repository.JournalEntry) error {
+		if first.IsZero() {
+			first = entry.EntryDate
+		}
+		last = entry.EntryDate
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), first)
+	assert.False(t, last.After(testNow))
+
+	t.Run("rejects an existing tenant name", func(t *testing.T) {
+		_, err := Run(ctx, admin, ledger, Options{TenantName: "Demo Company", CurrencyCode: "USD", Months: 1, Now: testNow})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	})
+
+	t.Run("validates options", func(t *testing.T) {
+		_, err := Run(ctx, admin, ledger, Options{TenantName: "Other", CurrencyCode: "USD", Months: 0})
+		assert.Error(t, err)
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	opts := Options{Months: 3, Seed: 42, Now: testNow}
+
+	entries := generate(opts)
+	assert.Equal(t, entries, generate(opts), "the same seed generates the same entries")
+
+	references := make(map[string]bool)
+	for i, e := range entries {
+		var debits, credits int64
+		for _, l := range e.lines {
+			debits += l.debit
+			credits += l.credit
+			assert.True(t, (l.debit > 0) != (l.credit > 0), "%s has a one-sided line", e.reference)
+		}
+		assert.Equal(t, debits, credits, "%s balances", e.reference)
+
+		assert.False(t, references[e.reference], "%s is unique", e.reference)
+		references[e.reference] = true
+
+		if i > 0 {
+			assert.False(t, e.date.Before(entries[i-1].date), "entries are in date order")
+		}
+	}
+	assert.Equal(t, "INV-0001", firstWithPrefix(entries, "INV").reference)
+}
+
+func firstWithPrefix(entries []entry, prefix string) entry {
+	for _, e := range entries {
+		if e.prefix == prefix {
+			return e
+		}
+	}
+	return entry{}
+}
+
+func uuidOf(t *testing.T, s string) uuid.UUID {
+	t.Helper()
+	id, err := uuid.Parse(s)
+	require.NoError(t, err)
+	return id
+}
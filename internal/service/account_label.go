@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// Limits on account labels and external identifiers
+const (
+	maxAccountLabels    = 64
+	maxLabelKeyLength   = 63
+	maxLabelValueLength = 255
+	maxExternalIDLength = 255
+)
+
+// SetAccountLabels replaces the labels of an account
+func (s *LedgerService) SetAccountLabels(ctx context.Context, req *pb.SetAccountLabelsRequest) (*pb.SetAccountLabelsResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkLabels("labels", req.Labels); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.SetLabels(ctx, tenantID, accountID, req.Labels)
+	if err != nil {
+		return nil, repositoryError("set account labels", err)
+	}
+
+	return &pb.SetAccountLabelsResponse{
+		Account: s.accountToProto(account),
+	}, nil
+}
+
+// SetAccountExternalId maps an account to its identifier in a source
+// system, such as an ERP or CRM, or removes the mapping when the identifier
+// is empty. An identifier already mapped to another account of the tenant
+// is rejected with ALREADY_EXISTS.
+func (s *LedgerService) SetAccountExternalId(ctx context.Context, req *pb.SetAccountExternalIdRequest) (*pb.SetAccountExternalIdResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkLabelKey("source", req.Source); err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(req.ExternalId) > maxExternalIDLength {
+		return nil, invalidField("external_id", fmt.Sprintf("external ID must be at most %d characters", maxExternalIDLength))
+	}
+
+	account, err := s.accountRepo.SetExternalID(ctx, tenantID, accountID, req.Source, req.ExternalId)
+	if err != nil {
+		return nil, repositoryError("set external ID", err)
+	}
+
+	return &pb.SetAccountExternalIdResponse{
+		Account: s.accountToProto(account),
+	}, nil
+}
+
+// GetAccountByExternalId retrieves the account mapped to an identifier of a
+// source system, so integrations can address accounts by their own keys
+func (s *LedgerService) GetAccountByExternalId(ctx context.Context, req *pb.GetAccountByExternalIdRequest) (*pb.GetAccountByExternalIdResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkLabelKey("source", req.Source); err != nil {
+		return nil, err
+	}
+	if req.ExternalId == "" {
+		return nil, invalidField("external_id", "external ID is required")
+	}
+
+	account, err := s.accountRepo.GetByExternalID(ctx, tenantID, req.Source, req.ExternalId)
+	if err != nil {
+		return nil, repositoryError("get account by external ID", err)
+	}
+
+	return &pb.GetAccountByExternalIdResponse{
+		Account: s.accountToProto(account),
+	}, nil
+}
+
+// checkLabels checks the keys and values of account labels
+func checkLabels(field string, labels map[string]string) error {
+	if len(labels) > maxAccountLabels {
+		return invalidField(field, fmt.Sprintf("an account may have at most %d labels", maxAccountLabels))
+	}
+	for key, value := range labels {
+		if err := checkLabelKey(field, key); err != nil {
+			return err
+		}
+		if utf8.RuneCountInString(value) > maxLabelValueLength {
+			return invalidField(field, fmt.Sprintf("label %q must be at most %d characters", key, maxLabelValueLength))
+		}
+	}
+	return nil
+}
+
+// checkExternalIDs checks the sources and identifiers an account is created with
+func checkExternalIDs(field string, externalIDs map[string]string) error {
+	for source, externalID := range externalIDs {
+		if err := checkLabelKey(field, source); err != nil {
+			return err
+		}
+		if externalID == "" || utf8.RuneCountInString(externalID) > maxExternalIDLength {
+			return invalidField(field, fmt.Sprintf("external ID for %q must be 1 to %d characters", source, maxExternalIDLength))
+		}
+	}
+	return nil
+}
+
+// checkLabelKey checks a label key or source system name: 1 to 63 ASCII
+// letters, digits, '_', '-' and '.', starting with a letter or digit
+func checkLabelKey(field, key string) error {
+	valid := key != "" && len(key) <= maxLabelKeyLength
+	for i := 0; valid && i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case i > 0 && (c == '_' || c == '-' || c == '.'):
+		default:
+			valid = false
+		}
+	}
+	if !valid {
+		return invalidField(field, fmt.Sprintf("%q must be 1 to %d letters, digits, '_', '-' or '.', starting with a letter or digit", key, maxLabelKeyLength))
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+func TestLedgerService_AccountLabelsAndExternalIDs(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	tenants := memory.NewTenantRepository(store)
+	service := NewLedgerService(
+		tenants,
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+	)
+
+	tenant, err := tenants.Create(ctx, "erp", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	createAccount := func(tenantID, number string, labels, externalIDs map[string]string) (*pb.CreateAccountResponse, error) {
+		return service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: 1,
+			CurrencyCode:  "USD",
+			Labels:        labels,
+			ExternalIds:   externalIDs,
+		})
+	}
+	getByExternalID := func(tenantID, source, externalID string) (*pb.GetAccountByExternalIdResponse, error) {
+		return service.GetAccountByExternalId(ctx, &pb.GetAccountByExternalIdRequest{TenantId: tenantID, Source: source, ExternalId: externalID})
+	}
+
+	cash, err := createAccount(tenantID, "1000", map[string]string{"region": "emea", "team": "treasury"}, map[string]string{"sap": "100000", "crm": "C-1"})
+	require.NoError(t, err)
+	bank, err := createAccount(tenantID, "1100", map[string]string{"region": "emea"}, nil)
+	require.NoError(t, err)
+
+	t.Run("looks accounts up by their external IDs", func(t *testing.T) {
+		resp, err := getByExternalID(tenantID, "sap", "100000")
+		require.NoError(t, err)
+		assert.Equal(t, cash.AccountId, resp.Account.AccountId)
+		assert.Equal(t, map[string]string{"sap": "100000", "crm": "C-1"}, resp.Account.ExternalIds)
+		assert.Equal(t, map[string]string{"region": "emea", "team": "treasury"}, resp.Account.Labels)
+
+		// Identifiers are scoped to their source system
+		_, err = getByExternalID(tenantID, "crm", "100000")
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("keeps external IDs unique per source within a tenant", func(t *testing.T) {
+		_, err := createAccount(tenantID, "1200", nil, map[string]string{"sap": "100000"})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+		_, err = service.SetAccountExternalId(ctx, &pb.SetAccountExternalIdRequest{TenantId: tenantID, AccountId: bank.AccountId, Source: "sap", ExternalId: "100000"})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+		// Another tenant may use the same identifier
+		other, err := tenants.Create(ctx, "other", nil)
+		require.NoError(t, err)
+		_, err = createAccount(other.ID.String(), "1000", nil, map[string]string{"sap": "100000"})
+		require.NoError(t, err)
+		resp, err := getByExternalID(tenantID, "sap", "100000")
+		require.NoError(t, err)
+		assert.Equal(t, cash.AccountId, resp.Account.AccountId)
+	})
+
+	t.Run("replaces and removes external IDs", func(t *testing.T) {
+		set := func(externalID string) (*pb.SetAccountExternalIdResponse, error) {
+			return service.SetAccountExternalId(ctx, &pb.SetAccountExternalIdRequest{TenantId: tenantID, AccountId: bank.AccountId, Source: "sap", ExternalId: externalID})
+		}
+
+		resp, err := set("110000")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"sap": "110000"}, resp.Account.ExternalIds)
+
+		resp, err = set("110001")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"sap": "110001"}, resp.Account.ExternalIds)
+		_, err = getByExternalID(tenantID, "sap", "110000")
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		resp, err = set("")
+		require.NoError(t, err)
+		assert.Empty(t, resp.Account.ExternalIds)
+		_, err = getByExternalID(tenantID, "sap", "110001")
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("replaces labels and lists accounts by label", func(t *testing.T) {
+		listByLabels := func(labels map[string]string) []string {
+			resp, err := service.ListAccounts(ctx, &pb.ListAccountsRequest{TenantId: tenantID, Labels: labels, SortBy: pb.AccountSortField_ACCOUNT_SORT_FIELD_NUMBER})
+			require.NoError(t, err)
+			numbers := make([]string, len(resp.Accounts))
+			for i, account := range resp.Accounts {
+				numbers[i] = account.AccountNumber
+			}
+			return numbers
+		}
+
+		assert.Equal(t, []string{"1000", "1100"}, listByLabels(map[string]string{"region": "emea"}))
+		assert.Equal(t, []string{"1000"}, listByLabels(map[string]string{"region": "emea", "team": "treasury"}))
+
+		resp, err := service.SetAccountLabels(ctx, &pb.SetAccountLabelsRequest{TenantId: tenantID, AccountId: cash.AccountId, Labels: map[string]string{"region": "apac"}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"region": "apac"}, resp.Account.Labels)
+		assert.Equal(t, []string{"1100"}, listByLabels(map[string]string{"region": "emea"}))
+
+		resp, err = service.SetAccountLabels(ctx, &pb.SetAccountLabelsRequest{TenantId: tenantID, AccountId: cash.AccountId})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Account.Labels)
+	})
+
+	t.Run("rejects invalid labels and identifiers", func(t *testing.T) {
+		tooMany := make(map[string]string)
+		for i := 0; i <= maxAccountLabels; i++ {
+			tooMany[fmt.Sprintf("key%d", i)] = "v"
+		}
+
+		tests := []struct {
+			name string
+			call func() error
+		}{
+			{"label key with spaces", func() error {
+				_, err := createAccount(tenantID, "1300", map[string]string{"cost center": "x"}, nil)
+				return err
+			}},
+			{"label key starting with a dash", func() error {
+				_, err := service.SetAccountLabels(ctx, &pb.SetAccountLabelsRequest{TenantId: tenantID, AccountId: bank.AccountId, Labels: map[string]string{"-x": "y"}})
+				return err
+			}},
+			{"label value too long", func() error {
+				_, err := service.SetAccountLabels(ctx, &pb.SetAccountLabelsRequest{TenantId: tenantID, AccountId: bank.AccountId, Labels: map[string]string{"note": strings.Repeat("x", maxLabelValueLength+1)}})
+				return err
+			}},
+			{"too many labels", func() error {
+				_, err := service.SetAccountLabels(ctx, &pb.SetAccountLabelsRequest{TenantId: tenantID, AccountId: bank.AccountId, Labels: tooMany})
+				return err
+			}},
+			{"empty external ID on create", func() error {
+				_, err := createAccount(tenantID, "1300", nil, map[string]string{"sap": ""})
+				return err
+			}},
+			{"missing source", func() error {
+				_, err := service.SetAccountExternalId(ctx, &pb.SetAccountExternalIdRequest{TenantId: tenantID, AccountId: bank.AccountId, ExternalId: "1"})
+				return err
+			}},
+			{"missing external ID on lookup", func() error {
+				_, err := getByExternalID(tenantID, "sap", "")
+				return err
+			}},
+			{"label filter with an invalid key", func() error {
+				_, err := service.ListAccounts(ctx, &pb.ListAccountsRequest{TenantId: tenantID, Labels: map[string]string{"a b": "c"}})
+				return err
+			}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.Equal(t, codes.InvalidArgument, status.Code(tt.call()))
+			})
+		}
+	})
+
+	t.Run("does not map deleted accounts", func(t *testing.T) {
+		_, err := service.DeleteAccount(ctx, &pb.DeleteAccountRequest{TenantId: tenantID, AccountId: cash.AccountId})
+		require.NoError(t, err)
+
+		_, err = getByExternalID(tenantID, "crm", "C-1")
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = service.SetAccountExternalId(ctx, &pb.SetAccountExternalIdRequest{TenantId: tenantID, AccountId: cash.AccountId, Source: "crm", ExternalId: "C-2"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
@@ -0,0 +1,487 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/export"
+	"github.com/hesabFun/ledger/internal/locale"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// AdminService implements the privileged gRPC AdminService
+type AdminService struct {
+	pb.UnimplementedAdminServiceServer
+	tenantRepo      repository.TenantRepositoryInterface
+	referenceRepo   repository.ReferenceRepositoryInterface
+	schemaRepo      repository.SchemaRepositoryInterface
+	quotaRepo       repository.QuotaRepositoryInterface
+	balanceRepo     repository.BalanceRepositoryInterface
+	consistencyRepo repository.ConsistencyRepositoryInterface
+	tenantDataRepo  repository.TenantDataRepositoryInterface
+	eventRepo       repository.EventRepositoryInterface
+	exporter        *export.Exporter
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(
+	tenantRepo repository.TenantRepositoryInterface,
+	referenceRepo repository.ReferenceRepositoryInterface,
+	schemaRepo repository.SchemaRepositoryInterface,
+	opts ...Option,
+) *AdminService {
+	o := applyOptions(opts)
+	return &AdminService{
+		tenantRepo:      tenantRepo,
+		referenceRepo:   referenceRepo,
+		schemaRepo:      schemaRepo,
+		quotaRepo:       o.quotaRepo,
+		balanceRepo:     o.balanceRepo,
+		consistencyRepo: o.consistencyRepo,
+		tenantDataRepo:  o.tenantDataRepo,
+		eventRepo:       o.eventRepo,
+		exporter:        o.exporter,
+	}
+}
+
+// CreateTenant creates a new tenant
+func (s *AdminService) CreateTenant(ctx context.Context, req *pb.CreateTenantRequest) (*pb.CreateTenantResponse, error) {
+	var tenantUUID *uuid.UUID
+	if req.Uuid != nil && *req.Uuid != "" {
+		parsed, err := uuid.Parse(*req.Uuid)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid UUID format")
+		}
+		tenantUUID = &parsed
+	}
+
+	tenant, err := s.tenantRepo.Create(ctx, req.Name, tenantUUID)
+	if err != nil {
+		return nil, repositoryError("create tenant", err)
+	}
+
+	return &pb.CreateTenantResponse{
+		TenantId:  tenant.ID.String(),
+		Name:      tenant.Name,
+		CreatedAt: timestamppb.New(tenant.CreatedAt),
+	}, nil
+}
+
+// GetTenant retrieves a tenant by ID
+func (s *AdminService) GetTenant(ctx context.Context, req *pb.GetTenantRequest) (*pb.GetTenantResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("get tenant", err)
+	}
+
+	return &pb.GetTenantResponse{
+		Tenant: tenantToProto(tenant),
+	}, nil
+}
+
+// ListTenants lists the tenants matching a filter, newest first
+func (s *AdminService) ListTenants(ctx context.Context, req *pb.ListTenantsRequest) (*pb.ListTenantsResponse, error) {
+	page := int(req.GetPage())
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	offset := (page - 1) * pageSize
+
+	filter := repository.TenantFilter{
+		NameContains:   req.NameContains,
+		IncludeDeleted: req.IncludeDeleted,
+		IsTest:         req.Test,
+	}
+	if req.CreatedFrom != nil {
+		t := req.CreatedFrom.AsTime()
+		filter.CreatedFrom = &t
+	}
+	if req.CreatedTo != nil {
+		t := req.CreatedTo.AsTime()
+		filter.CreatedTo = &t
+	}
+	if req.Status != pb.TenantStatus_TENANT_STATUS_UNSPECIFIED {
+		stored, ok := tenantStatusFromProto(req.Status)
+		if !ok {
+			return nil, invalidField("status", "unknown tenant status")
+		}
+		filter.Status = &stored
+	}
+
+	tenants, totalCount, err := s.tenantRepo.List(ctx, filter, pageSize, offset)
+	if err != nil {
+		return nil, repositoryError("list tenants", err)
+	}
+
+	pbTenants := make([]*pb.Tenant, len(tenants))
+	for i, tenant := range tenants {
+		pbTenants[i] = tenantToProto(tenant)
+	}
+
+	return &pb.ListTenantsResponse{
+		Tenants:    pbTenants,
+		TotalCount: int32(totalCount),
+	}, nil
+}
+
+// DeleteTenant soft-deletes a tenant
+func (s *AdminService) DeleteTenant(ctx context.Context, req *pb.DeleteTenantRequest) (*pb.DeleteTenantResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := s.tenantRepo.Delete(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("delete tenant", err)
+	}
+
+	return &pb.DeleteTenantResponse{
+		Tenant: tenantToProto(tenant),
+	}, nil
+}
+
+// RestoreTenant restores a soft-deleted tenant
+func (s *AdminService) RestoreTenant(ctx context.Context, req *pb.RestoreTenantRequest) (*pb.RestoreTenantResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := s.tenantRepo.Restore(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("restore tenant", err)
+	}
+
+	return &pb.RestoreTenantResponse{
+		Tenant: tenantToProto(tenant),
+	}, nil
+}
+
+// SuspendTenant locks a tenant out of the tenant API
+func (s *AdminService) SuspendTenant(ctx context.Context, req *pb.SuspendTenantRequest) (*pb.SuspendTenantResponse, error) {
+	tenant, err := s.setTenantStatus(ctx, req.TenantId, repository.TenantStatusSuspended)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.SuspendTenantResponse{
+		Tenant: tenantToProto(tenant),
+	}, nil
+}
+
+// ArchiveTenant makes a tenant read-only
+func (s *AdminService) ArchiveTenant(ctx context.Context, req *pb.ArchiveTenantRequest) (*pb.ArchiveTenantResponse, error) {
+	tenant, err := s.setTenantStatus(ctx, req.TenantId, repository.TenantStatusArchived)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ArchiveTenantResponse{
+		Tenant: tenantToProto(tenant),
+	}, nil
+}
+
+// ActivateTenant returns a suspended or archived tenant to active
+func (s *AdminService) ActivateTenant(ctx context.Context, req *pb.ActivateTenantRequest) (*pb.ActivateTenantResponse, error) {
+	tenant, err := s.setTenantStatus(ctx, req.TenantId, repository.TenantStatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ActivateTenantResponse{
+		Tenant: tenantToProto(tenant),
+	}, nil
+}
+
+// SetTenantTestMode marks a tenant as test or clears the mark. Test tenants
+// are left out of consolidated reports and the consistency metrics, and are
+// purged by the cleanup job once their retention has passed.
+func (s *AdminService) SetTenantTestMode(ctx context.Context, req *pb.SetTenantTestModeRequest) (*pb.SetTenantTestModeResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := s.tenantRepo.SetTestMode(ctx, tenantID, req.Test)
+	if err != nil {
+		return nil, repositoryError("set tenant test mode", err)
+	}
+
+	return &pb.SetTenantTestModeResponse{
+		Tenant: tenantToProto(tenant),
+	}, nil
+}
+
+// setTenantStatus changes the status of the tenant of a request
+func (s *AdminService) setTenantStatus(ctx context.Context, id string, status string) (*repository.Tenant, error) {
+	tenantID, err := requestID("tenant_id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := s.tenantRepo.SetStatus(ctx, tenantID, status)
+	if err != nil {
+		return nil, repositoryError("set tenant status", err)
+	}
+
+	return tenant, nil
+}
+
+// CreateAccountType creates a new account type
+func (s *AdminService) CreateAccountType(ctx context.Context, req *pb.CreateAccountTypeRequest) (*pb.CreateAccountTypeResponse, error) {
+	if req.NormalBalance != "DEBIT" && req.NormalBalance != "CREDIT" {
+		return nil, status.Error(codes.InvalidArgument, "normal balance must be DEBIT or CREDIT")
+	}
+
+	accountType, err := s.referenceRepo.CreateAccountType(ctx, repository.CreateAccountTypeParams{
+		Code:          req.Code,
+		Name:          req.Name,
+		NormalBalance: req.NormalBalance,
+	})
+	if err != nil {
+		return nil, repositoryError("create account type", err)
+	}
+
+	return &pb.CreateAccountTypeResponse{
+		AccountType: accountTypeToProto(accountType),
+	}, nil
+}
+
+// CreateCurrency creates a new currency
+func (s *AdminService) CreateCurrency(ctx context.Context, req *pb.CreateCurrencyRequest) (*pb.CreateCurrencyResponse, error) {
+	if len(req.Code) != 3 {
+		return nil, status.Error(codes.InvalidArgument, "currency code must be a 3-letter ISO 4217 code")
+	}
+
+	if req.Precision < 0 || req.Precision > 18 {
+		return nil, status.Error(codes.InvalidArgument, "currency precision must be between 0 and 18")
+	}
+
+	currency, err := s.referenceRepo.CreateCurrency(ctx, repository.CreateCurrencyParams{
+		Code:      req.Code,
+		Name:      req.Name,
+		Symbol:    req.Symbol,
+		Precision: req.Precision,
+	})
+	if err != nil {
+		return nil, repositoryError("create currency", err)
+	}
+
+	return &pb.CreateCurrencyResponse{
+		Currency: currencyToProto(currency),
+	}, nil
+}
+
+// UpdateCurrency updates an existing currency
+func (s *AdminService) UpdateCurrency(ctx context.Context, req *pb.UpdateCurrencyRequest) (*pb.UpdateCurrencyResponse, error) {
+	if req.Precision != nil && (*req.Precision < 0 || *req.Precision > 18) {
+		return nil, status.Error(codes.InvalidArgument, "currency precision must be between 0 and 18")
+	}
+
+	currency, err := s.referenceRepo.UpdateCurrency(ctx, req.Code, repository.UpdateCurrencyParams{
+		Name:      req.Name,
+		Symbol:    req.Symbol,
+		Precision: req.Precision,
+	})
+	if err != nil {
+		return nil, repositoryError("update currency", err)
+	}
+
+	return &pb.UpdateCurrencyResponse{
+		Currency: currencyToProto(currency),
+	}, nil
+}
+
+// SetAccountTypeTranslation sets or, with an empty name, removes the name of
+// an account type in a locale
+func (s *AdminService) SetAccountTypeTranslation(ctx context.Context, req *pb.SetAccountTypeTranslationRequest) (*pb.SetAccountTypeTranslationResponse, error) {
+	tag, err := locale.Parse(req.Locale)
+	if err != nil {
+		return nil, invalidField("locale", "invalid locale")
+	}
+
+	if err := s.referenceRepo.SetAccountTypeTranslation(ctx, req.Code, tag.String(), req.Name); err != nil {
+		return nil, repositoryError("set account type translation", err)
+	}
+
+	accountTypes, err := localizedAccountTypes(ctx, s.referenceRepo, tag.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, accountType := range accountTypes {
+		if accountType.Code == req.Code {
+			return &pb.SetAccountTypeTranslationResponse{
+				AccountType: accountTypeToProto(accountType),
+			}, nil
+		}
+	}
+
+	return nil, repositoryError("set account type translation", fmt.Errorf("account type %w", repository.ErrNotFound))
+}
+
+// SetCurrencyTranslation sets or, with an empty name, removes the name of a
+// currency in a locale
+func (s *AdminService) SetCurrencyTranslation(ctx context.Context, req *pb.SetCurrencyTranslationRequest) (*pb.SetCurrencyTranslationResponse, error) {
+	tag, err := locale.Parse(req.Locale)
+	if err != nil {
+		return nil, invalidField("locale", "invalid locale")
+	}
+
+	if err := s.referenceRepo.SetCurrencyTranslation(ctx, req.Code, tag.String(), req.Name); err != nil {
+		return nil, repositoryError("set currency translation", err)
+	}
+
+	currencies, err := localizedCurrencies(ctx, s.referenceRepo, tag.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, currency := range currencies {
+		if currency.Code == req.Code {
+			return &pb.SetCurrencyTranslationResponse{
+				Currency: currencyToProto(currency),
+			}, nil
+		}
+	}
+
+	return nil, repositoryError("set currency translation", fmt.Errorf("currency %w", repository.ErrNotFound))
+}
+
+// GetSchemaInfo returns the applied database migrations
+func (s *AdminService) GetSchemaInfo(ctx context.Context, req *pb.GetSchemaInfoRequest) (*pb.GetSchemaInfoResponse, error) {
+	migrations, err := s.schemaRepo.ListMigrations(ctx)
+	if err != nil {
+		return nil, repositoryError("get schema info", err)
+	}
+
+	resp := &pb.GetSchemaInfoResponse{
+		Migrations: make([]*pb.SchemaMigration, len(migrations)),
+	}
+	for i, m := range migrations {
+		resp.Migrations[i] = &pb.SchemaMigration{
+			Version:     m.Version,
+			Description: m.Description,
+			ExecutedAt:  timestamppb.New(m.ExecutedAt),
+		}
+	}
+
+	if len(migrations) > 0 {
+		resp.CurrentVersion = migrations[len(migrations)-1].Version
+	}
+
+	return resp, nil
+}
+
+// VerifyRowLevelSecurity reports whether row-level security isolates the
+// tenant tables for the role the ledger connects as, and every problem found
+func (s *AdminService) VerifyRowLevelSecurity(ctx context.Context, req *pb.VerifyRowLevelSecurityRequest) (*pb.VerifyRowLevelSecurityResponse, error) {
+	verification, err := s.schemaRepo.VerifyRowLevelSecurity(ctx)
+	if err != nil {
+		return nil, repositoryError("verify row level security", err)
+	}
+
+	resp := &pb.VerifyRowLevelSecurityResponse{
+		Ok:                   verification.OK(),
+		Role:                 verification.Role,
+		BypassesRls:          verification.BypassesRLS,
+		TenantSettingApplied: verification.TenantSettingApplied,
+		Tables:               make([]*pb.RLSTableStatus, len(verification.Tables)),
+		Problems:             verification.Problems,
+	}
+	for i, table := range verification.Tables {
+		resp.Tables[i] = &pb.RLSTableStatus{
+			Name:         table.Name,
+			Enabled:      table.Enabled,
+			Forced:       table.Forced,
+			OwnedByRole:  table.OwnedByRole,
+			HasTenantId:  table.HasTenantID,
+			Policies:     int32(table.Policies),
+			TenantPolicy: table.TenantPolicy,
+			Leaked:       table.Leaked,
+		}
+	}
+
+	return resp, nil
+}
+
+// tenantStatuses maps stored tenant statuses to their API values
+var tenantStatuses = map[string]pb.TenantStatus{
+	repository.TenantStatusActive:    pb.TenantStatus_TENANT_STATUS_ACTIVE,
+	repository.TenantStatusSuspended: pb.TenantStatus_TENANT_STATUS_SUSPENDED,
+	repository.TenantStatusArchived:  pb.TenantStatus_TENANT_STATUS_ARCHIVED,
+}
+
+// tenantStatusFromProto returns the stored status of an API tenant status
+func tenantStatusFromProto(status pb.TenantStatus) (string, bool) {
+	for stored, value := range tenantStatuses {
+		if value == status {
+			return stored, true
+		}
+	}
+	return "", false
+}
+
+func tenantToProto(tenant *repository.Tenant) *pb.Tenant {
+	pbTenant := &pb.Tenant{
+		TenantId:  tenant.ID.String(),
+		Name:      tenant.Name,
+		CreatedAt: timestamppb.New(tenant.CreatedAt),
+		UpdatedAt: timestamppb.New(tenant.UpdatedAt),
+		Status:    tenantStatuses[tenant.Status],
+		Test:      tenant.IsTest,
+	}
+
+	if tenant.DeletedAt != nil {
+		pbTenant.DeletedAt = timestamppb.New(*tenant.DeletedAt)
+	}
+	if tenant.ClonedFromID != nil {
+		clonedFrom := tenant.ClonedFromID.String()
+		pbTenant.ClonedFromTenantId = &clonedFrom
+	}
+	if tenant.TestSince != nil {
+		pbTenant.TestSince = timestamppb.New(*tenant.TestSince)
+	}
+
+	return pbTenant
+}
+
+func accountTypeToProto(at *repository.AccountType) *pb.AccountType {
+	return &pb.AccountType{
+		Id:            at.ID,
+		Code:          at.Code,
+		Name:          at.Name,
+		NormalBalance: at.NormalBalance,
+		UpdatedAt:     timestamppb.New(at.UpdatedAt),
+	}
+}
+
+func currencyToProto(c *repository.Currency) *pb.Currency {
+	return &pb.Currency{
+		Id:        c.ID,
+		Code:      c.Code,
+		Name:      c.Name,
+		Symbol:    c.Symbol,
+		Precision: c.Precision,
+		UpdatedAt: timestamppb.New(c.UpdatedAt),
+	}
+}
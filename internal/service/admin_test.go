@@ -0,0 +1,491 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockSchemaRepository struct {
+	mock.Mock
+}
+
+func (m *MockSchemaRepository) ListMigrations(ctx context.Context) ([]*repository.SchemaMigration, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.SchemaMigration), args.Error(1)
+}
+
+func (m *MockSchemaRepository) VerifyRowLevelSecurity(ctx context.Context) (*repository.RLSVerification, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.RLSVerification), args.Error(1)
+}
+
+func (m *MockSchemaRepository) VerifyChangeDataCapture(ctx context.Context, publication string) (*repository.CDCVerification, error) {
+	args := m.Called(ctx, publication)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.CDCVerification), args.Error(1)
+}
+
+func (m *MockSchemaRepository) ListReplicationSlots(ctx context.Context) ([]*repository.ReplicationSlot, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.ReplicationSlot), args.Error(1)
+}
+
+// Test CreateTenant
+func TestAdminService_CreateTenant(t *testing.T) {
+	ctx := context.Background()
+	mockTenantRepo := new(MockTenantRepository)
+	service := NewAdminService(mockTenantRepo, nil, nil)
+
+	t.Run("successfully creates tenant", func(t *testing.T) {
+		tenantID := uuid.New()
+		now := time.Now()
+
+		mockTenantRepo.On("Create", ctx, "Test Tenant", (*uuid.UUID)(nil)).Return(&repository.Tenant{
+			ID:        tenantID,
+			Name:      "Test Tenant",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}, nil).Once()
+
+		req := &pb.CreateTenantRequest{Name: "Test Tenant"}
+		resp, err := service.CreateTenant(ctx, req)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.Equal(t, tenantID.String(), resp.TenantId)
+		assert.Equal(t, "Test Tenant", resp.Name)
+		mockTenantRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error when name is empty", func(t *testing.T) {
+		req := &pb.CreateTenantRequest{Name: ""}
+		resp, err := validated(ctx, req, service.CreateTenant)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, "name", fieldViolations(t, err)[0].Field)
+		assert.Nil(t, resp)
+	})
+}
+
+// Test GetTenant
+func TestAdminService_GetTenant(t *testing.T) {
+	ctx := context.Background()
+	mockTenantRepo := new(MockTenantRepository)
+	service := NewAdminService(mockTenantRepo, nil, nil)
+
+	t.Run("returns not found when tenant does not exist", func(t *testing.T) {
+		tenantID := uuid.New()
+		mockTenantRepo.On("GetByID", ctx, tenantID).Return(nil, fmt.Errorf("tenant %w", repository.ErrNotFound)).Once()
+
+		resp, err := service.GetTenant(ctx, &pb.GetTenantRequest{TenantId: tenantID.String()})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Nil(t, resp)
+		mockTenantRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns internal when the lookup fails", func(t *testing.T) {
+		tenantID := uuid.New()
+		mockTenantRepo.On("GetByID", ctx, tenantID).Return(nil, errors.New("connection refused")).Once()
+
+		resp, err := service.GetTenant(ctx, &pb.GetTenantRequest{TenantId: tenantID.String()})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Nil(t, resp)
+		mockTenantRepo.AssertExpectations(t)
+	})
+}
+
+// Test CreateAccountType
+func TestAdminService_CreateAccountType(t *testing.T) {
+	ctx := context.Background()
+	mockReferenceRepo := new(MockReferenceRepository)
+	service := NewAdminService(nil, mockReferenceRepo, nil)
+
+	t.Run("successfully creates account type", func(t *testing.T) {
+		params := repository.CreateAccountTypeParams{Code: "CONTRA", Name: "Contra Asset", NormalBalance: "CREDIT"}
+		mockReferenceRepo.On("CreateAccountType", ctx, params).Return(&repository.AccountType{
+			ID: 6, Code: "CONTRA", Name: "Contra Asset", NormalBalance: "CREDIT",
+		}, nil).Once()
+
+		resp, err := service.CreateAccountType(ctx, &pb.CreateAccountTypeRequest{
+			Code: "CONTRA", Name: "Contra Asset", NormalBalance: "CREDIT",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int32(6), resp.AccountType.Id)
+		mockReferenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error for invalid normal balance", func(t *testing.T) {
+		resp, err := service.CreateAccountType(ctx, &pb.CreateAccountTypeRequest{
+			Code: "CONTRA", Name: "Contra Asset", NormalBalance: "SIDEWAYS",
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test CreateCurrency
+func TestAdminService_CreateCurrency(t *testing.T) {
+	ctx := context.Background()
+	mockReferenceRepo := new(MockReferenceRepository)
+	service := NewAdminService(nil, mockReferenceRepo, nil)
+
+	t.Run("successfully creates currency", func(t *testing.T) {
+		params := repository.CreateCurrencyParams{Code: "IRR", Name: "Iranian Rial", Symbol: "﷼", Precision: 0}
+		mockReferenceRepo.On("CreateCurrency", ctx, params).Return(&repository.Currency{
+			ID: 3, Code: "IRR", Name: "Iranian Rial", Symbol: "﷼", Precision: 0,
+		}, nil).Once()
+
+		resp, err := service.CreateCurrency(ctx, &pb.CreateCurrencyRequest{
+			Code: "IRR", Name: "Iranian Rial", Symbol: "﷼", Precision: 0,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "IRR", resp.Currency.Code)
+		mockReferenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error for invalid code", func(t *testing.T) {
+		resp, err := service.CreateCurrency(ctx, &pb.CreateCurrencyRequest{Code: "DOLLAR", Name: "Dollar"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+func TestAdminService_SetTranslations(t *testing.T) {
+	ctx := context.Background()
+	mockReferenceRepo := new(MockReferenceRepository)
+	service := NewAdminService(nil, mockReferenceRepo, nil)
+
+	t.Run("returns the account type named in the locale", func(t *testing.T) {
+		mockReferenceRepo.On("SetAccountTypeTranslation", ctx, "ASSET", "fa-IR", "دارایی").Return(nil).Once()
+		mockReferenceRepo.On("ListAccountTypes", ctx).Return([]*repository.AccountType{
+			{ID: 1, Code: "ASSET", Name: "Asset", NormalBalance: "DEBIT"},
+		}, nil).Once()
+		mockReferenceRepo.On("ListAccountTypeTranslations", ctx, []string{"fa-IR", "fa"}).Return(map[string]string{"ASSET": "دارایی"}, nil).Once()
+
+		resp, err := service.SetAccountTypeTranslation(ctx, &pb.SetAccountTypeTranslationRequest{
+			Code: "ASSET", Locale: "fa-ir", Name: "دارایی",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "دارایی", resp.AccountType.Name)
+		mockReferenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for an unknown currency", func(t *testing.T) {
+		mockReferenceRepo.On("SetCurrencyTranslation", ctx, "XXX", "ar", "").Return(fmt.Errorf("currency %w", repository.ErrNotFound)).Once()
+
+		resp, err := service.SetCurrencyTranslation(ctx, &pb.SetCurrencyTranslationRequest{Code: "XXX", Locale: "ar"})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("rejects an invalid locale", func(t *testing.T) {
+		resp, err := service.SetCurrencyTranslation(ctx, &pb.SetCurrencyTranslationRequest{Code: "IRR", Locale: "", Name: "ریال"})
+
+		assert.Equal(t, "INVALID_FIELD", errorReason(t, err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test GetSchemaInfo
+func TestAdminService_GetSchemaInfo(t *testing.T) {
+	ctx := context.Background()
+	mockSchemaRepo := new(MockSchemaRepository)
+	service := NewAdminService(nil, nil, mockSchemaRepo)
+
+	t.Run("returns latest migration as current version", func(t *testing.T) {
+		now := time.Now()
+		mockSchemaRepo.On("ListMigrations", ctx).Return([]*repository.SchemaMigration{
+			{Version: "20250101000000", Description: "init", ExecutedAt: now},
+			{Version: "20250201000000", Description: "balances", ExecutedAt: now},
+		}, nil).Once()
+
+		resp, err := service.GetSchemaInfo(ctx, &pb.GetSchemaInfoRequest{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "20250201000000", resp.CurrentVersion)
+		assert.Len(t, resp.Migrations, 2)
+		mockSchemaRepo.AssertExpectations(t)
+	})
+}
+
+// Test VerifyRowLevelSecurity
+func TestAdminService_VerifyRowLevelSecurity(t *testing.T) {
+	ctx := context.Background()
+	mockSchemaRepo := new(MockSchemaRepository)
+	service := NewAdminService(nil, nil, mockSchemaRepo)
+
+	t.Run("reports tables and problems", func(t *testing.T) {
+		mockSchemaRepo.On("VerifyRowLevelSecurity", ctx).Return(&repository.RLSVerification{
+			Role:                 "ledger",
+			TenantSettingApplied: true,
+			Tables: []*repository.RLSTable{
+				{Name: "accounts", Enabled: true, Forced: true, HasTenantID: true, Policies: 1, TenantPolicy: true},
+				{Name: "journal_entries", Enabled: false, HasTenantID: true},
+			},
+			Problems: []string{"row level security is disabled on journal_entries"},
+		}, nil).Once()
+
+		resp, err := service.VerifyRowLevelSecurity(ctx, &pb.VerifyRowLevelSecurityRequest{})
+
+		require.NoError(t, err)
+		assert.False(t, resp.Ok)
+		assert.Equal(t, "ledger", resp.Role)
+		assert.True(t, resp.TenantSettingApplied)
+		require.Len(t, resp.Tables, 2)
+		assert.True(t, resp.Tables[0].TenantPolicy)
+		assert.False(t, resp.Tables[1].Enabled)
+		assert.Equal(t, []string{"row level security is disabled on journal_entries"}, resp.Problems)
+		mockSchemaRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns internal error when the check fails", func(t *testing.T) {
+		mockSchemaRepo.On("VerifyRowLevelSecurity", ctx).Return(nil, errors.New("connection refused")).Once()
+
+		_, err := service.VerifyRowLevelSecurity(ctx, &pb.VerifyRowLevelSecurityRequest{})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		mockSchemaRepo.AssertExpectations(t)
+	})
+}
+
+// Test ListTenants
+func TestAdminService_ListTenants(t *testing.T) {
+	ctx := context.Background()
+	mockTenantRepo := new(MockTenantRepository)
+	service := NewAdminService(mockTenantRepo, nil, nil)
+
+	t.Run("lists tenants matching a filter", func(t *testing.T) {
+		from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		name := "acme"
+		suspended := repository.TenantStatusSuspended
+		test := false
+		tenant := &repository.Tenant{ID: uuid.New(), Name: "Acme", Status: suspended, CreatedAt: from}
+
+		mockTenantRepo.On("List", ctx, repository.TenantFilter{
+			NameContains: &name,
+			CreatedFrom:  &from,
+			Status:       &suspended,
+			IsTest:       &test,
+		}, 20, 20).Return([]*repository.Tenant{tenant}, 21, nil).Once()
+
+		resp, err := service.ListTenants(ctx, &pb.ListTenantsRequest{
+			NameContains: &name,
+			CreatedFrom:  timestamppb.New(from),
+			Status:       pb.TenantStatus_TENANT_STATUS_SUSPENDED,
+			Test:         &test,
+			Page:         2,
+			PageSize:     20,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(21), resp.TotalCount)
+		require.Len(t, resp.Tenants, 1)
+		assert.Equal(t, "Acme", resp.Tenants[0].Name)
+		assert.Equal(t, pb.TenantStatus_TENANT_STATUS_SUSPENDED, resp.Tenants[0].Status)
+		mockTenantRepo.AssertExpectations(t)
+	})
+
+	t.Run("defaults the page size", func(t *testing.T) {
+		mockTenantRepo.On("List", ctx, repository.TenantFilter{IncludeDeleted: true}, 50, 0).
+			Return([]*repository.Tenant{}, 0, nil).Once()
+
+		resp, err := service.ListTenants(ctx, &pb.ListTenantsRequest{IncludeDeleted: true})
+
+		require.NoError(t, err)
+		assert.Empty(t, resp.Tenants)
+		mockTenantRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects an unknown status", func(t *testing.T) {
+		_, err := service.ListTenants(ctx, &pb.ListTenantsRequest{Status: pb.TenantStatus(42)})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// Test DeleteTenant
+func TestAdminService_DeleteTenant(t *testing.T) {
+	ctx := context.Background()
+	mockTenantRepo := new(MockTenantRepository)
+	service := NewAdminService(mockTenantRepo, nil, nil)
+
+	t.Run("successfully deletes tenant", func(t *testing.T) {
+		tenantID := uuid.New()
+		now := time.Now()
+
+		mockTenantRepo.On("Delete", ctx, tenantID).Return(&repository.Tenant{
+			ID:        tenantID,
+			Name:      "Acme",
+			CreatedAt: now,
+			UpdatedAt: now,
+			DeletedAt: &now,
+		}, nil).Once()
+
+		resp, err := service.DeleteTenant(ctx, &pb.DeleteTenantRequest{TenantId: tenantID.String()})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp.Tenant.DeletedAt)
+		mockTenantRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for unknown tenant", func(t *testing.T) {
+		tenantID := uuid.New()
+		mockTenantRepo.On("Delete", ctx, tenantID).Return(nil, repository.ErrNotFound).Once()
+
+		resp, err := service.DeleteTenant(ctx, &pb.DeleteTenantRequest{TenantId: tenantID.String()})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Nil(t, resp)
+		mockTenantRepo.AssertExpectations(t)
+	})
+}
+
+// Test SuspendTenant, ArchiveTenant and ActivateTenant
+func TestAdminService_TenantStatus(t *testing.T) {
+	ctx := context.Background()
+	mockTenantRepo := new(MockTenantRepository)
+	service := NewAdminService(mockTenantRepo, nil, nil)
+
+	tenantID := uuid.New()
+	tenantWithStatus := func(status string) *repository.Tenant {
+		return &repository.Tenant{ID: tenantID, Name: "Acme", Status: status}
+	}
+
+	t.Run("suspends a tenant", func(t *testing.T) {
+		mockTenantRepo.On("SetStatus", ctx, tenantID, repository.TenantStatusSuspended).
+			Return(tenantWithStatus(repository.TenantStatusSuspended), nil).Once()
+
+		resp, err := service.SuspendTenant(ctx, &pb.SuspendTenantRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.Equal(t, pb.TenantStatus_TENANT_STATUS_SUSPENDED, resp.Tenant.Status)
+		mockTenantRepo.AssertExpectations(t)
+	})
+
+	t.Run("archives a tenant", func(t *testing.T) {
+		mockTenantRepo.On("SetStatus", ctx, tenantID, repository.TenantStatusArchived).
+			Return(tenantWithStatus(repository.TenantStatusArchived), nil).Once()
+
+		resp, err := service.ArchiveTenant(ctx, &pb.ArchiveTenantRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.Equal(t, pb.TenantStatus_TENANT_STATUS_ARCHIVED, resp.Tenant.Status)
+		mockTenantRepo.AssertExpectations(t)
+	})
+
+	t.Run("activates a tenant", func(t *testing.T) {
+		mockTenantRepo.On("SetStatus", ctx, tenantID, repository.TenantStatusActive).
+			Return(tenantWithStatus(repository.TenantStatusActive), nil).Once()
+
+		resp, err := service.ActivateTenant(ctx, &pb.ActivateTenantRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.Equal(t, pb.TenantStatus_TENANT_STATUS_ACTIVE, resp.Tenant.Status)
+		mockTenantRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for a deleted tenant", func(t *testing.T) {
+		deletedID := uuid.New()
+		mockTenantRepo.On("SetStatus", ctx, deletedID, repository.TenantStatusSuspended).Return(nil, repository.ErrNotFound).Once()
+
+		_, err := service.SuspendTenant(ctx, &pb.SuspendTenantRequest{TenantId: deletedID.String()})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("rejects an invalid tenant ID", func(t *testing.T) {
+		_, err := service.ArchiveTenant(ctx, &pb.ArchiveTenantRequest{TenantId: "invalid"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// Test SetTenantTestMode
+func TestAdminService_SetTenantTestMode(t *testing.T) {
+	ctx := context.Background()
+	mockTenantRepo := new(MockTenantRepository)
+	service := NewAdminService(mockTenantRepo, nil, nil)
+
+	tenantID := uuid.New()
+
+	t.Run("marks a tenant as test", func(t *testing.T) {
+		testSince := time.Now().UTC()
+		mockTenantRepo.On("SetTestMode", ctx, tenantID, true).Return(&repository.Tenant{
+			ID:        tenantID,
+			Name:      "Acme",
+			Status:    repository.TenantStatusActive,
+			IsTest:    true,
+			TestSince: &testSince,
+		}, nil).Once()
+
+		resp, err := service.SetTenantTestMode(ctx, &pb.SetTenantTestModeRequest{TenantId: tenantID.String(), Test: true})
+
+		require.NoError(t, err)
+		assert.True(t, resp.Tenant.Test)
+		assert.Equal(t, testSince, resp.Tenant.TestSince.AsTime())
+		mockTenantRepo.AssertExpectations(t)
+	})
+
+	t.Run("clears the test mark", func(t *testing.T) {
+		mockTenantRepo.On("SetTestMode", ctx, tenantID, false).Return(&repository.Tenant{
+			ID:     tenantID,
+			Name:   "Acme",
+			Status: repository.TenantStatusActive,
+		}, nil).Once()
+
+		resp, err := service.SetTenantTestMode(ctx, &pb.SetTenantTestModeRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.False(t, resp.Tenant.Test)
+		assert.Nil(t, resp.Tenant.TestSince)
+		mockTenantRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for a deleted tenant", func(t *testing.T) {
+		deletedID := uuid.New()
+		mockTenantRepo.On("SetTestMode", ctx, deletedID, true).Return(nil, repository.ErrNotFound).Once()
+
+		_, err := service.SetTenantTestMode(ctx, &pb.SetTenantTestModeRequest{TenantId: deletedID.String(), Test: true})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("rejects an invalid tenant ID", func(t *testing.T) {
+		_, err := service.SetTenantTestMode(ctx, &pb.SetTenantTestModeRequest{TenantId: "invalid"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
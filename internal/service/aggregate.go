@@ -0,0 +1,247 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// AggregateJournalLines sums posted lines over a date range, grouped by any
+// combination of account, account type, currency, dimension values and a day
+// or month bucket; months are Jalali months for tenants on the Jalali calendar.
+// Aggregates read from the period totals report how current those are.
+// Aggregates translated into a reporting currency are summed per currency
+// and account type first, so every line translates at its own rate.
+func (s *LedgerService) AggregateJournalLines(ctx context.Context, req *pb.AggregateJournalLinesRequest) (*pb.AggregateJournalLinesResponse, error) {
+	if s.reportRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "aggregate queries are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := repository.LineAggregateFilter{}
+	byDimension := false
+	for _, groupBy := range req.GroupBy {
+		switch groupBy {
+		case pb.AggregateGroupBy_AGGREGATE_GROUP_BY_ACCOUNT:
+			filter.ByAccount = true
+		case pb.AggregateGroupBy_AGGREGATE_GROUP_BY_ACCOUNT_TYPE:
+			filter.ByAccountType = true
+		case pb.AggregateGroupBy_AGGREGATE_GROUP_BY_CURRENCY:
+			filter.ByCurrency = true
+		case pb.AggregateGroupBy_AGGREGATE_GROUP_BY_DIMENSION:
+			byDimension = true
+		case pb.AggregateGroupBy_AGGREGATE_GROUP_BY_DAY, pb.AggregateGroupBy_AGGREGATE_GROUP_BY_MONTH:
+			period := repository.AggregatePeriodDay
+			if groupBy == pb.AggregateGroupBy_AGGREGATE_GROUP_BY_MONTH {
+				period = repository.AggregatePeriodMonth
+			}
+			if filter.Period != "" && filter.Period != period {
+				return nil, invalidField("group_by", "lines can be grouped by day or by month, not both")
+			}
+			filter.Period = period
+		default:
+			return nil, invalidField("group_by", "unknown group by key")
+		}
+	}
+
+	if byDimension != (len(req.Dimensions) > 0) {
+		return nil, invalidField("dimensions", "dimensions must be given when, and only when, grouping by dimension")
+	}
+
+	if byDimension {
+		if s.dimensionRepo == nil {
+			return nil, status.Error(codes.Unimplemented, "dimensions are not enabled")
+		}
+		dimensions, err := s.dimensionRepo.GetByCodes(ctx, tenantID, req.Dimensions)
+		if err != nil {
+			return nil, repositoryError("get dimensions", err)
+		}
+		for _, code := range req.Dimensions {
+			if _, ok := dimensions[code]; !ok {
+				return nil, status.Errorf(codes.InvalidArgument, "unknown dimension %s", code)
+			}
+		}
+		filter.Dimensions = req.Dimensions
+	}
+
+	if req.AccountId != nil && *req.AccountId != "" {
+		accountID, err := requestID("account_id", *req.AccountId)
+		if err != nil {
+			return nil, err
+		}
+		filter.AccountID = &accountID
+	}
+
+	if filter.BookID, err = parseBookID(req.BookId); err != nil {
+		return nil, err
+	}
+
+	clock, err := s.clock(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.FromDate, err = dateField("from_date", req.FromDate, req.JalaliFromDate); err != nil {
+		return nil, err
+	}
+	if filter.ToDate, err = dateField("to_date", req.ToDate, req.JalaliToDate); err != nil {
+		return nil, err
+	}
+
+	// SQL has no Jalali months, so their days are summed and merged here
+	jalaliMonthly := filter.Period == repository.AggregatePeriodMonth && clock.calendar == repository.CalendarJalali
+	if jalaliMonthly {
+		filter.Period = repository.AggregatePeriodDay
+	}
+
+	if filter.FromDate != nil && filter.ToDate != nil && filter.FromDate.After(*filter.ToDate) {
+		return nil, invalidField("from_date", "from_date must not be after to_date")
+	}
+
+	translation, err := s.reportingCurrency(ctx, req.ReportingCurrency)
+	if err != nil {
+		return nil, err
+	}
+	byCurrency, byAccountType := filter.ByCurrency, filter.ByAccountType
+	if translation != nil {
+		filter.ByCurrency, filter.ByAccountType = true, true
+	}
+
+	aggregates, err := s.reportRepo.AggregateJournalLines(ctx, tenantID, filter)
+	if err != nil {
+		return nil, repositoryError("aggregate journal lines", err)
+	}
+	if jalaliMonthly {
+		aggregates = jalaliMonths(aggregates)
+	}
+	if translation != nil {
+		if aggregates, err = translateAggregates(aggregates, translation, byCurrency, byAccountType); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &pb.AggregateJournalLinesResponse{
+		Aggregates: make([]*pb.JournalLineAggregate, len(aggregates)),
+	}
+	for i, aggregate := range aggregates {
+		pbAggregate := &pb.JournalLineAggregate{
+			AccountNumber:   aggregate.AccountNumber,
+			AccountTypeCode: aggregate.AccountTypeCode,
+			CurrencyCode:    aggregate.CurrencyCode,
+			Dimensions:      aggregate.Dimensions,
+			TotalDebit:      aggregate.Debit.String(),
+			TotalCredit:     aggregate.Credit.String(),
+			NetAmount:       aggregate.Net().String(),
+			LineCount:       aggregate.LineCount,
+		}
+		if aggregate.AccountID != nil {
+			accountID := aggregate.AccountID.String()
+			pbAggregate.AccountId = &accountID
+		}
+		if aggregate.PeriodStart != nil {
+			pbAggregate.PeriodStart = timestamppb.New(*aggregate.PeriodStart)
+			pbAggregate.JalaliPeriodStart = jalaliToProto(*aggregate.PeriodStart)
+		}
+		resp.Aggregates[i] = pbAggregate
+	}
+
+	if filter.UsesPeriodTotals() {
+		freshness, err := s.reportRepo.GetFreshness(ctx, tenantID)
+		if err != nil {
+			return nil, repositoryError("get report freshness", err)
+		}
+		resp.Freshness = freshnessToProto(freshness)
+	}
+	if translation != nil {
+		resp.ReportingCurrency = &translation.currency
+	}
+
+	return resp, nil
+}
+
+// translateAggregates translates aggregates grouped by currency and account
+// type, then merges those that differ only in the keys the caller did not
+// group by
+func translateAggregates(aggregates []*repository.LineAggregate, translation *reportTranslation, byCurrency, byAccountType bool) ([]*repository.LineAggregate, error) {
+	merged := make([]*repository.LineAggregate, 0, len(aggregates))
+	for _, aggregate := range aggregates {
+		debit, credit, err := translation.translate(*aggregate.CurrencyCode, *aggregate.AccountTypeCode, aggregate.Debit, aggregate.Credit)
+		if err != nil {
+			return nil, err
+		}
+		aggregate.Debit, aggregate.Credit = debit, credit
+		if !byCurrency {
+			aggregate.CurrencyCode = nil
+		}
+		if !byAccountType {
+			aggregate.AccountTypeCode = nil
+		}
+
+		i := slices.IndexFunc(merged, func(m *repository.LineAggregate) bool {
+			return sameGroupKeys(m, aggregate) && samePeriod(m.PeriodStart, aggregate.PeriodStart)
+		})
+		if i < 0 {
+			merged = append(merged, aggregate)
+			continue
+		}
+		merged[i].Debit = merged[i].Debit.Add(aggregate.Debit)
+		merged[i].Credit = merged[i].Credit.Add(aggregate.Credit)
+		merged[i].LineCount += aggregate.LineCount
+	}
+
+	// Keys added for the translation ordered the rows first, so they are
+	// ordered again by the keys left
+	slices.SortStableFunc(merged, func(a, b *repository.LineAggregate) int {
+		return cmp.Or(
+			cmp.Compare(deref(a.AccountNumber), deref(b.AccountNumber)),
+			cmp.Compare(deref(a.AccountTypeCode), deref(b.AccountTypeCode)),
+			cmp.Compare(deref(a.CurrencyCode), deref(b.CurrencyCode)),
+			cmp.Compare(fmt.Sprint(a.Dimensions), fmt.Sprint(b.Dimensions)),
+			cmp.Compare(deref(a.PeriodStart).Unix(), deref(b.PeriodStart).Unix()),
+		)
+	})
+
+	return merged, nil
+}
+
+// deref returns the value a pointer points to, or the zero value for nil
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
+func samePeriod(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func freshnessToProto(freshness *repository.ReportFreshness) *pb.ReportFreshness {
+	refresh := pb.PeriodTotalsRefresh_PERIOD_TOTALS_REFRESH_ON_POST
+	if freshness.Scheduled {
+		refresh = pb.PeriodTotalsRefresh_PERIOD_TOTALS_REFRESH_SCHEDULED
+	}
+
+	return &pb.ReportFreshness{
+		Refresh:        refresh,
+		TotalsAsOf:     timestamppb.New(freshness.AsOf),
+		PendingEntries: freshness.PendingEntries,
+	}
+}
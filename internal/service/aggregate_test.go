@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+func TestLedgerService_AggregateJournalLines(t *testing.T) {
+	ctx := context.Background()
+	mockReportRepo := new(MockReportRepository)
+	mockDimensionRepo := new(MockDimensionRepository)
+	service := NewLedgerService(nil, nil, nil, nil,
+		WithReportRepository(mockReportRepo),
+		WithDimensionRepository(mockDimensionRepo),
+	)
+
+	tenantID := uuid.New()
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("sums lines per account type and month", func(t *testing.T) {
+		asset := "ASSET"
+		january := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockReportRepo.On("AggregateJournalLines", ctx, tenantID, repository.LineAggregateFilter{
+			ByAccountType: true,
+			Period:        repository.AggregatePeriodMonth,
+			FromDate:      &from,
+			ToDate:        &to,
+		}).Return([]*repository.LineAggregate{
+			{
+				AccountTypeCode: &asset,
+				PeriodStart:     &january,
+				Debit:           decimal.NewFromInt(150),
+				Credit:          decimal.NewFromInt(40),
+				LineCount:       3,
+			},
+		}, nil).Once()
+		queuedAt := time.Date(2026, 4, 1, 9, 30, 0, 0, time.UTC)
+		mockReportRepo.On("GetFreshness", ctx, tenantID).Return(&repository.ReportFreshness{
+			Scheduled:      true,
+			AsOf:           queuedAt,
+			PendingEntries: 2,
+		}, nil).Once()
+
+		resp, err := service.AggregateJournalLines(ctx, &pb.AggregateJournalLinesRequest{
+			TenantId: tenantID.String(),
+			GroupBy: []pb.AggregateGroupBy{
+				pb.AggregateGroupBy_AGGREGATE_GROUP_BY_ACCOUNT_TYPE,
+				pb.AggregateGroupBy_AGGREGATE_GROUP_BY_MONTH,
+			},
+			FromDate: timestamppb.New(from),
+			ToDate:   timestamppb.New(to),
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Aggregates, 1)
+		aggregate := resp.Aggregates[0]
+		assert.Equal(t, "ASSET", aggregate.GetAccountTypeCode())
+		assert.Nil(t, aggregate.AccountId)
+		assert.Equal(t, january, aggregate.PeriodStart.AsTime())
+		assert.Equal(t, "150", aggregate.TotalDebit)
+		assert.Equal(t, "40", aggregate.TotalCredit)
+		assert.Equal(t, "110", aggregate.NetAmount)
+		assert.Equal(t, int64(3), aggregate.LineCount)
+		assert.Equal(t, pb.PeriodTotalsRefresh_PERIOD_TOTALS_REFRESH_SCHEDULED, resp.Freshness.Refresh)
+		assert.Equal(t, queuedAt, resp.Freshness.TotalsAsOf.AsTime())
+		assert.Equal(t, int64(2), resp.Freshness.PendingEntries)
+		mockReportRepo.AssertExpectations(t)
+	})
+
+	t.Run("groups by known dimensions", func(t *testing.T) {
+		accountID := uuid.New()
+		accountNumber := "5000"
+		mockDimensionRepo.On("GetByCodes", ctx, tenantID, []string{"COST_CENTER"}).
+			Return(map[string]*repository.Dimension{"COST_CENTER": {Code: "COST_CENTER", IsActive: true}}, nil).Once()
+		mockReportRepo.On("AggregateJournalLines", ctx, tenantID, repository.LineAggregateFilter{
+			ByAccount:  true,
+			Dimensions: []string{"COST_CENTER"},
+		}).Return([]*repository.LineAggregate{
+			{
+				AccountID:     &accountID,
+				AccountNumber: &accountNumber,
+				Dimensions:    map[string]string{"COST_CENTER": "OPS"},
+				Debit:         decimal.NewFromInt(20),
+				Credit:        decimal.Zero,
+				LineCount:     1,
+			},
+		}, nil).Once()
+
+		resp, err := service.AggregateJournalLines(ctx, &pb.AggregateJournalLinesRequest{
+			TenantId: tenantID.String(),
+			GroupBy: []pb.AggregateGroupBy{
+				pb.AggregateGroupBy_AGGREGATE_GROUP_BY_ACCOUNT,
+				pb.AggregateGroupBy_AGGREGATE_GROUP_BY_DIMENSION,
+			},
+			Dimensions: []string{"COST_CENTER"},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Aggregates, 1)
+		assert.Equal(t, accountID.String(), resp.Aggregates[0].GetAccountId())
+		assert.Equal(t, "OPS", resp.Aggregates[0].Dimensions["COST_CENTER"])
+		assert.Nil(t, resp.Aggregates[0].PeriodStart)
+		mockDimensionRepo.AssertExpectations(t)
+		mockReportRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns invalid argument for an unknown dimension", func(t *testing.T) {
+		mockDimensionRepo.On("GetByCodes", ctx, tenantID, []string{"REGION"}).
+			Return(map[string]*repository.Dimension{}, nil).Once()
+
+		_, err := service.AggregateJournalLines(ctx, &pb.AggregateJournalLinesRequest{
+			TenantId:   tenantID.String(),
+			GroupBy:    []pb.AggregateGroupBy{pb.AggregateGroupBy_AGGREGATE_GROUP_BY_DIMENSION},
+			Dimensions: []string{"REGION"},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		invalidAccount := "invalid"
+		for _, req := range []*pb.AggregateJournalLinesRequest{
+			{TenantId: "invalid"},
+			{TenantId: tenantID.String(), GroupBy: []pb.AggregateGroupBy{pb.AggregateGroupBy_AGGREGATE_GROUP_BY_UNSPECIFIED}},
+			{TenantId: tenantID.String(), GroupBy: []pb.AggregateGroupBy{pb.AggregateGroupBy_AGGREGATE_GROUP_BY_DAY, pb.AggregateGroupBy_AGGREGATE_GROUP_BY_MONTH}},
+			{TenantId: tenantID.String(), GroupBy: []pb.AggregateGroupBy{pb.AggregateGroupBy_AGGREGATE_GROUP_BY_DIMENSION}},
+			{TenantId: tenantID.String(), Dimensions: []string{"COST_CENTER"}},
+			{TenantId: tenantID.String(), AccountId: &invalidAccount},
+			{TenantId: tenantID.String(), FromDate: timestamppb.New(to), ToDate: timestamppb.New(from)},
+		} {
+			_, err := service.AggregateJournalLines(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+	})
+
+	t.Run("returns unimplemented without a report repository", func(t *testing.T) {
+		_, err := NewLedgerService(nil, nil, nil, nil).AggregateJournalLines(ctx, &pb.AggregateJournalLinesRequest{TenantId: tenantID.String()})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
+
+func TestLedgerService_AggregateJournalLines_JalaliCalendar(t *testing.T) {
+	ctx := context.Background()
+	mockReportRepo := new(MockReportRepository)
+	mockSettingsRepo := new(MockTenantSettingsRepository)
+	service := NewLedgerService(nil, nil, nil, nil,
+		WithReportRepository(mockReportRepo),
+		WithTenantSettingsRepository(mockSettingsRepo),
+	)
+
+	tenantID := uuid.New()
+	asset := "ASSET"
+	day := func(month time.Month, day int) *time.Time {
+		t := time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	t.Run("buckets months by the Jalali calendar", func(t *testing.T) {
+		// Bahman 1402 runs from 21 January to 19 February 2024
+		from := time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)
+		mockSettingsRepo.On("Get", ctx, tenantID).Return(&repository.TenantSettings{TenantID: tenantID, Calendar: repository.CalendarJalali}, nil).Once()
+		mockReportRepo.On("AggregateJournalLines", ctx, tenantID, repository.LineAggregateFilter{
+			ByAccountType: true,
+			Period:        repository.AggregatePeriodDay,
+			FromDate:      &from,
+		}).Return([]*repository.LineAggregate{
+			{AccountTypeCode: &asset, PeriodStart: day(time.January, 25), Debit: decimal.NewFromInt(10), Credit: decimal.Zero, LineCount: 1},
+			{AccountTypeCode: &asset, PeriodStart: day(time.February, 19), Debit: decimal.NewFromInt(5), Credit: decimal.NewFromInt(2), LineCount: 2},
+			{AccountTypeCode: &asset, PeriodStart: day(time.February, 20), Debit: decimal.NewFromInt(7), Credit: decimal.Zero, LineCount: 1},
+		}, nil).Once()
+
+		resp, err := service.AggregateJournalLines(ctx, &pb.AggregateJournalLinesRequest{
+			TenantId: tenantID.String(),
+			GroupBy: []pb.AggregateGroupBy{
+				pb.AggregateGroupBy_AGGREGATE_GROUP_BY_ACCOUNT_TYPE,
+				pb.AggregateGroupBy_AGGREGATE_GROUP_BY_MONTH,
+			},
+			JalaliFromDate: &pb.JalaliDate{Year: 1402, Month: 11, Day: 1},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Aggregates, 2)
+		bahman, esfand := resp.Aggregates[0], resp.Aggregates[1]
+		assert.Equal(t, from, bahman.PeriodStart.AsTime())
+		assert.Equal(t, int32(11), bahman.JalaliPeriodStart.Month)
+		assert.Equal(t, "15", bahman.TotalDebit)
+		assert.Equal(t, int64(3), bahman.LineCount)
+		assert.Equal(t, *day(time.February, 20), esfand.PeriodStart.AsTime())
+		assert.Equal(t, &pb.JalaliDate{Year: 1402, Month: 12, Day: 1}, esfand.JalaliPeriodStart)
+		mockSettingsRepo.AssertExpectations(t)
+		mockReportRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a date set in both calendars", func(t *testing.T) {
+		mockSettingsRepo.On("Get", ctx, tenantID).Return(&repository.TenantSettings{TenantID: tenantID}, nil).Once()
+
+		_, err := service.AggregateJournalLines(ctx, &pb.AggregateJournalLinesRequest{
+			TenantId:       tenantID.String(),
+			FromDate:       timestamppb.New(*day(time.January, 1)),
+			JalaliFromDate: &pb.JalaliDate{Year: 1402, Month: 10, Day: 11},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, "INVALID_FIELD", errorReason(t, err))
+	})
+
+	t.Run("rejects an invalid Jalali date", func(t *testing.T) {
+		mockSettingsRepo.On("Get", ctx, tenantID).Return(&repository.TenantSettings{TenantID: tenantID}, nil).Once()
+
+		_, err := service.AggregateJournalLines(ctx, &pb.AggregateJournalLinesRequest{
+			TenantId:     tenantID.String(),
+			JalaliToDate: &pb.JalaliDate{Year: 1402, Month: 12, Day: 30},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestLedgerService_AggregateJournalLines_ReportingCurrency(t *testing.T) {
+	ctx := context.Background()
+	mockReportRepo := new(MockReportRepository)
+	mockReferenceRepo := new(MockReferenceRepository)
+	service := NewLedgerService(nil, nil, nil, mockReferenceRepo, WithReportRepository(mockReportRepo))
+
+	tenantID := uuid.New()
+	asset, expense := "ASSET", "EXPENSE"
+	usd, eur := "USD", "EUR"
+	january := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	reporting := &pb.ReportingCurrency{
+		CurrencyCode: "USD",
+		Rates:        []*pb.ExchangeRate{{CurrencyCode: "EUR", ClosingRate: "1.2", AverageRate: "1.1"}},
+	}
+
+	t.Run("translates each currency and account type before merging", func(t *testing.T) {
+		mockReferenceRepo.On("ListCurrencies", ctx).Return([]*repository.Currency{{Code: "USD"}, {Code: "EUR"}}, nil).Once()
+		mockReportRepo.On("AggregateJournalLines", ctx, tenantID, repository.LineAggregateFilter{
+			ByAccountType: true,
+			ByCurrency:    true,
+			Period:        repository.AggregatePeriodMonth,
+		}).Return([]*repository.LineAggregate{
+			{AccountTypeCode: &asset, CurrencyCode: &eur, PeriodStart: &february, Debit: decimal.NewFromInt(100), Credit: decimal.Zero, LineCount: 1},
+			{AccountTypeCode: &asset, CurrencyCode: &usd, PeriodStart: &january, Debit: decimal.NewFromInt(50), Credit: decimal.Zero, LineCount: 2},
+			{AccountTypeCode: &expense, CurrencyCode: &eur, PeriodStart: &january, Debit: decimal.NewFromInt(100), Credit: decimal.Zero, LineCount: 1},
+		}, nil).Once()
+		mockReportRepo.On("GetFreshness", ctx, tenantID).Return(&repository.ReportFreshness{AsOf: february}, nil).Once()
+
+		resp, err := service.AggregateJournalLines(ctx, &pb.AggregateJournalLinesRequest{
+			TenantId:          tenantID.String(),
+			GroupBy:           []pb.AggregateGroupBy{pb.AggregateGroupBy_AGGREGATE_GROUP_BY_MONTH},
+			ReportingCurrency: reporting,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "USD", resp.GetReportingCurrency())
+		require.Len(t, resp.Aggregates, 2)
+		assert.True(t, resp.Aggregates[0].PeriodStart.AsTime().Equal(january))
+		assert.Nil(t, resp.Aggregates[0].CurrencyCode)
+		assert.Nil(t, resp.Aggregates[0].AccountTypeCode)
+		// 50 USD plus 100 EUR of expenses at the average rate
+		assert.Equal(t, "160", resp.Aggregates[0].TotalDebit)
+		assert.Equal(t, int64(3), resp.Aggregates[0].LineCount)
+		// 100 EUR of assets at the closing rate
+		assert.True(t, resp.Aggregates[1].PeriodStart.AsTime().Equal(february))
+		assert.Equal(t, "120", resp.Aggregates[1].TotalDebit)
+		mockReferenceRepo.AssertExpectations(t)
+		mockReportRepo.AssertExpectations(t)
+	})
+
+	t.Run("fails without a rate for an account currency", func(t *testing.T) {
+		mockReferenceRepo.On("ListCurrencies", ctx).Return([]*repository.Currency{{Code: "USD"}, {Code: "EUR"}}, nil).Once()
+		mockReportRepo.On("AggregateJournalLines", ctx, tenantID, repository.LineAggregateFilter{
+			ByAccount:     true,
+			ByAccountType: true,
+			ByCurrency:    true,
+		}).Return([]*repository.LineAggregate{
+			{AccountTypeCode: &asset, CurrencyCode: &eur, Debit: decimal.NewFromInt(100), Credit: decimal.Zero, LineCount: 1},
+		}, nil).Once()
+
+		_, err := service.AggregateJournalLines(ctx, &pb.AggregateJournalLinesRequest{
+			TenantId:          tenantID.String(),
+			GroupBy:           []pb.AggregateGroupBy{pb.AggregateGroupBy_AGGREGATE_GROUP_BY_ACCOUNT},
+			ReportingCurrency: &pb.ReportingCurrency{CurrencyCode: "USD"},
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockReportRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,413 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/depreciation"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// AssetService implements the gRPC AssetService for the fixed asset register
+type AssetService struct {
+	pb.UnimplementedAssetServiceServer
+	accountRepo repository.AccountRepositoryInterface
+	assetRepo   repository.FixedAssetRepositoryInterface
+	poster      *depreciation.Poster
+}
+
+// NewAssetService creates a new asset service
+func NewAssetService(
+	accountRepo repository.AccountRepositoryInterface,
+	assetRepo repository.FixedAssetRepositoryInterface,
+) *AssetService {
+	return &AssetService{
+		accountRepo: accountRepo,
+		assetRepo:   assetRepo,
+		poster:      depreciation.NewPoster(assetRepo),
+	}
+}
+
+// CreateFixedAsset registers a fixed asset and generates its depreciation schedule
+func (s *AssetService) CreateFixedAsset(ctx context.Context, req *pb.CreateFixedAssetRequest) (*pb.CreateFixedAssetResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	assetAccountID, err := requestID("asset_account_id", req.AssetAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	accumulatedAccountID, err := requestID("accumulated_depreciation_account_id", req.AccumulatedDepreciationAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	expenseAccountID, err := requestID("depreciation_expense_account_id", req.DepreciationExpenseAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if accumulatedAccountID == expenseAccountID {
+		return nil, status.Error(codes.InvalidArgument, "accumulated depreciation and expense accounts must differ")
+	}
+
+	precision, err := s.assetPrecision(ctx, tenantID, assetAccountID, accumulatedAccountID, expenseAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := depreciationParams(req.Terms, precision)
+	if err != nil {
+		return nil, err
+	}
+
+	periods, err := depreciation.Schedule(params)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	var decliningFactor *decimal.Decimal
+	if params.Method == depreciation.MethodDecliningBalance {
+		decliningFactor = &params.DecliningFactor
+	}
+
+	asset, err := s.assetRepo.Create(ctx, tenantID, repository.CreateFixedAssetParams{
+		Code:                             req.Code,
+		Name:                             req.Name,
+		Description:                      req.Description,
+		AssetAccountID:                   assetAccountID,
+		AccumulatedDepreciationAccountID: accumulatedAccountID,
+		DepreciationExpenseAccountID:     expenseAccountID,
+		Method:                           string(params.Method),
+		Cost:                             params.Cost,
+		SalvageValue:                     params.SalvageValue,
+		UsefulLifeMonths:                 int32(params.UsefulLifeMonths),
+		DecliningFactor:                  decliningFactor,
+		InServiceDate:                    params.InServiceDate,
+		Schedule:                         scheduleLines(periods),
+	})
+	if err != nil {
+		return nil, repositoryError("create fixed asset", err)
+	}
+
+	return &pb.CreateFixedAssetResponse{
+		Asset: fixedAssetToProto(asset),
+	}, nil
+}
+
+// GetFixedAsset retrieves a fixed asset with its depreciation schedule
+func (s *AssetService) GetFixedAsset(ctx context.Context, req *pb.GetFixedAssetRequest) (*pb.GetFixedAssetResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	assetID, err := requestID("asset_id", req.AssetId)
+	if err != nil {
+		return nil, err
+	}
+
+	asset, err := s.assetRepo.GetByID(ctx, tenantID, assetID)
+	if err != nil {
+		return nil, repositoryError("get fixed asset", err)
+	}
+
+	return &pb.GetFixedAssetResponse{
+		Asset: fixedAssetToProto(asset),
+	}, nil
+}
+
+// ListFixedAssets lists the fixed asset register, optionally by status
+func (s *AssetService) ListFixedAssets(ctx context.Context, req *pb.ListFixedAssetsRequest) (*pb.ListFixedAssetsResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := repository.FixedAssetFilter{}
+	switch req.Status {
+	case pb.FixedAssetStatus_FIXED_ASSET_STATUS_ACTIVE:
+		assetStatus := repository.AssetStatusActive
+		filter.Status = &assetStatus
+	case pb.FixedAssetStatus_FIXED_ASSET_STATUS_FULLY_DEPRECIATED:
+		assetStatus := repository.AssetStatusFullyDepreciated
+		filter.Status = &assetStatus
+	}
+
+	page := int(req.GetPage())
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	assets, totalCount, err := s.assetRepo.List(ctx, tenantID, filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, repositoryError("list fixed assets", err)
+	}
+
+	pbAssets := make([]*pb.FixedAsset, len(assets))
+	for i, asset := range assets {
+		pbAssets[i] = fixedAssetToProto(asset)
+	}
+
+	return &pb.ListFixedAssetsResponse{
+		Assets:     pbAssets,
+		TotalCount: int32(totalCount),
+	}, nil
+}
+
+// PreviewDepreciationSchedule generates the schedule an asset would get
+// without registering it
+func (s *AssetService) PreviewDepreciationSchedule(ctx context.Context, req *pb.PreviewDepreciationScheduleRequest) (*pb.PreviewDepreciationScheduleResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	assetAccountID, err := requestID("asset_account_id", req.AssetAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	precision, err := s.assetPrecision(ctx, tenantID, assetAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := depreciationParams(req.Terms, precision)
+	if err != nil {
+		return nil, err
+	}
+
+	periods, err := depreciation.Schedule(params)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	lines := scheduleLines(periods)
+	pbLines := make([]*pb.DepreciationLine, len(lines))
+	for i, line := range lines {
+		pbLines[i] = depreciationLineToProto(line)
+	}
+
+	return &pb.PreviewDepreciationScheduleResponse{
+		Schedule: pbLines,
+	}, nil
+}
+
+// PostDepreciation posts the journal entries of every period that has fallen
+// due, for one asset or the whole register
+func (s *AssetService) PostDepreciation(ctx context.Context, req *pb.PostDepreciationRequest) (*pb.PostDepreciationResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	var assetID *uuid.UUID
+	if req.AssetId != nil {
+		id, err := requestID("asset_id", *req.AssetId)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.assetRepo.GetByID(ctx, tenantID, id); err != nil {
+			return nil, repositoryError("get fixed asset", err)
+		}
+		assetID = &id
+	}
+
+	through := time.Now()
+	if req.Through != nil {
+		through = req.Through.AsTime()
+	}
+
+	posted, err := s.poster.PostDue(ctx, tenantID, assetID, through)
+	if err != nil {
+		return nil, repositoryError("post depreciation", err)
+	}
+
+	pbLines := make([]*pb.DepreciationLine, len(posted))
+	for i, line := range posted {
+		pbLines[i] = depreciationLineToProto(line)
+	}
+
+	return &pb.PostDepreciationResponse{
+		Posted: pbLines,
+	}, nil
+}
+
+// assetPrecision checks that the accounts exist and share a currency, and
+// returns the precision of that currency
+func (s *AssetService) assetPrecision(ctx context.Context, tenantID uuid.UUID, accountIDs ...uuid.UUID) (int32, error) {
+	currencies, err := s.accountRepo.AccountCurrencies(ctx, tenantID, accountIDs)
+	if err != nil {
+		return 0, repositoryError("get account currencies", err)
+	}
+
+	var currency *repository.AccountCurrency
+	for _, accountID := range accountIDs {
+		c, ok := currencies[accountID]
+		if !ok {
+			return 0, status.Errorf(codes.NotFound, "account not found: %s", accountID)
+		}
+		if currency != nil && c.CurrencyCode != currency.CurrencyCode {
+			return 0, status.Error(codes.InvalidArgument, "asset accounts must share a currency")
+		}
+		currency = &c
+	}
+
+	return currency.Precision, nil
+}
+
+// depreciationParams validates depreciation terms; amounts are rounded to precision
+func depreciationParams(terms *pb.DepreciationTerms, precision int32) (depreciation.Params, error) {
+	if terms == nil {
+		return depreciation.Params{}, status.Error(codes.InvalidArgument, "depreciation terms are required")
+	}
+
+	params := depreciation.Params{
+		UsefulLifeMonths: int(terms.UsefulLifeMonths),
+		SalvageValue:     decimal.Zero,
+		Precision:        precision,
+	}
+
+	switch terms.Method {
+	case pb.DepreciationMethod_DEPRECIATION_METHOD_STRAIGHT_LINE:
+		params.Method = depreciation.MethodStraightLine
+	case pb.DepreciationMethod_DEPRECIATION_METHOD_DECLINING_BALANCE:
+		params.Method = depreciation.MethodDecliningBalance
+		params.DecliningFactor = depreciation.DefaultDecliningFactor
+		if terms.DecliningFactor != nil {
+			factor, err := decimal.NewFromString(*terms.DecliningFactor)
+			if err != nil {
+				return params, status.Error(codes.InvalidArgument, "invalid declining factor")
+			}
+			params.DecliningFactor = factor
+		}
+	default:
+		return params, status.Error(codes.InvalidArgument, "depreciation method must be STRAIGHT_LINE or DECLINING_BALANCE")
+	}
+
+	cost, err := decimal.NewFromString(terms.Cost)
+	if err != nil {
+		return params, status.Error(codes.InvalidArgument, "invalid cost")
+	}
+	params.Cost = cost
+
+	if terms.SalvageValue != "" {
+		salvage, err := decimal.NewFromString(terms.SalvageValue)
+		if err != nil {
+			return params, status.Error(codes.InvalidArgument, "invalid salvage value")
+		}
+		params.SalvageValue = salvage
+	}
+
+	if terms.InServiceDate == nil {
+		return params, status.Error(codes.InvalidArgument, "in-service date is required")
+	}
+	params.InServiceDate = terms.InServiceDate.AsTime()
+
+	return params, nil
+}
+
+func scheduleLines(periods []depreciation.Period) []*repository.DepreciationLine {
+	lines := make([]*repository.DepreciationLine, len(periods))
+	for i, p := range periods {
+		lines[i] = &repository.DepreciationLine{
+			Period:      int32(p.Number),
+			PeriodDate:  p.Date,
+			Amount:      p.Amount,
+			Accumulated: p.Accumulated,
+			BookValue:   p.BookValue,
+		}
+	}
+	return lines
+}
+
+func fixedAssetToProto(asset *repository.FixedAsset) *pb.FixedAsset {
+	pbAsset := &pb.FixedAsset{
+		AssetId:                          asset.ID.String(),
+		TenantId:                         asset.TenantID.String(),
+		Code:                             asset.Code,
+		Name:                             asset.Name,
+		Description:                      asset.Description,
+		AssetAccountId:                   asset.AssetAccountID.String(),
+		AccumulatedDepreciationAccountId: asset.AccumulatedDepreciationAccountID.String(),
+		DepreciationExpenseAccountId:     asset.DepreciationExpenseAccountID.String(),
+		Cost:                             asset.Cost.String(),
+		SalvageValue:                     asset.SalvageValue.String(),
+		UsefulLifeMonths:                 asset.UsefulLifeMonths,
+		InServiceDate:                    timestamppb.New(asset.InServiceDate),
+		AccumulatedDepreciation:          asset.AccumulatedDepreciation.String(),
+		BookValue:                        asset.BookValue().String(),
+		Schedule:                         make([]*pb.DepreciationLine, len(asset.Schedule)),
+		CreatedAt:                        timestamppb.New(asset.CreatedAt),
+		UpdatedAt:                        timestamppb.New(asset.UpdatedAt),
+	}
+
+	switch depreciation.Method(asset.Method) {
+	case depreciation.MethodStraightLine:
+		pbAsset.Method = pb.DepreciationMethod_DEPRECIATION_METHOD_STRAIGHT_LINE
+	case depreciation.MethodDecliningBalance:
+		pbAsset.Method = pb.DepreciationMethod_DEPRECIATION_METHOD_DECLINING_BALANCE
+	}
+
+	switch asset.Status {
+	case repository.AssetStatusActive:
+		pbAsset.Status = pb.FixedAssetStatus_FIXED_ASSET_STATUS_ACTIVE
+	case repository.AssetStatusFullyDepreciated:
+		pbAsset.Status = pb.FixedAssetStatus_FIXED_ASSET_STATUS_FULLY_DEPRECIATED
+	}
+
+	if asset.DecliningFactor != nil {
+		factor := asset.DecliningFactor.String()
+		pbAsset.DecliningFactor = &factor
+	}
+
+	for i, line := range asset.Schedule {
+		pbAsset.Schedule[i] = depreciationLineToProto(line)
+	}
+
+	return pbAsset
+}
+
+func depreciationLineToProto(line *repository.DepreciationLine) *pb.DepreciationLine {
+	pbLine := &pb.DepreciationLine{
+		Period:      line.Period,
+		PeriodDate:  timestamppb.New(line.PeriodDate),
+		Amount:      line.Amount.String(),
+		Accumulated: line.Accumulated.String(),
+		BookValue:   line.BookValue.String(),
+	}
+
+	if line.ID != uuid.Nil {
+		pbLine.LineId = line.ID.String()
+	}
+
+	if line.JournalEntryID != nil {
+		entryID := line.JournalEntryID.String()
+		pbLine.JournalEntryId = &entryID
+	}
+
+	if line.PostedAt != nil {
+		pbLine.PostedAt = timestamppb.New(*line.PostedAt)
+	}
+
+	return pbLine
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockFixedAssetRepository struct {
+	mock.Mock
+}
+
+func (m *MockFixedAssetRepository) Create(ctx context.Context, tenantID uuid.UUID, params repository.CreateFixedAssetParams) (*repository.FixedAsset, error) {
+	args := m.Called(ctx, tenantID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.FixedAsset), args.Error(1)
+}
+
+func (m *MockFixedAssetRepository) GetByID(ctx context.Context, tenantID uuid.UUID, assetID uuid.UUID) (*repository.FixedAsset, error) {
+	args := m.Called(ctx, tenantID, assetID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.FixedAsset), args.Error(1)
+}
+
+func (m *MockFixedAssetRepository) List(ctx context.Context, tenantID uuid.UUID, filter repository.FixedAssetFilter, limit, offset int) ([]*repository.FixedAsset, int, error) {
+	args := m.Called(ctx, tenantID, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*repository.FixedAsset), args.Int(1), args.Error(2)
+}
+
+func (m *MockFixedAssetRepository) ListDueDepreciation(ctx context.Context, tenantID uuid.UUID, assetID *uuid.UUID, through time.Time) ([]*repository.DepreciationLine, error) {
+	args := m.Called(ctx, tenantID, assetID, through)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.DepreciationLine), args.Error(1)
+}
+
+func (m *MockFixedAssetRepository) PostDepreciation(ctx context.Context, tenantID uuid.UUID, lineID uuid.UUID, entry repository.CreateJournalEntryParams) (*repository.DepreciationLine, error) {
+	args := m.Called(ctx, tenantID, lineID, entry)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.DepreciationLine), args.Error(1)
+}
+
+// Test CreateFixedAsset
+func TestAssetService_CreateFixedAsset(t *testing.T) {
+	ctx := context.Background()
+	inService := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("generates a straight line schedule", func(t *testing.T) {
+		mockAccountRepo := new(MockAccountRepository)
+		mockAssetRepo := new(MockFixedAssetRepository)
+		service := NewAssetService(mockAccountRepo, mockAssetRepo)
+		tenantID := uuid.New()
+		assetAccountID, accumulatedID, expenseID := uuid.New(), uuid.New(), uuid.New()
+		usd := repository.AccountCurrency{CurrencyCode: "USD", Precision: 2}
+
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{assetAccountID, accumulatedID, expenseID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{assetAccountID: usd, accumulatedID: usd, expenseID: usd}, nil).Once()
+		mockAssetRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(p repository.CreateFixedAssetParams) bool {
+			return p.Code == "FA-001" && p.Method == "STRAIGHT_LINE" && p.DecliningFactor == nil &&
+				len(p.Schedule) == 36 && p.Schedule[0].Amount.Equal(decimal.NewFromInt(100)) &&
+				p.Schedule[35].BookValue.Equal(decimal.NewFromInt(400))
+		})).Return(&repository.FixedAsset{
+			ID:               uuid.New(),
+			TenantID:         tenantID,
+			Code:             "FA-001",
+			Name:             "Delivery van",
+			Method:           "STRAIGHT_LINE",
+			Cost:             decimal.NewFromInt(4000),
+			SalvageValue:     decimal.NewFromInt(400),
+			UsefulLifeMonths: 36,
+			InServiceDate:    inService,
+			Status:           repository.AssetStatusActive,
+		}, nil).Once()
+
+		resp, err := service.CreateFixedAsset(ctx, &pb.CreateFixedAssetRequest{
+			TenantId:                         tenantID.String(),
+			Code:                             "FA-001",
+			Name:                             "Delivery van",
+			AssetAccountId:                   assetAccountID.String(),
+			AccumulatedDepreciationAccountId: accumulatedID.String(),
+			DepreciationExpenseAccountId:     expenseID.String(),
+			Terms: &pb.DepreciationTerms{
+				Method:           pb.DepreciationMethod_DEPRECIATION_METHOD_STRAIGHT_LINE,
+				Cost:             "4000",
+				SalvageValue:     "400",
+				UsefulLifeMonths: 36,
+				InServiceDate:    timestamppb.New(inService),
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, pb.FixedAssetStatus_FIXED_ASSET_STATUS_ACTIVE, resp.Asset.Status)
+		assert.Equal(t, "4000", resp.Asset.BookValue)
+		mockAccountRepo.AssertExpectations(t)
+		mockAssetRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for an unknown account", func(t *testing.T) {
+		mockAccountRepo := new(MockAccountRepository)
+		service := NewAssetService(mockAccountRepo, new(MockFixedAssetRepository))
+		tenantID := uuid.New()
+		assetAccountID, accumulatedID, expenseID := uuid.New(), uuid.New(), uuid.New()
+
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{assetAccountID, accumulatedID, expenseID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{}, nil).Once()
+
+		resp, err := service.CreateFixedAsset(ctx, &pb.CreateFixedAssetRequest{
+			TenantId:                         tenantID.String(),
+			Code:                             "FA-001",
+			Name:                             "Delivery van",
+			AssetAccountId:                   assetAccountID.String(),
+			AccumulatedDepreciationAccountId: accumulatedID.String(),
+			DepreciationExpenseAccountId:     expenseID.String(),
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("rejects a salvage value above cost", func(t *testing.T) {
+		mockAccountRepo := new(MockAccountRepository)
+		service := NewAssetService(mockAccountRepo, new(MockFixedAssetRepository))
+		tenantID := uuid.New()
+		assetAccountID, accumulatedID, expenseID := uuid.New(), uuid.New(), uuid.New()
+		usd := repository.AccountCurrency{CurrencyCode: "USD", Precision: 2}
+
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{assetAccountID, accumulatedID, expenseID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{assetAccountID: usd, accumulatedID: usd, expenseID: usd}, nil).Once()
+
+		resp, err := service.CreateFixedAsset(ctx, &pb.CreateFixedAssetRequest{
+			TenantId:                         tenantID.String(),
+			Code:                             "FA-001",
+			Name:                             "Delivery van",
+			AssetAccountId:                   assetAccountID.String(),
+			AccumulatedDepreciationAccountId: accumulatedID.String(),
+			DepreciationExpenseAccountId:     expenseID.String(),
+			Terms: &pb.DepreciationTerms{
+				Method:           pb.DepreciationMethod_DEPRECIATION_METHOD_DECLINING_BALANCE,
+				Cost:             "4000",
+				SalvageValue:     "5000",
+				UsefulLifeMonths: 36,
+				InServiceDate:    timestamppb.New(inService),
+			},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test PostDepreciation
+func TestAssetService_PostDepreciation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("posts the due periods of an asset", func(t *testing.T) {
+		mockAssetRepo := new(MockFixedAssetRepository)
+		service := NewAssetService(nil, mockAssetRepo)
+		tenantID := uuid.New()
+		expenseID, accumulatedID := uuid.New(), uuid.New()
+		asset := &repository.FixedAsset{
+			ID:                               uuid.New(),
+			Code:                             "FA-001",
+			Name:                             "Delivery van",
+			AccumulatedDepreciationAccountID: accumulatedID,
+			DepreciationExpenseAccountID:     expenseID,
+		}
+		through := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+		line := &repository.DepreciationLine{
+			ID:         uuid.New(),
+			AssetID:    asset.ID,
+			Period:     1,
+			PeriodDate: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+			Amount:     decimal.NewFromInt(100),
+		}
+		entryID := uuid.New()
+
+		mockAssetRepo.On("GetByID", ctx, tenantID, asset.ID).Return(asset, nil).Twice()
+		mockAssetRepo.On("ListDueDepreciation", ctx, tenantID, &asset.ID, through).
+			Return([]*repository.DepreciationLine{line}, nil).Once()
+		mockAssetRepo.On("PostDepreciation", ctx, tenantID, line.ID, mock.MatchedBy(func(e repository.CreateJournalEntryParams) bool {
+			return e.ReferenceNumber == "FA-001-DEP-001" && e.EntryDate.Equal(line.PeriodDate) &&
+				e.Lines[0].AccountID == expenseID && e.Lines[1].AccountID == accumulatedID
+		})).Return(&repository.DepreciationLine{
+			ID:             line.ID,
+			AssetID:        asset.ID,
+			Period:         1,
+			PeriodDate:     line.PeriodDate,
+			Amount:         line.Amount,
+			JournalEntryID: &entryID,
+		}, nil).Once()
+
+		assetID := asset.ID.String()
+		resp, err := service.PostDepreciation(ctx, &pb.PostDepreciationRequest{
+			TenantId: tenantID.String(),
+			AssetId:  &assetID,
+			Through:  timestamppb.New(through),
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Posted, 1)
+		assert.Equal(t, entryID.String(), resp.Posted[0].GetJournalEntryId())
+		mockAssetRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for an unknown asset", func(t *testing.T) {
+		mockAssetRepo := new(MockFixedAssetRepository)
+		service := NewAssetService(nil, mockAssetRepo)
+		tenantID, assetID := uuid.New(), uuid.New()
+
+		mockAssetRepo.On("GetByID", ctx, tenantID, assetID).
+			Return(nil, repository.ErrNotFound).Once()
+
+		id := assetID.String()
+		resp, err := service.PostDepreciation(ctx, &pb.PostDepreciationRequest{
+			TenantId: tenantID.String(),
+			AssetId:  &id,
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("rejects posting to a deleted account", func(t *testing.T) {
+		mockAssetRepo := new(MockFixedAssetRepository)
+		service := NewAssetService(nil, mockAssetRepo)
+		tenantID := uuid.New()
+		asset := &repository.FixedAsset{ID: uuid.New(), Code: "FA-002"}
+		line := &repository.DepreciationLine{ID: uuid.New(), AssetID: asset.ID, Period: 1}
+
+		mockAssetRepo.On("ListDueDepreciation", ctx, tenantID, (*uuid.UUID)(nil), mock.AnythingOfType("time.Time")).
+			Return([]*repository.DepreciationLine{line}, nil).Once()
+		mockAssetRepo.On("GetByID", ctx, tenantID, asset.ID).Return(asset, nil).Once()
+		mockAssetRepo.On("PostDepreciation", ctx, tenantID, line.ID, mock.Anything).
+			Return(nil, repository.ErrDeletedAccount).Once()
+
+		resp, err := service.PostDepreciation(ctx, &pb.PostDepreciationRequest{
+			TenantId: tenantID.String(),
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
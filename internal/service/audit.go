@@ -0,0 +1,114 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// Audit streams read the event store in pages and poll it for new events
+// once caught up
+const (
+	auditEventPageSize     = 100
+	defaultAuditPollPeriod = time.Second
+)
+
+// WatchAuditEvents streams a tenant's ledger events as they are recorded,
+// each with a resume token that continues the stream after it, so
+// compliance pipelines can reconnect without missing or repeating events
+func (s *LedgerService) WatchAuditEvents(req *pb.WatchAuditEventsRequest, stream pb.LedgerService_WatchAuditEventsServer) error {
+	if s.eventRepo == nil {
+		return status.Error(codes.Unimplemented, "the event store is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	var after int64
+	switch {
+	case req.ResumeToken != "":
+		after, err = parseAuditResumeToken(req.ResumeToken, tenantID)
+		if err != nil {
+			return invalidField("resume_token", err.Error())
+		}
+	case !req.IncludeHistory:
+		after, err = s.eventRepo.LastSequence(ctx, tenantID)
+		if err != nil {
+			return repositoryError("get last ledger event", err)
+		}
+	}
+
+	ticker := time.NewTicker(s.auditPollPeriod)
+	defer ticker.Stop()
+
+	for {
+		events, err := s.eventRepo.List(ctx, tenantID, after, auditEventPageSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return repositoryError("list ledger events", err)
+		}
+
+		for _, event := range events {
+			err := stream.Send(&pb.WatchAuditEventsResponse{
+				Event:       s.ledgerEventToProto(event),
+				ResumeToken: auditResumeToken(tenantID, event.Sequence),
+			})
+			if err != nil {
+				return err
+			}
+			after = event.Sequence
+		}
+
+		// Read the next page at once while catching up
+		if len(events) == auditEventPageSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// auditResumeToken encodes the position after an event of a tenant's stream
+func auditResumeToken(tenantID uuid.UUID, sequence int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("v1:%s:%d", tenantID, sequence)))
+}
+
+// parseAuditResumeToken decodes a resume token issued for a tenant's stream
+func parseAuditResumeToken(token string, tenantID uuid.UUID) (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid resume token")
+	}
+
+	parts := strings.Split(string(data), ":")
+	if len(parts) != 3 || parts[0] != "v1" {
+		return 0, fmt.Errorf("invalid resume token")
+	}
+	if parts[1] != tenantID.String() {
+		return 0, fmt.Errorf("resume token was issued for another tenant")
+	}
+
+	sequence, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || sequence < 0 {
+		return 0, fmt.Errorf("invalid resume token")
+	}
+
+	return sequence, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// fakeAuditStream collects the events sent by WatchAuditEvents and ends the
+// stream once it has received want of them
+type fakeAuditStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	cancel    context.CancelFunc
+	want      int
+	responses []*pb.WatchAuditEventsResponse
+}
+
+func newFakeAuditStream(want int) *fakeAuditStream {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	return &fakeAuditStream{ctx: ctx, cancel: cancel, want: want}
+}
+
+func (f *fakeAuditStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeAuditStream) Send(resp *pb.WatchAuditEventsResponse) error {
+	f.responses = append(f.responses, resp)
+	if len(f.responses) >= f.want {
+		f.cancel()
+	}
+	return nil
+}
+
+func TestLedgerService_WatchAuditEvents(t *testing.T) {
+	event := func(tenantID uuid.UUID, sequence int64) *repository.LedgerEvent {
+		return &repository.LedgerEvent{
+			Sequence:      sequence,
+			TenantID:      tenantID,
+			AggregateType: repository.AggregateAccount,
+			AggregateID:   uuid.New(),
+			EventType:     repository.EventAccountCreated,
+			Payload:       json.RawMessage(`{}`),
+			RecordedAt:    time.Now(),
+		}
+	}
+
+	newService := func(repo *MockEventRepository) *LedgerService {
+		service := NewLedgerService(nil, nil, nil, nil, WithEventRepository(repo))
+		service.auditPollPeriod = time.Millisecond
+		return service
+	}
+
+	t.Run("streams the history and then new events", func(t *testing.T) {
+		tenantID := uuid.New()
+		mockEventRepo := new(MockEventRepository)
+		stream := newFakeAuditStream(3)
+
+		mockEventRepo.On("List", stream.ctx, tenantID, int64(0), auditEventPageSize).Return([]*repository.LedgerEvent{
+			event(tenantID, 1), event(tenantID, 4),
+		}, nil).Once()
+		mockEventRepo.On("List", stream.ctx, tenantID, int64(4), auditEventPageSize).Return([]*repository.LedgerEvent{}, nil).Once()
+		mockEventRepo.On("List", stream.ctx, tenantID, int64(4), auditEventPageSize).Return([]*repository.LedgerEvent{
+			event(tenantID, 7),
+		}, nil).Once()
+
+		err := newService(mockEventRepo).WatchAuditEvents(&pb.WatchAuditEventsRequest{
+			TenantId:       tenantID.String(),
+			IncludeHistory: true,
+		}, stream)
+
+		require.NoError(t, err)
+		require.Len(t, stream.responses, 3)
+		assert.Equal(t, int64(7), stream.responses[2].Event.Sequence)
+		mockEventRepo.AssertExpectations(t)
+
+		sequence, err := parseAuditResumeToken(stream.responses[1].ResumeToken, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), sequence)
+	})
+
+	t.Run("resumes after the event of a token", func(t *testing.T) {
+		tenantID := uuid.New()
+		mockEventRepo := new(MockEventRepository)
+		stream := newFakeAuditStream(1)
+
+		mockEventRepo.On("List", stream.ctx, tenantID, int64(42), auditEventPageSize).Return([]*repository.LedgerEvent{
+			event(tenantID, 43),
+		}, nil).Once()
+
+		err := newService(mockEventRepo).WatchAuditEvents(&pb.WatchAuditEventsRequest{
+			TenantId:    tenantID.String(),
+			ResumeToken: auditResumeToken(tenantID, 42),
+		}, stream)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(43), stream.responses[0].Event.Sequence)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("starts after the latest event without history", func(t *testing.T) {
+		tenantID := uuid.New()
+		mockEventRepo := new(MockEventRepository)
+		stream := newFakeAuditStream(1)
+
+		mockEventRepo.On("LastSequence", stream.ctx, tenantID).Return(int64(99), nil).Once()
+		mockEventRepo.On("List", stream.ctx, tenantID, int64(99), auditEventPageSize).Return([]*repository.LedgerEvent{
+			event(tenantID, 100),
+		}, nil).Once()
+
+		err := newService(mockEventRepo).WatchAuditEvents(&pb.WatchAuditEventsRequest{
+			TenantId: tenantID.String(),
+		}, stream)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), stream.responses[0].Event.Sequence)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a token of another tenant", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+
+		err := newService(mockEventRepo).WatchAuditEvents(&pb.WatchAuditEventsRequest{
+			TenantId:    uuid.New().String(),
+			ResumeToken: auditResumeToken(uuid.New(), 42),
+		}, newFakeAuditStream(1))
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		mockEventRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns unimplemented when the event store is disabled", func(t *testing.T) {
+		err := NewLedgerService(nil, nil, nil, nil).WatchAuditEvents(&pb.WatchAuditEventsRequest{
+			TenantId: uuid.New().String(),
+		}, newFakeAuditStream(1))
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
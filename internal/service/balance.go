@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/projection"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// RebuildAccountBalances recomputes account balances and period totals from
+// journal lines, or only the balances by replaying the event store, and
+// reports the ones that had drifted
+func (s *AdminService) RebuildAccountBalances(ctx context.Context, req *pb.RebuildAccountBalancesRequest) (*pb.RebuildAccountBalancesResponse, error) {
+	if s.balanceRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "balance maintenance is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	var accountID *uuid.UUID
+	if req.AccountId != nil && *req.AccountId != "" {
+		id, err := requestID("account_id", *req.AccountId)
+		if err != nil {
+			return nil, err
+		}
+		accountID = &id
+	}
+
+	var result *repository.BalanceRebuild
+	if req.FromEvents {
+		result, err = s.balanceRepo.RebuildFromEvents(ctx, tenantID, accountID, projection.NewBalances(), projection.NewAccounts())
+	} else {
+		result, err = s.balanceRepo.Rebuild(ctx, tenantID, accountID)
+	}
+	if err != nil {
+		return nil, repositoryError("rebuild account balances", err)
+	}
+
+	resp := &pb.RebuildAccountBalancesResponse{
+		AccountsChecked:       int32(result.AccountsChecked),
+		Discrepancies:         make([]*pb.BalanceDiscrepancy, len(result.Discrepancies)),
+		PeriodTotalsCorrected: int32(result.PeriodTotalsCorrected),
+	}
+	for i, d := range result.Discrepancies {
+		resp.Discrepancies[i] = balanceDiscrepancyToProto(d)
+	}
+
+	return resp, nil
+}
+
+// VerifyTenantBalances verifies a tenant's stored balances without changing
+// them: debit balances must equal credit balances and the journal line
+// totals, and every account's balance the sums of its lines
+func (s *LedgerService) VerifyTenantBalances(ctx context.Context, req *pb.VerifyTenantBalancesRequest) (*pb.VerifyTenantBalancesResponse, error) {
+	if s.consistencyRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "balance verification is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.consistencyRepo.VerifyBalances(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("verify tenant balances", err)
+	}
+
+	resp := &pb.VerifyTenantBalancesResponse{
+		Verified:           result.Verified(),
+		VerifiedAt:         timestamppb.New(result.VerifiedAt),
+		AccountsVerified:   int32(result.AccountsVerified),
+		TotalDebitBalance:  result.BalanceDebit.String(),
+		TotalCreditBalance: result.BalanceCredit.String(),
+		TotalLineDebit:     result.LineDebit.String(),
+		TotalLineCredit:    result.LineCredit.String(),
+		BalancesEqual:      result.BalancesEqual(),
+		BalancesMatchLines: result.BalancesMatchLines(),
+		Discrepancies:      make([]*pb.BalanceDiscrepancy, len(result.Discrepancies)),
+	}
+	for i, d := range result.Discrepancies {
+		resp.Discrepancies[i] = balanceDiscrepancyToProto(d)
+	}
+
+	return resp, nil
+}
+
+func balanceDiscrepancyToProto(d *repository.BalanceDiscrepancy) *pb.BalanceDiscrepancy {
+	return &pb.BalanceDiscrepancy{
+		AccountId:      d.AccountID.String(),
+		StoredDebit:    d.StoredDebit.String(),
+		StoredCredit:   d.StoredCredit.String(),
+		ComputedDebit:  d.ComputedDebit.String(),
+		ComputedCredit: d.ComputedCredit.String(),
+		Missing:        d.Missing,
+	}
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockBalanceRepository struct {
+	mock.Mock
+}
+
+func (m *MockBalanceRepository) Rebuild(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID) (*repository.BalanceRebuild, error) {
+	args := m.Called(ctx, tenantID, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.BalanceRebuild), args.Error(1)
+}
+
+// RebuildFromEvents replays the events given to On into the projections and
+// reports the balances they project for the given accounts, as lost ones
+func (m *MockBalanceRepository) RebuildFromEvents(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, balances repository.BalanceProjection, accounts repository.AccountProjection) (*repository.BalanceRebuild, error) {
+	args := m.Called(ctx, tenantID, accountID)
+	if err := args.Error(2); err != nil {
+		return nil, err
+	}
+	events, _ := args.Get(0).([]*repository.LedgerEvent)
+	for _, event := range events {
+		if err := balances.Apply(event); err != nil {
+			return nil, err
+		}
+		if err := accounts.Apply(event); err != nil {
+			return nil, err
+		}
+	}
+	ids, _ := args.Get(1).([]uuid.UUID)
+	result := &repository.BalanceRebuild{AccountsChecked: len(ids), AccountsRestored: len(accounts.Accounts())}
+	for _, id := range ids {
+		d := &repository.BalanceDiscrepancy{AccountID: id, Missing: true}
+		d.ComputedDebit, d.ComputedCredit = balances.Totals(id)
+		result.Discrepancies = append(result.Discrepancies, d)
+	}
+	return result, nil
+}
+
+// Test RebuildAccountBalances
+func TestAdminService_RebuildAccountBalances(t *testing.T) {
+	ctx := context.Background()
+	mockBalanceRepo := new(MockBalanceRepository)
+	service := NewAdminService(nil, nil, nil, WithBalanceRepository(mockBalanceRepo))
+
+	t.Run("reports corrected balances for the tenant", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+
+		mockBalanceRepo.On("Rebuild", ctx, tenantID, (*uuid.UUID)(nil)).Return(&repository.BalanceRebuild{
+			AccountsChecked: 12,
+			Discrepancies: []*repository.BalanceDiscrepancy{
+				{
+					AccountID:      accountID,
+					StoredDebit:    decimal.NewFromInt(90),
+					StoredCredit:   decimal.Zero,
+					ComputedDebit:  decimal.NewFromInt(100),
+					ComputedCredit: decimal.Zero,
+				},
+			},
+			PeriodTotalsCorrected: 3,
+		}, nil).Once()
+
+		resp, err := service.RebuildAccountBalances(ctx, &pb.RebuildAccountBalancesRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(12), resp.AccountsChecked)
+		require.Len(t, resp.Discrepancies, 1)
+		assert.Equal(t, accountID.String(), resp.Discrepancies[0].AccountId)
+		assert.Equal(t, "90", resp.Discrepancies[0].StoredDebit)
+		assert.Equal(t, "100", resp.Discrepancies[0].ComputedDebit)
+		assert.Equal(t, int32(3), resp.PeriodTotalsCorrected)
+		mockBalanceRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for an unknown account", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+
+		mockBalanceRepo.On("Rebuild", ctx, tenantID, &accountID).
+			Return(nil, fmt.Errorf("account %w", repository.ErrNotFound)).Once()
+
+		id := accountID.String()
+		resp, err := service.RebuildAccountBalances(ctx, &pb.RebuildAccountBalancesRequest{
+			TenantId:  tenantID.String(),
+			AccountId: &id,
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Nil(t, resp)
+		mockBalanceRepo.AssertExpectations(t)
+	})
+
+	t.Run("rebuilds balances from the events alone", func(t *testing.T) {
+		tenantID := uuid.New()
+		cash, revenue := uuid.New(), uuid.New()
+		posted := func(sequence int64, amount int64, debit, credit uuid.UUID) *repository.LedgerEvent {
+			payload, err := json.Marshal(repository.JournalEntryPostedPayload{
+				ReferenceNumber: fmt.Sprintf("REF%03d", sequence),
+				EntryDate:       "2026-03-01",
+				Lines: []repository.PostedLine{
+					{AccountID: debit, Debit: decimal.NewFromInt(amount)},
+					{AccountID: credit, Credit: decimal.NewFromInt(amount)},
+				},
+			})
+			require.NoError(t, err)
+			return &repository.LedgerEvent{Sequence: sequence, EventType: repository.EventJournalEntryPosted, Payload: payload}
+		}
+		created := func(sequence int64, accountID uuid.UUID) *repository.LedgerEvent {
+			return &repository.LedgerEvent{
+				Sequence:      sequence,
+				AggregateType: repository.AggregateAccount,
+				AggregateID:   accountID,
+				EventType:     repository.EventAccountCreated,
+				Payload:       json.RawMessage(`{"account_number":"1000","name":"Cash","currency_code":"USD"}`),
+			}
+		}
+
+		mockBalanceRepo.On("RebuildFromEvents", ctx, tenantID, (*uuid.UUID)(nil)).Return(
+			[]*repository.LedgerEvent{created(1, cash), created(2, revenue), posted(3, 100, cash, revenue), posted(4, 40, revenue, cash)},
+			[]uuid.UUID{cash, revenue},
+			nil,
+		).Once()
+
+		resp, err := service.RebuildAccountBalances(ctx, &pb.RebuildAccountBalancesRequest{
+			TenantId:   tenantID.String(),
+			FromEvents: true,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Discrepancies, 2)
+		assert.Equal(t, "100", resp.Discrepancies[0].ComputedDebit)
+		assert.Equal(t, "40", resp.Discrepancies[0].ComputedCredit)
+		assert.Equal(t, "40", resp.Discrepancies[1].ComputedDebit)
+		assert.Equal(t, "100", resp.Discrepancies[1].ComputedCredit)
+		assert.Zero(t, resp.PeriodTotalsCorrected)
+		mockBalanceRepo.AssertExpectations(t)
+	})
+
+	t.Run("refuses to rebuild from an incomplete event history", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockBalanceRepo.On("RebuildFromEvents", ctx, tenantID, (*uuid.UUID)(nil)).
+			Return(nil, nil, repository.ErrEventHistoryIncomplete).Once()
+
+		_, err := service.RebuildAccountBalances(ctx, &pb.RebuildAccountBalancesRequest{
+			TenantId:   tenantID.String(),
+			FromEvents: true,
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockBalanceRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns unimplemented when balance maintenance is disabled", func(t *testing.T) {
+		resp, err := NewAdminService(nil, nil, nil).RebuildAccountBalances(ctx, &pb.RebuildAccountBalancesRequest{
+			TenantId: uuid.New().String(),
+		})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test VerifyTenantBalances
+func TestLedgerService_VerifyTenantBalances(t *testing.T) {
+	ctx := context.Background()
+	mockConsistencyRepo := new(MockConsistencyRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithConsistencyRepository(mockConsistencyRepo))
+
+	t.Run("reports verified balances", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockConsistencyRepo.On("VerifyBalances", ctx, tenantID).Return(&repository.BalanceVerification{
+			TenantID:         tenantID,
+			VerifiedAt:       time.Now(),
+			AccountsVerified: 4,
+			BalanceDebit:     decimal.NewFromInt(700),
+			BalanceCredit:    decimal.NewFromInt(700),
+			LineDebit:        decimal.NewFromInt(700),
+			LineCredit:       decimal.NewFromInt(700),
+			Discrepancies:    []*repository.BalanceDiscrepancy{},
+		}, nil).Once()
+
+		resp, err := service.VerifyTenantBalances(ctx, &pb.VerifyTenantBalancesRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.True(t, resp.Verified)
+		assert.True(t, resp.BalancesEqual)
+		assert.True(t, resp.BalancesMatchLines)
+		assert.Equal(t, int32(4), resp.AccountsVerified)
+		assert.Equal(t, "700", resp.TotalDebitBalance)
+		assert.Empty(t, resp.Discrepancies)
+		mockConsistencyRepo.AssertExpectations(t)
+	})
+
+	t.Run("reports drifted balances", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+
+		mockConsistencyRepo.On("VerifyBalances", ctx, tenantID).Return(&repository.BalanceVerification{
+			TenantID:         tenantID,
+			VerifiedAt:       time.Now(),
+			AccountsVerified: 2,
+			BalanceDebit:     decimal.NewFromInt(90),
+			BalanceCredit:    decimal.NewFromInt(100),
+			LineDebit:        decimal.NewFromInt(100),
+			LineCredit:       decimal.NewFromInt(100),
+			Discrepancies: []*repository.BalanceDiscrepancy{
+				{AccountID: accountID, StoredDebit: decimal.NewFromInt(90), ComputedDebit: decimal.NewFromInt(100)},
+			},
+		}, nil).Once()
+
+		resp, err := service.VerifyTenantBalances(ctx, &pb.VerifyTenantBalancesRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.False(t, resp.Verified)
+		assert.False(t, resp.BalancesEqual)
+		assert.False(t, resp.BalancesMatchLines)
+		require.Len(t, resp.Discrepancies, 1)
+		assert.Equal(t, accountID.String(), resp.Discrepancies[0].AccountId)
+		assert.Equal(t, "90", resp.Discrepancies[0].StoredDebit)
+		assert.Equal(t, "100", resp.Discrepancies[0].ComputedDebit)
+		mockConsistencyRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns invalid argument for a bad tenant ID", func(t *testing.T) {
+		resp, err := service.VerifyTenantBalances(ctx, &pb.VerifyTenantBalancesRequest{TenantId: "invalid"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns unimplemented when balance verification is disabled", func(t *testing.T) {
+		resp, err := NewLedgerService(nil, nil, nil, nil).VerifyTenantBalances(ctx, &pb.VerifyTenantBalancesRequest{
+			TenantId: uuid.New().String(),
+		})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
@@ -0,0 +1,479 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// CreateBatch creates an open journal batch to collect draft entries, such
+// as the entries of a payroll or billing run
+func (s *LedgerService) CreateBatch(ctx context.Context, req *pb.CreateBatchRequest) (*pb.CreateBatchResponse, error) {
+	if s.batchRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "journal batches are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, invalidField("name", "name is required")
+	}
+
+	batch, err := s.batchRepo.Create(ctx, tenantID, name, req.Description)
+	if err != nil {
+		return nil, repositoryError("create journal batch", err)
+	}
+
+	return &pb.CreateBatchResponse{
+		Batch: journalBatchToProto(batch),
+	}, nil
+}
+
+// GetBatch retrieves a journal batch with its entries
+func (s *LedgerService) GetBatch(ctx context.Context, req *pb.GetBatchRequest) (*pb.GetBatchResponse, error) {
+	if s.batchRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "journal batches are not enabled")
+	}
+
+	tenantID, batchID, err := parseBatchIDs(req.TenantId, req.BatchId)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := s.batchRepo.GetByID(ctx, tenantID, batchID)
+	if err != nil {
+		return nil, repositoryError("get journal batch", err)
+	}
+
+	return &pb.GetBatchResponse{
+		Batch: journalBatchToProto(batch),
+	}, nil
+}
+
+// ListBatches lists the journal batches of a tenant, optionally of one
+// status, without their entries
+func (s *LedgerService) ListBatches(ctx context.Context, req *pb.ListBatchesRequest) (*pb.ListBatchesResponse, error) {
+	if s.batchRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "journal batches are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	var batchStatus string
+	switch req.Status {
+	case pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_OPEN:
+		batchStatus = repository.JournalBatchStatusOpen
+	case pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_APPROVED:
+		batchStatus = repository.JournalBatchStatusApproved
+	case pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_POSTED:
+		batchStatus = repository.JournalBatchStatusPosted
+	case pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_REJECTED:
+		batchStatus = repository.JournalBatchStatusRejected
+	}
+	var statusFilter *string
+	if batchStatus != "" {
+		statusFilter = &batchStatus
+	}
+
+	page := int(req.GetPage())
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	batches, totalCount, err := s.batchRepo.List(ctx, tenantID, statusFilter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, repositoryError("list journal batches", err)
+	}
+
+	pbBatches := make([]*pb.JournalBatch, len(batches))
+	for i, batch := range batches {
+		pbBatches[i] = journalBatchToProto(batch)
+	}
+
+	return &pb.ListBatchesResponse{
+		Batches:    pbBatches,
+		TotalCount: int32(totalCount),
+	}, nil
+}
+
+// AddToBatch adds a draft entry to an open batch. The entry is checked as
+// CreateJournalEntry checks its request, and takes its reference number
+// now, but it posts only when the batch does.
+func (s *LedgerService) AddToBatch(ctx context.Context, req *pb.AddToBatchRequest) (*pb.AddToBatchResponse, error) {
+	if s.batchRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "journal batches are not enabled")
+	}
+
+	tenantID, batchID, err := parseBatchIDs(req.TenantId, req.BatchId)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := s.journalEntryParams(ctx, tenantID, &pb.CreateJournalEntryRequest{
+		TenantId:           req.TenantId,
+		ReferenceNumber:    req.ReferenceNumber,
+		Description:        req.Description,
+		EntryDate:          req.EntryDate,
+		Lines:              req.Lines,
+		Metadata:           req.Metadata,
+		CurrencyCode:       req.CurrencyCode,
+		TransactionId:      req.TransactionId,
+		BookId:             req.BookId,
+		JalaliEntryDate:    req.JalaliEntryDate,
+		LockOverrideReason: req.LockOverrideReason,
+	}, s.limits)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, entry, err := s.batchRepo.AddEntry(ctx, tenantID, batchID, params)
+	if err != nil {
+		return nil, repositoryError("add to journal batch", err)
+	}
+
+	return &pb.AddToBatchResponse{
+		Batch: journalBatchToProto(batch),
+		Entry: journalBatchEntryToProto(entry),
+	}, nil
+}
+
+// ValidateBatch reports every entry of a batch that would fail to post,
+// either against the posting policy as it stands now or when posted after
+// the entries before it. Nothing is posted.
+func (s *LedgerService) ValidateBatch(ctx context.Context, req *pb.ValidateBatchRequest) (*pb.ValidateBatchResponse, error) {
+	if s.batchRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "journal batches are not enabled")
+	}
+
+	tenantID, batchID, err := parseBatchIDs(req.TenantId, req.BatchId)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := s.batchRepo.GetByID(ctx, tenantID, batchID)
+	if err != nil {
+		return nil, repositoryError("get journal batch", err)
+	}
+
+	problems, err := s.batchPolicyProblems(ctx, tenantID, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries the policy refuses are not posted either, so they are
+	// reported once
+	refused := make(map[string]bool, len(problems))
+	for _, problem := range problems {
+		refused[problem.BatchEntryId] = true
+	}
+
+	postingProblems, err := s.batchRepo.Validate(ctx, tenantID, batchID)
+	if err != nil {
+		return nil, repositoryError("validate journal batch", err)
+	}
+
+	for _, problem := range postingProblems {
+		if refused[problem.Entry.ID.String()] {
+			continue
+		}
+
+		// Errors that do not depend on the entry fail the validation
+		st := status.Convert(repositoryError("post journal entry", problem.Err))
+		if st.Code() != codes.FailedPrecondition && st.Code() != codes.InvalidArgument {
+			return nil, st.Err()
+		}
+		problems = append(problems, batchEntryProblem(problem.Entry, st))
+	}
+
+	return &pb.ValidateBatchResponse{
+		Valid:    len(problems) == 0,
+		Problems: problems,
+	}, nil
+}
+
+// ApproveBatch approves an open batch for posting once every entry
+// validates, failing with the error of the first entry that does not
+func (s *LedgerService) ApproveBatch(ctx context.Context, req *pb.ApproveBatchRequest) (*pb.ApproveBatchResponse, error) {
+	if s.batchRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "journal batches are not enabled")
+	}
+
+	tenantID, batchID, err := parseBatchIDs(req.TenantId, req.BatchId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkBatchPostingPolicy(ctx, tenantID, batchID, repository.JournalBatchStatusOpen); err != nil {
+		return nil, err
+	}
+
+	batch, err := s.batchRepo.Approve(ctx, tenantID, batchID)
+	if err != nil {
+		return nil, repositoryError("approve journal batch", err)
+	}
+
+	return &pb.ApproveBatchResponse{
+		Batch: journalBatchToProto(batch),
+	}, nil
+}
+
+// RejectBatch rejects an open or approved batch so none of its entries post
+func (s *LedgerService) RejectBatch(ctx context.Context, req *pb.RejectBatchRequest) (*pb.RejectBatchResponse, error) {
+	if s.batchRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "journal batches are not enabled")
+	}
+
+	tenantID, batchID, err := parseBatchIDs(req.TenantId, req.BatchId)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, invalidField("reason", "reason is required")
+	}
+
+	batch, err := s.batchRepo.Reject(ctx, tenantID, batchID, reason)
+	if err != nil {
+		return nil, repositoryError("reject journal batch", err)
+	}
+
+	return &pb.RejectBatchResponse{
+		Batch: journalBatchToProto(batch),
+	}, nil
+}
+
+// PostBatch posts every entry of an approved batch in a single transaction,
+// so either all of them post or none do
+func (s *LedgerService) PostBatch(ctx context.Context, req *pb.PostBatchRequest) (*pb.PostBatchResponse, error) {
+	if s.batchRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "journal batches are not enabled")
+	}
+
+	tenantID, batchID, err := parseBatchIDs(req.TenantId, req.BatchId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkBatchPostingPolicy(ctx, tenantID, batchID, repository.JournalBatchStatusApproved); err != nil {
+		return nil, err
+	}
+
+	batch, err := s.batchRepo.Post(ctx, tenantID, batchID)
+	if err != nil {
+		return nil, repositoryError("post journal batch", err)
+	}
+
+	return &pb.PostBatchResponse{
+		Batch: journalBatchToProto(batch),
+	}, nil
+}
+
+// checkBatchPostingPolicy rejects a batch in the given status when an entry
+// breaks the posting policy as it stands now, since the policy may have
+// changed after the entry was added. A batch in another status is left for
+// the repository to approve or post again, or refuse.
+func (s *LedgerService) checkBatchPostingPolicy(ctx context.Context, tenantID, batchID uuid.UUID, batchStatus string) error {
+	if s.policyRepo == nil {
+		return nil
+	}
+
+	batch, err := s.batchRepo.GetByID(ctx, tenantID, batchID)
+	if err != nil {
+		return repositoryError("get journal batch", err)
+	}
+	if batch.Status != batchStatus {
+		return nil
+	}
+
+	problems, err := s.batchPolicyProblems(ctx, tenantID, batch)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return failedPrecondition(problems[0].Reason, problems[0].BatchEntryId,
+		fmt.Sprintf("batch entry %d: %s", problems[0].Position, problems[0].Message), nil)
+}
+
+// batchPolicyProblems checks the entries of a batch against the posting
+// policy. Entries that overrode the lock date when they were added keep the
+// override.
+func (s *LedgerService) batchPolicyProblems(ctx context.Context, tenantID uuid.UUID, batch *repository.JournalBatch) ([]*pb.BatchEntryProblem, error) {
+	problems := make([]*pb.BatchEntryProblem, 0)
+	if s.policyRepo == nil {
+		return problems, nil
+	}
+
+	clock, err := s.clock(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range batch.Entries {
+		if entry.Entry.LockOverrideReason != nil {
+			continue
+		}
+
+		if _, err := s.checkPostingPolicy(ctx, tenantID, clock, entry.Entry.EntryDate, nil); err != nil {
+			st := status.Convert(err)
+			if st.Code() != codes.FailedPrecondition {
+				return nil, err
+			}
+			problems = append(problems, batchEntryProblem(entry, st))
+		}
+	}
+
+	return problems, nil
+}
+
+// batchEntryProblem reports a batch entry that fails with st
+func batchEntryProblem(entry *repository.JournalBatchEntry, st *status.Status) *pb.BatchEntryProblem {
+	problem := &pb.BatchEntryProblem{
+		BatchEntryId: entry.ID.String(),
+		Position:     int32(entry.Position),
+		Message:      st.Message(),
+	}
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			problem.Reason = info.Reason
+			break
+		}
+	}
+
+	return problem
+}
+
+func parseBatchIDs(tenantIDValue, batchIDValue string) (uuid.UUID, uuid.UUID, error) {
+	tenantID, err := requestID("tenant_id", tenantIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	batchID, err := requestID("batch_id", batchIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return tenantID, batchID, nil
+}
+
+func journalBatchToProto(batch *repository.JournalBatch) *pb.JournalBatch {
+	pbBatch := &pb.JournalBatch{
+		BatchId:         batch.ID.String(),
+		TenantId:        batch.TenantID.String(),
+		Name:            batch.Name,
+		Description:     batch.Description,
+		EntryCount:      int32(batch.EntryCount),
+		TotalDebit:      batch.TotalDebit.String(),
+		Entries:         make([]*pb.JournalBatchEntry, len(batch.Entries)),
+		RejectionReason: batch.RejectionReason,
+		CreatedAt:       timestamppb.New(batch.CreatedAt),
+		UpdatedAt:       timestamppb.New(batch.UpdatedAt),
+	}
+
+	switch batch.Status {
+	case repository.JournalBatchStatusOpen:
+		pbBatch.Status = pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_OPEN
+	case repository.JournalBatchStatusApproved:
+		pbBatch.Status = pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_APPROVED
+	case repository.JournalBatchStatusPosted:
+		pbBatch.Status = pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_POSTED
+	case repository.JournalBatchStatusRejected:
+		pbBatch.Status = pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_REJECTED
+	}
+
+	if batch.ApprovedAt != nil {
+		pbBatch.ApprovedAt = timestamppb.New(*batch.ApprovedAt)
+	}
+	if batch.PostedAt != nil {
+		pbBatch.PostedAt = timestamppb.New(*batch.PostedAt)
+	}
+	if batch.RejectedAt != nil {
+		pbBatch.RejectedAt = timestamppb.New(*batch.RejectedAt)
+	}
+
+	for i, entry := range batch.Entries {
+		pbBatch.Entries[i] = journalBatchEntryToProto(entry)
+	}
+
+	return pbBatch
+}
+
+func journalBatchEntryToProto(entry *repository.JournalBatchEntry) *pb.JournalBatchEntry {
+	pbEntry := &pb.JournalBatchEntry{
+		BatchEntryId:    entry.ID.String(),
+		Position:        int32(entry.Position),
+		ReferenceNumber: entry.Entry.ReferenceNumber,
+		Description:     entry.Entry.Description,
+		EntryDate:       timestamppb.New(entry.Entry.EntryDate),
+		Lines:           make([]*pb.JournalEntryLine, len(entry.Entry.Lines)),
+		CreatedAt:       timestamppb.New(entry.CreatedAt),
+	}
+
+	if entry.Entry.TransactionID != "" {
+		transactionID := entry.Entry.TransactionID
+		pbEntry.TransactionId = &transactionID
+	}
+
+	if entry.JournalEntryID != nil {
+		journalEntryID := entry.JournalEntryID.String()
+		pbEntry.JournalEntryId = &journalEntryID
+	}
+
+	for i, line := range entry.Entry.Lines {
+		pbEntry.Lines[i] = &pb.JournalEntryLine{
+			AccountId:   line.AccountID.String(),
+			Debit:       line.Debit.String(),
+			Credit:      line.Credit.String(),
+			Description: line.Description,
+			IsTax:       line.IsTax,
+			Dimensions:  line.Dimensions,
+		}
+		if line.CounterpartyTenantID != nil {
+			counterpartyID := line.CounterpartyTenantID.String()
+			pbEntry.Lines[i].CounterpartyTenantId = &counterpartyID
+		}
+		if line.FxRate != nil {
+			fxRate := line.FxRate.String()
+			pbEntry.Lines[i].FxRate = &fxRate
+		}
+		if line.TaxCodeID != nil {
+			taxCodeID := line.TaxCodeID.String()
+			pbEntry.Lines[i].TaxCodeId = &taxCodeID
+		}
+		if line.PartyID != nil {
+			partyID := line.PartyID.String()
+			pbEntry.Lines[i].PartyId = &partyID
+		}
+	}
+
+	return pbEntry
+}
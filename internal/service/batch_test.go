@@ -0,0 +1,463 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockJournalBatchRepository struct {
+	mock.Mock
+}
+
+func (m *MockJournalBatchRepository) Create(ctx context.Context, tenantID uuid.UUID, name, description string) (*repository.JournalBatch, error) {
+	args := m.Called(ctx, tenantID, name, description)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.JournalBatch), args.Error(1)
+}
+
+func (m *MockJournalBatchRepository) GetByID(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID) (*repository.JournalBatch, error) {
+	args := m.Called(ctx, tenantID, batchID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.JournalBatch), args.Error(1)
+}
+
+func (m *MockJournalBatchRepository) List(ctx context.Context, tenantID uuid.UUID, batchStatus *string, limit, offset int) ([]*repository.JournalBatch, int, error) {
+	args := m.Called(ctx, tenantID, batchStatus, limit, offset)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*repository.JournalBatch), args.Int(1), args.Error(2)
+}
+
+func (m *MockJournalBatchRepository) AddEntry(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID, entry repository.CreateJournalEntryParams) (*repository.JournalBatch, *repository.JournalBatchEntry, error) {
+	args := m.Called(ctx, tenantID, batchID, entry)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*repository.JournalBatch), args.Get(1).(*repository.JournalBatchEntry), args.Error(2)
+}
+
+func (m *MockJournalBatchRepository) Validate(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID) ([]*repository.JournalBatchProblem, error) {
+	args := m.Called(ctx, tenantID, batchID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.JournalBatchProblem), args.Error(1)
+}
+
+func (m *MockJournalBatchRepository) Approve(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID) (*repository.JournalBatch, error) {
+	args := m.Called(ctx, tenantID, batchID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.JournalBatch), args.Error(1)
+}
+
+func (m *MockJournalBatchRepository) Reject(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID, reason string) (*repository.JournalBatch, error) {
+	args := m.Called(ctx, tenantID, batchID, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.JournalBatch), args.Error(1)
+}
+
+func (m *MockJournalBatchRepository) Post(ctx context.Context, tenantID uuid.UUID, batchID uuid.UUID) (*repository.JournalBatch, error) {
+	args := m.Called(ctx, tenantID, batchID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.JournalBatch), args.Error(1)
+}
+
+func TestLedgerService_JournalBatches(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	mockBatchRepo := new(MockJournalBatchRepository)
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		new(MockJournalRepository),
+		memory.NewReferenceRepository(store),
+		WithJournalBatchRepository(mockBatchRepo),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "payroll", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID
+
+	createAccount := func(number string, accountTypeID int32) uuid.UUID {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID.String(),
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: accountTypeID,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(t, err)
+		return uuid.MustParse(resp.AccountId)
+	}
+	salaries := createAccount("5001", 5)
+	bank := createAccount("1001", 1)
+
+	lines := []*pb.JournalEntryLine{
+		{AccountId: salaries.String(), Debit: "1200.00", Credit: "0"},
+		{AccountId: bank.String(), Debit: "0", Credit: "1200.00"},
+	}
+
+	openBatch := func() *repository.JournalBatch {
+		return &repository.JournalBatch{
+			ID:       uuid.New(),
+			TenantID: tenantID,
+			Name:     "Payroll 2026-09",
+			Status:   repository.JournalBatchStatusOpen,
+			Entries:  []*repository.JournalBatchEntry{},
+		}
+	}
+
+	t.Run("creates an open batch", func(t *testing.T) {
+		batch := openBatch()
+		mockBatchRepo.On("Create", ctx, tenantID, "Payroll 2026-09", "September salaries").Return(batch, nil).Once()
+
+		resp, err := service.CreateBatch(ctx, &pb.CreateBatchRequest{
+			TenantId:    tenantID.String(),
+			Name:        " Payroll 2026-09 ",
+			Description: "September salaries",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, batch.ID.String(), resp.Batch.BatchId)
+		assert.Equal(t, pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_OPEN, resp.Batch.Status)
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("requires a batch name", func(t *testing.T) {
+		_, err := service.CreateBatch(ctx, &pb.CreateBatchRequest{TenantId: tenantID.String(), Name: " "})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("adds a draft entry to a batch", func(t *testing.T) {
+		batch := openBatch()
+		batch.EntryCount = 1
+		batch.TotalDebit = decimal.NewFromInt(1200)
+		entry := &repository.JournalBatchEntry{ID: uuid.New(), BatchID: batch.ID, Position: 1}
+
+		mockBatchRepo.On("AddEntry", ctx, tenantID, batch.ID, mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			entry.Entry = p
+			return len(p.Lines) == 2 && p.Lines[0].AccountID == salaries && p.ReferenceNumber == "PAY-1"
+		})).Return(batch, entry, nil).Once()
+
+		resp, err := service.AddToBatch(ctx, &pb.AddToBatchRequest{
+			TenantId:        tenantID.String(),
+			BatchId:         batch.ID.String(),
+			ReferenceNumber: "PAY-1",
+			Lines:           lines,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), resp.Batch.EntryCount)
+		assert.Equal(t, "1200", resp.Batch.TotalDebit)
+		assert.Equal(t, int32(1), resp.Entry.Position)
+		assert.Equal(t, "PAY-1", resp.Entry.ReferenceNumber)
+		require.Len(t, resp.Entry.Lines, 2)
+		assert.Equal(t, bank.String(), resp.Entry.Lines[1].AccountId)
+		assert.Nil(t, resp.Entry.JournalEntryId)
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("checks a draft entry before adding it", func(t *testing.T) {
+		_, err := service.AddToBatch(ctx, &pb.AddToBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  uuid.NewString(),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: salaries.String(), Debit: "1200.00", Credit: "0"},
+				{AccountId: bank.String(), Debit: "0", Credit: "1000.00"},
+			},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, reasonUnbalancedEntry, errorReason(t, err))
+	})
+
+	t.Run("refuses adding to a batch that is not open", func(t *testing.T) {
+		batchID := uuid.New()
+		mockBatchRepo.On("AddEntry", ctx, tenantID, batchID, mock.Anything).Return(nil, nil, repository.ErrBatchNotOpen).Once()
+
+		_, err := service.AddToBatch(ctx, &pb.AddToBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  batchID.String(),
+			Lines:    lines,
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonBatchNotOpen, errorReason(t, err))
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("reports every entry that would fail to post", func(t *testing.T) {
+		batch := openBatch()
+		first := &repository.JournalBatchEntry{ID: uuid.New(), Position: 1}
+		second := &repository.JournalBatchEntry{ID: uuid.New(), Position: 2}
+		third := &repository.JournalBatchEntry{ID: uuid.New(), Position: 3}
+		batch.Entries = []*repository.JournalBatchEntry{first, second, third}
+
+		mockBatchRepo.On("GetByID", ctx, tenantID, batch.ID).Return(batch, nil).Once()
+		mockBatchRepo.On("Validate", ctx, tenantID, batch.ID).Return([]*repository.JournalBatchProblem{
+			{Entry: second, Err: repository.ErrInsufficientFunds},
+			{Entry: third, Err: repository.ErrDeletedAccount},
+		}, nil).Once()
+
+		resp, err := service.ValidateBatch(ctx, &pb.ValidateBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  batch.ID.String(),
+		})
+
+		require.NoError(t, err)
+		assert.False(t, resp.Valid)
+		require.Len(t, resp.Problems, 2)
+		assert.Equal(t, second.ID.String(), resp.Problems[0].BatchEntryId)
+		assert.Equal(t, int32(2), resp.Problems[0].Position)
+		assert.Equal(t, reasonInsufficientFunds, resp.Problems[0].Reason)
+		assert.Equal(t, reasonDeletedAccount, resp.Problems[1].Reason)
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("fails validation on errors unrelated to the entries", func(t *testing.T) {
+		batch := openBatch()
+		entry := &repository.JournalBatchEntry{ID: uuid.New(), Position: 1}
+		batch.Entries = []*repository.JournalBatchEntry{entry}
+
+		mockBatchRepo.On("GetByID", ctx, tenantID, batch.ID).Return(batch, nil).Once()
+		mockBatchRepo.On("Validate", ctx, tenantID, batch.ID).Return([]*repository.JournalBatchProblem{
+			{Entry: entry, Err: errors.New("connection reset")},
+		}, nil).Once()
+
+		_, err := service.ValidateBatch(ctx, &pb.ValidateBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  batch.ID.String(),
+		})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("approves and posts a batch", func(t *testing.T) {
+		batch := openBatch()
+		approvedAt := time.Now()
+		approved := *batch
+		approved.Status = repository.JournalBatchStatusApproved
+		approved.ApprovedAt = &approvedAt
+
+		mockBatchRepo.On("Approve", ctx, tenantID, batch.ID).Return(&approved, nil).Once()
+
+		approveResp, err := service.ApproveBatch(ctx, &pb.ApproveBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  batch.ID.String(),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_APPROVED, approveResp.Batch.Status)
+		assert.NotNil(t, approveResp.Batch.ApprovedAt)
+
+		entryID := uuid.New()
+		postedAt := time.Now()
+		posted := approved
+		posted.Status = repository.JournalBatchStatusPosted
+		posted.PostedAt = &postedAt
+		posted.Entries = []*repository.JournalBatchEntry{{ID: uuid.New(), Position: 1, JournalEntryID: &entryID}}
+
+		mockBatchRepo.On("Post", ctx, tenantID, batch.ID).Return(&posted, nil).Once()
+
+		postResp, err := service.PostBatch(ctx, &pb.PostBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  batch.ID.String(),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_POSTED, postResp.Batch.Status)
+		require.Len(t, postResp.Batch.Entries, 1)
+		assert.Equal(t, entryID.String(), postResp.Batch.Entries[0].GetJournalEntryId())
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("refuses approving a batch with a failing entry", func(t *testing.T) {
+		batchID := uuid.New()
+		mockBatchRepo.On("Approve", ctx, tenantID, batchID).
+			Return(nil, errors.Join(errors.New("batch entry 2"), repository.ErrInsufficientFunds)).Once()
+
+		_, err := service.ApproveBatch(ctx, &pb.ApproveBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  batchID.String(),
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonInsufficientFunds, errorReason(t, err))
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("refuses posting a batch that is not approved", func(t *testing.T) {
+		batchID := uuid.New()
+		mockBatchRepo.On("Post", ctx, tenantID, batchID).Return(nil, repository.ErrBatchNotApproved).Once()
+
+		_, err := service.PostBatch(ctx, &pb.PostBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  batchID.String(),
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonBatchNotApproved, errorReason(t, err))
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a batch with a reason", func(t *testing.T) {
+		batch := openBatch()
+		reason := "overtime missing"
+		rejected := *batch
+		rejected.Status = repository.JournalBatchStatusRejected
+		rejected.RejectionReason = &reason
+
+		mockBatchRepo.On("Reject", ctx, tenantID, batch.ID, reason).Return(&rejected, nil).Once()
+
+		resp, err := service.RejectBatch(ctx, &pb.RejectBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  batch.ID.String(),
+			Reason:   reason,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_REJECTED, resp.Batch.Status)
+		assert.Equal(t, reason, resp.Batch.GetRejectionReason())
+
+		_, err = service.RejectBatch(ctx, &pb.RejectBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  batch.ID.String(),
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("lists batches of a status", func(t *testing.T) {
+		approved := repository.JournalBatchStatusApproved
+		mockBatchRepo.On("List", ctx, tenantID, &approved, 50, 0).
+			Return([]*repository.JournalBatch{openBatch()}, 1, nil).Once()
+
+		resp, err := service.ListBatches(ctx, &pb.ListBatchesRequest{
+			TenantId: tenantID.String(),
+			Status:   pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_APPROVED,
+		})
+
+		require.NoError(t, err)
+		assert.Len(t, resp.Batches, 1)
+		assert.Equal(t, int32(1), resp.TotalCount)
+		mockBatchRepo.AssertExpectations(t)
+	})
+}
+
+func TestLedgerService_JournalBatchPostingPolicy(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	mockBatchRepo := new(MockJournalBatchRepository)
+	mockPolicyRepo := new(MockPostingPolicyRepository)
+	service := NewLedgerService(nil, nil, nil, nil,
+		WithJournalBatchRepository(mockBatchRepo),
+		WithPostingPolicyRepository(mockPolicyRepo),
+	)
+
+	lockDate := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	mockPolicyRepo.On("Get", ctx, tenantID).Return(&repository.PostingPolicy{
+		TenantID:         tenantID,
+		AllowFutureDates: true,
+		LockDate:         &lockDate,
+	}, nil)
+
+	overrideReason := "audit adjustment"
+	batch := &repository.JournalBatch{
+		ID:       uuid.New(),
+		TenantID: tenantID,
+		Status:   repository.JournalBatchStatusOpen,
+		Entries: []*repository.JournalBatchEntry{
+			{ID: uuid.New(), Position: 1, Entry: repository.CreateJournalEntryParams{
+				EntryDate: lockDate.AddDate(0, 0, 1),
+			}},
+			{ID: uuid.New(), Position: 2, Entry: repository.CreateJournalEntryParams{
+				EntryDate:          lockDate,
+				LockOverrideReason: &overrideReason,
+			}},
+			{ID: uuid.New(), Position: 3, Entry: repository.CreateJournalEntryParams{
+				EntryDate: lockDate.AddDate(0, 0, -1),
+			}},
+		},
+	}
+
+	t.Run("refuses approving entries locked after they were added", func(t *testing.T) {
+		mockBatchRepo.On("GetByID", ctx, tenantID, batch.ID).Return(batch, nil).Once()
+
+		_, err := service.ApproveBatch(ctx, &pb.ApproveBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  batch.ID.String(),
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonPeriodLocked, errorReason(t, err))
+		assert.Contains(t, status.Convert(err).Message(), "batch entry 3")
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("reports locked entries once when validating", func(t *testing.T) {
+		mockBatchRepo.On("GetByID", ctx, tenantID, batch.ID).Return(batch, nil).Once()
+		mockBatchRepo.On("Validate", ctx, tenantID, batch.ID).Return([]*repository.JournalBatchProblem{
+			{Entry: batch.Entries[2], Err: repository.ErrDeletedAccount},
+		}, nil).Once()
+
+		resp, err := service.ValidateBatch(ctx, &pb.ValidateBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  batch.ID.String(),
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Problems, 1)
+		assert.Equal(t, int32(3), resp.Problems[0].Position)
+		assert.Equal(t, reasonPeriodLocked, resp.Problems[0].Reason)
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns a posted batch without checking the policy again", func(t *testing.T) {
+		posted := *batch
+		posted.Status = repository.JournalBatchStatusPosted
+		mockBatchRepo.On("GetByID", ctx, tenantID, batch.ID).Return(&posted, nil).Once()
+		mockBatchRepo.On("Post", ctx, tenantID, batch.ID).Return(&posted, nil).Once()
+
+		resp, err := service.PostBatch(ctx, &pb.PostBatchRequest{
+			TenantId: tenantID.String(),
+			BatchId:  batch.ID.String(),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, pb.JournalBatchStatus_JOURNAL_BATCH_STATUS_POSTED, resp.Batch.Status)
+		mockBatchRepo.AssertExpectations(t)
+	})
+}
+
+func TestLedgerService_JournalBatchesNotEnabled(t *testing.T) {
+	service := NewLedgerService(nil, nil, nil, nil)
+
+	_, err := service.CreateBatch(context.Background(), &pb.CreateBatchRequest{TenantId: uuid.NewString(), Name: "Payroll"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
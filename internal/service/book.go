@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// CreateBook creates a book kept alongside the tenant's default book
+func (s *LedgerService) CreateBook(ctx context.Context, req *pb.CreateBookRequest) (*pb.CreateBookResponse, error) {
+	if s.bookRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "books are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Code == "" {
+		return nil, invalidField("code", "book code is required")
+	}
+
+	if req.Name == "" {
+		return nil, invalidField("name", "book name is required")
+	}
+
+	params := repository.CreateBookParams{
+		Code: req.Code,
+		Name: req.Name,
+	}
+
+	if req.Description != "" {
+		params.Description = &req.Description
+	}
+
+	book, err := s.bookRepo.Create(ctx, tenantID, params)
+	if err != nil {
+		return nil, repositoryError("create book", err)
+	}
+
+	return &pb.CreateBookResponse{
+		Book: bookToProto(book),
+	}, nil
+}
+
+// GetBook retrieves a book
+func (s *LedgerService) GetBook(ctx context.Context, req *pb.GetBookRequest) (*pb.GetBookResponse, error) {
+	if s.bookRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "books are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	bookID, err := requestID("book_id", req.BookId)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := s.bookRepo.GetByID(ctx, tenantID, bookID)
+	if err != nil {
+		return nil, repositoryError("get book", err)
+	}
+
+	return &pb.GetBookResponse{
+		Book: bookToProto(book),
+	}, nil
+}
+
+// ListBooks lists the books of a tenant, the default book first
+func (s *LedgerService) ListBooks(ctx context.Context, req *pb.ListBooksRequest) (*pb.ListBooksResponse, error) {
+	if s.bookRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "books are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	books, err := s.bookRepo.List(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("list books", err)
+	}
+
+	resp := &pb.ListBooksResponse{
+		Books: make([]*pb.Book, len(books)),
+	}
+	for i, book := range books {
+		resp.Books[i] = bookToProto(book)
+	}
+
+	return resp, nil
+}
+
+// parseBookID parses the optional book of a request
+func parseBookID(value *string) (*uuid.UUID, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+
+	bookID, err := requestID("book_id", *value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bookID, nil
+}
+
+func bookToProto(book *repository.Book) *pb.Book {
+	pbBook := &pb.Book{
+		BookId:    book.ID.String(),
+		TenantId:  book.TenantID.String(),
+		Code:      book.Code,
+		Name:      book.Name,
+		IsDefault: book.IsDefault,
+		CreatedAt: timestamppb.New(book.CreatedAt),
+		UpdatedAt: timestamppb.New(book.UpdatedAt),
+	}
+
+	if book.Description != nil {
+		pbBook.Description = *book.Description
+	}
+
+	return pbBook
+}
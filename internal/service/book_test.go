@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+func TestLedgerService_Books(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+		WithBookRepository(memory.NewBookRepository(store)),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "ifrs", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	ifrsResp, err := service.CreateBook(ctx, &pb.CreateBookRequest{
+		TenantId: tenantID,
+		Code:     "IFRS",
+		Name:     "IFRS reporting",
+	})
+	require.NoError(t, err)
+	ifrs := ifrsResp.Book.BookId
+
+	createAccount := func(number string, accountTypeID int32, bookID, parentID *string) (*pb.CreateAccountResponse, error) {
+		return service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:        tenantID,
+			AccountNumber:   number,
+			Name:            "Account " + number,
+			AccountTypeId:   accountTypeID,
+			CurrencyCode:    "USD",
+			BookId:          bookID,
+			ParentAccountId: parentID,
+		})
+	}
+
+	mainCash, err := createAccount("1000", 1, nil, nil)
+	require.NoError(t, err)
+	mainEquity, err := createAccount("3000", 3, nil, nil)
+	require.NoError(t, err)
+	ifrsCash, err := createAccount("1000", 1, &ifrs, nil)
+	require.NoError(t, err)
+	ifrsEquity, err := createAccount("3000", 3, &ifrs, nil)
+	require.NoError(t, err)
+
+	post := func(reference string, bookID *string, debitAccount, creditAccount string) (*pb.CreateJournalEntryResponse, error) {
+		return service.CreateJournalEntry(ctx, &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID,
+			ReferenceNumber: reference,
+			Description:     "Capital",
+			EntryDate:       timestamppb.New(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)),
+			BookId:          bookID,
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: debitAccount, Debit: "100", Credit: "0"},
+				{AccountId: creditAccount, Debit: "0", Credit: "100"},
+			},
+		})
+	}
+
+	t.Run("lists the default book first", func(t *testing.T) {
+		resp, err := service.ListBooks(ctx, &pb.ListBooksRequest{TenantId: tenantID})
+		require.NoError(t, err)
+		require.Len(t, resp.Books, 2)
+		assert.Equal(t, "MAIN", resp.Books[0].Code)
+		assert.True(t, resp.Books[0].IsDefault)
+		assert.Equal(t, "IFRS", resp.Books[1].Code)
+
+		book, err := service.GetBook(ctx, &pb.GetBookRequest{TenantId: tenantID, BookId: ifrs})
+		require.NoError(t, err)
+		assert.Equal(t, "IFRS reporting", book.Book.Name)
+		assert.Equal(t, resp.Books[0].BookId, mainCash.BookId)
+	})
+
+	t.Run("rejects a duplicate book code", func(t *testing.T) {
+		_, err := service.CreateBook(ctx, &pb.CreateBookRequest{TenantId: tenantID, Code: "IFRS", Name: "Again"})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	})
+
+	t.Run("keeps child accounts in the book of their parent", func(t *testing.T) {
+		resp, err := createAccount("1010", 1, nil, &ifrsCash.AccountId)
+		require.NoError(t, err)
+		assert.Equal(t, ifrs, resp.BookId)
+
+		mainBook := mainCash.BookId
+		_, err = createAccount("1020", 1, &mainBook, &ifrsCash.AccountId)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonBookMismatch, errorReason(t, err))
+	})
+
+	t.Run("posts entries within one book", func(t *testing.T) {
+		_, err := post("MAIN-1", nil, mainCash.AccountId, mainEquity.AccountId)
+		require.NoError(t, err)
+		_, err = post("IFRS-1", &ifrs, ifrsCash.AccountId, ifrsEquity.AccountId)
+		require.NoError(t, err)
+
+		resp, err := service.ListJournalEntries(ctx, &pb.ListJournalEntriesRequest{TenantId: tenantID, BookId: &ifrs})
+		require.NoError(t, err)
+		require.Len(t, resp.JournalEntries, 1)
+		assert.Equal(t, "IFRS-1", resp.JournalEntries[0].ReferenceNumber)
+	})
+
+	t.Run("rejects entries across books", func(t *testing.T) {
+		_, err := post("MIX-1", nil, mainCash.AccountId, ifrsEquity.AccountId)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonBookMismatch, errorReason(t, err))
+
+		_, err = post("MIX-2", &ifrs, mainCash.AccountId, mainEquity.AccountId)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonBookMismatch, errorReason(t, err))
+	})
+
+	t.Run("lists the accounts of a book", func(t *testing.T) {
+		resp, err := service.ListAccounts(ctx, &pb.ListAccountsRequest{TenantId: tenantID, BookId: &ifrs})
+		require.NoError(t, err)
+		assert.Len(t, resp.Accounts, 3)
+		for _, account := range resp.Accounts {
+			assert.Equal(t, ifrs, account.BookId)
+		}
+	})
+
+	t.Run("does not move accounts across books", func(t *testing.T) {
+		_, err := service.MoveAccount(ctx, &pb.MoveAccountRequest{
+			TenantId:        tenantID,
+			AccountId:       mainCash.AccountId,
+			ParentAccountId: &ifrsCash.AccountId,
+		})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonBookMismatch, errorReason(t, err))
+	})
+
+	t.Run("returns not found for an unknown book", func(t *testing.T) {
+		unknown := uuid.New().String()
+		_, err := createAccount("1900", 1, &unknown, nil)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// CreateBudget creates a budget with its lines
+func (s *LedgerService) CreateBudget(ctx context.Context, req *pb.CreateBudgetRequest) (*pb.CreateBudgetResponse, error) {
+	if s.budgetRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "budgets are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := budgetParams(req.Name, req.Description, req.Lines)
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := s.budgetRepo.Create(ctx, tenantID, params)
+	if err != nil {
+		return nil, repositoryError("create budget", err)
+	}
+
+	return &pb.CreateBudgetResponse{
+		Budget: budgetToProto(budget),
+	}, nil
+}
+
+// GetBudget retrieves a budget with its lines
+func (s *LedgerService) GetBudget(ctx context.Context, req *pb.GetBudgetRequest) (*pb.GetBudgetResponse, error) {
+	if s.budgetRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "budgets are not enabled")
+	}
+
+	tenantID, budgetID, err := parseBudgetIDs(req.TenantId, req.BudgetId)
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := s.budgetRepo.GetByID(ctx, tenantID, budgetID)
+	if err != nil {
+		return nil, repositoryError("get budget", err)
+	}
+
+	return &pb.GetBudgetResponse{
+		Budget: budgetToProto(budget),
+	}, nil
+}
+
+// ListBudgets lists the budgets of a tenant without their lines
+func (s *LedgerService) ListBudgets(ctx context.Context, req *pb.ListBudgetsRequest) (*pb.ListBudgetsResponse, error) {
+	if s.budgetRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "budgets are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	page := int(req.GetPage())
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	budgets, totalCount, err := s.budgetRepo.List(ctx, tenantID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, repositoryError("list budgets", err)
+	}
+
+	pbBudgets := make([]*pb.Budget, len(budgets))
+	for i, budget := range budgets {
+		pbBudgets[i] = budgetToProto(budget)
+	}
+
+	return &pb.ListBudgetsResponse{
+		Budgets:    pbBudgets,
+		TotalCount: int32(totalCount),
+	}, nil
+}
+
+// UpdateBudget replaces the name, description and lines of a budget
+func (s *LedgerService) UpdateBudget(ctx context.Context, req *pb.UpdateBudgetRequest) (*pb.UpdateBudgetResponse, error) {
+	if s.budgetRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "budgets are not enabled")
+	}
+
+	tenantID, budgetID, err := parseBudgetIDs(req.TenantId, req.BudgetId)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := budgetParams(req.Name, req.Description, req.Lines)
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := s.budgetRepo.Update(ctx, tenantID, budgetID, params)
+	if err != nil {
+		return nil, repositoryError("update budget", err)
+	}
+
+	return &pb.UpdateBudgetResponse{
+		Budget: budgetToProto(budget),
+	}, nil
+}
+
+// DeleteBudget deletes a budget and its lines
+func (s *LedgerService) DeleteBudget(ctx context.Context, req *pb.DeleteBudgetRequest) (*pb.DeleteBudgetResponse, error) {
+	if s.budgetRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "budgets are not enabled")
+	}
+
+	tenantID, budgetID, err := parseBudgetIDs(req.TenantId, req.BudgetId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.budgetRepo.Delete(ctx, tenantID, budgetID); err != nil {
+		return nil, repositoryError("delete budget", err)
+	}
+
+	return &pb.DeleteBudgetResponse{}, nil
+}
+
+// GetBudgetVsActual compares each budget line with the amounts posted in its
+// period and computes the variance
+func (s *LedgerService) GetBudgetVsActual(ctx context.Context, req *pb.GetBudgetVsActualRequest) (*pb.GetBudgetVsActualResponse, error) {
+	if s.budgetRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "budgets are not enabled")
+	}
+
+	tenantID, budgetID, err := parseBudgetIDs(req.TenantId, req.BudgetId)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.budgetRepo.GetByID(ctx, tenantID, budgetID); err != nil {
+		return nil, repositoryError("get budget", err)
+	}
+
+	actuals, err := s.budgetRepo.GetActuals(ctx, tenantID, budgetID)
+	if err != nil {
+		return nil, repositoryError("get budget actuals", err)
+	}
+
+	resp := &pb.GetBudgetVsActualResponse{
+		BudgetId:  budgetID.String(),
+		Variances: make([]*pb.BudgetVariance, len(actuals)),
+	}
+
+	totalBudget := decimal.Zero
+	totalActual := decimal.Zero
+	for i, a := range actuals {
+		actual := a.Actual()
+		variance := actual.Sub(a.Line.Amount)
+
+		pbVariance := &pb.BudgetVariance{
+			Line:          budgetLineToProto(a.Line),
+			AccountNumber: a.AccountNumber,
+			AccountName:   a.AccountName,
+			BudgetAmount:  a.Line.Amount.String(),
+			ActualAmount:  actual.String(),
+			Variance:      variance.String(),
+		}
+		if !a.Line.Amount.IsZero() {
+			percent := variance.Div(a.Line.Amount).Mul(decimal.NewFromInt(100)).Round(2).String()
+			pbVariance.VariancePercent = &percent
+		}
+		resp.Variances[i] = pbVariance
+
+		totalBudget = totalBudget.Add(a.Line.Amount)
+		totalActual = totalActual.Add(actual)
+	}
+
+	resp.TotalBudget = totalBudget.String()
+	resp.TotalActual = totalActual.String()
+	resp.TotalVariance = totalActual.Sub(totalBudget).String()
+
+	return resp, nil
+}
+
+func parseBudgetIDs(tenantIDValue, budgetIDValue string) (uuid.UUID, uuid.UUID, error) {
+	tenantID, err := requestID("tenant_id", tenantIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	budgetID, err := requestID("budget_id", budgetIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return tenantID, budgetID, nil
+}
+
+func budgetParams(name, description string, lines []*pb.BudgetLine) (repository.BudgetParams, error) {
+	params := repository.BudgetParams{
+		Name:        name,
+		Description: description,
+		Lines:       make([]*repository.BudgetLineParams, len(lines)),
+	}
+
+	for i, line := range lines {
+		accountID, err := uuid.Parse(line.AccountId)
+		if err != nil {
+			return params, invalidLine(i, "account_id", "invalid account ID at line %d", i)
+		}
+
+		if line.PeriodStart == nil || line.PeriodEnd == nil {
+			return params, invalidLine(i, "period_start", "period start and end are required at line %d", i)
+		}
+
+		periodStart := line.PeriodStart.AsTime()
+		periodEnd := line.PeriodEnd.AsTime()
+		if periodEnd.Before(periodStart) {
+			return params, invalidLine(i, "period_end", "period end must not be before period start at line %d", i)
+		}
+
+		amount, err := decimal.NewFromString(line.Amount)
+		if err != nil {
+			return params, invalidLine(i, "amount", "invalid amount at line %d", i)
+		}
+
+		if (line.DimensionKey == nil) != (line.DimensionValue == nil) {
+			return params, invalidLine(i, "dimension_value", "dimension key and value must be set together at line %d", i)
+		}
+
+		params.Lines[i] = &repository.BudgetLineParams{
+			AccountID:      accountID,
+			PeriodStart:    periodStart,
+			PeriodEnd:      periodEnd,
+			Amount:         amount,
+			DimensionKey:   line.DimensionKey,
+			DimensionValue: line.DimensionValue,
+		}
+	}
+
+	return params, nil
+}
+
+func budgetToProto(budget *repository.Budget) *pb.Budget {
+	pbBudget := &pb.Budget{
+		BudgetId:    budget.ID.String(),
+		TenantId:    budget.TenantID.String(),
+		Name:        budget.Name,
+		Description: budget.Description,
+		Lines:       make([]*pb.BudgetLine, len(budget.Lines)),
+		CreatedAt:   timestamppb.New(budget.CreatedAt),
+		UpdatedAt:   timestamppb.New(budget.UpdatedAt),
+	}
+
+	for i, line := range budget.Lines {
+		pbBudget.Lines[i] = budgetLineToProto(line)
+	}
+
+	return pbBudget
+}
+
+func budgetLineToProto(line *repository.BudgetLine) *pb.BudgetLine {
+	lineID := line.ID.String()
+	return &pb.BudgetLine{
+		LineId:         &lineID,
+		AccountId:      line.AccountID.String(),
+		PeriodStart:    timestamppb.New(line.PeriodStart),
+		PeriodEnd:      timestamppb.New(line.PeriodEnd),
+		Amount:         line.Amount.String(),
+		DimensionKey:   line.DimensionKey,
+		DimensionValue: line.DimensionValue,
+	}
+}
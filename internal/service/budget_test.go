@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockBudgetRepository struct {
+	mock.Mock
+}
+
+func (m *MockBudgetRepository) Create(ctx context.Context, tenantID uuid.UUID, params repository.BudgetParams) (*repository.Budget, error) {
+	args := m.Called(ctx, tenantID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Budget), args.Error(1)
+}
+
+func (m *MockBudgetRepository) Update(ctx context.Context, tenantID uuid.UUID, budgetID uuid.UUID, params repository.BudgetParams) (*repository.Budget, error) {
+	args := m.Called(ctx, tenantID, budgetID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Budget), args.Error(1)
+}
+
+func (m *MockBudgetRepository) Delete(ctx context.Context, tenantID uuid.UUID, budgetID uuid.UUID) error {
+	args := m.Called(ctx, tenantID, budgetID)
+	return args.Error(0)
+}
+
+func (m *MockBudgetRepository) GetByID(ctx context.Context, tenantID uuid.UUID, budgetID uuid.UUID) (*repository.Budget, error) {
+	args := m.Called(ctx, tenantID, budgetID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Budget), args.Error(1)
+}
+
+func (m *MockBudgetRepository) List(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*repository.Budget, int, error) {
+	args := m.Called(ctx, tenantID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*repository.Budget), args.Int(1), args.Error(2)
+}
+
+func (m *MockBudgetRepository) GetActuals(ctx context.Context, tenantID uuid.UUID, budgetID uuid.UUID) ([]*repository.BudgetActual, error) {
+	args := m.Called(ctx, tenantID, budgetID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.BudgetActual), args.Error(1)
+}
+
+// Test CreateBudget
+func TestLedgerService_CreateBudget(t *testing.T) {
+	ctx := context.Background()
+	mockBudgetRepo := new(MockBudgetRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithBudgetRepository(mockBudgetRepo))
+	january := timestamppb.New(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	endOfJanuary := timestamppb.New(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))
+
+	t.Run("returns unimplemented when budgets are not enabled", func(t *testing.T) {
+		resp, err := NewLedgerService(nil, nil, nil, nil).CreateBudget(ctx, &pb.CreateBudgetRequest{TenantId: uuid.New().String()})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns error when period end is before start", func(t *testing.T) {
+		resp, err := service.CreateBudget(ctx, &pb.CreateBudgetRequest{
+			TenantId: uuid.New().String(),
+			Name:     "FY2024",
+			Lines: []*pb.BudgetLine{{
+				AccountId:   uuid.New().String(),
+				PeriodStart: endOfJanuary,
+				PeriodEnd:   january,
+				Amount:      "1000",
+			}},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns error when only a dimension key is given", func(t *testing.T) {
+		resp, err := service.CreateBudget(ctx, &pb.CreateBudgetRequest{
+			TenantId: uuid.New().String(),
+			Name:     "FY2024",
+			Lines: []*pb.BudgetLine{{
+				AccountId:    uuid.New().String(),
+				PeriodStart:  january,
+				PeriodEnd:    endOfJanuary,
+				Amount:       "1000",
+				DimensionKey: stringPtr("department"),
+			}},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("creates budget", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+
+		mockBudgetRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(p repository.BudgetParams) bool {
+			return p.Name == "FY2024" && len(p.Lines) == 1 && p.Lines[0].AccountID == accountID
+		})).Return(&repository.Budget{
+			ID:       uuid.New(),
+			TenantID: tenantID,
+			Name:     "FY2024",
+			Lines: []*repository.BudgetLine{{
+				ID:        uuid.New(),
+				AccountID: accountID,
+				Amount:    decimal.NewFromInt(1000),
+			}},
+		}, nil).Once()
+
+		resp, err := service.CreateBudget(ctx, &pb.CreateBudgetRequest{
+			TenantId: tenantID.String(),
+			Name:     "FY2024",
+			Lines: []*pb.BudgetLine{{
+				AccountId:   accountID.String(),
+				PeriodStart: january,
+				PeriodEnd:   endOfJanuary,
+				Amount:      "1000",
+			}},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Budget.Lines, 1)
+		mockBudgetRepo.AssertExpectations(t)
+	})
+}
+
+// Test GetBudgetVsActual
+func TestLedgerService_GetBudgetVsActual(t *testing.T) {
+	ctx := context.Background()
+	mockBudgetRepo := new(MockBudgetRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithBudgetRepository(mockBudgetRepo))
+
+	t.Run("computes variances on the normal balance side", func(t *testing.T) {
+		tenantID := uuid.New()
+		budgetID := uuid.New()
+
+		mockBudgetRepo.On("GetByID", ctx, tenantID, budgetID).Return(&repository.Budget{ID: budgetID}, nil).Once()
+		mockBudgetRepo.On("GetActuals", ctx, tenantID, budgetID).Return([]*repository.BudgetActual{
+			{
+				Line:          &repository.BudgetLine{ID: uuid.New(), Amount: decimal.NewFromInt(1000)},
+				AccountNumber: "4000",
+				NormalBalance: "CREDIT",
+				Debit:         decimal.NewFromInt(50),
+				Credit:        decimal.NewFromInt(1250),
+			},
+			{
+				Line:          &repository.BudgetLine{ID: uuid.New(), Amount: decimal.NewFromInt(400)},
+				AccountNumber: "6000",
+				NormalBalance: "DEBIT",
+				Debit:         decimal.NewFromInt(300),
+			},
+		}, nil).Once()
+
+		resp, err := service.GetBudgetVsActual(ctx, &pb.GetBudgetVsActualRequest{
+			TenantId: tenantID.String(),
+			BudgetId: budgetID.String(),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1200", resp.Variances[0].ActualAmount)
+		assert.Equal(t, "200", resp.Variances[0].Variance)
+		assert.Equal(t, "20", resp.Variances[0].GetVariancePercent())
+		assert.Equal(t, "-100", resp.Variances[1].Variance)
+		assert.Equal(t, "1400", resp.TotalBudget)
+		assert.Equal(t, "1500", resp.TotalActual)
+		assert.Equal(t, "100", resp.TotalVariance)
+		mockBudgetRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for unknown budget", func(t *testing.T) {
+		tenantID := uuid.New()
+		budgetID := uuid.New()
+
+		mockBudgetRepo.On("GetByID", ctx, tenantID, budgetID).Return(nil, repository.ErrNotFound).Once()
+
+		resp, err := service.GetBudgetVsActual(ctx, &pb.GetBudgetVsActualRequest{
+			TenantId: tenantID.String(),
+			BudgetId: budgetID.String(),
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
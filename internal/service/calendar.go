@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/jalali"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// tenantClock is the calendar and timezone a tenant's dates are reckoned in
+type tenantClock struct {
+	calendar string
+	location *time.Location
+}
+
+// utcClock reckons dates in the Gregorian calendar in UTC
+var utcClock = tenantClock{calendar: repository.DefaultCalendar, location: time.UTC}
+
+// clock returns the calendar and timezone of a tenant, the Gregorian
+// calendar in UTC when tenant settings are not enabled
+func (s *LedgerService) clock(ctx context.Context, tenantID uuid.UUID) (tenantClock, error) {
+	if s.settingsRepo == nil {
+		return utcClock, nil
+	}
+
+	settings, err := s.settingsRepo.Get(ctx, tenantID)
+	if err != nil {
+		return tenantClock{}, repositoryError("get tenant settings", err)
+	}
+
+	clock := utcClock
+	if settings.Calendar != "" {
+		clock.calendar = settings.Calendar
+	}
+	if settings.Timezone != "" {
+		location, err := time.LoadLocation(settings.Timezone)
+		if err != nil {
+			return tenantClock{}, status.Errorf(codes.Internal, "invalid tenant timezone %q", settings.Timezone)
+		}
+		clock.location = location
+	}
+
+	return clock, nil
+}
+
+// today returns the current day in the tenant's timezone as midnight UTC,
+// the form dates are stored and compared in
+func (c tenantClock) today() time.Time {
+	year, month, day := time.Now().In(c.location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// dayStart returns the instant the current day began in the tenant's timezone
+func (c tenantClock) dayStart() time.Time {
+	year, month, day := time.Now().In(c.location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, c.location)
+}
+
+// dateOf returns the day a date field names, or nil when it is not set.
+// Date fields carry a calendar day as midnight UTC, the form entry dates are
+// stored and returned in, so the day is read in UTC whatever the tenant's
+// timezone.
+func dateOf(date *timestamppb.Timestamp) *time.Time {
+	if date == nil {
+		return nil
+	}
+	day := startOfDay(date.AsTime())
+	return &day
+}
+
+// dateField returns the day of a date field or, when its jalali_ counterpart
+// is set instead, the day of that Jalali date. It returns nil when neither is
+// set.
+func dateField(field string, date *timestamppb.Timestamp, jalaliDate *pb.JalaliDate) (*time.Time, error) {
+	if jalaliDate == nil {
+		return dateOf(date), nil
+	}
+	if date != nil {
+		return nil, invalidField(field, fmt.Sprintf("set either %s or jalali_%s, not both", field, field))
+	}
+
+	d := jalali.Date{Year: int(jalaliDate.Year), Month: int(jalaliDate.Month), Day: int(jalaliDate.Day)}
+	if !d.Valid() {
+		return nil, invalidField("jalali_"+field, "invalid Jalali date")
+	}
+
+	day := d.Time()
+	return &day, nil
+}
+
+// jalaliToProto converts the UTC date of t to the Jalali calendar
+func jalaliToProto(t time.Time) *pb.JalaliDate {
+	d := jalali.FromTime(t)
+	return &pb.JalaliDate{
+		Year:  int32(d.Year),
+		Month: int32(d.Month),
+		Day:   int32(d.Day),
+	}
+}
+
+// jalaliMonths merges day aggregates into Jalali months. The days arrive
+// ordered by their other group keys and then by day, so the days of a month
+// with the same keys are adjacent.
+func jalaliMonths(days []*repository.LineAggregate) []*repository.LineAggregate {
+	months := make([]*repository.LineAggregate, 0, len(days))
+	var month *repository.LineAggregate
+	for _, day := range days {
+		start := jalali.FromTime(*day.PeriodStart).MonthStart().Time()
+		if month != nil && month.PeriodStart.Equal(start) && sameGroupKeys(month, day) {
+			month.Debit = month.Debit.Add(day.Debit)
+			month.Credit = month.Credit.Add(day.Credit)
+			month.LineCount += day.LineCount
+			continue
+		}
+
+		day.PeriodStart = &start
+		month = day
+		months = append(months, month)
+	}
+
+	return months
+}
+
+// sameGroupKeys reports whether two aggregates share every key but the period
+func sameGroupKeys(a, b *repository.LineAggregate) bool {
+	return equalPtr(a.AccountID, b.AccountID) &&
+		equalPtr(a.AccountNumber, b.AccountNumber) &&
+		equalPtr(a.AccountTypeCode, b.AccountTypeCode) &&
+		equalPtr(a.CurrencyCode, b.CurrencyCode) &&
+		maps.Equal(a.Dimensions, b.Dimensions)
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
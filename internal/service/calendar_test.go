@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+func TestTenantClock(t *testing.T) {
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	require.NoError(t, err)
+	clock := tenantClock{calendar: repository.CalendarJalali, location: tehran}
+
+	t.Run("reads date fields as UTC days whatever the timezone", func(t *testing.T) {
+		// 21:00 UTC is already the next day in Tehran, but the field names the 30th
+		late := time.Date(2024, 1, 30, 21, 0, 0, 0, time.UTC)
+		assert.Equal(t, time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC), *dateOf(timestamppb.New(late)))
+		assert.Nil(t, dateOf(nil))
+	})
+
+	t.Run("takes today in the tenant's timezone", func(t *testing.T) {
+		year, month, day := time.Now().In(tehran).Date()
+		assert.Equal(t, time.Date(year, month, day, 0, 0, 0, 0, time.UTC), clock.today())
+	})
+
+	t.Run("takes a Jalali date as it is", func(t *testing.T) {
+		day, err := dateField("from_date", nil, &pb.JalaliDate{Year: 1402, Month: 11, Day: 11})
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), *day)
+	})
+
+	t.Run("returns nil for an unset date", func(t *testing.T) {
+		day, err := dateField("from_date", nil, nil)
+		require.NoError(t, err)
+		assert.Nil(t, day)
+	})
+
+	t.Run("rejects a date set in both calendars", func(t *testing.T) {
+		_, err := dateField("from_date", timestamppb.Now(), &pb.JalaliDate{Year: 1402, Month: 11, Day: 11})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, "INVALID_FIELD", errorReason(t, err))
+	})
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// CloneJournalEntry copies the lines, description and metadata of an
+// existing entry into a new entry for another date, so recurring entries can
+// be repeated with minor changes. The clone keeps the entry's currency, book
+// and business transaction. The clone is returned as a draft request
+// for CreateJournalEntry, and posted through the same validation when post is
+// set. Generated tax lines are left out and generated again from the tax
+// codes of the taxable lines.
+func (s *LedgerService) CloneJournalEntry(ctx context.Context, req *pb.CloneJournalEntryRequest) (*pb.CloneJournalEntryResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	journalEntryID, err := requestID("journal_entry_id", req.JournalEntryId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.EntryDate == nil {
+		return nil, invalidField("entry_date", "entry date is required")
+	}
+
+	source, err := s.journalRepo.GetByID(ctx, tenantID, journalEntryID)
+	if err != nil {
+		return nil, repositoryError("get journal entry", err)
+	}
+
+	entry := journalEntryToProto(source)
+	lines := make([]*pb.JournalEntryLine, 0, len(entry.Lines))
+	for _, line := range entry.Lines {
+		if line.IsTax {
+			continue
+		}
+		line.LineId = nil
+		line.CreatedAt = nil
+		lines = append(lines, line)
+	}
+
+	draft := &pb.CreateJournalEntryRequest{
+		TenantId:        req.TenantId,
+		ReferenceNumber: req.ReferenceNumber,
+		Description:     entry.Description,
+		EntryDate:       req.EntryDate,
+		Lines:           lines,
+		Metadata:        entry.Metadata,
+	}
+	if source.CurrencyCode != "" {
+		draft.CurrencyCode = &source.CurrencyCode
+	}
+	if source.BookID != uuid.Nil {
+		bookID := source.BookID.String()
+		draft.BookId = &bookID
+	}
+	if source.TransactionID != "" {
+		draft.TransactionId = &source.TransactionID
+	}
+	if req.Description != nil {
+		draft.Description = *req.Description
+	}
+
+	if !req.Post {
+		return &pb.CloneJournalEntryResponse{Draft: draft}, nil
+	}
+
+	posted, err := s.createJournalEntry(ctx, tenantID, draft, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CloneJournalEntryResponse{
+		Draft:        draft,
+		JournalEntry: journalEntryToProto(posted),
+	}, nil
+}
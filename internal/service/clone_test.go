@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+func TestLedgerService_CloneJournalEntry(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "clone", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	createAccount := func(number string) string {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: 1,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(t, err)
+		return resp.AccountId
+	}
+	rent := createAccount("6100")
+	bank := createAccount("1010")
+
+	metadata := `{"period":"2026-09"}`
+	source, err := service.CreateJournalEntry(ctx, &pb.CreateJournalEntryRequest{
+		TenantId:        tenantID,
+		ReferenceNumber: "RENT-09",
+		Description:     "Office rent",
+		EntryDate:       timestamppb.New(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)),
+		Metadata:        &metadata,
+		Lines: []*pb.JournalEntryLine{
+			{AccountId: rent, Debit: "1200", Credit: "0", Description: "Rent"},
+			{AccountId: bank, Debit: "0", Credit: "1200", Description: "Payment"},
+		},
+	})
+	require.NoError(t, err)
+
+	october := timestamppb.New(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+
+	t.Run("returns a draft without posting", func(t *testing.T) {
+		resp, err := service.CloneJournalEntry(ctx, &pb.CloneJournalEntryRequest{
+			TenantId:        tenantID,
+			JournalEntryId:  source.JournalEntryId,
+			EntryDate:       october,
+			ReferenceNumber: "RENT-10",
+		})
+		require.NoError(t, err)
+
+		assert.Nil(t, resp.JournalEntry)
+		assert.Equal(t, "RENT-10", resp.Draft.ReferenceNumber)
+		assert.Equal(t, "Office rent", resp.Draft.Description)
+		assert.Equal(t, metadata, resp.Draft.GetMetadata())
+		require.Len(t, resp.Draft.Lines, 2)
+		assert.Equal(t, rent, resp.Draft.Lines[0].AccountId)
+		assert.Equal(t, "1200", resp.Draft.Lines[0].Debit)
+		assert.Nil(t, resp.Draft.Lines[0].LineId)
+
+		entries, err := service.ListJournalEntries(ctx, &pb.ListJournalEntriesRequest{TenantId: tenantID})
+		require.NoError(t, err)
+		assert.Len(t, entries.JournalEntries, 1)
+	})
+
+	t.Run("posts the clone with a new date and description", func(t *testing.T) {
+		resp, err := service.CloneJournalEntry(ctx, &pb.CloneJournalEntryRequest{
+			TenantId:        tenantID,
+			JournalEntryId:  source.JournalEntryId,
+			EntryDate:       october,
+			ReferenceNumber: "RENT-10",
+			Description:     stringPtr("Office rent, October"),
+			Post:            true,
+		})
+		require.NoError(t, err)
+
+		require.NotNil(t, resp.JournalEntry)
+		assert.NotEqual(t, source.JournalEntryId, resp.JournalEntry.JournalEntryId)
+		assert.Equal(t, "RENT-10", resp.JournalEntry.ReferenceNumber)
+		assert.Equal(t, "Office rent, October", resp.JournalEntry.Description)
+		assert.True(t, october.AsTime().Equal(resp.JournalEntry.EntryDate.AsTime()))
+		assert.Len(t, resp.JournalEntry.Lines, 2)
+
+		balance, err := service.GetAccountBalance(ctx, &pb.GetAccountBalanceRequest{TenantId: tenantID, AccountId: rent})
+		require.NoError(t, err)
+		assert.Equal(t, "2400", balance.DebitBalance)
+	})
+
+	t.Run("requires an entry date", func(t *testing.T) {
+		_, err := service.CloneJournalEntry(ctx, &pb.CloneJournalEntryRequest{
+			TenantId:       tenantID,
+			JournalEntryId: source.JournalEntryId,
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("returns not found for an unknown entry", func(t *testing.T) {
+		_, err := service.CloneJournalEntry(ctx, &pb.CloneJournalEntryRequest{
+			TenantId:       tenantID,
+			JournalEntryId: uuid.New().String(),
+			EntryDate:      october,
+		})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestLedgerService_CloneJournalEntry_CrossCurrency(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "clone", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	createAccount := func(number, currency string) string {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: 1,
+			CurrencyCode:  currency,
+		})
+		require.NoError(t, err)
+		return resp.AccountId
+	}
+	usd := createAccount("1000", "USD")
+	usdConversion := createAccount("1900", "USD")
+	eurConversion := createAccount("1901", "EUR")
+	eur := createAccount("1100", "EUR")
+
+	// 100 USD moved to a euro account at 0.92, grouped under a transaction
+	rate := "0.92"
+	currency, transactionID := "USD", "FX-2026-09"
+	source, err := service.CreateJournalEntry(ctx, &pb.CreateJournalEntryRequest{
+		TenantId:        tenantID,
+		ReferenceNumber: "FX-09",
+		Description:     "Monthly euro funding",
+		EntryDate:       timestamppb.New(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)),
+		CurrencyCode:    &currency,
+		TransactionId:   &transactionID,
+		Lines: []*pb.JournalEntryLine{
+			{AccountId: usdConversion, Debit: "100", Credit: "0"},
+			{AccountId: usd, Debit: "0", Credit: "100"},
+			{AccountId: eur, Debit: "92", Credit: "0", FxRate: &rate},
+			{AccountId: eurConversion, Debit: "0", Credit: "92", FxRate: &rate},
+		},
+	})
+	require.NoError(t, err)
+
+	resp, err := service.CloneJournalEntry(ctx, &pb.CloneJournalEntryRequest{
+		TenantId:        tenantID,
+		JournalEntryId:  source.JournalEntryId,
+		EntryDate:       timestamppb.New(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)),
+		ReferenceNumber: "FX-10",
+		Post:            true,
+	})
+	require.NoError(t, err)
+
+	account, err := service.GetAccount(ctx, &pb.GetAccountRequest{TenantId: tenantID, AccountId: usd})
+	require.NoError(t, err)
+	assert.Equal(t, "USD", resp.Draft.GetCurrencyCode())
+	assert.Equal(t, account.Account.GetBookId(), resp.Draft.GetBookId())
+	assert.Equal(t, transactionID, resp.Draft.GetTransactionId())
+	require.Len(t, resp.Draft.Lines, 4)
+	assert.Equal(t, rate, resp.Draft.Lines[2].GetFxRate())
+
+	require.NotNil(t, resp.JournalEntry)
+	assert.Equal(t, "USD", resp.JournalEntry.GetCurrencyCode())
+	group, err := service.GetTransactionGroup(ctx, &pb.GetTransactionGroupRequest{TenantId: tenantID, TransactionId: transactionID})
+	require.NoError(t, err)
+	assert.Len(t, group.Entries, 2)
+
+	balance, err := service.GetAccountBalance(ctx, &pb.GetAccountBalanceRequest{TenantId: tenantID, AccountId: eur})
+	require.NoError(t, err)
+	assert.Equal(t, "184", balance.DebitBalance)
+}
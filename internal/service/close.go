@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// closeTaskActions maps close task actions to their stored names
+var closeTaskActions = map[pb.CloseTaskAction]string{
+	pb.CloseTaskAction_CLOSE_TASK_ACTION_MANUAL:            repository.CloseActionManual,
+	pb.CloseTaskAction_CLOSE_TASK_ACTION_RECONCILE_BANK:    repository.CloseActionReconcileBank,
+	pb.CloseTaskAction_CLOSE_TASK_ACTION_POST_DEPRECIATION: repository.CloseActionPostDepreciation,
+	pb.CloseTaskAction_CLOSE_TASK_ACTION_LOCK_PERIOD:       repository.CloseActionLockPeriod,
+}
+
+// closeTaskStatuses maps close task statuses to their stored names
+var closeTaskStatuses = map[pb.CloseTaskStatus]string{
+	pb.CloseTaskStatus_CLOSE_TASK_STATUS_PENDING:     repository.CloseTaskPending,
+	pb.CloseTaskStatus_CLOSE_TASK_STATUS_IN_PROGRESS: repository.CloseTaskInProgress,
+	pb.CloseTaskStatus_CLOSE_TASK_STATUS_DONE:        repository.CloseTaskDone,
+	pb.CloseTaskStatus_CLOSE_TASK_STATUS_SKIPPED:     repository.CloseTaskSkipped,
+}
+
+// GetCloseTemplate retrieves the tasks every period close of a tenant starts
+// with
+func (s *LedgerService) GetCloseTemplate(ctx context.Context, req *pb.GetCloseTemplateRequest) (*pb.GetCloseTemplateResponse, error) {
+	if s.closeRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "period close is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.closeRepo.GetTemplate(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("get close template", err)
+	}
+
+	return &pb.GetCloseTemplateResponse{
+		Tasks: closeTemplateToProto(tasks),
+	}, nil
+}
+
+// UpdateCloseTemplate replaces the close template of a tenant. Checklists
+// already started keep their tasks.
+func (s *LedgerService) UpdateCloseTemplate(ctx context.Context, req *pb.UpdateCloseTemplateRequest) (*pb.UpdateCloseTemplateResponse, error) {
+	if s.closeRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "period close is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := closeTemplateParams(req.Tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err = s.closeRepo.SetTemplate(ctx, tenantID, tasks)
+	if err != nil {
+		return nil, repositoryError("update close template", err)
+	}
+
+	return &pb.UpdateCloseTemplateResponse{
+		Tasks: closeTemplateToProto(tasks),
+	}, nil
+}
+
+// StartPeriodClose creates the close checklist of a period from the tenant's
+// close template. Tasks whose action has already run for the period start out
+// done.
+func (s *LedgerService) StartPeriodClose(ctx context.Context, req *pb.StartPeriodCloseRequest) (*pb.StartPeriodCloseResponse, error) {
+	if s.closeRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "period close is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.PeriodStart == nil {
+		return nil, invalidField("period_start", "period start is required")
+	}
+	if req.PeriodEnd == nil {
+		return nil, invalidField("period_end", "period end is required")
+	}
+
+	periodStart := *dateOf(req.PeriodStart)
+	periodEnd := *dateOf(req.PeriodEnd)
+	if periodEnd.Before(periodStart) {
+		return nil, invalidField("period_end", "period end must not be before period start")
+	}
+
+	checklist, err := s.closeRepo.Start(ctx, tenantID, periodStart, periodEnd)
+	if err != nil {
+		return nil, repositoryError("start period close", err)
+	}
+
+	return &pb.StartPeriodCloseResponse{
+		Checklist: closeChecklistToProto(checklist),
+	}, nil
+}
+
+// GetCloseChecklist retrieves a close checklist with its tasks
+func (s *LedgerService) GetCloseChecklist(ctx context.Context, req *pb.GetCloseChecklistRequest) (*pb.GetCloseChecklistResponse, error) {
+	if s.closeRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "period close is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	checklistID, err := requestID("checklist_id", req.ChecklistId)
+	if err != nil {
+		return nil, err
+	}
+
+	checklist, err := s.closeRepo.GetByID(ctx, tenantID, checklistID)
+	if err != nil {
+		return nil, repositoryError("get close checklist", err)
+	}
+
+	return &pb.GetCloseChecklistResponse{
+		Checklist: closeChecklistToProto(checklist),
+	}, nil
+}
+
+// ListCloseChecklists lists the close checklists of a tenant without their
+// tasks, latest period first
+func (s *LedgerService) ListCloseChecklists(ctx context.Context, req *pb.ListCloseChecklistsRequest) (*pb.ListCloseChecklistsResponse, error) {
+	if s.closeRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "period close is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	page := int(req.GetPage())
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	checklists, totalCount, err := s.closeRepo.List(ctx, tenantID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, repositoryError("list close checklists", err)
+	}
+
+	pbChecklists := make([]*pb.CloseChecklist, len(checklists))
+	for i, checklist := range checklists {
+		pbChecklists[i] = closeChecklistToProto(checklist)
+	}
+
+	return &pb.ListCloseChecklistsResponse{
+		Checklists: pbChecklists,
+		TotalCount: int32(totalCount),
+	}, nil
+}
+
+// UpdateCloseTask sets the status of a close task, and its note when one is
+// given, and returns the task's checklist
+func (s *LedgerService) UpdateCloseTask(ctx context.Context, req *pb.UpdateCloseTaskRequest) (*pb.UpdateCloseTaskResponse, error) {
+	if s.closeRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "period close is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	taskID, err := requestID("task_id", req.TaskId)
+	if err != nil {
+		return nil, err
+	}
+
+	taskStatus, ok := closeTaskStatuses[req.Status]
+	if !ok {
+		return nil, invalidField("status", "status is required")
+	}
+
+	checklist, err := s.closeRepo.UpdateTask(ctx, tenantID, taskID, taskStatus, req.Note)
+	if err != nil {
+		return nil, repositoryError("update close task", err)
+	}
+
+	return &pb.UpdateCloseTaskResponse{
+		Checklist: closeChecklistToProto(checklist),
+	}, nil
+}
+
+func closeTemplateParams(tasks []*pb.CloseTaskTemplate) ([]*repository.CloseTaskTemplate, error) {
+	if len(tasks) == 0 {
+		return nil, invalidField("tasks", "at least one task is required")
+	}
+
+	params := make([]*repository.CloseTaskTemplate, len(tasks))
+	seen := make(map[string]bool, len(tasks))
+	for i, task := range tasks {
+		if task.Code == "" {
+			return nil, invalidField("tasks", "task code is required")
+		}
+		if seen[task.Code] {
+			return nil, invalidField("tasks", "duplicate task code "+task.Code)
+		}
+		seen[task.Code] = true
+
+		if task.Name == "" {
+			return nil, invalidField("tasks", "task name is required")
+		}
+
+		action, ok := closeTaskActions[task.Action]
+		if !ok {
+			return nil, invalidField("tasks", "task action is required")
+		}
+
+		params[i] = &repository.CloseTaskTemplate{
+			Code:   task.Code,
+			Name:   task.Name,
+			Action: action,
+		}
+	}
+
+	return params, nil
+}
+
+func closeTemplateToProto(tasks []*repository.CloseTaskTemplate) []*pb.CloseTaskTemplate {
+	pbTasks := make([]*pb.CloseTaskTemplate, len(tasks))
+	for i, task := range tasks {
+		pbTasks[i] = &pb.CloseTaskTemplate{
+			Code:   task.Code,
+			Name:   task.Name,
+			Action: closeTaskActionToProto(task.Action),
+		}
+	}
+	return pbTasks
+}
+
+func closeChecklistToProto(checklist *repository.CloseChecklist) *pb.CloseChecklist {
+	pbChecklist := &pb.CloseChecklist{
+		ChecklistId: checklist.ID.String(),
+		TenantId:    checklist.TenantID.String(),
+		PeriodStart: timestamppb.New(checklist.PeriodStart),
+		PeriodEnd:   timestamppb.New(checklist.PeriodEnd),
+		Tasks:       make([]*pb.CloseTask, len(checklist.Tasks)),
+		CreatedAt:   timestamppb.New(checklist.CreatedAt),
+	}
+
+	for i, task := range checklist.Tasks {
+		pbChecklist.Tasks[i] = closeTaskToProto(task)
+	}
+
+	if checklist.CompletedAt != nil {
+		pbChecklist.CompletedAt = timestamppb.New(*checklist.CompletedAt)
+	}
+
+	return pbChecklist
+}
+
+func closeTaskToProto(task *repository.CloseTask) *pb.CloseTask {
+	pbTask := &pb.CloseTask{
+		TaskId:                 task.ID.String(),
+		Code:                   task.Code,
+		Name:                   task.Name,
+		Action:                 closeTaskActionToProto(task.Action),
+		Note:                   task.Note,
+		CompletedAutomatically: task.CompletedAutomatically,
+		UpdatedAt:              timestamppb.New(task.UpdatedAt),
+	}
+
+	for pbStatus, taskStatus := range closeTaskStatuses {
+		if taskStatus == task.Status {
+			pbTask.Status = pbStatus
+		}
+	}
+
+	if task.CompletedAt != nil {
+		pbTask.CompletedAt = timestamppb.New(*task.CompletedAt)
+	}
+
+	return pbTask
+}
+
+func closeTaskActionToProto(action string) pb.CloseTaskAction {
+	for pbAction, name := range closeTaskActions {
+		if name == action {
+			return pbAction
+		}
+	}
+	return pb.CloseTaskAction_CLOSE_TASK_ACTION_UNSPECIFIED
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockCloseRepository struct {
+	mock.Mock
+}
+
+func (m *MockCloseRepository) GetTemplate(ctx context.Context, tenantID uuid.UUID) ([]*repository.CloseTaskTemplate, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.CloseTaskTemplate), args.Error(1)
+}
+
+func (m *MockCloseRepository) SetTemplate(ctx context.Context, tenantID uuid.UUID, tasks []*repository.CloseTaskTemplate) ([]*repository.CloseTaskTemplate, error) {
+	args := m.Called(ctx, tenantID, tasks)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.CloseTaskTemplate), args.Error(1)
+}
+
+func (m *MockCloseRepository) Start(ctx context.Context, tenantID uuid.UUID, periodStart, periodEnd time.Time) (*repository.CloseChecklist, error) {
+	args := m.Called(ctx, tenantID, periodStart, periodEnd)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.CloseChecklist), args.Error(1)
+}
+
+func (m *MockCloseRepository) GetByID(ctx context.Context, tenantID uuid.UUID, checklistID uuid.UUID) (*repository.CloseChecklist, error) {
+	args := m.Called(ctx, tenantID, checklistID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.CloseChecklist), args.Error(1)
+}
+
+func (m *MockCloseRepository) List(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*repository.CloseChecklist, int, error) {
+	args := m.Called(ctx, tenantID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*repository.CloseChecklist), args.Int(1), args.Error(2)
+}
+
+func (m *MockCloseRepository) UpdateTask(ctx context.Context, tenantID uuid.UUID, taskID uuid.UUID, status string, note *string) (*repository.CloseChecklist, error) {
+	args := m.Called(ctx, tenantID, taskID, status, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.CloseChecklist), args.Error(1)
+}
+
+// Test UpdateCloseTemplate
+func TestLedgerService_UpdateCloseTemplate(t *testing.T) {
+	ctx := context.Background()
+	mockCloseRepo := new(MockCloseRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithCloseRepository(mockCloseRepo))
+
+	t.Run("returns unimplemented when period close is not enabled", func(t *testing.T) {
+		resp, err := NewLedgerService(nil, nil, nil, nil).UpdateCloseTemplate(ctx, &pb.UpdateCloseTemplateRequest{TenantId: uuid.New().String()})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns error for duplicate task codes", func(t *testing.T) {
+		resp, err := service.UpdateCloseTemplate(ctx, &pb.UpdateCloseTemplateRequest{
+			TenantId: uuid.New().String(),
+			Tasks: []*pb.CloseTaskTemplate{
+				{Code: "ACCRUALS", Name: "Post accruals", Action: pb.CloseTaskAction_CLOSE_TASK_ACTION_MANUAL},
+				{Code: "ACCRUALS", Name: "Review accruals", Action: pb.CloseTaskAction_CLOSE_TASK_ACTION_MANUAL},
+			},
+		})
+
+		assert.Equal(t, "INVALID_FIELD", errorReason(t, err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns error when a task has no action", func(t *testing.T) {
+		resp, err := service.UpdateCloseTemplate(ctx, &pb.UpdateCloseTemplateRequest{
+			TenantId: uuid.New().String(),
+			Tasks:    []*pb.CloseTaskTemplate{{Code: "ACCRUALS", Name: "Post accruals"}},
+		})
+
+		assert.Equal(t, "INVALID_FIELD", errorReason(t, err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("replaces the template", func(t *testing.T) {
+		tenantID := uuid.New()
+		tasks := []*repository.CloseTaskTemplate{
+			{Code: "ACCRUALS", Name: "Post accruals", Action: repository.CloseActionManual},
+			{Code: "LOCK", Name: "Lock the period", Action: repository.CloseActionLockPeriod},
+		}
+
+		mockCloseRepo.On("SetTemplate", ctx, tenantID, tasks).Return(tasks, nil).Once()
+
+		resp, err := service.UpdateCloseTemplate(ctx, &pb.UpdateCloseTemplateRequest{
+			TenantId: tenantID.String(),
+			Tasks: []*pb.CloseTaskTemplate{
+				{Code: "ACCRUALS", Name: "Post accruals", Action: pb.CloseTaskAction_CLOSE_TASK_ACTION_MANUAL},
+				{Code: "LOCK", Name: "Lock the period", Action: pb.CloseTaskAction_CLOSE_TASK_ACTION_LOCK_PERIOD},
+			},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Tasks, 2)
+		assert.Equal(t, pb.CloseTaskAction_CLOSE_TASK_ACTION_LOCK_PERIOD, resp.Tasks[1].Action)
+		mockCloseRepo.AssertExpectations(t)
+	})
+}
+
+// Test StartPeriodClose
+func TestLedgerService_StartPeriodClose(t *testing.T) {
+	ctx := context.Background()
+	mockCloseRepo := new(MockCloseRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithCloseRepository(mockCloseRepo))
+	january := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endOfJanuary := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("returns error when period end is before start", func(t *testing.T) {
+		resp, err := service.StartPeriodClose(ctx, &pb.StartPeriodCloseRequest{
+			TenantId:    uuid.New().String(),
+			PeriodStart: timestamppb.New(endOfJanuary),
+			PeriodEnd:   timestamppb.New(january),
+		})
+
+		assert.Equal(t, "INVALID_FIELD", errorReason(t, err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns already exists when the period has a checklist", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockCloseRepo.On("Start", ctx, tenantID, january, endOfJanuary).
+			Return(nil, &pgconn.PgError{Code: "23505"}).Once()
+
+		resp, err := service.StartPeriodClose(ctx, &pb.StartPeriodCloseRequest{
+			TenantId:    tenantID.String(),
+			PeriodStart: timestamppb.New(january),
+			PeriodEnd:   timestamppb.New(endOfJanuary),
+		})
+
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+		assert.Nil(t, resp)
+		mockCloseRepo.AssertExpectations(t)
+	})
+
+	t.Run("starts the checklist with tasks already done", func(t *testing.T) {
+		tenantID := uuid.New()
+		completedAt := time.Date(2024, 2, 2, 9, 0, 0, 0, time.UTC)
+
+		mockCloseRepo.On("Start", ctx, tenantID, january, endOfJanuary).Return(&repository.CloseChecklist{
+			ID:          uuid.New(),
+			TenantID:    tenantID,
+			PeriodStart: january,
+			PeriodEnd:   endOfJanuary,
+			Tasks: []*repository.CloseTask{
+				{ID: uuid.New(), Code: "RECONCILE_BANK", Action: repository.CloseActionReconcileBank, Status: repository.CloseTaskDone, CompletedAutomatically: true, CompletedAt: &completedAt},
+				{ID: uuid.New(), Code: "LOCK_PERIOD", Action: repository.CloseActionLockPeriod, Status: repository.CloseTaskPending},
+			},
+		}, nil).Once()
+
+		resp, err := service.StartPeriodClose(ctx, &pb.StartPeriodCloseRequest{
+			TenantId:    tenantID.String(),
+			PeriodStart: timestamppb.New(january),
+			PeriodEnd:   timestamppb.New(endOfJanuary),
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Checklist.Tasks, 2)
+		assert.Equal(t, pb.CloseTaskStatus_CLOSE_TASK_STATUS_DONE, resp.Checklist.Tasks[0].Status)
+		assert.True(t, resp.Checklist.Tasks[0].CompletedAutomatically)
+		assert.Equal(t, pb.CloseTaskStatus_CLOSE_TASK_STATUS_PENDING, resp.Checklist.Tasks[1].Status)
+		assert.Nil(t, resp.Checklist.CompletedAt)
+		mockCloseRepo.AssertExpectations(t)
+	})
+
+	t.Run("reads the period as UTC days whatever the tenant's timezone", func(t *testing.T) {
+		tenantID := uuid.New()
+		mockSettingsRepo := new(MockTenantSettingsRepository)
+		service := NewLedgerService(nil, nil, nil, nil, WithCloseRepository(mockCloseRepo), WithTenantSettingsRepository(mockSettingsRepo))
+
+		mockCloseRepo.On("Start", ctx, tenantID, january, endOfJanuary).Return(&repository.CloseChecklist{
+			ID:          uuid.New(),
+			TenantID:    tenantID,
+			PeriodStart: january,
+			PeriodEnd:   endOfJanuary,
+		}, nil).Once()
+
+		// Late evening UTC is already the next day in Tehran, but the fields
+		// name the UTC day
+		resp, err := service.StartPeriodClose(ctx, &pb.StartPeriodCloseRequest{
+			TenantId:    tenantID.String(),
+			PeriodStart: timestamppb.New(time.Date(2024, 1, 1, 20, 30, 0, 0, time.UTC)),
+			PeriodEnd:   timestamppb.New(time.Date(2024, 1, 31, 20, 30, 0, 0, time.UTC)),
+		})
+
+		require.NoError(t, err)
+		assert.NotNil(t, resp.Checklist)
+		mockSettingsRepo.AssertNotCalled(t, "Get", ctx, tenantID)
+		mockCloseRepo.AssertExpectations(t)
+	})
+}
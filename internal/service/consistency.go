@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/projection"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// CheckLedgerConsistency checks a tenant's ledger invariants: debits equal
+// credits, stored balances match their lines and no line is orphaned. With
+// the event store enabled, stored balances must also match the balances
+// projected from its events.
+func (s *AdminService) CheckLedgerConsistency(ctx context.Context, req *pb.CheckLedgerConsistencyRequest) (*pb.CheckLedgerConsistencyResponse, error) {
+	if s.consistencyRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "consistency checks are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.consistencyRepo.Check(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("check ledger consistency", err)
+	}
+
+	if s.eventRepo != nil {
+		violations, err := s.checkEventProjection(ctx, tenantID)
+		if err != nil {
+			return nil, repositoryError("replay ledger events", err)
+		}
+		report.Violations = append(report.Violations, violations...)
+	}
+
+	resp := &pb.CheckLedgerConsistencyResponse{
+		Consistent:  report.Consistent(),
+		CheckedAt:   timestamppb.New(report.CheckedAt),
+		TotalDebit:  report.TotalDebit.String(),
+		TotalCredit: report.TotalCredit.String(),
+		Violations:  make([]*pb.ConsistencyViolation, len(report.Violations)),
+	}
+	for i, v := range report.Violations {
+		resp.Violations[i] = consistencyViolationToProto(v)
+	}
+
+	return resp, nil
+}
+
+// checkEventProjection replays the tenant's events into a balance projection
+// and reports the accounts whose stored balance differs from it
+func (s *AdminService) checkEventProjection(ctx context.Context, tenantID uuid.UUID) ([]*repository.ConsistencyViolation, error) {
+	balances := projection.NewBalances()
+	stored, err := s.eventRepo.ReplayWithBalances(ctx, tenantID, balances.Apply)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []*repository.ConsistencyViolation
+	for _, b := range stored {
+		projected := balances.Get(b.AccountID)
+		if projected.Debit.Equal(b.DebitBalance) && projected.Credit.Equal(b.CreditBalance) {
+			continue
+		}
+
+		accountID := b.AccountID
+		violations = append(violations, &repository.ConsistencyViolation{
+			Check:     repository.CheckEventProjection,
+			AccountID: &accountID,
+			Detail: fmt.Sprintf("stored balance %s/%s does not match replayed events %s/%s",
+				b.DebitBalance, b.CreditBalance, projected.Debit, projected.Credit),
+		})
+		if len(violations) == repository.MaxViolationsPerCheck {
+			break
+		}
+	}
+
+	return violations, nil
+}
+
+func consistencyViolationToProto(v *repository.ConsistencyViolation) *pb.ConsistencyViolation {
+	violation := &pb.ConsistencyViolation{
+		Check:  v.Check,
+		Detail: v.Detail,
+	}
+	if v.JournalEntryID != nil {
+		id := v.JournalEntryID.String()
+		violation.JournalEntryId = &id
+	}
+	if v.JournalLineID != nil {
+		id := v.JournalLineID.String()
+		violation.JournalLineId = &id
+	}
+	if v.AccountID != nil {
+		id := v.AccountID.String()
+		violation.AccountId = &id
+	}
+	return violation
+}
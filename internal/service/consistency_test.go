@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockConsistencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockConsistencyRepository) Check(ctx context.Context, tenantID uuid.UUID) (*repository.ConsistencyReport, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ConsistencyReport), args.Error(1)
+}
+
+func (m *MockConsistencyRepository) VerifyBalances(ctx context.Context, tenantID uuid.UUID) (*repository.BalanceVerification, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.BalanceVerification), args.Error(1)
+}
+
+// Test CheckLedgerConsistency
+func TestAdminService_CheckLedgerConsistency(t *testing.T) {
+	ctx := context.Background()
+	mockConsistencyRepo := new(MockConsistencyRepository)
+	service := NewAdminService(nil, nil, nil, WithConsistencyRepository(mockConsistencyRepo))
+
+	t.Run("reports a consistent ledger", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockConsistencyRepo.On("Check", ctx, tenantID).Return(&repository.ConsistencyReport{
+			TenantID:    tenantID,
+			CheckedAt:   time.Now(),
+			TotalDebit:  decimal.NewFromInt(500),
+			TotalCredit: decimal.NewFromInt(500),
+			Violations:  []*repository.ConsistencyViolation{},
+		}, nil).Once()
+
+		resp, err := service.CheckLedgerConsistency(ctx, &pb.CheckLedgerConsistencyRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.True(t, resp.Consistent)
+		assert.Equal(t, "500", resp.TotalDebit)
+		assert.Empty(t, resp.Violations)
+		mockConsistencyRepo.AssertExpectations(t)
+	})
+
+	t.Run("reports violations", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+
+		mockConsistencyRepo.On("Check", ctx, tenantID).Return(&repository.ConsistencyReport{
+			TenantID:    tenantID,
+			CheckedAt:   time.Now(),
+			TotalDebit:  decimal.NewFromInt(500),
+			TotalCredit: decimal.NewFromInt(500),
+			Violations: []*repository.ConsistencyViolation{
+				{Check: repository.CheckAccountBalances, AccountID: &accountID, Detail: "stored balance 90/0 does not match line sums 100/0"},
+			},
+		}, nil).Once()
+
+		resp, err := service.CheckLedgerConsistency(ctx, &pb.CheckLedgerConsistencyRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.False(t, resp.Consistent)
+		require.Len(t, resp.Violations, 1)
+		assert.Equal(t, repository.CheckAccountBalances, resp.Violations[0].Check)
+		require.NotNil(t, resp.Violations[0].AccountId)
+		assert.Equal(t, accountID.String(), *resp.Violations[0].AccountId)
+		assert.Nil(t, resp.Violations[0].JournalEntryId)
+		mockConsistencyRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns invalid argument for a bad tenant ID", func(t *testing.T) {
+		resp, err := service.CheckLedgerConsistency(ctx, &pb.CheckLedgerConsistencyRequest{TenantId: "invalid"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("reports stored balances that differ from the replayed events", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		service := NewAdminService(nil, nil, nil,
+			WithConsistencyRepository(mockConsistencyRepo),
+			WithEventRepository(mockEventRepo),
+		)
+		tenantID := uuid.New()
+		cashID := uuid.New()
+		salesID := uuid.New()
+
+		payload, err := json.Marshal(repository.JournalEntryPostedPayload{
+			ReferenceNumber: "REF001",
+			EntryDate:       "2026-03-01",
+			Lines: []repository.PostedLine{
+				{AccountID: cashID, Debit: decimal.NewFromInt(100)},
+				{AccountID: salesID, Credit: decimal.NewFromInt(100)},
+			},
+		})
+		require.NoError(t, err)
+
+		mockConsistencyRepo.On("Check", ctx, tenantID).Return(&repository.ConsistencyReport{
+			TenantID:   tenantID,
+			CheckedAt:  time.Now(),
+			Violations: []*repository.ConsistencyViolation{},
+		}, nil).Once()
+		mockEventRepo.On("ReplayWithBalances", ctx, tenantID).Return(
+			[]*repository.LedgerEvent{{Sequence: 1, EventType: repository.EventJournalEntryPosted, Payload: payload}},
+			[]*repository.AccountBalance{
+				{AccountID: cashID, DebitBalance: decimal.NewFromInt(90), CreditBalance: decimal.Zero},
+				{AccountID: salesID, DebitBalance: decimal.Zero, CreditBalance: decimal.NewFromInt(100)},
+			},
+			nil,
+		).Once()
+
+		resp, err := service.CheckLedgerConsistency(ctx, &pb.CheckLedgerConsistencyRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.False(t, resp.Consistent)
+		require.Len(t, resp.Violations, 1)
+		assert.Equal(t, repository.CheckEventProjection, resp.Violations[0].Check)
+		require.NotNil(t, resp.Violations[0].AccountId)
+		assert.Equal(t, cashID.String(), *resp.Violations[0].AccountId)
+		assert.Equal(t, "stored balance 90/0 does not match replayed events 100/0", resp.Violations[0].Detail)
+		mockConsistencyRepo.AssertExpectations(t)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns unimplemented when consistency checks are disabled", func(t *testing.T) {
+		resp, err := NewAdminService(nil, nil, nil).CheckLedgerConsistency(ctx, &pb.CheckLedgerConsistencyRequest{
+			TenantId: uuid.New().String(),
+		})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
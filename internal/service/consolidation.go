@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// ConsolidationService implements the gRPC ConsolidationService. It reads
+// across tenants, so it is served on the admin listener.
+type ConsolidationService struct {
+	pb.UnimplementedConsolidationServiceServer
+	journalRepo       repository.JournalRepositoryInterface
+	intercompanyRepo  repository.IntercompanyRepositoryInterface
+	reportRepo        repository.ReportRepositoryInterface
+	consolidationRepo repository.ConsolidationRepositoryInterface
+}
+
+// NewConsolidationService creates a new consolidation service
+func NewConsolidationService(
+	journalRepo repository.JournalRepositoryInterface,
+	intercompanyRepo repository.IntercompanyRepositoryInterface,
+	reportRepo repository.ReportRepositoryInterface,
+	consolidationRepo repository.ConsolidationRepositoryInterface,
+) *ConsolidationService {
+	return &ConsolidationService{
+		journalRepo:       journalRepo,
+		intercompanyRepo:  intercompanyRepo,
+		reportRepo:        reportRepo,
+		consolidationRepo: consolidationRepo,
+	}
+}
+
+// GetEliminationReport lists the intercompany balances between a group of
+// tenants and whether both sides of every tenant pair agree
+func (s *ConsolidationService) GetEliminationReport(ctx context.Context, req *pb.GetEliminationReportRequest) (*pb.GetEliminationReportResponse, error) {
+	tenantIDs, err := parseTenantGroup(req.TenantIds)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf := time.Now()
+	if req.AsOfDate != nil {
+		asOf = req.AsOfDate.AsTime()
+	}
+
+	balances, err := s.intercompanyBalances(ctx, tenantIDs, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetEliminationReportResponse{
+		Balances: make([]*pb.IntercompanyBalance, len(balances)),
+		Balanced: true,
+	}
+	for i, b := range balances {
+		resp.Balances[i] = &pb.IntercompanyBalance{
+			TenantId:             b.TenantID.String(),
+			CounterpartyTenantId: b.CounterpartyTenantID.String(),
+			AccountId:            b.AccountID.String(),
+			AccountNumber:        b.AccountNumber,
+			AccountName:          b.AccountName,
+			CurrencyCode:         b.CurrencyCode,
+			Balance:              b.Balance().String(),
+		}
+	}
+
+	for _, pair := range intercompanyPairs(balances) {
+		difference := pair.balance.Add(pair.counterpartyBalance)
+		if !difference.IsZero() {
+			resp.Balanced = false
+		}
+		resp.Pairs = append(resp.Pairs, &pb.IntercompanyPair{
+			TenantId:             pair.tenantID.String(),
+			CounterpartyTenantId: pair.counterpartyTenantID.String(),
+			Balance:              pair.balance.String(),
+			CounterpartyBalance:  pair.counterpartyBalance.String(),
+			Difference:           difference.String(),
+		})
+	}
+
+	return resp, nil
+}
+
+// GenerateEliminationEntries posts a journal entry in the elimination tenant
+// that reverses the group's intercompany balances, matched by account number
+func (s *ConsolidationService) GenerateEliminationEntries(ctx context.Context, req *pb.GenerateEliminationEntriesRequest) (*pb.GenerateEliminationEntriesResponse, error) {
+	eliminationTenantID, err := requestID("elimination_tenant_id", req.EliminationTenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantIDs, err := parseTenantGroup(req.TenantIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range tenantIDs {
+		if id == eliminationTenantID {
+			return nil, status.Error(codes.InvalidArgument, "elimination tenant must not be part of the eliminated tenants")
+		}
+	}
+
+	asOf := time.Now()
+	if req.AsOfDate != nil {
+		asOf = req.AsOfDate.AsTime()
+	}
+
+	balances, err := s.intercompanyBalances(ctx, tenantIDs, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	nets := make(map[string]decimal.Decimal)
+	total := decimal.Zero
+	for _, b := range balances {
+		nets[b.AccountNumber] = nets[b.AccountNumber].Add(b.Balance())
+		total = total.Add(b.Balance())
+	}
+
+	if !total.IsZero() {
+		return nil, status.Errorf(codes.FailedPrecondition, "intercompany balances do not agree: difference is %s", total)
+	}
+
+	numbers := make([]string, 0, len(nets))
+	for number, net := range nets {
+		if !net.IsZero() {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Strings(numbers)
+
+	if len(numbers) == 0 {
+		return nil, status.Error(codes.FailedPrecondition, "no intercompany balances to eliminate")
+	}
+
+	accounts, err := s.intercompanyRepo.FindAccountIDsByNumber(ctx, eliminationTenantID, numbers)
+	if err != nil {
+		return nil, repositoryError("find elimination accounts", err)
+	}
+
+	lines := make([]*repository.CreateJournalEntryLineParams, len(numbers))
+	for i, number := range numbers {
+		accountID, ok := accounts[number]
+		if !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "elimination tenant has no account %s", number)
+		}
+
+		line := &repository.CreateJournalEntryLineParams{
+			AccountID:   accountID,
+			Debit:       decimal.Zero,
+			Credit:      decimal.Zero,
+			Description: "Intercompany elimination",
+		}
+		if net := nets[number]; net.IsPositive() {
+			line.Credit = net
+		} else {
+			line.Debit = net.Neg()
+		}
+		lines[i] = line
+	}
+
+	referenceNumber := req.ReferenceNumber
+	if referenceNumber == "" {
+		referenceNumber = "ELIM-" + asOf.Format("20060102")
+	}
+
+	entry, err := s.journalRepo.Create(ctx, eliminationTenantID, repository.CreateJournalEntryParams{
+		ReferenceNumber: referenceNumber,
+		Description:     fmt.Sprintf("Intercompany eliminations as of %s", asOf.Format("2006-01-02")),
+		EntryDate:       asOf,
+		Metadata:        map[string]interface{}{"elimination": true},
+		Lines:           lines,
+	})
+	if err != nil {
+		return nil, repositoryError("create elimination entry", err)
+	}
+
+	return &pb.GenerateEliminationEntriesResponse{
+		JournalEntry: journalEntryToProto(entry),
+	}, nil
+}
+
+// intercompanyBalances collects the intercompany balances of every tenant in
+// the group whose counterparty is also part of the group
+func (s *ConsolidationService) intercompanyBalances(ctx context.Context, tenantIDs []uuid.UUID, asOf time.Time) ([]*repository.IntercompanyBalance, error) {
+	inGroup := make(map[uuid.UUID]bool, len(tenantIDs))
+	for _, id := range tenantIDs {
+		inGroup[id] = true
+	}
+
+	var balances []*repository.IntercompanyBalance
+	for _, tenantID := range tenantIDs {
+		tenantBalances, err := s.intercompanyRepo.ListBalances(ctx, tenantID, asOf)
+		if err != nil {
+			return nil, repositoryError("list intercompany balances", err)
+		}
+		for _, b := range tenantBalances {
+			if inGroup[b.CounterpartyTenantID] {
+				balances = append(balances, b)
+			}
+		}
+	}
+
+	return balances, nil
+}
+
+type intercompanyPair struct {
+	tenantID             uuid.UUID
+	counterpartyTenantID uuid.UUID
+	balance              decimal.Decimal
+	counterpartyBalance  decimal.Decimal
+}
+
+// intercompanyPairs nets balances per unordered tenant pair
+func intercompanyPairs(balances []*repository.IntercompanyBalance) []*intercompanyPair {
+	pairs := make(map[[2]uuid.UUID]*intercompanyPair)
+	var order [][2]uuid.UUID
+
+	for _, b := range balances {
+		first, second := b.TenantID, b.CounterpartyTenantID
+		if second.String() < first.String() {
+			first, second = second, first
+		}
+		key := [2]uuid.UUID{first, second}
+
+		pair, ok := pairs[key]
+		if !ok {
+			pair = &intercompanyPair{tenantID: first, counterpartyTenantID: second}
+			pairs[key] = pair
+			order = append(order, key)
+		}
+
+		if b.TenantID == first {
+			pair.balance = pair.balance.Add(b.Balance())
+		} else {
+			pair.counterpartyBalance = pair.counterpartyBalance.Add(b.Balance())
+		}
+	}
+
+	result := make([]*intercompanyPair, len(order))
+	for i, key := range order {
+		result[i] = pairs[key]
+	}
+	return result
+}
+
+// parseTenantGroup parses the tenant IDs of a group, which must contain at least two distinct tenants
+func parseTenantGroup(ids []string) ([]uuid.UUID, error) {
+	if len(ids) < 2 {
+		return nil, status.Error(codes.InvalidArgument, "at least two tenants are required")
+	}
+
+	seen := make(map[uuid.UUID]bool, len(ids))
+	tenantIDs := make([]uuid.UUID, len(ids))
+	for i, id := range ids {
+		tenantID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid tenant ID %q", id)
+		}
+		if seen[tenantID] {
+			return nil, status.Errorf(codes.InvalidArgument, "duplicate tenant ID %q", id)
+		}
+		seen[tenantID] = true
+		tenantIDs[i] = tenantID
+	}
+
+	return tenantIDs, nil
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/locale"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// CreateConsolidationGroup creates a group of tenants reported on together
+func (s *ConsolidationService) CreateConsolidationGroup(ctx context.Context, req *pb.CreateConsolidationGroupRequest) (*pb.CreateConsolidationGroupResponse, error) {
+	params, err := consolidationGroupParams(req.Name, req.ReportingCurrency, req.EliminationTenantId, req.MemberTenantIds)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.consolidationRepo.CreateGroup(ctx, params)
+	if err != nil {
+		return nil, repositoryError("create consolidation group", err)
+	}
+
+	return &pb.CreateConsolidationGroupResponse{
+		Group: consolidationGroupToProto(group),
+	}, nil
+}
+
+// GetConsolidationGroup retrieves a consolidation group
+func (s *ConsolidationService) GetConsolidationGroup(ctx context.Context, req *pb.GetConsolidationGroupRequest) (*pb.GetConsolidationGroupResponse, error) {
+	groupID, err := requestID("group_id", req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.getGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetConsolidationGroupResponse{
+		Group: consolidationGroupToProto(group),
+	}, nil
+}
+
+// UpdateConsolidationGroup replaces the settings and members of a consolidation group
+func (s *ConsolidationService) UpdateConsolidationGroup(ctx context.Context, req *pb.UpdateConsolidationGroupRequest) (*pb.UpdateConsolidationGroupResponse, error) {
+	groupID, err := requestID("group_id", req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := consolidationGroupParams(req.Name, req.ReportingCurrency, req.EliminationTenantId, req.MemberTenantIds)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.consolidationRepo.UpdateGroup(ctx, groupID, params)
+	if err != nil {
+		return nil, repositoryError("update consolidation group", err)
+	}
+
+	return &pb.UpdateConsolidationGroupResponse{
+		Group: consolidationGroupToProto(group),
+	}, nil
+}
+
+// GetConsolidatedBalanceSheet aggregates the balance sheets of a group's
+// tenants as of a date, translated into the reporting currency
+func (s *ConsolidationService) GetConsolidatedBalanceSheet(ctx context.Context, req *pb.GetConsolidatedBalanceSheetRequest) (*pb.GetConsolidatedBalanceSheetResponse, error) {
+	groupID, err := requestID("group_id", req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := parseExchangeRates(req.Rates)
+	if err != nil {
+		return nil, err
+	}
+
+	formatter, err := reportFormatter(req.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf := time.Now()
+	if req.AsOfDate != nil {
+		asOf = req.AsOfDate.AsTime()
+	}
+
+	group, err := s.getGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var postedAsOf *time.Time
+	if req.PostedAsOf != nil {
+		t := req.PostedAsOf.AsTime()
+		postedAsOf = &t
+	}
+
+	accounts, err := s.consolidate(ctx, group, req.GetBookCode(), nil, asOf, postedAsOf, rates)
+	if err != nil {
+		return nil, err
+	}
+
+	freshness, err := s.freshness(ctx, group, postedAsOf)
+	if err != nil {
+		return nil, err
+	}
+
+	assets := statementSection(repository.AccountTypeAsset, accounts, formatter)
+	liabilities := statementSection(repository.AccountTypeLiability, accounts, formatter)
+	equity := statementSection(repository.AccountTypeEquity, accounts, formatter)
+	netIncome := statementTotal(repository.AccountTypeRevenue, accounts).Sub(statementTotal(repository.AccountTypeExpense, accounts))
+
+	totalAssets := statementTotal(repository.AccountTypeAsset, accounts)
+	totalLiabilitiesAndEquity := statementTotal(repository.AccountTypeLiability, accounts).
+		Add(statementTotal(repository.AccountTypeEquity, accounts)).
+		Add(netIncome)
+	translationAdjustment := totalAssets.Sub(totalLiabilitiesAndEquity)
+
+	return &pb.GetConsolidatedBalanceSheetResponse{
+		ReportingCurrency:         group.ReportingCurrency,
+		AsOfDate:                  timestamppb.New(asOf),
+		Assets:                    assets,
+		Liabilities:               liabilities,
+		Equity:                    equity,
+		NetIncome:                 formatter.Decimal(netIncome),
+		TranslationAdjustment:     formatter.Decimal(translationAdjustment),
+		TotalAssets:               formatter.Decimal(totalAssets),
+		TotalLiabilitiesAndEquity: formatter.Decimal(totalLiabilitiesAndEquity.Add(translationAdjustment)),
+		Freshness:                 freshness,
+	}, nil
+}
+
+// GetConsolidatedIncomeStatement aggregates the income statements of a
+// group's tenants over a period, translated into the reporting currency
+func (s *ConsolidationService) GetConsolidatedIncomeStatement(ctx context.Context, req *pb.GetConsolidatedIncomeStatementRequest) (*pb.GetConsolidatedIncomeStatementResponse, error) {
+	groupID, err := requestID("group_id", req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FromDate == nil || req.ToDate == nil {
+		return nil, status.Error(codes.InvalidArgument, "from date and to date are required")
+	}
+
+	fromDate := req.FromDate.AsTime()
+	toDate := req.ToDate.AsTime()
+	if toDate.Before(fromDate) {
+		return nil, status.Error(codes.InvalidArgument, "to date must not be before from date")
+	}
+
+	rates, err := parseExchangeRates(req.Rates)
+	if err != nil {
+		return nil, err
+	}
+
+	formatter, err := reportFormatter(req.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.getGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var postedAsOf *time.Time
+	if req.PostedAsOf != nil {
+		t := req.PostedAsOf.AsTime()
+		postedAsOf = &t
+	}
+
+	accounts, err := s.consolidate(ctx, group, req.GetBookCode(), &fromDate, toDate, postedAsOf, rates)
+	if err != nil {
+		return nil, err
+	}
+
+	freshness, err := s.freshness(ctx, group, postedAsOf)
+	if err != nil {
+		return nil, err
+	}
+
+	netIncome := statementTotal(repository.AccountTypeRevenue, accounts).Sub(statementTotal(repository.AccountTypeExpense, accounts))
+
+	return &pb.GetConsolidatedIncomeStatementResponse{
+		ReportingCurrency: group.ReportingCurrency,
+		FromDate:          req.FromDate,
+		ToDate:            req.ToDate,
+		Revenue:           statementSection(repository.AccountTypeRevenue, accounts, formatter),
+		Expenses:          statementSection(repository.AccountTypeExpense, accounts, formatter),
+		NetIncome:         formatter.Decimal(netIncome),
+		Freshness:         freshness,
+	}, nil
+}
+
+func (s *ConsolidationService) getGroup(ctx context.Context, groupID uuid.UUID) (*repository.ConsolidationGroup, error) {
+	group, err := s.consolidationRepo.GetGroup(ctx, groupID)
+	if err != nil {
+		return nil, repositoryError("get consolidation group", err)
+	}
+	return group, nil
+}
+
+// consolidatedAccount is the group-wide balance of one account number in the reporting currency
+type consolidatedAccount struct {
+	number   string
+	name     string
+	typeCode string
+	balance  decimal.Decimal
+}
+
+// consolidate sums the trial balances of the group's members and its
+// elimination tenant per account number, translating balance sheet accounts
+// at the closing rate and income statement accounts at the average rate.
+// Each tenant contributes its book with the given code, or its default book
+// when the code is empty. Entries posted after postedAsOf, when set, are
+// left out.
+func (s *ConsolidationService) consolidate(ctx context.Context, group *repository.ConsolidationGroup, bookCode string, fromDate *time.Time, toDate time.Time, postedAsOf *time.Time, rates map[string]exchangeRate) (map[string]*consolidatedAccount, error) {
+	translation := &reportTranslation{currency: group.ReportingCurrency, rates: rates}
+	accounts := make(map[string]*consolidatedAccount)
+	for _, tenantID := range groupTenantIDs(group) {
+		rows, err := s.reportRepo.GetTrialBalance(ctx, tenantID, bookCode, fromDate, toDate, postedAsOf)
+		if err != nil {
+			return nil, repositoryError("get trial balance", err)
+		}
+
+		for _, row := range rows {
+			rate, err := translation.rate(row.CurrencyCode, row.AccountTypeCode)
+			if err != nil {
+				return nil, err
+			}
+
+			account, ok := accounts[row.AccountNumber]
+			if !ok {
+				account = &consolidatedAccount{
+					number:   row.AccountNumber,
+					name:     row.AccountName,
+					typeCode: row.AccountTypeCode,
+				}
+				accounts[row.AccountNumber] = account
+			}
+			account.balance = account.balance.Add(row.Balance().Mul(rate))
+		}
+	}
+
+	return accounts, nil
+}
+
+// freshness combines the period totals freshness of the group's tenants,
+// reporting the totals as current as those of the tenant lagging most. A
+// report of entries posted as of a time reads no totals, so has none.
+func (s *ConsolidationService) freshness(ctx context.Context, group *repository.ConsolidationGroup, postedAsOf *time.Time) (*pb.ReportFreshness, error) {
+	if postedAsOf != nil {
+		return nil, nil
+	}
+
+	var combined *repository.ReportFreshness
+	for _, tenantID := range groupTenantIDs(group) {
+		freshness, err := s.reportRepo.GetFreshness(ctx, tenantID)
+		if err != nil {
+			return nil, repositoryError("get report freshness", err)
+		}
+
+		if combined == nil {
+			combined = freshness
+			continue
+		}
+		if freshness.AsOf.Before(combined.AsOf) {
+			combined.AsOf = freshness.AsOf
+		}
+		combined.PendingEntries += freshness.PendingEntries
+	}
+	if combined == nil {
+		return nil, nil
+	}
+
+	return freshnessToProto(combined), nil
+}
+
+// groupTenantIDs returns the members of a group followed by its elimination
+// tenant, if any, leaving out tenants in test mode
+func groupTenantIDs(group *repository.ConsolidationGroup) []uuid.UUID {
+	all := group.MemberTenantIDs
+	if group.EliminationTenantID != nil {
+		all = append(append([]uuid.UUID{}, all...), *group.EliminationTenantID)
+	}
+
+	tenantIDs := make([]uuid.UUID, 0, len(all))
+	for _, id := range all {
+		if !slices.Contains(group.TestTenantIDs, id) {
+			tenantIDs = append(tenantIDs, id)
+		}
+	}
+	return tenantIDs
+}
+
+// statementSection lists the accounts of one type with amounts on their
+// normal side, formatted for display when a formatter is given
+func statementSection(typeCode string, accounts map[string]*consolidatedAccount, formatter *locale.Formatter) *pb.StatementSection {
+	var numbers []string
+	for number, account := range accounts {
+		if account.typeCode == typeCode {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Strings(numbers)
+
+	section := &pb.StatementSection{
+		AccountType: typeCode,
+		Lines:       make([]*pb.ConsolidatedLine, len(numbers)),
+		Total:       formatter.Decimal(statementTotal(typeCode, accounts)),
+	}
+	for i, number := range numbers {
+		account := accounts[number]
+		section.Lines[i] = &pb.ConsolidatedLine{
+			AccountNumber: account.number,
+			AccountName:   account.name,
+			AccountType:   account.typeCode,
+			Amount:        formatter.Decimal(normalAmount(account.typeCode, account.balance)),
+		}
+	}
+
+	return section
+}
+
+// statementTotal sums the accounts of one type on their normal side
+func statementTotal(typeCode string, accounts map[string]*consolidatedAccount) decimal.Decimal {
+	total := decimal.Zero
+	for _, account := range accounts {
+		if account.typeCode == typeCode {
+			total = total.Add(normalAmount(typeCode, account.balance))
+		}
+	}
+	return total
+}
+
+// normalAmount converts a debit balance to the account type's normal side
+func normalAmount(typeCode string, debitBalance decimal.Decimal) decimal.Decimal {
+	if typeCode == repository.AccountTypeAsset || typeCode == repository.AccountTypeExpense {
+		return debitBalance
+	}
+	return debitBalance.Neg()
+}
+
+func consolidationGroupParams(name, reportingCurrency string, eliminationTenantID *string, memberIDs []string) (repository.ConsolidationGroupParams, error) {
+	params := repository.ConsolidationGroupParams{
+		Name:              name,
+		ReportingCurrency: reportingCurrency,
+	}
+
+	if len(reportingCurrency) != 3 {
+		return params, status.Error(codes.InvalidArgument, "reporting currency must be a 3-letter ISO 4217 code")
+	}
+
+	members, err := parseTenantGroup(memberIDs)
+	if err != nil {
+		return params, err
+	}
+	params.MemberTenantIDs = members
+
+	if eliminationTenantID != nil && *eliminationTenantID != "" {
+		id, err := requestID("elimination_tenant_id", *eliminationTenantID)
+		if err != nil {
+			return params, err
+		}
+		for _, member := range members {
+			if member == id {
+				return params, status.Error(codes.InvalidArgument, "elimination tenant must not be a group member")
+			}
+		}
+		params.EliminationTenantID = &id
+	}
+
+	return params, nil
+}
+
+func consolidationGroupToProto(group *repository.ConsolidationGroup) *pb.ConsolidationGroup {
+	pbGroup := &pb.ConsolidationGroup{
+		GroupId:           group.ID.String(),
+		Name:              group.Name,
+		ReportingCurrency: group.ReportingCurrency,
+		MemberTenantIds:   make([]string, len(group.MemberTenantIDs)),
+		TestTenantIds:     make([]string, len(group.TestTenantIDs)),
+		CreatedAt:         timestamppb.New(group.CreatedAt),
+		UpdatedAt:         timestamppb.New(group.UpdatedAt),
+	}
+
+	for i, id := range group.MemberTenantIDs {
+		pbGroup.MemberTenantIds[i] = id.String()
+	}
+	for i, id := range group.TestTenantIDs {
+		pbGroup.TestTenantIds[i] = id.String()
+	}
+
+	if group.EliminationTenantID != nil {
+		eliminationTenantID := group.EliminationTenantID.String()
+		pbGroup.EliminationTenantId = &eliminationTenantID
+	}
+
+	return pbGroup
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockReportRepository struct {
+	mock.Mock
+}
+
+func (m *MockReportRepository) GetTrialBalance(ctx context.Context, tenantID uuid.UUID, bookCode string, fromDate *time.Time, toDate time.Time, postedAsOf *time.Time) ([]*repository.TrialBalanceRow, error) {
+	args := m.Called(ctx, tenantID, bookCode, fromDate, toDate, postedAsOf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.TrialBalanceRow), args.Error(1)
+}
+
+func (m *MockReportRepository) AggregateJournalLines(ctx context.Context, tenantID uuid.UUID, filter repository.LineAggregateFilter) ([]*repository.LineAggregate, error) {
+	args := m.Called(ctx, tenantID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.LineAggregate), args.Error(1)
+}
+
+func (m *MockReportRepository) GetFreshness(ctx context.Context, tenantID uuid.UUID) (*repository.ReportFreshness, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ReportFreshness), args.Error(1)
+}
+
+func (m *MockReportRepository) RefreshPeriodTotals(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	args := m.Called(ctx, tenantID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockReportRepository) GetAccountStatement(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, fromDate, toDate time.Time) (*repository.AccountStatement, error) {
+	args := m.Called(ctx, tenantID, accountID, fromDate, toDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.AccountStatement), args.Error(1)
+}
+
+type MockConsolidationRepository struct {
+	mock.Mock
+}
+
+func (m *MockConsolidationRepository) CreateGroup(ctx context.Context, params repository.ConsolidationGroupParams) (*repository.ConsolidationGroup, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ConsolidationGroup), args.Error(1)
+}
+
+func (m *MockConsolidationRepository) UpdateGroup(ctx context.Context, groupID uuid.UUID, params repository.ConsolidationGroupParams) (*repository.ConsolidationGroup, error) {
+	args := m.Called(ctx, groupID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ConsolidationGroup), args.Error(1)
+}
+
+func (m *MockConsolidationRepository) GetGroup(ctx context.Context, groupID uuid.UUID) (*repository.ConsolidationGroup, error) {
+	args := m.Called(ctx, groupID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ConsolidationGroup), args.Error(1)
+}
+
+func trialBalanceRow(number, typeCode, currency string, debit, credit int64) *repository.TrialBalanceRow {
+	return &repository.TrialBalanceRow{
+		AccountID:       uuid.New(),
+		AccountNumber:   number,
+		AccountName:     "Account " + number,
+		AccountTypeCode: typeCode,
+		CurrencyCode:    currency,
+		Debit:           decimal.NewFromInt(debit),
+		Credit:          decimal.NewFromInt(credit),
+	}
+}
+
+// Test GetConsolidatedBalanceSheet
+func TestConsolidationService_GetConsolidatedBalanceSheet(t *testing.T) {
+	ctx := context.Background()
+	mockReportRepo := new(MockReportRepository)
+	mockConsolidationRepo := new(MockConsolidationRepository)
+	service := NewConsolidationService(nil, nil, mockReportRepo, mockConsolidationRepo)
+	asOf := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	parent, sub := uuid.New(), uuid.New()
+	groupID := uuid.New()
+	group := &repository.ConsolidationGroup{
+		ID:                groupID,
+		ReportingCurrency: "USD",
+		MemberTenantIDs:   []uuid.UUID{parent, sub},
+	}
+
+	t.Run("translates and aggregates member balances", func(t *testing.T) {
+		mockConsolidationRepo.On("GetGroup", ctx, groupID).Return(group, nil).Once()
+		mockReportRepo.On("GetTrialBalance", ctx, parent, "", (*time.Time)(nil), asOf, (*time.Time)(nil)).Return([]*repository.TrialBalanceRow{
+			trialBalanceRow("1000", repository.AccountTypeAsset, "USD", 1000, 0),
+			trialBalanceRow("3000", repository.AccountTypeEquity, "USD", 0, 1000),
+		}, nil).Once()
+		mockReportRepo.On("GetTrialBalance", ctx, sub, "", (*time.Time)(nil), asOf, (*time.Time)(nil)).Return([]*repository.TrialBalanceRow{
+			trialBalanceRow("1000", repository.AccountTypeAsset, "EUR", 100, 0),
+			trialBalanceRow("4000", repository.AccountTypeRevenue, "EUR", 0, 100),
+		}, nil).Once()
+		queuedAt := time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC)
+		mockReportRepo.On("GetFreshness", ctx, parent).Return(&repository.ReportFreshness{
+			Scheduled: true,
+			AsOf:      queuedAt.Add(time.Hour),
+		}, nil).Once()
+		mockReportRepo.On("GetFreshness", ctx, sub).Return(&repository.ReportFreshness{
+			Scheduled:      true,
+			AsOf:           queuedAt,
+			PendingEntries: 3,
+		}, nil).Once()
+
+		resp, err := service.GetConsolidatedBalanceSheet(ctx, &pb.GetConsolidatedBalanceSheetRequest{
+			GroupId:  groupID.String(),
+			AsOfDate: timestamppb.New(asOf),
+			Rates:    []*pb.ExchangeRate{{CurrencyCode: "EUR", ClosingRate: "1.1", AverageRate: "1.05"}},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Assets.Lines, 1)
+		assert.Equal(t, "1110", resp.TotalAssets)
+		assert.Equal(t, "1000", resp.Equity.Total)
+		assert.Equal(t, "105", resp.NetIncome)
+		assert.Equal(t, "5", resp.TranslationAdjustment)
+		assert.Equal(t, "1110", resp.TotalLiabilitiesAndEquity)
+		assert.Equal(t, queuedAt, resp.Freshness.TotalsAsOf.AsTime())
+		assert.Equal(t, int64(3), resp.Freshness.PendingEntries)
+		mockConsolidationRepo.AssertExpectations(t)
+		mockReportRepo.AssertExpectations(t)
+	})
+
+	t.Run("translates equity at its historical rate", func(t *testing.T) {
+		mockConsolidationRepo.On("GetGroup", ctx, groupID).Return(group, nil).Once()
+		mockReportRepo.On("GetTrialBalance", ctx, parent, "", (*time.Time)(nil), asOf, (*time.Time)(nil)).Return([]*repository.TrialBalanceRow{}, nil).Once()
+		mockReportRepo.On("GetTrialBalance", ctx, sub, "", (*time.Time)(nil), asOf, (*time.Time)(nil)).Return([]*repository.TrialBalanceRow{
+			trialBalanceRow("1000", repository.AccountTypeAsset, "EUR", 500, 0),
+			trialBalanceRow("3000", repository.AccountTypeEquity, "EUR", 0, 500),
+		}, nil).Once()
+		mockReportRepo.On("GetFreshness", ctx, mock.Anything).Return(&repository.ReportFreshness{AsOf: asOf}, nil).Twice()
+
+		// The capital was contributed at 1.1 and the rate has since moved to 1.2
+		resp, err := service.GetConsolidatedBalanceSheet(ctx, &pb.GetConsolidatedBalanceSheetRequest{
+			GroupId:  groupID.String(),
+			AsOfDate: timestamppb.New(asOf),
+			Rates:    []*pb.ExchangeRate{{CurrencyCode: "EUR", ClosingRate: "1.2", HistoricalRate: "1.1"}},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "600", resp.TotalAssets)
+		assert.Equal(t, "550", resp.Equity.Total)
+		assert.Equal(t, "50", resp.TranslationAdjustment)
+		assert.Equal(t, "600", resp.TotalLiabilitiesAndEquity)
+		mockReportRepo.AssertExpectations(t)
+	})
+
+	t.Run("leaves out entries posted after the cutoff", func(t *testing.T) {
+		postedAsOf := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+
+		mockConsolidationRepo.On("GetGroup", ctx, groupID).Return(group, nil).Once()
+		mockReportRepo.On("GetTrialBalance", ctx, parent, "", (*time.Time)(nil), asOf, &postedAsOf).Return([]*repository.TrialBalanceRow{
+			trialBalanceRow("1000", repository.AccountTypeAsset, "USD", 900, 0),
+			trialBalanceRow("3000", repository.AccountTypeEquity, "USD", 0, 900),
+		}, nil).Once()
+		mockReportRepo.On("GetTrialBalance", ctx, sub, "", (*time.Time)(nil), asOf, &postedAsOf).Return([]*repository.TrialBalanceRow{}, nil).Once()
+
+		resp, err := service.GetConsolidatedBalanceSheet(ctx, &pb.GetConsolidatedBalanceSheetRequest{
+			GroupId:    groupID.String(),
+			AsOfDate:   timestamppb.New(asOf),
+			PostedAsOf: timestamppb.New(postedAsOf),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "900", resp.TotalAssets)
+		assert.Nil(t, resp.Freshness)
+		mockReportRepo.AssertExpectations(t)
+	})
+
+	t.Run("consolidates the members' books with the given code", func(t *testing.T) {
+		mockConsolidationRepo.On("GetGroup", ctx, groupID).Return(group, nil).Once()
+		mockReportRepo.On("GetTrialBalance", ctx, parent, "IFRS", (*time.Time)(nil), asOf, (*time.Time)(nil)).Return([]*repository.TrialBalanceRow{
+			trialBalanceRow("1000", repository.AccountTypeAsset, "USD", 1200, 0),
+			trialBalanceRow("3000", repository.AccountTypeEquity, "USD", 0, 1200),
+		}, nil).Once()
+		mockReportRepo.On("GetTrialBalance", ctx, sub, "IFRS", (*time.Time)(nil), asOf, (*time.Time)(nil)).Return([]*repository.TrialBalanceRow{}, nil).Once()
+		mockReportRepo.On("GetFreshness", ctx, mock.Anything).Return(&repository.ReportFreshness{AsOf: asOf}, nil).Twice()
+
+		bookCode := "IFRS"
+		resp, err := service.GetConsolidatedBalanceSheet(ctx, &pb.GetConsolidatedBalanceSheetRequest{
+			GroupId:  groupID.String(),
+			AsOfDate: timestamppb.New(asOf),
+			BookCode: &bookCode,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1200", resp.TotalAssets)
+		mockReportRepo.AssertExpectations(t)
+	})
+
+	t.Run("leaves out members in test mode", func(t *testing.T) {
+		testGroup := &repository.ConsolidationGroup{
+			ID:                groupID,
+			ReportingCurrency: "USD",
+			MemberTenantIDs:   []uuid.UUID{parent, sub},
+			TestTenantIDs:     []uuid.UUID{sub},
+		}
+		mockConsolidationRepo.On("GetGroup", ctx, groupID).Return(testGroup, nil).Once()
+		mockReportRepo.On("GetTrialBalance", ctx, parent, "", (*time.Time)(nil), asOf, (*time.Time)(nil)).Return([]*repository.TrialBalanceRow{
+			trialBalanceRow("1000", repository.AccountTypeAsset, "USD", 1000, 0),
+			trialBalanceRow("3000", repository.AccountTypeEquity, "USD", 0, 1000),
+		}, nil).Once()
+		mockReportRepo.On("GetFreshness", ctx, parent).Return(&repository.ReportFreshness{AsOf: asOf}, nil).Once()
+
+		resp, err := service.GetConsolidatedBalanceSheet(ctx, &pb.GetConsolidatedBalanceSheetRequest{
+			GroupId:  groupID.String(),
+			AsOfDate: timestamppb.New(asOf),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1000", resp.TotalAssets)
+		mockReportRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns failed precondition when a rate is missing", func(t *testing.T) {
+		mockConsolidationRepo.On("GetGroup", ctx, groupID).Return(group, nil).Once()
+		mockReportRepo.On("GetTrialBalance", ctx, parent, "", (*time.Time)(nil), asOf, (*time.Time)(nil)).Return([]*repository.TrialBalanceRow{
+			trialBalanceRow("1000", repository.AccountTypeAsset, "GBP", 10, 0),
+		}, nil).Once()
+
+		resp, err := service.GetConsolidatedBalanceSheet(ctx, &pb.GetConsolidatedBalanceSheetRequest{
+			GroupId:  groupID.String(),
+			AsOfDate: timestamppb.New(asOf),
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test CreateConsolidationGroup
+func TestConsolidationService_CreateConsolidationGroup(t *testing.T) {
+	ctx := context.Background()
+	mockConsolidationRepo := new(MockConsolidationRepository)
+	service := NewConsolidationService(nil, nil, nil, mockConsolidationRepo)
+
+	t.Run("returns error when elimination tenant is a member", func(t *testing.T) {
+		parent := uuid.New().String()
+		resp, err := service.CreateConsolidationGroup(ctx, &pb.CreateConsolidationGroupRequest{
+			Name:                "Holding",
+			ReportingCurrency:   "USD",
+			EliminationTenantId: &parent,
+			MemberTenantIds:     []string{parent, uuid.New().String()},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+		mockConsolidationRepo.AssertNotCalled(t, "CreateGroup", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockIntercompanyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIntercompanyRepository) ListBalances(ctx context.Context, tenantID uuid.UUID, asOf time.Time) ([]*repository.IntercompanyBalance, error) {
+	args := m.Called(ctx, tenantID, asOf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.IntercompanyBalance), args.Error(1)
+}
+
+func (m *MockIntercompanyRepository) FindAccountIDsByNumber(ctx context.Context, tenantID uuid.UUID, accountNumbers []string) (map[string]uuid.UUID, error) {
+	args := m.Called(ctx, tenantID, accountNumbers)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]uuid.UUID), args.Error(1)
+}
+
+// intercompanyFixture returns a receivable booked by parent against sub and the matching payable booked by sub
+func intercompanyFixture(parent, sub uuid.UUID, payable int64) ([]*repository.IntercompanyBalance, []*repository.IntercompanyBalance) {
+	return []*repository.IntercompanyBalance{{
+		TenantID:             parent,
+		CounterpartyTenantID: sub,
+		AccountID:            uuid.New(),
+		AccountNumber:        "1300",
+		Debit:                decimal.NewFromInt(500),
+		Credit:               decimal.Zero,
+	}}, []*repository.IntercompanyBalance{{
+		TenantID:             sub,
+		CounterpartyTenantID: parent,
+		AccountID:            uuid.New(),
+		AccountNumber:        "2300",
+		Debit:                decimal.Zero,
+		Credit:               decimal.NewFromInt(payable),
+	}}
+}
+
+// Test GetEliminationReport
+func TestConsolidationService_GetEliminationReport(t *testing.T) {
+	ctx := context.Background()
+	mockIntercompanyRepo := new(MockIntercompanyRepository)
+	service := NewConsolidationService(nil, mockIntercompanyRepo, nil, nil)
+	asOf := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("reports differences between tenant pairs", func(t *testing.T) {
+		parent, sub := uuid.New(), uuid.New()
+		parentBalances, subBalances := intercompanyFixture(parent, sub, 450)
+
+		mockIntercompanyRepo.On("ListBalances", ctx, parent, asOf).Return(parentBalances, nil).Once()
+		mockIntercompanyRepo.On("ListBalances", ctx, sub, asOf).Return(subBalances, nil).Once()
+
+		resp, err := service.GetEliminationReport(ctx, &pb.GetEliminationReportRequest{
+			TenantIds: []string{parent.String(), sub.String()},
+			AsOfDate:  timestamppb.New(asOf),
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Balances, 2)
+		assert.Len(t, resp.Pairs, 1)
+		assert.Equal(t, "50", resp.Pairs[0].Difference)
+		assert.False(t, resp.Balanced)
+		mockIntercompanyRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error for a single tenant", func(t *testing.T) {
+		resp, err := service.GetEliminationReport(ctx, &pb.GetEliminationReportRequest{
+			TenantIds: []string{uuid.New().String()},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test GenerateEliminationEntries
+func TestConsolidationService_GenerateEliminationEntries(t *testing.T) {
+	ctx := context.Background()
+	mockJournalRepo := new(MockJournalRepository)
+	mockIntercompanyRepo := new(MockIntercompanyRepository)
+	service := NewConsolidationService(mockJournalRepo, mockIntercompanyRepo, nil, nil)
+	asOf := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("reverses intercompany balances in the elimination tenant", func(t *testing.T) {
+		parent, sub, elimination := uuid.New(), uuid.New(), uuid.New()
+		receivableID, payableID := uuid.New(), uuid.New()
+		parentBalances, subBalances := intercompanyFixture(parent, sub, 500)
+
+		mockIntercompanyRepo.On("ListBalances", ctx, parent, asOf).Return(parentBalances, nil).Once()
+		mockIntercompanyRepo.On("ListBalances", ctx, sub, asOf).Return(subBalances, nil).Once()
+		mockIntercompanyRepo.On("FindAccountIDsByNumber", ctx, elimination, []string{"1300", "2300"}).Return(map[string]uuid.UUID{
+			"1300": receivableID,
+			"2300": payableID,
+		}, nil).Once()
+		mockJournalRepo.On("Create", ctx, elimination, mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			return p.ReferenceNumber == "ELIM-20241231" && len(p.Lines) == 2 &&
+				p.Lines[0].AccountID == receivableID && p.Lines[0].Credit.Equal(decimal.NewFromInt(500)) &&
+				p.Lines[1].AccountID == payableID && p.Lines[1].Debit.Equal(decimal.NewFromInt(500))
+		})).Return(&repository.JournalEntry{
+			ID:              uuid.New(),
+			TenantID:        elimination,
+			ReferenceNumber: "ELIM-20241231",
+			EntryDate:       asOf,
+		}, nil).Once()
+
+		resp, err := service.GenerateEliminationEntries(ctx, &pb.GenerateEliminationEntriesRequest{
+			EliminationTenantId: elimination.String(),
+			TenantIds:           []string{parent.String(), sub.String()},
+			AsOfDate:            timestamppb.New(asOf),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "ELIM-20241231", resp.JournalEntry.ReferenceNumber)
+		mockIntercompanyRepo.AssertExpectations(t)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns failed precondition when balances disagree", func(t *testing.T) {
+		parent, sub := uuid.New(), uuid.New()
+		parentBalances, subBalances := intercompanyFixture(parent, sub, 450)
+
+		mockIntercompanyRepo.On("ListBalances", ctx, parent, asOf).Return(parentBalances, nil).Once()
+		mockIntercompanyRepo.On("ListBalances", ctx, sub, asOf).Return(subBalances, nil).Once()
+
+		resp, err := service.GenerateEliminationEntries(ctx, &pb.GenerateEliminationEntriesRequest{
+			EliminationTenantId: uuid.New().String(),
+			TenantIds:           []string{parent.String(), sub.String()},
+			AsOfDate:            timestamppb.New(asOf),
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
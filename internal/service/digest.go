@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// GetDailyDigest retrieves the digest of a tenant's journal entries for a
+// UTC day. Digests are computed by the background runner once a day ends.
+func (s *LedgerService) GetDailyDigest(ctx context.Context, req *pb.GetDailyDigestRequest) (*pb.GetDailyDigestResponse, error) {
+	if s.digestRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "daily digests are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+	if req.Date == nil {
+		return nil, invalidField("date", "date is required")
+	}
+
+	digest, err := s.digestRepo.Get(ctx, tenantID, req.Date.AsTime())
+	if err != nil {
+		return nil, repositoryError("get daily digest", err)
+	}
+
+	return &pb.GetDailyDigestResponse{
+		Digest: dailyDigestToProto(digest),
+	}, nil
+}
+
+func dailyDigestToProto(digest *repository.DailyDigest) *pb.DailyDigest {
+	return &pb.DailyDigest{
+		TenantId:     digest.TenantID.String(),
+		Date:         timestamppb.New(digest.DigestDate),
+		MerkleRoot:   hex.EncodeToString(digest.MerkleRoot),
+		EntryCount:   int32(digest.EntryCount),
+		LastSequence: digest.LastSequence,
+		Published:    digest.Published,
+		CreatedAt:    timestamppb.New(digest.CreatedAt),
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockDigestRepository struct {
+	mock.Mock
+}
+
+func (m *MockDigestRepository) Get(ctx context.Context, tenantID uuid.UUID, date time.Time) (*repository.DailyDigest, error) {
+	args := m.Called(ctx, tenantID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.DailyDigest), args.Error(1)
+}
+
+func (m *MockDigestRepository) Latest(ctx context.Context, tenantID uuid.UUID) (*repository.DailyDigest, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.DailyDigest), args.Error(1)
+}
+
+func (m *MockDigestRepository) Compute(ctx context.Context, tenantID uuid.UUID, date time.Time, publish bool) (*repository.DailyDigest, error) {
+	args := m.Called(ctx, tenantID, date, publish)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.DailyDigest), args.Error(1)
+}
+
+// Test GetDailyDigest
+func TestLedgerService_GetDailyDigest(t *testing.T) {
+	ctx := context.Background()
+	mockDigestRepo := new(MockDigestRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithDigestRepository(mockDigestRepo))
+	date := time.Date(2026, 3, 15, 14, 30, 0, 0, time.UTC)
+
+	t.Run("returns the digest of the day", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockDigestRepo.On("Get", ctx, tenantID, date).Return(&repository.DailyDigest{
+			ID:           uuid.New(),
+			TenantID:     tenantID,
+			DigestDate:   time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC),
+			MerkleRoot:   []byte{0xab, 0xcd},
+			EntryCount:   3,
+			LastSequence: 42,
+			Published:    true,
+			CreatedAt:    time.Now(),
+		}, nil).Once()
+
+		resp, err := service.GetDailyDigest(ctx, &pb.GetDailyDigestRequest{
+			TenantId: tenantID.String(),
+			Date:     timestamppb.New(date),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "abcd", resp.Digest.MerkleRoot)
+		assert.Equal(t, int32(3), resp.Digest.EntryCount)
+		assert.Equal(t, int64(42), resp.Digest.LastSequence)
+		assert.True(t, resp.Digest.Published)
+		mockDigestRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for a day without a digest", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockDigestRepo.On("Get", ctx, tenantID, date).Return(nil, fmt.Errorf("daily digest %w", repository.ErrNotFound)).Once()
+
+		resp, err := service.GetDailyDigest(ctx, &pb.GetDailyDigestRequest{
+			TenantId: tenantID.String(),
+			Date:     timestamppb.New(date),
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Nil(t, resp)
+		mockDigestRepo.AssertExpectations(t)
+	})
+
+	t.Run("requires a date", func(t *testing.T) {
+		resp, err := service.GetDailyDigest(ctx, &pb.GetDailyDigestRequest{
+			TenantId: uuid.New().String(),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns unimplemented when digests are not enabled", func(t *testing.T) {
+		resp, err := NewLedgerService(nil, nil, nil, nil).GetDailyDigest(ctx, &pb.GetDailyDigestRequest{
+			TenantId: uuid.New().String(),
+			Date:     timestamppb.New(date),
+		})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
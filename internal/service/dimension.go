@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// CreateDimension creates a custom dimension journal lines can be tagged with
+func (s *LedgerService) CreateDimension(ctx context.Context, req *pb.CreateDimensionRequest) (*pb.CreateDimensionResponse, error) {
+	if s.dimensionRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "dimensions are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := dimensionParams(req.Code, req.Name, req.AllowedValues, true)
+	if err != nil {
+		return nil, err
+	}
+
+	dimension, err := s.dimensionRepo.Create(ctx, tenantID, params)
+	if err != nil {
+		return nil, repositoryError("create dimension", err)
+	}
+
+	return &pb.CreateDimensionResponse{
+		Dimension: dimensionToProto(dimension),
+	}, nil
+}
+
+// GetDimension retrieves a dimension
+func (s *LedgerService) GetDimension(ctx context.Context, req *pb.GetDimensionRequest) (*pb.GetDimensionResponse, error) {
+	if s.dimensionRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "dimensions are not enabled")
+	}
+
+	tenantID, dimensionID, err := parseDimensionIDs(req.TenantId, req.DimensionId)
+	if err != nil {
+		return nil, err
+	}
+
+	dimension, err := s.dimensionRepo.GetByID(ctx, tenantID, dimensionID)
+	if err != nil {
+		return nil, repositoryError("get dimension", err)
+	}
+
+	return &pb.GetDimensionResponse{
+		Dimension: dimensionToProto(dimension),
+	}, nil
+}
+
+// ListDimensions lists the dimensions of a tenant
+func (s *LedgerService) ListDimensions(ctx context.Context, req *pb.ListDimensionsRequest) (*pb.ListDimensionsResponse, error) {
+	if s.dimensionRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "dimensions are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	dimensions, err := s.dimensionRepo.List(ctx, tenantID, req.IncludeInactive)
+	if err != nil {
+		return nil, repositoryError("list dimensions", err)
+	}
+
+	resp := &pb.ListDimensionsResponse{
+		Dimensions: make([]*pb.Dimension, len(dimensions)),
+	}
+	for i, dimension := range dimensions {
+		resp.Dimensions[i] = dimensionToProto(dimension)
+	}
+
+	return resp, nil
+}
+
+// UpdateDimension replaces the settings of a dimension
+func (s *LedgerService) UpdateDimension(ctx context.Context, req *pb.UpdateDimensionRequest) (*pb.UpdateDimensionResponse, error) {
+	if s.dimensionRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "dimensions are not enabled")
+	}
+
+	tenantID, dimensionID, err := parseDimensionIDs(req.TenantId, req.DimensionId)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := dimensionParams(req.Code, req.Name, req.AllowedValues, req.IsActive)
+	if err != nil {
+		return nil, err
+	}
+
+	dimension, err := s.dimensionRepo.Update(ctx, tenantID, dimensionID, params)
+	if err != nil {
+		return nil, repositoryError("update dimension", err)
+	}
+
+	return &pb.UpdateDimensionResponse{
+		Dimension: dimensionToProto(dimension),
+	}, nil
+}
+
+// GetDimensionBalances sums posted lines per account, broken down by the
+// values of the requested dimensions, optionally translated into a
+// reporting currency
+func (s *LedgerService) GetDimensionBalances(ctx context.Context, req *pb.GetDimensionBalancesRequest) (*pb.GetDimensionBalancesResponse, error) {
+	if s.dimensionRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "dimensions are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := repository.DimensionBalanceFilter{}
+
+	if len(req.GroupBy) > 0 {
+		dimensions, err := s.dimensionRepo.GetByCodes(ctx, tenantID, req.GroupBy)
+		if err != nil {
+			return nil, repositoryError("get dimensions", err)
+		}
+		for _, code := range req.GroupBy {
+			if _, ok := dimensions[code]; !ok {
+				return nil, status.Errorf(codes.InvalidArgument, "unknown dimension %s", code)
+			}
+		}
+		filter.GroupBy = req.GroupBy
+	}
+
+	if req.AccountId != nil && *req.AccountId != "" {
+		accountID, err := requestID("account_id", *req.AccountId)
+		if err != nil {
+			return nil, err
+		}
+		filter.AccountID = &accountID
+	}
+
+	if filter.BookID, err = parseBookID(req.BookId); err != nil {
+		return nil, err
+	}
+
+	translation, err := s.reportingCurrency(ctx, req.ReportingCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.FromDate = dateOf(req.FromDate)
+	filter.ToDate = dateOf(req.ToDate)
+
+	balances, err := s.dimensionRepo.GetBalances(ctx, tenantID, filter)
+	if err != nil {
+		return nil, repositoryError("get dimension balances", err)
+	}
+
+	resp := &pb.GetDimensionBalancesResponse{
+		Balances: make([]*pb.DimensionBalance, len(balances)),
+	}
+	for i, balance := range balances {
+		debit, credit, err := translation.translate(balance.CurrencyCode, balance.AccountTypeCode, balance.Debit, balance.Credit)
+		if err != nil {
+			return nil, err
+		}
+		resp.Balances[i] = &pb.DimensionBalance{
+			AccountId:     balance.AccountID.String(),
+			AccountNumber: balance.AccountNumber,
+			AccountName:   balance.AccountName,
+			CurrencyCode:  balance.CurrencyCode,
+			Values:        balance.Values,
+			Debit:         debit.String(),
+			Credit:        credit.String(),
+			Balance:       debit.Sub(credit).String(),
+		}
+	}
+	if translation != nil {
+		resp.ReportingCurrency = &translation.currency
+	}
+
+	return resp, nil
+}
+
+// checkLineDimensions verifies that every dimension the lines are tagged
+// with exists, is active and allows the line's value
+func (s *LedgerService) checkLineDimensions(ctx context.Context, tenantID uuid.UUID, lines []*repository.CreateJournalEntryLineParams) error {
+	dimensionCodes := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		for code := range line.Dimensions {
+			if !seen[code] {
+				seen[code] = true
+				dimensionCodes = append(dimensionCodes, code)
+			}
+		}
+	}
+
+	if len(dimensionCodes) == 0 {
+		return nil
+	}
+
+	if s.dimensionRepo == nil {
+		return status.Error(codes.Unimplemented, "dimensions are not enabled")
+	}
+
+	sort.Strings(dimensionCodes)
+	dimensions, err := s.dimensionRepo.GetByCodes(ctx, tenantID, dimensionCodes)
+	if err != nil {
+		return repositoryError("get dimensions", err)
+	}
+
+	for i, line := range lines {
+		for code, value := range line.Dimensions {
+			dimension, ok := dimensions[code]
+			if !ok {
+				return invalidLine(i, "dimensions", "unknown dimension %s at line %d", code, i)
+			}
+			if !dimension.IsActive {
+				return failedPrecondition(reasonInactiveDimension, fmt.Sprintf("lines[%d].dimensions", i),
+					fmt.Sprintf("dimension %s is inactive at line %d", code, i), map[string]string{"dimension": code})
+			}
+			if value == "" {
+				return invalidLine(i, "dimensions", "dimension %s has no value at line %d", code, i)
+			}
+			if !dimension.Allows(value) {
+				return invalidLine(i, "dimensions", "value %q is not allowed for dimension %s at line %d", value, code, i)
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseDimensionIDs(tenantIDValue, dimensionIDValue string) (uuid.UUID, uuid.UUID, error) {
+	tenantID, err := requestID("tenant_id", tenantIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	dimensionID, err := requestID("dimension_id", dimensionIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return tenantID, dimensionID, nil
+}
+
+func dimensionParams(code, name string, allowedValues []string, isActive bool) (repository.DimensionParams, error) {
+	params := repository.DimensionParams{
+		Code:          code,
+		Name:          name,
+		AllowedValues: make([]string, 0, len(allowedValues)),
+		IsActive:      isActive,
+	}
+
+	seen := make(map[string]bool, len(allowedValues))
+	for _, value := range allowedValues {
+		if value == "" {
+			return params, status.Error(codes.InvalidArgument, "allowed values must not be empty")
+		}
+		if seen[value] {
+			return params, status.Errorf(codes.InvalidArgument, "duplicate allowed value %q", value)
+		}
+		seen[value] = true
+		params.AllowedValues = append(params.AllowedValues, value)
+	}
+
+	return params, nil
+}
+
+func dimensionToProto(dimension *repository.Dimension) *pb.Dimension {
+	return &pb.Dimension{
+		DimensionId:   dimension.ID.String(),
+		TenantId:      dimension.TenantID.String(),
+		Code:          dimension.Code,
+		Name:          dimension.Name,
+		AllowedValues: dimension.AllowedValues,
+		IsActive:      dimension.IsActive,
+		CreatedAt:     timestamppb.New(dimension.CreatedAt),
+		UpdatedAt:     timestamppb.New(dimension.UpdatedAt),
+	}
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockDimensionRepository struct {
+	mock.Mock
+}
+
+func (m *MockDimensionRepository) Create(ctx context.Context, tenantID uuid.UUID, params repository.DimensionParams) (*repository.Dimension, error) {
+	args := m.Called(ctx, tenantID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Dimension), args.Error(1)
+}
+
+func (m *MockDimensionRepository) Update(ctx context.Context, tenantID uuid.UUID, dimensionID uuid.UUID, params repository.DimensionParams) (*repository.Dimension, error) {
+	args := m.Called(ctx, tenantID, dimensionID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Dimension), args.Error(1)
+}
+
+func (m *MockDimensionRepository) GetByID(ctx context.Context, tenantID uuid.UUID, dimensionID uuid.UUID) (*repository.Dimension, error) {
+	args := m.Called(ctx, tenantID, dimensionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Dimension), args.Error(1)
+}
+
+func (m *MockDimensionRepository) GetByCodes(ctx context.Context, tenantID uuid.UUID, codes []string) (map[string]*repository.Dimension, error) {
+	args := m.Called(ctx, tenantID, codes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*repository.Dimension), args.Error(1)
+}
+
+func (m *MockDimensionRepository) List(ctx context.Context, tenantID uuid.UUID, includeInactive bool) ([]*repository.Dimension, error) {
+	args := m.Called(ctx, tenantID, includeInactive)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.Dimension), args.Error(1)
+}
+
+func (m *MockDimensionRepository) GetBalances(ctx context.Context, tenantID uuid.UUID, filter repository.DimensionBalanceFilter) ([]*repository.DimensionBalance, error) {
+	args := m.Called(ctx, tenantID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.DimensionBalance), args.Error(1)
+}
+
+// Test CreateDimension
+func TestLedgerService_CreateDimension(t *testing.T) {
+	ctx := context.Background()
+	mockDimensionRepo := new(MockDimensionRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithDimensionRepository(mockDimensionRepo))
+
+	t.Run("creates a dimension with allowed values", func(t *testing.T) {
+		tenantID := uuid.New()
+		params := repository.DimensionParams{
+			Code:          "COST_CENTER",
+			Name:          "Cost center",
+			AllowedValues: []string{"OPS", "SALES"},
+			IsActive:      true,
+		}
+
+		mockDimensionRepo.On("Create", ctx, tenantID, params).Return(&repository.Dimension{
+			ID:            uuid.New(),
+			TenantID:      tenantID,
+			Code:          params.Code,
+			Name:          params.Name,
+			AllowedValues: params.AllowedValues,
+			IsActive:      true,
+		}, nil).Once()
+
+		resp, err := service.CreateDimension(ctx, &pb.CreateDimensionRequest{
+			TenantId:      tenantID.String(),
+			Code:          "COST_CENTER",
+			Name:          "Cost center",
+			AllowedValues: []string{"OPS", "SALES"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"OPS", "SALES"}, resp.Dimension.AllowedValues)
+		assert.True(t, resp.Dimension.IsActive)
+		mockDimensionRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns invalid argument for duplicate allowed values", func(t *testing.T) {
+		resp, err := service.CreateDimension(ctx, &pb.CreateDimensionRequest{
+			TenantId:      uuid.New().String(),
+			Code:          "PROJECT",
+			Name:          "Project",
+			AllowedValues: []string{"P1", "P1"},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns unimplemented when dimensions are disabled", func(t *testing.T) {
+		service := NewLedgerService(nil, nil, nil, nil)
+
+		resp, err := service.CreateDimension(ctx, &pb.CreateDimensionRequest{TenantId: uuid.New().String()})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test CreateJournalEntry with dimension values
+func TestLedgerService_CreateJournalEntryWithDimensions(t *testing.T) {
+	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	mockDimensionRepo := new(MockDimensionRepository)
+	service := NewLedgerService(nil, mockAccountRepo, mockJournalRepo, nil, WithDimensionRepository(mockDimensionRepo))
+
+	tenantID := uuid.New()
+	expenseID, cashID := uuid.New(), uuid.New()
+	now := time.Now()
+	costCenter := &repository.Dimension{ID: uuid.New(), Code: "COST_CENTER", AllowedValues: []string{"OPS", "SALES"}, IsActive: true}
+	project := &repository.Dimension{ID: uuid.New(), Code: "PROJECT", IsActive: true}
+
+	request := func(costCenterValue string) *pb.CreateJournalEntryRequest {
+		return &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "EXP-001",
+			EntryDate:       timestamppb.New(now),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: expenseID.String(), Debit: "100", Credit: "0", Dimensions: map[string]string{"COST_CENTER": costCenterValue, "PROJECT": "APOLLO"}},
+				{AccountId: cashID.String(), Debit: "0", Credit: "100"},
+			},
+		}
+	}
+
+	t.Run("posts the dimension values on their line", func(t *testing.T) {
+		mockDimensionRepo.On("GetByCodes", ctx, tenantID, []string{"COST_CENTER", "PROJECT"}).
+			Return(map[string]*repository.Dimension{"COST_CENTER": costCenter, "PROJECT": project}, nil).Once()
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{expenseID, cashID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				expenseID: {CurrencyCode: "USD", Precision: 2},
+				cashID:    {CurrencyCode: "USD", Precision: 2},
+			}, nil).Once()
+		mockJournalRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			return p.Lines[0].Dimensions["COST_CENTER"] == "OPS" && p.Lines[0].Dimensions["PROJECT"] == "APOLLO" && p.Lines[1].Dimensions == nil
+		})).Return(&repository.JournalEntry{
+			ID:        uuid.New(),
+			TenantID:  tenantID,
+			EntryDate: now,
+			CreatedAt: now,
+		}, nil).Once()
+
+		_, err := service.CreateJournalEntry(ctx, request("OPS"))
+
+		require.NoError(t, err)
+		mockDimensionRepo.AssertExpectations(t)
+		mockAccountRepo.AssertExpectations(t)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns invalid argument for a value that is not allowed", func(t *testing.T) {
+		mockDimensionRepo.On("GetByCodes", ctx, tenantID, []string{"COST_CENTER", "PROJECT"}).
+			Return(map[string]*repository.Dimension{"COST_CENTER": costCenter, "PROJECT": project}, nil).Once()
+
+		resp, err := service.CreateJournalEntry(ctx, request("R&D"))
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Contains(t, err.Error(), "COST_CENTER")
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns invalid argument for an unknown dimension", func(t *testing.T) {
+		mockDimensionRepo.On("GetByCodes", ctx, tenantID, []string{"COST_CENTER", "PROJECT"}).
+			Return(map[string]*repository.Dimension{"COST_CENTER": costCenter}, nil).Once()
+
+		resp, err := service.CreateJournalEntry(ctx, request("OPS"))
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns failed precondition for an inactive dimension", func(t *testing.T) {
+		inactive := *project
+		inactive.IsActive = false
+		mockDimensionRepo.On("GetByCodes", ctx, tenantID, []string{"COST_CENTER", "PROJECT"}).
+			Return(map[string]*repository.Dimension{"COST_CENTER": costCenter, "PROJECT": &inactive}, nil).Once()
+
+		resp, err := service.CreateJournalEntry(ctx, request("OPS"))
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test GetDimensionBalances
+func TestLedgerService_GetDimensionBalances(t *testing.T) {
+	ctx := context.Background()
+	mockDimensionRepo := new(MockDimensionRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithDimensionRepository(mockDimensionRepo))
+
+	t.Run("groups balances by dimension values", func(t *testing.T) {
+		tenantID, accountID := uuid.New(), uuid.New()
+		groupBy := []string{"COST_CENTER"}
+
+		mockDimensionRepo.On("GetByCodes", ctx, tenantID, groupBy).
+			Return(map[string]*repository.Dimension{"COST_CENTER": {Code: "COST_CENTER"}}, nil).Once()
+		mockDimensionRepo.On("GetBalances", ctx, tenantID, repository.DimensionBalanceFilter{GroupBy: groupBy}).Return([]*repository.DimensionBalance{
+			{AccountID: accountID, AccountNumber: "6000", Values: map[string]string{"COST_CENTER": "OPS"}, Debit: decimal.NewFromInt(70), Credit: decimal.Zero},
+			{AccountID: accountID, AccountNumber: "6000", Values: map[string]string{}, Debit: decimal.NewFromInt(30), Credit: decimal.NewFromInt(5)},
+		}, nil).Once()
+
+		resp, err := service.GetDimensionBalances(ctx, &pb.GetDimensionBalancesRequest{
+			TenantId: tenantID.String(),
+			GroupBy:  groupBy,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Balances, 2)
+		assert.Equal(t, "OPS", resp.Balances[0].Values["COST_CENTER"])
+		assert.Equal(t, "70", resp.Balances[0].Balance)
+		assert.Empty(t, resp.Balances[1].Values)
+		assert.Equal(t, "25", resp.Balances[1].Balance)
+		mockDimensionRepo.AssertExpectations(t)
+	})
+
+	t.Run("translates balances into the reporting currency", func(t *testing.T) {
+		mockReferenceRepo := new(MockReferenceRepository)
+		service := NewLedgerService(nil, nil, nil, mockReferenceRepo, WithDimensionRepository(mockDimensionRepo))
+		tenantID, accountID := uuid.New(), uuid.New()
+
+		mockReferenceRepo.On("ListCurrencies", ctx).Return([]*repository.Currency{{Code: "USD"}, {Code: "EUR"}}, nil).Once()
+		mockDimensionRepo.On("GetBalances", ctx, tenantID, repository.DimensionBalanceFilter{}).Return([]*repository.DimensionBalance{
+			{AccountID: accountID, AccountNumber: "6000", AccountTypeCode: repository.AccountTypeExpense, CurrencyCode: "EUR", Values: map[string]string{}, Debit: decimal.NewFromInt(100), Credit: decimal.NewFromInt(20)},
+		}, nil).Once()
+
+		resp, err := service.GetDimensionBalances(ctx, &pb.GetDimensionBalancesRequest{
+			TenantId: tenantID.String(),
+			ReportingCurrency: &pb.ReportingCurrency{
+				CurrencyCode: "USD",
+				Rates:        []*pb.ExchangeRate{{CurrencyCode: "EUR", ClosingRate: "1.2", AverageRate: "1.1"}},
+			},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "USD", resp.GetReportingCurrency())
+		require.Len(t, resp.Balances, 1)
+		assert.Equal(t, "EUR", resp.Balances[0].CurrencyCode)
+		assert.Equal(t, "110", resp.Balances[0].Debit)
+		assert.Equal(t, "22", resp.Balances[0].Credit)
+		assert.Equal(t, "88", resp.Balances[0].Balance)
+		mockDimensionRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns invalid argument for an unknown dimension", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockDimensionRepo.On("GetByCodes", ctx, tenantID, []string{"REGION"}).
+			Return(map[string]*repository.Dimension{}, nil).Once()
+
+		resp, err := service.GetDimensionBalances(ctx, &pb.GetDimensionBalancesRequest{
+			TenantId: tenantID.String(),
+			GroupBy:  []string{"REGION"},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
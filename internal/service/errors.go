@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain identifies the ledger in ErrorInfo details
+const errorDomain = "ledger.v1"
+
+// Reasons reported in ErrorInfo details. Clients should branch on these
+// rather than on error messages.
+const (
+	reasonInvalidField         = "INVALID_FIELD"
+	reasonUnbalancedEntry      = "UNBALANCED_ENTRY"
+	reasonPeriodLocked         = "PERIOD_LOCKED"
+	reasonFutureDate           = "FUTURE_DATE_NOT_ALLOWED"
+	reasonBackdateLimit        = "BACKDATE_LIMIT_EXCEEDED"
+	reasonNotFound             = "NOT_FOUND"
+	reasonAlreadyExists        = "ALREADY_EXISTS"
+	reasonReferenceNotFound    = "REFERENCE_NOT_FOUND"
+	reasonPermissionDenied     = "PERMISSION_DENIED"
+	reasonDeletedAccount       = "DELETED_ACCOUNT"
+	reasonNonZeroBalance       = "NON_ZERO_BALANCE"
+	reasonAccountHasChildren   = "ACCOUNT_HAS_CHILDREN"
+	reasonAccountCycle         = "ACCOUNT_CYCLE"
+	reasonAccountTypeMismatch  = "ACCOUNT_TYPE_MISMATCH"
+	reasonCurrencyMismatch     = "CURRENCY_MISMATCH"
+	reasonBookMismatch         = "BOOK_MISMATCH"
+	reasonAlreadyReconciled    = "ALREADY_RECONCILED"
+	reasonAccountMismatch      = "ACCOUNT_MISMATCH"
+	reasonApplicationMismatch  = "APPLICATION_MISMATCH"
+	reasonOverApplication      = "OVER_APPLICATION"
+	reasonDepreciationPosted   = "DEPRECIATION_POSTED"
+	reasonInterestAccrued      = "INTEREST_ACCRUED"
+	reasonInactiveTaxCode      = "INACTIVE_TAX_CODE"
+	reasonDeletedParty         = "DELETED_PARTY"
+	reasonInactiveDimension    = "INACTIVE_DIMENSION"
+	reasonIdempotencyKeyReused = "IDEMPOTENCY_KEY_REUSED"
+	reasonHoldNotPending       = "HOLD_NOT_PENDING"
+	reasonHoldExpired          = "HOLD_EXPIRED"
+	reasonCaptureExceedsHold   = "CAPTURE_EXCEEDS_HOLD"
+	reasonPreparedNotPending   = "PREPARED_ENTRY_NOT_PENDING"
+	reasonPreparedExpired      = "PREPARED_ENTRY_EXPIRED"
+	reasonBatchNotOpen         = "BATCH_NOT_OPEN"
+	reasonBatchNotApproved     = "BATCH_NOT_APPROVED"
+	reasonBatchPosted          = "BATCH_POSTED"
+	reasonBatchEmpty           = "BATCH_EMPTY"
+	reasonInsufficientFunds    = "INSUFFICIENT_FUNDS"
+	reasonFxAccountMissing     = "FX_ACCOUNT_NOT_CONFIGURED"
+	reasonEntryLimitExceeded   = "ENTRY_LIMIT_EXCEEDED"
+	reasonFinerThanMinorUnits  = "AMOUNT_FINER_THAN_MINOR_UNITS"
+	reasonTenantNotDeleted     = "TENANT_NOT_DELETED"
+	reasonTenantPurged         = "TENANT_PURGED"
+	reasonPurgeTokenInvalid    = "PURGE_TOKEN_INVALID"
+	reasonEliminationTenant    = "ELIMINATION_TENANT"
+	reasonEventHistory         = "EVENT_HISTORY_INCOMPLETE"
+	reasonSequenceMovedBack    = "SEQUENCE_MOVED_BACK"
+)
+
+// preconditionReasons maps the repository's precondition errors to reasons
+var preconditionReasons = []struct {
+	err    error
+	reason string
+}{
+	{repository.ErrDeletedAccount, reasonDeletedAccount},
+	{repository.ErrNonZeroBalance, reasonNonZeroBalance},
+	{repository.ErrAccountHasChildren, reasonAccountHasChildren},
+	{repository.ErrAccountCycle, reasonAccountCycle},
+	{repository.ErrAccountTypeMismatch, reasonAccountTypeMismatch},
+	{repository.ErrCurrencyMismatch, reasonCurrencyMismatch},
+	{repository.ErrBookMismatch, reasonBookMismatch},
+	{repository.ErrAlreadyReconciled, reasonAlreadyReconciled},
+	{repository.ErrAccountMismatch, reasonAccountMismatch},
+	{repository.ErrApplicationMismatch, reasonApplicationMismatch},
+	{repository.ErrOverApplication, reasonOverApplication},
+	{repository.ErrDepreciationPosted, reasonDepreciationPosted},
+	{repository.ErrInterestAccrued, reasonInterestAccrued},
+	{repository.ErrHoldNotPending, reasonHoldNotPending},
+	{repository.ErrHoldExpired, reasonHoldExpired},
+	{repository.ErrCaptureExceedsHold, reasonCaptureExceedsHold},
+	{repository.ErrPreparedEntryNotPending, reasonPreparedNotPending},
+	{repository.ErrPreparedEntryExpired, reasonPreparedExpired},
+	{repository.ErrBatchNotOpen, reasonBatchNotOpen},
+	{repository.ErrBatchNotApproved, reasonBatchNotApproved},
+	{repository.ErrBatchPosted, reasonBatchPosted},
+	{repository.ErrBatchEmpty, reasonBatchEmpty},
+	{repository.ErrInsufficientFunds, reasonInsufficientFunds},
+	{repository.ErrUnbalancedEntry, reasonUnbalancedEntry},
+	{repository.ErrFxAccountNotConfigured, reasonFxAccountMissing},
+	{repository.ErrTenantNotDeleted, reasonTenantNotDeleted},
+	{repository.ErrTenantPurged, reasonTenantPurged},
+	{repository.ErrPurgeTokenInvalid, reasonPurgeTokenInvalid},
+	{repository.ErrEliminationTenant, reasonEliminationTenant},
+	{repository.ErrEventHistoryIncomplete, reasonEventHistory},
+	{repository.ErrSequenceMovedBack, reasonSequenceMovedBack},
+	{repository.ErrPeriodLocked, reasonPeriodLocked},
+	{repository.ErrFutureDate, reasonFutureDate},
+	{repository.ErrBackdateLimit, reasonBackdateLimit},
+}
+
+// errorInfo builds the ErrorInfo detail for a reason
+func errorInfo(reason string, metadata map[string]string) *errdetails.ErrorInfo {
+	return &errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   errorDomain,
+		Metadata: metadata,
+	}
+}
+
+// detailedError returns a status error carrying the given details. The
+// message stays readable for clients that ignore details.
+func detailedError(code codes.Code, message string, details ...protoadapt.MessageV1) error {
+	st := status.New(code, message)
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// invalidField rejects a request field with a BadRequest field violation
+func invalidField(field, description string) error {
+	return badRequest(description, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: description,
+	})
+}
+
+// badRequest rejects a request with one violation per invalid field
+func badRequest(message string, violations ...*errdetails.BadRequest_FieldViolation) error {
+	return detailedError(codes.InvalidArgument, message,
+		errorInfo(reasonInvalidField, nil),
+		&errdetails.BadRequest{FieldViolations: violations},
+	)
+}
+
+// lineViolations rejects journal entry lines, listing every violation in
+// the message and as BadRequest field violations
+func lineViolations(violations []*errdetails.BadRequest_FieldViolation) error {
+	descriptions := make([]string, len(violations))
+	for i, v := range violations {
+		descriptions[i] = v.Field + ": " + v.Description
+	}
+	return badRequest("invalid journal entry lines: "+strings.Join(descriptions, "; "), violations...)
+}
+
+// invalidLine rejects a single field of a request line
+func invalidLine(line int, field, format string, args ...interface{}) error {
+	return badRequest(fmt.Sprintf(format, args...), lineViolation(line, field, format, args...))
+}
+
+func lineViolation(line int, field, format string, args ...interface{}) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{
+		Field:       fmt.Sprintf("lines[%d].%s", line, field),
+		Description: fmt.Sprintf(format, args...),
+	}
+}
+
+// failedPrecondition reports a violated business rule with a
+// PreconditionFailure detail naming the rule and its subject
+func failedPrecondition(reason, subject, description string, metadata map[string]string) error {
+	return detailedError(codes.FailedPrecondition, description,
+		errorInfo(reason, metadata),
+		&errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        reason,
+				Subject:     subject,
+				Description: description,
+			}},
+		},
+	)
+}
+
+// repositoryError maps a repository error to a status error: missing rows
+// to NotFound, broken business rules to FailedPrecondition, duplicates to
+// AlreadyExists, row-level security and privilege failures to
+// PermissionDenied, canceled calls to Canceled, timed out statements to
+// DeadlineExceeded, an unavailable database to Unavailable, invalid entry
+// lines to InvalidArgument, and anything else to Internal
+func repositoryError(action string, err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return detailedError(codes.NotFound, err.Error(), errorInfo(reasonNotFound, nil))
+	}
+
+	for _, p := range preconditionReasons {
+		if errors.Is(err, p.err) {
+			return failedPrecondition(p.reason, "", err.Error(), nil)
+		}
+	}
+
+	switch {
+	case repository.IsPermissionDenied(err):
+		return detailedError(codes.PermissionDenied, fmt.Sprintf("failed to %s: permission denied", action),
+			errorInfo(reasonPermissionDenied, nil))
+	case repository.IsUniqueViolation(err):
+		return detailedError(codes.AlreadyExists, fmt.Sprintf("failed to %s: already exists", action),
+			errorInfo(reasonAlreadyExists, nil))
+	case repository.IsForeignKeyViolation(err):
+		return failedPrecondition(reasonReferenceNotFound, "", fmt.Sprintf("failed to %s: a referenced record does not exist", action), nil)
+	case errors.Is(err, context.Canceled):
+		return status.Errorf(codes.Canceled, "failed to %s: canceled", action)
+	case errors.Is(err, context.DeadlineExceeded), repository.IsQueryCanceled(err):
+		return status.Errorf(codes.DeadlineExceeded, "failed to %s: timed out", action)
+	case errors.Is(err, repository.ErrInvalidEntryLines):
+		return badRequest(err.Error())
+	case errors.Is(err, repository.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, db.ErrCircuitOpen):
+		return status.Errorf(codes.Unavailable, "failed to %s: %v", action, err)
+	}
+
+	return redact.Internal("failed to "+action, err)
+}
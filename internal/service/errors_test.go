@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/db"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/internal/validation"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorReason returns the reason of the ErrorInfo detail of an error
+func errorReason(t *testing.T, err error) string {
+	t.Helper()
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			assert.Equal(t, errorDomain, info.Domain)
+			return info.Reason
+		}
+	}
+	t.Fatal("no ErrorInfo detail")
+	return ""
+}
+
+// preconditionViolations returns the violations of the PreconditionFailure
+// detail of an error
+func preconditionViolations(t *testing.T, err error) []*errdetails.PreconditionFailure_Violation {
+	t.Helper()
+	for _, detail := range status.Convert(err).Details() {
+		if failure, ok := detail.(*errdetails.PreconditionFailure); ok {
+			return failure.Violations
+		}
+	}
+	t.Fatal("no PreconditionFailure detail")
+	return nil
+}
+
+// fieldViolations returns the violations of the BadRequest detail of an error
+func fieldViolations(t *testing.T, err error) []*errdetails.BadRequest_FieldViolation {
+	t.Helper()
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			return badRequest.FieldViolations
+		}
+	}
+	t.Fatal("no BadRequest detail")
+	return nil
+}
+
+// validated calls a handler the way the server does, behind the validation
+// interceptor, so requests breaking their rules never reach it
+func validated[Req, Resp any](ctx context.Context, req Req, handler func(context.Context, Req) (Resp, error)) (Resp, error) {
+	resp, err := validation.UnaryServerInterceptor()(ctx, req, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return handler(ctx, req.(Req))
+	})
+	if err != nil {
+		var none Resp
+		return none, err
+	}
+	return resp.(Resp), nil
+}
+
+func TestRepositoryError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{
+			name:   "maps missing rows to not found",
+			err:    fmt.Errorf("account %w", repository.ErrNotFound),
+			code:   codes.NotFound,
+			reason: reasonNotFound,
+		},
+		{
+			name:   "maps broken business rules to failed precondition",
+			err:    repository.ErrNonZeroBalance,
+			code:   codes.FailedPrecondition,
+			reason: reasonNonZeroBalance,
+		},
+		{
+			name:   "maps unbalanced entries to failed precondition",
+			err:    fmt.Errorf("%w: debits 100, credits 90", repository.ErrUnbalancedEntry),
+			code:   codes.FailedPrecondition,
+			reason: reasonUnbalancedEntry,
+		},
+		{
+			name:   "maps invalid entry lines to invalid argument",
+			err:    fmt.Errorf("%w: at least two lines are required", repository.ErrInvalidEntryLines),
+			code:   codes.InvalidArgument,
+			reason: reasonInvalidField,
+		},
+		{
+			name:   "maps insufficient privileges to permission denied",
+			err:    fmt.Errorf("failed to create account: %w", &pgconn.PgError{Code: "42501"}),
+			code:   codes.PermissionDenied,
+			reason: reasonPermissionDenied,
+		},
+		{
+			name:   "maps unique violations to already exists",
+			err:    &pgconn.PgError{Code: "23505"},
+			code:   codes.AlreadyExists,
+			reason: reasonAlreadyExists,
+		},
+		{
+			name:   "maps foreign key violations to failed precondition",
+			err:    &pgconn.PgError{Code: "23503"},
+			code:   codes.FailedPrecondition,
+			reason: reasonReferenceNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repositoryError("create account", tt.err)
+
+			assert.Equal(t, tt.code, status.Code(err))
+			assert.Equal(t, tt.reason, errorReason(t, err))
+		})
+	}
+
+	t.Run("maps timed out and canceled calls", func(t *testing.T) {
+		for _, tt := range []struct {
+			err  error
+			code codes.Code
+		}{
+			{fmt.Errorf("failed to list accounts: %w", &pgconn.PgError{Code: "57014"}), codes.DeadlineExceeded},
+			{fmt.Errorf("unable to acquire connection: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+			{fmt.Errorf("failed to list accounts: %w", context.Canceled), codes.Canceled},
+		} {
+			assert.Equal(t, tt.code, status.Code(repositoryError("list accounts", tt.err)))
+		}
+	})
+
+	t.Run("maps an open circuit breaker to unavailable", func(t *testing.T) {
+		err := repositoryError("list accounts", db.ErrCircuitOpen)
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("maps anything else to internal", func(t *testing.T) {
+		err := repositoryError("create account", errors.New("connection reset"))
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Equal(t, "failed to create account: connection reset", status.Convert(err).Message())
+	})
+
+	t.Run("redacts data from internal errors", func(t *testing.T) {
+		err := repositoryError("create journal entry", fmt.Errorf("failed to insert entry: %w", &pgconn.PgError{
+			Severity: "ERROR",
+			Code:     "22P02",
+			Message:  `invalid input syntax for type json: "Jane Doe"`,
+		}))
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Equal(t, "failed to create journal entry: failed to insert entry: database error (SQLSTATE 22P02)", status.Convert(err).Message())
+	})
+}
+
+func TestCheckBalanced(t *testing.T) {
+	t.Run("accepts balanced lines", func(t *testing.T) {
+		err := checkBalanced([]*repository.CreateJournalEntryLineParams{
+			{AccountID: uuid.New(), Debit: decimal.NewFromInt(100)},
+			{AccountID: uuid.New(), Credit: decimal.NewFromInt(100)},
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("reports the totals of unbalanced lines", func(t *testing.T) {
+		err := checkBalanced([]*repository.CreateJournalEntryLineParams{
+			{AccountID: uuid.New(), Debit: decimal.NewFromInt(100)},
+			{AccountID: uuid.New(), Credit: decimal.NewFromInt(90)},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		var info *errdetails.ErrorInfo
+		for _, detail := range status.Convert(err).Details() {
+			if d, ok := detail.(*errdetails.ErrorInfo); ok {
+				info = d
+			}
+		}
+		require.NotNil(t, info)
+		assert.Equal(t, reasonUnbalancedEntry, info.Reason)
+		assert.Equal(t, "100", info.Metadata["total_debit"])
+		assert.Equal(t, "90", info.Metadata["total_credit"])
+	})
+}
+
+func TestInvalidLine(t *testing.T) {
+	err := invalidLine(2, "debit", "invalid debit amount at line %d", 2)
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, reasonInvalidField, errorReason(t, err))
+	violations := fieldViolations(t, err)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "lines[2].debit", violations[0].Field)
+	assert.Equal(t, "invalid debit amount at line 2", violations[0].Description)
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/eventsig"
+	"github.com/hesabFun/ledger/internal/jsonschema"
+	"github.com/hesabFun/ledger/internal/projection"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// ListLedgerEvents retrieves events from the event store after a sequence
+// number, for consumers that build their own read models
+func (s *LedgerService) ListLedgerEvents(ctx context.Context, req *pb.ListLedgerEventsRequest) (*pb.ListLedgerEventsResponse, error) {
+	if s.eventRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "the event store is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AfterSequence < 0 {
+		return nil, status.Error(codes.InvalidArgument, "after sequence must not be negative")
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	events, err := s.eventRepo.List(ctx, tenantID, req.AfterSequence, pageSize)
+	if err != nil {
+		return nil, repositoryError("list ledger events", err)
+	}
+
+	resp := &pb.ListLedgerEventsResponse{
+		Events:       make([]*pb.LedgerEvent, len(events)),
+		LastSequence: req.AfterSequence,
+	}
+	for i, event := range events {
+		resp.Events[i] = s.ledgerEventToProto(event)
+		resp.LastSequence = event.Sequence
+	}
+
+	return resp, nil
+}
+
+// GetEventSchema returns the JSON Schema of event payloads, so consumers can
+// validate events against the schema version they were recorded with
+func (s *LedgerService) GetEventSchema(ctx context.Context, req *pb.GetEventSchemaRequest) (*pb.GetEventSchemaResponse, error) {
+	if req.EventType == "" && req.Version != nil {
+		return nil, invalidField("version", "version requires an event type")
+	}
+
+	schemas := repository.EventSchemas(req.EventType)
+	if req.EventType == "" {
+		schemas = latestEventSchemas(schemas)
+	}
+	if req.Version != nil {
+		schemas = slices.DeleteFunc(schemas, func(schema repository.EventSchema) bool {
+			return schema.Version != *req.Version
+		})
+	}
+	if len(schemas) == 0 {
+		return nil, status.Error(codes.NotFound, "event schema not found")
+	}
+
+	resp := &pb.GetEventSchemaResponse{
+		Schemas: make([]*pb.EventSchema, len(schemas)),
+	}
+	for i, schema := range schemas {
+		pbSchema, err := eventSchemaToProto(schema)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to describe %s events: %v", schema.EventType, err)
+		}
+		resp.Schemas[i] = pbSchema
+	}
+
+	return resp, nil
+}
+
+// latestEventSchemas keeps the latest version of each event type, in the
+// order the types were registered
+func latestEventSchemas(schemas []repository.EventSchema) []repository.EventSchema {
+	var latest []repository.EventSchema
+	index := make(map[string]int)
+	for _, schema := range schemas {
+		i, ok := index[schema.EventType]
+		if !ok {
+			index[schema.EventType] = len(latest)
+			latest = append(latest, schema)
+			continue
+		}
+		if schema.Version > latest[i].Version {
+			latest[i] = schema
+		}
+	}
+	return latest
+}
+
+func eventSchemaToProto(schema repository.EventSchema) (*pb.EventSchema, error) {
+	document, err := jsonschema.For(schema.Payload)
+	if err != nil {
+		return nil, err
+	}
+	document.ID = fmt.Sprintf("urn:hesabfun:ledger:event:%s:v%d", schema.EventType, schema.Version)
+	document.Title = schema.EventType
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.EventSchema{
+		EventType:     schema.EventType,
+		AggregateType: schema.AggregateType,
+		Version:       schema.Version,
+		JsonSchema:    string(data),
+	}, nil
+}
+
+// entityAggregateTypes maps entity types to the aggregate type of their events
+var entityAggregateTypes = map[pb.EntityType]string{
+	pb.EntityType_ENTITY_TYPE_ACCOUNT:       repository.AggregateAccount,
+	pb.EntityType_ENTITY_TYPE_TENANT:        repository.AggregateTenant,
+	pb.EntityType_ENTITY_TYPE_JOURNAL_ENTRY: repository.AggregateJournalEntry,
+}
+
+// GetEntityHistory retrieves the field-level changes of an account, journal
+// entry or the tenant itself, oldest first, by replaying the entity's events
+func (s *LedgerService) GetEntityHistory(ctx context.Context, req *pb.GetEntityHistoryRequest) (*pb.GetEntityHistoryResponse, error) {
+	if s.eventRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "the event store is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregateType, ok := entityAggregateTypes[req.EntityType]
+	if !ok {
+		return nil, invalidField("entity_type", "entity type is required")
+	}
+
+	entityID := tenantID
+	if req.EntityType != pb.EntityType_ENTITY_TYPE_TENANT {
+		entityID, err = requestID("entity_id", req.EntityId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	history := projection.NewHistory()
+	events := 0
+	err = s.eventRepo.ReplayAggregate(ctx, tenantID, aggregateType, entityID, func(event *repository.LedgerEvent) error {
+		events++
+		return history.Apply(event)
+	})
+	if err != nil {
+		return nil, repositoryError("replay entity events", err)
+	}
+	if events == 0 {
+		return nil, status.Error(codes.NotFound, "no history recorded for the entity")
+	}
+
+	changes := history.Changes()
+	resp := &pb.GetEntityHistoryResponse{
+		Changes: make([]*pb.EntityChange, len(changes)),
+	}
+	for i, change := range changes {
+		resp.Changes[i] = entityChangeToProto(change)
+	}
+
+	return resp, nil
+}
+
+// getAccountBalanceAsOf rebuilds an account balance by replaying the events
+// recorded up to postedAsOf, counting only entries effective on or before
+// effectiveAsOf. Either bound may be nil.
+func (s *LedgerService) getAccountBalanceAsOf(ctx context.Context, tenantID, accountID uuid.UUID, postedAsOf, effectiveAsOf *time.Time) (*pb.GetAccountBalanceResponse, error) {
+	if s.eventRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "the event store is not enabled")
+	}
+
+	balances := projection.NewBalances()
+	if effectiveAsOf != nil {
+		balances = projection.NewBalancesEffectiveAsOf(*effectiveAsOf)
+	}
+	if err := s.eventRepo.Replay(ctx, tenantID, postedAsOf, balances.Apply); err != nil {
+		return nil, repositoryError("replay ledger events", err)
+	}
+
+	balance := balances.Get(accountID)
+
+	asOf := time.Now()
+	if postedAsOf != nil {
+		asOf = *postedAsOf
+	}
+
+	return &pb.GetAccountBalanceResponse{
+		AccountId:     accountID.String(),
+		DebitBalance:  balance.Debit.String(),
+		CreditBalance: balance.Credit.String(),
+		NetBalance:    balance.Net().String(),
+		UpdatedAt:     timestamppb.New(asOf),
+	}, nil
+}
+
+func entityChangeToProto(change *projection.EntityChange) *pb.EntityChange {
+	pbChange := &pb.EntityChange{
+		Sequence:   change.Sequence,
+		EventType:  change.EventType,
+		RecordedAt: timestamppb.New(change.RecordedAt),
+		Fields:     make([]*pb.FieldChange, len(change.Fields)),
+	}
+
+	for i, field := range change.Fields {
+		pbChange.Fields[i] = &pb.FieldChange{Field: field.Field}
+		if field.OldValue != nil {
+			oldValue := string(field.OldValue)
+			pbChange.Fields[i].OldValue = &oldValue
+		}
+		if field.NewValue != nil {
+			newValue := string(field.NewValue)
+			pbChange.Fields[i].NewValue = &newValue
+		}
+	}
+
+	return pbChange
+}
+
+// ledgerEventToProto converts an event, signing it when a signer is set
+func (s *LedgerService) ledgerEventToProto(event *repository.LedgerEvent) *pb.LedgerEvent {
+	pbEvent := &pb.LedgerEvent{
+		Sequence:      event.Sequence,
+		AggregateType: event.AggregateType,
+		AggregateId:   event.AggregateID.String(),
+		EventType:     event.EventType,
+		Payload:       string(event.Payload),
+		RecordedAt:    timestamppb.New(event.RecordedAt),
+		SchemaVersion: event.SchemaVersion,
+	}
+
+	if s.eventSigner != nil {
+		pbEvent.Signature = s.eventSigner.Sign(eventsig.Event{
+			TenantID:      event.TenantID,
+			Sequence:      event.Sequence,
+			EventType:     event.EventType,
+			SchemaVersion: event.SchemaVersion,
+			Payload:       event.Payload,
+		})
+	}
+
+	return pbEvent
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/eventsig"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockEventRepository) List(ctx context.Context, tenantID uuid.UUID, afterSequence int64, limit int) ([]*repository.LedgerEvent, error) {
+	args := m.Called(ctx, tenantID, afterSequence, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.LedgerEvent), args.Error(1)
+}
+
+func (m *MockEventRepository) LastSequence(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, tenantID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// Replay calls fn for each event passed as the first return value
+func (m *MockEventRepository) Replay(ctx context.Context, tenantID uuid.UUID, until *time.Time, fn func(*repository.LedgerEvent) error) error {
+	args := m.Called(ctx, tenantID, until)
+	if events, ok := args.Get(0).([]*repository.LedgerEvent); ok {
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// ReplayAggregate calls fn for each event passed as the first return value
+func (m *MockEventRepository) ReplayAggregate(ctx context.Context, tenantID uuid.UUID, aggregateType string, aggregateID uuid.UUID, fn func(*repository.LedgerEvent) error) error {
+	args := m.Called(ctx, tenantID, aggregateType, aggregateID)
+	if events, ok := args.Get(0).([]*repository.LedgerEvent); ok {
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// ReplayWithBalances calls fn for each event passed as the first return
+// value and returns the balances passed as the second
+func (m *MockEventRepository) ReplayWithBalances(ctx context.Context, tenantID uuid.UUID, fn func(*repository.LedgerEvent) error) ([]*repository.AccountBalance, error) {
+	args := m.Called(ctx, tenantID)
+	if events, ok := args.Get(0).([]*repository.LedgerEvent); ok {
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return nil, err
+			}
+		}
+	}
+	balances, _ := args.Get(1).([]*repository.AccountBalance)
+	return balances, args.Error(2)
+}
+
+// Test ListLedgerEvents
+func TestLedgerService_ListLedgerEvents(t *testing.T) {
+	ctx := context.Background()
+	mockEventRepo := new(MockEventRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithEventRepository(mockEventRepo))
+
+	t.Run("returns events after a sequence with the last sequence", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+
+		mockEventRepo.On("List", ctx, tenantID, int64(10), 100).Return([]*repository.LedgerEvent{
+			{Sequence: 11, TenantID: tenantID, AggregateType: repository.AggregateAccount, AggregateID: accountID, EventType: repository.EventAccountDeleted, Payload: json.RawMessage(`{}`)},
+			{Sequence: 14, TenantID: tenantID, AggregateType: repository.AggregateAccount, AggregateID: accountID, EventType: repository.EventAccountRestored, Payload: json.RawMessage(`{}`)},
+		}, nil).Once()
+
+		resp, err := service.ListLedgerEvents(ctx, &pb.ListLedgerEventsRequest{
+			TenantId:      tenantID.String(),
+			AfterSequence: 10,
+			PageSize:      500,
+		})
+
+		require.NoError(t, err)
+		assert.Len(t, resp.Events, 2)
+		assert.Equal(t, repository.EventAccountDeleted, resp.Events[0].EventType)
+		assert.Equal(t, accountID.String(), resp.Events[0].AggregateId)
+		assert.Equal(t, int64(14), resp.LastSequence)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("signs events when a signer is set", func(t *testing.T) {
+		tenantID := uuid.New()
+		event := &repository.LedgerEvent{Sequence: 3, TenantID: tenantID, AggregateType: repository.AggregateTenant, AggregateID: tenantID,
+			EventType: repository.EventTenantStatusChanged, SchemaVersion: 1, Payload: json.RawMessage(`{"status": "SUSPENDED"}`)}
+		mockEventRepo.On("List", ctx, tenantID, int64(0), 50).Return([]*repository.LedgerEvent{event}, nil).Once()
+
+		service := NewLedgerService(nil, nil, nil, nil, WithEventRepository(mockEventRepo),
+			WithEventSigner(eventsig.NewSigner([]byte("secret"))))
+
+		resp, err := service.ListLedgerEvents(ctx, &pb.ListLedgerEventsRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Events, 1)
+		assert.Equal(t, int32(1), resp.Events[0].SchemaVersion)
+		assert.NoError(t, eventsig.Verify(resp.Events[0].Signature, eventsig.Event{
+			TenantID:      tenantID,
+			Sequence:      resp.Events[0].Sequence,
+			EventType:     resp.Events[0].EventType,
+			SchemaVersion: resp.Events[0].SchemaVersion,
+			Payload:       []byte(resp.Events[0].Payload),
+		}, []byte("secret")))
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns unimplemented when the event store is disabled", func(t *testing.T) {
+		resp, err := NewLedgerService(nil, nil, nil, nil).ListLedgerEvents(ctx, &pb.ListLedgerEventsRequest{
+			TenantId: uuid.New().String(),
+		})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test GetEventSchema
+func TestLedgerService_GetEventSchema(t *testing.T) {
+	ctx := context.Background()
+	service := NewLedgerService(nil, nil, nil, nil)
+
+	t.Run("returns the schema of an event type", func(t *testing.T) {
+		resp, err := service.GetEventSchema(ctx, &pb.GetEventSchemaRequest{
+			EventType: repository.EventTenantStatusChanged,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Schemas, 1)
+		assert.Equal(t, repository.AggregateTenant, resp.Schemas[0].AggregateType)
+		assert.Equal(t, int32(1), resp.Schemas[0].Version)
+		assert.JSONEq(t, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"$id": "urn:hesabfun:ledger:event:TenantStatusChanged:v1",
+			"title": "TenantStatusChanged",
+			"type": "object",
+			"properties": {"status": {"type": "string"}},
+			"required": ["status"]
+		}`, resp.Schemas[0].JsonSchema)
+	})
+
+	t.Run("lists the latest schema of every event type", func(t *testing.T) {
+		resp, err := service.GetEventSchema(ctx, &pb.GetEventSchemaRequest{})
+
+		require.NoError(t, err)
+		assert.Len(t, resp.Schemas, len(latestEventSchemas(repository.EventSchemas(""))))
+		for _, schema := range resp.Schemas {
+			assert.True(t, json.Valid([]byte(schema.JsonSchema)), schema.EventType)
+		}
+	})
+
+	t.Run("returns not found for unknown types and versions", func(t *testing.T) {
+		_, err := service.GetEventSchema(ctx, &pb.GetEventSchemaRequest{EventType: "Unknown"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		version := int32(99)
+		_, err = service.GetEventSchema(ctx, &pb.GetEventSchemaRequest{
+			EventType: repository.EventAccountCreated,
+			Version:   &version,
+		})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+// Test GetAccountBalance as of a point in time
+func TestLedgerService_GetAccountBalanceAsOf(t *testing.T) {
+	ctx := context.Background()
+	mockEventRepo := new(MockEventRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithEventRepository(mockEventRepo))
+
+	tenantID := uuid.New()
+	accountID := uuid.New()
+	asOf := time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC)
+
+	payload, err := json.Marshal(repository.JournalEntryPostedPayload{
+		ReferenceNumber: "REF001",
+		EntryDate:       "2026-03-01",
+		Lines: []repository.PostedLine{
+			{AccountID: accountID, Debit: decimal.NewFromInt(250)},
+			{AccountID: uuid.New(), Credit: decimal.NewFromInt(250)},
+		},
+	})
+	require.NoError(t, err)
+
+	mockEventRepo.On("Replay", ctx, tenantID, mock.MatchedBy(func(until *time.Time) bool {
+		return until != nil && until.Equal(asOf)
+	})).Return([]*repository.LedgerEvent{
+		{Sequence: 1, EventType: repository.EventJournalEntryPosted, Payload: payload},
+	}, nil).Once()
+
+	resp, err := service.GetAccountBalance(ctx, &pb.GetAccountBalanceRequest{
+		TenantId:  tenantID.String(),
+		AccountId: accountID.String(),
+		AsOf:      timestamppb.New(asOf),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "250", resp.DebitBalance)
+	assert.Equal(t, "250", resp.NetBalance)
+	assert.True(t, resp.UpdatedAt.AsTime().Equal(asOf))
+	mockEventRepo.AssertExpectations(t)
+}
+
+// Test GetAccountBalance effective as of a date
+func TestLedgerService_GetAccountBalanceEffectiveAsOf(t *testing.T) {
+	ctx := context.Background()
+	mockEventRepo := new(MockEventRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithEventRepository(mockEventRepo))
+
+	tenantID := uuid.New()
+	accountID := uuid.New()
+
+	posted := func(entryDate string, amount int64) json.RawMessage {
+		payload, err := json.Marshal(repository.JournalEntryPostedPayload{
+			ReferenceNumber: "REF-" + entryDate,
+			EntryDate:       entryDate,
+			Lines: []repository.PostedLine{
+				{AccountID: accountID, Debit: decimal.NewFromInt(amount)},
+				{AccountID: uuid.New(), Credit: decimal.NewFromInt(amount)},
+			},
+		})
+		require.NoError(t, err)
+		return payload
+	}
+
+	// Every posted event is replayed; entries effective after March are skipped
+	mockEventRepo.On("Replay", ctx, tenantID, (*time.Time)(nil)).Return([]*repository.LedgerEvent{
+		{Sequence: 1, EventType: repository.EventJournalEntryPosted, Payload: posted("2026-03-01", 250)},
+		{Sequence: 2, EventType: repository.EventJournalEntryPosted, Payload: posted("2026-04-02", 100)},
+		{Sequence: 3, EventType: repository.EventJournalEntryPosted, Payload: posted("2026-03-31", 25)},
+	}, nil).Once()
+
+	resp, err := service.GetAccountBalance(ctx, &pb.GetAccountBalanceRequest{
+		TenantId:      tenantID.String(),
+		AccountId:     accountID.String(),
+		EffectiveAsOf: timestamppb.New(time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "275", resp.DebitBalance)
+	mockEventRepo.AssertExpectations(t)
+}
+
+// Test GetEntityHistory
+func TestLedgerService_GetEntityHistory(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	t.Run("returns the field changes of an account", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		service := NewLedgerService(nil, nil, nil, nil, WithEventRepository(mockEventRepo))
+
+		accountID, parentID := uuid.New(), uuid.New()
+		mockEventRepo.On("ReplayAggregate", ctx, tenantID, repository.AggregateAccount, accountID).Return([]*repository.LedgerEvent{
+			{Sequence: 3, EventType: repository.EventAccountCreated, Payload: json.RawMessage(`{"name": "Cash"}`)},
+			{Sequence: 9, EventType: repository.EventAccountMoved, Payload: json.RawMessage(`{"parent_account_id": "` + parentID.String() + `"}`)},
+		}, nil).Once()
+
+		resp, err := service.GetEntityHistory(ctx, &pb.GetEntityHistoryRequest{
+			TenantId:   tenantID.String(),
+			EntityType: pb.EntityType_ENTITY_TYPE_ACCOUNT,
+			EntityId:   accountID.String(),
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Changes, 2)
+		assert.Equal(t, int64(9), resp.Changes[1].Sequence)
+		require.Len(t, resp.Changes[1].Fields, 1)
+		assert.Equal(t, "parent_account_id", resp.Changes[1].Fields[0].Field)
+		assert.Nil(t, resp.Changes[1].Fields[0].OldValue)
+		assert.Equal(t, `"`+parentID.String()+`"`, resp.Changes[1].Fields[0].GetNewValue())
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("uses the tenant as the entity of tenant history", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		service := NewLedgerService(nil, nil, nil, nil, WithEventRepository(mockEventRepo))
+
+		mockEventRepo.On("ReplayAggregate", ctx, tenantID, repository.AggregateTenant, tenantID).Return([]*repository.LedgerEvent{
+			{Sequence: 1, EventType: repository.EventTenantSettingsUpdated, Payload: json.RawMessage(`{"timezone": "UTC"}`)},
+			{Sequence: 2, EventType: repository.EventTenantSettingsUpdated, Payload: json.RawMessage(`{"timezone": "Asia/Tehran"}`)},
+		}, nil).Once()
+
+		resp, err := service.GetEntityHistory(ctx, &pb.GetEntityHistoryRequest{
+			TenantId:   tenantID.String(),
+			EntityType: pb.EntityType_ENTITY_TYPE_TENANT,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Changes, 2)
+		assert.Equal(t, `"UTC"`, resp.Changes[1].Fields[0].GetOldValue())
+		assert.Equal(t, `"Asia/Tehran"`, resp.Changes[1].Fields[0].GetNewValue())
+	})
+
+	t.Run("returns not found for an entity without events", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		service := NewLedgerService(nil, nil, nil, nil, WithEventRepository(mockEventRepo))
+
+		entryID := uuid.New()
+		mockEventRepo.On("ReplayAggregate", ctx, tenantID, repository.AggregateJournalEntry, entryID).Return(nil, nil).Once()
+
+		_, err := service.GetEntityHistory(ctx, &pb.GetEntityHistoryRequest{
+			TenantId:   tenantID.String(),
+			EntityType: pb.EntityType_ENTITY_TYPE_JOURNAL_ENTRY,
+			EntityId:   entryID.String(),
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("requires an entity type", func(t *testing.T) {
+		service := NewLedgerService(nil, nil, nil, nil, WithEventRepository(new(MockEventRepository)))
+
+		_, err := service.GetEntityHistory(ctx, &pb.GetEntityHistoryRequest{
+			TenantId: tenantID.String(),
+			EntityId: uuid.New().String(),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestLatestEventSchemas(t *testing.T) {
+	latest := latestEventSchemas([]repository.EventSchema{
+		{EventType: "A", Version: 1},
+		{EventType: "B", Version: 1},
+		{EventType: "A", Version: 2},
+	})
+
+	assert.Equal(t, []repository.EventSchema{
+		{EventType: "A", Version: 2},
+		{EventType: "B", Version: 1},
+	}, latest)
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/export"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// exportChunkSize is the size of the chunks DownloadExportFile streams
+const exportChunkSize = 64 << 10
+
+// ExportLedgerData starts a background job that writes the tenant's accounts,
+// journal entries and lines to CSV, Parquet or JSON Lines files
+func (s *LedgerService) ExportLedgerData(ctx context.Context, req *pb.ExportLedgerDataRequest) (*pb.ExportLedgerDataResponse, error) {
+	if s.exporter == nil {
+		return nil, status.Error(codes.Unimplemented, "data exports are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	var format export.Format
+	switch req.Format {
+	case pb.ExportFormat_EXPORT_FORMAT_CSV:
+		format = export.FormatCSV
+	case pb.ExportFormat_EXPORT_FORMAT_PARQUET:
+		format = export.FormatParquet
+	case pb.ExportFormat_EXPORT_FORMAT_JSONL:
+		format = export.FormatJSONL
+	default:
+		return nil, status.Error(codes.InvalidArgument, "export format must be CSV, PARQUET or JSONL")
+	}
+
+	datasets := export.AllDatasets
+	if len(req.Datasets) > 0 {
+		datasets = make([]export.Dataset, 0, len(req.Datasets))
+		for _, d := range req.Datasets {
+			dataset, ok := exportDatasetFromProto(d)
+			if !ok {
+				return nil, status.Error(codes.InvalidArgument, "invalid export dataset")
+			}
+			if !slices.Contains(datasets, dataset) {
+				datasets = append(datasets, dataset)
+			}
+		}
+	}
+
+	job, err := s.exporter.Start(ctx, tenantID, format, datasets)
+	if err != nil {
+		return nil, repositoryError("start export", err)
+	}
+
+	return &pb.ExportLedgerDataResponse{
+		Job: exportJobToProto(job),
+	}, nil
+}
+
+// GetExportJob returns the status of an export job
+func (s *LedgerService) GetExportJob(ctx context.Context, req *pb.GetExportJobRequest) (*pb.GetExportJobResponse, error) {
+	if s.exporter == nil {
+		return nil, status.Error(codes.Unimplemented, "data exports are not enabled")
+	}
+
+	tenantID, jobID, err := parseExportJobIDs(req.TenantId, req.JobId)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.exporter.Get(ctx, tenantID, jobID)
+	if err != nil {
+		return nil, repositoryError("get export job", err)
+	}
+
+	return &pb.GetExportJobResponse{
+		Job: exportJobToProto(job),
+	}, nil
+}
+
+// DownloadExportFile streams one file of a completed export job
+func (s *LedgerService) DownloadExportFile(req *pb.DownloadExportFileRequest, stream pb.LedgerService_DownloadExportFileServer) error {
+	if s.exporter == nil {
+		return status.Error(codes.Unimplemented, "data exports are not enabled")
+	}
+
+	ctx := stream.Context()
+
+	tenantID, jobID, err := parseExportJobIDs(req.TenantId, req.JobId)
+	if err != nil {
+		return err
+	}
+
+	job, err := s.exporter.Get(ctx, tenantID, jobID)
+	if err != nil {
+		return repositoryError("get export job", err)
+	}
+
+	if job.Status != repository.ExportJobCompleted {
+		return status.Error(codes.FailedPrecondition, "export job is not completed")
+	}
+
+	// Only files recorded on the job can be downloaded
+	if !slices.Contains(job.Files, req.File) {
+		return status.Error(codes.NotFound, "export file not found")
+	}
+
+	return sendExportFile(ctx, s.exporter, req.File, func(chunk []byte) error {
+		return stream.Send(&pb.DownloadExportFileResponse{Chunk: chunk})
+	})
+}
+
+// fileOpener opens files written to the export store
+type fileOpener interface {
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// sendExportFile sends a file of the export store in chunks of exportChunkSize
+func sendExportFile(ctx context.Context, store fileOpener, key string, send func([]byte) error) error {
+	f, err := store.Open(ctx, key)
+	if err != nil {
+		return repositoryError("open export file", err)
+	}
+	defer f.Close()
+
+	buf := make([]byte, exportChunkSize)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if sendErr := send(buf[:n]); sendErr != nil {
+				return sendErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return repositoryError("read export file", err)
+		}
+	}
+}
+
+func parseExportJobIDs(tenantIDStr, jobIDStr string) (uuid.UUID, uuid.UUID, error) {
+	tenantID, err := requestID("tenant_id", tenantIDStr)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	jobID, err := requestID("export_job_id", jobIDStr)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return tenantID, jobID, nil
+}
+
+func exportDatasetFromProto(d pb.ExportDataset) (export.Dataset, bool) {
+	switch d {
+	case pb.ExportDataset_EXPORT_DATASET_ACCOUNTS:
+		return export.DatasetAccounts, true
+	case pb.ExportDataset_EXPORT_DATASET_JOURNAL_ENTRIES:
+		return export.DatasetJournalEntries, true
+	case pb.ExportDataset_EXPORT_DATASET_JOURNAL_LINES:
+		return export.DatasetJournalLines, true
+	}
+	return "", false
+}
+
+func exportJobToProto(job *repository.ExportJob) *pb.ExportJob {
+	pbJob := &pb.ExportJob{
+		JobId:     job.ID.String(),
+		TenantId:  job.TenantID.String(),
+		Files:     job.Files,
+		Error:     job.Error,
+		Datasets:  make([]pb.ExportDataset, 0, len(job.Datasets)),
+		CreatedAt: timestamppb.New(job.CreatedAt),
+		Archive:   job.Archive,
+	}
+
+	switch export.Format(job.Format) {
+	case export.FormatCSV:
+		pbJob.Format = pb.ExportFormat_EXPORT_FORMAT_CSV
+	case export.FormatParquet:
+		pbJob.Format = pb.ExportFormat_EXPORT_FORMAT_PARQUET
+	case export.FormatJSONL:
+		pbJob.Format = pb.ExportFormat_EXPORT_FORMAT_JSONL
+	}
+
+	for _, d := range job.Datasets {
+		switch export.Dataset(d) {
+		case export.DatasetAccounts:
+			pbJob.Datasets = append(pbJob.Datasets, pb.ExportDataset_EXPORT_DATASET_ACCOUNTS)
+		case export.DatasetJournalEntries:
+			pbJob.Datasets = append(pbJob.Datasets, pb.ExportDataset_EXPORT_DATASET_JOURNAL_ENTRIES)
+		case export.DatasetJournalLines:
+			pbJob.Datasets = append(pbJob.Datasets, pb.ExportDataset_EXPORT_DATASET_JOURNAL_LINES)
+		case export.DatasetBalances:
+			pbJob.Datasets = append(pbJob.Datasets, pb.ExportDataset_EXPORT_DATASET_BALANCES)
+		case export.DatasetSettings:
+			pbJob.Datasets = append(pbJob.Datasets, pb.ExportDataset_EXPORT_DATASET_SETTINGS)
+		}
+	}
+
+	switch job.Status {
+	case repository.ExportJobPending:
+		pbJob.Status = pb.ExportJobStatus_EXPORT_JOB_STATUS_PENDING
+	case repository.ExportJobRunning:
+		pbJob.Status = pb.ExportJobStatus_EXPORT_JOB_STATUS_RUNNING
+	case repository.ExportJobCompleted:
+		pbJob.Status = pb.ExportJobStatus_EXPORT_JOB_STATUS_COMPLETED
+	case repository.ExportJobFailed:
+		pbJob.Status = pb.ExportJobStatus_EXPORT_JOB_STATUS_FAILED
+	}
+
+	if job.CompletedAt != nil {
+		pbJob.CompletedAt = timestamppb.New(*job.CompletedAt)
+	}
+
+	return pbJob
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/export"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockExportJobRepository struct {
+	mock.Mock
+}
+
+func (m *MockExportJobRepository) Create(ctx context.Context, tenantID uuid.UUID, format string, datasets []string, archive bool) (*repository.ExportJob, error) {
+	args := m.Called(ctx, tenantID, format, datasets, archive)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ExportJob), args.Error(1)
+}
+
+func (m *MockExportJobRepository) GetByID(ctx context.Context, tenantID uuid.UUID, jobID uuid.UUID) (*repository.ExportJob, error) {
+	args := m.Called(ctx, tenantID, jobID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ExportJob), args.Error(1)
+}
+
+func (m *MockExportJobRepository) UpdateStatus(ctx context.Context, tenantID uuid.UUID, jobID uuid.UUID, status string, files []string, errMsg *string) error {
+	args := m.Called(ctx, tenantID, jobID, status, files, errMsg)
+	return args.Error(0)
+}
+
+// fakeDownloadStream collects the chunks sent by DownloadExportFile
+type fakeDownloadStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	data []byte
+}
+
+func (f *fakeDownloadStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeDownloadStream) Send(resp *pb.DownloadExportFileResponse) error {
+	f.data = append(f.data, resp.Chunk...)
+	return nil
+}
+
+// Test ExportLedgerData
+func TestLedgerService_ExportLedgerData(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns unimplemented when exports are disabled", func(t *testing.T) {
+		service := NewLedgerService(nil, nil, nil, nil)
+
+		resp, err := service.ExportLedgerData(ctx, &pb.ExportLedgerDataRequest{
+			TenantId: uuid.New().String(),
+			Format:   pb.ExportFormat_EXPORT_FORMAT_CSV,
+		})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns error for unspecified format", func(t *testing.T) {
+		exporter := export.NewExporter(nil, nil, new(MockExportJobRepository), nil, export.NewDirStore(t.TempDir()))
+		service := NewLedgerService(nil, nil, nil, nil, WithExporter(exporter))
+
+		resp, err := service.ExportLedgerData(ctx, &pb.ExportLedgerDataRequest{
+			TenantId: uuid.New().String(),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test DownloadExportFile
+func TestLedgerService_DownloadExportFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	mockJobRepo := new(MockExportJobRepository)
+	exporter := export.NewExporter(nil, nil, mockJobRepo, nil, export.NewDirStore(dir))
+	service := NewLedgerService(nil, nil, nil, nil, WithExporter(exporter))
+
+	tenantID, jobID := uuid.New(), uuid.New()
+	key := tenantID.String() + "/" + jobID.String() + "/accounts.csv"
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, tenantID.String(), jobID.String()), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.FromSlash(key)), []byte("account_id\n"), 0o600))
+
+	t.Run("streams a file of a completed job", func(t *testing.T) {
+		mockJobRepo.On("GetByID", ctx, tenantID, jobID).Return(&repository.ExportJob{
+			ID:       jobID,
+			TenantID: tenantID,
+			Status:   repository.ExportJobCompleted,
+			Files:    []string{key},
+		}, nil).Once()
+
+		stream := &fakeDownloadStream{ctx: ctx}
+		err := service.DownloadExportFile(&pb.DownloadExportFileRequest{
+			TenantId: tenantID.String(),
+			JobId:    jobID.String(),
+			File:     key,
+		}, stream)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "account_id\n", string(stream.data))
+		mockJobRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects files that do not belong to the job", func(t *testing.T) {
+		mockJobRepo.On("GetByID", ctx, tenantID, jobID).Return(&repository.ExportJob{
+			ID:       jobID,
+			TenantID: tenantID,
+			Status:   repository.ExportJobCompleted,
+			Files:    []string{key},
+		}, nil).Once()
+
+		err := service.DownloadExportFile(&pb.DownloadExportFileRequest{
+			TenantId: tenantID.String(),
+			JobId:    jobID.String(),
+			File:     uuid.New().String() + "/other/accounts.csv",
+		}, &fakeDownloadStream{ctx: ctx})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		mockJobRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns failed precondition while the job is running", func(t *testing.T) {
+		mockJobRepo.On("GetByID", ctx, tenantID, jobID).Return(&repository.ExportJob{
+			ID:       jobID,
+			TenantID: tenantID,
+			Status:   repository.ExportJobRunning,
+		}, nil).Once()
+
+		err := service.DownloadExportFile(&pb.DownloadExportFileRequest{
+			TenantId: tenantID.String(),
+			JobId:    jobID.String(),
+			File:     key,
+		}, &fakeDownloadStream{ctx: ctx})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockJobRepo.AssertExpectations(t)
+	})
+}
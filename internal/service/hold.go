@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// defaultHoldExpiry is how long a hold stays pending when no expiry is given
+const defaultHoldExpiry = 7 * 24 * time.Hour
+
+// CreateHold reserves an amount of an account for a later capture into the
+// destination account. The hold reduces the available balance of the account
+// but posts nothing until it is captured.
+func (s *LedgerService) CreateHold(ctx context.Context, req *pb.CreateHoldRequest) (*pb.CreateHoldResponse, error) {
+	if s.holdRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "holds are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	destinationID, err := requestID("destination_account_id", req.DestinationAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if accountID == destinationID {
+		return nil, invalidField("destination_account_id", "destination account must differ from the held account")
+	}
+
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil || !amount.IsPositive() {
+		return nil, invalidField("amount", "amount must be a positive number")
+	}
+
+	expiresAt := time.Now().Add(defaultHoldExpiry)
+	if req.ExpiresAt != nil {
+		expiresAt = req.ExpiresAt.AsTime()
+		if !expiresAt.After(time.Now()) {
+			return nil, invalidField("expires_at", "expiry must be in the future")
+		}
+	}
+
+	for _, field := range []struct {
+		name string
+		id   uuid.UUID
+	}{
+		{"account_id", accountID},
+		{"destination_account_id", destinationID},
+	} {
+		account, err := s.accountRepo.GetByID(ctx, tenantID, field.id)
+		if err != nil {
+			return nil, repositoryError("get account", err)
+		}
+		if account.DeletedAt != nil {
+			return nil, failedPrecondition(reasonDeletedAccount, field.name,
+				fmt.Sprintf("account %s is deleted", account.AccountNumber), map[string]string{"account": account.AccountNumber})
+		}
+	}
+
+	// Validate the currencies and precision of the entry a capture would post
+	if err := s.checkLineCurrencies(ctx, tenantID, "", []*repository.CreateJournalEntryLineParams{
+		{AccountID: destinationID, Debit: amount, Credit: decimal.Zero},
+		{AccountID: accountID, Debit: decimal.Zero, Credit: amount},
+	}); err != nil {
+		return nil, err
+	}
+
+	hold, err := s.holdRepo.Create(ctx, tenantID, repository.CreateHoldParams{
+		AccountID:            accountID,
+		DestinationAccountID: destinationID,
+		Amount:               amount,
+		ReferenceNumber:      req.ReferenceNumber,
+		Description:          req.Description,
+		ExpiresAt:            expiresAt,
+	})
+	if err != nil {
+		return nil, repositoryError("create hold", err)
+	}
+
+	return &pb.CreateHoldResponse{
+		Hold: holdToProto(hold),
+	}, nil
+}
+
+// GetHold retrieves a hold
+func (s *LedgerService) GetHold(ctx context.Context, req *pb.GetHoldRequest) (*pb.GetHoldResponse, error) {
+	if s.holdRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "holds are not enabled")
+	}
+
+	tenantID, holdID, err := parseHoldIDs(req.TenantId, req.HoldId)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := s.holdRepo.GetByID(ctx, tenantID, holdID)
+	if err != nil {
+		return nil, repositoryError("get hold", err)
+	}
+
+	return &pb.GetHoldResponse{
+		Hold: holdToProto(hold),
+	}, nil
+}
+
+// ListHolds lists the holds of a tenant, optionally of one account or status
+func (s *LedgerService) ListHolds(ctx context.Context, req *pb.ListHoldsRequest) (*pb.ListHoldsResponse, error) {
+	if s.holdRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "holds are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := repository.HoldFilter{}
+	if req.AccountId != nil && *req.AccountId != "" {
+		accountID, err := requestID("account_id", *req.AccountId)
+		if err != nil {
+			return nil, err
+		}
+		filter.AccountID = &accountID
+	}
+
+	var holdStatus string
+	switch req.Status {
+	case pb.HoldStatus_HOLD_STATUS_PENDING:
+		holdStatus = repository.HoldStatusPending
+	case pb.HoldStatus_HOLD_STATUS_CAPTURED:
+		holdStatus = repository.HoldStatusCaptured
+	case pb.HoldStatus_HOLD_STATUS_RELEASED:
+		holdStatus = repository.HoldStatusReleased
+	case pb.HoldStatus_HOLD_STATUS_EXPIRED:
+		holdStatus = repository.HoldStatusExpired
+	}
+	if holdStatus != "" {
+		filter.Status = &holdStatus
+	}
+
+	page := int(req.GetPage())
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	holds, totalCount, err := s.holdRepo.List(ctx, tenantID, filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, repositoryError("list holds", err)
+	}
+
+	pbHolds := make([]*pb.Hold, len(holds))
+	for i, hold := range holds {
+		pbHolds[i] = holdToProto(hold)
+	}
+
+	return &pb.ListHoldsResponse{
+		Holds:      pbHolds,
+		TotalCount: int32(totalCount),
+	}, nil
+}
+
+// CaptureHold posts the journal entry of a pending hold, moving the captured
+// amount from the held account to the destination account. The amount
+// defaults to the held amount; capturing less releases the rest.
+func (s *LedgerService) CaptureHold(ctx context.Context, req *pb.CaptureHoldRequest) (*pb.CaptureHoldResponse, error) {
+	if s.holdRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "holds are not enabled")
+	}
+
+	tenantID, holdID, err := parseHoldIDs(req.TenantId, req.HoldId)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := s.holdRepo.GetByID(ctx, tenantID, holdID)
+	if err != nil {
+		return nil, repositoryError("get hold", err)
+	}
+
+	amount := hold.Amount
+	if req.Amount != nil {
+		amount, err = decimal.NewFromString(*req.Amount)
+		if err != nil || !amount.IsPositive() {
+			return nil, invalidField("amount", "amount must be a positive number")
+		}
+	}
+
+	lines, err := s.holdLines(ctx, tenantID, hold, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := fmt.Sprintf(`{"hold_id":%q}`, hold.ID.String())
+	params, err := s.journalEntryParams(ctx, tenantID, &pb.CreateJournalEntryRequest{
+		TenantId:        req.TenantId,
+		ReferenceNumber: hold.ReferenceNumber,
+		Description:     hold.Description,
+		EntryDate:       timestamppb.Now(),
+		Metadata:        &metadata,
+		Lines:           lines,
+	}, s.limits)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err = s.holdRepo.Capture(ctx, tenantID, holdID, amount, params)
+	if err != nil {
+		return nil, repositoryError("capture hold", err)
+	}
+
+	entry, err := s.journalRepo.GetByID(ctx, tenantID, *hold.JournalEntryID)
+	if err != nil {
+		return nil, repositoryError("get journal entry", err)
+	}
+
+	return &pb.CaptureHoldResponse{
+		Hold:         holdToProto(hold),
+		JournalEntry: journalEntryToProto(entry),
+	}, nil
+}
+
+// ReleaseHold frees a pending hold without posting
+func (s *LedgerService) ReleaseHold(ctx context.Context, req *pb.ReleaseHoldRequest) (*pb.ReleaseHoldResponse, error) {
+	if s.holdRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "holds are not enabled")
+	}
+
+	tenantID, holdID, err := parseHoldIDs(req.TenantId, req.HoldId)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := s.holdRepo.Release(ctx, tenantID, holdID)
+	if err != nil {
+		return nil, repositoryError("release hold", err)
+	}
+
+	return &pb.ReleaseHoldResponse{
+		Hold: holdToProto(hold),
+	}, nil
+}
+
+// holdLines builds the lines capturing amount of a hold. The held account is
+// reduced against its normal balance, so a credit-normal wallet is debited
+// and a debit-normal account is credited, with the destination taking the
+// other side.
+func (s *LedgerService) holdLines(ctx context.Context, tenantID uuid.UUID, hold *repository.Hold, amount decimal.Decimal) ([]*pb.JournalEntryLine, error) {
+	account, err := s.accountRepo.GetByID(ctx, tenantID, hold.AccountID)
+	if err != nil {
+		return nil, repositoryError("get account", err)
+	}
+
+	accountTypes, err := s.referenceRepo.ListAccountTypes(ctx)
+	if err != nil {
+		return nil, repositoryError("list account types", err)
+	}
+
+	creditNormal := false
+	for _, accountType := range accountTypes {
+		if accountType.ID == account.AccountTypeID {
+			creditNormal = accountType.NormalBalance == "CREDIT"
+		}
+	}
+
+	held := &pb.JournalEntryLine{AccountId: hold.AccountID.String(), Debit: "0", Credit: amount.String(), Description: hold.Description}
+	destination := &pb.JournalEntryLine{AccountId: hold.DestinationAccountID.String(), Debit: amount.String(), Credit: "0", Description: hold.Description}
+	if creditNormal {
+		held.Debit, held.Credit = amount.String(), "0"
+		destination.Debit, destination.Credit = "0", amount.String()
+	}
+
+	return []*pb.JournalEntryLine{held, destination}, nil
+}
+
+func parseHoldIDs(tenantIDValue, holdIDValue string) (uuid.UUID, uuid.UUID, error) {
+	tenantID, err := requestID("tenant_id", tenantIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	holdID, err := requestID("hold_id", holdIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return tenantID, holdID, nil
+}
+
+func holdToProto(hold *repository.Hold) *pb.Hold {
+	pbHold := &pb.Hold{
+		HoldId:               hold.ID.String(),
+		TenantId:             hold.TenantID.String(),
+		AccountId:            hold.AccountID.String(),
+		DestinationAccountId: hold.DestinationAccountID.String(),
+		Amount:               hold.Amount.String(),
+		CapturedAmount:       hold.CapturedAmount.String(),
+		ReferenceNumber:      hold.ReferenceNumber,
+		Description:          hold.Description,
+		ExpiresAt:            timestamppb.New(hold.ExpiresAt),
+		CreatedAt:            timestamppb.New(hold.CreatedAt),
+		UpdatedAt:            timestamppb.New(hold.UpdatedAt),
+	}
+
+	switch hold.Status {
+	case repository.HoldStatusPending:
+		pbHold.Status = pb.HoldStatus_HOLD_STATUS_PENDING
+	case repository.HoldStatusCaptured:
+		pbHold.Status = pb.HoldStatus_HOLD_STATUS_CAPTURED
+	case repository.HoldStatusReleased:
+		pbHold.Status = pb.HoldStatus_HOLD_STATUS_RELEASED
+	case repository.HoldStatusExpired:
+		pbHold.Status = pb.HoldStatus_HOLD_STATUS_EXPIRED
+	}
+
+	if hold.JournalEntryID != nil {
+		journalEntryID := hold.JournalEntryID.String()
+		pbHold.JournalEntryId = &journalEntryID
+	}
+
+	return pbHold
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockHoldRepository struct {
+	mock.Mock
+}
+
+func (m *MockHoldRepository) Create(ctx context.Context, tenantID uuid.UUID, params repository.CreateHoldParams) (*repository.Hold, error) {
+	args := m.Called(ctx, tenantID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Hold), args.Error(1)
+}
+
+func (m *MockHoldRepository) GetByID(ctx context.Context, tenantID uuid.UUID, holdID uuid.UUID) (*repository.Hold, error) {
+	args := m.Called(ctx, tenantID, holdID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Hold), args.Error(1)
+}
+
+func (m *MockHoldRepository) List(ctx context.Context, tenantID uuid.UUID, filter repository.HoldFilter, limit, offset int) ([]*repository.Hold, int, error) {
+	args := m.Called(ctx, tenantID, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*repository.Hold), args.Int(1), args.Error(2)
+}
+
+func (m *MockHoldRepository) Capture(ctx context.Context, tenantID uuid.UUID, holdID uuid.UUID, amount decimal.Decimal, entry repository.CreateJournalEntryParams) (*repository.Hold, error) {
+	args := m.Called(ctx, tenantID, holdID, amount, entry)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Hold), args.Error(1)
+}
+
+func (m *MockHoldRepository) Release(ctx context.Context, tenantID uuid.UUID, holdID uuid.UUID) (*repository.Hold, error) {
+	args := m.Called(ctx, tenantID, holdID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Hold), args.Error(1)
+}
+
+func TestLedgerService_Holds(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	mockHoldRepo := new(MockHoldRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		mockJournalRepo,
+		memory.NewReferenceRepository(store),
+		WithHoldRepository(mockHoldRepo),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "holds", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID
+
+	createAccount := func(number string, accountTypeID int32, currency string) uuid.UUID {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID.String(),
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: accountTypeID,
+			CurrencyCode:  currency,
+		})
+		require.NoError(t, err)
+		return uuid.MustParse(resp.AccountId)
+	}
+	wallet := createAccount("2001", 2, "USD")
+	merchant := createAccount("2002", 2, "USD")
+	card := createAccount("1001", 1, "USD")
+	euros := createAccount("2003", 2, "EUR")
+
+	pendingHold := func(accountID, destinationID uuid.UUID) *repository.Hold {
+		return &repository.Hold{
+			ID:                   uuid.New(),
+			TenantID:             tenantID,
+			AccountID:            accountID,
+			DestinationAccountID: destinationID,
+			Amount:               decimal.NewFromInt(100),
+			CapturedAmount:       decimal.Zero,
+			Status:               repository.HoldStatusPending,
+			ReferenceNumber:      "AUTH-1",
+			Description:          "Card authorization",
+			ExpiresAt:            time.Now().Add(time.Hour),
+		}
+	}
+
+	t.Run("creates a hold expiring in seven days by default", func(t *testing.T) {
+		hold := pendingHold(wallet, merchant)
+		mockHoldRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(p repository.CreateHoldParams) bool {
+			return p.AccountID == wallet && p.DestinationAccountID == merchant && p.Amount.Equal(decimal.NewFromInt(100)) &&
+				p.ExpiresAt.Sub(time.Now()) > defaultHoldExpiry-time.Minute
+		})).Return(hold, nil).Once()
+
+		resp, err := service.CreateHold(ctx, &pb.CreateHoldRequest{
+			TenantId:             tenantID.String(),
+			AccountId:            wallet.String(),
+			DestinationAccountId: merchant.String(),
+			Amount:               "100.00",
+			ReferenceNumber:      "AUTH-1",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, hold.ID.String(), resp.Hold.HoldId)
+		assert.Equal(t, pb.HoldStatus_HOLD_STATUS_PENDING, resp.Hold.Status)
+		assert.Nil(t, resp.Hold.JournalEntryId)
+		mockHoldRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects accounts of different currencies", func(t *testing.T) {
+		_, err := service.CreateHold(ctx, &pb.CreateHoldRequest{
+			TenantId:             tenantID.String(),
+			AccountId:            wallet.String(),
+			DestinationAccountId: euros.String(),
+			Amount:               "1",
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("returns not found for an unknown account", func(t *testing.T) {
+		_, err := service.CreateHold(ctx, &pb.CreateHoldRequest{
+			TenantId:             tenantID.String(),
+			AccountId:            wallet.String(),
+			DestinationAccountId: uuid.NewString(),
+			Amount:               "1",
+		})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("validates the hold request", func(t *testing.T) {
+		past := timestamppb.New(time.Now().Add(-time.Minute))
+		for _, req := range []*pb.CreateHoldRequest{
+			{TenantId: "invalid", AccountId: wallet.String(), DestinationAccountId: merchant.String(), Amount: "1"},
+			{TenantId: tenantID.String(), AccountId: "invalid", DestinationAccountId: merchant.String(), Amount: "1"},
+			{TenantId: tenantID.String(), AccountId: wallet.String(), DestinationAccountId: wallet.String(), Amount: "1"},
+			{TenantId: tenantID.String(), AccountId: wallet.String(), DestinationAccountId: merchant.String(), Amount: "0"},
+			{TenantId: tenantID.String(), AccountId: wallet.String(), DestinationAccountId: merchant.String(), Amount: "1.005"},
+			{TenantId: tenantID.String(), AccountId: wallet.String(), DestinationAccountId: merchant.String(), Amount: "1", ExpiresAt: past},
+		} {
+			_, err := service.CreateHold(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+	})
+
+	t.Run("captures a credit-normal account by debiting it", func(t *testing.T) {
+		hold := pendingHold(wallet, merchant)
+		entryID := uuid.New()
+		captured := *hold
+		captured.Status = repository.HoldStatusCaptured
+		captured.CapturedAmount = decimal.NewFromInt(60)
+		captured.JournalEntryID = &entryID
+
+		mockHoldRepo.On("GetByID", ctx, tenantID, hold.ID).Return(hold, nil).Once()
+		mockHoldRepo.On("Capture", ctx, tenantID, hold.ID, decimal.RequireFromString("60"), mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			return len(p.Lines) == 2 &&
+				p.Lines[0].AccountID == wallet && p.Lines[0].Debit.Equal(decimal.NewFromInt(60)) &&
+				p.Lines[1].AccountID == merchant && p.Lines[1].Credit.Equal(decimal.NewFromInt(60)) &&
+				p.ReferenceNumber == "AUTH-1" && p.Metadata["hold_id"] == hold.ID.String()
+		})).Return(&captured, nil).Once()
+		mockJournalRepo.On("GetByID", ctx, tenantID, entryID).Return(&repository.JournalEntry{
+			ID:       entryID,
+			TenantID: tenantID,
+		}, nil).Once()
+
+		amount := "60"
+		resp, err := service.CaptureHold(ctx, &pb.CaptureHoldRequest{
+			TenantId: tenantID.String(),
+			HoldId:   hold.ID.String(),
+			Amount:   &amount,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, pb.HoldStatus_HOLD_STATUS_CAPTURED, resp.Hold.Status)
+		assert.Equal(t, "60", resp.Hold.CapturedAmount)
+		assert.Equal(t, entryID.String(), resp.JournalEntry.JournalEntryId)
+		mockHoldRepo.AssertExpectations(t)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("captures a debit-normal account by crediting it", func(t *testing.T) {
+		hold := pendingHold(card, merchant)
+		mockHoldRepo.On("GetByID", ctx, tenantID, hold.ID).Return(hold, nil).Once()
+		mockHoldRepo.On("Capture", ctx, tenantID, hold.ID, hold.Amount, mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			return p.Lines[0].AccountID == card && p.Lines[0].Credit.Equal(hold.Amount) &&
+				p.Lines[1].AccountID == merchant && p.Lines[1].Debit.Equal(hold.Amount)
+		})).Return(nil, repository.ErrHoldExpired).Once()
+
+		_, err := service.CaptureHold(ctx, &pb.CaptureHoldRequest{
+			TenantId: tenantID.String(),
+			HoldId:   hold.ID.String(),
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockHoldRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects capturing more than the hold", func(t *testing.T) {
+		hold := pendingHold(wallet, merchant)
+		mockHoldRepo.On("GetByID", ctx, tenantID, hold.ID).Return(hold, nil).Once()
+		mockHoldRepo.On("Capture", ctx, tenantID, hold.ID, decimal.RequireFromString("150"), mock.Anything).
+			Return(nil, repository.ErrCaptureExceedsHold).Once()
+
+		amount := "150"
+		_, err := service.CaptureHold(ctx, &pb.CaptureHoldRequest{
+			TenantId: tenantID.String(),
+			HoldId:   hold.ID.String(),
+			Amount:   &amount,
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockHoldRepo.AssertExpectations(t)
+	})
+
+	t.Run("releases a pending hold", func(t *testing.T) {
+		hold := pendingHold(wallet, merchant)
+		hold.Status = repository.HoldStatusReleased
+		mockHoldRepo.On("Release", ctx, tenantID, hold.ID).Return(hold, nil).Once()
+		mockHoldRepo.On("Release", ctx, tenantID, hold.ID).Return(nil, repository.ErrHoldNotPending).Once()
+
+		resp, err := service.ReleaseHold(ctx, &pb.ReleaseHoldRequest{TenantId: tenantID.String(), HoldId: hold.ID.String()})
+		require.NoError(t, err)
+		assert.Equal(t, pb.HoldStatus_HOLD_STATUS_RELEASED, resp.Hold.Status)
+
+		_, err = service.ReleaseHold(ctx, &pb.ReleaseHoldRequest{TenantId: tenantID.String(), HoldId: hold.ID.String()})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockHoldRepo.AssertExpectations(t)
+	})
+
+	t.Run("lists holds by account and status", func(t *testing.T) {
+		expired := repository.HoldStatusExpired
+		hold := pendingHold(wallet, merchant)
+		hold.Status = expired
+		mockHoldRepo.On("List", ctx, tenantID, repository.HoldFilter{AccountID: &wallet, Status: &expired}, 50, 0).
+			Return([]*repository.Hold{hold}, 1, nil).Once()
+
+		accountID := wallet.String()
+		resp, err := service.ListHolds(ctx, &pb.ListHoldsRequest{
+			TenantId:  tenantID.String(),
+			AccountId: &accountID,
+			Status:    pb.HoldStatus_HOLD_STATUS_EXPIRED,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Holds, 1)
+		assert.Equal(t, pb.HoldStatus_HOLD_STATUS_EXPIRED, resp.Holds[0].Status)
+		assert.Equal(t, int32(1), resp.TotalCount)
+		mockHoldRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns unimplemented when holds are disabled", func(t *testing.T) {
+		service := NewLedgerService(nil, nil, nil, nil)
+
+		_, err := service.CreateHold(ctx, &pb.CreateHoldRequest{TenantId: tenantID.String()})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = service.CaptureHold(ctx, &pb.CaptureHoldRequest{TenantId: tenantID.String()})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
@@ -0,0 +1,422 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/interest"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// InterestService implements the gRPC InterestService for interest accrual on
+// lending and savings accounts
+type InterestService struct {
+	pb.UnimplementedInterestServiceServer
+	accountRepo  repository.AccountRepositoryInterface
+	interestRepo repository.InterestRepositoryInterface
+	poster       *interest.Poster
+	now          func() time.Time
+}
+
+// NewInterestService creates a new interest service
+func NewInterestService(
+	accountRepo repository.AccountRepositoryInterface,
+	interestRepo repository.InterestRepositoryInterface,
+) *InterestService {
+	return &InterestService{
+		accountRepo:  accountRepo,
+		interestRepo: interestRepo,
+		poster:       interest.NewPoster(interestRepo),
+		now:          time.Now,
+	}
+}
+
+// CreateInterestScheme creates an interest scheme
+func (s *InterestService) CreateInterestScheme(ctx context.Context, req *pb.CreateInterestSchemeRequest) (*pb.CreateInterestSchemeResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := interestSchemeParams(req.Code, req.Name, req.AnnualRate, req.DayCount, req.AccrualAccountId, req.InterestAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSchemeAccounts(ctx, tenantID, params); err != nil {
+		return nil, err
+	}
+
+	scheme, err := s.interestRepo.CreateScheme(ctx, tenantID, params)
+	if err != nil {
+		return nil, repositoryError("create interest scheme", err)
+	}
+
+	return &pb.CreateInterestSchemeResponse{
+		Scheme: interestSchemeToProto(scheme),
+	}, nil
+}
+
+// UpdateInterestScheme replaces the settings of an interest scheme; the new
+// settings apply to every period accrued afterwards
+func (s *InterestService) UpdateInterestScheme(ctx context.Context, req *pb.UpdateInterestSchemeRequest) (*pb.UpdateInterestSchemeResponse, error) {
+	tenantID, schemeID, err := parseInterestSchemeIDs(req.TenantId, req.SchemeId)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := interestSchemeParams(req.Code, req.Name, req.AnnualRate, req.DayCount, req.AccrualAccountId, req.InterestAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSchemeAccounts(ctx, tenantID, params); err != nil {
+		return nil, err
+	}
+
+	scheme, err := s.interestRepo.UpdateScheme(ctx, tenantID, schemeID, params)
+	if err != nil {
+		return nil, repositoryError("update interest scheme", err)
+	}
+
+	return &pb.UpdateInterestSchemeResponse{
+		Scheme: interestSchemeToProto(scheme),
+	}, nil
+}
+
+// GetInterestScheme retrieves an interest scheme
+func (s *InterestService) GetInterestScheme(ctx context.Context, req *pb.GetInterestSchemeRequest) (*pb.GetInterestSchemeResponse, error) {
+	tenantID, schemeID, err := parseInterestSchemeIDs(req.TenantId, req.SchemeId)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme, err := s.interestRepo.GetScheme(ctx, tenantID, schemeID)
+	if err != nil {
+		return nil, repositoryError("get interest scheme", err)
+	}
+
+	return &pb.GetInterestSchemeResponse{
+		Scheme: interestSchemeToProto(scheme),
+	}, nil
+}
+
+// ListInterestSchemes lists the interest schemes of a tenant
+func (s *InterestService) ListInterestSchemes(ctx context.Context, req *pb.ListInterestSchemesRequest) (*pb.ListInterestSchemesResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	schemes, err := s.interestRepo.ListSchemes(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("list interest schemes", err)
+	}
+
+	resp := &pb.ListInterestSchemesResponse{
+		Schemes: make([]*pb.InterestScheme, len(schemes)),
+	}
+	for i, scheme := range schemes {
+		resp.Schemes[i] = interestSchemeToProto(scheme)
+	}
+
+	return resp, nil
+}
+
+// AttachInterestScheme starts accruing interest on an account under a
+// scheme. The account must be in the currency of the scheme's accounts.
+func (s *InterestService) AttachInterestScheme(ctx context.Context, req *pb.AttachInterestSchemeRequest) (*pb.AttachInterestSchemeResponse, error) {
+	tenantID, schemeID, err := parseInterestSchemeIDs(req.TenantId, req.SchemeId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme, err := s.interestRepo.GetScheme(ctx, tenantID, schemeID)
+	if err != nil {
+		return nil, repositoryError("get interest scheme", err)
+	}
+
+	if accountID == scheme.AccrualAccountID || accountID == scheme.InterestAccountID {
+		return nil, invalidField("account_id", "account cannot be one of the scheme's accounts")
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, repositoryError("get account", err)
+	}
+	if account.DeletedAt != nil {
+		return nil, repositoryError("attach interest scheme", repository.ErrDeletedAccount)
+	}
+
+	accrualAccount, err := s.accountRepo.GetByID(ctx, tenantID, scheme.AccrualAccountID)
+	if err != nil {
+		return nil, repositoryError("get accrual account", err)
+	}
+	if account.CurrencyCode != accrualAccount.CurrencyCode {
+		return nil, repositoryError("attach interest scheme", repository.ErrCurrencyMismatch)
+	}
+
+	start := interest.Date(s.now())
+	if req.StartDate != nil {
+		start = interest.Date(req.StartDate.AsTime())
+	}
+
+	ai, err := s.interestRepo.AttachScheme(ctx, tenantID, accountID, schemeID, start.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, repositoryError("attach interest scheme", err)
+	}
+
+	return &pb.AttachInterestSchemeResponse{
+		AccountInterest: accountInterestToProto(ai),
+	}, nil
+}
+
+// DetachInterestScheme stops accruing interest on an account
+func (s *InterestService) DetachInterestScheme(ctx context.Context, req *pb.DetachInterestSchemeRequest) (*pb.DetachInterestSchemeResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.interestRepo.DetachScheme(ctx, tenantID, accountID); err != nil {
+		return nil, repositoryError("detach interest scheme", err)
+	}
+
+	return &pb.DetachInterestSchemeResponse{}, nil
+}
+
+// AccrueInterest posts the interest that has fallen due, for one account or
+// every account with a scheme attached
+func (s *InterestService) AccrueInterest(ctx context.Context, req *pb.AccrueInterestRequest) (*pb.AccrueInterestResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	var accountID *uuid.UUID
+	if req.AccountId != nil {
+		id, err := requestID("account_id", *req.AccountId)
+		if err != nil {
+			return nil, err
+		}
+		accountID = &id
+	}
+
+	yesterday := interest.Date(s.now()).AddDate(0, 0, -1)
+	through := yesterday
+	if req.Through != nil {
+		through = interest.Date(req.Through.AsTime())
+		if through.After(yesterday) {
+			return nil, invalidField("through", "interest can only be accrued through yesterday")
+		}
+	}
+
+	accruals, err := s.poster.AccrueDue(ctx, tenantID, accountID, through)
+	if err != nil {
+		return nil, repositoryError("accrue interest", err)
+	}
+
+	resp := &pb.AccrueInterestResponse{
+		Accruals: make([]*pb.InterestAccrual, len(accruals)),
+	}
+	for i, accrual := range accruals {
+		resp.Accruals[i] = interestAccrualToProto(accrual)
+	}
+
+	return resp, nil
+}
+
+// ListInterestAccruals lists posted accruals, latest period first
+func (s *InterestService) ListInterestAccruals(ctx context.Context, req *pb.ListInterestAccrualsRequest) (*pb.ListInterestAccrualsResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	var accountID *uuid.UUID
+	if req.AccountId != nil {
+		id, err := requestID("account_id", *req.AccountId)
+		if err != nil {
+			return nil, err
+		}
+		accountID = &id
+	}
+
+	page := int(req.GetPage())
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	accruals, totalCount, err := s.interestRepo.ListAccruals(ctx, tenantID, accountID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, repositoryError("list interest accruals", err)
+	}
+
+	resp := &pb.ListInterestAccrualsResponse{
+		Accruals:   make([]*pb.InterestAccrual, len(accruals)),
+		TotalCount: int32(totalCount),
+	}
+	for i, accrual := range accruals {
+		resp.Accruals[i] = interestAccrualToProto(accrual)
+	}
+
+	return resp, nil
+}
+
+// checkSchemeAccounts checks that the accrual and interest accounts of a
+// scheme exist and share a currency and book, so its entries can be posted
+func (s *InterestService) checkSchemeAccounts(ctx context.Context, tenantID uuid.UUID, params repository.InterestSchemeParams) error {
+	accrualAccount, err := s.accountRepo.GetByID(ctx, tenantID, params.AccrualAccountID)
+	if err != nil {
+		return repositoryError("get accrual account", err)
+	}
+
+	interestAccount, err := s.accountRepo.GetByID(ctx, tenantID, params.InterestAccountID)
+	if err != nil {
+		return repositoryError("get interest account", err)
+	}
+
+	switch {
+	case accrualAccount.CurrencyCode != interestAccount.CurrencyCode:
+		return repositoryError("check interest scheme accounts", repository.ErrCurrencyMismatch)
+	case accrualAccount.BookID != interestAccount.BookID:
+		return repositoryError("check interest scheme accounts", repository.ErrBookMismatch)
+	}
+
+	return nil
+}
+
+// interestSchemeParams validates the settings of an interest scheme
+func interestSchemeParams(code, name, rateValue string, dayCount pb.DayCountConvention, accrualAccountIDValue, interestAccountIDValue string) (repository.InterestSchemeParams, error) {
+	params := repository.InterestSchemeParams{
+		Code: code,
+		Name: name,
+	}
+
+	if code == "" {
+		return params, invalidField("code", "scheme code is required")
+	}
+
+	if name == "" {
+		return params, invalidField("name", "scheme name is required")
+	}
+
+	rate, err := decimal.NewFromString(rateValue)
+	if err != nil || rate.IsNegative() {
+		return params, invalidField("annual_rate", "annual rate must be a number of at least zero")
+	}
+	params.AnnualRate = rate
+
+	switch dayCount {
+	case pb.DayCountConvention_DAY_COUNT_CONVENTION_ACT_360:
+		params.DayCount = string(interest.DayCountActual360)
+	case pb.DayCountConvention_DAY_COUNT_CONVENTION_ACT_365:
+		params.DayCount = string(interest.DayCountActual365)
+	case pb.DayCountConvention_DAY_COUNT_CONVENTION_30_360:
+		params.DayCount = string(interest.DayCountThirty360)
+	default:
+		return params, invalidField("day_count", "day count convention is required")
+	}
+
+	accrualAccountID, err := requestID("accrual_account_id", accrualAccountIDValue)
+	if err != nil {
+		return params, err
+	}
+	params.AccrualAccountID = accrualAccountID
+
+	interestAccountID, err := requestID("interest_account_id", interestAccountIDValue)
+	if err != nil {
+		return params, err
+	}
+	params.InterestAccountID = interestAccountID
+
+	if accrualAccountID == interestAccountID {
+		return params, invalidField("interest_account_id", "accrual and interest accounts must differ")
+	}
+
+	return params, nil
+}
+
+func parseInterestSchemeIDs(tenantIDValue, schemeIDValue string) (uuid.UUID, uuid.UUID, error) {
+	tenantID, err := requestID("tenant_id", tenantIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	schemeID, err := requestID("scheme_id", schemeIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return tenantID, schemeID, nil
+}
+
+func interestSchemeToProto(scheme *repository.InterestScheme) *pb.InterestScheme {
+	pbScheme := &pb.InterestScheme{
+		SchemeId:          scheme.ID.String(),
+		TenantId:          scheme.TenantID.String(),
+		Code:              scheme.Code,
+		Name:              scheme.Name,
+		AnnualRate:        scheme.AnnualRate.String(),
+		AccrualAccountId:  scheme.AccrualAccountID.String(),
+		InterestAccountId: scheme.InterestAccountID.String(),
+		CreatedAt:         timestamppb.New(scheme.CreatedAt),
+		UpdatedAt:         timestamppb.New(scheme.UpdatedAt),
+	}
+
+	switch interest.DayCount(scheme.DayCount) {
+	case interest.DayCountActual360:
+		pbScheme.DayCount = pb.DayCountConvention_DAY_COUNT_CONVENTION_ACT_360
+	case interest.DayCountActual365:
+		pbScheme.DayCount = pb.DayCountConvention_DAY_COUNT_CONVENTION_ACT_365
+	case interest.DayCountThirty360:
+		pbScheme.DayCount = pb.DayCountConvention_DAY_COUNT_CONVENTION_30_360
+	}
+
+	return pbScheme
+}
+
+func accountInterestToProto(ai *repository.AccountInterest) *pb.AccountInterest {
+	return &pb.AccountInterest{
+		AccountId:      ai.AccountID.String(),
+		SchemeId:       ai.SchemeID.String(),
+		AccruedThrough: timestamppb.New(ai.AccruedThrough),
+		CreatedAt:      timestamppb.New(ai.CreatedAt),
+	}
+}
+
+func interestAccrualToProto(accrual *repository.InterestAccrual) *pb.InterestAccrual {
+	return &pb.InterestAccrual{
+		AccrualId:      accrual.ID.String(),
+		AccountId:      accrual.AccountID.String(),
+		SchemeId:       accrual.SchemeID.String(),
+		PeriodStart:    timestamppb.New(accrual.PeriodStart),
+		PeriodEnd:      timestamppb.New(accrual.PeriodEnd),
+		Amount:         accrual.Amount.String(),
+		JournalEntryId: accrual.JournalEntryID.String(),
+		PostedAt:       timestamppb.New(accrual.PostedAt),
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockInterestRepository struct {
+	mock.Mock
+}
+
+func (m *MockInterestRepository) CreateScheme(ctx context.Context, tenantID uuid.UUID, params repository.InterestSchemeParams) (*repository.InterestScheme, error) {
+	args := m.Called(ctx, tenantID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.InterestScheme), args.Error(1)
+}
+
+func (m *MockInterestRepository) UpdateScheme(ctx context.Context, tenantID uuid.UUID, schemeID uuid.UUID, params repository.InterestSchemeParams) (*repository.InterestScheme, error) {
+	args := m.Called(ctx, tenantID, schemeID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.InterestScheme), args.Error(1)
+}
+
+func (m *MockInterestRepository) GetScheme(ctx context.Context, tenantID uuid.UUID, schemeID uuid.UUID) (*repository.InterestScheme, error) {
+	args := m.Called(ctx, tenantID, schemeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.InterestScheme), args.Error(1)
+}
+
+func (m *MockInterestRepository) ListSchemes(ctx context.Context, tenantID uuid.UUID) ([]*repository.InterestScheme, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.InterestScheme), args.Error(1)
+}
+
+func (m *MockInterestRepository) AttachScheme(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, schemeID uuid.UUID, accruedThrough time.Time) (*repository.AccountInterest, error) {
+	args := m.Called(ctx, tenantID, accountID, schemeID, accruedThrough)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.AccountInterest), args.Error(1)
+}
+
+func (m *MockInterestRepository) DetachScheme(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) error {
+	args := m.Called(ctx, tenantID, accountID)
+	return args.Error(0)
+}
+
+func (m *MockInterestRepository) ListDueAccruals(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, through time.Time) ([]*repository.AccountInterest, error) {
+	args := m.Called(ctx, tenantID, accountID, through)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.AccountInterest), args.Error(1)
+}
+
+func (m *MockInterestRepository) GetDailyMovements(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, after, through time.Time) (decimal.Decimal, []*repository.DailyMovement, error) {
+	args := m.Called(ctx, tenantID, accountID, after, through)
+	if args.Get(1) == nil {
+		return args.Get(0).(decimal.Decimal), nil, args.Error(2)
+	}
+	return args.Get(0).(decimal.Decimal), args.Get(1).([]*repository.DailyMovement), args.Error(2)
+}
+
+func (m *MockInterestRepository) PostAccrual(ctx context.Context, tenantID uuid.UUID, accrual repository.InterestAccrual, entry repository.CreateJournalEntryParams) (*repository.InterestAccrual, error) {
+	args := m.Called(ctx, tenantID, accrual, entry)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.InterestAccrual), args.Error(1)
+}
+
+func (m *MockInterestRepository) ListAccruals(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, limit, offset int) ([]*repository.InterestAccrual, int, error) {
+	args := m.Called(ctx, tenantID, accountID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*repository.InterestAccrual), args.Int(1), args.Error(2)
+}
+
+// Test CreateInterestScheme
+func TestInterestService_CreateInterestScheme(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	receivableID, incomeID := uuid.New(), uuid.New()
+	bookID := uuid.New()
+
+	request := func() *pb.CreateInterestSchemeRequest {
+		return &pb.CreateInterestSchemeRequest{
+			TenantId:          tenantID.String(),
+			Code:              "LOAN-5",
+			Name:              "Term loan 5%",
+			AnnualRate:        "0.05",
+			DayCount:          pb.DayCountConvention_DAY_COUNT_CONVENTION_ACT_360,
+			AccrualAccountId:  receivableID.String(),
+			InterestAccountId: incomeID.String(),
+		}
+	}
+
+	t.Run("creates a scheme", func(t *testing.T) {
+		mockAccountRepo := new(MockAccountRepository)
+		mockInterestRepo := new(MockInterestRepository)
+		service := NewInterestService(mockAccountRepo, mockInterestRepo)
+
+		mockAccountRepo.On("GetByID", ctx, tenantID, receivableID).Return(&repository.Account{ID: receivableID, BookID: bookID, CurrencyCode: "USD"}, nil).Once()
+		mockAccountRepo.On("GetByID", ctx, tenantID, incomeID).Return(&repository.Account{ID: incomeID, BookID: bookID, CurrencyCode: "USD"}, nil).Once()
+		mockInterestRepo.On("CreateScheme", ctx, tenantID, mock.MatchedBy(func(p repository.InterestSchemeParams) bool {
+			return p.Code == "LOAN-5" && p.DayCount == "ACT_360" && p.AnnualRate.Equal(decimal.RequireFromString("0.05"))
+		})).Return(&repository.InterestScheme{
+			ID:                uuid.New(),
+			TenantID:          tenantID,
+			Code:              "LOAN-5",
+			AnnualRate:        decimal.RequireFromString("0.05"),
+			DayCount:          "ACT_360",
+			AccrualAccountID:  receivableID,
+			InterestAccountID: incomeID,
+		}, nil).Once()
+
+		resp, err := service.CreateInterestScheme(ctx, request())
+
+		require.NoError(t, err)
+		assert.Equal(t, pb.DayCountConvention_DAY_COUNT_CONVENTION_ACT_360, resp.Scheme.DayCount)
+		assert.Equal(t, "0.05", resp.Scheme.AnnualRate)
+		mockAccountRepo.AssertExpectations(t)
+		mockInterestRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects accounts in different currencies", func(t *testing.T) {
+		mockAccountRepo := new(MockAccountRepository)
+		mockInterestRepo := new(MockInterestRepository)
+		service := NewInterestService(mockAccountRepo, mockInterestRepo)
+
+		mockAccountRepo.On("GetByID", ctx, tenantID, receivableID).Return(&repository.Account{ID: receivableID, BookID: bookID, CurrencyCode: "USD"}, nil).Once()
+		mockAccountRepo.On("GetByID", ctx, tenantID, incomeID).Return(&repository.Account{ID: incomeID, BookID: bookID, CurrencyCode: "EUR"}, nil).Once()
+
+		_, err := service.CreateInterestScheme(ctx, request())
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonCurrencyMismatch, errorReason(t, err))
+		mockInterestRepo.AssertNotCalled(t, "CreateScheme", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("validates the settings", func(t *testing.T) {
+		service := NewInterestService(new(MockAccountRepository), new(MockInterestRepository))
+
+		negative := request()
+		negative.AnnualRate = "-0.01"
+		_, err := service.CreateInterestScheme(ctx, negative)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		noDayCount := request()
+		noDayCount.DayCount = pb.DayCountConvention_DAY_COUNT_CONVENTION_UNSPECIFIED
+		_, err = service.CreateInterestScheme(ctx, noDayCount)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		sameAccount := request()
+		sameAccount.InterestAccountId = sameAccount.AccrualAccountId
+		_, err = service.CreateInterestScheme(ctx, sameAccount)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// Test AttachInterestScheme
+func TestInterestService_AttachInterestScheme(t *testing.T) {
+	ctx := context.Background()
+	tenantID, accountID := uuid.New(), uuid.New()
+	scheme := &repository.InterestScheme{ID: uuid.New(), AccrualAccountID: uuid.New(), InterestAccountID: uuid.New()}
+
+	t.Run("starts accruing on the start date", func(t *testing.T) {
+		mockAccountRepo := new(MockAccountRepository)
+		mockInterestRepo := new(MockInterestRepository)
+		service := NewInterestService(mockAccountRepo, mockInterestRepo)
+
+		mockInterestRepo.On("GetScheme", ctx, tenantID, scheme.ID).Return(scheme, nil).Once()
+		mockAccountRepo.On("GetByID", ctx, tenantID, accountID).Return(&repository.Account{ID: accountID, CurrencyCode: "USD"}, nil).Once()
+		mockAccountRepo.On("GetByID", ctx, tenantID, scheme.AccrualAccountID).Return(&repository.Account{ID: scheme.AccrualAccountID, CurrencyCode: "USD"}, nil).Once()
+		accruedThrough := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+		mockInterestRepo.On("AttachScheme", ctx, tenantID, accountID, scheme.ID, accruedThrough).
+			Return(&repository.AccountInterest{AccountID: accountID, SchemeID: scheme.ID, AccruedThrough: accruedThrough}, nil).Once()
+
+		resp, err := service.AttachInterestScheme(ctx, &pb.AttachInterestSchemeRequest{
+			TenantId:  tenantID.String(),
+			AccountId: accountID.String(),
+			SchemeId:  scheme.ID.String(),
+			StartDate: timestamppb.New(time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, accruedThrough, resp.AccountInterest.AccruedThrough.AsTime())
+		mockAccountRepo.AssertExpectations(t)
+		mockInterestRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects the scheme's own accounts", func(t *testing.T) {
+		mockInterestRepo := new(MockInterestRepository)
+		service := NewInterestService(new(MockAccountRepository), mockInterestRepo)
+
+		mockInterestRepo.On("GetScheme", ctx, tenantID, scheme.ID).Return(scheme, nil).Once()
+
+		_, err := service.AttachInterestScheme(ctx, &pb.AttachInterestSchemeRequest{
+			TenantId:  tenantID.String(),
+			AccountId: scheme.InterestAccountID.String(),
+			SchemeId:  scheme.ID.String(),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// Test AccrueInterest
+func TestInterestService_AccrueInterest(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	now := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	t.Run("accrues through yesterday by default", func(t *testing.T) {
+		mockInterestRepo := new(MockInterestRepository)
+		service := NewInterestService(new(MockAccountRepository), mockInterestRepo)
+		service.now = func() time.Time { return now }
+
+		mockInterestRepo.On("ListDueAccruals", ctx, tenantID, (*uuid.UUID)(nil), time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)).
+			Return([]*repository.AccountInterest{}, nil).Once()
+
+		resp, err := service.AccrueInterest(ctx, &pb.AccrueInterestRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.Empty(t, resp.Accruals)
+		mockInterestRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects accruing through today", func(t *testing.T) {
+		mockInterestRepo := new(MockInterestRepository)
+		service := NewInterestService(new(MockAccountRepository), mockInterestRepo)
+		service.now = func() time.Time { return now }
+
+		_, err := service.AccrueInterest(ctx, &pb.AccrueInterestRequest{
+			TenantId: tenantID.String(),
+			Through:  timestamppb.New(now),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		mockInterestRepo.AssertNotCalled(t, "ListDueAccruals", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
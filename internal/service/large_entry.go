@@ -0,0 +1,219 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// CreateLargeJournalEntry posts a journal entry whose lines are streamed in
+// chunks after its header, for entries such as payroll runs that are too
+// large for one message. Each line is checked as it arrives, with running
+// debit and credit totals held against the control totals of the header,
+// and the assembled entry goes through the checks of CreateJournalEntry and
+// is posted in one transaction, within the streamed line limit.
+func (s *LedgerService) CreateLargeJournalEntry(stream pb.LedgerService_CreateLargeJournalEntryServer) error {
+	ctx := stream.Context()
+
+	first, err := stream.Recv()
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to receive journal entry header: %v", err)
+	}
+
+	header := first.GetHeader()
+	if header == nil {
+		return status.Error(codes.InvalidArgument, "first message must contain the journal entry header")
+	}
+
+	tenantID, err := requestID("tenant_id", header.TenantId)
+	if err != nil {
+		return err
+	}
+
+	lines, err := newStreamedLines(header)
+	if err != nil {
+		return err
+	}
+
+	limits := s.limits
+	limits.MaxLines = limits.MaxStreamedLines
+
+	clock, err := s.clock(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	entryDate, err := dateField("entry_date", header.EntryDate, header.JalaliEntryDate)
+	if err != nil {
+		return err
+	}
+	if entryDate == nil {
+		today := clock.today()
+		entryDate = &today
+	}
+
+	// Fail before the lines are uploaded when the date cannot be posted to
+	if _, err := s.checkPostingPolicy(ctx, tenantID, clock, *entryDate, header.LockOverrideReason); err != nil {
+		return err
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if msg.GetHeader() != nil {
+			return status.Error(codes.InvalidArgument, "journal entry header must only be sent once")
+		}
+
+		for _, line := range msg.GetLines().GetLines() {
+			if err := lines.add(line); err != nil {
+				return err
+			}
+		}
+
+		if err := limits.checkLineCount(len(lines.lines)); err != nil {
+			return err
+		}
+	}
+
+	if err := lines.complete(); err != nil {
+		return err
+	}
+
+	params, err := s.journalEntryParams(ctx, tenantID, &pb.CreateJournalEntryRequest{
+		TenantId:           header.TenantId,
+		ReferenceNumber:    header.ReferenceNumber,
+		Description:        header.Description,
+		EntryDate:          header.EntryDate,
+		JalaliEntryDate:    header.JalaliEntryDate,
+		Lines:              lines.lines,
+		Metadata:           header.Metadata,
+		CurrencyCode:       header.CurrencyCode,
+		TransactionId:      header.TransactionId,
+		BookId:             header.BookId,
+		LockOverrideReason: header.LockOverrideReason,
+	}, limits)
+	if err != nil {
+		return err
+	}
+
+	entry, err := s.journalRepo.Create(ctx, tenantID, params)
+	if err != nil {
+		return repositoryError("create journal entry", err)
+	}
+
+	totalDebit, totalCredit := decimal.Zero, decimal.Zero
+	for _, line := range entry.Lines {
+		totalDebit = totalDebit.Add(line.Debit)
+		totalCredit = totalCredit.Add(line.Credit)
+	}
+
+	return stream.SendAndClose(&pb.CreateLargeJournalEntryResponse{
+		JournalEntryId:  entry.ID.String(),
+		TenantId:        entry.TenantID.String(),
+		ReferenceNumber: entry.ReferenceNumber,
+		EntryDate:       timestamppb.New(entry.EntryDate),
+		CreatedAt:       timestamppb.New(entry.CreatedAt),
+		LineCount:       int32(len(entry.Lines)),
+		TotalDebit:      totalDebit.String(),
+		TotalCredit:     totalCredit.String(),
+	})
+}
+
+// streamedLines collects the lines of a streamed journal entry with their
+// running totals, held against the control totals of its header
+type streamedLines struct {
+	lines         []*pb.JournalEntryLine
+	debit, credit decimal.Decimal
+
+	lineCount               *int32
+	totalDebit, totalCredit *decimal.Decimal
+}
+
+// newStreamedLines parses the control totals of a header
+func newStreamedLines(header *pb.LargeJournalEntryHeader) (*streamedLines, error) {
+	l := &streamedLines{lineCount: header.LineCount}
+
+	if header.LineCount != nil && *header.LineCount < 2 {
+		return nil, invalidField("line_count", "line count must be at least 2")
+	}
+
+	for _, total := range []struct {
+		field string
+		value *string
+		dest  **decimal.Decimal
+	}{
+		{"total_debit", header.TotalDebit, &l.totalDebit},
+		{"total_credit", header.TotalCredit, &l.totalCredit},
+	} {
+		if total.value == nil || *total.value == "" {
+			continue
+		}
+		amount, err := decimal.NewFromString(*total.value)
+		if err != nil || !amount.IsPositive() {
+			return nil, invalidField(total.field, total.field+" must be a positive number")
+		}
+		*total.dest = &amount
+	}
+
+	return l, nil
+}
+
+// add checks the amounts of the next line and adds them to the running
+// totals, failing as soon as the lines exceed a control total
+func (l *streamedLines) add(line *pb.JournalEntryLine) error {
+	i := len(l.lines)
+
+	debit, err := decimal.NewFromString(line.Debit)
+	if err != nil {
+		return invalidLine(i, "debit", "invalid debit amount at line %d", i)
+	}
+	credit, err := decimal.NewFromString(line.Credit)
+	if err != nil {
+		return invalidLine(i, "credit", "invalid credit amount at line %d", i)
+	}
+	if debit.IsNegative() || credit.IsNegative() || debit.IsPositive() == credit.IsPositive() {
+		return invalidLine(i, "debit", "line %d must have either a positive debit or a positive credit", i)
+	}
+
+	l.lines = append(l.lines, line)
+	l.debit = l.debit.Add(debit)
+	l.credit = l.credit.Add(credit)
+
+	switch {
+	case l.lineCount != nil && len(l.lines) > int(*l.lineCount):
+		return invalidField("line_count", fmt.Sprintf("more lines were sent than the line count of %d", *l.lineCount))
+	case l.totalDebit != nil && l.debit.GreaterThan(*l.totalDebit):
+		return invalidField("total_debit", fmt.Sprintf("debits exceed the control total of %s at line %d", l.totalDebit, i))
+	case l.totalCredit != nil && l.credit.GreaterThan(*l.totalCredit):
+		return invalidField("total_credit", fmt.Sprintf("credits exceed the control total of %s at line %d", l.totalCredit, i))
+	}
+
+	return nil
+}
+
+// complete checks the received lines match the control totals once the
+// stream has ended
+func (l *streamedLines) complete() error {
+	switch {
+	case l.lineCount != nil && len(l.lines) != int(*l.lineCount):
+		return invalidField("line_count", fmt.Sprintf("received %d lines, expected %d", len(l.lines), *l.lineCount))
+	case l.totalDebit != nil && !l.debit.Equal(*l.totalDebit):
+		return invalidField("total_debit", fmt.Sprintf("debits total %s, expected %s", l.debit, l.totalDebit))
+	case l.totalCredit != nil && !l.credit.Equal(*l.totalCredit):
+		return invalidField("total_credit", fmt.Sprintf("credits total %s, expected %s", l.credit, l.totalCredit))
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// fakeLargeEntryStream replays a fixed sequence of requests to
+// CreateLargeJournalEntry
+type fakeLargeEntryStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []*pb.CreateLargeJournalEntryRequest
+	response *pb.CreateLargeJournalEntryResponse
+}
+
+func (f *fakeLargeEntryStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeLargeEntryStream) Recv() (*pb.CreateLargeJournalEntryRequest, error) {
+	if len(f.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := f.requests[0]
+	f.requests = f.requests[1:]
+	return req, nil
+}
+
+func (f *fakeLargeEntryStream) SendAndClose(resp *pb.CreateLargeJournalEntryResponse) error {
+	f.response = resp
+	return nil
+}
+
+func TestLedgerService_CreateLargeJournalEntry(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+		WithEntryLimits(EntryLimits{MaxLines: 2, MaxStreamedLines: 100}),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "payroll", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	createAccount := func(number string, accountTypeID int32) string {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: accountTypeID,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(t, err)
+		return resp.AccountId
+	}
+	salaries := createAccount("6200", 5)
+	payable := createAccount("2300", 2)
+
+	header := func(reference string, lineCount int32, totalDebit string) *pb.CreateLargeJournalEntryRequest {
+		return &pb.CreateLargeJournalEntryRequest{
+			Payload: &pb.CreateLargeJournalEntryRequest_Header{
+				Header: &pb.LargeJournalEntryHeader{
+					TenantId:        tenantID,
+					ReferenceNumber: reference,
+					Description:     "October payroll",
+					EntryDate:       timestamppb.New(time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)),
+					LineCount:       &lineCount,
+					TotalDebit:      &totalDebit,
+				},
+			},
+		}
+	}
+
+	// payslips returns a chunk of n salary lines of 100 and the payable line
+	// crediting them
+	payslips := func(n int) *pb.CreateLargeJournalEntryRequest {
+		lines := make([]*pb.JournalEntryLine, 0, n+1)
+		for range n {
+			lines = append(lines, &pb.JournalEntryLine{AccountId: salaries, Debit: "100", Credit: "0"})
+		}
+		lines = append(lines, &pb.JournalEntryLine{AccountId: payable, Debit: "0", Credit: "100"})
+		return &pb.CreateLargeJournalEntryRequest{
+			Payload: &pb.CreateLargeJournalEntryRequest_Lines{
+				Lines: &pb.LargeJournalEntryLines{Lines: lines},
+			},
+		}
+	}
+
+	newStream := func(requests ...*pb.CreateLargeJournalEntryRequest) *fakeLargeEntryStream {
+		return &fakeLargeEntryStream{ctx: ctx, requests: requests}
+	}
+
+	t.Run("posts lines streamed in chunks as one entry", func(t *testing.T) {
+		stream := newStream(header("PAY-1", 6, "300"), payslips(1), payslips(1), payslips(1))
+
+		require.NoError(t, service.CreateLargeJournalEntry(stream))
+
+		require.NotNil(t, stream.response)
+		assert.Equal(t, "PAY-1", stream.response.ReferenceNumber)
+		assert.Equal(t, int32(6), stream.response.LineCount)
+		assert.Equal(t, "300", stream.response.TotalDebit)
+		assert.Equal(t, "300", stream.response.TotalCredit)
+
+		entry, err := service.GetJournalEntry(ctx, &pb.GetJournalEntryRequest{TenantId: tenantID, JournalEntryId: stream.response.JournalEntryId})
+		require.NoError(t, err)
+		assert.Len(t, entry.JournalEntry.Lines, 6)
+	})
+
+	t.Run("fails as soon as the lines exceed a control total", func(t *testing.T) {
+		stream := newStream(header("PAY-2", 6, "100"), payslips(1), payslips(1), payslips(1))
+
+		err := service.CreateLargeJournalEntry(stream)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Contains(t, status.Convert(err).Message(), "at line 2")
+		assert.Len(t, stream.requests, 1)
+	})
+
+	t.Run("rejects a truncated upload", func(t *testing.T) {
+		stream := newStream(header("PAY-3", 6, "300"), payslips(1), payslips(1))
+
+		err := service.CreateLargeJournalEntry(stream)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Contains(t, status.Convert(err).Message(), "received 4 lines, expected 6")
+	})
+
+	t.Run("rejects an unbalanced entry", func(t *testing.T) {
+		stream := newStream(header("PAY-4", 3, "200"), payslips(2))
+
+		err := service.CreateLargeJournalEntry(stream)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("enforces the streamed line limit", func(t *testing.T) {
+		stream := newStream(header("PAY-5", 102, "10100"), payslips(101))
+
+		err := service.CreateLargeJournalEntry(stream)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Contains(t, status.Convert(err).Message(), "the limit is 100 lines")
+	})
+
+	t.Run("requires the header first and only once", func(t *testing.T) {
+		err := service.CreateLargeJournalEntry(newStream(payslips(1)))
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		err = service.CreateLargeJournalEntry(newStream(header("PAY-6", 2, "100"), header("PAY-6", 2, "100")))
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
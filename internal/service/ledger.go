@@ -2,12 +2,22 @@ package service
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/eventsig"
+	"github.com/hesabFun/ledger/internal/export"
 	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/internal/statementrun"
+	"github.com/hesabFun/ledger/internal/watch"
 	"github.com/shopspring/decimal"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -18,10 +28,35 @@ import (
 // LedgerService implements the gRPC LedgerService
 type LedgerService struct {
 	pb.UnimplementedLedgerServiceServer
-	tenantRepo    repository.TenantRepositoryInterface
-	accountRepo   repository.AccountRepositoryInterface
-	journalRepo   repository.JournalRepositoryInterface
-	referenceRepo repository.ReferenceRepositoryInterface
+	tenantRepo      repository.TenantRepositoryInterface
+	accountRepo     repository.AccountRepositoryInterface
+	journalRepo     repository.JournalRepositoryInterface
+	referenceRepo   repository.ReferenceRepositoryInterface
+	quotaRepo       repository.QuotaRepositoryInterface
+	settingsRepo    repository.TenantSettingsRepositoryInterface
+	budgetRepo      repository.BudgetRepositoryInterface
+	closeRepo       repository.CloseRepositoryInterface
+	exporter        *export.Exporter
+	statementRunner *statementrun.Runner
+	policyRepo      repository.PostingPolicyRepositoryInterface
+	eventRepo       repository.EventRepositoryInterface
+	eventSigner     *eventsig.Signer
+	taxRepo         repository.TaxCodeRepositoryInterface
+	partyRepo       repository.PartyRepositoryInterface
+	dimensionRepo   repository.DimensionRepositoryInterface
+	bookRepo        repository.BookRepositoryInterface
+	broker          *watch.Broker
+	holdRepo        repository.HoldRepositoryInterface
+	preparedRepo    repository.PreparedEntryRepositoryInterface
+	batchRepo       repository.JournalBatchRepositoryInterface
+	reportRepo      repository.ReportRepositoryInterface
+	consistencyRepo repository.ConsistencyRepositoryInterface
+	sequenceRepo    repository.ReferenceSequenceRepositoryInterface
+	digestRepo      repository.DigestRepositoryInterface
+	limits          EntryLimits
+
+	// auditPollPeriod is how often caught-up audit streams look for new events
+	auditPollPeriod time.Duration
 }
 
 // NewLedgerService creates a new ledger service
@@ -30,77 +65,72 @@ func NewLedgerService(
 	accountRepo repository.AccountRepositoryInterface,
 	journalRepo repository.JournalRepositoryInterface,
 	referenceRepo repository.ReferenceRepositoryInterface,
+	opts ...Option,
 ) *LedgerService {
+	o := applyOptions(opts)
 	return &LedgerService{
-		tenantRepo:    tenantRepo,
-		accountRepo:   accountRepo,
-		journalRepo:   journalRepo,
-		referenceRepo: referenceRepo,
+		tenantRepo:      tenantRepo,
+		accountRepo:     accountRepo,
+		journalRepo:     journalRepo,
+		referenceRepo:   referenceRepo,
+		quotaRepo:       o.quotaRepo,
+		settingsRepo:    o.settingsRepo,
+		budgetRepo:      o.budgetRepo,
+		closeRepo:       o.closeRepo,
+		exporter:        o.exporter,
+		statementRunner: o.statementRunner,
+		policyRepo:      o.policyRepo,
+		eventRepo:       o.eventRepo,
+		eventSigner:     o.eventSigner,
+		taxRepo:         o.taxRepo,
+		partyRepo:       o.partyRepo,
+		dimensionRepo:   o.dimensionRepo,
+		bookRepo:        o.bookRepo,
+		broker:          o.broker,
+		holdRepo:        o.holdRepo,
+		preparedRepo:    o.preparedRepo,
+		batchRepo:       o.batchRepo,
+		reportRepo:      o.reportRepo,
+		consistencyRepo: o.consistencyRepo,
+		sequenceRepo:    o.sequenceRepo,
+		digestRepo:      o.digestRepo,
+		limits:          o.limits,
+		auditPollPeriod: defaultAuditPollPeriod,
 	}
 }
 
-// CreateTenant creates a new tenant
-func (s *LedgerService) CreateTenant(ctx context.Context, req *pb.CreateTenantRequest) (*pb.CreateTenantResponse, error) {
-	if req.Name == "" {
-		return nil, status.Error(codes.InvalidArgument, "tenant name is required")
-	}
-
-	var tenantUUID *uuid.UUID
-	if req.Uuid != nil && *req.Uuid != "" {
-		parsed, err := uuid.Parse(*req.Uuid)
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, "invalid UUID format")
-		}
-		tenantUUID = &parsed
-	}
-
-	tenant, err := s.tenantRepo.Create(ctx, req.Name, tenantUUID)
+// requestID parses an ID field of a request, rejecting a value that is not
+// a UUID with a field violation. The validation interceptor checks the same
+// rule, but the v2 service and in-process callers reach the handlers without
+// it.
+func requestID(field, value string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create tenant: %v", err)
+		name := field[strings.LastIndex(field, ".")+1:]
+		name = strings.TrimSuffix(strings.TrimSuffix(name, "_ids"), "_id")
+		return uuid.Nil, invalidField(field, "invalid "+strings.ReplaceAll(name, "_", " ")+" ID")
 	}
-
-	return &pb.CreateTenantResponse{
-		TenantId:  tenant.ID.String(),
-		Name:      tenant.Name,
-		CreatedAt: timestamppb.New(tenant.CreatedAt),
-	}, nil
+	return id, nil
 }
 
-// GetTenant retrieves a tenant by ID
-func (s *LedgerService) GetTenant(ctx context.Context, req *pb.GetTenantRequest) (*pb.GetTenantResponse, error) {
-	tenantID, err := uuid.Parse(req.TenantId)
-	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid tenant ID")
+// optionalRequestID parses an optional ID field of a request like requestID,
+// returning nil when it is absent or empty
+func optionalRequestID(field string, value *string) (*uuid.UUID, error) {
+	if value == nil || *value == "" {
+		return nil, nil
 	}
-
-	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	id, err := requestID(field, *value)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "tenant not found: %v", err)
+		return nil, err
 	}
-
-	return &pb.GetTenantResponse{
-		Tenant: &pb.Tenant{
-			TenantId:  tenant.ID.String(),
-			Name:      tenant.Name,
-			CreatedAt: timestamppb.New(tenant.CreatedAt),
-			UpdatedAt: timestamppb.New(tenant.UpdatedAt),
-		},
-	}, nil
+	return &id, nil
 }
 
 // CreateAccount creates a new account
 func (s *LedgerService) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
-	tenantID, err := uuid.Parse(req.TenantId)
+	tenantID, err := requestID("tenant_id", req.TenantId)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid tenant ID")
-	}
-
-	if req.AccountNumber == "" {
-		return nil, status.Error(codes.InvalidArgument, "account number is required")
-	}
-
-	if req.Name == "" {
-		return nil, status.Error(codes.InvalidArgument, "account name is required")
+		return nil, err
 	}
 
 	params := repository.CreateAccountParams{
@@ -110,21 +140,37 @@ func (s *LedgerService) CreateAccount(ctx context.Context, req *pb.CreateAccount
 		CurrencyCode:  req.CurrencyCode,
 	}
 
+	if params.ParentAccountID, err = optionalRequestID("parent_account_id", req.ParentAccountId); err != nil {
+		return nil, err
+	}
+	if params.BookID, err = optionalRequestID("book_id", req.BookId); err != nil {
+		return nil, err
+	}
+
 	if req.Description != "" {
 		params.Description = &req.Description
 	}
 
-	if req.ParentAccountId != nil {
-		parentID, err := uuid.Parse(*req.ParentAccountId)
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, "invalid parent account ID")
-		}
-		params.ParentAccountID = &parentID
+	if params.OverdraftLimit, err = parseOverdraftLimit(req.OverdraftLimit); err != nil {
+		return nil, err
+	}
+
+	if err := checkLabels("labels", req.Labels); err != nil {
+		return nil, err
+	}
+	if err := checkExternalIDs("external_ids", req.ExternalIds); err != nil {
+		return nil, err
+	}
+	params.Labels = req.Labels
+	params.ExternalIDs = req.ExternalIds
+
+	if params.MaxAccounts, err = s.accountQuota(ctx, tenantID); err != nil {
+		return nil, err
 	}
 
 	account, err := s.accountRepo.Create(ctx, tenantID, params)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create account: %v", err)
+		return nil, repositoryError("create account", err)
 	}
 
 	return &pb.CreateAccountResponse{
@@ -133,24 +179,25 @@ func (s *LedgerService) CreateAccount(ctx context.Context, req *pb.CreateAccount
 		AccountNumber: account.AccountNumber,
 		Name:          account.Name,
 		CreatedAt:     timestamppb.New(account.CreatedAt),
+		BookId:        account.BookID.String(),
 	}, nil
 }
 
 // GetAccount retrieves an account by ID
 func (s *LedgerService) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
-	tenantID, err := uuid.Parse(req.TenantId)
+	tenantID, err := requestID("tenant_id", req.TenantId)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid tenant ID")
+		return nil, err
 	}
 
-	accountID, err := uuid.Parse(req.AccountId)
+	accountID, err := requestID("account_id", req.AccountId)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid account ID")
+		return nil, err
 	}
 
 	account, err := s.accountRepo.GetByID(ctx, tenantID, accountID)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "account not found: %v", err)
+		return nil, repositoryError("get account", err)
 	}
 
 	return &pb.GetAccountResponse{
@@ -160,9 +207,9 @@ func (s *LedgerService) GetAccount(ctx context.Context, req *pb.GetAccountReques
 
 // ListAccounts retrieves accounts with optional filters
 func (s *LedgerService) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
-	tenantID, err := uuid.Parse(req.TenantId)
+	tenantID, err := requestID("tenant_id", req.TenantId)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid tenant ID")
+		return nil, err
 	}
 
 	page := int(req.GetPage())
@@ -180,19 +227,45 @@ func (s *LedgerService) ListAccounts(ctx context.Context, req *pb.ListAccountsRe
 
 	offset := (page - 1) * pageSize
 
-	var accountTypeID *int32
-	if req.AccountTypeId != nil {
-		accountTypeID = req.AccountTypeId
+	filter := repository.AccountFilter{
+		AccountTypeID:  req.AccountTypeId,
+		CurrencyCode:   req.CurrencyCode,
+		NamePrefix:     req.NamePrefix,
+		NumberPrefix:   req.NumberPrefix,
+		IsActive:       req.IsActive,
+		IncludeDeleted: req.IncludeDeleted,
+		SortDescending: req.SortDirection == pb.SortDirection_SORT_DIRECTION_DESC,
 	}
 
-	var currencyCode *string
-	if req.CurrencyCode != nil {
-		currencyCode = req.CurrencyCode
+	if filter.ParentAccountID, err = optionalRequestID("parent_account_id", req.ParentAccountId); err != nil {
+		return nil, err
 	}
 
-	accounts, totalCount, err := s.accountRepo.List(ctx, tenantID, accountTypeID, currencyCode, pageSize, offset)
+	if filter.AncestorAccountID, err = optionalRequestID("ancestor_account_id", req.AncestorAccountId); err != nil {
+		return nil, err
+	}
+
+	if filter.BookID, err = optionalRequestID("book_id", req.BookId); err != nil {
+		return nil, err
+	}
+
+	if err := checkLabels("labels", req.Labels); err != nil {
+		return nil, err
+	}
+	filter.Labels = req.Labels
+
+	switch req.SortBy {
+	case pb.AccountSortField_ACCOUNT_SORT_FIELD_NUMBER:
+		filter.SortBy = repository.AccountSortNumber
+	case pb.AccountSortField_ACCOUNT_SORT_FIELD_NAME:
+		filter.SortBy = repository.AccountSortName
+	case pb.AccountSortField_ACCOUNT_SORT_FIELD_CREATED_AT:
+		filter.SortBy = repository.AccountSortCreatedAt
+	}
+
+	accounts, totalCount, err := s.accountRepo.List(ctx, tenantID, filter, pageSize, offset)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list accounts: %v", err)
+		return nil, repositoryError("list accounts", err)
 	}
 
 	pbAccounts := make([]*pb.Account, len(accounts))
@@ -208,58 +281,317 @@ func (s *LedgerService) ListAccounts(ctx context.Context, req *pb.ListAccountsRe
 
 // GetAccountBalance retrieves the balance for an account
 func (s *LedgerService) GetAccountBalance(ctx context.Context, req *pb.GetAccountBalanceRequest) (*pb.GetAccountBalanceResponse, error) {
-	tenantID, err := uuid.Parse(req.TenantId)
+	tenantID, err := requestID("tenant_id", req.TenantId)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid tenant ID")
+		return nil, err
 	}
 
-	accountID, err := uuid.Parse(req.AccountId)
+	accountID, err := requestID("account_id", req.AccountId)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid account ID")
+		return nil, err
+	}
+
+	if req.AsOf != nil || req.EffectiveAsOf != nil {
+		var postedAsOf, effectiveAsOf *time.Time
+		if req.AsOf != nil {
+			t := req.AsOf.AsTime()
+			postedAsOf = &t
+		}
+		if req.EffectiveAsOf != nil {
+			effectiveAsOf = dateOf(req.EffectiveAsOf)
+		}
+		return s.getAccountBalanceAsOf(ctx, tenantID, accountID, postedAsOf, effectiveAsOf)
 	}
 
 	balance, err := s.accountRepo.GetBalance(ctx, tenantID, accountID)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "balance not found: %v", err)
+		return nil, repositoryError("get account balance", err)
 	}
 
 	netBalance := balance.DebitBalance.Sub(balance.CreditBalance)
 
-	return &pb.GetAccountBalanceResponse{
+	resp := &pb.GetAccountBalanceResponse{
 		AccountId:     balance.AccountID.String(),
 		DebitBalance:  balance.DebitBalance.String(),
 		CreditBalance: balance.CreditBalance.String(),
 		NetBalance:    netBalance.String(),
 		UpdatedAt:     timestamppb.New(balance.UpdatedAt),
+	}
+
+	available, err := s.accountRepo.GetAvailableBalance(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, repositoryError("get available balance", err)
+	}
+	heldAmount, availableBalance := available.Held.String(), available.Available.String()
+	resp.HeldAmount = &heldAmount
+	resp.AvailableBalance = &availableBalance
+
+	return resp, nil
+}
+
+// DeleteAccount soft-deletes an account
+func (s *LedgerService) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.Delete(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, repositoryError("delete account", err)
+	}
+
+	return &pb.DeleteAccountResponse{
+		Account: s.accountToProto(account),
+	}, nil
+}
+
+// RestoreAccount restores a soft-deleted account, which counts against the
+// tenant's account quota again
+func (s *LedgerService) RestoreAccount(ctx context.Context, req *pb.RestoreAccountRequest) (*pb.RestoreAccountResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAccounts, err := s.accountQuota(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.Restore(ctx, tenantID, accountID, maxAccounts)
+	if err != nil {
+		return nil, repositoryError("restore account", err)
+	}
+
+	return &pb.RestoreAccountResponse{
+		Account: s.accountToProto(account),
+	}, nil
+}
+
+// SetAccountOverdraftLimit sets how far postings and holds may take the
+// available balance of an account below zero. Without a limit the account
+// is no longer checked.
+func (s *LedgerService) SetAccountOverdraftLimit(ctx context.Context, req *pb.SetAccountOverdraftLimitRequest) (*pb.SetAccountOverdraftLimitResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := parseOverdraftLimit(req.OverdraftLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.SetOverdraftLimit(ctx, tenantID, accountID, limit)
+	if err != nil {
+		return nil, repositoryError("set overdraft limit", err)
+	}
+
+	return &pb.SetAccountOverdraftLimitResponse{
+		Account: s.accountToProto(account),
 	}, nil
 }
 
+// parseOverdraftLimit parses an optional overdraft limit, which must not be
+// negative
+func parseOverdraftLimit(limit *string) (*decimal.Decimal, error) {
+	if limit == nil {
+		return nil, nil
+	}
+
+	value, err := decimal.NewFromString(*limit)
+	if err != nil || value.IsNegative() {
+		return nil, invalidField("overdraft_limit", "overdraft limit must be a non-negative number")
+	}
+
+	return &value, nil
+}
+
+// MoveAccount moves an account with its descendants under another account
+// of the same type, or makes it a root account. Moves that would make the
+// account its own ancestor are rejected.
+func (s *LedgerService) MoveAccount(ctx context.Context, req *pb.MoveAccountRequest) (*pb.MoveAccountResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	var parentID *uuid.UUID
+	if parentID, err = optionalRequestID("parent_account_id", req.ParentAccountId); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.Move(ctx, tenantID, accountID, parentID)
+	if err != nil {
+		return nil, repositoryError("move account", err)
+	}
+
+	return &pb.MoveAccountResponse{
+		Account: s.accountToProto(account),
+	}, nil
+}
+
+// MergeAccounts moves the balance of one account to another of the same
+// type and currency and closes it, so duplicate accounts can be folded
+// together when cleaning up the chart of accounts. Posted lines are not
+// repointed: the balance moves with a reclassification entry referenced
+// MERGE-<source account number>-<merge ID>, dated today in the tenant's
+// timezone and checked against the posting policy like any other entry.
+func (s *LedgerService) MergeAccounts(ctx context.Context, req *pb.MergeAccountsRequest) (*pb.MergeAccountsResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceID, err := requestID("source_account_id", req.SourceAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	targetID, err := requestID("target_account_id", req.TargetAccountId)
+	if err != nil {
+		return nil, err
+	}
+	if targetID == sourceID {
+		return nil, invalidField("target_account_id", "an account cannot be merged into itself")
+	}
+
+	clock, err := s.clock(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	entryDate := clock.today()
+	if _, err := s.checkPostingPolicy(ctx, tenantID, clock, entryDate, nil); err != nil {
+		return nil, err
+	}
+
+	merge, err := s.accountRepo.Merge(ctx, tenantID, sourceID, targetID, entryDate)
+	if err != nil {
+		return nil, repositoryError("merge accounts", err)
+	}
+
+	resp := &pb.MergeAccountsResponse{
+		SourceAccount: s.accountToProto(merge.Source),
+		TargetAccount: s.accountToProto(merge.Target),
+	}
+	if merge.ReclassificationEntryID != nil {
+		id := merge.ReclassificationEntryID.String()
+		resp.ReclassificationEntryId = &id
+	}
+
+	return resp, nil
+}
+
 // CreateJournalEntry creates a new journal entry
 func (s *LedgerService) CreateJournalEntry(ctx context.Context, req *pb.CreateJournalEntryRequest) (*pb.CreateJournalEntryResponse, error) {
-	tenantID, err := uuid.Parse(req.TenantId)
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := s.createJournalEntry(ctx, tenantID, req, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateJournalEntryResponse{
+		JournalEntryId:  entry.ID.String(),
+		TenantId:        entry.TenantID.String(),
+		ReferenceNumber: entry.ReferenceNumber,
+		EntryDate:       timestamppb.New(entry.EntryDate),
+		CreatedAt:       timestamppb.New(entry.CreatedAt),
+	}, nil
+}
+
+// createJournalEntry validates and posts a journal entry, recording the
+// idempotency key when one is given
+func (s *LedgerService) createJournalEntry(ctx context.Context, tenantID uuid.UUID, req *pb.CreateJournalEntryRequest, idempotencyKey string) (*repository.JournalEntry, error) {
+	params, err := s.journalEntryParams(ctx, tenantID, req, s.limits)
+	if err != nil {
+		return nil, err
+	}
+	params.IdempotencyKey = idempotencyKey
+
+	entry, err := s.journalRepo.Create(ctx, tenantID, params)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid tenant ID")
+		return nil, repositoryError("create journal entry", err)
 	}
 
+	return entry, nil
+}
+
+// journalEntryParams validates a journal entry request within limits and
+// builds the parameters to post it, including generated tax lines
+func (s *LedgerService) journalEntryParams(ctx context.Context, tenantID uuid.UUID, req *pb.CreateJournalEntryRequest, limits EntryLimits) (repository.CreateJournalEntryParams, error) {
 	if len(req.Lines) < 2 {
-		return nil, status.Error(codes.InvalidArgument, "journal entry must have at least two lines")
+		return repository.CreateJournalEntryParams{}, status.Error(codes.InvalidArgument, "journal entry must have at least two lines")
+	}
+
+	if err := limits.checkEntry(req); err != nil {
+		return repository.CreateJournalEntryParams{}, err
+	}
+
+	bookID, err := parseBookID(req.BookId)
+	if err != nil {
+		return repository.CreateJournalEntryParams{}, err
+	}
+
+	clock, err := s.clock(ctx, tenantID)
+	if err != nil {
+		return repository.CreateJournalEntryParams{}, err
+	}
+
+	entryDate, err := dateField("entry_date", req.EntryDate, req.JalaliEntryDate)
+	if err != nil {
+		return repository.CreateJournalEntryParams{}, err
+	}
+	if entryDate == nil {
+		today := clock.today()
+		entryDate = &today
+	}
+
+	overridden, err := s.checkPostingPolicy(ctx, tenantID, clock, *entryDate, req.LockOverrideReason)
+	if err != nil {
+		return repository.CreateJournalEntryParams{}, err
 	}
 
 	lines := make([]*repository.CreateJournalEntryLineParams, len(req.Lines))
 	for i, line := range req.Lines {
 		accountID, err := uuid.Parse(line.AccountId)
 		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid account ID at line %d", i)
+			return repository.CreateJournalEntryParams{}, invalidLine(i, "account_id", "invalid account ID at line %d", i)
 		}
 
 		debit, err := decimal.NewFromString(line.Debit)
 		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid debit amount at line %d", i)
+			return repository.CreateJournalEntryParams{}, invalidLine(i, "debit", "invalid debit amount at line %d", i)
 		}
 
 		credit, err := decimal.NewFromString(line.Credit)
 		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid credit amount at line %d", i)
+			return repository.CreateJournalEntryParams{}, invalidLine(i, "credit", "invalid credit amount at line %d", i)
 		}
 
 		lines[i] = &repository.CreateJournalEntryLineParams{
@@ -268,64 +600,283 @@ func (s *LedgerService) CreateJournalEntry(ctx context.Context, req *pb.CreateJo
 			Credit:      credit,
 			Description: line.Description,
 		}
+
+		if line.CounterpartyTenantId != nil && *line.CounterpartyTenantId != "" {
+			counterpartyID, err := uuid.Parse(*line.CounterpartyTenantId)
+			if err != nil {
+				return repository.CreateJournalEntryParams{}, invalidLine(i, "counterparty_tenant_id", "invalid counterparty tenant ID at line %d", i)
+			}
+			if counterpartyID == tenantID {
+				return repository.CreateJournalEntryParams{}, invalidLine(i, "counterparty_tenant_id", "counterparty tenant must differ from the tenant at line %d", i)
+			}
+			lines[i].CounterpartyTenantID = &counterpartyID
+		}
+
+		if line.FxRate != nil && *line.FxRate != "" {
+			rate, err := decimal.NewFromString(*line.FxRate)
+			if err != nil || !rate.IsPositive() {
+				return repository.CreateJournalEntryParams{}, invalidLine(i, "fx_rate", "invalid fx rate at line %d", i)
+			}
+			lines[i].FxRate = &rate
+		}
+
+		if line.IsTax {
+			return repository.CreateJournalEntryParams{}, invalidLine(i, "is_tax", "tax lines are generated from tax codes and cannot be submitted at line %d", i)
+		}
+
+		if line.TaxCodeId != nil && *line.TaxCodeId != "" {
+			taxCodeID, err := uuid.Parse(*line.TaxCodeId)
+			if err != nil {
+				return repository.CreateJournalEntryParams{}, invalidLine(i, "tax_code_id", "invalid tax code ID at line %d", i)
+			}
+			lines[i].TaxCodeID = &taxCodeID
+		}
+
+		if line.PartyId != nil && *line.PartyId != "" {
+			partyID, err := uuid.Parse(*line.PartyId)
+			if err != nil {
+				return repository.CreateJournalEntryParams{}, invalidLine(i, "party_id", "invalid party ID at line %d", i)
+			}
+			lines[i].PartyID = &partyID
+		}
+
+		if len(line.Dimensions) > 0 {
+			lines[i].Dimensions = line.Dimensions
+		}
+	}
+
+	if err := s.checkLineParties(ctx, tenantID, lines); err != nil {
+		return repository.CreateJournalEntryParams{}, err
+	}
+
+	if err := s.checkLineDimensions(ctx, tenantID, lines); err != nil {
+		return repository.CreateJournalEntryParams{}, err
+	}
+
+	lines, err = s.addTaxLines(ctx, tenantID, lines)
+	if err != nil {
+		return repository.CreateJournalEntryParams{}, err
+	}
+
+	if err := checkBalanced(lines); err != nil {
+		return repository.CreateJournalEntryParams{}, err
+	}
+
+	if err := s.checkLineCurrencies(ctx, tenantID, req.GetCurrencyCode(), lines); err != nil {
+		return repository.CreateJournalEntryParams{}, err
 	}
 
 	var metadata map[string]interface{}
 	if req.Metadata != nil && *req.Metadata != "" {
 		if err := json.Unmarshal([]byte(*req.Metadata), &metadata); err != nil {
-			return nil, status.Error(codes.InvalidArgument, "invalid metadata JSON")
+			return repository.CreateJournalEntryParams{}, status.Error(codes.InvalidArgument, "invalid metadata JSON")
+		}
+		if _, ok := metadata[repository.SealedMetadataKey]; ok {
+			return repository.CreateJournalEntryParams{}, invalidField("metadata", "metadata key "+repository.SealedMetadataKey+" is reserved")
+		}
+	}
+
+	// Numbers are claimed only once the entry is valid, to keep gaps rare
+	referenceNumber := req.ReferenceNumber
+	if referenceNumber == "" && s.sequenceRepo != nil {
+		referenceNumber, err = s.sequenceRepo.Next(ctx, tenantID, *entryDate)
+		if err != nil {
+			return repository.CreateJournalEntryParams{}, repositoryError("generate reference number", err)
 		}
 	}
 
 	params := repository.CreateJournalEntryParams{
-		ReferenceNumber: req.ReferenceNumber,
+		ReferenceNumber: referenceNumber,
 		Description:     req.Description,
-		EntryDate:       req.EntryDate.AsTime(),
+		EntryDate:       *entryDate,
 		Metadata:        metadata,
 		Lines:           lines,
+		TransactionID:   req.GetTransactionId(),
+		BookID:          bookID,
+		// Without a currency the lines in the first account's currency
+		// identify it, so only an explicit one is recorded
+		CurrencyCode: req.GetCurrencyCode(),
 	}
 
-	entry, err := s.journalRepo.Create(ctx, tenantID, params)
+	if overridden {
+		params.LockOverrideReason = req.LockOverrideReason
+	}
+
+	return params, nil
+}
+
+// checkBalanced rejects entries whose debits and credits differ once tax
+// lines have been generated
+func checkBalanced(lines []*repository.CreateJournalEntryLineParams) error {
+	totalDebit, totalCredit := decimal.Zero, decimal.Zero
+	for _, line := range lines {
+		totalDebit = totalDebit.Add(line.Debit)
+		totalCredit = totalCredit.Add(line.Credit)
+	}
+
+	if totalDebit.Equal(totalCredit) {
+		return nil
+	}
+
+	return detailedError(codes.InvalidArgument,
+		fmt.Sprintf("journal entry is not balanced: debits %s, credits %s", totalDebit, totalCredit),
+		errorInfo(reasonUnbalancedEntry, map[string]string{
+			"total_debit":  totalDebit.String(),
+			"total_credit": totalCredit.String(),
+		}),
+	)
+}
+
+// checkLineCurrencies validates each line against the currency of its account.
+// A line must be in the entry currency unless it carries an fx rate, in which
+// case its amounts are in its account's currency. Amounts may not have more
+// decimal places than their currency allows, instead of letting the database
+// round them, and the lines of each currency must balance on their own, as
+// the conversion lines of a transfer make them. The entry currency defaults to
+// the currency of the first line's account. Unknown accounts are left for the
+// repository to report.
+func (s *LedgerService) checkLineCurrencies(ctx context.Context, tenantID uuid.UUID, entryCurrency string, lines []*repository.CreateJournalEntryLineParams) error {
+	accountIDs := make([]uuid.UUID, 0, len(lines))
+	seen := make(map[uuid.UUID]bool, len(lines))
+	for _, line := range lines {
+		if !seen[line.AccountID] {
+			seen[line.AccountID] = true
+			accountIDs = append(accountIDs, line.AccountID)
+		}
+	}
+
+	currencies, err := s.accountRepo.AccountCurrencies(ctx, tenantID, accountIDs)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create journal entry: %v", err)
+		return repositoryError("get account currencies", err)
 	}
 
-	return &pb.CreateJournalEntryResponse{
-		JournalEntryId:  entry.ID.String(),
-		TenantId:        entry.TenantID.String(),
-		ReferenceNumber: entry.ReferenceNumber,
-		EntryDate:       timestamppb.New(entry.EntryDate),
-		CreatedAt:       timestamppb.New(entry.CreatedAt),
-	}, nil
+	var precision int32
+	if entryCurrency != "" {
+		currency, err := s.findCurrency(ctx, entryCurrency)
+		if err != nil {
+			return err
+		}
+		precision = currency.Precision
+	} else if first, ok := currencies[lines[0].AccountID]; ok {
+		entryCurrency, precision = first.CurrencyCode, first.Precision
+	} else {
+		return nil
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	totals := make(map[string]*currencyTotal)
+	complete := true
+	for i, line := range lines {
+		account, ok := currencies[line.AccountID]
+		if !ok {
+			complete = false
+			continue
+		}
+
+		total, ok := totals[account.CurrencyCode]
+		if !ok {
+			total = &currencyTotal{debit: decimal.Zero, credit: decimal.Zero}
+			totals[account.CurrencyCode] = total
+		}
+		total.debit = total.debit.Add(line.Debit)
+		total.credit = total.credit.Add(line.Credit)
+
+		switch {
+		case account.CurrencyCode != entryCurrency && line.FxRate == nil:
+			violations = append(violations, lineViolation(i, "account_id",
+				"account currency %s does not match entry currency %s and no fx_rate is given", account.CurrencyCode, entryCurrency))
+		case account.CurrencyCode == entryCurrency && line.FxRate != nil:
+			violations = append(violations, lineViolation(i, "fx_rate",
+				"fx_rate is only allowed when the account currency differs from entry currency %s", entryCurrency))
+		}
+
+		lineCurrency, linePrecision := entryCurrency, precision
+		if line.FxRate != nil {
+			lineCurrency, linePrecision = account.CurrencyCode, account.Precision
+		}
+		if !line.Debit.Equal(line.Debit.Truncate(linePrecision)) {
+			violations = append(violations, lineViolation(i, "debit",
+				"amount has more than %d decimal places allowed by %s", linePrecision, lineCurrency))
+		}
+		if !line.Credit.Equal(line.Credit.Truncate(linePrecision)) {
+			violations = append(violations, lineViolation(i, "credit",
+				"amount has more than %d decimal places allowed by %s", linePrecision, lineCurrency))
+		}
+	}
+
+	if len(violations) > 0 {
+		return lineViolations(violations)
+	}
+
+	if !complete || len(totals) == 1 {
+		return nil
+	}
+
+	for _, code := range slices.Sorted(maps.Keys(totals)) {
+		if total := totals[code]; !total.debit.Equal(total.credit) {
+			return detailedError(codes.InvalidArgument,
+				fmt.Sprintf("journal entry is not balanced in %s: debits %s, credits %s", code, total.debit, total.credit),
+				errorInfo(reasonUnbalancedEntry, map[string]string{
+					"currency_code": code,
+					"total_debit":   total.debit.String(),
+					"total_credit":  total.credit.String(),
+				}),
+			)
+		}
+	}
+
+	return nil
+}
+
+// currencyTotal sums the lines of an entry in one currency
+type currencyTotal struct {
+	debit  decimal.Decimal
+	credit decimal.Decimal
+}
+
+// findCurrency looks up a currency in the reference data
+func (s *LedgerService) findCurrency(ctx context.Context, code string) (*repository.Currency, error) {
+	currencies, err := s.referenceRepo.ListCurrencies(ctx)
+	if err != nil {
+		return nil, repositoryError("list currencies", err)
+	}
+
+	for _, currency := range currencies {
+		if currency.Code == code {
+			return currency, nil
+		}
+	}
+
+	return nil, status.Errorf(codes.InvalidArgument, "unknown currency %q", code)
 }
 
 // GetJournalEntry retrieves a journal entry by ID
 func (s *LedgerService) GetJournalEntry(ctx context.Context, req *pb.GetJournalEntryRequest) (*pb.GetJournalEntryResponse, error) {
-	tenantID, err := uuid.Parse(req.TenantId)
+	tenantID, err := requestID("tenant_id", req.TenantId)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid tenant ID")
+		return nil, err
 	}
 
-	journalEntryID, err := uuid.Parse(req.JournalEntryId)
+	journalEntryID, err := requestID("journal_entry_id", req.JournalEntryId)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid journal entry ID")
+		return nil, err
 	}
 
 	entry, err := s.journalRepo.GetByID(ctx, tenantID, journalEntryID)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "journal entry not found: %v", err)
+		return nil, repositoryError("get journal entry", err)
 	}
 
 	return &pb.GetJournalEntryResponse{
-		JournalEntry: s.journalEntryToProto(entry),
+		JournalEntry: journalEntryToProto(entry),
 	}, nil
 }
 
 // ListJournalEntries retrieves journal entries with optional filters
 func (s *LedgerService) ListJournalEntries(ctx context.Context, req *pb.ListJournalEntriesRequest) (*pb.ListJournalEntriesResponse, error) {
-	tenantID, err := uuid.Parse(req.TenantId)
+	tenantID, err := requestID("tenant_id", req.TenantId)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid tenant ID")
+		return nil, err
 	}
 
 	page := int(req.GetPage())
@@ -343,56 +894,184 @@ func (s *LedgerService) ListJournalEntries(ctx context.Context, req *pb.ListJour
 
 	offset := (page - 1) * pageSize
 
-	var accountID *uuid.UUID
-	if req.AccountId != nil {
-		aid, err := uuid.Parse(*req.AccountId)
+	filter := repository.JournalEntryFilter{
+		ReferenceNumber:     req.ReferenceNumber,
+		ReferencePrefix:     req.ReferencePrefix,
+		DescriptionContains: req.DescriptionContains,
+	}
+
+	if filter.AccountID, err = optionalRequestID("account_id", req.AccountId); err != nil {
+		return nil, err
+	}
+
+	if filter.BookID, err = optionalRequestID("book_id", req.BookId); err != nil {
+		return nil, err
+	}
+
+	filter.FromDate = dateOf(req.FromDate)
+	filter.ToDate = dateOf(req.ToDate)
+
+	if req.PostedFrom != nil {
+		t := req.PostedFrom.AsTime()
+		filter.PostedFrom = &t
+	}
+	if req.PostedTo != nil {
+		t := req.PostedTo.AsTime()
+		filter.PostedTo = &t
+	}
+
+	if req.MinAmount != nil {
+		amount, err := decimal.NewFromString(*req.MinAmount)
 		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, "invalid account ID")
+			return nil, status.Error(codes.InvalidArgument, "invalid min amount")
 		}
-		accountID = &aid
+		filter.MinAmount = &amount
 	}
-
-	var fromTime, toTime *time.Time
-	if req.FromDate != nil {
-		t := req.FromDate.AsTime()
-		fromTime = &t
+	if req.MaxAmount != nil {
+		amount, err := decimal.NewFromString(*req.MaxAmount)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid max amount")
+		}
+		filter.MaxAmount = &amount
 	}
-	if req.ToDate != nil {
-		t := req.ToDate.AsTime()
-		toTime = &t
+	if filter.MinAmount != nil && filter.MaxAmount != nil && filter.MinAmount.GreaterThan(*filter.MaxAmount) {
+		return nil, status.Error(codes.InvalidArgument, "min amount must not exceed max amount")
 	}
 
-	entries, totalCount, err := s.journalRepo.List(ctx, tenantID, accountID, fromTime, toTime, pageSize, offset)
+	entries, totals, err := s.journalRepo.List(ctx, tenantID, filter, pageSize, offset)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list journal entries: %v", err)
+		return nil, repositoryError("list journal entries", err)
 	}
 
 	pbEntries := make([]*pb.JournalEntry, len(entries))
 	for i, entry := range entries {
-		pbEntries[i] = s.journalEntryToProto(entry)
+		pbEntries[i] = journalEntryToProto(entry)
 	}
 
 	return &pb.ListJournalEntriesResponse{
+		JournalEntries: pbEntries,
+		TotalCount:     int32(totals.EntryCount),
+		Totals: &pb.JournalEntryTotals{
+			EntryCount:  int32(totals.EntryCount),
+			TotalDebit:  totals.Debit.String(),
+			TotalCredit: totals.Credit.String(),
+		},
+	}, nil
+}
+
+// SearchJournalEntries finds journal entries by the words in their description,
+// reference number, line descriptions and metadata values
+func (s *LedgerService) SearchJournalEntries(ctx context.Context, req *pb.SearchJournalEntriesRequest) (*pb.SearchJournalEntriesResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.TrimSpace(req.Query)
+
+	page := int(req.GetPage())
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	fromTime, toTime := dateOf(req.FromDate), dateOf(req.ToDate)
+
+	entries, totalCount, err := s.journalRepo.Search(ctx, tenantID, query, fromTime, toTime, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, repositoryError("search journal entries", err)
+	}
+
+	pbEntries := make([]*pb.JournalEntry, len(entries))
+	for i, entry := range entries {
+		pbEntries[i] = journalEntryToProto(entry)
+	}
+
+	return &pb.SearchJournalEntriesResponse{
 		JournalEntries: pbEntries,
 		TotalCount:     int32(totalCount),
 	}, nil
 }
 
-// ListAccountTypes retrieves all account types
+// ExportJournalEntries streams every journal entry of a tenant in a date range,
+// without the page size cap of ListJournalEntries
+func (s *LedgerService) ExportJournalEntries(req *pb.ExportJournalEntriesRequest, stream pb.LedgerService_ExportJournalEntriesServer) error {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return err
+	}
+
+	fromTime, toTime := dateOf(req.FromDate), dateOf(req.ToDate)
+	if fromTime != nil && toTime != nil && toTime.Before(*fromTime) {
+		return status.Error(codes.InvalidArgument, "to date must not be before from date")
+	}
+
+	var sendErr error
+	err = s.journalRepo.Stream(stream.Context(), tenantID, fromTime, toTime, func(entry *repository.JournalEntry) error {
+		sendErr = stream.Send(&pb.ExportJournalEntriesResponse{
+			JournalEntry: journalEntryToProto(entry),
+		})
+		return sendErr
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return repositoryError("export journal entries", err)
+	}
+
+	return nil
+}
+
+// VerifyLedgerIntegrity recomputes the hash chain over a tenant's journal
+// entries and reports whether any posted entry was changed or removed
+func (s *LedgerService) VerifyLedgerIntegrity(ctx context.Context, req *pb.VerifyLedgerIntegrityRequest) (*pb.VerifyLedgerIntegrityResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.journalRepo.VerifyIntegrity(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("verify ledger integrity", err)
+	}
+
+	resp := &pb.VerifyLedgerIntegrityResponse{
+		Valid:            result.Valid,
+		EntriesVerified:  int32(result.EntriesVerified),
+		UnchainedEntries: int32(result.UnchainedEntries),
+		HeadSequence:     result.HeadSequence,
+		HeadHash:         hex.EncodeToString(result.HeadHash),
+	}
+
+	if result.FirstInvalidEntryID != nil {
+		id := result.FirstInvalidEntryID.String()
+		resp.FirstInvalidEntryId = &id
+	}
+	if result.Reason != "" {
+		resp.Reason = &result.Reason
+	}
+
+	return resp, nil
+}
+
+// ListAccountTypes retrieves all account types, named in the requested locale
 func (s *LedgerService) ListAccountTypes(ctx context.Context, req *pb.ListAccountTypesRequest) (*pb.ListAccountTypesResponse, error) {
-	accountTypes, err := s.referenceRepo.ListAccountTypes(ctx)
+	accountTypes, err := localizedAccountTypes(ctx, s.referenceRepo, req.Locale)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list account types: %v", err)
+		return nil, err
 	}
 
 	pbAccountTypes := make([]*pb.AccountType, len(accountTypes))
 	for i, at := range accountTypes {
-		pbAccountTypes[i] = &pb.AccountType{
-			Id:            at.ID,
-			Code:          at.Code,
-			Name:          at.Name,
-			NormalBalance: at.NormalBalance,
-		}
+		pbAccountTypes[i] = accountTypeToProto(at)
 	}
 
 	return &pb.ListAccountTypesResponse{
@@ -400,22 +1079,16 @@ func (s *LedgerService) ListAccountTypes(ctx context.Context, req *pb.ListAccoun
 	}, nil
 }
 
-// ListCurrencies retrieves all currencies
+// ListCurrencies retrieves all currencies, named in the requested locale
 func (s *LedgerService) ListCurrencies(ctx context.Context, req *pb.ListCurrenciesRequest) (*pb.ListCurrenciesResponse, error) {
-	currencies, err := s.referenceRepo.ListCurrencies(ctx)
+	currencies, err := localizedCurrencies(ctx, s.referenceRepo, req.Locale)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list currencies: %v", err)
+		return nil, err
 	}
 
 	pbCurrencies := make([]*pb.Currency, len(currencies))
 	for i, c := range currencies {
-		pbCurrencies[i] = &pb.Currency{
-			Id:        c.ID,
-			Code:      c.Code,
-			Name:      c.Name,
-			Symbol:    c.Symbol,
-			Precision: c.Precision,
-		}
+		pbCurrencies[i] = currencyToProto(c)
 	}
 
 	return &pb.ListCurrenciesResponse{
@@ -429,6 +1102,7 @@ func (s *LedgerService) accountToProto(account *repository.Account) *pb.Account
 	pbAccount := &pb.Account{
 		AccountId:     account.ID.String(),
 		TenantId:      account.TenantID.String(),
+		BookId:        account.BookID.String(),
 		AccountNumber: account.AccountNumber,
 		Name:          account.Name,
 		Description:   "",
@@ -437,6 +1111,10 @@ func (s *LedgerService) accountToProto(account *repository.Account) *pb.Account
 		IsActive:      account.IsActive,
 		CreatedAt:     timestamppb.New(account.CreatedAt),
 		UpdatedAt:     timestamppb.New(account.UpdatedAt),
+		Depth:         account.Depth,
+		Path:          account.Path,
+		Labels:        account.Labels,
+		ExternalIds:   account.ExternalIDs,
 	}
 
 	if account.Description != nil {
@@ -448,10 +1126,19 @@ func (s *LedgerService) accountToProto(account *repository.Account) *pb.Account
 		pbAccount.ParentAccountId = &parentID
 	}
 
+	if account.DeletedAt != nil {
+		pbAccount.DeletedAt = timestamppb.New(*account.DeletedAt)
+	}
+
+	if account.OverdraftLimit != nil {
+		limit := account.OverdraftLimit.String()
+		pbAccount.OverdraftLimit = &limit
+	}
+
 	return pbAccount
 }
 
-func (s *LedgerService) journalEntryToProto(entry *repository.JournalEntry) *pb.JournalEntry {
+func journalEntryToProto(entry *repository.JournalEntry) *pb.JournalEntry {
 	lines := make([]*pb.JournalEntryLine, len(entry.Lines))
 	for i, line := range entry.Lines {
 		lineID := line.ID.String()
@@ -465,17 +1152,50 @@ func (s *LedgerService) journalEntryToProto(entry *repository.JournalEntry) *pb.
 			Description: line.Description,
 			CreatedAt:   createdAt,
 		}
+
+		if line.CounterpartyTenantID != nil {
+			counterpartyID := line.CounterpartyTenantID.String()
+			lines[i].CounterpartyTenantId = &counterpartyID
+		}
+
+		if line.FxRate != nil {
+			fxRate := line.FxRate.String()
+			lines[i].FxRate = &fxRate
+		}
+
+		if line.TaxCodeID != nil {
+			taxCodeID := line.TaxCodeID.String()
+			lines[i].TaxCodeId = &taxCodeID
+			lines[i].IsTax = line.IsTax
+		}
+
+		if line.PartyID != nil {
+			partyID := line.PartyID.String()
+			lines[i].PartyId = &partyID
+		}
+
+		if len(line.Dimensions) > 0 {
+			lines[i].Dimensions = line.Dimensions
+		}
 	}
 
 	pbEntry := &pb.JournalEntry{
-		JournalEntryId:  entry.ID.String(),
-		TenantId:        entry.TenantID.String(),
-		ReferenceNumber: entry.ReferenceNumber,
-		Description:     entry.Description,
-		EntryDate:       timestamppb.New(entry.EntryDate),
-		Lines:           lines,
-		CreatedAt:       timestamppb.New(entry.CreatedAt),
-		UpdatedAt:       timestamppb.New(entry.UpdatedAt),
+		JournalEntryId:     entry.ID.String(),
+		TenantId:           entry.TenantID.String(),
+		ReferenceNumber:    entry.ReferenceNumber,
+		Description:        entry.Description,
+		EntryDate:          timestamppb.New(entry.EntryDate),
+		JalaliEntryDate:    jalaliToProto(entry.EntryDate),
+		PostedAt:           timestamppb.New(entry.PostedAt),
+		Lines:              lines,
+		LockOverrideReason: entry.LockOverrideReason,
+		CreatedAt:          timestamppb.New(entry.CreatedAt),
+		UpdatedAt:          timestamppb.New(entry.UpdatedAt),
+	}
+
+	if entry.CurrencyCode != "" {
+		currencyCode := entry.CurrencyCode
+		pbEntry.CurrencyCode = &currencyCode
 	}
 
 	if entry.Metadata != nil {
@@ -2,14 +2,23 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/internal/validation"
+	"github.com/hesabFun/ledger/repository/memory"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
@@ -44,6 +53,75 @@ func (m *MockTenantRepository) GetByName(ctx context.Context, name string) (*rep
 	return args.Get(0).(*repository.Tenant), args.Error(1)
 }
 
+func (m *MockTenantRepository) ListIDs(ctx context.Context) ([]uuid.UUID, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockTenantRepository) List(ctx context.Context, filter repository.TenantFilter, limit, offset int) ([]*repository.Tenant, int, error) {
+	args := m.Called(ctx, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*repository.Tenant), args.Int(1), args.Error(2)
+}
+
+func (m *MockTenantRepository) Delete(ctx context.Context, tenantID uuid.UUID) (*repository.Tenant, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepository) Restore(ctx context.Context, tenantID uuid.UUID) (*repository.Tenant, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepository) Status(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	args := m.Called(ctx, tenantID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTenantRepository) SetStatus(ctx context.Context, tenantID uuid.UUID, status string) (*repository.Tenant, error) {
+	args := m.Called(ctx, tenantID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepository) SetTestMode(ctx context.Context, tenantID uuid.UUID, test bool) (*repository.Tenant, error) {
+	args := m.Called(ctx, tenantID, test)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepository) ListTestIDs(ctx context.Context) ([]uuid.UUID, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockTenantRepository) ListExpiredTestIDs(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
 type MockAccountRepository struct {
 	mock.Mock
 }
@@ -64,8 +142,8 @@ func (m *MockAccountRepository) GetByID(ctx context.Context, tenantID uuid.UUID,
 	return args.Get(0).(*repository.Account), args.Error(1)
 }
 
-func (m *MockAccountRepository) List(ctx context.Context, tenantID uuid.UUID, accountTypeID *int32, currencyCode *string, limit, offset int) ([]*repository.Account, int, error) {
-	args := m.Called(ctx, tenantID, accountTypeID, currencyCode, limit, offset)
+func (m *MockAccountRepository) List(ctx context.Context, tenantID uuid.UUID, filter repository.AccountFilter, limit, offset int) ([]*repository.Account, int, error) {
+	args := m.Called(ctx, tenantID, filter, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Int(1), args.Error(2)
 	}
@@ -80,6 +158,94 @@ func (m *MockAccountRepository) GetBalance(ctx context.Context, tenantID uuid.UU
 	return args.Get(0).(*repository.AccountBalance), args.Error(1)
 }
 
+func (m *MockAccountRepository) GetAvailableBalance(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*repository.AvailableBalance, error) {
+	args := m.Called(ctx, tenantID, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.AvailableBalance), args.Error(1)
+}
+
+func (m *MockAccountRepository) GetProjectedBalances(ctx context.Context, tenantID uuid.UUID, accountIDs []uuid.UUID) ([]*repository.ProjectedBalance, error) {
+	args := m.Called(ctx, tenantID, accountIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.ProjectedBalance), args.Error(1)
+}
+
+func (m *MockAccountRepository) SetOverdraftLimit(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, limit *decimal.Decimal) (*repository.Account, error) {
+	args := m.Called(ctx, tenantID, accountID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) SetLabels(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, labels map[string]string) (*repository.Account, error) {
+	args := m.Called(ctx, tenantID, accountID, labels)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) SetExternalID(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, source, externalID string) (*repository.Account, error) {
+	args := m.Called(ctx, tenantID, accountID, source, externalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) GetByExternalID(ctx context.Context, tenantID uuid.UUID, source, externalID string) (*repository.Account, error) {
+	args := m.Called(ctx, tenantID, source, externalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) Move(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, parentID *uuid.UUID) (*repository.Account, error) {
+	args := m.Called(ctx, tenantID, accountID, parentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) Merge(ctx context.Context, tenantID uuid.UUID, sourceID, targetID uuid.UUID, entryDate time.Time) (*repository.AccountMerge, error) {
+	args := m.Called(ctx, tenantID, sourceID, targetID, entryDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.AccountMerge), args.Error(1)
+}
+
+func (m *MockAccountRepository) AccountCurrencies(ctx context.Context, tenantID uuid.UUID, accountIDs []uuid.UUID) (map[uuid.UUID]repository.AccountCurrency, error) {
+	args := m.Called(ctx, tenantID, accountIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]repository.AccountCurrency), args.Error(1)
+}
+
+func (m *MockAccountRepository) Delete(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*repository.Account, error) {
+	args := m.Called(ctx, tenantID, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) Restore(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, maxAccounts *int32) (*repository.Account, error) {
+	args := m.Called(ctx, tenantID, accountID, maxAccounts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Account), args.Error(1)
+}
+
 type MockJournalRepository struct {
 	mock.Mock
 }
@@ -100,14 +266,59 @@ func (m *MockJournalRepository) GetByID(ctx context.Context, tenantID uuid.UUID,
 	return args.Get(0).(*repository.JournalEntry), args.Error(1)
 }
 
-func (m *MockJournalRepository) List(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID, fromDate, toDate *time.Time, limit, offset int) ([]*repository.JournalEntry, int, error) {
-	args := m.Called(ctx, tenantID, accountID, fromDate, toDate, limit, offset)
+func (m *MockJournalRepository) GetByIdempotencyKey(ctx context.Context, tenantID uuid.UUID, key string) (*repository.JournalEntry, error) {
+	args := m.Called(ctx, tenantID, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.JournalEntry), args.Error(1)
+}
+
+func (m *MockJournalRepository) ListByTransactionID(ctx context.Context, tenantID uuid.UUID, transactionID string) ([]*repository.JournalEntry, error) {
+	args := m.Called(ctx, tenantID, transactionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.JournalEntry), args.Error(1)
+}
+
+func (m *MockJournalRepository) List(ctx context.Context, tenantID uuid.UUID, filter repository.JournalEntryFilter, limit, offset int) ([]*repository.JournalEntry, *repository.JournalEntryTotals, error) {
+	args := m.Called(ctx, tenantID, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*repository.JournalEntry), args.Get(1).(*repository.JournalEntryTotals), args.Error(2)
+}
+
+func (m *MockJournalRepository) Search(ctx context.Context, tenantID uuid.UUID, text string, fromDate, toDate *time.Time, limit, offset int) ([]*repository.JournalEntry, int, error) {
+	args := m.Called(ctx, tenantID, text, fromDate, toDate, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Int(1), args.Error(2)
 	}
 	return args.Get(0).([]*repository.JournalEntry), args.Int(1), args.Error(2)
 }
 
+// Stream passes each entry given in the first return value to fn
+func (m *MockJournalRepository) Stream(ctx context.Context, tenantID uuid.UUID, fromDate, toDate *time.Time, fn func(*repository.JournalEntry) error) error {
+	args := m.Called(ctx, tenantID, fromDate, toDate)
+	if entries, ok := args.Get(0).([]*repository.JournalEntry); ok {
+		for _, entry := range entries {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockJournalRepository) VerifyIntegrity(ctx context.Context, tenantID uuid.UUID) (*repository.LedgerIntegrity, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LedgerIntegrity), args.Error(1)
+}
+
 type MockReferenceRepository struct {
 	mock.Mock
 }
@@ -128,40 +339,54 @@ func (m *MockReferenceRepository) ListCurrencies(ctx context.Context) ([]*reposi
 	return args.Get(0).([]*repository.Currency), args.Error(1)
 }
 
-// Test CreateTenant
-func TestLedgerService_CreateTenant(t *testing.T) {
-	ctx := context.Background()
-	mockTenantRepo := new(MockTenantRepository)
-	service := NewLedgerService(mockTenantRepo, nil, nil, nil)
+func (m *MockReferenceRepository) CreateAccountType(ctx context.Context, params repository.CreateAccountTypeParams) (*repository.AccountType, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.AccountType), args.Error(1)
+}
 
-	t.Run("successfully creates tenant", func(t *testing.T) {
-		tenantID := uuid.New()
-		now := time.Now()
+func (m *MockReferenceRepository) CreateCurrency(ctx context.Context, params repository.CreateCurrencyParams) (*repository.Currency, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Currency), args.Error(1)
+}
 
-		mockTenantRepo.On("Create", ctx, "Test Tenant", (*uuid.UUID)(nil)).Return(&repository.Tenant{
-			ID:        tenantID,
-			Name:      "Test Tenant",
-			CreatedAt: now,
-			UpdatedAt: now,
-		}, nil).Once()
+func (m *MockReferenceRepository) UpdateCurrency(ctx context.Context, code string, params repository.UpdateCurrencyParams) (*repository.Currency, error) {
+	args := m.Called(ctx, code, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Currency), args.Error(1)
+}
 
-		req := &pb.CreateTenantRequest{Name: "Test Tenant"}
-		resp, err := service.CreateTenant(ctx, req)
+func (m *MockReferenceRepository) SetAccountTypeTranslation(ctx context.Context, code, locale, name string) error {
+	args := m.Called(ctx, code, locale, name)
+	return args.Error(0)
+}
 
-		assert.NoError(t, err)
-		assert.NotNil(t, resp)
-		assert.Equal(t, tenantID.String(), resp.TenantId)
-		assert.Equal(t, "Test Tenant", resp.Name)
-		mockTenantRepo.AssertExpectations(t)
-	})
+func (m *MockReferenceRepository) SetCurrencyTranslation(ctx context.Context, code, locale, name string) error {
+	args := m.Called(ctx, code, locale, name)
+	return args.Error(0)
+}
 
-	t.Run("returns error when name is empty", func(t *testing.T) {
-		req := &pb.CreateTenantRequest{Name: ""}
-		resp, err := service.CreateTenant(ctx, req)
+func (m *MockReferenceRepository) ListAccountTypeTranslations(ctx context.Context, locales []string) (map[string]string, error) {
+	args := m.Called(ctx, locales)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]string), args.Error(1)
+}
 
-		assert.Error(t, err)
-		assert.Nil(t, resp)
-	})
+func (m *MockReferenceRepository) ListCurrencyTranslations(ctx context.Context, locales []string) (map[string]string, error) {
+	args := m.Called(ctx, locales)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]string), args.Error(1)
 }
 
 // Test CreateAccount
@@ -216,30 +441,538 @@ func TestLedgerService_CreateAccount(t *testing.T) {
 			AccountNumber: "1000",
 			Name:          "Cash",
 		}
-		resp, err := service.CreateAccount(ctx, req)
+		resp, err := validated(ctx, req, service.CreateAccount)
 
-		assert.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Len(t, fieldViolations(t, err), 1)
+		assert.Equal(t, "tenant_id", fieldViolations(t, err)[0].Field)
 		assert.Nil(t, resp)
 	})
 
+	t.Run("rejects malformed IDs without the validation interceptor", func(t *testing.T) {
+		invalid := "invalid-uuid"
+		for field, req := range map[string]*pb.CreateAccountRequest{
+			"tenant_id":         {TenantId: invalid, AccountNumber: "1000", Name: "Cash"},
+			"parent_account_id": {TenantId: uuid.New().String(), AccountNumber: "1000", Name: "Cash", ParentAccountId: &invalid},
+		} {
+			_, err := service.CreateAccount(ctx, req)
+
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), field)
+		}
+	})
+
 	t.Run("returns error when account number is empty", func(t *testing.T) {
 		req := &pb.CreateAccountRequest{
 			TenantId:      uuid.New().String(),
 			AccountNumber: "",
 			Name:          "Cash",
 		}
-		resp, err := service.CreateAccount(ctx, req)
+		resp, err := validated(ctx, req, service.CreateAccount)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Len(t, fieldViolations(t, err), 1)
+		assert.Equal(t, "account_number", fieldViolations(t, err)[0].Field)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("opens an account with an overdraft limit", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockAccountRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(p repository.CreateAccountParams) bool {
+			return p.OverdraftLimit != nil && p.OverdraftLimit.IsZero()
+		})).Return(&repository.Account{ID: uuid.New(), TenantID: tenantID, AccountNumber: "2100", Name: "Wallet"}, nil).Once()
+
+		limit := "0"
+		_, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:       tenantID.String(),
+			AccountNumber:  "2100",
+			Name:           "Wallet",
+			AccountTypeId:  2,
+			CurrencyCode:   "USD",
+			OverdraftLimit: &limit,
+		})
+
+		require.NoError(t, err)
+		mockAccountRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a negative overdraft limit", func(t *testing.T) {
+		limit := "-5"
+		_, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:       uuid.New().String(),
+			AccountNumber:  "2100",
+			Name:           "Wallet",
+			OverdraftLimit: &limit,
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// Test DeleteAccount
+func TestLedgerService_ListAccounts(t *testing.T) {
+	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
+	service := NewLedgerService(nil, mockAccountRepo, nil, nil)
+
+	t.Run("passes filters and sort order to the repository", func(t *testing.T) {
+		tenantID := uuid.New()
+		parentID := uuid.New()
+		namePrefix := "cash"
+		active := true
+
+		mockAccountRepo.On("List", ctx, tenantID, repository.AccountFilter{
+			NamePrefix:      &namePrefix,
+			IsActive:        &active,
+			ParentAccountID: &parentID,
+			SortBy:          repository.AccountSortNumber,
+			SortDescending:  true,
+		}, 20, 20).Return([]*repository.Account{{
+			ID:            uuid.New(),
+			TenantID:      tenantID,
+			AccountNumber: "1010",
+			Name:          "Cash on hand",
+		}}, 21, nil).Once()
+
+		parent := parentID.String()
+		resp, err := service.ListAccounts(ctx, &pb.ListAccountsRequest{
+			TenantId:        tenantID.String(),
+			NamePrefix:      &namePrefix,
+			IsActive:        &active,
+			ParentAccountId: &parent,
+			SortBy:          pb.AccountSortField_ACCOUNT_SORT_FIELD_NUMBER,
+			SortDirection:   pb.SortDirection_SORT_DIRECTION_DESC,
+			Page:            2,
+			PageSize:        20,
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Accounts, 1)
+		assert.Equal(t, int32(21), resp.TotalCount)
+		mockAccountRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects an invalid parent account ID", func(t *testing.T) {
+		parent := "not-a-uuid"
+		err := validation.Validate(&pb.ListAccountsRequest{
+			TenantId:        uuid.New().String(),
+			ParentAccountId: &parent,
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestLedgerService_DeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
+	service := NewLedgerService(nil, mockAccountRepo, nil, nil)
+
+	t.Run("successfully deletes account", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+		now := time.Now()
+
+		mockAccountRepo.On("Delete", ctx, tenantID, accountID).Return(&repository.Account{
+			ID:        accountID,
+			TenantID:  tenantID,
+			CreatedAt: now,
+			UpdatedAt: now,
+			DeletedAt: &now,
+		}, nil).Once()
+
+		resp, err := service.DeleteAccount(ctx, &pb.DeleteAccountRequest{
+			TenantId:  tenantID.String(),
+			AccountId: accountID.String(),
+		})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp.Account.DeletedAt)
+		mockAccountRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns failed precondition when account has a balance", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+
+		mockAccountRepo.On("Delete", ctx, tenantID, accountID).Return(nil, repository.ErrNonZeroBalance).Once()
+
+		resp, err := service.DeleteAccount(ctx, &pb.DeleteAccountRequest{
+			TenantId:  tenantID.String(),
+			AccountId: accountID.String(),
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Nil(t, resp)
+		mockAccountRepo.AssertExpectations(t)
+	})
+}
+
+// Test RestoreAccount
+func TestLedgerService_RestoreAccount(t *testing.T) {
+	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
+	service := NewLedgerService(nil, mockAccountRepo, nil, nil)
+
+	t.Run("returns not found when account is not deleted", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+
+		mockAccountRepo.On("Restore", ctx, tenantID, accountID, (*int32)(nil)).Return(nil, fmt.Errorf("deleted account %w", repository.ErrNotFound)).Once()
+
+		resp, err := service.RestoreAccount(ctx, &pb.RestoreAccountRequest{
+			TenantId:  tenantID.String(),
+			AccountId: accountID.String(),
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Nil(t, resp)
+		mockAccountRepo.AssertExpectations(t)
+	})
+}
+
+// Test SetAccountOverdraftLimit and its enforcement on postings
+func TestLedgerService_SetAccountOverdraftLimit(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "overdraft", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	createAccount := func(number string) string {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: number,
+			Name:          "Bank " + number,
+			AccountTypeId: 1,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(t, err)
+		return resp.AccountId
+	}
+	checking := createAccount("1001")
+	savings := createAccount("1002")
+
+	transfer := func(source, destination, amount string) error {
+		_, err := service.Transfer(ctx, &pb.TransferRequest{
+			TenantId:             tenantID,
+			SourceAccountId:      source,
+			DestinationAccountId: destination,
+			Amount:               amount,
+			ReferenceNumber:      "TRF",
+		})
+		return err
+	}
+	setLimit := func(limit *string) (*pb.SetAccountOverdraftLimitResponse, error) {
+		return service.SetAccountOverdraftLimit(ctx, &pb.SetAccountOverdraftLimitRequest{
+			TenantId:       tenantID,
+			AccountId:      checking,
+			OverdraftLimit: limit,
+		})
+	}
+	limit := func(value string) *string { return &value }
+
+	t.Run("rejects postings that overdraw a limited account", func(t *testing.T) {
+		resp, err := setLimit(limit("10"))
+		require.NoError(t, err)
+		assert.Equal(t, "10", resp.Account.GetOverdraftLimit())
+
+		require.NoError(t, transfer(savings, checking, "50"))
+		require.NoError(t, transfer(checking, savings, "55"))
+
+		err = transfer(checking, savings, "6")
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonInsufficientFunds, errorReason(t, err))
+
+		balance, err := service.GetAccountBalance(ctx, &pb.GetAccountBalanceRequest{TenantId: tenantID, AccountId: checking})
+		require.NoError(t, err)
+		assert.Equal(t, "5", balance.GetAvailableBalance())
+		assert.Equal(t, "0", balance.GetHeldAmount())
+	})
+
+	t.Run("allows topping up an overdrawn account", func(t *testing.T) {
+		_, err := setLimit(limit("0"))
+		require.NoError(t, err)
+
+		require.NoError(t, transfer(savings, checking, "1"))
+		assert.Equal(t, codes.FailedPrecondition, status.Code(transfer(checking, savings, "1")))
+	})
+
+	t.Run("stops checking once the limit is cleared", func(t *testing.T) {
+		resp, err := setLimit(nil)
+		require.NoError(t, err)
+		assert.Nil(t, resp.Account.OverdraftLimit)
+
+		assert.NoError(t, transfer(checking, savings, "100"))
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		_, err := setLimit(limit("-1"))
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = service.SetAccountOverdraftLimit(ctx, &pb.SetAccountOverdraftLimitRequest{TenantId: tenantID, AccountId: uuid.NewString()})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestLedgerService_MoveAccount(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "tree", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	createAccount := func(number string, parentID *string) string {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:        tenantID,
+			AccountNumber:   number,
+			Name:            "Revenue " + number,
+			AccountTypeId:   4,
+			CurrencyCode:    "USD",
+			ParentAccountId: parentID,
+		})
+		require.NoError(t, err)
+		return resp.AccountId
+	}
+	revenue := createAccount("4000", nil)
+	sales := createAccount("4100", &revenue)
+	online := createAccount("4110", &sales)
+	services := createAccount("4200", nil)
+
+	setParent := func(accountID string, parentID *string) (*pb.MoveAccountResponse, error) {
+		return service.MoveAccount(ctx, &pb.MoveAccountRequest{
+			TenantId:        tenantID,
+			AccountId:       accountID,
+			ParentAccountId: parentID,
+		})
+	}
+
+	t.Run("reports the depth and path of accounts", func(t *testing.T) {
+		resp, err := service.GetAccount(ctx, &pb.GetAccountRequest{TenantId: tenantID, AccountId: online})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), resp.Account.Depth)
+		assert.Equal(t, "4000/4100/4110", resp.Account.Path)
+	})
+
+	t.Run("moves an account with its descendants", func(t *testing.T) {
+		resp, err := setParent(services, &revenue)
+		require.NoError(t, err)
+		assert.Equal(t, "4000/4200", resp.Account.Path)
+
+		list, err := service.ListAccounts(ctx, &pb.ListAccountsRequest{
+			TenantId:          tenantID,
+			AncestorAccountId: &revenue,
+			SortBy:            pb.AccountSortField_ACCOUNT_SORT_FIELD_NUMBER,
+		})
+		require.NoError(t, err)
+		require.Len(t, list.Accounts, 3)
+		assert.Equal(t, "4100", list.Accounts[0].AccountNumber)
+		assert.Equal(t, "4110", list.Accounts[1].AccountNumber)
+		assert.Equal(t, "4200", list.Accounts[2].AccountNumber)
+	})
+
+	t.Run("rejects cycles", func(t *testing.T) {
+		_, err := setParent(revenue, &online)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonAccountCycle, errorReason(t, err))
+
+		_, err = setParent(sales, &sales)
+		assert.Equal(t, reasonAccountCycle, errorReason(t, err))
+	})
+
+	t.Run("rejects parents of another type", func(t *testing.T) {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: "1000",
+			Name:          "Assets",
+			AccountTypeId: 1,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(t, err)
+
+		_, err = setParent(sales, &resp.AccountId)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonAccountTypeMismatch, errorReason(t, err))
+	})
+
+	t.Run("makes an account a root account", func(t *testing.T) {
+		resp, err := setParent(sales, nil)
+		require.NoError(t, err)
+		assert.Nil(t, resp.Account.ParentAccountId)
+		assert.Zero(t, resp.Account.Depth)
+
+		account, err := service.GetAccount(ctx, &pb.GetAccountRequest{TenantId: tenantID, AccountId: online})
+		require.NoError(t, err)
+		assert.Equal(t, "4100/4110", account.Account.Path)
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		invalid := "not-a-uuid"
+		err := validation.Validate(&pb.MoveAccountRequest{TenantId: tenantID, AccountId: sales, ParentAccountId: &invalid})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		missing := uuid.NewString()
+		_, err = setParent(sales, &missing)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestLedgerService_MergeAccounts(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	journalRepo := memory.NewJournalRepository(store)
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		journalRepo,
+		memory.NewReferenceRepository(store),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "merge", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	createAccount := func(number string, accountTypeID int32, currency string) string {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: accountTypeID,
+			CurrencyCode:  currency,
+		})
+		require.NoError(t, err)
+		return resp.AccountId
+	}
+	cash := createAccount("1000", 1, "USD")
+	oldCash := createAccount("1001", 1, "USD")
+	euroCash := createAccount("1002", 1, "EUR")
+	revenue := createAccount("4000", 4, "USD")
+
+	for _, accountID := range []string{cash, oldCash, oldCash} {
+		_, err := service.Transfer(ctx, &pb.TransferRequest{
+			TenantId:             tenantID,
+			SourceAccountId:      revenue,
+			DestinationAccountId: accountID,
+			Amount:               "25",
+			ReferenceNumber:      "SALE",
+		})
+		require.NoError(t, err)
+	}
+
+	merge := func(source, target string) (*pb.MergeAccountsResponse, error) {
+		return service.MergeAccounts(ctx, &pb.MergeAccountsRequest{
+			TenantId:        tenantID,
+			SourceAccountId: source,
+			TargetAccountId: target,
+		})
+	}
+
+	t.Run("rejects accounts of another type or currency", func(t *testing.T) {
+		_, err := merge(oldCash, revenue)
+		assert.Equal(t, reasonAccountTypeMismatch, errorReason(t, err))
+
+		_, err = merge(oldCash, euroCash)
+		assert.Equal(t, reasonCurrencyMismatch, errorReason(t, err))
+	})
+
+	t.Run("moves the balance and overdraft limit and closes the source", func(t *testing.T) {
+		limit := "10"
+		_, err := service.SetAccountOverdraftLimit(ctx, &pb.SetAccountOverdraftLimitRequest{TenantId: tenantID, AccountId: oldCash, OverdraftLimit: &limit})
+		require.NoError(t, err)
+
+		resp, err := merge(oldCash, cash)
+		require.NoError(t, err)
+		assert.NotNil(t, resp.SourceAccount.DeletedAt)
+		assert.Equal(t, "10", resp.TargetAccount.GetOverdraftLimit())
+
+		balance, err := service.GetAccountBalance(ctx, &pb.GetAccountBalanceRequest{TenantId: tenantID, AccountId: cash})
+		require.NoError(t, err)
+		assert.Equal(t, "75", balance.DebitBalance)
+
+		// The balance moved with an entry; the posted lines stay on the source
+		require.NotNil(t, resp.ReclassificationEntryId)
+		entry, err := service.GetJournalEntry(ctx, &pb.GetJournalEntryRequest{TenantId: tenantID, JournalEntryId: *resp.ReclassificationEntryId})
+		require.NoError(t, err)
+		assert.Regexp(t, `^MERGE-1001-[0-9a-f-]{36}$`, entry.JournalEntry.ReferenceNumber)
+		require.Len(t, entry.JournalEntry.Lines, 2)
+		assert.Equal(t, oldCash, entry.JournalEntry.Lines[0].AccountId)
+		assert.Equal(t, "50", entry.JournalEntry.Lines[0].Credit)
+		assert.Equal(t, cash, entry.JournalEntry.Lines[1].AccountId)
+		assert.Equal(t, "50", entry.JournalEntry.Lines[1].Debit)
+
+		sourceID := uuid.MustParse(oldCash)
+		entries, _, err := journalRepo.List(ctx, tenant.ID, repository.JournalEntryFilter{AccountID: &sourceID}, 10, 0)
+		require.NoError(t, err)
+		assert.Len(t, entries, 3)
+
+		_, err = service.GetAccount(ctx, &pb.GetAccountRequest{TenantId: tenantID, AccountId: oldCash})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("keeps the hash chain valid", func(t *testing.T) {
+		integrity, err := journalRepo.VerifyIntegrity(ctx, tenant.ID)
+		require.NoError(t, err)
+		assert.True(t, integrity.Valid)
+		assert.Equal(t, 4, integrity.EntriesVerified)
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		_, err := merge(cash, cash)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = merge(oldCash, cash)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
 
-		assert.Error(t, err)
-		assert.Nil(t, resp)
+	t.Run("references a restored account merged again apart from its first merge", func(t *testing.T) {
+		_, err := service.RestoreAccount(ctx, &pb.RestoreAccountRequest{TenantId: tenantID, AccountId: oldCash})
+		require.NoError(t, err)
+		_, err = service.Transfer(ctx, &pb.TransferRequest{
+			TenantId:             tenantID,
+			SourceAccountId:      revenue,
+			DestinationAccountId: oldCash,
+			Amount:               "5",
+			ReferenceNumber:      "SALE",
+		})
+		require.NoError(t, err)
+
+		resp, err := merge(oldCash, cash)
+		require.NoError(t, err)
+
+		sourceID := uuid.MustParse(oldCash)
+		entries, _, err := journalRepo.List(ctx, tenant.ID, repository.JournalEntryFilter{AccountID: &sourceID}, 10, 0)
+		require.NoError(t, err)
+		references := make(map[string]bool)
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.ReferenceNumber, "MERGE-1001-") {
+				references[entry.ReferenceNumber] = true
+			}
+		}
+		assert.Len(t, references, 2)
+		assert.NotNil(t, resp.ReclassificationEntryId)
 	})
 }
 
 // Test CreateJournalEntry
 func TestLedgerService_CreateJournalEntry(t *testing.T) {
 	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
 	mockJournalRepo := new(MockJournalRepository)
-	service := NewLedgerService(nil, nil, mockJournalRepo, nil)
+	service := NewLedgerService(nil, mockAccountRepo, mockJournalRepo, nil)
 
 	t.Run("successfully creates journal entry", func(t *testing.T) {
 		tenantID := uuid.New()
@@ -248,6 +981,12 @@ func TestLedgerService_CreateJournalEntry(t *testing.T) {
 		account2ID := uuid.New()
 		now := time.Now()
 
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{account1ID, account2ID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				account1ID: {CurrencyCode: "USD", Precision: 2},
+				account2ID: {CurrencyCode: "USD", Precision: 2},
+			}, nil).Once()
+
 		lines := []*repository.CreateJournalEntryLineParams{
 			{
 				AccountID:   account1ID,
@@ -308,6 +1047,243 @@ func TestLedgerService_CreateJournalEntry(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotNil(t, resp)
 		assert.Equal(t, journalID.String(), resp.JournalEntryId)
+		mockAccountRepo.AssertExpectations(t)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error when an amount exceeds the currency precision", func(t *testing.T) {
+		tenantID := uuid.New()
+		account1ID := uuid.New()
+		account2ID := uuid.New()
+
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{account1ID, account2ID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				account1ID: {CurrencyCode: "USD", Precision: 2},
+				account2ID: {CurrencyCode: "USD", Precision: 2},
+			}, nil).Once()
+
+		req := &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "REF002",
+			EntryDate:       timestamppb.Now(),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: account1ID.String(), Debit: "10.005", Credit: "0"},
+				{AccountId: account2ID.String(), Debit: "0", Credit: "10.005"},
+			},
+		}
+		resp, err := service.CreateJournalEntry(ctx, req)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Contains(t, err.Error(), "lines[0].debit: amount has more than 2 decimal places")
+		assert.Nil(t, resp)
+		mockAccountRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error when metadata uses the sealed metadata key", func(t *testing.T) {
+		tenantID := uuid.New()
+		account1ID := uuid.New()
+		account2ID := uuid.New()
+
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{account1ID, account2ID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				account1ID: {CurrencyCode: "USD", Precision: 2},
+				account2ID: {CurrencyCode: "USD", Precision: 2},
+			}, nil).Once()
+
+		metadata := `{"$sealed":{"key_id":"2026"}}`
+		req := &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "REF003",
+			EntryDate:       timestamppb.Now(),
+			Metadata:        &metadata,
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: account1ID.String(), Debit: "10.00", Credit: "0"},
+				{AccountId: account2ID.String(), Debit: "0", Credit: "10.00"},
+			},
+		}
+		resp, err := service.CreateJournalEntry(ctx, req)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Contains(t, err.Error(), "metadata key $sealed is reserved")
+		assert.Nil(t, resp)
+		mockAccountRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns field violations for lines in another currency", func(t *testing.T) {
+		tenantID := uuid.New()
+		usdID := uuid.New()
+		eurID := uuid.New()
+
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{usdID, eurID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				usdID: {CurrencyCode: "USD", Precision: 2},
+				eurID: {CurrencyCode: "EUR", Precision: 2},
+			}, nil).Once()
+
+		req := &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "REF004",
+			EntryDate:       timestamppb.Now(),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: usdID.String(), Debit: "100", Credit: "0"},
+				{AccountId: eurID.String(), Debit: "0", Credit: "100"},
+			},
+		}
+		resp, err := service.CreateJournalEntry(ctx, req)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+
+		var badRequest *errdetails.BadRequest
+		for _, detail := range status.Convert(err).Details() {
+			if d, ok := detail.(*errdetails.BadRequest); ok {
+				badRequest = d
+			}
+		}
+		require.NotNil(t, badRequest)
+		require.Len(t, badRequest.FieldViolations, 1)
+		assert.Equal(t, "lines[1].account_id", badRequest.FieldViolations[0].Field)
+		assert.Contains(t, badRequest.FieldViolations[0].Description, "EUR does not match entry currency USD")
+		mockAccountRepo.AssertExpectations(t)
+	})
+
+	t.Run("accepts lines in another currency with an fx rate", func(t *testing.T) {
+		tenantID := uuid.New()
+		usdID := uuid.New()
+		usdConversionID := uuid.New()
+		eurConversionID := uuid.New()
+		eurID := uuid.New()
+		rate := "0.92"
+
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{usdID, usdConversionID, eurConversionID, eurID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				usdID:           {CurrencyCode: "USD", Precision: 2},
+				usdConversionID: {CurrencyCode: "USD", Precision: 2},
+				eurConversionID: {CurrencyCode: "EUR", Precision: 2},
+				eurID:           {CurrencyCode: "EUR", Precision: 2},
+			}, nil).Once()
+		mockJournalRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			return p.Lines[0].FxRate == nil &&
+				p.Lines[3].FxRate != nil && p.Lines[3].FxRate.Equal(decimal.RequireFromString(rate))
+		})).Return(&repository.JournalEntry{
+			ID:       uuid.New(),
+			TenantID: tenantID,
+		}, nil).Once()
+
+		// The EUR lines are in euros, 100 USD at 0.92, and balance on their own
+		req := &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "REF005",
+			EntryDate:       timestamppb.Now(),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: usdID.String(), Debit: "100", Credit: "0"},
+				{AccountId: usdConversionID.String(), Debit: "0", Credit: "100"},
+				{AccountId: eurConversionID.String(), Debit: "92", Credit: "0", FxRate: &rate},
+				{AccountId: eurID.String(), Debit: "0", Credit: "92", FxRate: &rate},
+			},
+		}
+		resp, err := service.CreateJournalEntry(ctx, req)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		mockAccountRepo.AssertExpectations(t)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects an entry that does not balance in each currency", func(t *testing.T) {
+		tenantID := uuid.New()
+		usdID := uuid.New()
+		eurID := uuid.New()
+		rate := "0.92"
+
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{usdID, eurID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				usdID: {CurrencyCode: "USD", Precision: 2},
+				eurID: {CurrencyCode: "EUR", Precision: 2},
+			}, nil).Once()
+
+		req := &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "REF005",
+			EntryDate:       timestamppb.Now(),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: usdID.String(), Debit: "100", Credit: "0"},
+				{AccountId: eurID.String(), Debit: "0", Credit: "100", FxRate: &rate},
+			},
+		}
+		resp, err := service.CreateJournalEntry(ctx, req)
+
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		assert.Contains(t, st.Message(), "not balanced in EUR")
+		mockAccountRepo.AssertExpectations(t)
+	})
+
+	t.Run("accepts trailing zeros beyond the currency precision", func(t *testing.T) {
+		tenantID := uuid.New()
+		account1ID := uuid.New()
+		account2ID := uuid.New()
+
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{account1ID, account2ID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				account1ID: {CurrencyCode: "JPY", Precision: 0},
+				account2ID: {CurrencyCode: "JPY", Precision: 0},
+			}, nil).Once()
+		mockJournalRepo.On("Create", ctx, tenantID, mock.Anything).Return(&repository.JournalEntry{
+			ID:       uuid.New(),
+			TenantID: tenantID,
+		}, nil).Once()
+
+		req := &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "REF003",
+			EntryDate:       timestamppb.Now(),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: account1ID.String(), Debit: "500.000", Credit: "0"},
+				{AccountId: account2ID.String(), Debit: "0", Credit: "500"},
+			},
+		}
+		resp, err := service.CreateJournalEntry(ctx, req)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		mockAccountRepo.AssertExpectations(t)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("accepts a Jalali entry date", func(t *testing.T) {
+		tenantID := uuid.New()
+		account1ID := uuid.New()
+		account2ID := uuid.New()
+
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{account1ID, account2ID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				account1ID: {CurrencyCode: "IRR", Precision: 0},
+				account2ID: {CurrencyCode: "IRR", Precision: 0},
+			}, nil).Once()
+		mockJournalRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			return p.EntryDate.Equal(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))
+		})).Return(&repository.JournalEntry{
+			ID:       uuid.New(),
+			TenantID: tenantID,
+		}, nil).Once()
+
+		req := &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "REF004",
+			JalaliEntryDate: &pb.JalaliDate{Year: 1402, Month: 11, Day: 11},
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: account1ID.String(), Debit: "500", Credit: "0"},
+				{AccountId: account2ID.String(), Debit: "0", Credit: "500"},
+			},
+		}
+		resp, err := service.CreateJournalEntry(ctx, req)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		mockAccountRepo.AssertExpectations(t)
 		mockJournalRepo.AssertExpectations(t)
 	})
 
@@ -328,9 +1304,222 @@ func TestLedgerService_CreateJournalEntry(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, resp)
 	})
+
+	t.Run("returns error when counterparty is the tenant itself", func(t *testing.T) {
+		tenantID := uuid.New()
+		counterparty := tenantID.String()
+		req := &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "REF001",
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: uuid.New().String(), Debit: "100", Credit: "0", CounterpartyTenantId: &counterparty},
+				{AccountId: uuid.New().String(), Debit: "0", Credit: "100"},
+			},
+		}
+		resp, err := service.CreateJournalEntry(ctx, req)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test VerifyLedgerIntegrity
+func TestLedgerService_VerifyLedgerIntegrity(t *testing.T) {
+	ctx := context.Background()
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewLedgerService(nil, nil, mockJournalRepo, nil)
+
+	t.Run("reports the first invalid entry", func(t *testing.T) {
+		tenantID := uuid.New()
+		invalidID := uuid.New()
+
+		mockJournalRepo.On("VerifyIntegrity", ctx, tenantID).Return(&repository.LedgerIntegrity{
+			Valid:               false,
+			EntriesVerified:     4,
+			UnchainedEntries:    2,
+			HeadSequence:        4,
+			HeadHash:            []byte{0xab, 0xcd},
+			FirstInvalidEntryID: &invalidID,
+			Reason:              "entry content does not match its hash",
+		}, nil).Once()
+
+		resp, err := service.VerifyLedgerIntegrity(ctx, &pb.VerifyLedgerIntegrityRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.False(t, resp.Valid)
+		assert.Equal(t, int32(4), resp.EntriesVerified)
+		assert.Equal(t, int32(2), resp.UnchainedEntries)
+		assert.Equal(t, "abcd", resp.HeadHash)
+		assert.Equal(t, invalidID.String(), resp.GetFirstInvalidEntryId())
+		assert.Equal(t, "entry content does not match its hash", resp.GetReason())
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects an invalid tenant ID", func(t *testing.T) {
+		err := validation.Validate(&pb.VerifyLedgerIntegrityRequest{TenantId: "invalid"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
 }
 
 // Test GetAccountBalance
+func TestLedgerService_ListJournalEntries(t *testing.T) {
+	ctx := context.Background()
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewLedgerService(nil, nil, mockJournalRepo, nil)
+
+	t.Run("passes reference, amount and description filters", func(t *testing.T) {
+		tenantID := uuid.New()
+		prefix := "INV-"
+		contains := "rent"
+		minAmount, maxAmount := "1250.00", "1250.00"
+
+		mockJournalRepo.On("List", ctx, tenantID, mock.MatchedBy(func(f repository.JournalEntryFilter) bool {
+			return *f.ReferencePrefix == prefix && *f.DescriptionContains == contains &&
+				f.MinAmount.Equal(decimal.NewFromInt(1250)) && f.MaxAmount.Equal(decimal.NewFromInt(1250)) &&
+				f.AccountID == nil && f.ReferenceNumber == nil
+		}), 50, 0).Return([]*repository.JournalEntry{}, &repository.JournalEntryTotals{
+			EntryCount: 3,
+			Debit:      decimal.NewFromInt(3750),
+			Credit:     decimal.NewFromInt(3750),
+		}, nil).Once()
+
+		resp, err := service.ListJournalEntries(ctx, &pb.ListJournalEntriesRequest{
+			TenantId:            tenantID.String(),
+			ReferencePrefix:     &prefix,
+			DescriptionContains: &contains,
+			MinAmount:           &minAmount,
+			MaxAmount:           &maxAmount,
+		})
+
+		assert.NoError(t, err)
+		assert.Empty(t, resp.JournalEntries)
+		assert.Equal(t, int32(3), resp.TotalCount)
+		assert.Equal(t, int32(3), resp.Totals.EntryCount)
+		assert.Equal(t, "3750", resp.Totals.TotalDebit)
+		assert.Equal(t, "3750", resp.Totals.TotalCredit)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error when min amount exceeds max amount", func(t *testing.T) {
+		minAmount, maxAmount := "10", "5"
+		resp, err := service.ListJournalEntries(ctx, &pb.ListJournalEntriesRequest{
+			TenantId:  uuid.New().String(),
+			MinAmount: &minAmount,
+			MaxAmount: &maxAmount,
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+func TestLedgerService_SearchJournalEntries(t *testing.T) {
+	ctx := context.Background()
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewLedgerService(nil, nil, mockJournalRepo, nil)
+
+	t.Run("searches with trimmed query and default paging", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockJournalRepo.On("Search", ctx, tenantID, "office rent", (*time.Time)(nil), (*time.Time)(nil), 50, 0).Return([]*repository.JournalEntry{{
+			ID:              uuid.New(),
+			TenantID:        tenantID,
+			ReferenceNumber: "JE-7",
+			Description:     "Office rent March",
+			EntryDate:       time.Now(),
+		}}, 1, nil).Once()
+
+		resp, err := service.SearchJournalEntries(ctx, &pb.SearchJournalEntriesRequest{
+			TenantId: tenantID.String(),
+			Query:    "  office rent ",
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.JournalEntries, 1)
+		assert.Equal(t, int32(1), resp.TotalCount)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects an empty query", func(t *testing.T) {
+		err := validation.Validate(&pb.SearchJournalEntriesRequest{
+			TenantId: uuid.New().String(),
+			Query:    "   ",
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// fakeExportStream collects the messages sent by ExportJournalEntries
+type fakeExportStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses []*pb.ExportJournalEntriesResponse
+	sendErr   error
+}
+
+func (f *fakeExportStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeExportStream) Send(resp *pb.ExportJournalEntriesResponse) error {
+	if f.sendErr != nil {
+		return f.sendErr
+	}
+	f.responses = append(f.responses, resp)
+	return nil
+}
+
+func TestLedgerService_ExportJournalEntries(t *testing.T) {
+	ctx := context.Background()
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewLedgerService(nil, nil, mockJournalRepo, nil)
+
+	t.Run("streams one message per entry", func(t *testing.T) {
+		tenantID := uuid.New()
+		entries := []*repository.JournalEntry{
+			{ID: uuid.New(), TenantID: tenantID, ReferenceNumber: "JE-1", EntryDate: time.Now()},
+			{ID: uuid.New(), TenantID: tenantID, ReferenceNumber: "JE-2", EntryDate: time.Now()},
+		}
+
+		mockJournalRepo.On("Stream", ctx, tenantID, (*time.Time)(nil), (*time.Time)(nil)).Return(entries, nil).Once()
+
+		stream := &fakeExportStream{ctx: ctx}
+		err := service.ExportJournalEntries(&pb.ExportJournalEntriesRequest{TenantId: tenantID.String()}, stream)
+
+		assert.NoError(t, err)
+		assert.Len(t, stream.responses, 2)
+		assert.Equal(t, "JE-2", stream.responses[1].JournalEntry.ReferenceNumber)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("stops when the client goes away", func(t *testing.T) {
+		tenantID := uuid.New()
+		sendErr := status.Error(codes.Canceled, "context canceled")
+
+		mockJournalRepo.On("Stream", ctx, tenantID, (*time.Time)(nil), (*time.Time)(nil)).Return([]*repository.JournalEntry{
+			{ID: uuid.New(), TenantID: tenantID},
+		}, nil).Once()
+
+		stream := &fakeExportStream{ctx: ctx, sendErr: sendErr}
+		err := service.ExportJournalEntries(&pb.ExportJournalEntriesRequest{TenantId: tenantID.String()}, stream)
+
+		assert.Equal(t, codes.Canceled, status.Code(err))
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error for inverted date range", func(t *testing.T) {
+		err := service.ExportJournalEntries(&pb.ExportJournalEntriesRequest{
+			TenantId: uuid.New().String(),
+			FromDate: timestamppb.New(time.Now()),
+			ToDate:   timestamppb.New(time.Now().AddDate(0, 0, -1)),
+		}, &fakeExportStream{ctx: ctx})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
 func TestLedgerService_GetAccountBalance(t *testing.T) {
 	ctx := context.Background()
 	mockAccountRepo := new(MockAccountRepository)
@@ -347,6 +1536,14 @@ func TestLedgerService_GetAccountBalance(t *testing.T) {
 			CreditBalance: decimal.NewFromInt(500),
 			UpdatedAt:     now,
 		}, nil).Once()
+		limit := decimal.NewFromInt(50)
+		mockAccountRepo.On("GetAvailableBalance", ctx, tenantID, accountID).Return(&repository.AvailableBalance{
+			AccountID:      accountID,
+			Booked:         decimal.NewFromInt(500),
+			Held:           decimal.NewFromInt(200),
+			OverdraftLimit: &limit,
+			Available:      decimal.NewFromInt(350),
+		}, nil).Once()
 
 		req := &pb.GetAccountBalanceRequest{
 			TenantId:  tenantID.String(),
@@ -360,6 +1557,8 @@ func TestLedgerService_GetAccountBalance(t *testing.T) {
 		assert.Equal(t, "1000", resp.DebitBalance)
 		assert.Equal(t, "500", resp.CreditBalance)
 		assert.Equal(t, "500", resp.NetBalance) // 1000 - 500
+		assert.Equal(t, "200", resp.GetHeldAmount())
+		assert.Equal(t, "350", resp.GetAvailableBalance())
 		mockAccountRepo.AssertExpectations(t)
 	})
 }
@@ -387,6 +1586,32 @@ func TestLedgerService_ListAccountTypes(t *testing.T) {
 		assert.Equal(t, "ASSET", resp.AccountTypes[0].Code)
 		mockReferenceRepo.AssertExpectations(t)
 	})
+
+	t.Run("names account types in the requested locale", func(t *testing.T) {
+		accountTypes := []*repository.AccountType{
+			{ID: 1, Code: "ASSET", Name: "Asset", NormalBalance: "DEBIT"},
+			{ID: 2, Code: "LIABILITY", Name: "Liability", NormalBalance: "CREDIT"},
+		}
+
+		mockReferenceRepo.On("ListAccountTypes", ctx).Return(accountTypes, nil).Once()
+		mockReferenceRepo.On("ListAccountTypeTranslations", ctx, []string{"fa-IR", "fa"}).Return(map[string]string{"ASSET": "دارایی"}, nil).Once()
+
+		resp, err := service.ListAccountTypes(ctx, &pb.ListAccountTypesRequest{Locale: "fa-ir"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "دارایی", resp.AccountTypes[0].Name)
+		assert.Equal(t, "Liability", resp.AccountTypes[1].Name)
+		mockReferenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects an invalid locale", func(t *testing.T) {
+		mockReferenceRepo.On("ListAccountTypes", ctx).Return([]*repository.AccountType{}, nil).Once()
+
+		resp, err := service.ListAccountTypes(ctx, &pb.ListAccountTypesRequest{Locale: "not a locale"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
 }
 
 // Test ListCurrencies
@@ -413,3 +1638,56 @@ func TestLedgerService_ListCurrencies(t *testing.T) {
 		mockReferenceRepo.AssertExpectations(t)
 	})
 }
+
+func TestLedgerService_MemoryRepositories(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "memory", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	createAccount := func(number string, accountTypeID int32) string {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: accountTypeID,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(t, err)
+		return resp.AccountId
+	}
+	cash := createAccount("1000", 1)
+	sales := createAccount("4000", 4)
+
+	_, err = service.CreateJournalEntry(ctx, &pb.CreateJournalEntryRequest{
+		TenantId:        tenantID,
+		ReferenceNumber: "INV-1",
+		Description:     "Invoice",
+		EntryDate:       timestamppb.New(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)),
+		Lines: []*pb.JournalEntryLine{
+			{AccountId: cash, Debit: "250.00", Credit: "0"},
+			{AccountId: sales, Debit: "0", Credit: "250.00"},
+		},
+	})
+	require.NoError(t, err)
+
+	balance, err := service.GetAccountBalance(ctx, &pb.GetAccountBalanceRequest{TenantId: tenantID, AccountId: cash})
+	require.NoError(t, err)
+	assert.Equal(t, "250", balance.NetBalance)
+
+	_, err = service.CreateAccount(ctx, &pb.CreateAccountRequest{
+		TenantId: tenantID, AccountNumber: "1000", Name: "Duplicate", AccountTypeId: 1, CurrencyCode: "USD",
+	})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = service.DeleteAccount(ctx, &pb.DeleteAccountRequest{TenantId: tenantID, AccountId: cash})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
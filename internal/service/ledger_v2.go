@@ -0,0 +1,462 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+	pbv2 "github.com/hesabFun/ledger/gen/go/ledger/v2"
+)
+
+// nanosPerUnit is the number of nanos in a unit of Money
+const nanosPerUnit = 1_000_000_000
+
+// LedgerServiceV2 implements the ledger.v2 LedgerService by converting its
+// Money amounts to and from the decimal strings of the v1 service it calls,
+// so both versions are served from the same implementation
+type LedgerServiceV2 struct {
+	pbv2.UnimplementedLedgerServiceServer
+	v1 *LedgerService
+}
+
+// NewLedgerServiceV2 creates the v2 service on top of a v1 service
+func NewLedgerServiceV2(v1 *LedgerService) *LedgerServiceV2 {
+	return &LedgerServiceV2{v1: v1}
+}
+
+// GetAccountBalance retrieves the balances of an account in its currency
+func (s *LedgerServiceV2) GetAccountBalance(ctx context.Context, req *pbv2.GetAccountBalanceRequest) (*pbv2.GetAccountBalanceResponse, error) {
+	account, err := s.v1.GetAccount(ctx, &pb.GetAccountRequest{
+		TenantId:  req.TenantId,
+		AccountId: req.AccountId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.v1.GetAccountBalance(ctx, &pb.GetAccountBalanceRequest{
+		TenantId:      req.TenantId,
+		AccountId:     req.AccountId,
+		AsOf:          req.AsOf,
+		EffectiveAsOf: req.EffectiveAsOf,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	money := s.moneyConverter(req.AmountFormat)
+	currency := account.Account.CurrencyCode
+	resp := &pbv2.GetAccountBalanceResponse{
+		AccountId: balance.AccountId,
+		UpdatedAt: balance.UpdatedAt,
+	}
+	if resp.DebitBalance, err = money.fromString(ctx, currency, balance.DebitBalance); err != nil {
+		return nil, err
+	}
+	if resp.CreditBalance, err = money.fromString(ctx, currency, balance.CreditBalance); err != nil {
+		return nil, err
+	}
+	if resp.NetBalance, err = money.fromString(ctx, currency, balance.NetBalance); err != nil {
+		return nil, err
+	}
+	if balance.HeldAmount != nil {
+		if resp.HeldAmount, err = money.fromString(ctx, currency, *balance.HeldAmount); err != nil {
+			return nil, err
+		}
+	}
+	if balance.AvailableBalance != nil {
+		if resp.AvailableBalance, err = money.fromString(ctx, currency, *balance.AvailableBalance); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// CreateJournalEntry posts a journal entry whose currency is that of its line
+// amounts
+func (s *LedgerServiceV2) CreateJournalEntry(ctx context.Context, req *pbv2.CreateJournalEntryRequest) (*pbv2.CreateJournalEntryResponse, error) {
+	lines, currency, err := s.moneyConverter(pbv2.AmountFormat_AMOUNT_FORMAT_UNSPECIFIED).journalLines(ctx, req.TenantId, req.Lines)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.v1.CreateJournalEntry(ctx, &pb.CreateJournalEntryRequest{
+		TenantId:           req.TenantId,
+		ReferenceNumber:    req.ReferenceNumber,
+		Description:        req.Description,
+		EntryDate:          req.EntryDate,
+		Lines:              lines,
+		Metadata:           req.Metadata,
+		CurrencyCode:       currency,
+		TransactionId:      req.TransactionId,
+		BookId:             req.BookId,
+		JalaliEntryDate:    req.JalaliEntryDate,
+		LockOverrideReason: req.LockOverrideReason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pbv2.CreateJournalEntryResponse{
+		JournalEntryId:  resp.JournalEntryId,
+		TenantId:        resp.TenantId,
+		ReferenceNumber: resp.ReferenceNumber,
+		EntryDate:       resp.EntryDate,
+		CreatedAt:       resp.CreatedAt,
+	}, nil
+}
+
+// GetJournalEntry retrieves a journal entry with its amounts in the entry
+// currency
+func (s *LedgerServiceV2) GetJournalEntry(ctx context.Context, req *pbv2.GetJournalEntryRequest) (*pbv2.GetJournalEntryResponse, error) {
+	resp, err := s.v1.GetJournalEntry(ctx, &pb.GetJournalEntryRequest{
+		TenantId:       req.TenantId,
+		JournalEntryId: req.JournalEntryId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := s.moneyConverter(req.AmountFormat).journalEntry(ctx, resp.JournalEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pbv2.GetJournalEntryResponse{JournalEntry: entry}, nil
+}
+
+// Transfer moves an amount in the currency of the source account to another
+// account
+func (s *LedgerServiceV2) Transfer(ctx context.Context, req *pbv2.TransferRequest) (*pbv2.TransferResponse, error) {
+	money := s.moneyConverter(req.AmountFormat)
+	amount, err := money.toDecimal(ctx, req.Amount, func(description string) error {
+		return invalidField("amount", description)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.v1.GetAccount(ctx, &pb.GetAccountRequest{
+		TenantId:  req.TenantId,
+		AccountId: req.SourceAccountId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if req.Amount.CurrencyCode != source.Account.CurrencyCode {
+		return nil, invalidField("amount", fmt.Sprintf("amount must be in %s, the currency of the source account", source.Account.CurrencyCode))
+	}
+
+	resp, err := s.v1.Transfer(ctx, &pb.TransferRequest{
+		TenantId:             req.TenantId,
+		SourceAccountId:      req.SourceAccountId,
+		DestinationAccountId: req.DestinationAccountId,
+		Amount:               amount.String(),
+		ReferenceNumber:      req.ReferenceNumber,
+		Description:          req.Description,
+		EntryDate:            req.EntryDate,
+		Metadata:             req.Metadata,
+		IdempotencyKey:       req.IdempotencyKey,
+		TransactionId:        req.TransactionId,
+		FxRate:               req.FxRate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := money.journalEntry(ctx, resp.JournalEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pbv2.TransferResponse{
+		JournalEntry: entry,
+		Replayed:     resp.Replayed,
+	}, nil
+}
+
+// moneyConverter converts the amounts of one call between Money and the
+// decimal strings of v1, looking up the precision of each currency in minor
+// units once
+type moneyConverter struct {
+	v1 *LedgerService
+	// minorUnits expresses converted amounts in minor units
+	minorUnits bool
+	precisions map[string]int32
+}
+
+// moneyConverter creates the converter of a call whose responses use format
+func (s *LedgerServiceV2) moneyConverter(format pbv2.AmountFormat) *moneyConverter {
+	return &moneyConverter{
+		v1:         s.v1,
+		minorUnits: format == pbv2.AmountFormat_AMOUNT_FORMAT_MINOR_UNITS,
+		precisions: make(map[string]int32),
+	}
+}
+
+// precision returns the number of decimal places of a currency's minor unit
+func (c *moneyConverter) precision(ctx context.Context, code string) (int32, error) {
+	if precision, ok := c.precisions[code]; ok {
+		return precision, nil
+	}
+
+	currency, err := c.v1.findCurrency(ctx, code)
+	if err != nil {
+		return 0, err
+	}
+
+	c.precisions[code] = currency.Precision
+	return currency.Precision, nil
+}
+
+// toDecimal converts Money into a decimal amount. Invalid money, such as
+// nanos out of range or with a sign other than that of units, is reported
+// through invalid.
+func (c *moneyConverter) toDecimal(ctx context.Context, money *pbv2.Money, invalid func(description string) error) (decimal.Decimal, error) {
+	if money == nil {
+		return decimal.Decimal{}, invalid("amount is required")
+	}
+	if money.CurrencyCode == "" {
+		return decimal.Decimal{}, invalid("currency code is required")
+	}
+
+	if money.MinorUnits != nil {
+		if money.Units != 0 || money.Nanos != 0 {
+			return decimal.Decimal{}, invalid("set either minor units or units and nanos")
+		}
+		precision, err := c.precision(ctx, money.CurrencyCode)
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+		return decimal.New(*money.MinorUnits, -precision), nil
+	}
+
+	if money.Nanos <= -nanosPerUnit || money.Nanos >= nanosPerUnit {
+		return decimal.Decimal{}, invalid("nanos must be between -999999999 and 999999999")
+	}
+	if (money.Units > 0 && money.Nanos < 0) || (money.Units < 0 && money.Nanos > 0) {
+		return decimal.Decimal{}, invalid("nanos must have the sign of units")
+	}
+
+	return decimal.NewFromInt(money.Units).Add(decimal.New(int64(money.Nanos), -9)), nil
+}
+
+// fromString converts a decimal string of the v1 API into Money. In minor
+// units an amount finer than the currency's minor unit, such as a converted
+// balance, cannot be expressed and fails the call.
+func (c *moneyConverter) fromString(ctx context.Context, currency, amount string) (*pbv2.Money, error) {
+	value, err := decimal.NewFromString(amount)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "invalid amount %q", amount)
+	}
+
+	if c.minorUnits {
+		precision, err := c.precision(ctx, currency)
+		if err != nil {
+			return nil, err
+		}
+
+		minorUnits := value.Shift(precision)
+		if !minorUnits.Equal(minorUnits.Truncate(0)) {
+			return nil, failedPrecondition(reasonFinerThanMinorUnits, currency,
+				fmt.Sprintf("amount %s %s is finer than the currency's minor unit", amount, currency),
+				map[string]string{"amount": amount, "currency": currency})
+		}
+		if !minorUnits.BigInt().IsInt64() {
+			return nil, status.Errorf(codes.Internal, "amount %s is out of range", amount)
+		}
+
+		units := minorUnits.IntPart()
+		return &pbv2.Money{CurrencyCode: currency, MinorUnits: &units}, nil
+	}
+
+	units := value.Truncate(0)
+	if !units.BigInt().IsInt64() {
+		return nil, status.Errorf(codes.Internal, "amount %s is out of range", amount)
+	}
+	nanos := value.Sub(units).Shift(9)
+	if !nanos.Equal(nanos.Truncate(0)) {
+		return nil, status.Errorf(codes.Internal, "amount %s has more than 9 decimal places", amount)
+	}
+
+	return &pbv2.Money{
+		CurrencyCode: currency,
+		Units:        units.IntPart(),
+		Nanos:        int32(nanos.IntPart()),
+	}, nil
+}
+
+// accountCurrencies returns the currency code of each of the accounts of a
+// tenant by account ID. Malformed IDs and unknown accounts are left out, for
+// the v1 handlers to report.
+func (c *moneyConverter) accountCurrencies(ctx context.Context, tenantID string, accountIDs []string) (map[string]string, error) {
+	if len(accountIDs) == 0 {
+		return nil, nil
+	}
+
+	tenant, err := uuid.Parse(tenantID)
+	if err != nil {
+		return nil, nil
+	}
+	ids := make([]uuid.UUID, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		if id, err := uuid.Parse(accountID); err == nil && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	currencies, err := c.v1.accountRepo.AccountCurrencies(ctx, tenant, ids)
+	if err != nil {
+		return nil, repositoryError("get account currencies", err)
+	}
+
+	byID := make(map[string]string, len(currencies))
+	for id, currency := range currencies {
+		byID[id.String()] = currency.CurrencyCode
+	}
+	return byID, nil
+}
+
+// journalLines converts the v2 lines of a tenant's entry into v1 lines and
+// returns the entry currency. As in v1, the amounts of a line with an fx_rate
+// are in the currency of its account and those of every other line in the
+// entry currency
+func (c *moneyConverter) journalLines(ctx context.Context, tenantID string, lines []*pbv2.JournalEntryLine) ([]*pb.JournalEntryLine, *string, error) {
+	var fxAccountIDs []string
+	for _, line := range lines {
+		if line.FxRate != nil {
+			fxAccountIDs = append(fxAccountIDs, line.AccountId)
+		}
+	}
+	accountCurrencies, err := c.accountCurrencies(ctx, tenantID, fxAccountIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var currency *string
+	v1Lines := make([]*pb.JournalEntryLine, len(lines))
+	for i, line := range lines {
+		v1Lines[i] = &pb.JournalEntryLine{
+			AccountId:            line.AccountId,
+			Debit:                "0",
+			Credit:               "0",
+			Description:          line.Description,
+			CounterpartyTenantId: line.CounterpartyTenantId,
+			FxRate:               line.FxRate,
+			TaxCodeId:            line.TaxCodeId,
+			IsTax:                line.IsTax,
+			PartyId:              line.PartyId,
+			Dimensions:           line.Dimensions,
+		}
+
+		for _, side := range []struct {
+			field  string
+			amount *pbv2.Money
+			value  *string
+		}{
+			{"debit", line.Debit, &v1Lines[i].Debit},
+			{"credit", line.Credit, &v1Lines[i].Credit},
+		} {
+			if side.amount == nil {
+				continue
+			}
+
+			amount, err := c.toDecimal(ctx, side.amount, func(description string) error {
+				return invalidLine(i, side.field, "%s at line %d", description, i)
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+
+			switch accountCurrency, ok := accountCurrencies[line.AccountId]; {
+			case line.FxRate != nil && ok:
+				if side.amount.CurrencyCode != accountCurrency {
+					return nil, nil, invalidLine(i, side.field, "amount at line %d is in %s, not the account currency %s", i, side.amount.CurrencyCode, accountCurrency)
+				}
+			case line.FxRate != nil:
+				// An unknown account is left for the v1 handler to report
+			case currency == nil:
+				currency = &side.amount.CurrencyCode
+			case side.amount.CurrencyCode != *currency:
+				return nil, nil, invalidLine(i, side.field, "amount at line %d is in %s, not the entry currency %s", i, side.amount.CurrencyCode, *currency)
+			}
+			*side.value = amount.String()
+		}
+	}
+
+	return v1Lines, currency, nil
+}
+
+// journalEntry converts a v1 journal entry into a v2 entry. Line amounts are
+// in the entry currency, except on lines with an fx_rate, whose amounts are in
+// the currency of their account.
+func (c *moneyConverter) journalEntry(ctx context.Context, entry *pb.JournalEntry) (*pbv2.JournalEntry, error) {
+	if entry.CurrencyCode == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "the currency of journal entry %s is not recorded", entry.JournalEntryId)
+	}
+
+	var fxAccountIDs []string
+	for _, line := range entry.Lines {
+		if line.FxRate != nil {
+			fxAccountIDs = append(fxAccountIDs, line.AccountId)
+		}
+	}
+	accountCurrencies, err := c.accountCurrencies(ctx, entry.TenantId, fxAccountIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]*pbv2.JournalEntryLine, len(entry.Lines))
+	for i, line := range entry.Lines {
+		currency := *entry.CurrencyCode
+		if line.FxRate != nil {
+			accountCurrency, ok := accountCurrencies[line.AccountId]
+			if !ok {
+				return nil, status.Errorf(codes.Internal, "the currency of account %s of journal entry %s is not known", line.AccountId, entry.JournalEntryId)
+			}
+			currency = accountCurrency
+		}
+
+		lines[i] = &pbv2.JournalEntryLine{
+			LineId:               line.LineId,
+			AccountId:            line.AccountId,
+			Description:          line.Description,
+			CreatedAt:            line.CreatedAt,
+			CounterpartyTenantId: line.CounterpartyTenantId,
+			FxRate:               line.FxRate,
+			TaxCodeId:            line.TaxCodeId,
+			IsTax:                line.IsTax,
+			PartyId:              line.PartyId,
+			Dimensions:           line.Dimensions,
+		}
+
+		if lines[i].Debit, err = c.fromString(ctx, currency, line.Debit); err != nil {
+			return nil, err
+		}
+		if lines[i].Credit, err = c.fromString(ctx, currency, line.Credit); err != nil {
+			return nil, err
+		}
+	}
+
+	return &pbv2.JournalEntry{
+		JournalEntryId:     entry.JournalEntryId,
+		TenantId:           entry.TenantId,
+		ReferenceNumber:    entry.ReferenceNumber,
+		Description:        entry.Description,
+		EntryDate:          entry.EntryDate,
+		Lines:              lines,
+		Metadata:           entry.Metadata,
+		CreatedAt:          entry.CreatedAt,
+		UpdatedAt:          entry.UpdatedAt,
+		PostedAt:           entry.PostedAt,
+		JalaliEntryDate:    entry.JalaliEntryDate,
+		LockOverrideReason: entry.LockOverrideReason,
+	}, nil
+}
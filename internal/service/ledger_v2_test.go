@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+	pbv2 "github.com/hesabFun/ledger/gen/go/ledger/v2"
+)
+
+func TestMoneyConversion(t *testing.T) {
+	ctx := context.Background()
+	invalid := func(description string) error { return invalidField("amount", description) }
+
+	t.Run("converts amounts both ways", func(t *testing.T) {
+		money := NewLedgerServiceV2(NewLedgerService(nil, nil, nil, nil)).moneyConverter(pbv2.AmountFormat_AMOUNT_FORMAT_UNSPECIFIED)
+		for _, amount := range []string{"0", "12.5", "-12.5", "0.000000001", "1234567.89"} {
+			converted, err := money.fromString(ctx, "USD", amount)
+			require.NoError(t, err)
+
+			value, err := money.toDecimal(ctx, converted, invalid)
+			require.NoError(t, err)
+			assert.True(t, value.Equal(decimal.RequireFromString(amount)), amount)
+		}
+
+		converted, err := money.fromString(ctx, "EUR", "-3.25")
+		require.NoError(t, err)
+		assert.Equal(t, "EUR", converted.CurrencyCode)
+		assert.Equal(t, int64(-3), converted.Units)
+		assert.Equal(t, int32(-250_000_000), converted.Nanos)
+	})
+
+	t.Run("rejects invalid money", func(t *testing.T) {
+		money := NewLedgerServiceV2(NewLedgerService(nil, nil, nil, nil)).moneyConverter(pbv2.AmountFormat_AMOUNT_FORMAT_UNSPECIFIED)
+		minorUnits := int64(100)
+		for name, invalidMoney := range map[string]*pbv2.Money{
+			"missing":        nil,
+			"no currency":    {Units: 1},
+			"nanos too big":  {CurrencyCode: "USD", Nanos: 1_000_000_000},
+			"mismatched one": {CurrencyCode: "USD", Units: 1, Nanos: -5},
+			"both forms":     {CurrencyCode: "USD", Units: 1, MinorUnits: &minorUnits},
+		} {
+			_, err := money.toDecimal(ctx, invalidMoney, invalid)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), name)
+		}
+	})
+
+	t.Run("rejects amounts finer than nanos", func(t *testing.T) {
+		money := NewLedgerServiceV2(NewLedgerService(nil, nil, nil, nil)).moneyConverter(pbv2.AmountFormat_AMOUNT_FORMAT_UNSPECIFIED)
+		_, err := money.fromString(ctx, "USD", "0.0000000001")
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("converts minor units with the currency precision", func(t *testing.T) {
+		mockReferenceRepo := new(MockReferenceRepository)
+		mockReferenceRepo.On("ListCurrencies", ctx).Return([]*repository.Currency{
+			{Code: "USD", Precision: 2},
+			{Code: "JPY", Precision: 0},
+		}, nil).Twice()
+		money := NewLedgerServiceV2(NewLedgerService(nil, nil, nil, mockReferenceRepo)).moneyConverter(pbv2.AmountFormat_AMOUNT_FORMAT_MINOR_UNITS)
+
+		cents := int64(-1250)
+		value, err := money.toDecimal(ctx, &pbv2.Money{CurrencyCode: "USD", MinorUnits: &cents}, invalid)
+		require.NoError(t, err)
+		assert.True(t, value.Equal(decimal.RequireFromString("-12.5")))
+
+		converted, err := money.fromString(ctx, "USD", "12.5")
+		require.NoError(t, err)
+		require.NotNil(t, converted.MinorUnits)
+		assert.Equal(t, int64(1250), *converted.MinorUnits)
+		assert.Zero(t, converted.Units)
+
+		converted, err = money.fromString(ctx, "JPY", "300")
+		require.NoError(t, err)
+		assert.Equal(t, int64(300), converted.GetMinorUnits())
+
+		// Each currency is looked up once per call
+		mockReferenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects amounts finer than the minor unit", func(t *testing.T) {
+		mockReferenceRepo := new(MockReferenceRepository)
+		mockReferenceRepo.On("ListCurrencies", ctx).Return([]*repository.Currency{{Code: "USD", Precision: 2}}, nil).Once()
+		money := NewLedgerServiceV2(NewLedgerService(nil, nil, nil, mockReferenceRepo)).moneyConverter(pbv2.AmountFormat_AMOUNT_FORMAT_MINOR_UNITS)
+
+		_, err := money.fromString(ctx, "USD", "0.125")
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("rejects unknown currencies in minor units", func(t *testing.T) {
+		mockReferenceRepo := new(MockReferenceRepository)
+		mockReferenceRepo.On("ListCurrencies", ctx).Return([]*repository.Currency{{Code: "USD", Precision: 2}}, nil).Once()
+		money := NewLedgerServiceV2(NewLedgerService(nil, nil, nil, mockReferenceRepo)).moneyConverter(pbv2.AmountFormat_AMOUNT_FORMAT_UNSPECIFIED)
+
+		minorUnits := int64(5)
+		_, err := money.toDecimal(ctx, &pbv2.Money{CurrencyCode: "XXX", MinorUnits: &minorUnits}, invalid)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestLedgerServiceV2_CreateJournalEntry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("posts the lines in the currency of their amounts", func(t *testing.T) {
+		mockAccountRepo := new(MockAccountRepository)
+		mockJournalRepo := new(MockJournalRepository)
+		mockReferenceRepo := new(MockReferenceRepository)
+		service := NewLedgerServiceV2(NewLedgerService(nil, mockAccountRepo, mockJournalRepo, mockReferenceRepo))
+
+		tenantID, cashID, revenueID := uuid.New(), uuid.New(), uuid.New()
+		now := time.Now()
+
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{cashID, revenueID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				cashID:    {CurrencyCode: "USD", Precision: 2},
+				revenueID: {CurrencyCode: "USD", Precision: 2},
+			}, nil).Once()
+		mockReferenceRepo.On("ListCurrencies", ctx).Return([]*repository.Currency{{Code: "USD", Precision: 2}}, nil).Once()
+		mockJournalRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			return p.CurrencyCode == "USD" &&
+				p.Lines[0].Debit.Equal(decimal.RequireFromString("12.5")) &&
+				p.Lines[1].Credit.Equal(decimal.RequireFromString("12.5"))
+		})).Return(&repository.JournalEntry{
+			ID:              uuid.New(),
+			TenantID:        tenantID,
+			ReferenceNumber: "REF001",
+			EntryDate:       now,
+			CreatedAt:       now,
+		}, nil).Once()
+
+		resp, err := service.CreateJournalEntry(ctx, &pbv2.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "REF001",
+			EntryDate:       timestamppb.New(now),
+			Lines: []*pbv2.JournalEntryLine{
+				{AccountId: cashID.String(), Debit: &pbv2.Money{CurrencyCode: "USD", Units: 12, Nanos: 500_000_000}},
+				{AccountId: revenueID.String(), Credit: &pbv2.Money{CurrencyCode: "USD", Units: 12, Nanos: 500_000_000}},
+			},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "REF001", resp.ReferenceNumber)
+		mockAccountRepo.AssertExpectations(t)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects lines in different currencies", func(t *testing.T) {
+		service := NewLedgerServiceV2(NewLedgerService(nil, nil, nil, nil))
+
+		_, err := service.CreateJournalEntry(ctx, &pbv2.CreateJournalEntryRequest{
+			TenantId: uuid.New().String(),
+			Lines: []*pbv2.JournalEntryLine{
+				{AccountId: uuid.New().String(), Debit: &pbv2.Money{CurrencyCode: "USD", Units: 10}},
+				{AccountId: uuid.New().String(), Credit: &pbv2.Money{CurrencyCode: "EUR", Units: 10}},
+			},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestLedgerServiceV2_GetJournalEntry(t *testing.T) {
+	ctx := context.Background()
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewLedgerServiceV2(NewLedgerService(nil, nil, mockJournalRepo, nil))
+
+	tenantID, entryID := uuid.New(), uuid.New()
+	mockJournalRepo.On("GetByID", ctx, tenantID, entryID).Return(&repository.JournalEntry{
+		ID:           entryID,
+		TenantID:     tenantID,
+		CurrencyCode: "EUR",
+		Lines: []*repository.JournalEntryLine{
+			{ID: uuid.New(), AccountID: uuid.New(), Debit: decimal.RequireFromString("7.01"), Credit: decimal.Zero},
+			{ID: uuid.New(), AccountID: uuid.New(), Debit: decimal.Zero, Credit: decimal.RequireFromString("7.01")},
+		},
+	}, nil).Once()
+
+	resp, err := service.GetJournalEntry(ctx, &pbv2.GetJournalEntryRequest{
+		TenantId:       tenantID.String(),
+		JournalEntryId: entryID.String(),
+	})
+
+	require.NoError(t, err)
+	require.Len(t, resp.JournalEntry.Lines, 2)
+	assert.Equal(t, &pbv2.Money{CurrencyCode: "EUR", Units: 7, Nanos: 10_000_000}, resp.JournalEntry.Lines[0].Debit)
+	assert.Equal(t, &pbv2.Money{CurrencyCode: "EUR"}, resp.JournalEntry.Lines[0].Credit)
+	mockJournalRepo.AssertExpectations(t)
+
+	t.Run("rejects a malformed ID", func(t *testing.T) {
+		// The v2 requests are converted and passed to the v1 handlers without
+		// going through the validation interceptor again
+		_, err := service.GetJournalEntry(ctx, &pbv2.GetJournalEntryRequest{
+			TenantId:       tenantID.String(),
+			JournalEntryId: "not-a-uuid",
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestLedgerServiceV2_GetJournalEntryInMinorUnits(t *testing.T) {
+	ctx := context.Background()
+	mockJournalRepo := new(MockJournalRepository)
+	mockReferenceRepo := new(MockReferenceRepository)
+	service := NewLedgerServiceV2(NewLedgerService(nil, nil, mockJournalRepo, mockReferenceRepo))
+
+	tenantID, entryID := uuid.New(), uuid.New()
+	mockJournalRepo.On("GetByID", ctx, tenantID, entryID).Return(&repository.JournalEntry{
+		ID:           entryID,
+		TenantID:     tenantID,
+		CurrencyCode: "EUR",
+		Lines: []*repository.JournalEntryLine{
+			{ID: uuid.New(), AccountID: uuid.New(), Debit: decimal.RequireFromString("7.01"), Credit: decimal.Zero},
+			{ID: uuid.New(), AccountID: uuid.New(), Debit: decimal.Zero, Credit: decimal.RequireFromString("7.01")},
+		},
+	}, nil).Once()
+	mockReferenceRepo.On("ListCurrencies", ctx).Return([]*repository.Currency{{Code: "EUR", Precision: 2}}, nil).Once()
+
+	resp, err := service.GetJournalEntry(ctx, &pbv2.GetJournalEntryRequest{
+		TenantId:       tenantID.String(),
+		JournalEntryId: entryID.String(),
+		AmountFormat:   pbv2.AmountFormat_AMOUNT_FORMAT_MINOR_UNITS,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, resp.JournalEntry.Lines, 2)
+	assert.Equal(t, int64(701), resp.JournalEntry.Lines[0].Debit.GetMinorUnits())
+	assert.Equal(t, int64(0), resp.JournalEntry.Lines[0].Credit.GetMinorUnits())
+	assert.NotNil(t, resp.JournalEntry.Lines[0].Credit.MinorUnits)
+	mockJournalRepo.AssertExpectations(t)
+	mockReferenceRepo.AssertExpectations(t)
+}
+
+// fxLedgerV2 is a v2 ledger over in-memory repositories whose tenant has USD
+// and EUR accounts and a conversion account in each currency
+type fxLedgerV2 struct {
+	service       *LedgerServiceV2
+	tenantID      string
+	usd           string
+	eur           string
+	usdConversion string
+	eurConversion string
+}
+
+func newFxLedgerV2(t *testing.T) *fxLedgerV2 {
+	ctx := context.Background()
+	store := memory.NewStore()
+	mockSettingsRepo := new(MockTenantSettingsRepository)
+	v1 := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+		WithTenantSettingsRepository(mockSettingsRepo),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "fx", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	createAccount := func(number, currency string) string {
+		resp, err := v1.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: 1,
+			CurrencyCode:  currency,
+		})
+		require.NoError(t, err)
+		return resp.AccountId
+	}
+	ledger := &fxLedgerV2{
+		service:       NewLedgerServiceV2(v1),
+		tenantID:      tenantID,
+		usd:           createAccount("1000", "USD"),
+		eur:           createAccount("1100", "EUR"),
+		usdConversion: createAccount("1900", "USD"),
+		eurConversion: createAccount("1901", "EUR"),
+	}
+
+	mockSettingsRepo.On("Get", mock.Anything, tenant.ID).Return(&repository.TenantSettings{
+		TenantID: tenant.ID,
+		FxConversionAccounts: map[string]uuid.UUID{
+			"USD": uuid.MustParse(ledger.usdConversion),
+			"EUR": uuid.MustParse(ledger.eurConversion),
+		},
+	}, nil)
+	return ledger
+}
+
+func TestLedgerServiceV2_TransferAcrossCurrencies(t *testing.T) {
+	ctx := context.Background()
+	ledger := newFxLedgerV2(t)
+	service, tenantID := ledger.service, ledger.tenantID
+	usd, eur, usdConversion, eurConversion := ledger.usd, ledger.eur, ledger.usdConversion, ledger.eurConversion
+
+	rate := "0.92"
+	resp, err := service.Transfer(ctx, &pbv2.TransferRequest{
+		TenantId:             tenantID,
+		SourceAccountId:      usd,
+		DestinationAccountId: eur,
+		Amount:               &pbv2.Money{CurrencyCode: "USD", Units: 100},
+		ReferenceNumber:      "FX-1",
+		FxRate:               &rate,
+	})
+	require.NoError(t, err)
+
+	// The euro lines carry the rate and are labelled with their account's
+	// currency, the others with the entry currency
+	lines := make(map[string]*pbv2.JournalEntryLine, len(resp.JournalEntry.Lines))
+	for _, line := range resp.JournalEntry.Lines {
+		lines[line.AccountId] = line
+	}
+	require.Len(t, lines, 4)
+	assert.Equal(t, &pbv2.Money{CurrencyCode: "EUR", Units: 92}, lines[eur].Debit)
+	assert.Equal(t, &pbv2.Money{CurrencyCode: "EUR", Units: 92}, lines[eurConversion].Credit)
+	assert.Equal(t, &pbv2.Money{CurrencyCode: "USD", Units: 100}, lines[usdConversion].Debit)
+	assert.Equal(t, &pbv2.Money{CurrencyCode: "USD", Units: 100}, lines[usd].Credit)
+	assert.Equal(t, "EUR", lines[eur].Credit.CurrencyCode)
+
+	entry, err := service.GetJournalEntry(ctx, &pbv2.GetJournalEntryRequest{
+		TenantId:       tenantID,
+		JournalEntryId: resp.JournalEntry.JournalEntryId,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, resp.JournalEntry.Lines, entry.JournalEntry.Lines)
+}
+
+func TestLedgerServiceV2_CreateJournalEntryAcrossCurrencies(t *testing.T) {
+	ctx := context.Background()
+	ledger := newFxLedgerV2(t)
+	rate := "0.92"
+
+	request := func(eurAmount *pbv2.Money) *pbv2.CreateJournalEntryRequest {
+		return &pbv2.CreateJournalEntryRequest{
+			TenantId:        ledger.tenantID,
+			ReferenceNumber: "FX-1",
+			Lines: []*pbv2.JournalEntryLine{
+				{AccountId: ledger.eur, Debit: eurAmount, FxRate: &rate},
+				{AccountId: ledger.eurConversion, Credit: &pbv2.Money{CurrencyCode: "EUR", Units: 92}, FxRate: &rate},
+				{AccountId: ledger.usdConversion, Debit: &pbv2.Money{CurrencyCode: "USD", Units: 100}},
+				{AccountId: ledger.usd, Credit: &pbv2.Money{CurrencyCode: "USD", Units: 100}},
+			},
+		}
+	}
+
+	t.Run("takes the amounts of lines with an fx rate in their account's currency", func(t *testing.T) {
+		resp, err := ledger.service.CreateJournalEntry(ctx, request(&pbv2.Money{CurrencyCode: "EUR", Units: 92}))
+		require.NoError(t, err)
+
+		entry, err := ledger.service.GetJournalEntry(ctx, &pbv2.GetJournalEntryRequest{
+			TenantId:       ledger.tenantID,
+			JournalEntryId: resp.JournalEntryId,
+		})
+		require.NoError(t, err)
+		lines := make(map[string]*pbv2.JournalEntryLine, len(entry.JournalEntry.Lines))
+		for _, line := range entry.JournalEntry.Lines {
+			lines[line.AccountId] = line
+		}
+		assert.Equal(t, &pbv2.Money{CurrencyCode: "EUR", Units: 92}, lines[ledger.eur].Debit)
+		assert.Equal(t, &pbv2.Money{CurrencyCode: "USD", Units: 100}, lines[ledger.usd].Credit)
+	})
+
+	t.Run("rejects an amount with an fx rate in another currency than its account's", func(t *testing.T) {
+		_, err := ledger.service.CreateJournalEntry(ctx, request(&pbv2.Money{CurrencyCode: "USD", Units: 92}))
+		require.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Contains(t, err.Error(), "not the account currency EUR")
+	})
+}
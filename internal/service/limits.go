@@ -0,0 +1,96 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// EntryLimits bounds the size of the journal entries a deployment accepts,
+// whatever the quota of the tenant. A zero limit disables it.
+type EntryLimits struct {
+	// MaxLines bounds the submitted lines of an entry, and MaxStreamedLines
+	// those of an entry streamed by CreateLargeJournalEntry
+	MaxLines         int
+	MaxStreamedLines int
+	// MaxMetadataBytes bounds the JSON metadata of an entry
+	MaxMetadataBytes int
+	// MaxDescriptionLength bounds the entry and line descriptions, in
+	// characters
+	MaxDescriptionLength int
+}
+
+// checkEntry rejects a journal entry request exceeding the limits, before
+// any of its lines are validated or looked up
+func (l EntryLimits) checkEntry(req *pb.CreateJournalEntryRequest) error {
+	if err := l.checkLineCount(len(req.Lines)); err != nil {
+		return err
+	}
+
+	if err := l.checkMetadata(req.GetMetadata()); err != nil {
+		return err
+	}
+
+	if err := l.checkDescription("description", req.Description); err != nil {
+		return err
+	}
+
+	for i, line := range req.Lines {
+		if err := l.checkDescription(fmt.Sprintf("lines[%d].description", i), line.Description); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkLineCount rejects entries with more lines than allowed
+func (l EntryLimits) checkLineCount(count int) error {
+	if l.MaxLines == 0 || count <= l.MaxLines {
+		return nil
+	}
+	return entryLimitExceeded("lines", l.MaxLines,
+		fmt.Sprintf("journal entry has %d lines, the limit is %d lines per entry", count, l.MaxLines))
+}
+
+// checkMetadata rejects entry metadata larger than allowed
+func (l EntryLimits) checkMetadata(metadata string) error {
+	if l.MaxMetadataBytes == 0 || len(metadata) <= l.MaxMetadataBytes {
+		return nil
+	}
+	return entryLimitExceeded("metadata", l.MaxMetadataBytes,
+		fmt.Sprintf("metadata is %d bytes, the limit is %d bytes", len(metadata), l.MaxMetadataBytes))
+}
+
+// checkDescription rejects descriptions longer than allowed
+func (l EntryLimits) checkDescription(field, description string) error {
+	if l.MaxDescriptionLength == 0 {
+		return nil
+	}
+	length := utf8.RuneCountInString(description)
+	if length <= l.MaxDescriptionLength {
+		return nil
+	}
+	return entryLimitExceeded(field, l.MaxDescriptionLength,
+		fmt.Sprintf("%s is %d characters, the limit is %d characters", field, length, l.MaxDescriptionLength))
+}
+
+// entryLimitExceeded rejects a field over its limit, naming the limit in the
+// ErrorInfo metadata
+func entryLimitExceeded(field string, limit int, description string) error {
+	return detailedError(codes.InvalidArgument, description,
+		errorInfo(reasonEntryLimitExceeded, map[string]string{
+			"field": field,
+			"limit": strconv.Itoa(limit),
+		}),
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+			Field:       field,
+			Description: description,
+		}}},
+	)
+}
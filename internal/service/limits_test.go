@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+func TestLedgerService_CreateJournalEntry_Limits(t *testing.T) {
+	ctx := context.Background()
+	service := NewLedgerService(nil, nil, new(MockJournalRepository), nil, WithEntryLimits(EntryLimits{
+		MaxLines:             3,
+		MaxMetadataBytes:     16,
+		MaxDescriptionLength: 10,
+	}))
+
+	entry := func() *pb.CreateJournalEntryRequest {
+		return &pb.CreateJournalEntryRequest{
+			TenantId:        uuid.New().String(),
+			ReferenceNumber: "JE-1",
+			Description:     "Payroll",
+			EntryDate:       timestamppb.New(time.Now()),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: uuid.New().String(), Debit: "10", Credit: "0"},
+				{AccountId: uuid.New().String(), Debit: "0", Credit: "10"},
+			},
+		}
+	}
+
+	limitInfo := func(t *testing.T, err error) *errdetails.ErrorInfo {
+		st := status.Convert(err)
+		require.Equal(t, codes.InvalidArgument, st.Code())
+		for _, detail := range st.Details() {
+			if info, ok := detail.(*errdetails.ErrorInfo); ok {
+				return info
+			}
+		}
+		t.Fatal("missing ErrorInfo detail")
+		return nil
+	}
+
+	t.Run("rejects too many lines", func(t *testing.T) {
+		req := entry()
+		for range 2 {
+			req.Lines = append(req.Lines, &pb.JournalEntryLine{AccountId: uuid.New().String(), Debit: "0", Credit: "0"})
+		}
+
+		_, err := service.CreateJournalEntry(ctx, req)
+
+		info := limitInfo(t, err)
+		assert.Equal(t, reasonEntryLimitExceeded, info.Reason)
+		assert.Equal(t, "lines", info.Metadata["field"])
+		assert.Equal(t, "3", info.Metadata["limit"])
+		assert.Contains(t, status.Convert(err).Message(), "4 lines")
+	})
+
+	t.Run("rejects oversized metadata", func(t *testing.T) {
+		req := entry()
+		metadata := `{"batch":"2026-10-payroll"}`
+		req.Metadata = &metadata
+
+		_, err := service.CreateJournalEntry(ctx, req)
+
+		assert.Equal(t, "metadata", limitInfo(t, err).Metadata["field"])
+	})
+
+	t.Run("rejects long entry and line descriptions", func(t *testing.T) {
+		req := entry()
+		req.Description = strings.Repeat("é", 11)
+
+		_, err := service.CreateJournalEntry(ctx, req)
+		assert.Equal(t, "description", limitInfo(t, err).Metadata["field"])
+
+		req = entry()
+		req.Lines[1].Description = "Net salary, October"
+
+		_, err = service.CreateJournalEntry(ctx, req)
+		assert.Equal(t, "lines[1].description", limitInfo(t, err).Metadata["field"])
+	})
+
+	t.Run("counts descriptions in characters", func(t *testing.T) {
+		assert.NoError(t, EntryLimits{MaxDescriptionLength: 10}.checkDescription("description", strings.Repeat("é", 10)))
+	})
+
+	t.Run("leaves zero limits unbounded", func(t *testing.T) {
+		req := entry()
+		req.Description = strings.Repeat("x", 5000)
+		assert.NoError(t, EntryLimits{}.checkEntry(req))
+	})
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+
+	"github.com/hesabFun/ledger/internal/locale"
+	"github.com/hesabFun/ledger/internal/repository"
+)
+
+// localizedAccountTypes lists the account types with their names in a
+// locale, falling back to the locale's language and then the stored names;
+// an empty locale lists the stored names
+func localizedAccountTypes(ctx context.Context, referenceRepo repository.ReferenceRepositoryInterface, requested string) ([]*repository.AccountType, error) {
+	accountTypes, err := referenceRepo.ListAccountTypes(ctx)
+	if err != nil {
+		return nil, repositoryError("list account types", err)
+	}
+	if requested == "" {
+		return accountTypes, nil
+	}
+
+	tag, err := locale.Parse(requested)
+	if err != nil {
+		return nil, invalidField("locale", "invalid locale")
+	}
+
+	names, err := referenceRepo.ListAccountTypeTranslations(ctx, locale.Fallbacks(tag))
+	if err != nil {
+		return nil, repositoryError("list account type translations", err)
+	}
+	for _, accountType := range accountTypes {
+		if name, ok := names[accountType.Code]; ok {
+			accountType.Name = name
+		}
+	}
+
+	return accountTypes, nil
+}
+
+// localizedCurrencies lists the currencies with their names in a locale,
+// falling back to the locale's language and then the stored names; an empty
+// locale lists the stored names
+func localizedCurrencies(ctx context.Context, referenceRepo repository.ReferenceRepositoryInterface, requested string) ([]*repository.Currency, error) {
+	currencies, err := referenceRepo.ListCurrencies(ctx)
+	if err != nil {
+		return nil, repositoryError("list currencies", err)
+	}
+	if requested == "" {
+		return currencies, nil
+	}
+
+	tag, err := locale.Parse(requested)
+	if err != nil {
+		return nil, invalidField("locale", "invalid locale")
+	}
+
+	names, err := referenceRepo.ListCurrencyTranslations(ctx, locale.Fallbacks(tag))
+	if err != nil {
+		return nil, repositoryError("list currency translations", err)
+	}
+	for _, currency := range currencies {
+		if name, ok := names[currency.Code]; ok {
+			currency.Name = name
+		}
+	}
+
+	return currencies, nil
+}
+
+// reportFormatter returns the formatter of the locale a report was requested
+// in, or nil, which keeps plain amounts, when none was
+func reportFormatter(requested string) (*locale.Formatter, error) {
+	if requested == "" {
+		return nil, nil
+	}
+
+	formatter, err := locale.NewFormatter(requested)
+	if err != nil {
+		return nil, invalidField("locale", "invalid locale")
+	}
+
+	return formatter, nil
+}
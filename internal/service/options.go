@@ -0,0 +1,223 @@
+package service
+
+import (
+	"github.com/hesabFun/ledger/internal/eventsig"
+	"github.com/hesabFun/ledger/internal/export"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/internal/statementrun"
+	"github.com/hesabFun/ledger/internal/watch"
+)
+
+// Option configures optional dependencies shared by the gRPC services
+type Option func(*options)
+
+type options struct {
+	quotaRepo       repository.QuotaRepositoryInterface
+	settingsRepo    repository.TenantSettingsRepositoryInterface
+	budgetRepo      repository.BudgetRepositoryInterface
+	closeRepo       repository.CloseRepositoryInterface
+	exporter        *export.Exporter
+	statementRunner *statementrun.Runner
+	policyRepo      repository.PostingPolicyRepositoryInterface
+	eventRepo       repository.EventRepositoryInterface
+	eventSigner     *eventsig.Signer
+	balanceRepo     repository.BalanceRepositoryInterface
+	consistencyRepo repository.ConsistencyRepositoryInterface
+	taxRepo         repository.TaxCodeRepositoryInterface
+	partyRepo       repository.PartyRepositoryInterface
+	dimensionRepo   repository.DimensionRepositoryInterface
+	bookRepo        repository.BookRepositoryInterface
+	broker          *watch.Broker
+	holdRepo        repository.HoldRepositoryInterface
+	preparedRepo    repository.PreparedEntryRepositoryInterface
+	batchRepo       repository.JournalBatchRepositoryInterface
+	reportRepo      repository.ReportRepositoryInterface
+	sequenceRepo    repository.ReferenceSequenceRepositoryInterface
+	digestRepo      repository.DigestRepositoryInterface
+	tenantDataRepo  repository.TenantDataRepositoryInterface
+	limits          EntryLimits
+}
+
+// WithQuotaRepository enables tenant quota enforcement and management
+func WithQuotaRepository(repo repository.QuotaRepositoryInterface) Option {
+	return func(o *options) {
+		o.quotaRepo = repo
+	}
+}
+
+// WithTenantSettingsRepository enables tenant settings management
+func WithTenantSettingsRepository(repo repository.TenantSettingsRepositoryInterface) Option {
+	return func(o *options) {
+		o.settingsRepo = repo
+	}
+}
+
+// WithCloseRepository enables period close checklists
+func WithCloseRepository(repo repository.CloseRepositoryInterface) Option {
+	return func(o *options) {
+		o.closeRepo = repo
+	}
+}
+
+// WithBudgetRepository enables budget management and budget-vs-actual reporting
+func WithBudgetRepository(repo repository.BudgetRepositoryInterface) Option {
+	return func(o *options) {
+		o.budgetRepo = repo
+	}
+}
+
+// WithExporter enables ledger data export jobs
+func WithExporter(exporter *export.Exporter) Option {
+	return func(o *options) {
+		o.exporter = exporter
+	}
+}
+
+// WithStatementRunner enables PDF statement runs
+func WithStatementRunner(runner *statementrun.Runner) Option {
+	return func(o *options) {
+		o.statementRunner = runner
+	}
+}
+
+// WithPostingPolicyRepository enables posting policy management and enforcement
+func WithPostingPolicyRepository(repo repository.PostingPolicyRepositoryInterface) Option {
+	return func(o *options) {
+		o.policyRepo = repo
+	}
+}
+
+// WithEventRepository enables reading the event store and temporal balance queries
+func WithEventRepository(repo repository.EventRepositoryInterface) Option {
+	return func(o *options) {
+		o.eventRepo = repo
+	}
+}
+
+// WithEventSigner signs the events returned by ListLedgerEvents and
+// WatchAuditEvents
+func WithEventSigner(signer *eventsig.Signer) Option {
+	return func(o *options) {
+		o.eventSigner = signer
+	}
+}
+
+// WithBalanceRepository enables account balance maintenance
+func WithBalanceRepository(repo repository.BalanceRepositoryInterface) Option {
+	return func(o *options) {
+		o.balanceRepo = repo
+	}
+}
+
+// WithConsistencyRepository enables on-demand ledger consistency checks
+func WithConsistencyRepository(repo repository.ConsistencyRepositoryInterface) Option {
+	return func(o *options) {
+		o.consistencyRepo = repo
+	}
+}
+
+// WithTaxCodeRepository enables tax codes, tax line generation and tax reports
+func WithTaxCodeRepository(repo repository.TaxCodeRepositoryInterface) Option {
+	return func(o *options) {
+		o.taxRepo = repo
+	}
+}
+
+// WithPartyRepository enables parties, party lines and party statements
+func WithPartyRepository(repo repository.PartyRepositoryInterface) Option {
+	return func(o *options) {
+		o.partyRepo = repo
+	}
+}
+
+// WithDimensionRepository enables custom dimensions, their validation on
+// posted lines and dimension balances
+func WithDimensionRepository(repo repository.DimensionRepositoryInterface) Option {
+	return func(o *options) {
+		o.dimensionRepo = repo
+	}
+}
+
+// WithBookRepository enables managing the books of a tenant beyond its
+// default book
+func WithBookRepository(repo repository.BookRepositoryInterface) Option {
+	return func(o *options) {
+		o.bookRepo = repo
+	}
+}
+
+// WithBalanceBroker enables streaming account balance changes published to
+// the broker
+func WithBalanceBroker(broker *watch.Broker) Option {
+	return func(o *options) {
+		o.broker = broker
+	}
+}
+
+// WithHoldRepository enables authorization holds and their capture into
+// journal entries
+func WithHoldRepository(repo repository.HoldRepositoryInterface) Option {
+	return func(o *options) {
+		o.holdRepo = repo
+	}
+}
+
+// WithPreparedEntryRepository enables preparing journal entries to confirm
+// or cancel later
+func WithPreparedEntryRepository(repo repository.PreparedEntryRepositoryInterface) Option {
+	return func(o *options) {
+		o.preparedRepo = repo
+	}
+}
+
+// WithJournalBatchRepository enables grouping journal entries into batches
+// that are approved and posted as a unit
+func WithJournalBatchRepository(repo repository.JournalBatchRepositoryInterface) Option {
+	return func(o *options) {
+		o.batchRepo = repo
+	}
+}
+
+// WithReportRepository enables aggregate queries over journal lines
+func WithReportRepository(repo repository.ReportRepositoryInterface) Option {
+	return func(o *options) {
+		o.reportRepo = repo
+	}
+}
+
+// WithReferenceSequenceRepository enables generating the reference numbers of
+// journal entries created without one
+func WithReferenceSequenceRepository(repo repository.ReferenceSequenceRepositoryInterface) Option {
+	return func(o *options) {
+		o.sequenceRepo = repo
+	}
+}
+
+// WithDigestRepository enables reading the daily digests of tenants
+func WithDigestRepository(repo repository.DigestRepositoryInterface) Option {
+	return func(o *options) {
+		o.digestRepo = repo
+	}
+}
+
+// WithTenantDataRepository enables purging all data of deleted tenants
+func WithTenantDataRepository(repo repository.TenantDataRepositoryInterface) Option {
+	return func(o *options) {
+		o.tenantDataRepo = repo
+	}
+}
+
+// WithEntryLimits bounds the size of the journal entries accepted
+func WithEntryLimits(limits EntryLimits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
+
+func applyOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// CreateParty creates a customer, vendor or employee
+func (s *LedgerService) CreateParty(ctx context.Context, req *pb.CreatePartyRequest) (*pb.CreatePartyResponse, error) {
+	if s.partyRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "parties are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := partyParams(req.Code, req.Name, req.Type, req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	party, err := s.partyRepo.Create(ctx, tenantID, params)
+	if err != nil {
+		return nil, repositoryError("create party", err)
+	}
+
+	return &pb.CreatePartyResponse{
+		Party: partyToProto(party),
+	}, nil
+}
+
+// GetParty retrieves a party, including a deleted one
+func (s *LedgerService) GetParty(ctx context.Context, req *pb.GetPartyRequest) (*pb.GetPartyResponse, error) {
+	if s.partyRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "parties are not enabled")
+	}
+
+	tenantID, partyID, err := parsePartyIDs(req.TenantId, req.PartyId)
+	if err != nil {
+		return nil, err
+	}
+
+	party, err := s.partyRepo.GetByID(ctx, tenantID, partyID)
+	if err != nil {
+		return nil, repositoryError("get party", err)
+	}
+
+	return &pb.GetPartyResponse{
+		Party: partyToProto(party),
+	}, nil
+}
+
+// ListParties lists the parties of a tenant, optionally of one type
+func (s *LedgerService) ListParties(ctx context.Context, req *pb.ListPartiesRequest) (*pb.ListPartiesResponse, error) {
+	if s.partyRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "parties are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := repository.PartyFilter{
+		IncludeDeleted: req.IncludeDeleted,
+	}
+	if req.Type != nil && *req.Type != "" {
+		if !validPartyType(*req.Type) {
+			return nil, status.Error(codes.InvalidArgument, "party type must be CUSTOMER, VENDOR or EMPLOYEE")
+		}
+		filter.Type = req.Type
+	}
+
+	page := int(req.GetPage())
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	parties, totalCount, err := s.partyRepo.List(ctx, tenantID, filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, repositoryError("list parties", err)
+	}
+
+	pbParties := make([]*pb.Party, len(parties))
+	for i, party := range parties {
+		pbParties[i] = partyToProto(party)
+	}
+
+	return &pb.ListPartiesResponse{
+		Parties:    pbParties,
+		TotalCount: int32(totalCount),
+	}, nil
+}
+
+// UpdateParty replaces the details of a party
+func (s *LedgerService) UpdateParty(ctx context.Context, req *pb.UpdatePartyRequest) (*pb.UpdatePartyResponse, error) {
+	if s.partyRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "parties are not enabled")
+	}
+
+	tenantID, partyID, err := parsePartyIDs(req.TenantId, req.PartyId)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := partyParams(req.Code, req.Name, req.Type, req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	party, err := s.partyRepo.Update(ctx, tenantID, partyID, params)
+	if err != nil {
+		return nil, repositoryError("update party", err)
+	}
+
+	return &pb.UpdatePartyResponse{
+		Party: partyToProto(party),
+	}, nil
+}
+
+// DeleteParty soft-deletes a party; its posted lines keep referring to it
+func (s *LedgerService) DeleteParty(ctx context.Context, req *pb.DeletePartyRequest) (*pb.DeletePartyResponse, error) {
+	if s.partyRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "parties are not enabled")
+	}
+
+	tenantID, partyID, err := parsePartyIDs(req.TenantId, req.PartyId)
+	if err != nil {
+		return nil, err
+	}
+
+	party, err := s.partyRepo.Delete(ctx, tenantID, partyID)
+	if err != nil {
+		return nil, repositoryError("delete party", err)
+	}
+
+	return &pb.DeletePartyResponse{
+		Party: partyToProto(party),
+	}, nil
+}
+
+// GetPartyBalance returns the balance of a party on every account it has
+// lines on, optionally translated into a reporting currency
+func (s *LedgerService) GetPartyBalance(ctx context.Context, req *pb.GetPartyBalanceRequest) (*pb.GetPartyBalanceResponse, error) {
+	if s.partyRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "parties are not enabled")
+	}
+
+	tenantID, partyID, err := parsePartyIDs(req.TenantId, req.PartyId)
+	if err != nil {
+		return nil, err
+	}
+
+	translation, err := s.reportingCurrency(ctx, req.ReportingCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	party, err := s.partyRepo.GetByID(ctx, tenantID, partyID)
+	if err != nil {
+		return nil, repositoryError("get party", err)
+	}
+
+	balances, err := s.partyRepo.GetBalances(ctx, tenantID, partyID, dateOf(req.AsOf))
+	if err != nil {
+		return nil, repositoryError("get party balance", err)
+	}
+
+	resp := &pb.GetPartyBalanceResponse{
+		Party:    partyToProto(party),
+		Balances: make([]*pb.PartyAccountBalance, len(balances)),
+	}
+	for i, balance := range balances {
+		debit, credit, err := translation.translate(balance.CurrencyCode, balance.AccountTypeCode, balance.Debit, balance.Credit)
+		if err != nil {
+			return nil, err
+		}
+		resp.Balances[i] = &pb.PartyAccountBalance{
+			AccountId:     balance.AccountID.String(),
+			AccountNumber: balance.AccountNumber,
+			AccountName:   balance.AccountName,
+			CurrencyCode:  balance.CurrencyCode,
+			Debit:         debit.String(),
+			Credit:        credit.String(),
+			Balance:       debit.Sub(credit).String(),
+		}
+	}
+	if translation != nil {
+		resp.ReportingCurrency = &translation.currency
+	}
+
+	return resp, nil
+}
+
+// GetPartyStatement lists the lines of a party on one account over a period
+// with opening, running and closing balances
+func (s *LedgerService) GetPartyStatement(ctx context.Context, req *pb.GetPartyStatementRequest) (*pb.GetPartyStatementResponse, error) {
+	if s.partyRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "parties are not enabled")
+	}
+
+	tenantID, partyID, err := parsePartyIDs(req.TenantId, req.PartyId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := dateField("from_date", req.FromDate, req.JalaliFromDate)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := dateField("to_date", req.ToDate, req.JalaliToDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == nil || to == nil {
+		return nil, status.Error(codes.InvalidArgument, "from date and to date are required")
+	}
+
+	fromDate := *from
+	toDate := *to
+	if toDate.Before(fromDate) {
+		return nil, status.Error(codes.InvalidArgument, "to date must not be before from date")
+	}
+
+	formatter, err := reportFormatter(req.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	party, err := s.partyRepo.GetByID(ctx, tenantID, partyID)
+	if err != nil {
+		return nil, repositoryError("get party", err)
+	}
+
+	statement, err := s.partyRepo.GetStatement(ctx, tenantID, partyID, accountID, fromDate, toDate)
+	if err != nil {
+		return nil, repositoryError("get party statement", err)
+	}
+
+	resp := &pb.GetPartyStatementResponse{
+		Party:          partyToProto(party),
+		AccountId:      accountID.String(),
+		OpeningBalance: formatter.Decimal(statement.OpeningBalance),
+		Lines:          make([]*pb.PartyStatementLine, len(statement.Lines)),
+		ClosingBalance: formatter.Decimal(statement.ClosingBalance),
+	}
+	for i, line := range statement.Lines {
+		resp.Lines[i] = &pb.PartyStatementLine{
+			JournalEntryId:  line.JournalEntryID.String(),
+			LineId:          line.LineID.String(),
+			EntryDate:       timestamppb.New(line.EntryDate),
+			ReferenceNumber: line.ReferenceNumber,
+			Description:     line.Description,
+			Debit:           formatter.Decimal(line.Debit),
+			Credit:          formatter.Decimal(line.Credit),
+			Balance:         formatter.Decimal(line.Balance),
+		}
+		if formatter != nil {
+			resp.Lines[i].DisplayDate = formatter.Date(line.EntryDate)
+		}
+	}
+
+	return resp, nil
+}
+
+// checkLineParties verifies that every party referenced by the lines exists
+// and has not been deleted
+func (s *LedgerService) checkLineParties(ctx context.Context, tenantID uuid.UUID, lines []*repository.CreateJournalEntryLineParams) error {
+	partyIDs := make([]uuid.UUID, 0)
+	seen := make(map[uuid.UUID]bool)
+	for _, line := range lines {
+		if line.PartyID != nil && !seen[*line.PartyID] {
+			seen[*line.PartyID] = true
+			partyIDs = append(partyIDs, *line.PartyID)
+		}
+	}
+
+	if len(partyIDs) == 0 {
+		return nil
+	}
+
+	if s.partyRepo == nil {
+		return status.Error(codes.Unimplemented, "parties are not enabled")
+	}
+
+	parties, err := s.partyRepo.GetByIDs(ctx, tenantID, partyIDs)
+	if err != nil {
+		return repositoryError("get parties", err)
+	}
+
+	for i, line := range lines {
+		if line.PartyID == nil {
+			continue
+		}
+
+		party, ok := parties[*line.PartyID]
+		if !ok {
+			return invalidLine(i, "party_id", "unknown party at line %d", i)
+		}
+		if party.DeletedAt != nil {
+			return failedPrecondition(reasonDeletedParty, fmt.Sprintf("lines[%d].party_id", i),
+				fmt.Sprintf("party %s is deleted at line %d", party.Code, i), map[string]string{"party": party.Code})
+		}
+	}
+
+	return nil
+}
+
+func parsePartyIDs(tenantIDValue, partyIDValue string) (uuid.UUID, uuid.UUID, error) {
+	tenantID, err := requestID("tenant_id", tenantIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	partyID, err := requestID("party_id", partyIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return tenantID, partyID, nil
+}
+
+func validPartyType(partyType string) bool {
+	switch partyType {
+	case repository.PartyTypeCustomer, repository.PartyTypeVendor, repository.PartyTypeEmployee:
+		return true
+	}
+	return false
+}
+
+func partyParams(code, name, partyType, email string) (repository.PartyParams, error) {
+	params := repository.PartyParams{
+		Code:  code,
+		Name:  name,
+		Type:  partyType,
+		Email: email,
+	}
+
+	if !validPartyType(partyType) {
+		return params, status.Error(codes.InvalidArgument, "party type must be CUSTOMER, VENDOR or EMPLOYEE")
+	}
+
+	return params, nil
+}
+
+func partyToProto(party *repository.Party) *pb.Party {
+	pbParty := &pb.Party{
+		PartyId:   party.ID.String(),
+		TenantId:  party.TenantID.String(),
+		Code:      party.Code,
+		Name:      party.Name,
+		Type:      party.Type,
+		Email:     party.Email,
+		CreatedAt: timestamppb.New(party.CreatedAt),
+		UpdatedAt: timestamppb.New(party.UpdatedAt),
+	}
+
+	if party.DeletedAt != nil {
+		pbParty.DeletedAt = timestamppb.New(*party.DeletedAt)
+	}
+
+	return pbParty
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockPartyRepository struct {
+	mock.Mock
+}
+
+func (m *MockPartyRepository) Create(ctx context.Context, tenantID uuid.UUID, params repository.PartyParams) (*repository.Party, error) {
+	args := m.Called(ctx, tenantID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Party), args.Error(1)
+}
+
+func (m *MockPartyRepository) Update(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID, params repository.PartyParams) (*repository.Party, error) {
+	args := m.Called(ctx, tenantID, partyID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Party), args.Error(1)
+}
+
+func (m *MockPartyRepository) GetByID(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID) (*repository.Party, error) {
+	args := m.Called(ctx, tenantID, partyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Party), args.Error(1)
+}
+
+func (m *MockPartyRepository) GetByIDs(ctx context.Context, tenantID uuid.UUID, partyIDs []uuid.UUID) (map[uuid.UUID]*repository.Party, error) {
+	args := m.Called(ctx, tenantID, partyIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]*repository.Party), args.Error(1)
+}
+
+func (m *MockPartyRepository) List(ctx context.Context, tenantID uuid.UUID, filter repository.PartyFilter, limit, offset int) ([]*repository.Party, int, error) {
+	args := m.Called(ctx, tenantID, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*repository.Party), args.Int(1), args.Error(2)
+}
+
+func (m *MockPartyRepository) Delete(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID) (*repository.Party, error) {
+	args := m.Called(ctx, tenantID, partyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Party), args.Error(1)
+}
+
+func (m *MockPartyRepository) GetBalances(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID, asOf *time.Time) ([]*repository.PartyAccountBalance, error) {
+	args := m.Called(ctx, tenantID, partyID, asOf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.PartyAccountBalance), args.Error(1)
+}
+
+func (m *MockPartyRepository) GetStatement(ctx context.Context, tenantID uuid.UUID, partyID, accountID uuid.UUID, fromDate, toDate time.Time) (*repository.PartyStatement, error) {
+	args := m.Called(ctx, tenantID, partyID, accountID, fromDate, toDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.PartyStatement), args.Error(1)
+}
+
+// Test CreateParty
+func TestLedgerService_CreateParty(t *testing.T) {
+	ctx := context.Background()
+	mockPartyRepo := new(MockPartyRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithPartyRepository(mockPartyRepo))
+
+	t.Run("creates a customer", func(t *testing.T) {
+		tenantID := uuid.New()
+		params := repository.PartyParams{
+			Code:  "CUST-001",
+			Name:  "Acme",
+			Type:  repository.PartyTypeCustomer,
+			Email: "billing@acme.test",
+		}
+
+		mockPartyRepo.On("Create", ctx, tenantID, params).Return(&repository.Party{
+			ID:       uuid.New(),
+			TenantID: tenantID,
+			Code:     params.Code,
+			Name:     params.Name,
+			Type:     params.Type,
+			Email:    params.Email,
+		}, nil).Once()
+
+		resp, err := service.CreateParty(ctx, &pb.CreatePartyRequest{
+			TenantId: tenantID.String(),
+			Code:     "CUST-001",
+			Name:     "Acme",
+			Type:     "CUSTOMER",
+			Email:    "billing@acme.test",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "CUST-001", resp.Party.Code)
+		assert.Nil(t, resp.Party.DeletedAt)
+		mockPartyRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns invalid argument for an unknown type", func(t *testing.T) {
+		resp, err := service.CreateParty(ctx, &pb.CreatePartyRequest{
+			TenantId: uuid.New().String(),
+			Code:     "P-1",
+			Name:     "Someone",
+			Type:     "PARTNER",
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns unimplemented when parties are disabled", func(t *testing.T) {
+		service := NewLedgerService(nil, nil, nil, nil)
+
+		resp, err := service.CreateParty(ctx, &pb.CreatePartyRequest{TenantId: uuid.New().String()})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test CreateJournalEntry with party lines
+func TestLedgerService_CreateJournalEntryWithParty(t *testing.T) {
+	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	mockPartyRepo := new(MockPartyRepository)
+	service := NewLedgerService(nil, mockAccountRepo, mockJournalRepo, nil, WithPartyRepository(mockPartyRepo))
+
+	tenantID := uuid.New()
+	receivableID, revenueID := uuid.New(), uuid.New()
+	party := &repository.Party{ID: uuid.New(), Code: "CUST-001", Type: repository.PartyTypeCustomer}
+	partyID := party.ID.String()
+	now := time.Now()
+
+	request := func() *pb.CreateJournalEntryRequest {
+		return &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "INV-001",
+			EntryDate:       timestamppb.New(now),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: receivableID.String(), Debit: "100", Credit: "0", PartyId: &partyID},
+				{AccountId: revenueID.String(), Debit: "0", Credit: "100"},
+			},
+		}
+	}
+
+	t.Run("posts the party on its line", func(t *testing.T) {
+		mockPartyRepo.On("GetByIDs", ctx, tenantID, []uuid.UUID{party.ID}).
+			Return(map[uuid.UUID]*repository.Party{party.ID: party}, nil).Once()
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{receivableID, revenueID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				receivableID: {CurrencyCode: "USD", Precision: 2},
+				revenueID:    {CurrencyCode: "USD", Precision: 2},
+			}, nil).Once()
+		mockJournalRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			return p.Lines[0].PartyID != nil && *p.Lines[0].PartyID == party.ID && p.Lines[1].PartyID == nil
+		})).Return(&repository.JournalEntry{
+			ID:        uuid.New(),
+			TenantID:  tenantID,
+			EntryDate: now,
+			CreatedAt: now,
+		}, nil).Once()
+
+		_, err := service.CreateJournalEntry(ctx, request())
+
+		require.NoError(t, err)
+		mockPartyRepo.AssertExpectations(t)
+		mockAccountRepo.AssertExpectations(t)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns failed precondition for a deleted party", func(t *testing.T) {
+		deletedAt := now
+		deleted := *party
+		deleted.DeletedAt = &deletedAt
+		mockPartyRepo.On("GetByIDs", ctx, tenantID, []uuid.UUID{party.ID}).
+			Return(map[uuid.UUID]*repository.Party{party.ID: &deleted}, nil).Once()
+
+		resp, err := service.CreateJournalEntry(ctx, request())
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns invalid argument for an unknown party", func(t *testing.T) {
+		mockPartyRepo.On("GetByIDs", ctx, tenantID, []uuid.UUID{party.ID}).
+			Return(map[uuid.UUID]*repository.Party{}, nil).Once()
+
+		resp, err := service.CreateJournalEntry(ctx, request())
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test GetPartyStatement
+func TestLedgerService_GetPartyStatement(t *testing.T) {
+	ctx := context.Background()
+	mockPartyRepo := new(MockPartyRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithPartyRepository(mockPartyRepo))
+	fromDate := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("returns opening, running and closing balances", func(t *testing.T) {
+		tenantID, accountID := uuid.New(), uuid.New()
+		party := &repository.Party{ID: uuid.New(), TenantID: tenantID, Code: "CUST-001"}
+
+		mockPartyRepo.On("GetByID", ctx, tenantID, party.ID).Return(party, nil).Once()
+		mockPartyRepo.On("GetStatement", ctx, tenantID, party.ID, accountID, fromDate, toDate).Return(&repository.PartyStatement{
+			OpeningBalance: decimal.NewFromInt(50),
+			Lines: []*repository.PartyStatementLine{
+				{JournalEntryID: uuid.New(), LineID: uuid.New(), EntryDate: fromDate, ReferenceNumber: "INV-2", Debit: decimal.NewFromInt(100), Credit: decimal.Zero, Balance: decimal.NewFromInt(150)},
+				{JournalEntryID: uuid.New(), LineID: uuid.New(), EntryDate: toDate, ReferenceNumber: "PAY-1", Debit: decimal.Zero, Credit: decimal.NewFromInt(150), Balance: decimal.Zero},
+			},
+			ClosingBalance: decimal.Zero,
+		}, nil).Once()
+
+		resp, err := service.GetPartyStatement(ctx, &pb.GetPartyStatementRequest{
+			TenantId:  tenantID.String(),
+			PartyId:   party.ID.String(),
+			AccountId: accountID.String(),
+			FromDate:  timestamppb.New(fromDate),
+			ToDate:    timestamppb.New(toDate),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "50", resp.OpeningBalance)
+		require.Len(t, resp.Lines, 2)
+		assert.Equal(t, "150", resp.Lines[0].Balance)
+		assert.Equal(t, "0", resp.ClosingBalance)
+		mockPartyRepo.AssertExpectations(t)
+	})
+
+	t.Run("formats amounts and dates for the requested locale", func(t *testing.T) {
+		tenantID, accountID := uuid.New(), uuid.New()
+		party := &repository.Party{ID: uuid.New(), TenantID: tenantID, Code: "CUST-001"}
+
+		mockPartyRepo.On("GetByID", ctx, tenantID, party.ID).Return(party, nil).Once()
+		mockPartyRepo.On("GetStatement", ctx, tenantID, party.ID, accountID, fromDate, toDate).Return(&repository.PartyStatement{
+			OpeningBalance: decimal.NewFromInt(1250000),
+			Lines: []*repository.PartyStatementLine{
+				{JournalEntryID: uuid.New(), LineID: uuid.New(), EntryDate: fromDate, ReferenceNumber: "INV-2", Debit: decimal.RequireFromString("2500.5"), Credit: decimal.Zero, Balance: decimal.RequireFromString("1252500.5")},
+			},
+			ClosingBalance: decimal.RequireFromString("1252500.5"),
+		}, nil).Once()
+
+		resp, err := service.GetPartyStatement(ctx, &pb.GetPartyStatementRequest{
+			TenantId:  tenantID.String(),
+			PartyId:   party.ID.String(),
+			AccountId: accountID.String(),
+			FromDate:  timestamppb.New(fromDate),
+			ToDate:    timestamppb.New(toDate),
+			Locale:    "fa-IR",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "۱٬۲۵۰٬۰۰۰", resp.OpeningBalance)
+		require.Len(t, resp.Lines, 1)
+		assert.Equal(t, "۲٬۵۰۰٫۵", resp.Lines[0].Debit)
+		assert.Equal(t, "۱۴۰۲-۱۲-۱۱", resp.Lines[0].DisplayDate)
+		assert.Equal(t, fromDate, resp.Lines[0].EntryDate.AsTime())
+		mockPartyRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for an unknown party", func(t *testing.T) {
+		tenantID, partyID := uuid.New(), uuid.New()
+
+		mockPartyRepo.On("GetByID", ctx, tenantID, partyID).Return(nil, repository.ErrNotFound).Once()
+
+		resp, err := service.GetPartyStatement(ctx, &pb.GetPartyStatementRequest{
+			TenantId:  tenantID.String(),
+			PartyId:   partyID.String(),
+			AccountId: uuid.New().String(),
+			FromDate:  timestamppb.New(fromDate),
+			ToDate:    timestamppb.New(toDate),
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns invalid argument when the period is reversed", func(t *testing.T) {
+		resp, err := service.GetPartyStatement(ctx, &pb.GetPartyStatementRequest{
+			TenantId:  uuid.New().String(),
+			PartyId:   uuid.New().String(),
+			AccountId: uuid.New().String(),
+			FromDate:  timestamppb.New(toDate),
+			ToDate:    timestamppb.New(fromDate),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
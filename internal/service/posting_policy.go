@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/auth"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// GetPostingPolicy retrieves the posting policy of a tenant
+func (s *LedgerService) GetPostingPolicy(ctx context.Context, req *pb.GetPostingPolicyRequest) (*pb.GetPostingPolicyResponse, error) {
+	if s.policyRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "posting policies are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.policyRepo.Get(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("get posting policy", err)
+	}
+
+	return &pb.GetPostingPolicyResponse{
+		Policy: postingPolicyToProto(policy),
+	}, nil
+}
+
+// UpdatePostingPolicy updates the provided fields of a tenant's posting policy
+func (s *LedgerService) UpdatePostingPolicy(ctx context.Context, req *pb.UpdatePostingPolicyRequest) (*pb.UpdatePostingPolicyResponse, error) {
+	if s.policyRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "posting policies are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MaxBackdateDays != nil && *req.MaxBackdateDays < 0 {
+		return nil, status.Error(codes.InvalidArgument, "max backdate days must not be negative")
+	}
+	if req.MaxBackdateDays != nil && req.ClearMaxBackdateDays {
+		return nil, status.Error(codes.InvalidArgument, "max backdate days cannot be set and cleared at once")
+	}
+	if req.LockDate != nil && req.ClearLockDate {
+		return nil, status.Error(codes.InvalidArgument, "lock date cannot be set and cleared at once")
+	}
+
+	policy, err := s.policyRepo.Get(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("get posting policy", err)
+	}
+
+	if req.AllowFutureDates != nil {
+		policy.AllowFutureDates = *req.AllowFutureDates
+	}
+	if req.MaxBackdateDays != nil {
+		policy.MaxBackdateDays = req.MaxBackdateDays
+	}
+	if req.ClearMaxBackdateDays {
+		policy.MaxBackdateDays = nil
+	}
+	if req.LockDate != nil {
+		lockDate := *dateOf(req.LockDate)
+		policy.LockDate = &lockDate
+	}
+	if req.ClearLockDate {
+		policy.LockDate = nil
+	}
+
+	updated, err := s.policyRepo.Upsert(ctx, policy)
+	if err != nil {
+		return nil, repositoryError("update posting policy", err)
+	}
+
+	return &pb.UpdatePostingPolicyResponse{
+		Policy: postingPolicyToProto(updated),
+	}, nil
+}
+
+// checkPostingPolicy rejects entry dates the tenant's posting policy does not
+// allow. The entry date is a UTC day and today is the current day in the
+// tenant's timezone. A date in the locked period is allowed when a lock
+// override reason is given by credentials with the override:lock scope;
+// overridden reports whether that happened, so the reason is only recorded
+// on entries that needed it.
+func (s *LedgerService) checkPostingPolicy(ctx context.Context, tenantID uuid.UUID, clock tenantClock, day time.Time, overrideReason *string) (bool, error) {
+	if overrideReason != nil && strings.TrimSpace(*overrideReason) == "" {
+		return false, invalidField("lock_override_reason", "lock override reason must not be empty")
+	}
+
+	if s.policyRepo == nil {
+		return false, nil
+	}
+
+	policy, err := s.policyRepo.Get(ctx, tenantID)
+	if err != nil {
+		return false, repositoryError("get posting policy", err)
+	}
+
+	today := clock.today()
+	entryDay := day.Format("2006-01-02")
+	overridden := false
+
+	if policy.LockDate != nil && !day.After(startOfDay(*policy.LockDate)) {
+		lockDay := policy.LockDate.UTC().Format("2006-01-02")
+		if overrideReason == nil {
+			return false, failedPrecondition(reasonPeriodLocked, "entry_date",
+				fmt.Sprintf("entry date %s is on or before the lock date %s", entryDay, lockDay),
+				map[string]string{"entry_date": entryDay, "lock_date": lockDay})
+		}
+		if !auth.HasScope(ctx, auth.ScopeOverrideLock) {
+			return false, detailedError(codes.PermissionDenied,
+				fmt.Sprintf("posting on or before the lock date %s requires the %s scope", lockDay, auth.ScopeOverrideLock),
+				errorInfo(reasonPermissionDenied, map[string]string{"scope": auth.ScopeOverrideLock}))
+		}
+		overridden = true
+	}
+
+	if !policy.AllowFutureDates && day.After(today) {
+		return false, failedPrecondition(reasonFutureDate, "entry_date",
+			fmt.Sprintf("entry date %s is in the future", entryDay),
+			map[string]string{"entry_date": entryDay})
+	}
+
+	if policy.MaxBackdateDays != nil {
+		earliest := today.AddDate(0, 0, -int(*policy.MaxBackdateDays))
+		if day.Before(earliest) {
+			return false, failedPrecondition(reasonBackdateLimit, "entry_date",
+				fmt.Sprintf("entry date %s is more than %d days in the past", entryDay, *policy.MaxBackdateDays),
+				map[string]string{"entry_date": entryDay, "max_backdate_days": strconv.Itoa(int(*policy.MaxBackdateDays))})
+		}
+	}
+
+	return overridden, nil
+}
+
+func postingPolicyToProto(policy *repository.PostingPolicy) *pb.PostingPolicy {
+	pbPolicy := &pb.PostingPolicy{
+		TenantId:         policy.TenantID.String(),
+		AllowFutureDates: policy.AllowFutureDates,
+		MaxBackdateDays:  policy.MaxBackdateDays,
+	}
+
+	if policy.LockDate != nil {
+		pbPolicy.LockDate = timestamppb.New(*policy.LockDate)
+	}
+	if !policy.UpdatedAt.IsZero() {
+		pbPolicy.UpdatedAt = timestamppb.New(policy.UpdatedAt)
+	}
+
+	return pbPolicy
+}
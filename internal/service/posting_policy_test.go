@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/auth"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockPostingPolicyRepository struct {
+	mock.Mock
+}
+
+func (m *MockPostingPolicyRepository) Get(ctx context.Context, tenantID uuid.UUID) (*repository.PostingPolicy, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.PostingPolicy), args.Error(1)
+}
+
+func (m *MockPostingPolicyRepository) Upsert(ctx context.Context, policy *repository.PostingPolicy) (*repository.PostingPolicy, error) {
+	args := m.Called(ctx, policy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.PostingPolicy), args.Error(1)
+}
+
+// Test UpdatePostingPolicy
+func TestLedgerService_UpdatePostingPolicy(t *testing.T) {
+	ctx := context.Background()
+	mockPolicyRepo := new(MockPostingPolicyRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithPostingPolicyRepository(mockPolicyRepo))
+
+	t.Run("updates provided fields and clears the lock date", func(t *testing.T) {
+		tenantID := uuid.New()
+		lockDate := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+
+		mockPolicyRepo.On("Get", ctx, tenantID).Return(&repository.PostingPolicy{
+			TenantID:         tenantID,
+			AllowFutureDates: true,
+			MaxBackdateDays:  int32Ptr(30),
+			LockDate:         &lockDate,
+		}, nil).Once()
+		mockPolicyRepo.On("Upsert", ctx, mock.MatchedBy(func(p *repository.PostingPolicy) bool {
+			return !p.AllowFutureDates && *p.MaxBackdateDays == 30 && p.LockDate == nil
+		})).Return(&repository.PostingPolicy{
+			TenantID:        tenantID,
+			MaxBackdateDays: int32Ptr(30),
+		}, nil).Once()
+
+		allow := false
+		resp, err := service.UpdatePostingPolicy(ctx, &pb.UpdatePostingPolicyRequest{
+			TenantId:         tenantID.String(),
+			AllowFutureDates: &allow,
+			ClearLockDate:    true,
+		})
+
+		assert.NoError(t, err)
+		assert.False(t, resp.Policy.AllowFutureDates)
+		assert.Equal(t, int32(30), resp.Policy.GetMaxBackdateDays())
+		assert.Nil(t, resp.Policy.LockDate)
+		mockPolicyRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error for negative max backdate days", func(t *testing.T) {
+		resp, err := service.UpdatePostingPolicy(ctx, &pb.UpdatePostingPolicyRequest{
+			TenantId:        uuid.New().String(),
+			MaxBackdateDays: int32Ptr(-1),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns unimplemented when posting policies are disabled", func(t *testing.T) {
+		resp, err := NewLedgerService(nil, nil, nil, nil).UpdatePostingPolicy(ctx, &pb.UpdatePostingPolicyRequest{
+			TenantId: uuid.New().String(),
+		})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test posting policy enforcement in CreateJournalEntry
+func TestLedgerService_CreateJournalEntry_PostingPolicy(t *testing.T) {
+	ctx := context.Background()
+	mockPolicyRepo := new(MockPostingPolicyRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithPostingPolicyRepository(mockPolicyRepo))
+
+	today := startOfDay(time.Now())
+	lockDate := today.AddDate(0, 0, -10)
+
+	tests := []struct {
+		name      string
+		entryDate time.Time
+		policy    repository.PostingPolicy
+		reason    string
+	}{
+		{
+			name:      "rejects entries on the lock date",
+			entryDate: lockDate,
+			policy:    repository.PostingPolicy{AllowFutureDates: true, LockDate: &lockDate},
+			reason:    reasonPeriodLocked,
+		},
+		{
+			name:      "rejects future entries",
+			entryDate: today.AddDate(0, 0, 1),
+			policy:    repository.PostingPolicy{AllowFutureDates: false},
+			reason:    reasonFutureDate,
+		},
+		{
+			name:      "rejects entries beyond the backdating limit",
+			entryDate: today.AddDate(0, 0, -8),
+			policy:    repository.PostingPolicy{AllowFutureDates: true, MaxBackdateDays: int32Ptr(7)},
+			reason:    reasonBackdateLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID := uuid.New()
+			policy := tt.policy
+			policy.TenantID = tenantID
+			mockPolicyRepo.On("Get", ctx, tenantID).Return(&policy, nil).Once()
+
+			resp, err := service.CreateJournalEntry(ctx, &pb.CreateJournalEntryRequest{
+				TenantId:        tenantID.String(),
+				ReferenceNumber: "REF001",
+				EntryDate:       timestamppb.New(tt.entryDate),
+				Lines: []*pb.JournalEntryLine{
+					{AccountId: uuid.New().String(), Debit: "100", Credit: "0"},
+					{AccountId: uuid.New().String(), Debit: "0", Credit: "100"},
+				},
+			})
+
+			assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+			assert.Equal(t, tt.reason, errorReason(t, err))
+			violations := preconditionViolations(t, err)
+			if assert.Len(t, violations, 1) {
+				assert.Equal(t, "entry_date", violations[0].Subject)
+			}
+			assert.Nil(t, resp)
+			mockPolicyRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// Test entry dates are read as UTC days against the posting policy, whatever
+// the tenant's timezone
+func TestLedgerService_CreateJournalEntry_PostingPolicyTimezone(t *testing.T) {
+	ctx := context.Background()
+	mockPolicyRepo := new(MockPostingPolicyRepository)
+	mockSettingsRepo := new(MockTenantSettingsRepository)
+	service := NewLedgerService(nil, nil, nil, nil,
+		WithPostingPolicyRepository(mockPolicyRepo),
+		WithTenantSettingsRepository(mockSettingsRepo),
+	)
+
+	tenantID := uuid.New()
+	lockDate := time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC)
+	mockSettingsRepo.On("Get", ctx, tenantID).Return(&repository.TenantSettings{TenantID: tenantID, Timezone: "Asia/Tehran"}, nil).Once()
+	mockPolicyRepo.On("Get", ctx, tenantID).Return(&repository.PostingPolicy{TenantID: tenantID, AllowFutureDates: true, LockDate: &lockDate}, nil).Once()
+
+	// 21:00 UTC on 30 January is already 31 January in Tehran, but the
+	// field names 30 January
+	_, err := service.CreateJournalEntry(ctx, &pb.CreateJournalEntryRequest{
+		TenantId:        tenantID.String(),
+		ReferenceNumber: "REF001",
+		EntryDate:       timestamppb.New(time.Date(2024, 1, 30, 21, 0, 0, 0, time.UTC)),
+		Lines: []*pb.JournalEntryLine{
+			{AccountId: uuid.New().String(), Debit: "100", Credit: "0"},
+			{AccountId: uuid.New().String(), Debit: "0", Credit: "100"},
+		},
+	})
+
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, reasonPeriodLocked, errorReason(t, err))
+	mockSettingsRepo.AssertExpectations(t)
+	mockPolicyRepo.AssertExpectations(t)
+}
+
+// Test merges date their reclassification entry today and are rejected in a
+// locked period
+func TestLedgerService_MergeAccounts_PostingPolicy(t *testing.T) {
+	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
+	mockPolicyRepo := new(MockPostingPolicyRepository)
+	service := NewLedgerService(nil, mockAccountRepo, nil, nil, WithPostingPolicyRepository(mockPolicyRepo))
+
+	tenantID, sourceID, targetID := uuid.New(), uuid.New(), uuid.New()
+	request := &pb.MergeAccountsRequest{
+		TenantId:        tenantID.String(),
+		SourceAccountId: sourceID.String(),
+		TargetAccountId: targetID.String(),
+	}
+	today := startOfDay(time.Now())
+
+	t.Run("rejects a merge when today is locked", func(t *testing.T) {
+		mockPolicyRepo.On("Get", ctx, tenantID).Return(&repository.PostingPolicy{TenantID: tenantID, LockDate: &today}, nil).Once()
+
+		_, err := service.MergeAccounts(ctx, request)
+
+		assert.Equal(t, reasonPeriodLocked, errorReason(t, err))
+		mockAccountRepo.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("dates the reclassification entry today", func(t *testing.T) {
+		lockDate := today.AddDate(0, 0, -1)
+		mockPolicyRepo.On("Get", ctx, tenantID).Return(&repository.PostingPolicy{TenantID: tenantID, LockDate: &lockDate}, nil).Once()
+		mockAccountRepo.On("Merge", ctx, tenantID, sourceID, targetID, today).Return(&repository.AccountMerge{
+			Source: &repository.Account{ID: sourceID, TenantID: tenantID},
+			Target: &repository.Account{ID: targetID, TenantID: tenantID},
+		}, nil).Once()
+
+		_, err := service.MergeAccounts(ctx, request)
+
+		assert.NoError(t, err)
+		mockAccountRepo.AssertExpectations(t)
+	})
+}
+
+// Test posting into a locked period with a lock override
+func TestLedgerService_CreateJournalEntry_LockOverride(t *testing.T) {
+	lockDate := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	entryDate := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	reason := "Late supplier invoice approved by the controller"
+
+	newRequest := func(tenantID uuid.UUID, debitAccount, creditAccount uuid.UUID, overrideReason *string) *pb.CreateJournalEntryRequest {
+		return &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "REF001",
+			EntryDate:       timestamppb.New(entryDate),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: debitAccount.String(), Debit: "100", Credit: "0"},
+				{AccountId: creditAccount.String(), Debit: "0", Credit: "100"},
+			},
+			LockOverrideReason: overrideReason,
+		}
+	}
+
+	t.Run("posts with the reason when the credentials grant override:lock", func(t *testing.T) {
+		ctx := auth.NewScopesContext(context.Background(), []string{auth.ScopeWriteJournal, auth.ScopeOverrideLock})
+		mockAccountRepo := new(MockAccountRepository)
+		mockJournalRepo := new(MockJournalRepository)
+		mockPolicyRepo := new(MockPostingPolicyRepository)
+		service := NewLedgerService(nil, mockAccountRepo, mockJournalRepo, nil, WithPostingPolicyRepository(mockPolicyRepo))
+
+		tenantID := uuid.New()
+		debitAccount := uuid.New()
+		creditAccount := uuid.New()
+		mockPolicyRepo.On("Get", ctx, tenantID).Return(&repository.PostingPolicy{TenantID: tenantID, AllowFutureDates: true, LockDate: &lockDate}, nil).Once()
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{debitAccount, creditAccount}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				debitAccount:  {CurrencyCode: "USD", Precision: 2},
+				creditAccount: {CurrencyCode: "USD", Precision: 2},
+			}, nil).Once()
+		mockJournalRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			return p.LockOverrideReason != nil && *p.LockOverrideReason == reason
+		})).Return(&repository.JournalEntry{ID: uuid.New(), TenantID: tenantID, EntryDate: entryDate}, nil).Once()
+
+		_, err := service.CreateJournalEntry(ctx, newRequest(tenantID, debitAccount, creditAccount, &reason))
+
+		assert.NoError(t, err)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects the override without the override:lock scope", func(t *testing.T) {
+		ctx := auth.NewScopesContext(context.Background(), []string{auth.ScopeWriteJournal})
+		mockPolicyRepo := new(MockPostingPolicyRepository)
+		service := NewLedgerService(nil, nil, nil, nil, WithPostingPolicyRepository(mockPolicyRepo))
+
+		tenantID := uuid.New()
+		mockPolicyRepo.On("Get", ctx, tenantID).Return(&repository.PostingPolicy{TenantID: tenantID, AllowFutureDates: true, LockDate: &lockDate}, nil).Once()
+
+		resp, err := service.CreateJournalEntry(ctx, newRequest(tenantID, uuid.New(), uuid.New(), &reason))
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Equal(t, reasonPermissionDenied, errorReason(t, err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("rejects an empty reason", func(t *testing.T) {
+		service := NewLedgerService(nil, nil, nil, nil, WithPostingPolicyRepository(new(MockPostingPolicyRepository)))
+		empty := "  "
+
+		resp, err := service.CreateJournalEntry(context.Background(), newRequest(uuid.New(), uuid.New(), uuid.New(), &empty))
+
+		assert.Equal(t, reasonInvalidField, errorReason(t, err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("does not record a reason outside the locked period", func(t *testing.T) {
+		ctx := auth.NewScopesContext(context.Background(), []string{auth.ScopeWriteJournal})
+		mockAccountRepo := new(MockAccountRepository)
+		mockJournalRepo := new(MockJournalRepository)
+		mockPolicyRepo := new(MockPostingPolicyRepository)
+		service := NewLedgerService(nil, mockAccountRepo, mockJournalRepo, nil, WithPostingPolicyRepository(mockPolicyRepo))
+
+		tenantID := uuid.New()
+		debitAccount := uuid.New()
+		creditAccount := uuid.New()
+		earlierLock := entryDate.AddDate(0, 0, -1)
+		mockPolicyRepo.On("Get", ctx, tenantID).Return(&repository.PostingPolicy{TenantID: tenantID, AllowFutureDates: true, LockDate: &earlierLock}, nil).Once()
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{debitAccount, creditAccount}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				debitAccount:  {CurrencyCode: "USD", Precision: 2},
+				creditAccount: {CurrencyCode: "USD", Precision: 2},
+			}, nil).Once()
+		mockJournalRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			return p.LockOverrideReason == nil
+		})).Return(&repository.JournalEntry{ID: uuid.New(), TenantID: tenantID, EntryDate: entryDate}, nil).Once()
+
+		_, err := service.CreateJournalEntry(ctx, newRequest(tenantID, debitAccount, creditAccount, &reason))
+
+		assert.NoError(t, err)
+		mockJournalRepo.AssertExpectations(t)
+	})
+}
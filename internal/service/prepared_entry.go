@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// defaultPreparedEntryExpiry is how long a prepared entry stays pending when
+// no expiry is given
+const defaultPreparedEntryExpiry = 15 * time.Minute
+
+// PrepareJournalEntry validates a journal entry as CreateJournalEntry would
+// and reserves the amounts it takes from each account without posting it.
+// The entry is posted by ConfirmJournalEntry, or dropped by
+// CancelJournalEntry or when it expires, so a saga step can be undone
+// without a compensating entry.
+func (s *LedgerService) PrepareJournalEntry(ctx context.Context, req *pb.PrepareJournalEntryRequest) (*pb.PrepareJournalEntryResponse, error) {
+	if s.preparedRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "two-phase posting is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(defaultPreparedEntryExpiry)
+	if req.ExpiresAt != nil {
+		expiresAt = req.ExpiresAt.AsTime()
+		if !expiresAt.After(time.Now()) {
+			return nil, invalidField("expires_at", "expiry must be in the future")
+		}
+	}
+
+	params, err := s.journalEntryParams(ctx, tenantID, &pb.CreateJournalEntryRequest{
+		TenantId:           req.TenantId,
+		ReferenceNumber:    req.ReferenceNumber,
+		Description:        req.Description,
+		EntryDate:          req.EntryDate,
+		Lines:              req.Lines,
+		Metadata:           req.Metadata,
+		CurrencyCode:       req.CurrencyCode,
+		TransactionId:      req.TransactionId,
+		BookId:             req.BookId,
+		JalaliEntryDate:    req.JalaliEntryDate,
+		LockOverrideReason: req.LockOverrideReason,
+	}, s.limits)
+	if err != nil {
+		return nil, err
+	}
+
+	prepared, err := s.preparedRepo.Prepare(ctx, tenantID, params, expiresAt)
+	if err != nil {
+		return nil, repositoryError("prepare journal entry", err)
+	}
+
+	return &pb.PrepareJournalEntryResponse{
+		PreparedEntry: preparedEntryToProto(prepared),
+	}, nil
+}
+
+// GetPreparedJournalEntry retrieves a prepared entry, so a saga coordinator
+// recovering from a failure can learn how far the entry got
+func (s *LedgerService) GetPreparedJournalEntry(ctx context.Context, req *pb.GetPreparedJournalEntryRequest) (*pb.GetPreparedJournalEntryResponse, error) {
+	if s.preparedRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "two-phase posting is not enabled")
+	}
+
+	tenantID, preparedID, err := parsePreparedEntryIDs(req.TenantId, req.PreparedEntryId)
+	if err != nil {
+		return nil, err
+	}
+
+	prepared, err := s.preparedRepo.GetByID(ctx, tenantID, preparedID)
+	if err != nil {
+		return nil, repositoryError("get prepared journal entry", err)
+	}
+
+	return &pb.GetPreparedJournalEntryResponse{
+		PreparedEntry: preparedEntryToProto(prepared),
+	}, nil
+}
+
+// ConfirmJournalEntry posts the entry of a pending prepared entry. The
+// entry was validated when it was prepared and its reservations are
+// released as it posts, so only an expired or cancelled entry is refused.
+func (s *LedgerService) ConfirmJournalEntry(ctx context.Context, req *pb.ConfirmJournalEntryRequest) (*pb.ConfirmJournalEntryResponse, error) {
+	if s.preparedRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "two-phase posting is not enabled")
+	}
+
+	tenantID, preparedID, err := parsePreparedEntryIDs(req.TenantId, req.PreparedEntryId)
+	if err != nil {
+		return nil, err
+	}
+
+	prepared, err := s.preparedRepo.Confirm(ctx, tenantID, preparedID)
+	if err != nil {
+		return nil, repositoryError("confirm journal entry", err)
+	}
+
+	entry, err := s.journalRepo.GetByID(ctx, tenantID, *prepared.JournalEntryID)
+	if err != nil {
+		return nil, repositoryError("get journal entry", err)
+	}
+
+	return &pb.ConfirmJournalEntryResponse{
+		PreparedEntry: preparedEntryToProto(prepared),
+		JournalEntry:  journalEntryToProto(entry),
+	}, nil
+}
+
+// CancelJournalEntry frees the reservations of a prepared entry without
+// posting
+func (s *LedgerService) CancelJournalEntry(ctx context.Context, req *pb.CancelJournalEntryRequest) (*pb.CancelJournalEntryResponse, error) {
+	if s.preparedRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "two-phase posting is not enabled")
+	}
+
+	tenantID, preparedID, err := parsePreparedEntryIDs(req.TenantId, req.PreparedEntryId)
+	if err != nil {
+		return nil, err
+	}
+
+	prepared, err := s.preparedRepo.Cancel(ctx, tenantID, preparedID)
+	if err != nil {
+		return nil, repositoryError("cancel journal entry", err)
+	}
+
+	return &pb.CancelJournalEntryResponse{
+		PreparedEntry: preparedEntryToProto(prepared),
+	}, nil
+}
+
+func parsePreparedEntryIDs(tenantIDValue, preparedIDValue string) (uuid.UUID, uuid.UUID, error) {
+	tenantID, err := requestID("tenant_id", tenantIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	preparedID, err := requestID("prepared_entry_id", preparedIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return tenantID, preparedID, nil
+}
+
+func preparedEntryToProto(prepared *repository.PreparedJournalEntry) *pb.PreparedJournalEntry {
+	pbPrepared := &pb.PreparedJournalEntry{
+		PreparedEntryId: prepared.ID.String(),
+		TenantId:        prepared.TenantID.String(),
+		ReferenceNumber: prepared.ReferenceNumber,
+		Description:     prepared.Description,
+		ExpiresAt:       timestamppb.New(prepared.ExpiresAt),
+		Reservations:    make([]*pb.Reservation, len(prepared.Reservations)),
+		CreatedAt:       timestamppb.New(prepared.CreatedAt),
+		UpdatedAt:       timestamppb.New(prepared.UpdatedAt),
+	}
+
+	switch prepared.Status {
+	case repository.PreparedEntryStatusPending:
+		pbPrepared.Status = pb.PreparedEntryStatus_PREPARED_ENTRY_STATUS_PENDING
+	case repository.PreparedEntryStatusConfirmed:
+		pbPrepared.Status = pb.PreparedEntryStatus_PREPARED_ENTRY_STATUS_CONFIRMED
+	case repository.PreparedEntryStatusCancelled:
+		pbPrepared.Status = pb.PreparedEntryStatus_PREPARED_ENTRY_STATUS_CANCELLED
+	case repository.PreparedEntryStatusExpired:
+		pbPrepared.Status = pb.PreparedEntryStatus_PREPARED_ENTRY_STATUS_EXPIRED
+	}
+
+	if prepared.TransactionID != "" {
+		transactionID := prepared.TransactionID
+		pbPrepared.TransactionId = &transactionID
+	}
+
+	if prepared.JournalEntryID != nil {
+		journalEntryID := prepared.JournalEntryID.String()
+		pbPrepared.JournalEntryId = &journalEntryID
+	}
+
+	for i, reservation := range prepared.Reservations {
+		pbPrepared.Reservations[i] = &pb.Reservation{
+			AccountId: reservation.AccountID.String(),
+			Amount:    reservation.Amount.String(),
+		}
+	}
+
+	return pbPrepared
+}
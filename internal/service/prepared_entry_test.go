@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockPreparedEntryRepository struct {
+	mock.Mock
+}
+
+func (m *MockPreparedEntryRepository) Prepare(ctx context.Context, tenantID uuid.UUID, entry repository.CreateJournalEntryParams, expiresAt time.Time) (*repository.PreparedJournalEntry, error) {
+	args := m.Called(ctx, tenantID, entry, expiresAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.PreparedJournalEntry), args.Error(1)
+}
+
+func (m *MockPreparedEntryRepository) GetByID(ctx context.Context, tenantID uuid.UUID, preparedID uuid.UUID) (*repository.PreparedJournalEntry, error) {
+	args := m.Called(ctx, tenantID, preparedID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.PreparedJournalEntry), args.Error(1)
+}
+
+func (m *MockPreparedEntryRepository) Confirm(ctx context.Context, tenantID uuid.UUID, preparedID uuid.UUID) (*repository.PreparedJournalEntry, error) {
+	args := m.Called(ctx, tenantID, preparedID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.PreparedJournalEntry), args.Error(1)
+}
+
+func (m *MockPreparedEntryRepository) Cancel(ctx context.Context, tenantID uuid.UUID, preparedID uuid.UUID) (*repository.PreparedJournalEntry, error) {
+	args := m.Called(ctx, tenantID, preparedID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.PreparedJournalEntry), args.Error(1)
+}
+
+func TestLedgerService_PreparedEntries(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	mockPreparedRepo := new(MockPreparedEntryRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		mockJournalRepo,
+		memory.NewReferenceRepository(store),
+		WithPreparedEntryRepository(mockPreparedRepo),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "sagas", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID
+
+	createAccount := func(number string, accountTypeID int32) uuid.UUID {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID.String(),
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: accountTypeID,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(t, err)
+		return uuid.MustParse(resp.AccountId)
+	}
+	wallet := createAccount("2001", 2)
+	merchant := createAccount("2002", 2)
+
+	lines := []*pb.JournalEntryLine{
+		{AccountId: wallet.String(), Debit: "25.00", Credit: "0"},
+		{AccountId: merchant.String(), Debit: "0", Credit: "25.00"},
+	}
+
+	pendingEntry := func() *repository.PreparedJournalEntry {
+		return &repository.PreparedJournalEntry{
+			ID:              uuid.New(),
+			TenantID:        tenantID,
+			ReferenceNumber: "ORDER-1",
+			TransactionID:   "order-1",
+			Status:          repository.PreparedEntryStatusPending,
+			ExpiresAt:       time.Now().Add(defaultPreparedEntryExpiry),
+			Reservations: []*repository.Reservation{
+				{AccountID: wallet, Amount: decimal.NewFromInt(25)},
+			},
+		}
+	}
+
+	t.Run("prepares an entry expiring in fifteen minutes by default", func(t *testing.T) {
+		prepared := pendingEntry()
+		transactionID := "order-1"
+		mockPreparedRepo.On("Prepare", ctx, tenantID, mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			return len(p.Lines) == 2 && p.Lines[0].AccountID == wallet && p.Lines[0].Debit.Equal(decimal.NewFromInt(25)) &&
+				p.ReferenceNumber == "ORDER-1" && p.TransactionID == transactionID
+		}), mock.MatchedBy(func(expiresAt time.Time) bool {
+			return expiresAt.Sub(time.Now()) > defaultPreparedEntryExpiry-time.Minute
+		})).Return(prepared, nil).Once()
+
+		resp, err := service.PrepareJournalEntry(ctx, &pb.PrepareJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "ORDER-1",
+			Lines:           lines,
+			TransactionId:   &transactionID,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, prepared.ID.String(), resp.PreparedEntry.PreparedEntryId)
+		assert.Equal(t, pb.PreparedEntryStatus_PREPARED_ENTRY_STATUS_PENDING, resp.PreparedEntry.Status)
+		assert.Equal(t, transactionID, resp.PreparedEntry.GetTransactionId())
+		require.Len(t, resp.PreparedEntry.Reservations, 1)
+		assert.Equal(t, "25", resp.PreparedEntry.Reservations[0].Amount)
+		assert.Nil(t, resp.PreparedEntry.JournalEntryId)
+		mockPreparedRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects preparing without enough funds", func(t *testing.T) {
+		mockPreparedRepo.On("Prepare", ctx, tenantID, mock.Anything, mock.Anything).
+			Return(nil, repository.ErrInsufficientFunds).Once()
+
+		_, err := service.PrepareJournalEntry(ctx, &pb.PrepareJournalEntryRequest{
+			TenantId: tenantID.String(),
+			Lines:    lines,
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockPreparedRepo.AssertExpectations(t)
+	})
+
+	t.Run("validates the entry before preparing it", func(t *testing.T) {
+		past := timestamppb.New(time.Now().Add(-time.Minute))
+		for _, req := range []*pb.PrepareJournalEntryRequest{
+			{TenantId: "invalid", Lines: lines},
+			{TenantId: tenantID.String(), Lines: lines, ExpiresAt: past},
+			{TenantId: tenantID.String(), Lines: lines[:1]},
+			{TenantId: tenantID.String(), Lines: []*pb.JournalEntryLine{
+				{AccountId: wallet.String(), Debit: "25.00", Credit: "0"},
+				{AccountId: merchant.String(), Debit: "0", Credit: "20.00"},
+			}},
+		} {
+			_, err := service.PrepareJournalEntry(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+	})
+
+	t.Run("confirms a prepared entry into the journal", func(t *testing.T) {
+		prepared := pendingEntry()
+		entryID := uuid.New()
+		confirmed := *prepared
+		confirmed.Status = repository.PreparedEntryStatusConfirmed
+		confirmed.JournalEntryID = &entryID
+
+		mockPreparedRepo.On("Confirm", ctx, tenantID, prepared.ID).Return(&confirmed, nil).Once()
+		mockJournalRepo.On("GetByID", ctx, tenantID, entryID).Return(&repository.JournalEntry{
+			ID:       entryID,
+			TenantID: tenantID,
+		}, nil).Once()
+
+		resp, err := service.ConfirmJournalEntry(ctx, &pb.ConfirmJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			PreparedEntryId: prepared.ID.String(),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, pb.PreparedEntryStatus_PREPARED_ENTRY_STATUS_CONFIRMED, resp.PreparedEntry.Status)
+		assert.Equal(t, entryID.String(), resp.PreparedEntry.GetJournalEntryId())
+		assert.Equal(t, entryID.String(), resp.JournalEntry.JournalEntryId)
+		mockPreparedRepo.AssertExpectations(t)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects confirming an expired entry", func(t *testing.T) {
+		preparedID := uuid.New()
+		mockPreparedRepo.On("Confirm", ctx, tenantID, preparedID).Return(nil, repository.ErrPreparedEntryExpired).Once()
+
+		_, err := service.ConfirmJournalEntry(ctx, &pb.ConfirmJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			PreparedEntryId: preparedID.String(),
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockPreparedRepo.AssertExpectations(t)
+	})
+
+	t.Run("cancels a prepared entry unless it was confirmed", func(t *testing.T) {
+		prepared := pendingEntry()
+		prepared.Status = repository.PreparedEntryStatusCancelled
+		mockPreparedRepo.On("Cancel", ctx, tenantID, prepared.ID).Return(prepared, nil).Once()
+		mockPreparedRepo.On("Cancel", ctx, tenantID, prepared.ID).Return(nil, repository.ErrPreparedEntryNotPending).Once()
+
+		resp, err := service.CancelJournalEntry(ctx, &pb.CancelJournalEntryRequest{TenantId: tenantID.String(), PreparedEntryId: prepared.ID.String()})
+		require.NoError(t, err)
+		assert.Equal(t, pb.PreparedEntryStatus_PREPARED_ENTRY_STATUS_CANCELLED, resp.PreparedEntry.Status)
+
+		_, err = service.CancelJournalEntry(ctx, &pb.CancelJournalEntryRequest{TenantId: tenantID.String(), PreparedEntryId: prepared.ID.String()})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockPreparedRepo.AssertExpectations(t)
+	})
+
+	t.Run("gets a prepared entry", func(t *testing.T) {
+		prepared := pendingEntry()
+		prepared.Status = repository.PreparedEntryStatusExpired
+		mockPreparedRepo.On("GetByID", ctx, tenantID, prepared.ID).Return(prepared, nil).Once()
+
+		resp, err := service.GetPreparedJournalEntry(ctx, &pb.GetPreparedJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			PreparedEntryId: prepared.ID.String(),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, pb.PreparedEntryStatus_PREPARED_ENTRY_STATUS_EXPIRED, resp.PreparedEntry.Status)
+
+		_, err = service.GetPreparedJournalEntry(ctx, &pb.GetPreparedJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			PreparedEntryId: "invalid",
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		mockPreparedRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns unimplemented when two-phase posting is disabled", func(t *testing.T) {
+		service := NewLedgerService(nil, nil, nil, nil)
+
+		_, err := service.PrepareJournalEntry(ctx, &pb.PrepareJournalEntryRequest{TenantId: tenantID.String()})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = service.ConfirmJournalEntry(ctx, &pb.ConfirmJournalEntryRequest{TenantId: tenantID.String()})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
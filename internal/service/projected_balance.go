@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// maxProjectedAccounts limits the accounts of a single projected balance
+// request
+const maxProjectedAccounts = 100
+
+// GetProjectedBalance reports, for each account, the booked balance next to
+// what pending work will change it by: the draft entries of open and
+// approved journal batches, less pending holds and prepared entry
+// reservations. Treasury views can show committed funds against available
+// ones from a single call.
+func (s *LedgerService) GetProjectedBalance(ctx context.Context, req *pb.GetProjectedBalanceRequest) (*pb.GetProjectedBalanceResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.AccountIds) == 0 {
+		return nil, invalidField("account_ids", "at least one account ID is required")
+	}
+	if len(req.AccountIds) > maxProjectedAccounts {
+		return nil, invalidField("account_ids", "at most 100 accounts can be projected")
+	}
+
+	accountIDs := make([]uuid.UUID, 0, len(req.AccountIds))
+	seen := make(map[uuid.UUID]bool, len(req.AccountIds))
+	for _, raw := range req.AccountIds {
+		id, err := requestID("account_ids", raw)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[id] {
+			seen[id] = true
+			accountIDs = append(accountIDs, id)
+		}
+	}
+
+	balances, err := s.accountRepo.GetProjectedBalances(ctx, tenantID, accountIDs)
+	if err != nil {
+		return nil, repositoryError("get projected balances", err)
+	}
+
+	resp := &pb.GetProjectedBalanceResponse{
+		Balances: make([]*pb.ProjectedBalance, len(balances)),
+	}
+	for i, balance := range balances {
+		resp.Balances[i] = &pb.ProjectedBalance{
+			AccountId:        balance.AccountID.String(),
+			BookedBalance:    balance.Booked.String(),
+			HeldAmount:       balance.Held.String(),
+			DraftAmount:      balance.Drafts.String(),
+			PendingAmount:    balance.Pending.String(),
+			ProjectedBalance: balance.Projected.String(),
+			AvailableBalance: balance.Available.String(),
+		}
+	}
+
+	return resp, nil
+}
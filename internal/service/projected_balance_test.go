@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+func TestLedgerService_GetProjectedBalance(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	mockAccountRepo := new(MockAccountRepository)
+	service := NewLedgerService(nil, mockAccountRepo, nil, nil)
+
+	treasury := uuid.New()
+	payroll := uuid.New()
+
+	t.Run("reports booked, pending and projected balances per account", func(t *testing.T) {
+		limit := decimal.NewFromInt(100)
+		mockAccountRepo.On("GetProjectedBalances", ctx, tenantID, []uuid.UUID{treasury, payroll}).Return([]*repository.ProjectedBalance{
+			{
+				AvailableBalance: repository.AvailableBalance{
+					AccountID:      treasury,
+					Booked:         decimal.NewFromInt(5000),
+					Held:           decimal.NewFromInt(300),
+					OverdraftLimit: &limit,
+					Available:      decimal.NewFromInt(4800),
+				},
+				Drafts:    decimal.NewFromInt(-1200),
+				Pending:   decimal.NewFromInt(-1500),
+				Projected: decimal.NewFromInt(3500),
+			},
+			{
+				AvailableBalance: repository.AvailableBalance{
+					AccountID: payroll,
+					Booked:    decimal.Zero,
+					Held:      decimal.Zero,
+					Available: decimal.Zero,
+				},
+				Drafts:    decimal.NewFromInt(1200),
+				Pending:   decimal.NewFromInt(1200),
+				Projected: decimal.NewFromInt(1200),
+			},
+		}, nil).Once()
+
+		resp, err := service.GetProjectedBalance(ctx, &pb.GetProjectedBalanceRequest{
+			TenantId:   tenantID.String(),
+			AccountIds: []string{treasury.String(), payroll.String(), treasury.String()},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Balances, 2)
+		assert.Equal(t, treasury.String(), resp.Balances[0].AccountId)
+		assert.Equal(t, "5000", resp.Balances[0].BookedBalance)
+		assert.Equal(t, "300", resp.Balances[0].HeldAmount)
+		assert.Equal(t, "-1200", resp.Balances[0].DraftAmount)
+		assert.Equal(t, "-1500", resp.Balances[0].PendingAmount)
+		assert.Equal(t, "3500", resp.Balances[0].ProjectedBalance)
+		assert.Equal(t, "4800", resp.Balances[0].AvailableBalance)
+		assert.Equal(t, "1200", resp.Balances[1].ProjectedBalance)
+		mockAccountRepo.AssertExpectations(t)
+	})
+
+	t.Run("fails for an unknown account", func(t *testing.T) {
+		unknown := uuid.New()
+		mockAccountRepo.On("GetProjectedBalances", ctx, tenantID, []uuid.UUID{unknown}).
+			Return(nil, repository.ErrNotFound).Once()
+
+		_, err := service.GetProjectedBalance(ctx, &pb.GetProjectedBalanceRequest{
+			TenantId:   tenantID.String(),
+			AccountIds: []string{unknown.String()},
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		mockAccountRepo.AssertExpectations(t)
+	})
+
+	t.Run("validates the accounts", func(t *testing.T) {
+		tooMany := make([]string, maxProjectedAccounts+1)
+		for i := range tooMany {
+			tooMany[i] = uuid.NewString()
+		}
+
+		for _, req := range []*pb.GetProjectedBalanceRequest{
+			{TenantId: "invalid", AccountIds: []string{treasury.String()}},
+			{TenantId: tenantID.String()},
+			{TenantId: tenantID.String(), AccountIds: []string{"invalid"}},
+			{TenantId: tenantID.String(), AccountIds: tooMany},
+		} {
+			_, err := service.GetProjectedBalance(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// GetQuotaUsage returns the tenant's quota limits together with its current usage
+func (s *LedgerService) GetQuotaUsage(ctx context.Context, req *pb.GetQuotaUsageRequest) (*pb.GetQuotaUsageResponse, error) {
+	if s.quotaRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tenant quotas are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	quota, err := s.quotaRepo.Get(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("get tenant quota", err)
+	}
+
+	clock, err := s.clock(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.quotaRepo.GetUsage(ctx, tenantID, clock.dayStart())
+	if err != nil {
+		return nil, repositoryError("get quota usage", err)
+	}
+
+	return &pb.GetQuotaUsageResponse{
+		Quota: quotaToProto(quota),
+		Usage: &pb.QuotaUsage{
+			AccountCount: int32(usage.AccountCount),
+			EntriesToday: int32(usage.EntriesSince),
+		},
+	}, nil
+}
+
+// GetTenantQuota retrieves the quota limits of a tenant
+func (s *AdminService) GetTenantQuota(ctx context.Context, req *pb.GetTenantQuotaRequest) (*pb.GetTenantQuotaResponse, error) {
+	if s.quotaRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tenant quotas are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	quota, err := s.quotaRepo.Get(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("get tenant quota", err)
+	}
+
+	return &pb.GetTenantQuotaResponse{
+		Quota: quotaToProto(quota),
+	}, nil
+}
+
+// UpdateTenantQuota changes the quota limits of a tenant; a limit of 0 removes it
+func (s *AdminService) UpdateTenantQuota(ctx context.Context, req *pb.UpdateTenantQuotaRequest) (*pb.UpdateTenantQuotaResponse, error) {
+	if s.quotaRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tenant quotas are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, limit := range []*int32{req.MaxAccounts, req.MaxEntriesPerDay, req.MaxLinesPerEntry} {
+		if limit != nil && *limit < 0 {
+			return nil, status.Error(codes.InvalidArgument, "quota limits must not be negative")
+		}
+	}
+
+	quota, err := s.quotaRepo.Get(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("get tenant quota", err)
+	}
+
+	if req.MaxAccounts != nil {
+		quota.MaxAccounts = limitOrNil(*req.MaxAccounts)
+	}
+	if req.MaxEntriesPerDay != nil {
+		quota.MaxEntriesPerDay = limitOrNil(*req.MaxEntriesPerDay)
+	}
+	if req.MaxLinesPerEntry != nil {
+		quota.MaxLinesPerEntry = limitOrNil(*req.MaxLinesPerEntry)
+	}
+
+	updated, err := s.quotaRepo.Set(ctx, quota)
+	if err != nil {
+		return nil, repositoryError("update tenant quota", err)
+	}
+
+	return &pb.UpdateTenantQuotaResponse{
+		Quota: quotaToProto(updated),
+	}, nil
+}
+
+// accountQuota returns the tenant's account limit, which the repository
+// checks in the transaction that creates the account
+func (s *LedgerService) accountQuota(ctx context.Context, tenantID uuid.UUID) (*int32, error) {
+	if s.quotaRepo == nil {
+		return nil, nil
+	}
+
+	quota, err := s.quotaRepo.Get(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("get tenant quota", err)
+	}
+
+	return quota.MaxAccounts, nil
+}
+
+// startOfDay returns midnight UTC of the day containing t
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// limitOrNil maps a zero limit to nil, meaning unlimited
+func limitOrNil(limit int32) *int32 {
+	if limit == 0 {
+		return nil
+	}
+	return &limit
+}
+
+func quotaToProto(quota *repository.TenantQuota) *pb.TenantQuota {
+	return &pb.TenantQuota{
+		TenantId:         quota.TenantID.String(),
+		MaxAccounts:      quota.MaxAccounts,
+		MaxEntriesPerDay: quota.MaxEntriesPerDay,
+		MaxLinesPerEntry: quota.MaxLinesPerEntry,
+		UpdatedAt:        timestamppb.New(quota.UpdatedAt),
+	}
+}
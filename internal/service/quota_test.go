@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockQuotaRepository struct {
+	mock.Mock
+}
+
+func (m *MockQuotaRepository) Get(ctx context.Context, tenantID uuid.UUID) (*repository.TenantQuota, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TenantQuota), args.Error(1)
+}
+
+func (m *MockQuotaRepository) Set(ctx context.Context, quota *repository.TenantQuota) (*repository.TenantQuota, error) {
+	args := m.Called(ctx, quota)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TenantQuota), args.Error(1)
+}
+
+func (m *MockQuotaRepository) GetUsage(ctx context.Context, tenantID uuid.UUID, since time.Time) (*repository.QuotaUsage, error) {
+	args := m.Called(ctx, tenantID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.QuotaUsage), args.Error(1)
+}
+
+func int32Ptr(v int32) *int32 {
+	return &v
+}
+
+// Test quota enforcement on CreateAccount
+func TestLedgerService_CreateAccount_Quota(t *testing.T) {
+	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
+	mockQuotaRepo := new(MockQuotaRepository)
+	service := NewLedgerService(nil, mockAccountRepo, nil, nil, WithQuotaRepository(mockQuotaRepo))
+
+	t.Run("returns resource exhausted when account limit is reached", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockQuotaRepo.On("Get", ctx, tenantID).Return(&repository.TenantQuota{
+			TenantID:    tenantID,
+			MaxAccounts: int32Ptr(2),
+		}, nil).Once()
+		// The repository counts the accounts in the creating transaction
+		mockAccountRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(params repository.CreateAccountParams) bool {
+			return params.MaxAccounts != nil && *params.MaxAccounts == 2
+		})).Return(nil, fmt.Errorf("account %w: limit is 2 accounts", repository.ErrQuotaExceeded)).Once()
+
+		req := &pb.CreateAccountRequest{
+			TenantId:      tenantID.String(),
+			AccountNumber: "1000",
+			Name:          "Cash",
+			AccountTypeId: 1,
+			CurrencyCode:  "USD",
+		}
+		resp, err := service.CreateAccount(ctx, req)
+
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Nil(t, resp)
+		mockQuotaRepo.AssertExpectations(t)
+		mockAccountRepo.AssertExpectations(t)
+	})
+}
+
+// Test quota enforcement on RestoreAccount
+func TestLedgerService_RestoreAccount_Quota(t *testing.T) {
+	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
+	mockQuotaRepo := new(MockQuotaRepository)
+	service := NewLedgerService(nil, mockAccountRepo, nil, nil, WithQuotaRepository(mockQuotaRepo))
+
+	t.Run("returns resource exhausted when account limit is reached", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+
+		mockQuotaRepo.On("Get", ctx, tenantID).Return(&repository.TenantQuota{
+			TenantID:    tenantID,
+			MaxAccounts: int32Ptr(2),
+		}, nil).Once()
+		// Deleted accounts do not count, so restoring one checks the quota
+		mockAccountRepo.On("Restore", ctx, tenantID, accountID, int32Ptr(2)).
+			Return(nil, fmt.Errorf("account %w: limit is 2 accounts", repository.ErrQuotaExceeded)).Once()
+
+		resp, err := service.RestoreAccount(ctx, &pb.RestoreAccountRequest{
+			TenantId:  tenantID.String(),
+			AccountId: accountID.String(),
+		})
+
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Nil(t, resp)
+		mockQuotaRepo.AssertExpectations(t)
+		mockAccountRepo.AssertExpectations(t)
+	})
+}
+
+// Test quota enforcement on CreateJournalEntry
+func TestLedgerService_CreateJournalEntry_Quota(t *testing.T) {
+	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewLedgerService(nil, mockAccountRepo, mockJournalRepo, nil)
+
+	lines := []*pb.JournalEntryLine{
+		{AccountId: uuid.New().String(), Debit: "100", Credit: "0"},
+		{AccountId: uuid.New().String(), Debit: "0", Credit: "100"},
+	}
+
+	for _, quotaErr := range []error{
+		fmt.Errorf("line %w: limit is 1 lines per entry", repository.ErrQuotaExceeded),
+		fmt.Errorf("journal entry %w: limit is 10 entries per day", repository.ErrQuotaExceeded),
+	} {
+		t.Run("returns resource exhausted when "+quotaErr.Error(), func(t *testing.T) {
+			tenantID := uuid.New()
+			usd := repository.AccountCurrency{CurrencyCode: "USD", Precision: 2}
+			currencies := make(map[uuid.UUID]repository.AccountCurrency)
+			accountIDs := make([]uuid.UUID, len(lines))
+			for i, line := range lines {
+				accountIDs[i] = uuid.MustParse(line.AccountId)
+				currencies[accountIDs[i]] = usd
+			}
+			mockAccountRepo.On("AccountCurrencies", ctx, tenantID, accountIDs).Return(currencies, nil).Once()
+			// The repository checks the tenant's quotas under its journal
+			// lock, so concurrent postings count each other
+			mockJournalRepo.On("Create", ctx, tenantID, mock.Anything).Return(nil, quotaErr).Once()
+
+			req := &pb.CreateJournalEntryRequest{TenantId: tenantID.String(), ReferenceNumber: "REF001", Lines: lines}
+			resp, err := service.CreateJournalEntry(ctx, req)
+
+			assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+			assert.Nil(t, resp)
+			mockJournalRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// Test GetQuotaUsage
+func TestLedgerService_GetQuotaUsage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns unimplemented when quotas are not enabled", func(t *testing.T) {
+		service := NewLedgerService(nil, nil, nil, nil)
+
+		resp, err := service.GetQuotaUsage(ctx, &pb.GetQuotaUsageRequest{TenantId: uuid.New().String()})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns quota and usage", func(t *testing.T) {
+		mockQuotaRepo := new(MockQuotaRepository)
+		service := NewLedgerService(nil, nil, nil, nil, WithQuotaRepository(mockQuotaRepo))
+		tenantID := uuid.New()
+		now := time.Now().UTC()
+		dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+		mockQuotaRepo.On("Get", ctx, tenantID).Return(&repository.TenantQuota{
+			TenantID:    tenantID,
+			MaxAccounts: int32Ptr(100),
+		}, nil).Once()
+		mockQuotaRepo.On("GetUsage", ctx, tenantID, dayStart).Return(&repository.QuotaUsage{
+			AccountCount: 12,
+			EntriesSince: 3,
+		}, nil).Once()
+
+		resp, err := service.GetQuotaUsage(ctx, &pb.GetQuotaUsageRequest{TenantId: tenantID.String()})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int32(100), resp.Quota.GetMaxAccounts())
+		assert.Nil(t, resp.Quota.MaxEntriesPerDay)
+		assert.Equal(t, int32(12), resp.Usage.AccountCount)
+		assert.Equal(t, int32(3), resp.Usage.EntriesToday)
+		mockQuotaRepo.AssertExpectations(t)
+	})
+}
+
+// Test UpdateTenantQuota
+func TestAdminService_UpdateTenantQuota(t *testing.T) {
+	ctx := context.Background()
+	mockQuotaRepo := new(MockQuotaRepository)
+	service := NewAdminService(nil, nil, nil, WithQuotaRepository(mockQuotaRepo))
+
+	t.Run("merges provided limits and clears zero limits", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockQuotaRepo.On("Get", ctx, tenantID).Return(&repository.TenantQuota{
+			TenantID:         tenantID,
+			MaxAccounts:      int32Ptr(10),
+			MaxLinesPerEntry: int32Ptr(500),
+		}, nil).Once()
+		mockQuotaRepo.On("Set", ctx, mock.MatchedBy(func(q *repository.TenantQuota) bool {
+			return q.MaxAccounts == nil &&
+				q.MaxEntriesPerDay != nil && *q.MaxEntriesPerDay == 1000 &&
+				q.MaxLinesPerEntry != nil && *q.MaxLinesPerEntry == 500
+		})).Return(&repository.TenantQuota{
+			TenantID:         tenantID,
+			MaxEntriesPerDay: int32Ptr(1000),
+			MaxLinesPerEntry: int32Ptr(500),
+		}, nil).Once()
+
+		resp, err := service.UpdateTenantQuota(ctx, &pb.UpdateTenantQuotaRequest{
+			TenantId:         tenantID.String(),
+			MaxAccounts:      int32Ptr(0),
+			MaxEntriesPerDay: int32Ptr(1000),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1000), resp.Quota.GetMaxEntriesPerDay())
+		mockQuotaRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error for negative limits", func(t *testing.T) {
+		resp, err := service.UpdateTenantQuota(ctx, &pb.UpdateTenantQuotaRequest{
+			TenantId:    uuid.New().String(),
+			MaxAccounts: int32Ptr(-1),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
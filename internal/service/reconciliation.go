@@ -0,0 +1,447 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/reconcile"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/internal/statement"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// maxStatementSize caps the size of an uploaded bank statement
+const maxStatementSize = 10 << 20
+
+// ReconciliationService implements the gRPC ReconciliationService
+type ReconciliationService struct {
+	pb.UnimplementedReconciliationServiceServer
+	accountRepo        repository.AccountRepositoryInterface
+	statementRepo      repository.StatementRepositoryInterface
+	reconciliationRepo repository.ReconciliationRepositoryInterface
+}
+
+// NewReconciliationService creates a new reconciliation service
+func NewReconciliationService(
+	accountRepo repository.AccountRepositoryInterface,
+	statementRepo repository.StatementRepositoryInterface,
+	reconciliationRepo repository.ReconciliationRepositoryInterface,
+) *ReconciliationService {
+	return &ReconciliationService{
+		accountRepo:        accountRepo,
+		statementRepo:      statementRepo,
+		reconciliationRepo: reconciliationRepo,
+	}
+}
+
+// ImportBankStatement parses a streamed CSV or OFX statement and stores its lines
+// against a ledger account. The first message carries the header, the rest carry
+// the file contents in chunks.
+func (s *ReconciliationService) ImportBankStatement(stream pb.ReconciliationService_ImportBankStatementServer) error {
+	ctx := stream.Context()
+
+	first, err := stream.Recv()
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to receive statement header: %v", err)
+	}
+
+	header := first.GetHeader()
+	if header == nil {
+		return status.Error(codes.InvalidArgument, "first message must contain the statement header")
+	}
+
+	tenantID, err := requestID("tenant_id", header.TenantId)
+	if err != nil {
+		return err
+	}
+
+	accountID, err := requestID("account_id", header.AccountId)
+	if err != nil {
+		return err
+	}
+
+	format, err := statementFormatFromProto(header.Format)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.accountRepo.GetByID(ctx, tenantID, accountID); err != nil {
+		return repositoryError("get account", err)
+	}
+
+	var data bytes.Buffer
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if msg.GetHeader() != nil {
+			return status.Error(codes.InvalidArgument, "statement header must only be sent once")
+		}
+
+		if data.Len()+len(msg.GetChunk()) > maxStatementSize {
+			return status.Errorf(codes.ResourceExhausted, "statement exceeds the maximum size of %d bytes", maxStatementSize)
+		}
+		data.Write(msg.GetChunk())
+	}
+
+	lines, err := statement.Parse(format, &data)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to parse statement: %v", err)
+	}
+
+	if len(lines) == 0 {
+		return status.Error(codes.InvalidArgument, "statement contains no transactions")
+	}
+
+	params := repository.ImportStatementParams{
+		AccountID: accountID,
+		Format:    string(format),
+		Reference: header.Reference,
+		Lines:     make([]*repository.CreateStatementLineParams, len(lines)),
+	}
+	for i, line := range lines {
+		params.Lines[i] = &repository.CreateStatementLineParams{
+			PostedAt:    line.PostedAt,
+			Amount:      line.Amount,
+			Description: line.Description,
+			Reference:   line.Reference,
+		}
+	}
+
+	imported, err := s.statementRepo.Import(ctx, tenantID, params)
+	if err != nil {
+		return repositoryError("import statement", err)
+	}
+
+	return stream.SendAndClose(&pb.ImportBankStatementResponse{
+		Statement: statementToProto(imported),
+	})
+}
+
+// ListStatementLines lists the imported statement lines of an account
+func (s *ReconciliationService) ListStatementLines(ctx context.Context, req *pb.ListStatementLinesRequest) (*pb.ListStatementLinesResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := repository.StatementLineFilter{AccountID: accountID}
+	switch req.Status {
+	case pb.StatementLineStatus_STATEMENT_LINE_STATUS_MATCHED:
+		lineStatus := repository.StatementLineMatched
+		filter.Status = &lineStatus
+	case pb.StatementLineStatus_STATEMENT_LINE_STATUS_UNMATCHED:
+		lineStatus := repository.StatementLineUnmatched
+		filter.Status = &lineStatus
+	}
+
+	if req.FromDate != nil {
+		fromDate := req.FromDate.AsTime()
+		filter.FromDate = &fromDate
+	}
+
+	if req.ToDate != nil {
+		toDate := req.ToDate.AsTime()
+		filter.ToDate = &toDate
+	}
+
+	lines, err := s.reconciliationRepo.ListStatementLines(ctx, tenantID, filter)
+	if err != nil {
+		return nil, repositoryError("list statement lines", err)
+	}
+
+	resp := &pb.ListStatementLinesResponse{
+		Lines: make([]*pb.StatementLine, len(lines)),
+	}
+	for i, line := range lines {
+		resp.Lines[i] = statementLineToProto(line)
+	}
+
+	return resp, nil
+}
+
+// AutoMatch matches the unmatched statement lines of an account against its
+// unreconciled journal lines, exact matches first and fuzzy matches second
+func (s *ReconciliationService) AutoMatch(ctx context.Context, req *pb.AutoMatchRequest) (*pb.AutoMatchResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := reconcile.Rules{DateToleranceDays: reconcile.DefaultDateToleranceDays}
+	if req.DateToleranceDays != nil {
+		if *req.DateToleranceDays < 0 || *req.DateToleranceDays > 31 {
+			return nil, status.Error(codes.InvalidArgument, "date tolerance must be between 0 and 31 days")
+		}
+		rules.DateToleranceDays = int(*req.DateToleranceDays)
+	}
+
+	unmatched := repository.StatementLineUnmatched
+	filter := repository.StatementLineFilter{AccountID: accountID, Status: &unmatched}
+
+	// Widen the journal window by the tolerance so lines near the period edges can still match
+	var ledgerFrom, ledgerTo *time.Time
+	tolerance := time.Duration(rules.DateToleranceDays) * 24 * time.Hour
+	if req.FromDate != nil {
+		fromDate := req.FromDate.AsTime()
+		filter.FromDate = &fromDate
+		widened := fromDate.Add(-tolerance)
+		ledgerFrom = &widened
+	}
+	if req.ToDate != nil {
+		toDate := req.ToDate.AsTime()
+		filter.ToDate = &toDate
+		widened := toDate.Add(tolerance)
+		ledgerTo = &widened
+	}
+
+	statementLines, err := s.reconciliationRepo.ListStatementLines(ctx, tenantID, filter)
+	if err != nil {
+		return nil, repositoryError("list statement lines", err)
+	}
+
+	journalLines, err := s.reconciliationRepo.ListUnreconciledJournalLines(ctx, tenantID, accountID, ledgerFrom, ledgerTo)
+	if err != nil {
+		return nil, repositoryError("list journal lines", err)
+	}
+
+	candidates := make([]reconcile.StatementLine, len(statementLines))
+	for i, line := range statementLines {
+		candidates[i] = reconcile.StatementLine{
+			ID:          line.ID,
+			PostedAt:    line.PostedAt,
+			Amount:      line.Amount,
+			Description: line.Description,
+			Reference:   line.Reference,
+		}
+	}
+
+	ledger := make([]reconcile.LedgerLine, len(journalLines))
+	for i, line := range journalLines {
+		ledger[i] = reconcile.LedgerLine{
+			ID:          line.ID,
+			EntryDate:   line.EntryDate,
+			Amount:      line.Debit.Sub(line.Credit),
+			Description: line.Description,
+			Reference:   line.ReferenceNumber,
+		}
+	}
+
+	matches := reconcile.AutoMatch(candidates, ledger, rules)
+
+	resp := &pb.AutoMatchResponse{
+		Matched:        make([]*pb.StatementLine, 0, len(matches)),
+		UnmatchedCount: int32(len(statementLines) - len(matches)),
+	}
+	if len(matches) == 0 {
+		return resp, nil
+	}
+
+	params := make([]*repository.MatchParams, len(matches))
+	for i, m := range matches {
+		params[i] = &repository.MatchParams{
+			StatementLineID:    m.StatementLineID,
+			JournalEntryLineID: m.LedgerLineID,
+			Method:             string(m.Method),
+		}
+	}
+
+	matched, err := s.reconciliationRepo.Match(ctx, tenantID, params)
+	if err != nil {
+		return nil, repositoryError("match statement lines", err)
+	}
+
+	for _, line := range matched {
+		resp.Matched = append(resp.Matched, statementLineToProto(line))
+	}
+
+	return resp, nil
+}
+
+// MatchStatementLine manually matches a statement line to a journal line
+func (s *ReconciliationService) MatchStatementLine(ctx context.Context, req *pb.MatchStatementLineRequest) (*pb.MatchStatementLineResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	statementLineID, err := requestID("statement_line_id", req.StatementLineId)
+	if err != nil {
+		return nil, err
+	}
+
+	journalLineID, err := requestID("journal_entry_line_id", req.JournalEntryLineId)
+	if err != nil {
+		return nil, err
+	}
+
+	matched, err := s.reconciliationRepo.Match(ctx, tenantID, []*repository.MatchParams{{
+		StatementLineID:    statementLineID,
+		JournalEntryLineID: journalLineID,
+		Method:             string(reconcile.MethodManual),
+	}})
+	if err != nil {
+		return nil, repositoryError("match statement line", err)
+	}
+
+	return &pb.MatchStatementLineResponse{
+		Line: statementLineToProto(matched[0]),
+	}, nil
+}
+
+// UnmatchStatementLine clears the match of a statement line
+func (s *ReconciliationService) UnmatchStatementLine(ctx context.Context, req *pb.UnmatchStatementLineRequest) (*pb.UnmatchStatementLineResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	statementLineID, err := requestID("statement_line_id", req.StatementLineId)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := s.reconciliationRepo.Unmatch(ctx, tenantID, statementLineID)
+	if err != nil {
+		return nil, repositoryError("unmatch statement line", err)
+	}
+
+	return &pb.UnmatchStatementLineResponse{
+		Line: statementLineToProto(line),
+	}, nil
+}
+
+// GetReconciliationStatus summarises how much of an account is reconciled over a period
+func (s *ReconciliationService) GetReconciliationStatus(ctx context.Context, req *pb.GetReconciliationStatusRequest) (*pb.GetReconciliationStatusResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := requestID("account_id", req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FromDate == nil || req.ToDate == nil {
+		return nil, status.Error(codes.InvalidArgument, "from date and to date are required")
+	}
+
+	fromDate := req.FromDate.AsTime()
+	toDate := req.ToDate.AsTime()
+	if toDate.Before(fromDate) {
+		return nil, status.Error(codes.InvalidArgument, "to date must not be before from date")
+	}
+
+	summary, err := s.reconciliationRepo.GetSummary(ctx, tenantID, accountID, fromDate, toDate)
+	if err != nil {
+		return nil, repositoryError("get reconciliation status", err)
+	}
+
+	return &pb.GetReconciliationStatusResponse{
+		Status: &pb.ReconciliationStatus{
+			AccountId:             accountID.String(),
+			FromDate:              req.FromDate,
+			ToDate:                req.ToDate,
+			StatementLines:        int32(summary.StatementLines),
+			MatchedLines:          int32(summary.MatchedLines),
+			UnmatchedLines:        int32(summary.UnmatchedLines),
+			StatementTotal:        summary.StatementTotal.String(),
+			UnmatchedTotal:        summary.UnmatchedTotal.String(),
+			UnmatchedJournalLines: int32(summary.UnmatchedJournalLines),
+			Reconciled:            summary.UnmatchedLines == 0 && summary.UnmatchedJournalLines == 0,
+		},
+	}, nil
+}
+
+func statementFormatFromProto(format pb.StatementFormat) (statement.Format, error) {
+	switch format {
+	case pb.StatementFormat_STATEMENT_FORMAT_CSV:
+		return statement.FormatCSV, nil
+	case pb.StatementFormat_STATEMENT_FORMAT_OFX:
+		return statement.FormatOFX, nil
+	default:
+		return "", status.Error(codes.InvalidArgument, "statement format must be CSV or OFX")
+	}
+}
+
+func statementToProto(s *repository.BankStatement) *pb.BankStatement {
+	format := pb.StatementFormat_STATEMENT_FORMAT_UNSPECIFIED
+	switch statement.Format(s.Format) {
+	case statement.FormatCSV:
+		format = pb.StatementFormat_STATEMENT_FORMAT_CSV
+	case statement.FormatOFX:
+		format = pb.StatementFormat_STATEMENT_FORMAT_OFX
+	}
+
+	return &pb.BankStatement{
+		StatementId: s.ID.String(),
+		TenantId:    s.TenantID.String(),
+		AccountId:   s.AccountID.String(),
+		Format:      format,
+		Reference:   s.Reference,
+		LineCount:   int32(s.LineCount),
+		ImportedAt:  timestamppb.New(s.ImportedAt),
+	}
+}
+
+func statementLineToProto(line *repository.StatementLine) *pb.StatementLine {
+	pbLine := &pb.StatementLine{
+		StatementLineId: line.ID.String(),
+		StatementId:     line.StatementID.String(),
+		AccountId:       line.AccountID.String(),
+		PostedAt:        timestamppb.New(line.PostedAt),
+		Amount:          line.Amount.String(),
+		Description:     line.Description,
+		Reference:       line.Reference,
+		Status:          pb.StatementLineStatus_STATEMENT_LINE_STATUS_UNMATCHED,
+	}
+
+	if line.Status == repository.StatementLineMatched {
+		pbLine.Status = pb.StatementLineStatus_STATEMENT_LINE_STATUS_MATCHED
+	}
+
+	if line.JournalEntryLineID != nil {
+		journalLineID := line.JournalEntryLineID.String()
+		pbLine.JournalEntryLineId = &journalLineID
+	}
+
+	if line.MatchMethod != nil {
+		switch reconcile.Method(*line.MatchMethod) {
+		case reconcile.MethodExact:
+			pbLine.MatchMethod = pb.MatchMethod_MATCH_METHOD_EXACT
+		case reconcile.MethodFuzzy:
+			pbLine.MatchMethod = pb.MatchMethod_MATCH_METHOD_FUZZY
+		case reconcile.MethodManual:
+			pbLine.MatchMethod = pb.MatchMethod_MATCH_METHOD_MANUAL
+		}
+	}
+
+	if line.MatchedAt != nil {
+		pbLine.MatchedAt = timestamppb.New(*line.MatchedAt)
+	}
+
+	return pbLine
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockStatementRepository struct {
+	mock.Mock
+}
+
+func (m *MockStatementRepository) Import(ctx context.Context, tenantID uuid.UUID, params repository.ImportStatementParams) (*repository.BankStatement, error) {
+	args := m.Called(ctx, tenantID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.BankStatement), args.Error(1)
+}
+
+type MockReconciliationRepository struct {
+	mock.Mock
+}
+
+func (m *MockReconciliationRepository) ListStatementLines(ctx context.Context, tenantID uuid.UUID, filter repository.StatementLineFilter) ([]*repository.StatementLine, error) {
+	args := m.Called(ctx, tenantID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.StatementLine), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) ListUnreconciledJournalLines(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, fromDate, toDate *time.Time) ([]*repository.UnreconciledJournalLine, error) {
+	args := m.Called(ctx, tenantID, accountID, fromDate, toDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.UnreconciledJournalLine), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) Match(ctx context.Context, tenantID uuid.UUID, matches []*repository.MatchParams) ([]*repository.StatementLine, error) {
+	args := m.Called(ctx, tenantID, matches)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.StatementLine), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) Unmatch(ctx context.Context, tenantID uuid.UUID, statementLineID uuid.UUID) (*repository.StatementLine, error) {
+	args := m.Called(ctx, tenantID, statementLineID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.StatementLine), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) GetSummary(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, fromDate, toDate time.Time) (*repository.ReconciliationSummary, error) {
+	args := m.Called(ctx, tenantID, accountID, fromDate, toDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ReconciliationSummary), args.Error(1)
+}
+
+// fakeImportStream replays a fixed sequence of requests to ImportBankStatement
+type fakeImportStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []*pb.ImportBankStatementRequest
+	response *pb.ImportBankStatementResponse
+}
+
+func (f *fakeImportStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeImportStream) Recv() (*pb.ImportBankStatementRequest, error) {
+	if len(f.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := f.requests[0]
+	f.requests = f.requests[1:]
+	return req, nil
+}
+
+func (f *fakeImportStream) SendAndClose(resp *pb.ImportBankStatementResponse) error {
+	f.response = resp
+	return nil
+}
+
+func headerRequest(tenantID, accountID uuid.UUID, format pb.StatementFormat) *pb.ImportBankStatementRequest {
+	return &pb.ImportBankStatementRequest{
+		Payload: &pb.ImportBankStatementRequest_Header{
+			Header: &pb.StatementHeader{
+				TenantId:  tenantID.String(),
+				AccountId: accountID.String(),
+				Format:    format,
+			},
+		},
+	}
+}
+
+func chunkRequest(data string) *pb.ImportBankStatementRequest {
+	return &pb.ImportBankStatementRequest{
+		Payload: &pb.ImportBankStatementRequest_Chunk{Chunk: []byte(data)},
+	}
+}
+
+// Test ImportBankStatement
+func TestReconciliationService_ImportBankStatement(t *testing.T) {
+	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
+	mockStatementRepo := new(MockStatementRepository)
+	service := NewReconciliationService(mockAccountRepo, mockStatementRepo, nil)
+
+	t.Run("imports CSV statement sent in chunks", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+		statementID := uuid.New()
+
+		mockAccountRepo.On("GetByID", ctx, tenantID, accountID).Return(&repository.Account{ID: accountID}, nil).Once()
+		mockStatementRepo.On("Import", ctx, tenantID, mock.MatchedBy(func(p repository.ImportStatementParams) bool {
+			return p.AccountID == accountID && p.Format == "CSV" && len(p.Lines) == 2 &&
+				p.Lines[1].Description == "Deposit"
+		})).Return(&repository.BankStatement{
+			ID:        statementID,
+			TenantID:  tenantID,
+			AccountID: accountID,
+			Format:    "CSV",
+			LineCount: 2,
+		}, nil).Once()
+
+		stream := &fakeImportStream{
+			ctx: ctx,
+			requests: []*pb.ImportBankStatementRequest{
+				headerRequest(tenantID, accountID, pb.StatementFormat_STATEMENT_FORMAT_CSV),
+				chunkRequest("date,amount,description\n2024-01-15,-10.00,Fee\n"),
+				chunkRequest("2024-01-16,200.00,Deposit\n"),
+			},
+		}
+
+		err := service.ImportBankStatement(stream)
+
+		assert.NoError(t, err)
+		assert.Equal(t, statementID.String(), stream.response.Statement.StatementId)
+		assert.Equal(t, pb.StatementFormat_STATEMENT_FORMAT_CSV, stream.response.Statement.Format)
+		assert.Equal(t, int32(2), stream.response.Statement.LineCount)
+		mockAccountRepo.AssertExpectations(t)
+		mockStatementRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error when header is missing", func(t *testing.T) {
+		stream := &fakeImportStream{
+			ctx:      ctx,
+			requests: []*pb.ImportBankStatementRequest{chunkRequest("date,amount\n")},
+		}
+
+		err := service.ImportBankStatement(stream)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("returns error for unparseable statement", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+
+		mockAccountRepo.On("GetByID", ctx, tenantID, accountID).Return(&repository.Account{ID: accountID}, nil).Once()
+
+		stream := &fakeImportStream{
+			ctx: ctx,
+			requests: []*pb.ImportBankStatementRequest{
+				headerRequest(tenantID, accountID, pb.StatementFormat_STATEMENT_FORMAT_OFX),
+				chunkRequest("not an ofx file"),
+			},
+		}
+
+		err := service.ImportBankStatement(stream)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		mockStatementRepo.AssertNotCalled(t, "Import", mock.Anything, tenantID, mock.Anything)
+	})
+}
+
+// Test AutoMatch
+func TestReconciliationService_AutoMatch(t *testing.T) {
+	ctx := context.Background()
+	mockReconciliationRepo := new(MockReconciliationRepository)
+	service := NewReconciliationService(nil, nil, mockReconciliationRepo)
+
+	t.Run("matches statement lines against journal lines", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+		day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+		statementLine := &repository.StatementLine{ID: uuid.New(), AccountID: accountID, PostedAt: day, Amount: decimal.NewFromInt(-40)}
+		otherLine := &repository.StatementLine{ID: uuid.New(), AccountID: accountID, PostedAt: day, Amount: decimal.NewFromInt(999)}
+		journalLine := &repository.UnreconciledJournalLine{ID: uuid.New(), EntryDate: day.AddDate(0, 0, 1), Debit: decimal.Zero, Credit: decimal.NewFromInt(40)}
+		matchedAt := time.Now()
+		method := "FUZZY"
+
+		mockReconciliationRepo.On("ListStatementLines", ctx, tenantID, mock.Anything).Return([]*repository.StatementLine{statementLine, otherLine}, nil).Once()
+		mockReconciliationRepo.On("ListUnreconciledJournalLines", ctx, tenantID, accountID, (*time.Time)(nil), (*time.Time)(nil)).
+			Return([]*repository.UnreconciledJournalLine{journalLine}, nil).Once()
+		mockReconciliationRepo.On("Match", ctx, tenantID, []*repository.MatchParams{{
+			StatementLineID:    statementLine.ID,
+			JournalEntryLineID: journalLine.ID,
+			Method:             "FUZZY",
+		}}).Return([]*repository.StatementLine{{
+			ID:                 statementLine.ID,
+			AccountID:          accountID,
+			Amount:             statementLine.Amount,
+			Status:             repository.StatementLineMatched,
+			JournalEntryLineID: &journalLine.ID,
+			MatchMethod:        &method,
+			MatchedAt:          &matchedAt,
+		}}, nil).Once()
+
+		resp, err := service.AutoMatch(ctx, &pb.AutoMatchRequest{
+			TenantId:  tenantID.String(),
+			AccountId: accountID.String(),
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Matched, 1)
+		assert.Equal(t, pb.MatchMethod_MATCH_METHOD_FUZZY, resp.Matched[0].MatchMethod)
+		assert.Equal(t, int32(1), resp.UnmatchedCount)
+		mockReconciliationRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error for negative date tolerance", func(t *testing.T) {
+		tolerance := int32(-1)
+		resp, err := service.AutoMatch(ctx, &pb.AutoMatchRequest{
+			TenantId:          uuid.New().String(),
+			AccountId:         uuid.New().String(),
+			DateToleranceDays: &tolerance,
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test MatchStatementLine
+func TestReconciliationService_MatchStatementLine(t *testing.T) {
+	ctx := context.Background()
+	mockReconciliationRepo := new(MockReconciliationRepository)
+	service := NewReconciliationService(nil, nil, mockReconciliationRepo)
+
+	t.Run("returns failed precondition when line is already matched", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockReconciliationRepo.On("Match", ctx, tenantID, mock.Anything).Return(nil, repository.ErrAlreadyReconciled).Once()
+
+		resp, err := service.MatchStatementLine(ctx, &pb.MatchStatementLineRequest{
+			TenantId:           tenantID.String(),
+			StatementLineId:    uuid.New().String(),
+			JournalEntryLineId: uuid.New().String(),
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Nil(t, resp)
+		mockReconciliationRepo.AssertExpectations(t)
+	})
+}
+
+// Test GetReconciliationStatus
+func TestReconciliationService_GetReconciliationStatus(t *testing.T) {
+	ctx := context.Background()
+	mockReconciliationRepo := new(MockReconciliationRepository)
+	service := NewReconciliationService(nil, nil, mockReconciliationRepo)
+
+	t.Run("reports reconciled period", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+		from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+		mockReconciliationRepo.On("GetSummary", ctx, tenantID, accountID, from, to).Return(&repository.ReconciliationSummary{
+			StatementLines: 4,
+			MatchedLines:   4,
+			StatementTotal: decimal.NewFromInt(150),
+			UnmatchedTotal: decimal.Zero,
+		}, nil).Once()
+
+		resp, err := service.GetReconciliationStatus(ctx, &pb.GetReconciliationStatusRequest{
+			TenantId:  tenantID.String(),
+			AccountId: accountID.String(),
+			FromDate:  timestamppb.New(from),
+			ToDate:    timestamppb.New(to),
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, resp.Status.Reconciled)
+		assert.Equal(t, "150", resp.Status.StatementTotal)
+		mockReconciliationRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error without period", func(t *testing.T) {
+		resp, err := service.GetReconciliationStatus(ctx, &pb.GetReconciliationStatusRequest{
+			TenantId:  uuid.New().String(),
+			AccountId: uuid.New().String(),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
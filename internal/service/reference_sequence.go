@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// Limits of a reference sequence, keeping generated numbers within the
+// length of a reference number
+const (
+	maxReferencePrefixLength = 20
+	maxReferencePadding      = 18
+)
+
+// GetReferenceSequence retrieves how a tenant's reference numbers are generated
+func (s *LedgerService) GetReferenceSequence(ctx context.Context, req *pb.GetReferenceSequenceRequest) (*pb.GetReferenceSequenceResponse, error) {
+	if s.sequenceRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "reference number generation is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	sequence, err := s.sequenceRepo.Get(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("get reference sequence", err)
+	}
+
+	return &pb.GetReferenceSequenceResponse{
+		Sequence: referenceSequenceToProto(sequence),
+	}, nil
+}
+
+// UpdateReferenceSequence updates the provided fields of a tenant's reference sequence
+func (s *LedgerService) UpdateReferenceSequence(ctx context.Context, req *pb.UpdateReferenceSequenceRequest) (*pb.UpdateReferenceSequenceResponse, error) {
+	if s.sequenceRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "reference number generation is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Prefix != nil && len(*req.Prefix) > maxReferencePrefixLength {
+		return nil, invalidField("prefix", "prefix must be at most 20 characters")
+	}
+	if req.Padding != nil && (*req.Padding < 1 || *req.Padding > maxReferencePadding) {
+		return nil, invalidField("padding", "padding must be between 1 and 18")
+	}
+	if req.NextValue != nil && *req.NextValue < 1 {
+		return nil, invalidField("next_value", "next value must be positive")
+	}
+
+	sequence, err := s.sequenceRepo.Get(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("get reference sequence", err)
+	}
+
+	if req.Prefix != nil {
+		sequence.Prefix = *req.Prefix
+	}
+	if req.Padding != nil {
+		sequence.Padding = *req.Padding
+	}
+	switch req.DateComponent {
+	case pb.ReferenceDateComponent_REFERENCE_DATE_COMPONENT_NONE:
+		sequence.DateComponent = repository.ReferenceDateNone
+	case pb.ReferenceDateComponent_REFERENCE_DATE_COMPONENT_YEAR:
+		sequence.DateComponent = repository.ReferenceDateYear
+	case pb.ReferenceDateComponent_REFERENCE_DATE_COMPONENT_YEAR_MONTH:
+		sequence.DateComponent = repository.ReferenceDateYearMonth
+	case pb.ReferenceDateComponent_REFERENCE_DATE_COMPONENT_DATE:
+		sequence.DateComponent = repository.ReferenceDateDay
+	}
+	if req.NextValue != nil {
+		// Going back would hand out numbers that were already used
+		if *req.NextValue < sequence.NextValue {
+			return nil, invalidField("next_value", "next value must not be lower than the current next value")
+		}
+		sequence.NextValue = *req.NextValue
+	}
+
+	updated, err := s.sequenceRepo.Upsert(ctx, sequence)
+	if err != nil {
+		return nil, repositoryError("update reference sequence", err)
+	}
+
+	return &pb.UpdateReferenceSequenceResponse{
+		Sequence: referenceSequenceToProto(updated),
+	}, nil
+}
+
+func referenceSequenceToProto(sequence *repository.ReferenceSequence) *pb.ReferenceSequence {
+	pbSequence := &pb.ReferenceSequence{
+		TenantId:      sequence.TenantID.String(),
+		Prefix:        sequence.Prefix,
+		Padding:       sequence.Padding,
+		NextValue:     sequence.NextValue,
+		NextReference: sequence.Format(sequence.NextValue, time.Now()),
+	}
+
+	switch sequence.DateComponent {
+	case repository.ReferenceDateNone:
+		pbSequence.DateComponent = pb.ReferenceDateComponent_REFERENCE_DATE_COMPONENT_NONE
+	case repository.ReferenceDateYear:
+		pbSequence.DateComponent = pb.ReferenceDateComponent_REFERENCE_DATE_COMPONENT_YEAR
+	case repository.ReferenceDateYearMonth:
+		pbSequence.DateComponent = pb.ReferenceDateComponent_REFERENCE_DATE_COMPONENT_YEAR_MONTH
+	case repository.ReferenceDateDay:
+		pbSequence.DateComponent = pb.ReferenceDateComponent_REFERENCE_DATE_COMPONENT_DATE
+	}
+
+	if !sequence.UpdatedAt.IsZero() {
+		pbSequence.UpdatedAt = timestamppb.New(sequence.UpdatedAt)
+	}
+
+	return pbSequence
+}
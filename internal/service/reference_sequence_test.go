@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockReferenceSequenceRepository struct {
+	mock.Mock
+}
+
+func (m *MockReferenceSequenceRepository) Get(ctx context.Context, tenantID uuid.UUID) (*repository.ReferenceSequence, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ReferenceSequence), args.Error(1)
+}
+
+func (m *MockReferenceSequenceRepository) Upsert(ctx context.Context, sequence *repository.ReferenceSequence) (*repository.ReferenceSequence, error) {
+	args := m.Called(ctx, sequence)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ReferenceSequence), args.Error(1)
+}
+
+func (m *MockReferenceSequenceRepository) Next(ctx context.Context, tenantID uuid.UUID, entryDate time.Time) (string, error) {
+	args := m.Called(ctx, tenantID, entryDate)
+	return args.String(0), args.Error(1)
+}
+
+// Test UpdateReferenceSequence
+func TestLedgerService_UpdateReferenceSequence(t *testing.T) {
+	ctx := context.Background()
+	mockSequenceRepo := new(MockReferenceSequenceRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithReferenceSequenceRepository(mockSequenceRepo))
+
+	t.Run("updates provided fields", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockSequenceRepo.On("Get", ctx, tenantID).Return(&repository.ReferenceSequence{
+			TenantID:      tenantID,
+			Prefix:        repository.DefaultReferencePrefix,
+			Padding:       repository.DefaultReferencePadding,
+			DateComponent: repository.ReferenceDateNone,
+			NextValue:     42,
+		}, nil).Once()
+		mockSequenceRepo.On("Upsert", ctx, &repository.ReferenceSequence{
+			TenantID:      tenantID,
+			Prefix:        "INV",
+			Padding:       4,
+			DateComponent: repository.ReferenceDateYear,
+			NextValue:     42,
+		}).Return(&repository.ReferenceSequence{
+			TenantID:      tenantID,
+			Prefix:        "INV",
+			Padding:       4,
+			DateComponent: repository.ReferenceDateYear,
+			NextValue:     42,
+		}, nil).Once()
+
+		prefix, padding := "INV", int32(4)
+		resp, err := service.UpdateReferenceSequence(ctx, &pb.UpdateReferenceSequenceRequest{
+			TenantId:      tenantID.String(),
+			Prefix:        &prefix,
+			Padding:       &padding,
+			DateComponent: pb.ReferenceDateComponent_REFERENCE_DATE_COMPONENT_YEAR,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, pb.ReferenceDateComponent_REFERENCE_DATE_COMPONENT_YEAR, resp.Sequence.DateComponent)
+		assert.Equal(t, "INV"+time.Now().UTC().Format("2006")+"-0042", resp.Sequence.NextReference)
+		mockSequenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects moving the sequence back", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockSequenceRepo.On("Get", ctx, tenantID).Return(&repository.ReferenceSequence{
+			TenantID:  tenantID,
+			NextValue: 42,
+		}, nil).Once()
+
+		nextValue := int64(41)
+		resp, err := service.UpdateReferenceSequence(ctx, &pb.UpdateReferenceSequenceRequest{
+			TenantId:  tenantID.String(),
+			NextValue: &nextValue,
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+		mockSequenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects moving back a sequence that advanced since it was read", func(t *testing.T) {
+		tenantID := uuid.New()
+		stale := &repository.ReferenceSequence{
+			TenantID:      tenantID,
+			Prefix:        repository.DefaultReferencePrefix,
+			Padding:       repository.DefaultReferencePadding,
+			DateComponent: repository.ReferenceDateNone,
+			NextValue:     42,
+		}
+
+		mockSequenceRepo.On("Get", ctx, tenantID).Return(stale, nil).Once()
+		// A posting claimed 42 between the read and the write
+		mockSequenceRepo.On("Upsert", ctx, mock.Anything).Return(nil, repository.ErrSequenceMovedBack).Once()
+
+		prefix := "INV"
+		resp, err := service.UpdateReferenceSequence(ctx, &pb.UpdateReferenceSequenceRequest{
+			TenantId: tenantID.String(),
+			Prefix:   &prefix,
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Nil(t, resp)
+		mockSequenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		long, zero, tooWide := "ABCDEFGHIJKLMNOPQRSTU", int32(0), int32(19)
+		for _, req := range []*pb.UpdateReferenceSequenceRequest{
+			{TenantId: "invalid"},
+			{TenantId: uuid.New().String(), Prefix: &long},
+			{TenantId: uuid.New().String(), Padding: &zero},
+			{TenantId: uuid.New().String(), Padding: &tooWide},
+		} {
+			_, err := service.UpdateReferenceSequence(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+	})
+
+	t.Run("returns unimplemented when reference numbers are not generated", func(t *testing.T) {
+		resp, err := NewLedgerService(nil, nil, nil, nil).GetReferenceSequence(ctx, &pb.GetReferenceSequenceRequest{
+			TenantId: uuid.New().String(),
+		})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+func TestLedgerService_CreateJournalEntry_ReferenceSequence(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	mockSequenceRepo := new(MockReferenceSequenceRepository)
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+		WithReferenceSequenceRepository(mockSequenceRepo),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "sequence", nil)
+	require.NoError(t, err)
+
+	accountIDs := make([]string, 2)
+	for i, number := range []string{"1001", "4001"} {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenant.ID.String(),
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: 1,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(t, err)
+		accountIDs[i] = resp.AccountId
+	}
+
+	entryDate := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	request := func(referenceNumber string) *pb.CreateJournalEntryRequest {
+		return &pb.CreateJournalEntryRequest{
+			TenantId:        tenant.ID.String(),
+			ReferenceNumber: referenceNumber,
+			EntryDate:       timestamppb.New(entryDate),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: accountIDs[0], Debit: "100", Credit: "0"},
+				{AccountId: accountIDs[1], Debit: "0", Credit: "100"},
+			},
+		}
+	}
+
+	t.Run("generates a reference number when none is given", func(t *testing.T) {
+		mockSequenceRepo.On("Next", ctx, tenant.ID, entryDate).Return("JE-000007", nil).Once()
+
+		resp, err := service.CreateJournalEntry(ctx, request(""))
+
+		require.NoError(t, err)
+		assert.Equal(t, "JE-000007", resp.ReferenceNumber)
+		mockSequenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("keeps a given reference number", func(t *testing.T) {
+		resp, err := service.CreateJournalEntry(ctx, request("MANUAL-1"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "MANUAL-1", resp.ReferenceNumber)
+		mockSequenceRepo.AssertNumberOfCalls(t, "Next", 1)
+	})
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// GetTenantSettings retrieves the settings of a tenant
+func (s *LedgerService) GetTenantSettings(ctx context.Context, req *pb.GetTenantSettingsRequest) (*pb.GetTenantSettingsResponse, error) {
+	if s.settingsRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tenant settings are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.settingsRepo.Get(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("get tenant settings", err)
+	}
+
+	return &pb.GetTenantSettingsResponse{
+		Settings: settingsToProto(settings),
+	}, nil
+}
+
+// UpdateTenantSettings updates the provided settings of a tenant
+func (s *LedgerService) UpdateTenantSettings(ctx context.Context, req *pb.UpdateTenantSettingsRequest) (*pb.UpdateTenantSettingsResponse, error) {
+	if s.settingsRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tenant settings are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Timezone != nil {
+		if *req.Timezone == "" || *req.Timezone == "Local" {
+			return nil, status.Error(codes.InvalidArgument, "timezone must be an IANA time zone name")
+		}
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown timezone %q", *req.Timezone)
+		}
+	}
+
+	if req.Locale != nil {
+		if _, err := language.Parse(*req.Locale); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid locale %q", *req.Locale)
+		}
+	}
+
+	if req.Calendar != nil && *req.Calendar != repository.CalendarGregorian && *req.Calendar != repository.CalendarJalali {
+		return nil, invalidField("calendar", "calendar must be GREGORIAN or JALALI")
+	}
+
+	if req.BaseCurrency != nil && *req.BaseCurrency != "" {
+		if err := s.checkCurrencyExists(ctx, *req.BaseCurrency); err != nil {
+			return nil, err
+		}
+	}
+
+	fxGainAccountID, err := s.settingsAccount(ctx, tenantID, "fx_gain_account_id", req.FxGainAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	fxLossAccountID, err := s.settingsAccount(ctx, tenantID, "fx_loss_account_id", req.FxLossAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	conversionAccounts := make(map[string]*uuid.UUID, len(req.FxConversionAccounts))
+	for currencyCode, value := range req.FxConversionAccounts {
+		if value == "" {
+			conversionAccounts[currencyCode] = nil
+			continue
+		}
+
+		field := "fx_conversion_accounts[" + currencyCode + "]"
+		accountID, err := uuid.Parse(value)
+		if err != nil {
+			return nil, invalidField(field, "invalid account ID")
+		}
+
+		account, err := s.accountRepo.GetByID(ctx, tenantID, accountID)
+		if err != nil {
+			return nil, repositoryError("get account", err)
+		}
+		if account.CurrencyCode != currencyCode {
+			return nil, invalidField(field, fmt.Sprintf("account currency %s does not match %s", account.CurrencyCode, currencyCode))
+		}
+		conversionAccounts[currencyCode] = &accountID
+	}
+
+	overdraftLimits, err := s.settingsOverdraftLimits(ctx, req.OverdraftLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.settingsRepo.Get(ctx, tenantID)
+	if err != nil {
+		return nil, repositoryError("get tenant settings", err)
+	}
+
+	if req.BaseCurrency != nil {
+		settings.BaseCurrency = *req.BaseCurrency
+	}
+	if req.Timezone != nil {
+		settings.Timezone = *req.Timezone
+	}
+	if req.Locale != nil {
+		settings.Locale = *req.Locale
+	}
+	if req.Calendar != nil {
+		settings.Calendar = *req.Calendar
+	}
+	if req.EncryptMetadata != nil {
+		settings.EncryptMetadata = *req.EncryptMetadata
+	}
+	if req.FxGainAccountId != nil {
+		settings.FxGainAccountID = fxGainAccountID
+	}
+	if req.FxLossAccountId != nil {
+		settings.FxLossAccountID = fxLossAccountID
+	}
+	for currencyCode, accountID := range conversionAccounts {
+		if accountID == nil {
+			delete(settings.FxConversionAccounts, currencyCode)
+			continue
+		}
+		if settings.FxConversionAccounts == nil {
+			settings.FxConversionAccounts = make(map[string]uuid.UUID)
+		}
+		settings.FxConversionAccounts[currencyCode] = *accountID
+	}
+	for accountType, limit := range overdraftLimits {
+		if limit == nil {
+			delete(settings.OverdraftLimits, accountType)
+			continue
+		}
+		if settings.OverdraftLimits == nil {
+			settings.OverdraftLimits = make(map[string]decimal.Decimal)
+		}
+		settings.OverdraftLimits[accountType] = *limit
+	}
+
+	updated, err := s.settingsRepo.Upsert(ctx, settings)
+	if err != nil {
+		return nil, repositoryError("update tenant settings", err)
+	}
+
+	return &pb.UpdateTenantSettingsResponse{
+		Settings: settingsToProto(updated),
+	}, nil
+}
+
+// checkCurrencyExists verifies that a currency code is part of the reference data
+func (s *LedgerService) checkCurrencyExists(ctx context.Context, code string) error {
+	_, err := s.findCurrency(ctx, code)
+	return err
+}
+
+// settingsAccount parses an account setting and checks the account exists; an
+// unset or empty value yields no account
+func (s *LedgerService) settingsAccount(ctx context.Context, tenantID uuid.UUID, field string, value *string) (*uuid.UUID, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+
+	accountID, err := uuid.Parse(*value)
+	if err != nil {
+		return nil, invalidField(field, "invalid account ID")
+	}
+
+	if _, err := s.accountRepo.GetByID(ctx, tenantID, accountID); err != nil {
+		return nil, repositoryError("get account", err)
+	}
+
+	return &accountID, nil
+}
+
+// settingsOverdraftLimits parses the overdraft limits of account types, by
+// code, which must name known account types and not be negative; an empty
+// limit yields nil, removing the type's limit
+func (s *LedgerService) settingsOverdraftLimits(ctx context.Context, values map[string]string) (map[string]*decimal.Decimal, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	accountTypes, err := s.referenceRepo.ListAccountTypes(ctx)
+	if err != nil {
+		return nil, repositoryError("list account types", err)
+	}
+	known := make(map[string]bool, len(accountTypes))
+	for _, accountType := range accountTypes {
+		known[accountType.Code] = true
+	}
+
+	limits := make(map[string]*decimal.Decimal, len(values))
+	for code, value := range values {
+		field := "overdraft_limits[" + code + "]"
+		if !known[code] {
+			return nil, invalidField(field, fmt.Sprintf("unknown account type %q", code))
+		}
+		if value == "" {
+			limits[code] = nil
+			continue
+		}
+
+		limit, err := decimal.NewFromString(value)
+		if err != nil || limit.IsNegative() {
+			return nil, invalidField(field, "overdraft limit must be a non-negative number")
+		}
+		limits[code] = &limit
+	}
+
+	return limits, nil
+}
+
+func settingsToProto(settings *repository.TenantSettings) *pb.TenantSettings {
+	pbSettings := &pb.TenantSettings{
+		TenantId:        settings.TenantID.String(),
+		BaseCurrency:    settings.BaseCurrency,
+		Timezone:        settings.Timezone,
+		Locale:          settings.Locale,
+		Calendar:        settings.Calendar,
+		EncryptMetadata: settings.EncryptMetadata,
+	}
+
+	if settings.FxGainAccountID != nil {
+		accountID := settings.FxGainAccountID.String()
+		pbSettings.FxGainAccountId = &accountID
+	}
+	if settings.FxLossAccountID != nil {
+		accountID := settings.FxLossAccountID.String()
+		pbSettings.FxLossAccountId = &accountID
+	}
+
+	if len(settings.FxConversionAccounts) > 0 {
+		pbSettings.FxConversionAccounts = make(map[string]string, len(settings.FxConversionAccounts))
+		for currencyCode, accountID := range settings.FxConversionAccounts {
+			pbSettings.FxConversionAccounts[currencyCode] = accountID.String()
+		}
+	}
+
+	if len(settings.OverdraftLimits) > 0 {
+		pbSettings.OverdraftLimits = make(map[string]string, len(settings.OverdraftLimits))
+		for accountType, limit := range settings.OverdraftLimits {
+			pbSettings.OverdraftLimits[accountType] = limit.String()
+		}
+	}
+
+	if !settings.UpdatedAt.IsZero() {
+		pbSettings.UpdatedAt = timestamppb.New(settings.UpdatedAt)
+	}
+
+	return pbSettings
+}
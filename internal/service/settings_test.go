@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockTenantSettingsRepository struct {
+	mock.Mock
+}
+
+func (m *MockTenantSettingsRepository) Get(ctx context.Context, tenantID uuid.UUID) (*repository.TenantSettings, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TenantSettings), args.Error(1)
+}
+
+func (m *MockTenantSettingsRepository) Upsert(ctx context.Context, settings *repository.TenantSettings) (*repository.TenantSettings, error) {
+	args := m.Called(ctx, settings)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TenantSettings), args.Error(1)
+}
+
+func stringPtr(v string) *string {
+	return &v
+}
+
+// Test GetTenantSettings
+func TestLedgerService_GetTenantSettings(t *testing.T) {
+	ctx := context.Background()
+	mockSettingsRepo := new(MockTenantSettingsRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithTenantSettingsRepository(mockSettingsRepo))
+
+	t.Run("returns stored settings", func(t *testing.T) {
+		tenantID := uuid.New()
+		mockSettingsRepo.On("Get", ctx, tenantID).Return(&repository.TenantSettings{
+			TenantID:     tenantID,
+			BaseCurrency: "EUR",
+			Timezone:     "Europe/Berlin",
+			Locale:       "de-DE",
+		}, nil).Once()
+
+		resp, err := service.GetTenantSettings(ctx, &pb.GetTenantSettingsRequest{TenantId: tenantID.String()})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "EUR", resp.Settings.BaseCurrency)
+		assert.Equal(t, "Europe/Berlin", resp.Settings.Timezone)
+		assert.Nil(t, resp.Settings.UpdatedAt)
+		mockSettingsRepo.AssertExpectations(t)
+	})
+}
+
+// Test UpdateTenantSettings
+func TestLedgerService_UpdateTenantSettings(t *testing.T) {
+	ctx := context.Background()
+	mockSettingsRepo := new(MockTenantSettingsRepository)
+	mockReferenceRepo := new(MockReferenceRepository)
+	mockAccountRepo := new(MockAccountRepository)
+	service := NewLedgerService(nil, mockAccountRepo, nil, mockReferenceRepo, WithTenantSettingsRepository(mockSettingsRepo))
+
+	t.Run("updates only provided fields", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockReferenceRepo.On("ListCurrencies", ctx).Return([]*repository.Currency{
+			{Code: "USD"}, {Code: "IRR"},
+		}, nil).Once()
+		mockSettingsRepo.On("Get", ctx, tenantID).Return(&repository.TenantSettings{
+			TenantID: tenantID,
+			Timezone: repository.DefaultTimezone,
+			Locale:   repository.DefaultLocale,
+		}, nil).Once()
+		mockSettingsRepo.On("Upsert", ctx, &repository.TenantSettings{
+			TenantID:     tenantID,
+			BaseCurrency: "IRR",
+			Timezone:     "Asia/Tehran",
+			Locale:       repository.DefaultLocale,
+		}).Return(&repository.TenantSettings{
+			TenantID:     tenantID,
+			BaseCurrency: "IRR",
+			Timezone:     "Asia/Tehran",
+			Locale:       repository.DefaultLocale,
+		}, nil).Once()
+
+		resp, err := service.UpdateTenantSettings(ctx, &pb.UpdateTenantSettingsRequest{
+			TenantId:     tenantID.String(),
+			BaseCurrency: stringPtr("IRR"),
+			Timezone:     stringPtr("Asia/Tehran"),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "IRR", resp.Settings.BaseCurrency)
+		assert.Equal(t, "Asia/Tehran", resp.Settings.Timezone)
+		mockSettingsRepo.AssertExpectations(t)
+		mockReferenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("turns on metadata encryption", func(t *testing.T) {
+		tenantID := uuid.New()
+		encrypt := true
+
+		mockSettingsRepo.On("Get", ctx, tenantID).Return(&repository.TenantSettings{
+			TenantID: tenantID,
+			Timezone: repository.DefaultTimezone,
+			Locale:   repository.DefaultLocale,
+		}, nil).Once()
+		mockSettingsRepo.On("Upsert", ctx, &repository.TenantSettings{
+			TenantID:        tenantID,
+			Timezone:        repository.DefaultTimezone,
+			Locale:          repository.DefaultLocale,
+			EncryptMetadata: true,
+		}).Return(&repository.TenantSettings{
+			TenantID:        tenantID,
+			Timezone:        repository.DefaultTimezone,
+			Locale:          repository.DefaultLocale,
+			EncryptMetadata: true,
+		}, nil).Once()
+
+		resp, err := service.UpdateTenantSettings(ctx, &pb.UpdateTenantSettingsRequest{
+			TenantId:        tenantID.String(),
+			EncryptMetadata: &encrypt,
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, resp.Settings.EncryptMetadata)
+		mockSettingsRepo.AssertExpectations(t)
+	})
+
+	t.Run("sets and clears the exchange difference accounts", func(t *testing.T) {
+		tenantID := uuid.New()
+		gainAccountID, lossAccountID := uuid.New(), uuid.New()
+
+		mockAccountRepo.On("GetByID", ctx, tenantID, gainAccountID).Return(&repository.Account{ID: gainAccountID}, nil).Once()
+		mockSettingsRepo.On("Get", ctx, tenantID).Return(&repository.TenantSettings{
+			TenantID:        tenantID,
+			Timezone:        repository.DefaultTimezone,
+			Locale:          repository.DefaultLocale,
+			FxLossAccountID: &lossAccountID,
+		}, nil).Once()
+		mockSettingsRepo.On("Upsert", ctx, &repository.TenantSettings{
+			TenantID:        tenantID,
+			Timezone:        repository.DefaultTimezone,
+			Locale:          repository.DefaultLocale,
+			FxGainAccountID: &gainAccountID,
+		}).Return(&repository.TenantSettings{
+			TenantID:        tenantID,
+			Timezone:        repository.DefaultTimezone,
+			Locale:          repository.DefaultLocale,
+			FxGainAccountID: &gainAccountID,
+		}, nil).Once()
+
+		resp, err := service.UpdateTenantSettings(ctx, &pb.UpdateTenantSettingsRequest{
+			TenantId:        tenantID.String(),
+			FxGainAccountId: stringPtr(gainAccountID.String()),
+			FxLossAccountId: stringPtr(""),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, gainAccountID.String(), resp.Settings.GetFxGainAccountId())
+		assert.Nil(t, resp.Settings.FxLossAccountId)
+		mockAccountRepo.AssertExpectations(t)
+		mockSettingsRepo.AssertExpectations(t)
+	})
+
+	t.Run("sets and removes conversion accounts by currency", func(t *testing.T) {
+		tenantID := uuid.New()
+		euroAccountID, dollarAccountID := uuid.New(), uuid.New()
+
+		mockAccountRepo.On("GetByID", ctx, tenantID, euroAccountID).
+			Return(&repository.Account{ID: euroAccountID, CurrencyCode: "EUR"}, nil).Once()
+		mockSettingsRepo.On("Get", ctx, tenantID).Return(&repository.TenantSettings{
+			TenantID:             tenantID,
+			FxConversionAccounts: map[string]uuid.UUID{"USD": dollarAccountID},
+		}, nil).Once()
+		mockSettingsRepo.On("Upsert", ctx, &repository.TenantSettings{
+			TenantID:             tenantID,
+			FxConversionAccounts: map[string]uuid.UUID{"EUR": euroAccountID},
+		}).Return(&repository.TenantSettings{
+			TenantID:             tenantID,
+			FxConversionAccounts: map[string]uuid.UUID{"EUR": euroAccountID},
+		}, nil).Once()
+
+		resp, err := service.UpdateTenantSettings(ctx, &pb.UpdateTenantSettingsRequest{
+			TenantId:             tenantID.String(),
+			FxConversionAccounts: map[string]string{"EUR": euroAccountID.String(), "USD": ""},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"EUR": euroAccountID.String()}, resp.Settings.FxConversionAccounts)
+		mockAccountRepo.AssertExpectations(t)
+		mockSettingsRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a conversion account of another currency", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+
+		mockAccountRepo.On("GetByID", ctx, tenantID, accountID).
+			Return(&repository.Account{ID: accountID, CurrencyCode: "USD"}, nil).Once()
+
+		resp, err := service.UpdateTenantSettings(ctx, &pb.UpdateTenantSettingsRequest{
+			TenantId:             tenantID.String(),
+			FxConversionAccounts: map[string]string{"EUR": accountID.String()},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+		mockAccountRepo.AssertExpectations(t)
+	})
+
+	t.Run("sets and removes overdraft limits by account type", func(t *testing.T) {
+		tenantID := uuid.New()
+
+		mockReferenceRepo.On("ListAccountTypes", ctx).Return([]*repository.AccountType{
+			{Code: "ASSET"}, {Code: "LIABILITY"},
+		}, nil).Once()
+		mockSettingsRepo.On("Get", ctx, tenantID).Return(&repository.TenantSettings{
+			TenantID:        tenantID,
+			OverdraftLimits: map[string]decimal.Decimal{"ASSET": decimal.NewFromInt(100)},
+		}, nil).Once()
+		mockSettingsRepo.On("Upsert", ctx, &repository.TenantSettings{
+			TenantID:        tenantID,
+			OverdraftLimits: map[string]decimal.Decimal{"LIABILITY": decimal.RequireFromString("0")},
+		}).Return(&repository.TenantSettings{
+			TenantID:        tenantID,
+			OverdraftLimits: map[string]decimal.Decimal{"LIABILITY": decimal.RequireFromString("0")},
+		}, nil).Once()
+
+		resp, err := service.UpdateTenantSettings(ctx, &pb.UpdateTenantSettingsRequest{
+			TenantId:        tenantID.String(),
+			OverdraftLimits: map[string]string{"LIABILITY": "0", "ASSET": ""},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"LIABILITY": "0"}, resp.Settings.OverdraftLimits)
+		mockReferenceRepo.AssertExpectations(t)
+		mockSettingsRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects overdraft limits of unknown account types and negative limits", func(t *testing.T) {
+		for _, limits := range []map[string]string{{"WALLET": "0"}, {"LIABILITY": "-1"}} {
+			mockReferenceRepo.On("ListAccountTypes", ctx).Return([]*repository.AccountType{{Code: "LIABILITY"}}, nil).Once()
+
+			resp, err := service.UpdateTenantSettings(ctx, &pb.UpdateTenantSettingsRequest{
+				TenantId:        uuid.New().String(),
+				OverdraftLimits: limits,
+			})
+
+			assert.Equal(t, "INVALID_FIELD", errorReason(t, err))
+			assert.Nil(t, resp)
+		}
+		mockReferenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for an unknown exchange difference account", func(t *testing.T) {
+		tenantID := uuid.New()
+		accountID := uuid.New()
+
+		mockAccountRepo.On("GetByID", ctx, tenantID, accountID).Return(nil, fmt.Errorf("account %w", repository.ErrNotFound)).Once()
+
+		resp, err := service.UpdateTenantSettings(ctx, &pb.UpdateTenantSettingsRequest{
+			TenantId:        tenantID.String(),
+			FxGainAccountId: stringPtr(accountID.String()),
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Nil(t, resp)
+		mockAccountRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns error for unknown timezone", func(t *testing.T) {
+		resp, err := service.UpdateTenantSettings(ctx, &pb.UpdateTenantSettingsRequest{
+			TenantId: uuid.New().String(),
+			Timezone: stringPtr("Mars/Olympus_Mons"),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns error for invalid locale", func(t *testing.T) {
+		resp, err := service.UpdateTenantSettings(ctx, &pb.UpdateTenantSettingsRequest{
+			TenantId: uuid.New().String(),
+			Locale:   stringPtr("not a locale!"),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns error for an unknown calendar", func(t *testing.T) {
+		resp, err := service.UpdateTenantSettings(ctx, &pb.UpdateTenantSettingsRequest{
+			TenantId: uuid.New().String(),
+			Calendar: stringPtr("LUNAR"),
+		})
+
+		assert.Equal(t, "INVALID_FIELD", errorReason(t, err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns error for unknown base currency", func(t *testing.T) {
+		mockReferenceRepo.On("ListCurrencies", ctx).Return([]*repository.Currency{{Code: "USD"}}, nil).Once()
+
+		resp, err := service.UpdateTenantSettings(ctx, &pb.UpdateTenantSettingsRequest{
+			TenantId:     uuid.New().String(),
+			BaseCurrency: stringPtr("XYZ"),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// maxStatementsPerRun limits the accounts and parties of a single statement run
+const maxStatementsPerRun = 1000
+
+// GenerateStatements starts a background run that draws up a PDF statement
+// for each account, and each party on one account, over a period and writes
+// them to the export store. Poll GetStatementRun for its status, then fetch
+// the statements with DownloadStatement.
+func (s *LedgerService) GenerateStatements(ctx context.Context, req *pb.GenerateStatementsRequest) (*pb.GenerateStatementsResponse, error) {
+	if s.statementRunner == nil {
+		return nil, status.Error(codes.Unimplemented, "statement delivery is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	params := repository.StatementRunParams{}
+	if params.AccountIDs, err = parseStatementIDs("account_ids", "account", req.AccountIds); err != nil {
+		return nil, err
+	}
+	if params.PartyIDs, err = parseStatementIDs("party_ids", "party", req.PartyIds); err != nil {
+		return nil, err
+	}
+	if len(params.AccountIDs)+len(params.PartyIDs) == 0 {
+		return nil, invalidField("account_ids", "at least one account or party is required")
+	}
+	if len(params.AccountIDs)+len(params.PartyIDs) > maxStatementsPerRun {
+		return nil, invalidField("account_ids", fmt.Sprintf("at most %d statements can be generated per run", maxStatementsPerRun))
+	}
+
+	if req.PartyAccountId != nil && *req.PartyAccountId != "" {
+		accountID, err := requestID("party_account_id", *req.PartyAccountId)
+		if err != nil {
+			return nil, err
+		}
+		params.PartyAccountID = &accountID
+	}
+	if (params.PartyAccountID != nil) != (len(params.PartyIDs) > 0) {
+		return nil, invalidField("party_account_id", "party_account_id must be given when, and only when, party_ids are")
+	}
+
+	from, err := dateField("from_date", req.FromDate, req.JalaliFromDate)
+	if err != nil {
+		return nil, err
+	}
+	to, err := dateField("to_date", req.ToDate, req.JalaliToDate)
+	if err != nil {
+		return nil, err
+	}
+	if from == nil || to == nil {
+		return nil, status.Error(codes.InvalidArgument, "from date and to date are required")
+	}
+	if to.Before(*from) {
+		return nil, status.Error(codes.InvalidArgument, "to date must not be before from date")
+	}
+	params.FromDate, params.ToDate = *from, *to
+
+	if err := s.checkStatementSubjects(ctx, tenantID, params); err != nil {
+		return nil, err
+	}
+
+	run, err := s.statementRunner.Start(ctx, tenantID, params)
+	if err != nil {
+		return nil, repositoryError("start statement run", err)
+	}
+
+	return &pb.GenerateStatementsResponse{
+		Run: statementRunToProto(run),
+	}, nil
+}
+
+// GetStatementRun returns the status of a statement run
+func (s *LedgerService) GetStatementRun(ctx context.Context, req *pb.GetStatementRunRequest) (*pb.GetStatementRunResponse, error) {
+	if s.statementRunner == nil {
+		return nil, status.Error(codes.Unimplemented, "statement delivery is not enabled")
+	}
+
+	tenantID, runID, err := parseStatementRunIDs(req.TenantId, req.RunId)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := s.statementRunner.Get(ctx, tenantID, runID)
+	if err != nil {
+		return nil, repositoryError("get statement run", err)
+	}
+
+	return &pb.GetStatementRunResponse{
+		Run: statementRunToProto(run),
+	}, nil
+}
+
+// DownloadStatement streams one statement of a completed run
+func (s *LedgerService) DownloadStatement(req *pb.DownloadStatementRequest, stream pb.LedgerService_DownloadStatementServer) error {
+	if s.statementRunner == nil {
+		return status.Error(codes.Unimplemented, "statement delivery is not enabled")
+	}
+
+	ctx := stream.Context()
+
+	tenantID, runID, err := parseStatementRunIDs(req.TenantId, req.RunId)
+	if err != nil {
+		return err
+	}
+
+	run, err := s.statementRunner.Get(ctx, tenantID, runID)
+	if err != nil {
+		return repositoryError("get statement run", err)
+	}
+
+	if run.Status != repository.StatementRunCompleted {
+		return status.Error(codes.FailedPrecondition, "statement run is not completed")
+	}
+
+	// Only files recorded on the run can be downloaded
+	if !slices.ContainsFunc(run.Statements, func(st repository.DeliveredStatement) bool { return st.File == req.File }) {
+		return status.Error(codes.NotFound, "statement not found")
+	}
+
+	return sendExportFile(ctx, s.statementRunner, req.File, func(chunk []byte) error {
+		return stream.Send(&pb.DownloadStatementResponse{Chunk: chunk})
+	})
+}
+
+// checkStatementSubjects verifies that the accounts and parties of a run
+// exist before it starts, so a typo fails the request instead of the run
+func (s *LedgerService) checkStatementSubjects(ctx context.Context, tenantID uuid.UUID, params repository.StatementRunParams) error {
+	accountIDs := params.AccountIDs
+	if params.PartyAccountID != nil {
+		accountIDs = append(slices.Clip(accountIDs), *params.PartyAccountID)
+	}
+
+	accounts, err := s.accountRepo.AccountCurrencies(ctx, tenantID, accountIDs)
+	if err != nil {
+		return repositoryError("get accounts", err)
+	}
+	for _, id := range accountIDs {
+		if _, ok := accounts[id]; !ok {
+			return repositoryError("get accounts", fmt.Errorf("account %s %w", id, repository.ErrNotFound))
+		}
+	}
+
+	if len(params.PartyIDs) == 0 {
+		return nil
+	}
+	if s.partyRepo == nil {
+		return status.Error(codes.Unimplemented, "parties are not enabled")
+	}
+
+	parties, err := s.partyRepo.GetByIDs(ctx, tenantID, params.PartyIDs)
+	if err != nil {
+		return repositoryError("get parties", err)
+	}
+	for _, id := range params.PartyIDs {
+		if _, ok := parties[id]; !ok {
+			return repositoryError("get parties", fmt.Errorf("party %s %w", id, repository.ErrNotFound))
+		}
+	}
+
+	return nil
+}
+
+// parseStatementIDs parses the IDs of a statement run, dropping duplicates
+func parseStatementIDs(field, kind string, raw []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(raw))
+	for _, value := range raw {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, invalidField(field, "invalid "+kind+" ID")
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func parseStatementRunIDs(tenantIDStr, runIDStr string) (uuid.UUID, uuid.UUID, error) {
+	tenantID, err := requestID("tenant_id", tenantIDStr)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	runID, err := requestID("run_id", runIDStr)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return tenantID, runID, nil
+}
+
+func statementRunToProto(run *repository.StatementRun) *pb.StatementRun {
+	pbRun := &pb.StatementRun{
+		RunId:      run.ID.String(),
+		TenantId:   run.TenantID.String(),
+		AccountIds: make([]string, len(run.AccountIDs)),
+		PartyIds:   make([]string, len(run.PartyIDs)),
+		FromDate:   timestamppb.New(run.FromDate),
+		ToDate:     timestamppb.New(run.ToDate),
+		Statements: make([]*pb.DeliveredStatement, len(run.Statements)),
+		Error:      run.Error,
+		CreatedAt:  timestamppb.New(run.CreatedAt),
+	}
+
+	for i, id := range run.AccountIDs {
+		pbRun.AccountIds[i] = id.String()
+	}
+	for i, id := range run.PartyIDs {
+		pbRun.PartyIds[i] = id.String()
+	}
+	if run.PartyAccountID != nil {
+		id := run.PartyAccountID.String()
+		pbRun.PartyAccountId = &id
+	}
+
+	switch run.Status {
+	case repository.StatementRunPending:
+		pbRun.Status = pb.StatementRunStatus_STATEMENT_RUN_STATUS_PENDING
+	case repository.StatementRunRunning:
+		pbRun.Status = pb.StatementRunStatus_STATEMENT_RUN_STATUS_RUNNING
+	case repository.StatementRunCompleted:
+		pbRun.Status = pb.StatementRunStatus_STATEMENT_RUN_STATUS_COMPLETED
+	case repository.StatementRunFailed:
+		pbRun.Status = pb.StatementRunStatus_STATEMENT_RUN_STATUS_FAILED
+	}
+
+	for i, st := range run.Statements {
+		pbStatement := &pb.DeliveredStatement{
+			AccountId: st.AccountID.String(),
+			File:      st.File,
+		}
+		if st.PartyID != nil {
+			id := st.PartyID.String()
+			pbStatement.PartyId = &id
+		}
+		pbRun.Statements[i] = pbStatement
+	}
+
+	if run.CompletedAt != nil {
+		pbRun.CompletedAt = timestamppb.New(*run.CompletedAt)
+	}
+
+	return pbRun
+}
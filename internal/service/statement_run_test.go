@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/export"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/internal/statementrun"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockStatementRunRepository struct {
+	mock.Mock
+}
+
+func (m *MockStatementRunRepository) Create(ctx context.Context, tenantID uuid.UUID, params repository.StatementRunParams) (*repository.StatementRun, error) {
+	args := m.Called(ctx, tenantID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.StatementRun), args.Error(1)
+}
+
+func (m *MockStatementRunRepository) GetByID(ctx context.Context, tenantID uuid.UUID, runID uuid.UUID) (*repository.StatementRun, error) {
+	args := m.Called(ctx, tenantID, runID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.StatementRun), args.Error(1)
+}
+
+func (m *MockStatementRunRepository) UpdateStatus(ctx context.Context, tenantID uuid.UUID, runID uuid.UUID, status string, statements []repository.DeliveredStatement, errMsg *string) error {
+	args := m.Called(ctx, tenantID, runID, status, statements, errMsg)
+	return args.Error(0)
+}
+
+// fakeStatementStream collects the chunks sent by DownloadStatement
+type fakeStatementStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	data []byte
+}
+
+func (f *fakeStatementStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeStatementStream) Send(resp *pb.DownloadStatementResponse) error {
+	f.data = append(f.data, resp.Chunk...)
+	return nil
+}
+
+// Test GenerateStatements
+func TestLedgerService_GenerateStatements(t *testing.T) {
+	ctx := context.Background()
+	tenantID, accountID := uuid.New(), uuid.New()
+	from := timestamppb.New(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	to := timestamppb.New(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))
+
+	t.Run("returns unimplemented when statement delivery is disabled", func(t *testing.T) {
+		service := NewLedgerService(nil, nil, nil, nil)
+
+		resp, err := service.GenerateStatements(ctx, &pb.GenerateStatementsRequest{
+			TenantId:   tenantID.String(),
+			AccountIds: []string{accountID.String()},
+		})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	tests := []struct {
+		name string
+		req  *pb.GenerateStatementsRequest
+	}{
+		{
+			name: "requires an account or party",
+			req:  &pb.GenerateStatementsRequest{TenantId: tenantID.String(), FromDate: from, ToDate: to},
+		},
+		{
+			name: "rejects invalid account IDs",
+			req:  &pb.GenerateStatementsRequest{TenantId: tenantID.String(), AccountIds: []string{"nope"}, FromDate: from, ToDate: to},
+		},
+		{
+			name: "requires an account for party statements",
+			req:  &pb.GenerateStatementsRequest{TenantId: tenantID.String(), PartyIds: []string{uuid.NewString()}, FromDate: from, ToDate: to},
+		},
+		{
+			name: "requires the period",
+			req:  &pb.GenerateStatementsRequest{TenantId: tenantID.String(), AccountIds: []string{accountID.String()}, FromDate: from},
+		},
+		{
+			name: "rejects a period ending before it starts",
+			req:  &pb.GenerateStatementsRequest{TenantId: tenantID.String(), AccountIds: []string{accountID.String()}, FromDate: to, ToDate: from},
+		},
+	}
+
+	runner := statementrun.NewRunner(nil, nil, nil, new(MockStatementRunRepository), export.NewDirStore(t.TempDir()))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewLedgerService(nil, nil, nil, nil, WithStatementRunner(runner))
+
+			resp, err := service.GenerateStatements(ctx, tt.req)
+
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			assert.Nil(t, resp)
+		})
+	}
+
+	t.Run("returns not found for unknown accounts without starting a run", func(t *testing.T) {
+		mockAccountRepo := new(MockAccountRepository)
+		mockRunRepo := new(MockStatementRunRepository)
+		runner := statementrun.NewRunner(mockAccountRepo, nil, nil, mockRunRepo, export.NewDirStore(t.TempDir()))
+		service := NewLedgerService(nil, mockAccountRepo, nil, nil, WithStatementRunner(runner))
+
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{accountID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{}, nil)
+
+		resp, err := service.GenerateStatements(ctx, &pb.GenerateStatementsRequest{
+			TenantId:   tenantID.String(),
+			AccountIds: []string{accountID.String(), accountID.String()},
+			FromDate:   from,
+			ToDate:     to,
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Nil(t, resp)
+		mockAccountRepo.AssertExpectations(t)
+		mockRunRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// Test GetStatementRun
+func TestLedgerService_GetStatementRun(t *testing.T) {
+	ctx := context.Background()
+	mockRunRepo := new(MockStatementRunRepository)
+	runner := statementrun.NewRunner(nil, nil, nil, mockRunRepo, export.NewDirStore(t.TempDir()))
+	service := NewLedgerService(nil, nil, nil, nil, WithStatementRunner(runner))
+
+	tenantID, runID, accountID, partyID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	completedAt := time.Now()
+
+	mockRunRepo.On("GetByID", ctx, tenantID, runID).Return(&repository.StatementRun{
+		ID:             runID,
+		TenantID:       tenantID,
+		PartyIDs:       []uuid.UUID{partyID},
+		PartyAccountID: &accountID,
+		Status:         repository.StatementRunCompleted,
+		Statements: []repository.DeliveredStatement{
+			{AccountID: accountID, PartyID: &partyID, File: "party.pdf"},
+		},
+		CompletedAt: &completedAt,
+	}, nil)
+
+	resp, err := service.GetStatementRun(ctx, &pb.GetStatementRunRequest{
+		TenantId: tenantID.String(),
+		RunId:    runID.String(),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, pb.StatementRunStatus_STATEMENT_RUN_STATUS_COMPLETED, resp.Run.Status)
+	assert.Equal(t, []string{partyID.String()}, resp.Run.PartyIds)
+	assert.Equal(t, accountID.String(), resp.Run.GetPartyAccountId())
+	require.Len(t, resp.Run.Statements, 1)
+	assert.Equal(t, partyID.String(), resp.Run.Statements[0].GetPartyId())
+	assert.Equal(t, "party.pdf", resp.Run.Statements[0].File)
+	assert.NotNil(t, resp.Run.CompletedAt)
+	mockRunRepo.AssertExpectations(t)
+}
+
+// Test DownloadStatement
+func TestLedgerService_DownloadStatement(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	mockRunRepo := new(MockStatementRunRepository)
+	runner := statementrun.NewRunner(nil, nil, nil, mockRunRepo, export.NewDirStore(dir))
+	service := NewLedgerService(nil, nil, nil, nil, WithStatementRunner(runner))
+
+	tenantID, runID, accountID := uuid.New(), uuid.New(), uuid.New()
+	key := tenantID.String() + "/" + runID.String() + "/account-" + accountID.String() + ".pdf"
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, tenantID.String(), runID.String()), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.FromSlash(key)), []byte("%PDF-1.4\n"), 0o600))
+
+	completed := &repository.StatementRun{
+		ID:         runID,
+		TenantID:   tenantID,
+		Status:     repository.StatementRunCompleted,
+		Statements: []repository.DeliveredStatement{{AccountID: accountID, File: key}},
+	}
+
+	t.Run("streams a statement of a completed run", func(t *testing.T) {
+		mockRunRepo.On("GetByID", ctx, tenantID, runID).Return(completed, nil).Once()
+
+		stream := &fakeStatementStream{ctx: ctx}
+		err := service.DownloadStatement(&pb.DownloadStatementRequest{
+			TenantId: tenantID.String(),
+			RunId:    runID.String(),
+			File:     key,
+		}, stream)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "%PDF-1.4\n", string(stream.data))
+		mockRunRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects files that do not belong to the run", func(t *testing.T) {
+		mockRunRepo.On("GetByID", ctx, tenantID, runID).Return(completed, nil).Once()
+
+		err := service.DownloadStatement(&pb.DownloadStatementRequest{
+			TenantId: tenantID.String(),
+			RunId:    runID.String(),
+			File:     uuid.NewString() + "/other/account.pdf",
+		}, &fakeStatementStream{ctx: ctx})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		mockRunRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns failed precondition while the run is running", func(t *testing.T) {
+		mockRunRepo.On("GetByID", ctx, tenantID, runID).Return(&repository.StatementRun{
+			ID:       runID,
+			TenantID: tenantID,
+			Status:   repository.StatementRunRunning,
+		}, nil).Once()
+
+		err := service.DownloadStatement(&pb.DownloadStatementRequest{
+			TenantId: tenantID.String(),
+			RunId:    runID.String(),
+			File:     key,
+		}, &fakeStatementStream{ctx: ctx})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockRunRepo.AssertExpectations(t)
+	})
+}
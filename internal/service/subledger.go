@@ -0,0 +1,437 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// SubledgerService implements the gRPC SubledgerService for receivables and payables
+type SubledgerService struct {
+	pb.UnimplementedSubledgerServiceServer
+	accountRepo   repository.AccountRepositoryInterface
+	subledgerRepo repository.SubledgerRepositoryInterface
+}
+
+// NewSubledgerService creates a new sub-ledger service
+func NewSubledgerService(
+	accountRepo repository.AccountRepositoryInterface,
+	subledgerRepo repository.SubledgerRepositoryInterface,
+) *SubledgerService {
+	return &SubledgerService{
+		accountRepo:   accountRepo,
+		subledgerRepo: subledgerRepo,
+	}
+}
+
+// CreateDocument records an invoice, bill or payment and posts its journal
+// entry against the control account
+func (s *SubledgerService) CreateDocument(ctx context.Context, req *pb.CreateDocumentRequest) (*pb.CreateDocumentResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	docType, ledger, err := documentTypeFromProto(req.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	controlAccountID, err := requestID("control_account_id", req.ControlAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	counterAccountID, err := requestID("counter_account_id", req.CounterAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if controlAccountID == counterAccountID {
+		return nil, status.Error(codes.InvalidArgument, "control and counter accounts must differ")
+	}
+
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil || !amount.IsPositive() {
+		return nil, status.Error(codes.InvalidArgument, "amount must be a positive number")
+	}
+
+	documentDate := *dateOf(req.DocumentDate)
+
+	var dueDate *time.Time
+	if req.DueDate != nil {
+		if docType != repository.DocumentTypeInvoice && docType != repository.DocumentTypeBill {
+			return nil, status.Error(codes.InvalidArgument, "due date is only allowed on invoices and bills")
+		}
+		dueDate = dateOf(req.DueDate)
+		if dueDate.Before(documentDate) {
+			return nil, status.Error(codes.InvalidArgument, "due date must not be before document date")
+		}
+	}
+
+	var fxRate *decimal.Decimal
+	if req.FxRate != nil {
+		rate, err := decimal.NewFromString(*req.FxRate)
+		if err != nil || !rate.IsPositive() {
+			return nil, invalidField("fx_rate", "fx rate must be a positive number")
+		}
+		fxRate = &rate
+	}
+
+	controlAccount, err := s.accountRepo.GetByID(ctx, tenantID, controlAccountID)
+	if err != nil {
+		return nil, repositoryError("get account", err)
+	}
+	if _, err := s.accountRepo.GetByID(ctx, tenantID, counterAccountID); err != nil {
+		return nil, repositoryError("get account", err)
+	}
+
+	// A document in a foreign currency posts its amount converted into the
+	// control account currency and keeps the rate for settlement
+	currencyCode := controlAccount.CurrencyCode
+	if req.CurrencyCode != nil && *req.CurrencyCode != "" {
+		currencyCode = *req.CurrencyCode
+	}
+	switch {
+	case currencyCode != controlAccount.CurrencyCode && fxRate == nil:
+		return nil, invalidField("fx_rate", fmt.Sprintf(
+			"fx_rate is required when currency %s differs from control account currency %s", currencyCode, controlAccount.CurrencyCode))
+	case currencyCode == controlAccount.CurrencyCode && fxRate != nil:
+		return nil, invalidField("fx_rate", fmt.Sprintf(
+			"fx_rate is only allowed when the currency differs from control account currency %s", controlAccount.CurrencyCode))
+	}
+
+	// The converted amount is rounded to the control account currency, in
+	// which it posts
+	postedAmount := amount
+	if fxRate != nil {
+		currencies, err := s.accountRepo.AccountCurrencies(ctx, tenantID, []uuid.UUID{controlAccountID})
+		if err != nil {
+			return nil, repositoryError("get account currencies", err)
+		}
+		postedAmount = amount.Mul(*fxRate).Round(currencies[controlAccountID].Precision)
+		if !postedAmount.IsPositive() {
+			return nil, invalidField("amount", fmt.Sprintf(
+				"amount converted at fx_rate rounds to zero in %s", controlAccount.CurrencyCode))
+		}
+	}
+
+	description := req.Description
+	if description == "" {
+		description = req.Party
+	}
+
+	doc, err := s.subledgerRepo.CreateDocument(ctx, tenantID, repository.CreateDocumentParams{
+		Type:             docType,
+		Ledger:           ledger,
+		Number:           req.Number,
+		Party:            req.Party,
+		ControlAccountID: controlAccountID,
+		CounterAccountID: counterAccountID,
+		Amount:           amount,
+		CurrencyCode:     currencyCode,
+		FxRate:           fxRate,
+		DocumentDate:     documentDate,
+		DueDate:          dueDate,
+		Description:      req.Description,
+		Entry: repository.CreateJournalEntryParams{
+			ReferenceNumber: req.Number,
+			Description:     description,
+			EntryDate:       documentDate,
+			Metadata: map[string]interface{}{
+				"document_type": docType,
+				"party":         req.Party,
+			},
+			Lines: documentJournalLines(docType, controlAccountID, counterAccountID, postedAmount, description),
+		},
+	})
+	if err != nil {
+		return nil, repositoryError("create document", err)
+	}
+
+	return &pb.CreateDocumentResponse{
+		Document: documentToProto(doc),
+	}, nil
+}
+
+// GetDocument retrieves a document with its applications
+func (s *SubledgerService) GetDocument(ctx context.Context, req *pb.GetDocumentRequest) (*pb.GetDocumentResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	documentID, err := requestID("document_id", req.DocumentId)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.subledgerRepo.GetDocument(ctx, tenantID, documentID)
+	if err != nil {
+		return nil, repositoryError("get document", err)
+	}
+
+	return &pb.GetDocumentResponse{
+		Document: documentToProto(doc),
+	}, nil
+}
+
+// ListDocuments lists the documents of a sub-ledger, optionally only open items
+func (s *SubledgerService) ListDocuments(ctx context.Context, req *pb.ListDocumentsRequest) (*pb.ListDocumentsResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := repository.DocumentFilter{
+		Party:    req.Party,
+		OpenOnly: req.OpenOnly,
+	}
+
+	switch req.Ledger {
+	case pb.SubledgerType_SUBLEDGER_TYPE_RECEIVABLE:
+		ledger := repository.SubledgerReceivable
+		filter.Ledger = &ledger
+	case pb.SubledgerType_SUBLEDGER_TYPE_PAYABLE:
+		ledger := repository.SubledgerPayable
+		filter.Ledger = &ledger
+	}
+
+	page := int(req.GetPage())
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	docs, totalCount, err := s.subledgerRepo.ListDocuments(ctx, tenantID, filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, repositoryError("list documents", err)
+	}
+
+	pbDocs := make([]*pb.SubledgerDocument, len(docs))
+	for i, doc := range docs {
+		pbDocs[i] = documentToProto(doc)
+	}
+
+	return &pb.ListDocumentsResponse{
+		Documents:  pbDocs,
+		TotalCount: int32(totalCount),
+	}, nil
+}
+
+// ApplyPayment applies part of a payment to an invoice or bill of the same party
+func (s *SubledgerService) ApplyPayment(ctx context.Context, req *pb.ApplyPaymentRequest) (*pb.ApplyPaymentResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentID, err := requestID("payment_id", req.PaymentId)
+	if err != nil {
+		return nil, err
+	}
+
+	documentID, err := requestID("document_id", req.DocumentId)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil || !amount.IsPositive() {
+		return nil, status.Error(codes.InvalidArgument, "amount must be a positive number")
+	}
+
+	app, err := s.subledgerRepo.Apply(ctx, tenantID, paymentID, documentID, amount)
+	if err != nil {
+		return nil, repositoryError("apply payment", err)
+	}
+
+	payment, document, err := s.applicationDocuments(ctx, tenantID, app)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ApplyPaymentResponse{
+		Application: applicationToProto(app),
+		Payment:     payment,
+		Document:    document,
+	}, nil
+}
+
+// UnapplyPayment removes an application and reopens its payment and document
+func (s *SubledgerService) UnapplyPayment(ctx context.Context, req *pb.UnapplyPaymentRequest) (*pb.UnapplyPaymentResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	applicationID, err := requestID("application_id", req.ApplicationId)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := s.subledgerRepo.Unapply(ctx, tenantID, applicationID)
+	if err != nil {
+		return nil, repositoryError("unapply payment", err)
+	}
+
+	payment, document, err := s.applicationDocuments(ctx, tenantID, app)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.UnapplyPaymentResponse{
+		Payment:  payment,
+		Document: document,
+	}, nil
+}
+
+// applicationDocuments reloads both sides of an application after it changed
+func (s *SubledgerService) applicationDocuments(ctx context.Context, tenantID uuid.UUID, app *repository.DocumentApplication) (*pb.SubledgerDocument, *pb.SubledgerDocument, error) {
+	payment, err := s.subledgerRepo.GetDocument(ctx, tenantID, app.PaymentID)
+	if err != nil {
+		return nil, nil, repositoryError("get payment", err)
+	}
+
+	document, err := s.subledgerRepo.GetDocument(ctx, tenantID, app.DocumentID)
+	if err != nil {
+		return nil, nil, repositoryError("get document", err)
+	}
+
+	return documentToProto(payment), documentToProto(document), nil
+}
+
+// documentJournalLines builds the entry a document posts. Invoices and vendor
+// payments debit the control account, bills and customer payments credit it.
+func documentJournalLines(docType string, controlAccountID, counterAccountID uuid.UUID, amount decimal.Decimal, description string) []*repository.CreateJournalEntryLineParams {
+	debitAccountID, creditAccountID := controlAccountID, counterAccountID
+	if docType == repository.DocumentTypeBill || docType == repository.DocumentTypeCustomerPayment {
+		debitAccountID, creditAccountID = counterAccountID, controlAccountID
+	}
+
+	return []*repository.CreateJournalEntryLineParams{
+		{
+			AccountID:   debitAccountID,
+			Debit:       amount,
+			Credit:      decimal.Zero,
+			Description: description,
+		},
+		{
+			AccountID:   creditAccountID,
+			Debit:       decimal.Zero,
+			Credit:      amount,
+			Description: description,
+		},
+	}
+}
+
+func documentTypeFromProto(docType pb.DocumentType) (string, string, error) {
+	switch docType {
+	case pb.DocumentType_DOCUMENT_TYPE_INVOICE:
+		return repository.DocumentTypeInvoice, repository.SubledgerReceivable, nil
+	case pb.DocumentType_DOCUMENT_TYPE_CUSTOMER_PAYMENT:
+		return repository.DocumentTypeCustomerPayment, repository.SubledgerReceivable, nil
+	case pb.DocumentType_DOCUMENT_TYPE_BILL:
+		return repository.DocumentTypeBill, repository.SubledgerPayable, nil
+	case pb.DocumentType_DOCUMENT_TYPE_VENDOR_PAYMENT:
+		return repository.DocumentTypeVendorPayment, repository.SubledgerPayable, nil
+	default:
+		return "", "", status.Error(codes.InvalidArgument, "document type must be INVOICE, BILL, CUSTOMER_PAYMENT or VENDOR_PAYMENT")
+	}
+}
+
+func documentToProto(doc *repository.SubledgerDocument) *pb.SubledgerDocument {
+	pbDoc := &pb.SubledgerDocument{
+		DocumentId:       doc.ID.String(),
+		TenantId:         doc.TenantID.String(),
+		Number:           doc.Number,
+		Party:            doc.Party,
+		ControlAccountId: doc.ControlAccountID.String(),
+		CounterAccountId: doc.CounterAccountID.String(),
+		Amount:           doc.Amount.String(),
+		OpenAmount:       doc.OpenAmount.String(),
+		CurrencyCode:     doc.CurrencyCode,
+		DocumentDate:     timestamppb.New(doc.DocumentDate),
+		Description:      doc.Description,
+		JournalEntryId:   doc.JournalEntryID.String(),
+		Applications:     make([]*pb.DocumentApplication, len(doc.Applications)),
+		CreatedAt:        timestamppb.New(doc.CreatedAt),
+		UpdatedAt:        timestamppb.New(doc.UpdatedAt),
+	}
+
+	switch doc.Type {
+	case repository.DocumentTypeInvoice:
+		pbDoc.Type = pb.DocumentType_DOCUMENT_TYPE_INVOICE
+	case repository.DocumentTypeBill:
+		pbDoc.Type = pb.DocumentType_DOCUMENT_TYPE_BILL
+	case repository.DocumentTypeCustomerPayment:
+		pbDoc.Type = pb.DocumentType_DOCUMENT_TYPE_CUSTOMER_PAYMENT
+	case repository.DocumentTypeVendorPayment:
+		pbDoc.Type = pb.DocumentType_DOCUMENT_TYPE_VENDOR_PAYMENT
+	}
+
+	switch doc.Ledger {
+	case repository.SubledgerReceivable:
+		pbDoc.Ledger = pb.SubledgerType_SUBLEDGER_TYPE_RECEIVABLE
+	case repository.SubledgerPayable:
+		pbDoc.Ledger = pb.SubledgerType_SUBLEDGER_TYPE_PAYABLE
+	}
+
+	switch doc.Status {
+	case repository.DocumentStatusOpen:
+		pbDoc.Status = pb.DocumentStatus_DOCUMENT_STATUS_OPEN
+	case repository.DocumentStatusPartial:
+		pbDoc.Status = pb.DocumentStatus_DOCUMENT_STATUS_PARTIAL
+	case repository.DocumentStatusSettled:
+		pbDoc.Status = pb.DocumentStatus_DOCUMENT_STATUS_SETTLED
+	}
+
+	if doc.DueDate != nil {
+		pbDoc.DueDate = timestamppb.New(*doc.DueDate)
+	}
+
+	if doc.FxRate != nil {
+		rate := doc.FxRate.String()
+		pbDoc.FxRate = &rate
+	}
+
+	for i, app := range doc.Applications {
+		pbDoc.Applications[i] = applicationToProto(app)
+	}
+
+	return pbDoc
+}
+
+func applicationToProto(app *repository.DocumentApplication) *pb.DocumentApplication {
+	pbApp := &pb.DocumentApplication{
+		ApplicationId: app.ID.String(),
+		PaymentId:     app.PaymentID.String(),
+		DocumentId:    app.DocumentID.String(),
+		Amount:        app.Amount.String(),
+		AppliedAt:     timestamppb.New(app.AppliedAt),
+		FxGainLoss:    app.FxGainLoss.String(),
+	}
+
+	if app.FxJournalEntryID != nil {
+		entryID := app.FxJournalEntryID.String()
+		pbApp.FxJournalEntryId = &entryID
+	}
+
+	return pbApp
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockSubledgerRepository struct {
+	mock.Mock
+}
+
+func (m *MockSubledgerRepository) CreateDocument(ctx context.Context, tenantID uuid.UUID, params repository.CreateDocumentParams) (*repository.SubledgerDocument, error) {
+	args := m.Called(ctx, tenantID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.SubledgerDocument), args.Error(1)
+}
+
+func (m *MockSubledgerRepository) GetDocument(ctx context.Context, tenantID uuid.UUID, documentID uuid.UUID) (*repository.SubledgerDocument, error) {
+	args := m.Called(ctx, tenantID, documentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.SubledgerDocument), args.Error(1)
+}
+
+func (m *MockSubledgerRepository) ListDocuments(ctx context.Context, tenantID uuid.UUID, filter repository.DocumentFilter, limit, offset int) ([]*repository.SubledgerDocument, int, error) {
+	args := m.Called(ctx, tenantID, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*repository.SubledgerDocument), args.Int(1), args.Error(2)
+}
+
+func (m *MockSubledgerRepository) Apply(ctx context.Context, tenantID uuid.UUID, paymentID, documentID uuid.UUID, amount decimal.Decimal) (*repository.DocumentApplication, error) {
+	args := m.Called(ctx, tenantID, paymentID, documentID, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.DocumentApplication), args.Error(1)
+}
+
+func (m *MockSubledgerRepository) Unapply(ctx context.Context, tenantID uuid.UUID, applicationID uuid.UUID) (*repository.DocumentApplication, error) {
+	args := m.Called(ctx, tenantID, applicationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.DocumentApplication), args.Error(1)
+}
+
+// Test CreateDocument
+func TestSubledgerService_CreateDocument(t *testing.T) {
+	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
+	mockSubledgerRepo := new(MockSubledgerRepository)
+	service := NewSubledgerService(mockAccountRepo, mockSubledgerRepo)
+	documentDate := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("posts an invoice against the receivable control account", func(t *testing.T) {
+		tenantID := uuid.New()
+		receivableID, revenueID := uuid.New(), uuid.New()
+
+		mockAccountRepo.On("GetByID", ctx, tenantID, receivableID).Return(&repository.Account{ID: receivableID}, nil).Once()
+		mockAccountRepo.On("GetByID", ctx, tenantID, revenueID).Return(&repository.Account{ID: revenueID}, nil).Once()
+		mockSubledgerRepo.On("CreateDocument", ctx, tenantID, mock.MatchedBy(func(p repository.CreateDocumentParams) bool {
+			lines := p.Entry.Lines
+			return p.Type == repository.DocumentTypeInvoice && p.Ledger == repository.SubledgerReceivable &&
+				p.Entry.ReferenceNumber == "INV-1" && len(lines) == 2 &&
+				p.DocumentDate.Equal(documentDate) && p.Entry.EntryDate.Equal(documentDate) &&
+				p.DueDate != nil && p.DueDate.Equal(documentDate) &&
+				lines[0].AccountID == receivableID && lines[0].Debit.Equal(decimal.NewFromInt(250)) &&
+				lines[1].AccountID == revenueID && lines[1].Credit.Equal(decimal.NewFromInt(250))
+		})).Return(&repository.SubledgerDocument{
+			ID:               uuid.New(),
+			TenantID:         tenantID,
+			Type:             repository.DocumentTypeInvoice,
+			Ledger:           repository.SubledgerReceivable,
+			Number:           "INV-1",
+			Party:            "Acme",
+			ControlAccountID: receivableID,
+			CounterAccountID: revenueID,
+			Amount:           decimal.NewFromInt(250),
+			OpenAmount:       decimal.NewFromInt(250),
+			Status:           repository.DocumentStatusOpen,
+			DocumentDate:     documentDate,
+		}, nil).Once()
+
+		resp, err := service.CreateDocument(ctx, &pb.CreateDocumentRequest{
+			TenantId:         tenantID.String(),
+			Type:             pb.DocumentType_DOCUMENT_TYPE_INVOICE,
+			Number:           "INV-1",
+			Party:            "Acme",
+			ControlAccountId: receivableID.String(),
+			CounterAccountId: revenueID.String(),
+			Amount:           "250",
+			// Dates are read as UTC days, so a due date earlier on the same
+			// day is not before the document date
+			DocumentDate: timestamppb.New(documentDate.Add(22 * time.Hour)),
+			DueDate:      timestamppb.New(documentDate.Add(time.Hour)),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, pb.DocumentStatus_DOCUMENT_STATUS_OPEN, resp.Document.Status)
+		assert.Equal(t, pb.SubledgerType_SUBLEDGER_TYPE_RECEIVABLE, resp.Document.Ledger)
+		mockAccountRepo.AssertExpectations(t)
+		mockSubledgerRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a document without a party or document date", func(t *testing.T) {
+		id := uuid.New().String()
+		resp, err := validated(ctx, &pb.CreateDocumentRequest{
+			TenantId:         id,
+			Type:             pb.DocumentType_DOCUMENT_TYPE_INVOICE,
+			Number:           "INV-1",
+			ControlAccountId: id,
+			CounterAccountId: id,
+			Amount:           "250",
+		}, service.CreateDocument)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		violations := fieldViolations(t, err)
+		require.Len(t, violations, 2)
+		assert.Equal(t, "party", violations[0].Field)
+		assert.Equal(t, "document_date", violations[1].Field)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("posts a foreign-currency invoice converted at its fx rate", func(t *testing.T) {
+		tenantID := uuid.New()
+		rate := decimal.RequireFromString("1.1")
+		receivableID, revenueID := uuid.New(), uuid.New()
+
+		mockAccountRepo.On("GetByID", ctx, tenantID, receivableID).
+			Return(&repository.Account{ID: receivableID, CurrencyCode: "USD"}, nil).Once()
+		mockAccountRepo.On("GetByID", ctx, tenantID, revenueID).
+			Return(&repository.Account{ID: revenueID, CurrencyCode: "USD"}, nil).Once()
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{receivableID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{receivableID: {CurrencyCode: "USD", Precision: 2}}, nil).Once()
+		mockSubledgerRepo.On("CreateDocument", ctx, tenantID, mock.MatchedBy(func(p repository.CreateDocumentParams) bool {
+			lines := p.Entry.Lines
+			return p.CurrencyCode == "EUR" && p.FxRate != nil && p.FxRate.Equal(rate) &&
+				p.Amount.Equal(decimal.NewFromInt(100)) &&
+				lines[0].Debit.Equal(decimal.NewFromInt(110)) && lines[1].Credit.Equal(decimal.NewFromInt(110))
+		})).Return(&repository.SubledgerDocument{
+			ID:           uuid.New(),
+			TenantID:     tenantID,
+			Type:         repository.DocumentTypeInvoice,
+			Ledger:       repository.SubledgerReceivable,
+			Amount:       decimal.NewFromInt(100),
+			OpenAmount:   decimal.NewFromInt(100),
+			CurrencyCode: "EUR",
+			FxRate:       &rate,
+			Status:       repository.DocumentStatusOpen,
+			DocumentDate: documentDate,
+		}, nil).Once()
+
+		resp, err := service.CreateDocument(ctx, &pb.CreateDocumentRequest{
+			TenantId:         tenantID.String(),
+			Type:             pb.DocumentType_DOCUMENT_TYPE_INVOICE,
+			Number:           "INV-2",
+			Party:            "Acme",
+			ControlAccountId: receivableID.String(),
+			CounterAccountId: revenueID.String(),
+			Amount:           "100",
+			DocumentDate:     timestamppb.New(documentDate),
+			CurrencyCode:     stringPtr("EUR"),
+			FxRate:           stringPtr("1.1"),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "EUR", resp.Document.CurrencyCode)
+		assert.Equal(t, "1.1", resp.Document.GetFxRate())
+		mockAccountRepo.AssertExpectations(t)
+		mockSubledgerRepo.AssertExpectations(t)
+	})
+
+	t.Run("rounds the converted amount to the control account currency", func(t *testing.T) {
+		tenantID := uuid.New()
+		rate := decimal.RequireFromString("1.23456")
+		receivableID, revenueID := uuid.New(), uuid.New()
+
+		mockAccountRepo.On("GetByID", ctx, tenantID, receivableID).
+			Return(&repository.Account{ID: receivableID, CurrencyCode: "USD"}, nil).Once()
+		mockAccountRepo.On("GetByID", ctx, tenantID, revenueID).
+			Return(&repository.Account{ID: revenueID, CurrencyCode: "USD"}, nil).Once()
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{receivableID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{receivableID: {CurrencyCode: "USD", Precision: 2}}, nil).Once()
+		mockSubledgerRepo.On("CreateDocument", ctx, tenantID, mock.MatchedBy(func(p repository.CreateDocumentParams) bool {
+			lines := p.Entry.Lines
+			return p.Amount.Equal(decimal.NewFromInt(100)) &&
+				lines[0].Debit.Equal(decimal.RequireFromString("123.46")) && lines[1].Credit.Equal(decimal.RequireFromString("123.46"))
+		})).Return(&repository.SubledgerDocument{
+			ID:           uuid.New(),
+			TenantID:     tenantID,
+			Type:         repository.DocumentTypeInvoice,
+			Ledger:       repository.SubledgerReceivable,
+			Amount:       decimal.NewFromInt(100),
+			OpenAmount:   decimal.NewFromInt(100),
+			CurrencyCode: "EUR",
+			FxRate:       &rate,
+			Status:       repository.DocumentStatusOpen,
+			DocumentDate: documentDate,
+		}, nil).Once()
+
+		_, err := service.CreateDocument(ctx, &pb.CreateDocumentRequest{
+			TenantId:         tenantID.String(),
+			Type:             pb.DocumentType_DOCUMENT_TYPE_INVOICE,
+			Number:           "INV-3",
+			Party:            "Acme",
+			ControlAccountId: receivableID.String(),
+			CounterAccountId: revenueID.String(),
+			Amount:           "100",
+			DocumentDate:     timestamppb.New(documentDate),
+			CurrencyCode:     stringPtr("EUR"),
+			FxRate:           stringPtr("1.23456"),
+		})
+
+		require.NoError(t, err)
+		mockAccountRepo.AssertExpectations(t)
+		mockSubledgerRepo.AssertExpectations(t)
+	})
+
+	t.Run("requires an fx rate for a foreign-currency document", func(t *testing.T) {
+		tenantID := uuid.New()
+		payableID, expenseID := uuid.New(), uuid.New()
+
+		mockAccountRepo.On("GetByID", ctx, tenantID, payableID).
+			Return(&repository.Account{ID: payableID, CurrencyCode: "USD"}, nil).Once()
+		mockAccountRepo.On("GetByID", ctx, tenantID, expenseID).
+			Return(&repository.Account{ID: expenseID, CurrencyCode: "USD"}, nil).Once()
+
+		resp, err := service.CreateDocument(ctx, &pb.CreateDocumentRequest{
+			TenantId:         tenantID.String(),
+			Type:             pb.DocumentType_DOCUMENT_TYPE_BILL,
+			Number:           "BILL-2",
+			Party:            "Supplier",
+			ControlAccountId: payableID.String(),
+			CounterAccountId: expenseID.String(),
+			Amount:           "100",
+			DocumentDate:     timestamppb.New(documentDate),
+			CurrencyCode:     stringPtr("EUR"),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+		mockAccountRepo.AssertExpectations(t)
+	})
+
+	t.Run("credits the control account for a customer payment", func(t *testing.T) {
+		lines := documentJournalLines(repository.DocumentTypeCustomerPayment, uuid.New(), uuid.New(), decimal.NewFromInt(100), "")
+
+		assert.True(t, lines[0].Debit.Equal(decimal.NewFromInt(100)))
+		assert.True(t, lines[1].Credit.Equal(decimal.NewFromInt(100)))
+	})
+
+	t.Run("returns error for non-positive amount", func(t *testing.T) {
+		resp, err := service.CreateDocument(ctx, &pb.CreateDocumentRequest{
+			TenantId:         uuid.New().String(),
+			Type:             pb.DocumentType_DOCUMENT_TYPE_BILL,
+			Number:           "BILL-1",
+			Party:            "Supplier",
+			ControlAccountId: uuid.New().String(),
+			CounterAccountId: uuid.New().String(),
+			Amount:           "0",
+			DocumentDate:     timestamppb.New(documentDate),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("rejects a due date on a payment", func(t *testing.T) {
+		resp, err := service.CreateDocument(ctx, &pb.CreateDocumentRequest{
+			TenantId:         uuid.New().String(),
+			Type:             pb.DocumentType_DOCUMENT_TYPE_VENDOR_PAYMENT,
+			Number:           "PAY-1",
+			Party:            "Supplier",
+			ControlAccountId: uuid.New().String(),
+			CounterAccountId: uuid.New().String(),
+			Amount:           "10",
+			DocumentDate:     timestamppb.New(documentDate),
+			DueDate:          timestamppb.New(documentDate),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test ApplyPayment
+func TestSubledgerService_ApplyPayment(t *testing.T) {
+	ctx := context.Background()
+	mockSubledgerRepo := new(MockSubledgerRepository)
+	service := NewSubledgerService(nil, mockSubledgerRepo)
+
+	t.Run("returns both documents after applying", func(t *testing.T) {
+		tenantID, paymentID, invoiceID := uuid.New(), uuid.New(), uuid.New()
+		amount := decimal.NewFromInt(100)
+
+		mockSubledgerRepo.On("Apply", ctx, tenantID, paymentID, invoiceID, amount).Return(&repository.DocumentApplication{
+			ID:         uuid.New(),
+			PaymentID:  paymentID,
+			DocumentID: invoiceID,
+			Amount:     amount,
+		}, nil).Once()
+		mockSubledgerRepo.On("GetDocument", ctx, tenantID, paymentID).Return(&repository.SubledgerDocument{
+			ID:         paymentID,
+			Type:       repository.DocumentTypeCustomerPayment,
+			Amount:     amount,
+			OpenAmount: decimal.Zero,
+			Status:     repository.DocumentStatusSettled,
+		}, nil).Once()
+		mockSubledgerRepo.On("GetDocument", ctx, tenantID, invoiceID).Return(&repository.SubledgerDocument{
+			ID:         invoiceID,
+			Type:       repository.DocumentTypeInvoice,
+			Amount:     decimal.NewFromInt(250),
+			OpenAmount: decimal.NewFromInt(150),
+			Status:     repository.DocumentStatusPartial,
+		}, nil).Once()
+
+		resp, err := service.ApplyPayment(ctx, &pb.ApplyPaymentRequest{
+			TenantId:   tenantID.String(),
+			PaymentId:  paymentID.String(),
+			DocumentId: invoiceID.String(),
+			Amount:     "100",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, pb.DocumentStatus_DOCUMENT_STATUS_SETTLED, resp.Payment.Status)
+		assert.Equal(t, pb.DocumentStatus_DOCUMENT_STATUS_PARTIAL, resp.Document.Status)
+		assert.Equal(t, "150", resp.Document.OpenAmount)
+		mockSubledgerRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns failed precondition when over-applying", func(t *testing.T) {
+		tenantID, paymentID, invoiceID := uuid.New(), uuid.New(), uuid.New()
+		amount := decimal.NewFromInt(500)
+
+		mockSubledgerRepo.On("Apply", ctx, tenantID, paymentID, invoiceID, amount).Return(nil, repository.ErrOverApplication).Once()
+
+		resp, err := service.ApplyPayment(ctx, &pb.ApplyPaymentRequest{
+			TenantId:   tenantID.String(),
+			PaymentId:  paymentID.String(),
+			DocumentId: invoiceID.String(),
+			Amount:     "500",
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Nil(t, resp)
+		mockSubledgerRepo.AssertExpectations(t)
+	})
+}
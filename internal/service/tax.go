@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// CreateTaxCode creates an active tax code
+func (s *LedgerService) CreateTaxCode(ctx context.Context, req *pb.CreateTaxCodeRequest) (*pb.CreateTaxCodeResponse, error) {
+	if s.taxRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tax codes are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := taxCodeParams(req.Code, req.Name, req.Type, req.Rate, req.TaxAccountId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := s.taxRepo.Create(ctx, tenantID, params)
+	if err != nil {
+		return nil, repositoryError("create tax code", err)
+	}
+
+	return &pb.CreateTaxCodeResponse{
+		TaxCode: taxCodeToProto(code),
+	}, nil
+}
+
+// GetTaxCode retrieves a tax code
+func (s *LedgerService) GetTaxCode(ctx context.Context, req *pb.GetTaxCodeRequest) (*pb.GetTaxCodeResponse, error) {
+	if s.taxRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tax codes are not enabled")
+	}
+
+	tenantID, taxCodeID, err := parseTaxCodeIDs(req.TenantId, req.TaxCodeId)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := s.taxRepo.GetByID(ctx, tenantID, taxCodeID)
+	if err != nil {
+		return nil, repositoryError("get tax code", err)
+	}
+
+	return &pb.GetTaxCodeResponse{
+		TaxCode: taxCodeToProto(code),
+	}, nil
+}
+
+// ListTaxCodes lists the tax codes of a tenant
+func (s *LedgerService) ListTaxCodes(ctx context.Context, req *pb.ListTaxCodesRequest) (*pb.ListTaxCodesResponse, error) {
+	if s.taxRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tax codes are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	taxCodes, err := s.taxRepo.List(ctx, tenantID, req.IncludeInactive)
+	if err != nil {
+		return nil, repositoryError("list tax codes", err)
+	}
+
+	resp := &pb.ListTaxCodesResponse{
+		TaxCodes: make([]*pb.TaxCode, len(taxCodes)),
+	}
+	for i, code := range taxCodes {
+		resp.TaxCodes[i] = taxCodeToProto(code)
+	}
+
+	return resp, nil
+}
+
+// UpdateTaxCode replaces the settings of a tax code
+func (s *LedgerService) UpdateTaxCode(ctx context.Context, req *pb.UpdateTaxCodeRequest) (*pb.UpdateTaxCodeResponse, error) {
+	if s.taxRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tax codes are not enabled")
+	}
+
+	tenantID, taxCodeID, err := parseTaxCodeIDs(req.TenantId, req.TaxCodeId)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := taxCodeParams(req.Code, req.Name, req.Type, req.Rate, req.TaxAccountId, req.IsActive)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := s.taxRepo.Update(ctx, tenantID, taxCodeID, params)
+	if err != nil {
+		return nil, repositoryError("update tax code", err)
+	}
+
+	return &pb.UpdateTaxCodeResponse{
+		TaxCode: taxCodeToProto(code),
+	}, nil
+}
+
+// GetTaxReport summarises the taxable amounts and tax posted with each tax
+// code over a period, for VAT returns
+func (s *LedgerService) GetTaxReport(ctx context.Context, req *pb.GetTaxReportRequest) (*pb.GetTaxReportResponse, error) {
+	if s.taxRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tax codes are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := dateField("from_date", req.FromDate, req.JalaliFromDate)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := dateField("to_date", req.ToDate, req.JalaliToDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == nil || to == nil {
+		return nil, status.Error(codes.InvalidArgument, "from date and to date are required")
+	}
+
+	fromDate := *from
+	toDate := *to
+	if toDate.Before(fromDate) {
+		return nil, status.Error(codes.InvalidArgument, "to date must not be before from date")
+	}
+
+	formatter, err := reportFormatter(req.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.taxRepo.GetReport(ctx, tenantID, fromDate, toDate)
+	if err != nil {
+		return nil, repositoryError("get tax report", err)
+	}
+
+	resp := &pb.GetTaxReportResponse{
+		FromDate: timestamppb.New(fromDate),
+		ToDate:   timestamppb.New(toDate),
+		Lines:    make([]*pb.TaxReportLine, len(rows)),
+	}
+
+	salesTax := decimal.Zero
+	purchaseTax := decimal.Zero
+	for i, row := range rows {
+		tax := row.TaxAmount()
+		resp.Lines[i] = &pb.TaxReportLine{
+			TaxCode:       taxCodeToProto(row.TaxCode),
+			TaxableAmount: formatter.Decimal(row.TaxableAmount()),
+			TaxAmount:     formatter.Decimal(tax),
+		}
+
+		if row.TaxCode.Type == repository.TaxTypeSales {
+			salesTax = salesTax.Add(tax)
+		} else {
+			purchaseTax = purchaseTax.Add(tax)
+		}
+	}
+
+	resp.TotalSalesTax = formatter.Decimal(salesTax)
+	resp.TotalPurchaseTax = formatter.Decimal(purchaseTax)
+	resp.NetTaxPayable = formatter.Decimal(salesTax.Sub(purchaseTax))
+
+	return resp, nil
+}
+
+// addTaxLines appends a tax line for every line with a tax code. The tax is
+// the line amount times the code's rate, rounded to the precision of the tax
+// account's currency, and is posted to the code's tax account on the same
+// side and for the same party and dimensions as the line.
+func (s *LedgerService) addTaxLines(ctx context.Context, tenantID uuid.UUID, lines []*repository.CreateJournalEntryLineParams) ([]*repository.CreateJournalEntryLineParams, error) {
+	taxCodeIDs := make([]uuid.UUID, 0)
+	seen := make(map[uuid.UUID]bool)
+	for _, line := range lines {
+		if line.TaxCodeID != nil && !seen[*line.TaxCodeID] {
+			seen[*line.TaxCodeID] = true
+			taxCodeIDs = append(taxCodeIDs, *line.TaxCodeID)
+		}
+	}
+
+	if len(taxCodeIDs) == 0 {
+		return lines, nil
+	}
+
+	if s.taxRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tax codes are not enabled")
+	}
+
+	taxCodes, err := s.taxRepo.GetByIDs(ctx, tenantID, taxCodeIDs)
+	if err != nil {
+		return nil, repositoryError("get tax codes", err)
+	}
+
+	taxAccountIDs := make([]uuid.UUID, 0, len(taxCodes))
+	for _, code := range taxCodes {
+		taxAccountIDs = append(taxAccountIDs, code.TaxAccountID)
+	}
+
+	currencies, err := s.accountRepo.AccountCurrencies(ctx, tenantID, taxAccountIDs)
+	if err != nil {
+		return nil, repositoryError("get account currencies", err)
+	}
+
+	result := lines
+	for i, line := range lines {
+		if line.TaxCodeID == nil {
+			continue
+		}
+
+		code, ok := taxCodes[*line.TaxCodeID]
+		if !ok {
+			return nil, invalidLine(i, "tax_code_id", "unknown tax code at line %d", i)
+		}
+		if !code.IsActive {
+			return nil, failedPrecondition(reasonInactiveTaxCode, fmt.Sprintf("lines[%d].tax_code_id", i),
+				fmt.Sprintf("tax code %s is inactive at line %d", code.Code, i), map[string]string{"tax_code": code.Code})
+		}
+
+		currency, ok := currencies[code.TaxAccountID]
+		if !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "tax account of tax code %s not found", code.Code)
+		}
+
+		debit := line.Debit.Mul(code.Rate).Round(currency.Precision)
+		credit := line.Credit.Mul(code.Rate).Round(currency.Precision)
+		if debit.IsZero() && credit.IsZero() {
+			continue
+		}
+
+		result = append(result, &repository.CreateJournalEntryLineParams{
+			AccountID:   code.TaxAccountID,
+			Debit:       debit,
+			Credit:      credit,
+			Description: code.Name,
+			TaxCodeID:   &code.ID,
+			IsTax:       true,
+			PartyID:     line.PartyID,
+			Dimensions:  line.Dimensions,
+		})
+	}
+
+	return result, nil
+}
+
+func parseTaxCodeIDs(tenantIDValue, taxCodeIDValue string) (uuid.UUID, uuid.UUID, error) {
+	tenantID, err := requestID("tenant_id", tenantIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	taxCodeID, err := requestID("tax_code_id", taxCodeIDValue)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return tenantID, taxCodeID, nil
+}
+
+func taxCodeParams(code, name, taxType, rateValue, taxAccountIDValue string, isActive bool) (repository.TaxCodeParams, error) {
+	params := repository.TaxCodeParams{
+		Code:     code,
+		Name:     name,
+		Type:     taxType,
+		IsActive: isActive,
+	}
+
+	if taxType != repository.TaxTypeSales && taxType != repository.TaxTypePurchase {
+		return params, status.Error(codes.InvalidArgument, "tax type must be SALES or PURCHASE")
+	}
+
+	rate, err := decimal.NewFromString(rateValue)
+	if err != nil || rate.IsNegative() {
+		return params, status.Error(codes.InvalidArgument, "invalid tax rate")
+	}
+	params.Rate = rate
+
+	taxAccountID, err := requestID("tax_account_id", taxAccountIDValue)
+	if err != nil {
+		return params, err
+	}
+	params.TaxAccountID = taxAccountID
+
+	return params, nil
+}
+
+func taxCodeToProto(code *repository.TaxCode) *pb.TaxCode {
+	return &pb.TaxCode{
+		TaxCodeId:    code.ID.String(),
+		TenantId:     code.TenantID.String(),
+		Code:         code.Code,
+		Name:         code.Name,
+		Type:         code.Type,
+		Rate:         code.Rate.String(),
+		TaxAccountId: code.TaxAccountID.String(),
+		IsActive:     code.IsActive,
+		CreatedAt:    timestamppb.New(code.CreatedAt),
+		UpdatedAt:    timestamppb.New(code.UpdatedAt),
+	}
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockTaxCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaxCodeRepository) Create(ctx context.Context, tenantID uuid.UUID, params repository.TaxCodeParams) (*repository.TaxCode, error) {
+	args := m.Called(ctx, tenantID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TaxCode), args.Error(1)
+}
+
+func (m *MockTaxCodeRepository) Update(ctx context.Context, tenantID uuid.UUID, taxCodeID uuid.UUID, params repository.TaxCodeParams) (*repository.TaxCode, error) {
+	args := m.Called(ctx, tenantID, taxCodeID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TaxCode), args.Error(1)
+}
+
+func (m *MockTaxCodeRepository) GetByID(ctx context.Context, tenantID uuid.UUID, taxCodeID uuid.UUID) (*repository.TaxCode, error) {
+	args := m.Called(ctx, tenantID, taxCodeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TaxCode), args.Error(1)
+}
+
+func (m *MockTaxCodeRepository) GetByIDs(ctx context.Context, tenantID uuid.UUID, taxCodeIDs []uuid.UUID) (map[uuid.UUID]*repository.TaxCode, error) {
+	args := m.Called(ctx, tenantID, taxCodeIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]*repository.TaxCode), args.Error(1)
+}
+
+func (m *MockTaxCodeRepository) List(ctx context.Context, tenantID uuid.UUID, includeInactive bool) ([]*repository.TaxCode, error) {
+	args := m.Called(ctx, tenantID, includeInactive)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.TaxCode), args.Error(1)
+}
+
+func (m *MockTaxCodeRepository) GetReport(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time) ([]*repository.TaxReportRow, error) {
+	args := m.Called(ctx, tenantID, fromDate, toDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.TaxReportRow), args.Error(1)
+}
+
+// Test CreateTaxCode
+func TestLedgerService_CreateTaxCode(t *testing.T) {
+	ctx := context.Background()
+	mockTaxRepo := new(MockTaxCodeRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithTaxCodeRepository(mockTaxRepo))
+
+	tenantID := uuid.New()
+	taxAccountID := uuid.New()
+
+	t.Run("creates an active tax code", func(t *testing.T) {
+		params := repository.TaxCodeParams{
+			Code:         "VAT20",
+			Name:         "VAT 20%",
+			Type:         repository.TaxTypeSales,
+			Rate:         decimal.RequireFromString("0.2"),
+			TaxAccountID: taxAccountID,
+			IsActive:     true,
+		}
+		mockTaxRepo.On("Create", ctx, tenantID, params).Return(&repository.TaxCode{
+			ID:           uuid.New(),
+			TenantID:     tenantID,
+			Code:         "VAT20",
+			Name:         "VAT 20%",
+			Type:         repository.TaxTypeSales,
+			Rate:         params.Rate,
+			TaxAccountID: taxAccountID,
+			IsActive:     true,
+		}, nil).Once()
+
+		resp, err := service.CreateTaxCode(ctx, &pb.CreateTaxCodeRequest{
+			TenantId:     tenantID.String(),
+			Code:         "VAT20",
+			Name:         "VAT 20%",
+			Type:         repository.TaxTypeSales,
+			Rate:         "0.2",
+			TaxAccountId: taxAccountID.String(),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "0.2", resp.TaxCode.Rate)
+		assert.True(t, resp.TaxCode.IsActive)
+		mockTaxRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns invalid argument for an unknown type", func(t *testing.T) {
+		resp, err := service.CreateTaxCode(ctx, &pb.CreateTaxCodeRequest{
+			TenantId:     tenantID.String(),
+			Code:         "VAT20",
+			Name:         "VAT 20%",
+			Type:         "EXCISE",
+			Rate:         "0.2",
+			TaxAccountId: taxAccountID.String(),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns invalid argument for a negative rate", func(t *testing.T) {
+		resp, err := service.CreateTaxCode(ctx, &pb.CreateTaxCodeRequest{
+			TenantId:     tenantID.String(),
+			Code:         "VAT20",
+			Name:         "VAT 20%",
+			Type:         repository.TaxTypeSales,
+			Rate:         "-0.2",
+			TaxAccountId: taxAccountID.String(),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("returns unimplemented when tax codes are disabled", func(t *testing.T) {
+		resp, err := NewLedgerService(nil, nil, nil, nil).CreateTaxCode(ctx, &pb.CreateTaxCodeRequest{
+			TenantId: tenantID.String(),
+		})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test tax line generation when posting
+func TestLedgerService_CreateJournalEntryWithTaxCode(t *testing.T) {
+	ctx := context.Background()
+	mockAccountRepo := new(MockAccountRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	mockTaxRepo := new(MockTaxCodeRepository)
+	service := NewLedgerService(nil, mockAccountRepo, mockJournalRepo, nil, WithTaxCodeRepository(mockTaxRepo))
+
+	tenantID := uuid.New()
+	receivableID, revenueID, vatID := uuid.New(), uuid.New(), uuid.New()
+	taxCode := &repository.TaxCode{
+		ID:           uuid.New(),
+		Code:         "VAT20",
+		Name:         "VAT 20%",
+		Type:         repository.TaxTypeSales,
+		Rate:         decimal.RequireFromString("0.2"),
+		TaxAccountID: vatID,
+		IsActive:     true,
+	}
+	taxCodeID := taxCode.ID.String()
+	now := time.Now()
+
+	request := func() *pb.CreateJournalEntryRequest {
+		return &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID.String(),
+			ReferenceNumber: "INV-001",
+			EntryDate:       timestamppb.New(now),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: receivableID.String(), Debit: "120.01", Credit: "0"},
+				{AccountId: revenueID.String(), Debit: "0", Credit: "100.01", TaxCodeId: &taxCodeID},
+			},
+		}
+	}
+
+	t.Run("generates a rounded tax line on the side of the taxed line", func(t *testing.T) {
+		mockTaxRepo.On("GetByIDs", ctx, tenantID, []uuid.UUID{taxCode.ID}).
+			Return(map[uuid.UUID]*repository.TaxCode{taxCode.ID: taxCode}, nil).Once()
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{vatID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{vatID: {CurrencyCode: "USD", Precision: 2}}, nil).Once()
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{receivableID, revenueID, vatID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{
+				receivableID: {CurrencyCode: "USD", Precision: 2},
+				revenueID:    {CurrencyCode: "USD", Precision: 2},
+				vatID:        {CurrencyCode: "USD", Precision: 2},
+			}, nil).Once()
+
+		mockJournalRepo.On("Create", ctx, tenantID, mock.MatchedBy(func(p repository.CreateJournalEntryParams) bool {
+			if len(p.Lines) != 3 {
+				return false
+			}
+			tax := p.Lines[2]
+			return tax.AccountID == vatID &&
+				tax.IsTax &&
+				*tax.TaxCodeID == taxCode.ID &&
+				tax.Credit.Equal(decimal.RequireFromString("20")) &&
+				tax.Debit.IsZero()
+		})).Return(&repository.JournalEntry{
+			ID:        uuid.New(),
+			TenantID:  tenantID,
+			EntryDate: now,
+			CreatedAt: now,
+		}, nil).Once()
+
+		_, err := service.CreateJournalEntry(ctx, request())
+
+		require.NoError(t, err)
+		mockTaxRepo.AssertExpectations(t)
+		mockAccountRepo.AssertExpectations(t)
+		mockJournalRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns failed precondition for an inactive tax code", func(t *testing.T) {
+		inactive := *taxCode
+		inactive.IsActive = false
+		mockTaxRepo.On("GetByIDs", ctx, tenantID, []uuid.UUID{taxCode.ID}).
+			Return(map[uuid.UUID]*repository.TaxCode{taxCode.ID: &inactive}, nil).Once()
+		mockAccountRepo.On("AccountCurrencies", ctx, tenantID, []uuid.UUID{vatID}).
+			Return(map[uuid.UUID]repository.AccountCurrency{vatID: {CurrencyCode: "USD", Precision: 2}}, nil).Once()
+
+		resp, err := service.CreateJournalEntry(ctx, request())
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("rejects submitted tax lines", func(t *testing.T) {
+		req := request()
+		req.Lines[1].IsTax = true
+
+		resp, err := service.CreateJournalEntry(ctx, req)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
+
+// Test GetTaxReport
+func TestLedgerService_GetTaxReport(t *testing.T) {
+	ctx := context.Background()
+	mockTaxRepo := new(MockTaxCodeRepository)
+	service := NewLedgerService(nil, nil, nil, nil, WithTaxCodeRepository(mockTaxRepo))
+
+	tenantID := uuid.New()
+	fromDate := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("summarises sales and purchase tax", func(t *testing.T) {
+		mockTaxRepo.On("GetReport", ctx, tenantID, fromDate, toDate).Return([]*repository.TaxReportRow{
+			{
+				TaxCode:       &repository.TaxCode{Code: "VAT20", Type: repository.TaxTypeSales},
+				TaxableDebit:  decimal.NewFromInt(50),
+				TaxableCredit: decimal.NewFromInt(1050),
+				TaxDebit:      decimal.NewFromInt(10),
+				TaxCredit:     decimal.NewFromInt(210),
+			},
+			{
+				TaxCode:      &repository.TaxCode{Code: "VAT20-IN", Type: repository.TaxTypePurchase},
+				TaxableDebit: decimal.NewFromInt(400),
+				TaxDebit:     decimal.NewFromInt(80),
+			},
+		}, nil).Once()
+
+		resp, err := service.GetTaxReport(ctx, &pb.GetTaxReportRequest{
+			TenantId: tenantID.String(),
+			FromDate: timestamppb.New(fromDate),
+			ToDate:   timestamppb.New(toDate),
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Lines, 2)
+		assert.Equal(t, "1000", resp.Lines[0].TaxableAmount)
+		assert.Equal(t, "200", resp.Lines[0].TaxAmount)
+		assert.Equal(t, "400", resp.Lines[1].TaxableAmount)
+		assert.Equal(t, "200", resp.TotalSalesTax)
+		assert.Equal(t, "80", resp.TotalPurchaseTax)
+		assert.Equal(t, "120", resp.NetTaxPayable)
+		mockTaxRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns invalid argument when the period is missing", func(t *testing.T) {
+		resp, err := service.GetTaxReport(ctx, &pb.GetTaxReportRequest{TenantId: tenantID.String()})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Nil(t, resp)
+	})
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hesabFun/ledger/internal/export"
+	"github.com/hesabFun/ledger/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// ExportTenantData starts a background job that archives the accounts,
+// journal entries and lines, balances and settings of a tenant, read from a
+// single snapshot, as CSV or JSON Lines files in one zip file. Deleted
+// tenants can be exported until their data is purged.
+func (s *AdminService) ExportTenantData(ctx context.Context, req *pb.ExportTenantDataRequest) (*pb.ExportTenantDataResponse, error) {
+	if s.exporter == nil {
+		return nil, status.Error(codes.Unimplemented, "data exports are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	var format export.Format
+	switch req.Format {
+	case pb.ExportFormat_EXPORT_FORMAT_CSV:
+		format = export.FormatCSV
+	case pb.ExportFormat_EXPORT_FORMAT_JSONL:
+		format = export.FormatJSONL
+	default:
+		return nil, status.Error(codes.InvalidArgument, "tenant data export format must be CSV or JSONL")
+	}
+
+	job, err := s.exporter.StartArchive(ctx, tenantID, format)
+	if err != nil {
+		return nil, repositoryError("start tenant data export", err)
+	}
+
+	return &pb.ExportTenantDataResponse{
+		Job: exportJobToProto(job),
+	}, nil
+}
+
+// GetTenantDataExport returns the status of a tenant data export
+func (s *AdminService) GetTenantDataExport(ctx context.Context, req *pb.GetTenantDataExportRequest) (*pb.GetTenantDataExportResponse, error) {
+	job, err := s.getTenantDataExport(ctx, req.TenantId, req.JobId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetTenantDataExportResponse{
+		Job: exportJobToProto(job),
+	}, nil
+}
+
+// DownloadTenantDataExport streams the archive of a completed tenant data
+// export
+func (s *AdminService) DownloadTenantDataExport(req *pb.DownloadTenantDataExportRequest, stream pb.AdminService_DownloadTenantDataExportServer) error {
+	ctx := stream.Context()
+
+	job, err := s.getTenantDataExport(ctx, req.TenantId, req.JobId)
+	if err != nil {
+		return err
+	}
+
+	if job.Status != repository.ExportJobCompleted || len(job.Files) == 0 {
+		return status.Error(codes.FailedPrecondition, "export job is not completed")
+	}
+
+	return sendExportFile(ctx, s.exporter, job.Files[0], func(chunk []byte) error {
+		return stream.Send(&pb.DownloadTenantDataExportResponse{Chunk: chunk})
+	})
+}
+
+// getTenantDataExport retrieves an export job, which must be a tenant data
+// export
+func (s *AdminService) getTenantDataExport(ctx context.Context, tenantIDStr, jobIDStr string) (*repository.ExportJob, error) {
+	if s.exporter == nil {
+		return nil, status.Error(codes.Unimplemented, "data exports are not enabled")
+	}
+
+	tenantID, jobID, err := parseExportJobIDs(tenantIDStr, jobIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.exporter.Get(ctx, tenantID, jobID)
+	if err != nil {
+		return nil, repositoryError("get export job", err)
+	}
+	if !job.Archive {
+		return nil, status.Error(codes.NotFound, "tenant data export not found")
+	}
+
+	return job, nil
+}
+
+// PurgeTenantData permanently deletes the data of a deleted tenant in two
+// steps: a request without a confirmation token issues one and reports what
+// would be deleted, and the same request with that token runs the purge
+func (s *AdminService) PurgeTenantData(ctx context.Context, req *pb.PurgeTenantDataRequest) (*pb.PurgeTenantDataResponse, error) {
+	if s.tenantDataRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tenant data purges are not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetConfirmationToken() == "" {
+		request, err := s.tenantDataRepo.RequestPurge(ctx, tenantID)
+		if err != nil {
+			return nil, repositoryError("request tenant data purge", err)
+		}
+
+		return &pb.PurgeTenantDataResponse{
+			ConfirmationToken: &request.Token,
+			ExpiresAt:         timestamppb.New(request.ExpiresAt),
+			Rows:              tableRowCountsToProto(request.Rows),
+		}, nil
+	}
+
+	purge, err := s.tenantDataRepo.Purge(ctx, tenantID, req.GetConfirmationToken())
+	if err != nil {
+		return nil, repositoryError("purge tenant data", err)
+	}
+
+	return &pb.PurgeTenantDataResponse{
+		Purged:   true,
+		PurgedAt: timestamppb.New(purge.PurgedAt),
+		Rows:     tableRowCountsToProto(purge.Rows),
+	}, nil
+}
+
+// cloneModes maps clone modes to the repository's
+var cloneModes = map[pb.CloneMode]string{
+	pb.CloneMode_CLONE_MODE_CHART:    repository.CloneChart,
+	pb.CloneMode_CLONE_MODE_BALANCES: repository.CloneBalances,
+	pb.CloneMode_CLONE_MODE_HISTORY:  repository.CloneHistory,
+}
+
+// CloneTenant copies a tenant's chart of accounts, optionally with its
+// balances or its full journal, into a new tenant flagged as test, so
+// integrators can work against realistic data without touching the source
+func (s *AdminService) CloneTenant(ctx context.Context, req *pb.CloneTenantRequest) (*pb.CloneTenantResponse, error) {
+	if s.tenantDataRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "tenant cloning is not enabled")
+	}
+
+	sourceID, err := requestID("source_tenant_id", req.SourceTenantId)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, invalidField("name", "tenant name is required")
+	}
+	mode, ok := cloneModes[req.Mode]
+	if !ok {
+		return nil, invalidField("mode", "clone mode must be CHART, BALANCES or HISTORY")
+	}
+
+	clone, err := s.tenantDataRepo.Clone(ctx, repository.CloneTenantParams{
+		SourceTenantID: sourceID,
+		Name:           req.Name,
+		Mode:           mode,
+	})
+	if err != nil {
+		return nil, repositoryError("clone tenant", err)
+	}
+
+	return &pb.CloneTenantResponse{
+		Tenant:               tenantToProto(clone.Tenant),
+		AccountsCopied:       int32(clone.Accounts),
+		JournalEntriesPosted: int32(clone.JournalEntries),
+	}, nil
+}
+
+// tableRowCountsToProto lists row counts by table name
+func tableRowCountsToProto(rows map[string]int64) []*pb.TableRowCount {
+	counts := make([]*pb.TableRowCount, 0, len(rows))
+	for table, n := range rows {
+		counts = append(counts, &pb.TableRowCount{Table: table, Rows: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Table < counts[j].Table
+	})
+	return counts
+}
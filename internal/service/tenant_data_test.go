@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/export"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+type MockTenantDataRepository struct {
+	mock.Mock
+}
+
+func (m *MockTenantDataRepository) Snapshot(ctx context.Context, tenantID uuid.UUID, fn func(repository.TenantSnapshot) error) error {
+	args := m.Called(ctx, tenantID, fn)
+	return args.Error(0)
+}
+
+func (m *MockTenantDataRepository) RequestPurge(ctx context.Context, tenantID uuid.UUID) (*repository.TenantPurgeRequest, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TenantPurgeRequest), args.Error(1)
+}
+
+func (m *MockTenantDataRepository) Purge(ctx context.Context, tenantID uuid.UUID, token string) (*repository.TenantPurge, error) {
+	args := m.Called(ctx, tenantID, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TenantPurge), args.Error(1)
+}
+
+func (m *MockTenantDataRepository) PurgeExpiredTest(ctx context.Context, tenantID uuid.UUID, before time.Time) (*repository.TenantPurge, error) {
+	args := m.Called(ctx, tenantID, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TenantPurge), args.Error(1)
+}
+
+func (m *MockTenantDataRepository) Clone(ctx context.Context, params repository.CloneTenantParams) (*repository.TenantClone, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TenantClone), args.Error(1)
+}
+
+// Test PurgeTenantData
+func TestAdminService_PurgeTenantData(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockTenantDataRepository)
+	service := NewAdminService(nil, nil, nil, WithTenantDataRepository(mockRepo))
+	tenantID := uuid.New()
+
+	t.Run("issues a confirmation token without purging", func(t *testing.T) {
+		expiresAt := time.Now().Add(repository.PurgeTokenTTL)
+		mockRepo.On("RequestPurge", ctx, tenantID).Return(&repository.TenantPurgeRequest{
+			TenantID:  tenantID,
+			Token:     "token",
+			ExpiresAt: expiresAt,
+			Rows:      map[string]int64{"journal_entries": 2, "accounts": 3},
+		}, nil).Once()
+
+		resp, err := service.PurgeTenantData(ctx, &pb.PurgeTenantDataRequest{TenantId: tenantID.String()})
+
+		require.NoError(t, err)
+		assert.False(t, resp.Purged)
+		assert.Equal(t, "token", resp.GetConfirmationToken())
+		assert.Equal(t, expiresAt.Unix(), resp.ExpiresAt.AsTime().Unix())
+		require.Len(t, resp.Rows, 2)
+		assert.Equal(t, "accounts", resp.Rows[0].Table)
+		assert.Equal(t, int64(3), resp.Rows[0].Rows)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("purges with the confirmation token", func(t *testing.T) {
+		token := "token"
+		mockRepo.On("Purge", ctx, tenantID, token).Return(&repository.TenantPurge{
+			TenantID: tenantID,
+			PurgedAt: time.Now(),
+			Rows:     map[string]int64{"accounts": 3},
+		}, nil).Once()
+
+		resp, err := service.PurgeTenantData(ctx, &pb.PurgeTenantDataRequest{
+			TenantId:          tenantID.String(),
+			ConfirmationToken: &token,
+		})
+
+		require.NoError(t, err)
+		assert.True(t, resp.Purged)
+		assert.NotNil(t, resp.PurgedAt)
+		assert.Nil(t, resp.ConfirmationToken)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("reports an invalid token as a failed precondition", func(t *testing.T) {
+		token := "stale"
+		mockRepo.On("Purge", ctx, tenantID, token).Return(nil, repository.ErrPurgeTokenInvalid).Once()
+
+		_, err := service.PurgeTenantData(ctx, &pb.PurgeTenantDataRequest{
+			TenantId:          tenantID.String(),
+			ConfirmationToken: &token,
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, reasonPurgeTokenInvalid, errorReason(t, err))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("refuses tenants that are not deleted", func(t *testing.T) {
+		mockRepo.On("RequestPurge", ctx, tenantID).Return(nil, repository.ErrTenantNotDeleted).Once()
+
+		_, err := service.PurgeTenantData(ctx, &pb.PurgeTenantDataRequest{TenantId: tenantID.String()})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns unimplemented when purges are disabled", func(t *testing.T) {
+		_, err := NewAdminService(nil, nil, nil).PurgeTenantData(ctx, &pb.PurgeTenantDataRequest{TenantId: tenantID.String()})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
+
+// Test ExportTenantData
+func TestAdminService_ExportTenantData(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejects the parquet format", func(t *testing.T) {
+		exporter := export.NewExporter(nil, nil, new(MockExportJobRepository), nil, export.NewDirStore(t.TempDir()))
+		service := NewAdminService(nil, nil, nil, WithExporter(exporter))
+
+		_, err := service.ExportTenantData(ctx, &pb.ExportTenantDataRequest{
+			TenantId: uuid.New().String(),
+			Format:   pb.ExportFormat_EXPORT_FORMAT_PARQUET,
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("hides ledger exports", func(t *testing.T) {
+		mockJobRepo := new(MockExportJobRepository)
+		exporter := export.NewExporter(nil, nil, mockJobRepo, nil, export.NewDirStore(t.TempDir()))
+		service := NewAdminService(nil, nil, nil, WithExporter(exporter))
+		tenantID, jobID := uuid.New(), uuid.New()
+
+		mockJobRepo.On("GetByID", ctx, tenantID, jobID).Return(&repository.ExportJob{
+			ID:       jobID,
+			TenantID: tenantID,
+			Status:   repository.ExportJobCompleted,
+		}, nil).Once()
+
+		_, err := service.GetTenantDataExport(ctx, &pb.GetTenantDataExportRequest{
+			TenantId: tenantID.String(),
+			JobId:    jobID.String(),
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		mockJobRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns unimplemented when exports are disabled", func(t *testing.T) {
+		_, err := NewAdminService(nil, nil, nil).ExportTenantData(ctx, &pb.ExportTenantDataRequest{
+			TenantId: uuid.New().String(),
+			Format:   pb.ExportFormat_EXPORT_FORMAT_JSONL,
+		})
+
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
+
+// Test CloneTenant
+func TestAdminService_CloneTenant(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockTenantDataRepository)
+	service := NewAdminService(nil, nil, nil, WithTenantDataRepository(mockRepo))
+	sourceID := uuid.New()
+
+	t.Run("clones into a test tenant", func(t *testing.T) {
+		params := repository.CloneTenantParams{SourceTenantID: sourceID, Name: "Acme Sandbox", Mode: repository.CloneBalances}
+		mockRepo.On("Clone", ctx, params).Return(&repository.TenantClone{
+			Tenant: &repository.Tenant{
+				ID:           uuid.New(),
+				Name:         "Acme Sandbox",
+				Status:       repository.TenantStatusActive,
+				IsTest:       true,
+				ClonedFromID: &sourceID,
+			},
+			Accounts:       12,
+			JournalEntries: 1,
+		}, nil).Once()
+
+		resp, err := service.CloneTenant(ctx, &pb.CloneTenantRequest{
+			SourceTenantId: sourceID.String(),
+			Name:           "Acme Sandbox",
+			Mode:           pb.CloneMode_CLONE_MODE_BALANCES,
+		})
+
+		require.NoError(t, err)
+		assert.True(t, resp.Tenant.Test)
+		assert.Equal(t, sourceID.String(), resp.Tenant.GetClonedFromTenantId())
+		assert.Equal(t, int32(12), resp.AccountsCopied)
+		assert.Equal(t, int32(1), resp.JournalEntriesPosted)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("requires a mode", func(t *testing.T) {
+		_, err := service.CloneTenant(ctx, &pb.CloneTenantRequest{
+			SourceTenantId: sourceID.String(),
+			Name:           "Acme Sandbox",
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("returns not found for an unknown source", func(t *testing.T) {
+		params := repository.CloneTenantParams{SourceTenantID: sourceID, Name: "Acme Sandbox", Mode: repository.CloneChart}
+		mockRepo.On("Clone", ctx, params).Return(nil, fmt.Errorf("tenant %w", repository.ErrNotFound)).Once()
+
+		_, err := service.CloneTenant(ctx, &pb.CloneTenantRequest{
+			SourceTenantId: sourceID.String(),
+			Name:           "Acme Sandbox",
+			Mode:           pb.CloneMode_CLONE_MODE_CHART,
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		mockRepo.AssertExpectations(t)
+	})
+}
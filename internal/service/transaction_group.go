@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// GetTransactionGroup retrieves the journal entries posted under a business
+// transaction ID together with their totals
+func (s *LedgerService) GetTransactionGroup(ctx context.Context, req *pb.GetTransactionGroupRequest) (*pb.GetTransactionGroupResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TransactionId == "" {
+		return nil, invalidField("transaction_id", "transaction ID is required")
+	}
+
+	entries, err := s.journalRepo.ListByTransactionID(ctx, tenantID, req.TransactionId)
+	if err != nil {
+		return nil, repositoryError("list journal entries", err)
+	}
+	if len(entries) == 0 {
+		return nil, repositoryError("list journal entries", fmt.Errorf("transaction group %w", repository.ErrNotFound))
+	}
+
+	resp := &pb.GetTransactionGroupResponse{
+		TransactionId: req.TransactionId,
+		Entries:       make([]*pb.JournalEntry, len(entries)),
+	}
+
+	type accountTotal struct {
+		debit, credit decimal.Decimal
+	}
+	totals := make(map[uuid.UUID]*accountTotal)
+	var accountIDs []uuid.UUID
+	totalAmount := decimal.Zero
+
+	for i, entry := range entries {
+		resp.Entries[i] = journalEntryToProto(entry)
+		for _, line := range entry.Lines {
+			total, ok := totals[line.AccountID]
+			if !ok {
+				total = &accountTotal{debit: decimal.Zero, credit: decimal.Zero}
+				totals[line.AccountID] = total
+				accountIDs = append(accountIDs, line.AccountID)
+			}
+			total.debit = total.debit.Add(line.Debit)
+			total.credit = total.credit.Add(line.Credit)
+			totalAmount = totalAmount.Add(line.Debit)
+		}
+	}
+
+	resp.TotalAmount = totalAmount.String()
+	resp.AccountTotals = make([]*pb.TransactionGroupAccountTotal, len(accountIDs))
+	for i, accountID := range accountIDs {
+		total := totals[accountID]
+		resp.AccountTotals[i] = &pb.TransactionGroupAccountTotal{
+			AccountId:   accountID.String(),
+			TotalDebit:  total.debit.String(),
+			TotalCredit: total.credit.String(),
+			NetAmount:   total.debit.Sub(total.credit).String(),
+		}
+	}
+
+	return resp, nil
+}
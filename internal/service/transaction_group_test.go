@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+func TestLedgerService_GetTransactionGroup(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "orders", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	createAccount := func(number string, accountTypeID int32) string {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: accountTypeID,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(t, err)
+		return resp.AccountId
+	}
+	cash := createAccount("1000", 1)
+	sales := createAccount("4000", 4)
+	fees := createAccount("5000", 5)
+
+	orderID := "order-42"
+	post := func(reference, debitAccount, creditAccount, amount string, transactionID *string) {
+		_, err := service.CreateJournalEntry(ctx, &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID,
+			ReferenceNumber: reference,
+			Description:     reference,
+			EntryDate:       timestamppb.Now(),
+			TransactionId:   transactionID,
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: debitAccount, Debit: amount, Credit: "0"},
+				{AccountId: creditAccount, Debit: "0", Credit: amount},
+			},
+		})
+		require.NoError(t, err)
+	}
+	post("SALE-42", cash, sales, "100", &orderID)
+	post("FEE-42", fees, cash, "2.5", &orderID)
+	post("SALE-43", cash, sales, "40", nil)
+
+	t.Run("returns the entries of the transaction with their totals", func(t *testing.T) {
+		resp, err := service.GetTransactionGroup(ctx, &pb.GetTransactionGroupRequest{TenantId: tenantID, TransactionId: orderID})
+		require.NoError(t, err)
+
+		assert.Equal(t, orderID, resp.TransactionId)
+		require.Len(t, resp.Entries, 2)
+		assert.Equal(t, "SALE-42", resp.Entries[0].ReferenceNumber)
+		assert.Equal(t, "FEE-42", resp.Entries[1].ReferenceNumber)
+		assert.Equal(t, "102.5", resp.TotalAmount)
+
+		require.Len(t, resp.AccountTotals, 3)
+		assert.Equal(t, cash, resp.AccountTotals[0].AccountId)
+		assert.Equal(t, "100", resp.AccountTotals[0].TotalDebit)
+		assert.Equal(t, "2.5", resp.AccountTotals[0].TotalCredit)
+		assert.Equal(t, "97.5", resp.AccountTotals[0].NetAmount)
+		assert.Equal(t, sales, resp.AccountTotals[1].AccountId)
+		assert.Equal(t, "-100", resp.AccountTotals[1].NetAmount)
+		assert.Equal(t, fees, resp.AccountTotals[2].AccountId)
+		assert.Equal(t, "2.5", resp.AccountTotals[2].NetAmount)
+	})
+
+	t.Run("groups transfers", func(t *testing.T) {
+		refundID := "refund-42"
+		_, err := service.Transfer(ctx, &pb.TransferRequest{
+			TenantId:             tenantID,
+			SourceAccountId:      cash,
+			DestinationAccountId: sales,
+			Amount:               "10",
+			ReferenceNumber:      "REFUND-42",
+			TransactionId:        &refundID,
+		})
+		require.NoError(t, err)
+
+		resp, err := service.GetTransactionGroup(ctx, &pb.GetTransactionGroupRequest{TenantId: tenantID, TransactionId: refundID})
+		require.NoError(t, err)
+		require.Len(t, resp.Entries, 1)
+		assert.Equal(t, "10", resp.TotalAmount)
+	})
+
+	t.Run("returns not found for an unknown transaction", func(t *testing.T) {
+		_, err := service.GetTransactionGroup(ctx, &pb.GetTransactionGroupRequest{TenantId: tenantID, TransactionId: "order-0"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		for _, req := range []*pb.GetTransactionGroupRequest{
+			{TenantId: "invalid", TransactionId: orderID},
+			{TenantId: tenantID},
+		} {
+			_, err := service.GetTransactionGroup(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+	})
+}
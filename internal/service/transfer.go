@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// Transfer posts a two-line entry crediting the source account and debiting
+// the destination account, adding conversion lines between accounts of
+// different currencies. A request repeating the idempotency key of an earlier
+// transfer returns the entry that transfer posted.
+func (s *LedgerService) Transfer(ctx context.Context, req *pb.TransferRequest) (*pb.TransferResponse, error) {
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceID, err := requestID("source_account_id", req.SourceAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	destinationID, err := requestID("destination_account_id", req.DestinationAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if sourceID == destinationID {
+		return nil, invalidField("destination_account_id", "destination account must differ from the source account")
+	}
+
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil || !amount.IsPositive() {
+		return nil, invalidField("amount", "amount must be a positive number")
+	}
+
+	var fxRate *decimal.Decimal
+	if req.FxRate != nil && *req.FxRate != "" {
+		rate, err := decimal.NewFromString(*req.FxRate)
+		if err != nil || !rate.IsPositive() {
+			return nil, invalidField("fx_rate", "fx rate must be a positive number")
+		}
+		fxRate = &rate
+	}
+
+	key := req.GetIdempotencyKey()
+	if key != "" {
+		resp, err := s.previousTransfer(ctx, tenantID, key, sourceID, destinationID, amount, fxRate)
+		if resp != nil || err != nil {
+			return resp, err
+		}
+	}
+
+	entryDate := req.EntryDate
+	if entryDate == nil {
+		entryDate = timestamppb.Now()
+	}
+
+	lines, currencyCode, err := s.transferLines(ctx, tenantID, sourceID, destinationID, amount, fxRate, req.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := s.createJournalEntry(ctx, tenantID, &pb.CreateJournalEntryRequest{
+		TenantId:        req.TenantId,
+		ReferenceNumber: req.ReferenceNumber,
+		Description:     req.Description,
+		EntryDate:       entryDate,
+		Metadata:        req.Metadata,
+		TransactionId:   req.TransactionId,
+		CurrencyCode:    currencyCode,
+		Lines:           lines,
+	}, key)
+	if err != nil {
+		// A concurrent request with the same key may have posted first
+		if key != "" && status.Code(err) == codes.AlreadyExists {
+			resp, prevErr := s.previousTransfer(ctx, tenantID, key, sourceID, destinationID, amount, fxRate)
+			if resp != nil || prevErr != nil {
+				return resp, prevErr
+			}
+		}
+		return nil, err
+	}
+
+	return &pb.TransferResponse{
+		JournalEntry: journalEntryToProto(entry),
+	}, nil
+}
+
+// transferLines builds the lines of a transfer and the currency of its entry.
+// Between accounts of different currencies the amount moves through the
+// tenant's conversion account of each currency: the source currency one takes
+// the amount from the source account and the destination currency one pays
+// out the amount converted at the rate and rounded to the destination
+// currency, so the lines of each currency balance on their own. The entry is
+// in the source currency and its destination currency lines carry the rate.
+// Unknown accounts are left for the repository to report.
+func (s *LedgerService) transferLines(ctx context.Context, tenantID, sourceID, destinationID uuid.UUID, amount decimal.Decimal, fxRate *decimal.Decimal, description string) ([]*pb.JournalEntryLine, *string, error) {
+	lines := []*pb.JournalEntryLine{
+		{AccountId: destinationID.String(), Debit: amount.String(), Credit: "0", Description: description},
+		{AccountId: sourceID.String(), Debit: "0", Credit: amount.String(), Description: description},
+	}
+
+	currencies, err := s.accountRepo.AccountCurrencies(ctx, tenantID, []uuid.UUID{sourceID, destinationID})
+	if err != nil {
+		return nil, nil, repositoryError("get account currencies", err)
+	}
+
+	source, sourceFound := currencies[sourceID]
+	destination, destinationFound := currencies[destinationID]
+	if !sourceFound || !destinationFound {
+		return lines, nil, nil
+	}
+
+	if source.CurrencyCode == destination.CurrencyCode {
+		if fxRate != nil {
+			return nil, nil, invalidField("fx_rate", "fx_rate is only allowed between accounts of different currencies")
+		}
+		return lines, nil, nil
+	}
+
+	if fxRate == nil {
+		return nil, nil, invalidField("fx_rate", fmt.Sprintf(
+			"fx_rate is required to transfer from %s to %s", source.CurrencyCode, destination.CurrencyCode))
+	}
+
+	var conversionAccounts map[string]uuid.UUID
+	if s.settingsRepo != nil {
+		settings, err := s.settingsRepo.Get(ctx, tenantID)
+		if err != nil {
+			return nil, nil, repositoryError("get tenant settings", err)
+		}
+		conversionAccounts = settings.FxConversionAccounts
+	}
+
+	for _, currencyCode := range []string{source.CurrencyCode, destination.CurrencyCode} {
+		if _, ok := conversionAccounts[currencyCode]; !ok {
+			return nil, nil, failedPrecondition(reasonFxAccountMissing, "fx_conversion_accounts",
+				fmt.Sprintf("no conversion account is configured for %s", currencyCode), map[string]string{
+					"currency_code": currencyCode,
+				})
+		}
+	}
+
+	converted := amount.Mul(*fxRate).Round(destination.Precision)
+	if !converted.IsPositive() {
+		return nil, nil, invalidField("amount", fmt.Sprintf(
+			"amount converted at fx_rate rounds to zero in %s", destination.CurrencyCode))
+	}
+
+	rate := fxRate.String()
+	lines = []*pb.JournalEntryLine{
+		{AccountId: destinationID.String(), Debit: converted.String(), Credit: "0", Description: description, FxRate: &rate},
+		{AccountId: conversionAccounts[destination.CurrencyCode].String(), Debit: "0", Credit: converted.String(), Description: description, FxRate: &rate},
+		{AccountId: conversionAccounts[source.CurrencyCode].String(), Debit: amount.String(), Credit: "0", Description: description},
+		{AccountId: sourceID.String(), Debit: "0", Credit: amount.String(), Description: description},
+	}
+
+	return lines, &source.CurrencyCode, nil
+}
+
+// previousTransfer returns the transfer posted earlier with an idempotency
+// key, or nil when the key has not been used. A key used for a different
+// transfer is rejected.
+func (s *LedgerService) previousTransfer(ctx context.Context, tenantID uuid.UUID, key string, sourceID, destinationID uuid.UUID, amount decimal.Decimal, fxRate *decimal.Decimal) (*pb.TransferResponse, error) {
+	entry, err := s.journalRepo.GetByIdempotencyKey(ctx, tenantID, key)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, repositoryError("get journal entry", err)
+	}
+
+	if !isTransfer(entry, sourceID, destinationID, amount, fxRate) {
+		return nil, failedPrecondition(reasonIdempotencyKeyReused, "idempotency_key",
+			"idempotency key was already used for a different transfer", map[string]string{
+				"journal_entry_id": entry.ID.String(),
+			})
+	}
+
+	return &pb.TransferResponse{
+		JournalEntry: journalEntryToProto(entry),
+		Replayed:     true,
+	}, nil
+}
+
+// isTransfer reports whether an entry moves amount from the source to the
+// destination account, converted at fxRate when set, and nothing else
+func isTransfer(entry *repository.JournalEntry, sourceID, destinationID uuid.UUID, amount decimal.Decimal, fxRate *decimal.Decimal) bool {
+	// A cross-currency transfer adds two conversion lines
+	if len(entry.Lines) != 2 && len(entry.Lines) != 4 {
+		return false
+	}
+
+	var debited, credited bool
+	for _, line := range entry.Lines {
+		switch {
+		case line.AccountID == destinationID && line.Credit.IsZero() && sameRate(line.FxRate, fxRate):
+			// The converted amount was rounded to the destination currency
+			debited = fxRate != nil || line.Debit.Equal(amount)
+		case line.AccountID == sourceID && line.Credit.Equal(amount) && line.Debit.IsZero():
+			credited = true
+		}
+	}
+	return debited && credited
+}
+
+// sameRate reports whether two optional rates are both unset or equal
+func sameRate(a, b *decimal.Decimal) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
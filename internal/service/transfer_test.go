@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// lateJournalRepository hides idempotency keys from the first lookup, as if
+// a concurrent request posted between the lookup and the insert
+type lateJournalRepository struct {
+	repository.JournalRepositoryInterface
+	lookups int
+}
+
+func (r *lateJournalRepository) GetByIdempotencyKey(ctx context.Context, tenantID uuid.UUID, key string) (*repository.JournalEntry, error) {
+	r.lookups++
+	if r.lookups == 1 {
+		return nil, fmt.Errorf("journal entry %w", repository.ErrNotFound)
+	}
+	return r.JournalRepositoryInterface.GetByIdempotencyKey(ctx, tenantID, key)
+}
+
+// recordingJournalRepository keeps the parameters of the last entry created
+type recordingJournalRepository struct {
+	repository.JournalRepositoryInterface
+	params repository.CreateJournalEntryParams
+}
+
+func (r *recordingJournalRepository) Create(ctx context.Context, tenantID uuid.UUID, params repository.CreateJournalEntryParams) (*repository.JournalEntry, error) {
+	r.params = params
+	return r.JournalRepositoryInterface.Create(ctx, tenantID, params)
+}
+
+func TestLedgerService_Transfer(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	newService := func(journalRepo repository.JournalRepositoryInterface) *LedgerService {
+		return NewLedgerService(
+			memory.NewTenantRepository(store),
+			memory.NewAccountRepository(store),
+			journalRepo,
+			memory.NewReferenceRepository(store),
+		)
+	}
+	service := newService(memory.NewJournalRepository(store))
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "transfer", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	createAccount := func(number, currency string) string {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: number,
+			Name:          "Wallet " + number,
+			AccountTypeId: 2,
+			CurrencyCode:  currency,
+		})
+		require.NoError(t, err)
+		return resp.AccountId
+	}
+	alice := createAccount("2001", "USD")
+	bob := createAccount("2002", "USD")
+	euros := createAccount("2003", "EUR")
+
+	netBalance := func(accountID string) string {
+		resp, err := service.GetAccountBalance(ctx, &pb.GetAccountBalanceRequest{TenantId: tenantID, AccountId: accountID})
+		require.NoError(t, err)
+		return resp.NetBalance
+	}
+
+	transfer := func(key, amount string) *pb.TransferRequest {
+		return &pb.TransferRequest{
+			TenantId:             tenantID,
+			SourceAccountId:      alice,
+			DestinationAccountId: bob,
+			Amount:               amount,
+			ReferenceNumber:      "TRF-" + key,
+			Description:          "Payout",
+			IdempotencyKey:       &key,
+		}
+	}
+
+	t.Run("posts a balanced two-line entry", func(t *testing.T) {
+		resp, err := service.Transfer(ctx, &pb.TransferRequest{
+			TenantId:             tenantID,
+			SourceAccountId:      alice,
+			DestinationAccountId: bob,
+			Amount:               "12.50",
+			ReferenceNumber:      "TRF-1",
+		})
+		require.NoError(t, err)
+
+		assert.False(t, resp.Replayed)
+		require.Len(t, resp.JournalEntry.Lines, 2)
+		assert.Equal(t, bob, resp.JournalEntry.Lines[0].AccountId)
+		assert.Equal(t, "12.5", resp.JournalEntry.Lines[0].Debit)
+		assert.Equal(t, alice, resp.JournalEntry.Lines[1].AccountId)
+		assert.Equal(t, "12.5", resp.JournalEntry.Lines[1].Credit)
+		assert.Equal(t, "-12.5", netBalance(alice))
+		assert.Equal(t, "12.5", netBalance(bob))
+	})
+
+	t.Run("returns the original entry for a repeated idempotency key", func(t *testing.T) {
+		first, err := service.Transfer(ctx, transfer("payout-1", "5"))
+		require.NoError(t, err)
+
+		second, err := service.Transfer(ctx, transfer("payout-1", "5.00"))
+		require.NoError(t, err)
+
+		assert.True(t, second.Replayed)
+		assert.Equal(t, first.JournalEntry.JournalEntryId, second.JournalEntry.JournalEntryId)
+		assert.Equal(t, "17.5", netBalance(bob))
+	})
+
+	t.Run("rejects an idempotency key reused for another transfer", func(t *testing.T) {
+		_, err := service.Transfer(ctx, transfer("payout-2", "1"))
+		require.NoError(t, err)
+
+		_, err = service.Transfer(ctx, transfer("payout-2", "2"))
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("replays a transfer posted concurrently with the same key", func(t *testing.T) {
+		first, err := service.Transfer(ctx, transfer("payout-3", "3"))
+		require.NoError(t, err)
+
+		late := newService(&lateJournalRepository{JournalRepositoryInterface: memory.NewJournalRepository(store)})
+		second, err := late.Transfer(ctx, transfer("payout-3", "3"))
+		require.NoError(t, err)
+
+		assert.True(t, second.Replayed)
+		assert.Equal(t, first.JournalEntry.JournalEntryId, second.JournalEntry.JournalEntryId)
+	})
+
+	t.Run("rejects accounts of different currencies without an fx rate", func(t *testing.T) {
+		_, err := service.Transfer(ctx, &pb.TransferRequest{
+			TenantId:             tenantID,
+			SourceAccountId:      alice,
+			DestinationAccountId: euros,
+			Amount:               "1",
+			ReferenceNumber:      "TRF-FX",
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("converts between currencies through the conversion accounts", func(t *testing.T) {
+		usdConversion := createAccount("2004", "USD")
+		eurConversion := createAccount("2005", "EUR")
+
+		mockSettingsRepo := new(MockTenantSettingsRepository)
+		mockSettingsRepo.On("Get", ctx, tenant.ID).Return(&repository.TenantSettings{
+			TenantID: tenant.ID,
+			FxConversionAccounts: map[string]uuid.UUID{
+				"USD": uuid.MustParse(usdConversion),
+				"EUR": uuid.MustParse(eurConversion),
+			},
+		}, nil).Twice()
+		journalRepo := &recordingJournalRepository{JournalRepositoryInterface: memory.NewJournalRepository(store)}
+		fxService := NewLedgerService(
+			memory.NewTenantRepository(store),
+			memory.NewAccountRepository(store),
+			journalRepo,
+			memory.NewReferenceRepository(store),
+			WithTenantSettingsRepository(mockSettingsRepo),
+		)
+
+		resp, err := fxService.Transfer(ctx, &pb.TransferRequest{
+			TenantId:             tenantID,
+			SourceAccountId:      alice,
+			DestinationAccountId: euros,
+			Amount:               "10",
+			ReferenceNumber:      "TRF-FX-1",
+			FxRate:               stringPtr("0.9137"),
+		})
+		require.NoError(t, err)
+
+		// The euro lines carry the amount converted and rounded to cents
+		lines := resp.JournalEntry.Lines
+		require.Len(t, lines, 4)
+		assert.Equal(t, euros, lines[0].AccountId)
+		assert.Equal(t, "9.14", lines[0].Debit)
+		assert.Equal(t, eurConversion, lines[1].AccountId)
+		assert.Equal(t, "9.14", lines[1].Credit)
+		assert.Equal(t, usdConversion, lines[2].AccountId)
+		assert.Equal(t, "10", lines[2].Debit)
+		assert.Equal(t, alice, lines[3].AccountId)
+		assert.Equal(t, "10", lines[3].Credit)
+
+		posted := journalRepo.params.Lines
+		require.NotNil(t, posted[0].FxRate)
+		assert.Equal(t, "0.9137", posted[0].FxRate.String())
+		require.NotNil(t, posted[1].FxRate)
+		assert.Equal(t, "0.9137", posted[1].FxRate.String())
+		assert.Nil(t, posted[2].FxRate)
+		assert.Nil(t, posted[3].FxRate)
+
+		balance, err := fxService.GetAccountBalance(ctx, &pb.GetAccountBalanceRequest{TenantId: tenantID, AccountId: euros})
+		require.NoError(t, err)
+		assert.Equal(t, "9.14", balance.DebitBalance)
+		mockSettingsRepo.AssertExpectations(t)
+	})
+
+	t.Run("requires a conversion account for each currency", func(t *testing.T) {
+		mockSettingsRepo := new(MockTenantSettingsRepository)
+		mockSettingsRepo.On("Get", ctx, tenant.ID).Return(&repository.TenantSettings{TenantID: tenant.ID}, nil).Once()
+		fxService := NewLedgerService(
+			memory.NewTenantRepository(store),
+			memory.NewAccountRepository(store),
+			memory.NewJournalRepository(store),
+			memory.NewReferenceRepository(store),
+			WithTenantSettingsRepository(mockSettingsRepo),
+		)
+
+		_, err := fxService.Transfer(ctx, &pb.TransferRequest{
+			TenantId:             tenantID,
+			SourceAccountId:      alice,
+			DestinationAccountId: euros,
+			Amount:               "10",
+			ReferenceNumber:      "TRF-FX-2",
+			FxRate:               stringPtr("0.9"),
+		})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockSettingsRepo.AssertExpectations(t)
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		for _, req := range []*pb.TransferRequest{
+			{TenantId: "invalid", SourceAccountId: alice, DestinationAccountId: bob, Amount: "1"},
+			{TenantId: tenantID, SourceAccountId: "invalid", DestinationAccountId: bob, Amount: "1"},
+			{TenantId: tenantID, SourceAccountId: alice, DestinationAccountId: "invalid", Amount: "1"},
+			{TenantId: tenantID, SourceAccountId: alice, DestinationAccountId: alice, Amount: "1"},
+			{TenantId: tenantID, SourceAccountId: alice, DestinationAccountId: bob, Amount: "0"},
+			{TenantId: tenantID, SourceAccountId: alice, DestinationAccountId: bob, Amount: "-1"},
+			{TenantId: tenantID, SourceAccountId: alice, DestinationAccountId: bob, Amount: "abc"},
+			{TenantId: tenantID, SourceAccountId: alice, DestinationAccountId: bob, Amount: "1", FxRate: stringPtr("0.9")},
+			{TenantId: tenantID, SourceAccountId: alice, DestinationAccountId: euros, Amount: "1", FxRate: stringPtr("0")},
+		} {
+			_, err := service.Transfer(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+	})
+}
+
+func TestIsTransfer(t *testing.T) {
+	sourceID, destinationID := uuid.New(), uuid.New()
+	amount := decimal.NewFromInt(10)
+	rate := decimal.RequireFromString("0.9137")
+	entry := &repository.JournalEntry{
+		Lines: []*repository.JournalEntryLine{
+			{AccountID: destinationID, Debit: decimal.RequireFromString("9.14"), Credit: decimal.Zero, FxRate: &rate},
+			{AccountID: uuid.New(), Debit: decimal.Zero, Credit: decimal.RequireFromString("9.14"), FxRate: &rate},
+			{AccountID: uuid.New(), Debit: amount, Credit: decimal.Zero},
+			{AccountID: sourceID, Debit: decimal.Zero, Credit: amount},
+		},
+	}
+
+	assert.True(t, isTransfer(entry, sourceID, destinationID, amount, &rate))
+	otherRate := decimal.RequireFromString("0.9")
+	assert.False(t, isTransfer(entry, sourceID, destinationID, amount, &otherRate))
+	assert.False(t, isTransfer(entry, sourceID, destinationID, amount, nil))
+	assert.False(t, isTransfer(entry, sourceID, destinationID, decimal.NewFromInt(11), &rate))
+}
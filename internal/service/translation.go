@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// exchangeRate holds the rates translating one currency into the reporting currency
+type exchangeRate struct {
+	closing    decimal.Decimal
+	average    decimal.Decimal
+	historical decimal.Decimal
+}
+
+// reportTranslation translates report amounts from the currencies of their
+// accounts into a reporting currency, rounded to its precision
+type reportTranslation struct {
+	currency  string
+	precision int32
+	rates     map[string]exchangeRate
+}
+
+// rate returns the rate translating an amount of an account of the given
+// type and currency: the closing rate for assets and liabilities, the
+// historical rate for equity and the average rate for income statement
+// accounts. Amounts already in the reporting currency translate at 1.
+func (t *reportTranslation) rate(currencyCode, accountTypeCode string) (decimal.Decimal, error) {
+	if currencyCode == t.currency {
+		return decimal.NewFromInt(1), nil
+	}
+
+	r, ok := t.rates[currencyCode]
+	if !ok {
+		return decimal.Zero, status.Errorf(codes.FailedPrecondition, "no exchange rate for %s", currencyCode)
+	}
+	switch accountTypeCode {
+	case repository.AccountTypeRevenue, repository.AccountTypeExpense:
+		return r.average, nil
+	case repository.AccountTypeEquity:
+		return r.historical, nil
+	}
+	return r.closing, nil
+}
+
+// translate translates the debits and credits of an account, rounding each
+// to the precision of the reporting currency so report totals add up to the
+// translated rows; without a translation they are returned as they are
+func (t *reportTranslation) translate(currencyCode, accountTypeCode string, debit, credit decimal.Decimal) (decimal.Decimal, decimal.Decimal, error) {
+	if t == nil {
+		return debit, credit, nil
+	}
+
+	rate, err := t.rate(currencyCode, accountTypeCode)
+	if err != nil {
+		return debit, credit, err
+	}
+	return debit.Mul(rate).Round(t.precision), credit.Mul(rate).Round(t.precision), nil
+}
+
+// reportingCurrency checks a report's reporting currency against the
+// reference data and parses its rates; an unset currency yields no
+// translation
+func (s *LedgerService) reportingCurrency(ctx context.Context, reporting *pb.ReportingCurrency) (*reportTranslation, error) {
+	if reporting == nil {
+		return nil, nil
+	}
+
+	if reporting.CurrencyCode == "" {
+		return nil, invalidField("reporting_currency.currency_code", "reporting currency is required")
+	}
+	currency, err := s.findCurrency(ctx, reporting.CurrencyCode)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := parseExchangeRates(reporting.Rates)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reportTranslation{currency: reporting.CurrencyCode, precision: currency.Precision, rates: rates}, nil
+}
+
+func parseExchangeRates(rates []*pb.ExchangeRate) (map[string]exchangeRate, error) {
+	parsed := make(map[string]exchangeRate, len(rates))
+	for _, r := range rates {
+		closing, err := decimal.NewFromString(r.ClosingRate)
+		if err != nil || !closing.IsPositive() {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid closing rate for %s", r.CurrencyCode)
+		}
+
+		average := closing
+		if r.AverageRate != "" {
+			average, err = decimal.NewFromString(r.AverageRate)
+			if err != nil || !average.IsPositive() {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid average rate for %s", r.CurrencyCode)
+			}
+		}
+
+		historical := closing
+		if r.HistoricalRate != "" {
+			historical, err = decimal.NewFromString(r.HistoricalRate)
+			if err != nil || !historical.IsPositive() {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid historical rate for %s", r.CurrencyCode)
+			}
+		}
+
+		parsed[r.CurrencyCode] = exchangeRate{closing: closing, average: average, historical: historical}
+	}
+	return parsed, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+func TestReportTranslation_Rate(t *testing.T) {
+	translation := &reportTranslation{
+		currency: "USD",
+		rates: map[string]exchangeRate{
+			"EUR": {closing: decimal.RequireFromString("1.2"), average: decimal.RequireFromString("1.1"), historical: decimal.RequireFromString("1.3")},
+		},
+	}
+
+	for _, tc := range []struct {
+		currency, accountType, want string
+	}{
+		{"USD", repository.AccountTypeRevenue, "1"},
+		{"EUR", repository.AccountTypeAsset, "1.2"},
+		{"EUR", repository.AccountTypeLiability, "1.2"},
+		{"EUR", repository.AccountTypeEquity, "1.3"},
+		{"EUR", repository.AccountTypeRevenue, "1.1"},
+		{"EUR", repository.AccountTypeExpense, "1.1"},
+	} {
+		rate, err := translation.rate(tc.currency, tc.accountType)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, rate.String(), "%s %s", tc.currency, tc.accountType)
+	}
+
+	_, err := translation.rate("GBP", repository.AccountTypeAsset)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	translation.precision = 2
+	debit, credit, err := translation.translate("EUR", repository.AccountTypeAsset, decimal.RequireFromString("10.01"), decimal.RequireFromString("0.04"))
+	require.NoError(t, err)
+	assert.Equal(t, "12.01", debit.String())
+	assert.Equal(t, "0.05", credit.String())
+
+	var none *reportTranslation
+	debit, credit, err = none.translate("GBP", repository.AccountTypeAsset, decimal.NewFromInt(5), decimal.NewFromInt(2))
+	require.NoError(t, err)
+	assert.Equal(t, "5", debit.String())
+	assert.Equal(t, "2", credit.String())
+}
+
+func TestLedgerService_ReportingCurrency(t *testing.T) {
+	ctx := context.Background()
+	mockReferenceRepo := new(MockReferenceRepository)
+	service := NewLedgerService(nil, nil, nil, mockReferenceRepo)
+	mockReferenceRepo.On("ListCurrencies", ctx).Return([]*repository.Currency{{Code: "USD"}, {Code: "EUR"}}, nil)
+
+	t.Run("yields no translation when unset", func(t *testing.T) {
+		translation, err := service.reportingCurrency(ctx, nil)
+		require.NoError(t, err)
+		assert.Nil(t, translation)
+	})
+
+	t.Run("defaults the average and historical rates to the closing rate", func(t *testing.T) {
+		translation, err := service.reportingCurrency(ctx, &pb.ReportingCurrency{
+			CurrencyCode: "USD",
+			Rates:        []*pb.ExchangeRate{{CurrencyCode: "EUR", ClosingRate: "1.2"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "1.2", translation.rates["EUR"].average.String())
+		assert.Equal(t, "1.2", translation.rates["EUR"].historical.String())
+	})
+
+	t.Run("validates the currency and rates", func(t *testing.T) {
+		for _, reporting := range []*pb.ReportingCurrency{
+			{},
+			{CurrencyCode: "XXX"},
+			{CurrencyCode: "USD", Rates: []*pb.ExchangeRate{{CurrencyCode: "EUR", ClosingRate: "0"}}},
+			{CurrencyCode: "USD", Rates: []*pb.ExchangeRate{{CurrencyCode: "EUR", ClosingRate: "1.2", AverageRate: "x"}}},
+			{CurrencyCode: "USD", Rates: []*pb.ExchangeRate{{CurrencyCode: "EUR", ClosingRate: "1.2", HistoricalRate: "-1"}}},
+		} {
+			_, err := service.reportingCurrency(ctx, reporting)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+	})
+}
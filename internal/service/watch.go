@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// maxWatchedAccounts limits the accounts a single balance stream watches
+const maxWatchedAccounts = 100
+
+// WatchAccountBalances streams the balances of accounts: each balance once
+// when the stream opens, then again whenever a posting changes it
+func (s *LedgerService) WatchAccountBalances(req *pb.WatchAccountBalancesRequest, stream pb.LedgerService_WatchAccountBalancesServer) error {
+	if s.broker == nil {
+		return status.Error(codes.Unimplemented, "balance streaming is not enabled")
+	}
+
+	tenantID, err := requestID("tenant_id", req.TenantId)
+	if err != nil {
+		return err
+	}
+
+	if len(req.AccountIds) == 0 {
+		return invalidField("account_ids", "at least one account ID is required")
+	}
+	if len(req.AccountIds) > maxWatchedAccounts {
+		return invalidField("account_ids", "at most 100 accounts can be watched")
+	}
+
+	accountIDs := make([]uuid.UUID, 0, len(req.AccountIds))
+	seen := make(map[uuid.UUID]bool, len(req.AccountIds))
+	for _, raw := range req.AccountIds {
+		id, err := requestID("account_ids", raw)
+		if err != nil {
+			return err
+		}
+		if !seen[id] {
+			seen[id] = true
+			accountIDs = append(accountIDs, id)
+		}
+	}
+
+	// Subscribe before reading the initial balances so no posting in
+	// between is missed
+	sub := s.broker.Subscribe(tenantID, accountIDs)
+	defer sub.Close()
+
+	ctx := stream.Context()
+	if err := s.sendBalances(ctx, stream, tenantID, accountIDs); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sub.Done():
+			return status.Error(codes.Unavailable, "the server is shutting down")
+		case <-sub.Ready():
+			if err := s.sendBalances(ctx, stream, tenantID, sub.Changed()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sendBalances sends the current balance of each account on a balance stream
+func (s *LedgerService) sendBalances(ctx context.Context, stream pb.LedgerService_WatchAccountBalancesServer, tenantID uuid.UUID, accountIDs []uuid.UUID) error {
+	for _, accountID := range accountIDs {
+		balance, err := s.accountRepo.GetBalance(ctx, tenantID, accountID)
+		if err != nil {
+			return repositoryError("get account balance", err)
+		}
+
+		err = stream.Send(&pb.WatchAccountBalancesResponse{
+			AccountId:     balance.AccountID.String(),
+			DebitBalance:  balance.DebitBalance.String(),
+			CreditBalance: balance.CreditBalance.String(),
+			NetBalance:    balance.DebitBalance.Sub(balance.CreditBalance).String(),
+			UpdatedAt:     timestamppb.New(balance.UpdatedAt),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
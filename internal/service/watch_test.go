@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/watch"
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+// fakeWatchStream passes the messages sent by WatchAccountBalances to a channel
+type fakeWatchStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses chan *pb.WatchAccountBalancesResponse
+}
+
+func (f *fakeWatchStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeWatchStream) Send(resp *pb.WatchAccountBalancesResponse) error {
+	f.responses <- resp
+	return nil
+}
+
+// next waits for the next message sent on the stream
+func (f *fakeWatchStream) next(t *testing.T) *pb.WatchAccountBalancesResponse {
+	t.Helper()
+	select {
+	case resp := <-f.responses:
+		return resp
+	case <-time.After(time.Second):
+		t.Fatal("no balance update received")
+		return nil
+	}
+}
+
+func TestLedgerService_WatchAccountBalances(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	broker := watch.NewBroker()
+	store.OnBalanceChange(broker.Publish)
+	service := NewLedgerService(
+		memory.NewTenantRepository(store),
+		memory.NewAccountRepository(store),
+		memory.NewJournalRepository(store),
+		memory.NewReferenceRepository(store),
+		WithBalanceBroker(broker),
+	)
+
+	tenant, err := memory.NewTenantRepository(store).Create(ctx, "watch", nil)
+	require.NoError(t, err)
+	tenantID := tenant.ID.String()
+
+	createAccount := func(number string, accountTypeID int32) string {
+		resp, err := service.CreateAccount(ctx, &pb.CreateAccountRequest{
+			TenantId:      tenantID,
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeId: accountTypeID,
+			CurrencyCode:  "USD",
+		})
+		require.NoError(t, err)
+		return resp.AccountId
+	}
+	cash := createAccount("1000", 1)
+	sales := createAccount("4000", 4)
+
+	post := func(amount string) {
+		_, err := service.CreateJournalEntry(ctx, &pb.CreateJournalEntryRequest{
+			TenantId:        tenantID,
+			ReferenceNumber: "INV-" + uuid.NewString(),
+			Description:     "Invoice",
+			EntryDate:       timestamppb.New(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)),
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: cash, Debit: amount, Credit: "0"},
+				{AccountId: sales, Debit: "0", Credit: amount},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("sends the current balances and then each change", func(t *testing.T) {
+		streamCtx, cancel := context.WithCancel(ctx)
+		stream := &fakeWatchStream{ctx: streamCtx, responses: make(chan *pb.WatchAccountBalancesResponse, 10)}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- service.WatchAccountBalances(&pb.WatchAccountBalancesRequest{
+				TenantId:   tenantID,
+				AccountIds: []string{cash, cash},
+			}, stream)
+		}()
+
+		initial := stream.next(t)
+		assert.Equal(t, cash, initial.AccountId)
+		assert.Equal(t, "0", initial.NetBalance)
+
+		post("40.00")
+		update := stream.next(t)
+		assert.Equal(t, cash, update.AccountId)
+		assert.Equal(t, "40", update.NetBalance)
+
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("returns not found for an unknown account", func(t *testing.T) {
+		stream := &fakeWatchStream{ctx: ctx, responses: make(chan *pb.WatchAccountBalancesResponse, 10)}
+		err := service.WatchAccountBalances(&pb.WatchAccountBalancesRequest{
+			TenantId:   tenantID,
+			AccountIds: []string{uuid.NewString()},
+		}, stream)
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("ends when the broker closes", func(t *testing.T) {
+		broker := watch.NewBroker()
+		broker.Close()
+		service := NewLedgerService(nil, memory.NewAccountRepository(store), nil, nil, WithBalanceBroker(broker))
+		stream := &fakeWatchStream{ctx: ctx, responses: make(chan *pb.WatchAccountBalancesResponse, 10)}
+
+		err := service.WatchAccountBalances(&pb.WatchAccountBalancesRequest{
+			TenantId:   tenantID,
+			AccountIds: []string{cash},
+		}, stream)
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		stream := &fakeWatchStream{ctx: ctx}
+		tooMany := make([]string, maxWatchedAccounts+1)
+		for i := range tooMany {
+			tooMany[i] = uuid.NewString()
+		}
+
+		for _, req := range []*pb.WatchAccountBalancesRequest{
+			{TenantId: "invalid", AccountIds: []string{cash}},
+			{TenantId: tenantID},
+			{TenantId: tenantID, AccountIds: []string{"invalid"}},
+			{TenantId: tenantID, AccountIds: tooMany},
+		} {
+			err := service.WatchAccountBalances(req, stream)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+	})
+
+	t.Run("returns unimplemented without a broker", func(t *testing.T) {
+		err := NewLedgerService(nil, nil, nil, nil).WatchAccountBalances(&pb.WatchAccountBalancesRequest{TenantId: tenantID}, &fakeWatchStream{ctx: ctx})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
@@ -0,0 +1,97 @@
+package statement
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// csvDateLayouts are the date formats accepted in the date column
+var csvDateLayouts = []string{"2006-01-02", "2006/01/02", "02.01.2006"}
+
+// ParseCSV parses a CSV statement. The first row is a header naming the
+// columns; "date" and "amount" are required, "description" and "reference"
+// are optional. Column names are matched case-insensitively.
+func ParseCSV(r io.Reader) ([]Line, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("statement is empty")
+		}
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	dateCol, ok := columns["date"]
+	if !ok {
+		return nil, errors.New("missing date column")
+	}
+	amountCol, ok := columns["amount"]
+	if !ok {
+		return nil, errors.New("missing amount column")
+	}
+	descriptionCol, hasDescription := columns["description"]
+	referenceCol, hasReference := columns["reference"]
+
+	var lines []Line
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read record: %w", err)
+		}
+
+		row, _ := reader.FieldPos(0)
+		field := func(col int) string {
+			if col >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[col])
+		}
+
+		postedAt, err := parseCSVDate(field(dateCol))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", row, err)
+		}
+
+		amount, err := decimal.NewFromString(field(amountCol))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount %q", row, field(amountCol))
+		}
+
+		line := Line{PostedAt: postedAt, Amount: amount}
+		if hasDescription {
+			line.Description = field(descriptionCol)
+		}
+		if hasReference {
+			line.Reference = field(referenceCol)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+func parseCSVDate(value string) (time.Time, error) {
+	for _, layout := range csvDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
@@ -0,0 +1,47 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	t.Run("parses rows by header name", func(t *testing.T) {
+		input := "Reference,Date,Description,Amount\n" +
+			"TX-1,2024-01-15,Coffee shop,-4.50\n" +
+			"TX-2,2024/01/16,\"Salary, January\",2500.00\n"
+
+		lines, err := ParseCSV(strings.NewReader(input))
+
+		require.NoError(t, err)
+		require.Len(t, lines, 2)
+		assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), lines[0].PostedAt)
+		assert.True(t, decimal.RequireFromString("-4.50").Equal(lines[0].Amount))
+		assert.Equal(t, "Coffee shop", lines[0].Description)
+		assert.Equal(t, "TX-1", lines[0].Reference)
+		assert.Equal(t, "Salary, January", lines[1].Description)
+	})
+
+	t.Run("returns error when amount column is missing", func(t *testing.T) {
+		_, err := ParseCSV(strings.NewReader("date,description\n2024-01-15,Coffee\n"))
+
+		assert.ErrorContains(t, err, "missing amount column")
+	})
+
+	t.Run("returns error with line number for invalid amount", func(t *testing.T) {
+		_, err := ParseCSV(strings.NewReader("date,amount\n2024-01-15,10\n2024-01-16,abc\n"))
+
+		assert.ErrorContains(t, err, "line 3")
+	})
+
+	t.Run("returns error for empty input", func(t *testing.T) {
+		_, err := ParseCSV(strings.NewReader(""))
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,140 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ParseOFX parses an OFX statement. Both the SGML (OFX 1.x, unclosed
+// elements) and XML (OFX 2.x) variants are accepted; only STMTTRN
+// aggregates are read.
+func ParseOFX(r io.Reader) ([]Line, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement: %w", err)
+	}
+
+	content := string(data)
+	start := strings.Index(strings.ToUpper(content), "<OFX>")
+	if start < 0 {
+		return nil, errors.New("missing OFX root element")
+	}
+	content = content[start:]
+
+	var (
+		lines   []Line
+		current map[string]string
+	)
+
+	for len(content) > 0 {
+		open := strings.IndexByte(content, '<')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(content[open:], '>')
+		if end < 0 {
+			return nil, errors.New("unterminated tag")
+		}
+		tag := strings.ToUpper(strings.TrimSpace(content[open+1 : open+end]))
+		content = content[open+end+1:]
+
+		next := strings.IndexByte(content, '<')
+		if next < 0 {
+			next = len(content)
+		}
+		value := strings.TrimSpace(content[:next])
+
+		switch {
+		case tag == "STMTTRN":
+			current = make(map[string]string)
+		case tag == "/STMTTRN":
+			if current == nil {
+				return nil, errors.New("unexpected </STMTTRN>")
+			}
+			line, err := ofxTransaction(current)
+			if err != nil {
+				return nil, fmt.Errorf("transaction %d: %w", len(lines)+1, err)
+			}
+			lines = append(lines, line)
+			current = nil
+		case current != nil && !strings.HasPrefix(tag, "/"):
+			current[tag] = value
+		}
+	}
+
+	if current != nil {
+		return nil, errors.New("unterminated STMTTRN aggregate")
+	}
+
+	return lines, nil
+}
+
+func ofxTransaction(fields map[string]string) (Line, error) {
+	postedAt, err := parseOFXDate(fields["DTPOSTED"])
+	if err != nil {
+		return Line{}, err
+	}
+
+	amount, err := decimal.NewFromString(fields["TRNAMT"])
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid amount %q", fields["TRNAMT"])
+	}
+
+	description := fields["NAME"]
+	if description == "" {
+		description = fields["MEMO"]
+	}
+
+	return Line{
+		PostedAt:    postedAt,
+		Amount:      amount,
+		Description: description,
+		Reference:   fields["FITID"],
+	}, nil
+}
+
+// parseOFXDate parses the OFX datetime format
+// YYYYMMDD[HHMMSS[.XXX]][[offset[:TZ]]], e.g. 20240115093000.000[-5:EST]
+func parseOFXDate(value string) (time.Time, error) {
+	location := time.UTC
+	if i := strings.IndexByte(value, '['); i >= 0 {
+		tz := strings.TrimSuffix(value[i+1:], "]")
+		value = value[:i]
+		if j := strings.IndexByte(tz, ':'); j >= 0 {
+			tz = tz[:j]
+		}
+		hours, err := strconv.ParseFloat(tz, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date offset %q", tz)
+		}
+		location = time.FixedZone("", int(hours*3600))
+	}
+
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		value = value[:i]
+	}
+
+	var layout string
+	switch len(value) {
+	case 8:
+		layout = "20060102"
+	case 12:
+		layout = "200601021504"
+	case 14:
+		layout = "20060102150405"
+	default:
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+
+	t, err := time.ParseInLocation(layout, value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return t, nil
+}
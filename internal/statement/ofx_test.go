@@ -0,0 +1,76 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOFX(t *testing.T) {
+	t.Run("parses SGML statement", func(t *testing.T) {
+		input := `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240115093000.000[-5:EST]
+<TRNAMT>-50.00
+<FITID>20240115001
+<NAME>Grocery Store
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240116
+<TRNAMT>1200.00
+<FITID>20240116001
+<MEMO>Payroll
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>`
+
+		lines, err := ParseOFX(strings.NewReader(input))
+
+		require.NoError(t, err)
+		require.Len(t, lines, 2)
+		assert.True(t, time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC).Equal(lines[0].PostedAt))
+		assert.True(t, decimal.RequireFromString("-50").Equal(lines[0].Amount))
+		assert.Equal(t, "Grocery Store", lines[0].Description)
+		assert.Equal(t, "20240115001", lines[0].Reference)
+		assert.Equal(t, "Payroll", lines[1].Description)
+	})
+
+	t.Run("parses XML statement", func(t *testing.T) {
+		input := `<?xml version="1.0"?><OFX><STMTTRN><DTPOSTED>20240201</DTPOSTED>` +
+			`<TRNAMT>9.99</TRNAMT><FITID>A1</FITID><NAME>Refund</NAME></STMTTRN></OFX>`
+
+		lines, err := ParseOFX(strings.NewReader(input))
+
+		require.NoError(t, err)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "Refund", lines[0].Description)
+		assert.Equal(t, "A1", lines[0].Reference)
+	})
+
+	t.Run("returns error for invalid date", func(t *testing.T) {
+		input := "<OFX><STMTTRN><DTPOSTED>2024<TRNAMT>1.00</STMTTRN></OFX>"
+
+		_, err := ParseOFX(strings.NewReader(input))
+
+		assert.ErrorContains(t, err, "invalid date")
+	})
+
+	t.Run("returns error without OFX root", func(t *testing.T) {
+		_, err := ParseOFX(strings.NewReader("date,amount\n"))
+
+		assert.Error(t, err)
+	})
+}
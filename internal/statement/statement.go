@@ -0,0 +1,40 @@
+// Package statement parses bank statements into lines that can be
+// reconciled against the ledger.
+package statement
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Format identifies the file format of a bank statement
+type Format string
+
+const (
+	FormatCSV Format = "CSV"
+	FormatOFX Format = "OFX"
+)
+
+// Line is a single transaction on a bank statement. Positive amounts are
+// deposits, negative amounts are withdrawals.
+type Line struct {
+	PostedAt    time.Time
+	Amount      decimal.Decimal
+	Description string
+	Reference   string
+}
+
+// Parse reads a statement in the given format
+func Parse(format Format, r io.Reader) ([]Line, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatOFX:
+		return ParseOFX(r)
+	default:
+		return nil, fmt.Errorf("unsupported statement format %q", format)
+	}
+}
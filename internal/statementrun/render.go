@@ -0,0 +1,146 @@
+package statementrun
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/locale"
+	"github.com/hesabFun/ledger/internal/pdf"
+	"github.com/shopspring/decimal"
+)
+
+// Page layout in points
+const (
+	marginLeft   = 40.0
+	marginRight  = pdf.PageWidth - 40
+	marginBottom = pdf.PageHeight - 50
+	rowHeight    = 14.0
+	fontSize     = 9.0
+)
+
+// Right edges of the table's amount columns and the left edges of its text
+// columns
+const (
+	colDate        = marginLeft
+	colReference   = marginLeft + 62
+	colDescription = marginLeft + 150
+	colDebit       = marginRight - 170
+	colCredit      = marginRight - 85
+	colBalance     = marginRight
+)
+
+// descriptionWidth leaves a gap between descriptions and the debit column
+const descriptionWidth = colDebit - 80 - colDescription
+
+// statementLine is a row of a rendered statement
+type statementLine struct {
+	date        time.Time
+	reference   string
+	description string
+	debit       decimal.Decimal
+	credit      decimal.Decimal
+	balance     decimal.Decimal
+}
+
+// document is the content of a statement to render
+type document struct {
+	title          string
+	account        string
+	party          string
+	currency       string
+	fromDate       time.Time
+	toDate         time.Time
+	generatedAt    time.Time
+	openingBalance decimal.Decimal
+	lines          []statementLine
+	closingBalance decimal.Decimal
+}
+
+// amounts formats amounts with thousands separators
+var amounts, _ = locale.NewFormatter("en")
+
+// render writes a statement as a PDF: a header naming the account, party and
+// period, then a table opening with the balance brought forward, one row per
+// line with the running balance, and the period totals and closing balance.
+// The table continues over as many pages as it needs, repeating its header.
+func render(w io.Writer, d *document) error {
+	doc := pdf.New(d.title)
+	y := 0.0
+
+	newPage := func() {
+		doc.AddPage()
+		doc.TextRight(marginRight, pdf.PageHeight-25, 8, pdf.Regular, "Page "+strconv.Itoa(doc.PageCount()))
+		y = 50
+		if doc.PageCount() == 1 {
+			doc.Text(marginLeft, y+10, 16, pdf.Bold, d.title)
+			doc.TextRight(marginRight, y+10, fontSize, pdf.Regular, "Generated "+d.generatedAt.UTC().Format(time.DateOnly))
+			y += 36
+			for _, field := range [][2]string{
+				{"Account", d.account},
+				{"Party", d.party},
+				{"Currency", d.currency},
+				{"Period", d.fromDate.Format(time.DateOnly) + " to " + d.toDate.Format(time.DateOnly)},
+			} {
+				if field[1] == "" {
+					continue
+				}
+				doc.Text(marginLeft, y, 10, pdf.Bold, field[0])
+				doc.Text(marginLeft+70, y, 10, pdf.Regular, field[1])
+				y += rowHeight + 2
+			}
+			y += 10
+		}
+
+		doc.Text(colDate, y, fontSize, pdf.Bold, "Date")
+		doc.Text(colReference, y, fontSize, pdf.Bold, "Reference")
+		doc.Text(colDescription, y, fontSize, pdf.Bold, "Description")
+		doc.TextRight(colDebit, y, fontSize, pdf.Bold, "Debit")
+		doc.TextRight(colCredit, y, fontSize, pdf.Bold, "Credit")
+		doc.TextRight(colBalance, y, fontSize, pdf.Bold, "Balance")
+		doc.Line(marginLeft, y+4, marginRight, y+4, 0.5)
+		y += rowHeight + 4
+	}
+
+	row := func() {
+		if y > marginBottom {
+			newPage()
+		}
+	}
+
+	newPage()
+
+	doc.Text(colDate, y, fontSize, pdf.Regular, d.fromDate.Format(time.DateOnly))
+	doc.Text(colDescription, y, fontSize, pdf.Regular, "Opening balance")
+	doc.TextRight(colBalance, y, fontSize, pdf.Regular, amounts.Decimal(d.openingBalance))
+	y += rowHeight
+
+	debits, credits := decimal.Zero, decimal.Zero
+	for _, line := range d.lines {
+		row()
+		doc.Text(colDate, y, fontSize, pdf.Regular, line.date.Format(time.DateOnly))
+		doc.Text(colReference, y, fontSize, pdf.Regular, pdf.Truncate(line.reference, fontSize, colDescription-colReference-6))
+		doc.Text(colDescription, y, fontSize, pdf.Regular, pdf.Truncate(line.description, fontSize, descriptionWidth))
+		if !line.debit.IsZero() {
+			doc.TextRight(colDebit, y, fontSize, pdf.Regular, amounts.Decimal(line.debit))
+		}
+		if !line.credit.IsZero() {
+			doc.TextRight(colCredit, y, fontSize, pdf.Regular, amounts.Decimal(line.credit))
+		}
+		doc.TextRight(colBalance, y, fontSize, pdf.Regular, amounts.Decimal(line.balance))
+		debits = debits.Add(line.debit)
+		credits = credits.Add(line.credit)
+		y += rowHeight
+	}
+
+	row()
+	doc.Line(marginLeft, y-rowHeight+4, marginRight, y-rowHeight+4, 0.5)
+	doc.Text(colDate, y, fontSize, pdf.Bold, d.toDate.Format(time.DateOnly))
+	doc.Text(colDescription, y, fontSize, pdf.Bold, "Closing balance")
+	doc.TextRight(colDebit, y, fontSize, pdf.Bold, amounts.Decimal(debits))
+	doc.TextRight(colCredit, y, fontSize, pdf.Bold, amounts.Decimal(credits))
+	doc.TextRight(colBalance, y, fontSize, pdf.Bold, amounts.Decimal(d.closingBalance))
+
+	_, err := doc.WriteTo(w)
+	return err
+}
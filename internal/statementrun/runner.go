@@ -0,0 +1,202 @@
+// Package statementrun generates customer-facing PDF statements of accounts
+// and parties for a period in the background, writing them to the export
+// store for delivery.
+package statementrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/export"
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+)
+
+// Runner runs statement runs, writing one PDF per statement to a store
+type Runner struct {
+	accountRepo repository.AccountRepositoryInterface
+	partyRepo   repository.PartyRepositoryInterface
+	reportRepo  repository.ReportRepositoryInterface
+	runRepo     repository.StatementRunRepositoryInterface
+	store       export.Store
+	now         func() time.Time
+}
+
+// NewRunner creates a new statement runner
+func NewRunner(
+	accountRepo repository.AccountRepositoryInterface,
+	partyRepo repository.PartyRepositoryInterface,
+	reportRepo repository.ReportRepositoryInterface,
+	runRepo repository.StatementRunRepositoryInterface,
+	store export.Store,
+) *Runner {
+	return &Runner{
+		accountRepo: accountRepo,
+		partyRepo:   partyRepo,
+		reportRepo:  reportRepo,
+		runRepo:     runRepo,
+		store:       store,
+		now:         time.Now,
+	}
+}
+
+// Start records a pending run and runs it in the background
+func (r *Runner) Start(ctx context.Context, tenantID uuid.UUID, params repository.StatementRunParams) (*repository.StatementRun, error) {
+	run, err := r.runRepo.Create(ctx, tenantID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	go r.Run(context.Background(), run)
+
+	return run, nil
+}
+
+// Get retrieves a statement run
+func (r *Runner) Get(ctx context.Context, tenantID uuid.UUID, runID uuid.UUID) (*repository.StatementRun, error) {
+	return r.runRepo.GetByID(ctx, tenantID, runID)
+}
+
+// Open opens a statement written by a run
+func (r *Runner) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return r.store.Open(ctx, key)
+}
+
+// Run writes the statements of a run and records its outcome. A run fails as
+// a whole, keeping no statements, when any of them cannot be written.
+func (r *Runner) Run(ctx context.Context, run *repository.StatementRun) {
+	if err := r.runRepo.UpdateStatus(ctx, run.TenantID, run.ID, repository.StatementRunRunning, nil, nil); err != nil {
+		redact.Printf(redact.LevelError, "statement run %s: %v", run.ID, err)
+		return
+	}
+
+	status := repository.StatementRunCompleted
+	statements, err := r.write(ctx, run)
+	var errMsg *string
+	if err != nil {
+		status = repository.StatementRunFailed
+		statements = nil
+		msg := redact.Error(err)
+		errMsg = &msg
+	}
+
+	if err := r.runRepo.UpdateStatus(ctx, run.TenantID, run.ID, status, statements, errMsg); err != nil {
+		redact.Printf(redact.LevelError, "statement run %s: %v", run.ID, err)
+	}
+}
+
+// write renders every statement of the run and returns where each was stored
+func (r *Runner) write(ctx context.Context, run *repository.StatementRun) ([]repository.DeliveredStatement, error) {
+	generatedAt := r.now()
+	statements := make([]repository.DeliveredStatement, 0, len(run.AccountIDs)+len(run.PartyIDs))
+
+	for _, accountID := range run.AccountIDs {
+		account, err := r.accountRepo.GetByID(ctx, run.TenantID, accountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account %s: %w", accountID, err)
+		}
+
+		statement, err := r.reportRepo.GetAccountStatement(ctx, run.TenantID, accountID, run.FromDate, run.ToDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get statement of account %s: %w", accountID, err)
+		}
+
+		doc := &document{
+			title:          "Account Statement",
+			account:        account.AccountNumber + " " + account.Name,
+			currency:       account.CurrencyCode,
+			fromDate:       run.FromDate,
+			toDate:         run.ToDate,
+			generatedAt:    generatedAt,
+			openingBalance: statement.OpeningBalance,
+			closingBalance: statement.ClosingBalance,
+		}
+		for _, line := range statement.Lines {
+			doc.lines = append(doc.lines, statementLine{
+				date:        line.EntryDate,
+				reference:   line.ReferenceNumber,
+				description: line.Description,
+				debit:       line.Debit,
+				credit:      line.Credit,
+				balance:     line.Balance,
+			})
+		}
+
+		key := fmt.Sprintf("%s/%s/account-%s.pdf", run.TenantID, run.ID, accountID)
+		if err := r.save(ctx, key, doc); err != nil {
+			return nil, err
+		}
+		statements = append(statements, repository.DeliveredStatement{AccountID: accountID, File: key})
+	}
+
+	if len(run.PartyIDs) == 0 {
+		return statements, nil
+	}
+	if run.PartyAccountID == nil {
+		return nil, fmt.Errorf("party statements need an account")
+	}
+
+	account, err := r.accountRepo.GetByID(ctx, run.TenantID, *run.PartyAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account %s: %w", *run.PartyAccountID, err)
+	}
+
+	for _, partyID := range run.PartyIDs {
+		party, err := r.partyRepo.GetByID(ctx, run.TenantID, partyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get party %s: %w", partyID, err)
+		}
+
+		statement, err := r.partyRepo.GetStatement(ctx, run.TenantID, partyID, account.ID, run.FromDate, run.ToDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get statement of party %s: %w", partyID, err)
+		}
+
+		doc := &document{
+			title:          "Statement of Account",
+			account:        account.AccountNumber + " " + account.Name,
+			party:          party.Code + " " + party.Name,
+			currency:       account.CurrencyCode,
+			fromDate:       run.FromDate,
+			toDate:         run.ToDate,
+			generatedAt:    generatedAt,
+			openingBalance: statement.OpeningBalance,
+			closingBalance: statement.ClosingBalance,
+		}
+		for _, line := range statement.Lines {
+			doc.lines = append(doc.lines, statementLine{
+				date:        line.EntryDate,
+				reference:   line.ReferenceNumber,
+				description: line.Description,
+				debit:       line.Debit,
+				credit:      line.Credit,
+				balance:     line.Balance,
+			})
+		}
+
+		key := fmt.Sprintf("%s/%s/party-%s.pdf", run.TenantID, run.ID, partyID)
+		if err := r.save(ctx, key, doc); err != nil {
+			return nil, err
+		}
+		statements = append(statements, repository.DeliveredStatement{AccountID: account.ID, PartyID: &partyID, File: key})
+	}
+
+	return statements, nil
+}
+
+// save renders a statement into the store under key
+func (r *Runner) save(ctx context.Context, key string, doc *document) error {
+	f, err := r.store.Create(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if err := render(f, doc); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to render statement: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,165 @@
+package statementrun
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAccountRepository struct {
+	repository.AccountRepositoryInterface
+	accounts map[uuid.UUID]*repository.Account
+}
+
+func (f *fakeAccountRepository) GetByID(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*repository.Account, error) {
+	account, ok := f.accounts[accountID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return account, nil
+}
+
+type fakeReportRepository struct {
+	repository.ReportRepositoryInterface
+	statement *repository.AccountStatement
+}
+
+func (f *fakeReportRepository) GetAccountStatement(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, fromDate, toDate time.Time) (*repository.AccountStatement, error) {
+	return f.statement, nil
+}
+
+type fakePartyRepository struct {
+	repository.PartyRepositoryInterface
+	party     *repository.Party
+	statement *repository.PartyStatement
+}
+
+func (f *fakePartyRepository) GetByID(ctx context.Context, tenantID uuid.UUID, partyID uuid.UUID) (*repository.Party, error) {
+	return f.party, nil
+}
+
+func (f *fakePartyRepository) GetStatement(ctx context.Context, tenantID uuid.UUID, partyID, accountID uuid.UUID, fromDate, toDate time.Time) (*repository.PartyStatement, error) {
+	return f.statement, nil
+}
+
+type fakeRunRepository struct {
+	repository.StatementRunRepositoryInterface
+	status     string
+	statements []repository.DeliveredStatement
+	errMsg     *string
+}
+
+func (f *fakeRunRepository) UpdateStatus(ctx context.Context, tenantID uuid.UUID, runID uuid.UUID, status string, statements []repository.DeliveredStatement, errMsg *string) error {
+	f.status, f.statements, f.errMsg = status, statements, errMsg
+	return nil
+}
+
+type memoryStore struct {
+	files map[string]*bytes.Buffer
+}
+
+type memoryFile struct {
+	*bytes.Buffer
+}
+
+func (memoryFile) Close() error { return nil }
+
+func (m *memoryStore) Create(ctx context.Context, key string) (io.WriteCloser, error) {
+	buf := &bytes.Buffer{}
+	m.files[key] = buf
+	return memoryFile{buf}, nil
+}
+
+func (m *memoryStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(m.files[key].Bytes())), nil
+}
+
+func TestRunner_Run(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	wallet := &repository.Account{ID: uuid.New(), AccountNumber: "2100", Name: "Wallet", CurrencyCode: "USD"}
+	receivable := &repository.Account{ID: uuid.New(), AccountNumber: "1200", Name: "Receivables", CurrencyCode: "USD"}
+	accounts := &fakeAccountRepository{accounts: map[uuid.UUID]*repository.Account{
+		wallet.ID:     wallet,
+		receivable.ID: receivable,
+	}}
+
+	// Enough lines to run over to a second page
+	statement := &repository.AccountStatement{OpeningBalance: decimal.NewFromInt(100)}
+	balance := statement.OpeningBalance
+	for i := range 80 {
+		balance = balance.Add(decimal.NewFromInt(10))
+		statement.Lines = append(statement.Lines, &repository.AccountStatementLine{
+			EntryDate:       time.Date(2026, 1, 1+i%28, 0, 0, 0, 0, time.UTC),
+			ReferenceNumber: "JE-1",
+			Description:     "Top-up",
+			Debit:           decimal.NewFromInt(10),
+			Credit:          decimal.Zero,
+			Balance:         balance,
+		})
+	}
+	statement.ClosingBalance = balance
+
+	partyID := uuid.New()
+	parties := &fakePartyRepository{
+		party:     &repository.Party{ID: partyID, Code: "ACME", Name: "Acme Ltd"},
+		statement: &repository.PartyStatement{OpeningBalance: decimal.Zero, ClosingBalance: decimal.Zero},
+	}
+
+	run := &repository.StatementRun{
+		ID:             uuid.New(),
+		TenantID:       tenantID,
+		AccountIDs:     []uuid.UUID{wallet.ID},
+		PartyIDs:       []uuid.UUID{partyID},
+		PartyAccountID: &receivable.ID,
+		FromDate:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		ToDate:         time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("writes one PDF per account and party", func(t *testing.T) {
+		runRepo := &fakeRunRepository{}
+		store := &memoryStore{files: map[string]*bytes.Buffer{}}
+		runner := NewRunner(accounts, parties, &fakeReportRepository{statement: statement}, runRepo, store)
+
+		runner.Run(ctx, run)
+
+		require.Equal(t, repository.StatementRunCompleted, runRepo.status)
+		require.Len(t, runRepo.statements, 2)
+		assert.Equal(t, wallet.ID, runRepo.statements[0].AccountID)
+		assert.Nil(t, runRepo.statements[0].PartyID)
+		assert.Equal(t, tenantID.String()+"/"+run.ID.String()+"/account-"+wallet.ID.String()+".pdf", runRepo.statements[0].File)
+		assert.Equal(t, receivable.ID, runRepo.statements[1].AccountID)
+		assert.Equal(t, &partyID, runRepo.statements[1].PartyID)
+
+		for _, delivered := range runRepo.statements {
+			data := store.files[delivered.File].Bytes()
+			assert.True(t, bytes.HasPrefix(data, []byte("%PDF-")))
+		}
+		assert.Contains(t, store.files[runRepo.statements[0].File].String(), "/Count 2")
+		assert.Contains(t, store.files[runRepo.statements[1].File].String(), "/Count 1")
+	})
+
+	t.Run("fails the run when an account is missing", func(t *testing.T) {
+		runRepo := &fakeRunRepository{}
+		runner := NewRunner(accounts, parties, &fakeReportRepository{statement: statement}, runRepo, &memoryStore{files: map[string]*bytes.Buffer{}})
+
+		runner.Run(ctx, &repository.StatementRun{
+			ID:         uuid.New(),
+			TenantID:   tenantID,
+			AccountIDs: []uuid.UUID{uuid.New()},
+		})
+
+		assert.Equal(t, repository.StatementRunFailed, runRepo.status)
+		assert.Empty(t, runRepo.statements)
+		require.NotNil(t, runRepo.errMsg)
+		assert.Contains(t, *runRepo.errMsg, "failed to get account")
+	})
+}
@@ -0,0 +1,30 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hesabFun/ledger/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestSetup(t *testing.T) {
+	t.Run("installs a tracer provider for the collector", func(t *testing.T) {
+		previous := otel.GetTracerProvider()
+		defer otel.SetTracerProvider(previous)
+
+		shutdown, err := Setup(context.Background(), config.TelemetryConfig{
+			ServiceName:        "ledger",
+			TracingEndpoint:    "http://127.0.0.1:4317",
+			TracingSampleRatio: 0.5,
+		})
+		require.NoError(t, err)
+
+		assert.IsType(t, &sdktrace.TracerProvider{}, otel.GetTracerProvider())
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+}
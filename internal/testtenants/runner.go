@@ -0,0 +1,108 @@
+// Package testtenants purges the data of test tenants once their retention
+// has passed
+package testtenants
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Runner periodically purges the test tenants marked as test longer than the
+// retention ago and reports the outcome as metrics and log lines
+type Runner struct {
+	tenantRepo     repository.TenantRepositoryInterface
+	tenantDataRepo repository.TenantDataRepositoryInterface
+	retention      time.Duration
+	interval       time.Duration
+
+	purged prometheus.Counter
+	errors prometheus.Counter
+}
+
+// NewRunner creates a new runner and registers its metrics with reg
+func NewRunner(
+	tenantRepo repository.TenantRepositoryInterface,
+	tenantDataRepo repository.TenantDataRepositoryInterface,
+	retention time.Duration,
+	interval time.Duration,
+	reg prometheus.Registerer,
+) *Runner {
+	r := &Runner{
+		tenantRepo:     tenantRepo,
+		tenantDataRepo: tenantDataRepo,
+		retention:      retention,
+		interval:       interval,
+		purged: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_test_tenants_purged_total",
+			Help: "Test tenants purged by the background runner after their retention.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_test_tenant_purge_errors_total",
+			Help: "Purges of expired test tenants that failed.",
+		}),
+	}
+
+	reg.MustRegister(r.purged, r.errors)
+
+	return r
+}
+
+// Run purges the expired test tenants once per interval until ctx is
+// cancelled
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.PurgeExpired(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeExpired deletes and purges every test tenant marked as test longer
+// than the retention ago. A tenant whose purge fails is logged and counted,
+// and the run carries on with the others; a tenant no longer expired by the
+// time it is purged, because its test mark was cleared, is skipped.
+func (r *Runner) PurgeExpired(ctx context.Context) {
+	before := time.Now().Add(-r.retention)
+	tenantIDs, err := r.tenantRepo.ListExpiredTestIDs(ctx, before)
+	if err != nil {
+		redact.Printf(redact.LevelError, "test tenant purge: %v", err)
+		r.errors.Inc()
+		return
+	}
+
+	for _, tenantID := range tenantIDs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		purge, err := r.tenantDataRepo.PurgeExpiredTest(ctx, tenantID, before)
+		if err != nil {
+			if errors.Is(err, repository.ErrTestTenantNotExpired) {
+				continue
+			}
+			redact.Printf(redact.LevelError, "test tenant purge of tenant %s: %v", tenantID, err)
+			r.errors.Inc()
+			continue
+		}
+		r.purged.Inc()
+
+		var rows int64
+		for _, n := range purge.Rows {
+			rows += n
+		}
+		log.Printf("test tenant purge: purged tenant %s, %d rows", tenantID, rows)
+	}
+}
@@ -0,0 +1,71 @@
+package testtenants
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTenantRepository struct {
+	repository.TenantRepositoryInterface
+	ids    []uuid.UUID
+	err    error
+	before time.Time
+}
+
+func (f *fakeTenantRepository) ListExpiredTestIDs(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	f.before = before
+	return f.ids, f.err
+}
+
+type fakeTenantDataRepository struct {
+	repository.TenantDataRepositoryInterface
+	errs   map[uuid.UUID]error
+	purged []uuid.UUID
+}
+
+func (f *fakeTenantDataRepository) PurgeExpiredTest(ctx context.Context, tenantID uuid.UUID, before time.Time) (*repository.TenantPurge, error) {
+	if err := f.errs[tenantID]; err != nil {
+		return nil, err
+	}
+	f.purged = append(f.purged, tenantID)
+	return &repository.TenantPurge{TenantID: tenantID, Rows: map[string]int64{"accounts": 3}}, nil
+}
+
+func TestRunner_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("purges every expired test tenant", func(t *testing.T) {
+		expired, unmarked, failing := uuid.New(), uuid.New(), uuid.New()
+		tenantDataRepo := &fakeTenantDataRepository{errs: map[uuid.UUID]error{
+			unmarked: repository.ErrTestTenantNotExpired,
+			failing:  errors.New("connection refused"),
+		}}
+		tenantRepo := &fakeTenantRepository{ids: []uuid.UUID{expired, unmarked, failing}}
+		runner := NewRunner(tenantRepo, tenantDataRepo, 24*time.Hour, 0, prometheus.NewRegistry())
+
+		runner.PurgeExpired(ctx)
+
+		assert.Equal(t, []uuid.UUID{expired}, tenantDataRepo.purged)
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.purged))
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.errors))
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), tenantRepo.before, time.Minute)
+	})
+
+	t.Run("counts an error when tenants cannot be listed", func(t *testing.T) {
+		tenantRepo := &fakeTenantRepository{err: errors.New("connection refused")}
+		runner := NewRunner(tenantRepo, &fakeTenantDataRepository{}, time.Hour, 0, prometheus.NewRegistry())
+
+		runner.PurgeExpired(ctx)
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(runner.errors))
+		assert.Zero(t, testutil.ToFloat64(runner.purged))
+	})
+}
@@ -0,0 +1,100 @@
+// Package validation enforces the protovalidate rules annotated on request
+// messages before a call reaches its handler, so malformed IDs and missing
+// fields are rejected the same way on every method.
+package validation
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"buf.build/go/protovalidate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrorInfo details match those the services attach to invalid fields, so
+// clients handle both alike
+const (
+	errorDomain        = "ledger.v1"
+	reasonInvalidField = "INVALID_FIELD"
+)
+
+// Validate checks a request against its rules, returning an InvalidArgument
+// status with one BadRequest field violation per broken rule
+func Validate(req interface{}) error {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	err := protovalidate.Validate(msg)
+	if err != nil {
+		var validationErr *protovalidate.ValidationError
+		if !errors.As(err, &validationErr) {
+			// The rules themselves could not be compiled or evaluated, which
+			// is a fault of the server rather than of the request
+			return status.Errorf(codes.Internal, "validate request: %v", err)
+		}
+		for _, v := range validationErr.Violations {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       protovalidate.FieldPathString(v.Proto.GetField()),
+				Description: v.Proto.GetMessage(),
+			})
+		}
+	}
+	violations = append(violations, missingRequiredFields(msg.ProtoReflect())...)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, len(violations))
+	for i, v := range violations {
+		descriptions[i] = v.Field + ": " + v.Description
+	}
+
+	st := status.New(codes.InvalidArgument, "invalid request: "+strings.Join(descriptions, "; "))
+	detailed, detailErr := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: reasonInvalidField, Domain: errorDomain},
+		&errdetails.BadRequest{FieldViolations: violations},
+	)
+	if detailErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// UnaryServerInterceptor returns a unary interceptor that rejects requests
+// breaking their rules
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := Validate(req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a stream interceptor that rejects every
+// received message breaking its rules
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingServerStream{ServerStream: ss})
+	}
+}
+
+// validatingServerStream validates every message received on a stream
+type validatingServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return Validate(m)
+}
@@ -0,0 +1,203 @@
+package validation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	pb "github.com/hesabFun/ledger/gen/go/ledger/v1"
+)
+
+func details(t *testing.T, err error) (*errdetails.ErrorInfo, *errdetails.BadRequest) {
+	t.Helper()
+	var info *errdetails.ErrorInfo
+	var badRequest *errdetails.BadRequest
+	for _, detail := range status.Convert(err).Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.BadRequest:
+			badRequest = d
+		}
+	}
+	require.NotNil(t, info, "no ErrorInfo detail")
+	require.NotNil(t, badRequest, "no BadRequest detail")
+	return info, badRequest
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("accepts valid requests", func(t *testing.T) {
+		assert.NoError(t, Validate(&pb.GetAccountRequest{
+			TenantId:  uuid.New().String(),
+			AccountId: uuid.New().String(),
+		}))
+	})
+
+	t.Run("ignores values that are not messages", func(t *testing.T) {
+		assert.NoError(t, Validate(struct{}{}))
+	})
+
+	t.Run("reports a field violation", func(t *testing.T) {
+		err := Validate(&pb.GetAccountRequest{
+			TenantId:  uuid.New().String(),
+			AccountId: "not-a-uuid",
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		info, badRequest := details(t, err)
+		assert.Equal(t, reasonInvalidField, info.Reason)
+		assert.Equal(t, errorDomain, info.Domain)
+		require.Len(t, badRequest.FieldViolations, 1)
+		assert.Equal(t, "account_id", badRequest.FieldViolations[0].Field)
+	})
+
+	t.Run("reports every violation with the paths of lines", func(t *testing.T) {
+		err := Validate(&pb.CreateJournalEntryRequest{
+			TenantId: "",
+			Lines: []*pb.JournalEntryLine{
+				{AccountId: uuid.New().String(), Debit: "10", Credit: "0"},
+				{AccountId: "cash", Debit: "0", Credit: "10"},
+			},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, badRequest := details(t, err)
+		fields := make([]string, len(badRequest.FieldViolations))
+		for i, v := range badRequest.FieldViolations {
+			fields[i] = v.Field
+		}
+		assert.ElementsMatch(t, []string{"tenant_id", "lines[1].account_id"}, fields)
+		assert.Contains(t, status.Convert(err).Message(), "lines[1].account_id")
+	})
+
+	t.Run("leaves empty optional IDs to mean none", func(t *testing.T) {
+		empty := ""
+		assert.NoError(t, Validate(&pb.ListAccountsRequest{
+			TenantId: uuid.New().String(),
+			BookId:   &empty,
+		}))
+	})
+}
+
+func TestValidate_RequiredFields(t *testing.T) {
+	t.Run("names existing fields of existing requests", func(t *testing.T) {
+		for name, fields := range requiredFields {
+			messageType, err := protoregistry.GlobalTypes.FindMessageByName(name)
+			require.NoError(t, err, name)
+			for _, field := range fields {
+				assert.NotNil(t, messageType.Descriptor().Fields().ByName(field), "%s.%s", name, field)
+			}
+		}
+	})
+
+	t.Run("reports empty required strings and messages", func(t *testing.T) {
+		id := uuid.New().String()
+		err := Validate(&pb.CreateDocumentRequest{
+			TenantId:         id,
+			Number:           "INV-1",
+			ControlAccountId: id,
+			CounterAccountId: id,
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, badRequest := details(t, err)
+		require.Len(t, badRequest.FieldViolations, 2)
+		assert.Equal(t, "party", badRequest.FieldViolations[0].Field)
+		assert.Equal(t, "value length must be at least 1 characters", badRequest.FieldViolations[0].Description)
+		assert.Equal(t, "document_date", badRequest.FieldViolations[1].Field)
+		assert.Equal(t, "value is required", badRequest.FieldViolations[1].Description)
+	})
+
+	t.Run("combines them with the generated rules", func(t *testing.T) {
+		err := Validate(&pb.CreatePartyRequest{TenantId: "invalid", Name: "Acme"})
+
+		_, badRequest := details(t, err)
+		fields := make([]string, len(badRequest.FieldViolations))
+		for i, v := range badRequest.FieldViolations {
+			fields[i] = v.Field
+		}
+		assert.ElementsMatch(t, []string{"tenant_id", "code"}, fields)
+	})
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+	called := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return "ok", nil
+	}
+
+	t.Run("calls the handler for valid requests", func(t *testing.T) {
+		called = false
+		resp, err := interceptor(context.Background(), &pb.VerifyLedgerIntegrityRequest{TenantId: uuid.New().String()}, &grpc.UnaryServerInfo{}, handler)
+
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp)
+		assert.True(t, called)
+	})
+
+	t.Run("rejects invalid requests without calling the handler", func(t *testing.T) {
+		called = false
+		_, err := interceptor(context.Background(), &pb.VerifyLedgerIntegrityRequest{TenantId: "invalid"}, &grpc.UnaryServerInfo{}, handler)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.False(t, called)
+	})
+}
+
+// recvStream delivers one message to RecvMsg
+type recvStream struct {
+	grpc.ServerStream
+	msg *pb.WatchAccountBalancesRequest
+}
+
+func (s *recvStream) Context() context.Context     { return context.Background() }
+func (s *recvStream) SetHeader(metadata.MD) error  { return nil }
+func (s *recvStream) SendHeader(metadata.MD) error { return nil }
+func (s *recvStream) SetTrailer(metadata.MD)       {}
+func (s *recvStream) SendMsg(interface{}) error    { return nil }
+
+func (s *recvStream) RecvMsg(m interface{}) error {
+	req := m.(*pb.WatchAccountBalancesRequest)
+	req.TenantId = s.msg.TenantId
+	req.AccountIds = s.msg.AccountIds
+	return nil
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	interceptor := StreamServerInterceptor()
+	recv := func(msg *pb.WatchAccountBalancesRequest) error {
+		return interceptor(nil, &recvStream{msg: msg}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+			return ss.RecvMsg(&pb.WatchAccountBalancesRequest{})
+		})
+	}
+
+	t.Run("passes valid messages", func(t *testing.T) {
+		assert.NoError(t, recv(&pb.WatchAccountBalancesRequest{
+			TenantId:   uuid.New().String(),
+			AccountIds: []string{uuid.New().String()},
+		}))
+	})
+
+	t.Run("rejects invalid messages", func(t *testing.T) {
+		err := recv(&pb.WatchAccountBalancesRequest{
+			TenantId:   uuid.New().String(),
+			AccountIds: []string{uuid.New().String(), "cash"},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, badRequest := details(t, err)
+		require.Len(t, badRequest.FieldViolations, 1)
+		assert.Equal(t, "account_ids[1]", badRequest.FieldViolations[0].Field)
+	})
+}
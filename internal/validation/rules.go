@@ -0,0 +1,58 @@
+package validation
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// requiredFields lists the required fields of requests whose generated
+// descriptors do not carry a protovalidate rule for them yet. Validate
+// reports a missing one as protovalidate reports its own min_len and
+// required rules, so handlers need no checks of their own either way.
+var requiredFields = map[protoreflect.FullName][]protoreflect.Name{
+	"ledger.v1.CreateDocumentRequest":            {"number", "party", "document_date"},
+	"ledger.v1.CreatePartyRequest":               {"code", "name"},
+	"ledger.v1.UpdatePartyRequest":               {"code", "name"},
+	"ledger.v1.CreateTaxCodeRequest":             {"code", "name"},
+	"ledger.v1.UpdateTaxCodeRequest":             {"code", "name"},
+	"ledger.v1.CreateFixedAssetRequest":          {"code", "name"},
+	"ledger.v1.CreateDimensionRequest":           {"code", "name"},
+	"ledger.v1.UpdateDimensionRequest":           {"code", "name"},
+	"ledger.v1.CreateBudgetRequest":              {"name"},
+	"ledger.v1.UpdateBudgetRequest":              {"name"},
+	"ledger.v1.CreateConsolidationGroupRequest":  {"name"},
+	"ledger.v1.UpdateConsolidationGroupRequest":  {"name"},
+	"ledger.v1.CreateTenantRequest":              {"name"},
+	"ledger.v1.CreateAccountTypeRequest":         {"code", "name"},
+	"ledger.v1.CreateCurrencyRequest":            {"name"},
+	"ledger.v1.UpdateCurrencyRequest":            {"code"},
+	"ledger.v1.SetAccountTypeTranslationRequest": {"code"},
+	"ledger.v1.SetCurrencyTranslationRequest":    {"code"},
+}
+
+// missingRequiredFields returns a violation for every field of msg in
+// requiredFields that is empty
+func missingRequiredFields(msg protoreflect.Message) []*errdetails.BadRequest_FieldViolation {
+	names := requiredFields[msg.Descriptor().FullName()]
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, name := range names {
+		field := msg.Descriptor().Fields().ByName(name)
+		if field == nil {
+			continue
+		}
+
+		switch {
+		case field.Kind() == protoreflect.StringKind && msg.Get(field).String() == "":
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       string(name),
+				Description: "value length must be at least 1 characters",
+			})
+		case field.Kind() == protoreflect.MessageKind && !msg.Has(field):
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       string(name),
+				Description: "value is required",
+			})
+		}
+	}
+	return violations
+}
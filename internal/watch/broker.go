@@ -0,0 +1,175 @@
+// Package watch fans balance changes out to the subscribers of account
+// balance streams. Changes come from the database through a balance
+// listener, so a subscriber sees postings made by every server sharing the
+// database, or from the in-memory store in tests and local tools.
+package watch
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+)
+
+// Broker delivers balance changes to the subscriptions watching the changed
+// accounts. Publishing never blocks: changes a subscriber has not collected
+// yet are coalesced per account.
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[uuid.UUID]map[*Subscription]struct{}
+	closed bool
+}
+
+// NewBroker creates a broker without subscriptions
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[uuid.UUID]map[*Subscription]struct{})}
+}
+
+// Subscription collects changes of the accounts it watches
+type Subscription struct {
+	broker   *Broker
+	tenantID uuid.UUID
+	accounts []uuid.UUID
+	watched  map[uuid.UUID]bool
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]bool
+	ready   chan struct{}
+	done    chan struct{}
+}
+
+// Subscribe watches balance changes of accounts of a tenant. The caller
+// must close the subscription when done.
+func (b *Broker) Subscribe(tenantID uuid.UUID, accountIDs []uuid.UUID) *Subscription {
+	sub := &Subscription{
+		broker:   b,
+		tenantID: tenantID,
+		accounts: accountIDs,
+		watched:  make(map[uuid.UUID]bool, len(accountIDs)),
+		pending:  make(map[uuid.UUID]bool),
+		ready:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	for _, id := range accountIDs {
+		sub.watched[id] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(sub.done)
+		return sub
+	}
+	if b.subs[tenantID] == nil {
+		b.subs[tenantID] = make(map[*Subscription]struct{})
+	}
+	b.subs[tenantID][sub] = struct{}{}
+
+	return sub
+}
+
+// Publish records a balance change for the subscriptions watching the account
+func (b *Broker) Publish(change repository.BalanceChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs[change.TenantID] {
+		if sub.watched[change.AccountID] {
+			sub.mark(change.AccountID)
+		}
+	}
+}
+
+// Resync marks every watched account as changed. It is called after the
+// balance listener reconnects, since changes committed while it was
+// disconnected were not received.
+func (b *Broker) Resync() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subs := range b.subs {
+		for sub := range subs {
+			sub.mark(sub.accounts...)
+		}
+	}
+}
+
+// Close ends every subscription, and subscriptions made afterwards end
+// immediately
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, subs := range b.subs {
+		for sub := range subs {
+			close(sub.done)
+		}
+	}
+	b.subs = nil
+}
+
+// mark records changes of watched accounts and wakes the subscriber
+func (s *Subscription) mark(accountIDs ...uuid.UUID) {
+	s.mu.Lock()
+	for _, id := range accountIDs {
+		s.pending[id] = true
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Ready receives when changes are waiting to be collected with Changed
+func (s *Subscription) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Done is closed when the broker closes
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Changed returns the accounts that changed since the last call, in the
+// order they were subscribed
+func (s *Subscription) Changed() []uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	changed := make([]uuid.UUID, 0, len(s.pending))
+	for _, id := range s.accounts {
+		if s.pending[id] {
+			changed = append(changed, id)
+		}
+	}
+	s.pending = make(map[uuid.UUID]bool)
+
+	return changed
+}
+
+// Close stops the subscription from receiving changes
+func (s *Subscription) Close() {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.subs[s.tenantID]
+	if _, ok := subs[s]; !ok {
+		return
+	}
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(b.subs, s.tenantID)
+	}
+}
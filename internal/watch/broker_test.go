@@ -0,0 +1,124 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// isReady reports whether the subscription has changes to collect
+func isReady(sub *Subscription) bool {
+	select {
+	case <-sub.Ready():
+		return true
+	default:
+		return false
+	}
+}
+
+// isDone reports whether the subscription has ended
+func isDone(sub *Subscription) bool {
+	select {
+	case <-sub.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestBroker(t *testing.T) {
+	tenantID, otherTenantID := uuid.New(), uuid.New()
+	first, second, unwatched := uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("coalesces changes of watched accounts", func(t *testing.T) {
+		broker := NewBroker()
+		sub := broker.Subscribe(tenantID, []uuid.UUID{first, second})
+		defer sub.Close()
+
+		broker.Publish(repository.BalanceChange{TenantID: tenantID, AccountID: second})
+		broker.Publish(repository.BalanceChange{TenantID: tenantID, AccountID: first})
+		broker.Publish(repository.BalanceChange{TenantID: tenantID, AccountID: second})
+
+		assert.True(t, isReady(sub))
+		assert.Equal(t, []uuid.UUID{first, second}, sub.Changed())
+		assert.Empty(t, sub.Changed())
+	})
+
+	t.Run("ignores other accounts and tenants", func(t *testing.T) {
+		broker := NewBroker()
+		sub := broker.Subscribe(tenantID, []uuid.UUID{first})
+		defer sub.Close()
+
+		broker.Publish(repository.BalanceChange{TenantID: tenantID, AccountID: unwatched})
+		broker.Publish(repository.BalanceChange{TenantID: otherTenantID, AccountID: first})
+
+		assert.False(t, isReady(sub))
+		assert.Empty(t, sub.Changed())
+	})
+
+	t.Run("resyncs every watched account", func(t *testing.T) {
+		broker := NewBroker()
+		sub := broker.Subscribe(tenantID, []uuid.UUID{first, second})
+		defer sub.Close()
+
+		broker.Resync()
+
+		assert.Equal(t, []uuid.UUID{first, second}, sub.Changed())
+	})
+
+	t.Run("stops delivering after close", func(t *testing.T) {
+		broker := NewBroker()
+		sub := broker.Subscribe(tenantID, []uuid.UUID{first})
+		sub.Close()
+		sub.Close()
+
+		broker.Publish(repository.BalanceChange{TenantID: tenantID, AccountID: first})
+
+		assert.Empty(t, sub.Changed())
+		assert.Empty(t, broker.subs)
+	})
+
+	t.Run("ends subscriptions when closed", func(t *testing.T) {
+		broker := NewBroker()
+		sub := broker.Subscribe(tenantID, []uuid.UUID{first})
+		broker.Close()
+		sub.Close()
+
+		assert.True(t, isDone(sub))
+		assert.True(t, isDone(broker.Subscribe(tenantID, []uuid.UUID{first})))
+	})
+}
+
+// fakeListener fails each Listen call after delivering its change
+type fakeListener struct {
+	change repository.BalanceChange
+	calls  int
+}
+
+func (f *fakeListener) Listen(ctx context.Context, listening func(), fn func(repository.BalanceChange)) error {
+	f.calls++
+	listening()
+	fn(f.change)
+	return errors.New("connection lost")
+}
+
+func TestFollow(t *testing.T) {
+	tenantID, accountID := uuid.New(), uuid.New()
+	broker := NewBroker()
+	sub := broker.Subscribe(tenantID, []uuid.UUID{accountID})
+	defer sub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	listener := &fakeListener{change: repository.BalanceChange{TenantID: tenantID, AccountID: accountID}}
+	Follow(ctx, listener, broker, 10*time.Millisecond)
+
+	assert.Greater(t, listener.calls, 1, "listening is retried")
+	assert.Equal(t, []uuid.UUID{accountID}, sub.Changed())
+}
@@ -0,0 +1,28 @@
+package watch
+
+import (
+	"context"
+	"time"
+
+	"github.com/hesabFun/ledger/internal/redact"
+	"github.com/hesabFun/ledger/internal/repository"
+)
+
+// Follow publishes the balance changes received by listener to broker until
+// ctx is cancelled. When listening fails it is restarted after retry, and
+// every subscription is resynced once it is listening again.
+func Follow(ctx context.Context, listener repository.BalanceListenerInterface, broker *Broker, retry time.Duration) {
+	for {
+		err := listener.Listen(ctx, broker.Resync, broker.Publish)
+		if ctx.Err() != nil {
+			return
+		}
+		redact.Printf(redact.LevelError, "balance listener: %v; retrying in %s", err, retry)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/shopspring/decimal"
+)
+
+// Account represents an account entity
+type Account struct {
+	ID              uuid.UUID
+	TenantID        uuid.UUID
+	BookID          uuid.UUID
+	AccountNumber   string
+	Name            string
+	Description     *string
+	AccountTypeID   int32
+	CurrencyCode    string
+	ParentAccountID *uuid.UUID
+	IsActive        bool
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       *time.Time
+	// OverdraftLimit is how far the available balance may go below zero;
+	// nil when the account has no limit of its own, though its account type
+	// may still have one in the tenant settings
+	OverdraftLimit *decimal.Decimal
+	// Depth is the number of ancestors of the account and Path the account
+	// numbers from the root of its tree down to it, separated by "/"
+	Depth int32
+	Path  string
+	// Labels are free-form key-value pairs, and ExternalIDs the identifiers
+	// of the account in other systems, keyed by source system
+	Labels      map[string]string
+	ExternalIDs map[string]string
+}
+
+// CreateAccountParams holds parameters for creating an account
+type CreateAccountParams struct {
+	AccountNumber   string
+	Name            string
+	Description     *string
+	AccountTypeID   int32
+	CurrencyCode    string
+	ParentAccountID *uuid.UUID
+	// BookID is the book the account is kept in; when nil, the book of the
+	// parent account or else the default book of the tenant
+	BookID *uuid.UUID
+	// OverdraftLimit, when set, turns on the available balance check from
+	// the account's creation
+	OverdraftLimit *decimal.Decimal
+	Labels         map[string]string
+	// ExternalIDs maps source systems to the account's identifier in them
+	ExternalIDs map[string]string
+	// MaxAccounts, when set, is the tenant's account limit, checked in the
+	// creating transaction
+	MaxAccounts *int32
+}
+
+// Sort fields accepted by AccountFilter.SortBy
+const (
+	AccountSortNumber    = "account_number"
+	AccountSortName      = "name"
+	AccountSortCreatedAt = "created_at"
+)
+
+// AccountFilter holds filters and ordering for listing accounts
+type AccountFilter struct {
+	BookID          *uuid.UUID
+	AccountTypeID   *int32
+	CurrencyCode    *string
+	NamePrefix      *string
+	NumberPrefix    *string
+	IsActive        *bool
+	ParentAccountID *uuid.UUID
+	// AncestorAccountID selects the descendants of an account at any depth
+	AncestorAccountID *uuid.UUID
+	IncludeDeleted    bool
+	// Labels selects the accounts carrying all of these labels
+	Labels map[string]string
+	// SortBy is one of the AccountSort* fields; empty lists the newest accounts first
+	SortBy         string
+	SortDescending bool
+}
+
+// AccountBalance represents account balance entity
+type AccountBalance struct {
+	AccountID     uuid.UUID
+	DebitBalance  decimal.Decimal
+	CreditBalance decimal.Decimal
+	UpdatedAt     time.Time
+}
+
+// AvailableBalance is the balance of an account that can still be spent.
+// Booked is the posted balance on the account's normal side, Held the sum
+// of its pending holds and prepared entry reservations, and Available is
+// Booked less Held plus the overdraft limit, if any.
+type AvailableBalance struct {
+	AccountID uuid.UUID
+	Booked    decimal.Decimal
+	Held      decimal.Decimal
+	// OverdraftLimit is the limit the account is checked against, its own
+	// or else its account type's, and nil when it has neither
+	OverdraftLimit *decimal.Decimal
+	Available      decimal.Decimal
+}
+
+// ProjectedBalance is the balance of an account once its pending work
+// settles. Drafts is the net, on the account's normal side, of the draft
+// entries of open and approved journal batches, Pending is Drafts less Held,
+// and Projected is Booked plus Pending.
+type ProjectedBalance struct {
+	AvailableBalance
+	Drafts    decimal.Decimal
+	Pending   decimal.Decimal
+	Projected decimal.Decimal
+}
+
+// AccountCurrency is the currency of an account and its number of decimal places
+type AccountCurrency struct {
+	CurrencyCode string
+	Precision    int32
+}
+
+// AccountMerge is the outcome of merging one account into another
+type AccountMerge struct {
+	// ID identifies the merge in the reference of its reclassification entry
+	// and in its AccountsMerged event
+	ID uuid.UUID
+	// Source is the merged account, closed by the merge
+	Source *Account
+	// Target is the account that received the balance of the source
+	Target *Account
+	// ReclassificationEntryID is the journal entry that moved the balance of
+	// the source to the target, nil when the source had no balance
+	ReclassificationEntryID *uuid.UUID
+}
+
+// MergeReference returns the reference number of the reclassification entry
+// of a merge. Account numbers are reused across books and after a merged
+// account is restored, so the merge ID keeps it unique.
+func MergeReference(sourceAccountNumber string, mergeID uuid.UUID) string {
+	return "MERGE-" + sourceAccountNumber + "-" + mergeID.String()
+}
+
+// BalanceChange identifies an account whose balance changed
+type BalanceChange struct {
+	TenantID  uuid.UUID
+	AccountID uuid.UUID
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBookCode is the code of the book every tenant is created with
+const DefaultBookCode = "MAIN"
+
+// Book is a set of books kept by a tenant, such as statutory and management
+// books or IFRS and local GAAP books. Every account belongs to exactly one
+// book and a journal entry may only post to accounts of a single book, so
+// each book balances on its own. Every tenant has one default book.
+type Book struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	Code        string
+	Name        string
+	Description *string
+	IsDefault   bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// CreateBookParams holds parameters for creating a book
+type CreateBookParams struct {
+	Code        string
+	Name        string
+	Description *string
+}
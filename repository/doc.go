@@ -0,0 +1,6 @@
+// Package repository defines the ledger models, the parameters of their
+// operations and the repository interfaces the ledger services are built
+// on, with the errors repositories report. The Postgres repositories of the
+// ledger service implement these interfaces, and so does package memory,
+// which other modules can use in their tests.
+package repository
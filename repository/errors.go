@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrNotFound is returned when a row does not exist or is not visible to the tenant
+	ErrNotFound = errors.New("not found")
+
+	// ErrNonZeroBalance is returned when deleting an account that still carries a balance
+	ErrNonZeroBalance = errors.New("account has a non-zero balance")
+
+	// ErrAccountHasChildren is returned when deleting an account that still has active child accounts
+	ErrAccountHasChildren = errors.New("account has active child accounts")
+
+	// ErrAccountCycle is returned when moving an account under itself or one of its descendants
+	ErrAccountCycle = errors.New("account cannot be moved under itself or one of its descendants")
+
+	// ErrAccountTypeMismatch is returned when moving an account under, or merging it into, an account of another type
+	ErrAccountTypeMismatch = errors.New("accounts have different account types")
+
+	// ErrCurrencyMismatch is returned when merging an account into an account of another currency
+	ErrCurrencyMismatch = errors.New("accounts have different currencies")
+
+	// ErrBookMismatch is returned when an account is created under, moved under or merged into an account
+	// of another book, or when an entry posts to accounts of more than one book or of a book other than
+	// the one requested
+	ErrBookMismatch = errors.New("accounts belong to different books")
+
+	// ErrDeletedAccount is returned when posting to an account that has been deleted
+	ErrDeletedAccount = errors.New("cannot post to a deleted account")
+
+	// ErrInvalidEntryLines is returned when posting an entry with fewer than two lines or a line that is not
+	// either a debit or a credit
+	ErrInvalidEntryLines = errors.New("invalid journal entry lines")
+
+	// ErrUnbalancedEntry is returned when posting an entry whose debits do not equal its credits
+	ErrUnbalancedEntry = errors.New("journal entry is not balanced")
+
+	// ErrInsufficientFunds is returned when a posting or hold would take an account below its overdraft limit
+	ErrInsufficientFunds = errors.New("insufficient available balance")
+
+	// ErrQuotaExceeded is returned when creating an account or posting an entry would exceed a tenant quota
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// ErrPeriodLocked is returned when posting an entry dated on or before the tenant's lock date without a
+	// lock override reason
+	ErrPeriodLocked = errors.New("entry date is in a locked period")
+
+	// ErrFutureDate is returned when posting an entry dated in the future while the posting policy forbids it
+	ErrFutureDate = errors.New("entry date is in the future")
+
+	// ErrBackdateLimit is returned when posting an entry dated further back than the posting policy allows
+	ErrBackdateLimit = errors.New("entry date is beyond the backdate limit")
+)
+
+// Postgres error codes surfaced to the services
+const (
+	pgInsufficientPrivilege = "42501"
+	pgUniqueViolation       = "23505"
+	pgForeignKeyViolation   = "23503"
+	pgQueryCanceled         = "57014"
+)
+
+// IsPermissionDenied reports whether err is a Postgres permission failure,
+// such as a write rejected by a row-level security policy
+func IsPermissionDenied(err error) bool {
+	return hasPgCode(err, pgInsufficientPrivilege)
+}
+
+// IsUniqueViolation reports whether err is a duplicate key error
+func IsUniqueViolation(err error) bool {
+	return hasPgCode(err, pgUniqueViolation)
+}
+
+// IsForeignKeyViolation reports whether err references a row that does not
+// exist or is not visible to the tenant
+func IsForeignKeyViolation(err error) bool {
+	return hasPgCode(err, pgForeignKeyViolation)
+}
+
+// IsQueryCanceled reports whether err is a statement canceled by the
+// server, either past the statement timeout or on a cancel request sent when
+// its context was done
+func IsQueryCanceled(err error) bool {
+	return hasPgCode(err, pgQueryCanceled)
+}
+
+func hasPgCode(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LedgerIntegrity is the outcome of verifying a tenant's journal hash chain.
+// Each chained entry stores a SHA-256 hash over its content and the hash of
+// the entry before it, so changing, removing or reordering a posted entry
+// breaks the chain from that entry on.
+type LedgerIntegrity struct {
+	Valid            bool
+	EntriesVerified  int
+	UnchainedEntries int
+	HeadSequence     int64
+	HeadHash         []byte
+	// Set to the first entry that fails verification
+	FirstInvalidEntryID *uuid.UUID
+	Reason              string
+}
+
+// hashedEntry is the canonical form of a journal entry covered by its hash
+type hashedEntry struct {
+	ID              string                 `json:"id"`
+	TenantID        string                 `json:"tenant_id"`
+	ReferenceNumber string                 `json:"reference_number"`
+	Description     string                 `json:"description"`
+	EntryDate       string                 `json:"entry_date"`
+	Metadata        map[string]interface{} `json:"metadata"`
+	CreatedAt       string                 `json:"created_at"`
+	Lines           []hashedLine           `json:"lines"`
+}
+
+type hashedLine struct {
+	ID                   string            `json:"id"`
+	AccountID            string            `json:"account_id"`
+	Debit                string            `json:"debit"`
+	Credit               string            `json:"credit"`
+	Description          string            `json:"description"`
+	CounterpartyTenantID *string           `json:"counterparty_tenant_id"`
+	FxRate               *string           `json:"fx_rate"`
+	TaxCodeID            *string           `json:"tax_code_id"`
+	IsTax                bool              `json:"is_tax"`
+	PartyID              *string           `json:"party_id"`
+	Dimensions           map[string]string `json:"dimensions"`
+}
+
+// EntryHash returns SHA-256(previousHash || canonical JSON of the entry), the
+// hash that links an entry into its tenant's chain. Every stored column of a
+// line is covered, amounts, rate, tax, party and dimensions alike. Lines that
+// account merges repointed before merges posted reclassification entries are
+// hashed with the account they were posted to, so they still verify.
+func EntryHash(previousHash []byte, entry *JournalEntry) ([]byte, error) {
+	content := hashedEntry{
+		ID:              entry.ID.String(),
+		TenantID:        entry.TenantID.String(),
+		ReferenceNumber: entry.ReferenceNumber,
+		Description:     entry.Description,
+		EntryDate:       entry.EntryDate.Format("2006-01-02"),
+		Metadata:        entry.Metadata,
+		CreatedAt:       entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		Lines:           make([]hashedLine, len(entry.Lines)),
+	}
+
+	for i, line := range entry.Lines {
+		content.Lines[i] = hashedLine{
+			ID:          line.ID.String(),
+			AccountID:   line.AccountID.String(),
+			Debit:       line.Debit.String(),
+			Credit:      line.Credit.String(),
+			Description: line.Description,
+			TaxCodeID:   uuidString(line.TaxCodeID),
+			IsTax:       line.IsTax,
+			PartyID:     uuidString(line.PartyID),
+		}
+		if len(line.Dimensions) > 0 {
+			content.Lines[i].Dimensions = line.Dimensions
+		}
+		if line.FxRate != nil {
+			rate := line.FxRate.String()
+			content.Lines[i].FxRate = &rate
+		}
+		content.Lines[i].CounterpartyTenantID = uuidString(line.CounterpartyTenantID)
+	}
+	sort.Slice(content.Lines, func(i, j int) bool {
+		return content.Lines[i].ID < content.Lines[j].ID
+	})
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+
+	h := sha256.New()
+	h.Write(previousHash)
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+// uuidString returns the string form of an optional UUID
+func uuidString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/shopspring/decimal"
+)
+
+// TenantRepositoryInterface defines methods for tenant operations
+type TenantRepositoryInterface interface {
+	Create(ctx context.Context, name string, tenantUUID *uuid.UUID) (*Tenant, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID) (*Tenant, error)
+	GetByName(ctx context.Context, name string) (*Tenant, error)
+	ListIDs(ctx context.Context) ([]uuid.UUID, error)
+	List(ctx context.Context, filter TenantFilter, limit, offset int) ([]*Tenant, int, error)
+	Delete(ctx context.Context, tenantID uuid.UUID) (*Tenant, error)
+	Restore(ctx context.Context, tenantID uuid.UUID) (*Tenant, error)
+	Status(ctx context.Context, tenantID uuid.UUID) (string, error)
+	SetStatus(ctx context.Context, tenantID uuid.UUID, status string) (*Tenant, error)
+	SetTestMode(ctx context.Context, tenantID uuid.UUID, test bool) (*Tenant, error)
+	ListTestIDs(ctx context.Context) ([]uuid.UUID, error)
+	ListExpiredTestIDs(ctx context.Context, before time.Time) ([]uuid.UUID, error)
+}
+
+// AccountRepositoryInterface defines methods for account operations
+type AccountRepositoryInterface interface {
+	Create(ctx context.Context, tenantID uuid.UUID, params CreateAccountParams) (*Account, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*Account, error)
+	List(ctx context.Context, tenantID uuid.UUID, filter AccountFilter, limit, offset int) ([]*Account, int, error)
+	GetBalance(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*AccountBalance, error)
+	GetAvailableBalance(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*AvailableBalance, error)
+	GetProjectedBalances(ctx context.Context, tenantID uuid.UUID, accountIDs []uuid.UUID) ([]*ProjectedBalance, error)
+	SetOverdraftLimit(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, limit *decimal.Decimal) (*Account, error)
+	SetLabels(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, labels map[string]string) (*Account, error)
+	SetExternalID(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, source, externalID string) (*Account, error)
+	GetByExternalID(ctx context.Context, tenantID uuid.UUID, source, externalID string) (*Account, error)
+	Move(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, parentID *uuid.UUID) (*Account, error)
+	Merge(ctx context.Context, tenantID uuid.UUID, sourceID, targetID uuid.UUID, entryDate time.Time) (*AccountMerge, error)
+	AccountCurrencies(ctx context.Context, tenantID uuid.UUID, accountIDs []uuid.UUID) (map[uuid.UUID]AccountCurrency, error)
+	Delete(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*Account, error)
+	Restore(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, maxAccounts *int32) (*Account, error)
+}
+
+// BookRepositoryInterface defines methods for book operations
+type BookRepositoryInterface interface {
+	Create(ctx context.Context, tenantID uuid.UUID, params CreateBookParams) (*Book, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, bookID uuid.UUID) (*Book, error)
+	List(ctx context.Context, tenantID uuid.UUID) ([]*Book, error)
+}
+
+// JournalRepositoryInterface defines methods for journal entry operations
+type JournalRepositoryInterface interface {
+	Create(ctx context.Context, tenantID uuid.UUID, params CreateJournalEntryParams) (*JournalEntry, error)
+	GetByID(ctx context.Context, tenantID uuid.UUID, journalEntryID uuid.UUID) (*JournalEntry, error)
+	GetByIdempotencyKey(ctx context.Context, tenantID uuid.UUID, key string) (*JournalEntry, error)
+	ListByTransactionID(ctx context.Context, tenantID uuid.UUID, transactionID string) ([]*JournalEntry, error)
+	List(ctx context.Context, tenantID uuid.UUID, filter JournalEntryFilter, limit, offset int) ([]*JournalEntry, *JournalEntryTotals, error)
+	Search(ctx context.Context, tenantID uuid.UUID, text string, fromDate, toDate *time.Time, limit, offset int) ([]*JournalEntry, int, error)
+	Stream(ctx context.Context, tenantID uuid.UUID, fromDate, toDate *time.Time, fn func(*JournalEntry) error) error
+	VerifyIntegrity(ctx context.Context, tenantID uuid.UUID) (*LedgerIntegrity, error)
+}
+
+// ReferenceRepositoryInterface defines methods for reference data operations
+type ReferenceRepositoryInterface interface {
+	ListAccountTypes(ctx context.Context) ([]*AccountType, error)
+	ListCurrencies(ctx context.Context) ([]*Currency, error)
+	CreateAccountType(ctx context.Context, params CreateAccountTypeParams) (*AccountType, error)
+	CreateCurrency(ctx context.Context, params CreateCurrencyParams) (*Currency, error)
+	UpdateCurrency(ctx context.Context, code string, params UpdateCurrencyParams) (*Currency, error)
+	SetAccountTypeTranslation(ctx context.Context, code, locale, name string) error
+	SetCurrencyTranslation(ctx context.Context, code, locale, name string) error
+	ListAccountTypeTranslations(ctx context.Context, locales []string) (map[string]string, error)
+	ListCurrencyTranslations(ctx context.Context, locales []string) (map[string]string, error)
+}
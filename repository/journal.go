@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/shopspring/decimal"
+)
+
+// JournalEntry represents a journal entry entity. EntryDate is the date the
+// entry is effective for; PostedAt is the immutable time it was recorded in
+// the ledger.
+type JournalEntry struct {
+	ID              uuid.UUID
+	TenantID        uuid.UUID
+	ReferenceNumber string
+	Description     string
+	EntryDate       time.Time
+	PostedAt        time.Time
+	Metadata        map[string]interface{}
+	Lines           []*JournalEntryLine
+	// LockOverrideReason is the justification given for posting the entry
+	// into a locked period
+	LockOverrideReason *string
+	// CurrencyCode is the currency the line amounts are in; empty for older
+	// entries that did not record it and have no line in it
+	CurrencyCode string
+	// BookID is the book of the accounts the entry posted to
+	BookID uuid.UUID
+	// TransactionID is the business transaction the entry was posted under,
+	// empty when it was posted on its own
+	TransactionID string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// JournalEntryLine represents a single line in a journal entry
+type JournalEntryLine struct {
+	ID                   uuid.UUID
+	JournalEntryID       uuid.UUID
+	AccountID            uuid.UUID
+	Debit                decimal.Decimal
+	Credit               decimal.Decimal
+	Description          string
+	CounterpartyTenantID *uuid.UUID
+	// FxRate is the rate a line in another currency than its entry was
+	// converted at
+	FxRate *decimal.Decimal
+	// TaxCodeID is set on lines a tax code was applied to and on the tax
+	// lines generated for them, which have IsTax set
+	TaxCodeID *uuid.UUID
+	IsTax     bool
+	// PartyID is the customer, vendor or employee the line relates to
+	PartyID *uuid.UUID
+	// Dimensions holds the custom dimension values of the line by code
+	Dimensions map[string]string
+	CreatedAt  time.Time
+}
+
+// CreateJournalEntryParams holds parameters for creating a journal entry
+type CreateJournalEntryParams struct {
+	ReferenceNumber string
+	Description     string
+	EntryDate       time.Time
+	Metadata        map[string]interface{}
+	Lines           []*CreateJournalEntryLineParams
+	// IdempotencyKey, when set, must be unique within the tenant; posting a
+	// second entry with the same key fails with a unique violation
+	IdempotencyKey string
+	// TransactionID, when set, groups the entry with the other entries of
+	// the same business transaction
+	TransactionID string
+	// BookID, when set, is the book every line must post to. Lines must
+	// always post to accounts of a single book.
+	BookID *uuid.UUID
+	// LockOverrideReason, when set, records why the entry was posted into a
+	// locked period
+	LockOverrideReason *string
+	// CurrencyCode, when set, records the currency the line amounts are in
+	CurrencyCode string
+}
+
+// CreateJournalEntryLineParams holds parameters for creating a journal entry line
+type CreateJournalEntryLineParams struct {
+	AccountID            uuid.UUID
+	Debit                decimal.Decimal
+	Credit               decimal.Decimal
+	Description          string
+	CounterpartyTenantID *uuid.UUID
+	// FxRate is set on lines on an account in another currency than the
+	// entry. Their amounts are in the account's currency, as on every line,
+	// and FxRate records the rate, in account currency units per entry
+	// currency unit, they were converted at; it is not applied again.
+	FxRate *decimal.Decimal
+	// TaxCodeID and IsTax mark taxable lines and their generated tax lines
+	TaxCodeID  *uuid.UUID
+	IsTax      bool
+	PartyID    *uuid.UUID
+	Dimensions map[string]string
+}
+
+// JournalEntryFilter holds filters for listing journal entries
+type JournalEntryFilter struct {
+	// BookID selects the entries posted to the accounts of a book
+	BookID          *uuid.UUID
+	AccountID       *uuid.UUID
+	FromDate        *time.Time
+	ToDate          *time.Time
+	ReferenceNumber *string
+	ReferencePrefix *string
+	// MinAmount and MaxAmount bound the entry total, i.e. the sum of its debits
+	MinAmount           *decimal.Decimal
+	MaxAmount           *decimal.Decimal
+	DescriptionContains *string
+	// PostedFrom and PostedTo bound the time entries were posted, regardless
+	// of the date they are effective for
+	PostedFrom *time.Time
+	PostedTo   *time.Time
+}
+
+// JournalEntryTotals summarises every journal entry matching a filter
+type JournalEntryTotals struct {
+	EntryCount int
+	// Debit and Credit sum the lines of the matching entries; with an account
+	// filter, only the lines on that account
+	Debit  decimal.Decimal
+	Credit decimal.Decimal
+}
@@ -0,0 +1,652 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/repository"
+	"github.com/shopspring/decimal"
+)
+
+// AccountRepository is an in-memory repository.AccountRepositoryInterface
+type AccountRepository struct {
+	store *Store
+}
+
+// NewAccountRepository creates a new in-memory account repository
+func NewAccountRepository(store *Store) *AccountRepository {
+	return &AccountRepository{store: store}
+}
+
+var _ repository.AccountRepositoryInterface = (*AccountRepository)(nil)
+
+// Create creates a new account with a zero balance, in the book of its
+// parent, else the requested book, else the default book. The tenant,
+// account type, currency, book and parent account must exist, and account
+// numbers are unique within a book.
+func (r *AccountRepository) Create(ctx context.Context, tenantID uuid.UUID, params repository.CreateAccountParams) (*repository.Account, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tenants[tenantID]; !ok {
+		return nil, fmt.Errorf("failed to create account: %w", foreignKeyViolation("accounts_tenant_id_fkey"))
+	}
+	if s.accountType(params.AccountTypeID) == nil {
+		return nil, fmt.Errorf("failed to create account: %w", foreignKeyViolation("accounts_account_type_id_fkey"))
+	}
+	if s.currency(params.CurrencyCode) == nil {
+		return nil, fmt.Errorf("failed to create account: %w", foreignKeyViolation("accounts_currency_code_fkey"))
+	}
+	if err := s.checkAccountQuota(tenantID, params.MaxAccounts); err != nil {
+		return nil, err
+	}
+
+	var book *repository.Book
+	switch {
+	case params.ParentAccountID != nil:
+		parent := s.account(tenantID, *params.ParentAccountID)
+		if parent == nil {
+			return nil, fmt.Errorf("failed to create account: %w", foreignKeyViolation("accounts_parent_account_id_fkey"))
+		}
+		if params.BookID != nil && *params.BookID != parent.BookID {
+			return nil, repository.ErrBookMismatch
+		}
+		book = s.books[parent.BookID]
+	case params.BookID != nil:
+		book = s.book(tenantID, *params.BookID)
+	default:
+		book = s.defaultBook(tenantID)
+	}
+	if book == nil {
+		return nil, fmt.Errorf("book %w", repository.ErrNotFound)
+	}
+
+	for _, record := range s.accounts {
+		if record.account.BookID == book.ID && record.account.AccountNumber == params.AccountNumber {
+			return nil, fmt.Errorf("failed to create account: %w", uniqueViolation("accounts_book_id_account_number_key"))
+		}
+	}
+	for source, externalID := range params.ExternalIDs {
+		if s.accountByExternalID(tenantID, source, externalID) != nil {
+			return nil, fmt.Errorf("failed to set external ID: %w", uniqueViolation("account_external_ids_tenant_id_source_external_id_key"))
+		}
+	}
+
+	now := time.Now().UTC()
+	account := &repository.Account{
+		ID:              uuid.New(),
+		TenantID:        tenantID,
+		BookID:          book.ID,
+		AccountNumber:   params.AccountNumber,
+		Name:            params.Name,
+		Description:     cloneString(params.Description),
+		AccountTypeID:   params.AccountTypeID,
+		CurrencyCode:    params.CurrencyCode,
+		ParentAccountID: cloneUUID(params.ParentAccountID),
+		OverdraftLimit:  cloneDecimal(params.OverdraftLimit),
+		Labels:          cloneStringMap(params.Labels),
+		ExternalIDs:     cloneStringMap(params.ExternalIDs),
+		IsActive:        true,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	s.accounts[account.ID] = &accountRecord{account: account, sequence: s.nextSequence()}
+	s.balances[account.ID] = &repository.AccountBalance{
+		AccountID:     account.ID,
+		DebitBalance:  decimal.Zero,
+		CreditBalance: decimal.Zero,
+		UpdatedAt:     now,
+	}
+
+	return s.cloneAccount(account), nil
+}
+
+// GetByID retrieves an account of the tenant that has not been deleted
+func (r *AccountRepository) GetByID(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*repository.Account, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	account := s.account(tenantID, accountID)
+	if account == nil || account.DeletedAt != nil {
+		return nil, fmt.Errorf("account %w", repository.ErrNotFound)
+	}
+
+	return s.cloneAccount(account), nil
+}
+
+// List retrieves accounts matching a filter; deleted accounts are only included when requested
+func (r *AccountRepository) List(ctx context.Context, tenantID uuid.UUID, filter repository.AccountFilter, limit, offset int) ([]*repository.Account, int, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]*accountRecord, 0)
+	for _, record := range s.accounts {
+		if record.account.TenantID == tenantID && s.matchesAccountFilter(record.account, filter) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, accountLess(records, filter))
+
+	accounts := make([]*repository.Account, 0)
+	for _, record := range page(records, limit, offset) {
+		accounts = append(accounts, s.cloneAccount(record.account))
+	}
+
+	return accounts, len(records), nil
+}
+
+func (s *Store) matchesAccountFilter(account *repository.Account, filter repository.AccountFilter) bool {
+	switch {
+	case !filter.IncludeDeleted && account.DeletedAt != nil:
+		return false
+	case filter.BookID != nil && account.BookID != *filter.BookID:
+		return false
+	case filter.AccountTypeID != nil && account.AccountTypeID != *filter.AccountTypeID:
+		return false
+	case filter.CurrencyCode != nil && account.CurrencyCode != *filter.CurrencyCode:
+		return false
+	case filter.NamePrefix != nil && !strings.HasPrefix(strings.ToLower(account.Name), strings.ToLower(*filter.NamePrefix)):
+		return false
+	case filter.NumberPrefix != nil && !strings.HasPrefix(account.AccountNumber, *filter.NumberPrefix):
+		return false
+	case filter.IsActive != nil && account.IsActive != *filter.IsActive:
+		return false
+	case filter.ParentAccountID != nil && (account.ParentAccountID == nil || *account.ParentAccountID != *filter.ParentAccountID):
+		return false
+	case filter.AncestorAccountID != nil && !s.hasAncestor(account, *filter.AncestorAccountID):
+		return false
+	}
+	for key, value := range filter.Labels {
+		if label, ok := account.Labels[key]; !ok || label != value {
+			return false
+		}
+	}
+	return true
+}
+
+// accountLess orders accounts the way the Postgres repository does: by the
+// sort field with ID as tie-breaker, or newest first when no field is given
+func accountLess(records []*accountRecord, filter repository.AccountFilter) func(i, j int) bool {
+	return func(i, j int) bool {
+		a, b := records[i].account, records[j].account
+
+		var cmp int
+		descending := filter.SortDescending
+		switch filter.SortBy {
+		case repository.AccountSortNumber:
+			cmp = strings.Compare(a.AccountNumber, b.AccountNumber)
+		case repository.AccountSortName:
+			cmp = strings.Compare(a.Name, b.Name)
+		case repository.AccountSortCreatedAt:
+			cmp = compareSequence(records[i].sequence, records[j].sequence)
+		default:
+			cmp = compareSequence(records[i].sequence, records[j].sequence)
+			descending = true
+		}
+
+		if cmp != 0 {
+			return (cmp < 0) != descending
+		}
+		return strings.Compare(a.ID.String(), b.ID.String()) < 0
+	}
+}
+
+func compareSequence(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// page returns the records in the window given by limit and offset
+func page[T any](records []T, limit, offset int) []T {
+	if offset >= len(records) {
+		return nil
+	}
+	records = records[offset:]
+	if limit >= 0 && limit < len(records) {
+		records = records[:limit]
+	}
+	return records
+}
+
+// GetBalance retrieves the balance for an account
+func (r *AccountRepository) GetBalance(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*repository.AccountBalance, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.account(tenantID, accountID) == nil {
+		return nil, fmt.Errorf("balance %w", repository.ErrNotFound)
+	}
+
+	balance := *s.balances[accountID]
+	return &balance, nil
+}
+
+// GetAvailableBalance retrieves the booked and available balance of an
+// account. The memory store keeps no holds, so nothing is held.
+func (r *AccountRepository) GetAvailableBalance(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*repository.AvailableBalance, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	account := s.account(tenantID, accountID)
+	if account == nil || account.DeletedAt != nil {
+		return nil, fmt.Errorf("account %w", repository.ErrNotFound)
+	}
+
+	booked := s.bookedBalance(account, decimal.Zero)
+	balance := &repository.AvailableBalance{
+		AccountID:      accountID,
+		Booked:         booked,
+		Held:           decimal.Zero,
+		OverdraftLimit: cloneDecimal(account.OverdraftLimit),
+		Available:      booked,
+	}
+	if account.OverdraftLimit != nil {
+		balance.Available = booked.Add(*account.OverdraftLimit)
+	}
+
+	return balance, nil
+}
+
+// GetProjectedBalances retrieves the projected balance of each account, in
+// the order given. The store keeps no holds, prepared entries or batches,
+// so the projected balance is the booked one.
+func (r *AccountRepository) GetProjectedBalances(ctx context.Context, tenantID uuid.UUID, accountIDs []uuid.UUID) ([]*repository.ProjectedBalance, error) {
+	balances := make([]*repository.ProjectedBalance, len(accountIDs))
+	for i, accountID := range accountIDs {
+		available, err := r.GetAvailableBalance(ctx, tenantID, accountID)
+		if err != nil {
+			return nil, err
+		}
+		balances[i] = &repository.ProjectedBalance{
+			AvailableBalance: *available,
+			Drafts:           decimal.Zero,
+			Pending:          decimal.Zero,
+			Projected:        available.Booked,
+		}
+	}
+
+	return balances, nil
+}
+
+// SetOverdraftLimit sets how far the available balance of an account may go
+// below zero, or stops checking the account when limit is nil
+func (r *AccountRepository) SetOverdraftLimit(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, limit *decimal.Decimal) (*repository.Account, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := s.account(tenantID, accountID)
+	if account == nil || account.DeletedAt != nil {
+		return nil, fmt.Errorf("account %w", repository.ErrNotFound)
+	}
+
+	account.OverdraftLimit = cloneDecimal(limit)
+	account.UpdatedAt = time.Now().UTC()
+
+	return s.cloneAccount(account), nil
+}
+
+// SetLabels replaces the labels of an account
+func (r *AccountRepository) SetLabels(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, labels map[string]string) (*repository.Account, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := s.account(tenantID, accountID)
+	if account == nil || account.DeletedAt != nil {
+		return nil, fmt.Errorf("account %w", repository.ErrNotFound)
+	}
+
+	account.Labels = cloneStringMap(labels)
+	account.UpdatedAt = time.Now().UTC()
+
+	return s.cloneAccount(account), nil
+}
+
+// SetExternalID maps an account to its identifier in a source system,
+// replacing any earlier identifier for that source, or removes the mapping
+// when externalID is empty. An identifier belongs to at most one account of
+// the tenant per source.
+func (r *AccountRepository) SetExternalID(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, source, externalID string) (*repository.Account, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := s.account(tenantID, accountID)
+	if account == nil || account.DeletedAt != nil {
+		return nil, fmt.Errorf("account %w", repository.ErrNotFound)
+	}
+
+	if externalID == "" {
+		delete(account.ExternalIDs, source)
+	} else {
+		if mapped := s.accountByExternalID(tenantID, source, externalID); mapped != nil && mapped.ID != accountID {
+			return nil, fmt.Errorf("failed to set external ID: %w", uniqueViolation("account_external_ids_tenant_id_source_external_id_key"))
+		}
+		if account.ExternalIDs == nil {
+			account.ExternalIDs = make(map[string]string)
+		}
+		account.ExternalIDs[source] = externalID
+	}
+	account.UpdatedAt = time.Now().UTC()
+
+	return s.cloneAccount(account), nil
+}
+
+// GetByExternalID retrieves the live account mapped to an identifier of a
+// source system
+func (r *AccountRepository) GetByExternalID(ctx context.Context, tenantID uuid.UUID, source, externalID string) (*repository.Account, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	account := s.accountByExternalID(tenantID, source, externalID)
+	if account == nil || account.DeletedAt != nil {
+		return nil, fmt.Errorf("account %w", repository.ErrNotFound)
+	}
+
+	return s.cloneAccount(account), nil
+}
+
+// accountByExternalID finds the account of the tenant, deleted or not,
+// mapped to an identifier of a source system; the caller must hold the lock
+func (s *Store) accountByExternalID(tenantID uuid.UUID, source, externalID string) *repository.Account {
+	for _, record := range s.accounts {
+		if id, ok := record.account.ExternalIDs[source]; ok && id == externalID && record.account.TenantID == tenantID {
+			return record.account
+		}
+	}
+	return nil
+}
+
+// Move moves an account with its descendants under a new parent, or makes
+// it a root account when parentID is nil. The parent must be a live account
+// of the same book and type that is neither the account itself nor one of
+// its descendants.
+func (r *AccountRepository) Move(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, parentID *uuid.UUID) (*repository.Account, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := s.account(tenantID, accountID)
+	if account == nil || account.DeletedAt != nil {
+		return nil, fmt.Errorf("account %w", repository.ErrNotFound)
+	}
+
+	if parentID != nil {
+		parent := s.account(tenantID, *parentID)
+		if parent == nil || parent.DeletedAt != nil {
+			return nil, fmt.Errorf("parent account %w", repository.ErrNotFound)
+		}
+		if parent.ID == accountID || s.hasAncestor(parent, accountID) {
+			return nil, repository.ErrAccountCycle
+		}
+		if parent.BookID != account.BookID {
+			return nil, repository.ErrBookMismatch
+		}
+		if parent.AccountTypeID != account.AccountTypeID {
+			return nil, repository.ErrAccountTypeMismatch
+		}
+	}
+
+	account.ParentAccountID = cloneUUID(parentID)
+	account.UpdatedAt = time.Now().UTC()
+
+	return s.cloneAccount(account), nil
+}
+
+// Merge moves the balance and the overdraft limit of the source account to
+// the target account, the balance with a reclassification entry dated
+// entryDate, and closes the source. Both accounts must be live and share
+// their book, account type and currency, and the source may not have active
+// children.
+func (r *AccountRepository) Merge(ctx context.Context, tenantID uuid.UUID, sourceID, targetID uuid.UUID, entryDate time.Time) (*repository.AccountMerge, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source := s.account(tenantID, sourceID)
+	if source == nil || source.DeletedAt != nil {
+		return nil, fmt.Errorf("source account %w", repository.ErrNotFound)
+	}
+	target := s.account(tenantID, targetID)
+	if target == nil || target.DeletedAt != nil {
+		return nil, fmt.Errorf("target account %w", repository.ErrNotFound)
+	}
+
+	switch {
+	case source.BookID != target.BookID:
+		return nil, repository.ErrBookMismatch
+	case source.AccountTypeID != target.AccountTypeID:
+		return nil, repository.ErrAccountTypeMismatch
+	case source.CurrencyCode != target.CurrencyCode:
+		return nil, repository.ErrCurrencyMismatch
+	}
+	for _, record := range s.accounts {
+		child := record.account
+		if child.ParentAccountID != nil && *child.ParentAccountID == sourceID && child.DeletedAt == nil {
+			return nil, repository.ErrAccountHasChildren
+		}
+	}
+
+	now := time.Now().UTC()
+	if source.OverdraftLimit != nil {
+		limit := *source.OverdraftLimit
+		if target.OverdraftLimit != nil {
+			limit = limit.Add(*target.OverdraftLimit)
+		}
+		target.OverdraftLimit = &limit
+		target.UpdatedAt = now
+	}
+
+	result := &repository.AccountMerge{ID: uuid.New()}
+	balance := s.balances[sourceID]
+	if net := balance.DebitBalance.Sub(balance.CreditBalance); !net.IsZero() {
+		description := fmt.Sprintf("Merge of account %s into %s", source.AccountNumber, target.AccountNumber)
+		sourceLine := &repository.CreateJournalEntryLineParams{AccountID: sourceID, Debit: decimal.Zero, Credit: decimal.Zero, Description: description}
+		targetLine := &repository.CreateJournalEntryLineParams{AccountID: targetID, Debit: decimal.Zero, Credit: decimal.Zero, Description: description}
+		if net.IsPositive() {
+			sourceLine.Credit, targetLine.Debit = net, net
+		} else {
+			sourceLine.Debit, targetLine.Credit = net.Neg(), net.Neg()
+		}
+
+		entry, err := s.postEntry(tenantID, repository.CreateJournalEntryParams{
+			ReferenceNumber: repository.MergeReference(source.AccountNumber, result.ID),
+			Description:     description,
+			EntryDate:       entryDate,
+			Lines:           []*repository.CreateJournalEntryLineParams{sourceLine, targetLine},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to post reclassification entry: %w", err)
+		}
+		result.ReclassificationEntryID = &entry.ID
+	}
+
+	source.DeletedAt = &now
+	source.UpdatedAt = now
+
+	if s.onBalanceChange != nil {
+		s.onBalanceChange(repository.BalanceChange{TenantID: tenantID, AccountID: sourceID})
+		s.onBalanceChange(repository.BalanceChange{TenantID: tenantID, AccountID: targetID})
+	}
+
+	result.Source = s.cloneAccount(source)
+	result.Target = s.cloneAccount(target)
+	return result, nil
+}
+
+// bookedBalance returns the balance of an account on its normal side after
+// a net debit change; the caller must hold the lock
+func (s *Store) bookedBalance(account *repository.Account, netDebit decimal.Decimal) decimal.Decimal {
+	balance := s.balances[account.ID]
+	booked := balance.DebitBalance.Sub(balance.CreditBalance).Add(netDebit)
+	if s.accountType(account.AccountTypeID).NormalBalance == "CREDIT" {
+		return booked.Neg()
+	}
+	return booked
+}
+
+// AccountCurrencies returns the currency of each account with its precision.
+// Unknown accounts are left out of the map.
+func (r *AccountRepository) AccountCurrencies(ctx context.Context, tenantID uuid.UUID, accountIDs []uuid.UUID) (map[uuid.UUID]repository.AccountCurrency, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	currencies := make(map[uuid.UUID]repository.AccountCurrency, len(accountIDs))
+	for _, accountID := range accountIDs {
+		account := s.account(tenantID, accountID)
+		if account == nil {
+			continue
+		}
+		currency := s.currency(account.CurrencyCode)
+		currencies[accountID] = repository.AccountCurrency{
+			CurrencyCode: currency.Code,
+			Precision:    currency.Precision,
+		}
+	}
+
+	return currencies, nil
+}
+
+// Delete soft-deletes an account; accounts with a balance or active children cannot be deleted
+func (r *AccountRepository) Delete(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID) (*repository.Account, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := s.account(tenantID, accountID)
+	if account == nil || account.DeletedAt != nil {
+		return nil, fmt.Errorf("account %w", repository.ErrNotFound)
+	}
+
+	balance := s.balances[accountID]
+	if !balance.DebitBalance.Equal(balance.CreditBalance) {
+		return nil, repository.ErrNonZeroBalance
+	}
+
+	for _, record := range s.accounts {
+		child := record.account
+		if child.ParentAccountID != nil && *child.ParentAccountID == accountID && child.DeletedAt == nil {
+			return nil, repository.ErrAccountHasChildren
+		}
+	}
+
+	now := time.Now().UTC()
+	account.DeletedAt = &now
+	account.UpdatedAt = now
+
+	return s.cloneAccount(account), nil
+}
+
+// Restore reverses the soft deletion of an account
+func (r *AccountRepository) Restore(ctx context.Context, tenantID uuid.UUID, accountID uuid.UUID, maxAccounts *int32) (*repository.Account, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := s.account(tenantID, accountID)
+	if account == nil || account.DeletedAt == nil {
+		return nil, fmt.Errorf("deleted account %w", repository.ErrNotFound)
+	}
+	if err := s.checkAccountQuota(tenantID, maxAccounts); err != nil {
+		return nil, err
+	}
+
+	account.DeletedAt = nil
+	account.UpdatedAt = time.Now().UTC()
+
+	return s.cloneAccount(account), nil
+}
+
+// checkAccountQuota rejects a new or restored account once the tenant has
+// maxAccounts that are not deleted; the caller must hold the lock
+func (s *Store) checkAccountQuota(tenantID uuid.UUID, maxAccounts *int32) error {
+	if maxAccounts == nil {
+		return nil
+	}
+	count := 0
+	for _, record := range s.accounts {
+		if record.account.TenantID == tenantID && record.account.DeletedAt == nil {
+			count++
+		}
+	}
+	if count >= int(*maxAccounts) {
+		return fmt.Errorf("account %w: limit is %d accounts", repository.ErrQuotaExceeded, *maxAccounts)
+	}
+	return nil
+}
+
+// account returns an account of the tenant, deleted or not, or nil; the
+// caller must hold the lock
+func (s *Store) account(tenantID, accountID uuid.UUID) *repository.Account {
+	record, ok := s.accounts[accountID]
+	if !ok || record.account.TenantID != tenantID {
+		return nil
+	}
+	return record.account
+}
+
+// ancestors returns the parent of an account, its parent and so on up to
+// the root of its tree; the caller must hold the lock
+func (s *Store) ancestors(account *repository.Account) []*repository.Account {
+	var ancestors []*repository.Account
+	visited := map[uuid.UUID]bool{account.ID: true}
+	for account.ParentAccountID != nil && !visited[*account.ParentAccountID] {
+		record, ok := s.accounts[*account.ParentAccountID]
+		if !ok {
+			break
+		}
+		account = record.account
+		visited[account.ID] = true
+		ancestors = append(ancestors, account)
+	}
+	return ancestors
+}
+
+// hasAncestor reports whether an account is a descendant of another; the
+// caller must hold the lock
+func (s *Store) hasAncestor(account *repository.Account, ancestorID uuid.UUID) bool {
+	for _, ancestor := range s.ancestors(account) {
+		if ancestor.ID == ancestorID {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneAccount copies an account with its depth and path; the caller must
+// hold the lock
+func (s *Store) cloneAccount(account *repository.Account) *repository.Account {
+	c := *account
+	c.Description = cloneString(account.Description)
+	c.ParentAccountID = cloneUUID(account.ParentAccountID)
+	c.DeletedAt = cloneTime(account.DeletedAt)
+	c.OverdraftLimit = cloneDecimal(account.OverdraftLimit)
+	c.Labels = cloneStringMap(account.Labels)
+	c.ExternalIDs = cloneStringMap(account.ExternalIDs)
+
+	ancestors := s.ancestors(account)
+	numbers := make([]string, 0, len(ancestors)+1)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		numbers = append(numbers, ancestors[i].AccountNumber)
+	}
+	c.Depth = int32(len(ancestors))
+	c.Path = strings.Join(append(numbers, account.AccountNumber), "/")
+	return &c
+}
@@ -0,0 +1,297 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTenant creates a store with one tenant
+func newTenant(t *testing.T) (*Store, uuid.UUID) {
+	t.Helper()
+	store := NewStore()
+	tenant, err := NewTenantRepository(store).Create(context.Background(), "tenant", nil)
+	require.NoError(t, err)
+	return store, tenant.ID
+}
+
+func createAccount(t *testing.T, repo *AccountRepository, tenantID uuid.UUID, number, name string) *repository.Account {
+	t.Helper()
+	account, err := repo.Create(context.Background(), tenantID, repository.CreateAccountParams{
+		AccountNumber: number,
+		Name:          name,
+		AccountTypeID: 1,
+		CurrencyCode:  "USD",
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestAccountRepository_Create(t *testing.T) {
+	ctx := context.Background()
+	store, tenantID := newTenant(t)
+	repo := NewAccountRepository(store)
+
+	account := createAccount(t, repo, tenantID, "1000", "Cash")
+	assert.Equal(t, tenantID, account.TenantID)
+	assert.True(t, account.IsActive)
+
+	balance, err := repo.GetBalance(ctx, tenantID, account.ID)
+	require.NoError(t, err)
+	assert.True(t, balance.DebitBalance.IsZero())
+	assert.True(t, balance.CreditBalance.IsZero())
+
+	unknown := uuid.New()
+	tests := []struct {
+		name   string
+		tenant uuid.UUID
+		params repository.CreateAccountParams
+		check  func(error) bool
+	}{
+		{
+			name:   "duplicate account number",
+			tenant: tenantID,
+			params: repository.CreateAccountParams{AccountNumber: "1000", Name: "Other", AccountTypeID: 1, CurrencyCode: "USD"},
+			check:  repository.IsUniqueViolation,
+		},
+		{
+			name:   "unknown tenant",
+			tenant: uuid.New(),
+			params: repository.CreateAccountParams{AccountNumber: "1000", Name: "Cash", AccountTypeID: 1, CurrencyCode: "USD"},
+			check:  repository.IsForeignKeyViolation,
+		},
+		{
+			name:   "unknown account type",
+			tenant: tenantID,
+			params: repository.CreateAccountParams{AccountNumber: "1001", Name: "Cash", AccountTypeID: 99, CurrencyCode: "USD"},
+			check:  repository.IsForeignKeyViolation,
+		},
+		{
+			name:   "unknown currency",
+			tenant: tenantID,
+			params: repository.CreateAccountParams{AccountNumber: "1001", Name: "Cash", AccountTypeID: 1, CurrencyCode: "XXX"},
+			check:  repository.IsForeignKeyViolation,
+		},
+		{
+			name:   "unknown parent",
+			tenant: tenantID,
+			params: repository.CreateAccountParams{AccountNumber: "1001", Name: "Cash", AccountTypeID: 1, CurrencyCode: "USD", ParentAccountID: &unknown},
+			check:  repository.IsForeignKeyViolation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.Create(ctx, tt.tenant, tt.params)
+			assert.True(t, tt.check(err), "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestAccountRepository_List(t *testing.T) {
+	ctx := context.Background()
+	store, tenantID := newTenant(t)
+	repo := NewAccountRepository(store)
+
+	cash := createAccount(t, repo, tenantID, "1000", "Cash")
+	bank := createAccount(t, repo, tenantID, "1100", "Bank")
+	sales := createAccount(t, repo, tenantID, "4000", "Sales")
+
+	otherTenant, err := NewTenantRepository(store).Create(ctx, "other", nil)
+	require.NoError(t, err)
+	createAccount(t, repo, otherTenant.ID, "1000", "Cash")
+
+	ids := func(accounts []*repository.Account) []uuid.UUID {
+		result := make([]uuid.UUID, len(accounts))
+		for i, a := range accounts {
+			result[i] = a.ID
+		}
+		return result
+	}
+
+	t.Run("lists the newest accounts of the tenant first", func(t *testing.T) {
+		accounts, total, err := repo.List(ctx, tenantID, repository.AccountFilter{}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.Equal(t, []uuid.UUID{sales.ID, bank.ID, cash.ID}, ids(accounts))
+	})
+
+	t.Run("sorts and pages", func(t *testing.T) {
+		accounts, total, err := repo.List(ctx, tenantID, repository.AccountFilter{SortBy: repository.AccountSortName}, 2, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.Equal(t, []uuid.UUID{cash.ID, sales.ID}, ids(accounts))
+	})
+
+	t.Run("filters by prefix", func(t *testing.T) {
+		prefix := "1"
+		accounts, _, err := repo.List(ctx, tenantID, repository.AccountFilter{NumberPrefix: &prefix, SortBy: repository.AccountSortNumber}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{cash.ID, bank.ID}, ids(accounts))
+
+		name := "sal"
+		accounts, _, err = repo.List(ctx, tenantID, repository.AccountFilter{NamePrefix: &name}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{sales.ID}, ids(accounts))
+	})
+
+	t.Run("includes deleted accounts on request", func(t *testing.T) {
+		_, err := repo.Delete(ctx, tenantID, bank.ID)
+		require.NoError(t, err)
+
+		_, total, err := repo.List(ctx, tenantID, repository.AccountFilter{}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+
+		_, total, err = repo.List(ctx, tenantID, repository.AccountFilter{IncludeDeleted: true}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+	})
+}
+
+func TestAccountRepository_Delete(t *testing.T) {
+	ctx := context.Background()
+	store, tenantID := newTenant(t)
+	repo := NewAccountRepository(store)
+	journal := NewJournalRepository(store)
+
+	cash := createAccount(t, repo, tenantID, "1000", "Cash")
+	equity := createAccount(t, repo, tenantID, "3000", "Equity")
+	child, err := repo.Create(ctx, tenantID, repository.CreateAccountParams{
+		AccountNumber: "3100", Name: "Retained", AccountTypeID: 3, CurrencyCode: "USD", ParentAccountID: &equity.ID,
+	})
+	require.NoError(t, err)
+
+	_, err = journal.Create(ctx, tenantID, entryParams(cash.ID, child.ID, "100"))
+	require.NoError(t, err)
+
+	t.Run("rejects accounts with a balance", func(t *testing.T) {
+		_, err := repo.Delete(ctx, tenantID, cash.ID)
+		assert.ErrorIs(t, err, repository.ErrNonZeroBalance)
+	})
+
+	t.Run("rejects accounts with active children", func(t *testing.T) {
+		_, err := repo.Delete(ctx, tenantID, equity.ID)
+		assert.ErrorIs(t, err, repository.ErrAccountHasChildren)
+	})
+
+	t.Run("hides deleted accounts until restored", func(t *testing.T) {
+		_, err := journal.Create(ctx, tenantID, entryParams(child.ID, cash.ID, "100"))
+		require.NoError(t, err)
+
+		deleted, err := repo.Delete(ctx, tenantID, cash.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, deleted.DeletedAt)
+
+		_, err = repo.GetByID(ctx, tenantID, cash.ID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		_, err = repo.Restore(ctx, tenantID, cash.ID, nil)
+		require.NoError(t, err)
+		_, err = repo.GetByID(ctx, tenantID, cash.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("counts only accounts that are not deleted against the quota", func(t *testing.T) {
+		_, err := repo.Delete(ctx, tenantID, cash.ID)
+		require.NoError(t, err)
+
+		// Equity, its child and the new account fill a quota of three
+		maxAccounts := int32(3)
+		_, err = repo.Create(ctx, tenantID, repository.CreateAccountParams{
+			AccountNumber: "1100", Name: "Bank", AccountTypeID: 1, CurrencyCode: "USD", MaxAccounts: &maxAccounts,
+		})
+		require.NoError(t, err)
+
+		_, err = repo.Restore(ctx, tenantID, cash.ID, &maxAccounts)
+		assert.ErrorIs(t, err, repository.ErrQuotaExceeded)
+
+		maxAccounts = 4
+		_, err = repo.Restore(ctx, tenantID, cash.ID, &maxAccounts)
+		assert.NoError(t, err)
+	})
+
+	t.Run("hides accounts of other tenants", func(t *testing.T) {
+		_, err := repo.GetByID(ctx, uuid.New(), cash.ID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = repo.GetBalance(ctx, uuid.New(), cash.ID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("reports account currencies", func(t *testing.T) {
+		currencies, err := repo.AccountCurrencies(ctx, tenantID, []uuid.UUID{cash.ID, uuid.New()})
+		require.NoError(t, err)
+		assert.Equal(t, map[uuid.UUID]repository.AccountCurrency{
+			cash.ID: {CurrencyCode: "USD", Precision: 2},
+		}, currencies)
+	})
+}
+
+// entryParams builds a two-line entry moving amount from credit to debit
+func entryParams(debit, credit uuid.UUID, amount string) repository.CreateJournalEntryParams {
+	value := decimal.RequireFromString(amount)
+	return repository.CreateJournalEntryParams{
+		ReferenceNumber: "JE-1",
+		Description:     "Test entry",
+		EntryDate:       testDate,
+		Lines: []*repository.CreateJournalEntryLineParams{
+			{AccountID: debit, Debit: value, Credit: decimal.Zero},
+			{AccountID: credit, Debit: decimal.Zero, Credit: value},
+		},
+	}
+}
+
+func TestAccountRepository_Move(t *testing.T) {
+	ctx := context.Background()
+	store, tenantID := newTenant(t)
+	repo := NewAccountRepository(store)
+
+	assets := createAccount(t, repo, tenantID, "1000", "Assets")
+	cash := createAccount(t, repo, tenantID, "1100", "Cash")
+	petty := createAccount(t, repo, tenantID, "1110", "Petty cash")
+
+	_, err := repo.Move(ctx, tenantID, cash.ID, &assets.ID)
+	require.NoError(t, err)
+	moved, err := repo.Move(ctx, tenantID, petty.ID, &cash.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), moved.Depth)
+	assert.Equal(t, "1000/1100/1110", moved.Path)
+
+	t.Run("lists descendants at any depth", func(t *testing.T) {
+		accounts, total, err := repo.List(ctx, tenantID, repository.AccountFilter{AncestorAccountID: &assets.ID}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		for _, account := range accounts {
+			assert.NotEqual(t, assets.ID, account.ID)
+		}
+	})
+
+	t.Run("rejects cycles", func(t *testing.T) {
+		_, err := repo.Move(ctx, tenantID, assets.ID, &petty.ID)
+		assert.ErrorIs(t, err, repository.ErrAccountCycle)
+		_, err = repo.Move(ctx, tenantID, assets.ID, &assets.ID)
+		assert.ErrorIs(t, err, repository.ErrAccountCycle)
+	})
+
+	t.Run("rejects unknown parents", func(t *testing.T) {
+		missing := uuid.New()
+		_, err := repo.Move(ctx, tenantID, cash.ID, &missing)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("makes an account a root account", func(t *testing.T) {
+		root, err := repo.Move(ctx, tenantID, cash.ID, nil)
+		require.NoError(t, err)
+		assert.Zero(t, root.Depth)
+		assert.Equal(t, "1100", root.Path)
+
+		child, err := repo.GetByID(ctx, tenantID, petty.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "1100/1110", child.Path)
+	})
+}
@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/repository"
+)
+
+// BookRepository is an in-memory repository.BookRepositoryInterface
+type BookRepository struct {
+	store *Store
+}
+
+// NewBookRepository creates a new in-memory book repository
+func NewBookRepository(store *Store) *BookRepository {
+	return &BookRepository{store: store}
+}
+
+var _ repository.BookRepositoryInterface = (*BookRepository)(nil)
+
+// Create creates a book that is not the default. The tenant must exist and
+// codes are unique within a tenant.
+func (r *BookRepository) Create(ctx context.Context, tenantID uuid.UUID, params repository.CreateBookParams) (*repository.Book, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tenants[tenantID]; !ok {
+		return nil, fmt.Errorf("failed to create book: %w", foreignKeyViolation("books_tenant_id_fkey"))
+	}
+	for _, book := range s.books {
+		if book.TenantID == tenantID && book.Code == params.Code {
+			return nil, fmt.Errorf("failed to create book: %w", uniqueViolation("books_tenant_id_code_key"))
+		}
+	}
+
+	now := time.Now().UTC()
+	book := &repository.Book{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		Code:        params.Code,
+		Name:        params.Name,
+		Description: cloneString(params.Description),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	s.books[book.ID] = book
+
+	return cloneBook(book), nil
+}
+
+// GetByID retrieves a book of the tenant
+func (r *BookRepository) GetByID(ctx context.Context, tenantID uuid.UUID, bookID uuid.UUID) (*repository.Book, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	book := s.book(tenantID, bookID)
+	if book == nil {
+		return nil, fmt.Errorf("book %w", repository.ErrNotFound)
+	}
+
+	return cloneBook(book), nil
+}
+
+// List retrieves the books of a tenant, the default book first and the
+// others ordered by code
+func (r *BookRepository) List(ctx context.Context, tenantID uuid.UUID) ([]*repository.Book, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	books := make([]*repository.Book, 0)
+	for _, book := range s.books {
+		if book.TenantID == tenantID {
+			books = append(books, cloneBook(book))
+		}
+	}
+	sort.Slice(books, func(i, j int) bool {
+		if books[i].IsDefault != books[j].IsDefault {
+			return books[i].IsDefault
+		}
+		return books[i].Code < books[j].Code
+	})
+
+	return books, nil
+}
+
+// book returns a book of the tenant or nil; the caller must hold the lock
+func (s *Store) book(tenantID, bookID uuid.UUID) *repository.Book {
+	book, ok := s.books[bookID]
+	if !ok || book.TenantID != tenantID {
+		return nil
+	}
+	return book
+}
+
+// defaultBook returns the default book of the tenant or nil; the caller
+// must hold the lock
+func (s *Store) defaultBook(tenantID uuid.UUID) *repository.Book {
+	for _, book := range s.books {
+		if book.TenantID == tenantID && book.IsDefault {
+			return book
+		}
+	}
+	return nil
+}
+
+func cloneBook(book *repository.Book) *repository.Book {
+	c := *book
+	c.Description = cloneString(book.Description)
+	return &c
+}
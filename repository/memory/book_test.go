@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookRepository(t *testing.T) {
+	ctx := context.Background()
+	store, tenantID := newTenant(t)
+	repo := NewBookRepository(store)
+
+	books, err := repo.List(ctx, tenantID)
+	require.NoError(t, err)
+	require.Len(t, books, 1)
+	assert.Equal(t, repository.DefaultBookCode, books[0].Code)
+	assert.True(t, books[0].IsDefault)
+
+	local, err := repo.Create(ctx, tenantID, repository.CreateBookParams{Code: "LOCAL", Name: "Local GAAP"})
+	require.NoError(t, err)
+	assert.False(t, local.IsDefault)
+
+	_, err = repo.Create(ctx, tenantID, repository.CreateBookParams{Code: "IFRS", Name: "IFRS"})
+	require.NoError(t, err)
+
+	books, err = repo.List(ctx, tenantID)
+	require.NoError(t, err)
+	require.Len(t, books, 3)
+	assert.Equal(t, []string{repository.DefaultBookCode, "IFRS", "LOCAL"}, []string{books[0].Code, books[1].Code, books[2].Code})
+
+	got, err := repo.GetByID(ctx, tenantID, local.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Local GAAP", got.Name)
+
+	_, err = repo.Create(ctx, tenantID, repository.CreateBookParams{Code: "LOCAL", Name: "Again"})
+	assert.True(t, repository.IsUniqueViolation(err))
+
+	_, err = repo.Create(ctx, uuid.New(), repository.CreateBookParams{Code: "LOCAL", Name: "Local GAAP"})
+	assert.True(t, repository.IsForeignKeyViolation(err))
+
+	_, err = repo.GetByID(ctx, tenantID, uuid.New())
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
@@ -0,0 +1,533 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/repository"
+	"github.com/shopspring/decimal"
+)
+
+// ErrUnbalancedEntry is returned when the debits of a journal entry do not
+// equal its credits
+var ErrUnbalancedEntry = errors.New("journal entry debits and credits do not balance")
+
+// JournalRepository is an in-memory repository.JournalRepositoryInterface
+type JournalRepository struct {
+	store *Store
+}
+
+// NewJournalRepository creates a new in-memory journal repository
+func NewJournalRepository(store *Store) *JournalRepository {
+	return &JournalRepository{store: store}
+}
+
+var _ repository.JournalRepositoryInterface = (*JournalRepository)(nil)
+
+// Create posts a journal entry. The entry must have at least two lines, each
+// a debit or a credit to an active account of the tenant, with debits equal
+// to credits, and post to a single book. Posting appends the entry to the tenant's hash chain and
+// updates the balances of its accounts.
+func (r *JournalRepository) Create(ctx context.Context, tenantID uuid.UUID, params repository.CreateJournalEntryParams) (*repository.JournalEntry, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tenants[tenantID]; !ok {
+		return nil, fmt.Errorf("failed to create journal entry: %w", foreignKeyViolation("journal_entries_tenant_id_fkey"))
+	}
+
+	entry, err := s.postEntry(tenantID, params)
+	if err != nil {
+		return nil, err
+	}
+	return cloneEntry(entry), nil
+}
+
+// postEntry checks and posts a journal entry of a tenant; the caller must
+// hold the lock
+func (s *Store) postEntry(tenantID uuid.UUID, params repository.CreateJournalEntryParams) (*repository.JournalEntry, error) {
+	if err := s.checkLines(tenantID, params.BookID, params.Lines); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAvailableBalances(tenantID, params.Lines); err != nil {
+		return nil, err
+	}
+
+	if params.IdempotencyKey != "" && s.entryByIdempotencyKey(tenantID, params.IdempotencyKey) != nil {
+		return nil, fmt.Errorf("failed to create journal entry: %w", uniqueViolation("journal_entry_idempotency_keys_pkey"))
+	}
+
+	metadata, err := cloneMetadata(params.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	y, m, d := params.EntryDate.Date()
+	entry := &repository.JournalEntry{
+		ID:              uuid.New(),
+		TenantID:        tenantID,
+		ReferenceNumber: params.ReferenceNumber,
+		Description:     params.Description,
+		EntryDate:       time.Date(y, m, d, 0, 0, 0, 0, time.UTC),
+		PostedAt:        now,
+		Metadata:        metadata,
+		Lines:           make([]*repository.JournalEntryLine, len(params.Lines)),
+		CurrencyCode:    params.CurrencyCode,
+		TransactionID:   params.TransactionID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if account := s.account(tenantID, params.Lines[0].AccountID); account != nil {
+		entry.BookID = account.BookID
+	}
+	if params.LockOverrideReason != nil {
+		reason := *params.LockOverrideReason
+		entry.LockOverrideReason = &reason
+	}
+
+	for i, line := range params.Lines {
+		entry.Lines[i] = &repository.JournalEntryLine{
+			ID:                   uuid.New(),
+			JournalEntryID:       entry.ID,
+			AccountID:            line.AccountID,
+			Debit:                line.Debit,
+			Credit:               line.Credit,
+			Description:          line.Description,
+			CounterpartyTenantID: cloneUUID(line.CounterpartyTenantID),
+			FxRate:               cloneDecimal(line.FxRate),
+			TaxCodeID:            cloneUUID(line.TaxCodeID),
+			IsTax:                line.TaxCodeID != nil && line.IsTax,
+			PartyID:              cloneUUID(line.PartyID),
+			Dimensions:           cloneDimensions(line.Dimensions),
+			CreatedAt:            now,
+		}
+	}
+
+	chain := s.chains[tenantID]
+	var previousHash []byte
+	if len(chain) > 0 {
+		previousHash = chain[len(chain)-1].hash
+	}
+
+	hash, err := repository.EntryHash(previousHash, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &entryRecord{
+		entry:          entry,
+		idempotencyKey: params.IdempotencyKey,
+		transactionID:  params.TransactionID,
+		sequence:       s.nextSequence(),
+		chainIndex:     int64(len(chain)) + 1,
+		previousHash:   previousHash,
+		hash:           hash,
+	}
+	s.entries[entry.ID] = record
+	s.chains[tenantID] = append(chain, record)
+
+	for _, line := range entry.Lines {
+		balance := s.balances[line.AccountID]
+		balance.DebitBalance = balance.DebitBalance.Add(line.Debit)
+		balance.CreditBalance = balance.CreditBalance.Add(line.Credit)
+		balance.UpdatedAt = now
+	}
+
+	if s.onBalanceChange != nil {
+		notified := make(map[uuid.UUID]bool, len(entry.Lines))
+		for _, line := range entry.Lines {
+			if !notified[line.AccountID] {
+				notified[line.AccountID] = true
+				s.onBalanceChange(repository.BalanceChange{TenantID: tenantID, AccountID: line.AccountID})
+			}
+		}
+	}
+
+	return entry, nil
+}
+
+// checkAvailableBalances rejects a posting that would leave an account with
+// an overdraft limit below its available balance; accounts the lines do not
+// reduce are not checked. The caller must hold the lock.
+func (s *Store) checkAvailableBalances(tenantID uuid.UUID, lines []*repository.CreateJournalEntryLineParams) error {
+	nets := make(map[uuid.UUID]decimal.Decimal, len(lines))
+	for _, line := range lines {
+		nets[line.AccountID] = nets[line.AccountID].Add(line.Debit).Sub(line.Credit)
+	}
+
+	var overdrawn []string
+	for accountID, net := range nets {
+		account := s.account(tenantID, accountID)
+		if account == nil || account.OverdraftLimit == nil {
+			continue
+		}
+		before, after := s.bookedBalance(account, decimal.Zero), s.bookedBalance(account, net)
+		if after.LessThan(before) && after.Add(*account.OverdraftLimit).IsNegative() {
+			overdrawn = append(overdrawn, account.AccountNumber)
+		}
+	}
+
+	if len(overdrawn) == 0 {
+		return nil
+	}
+	sort.Strings(overdrawn)
+	return fmt.Errorf("account %s: %w", overdrawn[0], repository.ErrInsufficientFunds)
+}
+
+// checkLines enforces the double-entry rules of a posting and keeps it to
+// one book, the given one when set; the caller must hold the lock
+func (s *Store) checkLines(tenantID uuid.UUID, bookID *uuid.UUID, lines []*repository.CreateJournalEntryLineParams) error {
+	if len(lines) < 2 {
+		return fmt.Errorf("failed to create journal entry: at least two lines are required: %w", ErrUnbalancedEntry)
+	}
+
+	// Postings to deleted accounts and across books are rejected before
+	// anything else, as in the Postgres repository
+	for _, line := range lines {
+		if account := s.account(tenantID, line.AccountID); account != nil && account.DeletedAt != nil {
+			return repository.ErrDeletedAccount
+		}
+	}
+	for _, line := range lines {
+		account := s.account(tenantID, line.AccountID)
+		if account == nil {
+			continue
+		}
+		if bookID != nil && account.BookID != *bookID {
+			return repository.ErrBookMismatch
+		}
+		bookID = &account.BookID
+	}
+
+	// Amounts are in the currency of their account, so each currency must
+	// balance on its own
+	type total struct{ debit, credit decimal.Decimal }
+	totals := make(map[string]total)
+	for i, line := range lines {
+		account := s.account(tenantID, line.AccountID)
+		if account == nil {
+			return fmt.Errorf("failed to create journal entry: %w", foreignKeyViolation("journal_entry_lines_account_id_fkey"))
+		}
+		if line.Debit.IsNegative() || line.Credit.IsNegative() || line.Debit.IsPositive() == line.Credit.IsPositive() {
+			return fmt.Errorf("failed to create journal entry: line %d must have either a positive debit or a positive credit", i+1)
+		}
+		t := totals[account.CurrencyCode]
+		totals[account.CurrencyCode] = total{debit: t.debit.Add(line.Debit), credit: t.credit.Add(line.Credit)}
+	}
+
+	for _, currency := range slices.Sorted(maps.Keys(totals)) {
+		if t := totals[currency]; !t.debit.Equal(t.credit) {
+			return fmt.Errorf("failed to create journal entry: debits %s, credits %s in %s: %w", t.debit, t.credit, currency, ErrUnbalancedEntry)
+		}
+	}
+
+	return nil
+}
+
+// GetByID retrieves a journal entry of the tenant with its lines
+func (r *JournalRepository) GetByID(ctx context.Context, tenantID uuid.UUID, journalEntryID uuid.UUID) (*repository.JournalEntry, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.entries[journalEntryID]
+	if !ok || record.entry.TenantID != tenantID {
+		return nil, fmt.Errorf("journal entry %w", repository.ErrNotFound)
+	}
+
+	return cloneEntry(record.entry), nil
+}
+
+// GetByIdempotencyKey retrieves the journal entry posted with an idempotency key
+func (r *JournalRepository) GetByIdempotencyKey(ctx context.Context, tenantID uuid.UUID, key string) (*repository.JournalEntry, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record := s.entryByIdempotencyKey(tenantID, key)
+	if record == nil {
+		return nil, fmt.Errorf("journal entry %w", repository.ErrNotFound)
+	}
+
+	return cloneEntry(record.entry), nil
+}
+
+// entryByIdempotencyKey finds the entry of a tenant posted with an
+// idempotency key; the caller must hold the lock
+func (s *Store) entryByIdempotencyKey(tenantID uuid.UUID, key string) *entryRecord {
+	for _, record := range s.chains[tenantID] {
+		if record.idempotencyKey == key {
+			return record
+		}
+	}
+	return nil
+}
+
+// ListByTransactionID retrieves the journal entries of a business
+// transaction, oldest first
+func (r *JournalRepository) ListByTransactionID(ctx context.Context, tenantID uuid.UUID, transactionID string) ([]*repository.JournalEntry, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]*repository.JournalEntry, 0)
+	for _, record := range s.chains[tenantID] {
+		if transactionID != "" && record.transactionID == transactionID {
+			entries = append(entries, cloneEntry(record.entry))
+		}
+	}
+	return entries, nil
+}
+
+// List retrieves journal entries matching a filter, newest first, with the
+// totals of all matching entries
+func (r *JournalRepository) List(ctx context.Context, tenantID uuid.UUID, filter repository.JournalEntryFilter, limit, offset int) ([]*repository.JournalEntry, *repository.JournalEntryTotals, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]*entryRecord, 0)
+	totals := &repository.JournalEntryTotals{Debit: decimal.Zero, Credit: decimal.Zero}
+	for _, record := range s.chains[tenantID] {
+		if !s.matchesEntryFilter(record.entry, filter) {
+			continue
+		}
+		records = append(records, record)
+		for _, line := range record.entry.Lines {
+			if filter.AccountID == nil || line.AccountID == *filter.AccountID {
+				totals.Debit = totals.Debit.Add(line.Debit)
+				totals.Credit = totals.Credit.Add(line.Credit)
+			}
+		}
+	}
+	totals.EntryCount = len(records)
+	sort.Slice(records, func(i, j int) bool {
+		return newerEntry(records[i], records[j])
+	})
+
+	entries := make([]*repository.JournalEntry, 0)
+	for _, record := range page(records, limit, offset) {
+		entries = append(entries, cloneEntry(record.entry))
+	}
+
+	return entries, totals, nil
+}
+
+func (s *Store) matchesEntryFilter(entry *repository.JournalEntry, filter repository.JournalEntryFilter) bool {
+	if filter.BookID != nil {
+		found := false
+		for _, line := range entry.Lines {
+			if account, ok := s.accounts[line.AccountID]; ok && account.account.BookID == *filter.BookID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if filter.AccountID != nil {
+		found := false
+		for _, line := range entry.Lines {
+			if line.AccountID == *filter.AccountID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	total := decimal.Zero
+	for _, line := range entry.Lines {
+		total = total.Add(line.Debit)
+	}
+
+	switch {
+	case !inDateRange(entry.EntryDate, filter.FromDate, filter.ToDate):
+		return false
+	case filter.PostedFrom != nil && entry.PostedAt.Before(*filter.PostedFrom):
+		return false
+	case filter.PostedTo != nil && entry.PostedAt.After(*filter.PostedTo):
+		return false
+	case filter.ReferenceNumber != nil && entry.ReferenceNumber != *filter.ReferenceNumber:
+		return false
+	case filter.ReferencePrefix != nil && !strings.HasPrefix(entry.ReferenceNumber, *filter.ReferencePrefix):
+		return false
+	case filter.DescriptionContains != nil && !strings.Contains(strings.ToLower(entry.Description), strings.ToLower(*filter.DescriptionContains)):
+		return false
+	case filter.MinAmount != nil && total.LessThan(*filter.MinAmount):
+		return false
+	case filter.MaxAmount != nil && total.GreaterThan(*filter.MaxAmount):
+		return false
+	}
+	return true
+}
+
+func inDateRange(date time.Time, fromDate, toDate *time.Time) bool {
+	return (fromDate == nil || !date.Before(*fromDate)) && (toDate == nil || !date.After(*toDate))
+}
+
+// newerEntry orders entries by entry date and then posting order, newest first
+func newerEntry(a, b *entryRecord) bool {
+	if !a.entry.EntryDate.Equal(b.entry.EntryDate) {
+		return a.entry.EntryDate.After(b.entry.EntryDate)
+	}
+	return a.sequence > b.sequence
+}
+
+// Search retrieves journal entries matching a query over their description,
+// reference number, line descriptions and metadata values, best matches
+// first. Like the Postgres repository it accepts web search syntax
+// ("quoted phrases", OR, -excluded words) and matches whole words without
+// stemming.
+func (r *JournalRepository) Search(ctx context.Context, tenantID uuid.UUID, text string, fromDate, toDate *time.Time, limit, offset int) ([]*repository.JournalEntry, int, error) {
+	query := parseSearchQuery(text)
+
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type match struct {
+		record *entryRecord
+		rank   int
+	}
+
+	matches := make([]match, 0)
+	for _, record := range s.chains[tenantID] {
+		if !inDateRange(record.entry.EntryDate, fromDate, toDate) {
+			continue
+		}
+		if rank, ok := query.match(searchWords(record.entry)); ok {
+			matches = append(matches, match{record: record, rank: rank})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].rank != matches[j].rank {
+			return matches[i].rank > matches[j].rank
+		}
+		return newerEntry(matches[i].record, matches[j].record)
+	})
+
+	entries := make([]*repository.JournalEntry, 0)
+	for _, m := range page(matches, limit, offset) {
+		entries = append(entries, cloneEntry(m.record.entry))
+	}
+
+	return entries, len(matches), nil
+}
+
+// Stream calls fn for every journal entry in a date range, with its lines,
+// oldest first. The entries are copied before fn is called, so fn may use
+// the repositories of the same store; returning an error from fn stops the
+// stream.
+func (r *JournalRepository) Stream(ctx context.Context, tenantID uuid.UUID, fromDate, toDate *time.Time, fn func(*repository.JournalEntry) error) error {
+	s := r.store
+	s.mu.RLock()
+	records := make([]*entryRecord, 0)
+	for _, record := range s.chains[tenantID] {
+		if inDateRange(record.entry.EntryDate, fromDate, toDate) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return newerEntry(records[j], records[i])
+	})
+
+	entries := make([]*repository.JournalEntry, len(records))
+	for i, record := range records {
+		entries[i] = cloneEntry(record.entry)
+	}
+	s.mu.RUnlock()
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// VerifyIntegrity recomputes the hash chain of a tenant's journal entries and
+// reports the first entry that does not match
+func (r *JournalRepository) VerifyIntegrity(ctx context.Context, tenantID uuid.UUID) (*repository.LedgerIntegrity, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := &repository.LedgerIntegrity{Valid: true}
+
+	var previousHash []byte
+	for _, record := range s.chains[tenantID] {
+		hash, err := repository.EntryHash(previousHash, record.entry)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case !bytes.Equal(record.previousHash, previousHash):
+			result.Reason = "previous hash does not match the preceding entry"
+		case !bytes.Equal(hash, record.hash):
+			result.Reason = "entry content does not match its hash"
+		}
+
+		if result.Reason != "" {
+			id := record.entry.ID
+			result.Valid = false
+			result.FirstInvalidEntryID = &id
+			break
+		}
+
+		result.EntriesVerified++
+		result.HeadSequence = record.chainIndex
+		result.HeadHash = bytes.Clone(record.hash)
+		previousHash = record.hash
+	}
+
+	return result, nil
+}
+
+func cloneEntry(entry *repository.JournalEntry) *repository.JournalEntry {
+	c := *entry
+	// Stored metadata came out of a JSON round trip, so it cannot fail to encode
+	c.Metadata, _ = cloneMetadata(entry.Metadata)
+	c.Lines = make([]*repository.JournalEntryLine, len(entry.Lines))
+	for i, line := range entry.Lines {
+		l := *line
+		l.CounterpartyTenantID = cloneUUID(line.CounterpartyTenantID)
+		l.FxRate = cloneDecimal(line.FxRate)
+		l.TaxCodeID = cloneUUID(line.TaxCodeID)
+		l.PartyID = cloneUUID(line.PartyID)
+		l.Dimensions = cloneDimensions(line.Dimensions)
+		c.Lines[i] = &l
+	}
+	return &c
+}
+
+func cloneDimensions(dimensions map[string]string) map[string]string {
+	if dimensions == nil {
+		return nil
+	}
+	c := make(map[string]string, len(dimensions))
+	for k, v := range dimensions {
+		c[k] = v
+	}
+	return c
+}
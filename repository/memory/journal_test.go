@@ -0,0 +1,326 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDate = time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+func TestJournalRepository_Create(t *testing.T) {
+	ctx := context.Background()
+	store, tenantID := newTenant(t)
+	accounts := NewAccountRepository(store)
+	journal := NewJournalRepository(store)
+
+	cash := createAccount(t, accounts, tenantID, "1000", "Cash")
+	sales := createAccount(t, accounts, tenantID, "4000", "Sales")
+	euroSales, err := accounts.Create(ctx, tenantID, repository.CreateAccountParams{
+		AccountNumber: "4100", Name: "Sales EUR", AccountTypeID: 1, CurrencyCode: "EUR",
+	})
+	require.NoError(t, err)
+
+	t.Run("posts an entry and updates balances", func(t *testing.T) {
+		params := entryParams(cash.ID, sales.ID, "150.25")
+		params.Metadata = map[string]interface{}{"invoice": "INV-7", "count": 3}
+
+		entry, err := journal.Create(ctx, tenantID, params)
+		require.NoError(t, err)
+		assert.Equal(t, tenantID, entry.TenantID)
+		require.Len(t, entry.Lines, 2)
+		assert.Equal(t, entry.ID, entry.Lines[0].JournalEntryID)
+		// Metadata round-trips through JSON like a jsonb column
+		assert.Equal(t, float64(3), entry.Metadata["count"])
+
+		balance, err := accounts.GetBalance(ctx, tenantID, cash.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "150.25", balance.DebitBalance.String())
+		assert.True(t, balance.CreditBalance.IsZero())
+
+		balance, err = accounts.GetBalance(ctx, tenantID, sales.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "150.25", balance.CreditBalance.String())
+	})
+
+	tests := []struct {
+		name  string
+		lines []*repository.CreateJournalEntryLineParams
+		check func(t *testing.T, err error)
+	}{
+		{
+			name: "unbalanced entry",
+			lines: []*repository.CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(100)},
+				{AccountID: sales.ID, Credit: decimal.NewFromInt(90)},
+			},
+			check: func(t *testing.T, err error) { assert.ErrorIs(t, err, ErrUnbalancedEntry) },
+		},
+		{
+			name: "single line",
+			lines: []*repository.CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(100)},
+			},
+			check: func(t *testing.T, err error) { assert.ErrorIs(t, err, ErrUnbalancedEntry) },
+		},
+		{
+			name: "line with both sides",
+			lines: []*repository.CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(100), Credit: decimal.NewFromInt(100)},
+				{AccountID: sales.ID},
+			},
+			check: func(t *testing.T, err error) { assert.Error(t, err) },
+		},
+		{
+			name: "entry balanced only across currencies",
+			lines: []*repository.CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(100)},
+				{AccountID: euroSales.ID, Credit: decimal.NewFromInt(100)},
+			},
+			check: func(t *testing.T, err error) { assert.ErrorIs(t, err, ErrUnbalancedEntry) },
+		},
+		{
+			name: "unknown account",
+			lines: []*repository.CreateJournalEntryLineParams{
+				{AccountID: cash.ID, Debit: decimal.NewFromInt(100)},
+				{AccountID: uuid.New(), Credit: decimal.NewFromInt(100)},
+			},
+			check: func(t *testing.T, err error) { assert.True(t, repository.IsForeignKeyViolation(err)) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("rejects "+tt.name, func(t *testing.T) {
+			before, err := accounts.GetBalance(ctx, tenantID, cash.ID)
+			require.NoError(t, err)
+
+			_, err = journal.Create(ctx, tenantID, repository.CreateJournalEntryParams{
+				ReferenceNumber: "BAD", EntryDate: testDate, Lines: tt.lines,
+			})
+			tt.check(t, err)
+
+			after, err := accounts.GetBalance(ctx, tenantID, cash.ID)
+			require.NoError(t, err)
+			assert.Equal(t, before, after)
+		})
+	}
+
+	t.Run("rejects postings to deleted accounts", func(t *testing.T) {
+		unused := createAccount(t, accounts, tenantID, "5000", "Unused")
+		_, err := accounts.Delete(ctx, tenantID, unused.ID)
+		require.NoError(t, err)
+
+		_, err = journal.Create(ctx, tenantID, entryParams(unused.ID, cash.ID, "1"))
+		assert.ErrorIs(t, err, repository.ErrDeletedAccount)
+	})
+
+	t.Run("keeps balances consistent under concurrent postings", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := journal.Create(ctx, tenantID, entryParams(cash.ID, sales.ID, "1"))
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		balance, err := accounts.GetBalance(ctx, tenantID, cash.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "200.25", balance.DebitBalance.String())
+
+		integrity, err := journal.VerifyIntegrity(ctx, tenantID)
+		require.NoError(t, err)
+		assert.True(t, integrity.Valid)
+		assert.Equal(t, 51, integrity.EntriesVerified)
+		assert.Equal(t, int64(51), integrity.HeadSequence)
+	})
+
+	t.Run("rejects a reused idempotency key", func(t *testing.T) {
+		params := entryParams(cash.ID, sales.ID, "10")
+		params.IdempotencyKey = "key-1"
+
+		entry, err := journal.Create(ctx, tenantID, params)
+		require.NoError(t, err)
+
+		_, err = journal.Create(ctx, tenantID, params)
+		assert.True(t, repository.IsUniqueViolation(err))
+
+		found, err := journal.GetByIdempotencyKey(ctx, tenantID, "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, entry.ID, found.ID)
+
+		_, err = journal.GetByIdempotencyKey(ctx, tenantID, "key-2")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("rejects postings that overdraw a limited account", func(t *testing.T) {
+		bank := createAccount(t, accounts, tenantID, "1100", "Bank")
+		limit := decimal.NewFromInt(5)
+		_, err := accounts.SetOverdraftLimit(ctx, tenantID, bank.ID, &limit)
+		require.NoError(t, err)
+
+		_, err = journal.Create(ctx, tenantID, entryParams(sales.ID, bank.ID, "6"))
+		assert.ErrorIs(t, err, repository.ErrInsufficientFunds)
+
+		_, err = journal.Create(ctx, tenantID, entryParams(sales.ID, bank.ID, "5"))
+		require.NoError(t, err)
+
+		available, err := accounts.GetAvailableBalance(ctx, tenantID, bank.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "-5", available.Booked.String())
+		assert.Equal(t, "0", available.Available.String())
+	})
+
+	t.Run("projects the booked balance without pending work", func(t *testing.T) {
+		balances, err := accounts.GetProjectedBalances(ctx, tenantID, []uuid.UUID{sales.ID})
+		require.NoError(t, err)
+		require.Len(t, balances, 1)
+		assert.True(t, balances[0].Projected.Equal(balances[0].Booked))
+		assert.True(t, balances[0].Pending.IsZero())
+
+		_, err = accounts.GetProjectedBalances(ctx, tenantID, []uuid.UUID{sales.ID, uuid.New()})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestJournalRepository_Queries(t *testing.T) {
+	ctx := context.Background()
+	store, tenantID := newTenant(t)
+	accounts := NewAccountRepository(store)
+	journal := NewJournalRepository(store)
+
+	cash := createAccount(t, accounts, tenantID, "1000", "Cash")
+	sales := createAccount(t, accounts, tenantID, "4000", "Sales")
+	rent := createAccount(t, accounts, tenantID, "6000", "Rent")
+
+	post := func(reference, description string, date time.Time, debit, credit uuid.UUID, amount string) *repository.JournalEntry {
+		params := entryParams(debit, credit, amount)
+		params.ReferenceNumber = reference
+		params.Description = description
+		params.EntryDate = date
+		entry, err := journal.Create(ctx, tenantID, params)
+		require.NoError(t, err)
+		return entry
+	}
+
+	march := post("INV-1", "Consulting invoice", testDate, cash.ID, sales.ID, "500")
+	april := post("RENT-4", "Office rent April", testDate.AddDate(0, 1, 0), rent.ID, cash.ID, "1200")
+	may := post("INV-2", "Consulting retainer", testDate.AddDate(0, 2, 0), cash.ID, sales.ID, "800")
+
+	ids := func(entries []*repository.JournalEntry) []uuid.UUID {
+		result := make([]uuid.UUID, len(entries))
+		for i, e := range entries {
+			result[i] = e.ID
+		}
+		return result
+	}
+
+	t.Run("gets entries of the tenant", func(t *testing.T) {
+		entry, err := journal.GetByID(ctx, tenantID, march.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "INV-1", entry.ReferenceNumber)
+
+		_, err = journal.GetByID(ctx, uuid.New(), march.ID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("lists newest first with filters", func(t *testing.T) {
+		entries, totals, err := journal.List(ctx, tenantID, repository.JournalEntryFilter{}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 3, totals.EntryCount)
+		assert.Equal(t, []uuid.UUID{may.ID, april.ID, march.ID}, ids(entries))
+
+		prefix := "INV-"
+		minAmount := decimal.NewFromInt(600)
+		entries, totals, err = journal.List(ctx, tenantID, repository.JournalEntryFilter{ReferencePrefix: &prefix, MinAmount: &minAmount}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, totals.EntryCount)
+		assert.Equal(t, []uuid.UUID{may.ID}, ids(entries))
+
+		entries, _, err = journal.List(ctx, tenantID, repository.JournalEntryFilter{AccountID: &rent.ID}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{april.ID}, ids(entries))
+	})
+
+	t.Run("totals every matching entry, not just the page", func(t *testing.T) {
+		entries, totals, err := journal.List(ctx, tenantID, repository.JournalEntryFilter{}, 1, 0)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, 3, totals.EntryCount)
+		assert.Equal(t, "2500", totals.Debit.String())
+		assert.Equal(t, "2500", totals.Credit.String())
+
+		_, totals, err = journal.List(ctx, tenantID, repository.JournalEntryFilter{AccountID: &sales.ID}, 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, totals.EntryCount)
+		assert.Equal(t, "0", totals.Debit.String())
+		assert.Equal(t, "1300", totals.Credit.String())
+	})
+
+	t.Run("searches with web search syntax", func(t *testing.T) {
+		entries, total, err := journal.Search(ctx, tenantID, "consulting -retainer", nil, nil, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, []uuid.UUID{march.ID}, ids(entries))
+
+		entries, _, err = journal.Search(ctx, tenantID, `"office rent" OR retainer`, nil, nil, 10, 0)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uuid.UUID{april.ID, may.ID}, ids(entries))
+
+		to := testDate.AddDate(0, 1, 0)
+		entries, _, err = journal.Search(ctx, tenantID, "consulting", nil, &to, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{march.ID}, ids(entries))
+	})
+
+	t.Run("streams oldest first", func(t *testing.T) {
+		var streamed []uuid.UUID
+		err := journal.Stream(ctx, tenantID, nil, nil, func(entry *repository.JournalEntry) error {
+			streamed = append(streamed, entry.ID)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{march.ID, april.ID, may.ID}, streamed)
+
+		stop := errors.New("stop")
+		err = journal.Stream(ctx, tenantID, nil, nil, func(*repository.JournalEntry) error { return stop })
+		assert.ErrorIs(t, err, stop)
+	})
+
+	t.Run("detects a tampered entry", func(t *testing.T) {
+		store.mu.Lock()
+		store.entries[april.ID].entry.Lines[0].Debit = decimal.NewFromInt(1)
+		store.mu.Unlock()
+
+		integrity, err := journal.VerifyIntegrity(ctx, tenantID)
+		require.NoError(t, err)
+		assert.False(t, integrity.Valid)
+		assert.Equal(t, 1, integrity.EntriesVerified)
+		assert.Equal(t, &april.ID, integrity.FirstInvalidEntryID)
+	})
+}
+
+func TestAccountRepository_Quota(t *testing.T) {
+	ctx := context.Background()
+	store, tenantID := newTenant(t)
+	accounts := NewAccountRepository(store)
+
+	createAccount(t, accounts, tenantID, "1000", "Cash")
+	createAccount(t, accounts, tenantID, "4000", "Sales")
+
+	maxAccounts := int32(2)
+	_, err := accounts.Create(ctx, tenantID, repository.CreateAccountParams{
+		AccountNumber: "5000", Name: "Rent", AccountTypeID: 5, CurrencyCode: "USD", MaxAccounts: &maxAccounts,
+	})
+	assert.ErrorIs(t, err, repository.ErrQuotaExceeded)
+}
@@ -0,0 +1,89 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/repository"
+	"github.com/hesabFun/ledger/repository/memory"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPublicAPI uses the in-memory repositories the way another module
+// would, through the public repository package alone
+func TestPublicAPI(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+
+	var (
+		tenants    repository.TenantRepositoryInterface    = memory.NewTenantRepository(store)
+		books      repository.BookRepositoryInterface      = memory.NewBookRepository(store)
+		accounts   repository.AccountRepositoryInterface   = memory.NewAccountRepository(store)
+		journal    repository.JournalRepositoryInterface   = memory.NewJournalRepository(store)
+		references repository.ReferenceRepositoryInterface = memory.NewReferenceRepository(store)
+	)
+
+	tenant, err := tenants.Create(ctx, "public", nil)
+	require.NoError(t, err)
+	assert.Equal(t, repository.TenantStatusActive, tenant.Status)
+
+	tenantBooks, err := books.List(ctx, tenant.ID)
+	require.NoError(t, err)
+	require.Len(t, tenantBooks, 1)
+	assert.Equal(t, repository.DefaultBookCode, tenantBooks[0].Code)
+
+	accountTypes, err := references.ListAccountTypes(ctx)
+	require.NoError(t, err)
+	typeIDs := make(map[string]int32, len(accountTypes))
+	for _, accountType := range accountTypes {
+		typeIDs[accountType.Code] = accountType.ID
+	}
+
+	create := func(number, accountType string) *repository.Account {
+		account, err := accounts.Create(ctx, tenant.ID, repository.CreateAccountParams{
+			AccountNumber: number,
+			Name:          "Account " + number,
+			AccountTypeID: typeIDs[accountType],
+			CurrencyCode:  "USD",
+		})
+		require.NoError(t, err)
+		return account
+	}
+	cash := create("1000", repository.AccountTypeAsset)
+	sales := create("4000", repository.AccountTypeRevenue)
+
+	entry, err := journal.Create(ctx, tenant.ID, repository.CreateJournalEntryParams{
+		ReferenceNumber: "INV-1",
+		Description:     "Sale",
+		EntryDate:       time.Now(),
+		Lines: []*repository.CreateJournalEntryLineParams{
+			{AccountID: cash.ID, Debit: decimal.NewFromInt(100), Credit: decimal.Zero},
+			{AccountID: sales.ID, Debit: decimal.Zero, Credit: decimal.NewFromInt(100)},
+		},
+	})
+	require.NoError(t, err)
+
+	balance, err := accounts.GetBalance(ctx, tenant.ID, cash.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "100", balance.DebitBalance.String())
+
+	entries, totals, err := journal.List(ctx, tenant.ID, repository.JournalEntryFilter{AccountID: &cash.ID}, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, entry.ID, entries[0].ID)
+	assert.Equal(t, "100", totals.Debit.String())
+
+	integrity, err := journal.VerifyIntegrity(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.True(t, integrity.Valid)
+
+	_, err = accounts.Merge(ctx, tenant.ID, cash.ID, sales.ID, time.Now().UTC())
+	assert.ErrorIs(t, err, repository.ErrAccountTypeMismatch)
+
+	_, err = accounts.GetByID(ctx, tenant.ID, uuid.New())
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
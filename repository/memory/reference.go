@@ -0,0 +1,247 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/hesabFun/ledger/repository"
+)
+
+// ReferenceRepository is an in-memory repository.ReferenceRepositoryInterface
+type ReferenceRepository struct {
+	store *Store
+}
+
+// NewReferenceRepository creates a new in-memory reference repository
+func NewReferenceRepository(store *Store) *ReferenceRepository {
+	return &ReferenceRepository{store: store}
+}
+
+var _ repository.ReferenceRepositoryInterface = (*ReferenceRepository)(nil)
+
+// ListAccountTypes retrieves all account types ordered by ID
+func (r *ReferenceRepository) ListAccountTypes(ctx context.Context) ([]*repository.AccountType, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	accountTypes := make([]*repository.AccountType, len(s.accountTypes))
+	for i, accountType := range s.accountTypes {
+		c := *accountType
+		accountTypes[i] = &c
+	}
+
+	return accountTypes, nil
+}
+
+// ListCurrencies retrieves all currencies ordered by code
+func (r *ReferenceRepository) ListCurrencies(ctx context.Context) ([]*repository.Currency, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	currencies := make([]*repository.Currency, len(s.currencies))
+	for i, currency := range s.currencies {
+		c := *currency
+		currencies[i] = &c
+	}
+
+	return currencies, nil
+}
+
+// CreateAccountType adds a new account type; codes are unique
+func (r *ReferenceRepository) CreateAccountType(ctx context.Context, params repository.CreateAccountTypeParams) (*repository.AccountType, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lastID int32
+	for _, accountType := range s.accountTypes {
+		if accountType.Code == params.Code {
+			return nil, fmt.Errorf("failed to create account type: %w", uniqueViolation("account_types_code_key"))
+		}
+		lastID = max(lastID, accountType.ID)
+	}
+
+	now := time.Now().UTC()
+	accountType := &repository.AccountType{
+		ID:            lastID + 1,
+		Code:          params.Code,
+		Name:          params.Name,
+		NormalBalance: params.NormalBalance,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	s.accountTypes = append(s.accountTypes, accountType)
+
+	c := *accountType
+	return &c, nil
+}
+
+// CreateCurrency adds a new currency; codes are unique
+func (r *ReferenceRepository) CreateCurrency(ctx context.Context, params repository.CreateCurrencyParams) (*repository.Currency, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lastID int32
+	for _, currency := range s.currencies {
+		if currency.Code == params.Code {
+			return nil, fmt.Errorf("failed to create currency: %w", uniqueViolation("currencies_code_key"))
+		}
+		lastID = max(lastID, currency.ID)
+	}
+
+	now := time.Now().UTC()
+	currency := &repository.Currency{
+		ID:        lastID + 1,
+		Code:      params.Code,
+		Name:      params.Name,
+		Symbol:    params.Symbol,
+		Precision: params.Precision,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.currencies = append(s.currencies, currency)
+	sort.Slice(s.currencies, func(i, j int) bool {
+		return s.currencies[i].Code < s.currencies[j].Code
+	})
+
+	c := *currency
+	return &c, nil
+}
+
+// UpdateCurrency updates an existing currency identified by its code
+func (r *ReferenceRepository) UpdateCurrency(ctx context.Context, code string, params repository.UpdateCurrencyParams) (*repository.Currency, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	currency := s.currency(code)
+	if currency == nil {
+		return nil, fmt.Errorf("currency %w", repository.ErrNotFound)
+	}
+
+	if params.Name != nil {
+		currency.Name = *params.Name
+	}
+	if params.Symbol != nil {
+		currency.Symbol = *params.Symbol
+	}
+	if params.Precision != nil {
+		currency.Precision = *params.Precision
+	}
+	currency.UpdatedAt = time.Now().UTC()
+
+	c := *currency
+	return &c, nil
+}
+
+// SetAccountTypeTranslation sets the name of an account type in a locale; an
+// empty name removes the translation. The account type's UpdatedAt moves
+// along.
+func (r *ReferenceRepository) SetAccountTypeTranslation(ctx context.Context, code, locale, name string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.accountTypes, func(accountType *repository.AccountType) bool {
+		return accountType.Code == code
+	})
+	if i < 0 {
+		return fmt.Errorf("account type %w", repository.ErrNotFound)
+	}
+
+	setTranslation(s.accountTypeNames, code, locale, name)
+	s.accountTypes[i].UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// SetCurrencyTranslation sets the name of a currency in a locale; an empty
+// name removes the translation. The currency's UpdatedAt moves along.
+func (r *ReferenceRepository) SetCurrencyTranslation(ctx context.Context, code, locale, name string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	currency := s.currency(code)
+	if currency == nil {
+		return fmt.Errorf("currency %w", repository.ErrNotFound)
+	}
+
+	setTranslation(s.currencyNames, code, locale, name)
+	currency.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// ListAccountTypeTranslations retrieves the translated names of the account
+// types by code, in the first of the locales each has a translation in
+func (r *ReferenceRepository) ListAccountTypeTranslations(ctx context.Context, locales []string) (map[string]string, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return translations(s.accountTypeNames, locales), nil
+}
+
+// ListCurrencyTranslations retrieves the translated names of the currencies
+// by code, in the first of the locales each has a translation in
+func (r *ReferenceRepository) ListCurrencyTranslations(ctx context.Context, locales []string) (map[string]string, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return translations(s.currencyNames, locales), nil
+}
+
+// setTranslation sets or, for an empty name, removes the name of a code in a
+// locale
+func setTranslation(names map[string]map[string]string, code, locale, name string) {
+	if name == "" {
+		delete(names[code], locale)
+		return
+	}
+	if names[code] == nil {
+		names[code] = make(map[string]string)
+	}
+	names[code][locale] = name
+}
+
+// translations picks the name of each code in the first locale it has one in
+func translations(names map[string]map[string]string, locales []string) map[string]string {
+	picked := make(map[string]string)
+	for code, byLocale := range names {
+		for _, locale := range locales {
+			if name, ok := byLocale[locale]; ok {
+				picked[code] = name
+				break
+			}
+		}
+	}
+	return picked
+}
+
+// accountType returns the account type with an ID, or nil; the caller must
+// hold the lock
+func (s *Store) accountType(id int32) *repository.AccountType {
+	for _, accountType := range s.accountTypes {
+		if accountType.ID == id {
+			return accountType
+		}
+	}
+	return nil
+}
+
+// currency returns the currency with a code, or nil; the caller must hold
+// the lock
+func (s *Store) currency(code string) *repository.Currency {
+	for _, currency := range s.currencies {
+		if currency.Code == code {
+			return currency
+		}
+	}
+	return nil
+}
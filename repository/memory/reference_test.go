@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hesabFun/ledger/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferenceRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewReferenceRepository(NewStore())
+
+	t.Run("seeds the schema's reference data", func(t *testing.T) {
+		accountTypes, err := repo.ListAccountTypes(ctx)
+		require.NoError(t, err)
+		require.Len(t, accountTypes, 5)
+		assert.Equal(t, repository.AccountTypeAsset, accountTypes[0].Code)
+
+		currencies, err := repo.ListCurrencies(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "EUR", currencies[0].Code)
+	})
+
+	t.Run("creates account types with unique codes", func(t *testing.T) {
+		params := repository.CreateAccountTypeParams{Code: "CONTRA", Name: "Contra Asset", NormalBalance: "CREDIT"}
+		accountType, err := repo.CreateAccountType(ctx, params)
+		require.NoError(t, err)
+		assert.Equal(t, int32(6), accountType.ID)
+
+		_, err = repo.CreateAccountType(ctx, params)
+		assert.True(t, repository.IsUniqueViolation(err))
+	})
+
+	t.Run("creates and updates currencies", func(t *testing.T) {
+		_, err := repo.CreateCurrency(ctx, repository.CreateCurrencyParams{Code: "CHF", Name: "Swiss Franc", Symbol: "Fr", Precision: 2})
+		require.NoError(t, err)
+
+		currencies, err := repo.ListCurrencies(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "CHF", currencies[0].Code)
+
+		precision := int32(4)
+		currency, err := repo.UpdateCurrency(ctx, "CHF", repository.UpdateCurrencyParams{Precision: &precision})
+		require.NoError(t, err)
+		assert.Equal(t, int32(4), currency.Precision)
+		assert.Equal(t, "Swiss Franc", currency.Name)
+
+		_, err = repo.UpdateCurrency(ctx, "XXX", repository.UpdateCurrencyParams{Precision: &precision})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("prefers translations in the first locale", func(t *testing.T) {
+		require.NoError(t, repo.SetAccountTypeTranslation(ctx, repository.AccountTypeAsset, "fa", "دارایی"))
+		require.NoError(t, repo.SetAccountTypeTranslation(ctx, repository.AccountTypeEquity, "fa", "حقوق صاحبان سهام"))
+		require.NoError(t, repo.SetAccountTypeTranslation(ctx, repository.AccountTypeEquity, "fa-AF", "سرمایه"))
+
+		names, err := repo.ListAccountTypeTranslations(ctx, []string{"fa-AF", "fa"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			repository.AccountTypeAsset:  "دارایی",
+			repository.AccountTypeEquity: "سرمایه",
+		}, names)
+
+		require.NoError(t, repo.SetAccountTypeTranslation(ctx, repository.AccountTypeEquity, "fa-AF", ""))
+		names, err = repo.ListAccountTypeTranslations(ctx, []string{"fa-AF", "fa"})
+		require.NoError(t, err)
+		assert.Equal(t, "حقوق صاحبان سهام", names[repository.AccountTypeEquity])
+
+		err = repo.SetCurrencyTranslation(ctx, "XXX", "fa", "نامعلوم")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("moves updated_at with the translations", func(t *testing.T) {
+		types, err := repo.ListAccountTypes(ctx)
+		require.NoError(t, err)
+		before := types[0].UpdatedAt
+
+		require.NoError(t, repo.SetAccountTypeTranslation(ctx, types[0].Code, "de", "Aktiva"))
+		types, err = repo.ListAccountTypes(ctx)
+		require.NoError(t, err)
+		assert.True(t, types[0].UpdatedAt.After(before))
+
+		require.NoError(t, repo.SetCurrencyTranslation(ctx, "USD", "de", "US-Dollar"))
+		currencies, err := repo.ListCurrencies(ctx)
+		require.NoError(t, err)
+		for _, currency := range currencies {
+			if currency.Code == "USD" {
+				assert.False(t, currency.UpdatedAt.Before(types[0].UpdatedAt))
+			}
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/hesabFun/ledger/repository"
+)
+
+// searchTerm is a word or quoted phrase of a search query
+type searchTerm struct {
+	words   []string
+	exclude bool
+}
+
+// searchQuery is a conjunction of terms, each with its OR alternatives
+type searchQuery [][]searchTerm
+
+// parseSearchQuery parses web search syntax the way websearch_to_tsquery
+// does: words and "quoted phrases" must all match, OR joins the terms on
+// either side, and a leading - excludes a term
+func parseSearchQuery(text string) searchQuery {
+	var query searchQuery
+	or := false
+
+	for rest := strings.TrimSpace(text); rest != ""; rest = strings.TrimSpace(rest) {
+		exclude := false
+		if rest[0] == '-' {
+			exclude = true
+			rest = rest[1:]
+		}
+
+		var token string
+		quoted := strings.HasPrefix(rest, `"`)
+		if quoted {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				token, rest = rest[1:], ""
+			} else {
+				token, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if end := strings.IndexFunc(rest, unicode.IsSpace); end < 0 {
+			token, rest = rest, ""
+		} else {
+			token, rest = rest[:end], rest[end:]
+		}
+
+		if !quoted && !exclude && token == "OR" {
+			or = len(query) > 0
+			continue
+		}
+
+		words := splitWords(token)
+		if len(words) == 0 {
+			continue
+		}
+
+		term := searchTerm{words: words, exclude: exclude}
+		if or {
+			query[len(query)-1] = append(query[len(query)-1], term)
+		} else {
+			query = append(query, []searchTerm{term})
+		}
+		or = false
+	}
+
+	return query
+}
+
+// match reports whether words satisfy every term of the query and ranks
+// the match by the number of times its terms occur
+func (q searchQuery) match(words []string) (int, bool) {
+	if len(q) == 0 {
+		return 0, false
+	}
+
+	rank := 0
+	for _, alternatives := range q {
+		matched := false
+		for _, term := range alternatives {
+			n := occurrences(words, term.words)
+			if term.exclude {
+				matched = matched || n == 0
+			} else if n > 0 {
+				matched = true
+				rank += n
+			}
+		}
+		if !matched {
+			return 0, false
+		}
+	}
+
+	return rank, true
+}
+
+// occurrences counts the places where phrase appears in words
+func occurrences(words, phrase []string) int {
+	n := 0
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		found := true
+		for j, word := range phrase {
+			if words[i+j] != word {
+				found = false
+				break
+			}
+		}
+		if found {
+			n++
+		}
+	}
+	return n
+}
+
+// searchWords returns the lower-cased words of the searchable text of an
+// entry: its description, reference number, line descriptions and
+// metadata values
+func searchWords(entry *repository.JournalEntry) []string {
+	texts := []string{entry.Description, entry.ReferenceNumber}
+	for _, line := range entry.Lines {
+		texts = append(texts, line.Description)
+	}
+	texts = appendMetadataValues(texts, entry.Metadata)
+
+	var words []string
+	for _, text := range texts {
+		words = append(words, splitWords(text)...)
+	}
+	return words
+}
+
+func appendMetadataValues(texts []string, value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return texts
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			texts = appendMetadataValues(texts, v[key])
+		}
+		return texts
+	case []interface{}:
+		for _, item := range v {
+			texts = appendMetadataValues(texts, item)
+		}
+		return texts
+	default:
+		return append(texts, fmt.Sprint(v))
+	}
+}
+
+// splitWords lower-cases text and splits it into runs of letters and digits
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,225 @@
+// Package memory provides in-memory implementations of the tenant, book,
+// account, journal and reference repositories for tests and local tools. They
+// follow the behaviour of the Postgres repositories: accounts are scoped to
+// their tenant and book, journal entries must balance within one book and
+// are chained by hash, account
+// balances are maintained on every posting, and constraint failures are
+// reported as the same Postgres errors so services map them identically.
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/repository"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+)
+
+// Postgres error codes reported for constraint failures
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+// Store holds the data shared by the in-memory repositories. All methods
+// are safe for concurrent use.
+type Store struct {
+	mu sync.RWMutex
+
+	tenants  map[uuid.UUID]*tenantRecord
+	books    map[uuid.UUID]*repository.Book
+	accounts map[uuid.UUID]*accountRecord
+	balances map[uuid.UUID]*repository.AccountBalance
+	entries  map[uuid.UUID]*entryRecord
+	// chains lists the journal entries of each tenant in posting order
+	chains map[uuid.UUID][]*entryRecord
+
+	accountTypes []*repository.AccountType
+	currencies   []*repository.Currency
+	// accountTypeNames and currencyNames hold the translated names of the
+	// reference data by code and then locale
+	accountTypeNames map[string]map[string]string
+	currencyNames    map[string]map[string]string
+
+	// sequence orders records created within the same clock tick
+	sequence int64
+
+	// onBalanceChange is called for each account a posting changes
+	onBalanceChange func(repository.BalanceChange)
+}
+
+type tenantRecord struct {
+	tenant   *repository.Tenant
+	sequence int64
+}
+
+type accountRecord struct {
+	account  *repository.Account
+	sequence int64
+}
+
+type entryRecord struct {
+	entry          *repository.JournalEntry
+	idempotencyKey string
+	transactionID  string
+	sequence       int64
+	chainIndex     int64
+	previousHash   []byte
+	hash           []byte
+}
+
+// NewStore creates an empty store seeded with the account types and
+// currencies installed by the database schema
+func NewStore() *Store {
+	now := time.Now().UTC()
+	s := &Store{
+		tenants:  make(map[uuid.UUID]*tenantRecord),
+		books:    make(map[uuid.UUID]*repository.Book),
+		accounts: make(map[uuid.UUID]*accountRecord),
+		balances: make(map[uuid.UUID]*repository.AccountBalance),
+		entries:  make(map[uuid.UUID]*entryRecord),
+		chains:   make(map[uuid.UUID][]*entryRecord),
+
+		accountTypeNames: make(map[string]map[string]string),
+		currencyNames:    make(map[string]map[string]string),
+	}
+
+	for i, t := range []struct{ code, name, normalBalance string }{
+		{repository.AccountTypeAsset, "Asset", "DEBIT"},
+		{repository.AccountTypeLiability, "Liability", "CREDIT"},
+		{repository.AccountTypeEquity, "Equity", "CREDIT"},
+		{repository.AccountTypeRevenue, "Revenue", "CREDIT"},
+		{repository.AccountTypeExpense, "Expense", "DEBIT"},
+	} {
+		s.accountTypes = append(s.accountTypes, &repository.AccountType{
+			ID:            int32(i + 1),
+			Code:          t.code,
+			Name:          t.name,
+			NormalBalance: t.normalBalance,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+
+	for i, c := range []struct {
+		code, name, symbol string
+		precision          int32
+	}{
+		{"EUR", "Euro", "€", 2},
+		{"GBP", "British Pound", "£", 2},
+		{"JPY", "Japanese Yen", "¥", 0},
+		{"USD", "US Dollar", "$", 2},
+	} {
+		s.currencies = append(s.currencies, &repository.Currency{
+			ID:        int32(i + 1),
+			Code:      c.code,
+			Name:      c.name,
+			Symbol:    c.symbol,
+			Precision: c.precision,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+
+	return s
+}
+
+// OnBalanceChange registers fn to be called for each account whose balance
+// a posting changes, as the Postgres repositories notify on their balance
+// channel. fn is called while the store is locked and must
+// not call back into it.
+func (s *Store) OnBalanceChange(fn func(repository.BalanceChange)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onBalanceChange = fn
+}
+
+// nextSequence returns the next record sequence; the caller must hold the write lock
+func (s *Store) nextSequence() int64 {
+	s.sequence++
+	return s.sequence
+}
+
+// uniqueViolation mirrors the error Postgres returns for a duplicate key
+func uniqueViolation(constraint string) error {
+	return &pgconn.PgError{
+		Code:           pgUniqueViolation,
+		Message:        fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		ConstraintName: constraint,
+	}
+}
+
+// foreignKeyViolation mirrors the error Postgres returns for a reference to
+// a missing row
+func foreignKeyViolation(constraint string) error {
+	return &pgconn.PgError{
+		Code:           pgForeignKeyViolation,
+		Message:        fmt.Sprintf("insert or update violates foreign key constraint %q", constraint),
+		ConstraintName: constraint,
+	}
+}
+
+// cloneMetadata deep-copies metadata through JSON, the way it round-trips
+// through a jsonb column
+func cloneMetadata(metadata map[string]interface{}) (map[string]interface{}, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	var clone map[string]interface{}
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+	return clone, nil
+}
+
+func cloneUUID(id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	c := *id
+	return &c
+}
+
+func cloneDecimal(d *decimal.Decimal) *decimal.Decimal {
+	if d == nil {
+		return nil
+	}
+	c := *d
+	return &c
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// cloneStringMap copies a map, returning an empty map for nil as the
+// Postgres repository reads empty JSON objects
+func cloneStringMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
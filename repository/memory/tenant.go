@@ -0,0 +1,290 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/repository"
+)
+
+// TenantRepository is an in-memory repository.TenantRepositoryInterface
+type TenantRepository struct {
+	store *Store
+}
+
+// NewTenantRepository creates a new in-memory tenant repository
+func NewTenantRepository(store *Store) *TenantRepository {
+	return &TenantRepository{store: store}
+}
+
+var _ repository.TenantRepositoryInterface = (*TenantRepository)(nil)
+
+// Create creates a new tenant with its default book, generating an ID
+// unless one is given. Tenant names and IDs are unique, including those of
+// deleted tenants.
+func (r *TenantRepository) Create(ctx context.Context, name string, tenantUUID *uuid.UUID) (*repository.Tenant, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenantID := uuid.New()
+	if tenantUUID != nil {
+		tenantID = *tenantUUID
+	}
+
+	if _, ok := s.tenants[tenantID]; ok {
+		return nil, fmt.Errorf("failed to create tenant: %w", uniqueViolation("tenants_pkey"))
+	}
+	for _, record := range s.tenants {
+		if record.tenant.Name == name {
+			return nil, fmt.Errorf("failed to create tenant: %w", uniqueViolation("tenants_name_key"))
+		}
+	}
+
+	now := time.Now().UTC()
+	tenant := &repository.Tenant{
+		ID:        tenantID,
+		Name:      name,
+		Status:    repository.TenantStatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.tenants[tenantID] = &tenantRecord{tenant: tenant, sequence: s.nextSequence()}
+
+	book := &repository.Book{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		Code:      repository.DefaultBookCode,
+		Name:      "Main",
+		IsDefault: true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.books[book.ID] = book
+
+	return cloneTenant(tenant), nil
+}
+
+// GetByID retrieves an active tenant by ID
+func (r *TenantRepository) GetByID(ctx context.Context, tenantID uuid.UUID) (*repository.Tenant, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.tenants[tenantID]
+	if !ok || record.tenant.DeletedAt != nil {
+		return nil, fmt.Errorf("tenant %w", repository.ErrNotFound)
+	}
+
+	return cloneTenant(record.tenant), nil
+}
+
+// Status returns the status of a tenant, repository.TenantStatusDeleted for
+// a soft-deleted tenant and an empty status for an unknown one
+func (r *TenantRepository) Status(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.tenants[tenantID]
+	if !ok {
+		return "", nil
+	}
+	if record.tenant.DeletedAt != nil {
+		return repository.TenantStatusDeleted, nil
+	}
+
+	return record.tenant.Status, nil
+}
+
+// GetByName retrieves an active tenant by name
+func (r *TenantRepository) GetByName(ctx context.Context, name string) (*repository.Tenant, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, record := range s.tenants {
+		if record.tenant.Name == name && record.tenant.DeletedAt == nil {
+			return cloneTenant(record.tenant), nil
+		}
+	}
+
+	return nil, fmt.Errorf("tenant %w", repository.ErrNotFound)
+}
+
+// ListIDs retrieves the IDs of all active tenants, oldest first, leaving out
+// suspended and archived tenants
+func (r *TenantRepository) ListIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return r.listIDs(func(tenant *repository.Tenant) bool {
+		return tenant.DeletedAt == nil && tenant.Status == repository.TenantStatusActive
+	}), nil
+}
+
+// ListTestIDs retrieves the IDs of the test tenants that are not deleted,
+// oldest first
+func (r *TenantRepository) ListTestIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return r.listIDs(func(tenant *repository.Tenant) bool {
+		return tenant.DeletedAt == nil && tenant.IsTest
+	}), nil
+}
+
+// ListExpiredTestIDs retrieves the IDs of the test tenants marked as test
+// before a time, oldest first. Tenant data is never purged from memory.
+func (r *TenantRepository) ListExpiredTestIDs(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	return r.listIDs(func(tenant *repository.Tenant) bool {
+		return tenant.IsTest && tenant.TestSince != nil && tenant.TestSince.Before(before)
+	}), nil
+}
+
+func (r *TenantRepository) listIDs(match func(*repository.Tenant) bool) []uuid.UUID {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]*tenantRecord, 0, len(s.tenants))
+	for _, record := range s.tenants {
+		if match(record.tenant) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].sequence < records[j].sequence
+	})
+
+	ids := make([]uuid.UUID, len(records))
+	for i, record := range records {
+		ids[i] = record.tenant.ID
+	}
+
+	return ids
+}
+
+// List retrieves a page of tenants matching a filter, newest first
+func (r *TenantRepository) List(ctx context.Context, filter repository.TenantFilter, limit, offset int) ([]*repository.Tenant, int, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]*tenantRecord, 0)
+	for _, record := range s.tenants {
+		if matchesTenantFilter(record.tenant, filter) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].sequence > records[j].sequence
+	})
+
+	tenants := make([]*repository.Tenant, 0)
+	for _, record := range page(records, limit, offset) {
+		tenants = append(tenants, cloneTenant(record.tenant))
+	}
+
+	return tenants, len(records), nil
+}
+
+func matchesTenantFilter(tenant *repository.Tenant, filter repository.TenantFilter) bool {
+	switch {
+	case !filter.IncludeDeleted && tenant.DeletedAt != nil:
+		return false
+	case filter.NameContains != nil && !strings.Contains(strings.ToLower(tenant.Name), strings.ToLower(*filter.NameContains)):
+		return false
+	case filter.CreatedFrom != nil && tenant.CreatedAt.Before(*filter.CreatedFrom):
+		return false
+	case filter.CreatedTo != nil && tenant.CreatedAt.After(*filter.CreatedTo):
+		return false
+	case filter.Status != nil && tenant.Status != *filter.Status:
+		return false
+	case filter.IsTest != nil && tenant.IsTest != *filter.IsTest:
+		return false
+	}
+	return true
+}
+
+// Delete soft-deletes a tenant, keeping its accounts and journal intact
+func (r *TenantRepository) Delete(ctx context.Context, tenantID uuid.UUID) (*repository.Tenant, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.tenants[tenantID]
+	if !ok || record.tenant.DeletedAt != nil {
+		return nil, fmt.Errorf("tenant %w", repository.ErrNotFound)
+	}
+
+	now := time.Now().UTC()
+	record.tenant.DeletedAt = &now
+	record.tenant.UpdatedAt = now
+
+	return cloneTenant(record.tenant), nil
+}
+
+// Restore reverses the soft deletion of a tenant
+func (r *TenantRepository) Restore(ctx context.Context, tenantID uuid.UUID) (*repository.Tenant, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.tenants[tenantID]
+	if !ok || record.tenant.DeletedAt == nil {
+		return nil, fmt.Errorf("deleted tenant %w", repository.ErrNotFound)
+	}
+
+	record.tenant.DeletedAt = nil
+	record.tenant.UpdatedAt = time.Now().UTC()
+
+	return cloneTenant(record.tenant), nil
+}
+
+// SetStatus suspends, archives or reactivates a tenant that is not deleted
+func (r *TenantRepository) SetStatus(ctx context.Context, tenantID uuid.UUID, status string) (*repository.Tenant, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.tenants[tenantID]
+	if !ok || record.tenant.DeletedAt != nil {
+		return nil, fmt.Errorf("tenant %w", repository.ErrNotFound)
+	}
+
+	record.tenant.Status = status
+	record.tenant.UpdatedAt = time.Now().UTC()
+
+	return cloneTenant(record.tenant), nil
+}
+
+// SetTestMode marks a tenant that is not deleted as test or clears the mark,
+// keeping the time it was first marked
+func (r *TenantRepository) SetTestMode(ctx context.Context, tenantID uuid.UUID, test bool) (*repository.Tenant, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.tenants[tenantID]
+	if !ok || record.tenant.DeletedAt != nil {
+		return nil, fmt.Errorf("tenant %w", repository.ErrNotFound)
+	}
+
+	now := time.Now().UTC()
+	record.tenant.IsTest = test
+	switch {
+	case !test:
+		record.tenant.TestSince = nil
+	case record.tenant.TestSince == nil:
+		record.tenant.TestSince = &now
+	}
+	record.tenant.UpdatedAt = now
+
+	return cloneTenant(record.tenant), nil
+}
+
+func cloneTenant(tenant *repository.Tenant) *repository.Tenant {
+	c := *tenant
+	c.DeletedAt = cloneTime(tenant.DeletedAt)
+	c.TestSince = cloneTime(tenant.TestSince)
+	return &c
+}
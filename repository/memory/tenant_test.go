@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hesabFun/ledger/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTenantRepository(NewStore())
+
+	first, err := repo.Create(ctx, "first", nil)
+	require.NoError(t, err)
+	id := uuid.New()
+	second, err := repo.Create(ctx, "second", &id)
+	require.NoError(t, err)
+	assert.Equal(t, id, second.ID)
+
+	t.Run("rejects duplicate names", func(t *testing.T) {
+		_, err := repo.Create(ctx, "first", nil)
+		assert.True(t, repository.IsUniqueViolation(err))
+	})
+
+	t.Run("finds tenants by ID and name", func(t *testing.T) {
+		tenant, err := repo.GetByID(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", tenant.Name)
+
+		tenant, err = repo.GetByName(ctx, "second")
+		require.NoError(t, err)
+		assert.Equal(t, id, tenant.ID)
+	})
+
+	t.Run("lists active tenants oldest first", func(t *testing.T) {
+		ids, err := repo.ListIDs(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{first.ID, second.ID}, ids)
+	})
+
+	t.Run("lists tenants matching a filter newest first", func(t *testing.T) {
+		tenants, total, err := repo.List(ctx, repository.TenantFilter{}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		require.Len(t, tenants, 2)
+		assert.Equal(t, second.ID, tenants[0].ID)
+
+		name := "IRS"
+		tenants, total, err = repo.List(ctx, repository.TenantFilter{NameContains: &name}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, first.ID, tenants[0].ID)
+
+		tenants, total, err = repo.List(ctx, repository.TenantFilter{}, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		require.Len(t, tenants, 1)
+		assert.Equal(t, first.ID, tenants[0].ID)
+	})
+
+	t.Run("deletes and restores tenants", func(t *testing.T) {
+		deleted, err := repo.Delete(ctx, first.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, deleted.DeletedAt)
+
+		_, err = repo.GetByID(ctx, first.ID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		ids, err := repo.ListIDs(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{second.ID}, ids)
+
+		restored, err := repo.Restore(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Nil(t, restored.DeletedAt)
+
+		_, err = repo.Restore(ctx, first.ID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("suspends and archives tenants", func(t *testing.T) {
+		suspended, err := repo.SetStatus(ctx, second.ID, repository.TenantStatusSuspended)
+		require.NoError(t, err)
+		assert.Equal(t, repository.TenantStatusSuspended, suspended.Status)
+
+		status, err := repo.Status(ctx, second.ID)
+		require.NoError(t, err)
+		assert.Equal(t, repository.TenantStatusSuspended, status)
+		ids, err := repo.ListIDs(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{first.ID}, ids)
+
+		_, err = repo.SetStatus(ctx, second.ID, repository.TenantStatusActive)
+		require.NoError(t, err)
+		status, err = repo.Status(ctx, second.ID)
+		require.NoError(t, err)
+		assert.Equal(t, repository.TenantStatusActive, status)
+
+		status, err = repo.Status(ctx, uuid.New())
+		require.NoError(t, err)
+		assert.Empty(t, status)
+	})
+
+	t.Run("marks tenants as test", func(t *testing.T) {
+		marked, err := repo.SetTestMode(ctx, second.ID, true)
+		require.NoError(t, err)
+		assert.True(t, marked.IsTest)
+		require.NotNil(t, marked.TestSince)
+
+		again, err := repo.SetTestMode(ctx, second.ID, true)
+		require.NoError(t, err)
+		assert.Equal(t, marked.TestSince, again.TestSince)
+
+		ids, err := repo.ListTestIDs(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{second.ID}, ids)
+		ids, err = repo.ListExpiredTestIDs(ctx, marked.TestSince.Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{second.ID}, ids)
+		ids, err = repo.ListExpiredTestIDs(ctx, *marked.TestSince)
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+
+		test := true
+		tenants, _, err := repo.List(ctx, repository.TenantFilter{IsTest: &test}, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, tenants, 1)
+		assert.Equal(t, second.ID, tenants[0].ID)
+
+		cleared, err := repo.SetTestMode(ctx, second.ID, false)
+		require.NoError(t, err)
+		assert.False(t, cleared.IsTest)
+		assert.Nil(t, cleared.TestSince)
+	})
+
+	t.Run("returns copies", func(t *testing.T) {
+		tenant, err := repo.GetByID(ctx, first.ID)
+		require.NoError(t, err)
+		tenant.Name = "changed"
+
+		tenant, err = repo.GetByID(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", tenant.Name)
+	})
+}
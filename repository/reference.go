@@ -0,0 +1,55 @@
+package repository
+
+import "time"
+
+// Account type codes used to classify accounts in financial statements
+const (
+	AccountTypeAsset     = "ASSET"
+	AccountTypeLiability = "LIABILITY"
+	AccountTypeEquity    = "EQUITY"
+	AccountTypeRevenue   = "REVENUE"
+	AccountTypeExpense   = "EXPENSE"
+)
+
+// AccountType represents an account type entity
+type AccountType struct {
+	ID            int32
+	Code          string
+	Name          string
+	NormalBalance string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Currency represents a currency entity
+type Currency struct {
+	ID        int32
+	Code      string
+	Name      string
+	Symbol    string
+	Precision int32
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CreateAccountTypeParams holds parameters for creating an account type
+type CreateAccountTypeParams struct {
+	Code          string
+	Name          string
+	NormalBalance string
+}
+
+// CreateCurrencyParams holds parameters for creating a currency
+type CreateCurrencyParams struct {
+	Code      string
+	Name      string
+	Symbol    string
+	Precision int32
+}
+
+// UpdateCurrencyParams holds parameters for updating a currency; nil fields are left unchanged
+type UpdateCurrencyParams struct {
+	Name      *string
+	Symbol    *string
+	Precision *int32
+}
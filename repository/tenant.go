@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenant statuses. Only active tenants may use the tenant API in full:
+// suspended tenants are locked out and archived tenants are read-only.
+const (
+	TenantStatusActive    = "ACTIVE"
+	TenantStatusSuspended = "SUSPENDED"
+	TenantStatusArchived  = "ARCHIVED"
+	// TenantStatusDeleted is reported by Status for soft-deleted tenants and
+	// is never stored
+	TenantStatusDeleted = "DELETED"
+)
+
+// Tenant represents a tenant entity
+type Tenant struct {
+	ID        uuid.UUID
+	Name      string
+	Status    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+	// IsTest marks sandbox tenants, such as clones made for integrators
+	IsTest bool
+	// ClonedFromID is the tenant a sandbox tenant was cloned from
+	ClonedFromID *uuid.UUID
+	// TestSince is when the tenant was marked as test; test tenants are
+	// purged once the test tenant retention has passed since
+	TestSince *time.Time
+}
+
+// TenantFilter narrows the tenants returned by List
+type TenantFilter struct {
+	// NameContains is a case-insensitive substring of the tenant name
+	NameContains *string
+	// CreatedFrom and CreatedTo bound the creation time, inclusive
+	CreatedFrom    *time.Time
+	CreatedTo      *time.Time
+	Status         *string
+	IncludeDeleted bool
+	// IsTest lists only test tenants when true and only other tenants when false
+	IsTest *bool
+}